@@ -11,7 +11,30 @@
 // A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
 package analytics
 
-import ()
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/conf"
+)
+
+const (
+	CategoryUsage    = "usage"
+	CategoryErrors   = "errors"
+	CategoryFeatures = "features"
+
+	eventsBufferSize = 1000
+	eventsBatchSize  = 100
+	flushInterval    = 10 * time.Second
+	sendTimeout      = 10 * time.Second
+)
+
+var Categories = []string{CategoryUsage, CategoryErrors, CategoryFeatures}
 
 type EventParam struct {
 	Name  string `json:"name"`
@@ -21,16 +44,201 @@ type EventParam struct {
 type EventBody struct {
 	DistinctId string                 `json:"distinct_id"`
 	Event      string                 `json:"event"`
+	Category   string                 `json:"category"`
 	Properties map[string]interface{} `json:"properties"`
 	TimeStamp  string                 `json:"timestamp"`
 }
 
+type sink struct {
+	endpoint       string
+	authHeader     string
+	distinctId     string
+	memphisVersion string
+	events         chan EventBody
+	done           chan struct{}
+	closed         chan struct{}
+	httpClient     *http.Client
+}
+
+var (
+	activeSink *sink
+
+	categoriesLock    sync.RWMutex
+	enabledCategories = map[string]bool{CategoryUsage: true, CategoryErrors: true, CategoryFeatures: true}
+)
+
+// InitializeAnalytics starts shipping events to the collection endpoint set in ANALYTICS_ENDPOINT,
+// without one events are dropped
 func InitializeAnalytics(memphisV, customDeploymentId string) error {
+	configuration := conf.GetConfig()
+	if configuration.ANALYTICS_ENDPOINT == "" {
+		return nil
+	}
+	distinctId := customDeploymentId
+	if distinctId == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		distinctId = hostname
+	}
+	activeSink = &sink{
+		endpoint:       configuration.ANALYTICS_ENDPOINT,
+		authHeader:     configuration.ANALYTICS_AUTH_HEADER,
+		distinctId:     distinctId,
+		memphisVersion: memphisV,
+		events:         make(chan EventBody, eventsBufferSize),
+		done:           make(chan struct{}),
+		closed:         make(chan struct{}),
+		httpClient:     &http.Client{Timeout: sendTimeout},
+	}
+	go activeSink.run()
 	return nil
 }
 
+// Close flushes the buffered events
 func Close() {
+	if activeSink == nil {
+		return
+	}
+	close(activeSink.done)
+	select {
+	case <-activeSink.closed:
+	case <-time.After(sendTimeout):
+	}
+}
+
+// SetEnabledCategories replaces the categories which are sent, missing categories are disabled
+func SetEnabledCategories(categories map[string]bool) {
+	categoriesLock.Lock()
+	defer categoriesLock.Unlock()
+	enabledCategories = make(map[string]bool, len(Categories))
+	for _, category := range Categories {
+		enabledCategories[category] = categories[category]
+	}
+}
+
+func EnabledCategories() map[string]bool {
+	categoriesLock.RLock()
+	defer categoriesLock.RUnlock()
+	categories := make(map[string]bool, len(enabledCategories))
+	for category, enabled := range enabledCategories {
+		categories[category] = enabled
+	}
+	return categories
+}
+
+func AnyCategoryEnabled() bool {
+	categoriesLock.RLock()
+	defer categoriesLock.RUnlock()
+	for _, enabled := range enabledCategories {
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// EventCategory maps an event to the category controlling it, ui and sdk actions are feature events
+func EventCategory(eventName string) string {
+	switch {
+	case eventName == "error":
+		return CategoryErrors
+	case strings.HasPrefix(eventName, "user-"):
+		return CategoryFeatures
+	default:
+		return CategoryUsage
+	}
 }
 
 func SendEvent(tenantName, username string, params map[string]interface{}, eventName string) {
+	if activeSink == nil {
+		return
+	}
+	category := EventCategory(eventName)
+	categoriesLock.RLock()
+	enabled := enabledCategories[category]
+	categoriesLock.RUnlock()
+	if !enabled {
+		return
+	}
+
+	properties := make(map[string]interface{}, len(params)+3)
+	for k, v := range params {
+		properties[k] = v
+	}
+	properties["memphis_version"] = activeSink.memphisVersion
+	if tenantName != "" {
+		properties["tenant_name"] = tenantName
+	}
+	if username != "" {
+		properties["username"] = username
+	}
+	event := EventBody{
+		DistinctId: activeSink.distinctId,
+		Event:      eventName,
+		Category:   category,
+		Properties: properties,
+		TimeStamp:  time.Now().UTC().Format(time.RFC3339),
+	}
+	select {
+	case activeSink.events <- event:
+	default:
+		// the collector is slower than the events rate, analytics are not worth blocking for
+	}
+}
+
+func (s *sink) run() {
+	defer close(s.closed)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]EventBody, 0, eventsBatchSize)
+	for {
+		select {
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) >= eventsBatchSize {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-s.done:
+			for {
+				select {
+				case event := <-s.events:
+					batch = append(batch, event)
+				default:
+					if len(batch) > 0 {
+						s.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts a batch as a json array, failed batches are dropped
+func (s *sink) send(batch []EventBody) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.authHeader != "" {
+		req.Header.Set("Authorization", s.authHeader)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
 }
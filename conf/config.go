@@ -12,6 +12,14 @@
 package conf
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/tkanos/gonfig"
 )
@@ -25,20 +33,33 @@ type Configuration struct {
 	DOCKER_ENV                   string
 	ROOT_PASSWORD                string
 	ANALYTICS                    string
+	ANALYTICS_ENDPOINT           string
+	ANALYTICS_AUTH_HEADER        string
 	JWT_SECRET                   string
 	REFRESH_JWT_SECRET           string
 	EXPORTER                     bool
+	METADATA_DB_URL              string
 	METADATA_DB_USER             string
 	METADATA_DB_PASS             string
 	METADATA_DB_DBNAME           string
 	METADATA_DB_HOST             string
 	METADATA_DB_PORT             string
 	METADATA_DB_MAX_CONNS        int
+	METADATA_DB_MIN_CONNS        int
+	METADATA_DB_CONN_LIFE_SEC    int
+	METADATA_DB_CONN_IDLE_SEC    int
+	METADATA_DB_TIMEOUT_SEC      int
+	METADATA_DB_RETRIES          int
+	METADATA_DB_SLOW_QUERY_MS    int
+	SLOW_OPERATION_MS            int
 	METADATA_DB_TLS_ENABLED      bool
 	METADATA_DB_TLS_MUTUAL       bool
 	METADATA_DB_TLS_KEY          string
 	METADATA_DB_TLS_CRT          string
 	METADATA_DB_TLS_CA           string
+	METADATA_DB_EMBEDDED         bool
+	METADATA_DB_EMBEDDED_DIR     string
+	METADATA_DB_BINARIES_URL     string
 	USER_PASS_BASED_AUTH         bool
 	CONNECTION_TOKEN             string
 	ENCRYPTION_SECRET_KEY        string
@@ -54,11 +75,265 @@ type Configuration struct {
 	FUNCTIONS_ADMIN_SERVICE_PORT string
 	INITIAL_CONFIG_FILE          string
 	WS_HOST                      string
+	WS_UPDATES_INTERVAL_SEC      int
+	ZOMBIE_CHECK_INTERVAL_MIN    int
+	ZOMBIE_CONN_STRIKES          int
+	ZOMBIE_CONN_COLLECT_SEC      int
+	ZOMBIE_CANDIDATE_TTL_MIN     int
+	SDK_HEARTBEAT_INTERVAL_SEC   int
+	SDK_HEARTBEAT_TIMEOUT_SEC    int
+	CONN_STATS_PERSIST_SEC       int
+	CONN_STATS_RETENTION_HOURS   int
+	COMPACTION_INTERVAL_SEC      int
+	COMPACTION_KEY_HEADER        string
+	COMPRESSION_CODECS           string
+	MIN_SDK_REQ_VERSION          int
+	CLAIM_CHECK_MAX_SIZE_MB      int
+	SCRAM_AUTH_ENABLED           bool
+	SECRETS_KMS_PROVIDER         string
+	SECRETS_KMS_LOCAL_KEY_FILE   string
+	SECRETS_KMS_AWS_KEY_ID       string
+	SECRETS_KMS_AWS_REGION       string
+	SECRETS_KMS_VAULT_ADDR       string
+	SECRETS_KMS_VAULT_TOKEN      string
+	SECRETS_KMS_VAULT_KEY        string
+	AUDIT_EXPORT_TYPE            string
+	AUDIT_EXPORT_FORMAT          string
+	AUDIT_EXPORT_ENDPOINT        string
+	AUDIT_EXPORT_AUTH_HEADER     string
+	AUDIT_EXPORT_BUFFER_SIZE     int
+	OPENLINEAGE_URL              string
+	OPENLINEAGE_API_KEY          string
+	OPENLINEAGE_NAMESPACE        string
+	EXTERNAL_AUTHZ_URL           string
+	EXTERNAL_AUTHZ_AUTH_HEADER   string
+	EXTERNAL_AUTHZ_TIMEOUT_MS    int
+	EXTERNAL_AUTHZ_CACHE_SEC     int
+	EXTERNAL_AUTHZ_FAIL_OPEN     bool
+	ADMIN_POLICY_FILE            string
+	ADMIN_POLICY_QUERY           string
+	ADMIN_POLICY_TIMEOUT_MS      int
+	ADMIN_POLICY_FAIL_OPEN       bool
+	USERS_PROVISIONING_FILE      string
+	DEV_MODE                     bool
+	DEV_SEED_DATA                bool
+	DEV_DATA_DIR                 string
+}
+
+const (
+	ConfigSourceFlag    = "flag"
+	ConfigSourceEnv     = "env"
+	ConfigSourceFile    = "file"
+	ConfigSourceDevMode = "dev_mode"
+	ConfigSourceDefault = "default"
+	ConfigSourceUnset   = "unset"
+)
+
+type ConfigEntry struct {
+	Name     string `json:"name"`
+	Value    any    `json:"value"`
+	Source   string `json:"source"`
+	Redacted bool   `json:"redacted"`
 }
 
+// GetConfig resolves the memphis settings, each one is taken from the first source which sets it:
+//  1. a --set KEY=VALUE command line flag
+//  2. the environment variable named after the setting
+//  3. the json/yaml file given by --app_config or the MEMPHIS_CONFIG_FILE environment variable
+//  4. the local playground defaults of devModeDefaults when running with --dev
+//  5. the defaults in applyConfigDefaults
 func GetConfig() Configuration {
+	configuration, _ := resolveConfig()
+	gin.SetMode(gin.ReleaseMode)
+	return configuration
+}
+
+// EffectiveConfig lists every setting with its resolved value and source, secrets are redacted
+func EffectiveConfig() []ConfigEntry {
+	configuration, sources := resolveConfig()
+	v := reflect.ValueOf(configuration)
+	entries := make([]ConfigEntry, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		entry := ConfigEntry{Name: name, Value: v.Field(i).Interface(), Source: sources[name]}
+		if isSecretConfig(name) && !v.Field(i).IsZero() {
+			entry.Value = "********"
+			entry.Redacted = true
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func isSecretConfig(name string) bool {
+	for _, marker := range []string{"PASS", "TOKEN", "_SECRET", "AUTH_HEADER", "API_KEY"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "_KEY")
+}
+
+// devModeFlags maps the local playground flags to the settings they turn on
+var devModeFlags = map[string]string{
+	"dev":      "DEV_MODE",
+	"dev_seed": "DEV_SEED_DATA",
+}
+
+// devModeDefaults are applied with --dev to the settings no other source sets, the broker runs as a single local
+// node and SDKs connect with the root username and password instead of a connection token
+var devModeDefaults = map[string]string{
+	"LOCAL_CLUSTER_ENV":    "true",
+	"USER_PASS_BASED_AUTH": "true",
+}
+
+// configArgs picks the memphis settings flags out of the command line, the broker's own flag set declares them as well
+func configArgs(args []string) (string, map[string]string) {
+	var configFile string
+	overrides := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		name := strings.TrimLeft(args[i], "-")
+		if name == args[i] {
+			continue
+		}
+		name, value, hasValue := strings.Cut(name, "=")
+		if setting, ok := devModeFlags[name]; ok {
+			// boolean flags, a value is only taken in the --dev=false form
+			if !hasValue {
+				value = "true"
+			}
+			overrides[setting] = value
+			continue
+		}
+		if name != "app_config" && name != "set" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if name == "app_config" {
+			configFile = value
+			continue
+		}
+		key, keyValue, ok := strings.Cut(value, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "ignoring setting override %v: expected KEY=VALUE\n", value)
+			continue
+		}
+		overrides[strings.ToUpper(strings.TrimSpace(key))] = keyValue
+	}
+	return configFile, overrides
+}
+
+func setConfigValue(configuration *Configuration, name, value string) error {
+	f := reflect.ValueOf(configuration).Elem().FieldByName(name)
+	if !f.IsValid() {
+		return fmt.Errorf("unknown setting %v", name)
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("setting %v expects a boolean: %v", name, err.Error())
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("setting %v expects a number: %v", name, err.Error())
+		}
+		f.SetInt(int64(n))
+	default:
+		return fmt.Errorf("setting %v can not be set from the command line", name)
+	}
+	return nil
+}
+
+func resolveConfig() (Configuration, map[string]string) {
 	configuration := Configuration{}
-	gonfig.GetConf("", &configuration)
+	configFile, overrides := configArgs(os.Args[1:])
+	if configFile == "" {
+		configFile = os.Getenv("MEMPHIS_CONFIG_FILE")
+	}
+	err := gonfig.GetConf(configFile, &configuration)
+	if err != nil {
+		// gonfig skips the environment when the file can not be read
+		fmt.Fprintf(os.Stderr, "failed reading settings file %v: %v\n", configFile, err.Error())
+		configFile = ""
+		configuration = Configuration{}
+		gonfig.GetConf("", &configuration)
+	}
+	for name, value := range overrides {
+		err := setConfigValue(&configuration, name, value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ignoring setting override: %v\n", err.Error())
+			delete(overrides, name)
+		}
+	}
+
+	sources := make(map[string]string)
+	v := reflect.ValueOf(&configuration).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if _, ok := overrides[name]; ok {
+			sources[name] = ConfigSourceFlag
+		} else if os.Getenv(name) != "" {
+			sources[name] = ConfigSourceEnv
+		} else if configFile != "" && !v.Field(i).IsZero() {
+			sources[name] = ConfigSourceFile
+		}
+	}
+
+	if configuration.DEV_MODE {
+		for name, value := range devModeDefaults {
+			if _, ok := sources[name]; ok {
+				continue
+			}
+			if err := setConfigValue(&configuration, name, value); err == nil {
+				sources[name] = ConfigSourceDevMode
+			}
+		}
+		// the playground keeps its metadata in the embedded store unless the settings point at another db
+		_, embeddedSet := sources["METADATA_DB_EMBEDDED"]
+		_, urlSet := sources["METADATA_DB_URL"]
+		_, hostSet := sources["METADATA_DB_HOST"]
+		if !embeddedSet && !urlSet && !hostSet {
+			configuration.METADATA_DB_EMBEDDED = true
+			sources["METADATA_DB_EMBEDDED"] = ConfigSourceDevMode
+		}
+		if _, ok := sources["METADATA_DB_EMBEDDED_DIR"]; !ok && configuration.METADATA_DB_EMBEDDED {
+			if configuration.DEV_DATA_DIR == "" {
+				configuration.DEV_DATA_DIR = filepath.Join(os.TempDir(), "memphis_dev")
+			}
+			configuration.METADATA_DB_EMBEDDED_DIR = filepath.Join(configuration.DEV_DATA_DIR, "metadata")
+			sources["METADATA_DB_EMBEDDED_DIR"] = ConfigSourceDevMode
+		}
+	}
+	applyConfigDefaults(&configuration)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if _, ok := sources[name]; ok {
+			continue
+		}
+		if v.Field(i).IsZero() {
+			sources[name] = ConfigSourceUnset
+		} else {
+			sources[name] = ConfigSourceDefault
+		}
+	}
+	return configuration, sources
+}
+
+func applyConfigDefaults(configuration *Configuration) {
 	if configuration.METADATA_DB_USER == "" {
 		configuration.METADATA_DB_USER = "memphis"
 	}
@@ -77,6 +352,9 @@ func GetConfig() Configuration {
 	if configuration.METADATA_DB_PORT == "" {
 		configuration.METADATA_DB_PORT = "5005"
 	}
+	if configuration.METADATA_DB_EMBEDDED_DIR == "" {
+		configuration.METADATA_DB_EMBEDDED_DIR = filepath.Join(os.TempDir(), "memphis_metadata")
+	}
 	if configuration.ROOT_PASSWORD == "" {
 		configuration.ROOT_PASSWORD = "memphis"
 	}
@@ -89,6 +367,57 @@ func GetConfig() Configuration {
 	if configuration.METADATA_DB_MAX_CONNS == 0 {
 		configuration.METADATA_DB_MAX_CONNS = 10
 	}
+	if configuration.METADATA_DB_TIMEOUT_SEC == 0 {
+		configuration.METADATA_DB_TIMEOUT_SEC = 40
+	}
+	if configuration.METADATA_DB_RETRIES == 0 {
+		configuration.METADATA_DB_RETRIES = 3
+	}
+	if configuration.METADATA_DB_SLOW_QUERY_MS == 0 {
+		configuration.METADATA_DB_SLOW_QUERY_MS = 1000
+	}
+	if configuration.SLOW_OPERATION_MS == 0 {
+		configuration.SLOW_OPERATION_MS = 1000
+	}
+	if configuration.WS_UPDATES_INTERVAL_SEC <= 0 {
+		configuration.WS_UPDATES_INTERVAL_SEC = 20
+	}
+	if configuration.ZOMBIE_CHECK_INTERVAL_MIN <= 0 {
+		configuration.ZOMBIE_CHECK_INTERVAL_MIN = 15
+	}
+	if configuration.ZOMBIE_CONN_STRIKES <= 0 {
+		configuration.ZOMBIE_CONN_STRIKES = 3
+	}
+	if configuration.ZOMBIE_CONN_COLLECT_SEC <= 0 {
+		configuration.ZOMBIE_CONN_COLLECT_SEC = 120
+	}
+	if configuration.ZOMBIE_CANDIDATE_TTL_MIN <= 0 {
+		configuration.ZOMBIE_CANDIDATE_TTL_MIN = 60
+	}
+	if configuration.SDK_HEARTBEAT_INTERVAL_SEC <= 0 {
+		configuration.SDK_HEARTBEAT_INTERVAL_SEC = 5
+	}
+	if configuration.SDK_HEARTBEAT_TIMEOUT_SEC <= configuration.SDK_HEARTBEAT_INTERVAL_SEC {
+		configuration.SDK_HEARTBEAT_TIMEOUT_SEC = 3 * configuration.SDK_HEARTBEAT_INTERVAL_SEC
+	}
+	if configuration.CONN_STATS_PERSIST_SEC <= 0 {
+		configuration.CONN_STATS_PERSIST_SEC = 60
+	}
+	if configuration.CONN_STATS_RETENTION_HOURS <= 0 {
+		configuration.CONN_STATS_RETENTION_HOURS = 168
+	}
+	if configuration.COMPACTION_INTERVAL_SEC <= 0 {
+		configuration.COMPACTION_INTERVAL_SEC = 60
+	}
+	if configuration.COMPACTION_KEY_HEADER == "" {
+		configuration.COMPACTION_KEY_HEADER = "msg-key"
+	}
+	if configuration.COMPRESSION_CODECS == "" {
+		configuration.COMPRESSION_CODECS = "zstd,snappy,gzip"
+	}
+	if configuration.CLAIM_CHECK_MAX_SIZE_MB <= 0 {
+		configuration.CLAIM_CHECK_MAX_SIZE_MB = 100
+	}
 	if configuration.USER_CACHE_LIFE_MINUTES == 0 {
 		configuration.USER_CACHE_LIFE_MINUTES = 10
 	}
@@ -104,7 +433,28 @@ func GetConfig() Configuration {
 	if configuration.WS_HOST == "" {
 		configuration.WS_HOST = "localhost:7770"
 	}
-
-	gin.SetMode(gin.ReleaseMode)
-	return configuration
+	if configuration.AUDIT_EXPORT_FORMAT == "" {
+		configuration.AUDIT_EXPORT_FORMAT = "json"
+	}
+	if configuration.AUDIT_EXPORT_BUFFER_SIZE == 0 {
+		configuration.AUDIT_EXPORT_BUFFER_SIZE = 10000
+	}
+	if configuration.OPENLINEAGE_NAMESPACE == "" {
+		configuration.OPENLINEAGE_NAMESPACE = "memphis"
+	}
+	if configuration.EXTERNAL_AUTHZ_TIMEOUT_MS <= 0 {
+		configuration.EXTERNAL_AUTHZ_TIMEOUT_MS = 2000
+	}
+	if configuration.EXTERNAL_AUTHZ_CACHE_SEC == 0 {
+		configuration.EXTERNAL_AUTHZ_CACHE_SEC = 60
+	}
+	if configuration.ADMIN_POLICY_QUERY == "" {
+		configuration.ADMIN_POLICY_QUERY = "data.memphis.admin"
+	}
+	if configuration.ADMIN_POLICY_TIMEOUT_MS <= 0 {
+		configuration.ADMIN_POLICY_TIMEOUT_MS = 2000
+	}
+	if configuration.DEV_DATA_DIR == "" {
+		configuration.DEV_DATA_DIR = filepath.Join(os.TempDir(), "memphis_dev")
+	}
 }
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 
 	"strings"
@@ -39,15 +40,35 @@ var configuration = conf.GetConfig()
 
 var MetadataDbClient MetadataStorage
 
-const (
-	DbOperationTimeout = 40
+// DbOperationTimeout is the deadline in seconds of a single metadata db operation
+var DbOperationTimeout = time.Duration(configuration.METADATA_DB_TIMEOUT_SEC)
+
+var (
+	// ErrSchemaExists is returned when a schema with the same name already exists in the tenant
+	ErrSchemaExists = errors.New("schema already exists")
+	// ErrSchemaVersionExists is returned when another writer already took the version number of a new schema version
+	ErrSchemaVersionExists = errors.New("version already exists")
+	// ErrSchemaVersionNotFound is returned when the version to activate does not exist (anymore)
+	ErrSchemaVersionNotFound = errors.New("schema version does not exist")
+	// ErrSchemaActiveVersionConflict is returned when the active version of a schema changed since the caller read it
+	ErrSchemaActiveVersionConflict = errors.New("the active version of the schema has been changed by another user, please refresh and try again")
+	// ErrProjectQuotaExceeded is returned when assigning stations would exceed the max stations of an environment or its project
+	ErrProjectQuotaExceeded = errors.New("the stations quota of the project is exceeded")
 )
 
 type logger interface {
 	Noticef(string, ...interface{})
+	Warnf(string, ...interface{})
 	Errorf(string, ...interface{})
 }
 
+var metadataDbLogger logger
+
+// SetMetadataDbLogger sets the logger used to report slow queries and retried connections
+func SetMetadataDbLogger(l logger) {
+	metadataDbLogger = l
+}
+
 type MetadataStorage struct {
 	Client *pgxpool.Pool
 	Ctx    context.Context
@@ -58,9 +79,105 @@ func CloseMetadataDb(db MetadataStorage, l logger) {
 	defer db.Cancel()
 	defer func() {
 		db.Client.Close()
+		if err := stopEmbeddedMetadataStore(); err != nil {
+			l.Errorf("Failed stopping the embedded metadata store: %v", err.Error())
+		}
 	}()
 }
 
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+type slowQueryTraceKey struct{}
+
+type slowQueryTrace struct {
+	start time.Time
+	sql   string
+}
+
+func (t slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryTraceKey{}, slowQueryTrace{start: time.Now(), sql: data.SQL})
+}
+
+func (t slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(slowQueryTraceKey{}).(slowQueryTrace)
+	if !ok || metadataDbLogger == nil {
+		return
+	}
+	if duration := time.Since(trace.start); duration >= t.threshold {
+		// prepared statements are traced by their name rather than the sql text
+		sql := strings.Join(strings.Fields(trace.sql), " ")
+		if len(sql) > 200 {
+			sql = sql[:200] + "..."
+		}
+		metadataDbLogger.Warnf("[metadata db] slow query took %v: %v", duration.Round(time.Millisecond), sql)
+	}
+}
+
+// isTransientDbError reports whether the error is a connection level failure worth retrying
+func isTransientDbError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// connection exceptions, admin/crash shutdown, cannot connect now and too many connections
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03" || pgErr.Code == "53300"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// acquireMetadataDbConn acquires a pool connection, retrying transient failures until the context expires
+func acquireMetadataDbConn(ctx context.Context) (*pgxpool.Conn, error) {
+	var conn *pgxpool.Conn
+	var err error
+	for attempt := 0; attempt <= configuration.METADATA_DB_RETRIES; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, err
+			case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+			}
+			if metadataDbLogger != nil {
+				metadataDbLogger.Warnf("[metadata db] retrying connection acquire (attempt %v): %v", attempt, err.Error())
+			}
+		}
+		conn, err = MetadataDbClient.Client.Acquire(ctx)
+		if !isTransientDbError(err) {
+			return conn, err
+		}
+	}
+	return nil, err
+}
+
+// WithTransaction runs fn in a single metadata db transaction, committed when fn returns nil and rolled back
+// otherwise, so flows writing several tables never leave half of their changes behind
+func WithTransaction(fn func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 func AddIndexToTable(indexName, tableName, field string, MetadataDbClient MetadataStorage) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
@@ -110,6 +227,8 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			SELECT 1 FROM information_schema.tables WHERE table_name = 'audit_logs' AND table_schema = 'public'
 		) THEN
 			ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant_name VARCHAR NOT NULL DEFAULT '$memphis';
+			ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS actor_ip VARCHAR NOT NULL DEFAULT '';
+			ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS request_id VARCHAR NOT NULL DEFAULT '';
 			DROP INDEX IF EXISTS station_name;
 			CREATE INDEX audit_logs_station_tenant_name ON audit_logs (station_name, tenant_name);
 		END IF;
@@ -123,8 +242,11 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		created_by_username VARCHAR NOT NULL,
 		created_at TIMESTAMPTZ NOT NULL,
 		tenant_name VARCHAR NOT NULL DEFAULT '$memphis',
+		actor_ip VARCHAR NOT NULL DEFAULT '',
+		request_id VARCHAR NOT NULL DEFAULT '',
 		PRIMARY KEY (id));
-	CREATE INDEX IF NOT EXISTS station_name ON audit_logs (station_name, tenant_name);`
+	CREATE INDEX IF NOT EXISTS station_name ON audit_logs (station_name, tenant_name);
+	CREATE INDEX IF NOT EXISTS audit_logs_tenant_created_at ON audit_logs (tenant_name, created_at);`
 
 	alterUsersTable := `
 	DO $$
@@ -240,6 +362,7 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		ALTER TABLE schemas DROP CONSTRAINT IF EXISTS schemas_name_tenant_name_key;
 		ALTER TABLE schemas ADD CONSTRAINT schemas_name_tenant_name_key UNIQUE(name, tenant_name);
 		ALTER TYPE enum_type ADD VALUE 'avro';
+		ALTER TABLE schemas ADD COLUMN IF NOT EXISTS owner_team VARCHAR NOT NULL DEFAULT '';
 		END IF;
 	END $$;`
 
@@ -251,6 +374,7 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		type enum_type NOT NULL DEFAULT 'protobuf',
 		created_by_username VARCHAR NOT NULL,
 		tenant_name VARCHAR NOT NULL DEFAULT '$memphis',
+		owner_team VARCHAR NOT NULL DEFAULT '',
 		PRIMARY KEY (id),
 		CONSTRAINT fk_tenant_name_schemas
 			FOREIGN KEY(tenant_name)
@@ -315,6 +439,9 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			ALTER TABLE consumers ADD COLUMN IF NOT EXISTS sdk VARCHAR NOT NULL DEFAULT 'unknown';
 			ALTER TABLE consumers ADD COLUMN IF NOT EXISTS app_id VARCHAR NOT NULL DEFAULT 'unknown';
 			UPDATE consumers SET app_id = connection_id WHERE app_id = 'unknown';
+			ALTER TABLE consumers ADD COLUMN IF NOT EXISTS client_id VARCHAR NOT NULL DEFAULT '';
+			DROP INDEX IF EXISTS consumer_station_client_id;
+			CREATE UNIQUE INDEX IF NOT EXISTS consumer_station_client_id_name ON consumers(station_id, client_id, name) WHERE client_id <> '';
 			IF EXISTS (
 				SELECT 1
 				FROM information_schema.columns
@@ -347,6 +474,7 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		version INTEGER NOT NULL DEFAULT 2,
 		sdk VARCHAR NOT NULL DEFAULT 'unknown',
 		app_id VARCHAR NOT NULL,
+		client_id VARCHAR NOT NULL DEFAULT '',
 		PRIMARY KEY (id),
 		CONSTRAINT fk_station_id
 			FOREIGN KEY(station_id)
@@ -358,7 +486,8 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		CREATE INDEX IF NOT EXISTS station_id ON consumers (station_id);
 		CREATE INDEX IF NOT EXISTS consumer_name ON consumers (name);
 		CREATE INDEX IF NOT EXISTS consumer_tenant_name ON consumers(tenant_name);
-		CREATE INDEX IF NOT EXISTS consumer_connection_id ON consumers(connection_id);`
+		CREATE INDEX IF NOT EXISTS consumer_connection_id ON consumers(connection_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS consumer_station_client_id_name ON consumers(station_id, client_id, name) WHERE client_id <> '';`
 
 	alterStationsTable := `
 	DO $$
@@ -367,6 +496,7 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			SELECT 1 FROM information_schema.tables WHERE table_name = 'stations' AND table_schema = 'public'
 		) THEN
 		ALTER TYPE enum_retention_type ADD VALUE IF NOT EXISTS 'ack_based';
+		ALTER TYPE enum_retention_type ADD VALUE IF NOT EXISTS 'compacted';
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS tenant_name VARCHAR NOT NULL DEFAULT '$memphis';
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS resend_disabled BOOL NOT NULL DEFAULT false;
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS partitions INTEGER[];
@@ -374,13 +504,21 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS dls_station VARCHAR NOT NULL DEFAULT '';
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS functions_lock_held BOOL NOT NULL DEFAULT false;
 		ALTER TABLE stations ADD COLUMN IF NOT EXISTS functions_locked_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS message_transform VARCHAR NOT NULL DEFAULT '';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS ack_retention_limit_type VARCHAR NOT NULL DEFAULT '';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS ack_retention_limit_value INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS owner_team VARCHAR NOT NULL DEFAULT '';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS description VARCHAR NOT NULL DEFAULT '';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS sla_tier VARCHAR NOT NULL DEFAULT '';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+		ALTER TABLE stations ADD COLUMN IF NOT EXISTS read_only BOOL NOT NULL DEFAULT false;
 		DROP INDEX IF EXISTS unique_station_name_deleted;
 		CREATE UNIQUE INDEX unique_station_name_deleted ON stations(name, is_deleted, tenant_name) WHERE is_deleted = false;
 		END IF;
 	END $$;`
 
 	stationsTable := `
-	CREATE TYPE enum_retention_type AS ENUM ('message_age_sec', 'messages', 'bytes', 'ack_based');
+	CREATE TYPE enum_retention_type AS ENUM ('message_age_sec', 'messages', 'bytes', 'ack_based', 'compacted');
 	CREATE TYPE enum_storage_type AS ENUM ('file', 'memory');
 	CREATE TABLE IF NOT EXISTS stations(
 		id SERIAL NOT NULL,
@@ -408,6 +546,14 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		dls_station VARCHAR NOT NULL DEFAULT '',
 		functions_lock_held BOOL NOT NULL DEFAULT false,
 		functions_locked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		message_transform VARCHAR NOT NULL DEFAULT '',
+		ack_retention_limit_type VARCHAR NOT NULL DEFAULT '',
+		ack_retention_limit_value INTEGER NOT NULL DEFAULT 0,
+		owner_team VARCHAR NOT NULL DEFAULT '',
+		description VARCHAR NOT NULL DEFAULT '',
+		sla_tier VARCHAR NOT NULL DEFAULT '',
+		labels JSONB NOT NULL DEFAULT '{}',
+		read_only BOOL NOT NULL DEFAULT false,
 		PRIMARY KEY (id),
 		CONSTRAINT fk_tenant_name_stations
 			FOREIGN KEY(tenant_name)
@@ -477,6 +623,11 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			ALTER TABLE producers ADD COLUMN IF NOT EXISTS sdk VARCHAR NOT NULL DEFAULT 'unknown';
 			ALTER TABLE producers ADD COLUMN IF NOT EXISTS app_id VARCHAR NOT NULL DEFAULT 'unknown';
 			UPDATE producers SET app_id = connection_id WHERE app_id = 'unknown';
+			ALTER TABLE producers ADD COLUMN IF NOT EXISTS owner VARCHAR NOT NULL DEFAULT '';
+			ALTER TABLE producers ADD COLUMN IF NOT EXISTS owner_team VARCHAR NOT NULL DEFAULT '';
+			ALTER TABLE producers ADD COLUMN IF NOT EXISTS client_id VARCHAR NOT NULL DEFAULT '';
+			DROP INDEX IF EXISTS producer_station_client_id;
+			CREATE UNIQUE INDEX IF NOT EXISTS producer_station_client_id_name ON producers(station_id, client_id, name) WHERE client_id <> '';
 			IF EXISTS (
 				SELECT 1
 				FROM information_schema.columns
@@ -503,6 +654,9 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		version INTEGER NOT NULL DEFAULT 2,
 		sdk VARCHAR NOT NULL DEFAULT 'unknown',
 		app_id VARCHAR NOT NULL,
+		owner VARCHAR NOT NULL DEFAULT '',
+		owner_team VARCHAR NOT NULL DEFAULT '',
+		client_id VARCHAR NOT NULL DEFAULT '',
 		PRIMARY KEY (id),
 		CONSTRAINT fk_station_id
 			FOREIGN KEY(station_id)
@@ -514,7 +668,8 @@ func createTables(MetadataDbClient MetadataStorage) error {
 		CREATE INDEX IF NOT EXISTS producer_station_id ON producers(station_id);
 		CREATE INDEX IF NOT EXISTS producer_name ON producers(name);
 		CREATE INDEX IF NOT EXISTS producer_tenant_name ON producers(tenant_name);
-		CREATE INDEX IF NOT EXISTS producer_connection_id ON producers(connection_id);`
+		CREATE INDEX IF NOT EXISTS producer_connection_id ON producers(connection_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS producer_station_client_id_name ON producers(station_id, client_id, name) WHERE client_id <> '';`
 
 	alterDlsMsgsTable := `DO $$
 	BEGIN
@@ -599,6 +754,15 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			REFERENCES tenants(name)
 		);`
 
+	// leases of the background tasks which have to run on a single broker, they are not per tenant
+	backgroundTaskLeasesTable := `
+		CREATE TABLE IF NOT EXISTS background_task_leases(
+			name VARCHAR NOT NULL,
+			holder VARCHAR NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (name)
+		);`
+
 	alterAsyncTasks := `DO $$
 	BEGIN
 		IF EXISTS (
@@ -657,2926 +821,9486 @@ func createTables(MetadataDbClient MetadataStorage) error {
 			REFERENCES tenants(name)
 		);`
 
-	db := MetadataDbClient.Client
-	ctx := MetadataDbClient.Ctx
+	apiKeysTable := `
+	CREATE TABLE IF NOT EXISTS api_keys(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		username VARCHAR NOT NULL,
+		key_prefix VARCHAR NOT NULL,
+		key_hash VARCHAR NOT NULL,
+		scopes VARCHAR[] NOT NULL DEFAULT '{}',
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		revoked BOOL NOT NULL DEFAULT false,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(key_hash),
+	CONSTRAINT fk_tenant_name_api_keys
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+	CREATE INDEX IF NOT EXISTS api_keys_username
+		ON api_keys(username, tenant_name);`
 
-	tables := []string{alterTenantsTable, tenantsTable, alterUsersTable, usersTable, alterAuditLogsTable, auditLogsTable, alterConfigurationsTable, configurationsTable, alterIntegrationsTable, integrationsTable, alterSchemasTable, schemasTable, alterTagsTable, tagsTable, alterStationsTable, stationsTable, alterDlsMsgsTable, dlsMessagesTable, alterConsumersTable, consumersTable, alterSchemaVerseTable, schemaVersionsTable, alterProducersTable, producersTable, alterConnectionsTable, asyncTasksTable, alterAsyncTasks, testEventsTable, functionsTable, attachedFunctionsTable, sharedLocksTable, functionsEngineWorkersTable, scheduledFunctionWorkersTable, connectorsEngineWorkersTable, connectorsConnectionsTable, connectorsTable, alterConnectorsTable, alterConnectorsConnectionsTable, rolesTable, permissionsTable}
+	connectionTokensTable := `
+	CREATE TABLE IF NOT EXISTS connection_tokens(
+		id SERIAL NOT NULL,
+		username VARCHAR NOT NULL,
+		token VARCHAR NOT NULL,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_tenant_name_connection_tokens
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+	CREATE INDEX IF NOT EXISTS connection_tokens_username
+		ON connection_tokens(username, tenant_name);`
 
-	for _, table := range tables {
-		_, err := db.Exec(ctx, table)
-		if err != nil {
-			var pgErr *pgconn.PgError
-			errPg := errors.As(err, &pgErr)
-			if errPg && !strings.Contains(pgErr.Message, "already exists") {
-				return err
-			}
-		}
-	}
-	return nil
-}
+	revokedConnectionTokensTable := `
+	CREATE TABLE IF NOT EXISTS revoked_connection_tokens(
+		id SERIAL NOT NULL,
+		username VARCHAR NOT NULL,
+		token_hash VARCHAR NOT NULL,
+		revoked_by VARCHAR NOT NULL,
+		revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(username, token_hash, tenant_name),
+	CONSTRAINT fk_tenant_name_revoked_connection_tokens
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-func InitalizeMetadataDbConnection() (MetadataStorage, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	scramCredentialsTable := `
+	CREATE TABLE IF NOT EXISTS scram_credentials(
+		id SERIAL NOT NULL,
+		username VARCHAR NOT NULL,
+		secret_id VARCHAR NOT NULL,
+		salt VARCHAR NOT NULL,
+		iterations INTEGER NOT NULL,
+		stored_key VARCHAR NOT NULL,
+		server_key VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(username, secret_id, tenant_name),
+	CONSTRAINT fk_tenant_name_scram_credentials
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-	defer cancelfunc()
-	metadataDbUser := configuration.METADATA_DB_USER
-	metadataDbPassword := configuration.METADATA_DB_PASS
-	metadataDbName := configuration.METADATA_DB_DBNAME
-	metadataDbHost := configuration.METADATA_DB_HOST
-	metadataDbPort := configuration.METADATA_DB_PORT
-	var metadataDbUrl string
-	if configuration.METADATA_DB_TLS_ENABLED {
-		metadataAuth := ""
-		if !configuration.METADATA_DB_TLS_MUTUAL {
-			metadataAuth = ":" + metadataDbPassword
-		}
-		metadataDbUrl = "postgres://" + metadataDbUser + metadataAuth + "@" + metadataDbHost + ":" + metadataDbPort + "/" + metadataDbName + "?sslmode=verify-full"
-	} else {
-		metadataDbUrl = "postgres://" + metadataDbUser + ":" + metadataDbPassword + "@" + metadataDbHost + ":" + metadataDbPort + "/" + metadataDbName + "?sslmode=prefer"
-	}
+	dynamicCredentialsTable := `
+	CREATE TABLE IF NOT EXISTS dynamic_credentials(
+		id SERIAL NOT NULL,
+		username VARCHAR NOT NULL,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(username, tenant_name),
+	CONSTRAINT fk_tenant_name_dynamic_credentials
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-	config, err := pgxpool.ParseConfig(metadataDbUrl)
-	if err != nil {
-		return MetadataStorage{}, err
-	}
-	config.MaxConns = int32(configuration.METADATA_DB_MAX_CONNS)
+	alertRulesTable := `
+	CREATE TABLE IF NOT EXISTS alert_rules(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		alert_type VARCHAR NOT NULL,
+		station_name VARCHAR NOT NULL DEFAULT '',
+		threshold FLOAT NOT NULL DEFAULT 0,
+		channels JSONB NOT NULL DEFAULT '[]',
+		enabled BOOL NOT NULL DEFAULT true,
+		state VARCHAR NOT NULL DEFAULT 'ok',
+		last_triggered_at TIMESTAMPTZ,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_alert_rules
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-	if configuration.METADATA_DB_TLS_ENABLED {
-		CACert, err := os.ReadFile(configuration.METADATA_DB_TLS_CA)
-		if err != nil {
-			return MetadataStorage{}, err
-		}
+	webhooksTable := `
+	CREATE TABLE IF NOT EXISTS webhooks(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		url VARCHAR NOT NULL,
+		secret VARCHAR NOT NULL,
+		event_types VARCHAR[] NOT NULL DEFAULT '{}',
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_webhooks
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-		CACertPool := x509.NewCertPool()
-		CACertPool.AppendCertsFromPEM(CACert)
+	amqpBridgesTable := `
+	CREATE TABLE IF NOT EXISTS amqp_bridges(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		direction VARCHAR NOT NULL,
+		url VARCHAR NOT NULL,
+		queue VARCHAR NOT NULL DEFAULT '',
+		exchange VARCHAR NOT NULL DEFAULT '',
+		routing_key VARCHAR NOT NULL DEFAULT '',
+		station_name VARCHAR NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_amqp_bridges
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-		cert, err := tls.LoadX509KeyPair(configuration.METADATA_DB_TLS_CRT, configuration.METADATA_DB_TLS_KEY)
-		if err != nil {
-			return MetadataStorage{}, err
-		}
+	cdcConnectorsTable := `
+	CREATE TABLE IF NOT EXISTS cdc_connectors(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		source_type VARCHAR NOT NULL,
+		connection_url VARCHAR NOT NULL,
+		database VARCHAR NOT NULL DEFAULT '',
+		tables VARCHAR[] NOT NULL DEFAULT '{}',
+		slot_name VARCHAR NOT NULL DEFAULT '',
+		position VARCHAR NOT NULL DEFAULT '',
+		station_name VARCHAR NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		transform VARCHAR NOT NULL DEFAULT '',
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_cdc_connectors
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-		config.ConnConfig.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: CACertPool, InsecureSkipVerify: true}
-	}
+	clickhouseSinksTable := `
+	CREATE TABLE IF NOT EXISTS clickhouse_sinks(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		url VARCHAR NOT NULL,
+		database VARCHAR NOT NULL,
+		table_name VARCHAR NOT NULL,
+		station_name VARCHAR NOT NULL,
+		batch_size INTEGER NOT NULL,
+		flush_interval_ms INTEGER NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		transform VARCHAR NOT NULL DEFAULT '',
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_clickhouse_sinks
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		return MetadataStorage{}, err
-	}
+	catalogExportersTable := `
+	CREATE TABLE IF NOT EXISTS catalog_exporters(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		type VARCHAR NOT NULL,
+		url VARCHAR NOT NULL,
+		token VARCHAR NOT NULL DEFAULT '',
+		service_name VARCHAR NOT NULL,
+		interval_sec INTEGER NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_catalog_exporters
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-	err = pool.Ping(ctx)
-	if err != nil {
-		return MetadataStorage{}, err
-	}
-	client := MetadataStorage{Ctx: ctx, Cancel: cancelfunc, Client: pool}
-	err = createTables(client)
-	if err != nil {
-		return MetadataStorage{}, err
-	}
-	MetadataDbClient = MetadataStorage{Client: pool, Ctx: ctx, Cancel: cancelfunc}
-	return MetadataDbClient, nil
-}
+	managedResourcesTable := `
+	CREATE TABLE IF NOT EXISTS managed_resources(
+		id SERIAL NOT NULL,
+		kind VARCHAR NOT NULL,
+		name VARCHAR NOT NULL,
+		owner VARCHAR NOT NULL,
+		generation BIGINT NOT NULL DEFAULT 0,
+		observed_generation BIGINT NOT NULL DEFAULT 0,
+		applied_etag VARCHAR NOT NULL DEFAULT '',
+		phase VARCHAR NOT NULL DEFAULT '',
+		message VARCHAR NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(kind, name, tenant_name),
+	CONSTRAINT fk_tenant_name_managed_resources
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-// System Keys Functions
-func GetSystemKey(key string, tenantName string) (bool, models.SystemKey, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return false, models.SystemKey{}, err
-	}
-	defer conn.Release()
-	query := `SELECT * FROM configurations WHERE key = $1 AND tenant_name = $2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_system_key", query)
-	if err != nil {
-		return false, models.SystemKey{}, err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, key, tenantName)
-	if err != nil {
-		return false, models.SystemKey{}, err
-	}
-	defer rows.Close()
-	systemKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SystemKey])
-	if err != nil {
-		return false, models.SystemKey{}, err
-	}
-	if len(systemKeys) == 0 {
-		return false, models.SystemKey{}, nil
-	}
-	return true, systemKeys[0], nil
-}
+	stationStorageKeysTable := `
+	CREATE TABLE IF NOT EXISTS station_storage_keys(
+		id SERIAL NOT NULL,
+		station_name VARCHAR NOT NULL,
+		wrapped_key VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		rotated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (id),
+		UNIQUE(station_name, tenant_name),
+	CONSTRAINT fk_tenant_name_station_storage_keys
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
 
-func InsertSystemKey(key string, value string, tenantName string) error {
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	err := InsertConfiguration(key, value, tenantName)
-	if err != nil {
-		return err
-	}
+	// retired keys are kept until every broker storing the station has re-wrapped its replicas
+	stationStorageKeyVersionsTable := `
+	CREATE TABLE IF NOT EXISTS station_storage_key_versions(
+		id SERIAL NOT NULL,
+		station_name VARCHAR NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		version INTEGER NOT NULL,
+		wrapped_key VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (id),
+		UNIQUE(station_name, tenant_name, version),
+	CONSTRAINT fk_tenant_name_station_storage_key_versions
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
+
+	stationStorageKeyRewrapsTable := `
+	CREATE TABLE IF NOT EXISTS station_storage_key_rewraps(
+		id SERIAL NOT NULL,
+		station_name VARCHAR NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		broker_name VARCHAR NOT NULL,
+		version INTEGER NOT NULL,
+		rewrapped_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (id),
+		UNIQUE(station_name, tenant_name, broker_name),
+	CONSTRAINT fk_tenant_name_station_storage_key_rewraps
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
+
+	// the single previous key of older versions becomes version 1 of the history
+	alterStationStorageKeysTable := `
+	DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns WHERE table_name = 'station_storage_keys' AND column_name = 'previous_wrapped_key' AND table_schema = 'public'
+		) THEN
+			ALTER TABLE station_storage_keys ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+			INSERT INTO station_storage_key_versions(station_name, tenant_name, version, wrapped_key)
+				SELECT station_name, tenant_name, 1, previous_wrapped_key FROM station_storage_keys WHERE previous_wrapped_key <> ''
+				ON CONFLICT DO NOTHING;
+			UPDATE station_storage_keys SET version = 2 WHERE previous_wrapped_key <> '';
+			ALTER TABLE station_storage_keys DROP COLUMN previous_wrapped_key;
+		END IF;
+	END $$;`
+
+	jobsTable := `
+	CREATE TABLE IF NOT EXISTS jobs(
+		id SERIAL NOT NULL,
+		type VARCHAR NOT NULL,
+		params JSON NOT NULL DEFAULT '{}',
+		status VARCHAR NOT NULL,
+		progress DOUBLE PRECISION NOT NULL DEFAULT 0,
+		result JSON NOT NULL DEFAULT '{}',
+		error VARCHAR NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 1,
+		broker_in_charge VARCHAR NOT NULL,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		finished_at TIMESTAMPTZ,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_tenant_name_jobs
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+	CREATE INDEX IF NOT EXISTS jobs_tenant_name_status ON jobs(tenant_name, status);`
+
+	teamsTable := `
+	CREATE TABLE IF NOT EXISTS teams(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		description VARCHAR NOT NULL DEFAULT '',
+		created_by_username VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_teams
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
+
+	userInvitationsTable := `
+	CREATE TABLE IF NOT EXISTS user_invitations(
+		id SERIAL NOT NULL,
+		username VARCHAR NOT NULL,
+		email VARCHAR NOT NULL,
+		invited_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(username, tenant_name),
+	CONSTRAINT fk_tenant_name_user_invitations
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
+
+	sessionsTable := `
+	CREATE TABLE IF NOT EXISTS sessions(
+		id VARCHAR NOT NULL,
+		username VARCHAR NOT NULL,
+		ip VARCHAR NOT NULL DEFAULT '',
+		user_agent VARCHAR NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_tenant_name_sessions
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+	CREATE INDEX IF NOT EXISTS sessions_tenant_name_username ON sessions(tenant_name, username);
+	CREATE INDEX IF NOT EXISTS sessions_expires_at ON sessions(expires_at);`
+
+	commentsTable := `
+	CREATE TABLE IF NOT EXISTS comments(
+		id SERIAL NOT NULL,
+		entity_type VARCHAR NOT NULL,
+		entity_id INTEGER NOT NULL,
+		version_number INTEGER NOT NULL DEFAULT 0,
+		parent_id INTEGER,
+		body TEXT NOT NULL,
+		mentions VARCHAR[] NOT NULL DEFAULT '{}',
+		created_by_username VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_parent_id_comments
+		FOREIGN KEY(parent_id)
+		REFERENCES comments(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_tenant_name_comments
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+	CREATE INDEX IF NOT EXISTS comments_entity ON comments(tenant_name, entity_type, entity_id);`
+
+	connectionStatsTable := `
+	CREATE TABLE IF NOT EXISTS connection_stats(
+		connection_id VARCHAR NOT NULL,
+		broker VARCHAR NOT NULL,
+		username VARCHAR NOT NULL DEFAULT '',
+		in_msgs BIGINT NOT NULL DEFAULT 0,
+		in_bytes BIGINT NOT NULL DEFAULT 0,
+		out_msgs BIGINT NOT NULL DEFAULT 0,
+		out_bytes BIGINT NOT NULL DEFAULT 0,
+		in_bytes_per_sec BIGINT NOT NULL DEFAULT 0,
+		out_bytes_per_sec BIGINT NOT NULL DEFAULT 0,
+		errors BIGINT NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_error_at TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (connection_id, broker, tenant_name)
+	);
+	CREATE INDEX IF NOT EXISTS connection_stats_broker ON connection_stats(broker);`
+
+	cgRebalancesTable := `
+	CREATE TABLE IF NOT EXISTS cg_rebalances(
+		id SERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		cg_name VARCHAR NOT NULL,
+		reason VARCHAR NOT NULL,
+		consumer_name VARCHAR NOT NULL DEFAULT '',
+		connection_id VARCHAR NOT NULL DEFAULT '',
+		active_members INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_station_id_cg_rebalances
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS cg_rebalances_station_cg ON cg_rebalances(station_id, cg_name, created_at);`
+
+	stationLifecyclePoliciesTable := `
+	CREATE TABLE IF NOT EXISTS station_lifecycle_policies(
+		id SERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		delete_after_idle_days INTEGER NOT NULL DEFAULT 0,
+		archive_after_inactive_days INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ,
+		notify_before_hours INTEGER NOT NULL DEFAULT 24,
+		inactive_since TIMESTAMPTZ,
+		notified_action VARCHAR NOT NULL DEFAULT '',
+		notified_due_at TIMESTAMPTZ,
+		notified_at TIMESTAMPTZ,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(station_id),
+	CONSTRAINT fk_station_id_station_lifecycle_policies
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);`
+
+	stationIndexConfigsTable := `
+	CREATE TABLE IF NOT EXISTS station_index_configs(
+		id SERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		extractors JSONB NOT NULL DEFAULT '[]',
+		scanned_seqs JSONB NOT NULL DEFAULT '{}',
+		updated_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(station_id),
+	CONSTRAINT fk_station_id_station_index_configs
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);`
+
+	stationMessageIndexTable := `
+	CREATE TABLE IF NOT EXISTS station_message_index(
+		id BIGSERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		extractor VARCHAR NOT NULL,
+		value VARCHAR NOT NULL,
+		partition_number INTEGER NOT NULL,
+		message_seq BIGINT NOT NULL,
+		produced_at TIMESTAMPTZ NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_station_id_station_message_index
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS station_message_index_lookup ON station_message_index(station_id, extractor, value);
+	CREATE INDEX IF NOT EXISTS station_message_index_seq ON station_message_index(station_id, partition_number, message_seq);`
+
+	schemaAliasesTable := `
+	CREATE TABLE IF NOT EXISTS schema_aliases(
+		name VARCHAR NOT NULL,
+		schema_id INTEGER NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (name, tenant_name),
+	CONSTRAINT fk_schema_id_schema_aliases
+		FOREIGN KEY(schema_id)
+		REFERENCES schemas(id)
+		ON DELETE CASCADE
+	);`
+
+	projectsTable := `
+	CREATE TABLE IF NOT EXISTS projects(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		path VARCHAR NOT NULL,
+		parent_id INTEGER,
+		description VARCHAR NOT NULL DEFAULT '',
+		tags VARCHAR[] NOT NULL DEFAULT '{}',
+		max_stations INTEGER NOT NULL DEFAULT 0,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(path, tenant_name),
+	CONSTRAINT fk_parent_id_projects
+		FOREIGN KEY(parent_id)
+		REFERENCES projects(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_tenant_name_projects
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);
+
+	CREATE TABLE IF NOT EXISTS project_stations(
+		station_id INTEGER NOT NULL,
+		project_id INTEGER NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (station_id),
+	CONSTRAINT fk_station_id_project_stations
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_project_id_project_stations
+		FOREIGN KEY(project_id)
+		REFERENCES projects(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS project_stations_project ON project_stations(project_id);
+
+	CREATE TABLE IF NOT EXISTS project_permissions(
+		project_id INTEGER NOT NULL,
+		role_id INTEGER NOT NULL,
+		type permissions_enum NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (project_id, role_id, type),
+	CONSTRAINT fk_project_id_project_permissions
+		FOREIGN KEY(project_id)
+		REFERENCES projects(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_role_id_project_permissions
+		FOREIGN KEY(role_id)
+		REFERENCES roles(id)
+		ON DELETE CASCADE
+	);`
+
+	stationContractReportSchedulesTable := `
+	CREATE TABLE IF NOT EXISTS station_contract_report_schedules(
+		id SERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		interval_hours INTEGER NOT NULL,
+		recipients VARCHAR[] NOT NULL DEFAULT '{}',
+		last_generated_at TIMESTAMPTZ,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(station_id),
+	CONSTRAINT fk_station_id_station_contract_report_schedules
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);`
+
+	stationContractReportsTable := `
+	CREATE TABLE IF NOT EXISTS station_contract_reports(
+		id BIGSERIAL NOT NULL,
+		station_id INTEGER NOT NULL,
+		health VARCHAR NOT NULL,
+		report JSONB NOT NULL,
+		generated_at TIMESTAMPTZ NOT NULL,
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+	CONSTRAINT fk_station_id_station_contract_reports
+		FOREIGN KEY(station_id)
+		REFERENCES stations(id)
+		ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS station_contract_reports_station ON station_contract_reports(station_id, generated_at);`
+
+	schemaRegistrySyncsTable := `
+	CREATE TABLE IF NOT EXISTS schema_registry_syncs(
+		id SERIAL NOT NULL,
+		name VARCHAR NOT NULL,
+		type VARCHAR NOT NULL,
+		url VARCHAR NOT NULL,
+		username VARCHAR NOT NULL DEFAULT '',
+		password VARCHAR NOT NULL DEFAULT '',
+		subject_prefix VARCHAR NOT NULL DEFAULT '',
+		conflict_policy VARCHAR NOT NULL,
+		push_back BOOL NOT NULL DEFAULT false,
+		interval_sec INTEGER NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		created_by VARCHAR NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		tenant_name VARCHAR NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(name, tenant_name),
+	CONSTRAINT fk_tenant_name_schema_registry_syncs
+		FOREIGN KEY(tenant_name)
+		REFERENCES tenants(name)
+	);`
+
+	// backs the per tenant aggregations of the schemas and stations lists
+	listAggregationIndexes := `
+	CREATE INDEX IF NOT EXISTS stations_tenant_schema_name ON stations(tenant_name, schema_name) WHERE is_deleted = false;
+	CREATE INDEX IF NOT EXISTS tags_tenant_name ON tags(tenant_name);`
+
+	db := MetadataDbClient.Client
+	ctx := MetadataDbClient.Ctx
+
+	tables := []string{alterTenantsTable, tenantsTable, alterUsersTable, usersTable, alterAuditLogsTable, auditLogsTable, alterConfigurationsTable, configurationsTable, alterIntegrationsTable, integrationsTable, alterSchemasTable, schemasTable, alterTagsTable, tagsTable, alterStationsTable, stationsTable, alterDlsMsgsTable, dlsMessagesTable, alterConsumersTable, consumersTable, alterSchemaVerseTable, schemaVersionsTable, alterProducersTable, producersTable, alterConnectionsTable, asyncTasksTable, alterAsyncTasks, testEventsTable, functionsTable, attachedFunctionsTable, sharedLocksTable, functionsEngineWorkersTable, scheduledFunctionWorkersTable, connectorsEngineWorkersTable, connectorsConnectionsTable, connectorsTable, alterConnectorsTable, alterConnectorsConnectionsTable, rolesTable, permissionsTable, apiKeysTable, connectionTokensTable, revokedConnectionTokensTable, scramCredentialsTable, dynamicCredentialsTable, alertRulesTable, webhooksTable, amqpBridgesTable, cdcConnectorsTable, clickhouseSinksTable, catalogExportersTable, managedResourcesTable, stationStorageKeysTable, stationStorageKeyVersionsTable, stationStorageKeyRewrapsTable, alterStationStorageKeysTable, jobsTable, teamsTable, userInvitationsTable, sessionsTable, commentsTable, connectionStatsTable, cgRebalancesTable, stationLifecyclePoliciesTable, stationIndexConfigsTable, stationMessageIndexTable, schemaRegistrySyncsTable, stationContractReportSchedulesTable, stationContractReportsTable, projectsTable, schemaAliasesTable, listAggregationIndexes, backgroundTaskLeasesTable}
+
+	for _, table := range tables {
+		_, err := db.Exec(ctx, table)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			errPg := errors.As(err, &pgErr)
+			if errPg && !strings.Contains(pgErr.Message, "already exists") {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// metadataDbPoolConfig builds the connection pool config of the configured metadata db
+func metadataDbPoolConfig() (*pgxpool.Config, error) {
+	metadataDbUser := configuration.METADATA_DB_USER
+	metadataDbPassword := configuration.METADATA_DB_PASS
+	metadataDbName := configuration.METADATA_DB_DBNAME
+	metadataDbHost := configuration.METADATA_DB_HOST
+	metadataDbPort := configuration.METADATA_DB_PORT
+	var metadataDbUrl string
+	if configuration.METADATA_DB_URL != "" {
+		// a full connection string, for managed postgres services which need parameters the separate fields can not express
+		metadataDbUrl = configuration.METADATA_DB_URL
+	} else if configuration.METADATA_DB_TLS_ENABLED {
+		metadataAuth := ""
+		if !configuration.METADATA_DB_TLS_MUTUAL {
+			metadataAuth = ":" + metadataDbPassword
+		}
+		metadataDbUrl = "postgres://" + metadataDbUser + metadataAuth + "@" + metadataDbHost + ":" + metadataDbPort + "/" + metadataDbName + "?sslmode=verify-full"
+	} else {
+		metadataDbUrl = "postgres://" + metadataDbUser + ":" + metadataDbPassword + "@" + metadataDbHost + ":" + metadataDbPort + "/" + metadataDbName + "?sslmode=prefer"
+	}
+
+	config, err := pgxpool.ParseConfig(metadataDbUrl)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxConns = int32(configuration.METADATA_DB_MAX_CONNS)
+	if configuration.METADATA_DB_MIN_CONNS > 0 {
+		config.MinConns = int32(configuration.METADATA_DB_MIN_CONNS)
+	}
+	if configuration.METADATA_DB_CONN_LIFE_SEC > 0 {
+		config.MaxConnLifetime = time.Duration(configuration.METADATA_DB_CONN_LIFE_SEC) * time.Second
+	}
+	if configuration.METADATA_DB_CONN_IDLE_SEC > 0 {
+		config.MaxConnIdleTime = time.Duration(configuration.METADATA_DB_CONN_IDLE_SEC) * time.Second
+	}
+	if configuration.METADATA_DB_SLOW_QUERY_MS > 0 {
+		config.ConnConfig.Tracer = slowQueryTracer{threshold: time.Duration(configuration.METADATA_DB_SLOW_QUERY_MS) * time.Millisecond}
+	}
+
+	if configuration.METADATA_DB_TLS_ENABLED {
+		CACert, err := os.ReadFile(configuration.METADATA_DB_TLS_CA)
+		if err != nil {
+			return nil, err
+		}
+
+		CACertPool := x509.NewCertPool()
+		CACertPool.AppendCertsFromPEM(CACert)
+
+		cert, err := tls.LoadX509KeyPair(configuration.METADATA_DB_TLS_CRT, configuration.METADATA_DB_TLS_KEY)
+		if err != nil {
+			return nil, err
+		}
+
+		config.ConnConfig.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: CACertPool, InsecureSkipVerify: true}
+	}
+	return config, nil
+}
+
+func InitalizeMetadataDbConnection() (MetadataStorage, error) {
+	if configuration.METADATA_DB_EMBEDDED {
+		err := startEmbeddedMetadataStore()
+		if err != nil {
+			return MetadataStorage{}, err
+		}
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+
+	defer cancelfunc()
+	config, err := metadataDbPoolConfig()
+	if err != nil {
+		return MetadataStorage{}, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return MetadataStorage{}, err
+	}
+
+	err = pool.Ping(ctx)
+	if err != nil {
+		return MetadataStorage{}, err
+	}
+	client := MetadataStorage{Ctx: ctx, Cancel: cancelfunc, Client: pool}
+	err = createTables(client)
+	if err != nil {
+		return MetadataStorage{}, err
+	}
+	MetadataDbClient = MetadataStorage{Client: pool, Ctx: ctx, Cancel: cancelfunc}
+	return MetadataDbClient, nil
+}
+
+func PingMetadataDb() error {
+	if MetadataDbClient.Client == nil {
+		return errors.New("metadata db client is not initialized")
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	return MetadataDbClient.Client.Ping(ctx)
+}
+
+// System Keys Functions
+func GetSystemKey(key string, tenantName string) (bool, models.SystemKey, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.SystemKey{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM configurations WHERE key = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_system_key", query)
+	if err != nil {
+		return false, models.SystemKey{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, key, tenantName)
+	if err != nil {
+		return false, models.SystemKey{}, err
+	}
+	defer rows.Close()
+	systemKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SystemKey])
+	if err != nil {
+		return false, models.SystemKey{}, err
+	}
+	if len(systemKeys) == 0 {
+		return false, models.SystemKey{}, nil
+	}
+	return true, systemKeys[0], nil
+}
+
+func InsertSystemKey(key string, value string, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err := InsertConfiguration(key, value, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func EditConfigurationValue(key string, value string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE configurations SET value = $2 WHERE key = $1 AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "edit_configuration_value", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, key, value, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Configuration Functions
+func GetAllConfigurations() (bool, []models.ConfigurationsValue, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, []models.ConfigurationsValue{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM configurations`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_configurations", query)
+	if err != nil {
+		return false, []models.ConfigurationsValue{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return false, []models.ConfigurationsValue{}, err
+	}
+	defer rows.Close()
+	configurations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConfigurationsValue])
+	if err != nil {
+		return false, []models.ConfigurationsValue{}, err
+	}
+	if len(configurations) == 0 {
+		return false, []models.ConfigurationsValue{}, nil
+	}
+
+	return true, configurations, nil
+}
+
+func InsertConfiguration(key string, value string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO configurations( 
+			key, 
+			value,
+			tenant_name) 
+		VALUES($1, $2, $3) 
+		RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_configuration", query)
+	if err != nil {
+		return err
+	}
+
+	newConfiguration := models.ConfigurationsValue{}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name,
+		key, value, tenantName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&newConfiguration.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return errors.New("configuration " + key + " already exists")
+				} else {
+					return errors.New(pgErr.Detail)
+				}
+			} else {
+				return errors.New(pgErr.Message)
+			}
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func UpsertConfiguration(key string, value string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `INSERT INTO configurations (key, value, tenant_name) VALUES($1, $2, $3)
+	ON CONFLICT(key, tenant_name) DO UPDATE SET value = EXCLUDED.value`
+	stmt, err := conn.Conn().Prepare(ctx, "update_configuration", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, key, value, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Connection Functions
+func UpdateProducersCounsumersConnection(connectionId string, isActive bool) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	query := `WITH updated_producers AS (
+		UPDATE producers
+		SET is_active = $1, updated_at = NOW()
+		WHERE connection_id = $2
+	  )
+	  UPDATE consumers
+	  SET is_active = $1, updated_at = NOW()
+	  WHERE connection_id = $2;`
+	stmt, err := conn.Conn().Prepare(ctx, "update_connection", query)
+	if err != nil {
+		return false, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	affectedRows := 0
+	for rows.Next() {
+		affectedRows++
+		if affectedRows > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func GetActiveConnections() ([]string, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+	defer conn.Release()
+	query := `SELECT connection_id FROM producers WHERE is_active = true AND type = 'application' UNION SELECT connection_id FROM consumers WHERE is_active = true AND type = 'application';`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_connection", query)
+	if err != nil {
+		return []string{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+	var connections []string
+	for rows.Next() {
+		var connectionID string
+		err = rows.Scan(&connectionID)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, connectionID)
+	}
+	if len(connections) == 0 {
+		return []string{}, nil
+	}
+	return connections, nil
+}
+
+// Audit Logs Functions
+func InsertAuditLogs(auditLogs []interface{}) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+	var auditLog []models.AuditLog
+
+	b, err := json.Marshal(auditLogs)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(b, &auditLog)
+	if err != nil {
+		return err
+	}
+
+	stationName := auditLog[0].StationName
+	message := auditLog[0].Message
+	createdBy := auditLog[0].CreatedBy
+	createdAt := auditLog[0].CreatedAt
+	createdByUserName := auditLog[0].CreatedByUsername
+	tenantName := auditLog[0].TenantName
+	actorIp := auditLog[0].ActorIp
+	requestId := auditLog[0].RequestId
+
+	query := `INSERT INTO audit_logs ( 
+		station_name, 
+		message, 
+		created_by,
+		created_by_username,
+		created_at,
+		tenant_name,
+		actor_ip,
+		request_id
+		) 
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_audit_logs", query)
+	if err != nil {
+		return err
+	}
+
+	newAuditLog := models.AuditLog{}
+	rows, err := conn.Conn().Query(ctx, stmt.Name,
+		stationName, message, createdBy, createdByUserName, createdAt, tenantName, actorIp, requestId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&newAuditLog.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SearchAuditLogs returns a page of the tenant audit logs, empty filters are ignored
+func SearchAuditLogs(tenantName, stationName, actor, search string, from, to time.Time, limit, offset int) ([]models.AuditLog, int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+	defer conn.Release()
+
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	filter := `WHERE tenant_name = $1
+		AND ($2 = '' OR station_name = $2)
+		AND ($3 = '' OR created_by_username = $3)
+		AND ($4 = '' OR message ILIKE '%' || $4 || '%')
+		AND created_at >= $5 AND created_at <= $6`
+
+	query := `SELECT COUNT(*) FROM audit_logs ` + filter
+	stmt, err := conn.Conn().Prepare(ctx, "count_search_audit_logs", query)
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+	var total int
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName, stationName, actor, search, from, to).Scan(&total)
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+
+	query = `SELECT * FROM audit_logs ` + filter + ` ORDER BY created_at DESC LIMIT $7 OFFSET $8`
+	stmt, err = conn.Conn().Prepare(ctx, "search_audit_logs", query)
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, stationName, actor, search, from, to, limit, offset)
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+	defer rows.Close()
+	auditLogs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AuditLog])
+	if err != nil {
+		return []models.AuditLog{}, 0, err
+	}
+	if len(auditLogs) == 0 {
+		return []models.AuditLog{}, total, nil
+	}
+	return auditLogs, total, nil
+}
+
+func GetAuditLogsByStation(name string, tenantName string) ([]models.AuditLog, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.AuditLog{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM audit_logs AS a
+		WHERE a.station_name = $1 AND a.tenant_name = $2
+		ORDER BY a.created_at DESC
+		LIMIT 1000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_audit_logs_by_station", query)
+	if err != nil {
+		return []models.AuditLog{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return []models.AuditLog{}, err
+	}
+	defer rows.Close()
+	auditLogs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AuditLog])
+	if err != nil {
+		return []models.AuditLog{}, err
+	}
+	if len(auditLogs) == 0 {
+		return []models.AuditLog{}, nil
+	}
+	return auditLogs, nil
+}
+
+func RemoveAllAuditLogsByStation(name string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	removeAuditLogs := `DELETE FROM audit_logs
+	WHERE station_name = $1 AND tenant_name = $2`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_station", removeAuditLogs)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+func UpdateAuditLogsOfDeletedUser(userId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE audit_logs SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)'`
+	stmt, err := conn.Conn().Prepare(ctx, "update_audit_logs_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, userId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveAuditLogsByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM audit_logs WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveAuditLogsByTenantAndCreatedAt(tenantName string, createdAt time.Time) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM audit_logs WHERE tenant_name = $1 AND created_at < $2`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_tenant_and_created_at", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName, createdAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Station Functions
+func GetActiveStationsPerTenant(tenantName string) ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations AS s WHERE (s.is_deleted = false) AND tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations_per_tenant", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, nil
+	}
+	return stations, nil
+}
+
+func GetActiveStations() ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations AS s WHERE s.is_deleted = false`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, nil
+	}
+	return stations, nil
+}
+
+func GetCompactedStations() ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE retention_type = 'compacted' AND is_deleted = false`
+	stmt, err := conn.Conn().Prepare(ctx, "get_compacted_stations", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	return stations, nil
+}
+
+func GetStationByName(name string, tenantName string) (bool, models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE name = $1 AND (is_deleted = false) AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_by_name", query)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return false, models.Station{}, nil
+	}
+	return true, stations[0], nil
+}
+
+func GetStationsByDlsStationName(name string, tenantName string) ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE dls_station = $1 AND (is_deleted = false) AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_stations_act_as_dls_station_by_name", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, nil
+	}
+	return stations, nil
+}
+
+func GetStationById(stationId int, tenantName string) (bool, models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE id = $1 AND (is_deleted = false) AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_by_id", query)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, tenantName)
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return false, models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return false, models.Station{}, nil
+	}
+	return true, stations[0], nil
+}
+
+func InsertNewStation(
+	stationName string,
+	userId int,
+	username string,
+	retentionType string,
+	retentionValue int,
+	storageType string,
+	replicas int,
+	schemaName string,
+	schemaVersionUpdate int,
+	idempotencyWindow int64,
+	isNative bool,
+	dlsConfiguration models.DlsConfiguration,
+	tieredStorageEnabled bool,
+	tenantName string,
+	partitionsList []int,
+	version int,
+	dlsStationName string) (models.Station, int64, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Station{}, 0, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO stations ( 
+		name, 
+		retention_type, 
+		retention_value,
+		storage_type, 
+		replicas, 
+		created_by, 
+		created_by_username,
+		created_at, 
+		updated_at, 
+		is_deleted, 
+		schema_name,
+		schema_version_number,
+		idempotency_window_ms, 
+		is_native, 
+		dls_configuration_poison, 
+		dls_configuration_schemaverse,
+		tiered_storage_enabled,
+		tenant_name,
+		partitions,
+		version,
+		dls_station,
+		owner_team
+		) 
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+		COALESCE((SELECT team FROM users WHERE username = $7 AND tenant_name = $18), '')) RETURNING id, owner_team`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_station", query)
+	if err != nil {
+		return models.Station{}, 0, err
+	}
+
+	createAt := time.Now()
+	updatedAt := time.Now()
+	var stationId int
+	var ownerTeam string
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name,
+		stationName, retentionType, retentionValue, storageType, replicas, userId, username, createAt, updatedAt,
+		false, schemaName, schemaVersionUpdate, idempotencyWindow, isNative, dlsConfiguration.Poison, dlsConfiguration.Schemaverse, tieredStorageEnabled, tenantName, partitionsList, version, dlsStationName)
+	if err != nil {
+		return models.Station{}, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&stationId, &ownerTeam)
+		if err != nil {
+			return models.Station{}, 0, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Station{}, 0, errors.New("Station" + stationName + " already exists")
+				} else {
+					return models.Station{}, 0, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.Station{}, 0, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Station{}, 0, err
+		}
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+
+	newStation := models.Station{
+		ID:                          stationId,
+		Name:                        stationName,
+		CreatedBy:                   userId,
+		CreatedByUsername:           username,
+		CreatedAt:                   createAt,
+		IsDeleted:                   false,
+		RetentionType:               retentionType,
+		RetentionValue:              retentionValue,
+		StorageType:                 storageType,
+		Replicas:                    replicas,
+		UpdatedAt:                   updatedAt,
+		SchemaName:                  schemaName,
+		SchemaVersionNumber:         schemaVersionUpdate,
+		IdempotencyWindow:           idempotencyWindow,
+		IsNative:                    isNative,
+		DlsConfigurationPoison:      dlsConfiguration.Poison,
+		DlsConfigurationSchemaverse: dlsConfiguration.Schemaverse,
+		TieredStorageEnabled:        tieredStorageEnabled,
+		TenantName:                  tenantName,
+		PartitionsList:              partitionsList,
+		Version:                     version,
+		DlsStation:                  dlsStationName,
+		OwnerTeam:                   ownerTeam,
+	}
+
+	rowsAffected := rows.CommandTag().RowsAffected()
+	return newStation, rowsAffected, nil
+}
+
+func GetAllStationsWithNoHA3() ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE replicas = 1 OR replicas = 5`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_with_no_ha_3", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, err
+	}
+	return stations, nil
+}
+
+func GetAllStationsDetailsPerTenant(tenantName string) ([]models.ExtendedStation, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	defer conn.Release()
+	query := `
+	SELECT s.*, COALESCE(p.id, 0),  
+	COALESCE(p.name, ''), 
+	COALESCE(p.station_id, 0), 
+	COALESCE(p.type, 'application'), 
+	COALESCE(p.connection_id, ''), 
+	COALESCE(p.is_active, false), 
+	COALESCE(p.updated_at, CURRENT_TIMESTAMP), 
+	COALESCE(c.id, 0),  
+	COALESCE(c.name, ''), 
+	COALESCE(c.station_id, 0), 
+	COALESCE(c.type, 'application'), 
+	COALESCE(c.connection_id, ''),
+	COALESCE(c.consumers_group, ''),
+	COALESCE(c.max_ack_time_ms, 0), 
+	COALESCE(c.is_active, false), 
+	COALESCE(c.updated_at, CURRENT_TIMESTAMP), 
+	COALESCE(c.max_msg_deliveries, 0), 
+	COALESCE(c.start_consume_from_seq, 0),
+	COALESCE(c.last_msgs, 0) 
+	FROM stations AS s
+	LEFT JOIN producers AS p
+	ON s.id = p.station_id 
+	LEFT JOIN consumers AS c 
+	ON s.id = c.station_id
+	WHERE s.is_deleted = false AND s.tenant_name = $1
+	GROUP BY s.id,p.id,c.id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details_per_tenant", query)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.ExtendedStation{}, nil
+	}
+	defer rows.Close()
+	stationsMap := map[int]models.ExtendedStation{}
+	for rows.Next() {
+		var stationRes models.Station
+		var producer models.Producer
+		var consumer models.Consumer
+		if err := rows.Scan(
+			&stationRes.ID,
+			&stationRes.Name,
+			&stationRes.RetentionType,
+			&stationRes.RetentionValue,
+			&stationRes.StorageType,
+			&stationRes.Replicas,
+			&stationRes.CreatedBy,
+			&stationRes.CreatedByUsername,
+			&stationRes.CreatedAt,
+			&stationRes.UpdatedAt,
+			&stationRes.IsDeleted,
+			&stationRes.SchemaName,
+			&stationRes.SchemaVersionNumber,
+			&stationRes.IdempotencyWindow,
+			&stationRes.IsNative,
+			&stationRes.DlsConfigurationPoison,
+			&stationRes.DlsConfigurationSchemaverse,
+			&stationRes.TieredStorageEnabled,
+			&stationRes.TenantName,
+			&stationRes.ResendDisabled,
+			&producer.ID,
+			&producer.Name,
+			&producer.StationId,
+			&producer.Type,
+			&producer.ConnectionId,
+			&producer.IsActive,
+			&producer.UpdatedAt,
+			&consumer.ID,
+			&consumer.Name,
+			&consumer.StationId,
+			&consumer.Type,
+			&consumer.ConnectionId,
+			&consumer.ConsumersGroup,
+			&consumer.MaxAckTimeMs,
+			&consumer.IsActive,
+			&consumer.UpdatedAt,
+			&consumer.MaxMsgDeliveries,
+			&consumer.StartConsumeFromSeq,
+			&consumer.LastMessages,
+		); err != nil {
+			return []models.ExtendedStation{}, err
+		}
+		if _, ok := stationsMap[stationRes.ID]; ok {
+			tempStation := stationsMap[stationRes.ID]
+			if producer.ID != 0 {
+				tempStation.Producers = append(tempStation.Producers, producer)
+			}
+			if consumer.ID != 0 {
+				tempStation.Consumers = append(tempStation.Consumers, consumer)
+			}
+			stationsMap[stationRes.ID] = tempStation
+		} else {
+			producers := []models.Producer{}
+			consumers := []models.Consumer{}
+			if producer.ID != 0 {
+				producers = append(producers, producer)
+			}
+			if consumer.ID != 0 {
+				consumers = append(consumers, consumer)
+			}
+			if tenantName != conf.GlobalAccount {
+				tenantName = strings.ToLower(tenantName)
+			}
+			station := models.ExtendedStation{
+				ID:                          stationRes.ID,
+				Name:                        stationRes.Name,
+				RetentionType:               stationRes.RetentionType,
+				RetentionValue:              stationRes.RetentionValue,
+				StorageType:                 stationRes.StorageType,
+				Replicas:                    stationRes.Replicas,
+				CreatedBy:                   stationRes.CreatedBy,
+				CreatedAt:                   stationRes.CreatedAt,
+				UpdatedAt:                   stationRes.UpdatedAt,
+				IdempotencyWindow:           stationRes.IdempotencyWindow,
+				IsNative:                    stationRes.IsNative,
+				DlsConfigurationPoison:      stationRes.DlsConfigurationPoison,
+				DlsConfigurationSchemaverse: stationRes.DlsConfigurationSchemaverse,
+				Producers:                   producers,
+				Consumers:                   consumers,
+				TieredStorageEnabled:        stationRes.TieredStorageEnabled,
+				TenantName:                  tenantName,
+			}
+			stationsMap[station.ID] = station
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	stations := getFilteredExtendedStations(stationsMap)
+	return stations, nil
+}
+
+func GetAllStationsDetailsLight(tenantName string) ([]models.ExtendedStationLight, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedStationLight{}, err
+	}
+	defer conn.Release()
+
+	query := `
+	SELECT s.*,
+	false AS is_active
+	FROM stations AS s
+	WHERE s.is_deleted = false AND s.tenant_name = $1
+	ORDER BY s.updated_at DESC
+	LIMIT 5000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details_for_main_overview", query)
+	if err != nil {
+		return []models.ExtendedStationLight{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ExtendedStationLight{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.ExtendedStationLight{}, nil
+	}
+	defer rows.Close()
+	stations := []models.ExtendedStationLight{}
+	for rows.Next() {
+		var stationRes models.ExtendedStationLight
+		if err := rows.Scan(
+			&stationRes.ID,
+			&stationRes.Name,
+			&stationRes.RetentionType,
+			&stationRes.RetentionValue,
+			&stationRes.StorageType,
+			&stationRes.Replicas,
+			&stationRes.CreatedBy,
+			&stationRes.CreatedByUsername,
+			&stationRes.CreatedAt,
+			&stationRes.UpdatedAt,
+			&stationRes.IsDeleted,
+			&stationRes.SchemaName,
+			&stationRes.SchemaVersionNumber,
+			&stationRes.IdempotencyWindow,
+			&stationRes.IsNative,
+			&stationRes.DlsConfigurationPoison,
+			&stationRes.DlsConfigurationSchemaverse,
+			&stationRes.TieredStorageEnabled,
+			&stationRes.TenantName,
+			&stationRes.ResendDisabled,
+			&stationRes.PartitionsList,
+			&stationRes.Version,
+			&stationRes.DlsStation,
+			&stationRes.FunctionsLockHeld,
+			&stationRes.FunctionsLockedAt,
+			&stationRes.MessageTransform,
+			&stationRes.AckRetentionLimitType,
+			&stationRes.AckRetentionLimitValue,
+			&stationRes.OwnerTeam,
+			&stationRes.Description,
+			&stationRes.SlaTier,
+			&stationRes.Labels,
+			&stationRes.ReadOnly,
+			&stationRes.Activity,
+		); err != nil {
+			return []models.ExtendedStationLight{}, err
+		}
+		stationRes.TenantName = tenantName
+		stations = append(stations, stationRes)
+	}
+	if err := rows.Err(); err != nil {
+		return []models.ExtendedStationLight{}, err
+	}
+	return stations, nil
+}
+
+func GetStationsLight(tenantName string) ([]models.StationLight, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.StationLight{}, err
+	}
+	defer conn.Release()
+
+	query := `
+	SELECT s.id, s.name, s.schema_name,
+	(SELECT COUNT(*) FROM dls_messages dm WHERE dm.station_id = s.id) AS dls_count
+	FROM stations AS s
+	WHERE s.is_deleted = false AND s.tenant_name = $1
+	ORDER BY s.updated_at DESC
+	LIMIT 40000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations_light", query)
+	if err != nil {
+		return []models.StationLight{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.StationLight{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.StationLight{}, nil
+	}
+	defer rows.Close()
+	stations := []models.StationLight{}
+	for rows.Next() {
+		var stationRes models.StationLight
+		if err := rows.Scan(
+			&stationRes.ID,
+			&stationRes.Name,
+			&stationRes.SchemaName,
+			&stationRes.DlsMsgs,
+		); err != nil {
+			return []models.StationLight{}, err
+		}
+		stations = append(stations, stationRes)
+	}
+	if err := rows.Err(); err != nil {
+		return []models.StationLight{}, err
+	}
+	return stations, nil
+}
+
+func GetAllStationsWithActiveProducersConsumersPerTenant(tenantName string) ([]models.ActiveProducersConsumersDetails, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ActiveProducersConsumersDetails{}, err
+	}
+	defer conn.Release()
+	query := `
+	SELECT s.id,
+			   COUNT(DISTINCT CASE WHEN p.is_active THEN p.id END) FILTER (WHERE p.is_active = true) AS active_producers_count,
+			   COUNT(DISTINCT CASE WHEN c.is_active THEN c.id END) FILTER (WHERE c.is_active = true) AS active_consumers_count
+		FROM stations AS s
+		LEFT JOIN producers AS p ON s.id = p.station_id AND p.is_active = true
+		LEFT JOIN consumers AS c ON s.id = c.station_id AND c.is_active = true
+		WHERE s.is_deleted = false AND s.tenant_name = $1
+	GROUP BY s.id;
+`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_producers_consumers_of_station", query)
+	if err != nil {
+		return []models.ActiveProducersConsumersDetails{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ActiveProducersConsumersDetails{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.ActiveProducersConsumersDetails{}, nil
+	}
+	defer rows.Close()
+	stations := []models.ActiveProducersConsumersDetails{}
+	for rows.Next() {
+		var stationId int
+		var activeProducersCount int
+		var activeConsumersCount int
+		if err := rows.Scan(
+			&stationId,
+			&activeProducersCount,
+			&activeConsumersCount,
+		); err != nil {
+			return []models.ActiveProducersConsumersDetails{}, err
+		}
+		station := models.ActiveProducersConsumersDetails{
+			ID:                   stationId,
+			ActiveProducersCount: activeProducersCount,
+			ActiveConsumersCount: activeConsumersCount,
+		}
+		stations = append(stations, station)
+	}
+	if err := rows.Err(); err != nil {
+		return []models.ActiveProducersConsumersDetails{}, err
+	}
+	return stations, nil
+}
+
+func GetAllStations() ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, err
+	}
+	return stations, nil
+}
+
+func CountStationsByTenant(tenantName string) (int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM stations where tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_count_stations_by_tenant", query)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetAllStationsDetails() ([]models.ExtendedStation, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	defer conn.Release()
+	query := `
+	SELECT s.*, COALESCE(p.id, 0),  
+	COALESCE(p.name, ''), 
+	COALESCE(p.station_id, 0), 
+	COALESCE(p.type, 'application'), 
+	COALESCE(p.connection_id, ''), 
+	COALESCE(p.created_by, 0), 
+	COALESCE(p.created_by_username, ''), 
+	COALESCE(p.is_active, false), 
+	COALESCE(p.created_at, CURRENT_TIMESTAMP), 
+	COALESCE(p.is_deleted, false),
+	COALESCE(p.tenant_name, ''),  
+	COALESCE(c.id, 0),  
+	COALESCE(c.name, ''), 
+	COALESCE(c.station_id, 0), 
+	COALESCE(c.type, 'application'), 
+	COALESCE(c.connection_id, ''),
+	COALESCE(c.consumers_group, ''),
+	COALESCE(c.max_ack_time_ms, 0), 
+	COALESCE(c.created_by, 0), 
+	COALESCE(c.created_by_username, ''), 
+	COALESCE(c.is_active, false), 
+	COALESCE(c.created_at, CURRENT_TIMESTAMP), 
+	COALESCE(c.is_deleted, false), 
+	COALESCE(c.max_msg_deliveries, 0), 
+	COALESCE(c.start_consume_from_seq, 0),
+	COALESCE(c.last_msgs, 0),
+	COALESCE(c.tenant_name, '') 
+	FROM stations AS s
+	LEFT JOIN producers AS p
+	ON s.id = p.station_id 
+	LEFT JOIN consumers AS c 
+	ON s.id = c.station_id
+	WHERE s.is_deleted = false
+	GROUP BY s.id,p.id,c.id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details", query)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.ExtendedStation{}, nil
+	}
+	defer rows.Close()
+	stationsMap := map[int]models.ExtendedStation{}
+	for rows.Next() {
+		var stationRes models.Station
+		var producer models.Producer
+		var consumer models.Consumer
+		if err := rows.Scan(
+			&stationRes.ID,
+			&stationRes.Name,
+			&stationRes.RetentionType,
+			&stationRes.RetentionValue,
+			&stationRes.StorageType,
+			&stationRes.Replicas,
+			&stationRes.CreatedBy,
+			&stationRes.CreatedByUsername,
+			&stationRes.CreatedAt,
+			&stationRes.UpdatedAt,
+			&stationRes.IsDeleted,
+			&stationRes.SchemaName,
+			&stationRes.SchemaVersionNumber,
+			&stationRes.IdempotencyWindow,
+			&stationRes.IsNative,
+			&stationRes.DlsConfigurationPoison,
+			&stationRes.DlsConfigurationSchemaverse,
+			&stationRes.TieredStorageEnabled,
+			&stationRes.TenantName,
+			&stationRes.FunctionsLockHeld,
+			&stationRes.FunctionsLockedAt,
+			&producer.ID,
+			&producer.Name,
+			&producer.StationId,
+			&producer.Type,
+			&producer.ConnectionId,
+			&producer.IsActive,
+			&producer.UpdatedAt,
+			&producer.TenantName,
+			&consumer.ID,
+			&consumer.Name,
+			&consumer.StationId,
+			&consumer.Type,
+			&consumer.ConnectionId,
+			&consumer.ConsumersGroup,
+			&consumer.MaxAckTimeMs,
+			&consumer.IsActive,
+			&consumer.UpdatedAt,
+			&consumer.MaxMsgDeliveries,
+			&consumer.StartConsumeFromSeq,
+			&consumer.LastMessages,
+			&consumer.TenantName,
+		); err != nil {
+			return []models.ExtendedStation{}, err
+		}
+		if _, ok := stationsMap[stationRes.ID]; ok {
+			tempStation := stationsMap[stationRes.ID]
+			if producer.ID != 0 {
+				tempStation.Producers = append(tempStation.Producers, producer)
+			}
+			if consumer.ID != 0 {
+				tempStation.Consumers = append(tempStation.Consumers, consumer)
+			}
+			stationsMap[stationRes.ID] = tempStation
+		} else {
+			producers := []models.Producer{}
+			consumers := []models.Consumer{}
+			if producer.ID != 0 {
+				producers = append(producers, producer)
+			}
+			if consumer.ID != 0 {
+				consumers = append(consumers, consumer)
+			}
+			station := models.ExtendedStation{
+				ID:                          stationRes.ID,
+				Name:                        stationRes.Name,
+				RetentionType:               stationRes.RetentionType,
+				RetentionValue:              stationRes.RetentionValue,
+				StorageType:                 stationRes.StorageType,
+				Replicas:                    stationRes.Replicas,
+				CreatedBy:                   stationRes.CreatedBy,
+				CreatedAt:                   stationRes.CreatedAt,
+				UpdatedAt:                   stationRes.UpdatedAt,
+				IdempotencyWindow:           stationRes.IdempotencyWindow,
+				IsNative:                    stationRes.IsNative,
+				DlsConfigurationPoison:      stationRes.DlsConfigurationPoison,
+				DlsConfigurationSchemaverse: stationRes.DlsConfigurationSchemaverse,
+				Producers:                   producers,
+				Consumers:                   consumers,
+				TieredStorageEnabled:        stationRes.TieredStorageEnabled,
+			}
+			stationsMap[station.ID] = station
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return []models.ExtendedStation{}, err
+	}
+	stations := getFilteredExtendedStations(stationsMap)
+	return stations, nil
+}
+
+func getFilteredExtendedStations(stationsMap map[int]models.ExtendedStation) []models.ExtendedStation {
+	stations := []models.ExtendedStation{}
+	for _, station := range stationsMap {
+		producersMap := map[string]models.Producer{}
+		for _, p := range station.Producers {
+			if _, ok := producersMap[p.Name]; ok {
+				if producersMap[p.Name].UpdatedAt.Before(p.UpdatedAt) {
+					producersMap[p.Name] = p
+				}
+			} else {
+				producersMap[p.Name] = p
+			}
+		}
+		producers := []models.Producer{}
+		for _, p := range producersMap {
+			producers = append(producers, p)
+		}
+
+		consumersMap := map[string]models.Consumer{}
+		for _, c := range station.Consumers {
+			if _, ok := consumersMap[c.Name]; ok {
+				if consumersMap[c.Name].UpdatedAt.Before(c.UpdatedAt) {
+					consumersMap[c.Name] = c
+				}
+			} else {
+				consumersMap[c.Name] = c
+			}
+		}
+		consumers := []models.Consumer{}
+		for _, c := range consumersMap {
+			consumers = append(consumers, c)
+		}
+		station.Consumers = consumers
+		station.Producers = producers
+		stations = append(stations, station)
+	}
+	return stations
+}
+
+func DeleteStationsByNames(stationNames []string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM stations
+	WHERE name = ANY($1)
+	AND (is_deleted = false)
+	AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_stations_by_names", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationNames, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveDeletedStations() error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM stations WHERE is_deleted = true`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_deleted_stations", query)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteStation(name string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM stations
+	WHERE name = $1
+	AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_station", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func AttachSchemaToStation(stationName string, schemaName string, versionNumber int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET schema_name = $2, schema_version_number = $3
+	WHERE name = $1 AND is_deleted = false AND tenant_name=$4`
+	stmt, err := conn.Conn().Prepare(ctx, "attach_schema_to_station", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, schemaName, versionNumber, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DetachSchemaFromStation(stationName string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET schema_name = '', schema_version_number = 0
+	WHERE name = $1 AND is_deleted = false AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "detach_schema_from_station", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateStationDlsConfig(stationName string, poison bool, schemaverse bool, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET dls_configuration_poison = $2, dls_configuration_schemaverse = $3
+	WHERE name = $1 AND is_deleted = false AND tenant_name=$4`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_dls_config", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, poison, schemaverse, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateStationMessageTransform(stationName string, transform string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET message_transform = $2 WHERE name = $1 AND is_deleted = false AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_message_transform", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, transform, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateStationAckRetentionLimit(stationName string, limitType string, limitValue int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET ack_retention_limit_type = $2, ack_retention_limit_value = $3, updated_at = NOW() WHERE name = $1 AND is_deleted = false AND tenant_name=$4`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_ack_retention_limit", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, limitType, limitValue, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateStationsOfDeletedUser(userId int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)' AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_stations_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateStationsWithNoHA3() error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET replicas = 3 WHERE replicas = 1 OR replicas = 5`
+	stmt, err := conn.Conn().Prepare(ctx, "update_stations_with_no_ha_3", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateResendDisabledInStations(resendDisabled bool, stationId []int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET resend_disabled = $1 WHERE id = ANY($2)`
+	stmt, err := conn.Conn().Prepare(ctx, "update_resend_disabled_in_stations", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, resendDisabled, stationId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveStationsByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM stations WHERE tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_stations_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetStationNamesUsingSchema(schemaName string, tenantName string) ([]string, error) {
+	stationNames := []string{}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT name FROM stations
+		WHERE schema_name = $1 AND is_deleted = false AND tenant_name = $2
+	`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_names_using_schema", query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaName, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stationName string
+		err := rows.Scan(&stationName)
+		if err != nil {
+			return nil, err
+		}
+		stationNames = append(stationNames, stationName)
+	}
+
+	if rows.Err() != nil {
+		return nil, err
+	}
+
+	return stationNames, nil
+
+}
+
+func GetCountStationsUsingSchema(schemaName string, tenantName string) (int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM stations WHERE schema_name = $1 AND is_deleted = false AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_count_stations_using_schema", query)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaName, tenantName).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func RemoveSchemaFromAllUsingStations(schemaName string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE stations SET schema_name = '' WHERE schema_name = $1 AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_from_all_using_stations", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, schemaName, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetDeletedStations() ([]models.Station, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM stations WHERE is_deleted = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_not_active_stations", query)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Station{}, err
+	}
+	defer rows.Close()
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	if err != nil {
+		return []models.Station{}, err
+	}
+	if len(stations) == 0 {
+		return []models.Station{}, err
+	}
+	return stations, nil
+}
+
+func UpdateStationsDls(stationNames []string, dlsName, tenantName string) error {
+	if len(stationNames) == 0 {
+		return nil
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	query := `UPDATE stations SET dls_station = $1 WHERE name = ANY($2) AND tenant_name=$3`
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	stmt, err := conn.Conn().Prepare(ctx, "update_stations_dls", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, dlsName, stationNames, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveDlsStationFromAllStations(name, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	query := `UPDATE stations SET dls_station = '' WHERE dls_station = $1 AND tenant_name=$2`
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	stmt, err := conn.Conn().Prepare(ctx, "remove_dls_station_from_all_stations", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Producer Functions
+func UpdateProducersActiveAndGetDetails(connectionId string, isActive bool) ([]models.LightProducer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.LightProducer{}, err
+	}
+	defer conn.Release()
+	query := `
+	WITH updated_producers AS (
+		UPDATE producers
+		SET is_active = $2
+		WHERE connection_id = $1 AND is_active = true
+		RETURNING *
+	)
+	SELECT DISTINCT ON (p.name) p.name, s.name, COUNT(*) OVER (PARTITION BY p.name)
+	FROM updated_producers AS p
+	LEFT JOIN stations AS s ON s.id = p.station_id
+	GROUP BY p.name, p.id, s.id
+	LIMIT 5000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producers_by_connection_id_with_station_details", query)
+	if err != nil {
+		return []models.LightProducer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, connectionId, isActive)
+	if err != nil {
+		return []models.LightProducer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightProducer])
+	if err != nil {
+		return []models.LightProducer{}, err
+	}
+	if len(producers) == 0 {
+		return []models.LightProducer{}, nil
+	}
+	return producers, nil
+}
+
+func UpdateProducersConnection(connectionId string, isActive bool) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE producers SET is_active = $1 WHERE connection_id = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_producers_connection", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetProducerByID(id int) (bool, models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM producers WHERE id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_id", query)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return false, models.Producer{}, nil
+	}
+	return true, producers[0], nil
+}
+
+func GetProducerByNameAndConnectionID(name string, connectionId string) (bool, models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM producers WHERE name = $1 AND connection_id = $2 AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_name_and_connection_id", query)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, connectionId)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return false, models.Producer{}, nil
+	}
+	return true, producers[0], nil
+}
+
+func GetProducerByStationIDAndConnectionId(name string, stationId int, connectionId string) (bool, models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND connection_id = $3 AND type = 'application' ORDER BY is_active DESC LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_station_id_and_connection_id", query)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId, connectionId)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return false, models.Producer{}, nil
+	}
+	return true, producers[0], nil
+}
+
+func GetProducerByNameAndStationID(name string, stationId int) (bool, models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND type = 'application' ORDER BY is_active DESC LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_name_and_station_id", query)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return false, models.Producer{}, nil
+	}
+	return true, producers[0], nil
+}
+
+func GetActiveProducerByStationID(producerName string, stationId int) (bool, models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer conn.Release()
+
+	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND is_active = true AND type = 'application' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_producer_by_station_id", query)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, producerName, stationId)
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return false, models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return false, models.Producer{}, nil
+	}
+	return true, producers[0], nil
+}
+
+func GetProducersForGraph(tenantName string) ([]models.ProducerForGraph, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ProducerForGraph{}, err
+	}
+	defer conn.Release()
+	query := `SELECT p.name, p.station_id, p.app_id, p.type
+				FROM producers AS p
+				WHERE p.tenant_name = $1 AND p.is_active = true
+				ORDER BY p.name, p.station_id DESC
+				LIMIT 10000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producers_for_graph", query)
+	if err != nil {
+		return []models.ProducerForGraph{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ProducerForGraph{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ProducerForGraph])
+	if err != nil {
+		return []models.ProducerForGraph{}, err
+	}
+	if len(producers) == 0 {
+		return []models.ProducerForGraph{}, nil
+	}
+	return producers, nil
+}
+
+// upsertProducerByClientId updates the record of a reconnecting client, its owner and team are kept
+const upsertProducerByClientId = `ON CONFLICT (station_id, client_id, name) WHERE client_id <> '' DO UPDATE SET
+		connection_id = EXCLUDED.connection_id,
+		is_active = EXCLUDED.is_active,
+		updated_at = EXCLUDED.updated_at,
+		type = EXCLUDED.type,
+		partitions = EXCLUDED.partitions,
+		version = EXCLUDED.version,
+		sdk = EXCLUDED.sdk,
+		app_id = EXCLUDED.app_id`
+
+// InsertNewProducer creates a producer, a producer with a client id replaces the record of the same client and name at the
+// station so a reconnecting sdk keeps its producer with the new connection id
+func InsertNewProducer(name string, stationId int, producerType string, connectionIdObj string, tenantName string, partitionsList []int, version int, sdk string, appId string, owner string, clientId string) (models.Producer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Producer{}, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO producers ( 
+		name, 
+		station_id, 
+		connection_id,
+		is_active, 
+		updated_at, 
+		type,
+		tenant_name,
+		partitions,
+		version,
+		sdk,
+		app_id,
+		owner,
+		owner_team,
+		client_id) 
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+		COALESCE((SELECT team FROM users WHERE username = $12 AND tenant_name = $7), ''), $13)
+	` + upsertProducerByClientId + ` RETURNING id, owner, owner_team`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_producer", query)
+	if err != nil {
+		return models.Producer{}, err
+	}
+
+	var producerId int
+	var ownerTeam string
+	updatedAt := time.Now()
+	isActive := true
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId, connectionIdObj, isActive, updatedAt, producerType, tenantName, partitionsList, version, sdk, appId, owner, clientId)
+	if err != nil {
+		return models.Producer{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&producerId, &owner, &ownerTeam)
+		if err != nil {
+			return models.Producer{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Producer{}, err
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				return models.Producer{}, errors.New(pgErr.Detail)
+			} else {
+				return models.Producer{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Producer{}, err
+		}
+	}
+
+	newProducer := models.Producer{
+		ID:             producerId,
+		Name:           name,
+		StationId:      stationId,
+		Type:           producerType,
+		ConnectionId:   connectionIdObj,
+		IsActive:       isActive,
+		UpdatedAt:      time.Now(),
+		PartitionsList: partitionsList,
+		Owner:          owner,
+		OwnerTeam:      ownerTeam,
+		ClientId:       clientId,
+	}
+	return newProducer, nil
+}
+
+func GetNotDeletedProducersByStationID(stationId int) ([]models.Producer, error) { // TODO: check if not needed - I think its not used
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	defer conn.Release()
+
+	query := `SELECT * FROM producers AS p WHERE p.station_id = $1 AND p.is_deleted = false AND p.type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "get_not_deleted_producers_by_station_id", query)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	defer rows.Close()
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	if len(producers) == 0 {
+		return []models.Producer{}, nil
+	}
+	return producers, nil
+}
+func GetAllProducersByStationID(stationId int) ([]models.ExtendedProducer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedProducer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT
+    p.id,
+    p.name,
+    p.type,
+    p.connection_id,
+    p.updated_at,
+    s.name,
+    p.is_active,
+	COUNT(CASE WHEN p.is_active THEN 1 END) OVER (PARTITION BY p.name) AS connected_producers_count,
+	COUNT(CASE WHEN NOT p.is_active THEN 1 END) OVER (PARTITION BY p.name) AS disconnected_producers_count,
+	p.version,
+	p.sdk
+FROM producers AS p
+LEFT JOIN stations AS s ON s.id = p.station_id
+WHERE p.station_id = $1 AND p.type = 'application'
+ORDER BY p.name, p.is_active DESC, p.updated_at DESC
+LIMIT 5000;
+`
+	stmt, err := conn.Conn().Prepare(ctx, "get_producers_by_station_id", query)
+	if err != nil {
+		return []models.ExtendedProducer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return []models.ExtendedProducer{}, err
+	}
+	defer rows.Close()
+
+	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ExtendedProducer])
+	if err != nil {
+		return []models.ExtendedProducer{}, err
+	}
+	if len(producers) == 0 {
+		return []models.ExtendedProducer{}, nil
+	}
+	return producers, nil
+}
+
+func DeleteProducerByNameAndStationID(name string, stationId int) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND type = 'application' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
+	if err != nil {
+		return false, err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func DeleteConnectorProducerByNameAndStationID(name string, stationId int) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND type = 'connector' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
+	if err != nil {
+		return false, err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// InsertNewProducers inserts the producers in a single transaction, either all of them are created or none
+func InsertNewProducers(producers []models.Producer) ([]models.Producer, error) {
+	if len(producers) == 0 {
+		return producers, nil
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO producers (
+		name,
+		station_id,
+		connection_id,
+		is_active,
+		updated_at,
+		type,
+		tenant_name,
+		partitions,
+		version,
+		sdk,
+		app_id,
+		owner,
+		owner_team,
+		client_id)
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+		COALESCE((SELECT team FROM users WHERE username = $12 AND tenant_name = $7), ''), $13)
+	` + upsertProducerByClientId + ` RETURNING id, owner, owner_team`
+
+	updatedAt := time.Now()
+	batch := &pgx.Batch{}
+	for i := range producers {
+		if producers[i].TenantName != conf.GlobalAccount {
+			producers[i].TenantName = strings.ToLower(producers[i].TenantName)
+		}
+		producers[i].IsActive = true
+		producers[i].UpdatedAt = updatedAt
+		p := producers[i]
+		batch.Queue(query, p.Name, p.StationId, p.ConnectionId, p.IsActive, p.UpdatedAt, p.Type, p.TenantName, p.PartitionsList, p.Version, p.Sdk, p.AppId, p.Owner, p.ClientId)
+	}
+
+	br := tx.SendBatch(ctx, batch)
+	for i := range producers {
+		err = br.QueryRow().Scan(&producers[i].ID, &producers[i].Owner, &producers[i].OwnerTeam)
+		if err != nil {
+			br.Close()
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				if pgErr.Detail != "" {
+					return []models.Producer{}, errors.New(pgErr.Detail)
+				}
+				return []models.Producer{}, errors.New(pgErr.Message)
+			}
+			return []models.Producer{}, err
+		}
+	}
+	err = br.Close()
+	if err != nil {
+		return []models.Producer{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []models.Producer{}, err
+	}
+	return producers, nil
+}
+
+func DeleteProducerByNameStationIDAndConnID(name string, stationId int, connId string) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND connection_id = $3
+	AND EXISTS (
+		SELECT 1 FROM producers
+		WHERE name = $1 AND station_id = $2 AND connection_id = $3
+		FETCH FIRST 1 ROW ONLY
+	);`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
+	if err != nil {
+		return false, err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId, connId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteProducersByNamesStationIDAndConnID deletes the producers of a connection at a station and returns the names which were deleted
+func DeleteProducersByNamesStationIDAndConnID(names []string, stationId int, connId string) ([]string, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE name = ANY($1) AND station_id = $2 AND connection_id = $3 RETURNING name`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producers_by_names_station_id_and_conn_id", query)
+	if err != nil {
+		return []string{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, names, stationId, connId)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+	deletedNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return []string{}, err
+	}
+	return deletedNames, nil
+}
+
+func DeleteProducersByStationID(stationId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE station_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producers_by_station_id", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func CountActiveProudcersByStationID(stationId int) (int64, error) {
+	var activeCount int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM producers WHERE station_id = $1 AND is_active = true AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "count_active_producers_by_station_id", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&activeCount)
+	if err != nil {
+		return 0, err
+	}
+
+	return activeCount, nil
+}
+
+func CountAllActiveProudcers() (int64, error) {
+	var producersCount int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM producers WHERE is_active = true AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "count_all_active_producers", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&producersCount)
+	if err != nil {
+		return 0, err
+	}
+
+	return producersCount, nil
+}
+
+func UpdateProducersOfDeletedUser(username string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE producers SET owner = CONCAT(owner, '(deleted)') WHERE owner = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_producers_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveProducersByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM producers WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_producers_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func KillProducersByConnections(connectionIds []string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE producers SET is_active = false WHERE connection_id = ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "kill_producers_by_connections", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, connectionIds)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetActiveClientsByConnections lists the active producers and consumers opened through the given connections
+func GetActiveClientsByConnections(connectionIds []string) ([]models.ConnectionClient, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ConnectionClient{}, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT 'producer', p.name, s.name, p.tenant_name, p.connection_id
+		FROM producers AS p
+		JOIN stations AS s ON s.id = p.station_id
+		WHERE p.is_active = true AND p.connection_id = ANY($1)
+		UNION ALL
+		SELECT 'consumer', c.name, s.name, c.tenant_name, c.connection_id
+		FROM consumers AS c
+		JOIN stations AS s ON s.id = c.station_id
+		WHERE c.is_active = true AND c.connection_id = ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_clients_by_connections", query)
+	if err != nil {
+		return []models.ConnectionClient{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, connectionIds)
+	if err != nil {
+		return []models.ConnectionClient{}, err
+	}
+	defer rows.Close()
+	clients, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConnectionClient])
+	if err != nil {
+		return []models.ConnectionClient{}, err
+	}
+	if len(clients) == 0 {
+		return []models.ConnectionClient{}, nil
+	}
+	return clients, nil
+}
+
+// Consumer Functions
+func GetActiveConsumerByCG(consumersGroup string, stationId int) (bool, models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer conn.Release()
+
+	query := `SELECT * FROM consumers WHERE consumers_group = $1 AND station_id = $2 AND type = 'application' AND is_active = true LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumer_by_cg", query)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, consumersGroup, stationId)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return false, models.Consumer{}, nil
+	}
+	return true, consumers[0], nil
+}
+
+// InsertNewConsumer creates a consumer, like producers a consumer with a client id replaces the record of the same
+// client at the station
+func InsertNewConsumer(name string,
+	stationId int,
+	consumerType string,
+	connectionIdObj string,
+	cgName string,
+	maxAckTime int,
+	maxMsgDeliveries int,
+	startConsumeFromSequence uint64,
+	lastMessages int64,
+	tenantName string,
+	partitionsList []int,
+	version int, sdk string, appId string, clientId string) (models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Consumer{}, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO consumers ( 
+		name, 
+		station_id,
+		connection_id,
+		consumers_group,
+		max_ack_time_ms,
+		is_active, 
+		updated_at,
+		max_msg_deliveries,
+		start_consume_from_seq,
+		last_msgs,
+		type,
+		tenant_name, 
+		partitions,
+		version,
+		sdk,
+		app_id,
+		client_id)
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	ON CONFLICT (station_id, client_id, name) WHERE client_id <> '' DO UPDATE SET
+		connection_id = EXCLUDED.connection_id,
+		consumers_group = EXCLUDED.consumers_group,
+		max_ack_time_ms = EXCLUDED.max_ack_time_ms,
+		is_active = EXCLUDED.is_active,
+		updated_at = EXCLUDED.updated_at,
+		max_msg_deliveries = EXCLUDED.max_msg_deliveries,
+		start_consume_from_seq = EXCLUDED.start_consume_from_seq,
+		last_msgs = EXCLUDED.last_msgs,
+		type = EXCLUDED.type,
+		partitions = EXCLUDED.partitions,
+		version = EXCLUDED.version,
+		sdk = EXCLUDED.sdk,
+		app_id = EXCLUDED.app_id
+	RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_consumer", query)
+	if err != nil {
+		return models.Consumer{}, err
+	}
+
+	var consumerId int
+	updatedAt := time.Now()
+	isActive := true
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name,
+		name, stationId, connectionIdObj, cgName, maxAckTime, isActive, updatedAt, maxMsgDeliveries, startConsumeFromSequence, lastMessages, consumerType, tenantName, partitionsList, version, sdk, appId, clientId)
+	if err != nil {
+		return models.Consumer{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&consumerId)
+		if err != nil {
+			return models.Consumer{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Consumer{}, err
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				return models.Consumer{}, errors.New(pgErr.Detail)
+			} else {
+				return models.Consumer{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Consumer{}, err
+		}
+	}
+
+	newConsumer := models.Consumer{
+		ID:                  consumerId,
+		Name:                name,
+		StationId:           stationId,
+		Type:                consumerType,
+		ConnectionId:        connectionIdObj,
+		ConsumersGroup:      cgName,
+		IsActive:            isActive,
+		UpdatedAt:           time.Now(),
+		MaxAckTimeMs:        int64(maxAckTime),
+		MaxMsgDeliveries:    maxMsgDeliveries,
+		StartConsumeFromSeq: startConsumeFromSequence,
+		LastMessages:        lastMessages,
+		TenantName:          tenantName,
+		PartitionsList:      partitionsList,
+		ClientId:            clientId,
+	}
+	return newConsumer, nil
+}
+
+func GetConsumers() ([]models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * From consumers AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "get_consumers", query)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return []models.Consumer{}, err
+	}
+	return consumers, nil
+}
+
+func GetAllConsumersByStation(stationId int) ([]models.ExtendedConsumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedConsumer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT DISTINCT ON (c.name, c.consumers_group) c.id, c.name, c.updated_at, c.is_active, c.consumers_group, c.max_ack_time_ms, c.max_msg_deliveries, s.name, c.partitions,
+				COUNT (CASE WHEN c.is_active THEN 1 END) OVER (PARTITION BY c.name) AS count, c.version, c.sdk
+				FROM consumers AS c
+				LEFT JOIN stations AS s ON s.id = c.station_id
+				WHERE c.station_id = $1 AND c.type = 'application' ORDER BY c.name, c.consumers_group, c.updated_at DESC
+				LIMIT 5000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_consumers_by_station", query)
+	if err != nil {
+		return []models.ExtendedConsumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return []models.ExtendedConsumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ExtendedConsumer])
+	if err != nil {
+		return []models.ExtendedConsumer{}, err
+	}
+	if len(consumers) == 0 {
+		return []models.ExtendedConsumer{}, nil
+	}
+	return consumers, nil
+}
+
+func GetConsumersForGraph(tenantName string) ([]models.ConsumerForGraph, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ConsumerForGraph{}, err
+	}
+	defer conn.Release()
+	query := `SELECT c.name, c.consumers_group, c.station_id, c.app_id, c.type
+				FROM consumers AS c
+				WHERE c.tenant_name = $1 AND c.is_active = true
+				ORDER BY c.name, c.station_id DESC
+				LIMIT 10000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_consumers_for_graph", query)
+	if err != nil {
+		return []models.ConsumerForGraph{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ConsumerForGraph{}, err
+	}
+	defer rows.Close()
+	cgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConsumerForGraph])
+	if err != nil {
+		return []models.ConsumerForGraph{}, err
+	}
+	if len(cgs) == 0 {
+		return []models.ConsumerForGraph{}, nil
+	}
+	return cgs, nil
+}
+
+func DeleteConsumerByNameStationIDAndConnID(connectionId, name string, stationId int) (bool, models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE connection_id = $1 AND name = $2 AND station_id = $3 LIMIT 1) RETURNING *`
+	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, connectionId, name, stationId)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return false, models.Consumer{}, err
+	}
+	return true, consumers[0], nil
+}
+
+// DeleteConsumersByNamesStationIDAndConnID deletes a single consumer per name of a connection at a station
+func DeleteConsumersByNamesStationIDAndConnID(connectionId string, names []string, stationId int) ([]models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM consumers WHERE ctid IN (
+		SELECT DISTINCT ON (name) ctid FROM consumers WHERE connection_id = $1 AND name = ANY($2) AND station_id = $3
+	) RETURNING *`
+	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers_by_names", query)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, connectionId, names, stationId)
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return []models.Consumer{}, err
+	}
+	return consumers, nil
+}
+
+func DeleteConsumerByNameStationIDAndType(consumerType, name string, stationId int) (bool, models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE type = $1 AND name = $2 AND station_id = $3 LIMIT 1) RETURNING *`
+	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, consumerType, name, stationId)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return false, models.Consumer{}, err
+	}
+	return true, consumers[0], nil
+}
+
+func DeleteConsumerByNameAndStationId(name string, stationId int) (bool, models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE name = $1 AND station_id = $2 LIMIT 1) RETURNING *`
+	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, name, stationId)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return false, models.Consumer{}, err
+	}
+	return true, consumers[0], nil
+}
+
+func DeleteAllConsumersByStationID(stationId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM consumers WHERE station_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_consumers_by_station_id", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteDLSMessagesByStationID(stationId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM dls_messages WHERE station_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_messages_from_chosen_dls", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func CountActiveConsumersInCG(consumersGroup string, stationId int) (int64, error) {
+	var count int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM consumers WHERE station_id = $1 AND consumers_group = $2 AND is_active = true`
+	stmt, err := conn.Conn().Prepare(ctx, "count_active_consumers_in_cg", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId, consumersGroup).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetActiveStationIds returns the stations of the tenant which have an active application producer or consumer
+func GetActiveStationIds(tenantName string) (map[int]bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `SELECT station_id FROM producers WHERE tenant_name = $1 AND is_active = true AND type = 'application'
+	UNION SELECT station_id FROM consumers WHERE tenant_name = $1 AND is_active = true AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_station_ids", query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stationIds := map[int]bool{}
+	for rows.Next() {
+		var stationId int
+		err := rows.Scan(&stationId)
+		if err != nil {
+			return nil, err
+		}
+		stationIds[stationId] = true
+	}
+	return stationIds, rows.Err()
+}
+
+func CountActiveConsumersByStationID(stationId int) (int64, error) {
+	var activeCount int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM consumers WHERE station_id = $1 AND is_active = true AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "count_active_consumers_by_station_id", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&activeCount)
+	if err != nil {
+		return 0, err
+	}
+
+	return activeCount, nil
+}
+
+func CountAllActiveConsumers() (int64, error) {
+	var consumersCount int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM consumers WHERE is_active = true AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "count_all_active_consumers", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&consumersCount)
+	if err != nil {
+		return 0, err
+	}
+
+	return consumersCount, nil
+}
+
+func GetConsumerGroupMembers(cgName string, stationId int) ([]models.CgMember, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.CgMember{}, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT
+			c.name,
+			c.connection_id,
+			c.is_active,
+			c.max_msg_deliveries,
+			c.max_ack_time_ms,
+			c.partitions,
+			COUNT (CASE WHEN c.is_active THEN 1 END) OVER (PARTITION BY c.name) AS count
+		FROM
+			consumers AS c
+		WHERE
+			c.consumers_group = $1
+			AND c.station_id = $2
+		ORDER BY
+			c.name, c.updated_at DESC
+	`
+	stmt, err := conn.Conn().Prepare(ctx, "get_consumer_group_members", query)
+	if err != nil {
+		return []models.CgMember{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, cgName, stationId)
+	if err != nil {
+		return []models.CgMember{}, err
+	}
+	defer rows.Close()
+
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CgMember])
+	if err != nil {
+		return []models.CgMember{}, err
+	}
+	if len(consumers) == 0 {
+		return []models.CgMember{}, nil
+	}
+	return consumers, nil
+}
+
+func UpdateCosnumersActiveAndGetDetails(connectionId string, isActive bool) ([]models.LightConsumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	defer conn.Release()
+	query := `
+	WITH updated_consumers AS (
+		UPDATE consumers
+		SET is_active = $2
+		WHERE connection_id = $1 AND is_active = true
+		RETURNING *
+	)
+	SELECT DISTINCT ON (c.name) c.name, s.name, COUNT(*) OVER (PARTITION BY c.name)
+	FROM updated_consumers AS c
+	LEFT JOIN stations AS s ON s.id = c.station_id
+	GROUP BY c.name, c.id, s.id
+	LIMIT 5000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_consumers_by_connection_id_with_station_details", query)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, connectionId, isActive)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightConsumer])
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	if len(consumers) == 0 {
+		return []models.LightConsumer{}, nil
+	}
+	return consumers, nil
+}
+
+func GetActiveConsumerByStationID(consumerName string, stationId int) (bool, models.Consumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM consumers WHERE name = $1 AND station_id = $2 AND is_active = true AND type = 'application' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumer_by_station_id", query)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, consumerName, stationId)
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	if err != nil {
+		return false, models.Consumer{}, err
+	}
+	if len(consumers) == 0 {
+		return false, models.Consumer{}, nil
+	}
+	return true, consumers[0], nil
+}
+
+func UpdateConsumersConnection(connectionId string, isActive bool) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE consumers SET is_active = $1 WHERE connection_id = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_consumers_connection", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateConsumersOfDeletedUser(userId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE consumers SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)'`
+	stmt, err := conn.Conn().Prepare(ctx, "update_consumers_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, userId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveConsumersByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM consumers WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_consumers_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func KillConsumersByConnections(connectionIds []string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE consumers SET is_active = false WHERE connection_id = ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "kill_consumers_by_connections", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, connectionIds)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetActiveCgsByName(names []string, tenantName string) ([]models.LightConsumer, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT c.consumers_group, s.name, COUNT(*)
+		FROM consumers AS c
+		LEFT JOIN stations AS s ON s.id = c.station_id
+		WHERE c.tenant_name = $1 AND c.consumers_group = ANY($2) AND c.is_active = true AND c.type = 'application'
+		GROUP BY c.consumers_group, s.name, c.station_id;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumers_by_name", query)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, names)
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	defer rows.Close()
+	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightConsumer])
+	if err != nil {
+		return []models.LightConsumer{}, err
+	}
+	if len(consumers) == 0 {
+		return []models.LightConsumer{}, nil
+	}
+	return consumers, nil
+}
+
+// Schema Functions
+func GetSchemaByName(name string, tenantName string) (bool, models.Schema, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	defer conn.Release()
+	// a renamed schema is still found by its old names, a schema which carries the name itself wins
+	query := `SELECT * FROM schemas WHERE tenant_name = $2 AND (name = $1 OR id = (SELECT schema_id FROM schema_aliases WHERE name = $1 AND tenant_name = $2))
+	ORDER BY name = $1 DESC LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_by_name", query)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	defer rows.Close()
+	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Schema])
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	if len(schemas) == 0 {
+		return false, models.Schema{}, nil
+	}
+	return true, schemas[0], nil
+}
+
+func GetSchemaByID(id int, tenantName string) (bool, models.Schema, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM schemas WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_by_id", query)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	defer rows.Close()
+	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Schema])
+	if err != nil {
+		return false, models.Schema{}, err
+	}
+	if len(schemas) == 0 {
+		return false, models.Schema{}, nil
+	}
+	return true, schemas[0], nil
+}
+
+// GetSchemaVersionByName fetches a schema version in a single query, version number 0 stands for the active version
+func GetSchemaVersionByName(schemaName string, versionNumber int, tenantName string) (bool, models.SchemaVersionDetails, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.SchemaVersionDetails{}, err
+	}
+	defer conn.Release()
+	query := `SELECT s.name, s.type, sv.id, sv.version_number, sv.active, sv.created_by, sv.created_by_username, sv.created_at,
+		sv.schema_content, sv.schema_id, sv.msg_struct_name, sv.descriptor, sv.tenant_name
+	FROM schemas AS s
+	INNER JOIN schema_versions AS sv ON sv.schema_id = s.id
+	WHERE s.name = $1 AND s.tenant_name = $2 AND (($3 = 0 AND sv.active = true) OR sv.version_number = $3)
+	LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_version_by_name", query)
+	if err != nil {
+		return false, models.SchemaVersionDetails{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	var details models.SchemaVersionDetails
+	v := &details.Version
+	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaName, tenantName, versionNumber).Scan(&details.SchemaName, &details.Type, &v.ID, &v.VersionNumber, &v.Active,
+		&v.CreatedBy, &v.CreatedByUsername, &v.CreatedAt, &v.SchemaContent, &v.SchemaId, &v.MessageStructName, &v.Descriptor, &v.TenantName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, models.SchemaVersionDetails{}, nil
+		}
+		return false, models.SchemaVersionDetails{}, err
+	}
+	return true, details, nil
+}
+
+func GetSchemaVersionsBySchemaID(id int) ([]models.SchemaVersion, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.SchemaVersion{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM schema_versions WHERE schema_id=$1 ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_versions_by_schema_id", query)
+	if err != nil {
+		cancelfunc()
+		return []models.SchemaVersion{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return []models.SchemaVersion{}, err
+	}
+	defer rows.Close()
+	schemaVersionsRes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
+	if err != nil {
+		return []models.SchemaVersion{}, err
+	}
+	if len(schemaVersionsRes) == 0 {
+		return []models.SchemaVersion{}, nil
+	}
+	schemaVersions := []models.SchemaVersion{}
+	for _, v := range schemaVersionsRes {
+		version := models.SchemaVersion{
+			ID:                v.ID,
+			VersionNumber:     v.VersionNumber,
+			Active:            v.Active,
+			CreatedBy:         v.CreatedBy,
+			CreatedByUsername: v.CreatedByUsername,
+			CreatedAt:         v.CreatedAt,
+			SchemaContent:     v.SchemaContent,
+			SchemaId:          v.SchemaId,
+			MessageStructName: v.MessageStructName,
+			Descriptor:        string(v.Descriptor),
+			TenantName:        strings.ToLower(v.TenantName),
+		}
+
+		schemaVersions = append(schemaVersions, version)
+	}
+	return schemaVersions, nil
+}
+
+func GetActiveVersionBySchemaID(id int) (models.SchemaVersion, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.SchemaVersion{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM schema_versions WHERE schema_id=$1 AND active=true LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_version_by_schema_id", query)
+	if err != nil {
+		return models.SchemaVersion{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return models.SchemaVersion{}, err
+	}
+	defer rows.Close()
+	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
+	if err != nil {
+		return models.SchemaVersion{}, err
+	}
+	if len(schemas) == 0 {
+		return models.SchemaVersion{}, nil
+	}
+	schemaVersion := models.SchemaVersion{
+		ID:                schemas[0].ID,
+		VersionNumber:     schemas[0].VersionNumber,
+		Active:            schemas[0].Active,
+		CreatedBy:         schemas[0].CreatedBy,
+		CreatedByUsername: schemas[0].CreatedByUsername,
+		CreatedAt:         schemas[0].CreatedAt,
+		SchemaContent:     schemas[0].SchemaContent,
+		SchemaId:          schemas[0].SchemaId,
+		MessageStructName: schemas[0].MessageStructName,
+		Descriptor:        string(schemas[0].Descriptor),
+		TenantName:        strings.ToLower(schemas[0].TenantName),
+	}
+
+	return schemaVersion, nil
+}
+
+func UpdateSchemasOfDeletedUser(userId int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := ` UPDATE schemas
+	SET created_by_username = CONCAT(created_by_username, '(deleted)')
+	WHERE created_by_username = (
+		SELECT username FROM users WHERE id = $1
+	)
+	AND created_by_username NOT LIKE '%(deleted)'
+	AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_schemas_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveSchemasByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM schemas WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_schemas_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateSchemaVersionsOfDeletedUser(userId int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := ` UPDATE schema_versions
+	SET created_by_username = CONCAT(created_by_username, '(deleted)')
+	WHERE created_by_username = (
+		SELECT username FROM users WHERE id = $1
+	)
+	AND created_by_username NOT LIKE '%(deleted)'
+	AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_schema_versions_of_deleted_user", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveSchemaVersionsByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	query := `DELETE FROM schema_versions WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_versions_by_tenant", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetSchemaVersionByNumberAndID(version int, schemaId int) (bool, models.SchemaVersion, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.SchemaVersion{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM schema_versions WHERE schema_id=$1 AND version_number=$2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_version_by_number_and_id", query)
+	if err != nil {
+		return false, models.SchemaVersion{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaId, version)
+	if err != nil {
+		return false, models.SchemaVersion{}, err
+	}
+	defer rows.Close()
+	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
+	if err != nil {
+		return false, models.SchemaVersion{}, err
+	}
+	if len(schemas) == 0 {
+		return false, models.SchemaVersion{}, nil
+	}
+	schemaVersion := models.SchemaVersion{
+		ID:                schemas[0].ID,
+		VersionNumber:     schemas[0].VersionNumber,
+		Active:            schemas[0].Active,
+		CreatedBy:         schemas[0].CreatedBy,
+		CreatedByUsername: schemas[0].CreatedByUsername,
+		CreatedAt:         schemas[0].CreatedAt,
+		SchemaContent:     schemas[0].SchemaContent,
+		SchemaId:          schemas[0].SchemaId,
+		MessageStructName: schemas[0].MessageStructName,
+		Descriptor:        string(schemas[0].Descriptor),
+		TenantName:        strings.ToLower(schemas[0].TenantName),
+	}
+	return true, schemaVersion, nil
+}
+
+// UpdateSchemaActiveVersion makes versionNumber the only active version of the schema. The schema row is locked for
+// the duration of the switch so concurrent activations run one after the other, and a non zero expectedActiveVersion
+// fails the switch with ErrSchemaActiveVersionConflict when another writer moved the active version first
+func UpdateSchemaActiveVersion(schemaId int, versionNumber int, expectedActiveVersion int) error {
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT id FROM schemas WHERE id = $1 FOR UPDATE`, schemaId)
+		if err != nil {
+			return err
+		}
+		var activeVersion int
+		var versionExists bool
+		err = tx.QueryRow(ctx, `SELECT COALESCE(MAX(version_number) FILTER (WHERE active = true), 0), COALESCE(BOOL_OR(version_number = $2), false)
+			FROM schema_versions WHERE schema_id = $1`, schemaId, versionNumber).Scan(&activeVersion, &versionExists)
+		if err != nil {
+			return err
+		}
+		if !versionExists {
+			return ErrSchemaVersionNotFound
+		}
+		if expectedActiveVersion != 0 && activeVersion != expectedActiveVersion {
+			return ErrSchemaActiveVersionConflict
+		}
+		_, err = tx.Exec(ctx, `UPDATE schema_versions SET active = (version_number = $2)
+			WHERE schema_id = $1 AND (active = true OR version_number = $2)`, schemaId, versionNumber)
+		return err
+	})
+}
+
+// GetLastSchemaVersionNumber returns the highest version number allocated for the schema, counting rows would
+// hand out a taken number once versions are deleted
+func GetLastSchemaVersionNumber(schemaId int, tenantName string) (int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COALESCE(MAX(version_number), 0) FROM schema_versions WHERE schema_id=$1 AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_last_schema_version_number", query)
+	if err != nil {
+		return 0, err
+	}
+	var versionNumber int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaId, tenantName).Scan(&versionNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	return versionNumber, nil
+}
+
+func GetShcemaVersionsCount(schemaId int, tenantName string) (int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM schema_versions WHERE schema_id=$1 AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_versions_count", query)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaId, tenantName).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func GetAllSchemasDetails(tenantName string) ([]models.ExtendedSchema, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ExtendedSchema{}, err
+	}
+	defer conn.Release()
+	// the usage and tags of all the schemas are aggregated once per tenant and joined in,
+	// so the cost of the query does not grow with a lookup per schema
+	query := `WITH schema_usage AS (
+	              SELECT schema_name, COUNT(*) AS stations_count FROM stations
+	              WHERE tenant_name = $1 AND is_deleted = false AND schema_name <> ''
+	              GROUP BY schema_name
+	          ), schema_tags AS (
+	              SELECT e.id, json_agg(json_build_object('name', t.name, 'color', t.color) ORDER BY t.name) AS tags
+	              FROM tags AS t, UNNEST(t.schemas) AS e(id)
+	              WHERE t.tenant_name = $1
+	              GROUP BY e.id
+	          )
+	          SELECT s.id, s.name, s.type, sv.created_by, s.created_by_username, sv.created_at, asv.version_number, s.owner_team,
+	                 COALESCE(su.stations_count, 0) > 0, COALESCE(st.tags, '[]'::json)
+	          FROM schemas AS s
+	          LEFT JOIN schema_versions AS sv ON s.id = sv.schema_id AND sv.version_number = 1
+	          LEFT JOIN schema_versions AS asv ON s.id = asv.schema_id AND asv.active = true
+	          LEFT JOIN schema_usage AS su ON su.schema_name = s.name
+	          LEFT JOIN schema_tags AS st ON st.id = s.id
+	          WHERE asv.id IS NOT NULL AND s.tenant_name = $1
+	          ORDER BY sv.created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_schemas_details", query)
+	if err != nil {
+		return []models.ExtendedSchema{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.ExtendedSchema{}, err
+	}
+	if err == pgx.ErrNoRows {
+		return []models.ExtendedSchema{}, nil
+	}
+	defer rows.Close()
+	schemas := []models.ExtendedSchema{}
+	for rows.Next() {
+		var sc models.ExtendedSchema
+		err := rows.Scan(&sc.ID, &sc.Name, &sc.Type, &sc.CreatedBy, &sc.CreatedByUsername, &sc.CreatedAt, &sc.ActiveVersionNumber, &sc.OwnerTeam, &sc.Used, &sc.Tags)
+		if err != nil {
+			return []models.ExtendedSchema{}, err
+		}
+		schemas = append(schemas, sc)
+	}
+	if len(schemas) == 0 {
+		return []models.ExtendedSchema{}, nil
+	}
+	return schemas, nil
+}
+
+// FindAndDeleteSchema deletes the schemas with their versions, and detaches them from stations, tags and comments,
+// in one transaction
+func FindAndDeleteSchema(schemaIds []int) error {
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE stations AS st SET schema_name = ''
+			FROM schemas AS sc
+			WHERE sc.id = ANY($1) AND st.schema_name = sc.name AND st.tenant_name = sc.tenant_name`, schemaIds)
+		if err != nil {
+			return err
+		}
+		err = removeEntitiesFromTags(ctx, tx, "schemas", schemaIds)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM comments WHERE entity_type = 'schema' AND entity_id = ANY($1)`, schemaIds)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_versions WHERE schema_id = ANY($1)`, schemaIds)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schemas WHERE id = ANY($1)`, schemaIds)
+		return err
+	})
+}
+
+// InsertNewSchemaWithVersion creates the schema, its first version as the active one and its tag references in one
+// transaction, tags that do not exist yet are created with their color
+func InsertNewSchemaWithVersion(schemaName, schemaType string, userId int, username, schemaContent, messageStructName, descriptor string, tags []models.CreateTag, tenantName string) (models.Schema, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	newSchema := models.Schema{
+		Name:              schemaName,
+		Type:              schemaType,
+		CreatedByUsername: username,
+		TenantName:        tenantName,
+	}
+	err := WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `INSERT INTO schemas (name, type, created_by_username, tenant_name, owner_team)
+			VALUES($1, $2, $3, $4, COALESCE((SELECT team FROM users WHERE username = $3 AND tenant_name = $4), ''))
+			RETURNING id, owner_team`, schemaName, schemaType, username, tenantName).Scan(&newSchema.ID, &newSchema.OwnerTeam)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrSchemaExists
+			}
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_versions (version_number, active, created_by, created_by_username, created_at, schema_content, schema_id, msg_struct_name, descriptor, tenant_name)
+			VALUES(1, true, $1, $2, $3, $4, $5, $6, $7, $8)`, userId, username, time.Now(), schemaContent, newSchema.ID, messageStructName, []byte(descriptor), tenantName)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			_, err = tx.Exec(ctx, `INSERT INTO tags (name, color, users, stations, schemas, tenant_name)
+				VALUES($1, $2, '{}', '{}', ARRAY[$3::INTEGER], $4)
+				ON CONFLICT (name, tenant_name) DO UPDATE SET schemas = ARRAY_APPEND(tags.schemas, $3::INTEGER)`, tag.Name, tag.Color, newSchema.ID, tenantName)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Schema{}, err
+	}
+	return newSchema, nil
+}
+
+// DeleteStationReferences removes the rows that only exist for the station, its producers, consumers, dls messages,
+// tag references and comments, and unsets it as the dls station of other stations, in one transaction
+func DeleteStationReferences(stationId int, stationName string, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM dls_messages WHERE station_id = $1`,
+			`DELETE FROM producers WHERE station_id = $1`,
+			`DELETE FROM consumers WHERE station_id = $1`,
+			`DELETE FROM comments WHERE entity_type = 'station' AND entity_id = $1`,
+			`DELETE FROM station_lifecycle_policies WHERE station_id = $1`,
+			`DELETE FROM station_index_configs WHERE station_id = $1`,
+			`DELETE FROM station_message_index WHERE station_id = $1`,
+			`DELETE FROM station_contract_report_schedules WHERE station_id = $1`,
+			`DELETE FROM station_contract_reports WHERE station_id = $1`,
+			`DELETE FROM project_stations WHERE station_id = $1`,
+		} {
+			_, err := tx.Exec(ctx, query, stationId)
+			if err != nil {
+				return err
+			}
+		}
+		err := removeEntitiesFromTags(ctx, tx, "stations", []int{stationId})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE stations SET dls_station = '' WHERE dls_station = $1 AND tenant_name = $2`, stationName, tenantName)
+		return err
+	})
+}
+
+// removeEntitiesFromTags drops the ids from the given entity column (stations, schemas or users) of every tag
+func removeEntitiesFromTags(ctx context.Context, tx pgx.Tx, entity string, ids []int) error {
+	column := pgx.Identifier{entity}.Sanitize()
+	_, err := tx.Exec(ctx, `UPDATE tags SET `+column+` = ARRAY(SELECT id FROM UNNEST(`+column+`) AS id WHERE id <> ALL($1))
+		WHERE `+column+` && $1::INTEGER[]`, ids)
+	return err
+}
+
+func InsertNewSchema(schemaName string, schemaType string, createdByUsername string, tenantName string) (models.Schema, int64, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Schema{}, 0, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO schemas ( 
+		name, 
+		type,
+		created_by_username,
+		tenant_name,
+		owner_team) 
+    VALUES($1, $2, $3, $4, COALESCE((SELECT team FROM users WHERE username = $3 AND tenant_name = $4), '')) RETURNING id, owner_team`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_schema", query)
+	if err != nil {
+		return models.Schema{}, 0, err
+	}
+
+	var schemaId int
+	var ownerTeam string
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaName, schemaType, createdByUsername, tenantName)
+	if err != nil {
+		return models.Schema{}, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&schemaId, &ownerTeam)
+		if err != nil {
+			return models.Schema{}, 0, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Schema{}, 0, errors.New("Schema " + schemaName + " already exists")
+				} else {
+					return models.Schema{}, 0, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.Schema{}, 0, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Schema{}, 0, err
+		}
+	}
+
+	rowsAffected := rows.CommandTag().RowsAffected()
+	newSchema := models.Schema{
+		ID:                schemaId,
+		Name:              schemaName,
+		Type:              schemaType,
+		CreatedByUsername: createdByUsername,
+		TenantName:        tenantName,
+		OwnerTeam:         ownerTeam,
+	}
+	return newSchema, rowsAffected, nil
+}
+
+func InsertNewSchemaVersion(schemaVersionNumber int, userId int, username string, schemaContent string, schemaId int, messageStructName string, descriptor string, active bool, tenantName string) (models.SchemaVersion, int64, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.SchemaVersion{}, 0, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO schema_versions ( 
+		version_number,
+		active,
+		created_by,
+		created_by_username,
+		created_at,
+		schema_content,
+		schema_id,
+		msg_struct_name,
+		descriptor,
+		tenant_name)
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_schema_version", query)
+	if err != nil {
+		return models.SchemaVersion{}, 0, err
+	}
+
+	var schemaVersionId int
+	createdAt := time.Now()
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaVersionNumber, active, userId, username, createdAt, schemaContent, schemaId, messageStructName, []byte(descriptor), tenantName)
+	if err != nil {
+		return models.SchemaVersion{}, 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&schemaVersionId)
+		if err != nil {
+			return models.SchemaVersion{}, 0, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.SchemaVersion{}, 0, ErrSchemaVersionExists
+				} else {
+					return models.SchemaVersion{}, 0, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.SchemaVersion{}, 0, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.SchemaVersion{}, 0, err
+		}
+
+	}
+
+	rowsAffected := rows.CommandTag().RowsAffected()
+	newSchemaVersion := models.SchemaVersion{
+		ID:                schemaVersionId,
+		VersionNumber:     schemaVersionNumber,
+		Active:            active,
+		CreatedBy:         userId,
+		CreatedByUsername: username,
+		CreatedAt:         time.Now(),
+		SchemaContent:     schemaContent,
+		SchemaId:          schemaId,
+		MessageStructName: messageStructName,
+		Descriptor:        descriptor,
+	}
+	return newSchemaVersion, rowsAffected, nil
+}
+
+func CountAllSchemasByTenant(tenantName string) (int64, error) {
+	var count int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM schemas WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_total_schemas_by_tenant", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Integration Functions
+func GetIntegration(name string, tenantName string) (bool, models.Integration, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Integration{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM integrations WHERE name=$1 AND tenant_name=$2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_integration", query)
+	if err != nil {
+		return false, models.Integration{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return false, models.Integration{}, err
+	}
+	defer rows.Close()
+	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	if err != nil {
+		return false, models.Integration{}, err
+	}
+	err = decryptIntegrationsKeys(integrations)
+	if err != nil {
+		return false, models.Integration{}, err
+	}
+	if len(integrations) == 0 {
+		return false, models.Integration{}, nil
+	}
+	return true, integrations[0], nil
+}
+
+func GetAllIntegrations() (bool, []models.Integration, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM integrations`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_integrations", query)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	defer rows.Close()
+	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	err = decryptIntegrationsKeys(integrations)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	if len(integrations) == 0 {
+		return false, []models.Integration{}, nil
+	}
+	return true, integrations, nil
+}
+
+func GetAllIntegrationsByTenant(tenantName string) (bool, []models.Integration, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM integrations WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_integrations_by_tenant", query)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	defer rows.Close()
+	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	err = decryptIntegrationsKeys(integrations)
+	if err != nil {
+		return false, []models.Integration{}, err
+	}
+	if len(integrations) == 0 {
+		return false, []models.Integration{}, nil
+	}
+	return true, integrations, nil
+}
+
+func DeleteIntegration(name string, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	removeIntegrationQuery := `DELETE FROM integrations WHERE name = $1 AND tenant_name = $2`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_integration", removeIntegrationQuery)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func InsertNewIntegration(tenantName string, name string, keys map[string]interface{}, properties map[string]bool) (models.Integration, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO integrations ( 
+		name, 
+		keys,
+		properties,
+		tenant_name,
+		is_valid) 
+    VALUES($1, $2, $3, $4, $5) RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_integration", query)
+	if err != nil {
+		return models.Integration{}, err
+	}
+
+	encryptedKeys, err := encryptIntegrationKeys(keys)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	var integrationId int
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, encryptedKeys, properties, tenantName, true)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&integrationId)
+		if err != nil {
+			return models.Integration{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Integration{}, errors.New("Integration " + name + " already exists")
+				} else {
+					return models.Integration{}, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.Integration{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Integration{}, err
+		}
+	}
+	newIntegration := models.Integration{
+		ID:         integrationId,
+		Name:       name,
+		Keys:       keys,
+		Properties: properties,
+		TenantName: tenantName,
+		IsValid:    true,
+	}
+	return newIntegration, nil
+}
+
+func UpdateIntegration(tenantName string, name string, keys map[string]interface{}, properties map[string]bool) (models.Integration, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	defer conn.Release()
+	query := `
+	INSERT INTO integrations(name, keys, properties, tenant_name)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT(name, tenant_name) DO UPDATE
+	SET keys = excluded.keys, properties = excluded.properties
+	RETURNING id, name, keys, properties, tenant_name, is_valid
+`
+	stmt, err := conn.Conn().Prepare(ctx, "update_integration", query)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	encryptedKeys, err := encryptIntegrationKeys(keys)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, encryptedKeys, properties, tenantName)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	defer rows.Close()
+	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	if err != nil {
+		return models.Integration{}, err
+	}
+	err = decryptIntegrationsKeys(integrations)
+	if err != nil {
+		return models.Integration{}, err
+	}
+	if len(integrations) == 0 {
+		return models.Integration{}, err
+	}
+	return integrations[0], nil
+}
+
+func UpdateIsValidIntegration(tenantName, integrationName string, isValid bool) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE integrations SET is_valid = $2 WHERE name = $1 AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "update_is_valid_integration", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, integrationName, isValid, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// User Functions
+func UpdatePendingUser(tenantName, username string, pending bool) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET pending = $2 WHERE username = $1 AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "update_pending_user", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, username, pending, tenantName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+func CreateUser(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string, pending bool, team, position, owner, description string) (models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO users ( 
+		username,
+		password,
+		type,
+		already_logged_in,
+		created_at,
+		avatar_id,
+		full_name, 
+		subscription,
+		skip_get_started,
+		tenant_name,
+		pending,
+		team, 
+		position,
+		owner,
+		description) 
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "create_new_user", query)
+	if err != nil {
+		return models.User{}, err
+	}
+	createdAt := time.Now()
+	skipGetStarted := false
+	alreadyLoggedIn := false
+
+	var userId int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	encryptedPassword, err := encryptSecret(hashedPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, encryptedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName, pending, team, position, owner, description)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&userId)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.User{}, errors.New("User " + username + " already exists")
+				} else {
+					return models.User{}, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.User{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.User{}, err
+		}
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	newUser := models.User{
+		ID:              userId,
+		Username:        username,
+		Password:        hashedPassword,
+		FullName:        fullName,
+		Subscribtion:    subscription,
+		UserType:        userType,
+		CreatedAt:       createdAt,
+		AlreadyLoggedIn: alreadyLoggedIn,
+		AvatarId:        avatarId,
+		TenantName:      tenantName,
+		Pending:         pending,
+		Team:            team,
+		Position:        position,
+		Owner:           owner,
+		Description:     description,
+	}
+	return newUser, nil
+}
+
+func UpsertUserUpdatePassword(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO users (
+		username,
+		password,
+		type,
+		already_logged_in,
+		created_at,
+		avatar_id,
+		full_name, 
+		subscription,
+		skip_get_started,
+		tenant_name
+	) 
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+	ON CONFLICT (username, tenant_name) DO UPDATE SET password = excluded.password
+	RETURNING id, CASE WHEN xmax = 0 THEN 'insert' ELSE 'update' END AS action`
+
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_new_user_update_password", query)
+	if err != nil {
+		return false, err
+	}
+	createdAt := time.Now()
+	skipGetStarted := false
+	alreadyLoggedIn := false
+
+	var userId int
+	var op string
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	encryptedPassword, err := encryptSecret(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, encryptedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&userId, &op)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	didCreate := false
+	if op == "insert" {
+		didCreate = true
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return false, errors.New("User " + username + " already exists")
+				} else {
+					return false, errors.New(pgErr.Detail)
+				}
+			} else {
+				return false, errors.New(pgErr.Message)
+			}
+		} else {
+			return false, err
+		}
+	}
+
+	return didCreate, nil
+}
+
+func ChangeUserPassword(username string, hashedPassword string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET password = $2 WHERE username = $1 AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "change_user_password", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	encryptedPassword, err := encryptSecret(hashedPassword)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, username, encryptedPassword, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func UpdateUserProfile(username string, fullName string, team string, position string, description string, avatarId int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET full_name = $2, team = $3, position = $4, description = $5, avatar_id = $6 WHERE username = $1 AND tenant_name=$7`
+	stmt, err := conn.Conn().Prepare(ctx, "update_user_profile", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, username, fullName, team, position, description, avatarId, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetRootUser(tenantName string) (bool, models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM users WHERE type = 'root' and tenant_name =$1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_root_user", query)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return false, models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if len(users) == 0 {
+		return false, models.User{}, nil
+	}
+	return true, users[0], nil
+}
+
+func GetUserByUsername(username string, tenantName string) (bool, models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM users WHERE username = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_by_username", query)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return false, models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if len(users) == 0 {
+		return false, models.User{}, nil
+	}
+	return true, users[0], nil
+}
+
+func GetUserWithPermissionsByUsername(username, tenantName string) (bool, models.UserWithPermissions, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.UserWithPermissions{}, err
+	}
+	defer conn.Release()
+
+	query := `
+        SELECT
+            u.id,
+            u.username,
+            u.password,
+            u.type AS user_type,
+            u.already_logged_in,
+            u.created_at,
+            u.avatar_id,
+            u.full_name,
+            u.subscription,
+            u.skip_get_started,
+            u.tenant_name,
+            u.pending,
+            u.position,
+            u.team,
+            u.owner,
+            u.description,
+            u.last_login,
+            (
+				SELECT ARRAY_AGG(p.pattern)
+				FROM permissions p
+				WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
+			) AS allow_read_patterns,
+			(
+				SELECT ARRAY_AGG(p.pattern)
+				FROM permissions p
+				WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
+			) AS allow_write_patterns
+			(
+				SELECT ARRAY_AGG(p.pattern)
+				FROM permissions p
+				WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
+			) AS deny_read_patterns,
+			(
+				SELECT ARRAY_AGG(p.pattern)
+				FROM permissions p
+				WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
+			) AS deny_write_patterns
+			FROM
+				users u
+        WHERE u.username = $1 AND tenant_name = $2
+        LIMIT 1
+    `
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions_by_username", query)
+	if err != nil {
+		return false, models.UserWithPermissions{}, err
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return false, models.UserWithPermissions{}, err
+	}
+	defer rows.Close()
+
+	var userWithPermissions models.UserWithPermissions
+	for rows.Next() {
+		err := rows.Scan(
+			&userWithPermissions.ID,
+			&userWithPermissions.Username,
+			&userWithPermissions.Password,
+			&userWithPermissions.UserType,
+			&userWithPermissions.AlreadyLoggedIn,
+			&userWithPermissions.CreatedAt,
+			&userWithPermissions.AvatarId,
+			&userWithPermissions.FullName,
+			&userWithPermissions.Subscribtion,
+			&userWithPermissions.SkipGetStarted,
+			&userWithPermissions.TenantName,
+			&userWithPermissions.Pending,
+			&userWithPermissions.Position,
+			&userWithPermissions.Team,
+			&userWithPermissions.Owner,
+			&userWithPermissions.Description,
+			&userWithPermissions.LastLogin,
+			&userWithPermissions.Permissions.AllowReadPermissions,
+			&userWithPermissions.Permissions.AllowWritePermissions,
+			&userWithPermissions.Permissions.DenyReadPermissions,
+			&userWithPermissions.Permissions.DenyWritePermissions,
+		)
+		if err != nil {
+			return false, models.UserWithPermissions{}, err
+		}
+		userWithPermissions.Password, err = decryptSecret(userWithPermissions.Password)
+		if err != nil {
+			return false, models.UserWithPermissions{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return false, models.UserWithPermissions{}, err
+	}
+
+	return true, userWithPermissions, nil
+}
+
+func GetUserForLogin(username string) (bool, models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM users WHERE username = $1 AND NOT type = 'application' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_for_login", query)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return false, models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if len(users) == 0 {
+		return false, models.User{}, nil
+	}
+	return true, users[0], nil
+}
+
+func GetUserForLoginByUsernameAndTenant(username, tenantname string) (bool, models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM users WHERE username = $1 AND tenant_name = $2  AND NOT type = 'application' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_for_login_by_username_and_tenant", query)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantname)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return false, models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return false, models.User{}, err
+	}
+	if len(users) == 0 {
+		return false, models.User{}, nil
+	}
+	return true, users[0], nil
+}
+
+func GetAllUsers(tenantName string) ([]models.FilteredGenericUser, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.FilteredGenericUser{}, err
+	}
+	defer conn.Release()
+	query := `SELECT s.id, s.username, s.type, s.created_at, s.avatar_id, s.full_name, s.pending, s.position, s.team, s.owner, s.description, s.last_login, s.role_id FROM users AS s WHERE tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_users", query)
+	if err != nil {
+		return []models.FilteredGenericUser{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.FilteredGenericUser{}, err
+	}
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredGenericUser])
+	if err != nil {
+		return []models.FilteredGenericUser{}, err
+	}
+	if len(users) == 0 {
+		return []models.FilteredGenericUser{}, nil
+	}
+	return users, nil
+}
+
+func CountAllUsers() (int64, error) {
+	var count int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM users WHERE username NOT LIKE '$%'` // filter memphis internal users
+	stmt, err := conn.Conn().Prepare(ctx, "get_total_users", query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func CountAllUsersByTenant(tenantName string, filterOutApplicationUsers bool) (int64, error) {
+	var count int64
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM users WHERE tenant_name = $1 AND username NOT LIKE '$%'` // filter memphis internal users`
+	preparedStmtName := "get_total_users_by_tenant"
+	if filterOutApplicationUsers {
+		query = `SELECT COUNT(*) FROM users WHERE tenant_name = $1 AND username NOT LIKE '$%' AND type != 'application'` // filter memphis internal users`
+		preparedStmtName = "get_total_users_by_tenant_filtered"
+	}
+	stmt, err := conn.Conn().Prepare(ctx, preparedStmtName, query)
+	if err != nil {
+		return 0, err
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func GetAllUsersByTypeAndTenantName(userType []string, tenantName string) ([]models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.User{}, err
+	}
+	defer conn.Release()
+	var rows pgx.Rows
+	query := `SELECT * FROM users WHERE type=ANY($1) AND tenant_name=$2 AND username NOT LIKE '$%'` // filter memphis internal users
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type_and_tenant_name", query)
+	if err != nil {
+		return []models.User{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err = conn.Conn().Query(ctx, stmt.Name, userType, tenantName)
+	if err != nil {
+		return []models.User{}, err
+	}
+
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return []models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return []models.User{}, err
+	}
+	if len(users) == 0 {
+		return []models.User{}, nil
+	}
+	return users, nil
+}
+
+func GetAllUsersByTenantName(tenantName string) ([]models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.User{}, err
+	}
+	defer conn.Release()
+	var rows pgx.Rows
+	query := `SELECT * FROM users WHERE tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type_and_tenant_name", query)
+	if err != nil {
+		return []models.User{}, err
+	}
+
+	rows, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.User{}, err
+	}
+
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return []models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return []models.User{}, err
+	}
+	if len(users) == 0 {
+		return []models.User{}, nil
+	}
+	return users, nil
+}
+
+func GetAllUsersByType(userType []string) ([]models.User, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.User{}, err
+	}
+	defer conn.Release()
+	var rows pgx.Rows
+	query := `SELECT * FROM users WHERE type=ANY($1) AND username NOT LIKE '$%'` // filter memphis internal users
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type", query)
+	if err != nil {
+		return []models.User{}, err
+	}
+	rows, err = conn.Conn().Query(ctx, stmt.Name, userType)
+	if err != nil {
+		return []models.User{}, err
+	}
+
+	defer rows.Close()
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return []models.User{}, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return []models.User{}, err
+	}
+	if len(users) == 0 {
+		return []models.User{}, nil
+	}
+	return users, nil
+}
+
+func UpdateUserAlreadyLoggedIn(userId int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET already_logged_in = true WHERE id = $1`
+	stmt, _ := conn.Conn().Prepare(ctx, "update_user_already_logged_in", query)
+	conn.Conn().Query(ctx, stmt.Name, userId)
+	return nil
+}
+
+func UpdateLastLoginUser(userId int) (time.Time, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET last_login = NOW() WHERE id = $1 RETURNING last_login`
+	stmt, err := conn.Conn().Prepare(ctx, "update_last_login_in_user", query)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var lastLogin time.Time
+	if err := conn.Conn().QueryRow(ctx, stmt.Name, userId).Scan(&lastLogin); err != nil {
+		return time.Time{}, err
+	}
+	return lastLogin, nil
+}
+
+func UpdateSkipGetStarted(username string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET skip_get_started = true WHERE username = $1 AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "update_skip_get_started", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteUser(username string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	removeUserQuery := `DELETE FROM users WHERE username = $1 AND tenant_name=$2`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_user", removeUserQuery)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func DeleteUsersByTenant(tenantName string) ([]string, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	removeUserQuery := `DELETE FROM users WHERE tenant_name = $1 RETURNING username`
+	rows, err := conn.Query(ctx, removeUserQuery, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users_list []string
+
+	for rows.Next() {
+		var usermame string
+		err := rows.Scan(&usermame)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(usermame, "$") { // skip memphis internal users
+			users_list = append(users_list, usermame)
+		}
+	}
+
+	return users_list, err
+}
+
+func EditAvatar(username string, avatarId int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE users SET avatar_id = $2 WHERE username = $1 AND tenant_name=$3`
+	stmt, err := conn.Conn().Prepare(ctx, "edit_avatar", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, username, avatarId, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetAllActiveUsersStations(tenantName string) ([]models.FilteredUser, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	defer conn.Release()
+	query := `
+	SELECT DISTINCT u.username
+	FROM users AS u
+	JOIN stations AS s ON u.id = s.created_by
+	WHERE s.tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
+
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_users_stations", query)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	defer rows.Close()
+	userList, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredUser])
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	if len(userList) == 0 {
+		return []models.FilteredUser{}, nil
+	}
+	return userList, nil
+}
+
+func GetAllActiveUsersSchemaVersions(tenantName string) ([]models.FilteredUser, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	defer conn.Release()
+	query := `
+	SELECT DISTINCT u.username
+	FROM users AS u
+	JOIN schema_versions AS s ON u.id = s.created_by
+	WHERE s.tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
+
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_users_schema_versions", query)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	defer rows.Close()
+	userList, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredUser])
+	if err != nil {
+		return []models.FilteredUser{}, err
+	}
+	if len(userList) == 0 {
+		return []models.FilteredUser{}, nil
+	}
+	return userList, nil
+}
+
+func UpsertBatchOfUsers(users []models.User) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	valueStrings := make([]string, 0, len(users))
+	valueArgs := make([]interface{}, 0, len(users)*6)
+	for i, user := range users {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+		encryptedPassword, err := encryptSecret(user.Password)
+		if err != nil {
+			return err
+		}
+		valueArgs = append(valueArgs, user.Username)
+		valueArgs = append(valueArgs, encryptedPassword)
+		valueArgs = append(valueArgs, user.UserType)
+		valueArgs = append(valueArgs, user.CreatedAt)
+		valueArgs = append(valueArgs, user.AvatarId)
+		valueArgs = append(valueArgs, user.FullName)
+	}
+	query := fmt.Sprintf("INSERT INTO users (username, password, type, created_at, avatar_id, full_name) VALUES %s ON CONFLICT (username) DO UPDATE SET password = EXCLUDED.password", strings.Join(valueStrings, ","))
+	stmt, err := conn.Conn().Prepare(ctx, "update_batch_of_users", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, valueArgs...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Tags Functions
+func InsertNewTag(name string, color string, stationArr []int, schemaArr []int, userArr []int, tenantName string) (models.Tag, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer conn.Release()
+
+	query := `INSERT INTO tags ( 
+		name,
+		color,
+		users,
+		stations,
+		schemas,
+		tenant_name) 
+    VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_tag", query)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	var tagId int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, color, userArr, stationArr, schemaArr, tenantName)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&tagId)
+		if err != nil {
+			return models.Tag{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Tag{}, errors.New("Tag" + name + " already exists")
+				} else {
+					return models.Tag{}, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.Tag{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.Tag{}, err
+		}
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	newTag := models.Tag{
+		ID:         tagId,
+		Name:       name,
+		Color:      color,
+		Stations:   stationArr,
+		Schemas:    schemaArr,
+		Users:      userArr,
+		TenantName: tenantName,
+	}
+	return newTag, nil
+}
+
+func InsertEntityToTag(tagName string, entity string, entity_id int, tenantName, color string) error {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if color == "" {
+		query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_APPEND(` + entityDBList + `, $1) WHERE name = $2 AND tenant_name = $3`
+		stmt, err := conn.Conn().Prepare(ctx, "insert_entity_to_tag", query)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Conn().Query(ctx, stmt.Name, entity_id, tagName, tenantName)
+		if err != nil {
+			return err
+		}
+	} else {
+		query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_APPEND(` + entityDBList + `, $1) , color = $4 WHERE name = $2 AND tenant_name = $3`
+		stmt, err := conn.Conn().Prepare(ctx, "insert_entity_to_tag", query)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Conn().Query(ctx, stmt.Name, entity_id, tagName, tenantName, color)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RemoveAllTagsFromEntity(entity string, entity_id int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE tags SET ` + entity + ` = ARRAY_REMOVE(` + entity + `, $1) WHERE $1 = ANY(` + entity + `)`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_all_tags_from_entity", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, entity_id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func RemoveTagFromEntity(tagName string, entity string, entity_id int) error {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_REMOVE(` + entityDBList + `, $2) WHERE name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_tag_from_entity", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, tagName, entity_id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetTagsByEntityID(entity string, id int) ([]models.Tag, error) {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	defer conn.Release()
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	query := `SELECT * FROM tags AS t WHERE $1 = ANY(t.` + entityDBList + `) LIMIT 1000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_id"+uid.String(), query)
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, err
+	}
+	return tags, nil
+}
+
+func GetTagsByEntityIDLight(entity string, id int) ([]models.CreateTag, error) {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.CreateTag{}, err
+	}
+	defer conn.Release()
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return []models.CreateTag{}, err
+	}
+	query := `SELECT t.name, t.color FROM tags AS t WHERE $1 = ANY(t.` + entityDBList + `) LIMIT 1000;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_id_light"+uid.String(), query)
+	if err != nil {
+		return []models.CreateTag{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CreateTag])
+	if err != nil {
+		return []models.CreateTag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.CreateTag{}, err
+	}
+	return tags, nil
+}
+
+// GetEntitiesTags returns the tags of every station, schema or user of the tenant by entity id in one query
+func GetEntitiesTags(entity string, tenantName string) (map[int][]models.CreateTag, error) {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	default:
+		return nil, fmt.Errorf("unsupported entity type %v", entity)
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `SELECT DISTINCT e.id, t.name, t.color FROM tags AS t, UNNEST(t.` + entityDBList + `) AS e(id) WHERE t.tenant_name = $1 ORDER BY e.id, t.name`
+	stmt, err := conn.Conn().Prepare(ctx, "get_entities_tags_"+entityDBList, query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := map[int][]models.CreateTag{}
+	for rows.Next() {
+		var entityId int
+		var tag models.CreateTag
+		err := rows.Scan(&entityId, &tag.Name, &tag.Color)
+		if err != nil {
+			return nil, err
+		}
+		tags[entityId] = append(tags[entityId], tag)
+	}
+	return tags, rows.Err()
+}
+
+func GetTagsByEntityType(entity string, tenantName string) ([]models.Tag, error) {
+	var entityDBList string
+	switch entity {
+	case "station":
+		entityDBList = "stations"
+	case "schema":
+		entityDBList = "schemas"
+	case "user":
+		entityDBList = "users"
+	default:
+		entityDBList = ""
+	}
+
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	defer conn.Release()
+	var query string
+	if entityDBList == "" { // Get All
+		query = `SELECT * FROM tags WHERE tenant_name=$1`
+	} else {
+		query = fmt.Sprintf(`SELECT * FROM tags WHERE %s IS NOT NULL AND array_length(%s, 1) > 0 AND tenant_name=$1`, entityDBList, entityDBList)
+	}
+	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_type", query)
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+	return tags, nil
+}
+
+func GetAllUsedTags(tenantName string) ([]models.Tag, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(schemas, 1) > 0 OR ARRAY_LENGTH(stations, 1) > 0 OR ARRAY_LENGTH(users, 1) > 0) AND tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_tags", query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+	return tags, nil
+}
+
+func GetAllUsedStationsTags(tenantName string) ([]models.Tag, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(stations, 1) > 0) AND tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_stations_tags", query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+	return tags, nil
+}
+
+func GetAllUsedSchemasTags(tenantName string) ([]models.Tag, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(schemas, 1) > 0) AND tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_schemas_tags", query)
+	if err != nil {
+		return nil, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+	return tags, nil
+}
+
+func GetTagByName(name string, tenantName string) (bool, models.Tag, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Tag{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM tags WHERE name=$1 AND tenant_name=$2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tag_by_name", query)
+	if err != nil {
+		return false, models.Tag{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		return false, models.Tag{}, err
+	}
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return false, models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return false, models.Tag{}, nil
+	}
+	return true, tags[0], nil
+}
+
+// Image Functions
+func InsertImage(name string, base64Encoding string, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err := UpsertConfiguration(name, base64Encoding, tenantName)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
-func EditConfigurationValue(key string, value string, tenantName string) error {
+func DeleteImage(name string, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE configurations SET value = $2 WHERE key = $1 AND tenant_name=$3`
-	stmt, err := conn.Conn().Prepare(ctx, "edit_configuration_value", query)
+
+	removeImageQuery := `DELETE FROM configurations
+	WHERE key = $1 AND tenant_name=$2`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_image", removeImageQuery)
 	if err != nil {
 		return err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, key, value, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Configuration Functions
-func GetAllConfigurations() (bool, []models.ConfigurationsValue, error) {
+func GetImage(name string, tenantName string) (bool, models.Image, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.ConfigurationsValue{}, err
+		return false, models.Image{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM configurations`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_configurations", query)
+	query := `SELECT id, key, value FROM configurations WHERE key = $1 AND tenant_name =$2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_image", query)
 	if err != nil {
-		return false, []models.ConfigurationsValue{}, err
+		return false, models.Image{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
 	if err != nil {
-		return false, []models.ConfigurationsValue{}, err
+		return false, models.Image{}, err
 	}
 	defer rows.Close()
-	configurations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConfigurationsValue])
+	images, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Image])
 	if err != nil {
-		return false, []models.ConfigurationsValue{}, err
+		return false, models.Image{}, err
 	}
-	if len(configurations) == 0 {
-		return false, []models.ConfigurationsValue{}, nil
+	if len(images) == 0 {
+		return false, models.Image{}, nil
 	}
-
-	return true, configurations, nil
+	return true, images[0], nil
 }
 
-func InsertConfiguration(key string, value string, tenantName string) error {
+// dls Functions
+func InsertSchemaverseDlsMsg(stationId int, messageSeq int, producerName string, poisonedCgs []string, messageDetails models.MessagePayload, validationError string, tenantName string, partitionNumber int) (models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	connection, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.DlsMessage{}, err
 	}
-	defer conn.Release()
+	defer connection.Release()
 
-	query := `INSERT INTO configurations( 
-			key, 
-			value,
-			tenant_name) 
-		VALUES($1, $2, $3) 
+	query := `INSERT INTO dls_messages( 
+			station_id,
+			message_seq,
+			poisoned_cgs,
+			message_details,
+			updated_at,
+			message_type,
+			validation_error,
+			tenant_name,
+			producer_name,
+			partition_number) 
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id`
 
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_configuration", query)
+	stmt, err := connection.Conn().Prepare(ctx, "insert_dls_messages", query)
 	if err != nil {
-		return err
+		return models.DlsMessage{}, err
 	}
-
-	newConfiguration := models.ConfigurationsValue{}
+	updatedAt := time.Now()
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name,
-		key, value, tenantName)
+	rows, err := connection.Conn().Query(ctx, stmt.Name, stationId, messageSeq, poisonedCgs, messageDetails, updatedAt, "schema", validationError, tenantName, producerName, partitionNumber)
 	if err != nil {
-		return err
+		return models.DlsMessage{}, err
 	}
 	defer rows.Close()
-
+	var messagePayloadId int
 	for rows.Next() {
-		err := rows.Scan(&newConfiguration.ID)
+		err := rows.Scan(&messagePayloadId)
 		if err != nil {
-			return err
+			return models.DlsMessage{}, err
 		}
 	}
 
+	if err != nil {
+		return models.DlsMessage{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	deadLetterPayload := models.DlsMessage{
+		ID:           messagePayloadId,
+		StationId:    stationId,
+		MessageSeq:   messageSeq,
+		ProducerName: producerName,
+		PoisonedCgs:  poisonedCgs,
+		MessageDetails: models.MessagePayload{
+			TimeSent: messageDetails.TimeSent,
+			Size:     messageDetails.Size,
+			Data:     messageDetails.Data,
+			Headers:  messageDetails.Headers,
+		},
+		UpdatedAt:       updatedAt,
+		TenantName:      tenantName,
+		PartitionNumber: partitionNumber,
+	}
+
 	if err := rows.Err(); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return errors.New("configuration " + key + " already exists")
+				if !strings.Contains(pgErr.Detail, "already exists") {
+					return models.DlsMessage{}, errors.New("schemaverse dls already exists")
 				} else {
-					return errors.New(pgErr.Detail)
+					return models.DlsMessage{}, errors.New(pgErr.Detail)
 				}
 			} else {
-				return errors.New(pgErr.Message)
+				return models.DlsMessage{}, errors.New(pgErr.Message)
 			}
 		} else {
-			return err
+			return models.DlsMessage{}, err
 		}
 	}
 
-	return nil
+	return deadLetterPayload, nil
 }
 
-func UpsertConfiguration(key string, value string, tenantName string) error {
+func GetMsgByStationIdAndMsgSeq(stationId, messageSeq, partitionNumber int) (bool, models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	connection, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
-	defer conn.Release()
-	query := `INSERT INTO configurations (key, value, tenant_name) VALUES($1, $2, $3)
-	ON CONFLICT(key, tenant_name) DO UPDATE SET value = EXCLUDED.value`
-	stmt, err := conn.Conn().Prepare(ctx, "update_configuration", query)
+	defer connection.Release()
+
+	query := `SELECT * FROM dls_messages WHERE station_id = $1 AND message_seq = $2 AND partition_number = $3 LIMIT 1`
+
+	stmt, err := connection.Conn().Prepare(ctx, "get_dls_messages_by_station_id_and_message_seq", query)
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+
+	rows, err := connection.Conn().Query(ctx, stmt.Name, stationId, messageSeq, partitionNumber)
+	if err != nil {
+		return false, models.DlsMessage{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, key, value, tenantName)
+	defer rows.Close()
+
+	message, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
-	return nil
+	if len(message) == 0 {
+		return false, models.DlsMessage{}, nil
+	}
+
+	return true, message[0], nil
 }
 
-// Connection Functions
-func UpdateProducersCounsumersConnection(connectionId string, isActive bool) (bool, error) {
+func StorePoisonMsg(stationId, messageSeq int, cgName string, producerName string, poisonedCgs []string, messageDetails models.MessagePayload, tenantName string, partitionNumber int, validationError string) (int, bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	updated := false
+	connection, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, err
+		return 0, updated, err
 	}
-	defer conn.Release()
-	query := `WITH updated_producers AS (
-		UPDATE producers
-		SET is_active = $1, updated_at = NOW()
-		WHERE connection_id = $2
-	  )
-	  UPDATE consumers
-	  SET is_active = $1, updated_at = NOW()
-	  WHERE connection_id = $2;`
-	stmt, err := conn.Conn().Prepare(ctx, "update_connection", query)
+	defer connection.Release()
+
+	tx, err := connection.Conn().Begin(ctx)
 	if err != nil {
-		return false, err
+		return 0, updated, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	defer tx.Rollback(ctx)
+
+	query := `SELECT EXISTS(SELECT 1 FROM consumers WHERE tenant_name = $1 AND consumers_group = $2 AND type = 'application')`
+	stmt, err := tx.Prepare(ctx, "check_if_consumer_exists", query)
 	if err != nil {
-		return false, err
+		return 0, updated, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	checkRows, err := tx.Query(ctx, stmt.Name, tenantName, cgName)
+	if err != nil {
+		return 0, updated, err
+	}
+	var exists bool
+	for checkRows.Next() {
+		checkRows.Scan(&exists)
+	}
+	if !exists {
+		return 0, updated, err
+	}
+	checkRows.Close()
+
+	query = `SELECT * FROM dls_messages WHERE station_id = $1 AND message_seq = $2 AND tenant_name =$3 AND partition_number = $4 LIMIT 1 FOR UPDATE`
+	stmt, err = tx.Prepare(ctx, "handle_insert_dls_message", query)
+	if err != nil {
+		return 0, updated, err
+	}
+	rows, err := tx.Query(ctx, stmt.Name, stationId, messageSeq, tenantName, partitionNumber)
+	if err != nil {
+		return 0, updated, err
+	}
+
+	message, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	if err != nil {
+		return 0, updated, err
 	}
 	defer rows.Close()
-	affectedRows := 0
-	for rows.Next() {
-		affectedRows++
-		if affectedRows > 0 {
-			return true, nil
+
+	var dlsMsgId int
+	if len(message) == 0 { // then insert
+		query = `INSERT INTO dls_messages( 
+			station_id,
+			message_seq,
+			producer_name,
+			poisoned_cgs,
+			message_details,
+			updated_at,
+			message_type,
+			validation_error,
+			tenant_name,
+			partition_number
+			) 
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+		stmt, err := tx.Prepare(ctx, "insert_dls_message", query)
+		if err != nil {
+			return 0, updated, err
 		}
+		updatedAt := time.Now()
+		if tenantName != conf.GlobalAccount {
+			tenantName = strings.ToLower(tenantName)
+		}
+		rows, err := tx.Query(ctx, stmt.Name, stationId, messageSeq, producerName, poisonedCgs, messageDetails, updatedAt, "poison", validationError, tenantName, partitionNumber)
+		if err != nil {
+			return 0, updated, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			err := rows.Scan(&dlsMsgId)
+			if err != nil {
+				return 0, updated, err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				if pgErr.Detail != "" {
+					if strings.Contains(pgErr.Detail, "already exists") {
+						return 0, updated, errors.New("dls_messages row already exists")
+					} else {
+						return 0, updated, errors.New(pgErr.Detail)
+					}
+				} else {
+					return 0, updated, errors.New(pgErr.Message)
+				}
+			} else {
+				return 0, updated, err
+			}
+		}
+	} else { // then update
+		query = `UPDATE dls_messages SET poisoned_cgs = ARRAY_APPEND(poisoned_cgs, $1), updated_at = $4, validation_error = $6 WHERE station_id=$2 AND message_seq=$3 AND not($1 = ANY(poisoned_cgs)) AND tenant_name=$5 RETURNING id`
+		stmt, err := tx.Prepare(ctx, "update_poisoned_cgs", query)
+		if err != nil {
+			return 0, updated, err
+		}
+		updatedAt := time.Now()
+		if tenantName != conf.GlobalAccount {
+			tenantName = strings.ToLower(tenantName)
+		}
+		rows, err = tx.Query(ctx, stmt.Name, poisonedCgs[0], stationId, messageSeq, updatedAt, tenantName, validationError)
+		if err != nil {
+			return 0, updated, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			err := rows.Scan(&dlsMsgId)
+			if err != nil {
+				return 0, updated, err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				return 0, updated, errors.New(pgErr.Message)
+			} else {
+				return 0, updated, err
+			}
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, updated, err
 	}
-	return false, nil
+
+	return dlsMsgId, updated, nil
 }
 
-func GetActiveConnections() ([]string, error) {
+func GetTotalPoisonMsgsPerCg(cgName string, stationId int) (int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []string{}, err
+		return 0, err
 	}
 	defer conn.Release()
-	query := `SELECT connection_id FROM producers WHERE is_active = true AND type = 'application' UNION SELECT connection_id FROM consumers WHERE is_active = true AND type = 'application';`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_connection", query)
+	query := `SELECT COUNT(*) FROM dls_messages WHERE $1 = ANY(poisoned_cgs) AND station_id = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_total_poison_msgs_per_cg", query)
 	if err != nil {
-		return []string{}, err
+		return 0, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	var count int
+	err = conn.Conn().QueryRow(ctx, stmt.Name, cgName, stationId).Scan(&count)
 	if err != nil {
-		return []string{}, err
-	}
-	defer rows.Close()
-	var connections []string
-	for rows.Next() {
-		var connectionID string
-		err = rows.Scan(&connectionID)
-		if err != nil {
-			return nil, err
-		}
-		connections = append(connections, connectionID)
-	}
-	if len(connections) == 0 {
-		return []string{}, nil
+		return 0, err
 	}
-	return connections, nil
+
+	return count, nil
 }
 
-// Audit Logs Functions
-func InsertAuditLogs(auditLogs []interface{}) error {
+func DeleteOldDlsMessageByRetention(updatedAt time.Time, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-
 	defer conn.Release()
-	var auditLog []models.AuditLog
 
-	b, err := json.Marshal(auditLogs)
+	query := `DELETE FROM dls_messages WHERE tenant_name = $1 AND updated_at < $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_old_dls_messages", query)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(b, &auditLog)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName, updatedAt)
 	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	stationName := auditLog[0].StationName
-	message := auditLog[0].Message
-	createdBy := auditLog[0].CreatedBy
-	createdAt := auditLog[0].CreatedAt
-	createdByUserName := auditLog[0].CreatedByUsername
-	tenantName := auditLog[0].TenantName
-
-	query := `INSERT INTO audit_logs ( 
-		station_name, 
-		message, 
-		created_by,
-		created_by_username,
-		created_at,
-		tenant_name
-		) 
-    VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+func DropDlsMessages(messageIds []int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return errors.New("dropSchemaDlsMsg: " + err.Error())
+	}
+	defer conn.Release()
 
-	stmt, err := conn.Conn().Prepare(ctx, "insert_audit_logs", query)
+	query := `DELETE FROM dls_messages where id=ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "drop_dls_schema_msg", query)
 	if err != nil {
 		return err
 	}
 
-	newAuditLog := models.AuditLog{}
-	rows, err := conn.Conn().Query(ctx, stmt.Name,
-		stationName, message, createdBy, createdByUserName, createdAt, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, messageIds)
 	if err != nil {
-		return err
+		return errors.New("dropSchemaDlsMsg: " + err.Error())
 	}
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&newAuditLog.ID)
-		if err != nil {
-			return err
-		}
+	return nil
+}
+
+func PurgeDlsMsgsFromStation(station_id int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return errors.New("PurgeDlsMsgsFromStation: " + err.Error())
 	}
+	defer conn.Release()
 
-	if err := rows.Err(); err != nil {
+	query := `DELETE FROM dls_messages where station_id=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "purge_dls_messages", query)
+	if err != nil {
 		return err
 	}
 
+	_, err = conn.Conn().Exec(ctx, stmt.Name, station_id)
+	if err != nil {
+		return errors.New("PurgeDlsMsgsFromStation: " + err.Error())
+	}
 	return nil
 }
 
-func GetAuditLogsByStation(name string, tenantName string) ([]models.AuditLog, error) {
+func PurgeDlsMsgsFromPartition(station_id, partitionNumber int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.AuditLog{}, err
+		return errors.New("PurgeDlsMsgsFromPartition: " + err.Error())
 	}
 	defer conn.Release()
-	query := `SELECT * FROM audit_logs AS a
-		WHERE a.station_name = $1 AND a.tenant_name = $2
-		ORDER BY a.created_at DESC
-		LIMIT 1000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_audit_logs_by_station", query)
-	if err != nil {
-		return []models.AuditLog{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+
+	query := `DELETE FROM dls_messages where station_id=$1 AND partition_number = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "purge_dls_messages", query)
 	if err != nil {
-		return []models.AuditLog{}, err
+		return err
 	}
-	defer rows.Close()
-	auditLogs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AuditLog])
+
+	_, err = conn.Conn().Exec(ctx, stmt.Name, station_id, partitionNumber)
 	if err != nil {
-		return []models.AuditLog{}, err
-	}
-	if len(auditLogs) == 0 {
-		return []models.AuditLog{}, nil
+		return errors.New("PurgeDlsMsgsFromPartition: " + err.Error())
 	}
-	return auditLogs, nil
+	return nil
 }
 
-func RemoveAllAuditLogsByStation(name string, tenantName string) error {
+func RemoveCgFromDlsMsg(msgId int, cgName string, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	removeAuditLogs := `DELETE FROM audit_logs
-	WHERE station_name = $1 AND tenant_name = $2`
+	query := `WITH removed_value AS (UPDATE dls_messages SET poisoned_cgs = ARRAY_REMOVE(poisoned_cgs, $1) WHERE id = $2 AND tenant_name = $3 RETURNING *) 
+	DELETE FROM dls_messages WHERE (SELECT array_length(poisoned_cgs, 1)) <= 1 AND id = $2;`
 
-	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_station", removeAuditLogs)
+	stmt, err := conn.Conn().Prepare(ctx, "get_msg_by_id_and_remove msg", query)
 	if err != nil {
 		return err
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
+	_, err = conn.Conn().Query(ctx, stmt.Name, cgName, msgId, tenantName)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
-func UpdateAuditLogsOfDeletedUser(userId int) error {
+
+func CountDlsMsgsByStationAndPartition(stationId, partitionNumber int) (int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer conn.Release()
-	query := `UPDATE audit_logs SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)'`
-	stmt, err := conn.Conn().Prepare(ctx, "update_audit_logs_of_deleted_user", query)
+	query := `SELECT COUNT(*) from dls_messages where station_id=$1`
+	stmtName := "count_dls_msgs_by_station_and_partition"
+	if partitionNumber > -1 {
+		query = `SELECT COUNT(*) from dls_messages where station_id=$1 AND partition_number = $2`
+		stmtName = "count_all_dls_msgs_by_station"
+	}
+
+	stmt, err := conn.Conn().Prepare(ctx, stmtName, query)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	var count uint64
+	if partitionNumber == -1 {
+		err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&count)
+	} else {
+		err = conn.Conn().QueryRow(ctx, stmt.Name, stationId, partitionNumber).Scan(&count)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+
+	return int(count), nil
 }
 
-func RemoveAuditLogsByTenant(tenantName string) error {
+func GetDlsMessageById(messageId int) (bool, models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM audit_logs WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_tenant", query)
+	query := `SELECT * from dls_messages where id=$1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_id", query)
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, messageId)
 	if err != nil {
-		return err
+		return false, models.DlsMessage{}, err
 	}
-	return nil
+	defer rows.Close()
+	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	if err != nil {
+		return false, models.DlsMessage{}, err
+	}
+	if len(dlsMsgs) == 0 {
+		return false, models.DlsMessage{}, nil
+	}
+	return true, dlsMsgs[0], nil
 }
 
-func RemoveAuditLogsByTenantAndCreatedAt(tenantName string, createdAt time.Time) error {
+func GetTotalDlsMessages(tenantName string) (uint64, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM audit_logs WHERE tenant_name = $1 AND created_at < $2`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_audit_logs_by_tenant_and_created_at", query)
+	query := `SELECT COUNT(*) FROM dls_messages WHERE tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_total_dls_msgs", query)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName, createdAt)
+	var count uint64
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+
+	return count, nil
 }
 
-// Station Functions
-func GetActiveStationsPerTenant(tenantName string) ([]models.Station, error) {
+func GetDlsMessagesCountByStation() ([]models.DlsMessagesCount, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessagesCount{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations AS s WHERE (s.is_deleted = false) AND tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations_per_tenant", query)
+	query := `SELECT s.tenant_name, s.name, d.message_type, COUNT(*)
+	FROM dls_messages AS d
+	INNER JOIN stations AS s ON s.id = d.station_id
+	WHERE s.is_deleted = false
+	GROUP BY s.tenant_name, s.name, d.message_type`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msgs_count_by_station", query)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessagesCount{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.DlsMessagesCount{}, err
+	}
+	defer rows.Close()
+	counts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessagesCount])
+	if err != nil {
+		return []models.DlsMessagesCount{}, err
+	}
+	return counts, nil
+}
+
+func GetStationIdsFromDlsMsgs(tenantName string) (map[int]string, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	stationIds := map[int]string{}
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return stationIds, err
+	}
+	defer conn.Release()
+	query := `SELECT DISTINCT station_id FROM dls_messages WHERE tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_ids_in_dls_messages", query)
+	if err != nil {
+		return stationIds, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
 	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.Station{}, err
+		return stationIds, err
 	}
 	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
-	if err != nil {
-		return []models.Station{}, err
-	}
-	if len(stations) == 0 {
-		return []models.Station{}, nil
+
+	for rows.Next() {
+		var stationId int
+		err := rows.Scan(&stationId)
+		if err != nil {
+			return stationIds, err
+		}
+		stationIds[stationId] = ""
 	}
-	return stations, nil
+	return stationIds, nil
 }
 
-func GetActiveStations() ([]models.Station, error) {
+// GetDlsMsgsCountPerStation counts the dead-letter messages of every station of the tenant in one query
+func GetDlsMsgsCountPerStation(tenantName string) (map[int]int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	counts := map[int]int{}
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Station{}, err
+		return counts, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations AS s WHERE s.is_deleted = false`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations", query)
+	query := `SELECT station_id, COUNT(*) FROM dls_messages WHERE tenant_name = $1 GROUP BY station_id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msgs_count_per_station", query)
 	if err != nil {
-		return []models.Station{}, err
+		return counts, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
-	if err != nil {
-		return []models.Station{}, err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.Station{}, err
+		return counts, err
 	}
-	if len(stations) == 0 {
-		return []models.Station{}, nil
+	defer rows.Close()
+	for rows.Next() {
+		var stationId, count int
+		err := rows.Scan(&stationId, &count)
+		if err != nil {
+			return counts, err
+		}
+		counts[stationId] = count
 	}
-	return stations, nil
+	return counts, rows.Err()
 }
 
-func GetStationByName(name string, tenantName string) (bool, models.Station, error) {
+func DeleteDlsMsgsByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Station{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations WHERE name = $1 AND (is_deleted = false) AND tenant_name = $2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_station_by_name", query)
-	if err != nil {
-		return false, models.Station{}, err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+
+	removeUserQuery := `DELETE FROM dls_messages WHERE tenant_name = $1`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_dls_msgs_by_tenant", removeUserQuery)
 	if err != nil {
-		return false, models.Station{}, err
+		return err
 	}
-	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return false, models.Station{}, err
-	}
-	if len(stations) == 0 {
-		return false, models.Station{}, nil
+		return err
 	}
-	return true, stations[0], nil
+
+	return nil
+
 }
 
-func GetStationsByDlsStationName(name string, tenantName string) ([]models.Station, error) {
+func GetMinMaxIdsOfDlsMsgsByUpdatedAt(tenantName string, updatedAt time.Time, stationId int) (int, int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Station{}, err
+		return -1, -1, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations WHERE dls_station = $1 AND (is_deleted = false) AND tenant_name = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_stations_act_as_dls_station_by_name", query)
+	query := `
+		WITH limited_rows AS (
+		SELECT id
+		FROM dls_messages
+		WHERE tenant_name = $1 AND updated_at <= $2 AND message_type = 'poison' AND station_id = $3
+		)
+		SELECT
+		(SELECT MIN(id) FROM limited_rows) AS min_id,
+		(SELECT MAX(id) FROM limited_rows) AS max_id
+		FROM limited_rows;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_min_max_id_dls_msgs_by_updated_at", query)
 	if err != nil {
-		return []models.Station{}, err
+		return -1, -1, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, updatedAt, stationId)
 	if err != nil {
-		return []models.Station{}, err
+		return -1, -1, err
 	}
 	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
-	if err != nil {
-		return []models.Station{}, err
-	}
-	if len(stations) == 0 {
-		return []models.Station{}, nil
+
+	var minId, maxId int
+	if rows.Next() {
+		err := rows.Scan(&minId, &maxId)
+		if err != nil {
+			return -1, -1, err
+		}
 	}
-	return stations, nil
+	return minId, maxId, nil
 }
 
-func GetStationById(stationId int, tenantName string) (bool, models.Station, error) {
+func GetDlsMsgsBatch(tenantName string, min, max, stationId int) (bool, []models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Station{}, err
+		return false, []models.DlsMessage{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations WHERE id = $1 AND (is_deleted = false) AND tenant_name = $2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_station_by_id", query)
+	query := `
+		SELECT *
+		FROM dls_messages
+		WHERE tenant_name = $1 AND message_type = 'poison' AND station_id = $2 AND id > $3 AND id <= $4 ORDER BY id ASC LIMIT 10
+		`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msgs_batch_between_min_max_id", query)
 	if err != nil {
-		return false, models.Station{}, err
+		return false, []models.DlsMessage{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, stationId, min, max)
 	if err != nil {
-		return false, models.Station{}, err
+		return false, []models.DlsMessage{}, err
 	}
 	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+
+	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
 	if err != nil {
-		return false, models.Station{}, err
+		return false, []models.DlsMessage{}, err
 	}
-	if len(stations) == 0 {
-		return false, models.Station{}, nil
+	if len(dlsMsgs) == 0 {
+		return false, []models.DlsMessage{}, nil
 	}
-	return true, stations[0], nil
+	return true, dlsMsgs, nil
 }
 
-func InsertNewStation(
-	stationName string,
-	userId int,
-	username string,
-	retentionType string,
-	retentionValue int,
-	storageType string,
-	replicas int,
-	schemaName string,
-	schemaVersionUpdate int,
-	idempotencyWindow int64,
-	isNative bool,
-	dlsConfiguration models.DlsConfiguration,
-	tieredStorageEnabled bool,
-	tenantName string,
-	partitionsList []int,
-	version int,
-	dlsStationName string) (models.Station, int64, error) {
+func GetDlsMsgsByStationId(stationId int) ([]models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Station{}, 0, err
+		return []models.DlsMessage{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO stations ( 
-		name, 
-		retention_type, 
-		retention_value,
-		storage_type, 
-		replicas, 
-		created_by, 
-		created_by_username,
-		created_at, 
-		updated_at, 
-		is_deleted, 
-		schema_name,
-		schema_version_number,
-		idempotency_window_ms, 
-		is_native, 
-		dls_configuration_poison, 
-		dls_configuration_schemaverse,
-		tiered_storage_enabled,
-		tenant_name,
-		partitions,
-		version,
-		dls_station
-		) 
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_station", query)
+	query := `SELECT *
+			FROM dls_messages AS dlsm
+			WHERE
+			dlsm.station_id = $1
+			ORDER BY dlsm.updated_at DESC limit 1000
+			`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_station_id", query)
 	if err != nil {
-		return models.Station{}, 0, err
-	}
-
-	createAt := time.Now()
-	updatedAt := time.Now()
-	var stationId int
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+		return []models.DlsMessage{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name,
-		stationName, retentionType, retentionValue, storageType, replicas, userId, username, createAt, updatedAt,
-		false, schemaName, schemaVersionUpdate, idempotencyWindow, isNative, dlsConfiguration.Poison, dlsConfiguration.Schemaverse, tieredStorageEnabled, tenantName, partitionsList, version, dlsStationName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
 	if err != nil {
-		return models.Station{}, 0, err
+		return []models.DlsMessage{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&stationId)
-		if err != nil {
-			return models.Station{}, 0, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Station{}, 0, errors.New("Station" + stationName + " already exists")
-				} else {
-					return models.Station{}, 0, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Station{}, 0, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Station{}, 0, err
-		}
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	if err != nil {
+		return []models.DlsMessage{}, err
 	}
-
-	newStation := models.Station{
-		ID:                          stationId,
-		Name:                        stationName,
-		CreatedBy:                   userId,
-		CreatedByUsername:           username,
-		CreatedAt:                   createAt,
-		IsDeleted:                   false,
-		RetentionType:               retentionType,
-		RetentionValue:              retentionValue,
-		StorageType:                 storageType,
-		Replicas:                    replicas,
-		UpdatedAt:                   updatedAt,
-		SchemaName:                  schemaName,
-		SchemaVersionNumber:         schemaVersionUpdate,
-		IdempotencyWindow:           idempotencyWindow,
-		IsNative:                    isNative,
-		DlsConfigurationPoison:      dlsConfiguration.Poison,
-		DlsConfigurationSchemaverse: dlsConfiguration.Schemaverse,
-		TieredStorageEnabled:        tieredStorageEnabled,
-		TenantName:                  tenantName,
-		PartitionsList:              partitionsList,
-		Version:                     version,
-		DlsStation:                  dlsStationName,
+	if len(dlsMsgs) == 0 {
+		return []models.DlsMessage{}, nil
 	}
 
-	rowsAffected := rows.CommandTag().RowsAffected()
-	return newStation, rowsAffected, nil
+	return dlsMsgs, nil
 }
 
-func GetAllStationsWithNoHA3() ([]models.Station, error) {
+func GetDlsMsgsByStationAndPartition(stationId, partitionNumber int) ([]models.DlsMessage, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessage{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations WHERE replicas = 1 OR replicas = 5`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_with_no_ha_3", query)
+	query := `SELECT *
+			FROM dls_messages AS dlsm
+			WHERE dlsm.station_id = $1 AND dlsm.partition_number = $2
+			ORDER BY updated_at DESC limit 1000;
+			`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_station_and_partition", query)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessage{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, partitionNumber)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessage{}, err
 	}
 	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
 	if err != nil {
-		return []models.Station{}, err
+		return []models.DlsMessage{}, err
 	}
-	if len(stations) == 0 {
-		return []models.Station{}, err
+	if len(dlsMsgs) == 0 {
+		return []models.DlsMessage{}, nil
 	}
-	return stations, nil
+
+	return dlsMsgs, nil
 }
 
-func GetAllStationsDetailsPerTenant(tenantName string) ([]models.ExtendedStation, error) {
+// Tenants functions
+func UpsertTenant(name string, encryptrdInternalWSPass string) (models.Tenant, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedStation{}, err
+		return models.Tenant{}, err
 	}
 	defer conn.Release()
-	query := `
-	SELECT s.*, COALESCE(p.id, 0),  
-	COALESCE(p.name, ''), 
-	COALESCE(p.station_id, 0), 
-	COALESCE(p.type, 'application'), 
-	COALESCE(p.connection_id, ''), 
-	COALESCE(p.is_active, false), 
-	COALESCE(p.updated_at, CURRENT_TIMESTAMP), 
-	COALESCE(c.id, 0),  
-	COALESCE(c.name, ''), 
-	COALESCE(c.station_id, 0), 
-	COALESCE(c.type, 'application'), 
-	COALESCE(c.connection_id, ''),
-	COALESCE(c.consumers_group, ''),
-	COALESCE(c.max_ack_time_ms, 0), 
-	COALESCE(c.is_active, false), 
-	COALESCE(c.updated_at, CURRENT_TIMESTAMP), 
-	COALESCE(c.max_msg_deliveries, 0), 
-	COALESCE(c.start_consume_from_seq, 0),
-	COALESCE(c.last_msgs, 0) 
-	FROM stations AS s
-	LEFT JOIN producers AS p
-	ON s.id = p.station_id 
-	LEFT JOIN consumers AS c 
-	ON s.id = c.station_id
-	WHERE s.is_deleted = false AND s.tenant_name = $1
-	GROUP BY s.id,p.id,c.id`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details_per_tenant", query)
+
+	query := `INSERT INTO tenants (name, internal_ws_pass) VALUES($1, $2) ON CONFLICT (name) DO NOTHING`
+
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_tenant", query)
 	if err != nil {
-		return []models.ExtendedStation{}, err
+		return models.Tenant{}, err
 	}
 
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	var tenantId int
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, encryptrdInternalWSPass)
 	if err != nil {
-		return []models.ExtendedStation{}, err
-	}
-	if err == pgx.ErrNoRows {
-		return []models.ExtendedStation{}, nil
+		return models.Tenant{}, err
 	}
 	defer rows.Close()
-	stationsMap := map[int]models.ExtendedStation{}
 	for rows.Next() {
-		var stationRes models.Station
-		var producer models.Producer
-		var consumer models.Consumer
-		if err := rows.Scan(
-			&stationRes.ID,
-			&stationRes.Name,
-			&stationRes.RetentionType,
-			&stationRes.RetentionValue,
-			&stationRes.StorageType,
-			&stationRes.Replicas,
-			&stationRes.CreatedBy,
-			&stationRes.CreatedByUsername,
-			&stationRes.CreatedAt,
-			&stationRes.UpdatedAt,
-			&stationRes.IsDeleted,
-			&stationRes.SchemaName,
-			&stationRes.SchemaVersionNumber,
-			&stationRes.IdempotencyWindow,
-			&stationRes.IsNative,
-			&stationRes.DlsConfigurationPoison,
-			&stationRes.DlsConfigurationSchemaverse,
-			&stationRes.TieredStorageEnabled,
-			&stationRes.TenantName,
-			&stationRes.ResendDisabled,
-			&producer.ID,
-			&producer.Name,
-			&producer.StationId,
-			&producer.Type,
-			&producer.ConnectionId,
-			&producer.IsActive,
-			&producer.UpdatedAt,
-			&consumer.ID,
-			&consumer.Name,
-			&consumer.StationId,
-			&consumer.Type,
-			&consumer.ConnectionId,
-			&consumer.ConsumersGroup,
-			&consumer.MaxAckTimeMs,
-			&consumer.IsActive,
-			&consumer.UpdatedAt,
-			&consumer.MaxMsgDeliveries,
-			&consumer.StartConsumeFromSeq,
-			&consumer.LastMessages,
-		); err != nil {
-			return []models.ExtendedStation{}, err
+		err := rows.Scan(&tenantId)
+		if err != nil {
+			return models.Tenant{}, err
 		}
-		if _, ok := stationsMap[stationRes.ID]; ok {
-			tempStation := stationsMap[stationRes.ID]
-			if producer.ID != 0 {
-				tempStation.Producers = append(tempStation.Producers, producer)
-			}
-			if consumer.ID != 0 {
-				tempStation.Consumers = append(tempStation.Consumers, consumer)
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Tenant{}, errors.New("Tenant " + name + " already exists")
+				} else {
+					return models.Tenant{}, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.Tenant{}, errors.New(pgErr.Message)
 			}
-			stationsMap[stationRes.ID] = tempStation
 		} else {
-			producers := []models.Producer{}
-			consumers := []models.Consumer{}
-			if producer.ID != 0 {
-				producers = append(producers, producer)
-			}
-			if consumer.ID != 0 {
-				consumers = append(consumers, consumer)
-			}
-			if tenantName != conf.GlobalAccount {
-				tenantName = strings.ToLower(tenantName)
-			}
-			station := models.ExtendedStation{
-				ID:                          stationRes.ID,
-				Name:                        stationRes.Name,
-				RetentionType:               stationRes.RetentionType,
-				RetentionValue:              stationRes.RetentionValue,
-				StorageType:                 stationRes.StorageType,
-				Replicas:                    stationRes.Replicas,
-				CreatedBy:                   stationRes.CreatedBy,
-				CreatedAt:                   stationRes.CreatedAt,
-				UpdatedAt:                   stationRes.UpdatedAt,
-				IdempotencyWindow:           stationRes.IdempotencyWindow,
-				IsNative:                    stationRes.IsNative,
-				DlsConfigurationPoison:      stationRes.DlsConfigurationPoison,
-				DlsConfigurationSchemaverse: stationRes.DlsConfigurationSchemaverse,
-				Producers:                   producers,
-				Consumers:                   consumers,
-				TieredStorageEnabled:        stationRes.TieredStorageEnabled,
-				TenantName:                  tenantName,
-			}
-			stationsMap[station.ID] = station
+			return models.Tenant{}, err
 		}
 	}
-	if err := rows.Err(); err != nil {
-		return []models.ExtendedStation{}, err
-	}
-	stations := getFilteredExtendedStations(stationsMap)
-	return stations, nil
-}
-
-func GetAllStationsDetailsLight(tenantName string) ([]models.ExtendedStationLight, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return []models.ExtendedStationLight{}, err
-	}
-	defer conn.Release()
 
-	query := `
-	SELECT s.*,
-	false AS is_active
-	FROM stations AS s
-	WHERE s.is_deleted = false AND s.tenant_name = $1
-	ORDER BY s.updated_at DESC
-	LIMIT 5000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details_for_main_overview", query)
-	if err != nil {
-		return []models.ExtendedStationLight{}, err
+	newTenant := models.Tenant{
+		Name: name,
 	}
 
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
-	if err != nil {
-		return []models.ExtendedStationLight{}, err
-	}
-	if err == pgx.ErrNoRows {
-		return []models.ExtendedStationLight{}, nil
-	}
-	defer rows.Close()
-	stations := []models.ExtendedStationLight{}
-	for rows.Next() {
-		var stationRes models.ExtendedStationLight
-		if err := rows.Scan(
-			&stationRes.ID,
-			&stationRes.Name,
-			&stationRes.RetentionType,
-			&stationRes.RetentionValue,
-			&stationRes.StorageType,
-			&stationRes.Replicas,
-			&stationRes.CreatedBy,
-			&stationRes.CreatedByUsername,
-			&stationRes.CreatedAt,
-			&stationRes.UpdatedAt,
-			&stationRes.IsDeleted,
-			&stationRes.SchemaName,
-			&stationRes.SchemaVersionNumber,
-			&stationRes.IdempotencyWindow,
-			&stationRes.IsNative,
-			&stationRes.DlsConfigurationPoison,
-			&stationRes.DlsConfigurationSchemaverse,
-			&stationRes.TieredStorageEnabled,
-			&stationRes.TenantName,
-			&stationRes.ResendDisabled,
-			&stationRes.PartitionsList,
-			&stationRes.Version,
-			&stationRes.DlsStation,
-			&stationRes.FunctionsLockHeld,
-			&stationRes.FunctionsLockedAt,
-			&stationRes.Activity,
-		); err != nil {
-			return []models.ExtendedStationLight{}, err
+	//After creation a tenant we update the users table and create fk
+	//(we can't do alter and create fk in tables before global tenant exists in tenants table)
+	queryAlterUsersTable := `
+	ALTER TABLE IF EXISTS users ADD CONSTRAINT fk_tenant_name_users FOREIGN KEY (tenant_name) REFERENCES tenants (name);
+	ALTER TABLE IF EXISTS configurations ADD CONSTRAINT fk_tenant_name_configurations FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS schemas ADD CONSTRAINT fk_tenant_name_schemas FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS tags ADD CONSTRAINT fk_tenant_name_tags FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS consumers ADD CONSTRAINT fk_tenant_name_consumers FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS stations ADD CONSTRAINT fk_tenant_name_stations FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS schema_versions ADD CONSTRAINT fk_tenant_name_schemaverse FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+	ALTER TABLE IF EXISTS producers ADD CONSTRAINT fk_tenant_name_producers FOREIGN KEY(tenant_name) REFERENCES tenants(name);`
+	// we remove alter from dls_msgs table because dls msgs with nats compatability
+	// TODO: add this alter and solve dls messages nats compatability
+	// ALTER TABLE IF EXISTS dls_messages ADD CONSTRAINT fk_tenant_name_dls_msgs FOREIGN KEY(tenant_name) REFERENCES tenants(name);
+
+	_, err = conn.Conn().Exec(ctx, queryAlterUsersTable)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		errPg := errors.As(err, &pgErr)
+		if errPg && !strings.Contains(pgErr.Message, "already exists") {
+			return models.Tenant{}, err
 		}
-		stationRes.TenantName = tenantName
-		stations = append(stations, stationRes)
 	}
-	if err := rows.Err(); err != nil {
-		return []models.ExtendedStationLight{}, err
-	}
-	return stations, nil
+	return newTenant, nil
 }
 
-func GetStationsLight(tenantName string) ([]models.StationLight, error) {
+func UpsertBatchOfTenants(tenants []models.TenantForUpsert) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.StationLight{}, err
+		return err
 	}
 	defer conn.Release()
 
-	query := `
-	SELECT s.id, s.name, s.schema_name,
-	(SELECT COUNT(*) FROM dls_messages dm WHERE dm.station_id = s.id) AS dls_count
-	FROM stations AS s
-	WHERE s.is_deleted = false AND s.tenant_name = $1
-	ORDER BY s.updated_at DESC
-	LIMIT 40000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_stations_light", query)
+	valueStrings := make([]string, 0, len(tenants))
+	valueArgs := make([]interface{}, 0, len(tenants))
+	for i, tenant := range tenants {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
+		valueArgs = append(valueArgs, tenant.Name)
+		valueArgs = append(valueArgs, tenant.InternalWSPass)
+	}
+	query := fmt.Sprintf("INSERT INTO tenants (name, internal_ws_pass) VALUES %s ON CONFLICT (name) DO NOTHING", strings.Join(valueStrings, ","))
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_tenants", query)
 	if err != nil {
-		return []models.StationLight{}, err
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, valueArgs...)
+	if err != nil {
+		return err
 	}
 
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	return nil
+}
+
+func GetGlobalTenant() (bool, models.Tenant, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.StationLight{}, err
+		return false, models.Tenant{}, err
 	}
-	if err == pgx.ErrNoRows {
-		return []models.StationLight{}, nil
+	defer conn.Release()
+	query := `SELECT * FROM tenants WHERE name = '$memphis' LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_global_tenant", query)
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return false, models.Tenant{}, err
 	}
 	defer rows.Close()
-	stations := []models.StationLight{}
-	for rows.Next() {
-		var stationRes models.StationLight
-		if err := rows.Scan(
-			&stationRes.ID,
-			&stationRes.Name,
-			&stationRes.SchemaName,
-			&stationRes.DlsMsgs,
-		); err != nil {
-			return []models.StationLight{}, err
-		}
-		stations = append(stations, stationRes)
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	if err != nil {
+		return false, models.Tenant{}, err
 	}
-	if err := rows.Err(); err != nil {
-		return []models.StationLight{}, err
+	if len(tenants) == 0 {
+		return false, models.Tenant{}, nil
 	}
-	return stations, nil
+	return true, tenants[0], nil
 }
 
-func GetAllStationsWithActiveProducersConsumersPerTenant(tenantName string) ([]models.ActiveProducersConsumersDetails, error) {
+func GetAllTenants() ([]models.Tenant, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ActiveProducersConsumersDetails{}, err
+		return []models.Tenant{}, err
 	}
 	defer conn.Release()
-	query := `
-	SELECT s.id,
-			   COUNT(DISTINCT CASE WHEN p.is_active THEN p.id END) FILTER (WHERE p.is_active = true) AS active_producers_count,
-			   COUNT(DISTINCT CASE WHEN c.is_active THEN c.id END) FILTER (WHERE c.is_active = true) AS active_consumers_count
-		FROM stations AS s
-		LEFT JOIN producers AS p ON s.id = p.station_id AND p.is_active = true
-		LEFT JOIN consumers AS c ON s.id = c.station_id AND c.is_active = true
-		WHERE s.is_deleted = false AND s.tenant_name = $1
-	GROUP BY s.id;
-`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_producers_consumers_of_station", query)
+	query := `SELECT * FROM tenants`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_tenants", query)
 	if err != nil {
-		return []models.ActiveProducersConsumersDetails{}, err
+		return []models.Tenant{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return []models.ActiveProducersConsumersDetails{}, err
-	}
-	if err == pgx.ErrNoRows {
-		return []models.ActiveProducersConsumersDetails{}, nil
+		return []models.Tenant{}, err
 	}
 	defer rows.Close()
-	stations := []models.ActiveProducersConsumersDetails{}
-	for rows.Next() {
-		var stationId int
-		var activeProducersCount int
-		var activeConsumersCount int
-		if err := rows.Scan(
-			&stationId,
-			&activeProducersCount,
-			&activeConsumersCount,
-		); err != nil {
-			return []models.ActiveProducersConsumersDetails{}, err
-		}
-		station := models.ActiveProducersConsumersDetails{
-			ID:                   stationId,
-			ActiveProducersCount: activeProducersCount,
-			ActiveConsumersCount: activeConsumersCount,
-		}
-		stations = append(stations, station)
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	if err != nil {
+		return []models.Tenant{}, err
 	}
-	if err := rows.Err(); err != nil {
-		return []models.ActiveProducersConsumersDetails{}, err
+	if len(tenants) == 0 {
+		return []models.Tenant{}, nil
 	}
-	return stations, nil
+	return tenants, nil
 }
 
-func GetAllStations() ([]models.Station, error) {
+func GetAllTenantsWithoutGlobal() ([]models.Tenant, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.Tenant{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM stations`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations", query)
+	query := `SELECT * FROM tenants WHERE name != $1 AND name != $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_tenants_without_global", query)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.Tenant{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, conf.MemphisGlobalAccountName, conf.GlobalAccount)
 	if err != nil {
-		return []models.Station{}, err
+		return []models.Tenant{}, err
 	}
 	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
 	if err != nil {
-		return []models.Station{}, err
+		return []models.Tenant{}, err
 	}
-	if len(stations) == 0 {
-		return []models.Station{}, err
+	if len(tenants) == 0 {
+		return []models.Tenant{}, nil
 	}
-	return stations, nil
+	return tenants, nil
 }
 
-func CountStationsByTenant(tenantName string) (int, error) {
+func IsTenantExists(tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM stations where tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_count_stations_by_tenant", query)
+
+	query := `SELECT EXISTS(SELECT 1 FROM tenants WHERE name = $1 LIMIT 1)`
+	stmt, err := conn.Conn().Prepare(ctx, "is_tenant_exists", query)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	var count int
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+
+	var exists bool
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&exists)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	return count, nil
+
+	return exists, nil
 }
 
-func GetAllStationsDetails() ([]models.ExtendedStation, error) {
+func GetTenantById(id int) (bool, models.Tenant, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedStation{}, err
+		return false, models.Tenant{}, err
 	}
 	defer conn.Release()
-	query := `
-	SELECT s.*, COALESCE(p.id, 0),  
-	COALESCE(p.name, ''), 
-	COALESCE(p.station_id, 0), 
-	COALESCE(p.type, 'application'), 
-	COALESCE(p.connection_id, ''), 
-	COALESCE(p.created_by, 0), 
-	COALESCE(p.created_by_username, ''), 
-	COALESCE(p.is_active, false), 
-	COALESCE(p.created_at, CURRENT_TIMESTAMP), 
-	COALESCE(p.is_deleted, false),
-	COALESCE(p.tenant_name, ''),  
-	COALESCE(c.id, 0),  
-	COALESCE(c.name, ''), 
-	COALESCE(c.station_id, 0), 
-	COALESCE(c.type, 'application'), 
-	COALESCE(c.connection_id, ''),
-	COALESCE(c.consumers_group, ''),
-	COALESCE(c.max_ack_time_ms, 0), 
-	COALESCE(c.created_by, 0), 
-	COALESCE(c.created_by_username, ''), 
-	COALESCE(c.is_active, false), 
-	COALESCE(c.created_at, CURRENT_TIMESTAMP), 
-	COALESCE(c.is_deleted, false), 
-	COALESCE(c.max_msg_deliveries, 0), 
-	COALESCE(c.start_consume_from_seq, 0),
-	COALESCE(c.last_msgs, 0),
-	COALESCE(c.tenant_name, '') 
-	FROM stations AS s
-	LEFT JOIN producers AS p
-	ON s.id = p.station_id 
-	LEFT JOIN consumers AS c 
-	ON s.id = c.station_id
-	WHERE s.is_deleted = false
-	GROUP BY s.id,p.id,c.id`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_stations_details", query)
+	query := `SELECT * FROM tenants AS c WHERE id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tenant_by_id", query)
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	defer rows.Close()
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	if len(tenants) == 0 {
+		return false, models.Tenant{}, nil
+	}
+	return true, tenants[0], nil
+}
+
+func GetTenantByName(name string) (bool, models.Tenant, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM tenants AS c WHERE name = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tenant_by_name", query)
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+
+	if name != conf.GlobalAccount {
+		name = strings.ToLower(name)
+	}
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name)
 	if err != nil {
-		return []models.ExtendedStation{}, err
+		return false, models.Tenant{}, err
+	}
+	defer rows.Close()
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	if err != nil {
+		return false, models.Tenant{}, err
+	}
+	if len(tenants) == 0 {
+		return false, models.Tenant{}, nil
 	}
+	return true, tenants[0], nil
+}
 
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+func CreateTenant(name, firebaseOrganizationId, encryptrdInternalWSPass, organizationName string, brandData models.BrandColors) (models.Tenant, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedStation{}, err
+		return models.Tenant{}, err
 	}
-	if err == pgx.ErrNoRows {
-		return []models.ExtendedStation{}, nil
+	defer conn.Release()
+
+	var colors any
+	colors = brandData.Colors
+	if brandData.Colors == nil || len(brandData.Colors) == 0 {
+		colors = []string{}
+	}
+
+	query := `INSERT INTO tenants 
+	(id, name, firebase_organization_id, internal_ws_pass, organization_name, dark_icon, light_icon, brand_colors)
+	VALUES(nextval('tenants_seq'),$1, $2, $3, $4, $5, $6, $7)`
+
+	stmt, err := conn.Conn().Prepare(ctx, "create_new_tenant", query)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+
+	var tenantId int
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, firebaseOrganizationId, encryptrdInternalWSPass, organizationName, brandData.DarkIcon, brandData.LightIcon, colors)
+	if err != nil {
+		return models.Tenant{}, err
 	}
 	defer rows.Close()
-	stationsMap := map[int]models.ExtendedStation{}
 	for rows.Next() {
-		var stationRes models.Station
-		var producer models.Producer
-		var consumer models.Consumer
-		if err := rows.Scan(
-			&stationRes.ID,
-			&stationRes.Name,
-			&stationRes.RetentionType,
-			&stationRes.RetentionValue,
-			&stationRes.StorageType,
-			&stationRes.Replicas,
-			&stationRes.CreatedBy,
-			&stationRes.CreatedByUsername,
-			&stationRes.CreatedAt,
-			&stationRes.UpdatedAt,
-			&stationRes.IsDeleted,
-			&stationRes.SchemaName,
-			&stationRes.SchemaVersionNumber,
-			&stationRes.IdempotencyWindow,
-			&stationRes.IsNative,
-			&stationRes.DlsConfigurationPoison,
-			&stationRes.DlsConfigurationSchemaverse,
-			&stationRes.TieredStorageEnabled,
-			&stationRes.TenantName,
-			&stationRes.FunctionsLockHeld,
-			&stationRes.FunctionsLockedAt,
-			&producer.ID,
-			&producer.Name,
-			&producer.StationId,
-			&producer.Type,
-			&producer.ConnectionId,
-			&producer.IsActive,
-			&producer.UpdatedAt,
-			&producer.TenantName,
-			&consumer.ID,
-			&consumer.Name,
-			&consumer.StationId,
-			&consumer.Type,
-			&consumer.ConnectionId,
-			&consumer.ConsumersGroup,
-			&consumer.MaxAckTimeMs,
-			&consumer.IsActive,
-			&consumer.UpdatedAt,
-			&consumer.MaxMsgDeliveries,
-			&consumer.StartConsumeFromSeq,
-			&consumer.LastMessages,
-			&consumer.TenantName,
-		); err != nil {
-			return []models.ExtendedStation{}, err
-		}
-		if _, ok := stationsMap[stationRes.ID]; ok {
-			tempStation := stationsMap[stationRes.ID]
-			if producer.ID != 0 {
-				tempStation.Producers = append(tempStation.Producers, producer)
-			}
-			if consumer.ID != 0 {
-				tempStation.Consumers = append(tempStation.Consumers, consumer)
-			}
-			stationsMap[stationRes.ID] = tempStation
-		} else {
-			producers := []models.Producer{}
-			consumers := []models.Consumer{}
-			if producer.ID != 0 {
-				producers = append(producers, producer)
-			}
-			if consumer.ID != 0 {
-				consumers = append(consumers, consumer)
-			}
-			station := models.ExtendedStation{
-				ID:                          stationRes.ID,
-				Name:                        stationRes.Name,
-				RetentionType:               stationRes.RetentionType,
-				RetentionValue:              stationRes.RetentionValue,
-				StorageType:                 stationRes.StorageType,
-				Replicas:                    stationRes.Replicas,
-				CreatedBy:                   stationRes.CreatedBy,
-				CreatedAt:                   stationRes.CreatedAt,
-				UpdatedAt:                   stationRes.UpdatedAt,
-				IdempotencyWindow:           stationRes.IdempotencyWindow,
-				IsNative:                    stationRes.IsNative,
-				DlsConfigurationPoison:      stationRes.DlsConfigurationPoison,
-				DlsConfigurationSchemaverse: stationRes.DlsConfigurationSchemaverse,
-				Producers:                   producers,
-				Consumers:                   consumers,
-				TieredStorageEnabled:        stationRes.TieredStorageEnabled,
-			}
-			stationsMap[station.ID] = station
+		err := rows.Scan(&tenantId)
+		if err != nil {
+			return models.Tenant{}, err
 		}
 	}
-	if err := rows.Err(); err != nil {
-		return []models.ExtendedStation{}, err
-	}
-	stations := getFilteredExtendedStations(stationsMap)
-	return stations, nil
-}
 
-func getFilteredExtendedStations(stationsMap map[int]models.ExtendedStation) []models.ExtendedStation {
-	stations := []models.ExtendedStation{}
-	for _, station := range stationsMap {
-		producersMap := map[string]models.Producer{}
-		for _, p := range station.Producers {
-			if _, ok := producersMap[p.Name]; ok {
-				if producersMap[p.Name].UpdatedAt.Before(p.UpdatedAt) {
-					producersMap[p.Name] = p
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.Tenant{}, errors.New("Tenant " + name + " already exists")
+				} else {
+					return models.Tenant{}, errors.New(pgErr.Detail)
 				}
 			} else {
-				producersMap[p.Name] = p
+				return models.Tenant{}, errors.New(pgErr.Message)
 			}
-		}
-		producers := []models.Producer{}
-		for _, p := range producersMap {
-			producers = append(producers, p)
+		} else {
+			return models.Tenant{}, err
 		}
 
-		consumersMap := map[string]models.Consumer{}
-		for _, c := range station.Consumers {
-			if _, ok := consumersMap[c.Name]; ok {
-				if consumersMap[c.Name].UpdatedAt.Before(c.UpdatedAt) {
-					consumersMap[c.Name] = c
-				}
-			} else {
-				consumersMap[c.Name] = c
-			}
-		}
-		consumers := []models.Consumer{}
-		for _, c := range consumersMap {
-			consumers = append(consumers, c)
-		}
-		station.Consumers = consumers
-		station.Producers = producers
-		stations = append(stations, station)
 	}
-	return stations
+
+	newTenant := models.Tenant{
+		Name: name,
+	}
+
+	return newTenant, nil
 }
 
-func DeleteStationsByNames(stationNames []string, tenantName string) error {
+func RemoveTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM stations
-	WHERE name = ANY($1)
-	AND (is_deleted = false)
-	AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_stations_by_names", query)
+
+	removeTenants := `DELETE FROM tenants WHERE name = $1`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_tenant", removeTenants)
 	if err != nil {
 		return err
 	}
+
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, stationNames, tenantName)
+
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func RemoveDeletedStations() error {
+func RenameTag(name, newName, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM stations WHERE is_deleted = true`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_deleted_stations", query)
+	query := `UPDATE tags SET name = $2 WHERE name = $1 AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "rename_tag", query)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	_, err = conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	tag, err := conn.Conn().Exec(ctx, stmt.Name, name, newName, tenantName)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
-func DeleteStation(name string, tenantName string) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return err
-	}
-	defer conn.Release()
-	query := `DELETE FROM stations
-	WHERE name = $1
-	AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_station", query)
-	if err != nil {
-		return err
-	}
+// MergeTags adds the resources of the source tag to the target tag, without duplicates, and removes the source tag
+func MergeTags(sourceName, targetName, tenantName string) error {
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
-	if err != nil {
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE tags AS t SET
+			stations = ARRAY(SELECT DISTINCT UNNEST(COALESCE(t.stations, '{}') || COALESCE(s.stations, '{}'))),
+			schemas = ARRAY(SELECT DISTINCT UNNEST(COALESCE(t.schemas, '{}') || COALESCE(s.schemas, '{}'))),
+			users = ARRAY(SELECT DISTINCT UNNEST(COALESCE(t.users, '{}') || COALESCE(s.users, '{}')))
+		FROM tags AS s
+		WHERE t.name = $2 AND s.name = $1 AND t.tenant_name = $3 AND s.tenant_name = $3`, sourceName, targetName, tenantName)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM tags WHERE name = $1 AND tenant_name = $2`, sourceName, tenantName)
 		return err
-	}
-	return nil
+	})
 }
 
-func AttachSchemaToStation(stationName string, schemaName string, versionNumber int, tenantName string) error {
+// GetTagResources lists the stations, schemas and users a tag is attached to, an empty entity type lists all of them
+func GetTagResources(tagName, entityType, tenantName string, limit, offset int) ([]models.TagResource, int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.TagResource{}, 0, err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET schema_name = $2, schema_version_number = $3
-	WHERE name = $1 AND is_deleted = false AND tenant_name=$4`
-	stmt, err := conn.Conn().Prepare(ctx, "attach_schema_to_station", query)
+	query := `SELECT r.entity_type, r.name, COUNT(*) OVER() FROM (
+		SELECT 'station' AS entity_type, s.name AS name FROM tags AS t
+		INNER JOIN stations AS s ON s.id = ANY(t.stations)
+		WHERE t.name = $1 AND t.tenant_name = $2 AND s.is_deleted = false
+		UNION ALL
+		SELECT 'schema', sc.name FROM tags AS t
+		INNER JOIN schemas AS sc ON sc.id = ANY(t.schemas)
+		WHERE t.name = $1 AND t.tenant_name = $2
+		UNION ALL
+		SELECT 'user', u.username FROM tags AS t
+		INNER JOIN users AS u ON u.id = ANY(t.users)
+		WHERE t.name = $1 AND t.tenant_name = $2
+	) AS r
+	WHERE $3 = '' OR r.entity_type = $3
+	ORDER BY r.entity_type, r.name
+	LIMIT $4 OFFSET $5`
+	stmt, err := conn.Conn().Prepare(ctx, "get_tag_resources", query)
 	if err != nil {
-		return err
+		return []models.TagResource{}, 0, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, schemaName, versionNumber, tenantName)
-	if err != nil {
-		return err
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tagName, tenantName, entityType, limit, offset)
+	if err != nil {
+		return []models.TagResource{}, 0, err
+	}
+	defer rows.Close()
+	resources := []models.TagResource{}
+	total := 0
+	for rows.Next() {
+		var resource models.TagResource
+		err = rows.Scan(&resource.EntityType, &resource.Name, &total)
+		if err != nil {
+			return []models.TagResource{}, 0, err
+		}
+		resources = append(resources, resource)
+	}
+	if err = rows.Err(); err != nil {
+		return []models.TagResource{}, 0, err
 	}
-	return nil
+	return resources, total, nil
 }
 
-func DetachSchemaFromStation(stationName string, tenantName string) error {
+func GetUnusedTags(tenantName string) ([]models.Tag, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET schema_name = '', schema_version_number = 0
-	WHERE name = $1 AND is_deleted = false AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "detach_schema_from_station", query)
+	query := `SELECT * FROM tags WHERE COALESCE(ARRAY_LENGTH(stations, 1), 0) = 0 AND COALESCE(ARRAY_LENGTH(schemas, 1), 0) = 0 AND COALESCE(ARRAY_LENGTH(users, 1), 0) = 0 AND tenant_name = $1 ORDER BY name`
+	stmt, err := conn.Conn().Prepare(ctx, "get_unused_tags", query)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	if err != nil {
+		return []models.Tag{}, err
+	}
+	if len(tags) == 0 {
+		return []models.Tag{}, nil
+	}
+	return tags, nil
 }
 
-func UpdateStationDlsConfig(stationName string, poison bool, schemaverse bool, tenantName string) error {
+func RemoveUnusedTags(tenantName string) ([]string, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET dls_configuration_poison = $2, dls_configuration_schemaverse = $3
-	WHERE name = $1 AND is_deleted = false AND tenant_name=$4`
-	stmt, err := conn.Conn().Prepare(ctx, "update_station_dls_config", query)
+	query := `DELETE FROM tags WHERE COALESCE(ARRAY_LENGTH(stations, 1), 0) = 0 AND COALESCE(ARRAY_LENGTH(schemas, 1), 0) = 0 AND COALESCE(ARRAY_LENGTH(users, 1), 0) = 0 AND tenant_name = $1 RETURNING name`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_unused_tags", query)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationName, poison, schemaverse, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return []string{}, err
+	}
+	return names, nil
 }
 
-func UpdateStationsOfDeletedUser(userId int, tenantName string) error {
+func RemoveTagsResourcesByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)' AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_stations_of_deleted_user", query)
+
+	query := `DELETE FROM tags WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_tenant_resources_by_tenant", query)
 	if err != nil {
 		return err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UpdateStationsWithNoHA3() error {
+func SetTenantSequence(sequence int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET replicas = 3 WHERE replicas = 1 OR replicas = 5`
-	stmt, err := conn.Conn().Prepare(ctx, "update_stations_with_no_ha_3", query)
-	if err != nil {
-		return err
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name)
+
+	tenant_seq := fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS tenants_seq start %v INCREMENT 1;", sequence)
+
+	_, err = conn.Conn().Exec(ctx, tenant_seq)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UpdateResendDisabledInStations(resendDisabled bool, stationId []int) error {
+func GetAllUsersInDB() (bool, []models.User, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
+
 	defer conn.Release()
-	query := `UPDATE stations SET resend_disabled = $1 WHERE id = ANY($2)`
-	stmt, err := conn.Conn().Prepare(ctx, "update_resend_disabled_in_stations", query)
+	query := `SELECT * FROM users`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user", query)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, resendDisabled, stationId)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	if err != nil {
+		return false, nil, err
+	}
+	err = decryptUsersSecrets(users)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(users) == 0 {
+		return false, nil, nil
+	}
+	return true, users, nil
 }
 
-func RemoveStationsByTenant(tenantName string) error {
+func GetAllUsersAndPermissions() (bool, []models.UserWithPermissions, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
+
 	defer conn.Release()
-	query := `DELETE FROM stations WHERE tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_stations_by_tenant", query)
+	query := `
+		SELECT
+		u.id,
+		u.username,
+		u.password,
+		u.type AS user_type,
+		u.already_logged_in,
+		u.created_at,
+		u.avatar_id,
+		u.full_name,
+		u.subscription,
+		u.skip_get_started,
+		u.tenant_name,
+		u.pending,
+		u.position,
+		u.team,
+		u.owner,
+		u.description,
+		u.last_login,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
+		) AS allow_read_patterns,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
+		) AS allow_write_patterns
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
+		) AS deny_read_patterns,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
+		) AS deny_write_patterns
+		FROM users WHERE username NOT LIKE '$%';` // filter memphis internal users
+
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions", query)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return err
+		return false, nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	var usersWithPermissions []models.UserWithPermissions
+	for rows.Next() {
+		var userWithPermissions models.UserWithPermissions
+		err := rows.Scan(
+			&userWithPermissions.ID,
+			&userWithPermissions.Username,
+			&userWithPermissions.Password,
+			&userWithPermissions.UserType,
+			&userWithPermissions.AlreadyLoggedIn,
+			&userWithPermissions.CreatedAt,
+			&userWithPermissions.AvatarId,
+			&userWithPermissions.FullName,
+			&userWithPermissions.Subscribtion,
+			&userWithPermissions.SkipGetStarted,
+			&userWithPermissions.TenantName,
+			&userWithPermissions.Pending,
+			&userWithPermissions.Position,
+			&userWithPermissions.Team,
+			&userWithPermissions.Owner,
+			&userWithPermissions.Description,
+			&userWithPermissions.LastLogin,
+			&userWithPermissions.Permissions.AllowReadPermissions,
+			&userWithPermissions.Permissions.AllowWritePermissions,
+			&userWithPermissions.Permissions.DenyReadPermissions,
+			&userWithPermissions.Permissions.DenyWritePermissions,
+		)
+		if err != nil {
+			return false, nil, err
+		}
+		userWithPermissions.Password, err = decryptSecret(userWithPermissions.Password)
+		if err != nil {
+			return false, nil, err
+		}
+		usersWithPermissions = append(usersWithPermissions, userWithPermissions)
+	}
+
+	if len(usersWithPermissions) == 0 {
+		return false, nil, nil
+	}
+
+	return true, usersWithPermissions, nil
 }
 
-func GetStationNamesUsingSchema(schemaName string, tenantName string) ([]string, error) {
-	stationNames := []string{}
+func GetAllUsersAndPermissionsByTenant(tenantName string) (bool, []models.UserWithPermissions, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return nil, err
+		return false, nil, err
 	}
+
 	defer conn.Release()
 	query := `
-		SELECT name FROM stations
-		WHERE schema_name = $1 AND is_deleted = false AND tenant_name = $2
-	`
-	stmt, err := conn.Conn().Prepare(ctx, "get_station_names_using_schema", query)
-	if err != nil {
-		return nil, err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+		SELECT
+		u.id,
+		u.username,
+		u.password,
+		u.type AS user_type,
+		u.already_logged_in,
+		u.created_at,
+		u.avatar_id,
+		u.full_name,
+		u.subscription,
+		u.skip_get_started,
+		u.tenant_name,
+		u.pending,
+		u.position,
+		u.team,
+		u.owner,
+		u.description,
+		u.last_login,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
+		) AS allow_read_permissions,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
+		) AS allow_write_permissions,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
+		) AS deny_read_permissions,
+		(
+			SELECT ARRAY_AGG(p.pattern)
+			FROM permissions p
+			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
+		) AS deny_write_permissions
+		FROM users u WHERE username NOT LIKE '$%' AND tenant_name=$1;` // filter memphis internal users
+
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions", query)
+	if err != nil {
+		return false, nil, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaName, tenantName)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return nil, err
+		return false, nil, err
 	}
 	defer rows.Close()
 
+	var usersWithPermissions []models.UserWithPermissions
 	for rows.Next() {
-		var stationName string
-		err := rows.Scan(&stationName)
+		var userWithPermissions models.UserWithPermissions
+		err := rows.Scan(
+			&userWithPermissions.ID,
+			&userWithPermissions.Username,
+			&userWithPermissions.Password,
+			&userWithPermissions.UserType,
+			&userWithPermissions.AlreadyLoggedIn,
+			&userWithPermissions.CreatedAt,
+			&userWithPermissions.AvatarId,
+			&userWithPermissions.FullName,
+			&userWithPermissions.Subscribtion,
+			&userWithPermissions.SkipGetStarted,
+			&userWithPermissions.TenantName,
+			&userWithPermissions.Pending,
+			&userWithPermissions.Position,
+			&userWithPermissions.Team,
+			&userWithPermissions.Owner,
+			&userWithPermissions.Description,
+			&userWithPermissions.LastLogin,
+			&userWithPermissions.Permissions.AllowReadPermissions,
+			&userWithPermissions.Permissions.AllowWritePermissions,
+			&userWithPermissions.Permissions.DenyReadPermissions,
+			&userWithPermissions.Permissions.DenyWritePermissions,
+		)
 		if err != nil {
-			return nil, err
+			return false, nil, err
 		}
-		stationNames = append(stationNames, stationName)
+		userWithPermissions.Password, err = decryptSecret(userWithPermissions.Password)
+		if err != nil {
+			return false, nil, err
+		}
+		usersWithPermissions = append(usersWithPermissions, userWithPermissions)
 	}
 
-	if rows.Err() != nil {
-		return nil, err
+	if len(usersWithPermissions) == 0 {
+		return false, nil, nil
 	}
 
-	return stationNames, nil
-
+	return true, usersWithPermissions, nil
 }
 
-func GetCountStationsUsingSchema(schemaName string, tenantName string) (int, error) {
+// DeleteOldProducersAndConsumers removes the disconnected producers and consumers which were not updated since olderThan,
+// the last consumer of every consumer group is kept so the group is still shown as deleted
+func DeleteOldProducersAndConsumers(olderThan time.Time, tenantName string) (models.PurgedClients, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return models.PurgedClients{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM stations WHERE schema_name = $1 AND is_deleted = false AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_count_stations_using_schema", query)
-	if err != nil {
-		return 0, err
-	}
-	var count int
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaName, tenantName).Scan(&count)
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
 
-func RemoveSchemaFromAllUsingStations(schemaName string, tenantName string) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return err
-	}
-	defer conn.Release()
-	query := `UPDATE stations SET schema_name = '' WHERE schema_name = $1 AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_from_all_using_stations", query)
-	if err != nil {
-		return err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, schemaName, tenantName)
+	tx, err := conn.Begin(ctx)
 	if err != nil {
-		return err
+		return models.PurgedClients{}, err
 	}
-	return nil
-}
+	defer tx.Rollback(ctx)
 
-func GetDeletedStations() ([]models.Station, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return []models.Station{}, err
-	}
-	defer conn.Release()
-	query := `SELECT * FROM stations WHERE is_deleted = true`
-	stmt, err := conn.Conn().Prepare(ctx, "get_not_active_stations", query)
+	query := `DELETE FROM producers WHERE is_active = false AND updated_at < $1 AND tenant_name = $2 RETURNING station_id`
+	rows, err := tx.Query(ctx, query, olderThan, tenantName)
 	if err != nil {
-		return []models.Station{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
-	if err != nil {
-		return []models.Station{}, err
+		return models.PurgedClients{}, err
 	}
-	defer rows.Close()
-	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	stationIds, err := pgx.CollectRows(rows, pgx.RowTo[int])
 	if err != nil {
-		return []models.Station{}, err
+		return models.PurgedClients{}, err
 	}
-	if len(stations) == 0 {
-		return []models.Station{}, err
-	}
-	return stations, nil
-}
 
-func UpdateStationsDls(stationNames []string, dlsName, tenantName string) error {
-	if len(stationNames) == 0 {
-		return nil
-	}
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	query := `UPDATE stations SET dls_station = $1 WHERE name = ANY($2) AND tenant_name=$3`
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	query = `DELETE FROM consumers
+		WHERE is_active = false AND updated_at < $1 AND tenant_name = $2
+		AND id NOT IN (
+			SELECT MIN(id)
+			FROM consumers
+			WHERE is_active = false AND updated_at < $1 AND tenant_name = $2
+			GROUP BY station_id, consumers_group
+		)`
+	tag, err := tx.Exec(ctx, query, olderThan, tenantName)
 	if err != nil {
-		return err
+		return models.PurgedClients{}, err
 	}
-	defer conn.Release()
-	stmt, err := conn.Conn().Prepare(ctx, "update_stations_dls", query)
+
+	err = tx.Commit(ctx)
 	if err != nil {
-		return err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+		return models.PurgedClients{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, dlsName, stationNames, tenantName)
-	if err != nil {
-		return err
+
+	purged := models.PurgedClients{Producers: int64(len(stationIds)), Consumers: tag.RowsAffected()}
+	seen := make(map[int]bool)
+	for _, id := range stationIds {
+		if !seen[id] {
+			seen[id] = true
+			purged.StationIds = append(purged.StationIds, id)
+		}
 	}
-	return nil
+	return purged, nil
 }
 
-func RemoveDlsStationFromAllStations(name, tenantName string) error {
+func RemovePoisonedCg(stationId int, cgName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	query := `UPDATE stations SET dls_station = '' WHERE dls_station = $1 AND tenant_name=$2`
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	connection, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
-	stmt, err := conn.Conn().Prepare(ctx, "remove_dls_station_from_all_stations", query)
+	defer connection.Release()
+
+	tx, err := connection.Conn().Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE dls_messages SET poisoned_cgs = ARRAY_REMOVE(poisoned_cgs, $1) WHERE station_id=$2 ;`
+
+	stmt, err := tx.Prepare(ctx, "update_dls_message", query)
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-// Producer Functions
-func UpdateProducersActiveAndGetDetails(connectionId string, isActive bool) ([]models.LightProducer, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return []models.LightProducer{}, err
-	}
-	defer conn.Release()
-	query := `
-	WITH updated_producers AS (
-		UPDATE producers
-		SET is_active = $2
-		WHERE connection_id = $1 AND is_active = true
-		RETURNING *
-	)
-	SELECT DISTINCT ON (p.name) p.name, s.name, COUNT(*) OVER (PARTITION BY p.name)
-	FROM updated_producers AS p
-	LEFT JOIN stations AS s ON s.id = p.station_id
-	GROUP BY p.name, p.id, s.id
-	LIMIT 5000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producers_by_connection_id_with_station_details", query)
-	if err != nil {
-		return []models.LightProducer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, connectionId, isActive)
-	if err != nil {
-		return []models.LightProducer{}, err
-	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightProducer])
+	rows, err := tx.Query(ctx, stmt.Name, cgName, stationId)
 	if err != nil {
-		return []models.LightProducer{}, err
-	}
-	if len(producers) == 0 {
-		return []models.LightProducer{}, nil
+		return err
 	}
-	return producers, nil
-}
+	rows.Close()
 
-func UpdateProducersConnection(connectionId string, isActive bool) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	query = `DELETE FROM dls_messages WHERE message_type = 'poison' AND poisoned_cgs = '{}' OR poisoned_cgs IS NULL;`
+	stmt, err = tx.Prepare(ctx, "delete_dls_message", query)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
-	query := `UPDATE producers SET is_active = $1 WHERE connection_id = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_producers_connection", query)
+
+	rows, err = tx.Query(ctx, stmt.Name)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	rows.Close()
+
+	err = tx.Commit(ctx)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func GetProducerByID(id int) (bool, models.Producer, error) {
+func DeleteConfByTenantName(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	defer conn.Release()
-	query := `SELECT * FROM producers WHERE id = $1 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_id", query)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	if len(producers) == 0 {
-		return false, models.Producer{}, nil
-	}
-	return true, producers[0], nil
-}
 
-func GetProducerByNameAndConnectionID(name string, connectionId string) (bool, models.Producer, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Producer{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM producers WHERE name = $1 AND connection_id = $2 AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_name_and_connection_id", query)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, connectionId)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+
+	query := `DELETE FROM configurations
+	WHERE tenant_name=$1`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_conf_by_tenant_name", query)
 	if err != nil {
-		return false, models.Producer{}, err
+		return err
 	}
-	if len(producers) == 0 {
-		return false, models.Producer{}, nil
+	tenantName = strings.ToLower(tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
 	}
-	return true, producers[0], nil
+	return nil
 }
 
-func GetProducerByStationIDAndConnectionId(name string, stationId int, connectionId string) (bool, models.Producer, error) {
+func DeleteIntegrationsByTenantName(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Producer{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND connection_id = $3 AND type = 'application' ORDER BY is_active DESC LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_station_id_and_connection_id", query)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId, connectionId)
+
+	query := `DELETE FROM integrations
+	WHERE tenant_name=$1`
+
+	stmt, err := conn.Conn().Prepare(ctx, "remove_integrations_by_tenant_name", query)
 	if err != nil {
-		return false, models.Producer{}, err
+		return err
 	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	tenantName = strings.ToLower(tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return false, models.Producer{}, err
-	}
-	if len(producers) == 0 {
-		return false, models.Producer{}, nil
+		return err
 	}
-	return true, producers[0], nil
+	return nil
 }
 
-func GetProducerByNameAndStationID(name string, stationId int) (bool, models.Producer, error) {
+// Async tasks functions
+func UpsertAsyncTask(task, brokerInCharge string, createdAt time.Time, tenantName string, stationId int, username string) (models.AsyncTask, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Producer{}, err
+		return models.AsyncTask{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND type = 'application' ORDER BY is_active DESC LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producer_by_name_and_station_id", query)
-	if err != nil {
-		return false, models.Producer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId)
+
+	query := `INSERT INTO async_tasks (name, broker_in_charge, created_at, updated_at, tenant_name, station_id, created_by, status) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_async_task", query)
 	if err != nil {
-		return false, models.Producer{}, err
+		return models.AsyncTask{}, err
 	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+
+	updatedAt := createdAt
+	var asyncTask models.AsyncTask
+	err = conn.Conn().QueryRow(ctx, stmt.Name, task, brokerInCharge, createdAt, updatedAt, tenantName, stationId, username, "running").Scan(
+		&asyncTask.ID,
+		&asyncTask.Name,
+		&asyncTask.BrokrInCharge,
+		&asyncTask.CreatedAt,
+		&asyncTask.UpdatedAt,
+		&asyncTask.Data,
+		&asyncTask.TenantName,
+		&asyncTask.StationId,
+		&asyncTask.CreatedBy,
+		&asyncTask.Status,
+		&asyncTask.FailureReason,
+	)
+
 	if err != nil {
-		return false, models.Producer{}, err
-	}
-	if len(producers) == 0 {
-		return false, models.Producer{}, nil
+		if err == pgx.ErrNoRows {
+			ctx, cancelfunc = context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+			defer cancelfunc()
+
+			conn, err = acquireMetadataDbConn(ctx)
+			if err != nil {
+				return models.AsyncTask{}, err
+			}
+			defer conn.Release()
+			// The task already exists, retrieve the existing task
+			queryExistingTask := `
+				SELECT *
+				FROM async_tasks
+				WHERE name = $1 AND tenant_name = $2 AND station_id = $3;
+			`
+			existingStmt, err := conn.Conn().Prepare(ctx, "select_existing_async_task", queryExistingTask)
+			if err != nil {
+				return models.AsyncTask{}, err
+			}
+
+			err = conn.Conn().QueryRow(ctx, existingStmt.Name, task, tenantName, stationId).Scan(
+				&asyncTask.ID,
+				&asyncTask.Name,
+				&asyncTask.BrokrInCharge,
+				&asyncTask.CreatedAt,
+				&asyncTask.UpdatedAt,
+				&asyncTask.Data,
+				&asyncTask.TenantName,
+				&asyncTask.StationId,
+				&asyncTask.CreatedBy,
+			)
+			if err != nil {
+				return models.AsyncTask{}, err
+			}
+		} else {
+			return models.AsyncTask{}, err
+		}
 	}
-	return true, producers[0], nil
+
+	return asyncTask, nil
 }
 
-func GetActiveProducerByStationID(producerName string, stationId int) (bool, models.Producer, error) {
+func GetAsyncTasksByName(task string) (bool, []models.AsyncTask, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Producer{}, err
+		return false, []models.AsyncTask{}, err
 	}
 	defer conn.Release()
 
-	query := `SELECT * FROM producers WHERE name = $1 AND station_id = $2 AND is_active = true AND type = 'application' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_producer_by_station_id", query)
+	query := `SELECT * FROM async_tasks WHERE name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_async_tasks_by_name", query)
 	if err != nil {
-		return false, models.Producer{}, err
+		return false, []models.AsyncTask{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, producerName, stationId)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, task)
 	if err != nil {
-		return false, models.Producer{}, err
+		return false, []models.AsyncTask{}, err
 	}
 	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	asyncTask, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AsyncTask])
 	if err != nil {
-		return false, models.Producer{}, err
+		return false, []models.AsyncTask{}, err
 	}
-	if len(producers) == 0 {
-		return false, models.Producer{}, nil
+	if len(asyncTask) == 0 {
+		return false, []models.AsyncTask{}, nil
 	}
-	return true, producers[0], nil
+	return true, asyncTask, nil
 }
 
-func GetProducersForGraph(tenantName string) ([]models.ProducerForGraph, error) {
+func GetAsyncTaskByNameAndBrokerName(task, brokerName string) (bool, []models.AsyncTask, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ProducerForGraph{}, err
+		return false, []models.AsyncTask{}, err
 	}
 	defer conn.Release()
-	query := `SELECT p.name, p.station_id, p.app_id, p.type
-				FROM producers AS p
-				WHERE p.tenant_name = $1 AND p.is_active = true
-				ORDER BY p.name, p.station_id DESC
-				LIMIT 10000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producers_for_graph", query)
+
+	query := `SELECT * FROM async_tasks WHERE name = $1 AND broker_in_charge = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_async_task_by_name_and_broker_name", query)
 	if err != nil {
-		return []models.ProducerForGraph{}, err
+		return false, []models.AsyncTask{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, task, brokerName)
 	if err != nil {
-		return []models.ProducerForGraph{}, err
+		return false, []models.AsyncTask{}, err
 	}
 	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ProducerForGraph])
+	asyncTask, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AsyncTask])
 	if err != nil {
-		return []models.ProducerForGraph{}, err
+		return false, []models.AsyncTask{}, err
 	}
-	if len(producers) == 0 {
-		return []models.ProducerForGraph{}, nil
+	if len(asyncTask) == 0 {
+		return false, []models.AsyncTask{}, nil
 	}
-	return producers, nil
+	return true, asyncTask, nil
 }
 
-func InsertNewProducer(name string, stationId int, producerType string, connectionIdObj string, tenantName string, partitionsList []int, version int, sdk string, appId string) (models.Producer, error) {
+func GetActiveAndUpdatedAsyncTasks(tenantName string) ([]models.AsyncTaskRes, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
 
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Producer{}, err
+		return []models.AsyncTaskRes{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO producers ( 
-		name, 
-		station_id, 
-		connection_id,
-		is_active, 
-		updated_at, 
-		type,
-		tenant_name,
-		partitions,
-		version,
-		sdk,
-		app_id) 
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_producer", query)
+	query := `SELECT
+	a.id,
+	a.name,
+	a.created_at,
+	a.created_by,
+	s.name AS station_name,
+	a.meta_data,
+	a.status,
+	a.failure_reason
+	FROM
+	async_tasks AS a
+	LEFT JOIN
+	stations AS s ON a.station_id = s.id
+	WHERE
+	a.tenant_name = $1
+	AND (a.status = 'running' OR a.id IN (
+		SELECT
+			id
+		FROM
+			async_tasks
+		WHERE
+			tenant_name = $1
+			AND status <> 'running'
+		ORDER BY
+			created_at DESC
+		LIMIT 10
+	))
+	ORDER BY
+	CASE
+		WHEN a.status = 'running' THEN 0
+		ELSE 1
+	END,
+	created_at DESC;`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_and_updated_async_tasks", query)
 	if err != nil {
-		return models.Producer{}, err
+		return []models.AsyncTaskRes{}, err
 	}
 
-	var producerId int
-	updatedAt := time.Now()
-	isActive := true
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, stationId, connectionIdObj, isActive, updatedAt, producerType, tenantName, partitionsList, version, sdk, appId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return models.Producer{}, err
+		return []models.AsyncTaskRes{}, err
 	}
 	defer rows.Close()
+
+	var asyncTasks []models.AsyncTaskRes
 	for rows.Next() {
-		err := rows.Scan(&producerId)
+		var task models.AsyncTaskRes
+		var sName pgtype.Varchar
+
+		err := rows.Scan(
+			&task.ID,
+			&task.Name,
+			&task.CreatedAt,
+			&task.CreatedBy,
+			&sName,
+			&task.Data,
+			&task.Status,
+			&task.FailureReason,
+		)
 		if err != nil {
-			return models.Producer{}, err
+			return []models.AsyncTaskRes{}, err
+		}
+
+		if sName.Status == pgtype.Present {
+			task.StationName = sName.String
+		} else {
+			task.StationName = "" // Handle NULL value
 		}
-	}
 
-	if err := rows.Err(); err != nil {
-		return models.Producer{}, err
+		asyncTasks = append(asyncTasks, task)
 	}
 
 	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				return models.Producer{}, errors.New(pgErr.Detail)
-			} else {
-				return models.Producer{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Producer{}, err
-		}
+		return []models.AsyncTaskRes{}, err
 	}
-
-	newProducer := models.Producer{
-		ID:             producerId,
-		Name:           name,
-		StationId:      stationId,
-		Type:           producerType,
-		ConnectionId:   connectionIdObj,
-		IsActive:       isActive,
-		UpdatedAt:      time.Now(),
-		PartitionsList: partitionsList,
+	if len(asyncTasks) == 0 {
+		return []models.AsyncTaskRes{}, nil
 	}
-	return newProducer, nil
+	return asyncTasks, nil
 }
 
-func GetNotDeletedProducersByStationID(stationId int) ([]models.Producer, error) { // TODO: check if not needed - I think its not used
+func UpdateAsyncTask(task, tenantName string, updatedAt time.Time, metaData interface{}, stationId int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Producer{}, err
+		return err
 	}
 	defer conn.Release()
-
-	query := `SELECT * FROM producers AS p WHERE p.station_id = $1 AND p.is_deleted = false AND p.type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "get_not_deleted_producers_by_station_id", query)
+	query := `UPDATE async_tasks SET updated_at = $1 ,meta_data = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5`
+	stmt, err := conn.Conn().Prepare(ctx, "edit_async_task_by_task_and_tenant_name_and_station_id", query)
 	if err != nil {
-		return []models.Producer{}, err
+		return err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
-	if err != nil {
-		return []models.Producer{}, err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	defer rows.Close()
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Producer])
+	_, err = conn.Conn().Query(ctx, stmt.Name, updatedAt, metaData, task, tenantName, stationId)
 	if err != nil {
-		return []models.Producer{}, err
-	}
-	if len(producers) == 0 {
-		return []models.Producer{}, nil
+		return err
 	}
-	return producers, nil
+	return nil
 }
-func GetAllProducersByStationID(stationId int) ([]models.ExtendedProducer, error) {
+
+func UpdateStatusAsyncTask(task, tenantName, status string, stationId int, failureReason, functionName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedProducer{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT
-    p.id,
-    p.name,
-    p.type,
-    p.connection_id,
-    p.updated_at,
-    s.name,
-    p.is_active,
-	COUNT(CASE WHEN p.is_active THEN 1 END) OVER (PARTITION BY p.name) AS connected_producers_count,
-	COUNT(CASE WHEN NOT p.is_active THEN 1 END) OVER (PARTITION BY p.name) AS disconnected_producers_count,
-	p.version,
-	p.sdk
-FROM producers AS p
-LEFT JOIN stations AS s ON s.id = p.station_id
-WHERE p.station_id = $1 AND p.type = 'application'
-ORDER BY p.name, p.is_active DESC, p.updated_at DESC
-LIMIT 5000;
-`
-	stmt, err := conn.Conn().Prepare(ctx, "get_producers_by_station_id", query)
-	if err != nil {
-		return []models.ExtendedProducer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
-	if err != nil {
-		return []models.ExtendedProducer{}, err
-	}
-	defer rows.Close()
+	var query string
+	var preparedStmt string
+	if stationId == -1 {
+		query = `UPDATE async_tasks SET status = $1, failure_reason = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5 AND meta_data->>'name' = $6`
+		preparedStmt = "edit_status_functions_async_task_by_task_and_tenant_name_and_station_id"
+	} else {
+		query = `UPDATE async_tasks SET status = $1, failure_reason = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5`
+		preparedStmt = "edit_status_resend_async_task_by_task_and_tenant_name_and_station_id"
 
-	producers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ExtendedProducer])
+	}
+	stmt, err := conn.Conn().Prepare(ctx, preparedStmt, query)
 	if err != nil {
-		return []models.ExtendedProducer{}, err
+		return err
 	}
-	if len(producers) == 0 {
-		return []models.ExtendedProducer{}, nil
+	tenantName = strings.ToLower(tenantName)
+	if stationId == -1 {
+		_, err = conn.Conn().Query(ctx, stmt.Name, status, failureReason, task, tenantName, stationId, functionName)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = conn.Conn().Query(ctx, stmt.Name, status, failureReason, task, tenantName, stationId)
+		if err != nil {
+			return err
+		}
 	}
-	return producers, nil
+
+	return nil
 }
 
-func DeleteProducerByNameAndStationID(name string, stationId int) (bool, error) {
+func RemoveOldAsyncTasks() error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, err
+		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND type = 'application' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
+	query := `DELETE FROM async_tasks
+	WHERE status != 'running' AND id NOT IN (
+		SELECT id
+		FROM async_tasks
+		WHERE status != 'running'
+		ORDER BY updated_at DESC
+		LIMIT 10
+	);`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_old_async_task", query)
 	if err != nil {
-		return false, err
+		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId)
+	_, err = conn.Conn().Exec(ctx, stmt.Name)
 	if err != nil {
-		return false, err
+		return err
 	}
-	return true, nil
+	return nil
 }
 
-func DeleteConnectorProducerByNameAndStationID(name string, stationId int) (bool, error) {
+func RemoveAllAsyncTasks(duration time.Duration) ([]int, error) {
+	sub := time.Now().Add(-duration)
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, err
+		return []int{}, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND type = 'connector' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
+	query := `WITH deleted_rows AS (
+		DELETE FROM async_tasks
+		WHERE updated_at <= $1
+		RETURNING station_id
+	  )
+	  SELECT ARRAY_AGG(station_id) AS deleted_station_ids
+	  FROM deleted_rows;`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_all_async_tasks", query)
 	if err != nil {
-		return false, err
+		return []int{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId)
+
+	var stationIds []int
+	rows, err := conn.Conn().Query(ctx, stmt.Name, sub)
 	if err != nil {
-		return false, err
+		return []int{}, err
 	}
-	return true, nil
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(&stationIds)
+		if err != nil {
+			return []int{}, err
+		}
+	}
+	return stationIds, nil
 }
 
-func DeleteProducerByNameStationIDAndConnID(name string, stationId int, connId string) (bool, error) {
+func CreateUserIfNotExist(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string, pending bool, team, position, owner, description string) (models.User, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, err
+		return models.User{}, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM producers WHERE name = $1 AND station_id = $2 AND connection_id = $3
-	AND EXISTS (
-		SELECT 1 FROM producers
-		WHERE name = $1 AND station_id = $2 AND connection_id = $3
-		FETCH FIRST 1 ROW ONLY
-	);`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_producer_by_name_and_station_id", query)
-	if err != nil {
-		return false, err
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, stationId, connId)
+
+	query := `INSERT INTO users ( 
+		username,
+		password,
+		type,
+		already_logged_in,
+		created_at,
+		avatar_id,
+		full_name, 
+		subscription,
+		skip_get_started,
+		tenant_name,
+		pending,
+		team, 
+		position,
+		owner,
+		description) 
+    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) 
+	ON CONFLICT (username, tenant_name) DO NOTHING
+	RETURNING id`
+
+	stmt, err := conn.Conn().Prepare(ctx, "create_new_user_if_not_exist", query)
 	if err != nil {
-		return false, err
+		return models.User{}, err
 	}
-	return true, nil
-}
+	createdAt := time.Now()
+	skipGetStarted := false
+	alreadyLoggedIn := false
 
-func DeleteProducersByStationID(stationId int) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	var userId int
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	encryptedPassword, err := encryptSecret(hashedPassword)
 	if err != nil {
-		return err
+		return models.User{}, err
 	}
-	defer conn.Release()
-	query := `DELETE FROM producers WHERE station_id = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_producers_by_station_id", query)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, encryptedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName, pending, team, position, owner, description)
 	if err != nil {
-		return err
+		return models.User{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&userId)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Detail != "" {
+				if strings.Contains(pgErr.Detail, "already exists") {
+					return models.User{}, errors.New("User " + username + " already exists")
+				} else {
+					return models.User{}, errors.New(pgErr.Detail)
+				}
+			} else {
+				return models.User{}, errors.New(pgErr.Message)
+			}
+		} else {
+			return models.User{}, err
+		}
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
-	if err != nil {
-		return err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	return nil
+	newUser := models.User{
+		ID:              userId,
+		Username:        username,
+		Password:        hashedPassword,
+		FullName:        fullName,
+		Subscribtion:    subscription,
+		UserType:        userType,
+		CreatedAt:       createdAt,
+		AlreadyLoggedIn: alreadyLoggedIn,
+		AvatarId:        avatarId,
+		TenantName:      tenantName,
+		Pending:         pending,
+		Team:            team,
+		Position:        position,
+		Owner:           owner,
+		Description:     description,
+	}
+	return newUser, nil
 }
 
-func CountActiveProudcersByStationID(stationId int) (int64, error) {
-	var activeCount int64
+func CountProudcersForStation(stationId int) (int64, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return 0, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM producers WHERE station_id = $1 AND is_active = true AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "count_active_producers_by_station_id", query)
+	query := `SELECT COUNT(*) FROM producers WHERE station_id=$1 AND type = 'application'`
+	stmt, err := conn.Conn().Prepare(ctx, "get_count_producers_for_station", query)
 	if err != nil {
 		return 0, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&activeCount)
+	var count int64
+	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
 
-	return activeCount, nil
+	return count, nil
 }
 
-func CountAllActiveProudcers() (int64, error) {
-	var producersCount int64
+// Shared Locks Functions
+func GetAndLockSharedLock(name string, tenantName string) (bool, bool, models.SharedLock, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return false, false, models.SharedLock{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM producers WHERE is_active = true AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "count_all_active_producers", query)
+	tx, err := conn.Begin(ctx)
 	if err != nil {
-		return 0, err
+		return false, false, models.SharedLock{}, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&producersCount)
+	defer tx.Rollback(ctx)
+
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+
+	selectQuery := `SELECT * FROM shared_locks WHERE name = $1 AND tenant_name = $2 FOR UPDATE LIMIT 1`
+	stmt, err := tx.Prepare(ctx, "get_and_lock_shared_lock", selectQuery)
 	if err != nil {
-		return 0, err
+		return false, false, models.SharedLock{}, err
+	}
+	rows, err := tx.Query(ctx, stmt.Name, name, tenantName)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, false, models.SharedLock{}, nil
+		} else {
+			return false, false, models.SharedLock{}, err
+		}
+	}
+	defer rows.Close()
+	sharedLocks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SharedLock])
+	if err != nil && err != pgx.ErrNoRows {
+		return false, false, models.SharedLock{}, err
 	}
+	var sharedLock models.SharedLock
+	lockTime := time.Now()
+	newLock := false
+	if len(sharedLocks) == 0 || err == pgx.ErrNoRows {
+		insterQuery := `INSERT INTO shared_locks(name, tenant_name, lock_held, locked_at) VALUES($1, $2, $3, $4) RETURNING *`
 
-	return producersCount, nil
+		stmt, err := conn.Conn().Prepare(ctx, "insert_new_shared_lock_and_lock", insterQuery)
+		if err != nil {
+			return false, false, models.SharedLock{}, err
+		}
+
+		newSharedLock := models.SharedLock{}
+		if tenantName != conf.GlobalAccount {
+			tenantName = strings.ToLower(tenantName)
+		}
+		rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName, true, lockTime)
+		if err != nil {
+			return false, false, models.SharedLock{}, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			err := rows.Scan(&newSharedLock.ID, &newSharedLock.Name, &newSharedLock.TenantName, &newSharedLock.LockHeld, &newSharedLock.LockedAt)
+			if err != nil {
+				return false, false, models.SharedLock{}, err
+			}
+		}
+		sharedLock = newSharedLock
+		newLock = true
+	} else {
+		sharedLock = sharedLocks[0]
+	}
+
+	if !sharedLock.LockHeld {
+		updateQuery := "UPDATE shared_locks SET lock_held = TRUE, locked_at = $1 WHERE id = $2"
+		lockStmt, err := tx.Prepare(ctx, "lock_shared_lock", updateQuery)
+		if err != nil {
+			return false, false, models.SharedLock{}, err
+		}
+		_, err = tx.Exec(ctx, lockStmt.Name, lockTime, sharedLock.ID)
+		if err != nil {
+			return false, false, models.SharedLock{}, err
+		}
+		sharedLock.LockHeld = true
+		sharedLock.LockedAt = lockTime
+	} else if !newLock {
+		return true, false, sharedLock, nil
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, false, models.SharedLock{}, err
+	}
+	return true, true, sharedLock, nil
 }
 
-func UpdateProducersOfDeletedUser(userId int) error {
+func SharedLockUnlock(name, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE producers SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)'`
-	stmt, err := conn.Conn().Prepare(ctx, "update_producers_of_deleted_user", query)
+
+	query := `UPDATE shared_locks SET lock_held = FALSE WHERE name = $1 AND tenant_name=$2`
+	stmt, err := conn.Conn().Prepare(ctx, "unlock_shared_lock", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId)
+	tenantName = strings.ToLower(tenantName)
+	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func RemoveProducersByTenant(tenantName string) error {
+// AcquireBackgroundTaskLease takes or renews the lease of a background task for the given holder, it returns false
+// while another holder has a lease which did not expire yet
+func AcquireBackgroundTaskLease(name, holder string, ttl time.Duration) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM producers WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_producers_by_tenant", query)
+	// the expiry is computed by the db clock so brokers with drifting clocks agree on it
+	query := `INSERT INTO background_task_leases(name, holder, expires_at) VALUES($1, $2, NOW() + make_interval(secs => $3))
+	          ON CONFLICT(name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+	          WHERE background_task_leases.holder = EXCLUDED.holder OR background_task_leases.expires_at < NOW()
+	          RETURNING holder`
+	stmt, err := conn.Conn().Prepare(ctx, "acquire_background_task_lease", query)
 	if err != nil {
-		return err
+		return false, err
+	}
+	var leaseHolder string
+	err = conn.Conn().QueryRow(ctx, stmt.Name, name, holder, ttl.Seconds()).Scan(&leaseHolder)
+	if err == pgx.ErrNoRows {
+		return false, nil
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return leaseHolder == holder, nil
 }
 
-func KillProducersByConnections(connectionIds []string) error {
+func ReleaseBackgroundTaskLease(name, holder string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE producers SET is_active = false WHERE connection_id = ANY($1)`
-	stmt, err := conn.Conn().Prepare(ctx, "kill_producers_by_connections", query)
-	if err != nil {
-		return err
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, connectionIds)
+	query := `DELETE FROM background_task_leases WHERE name = $1 AND holder = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "release_background_task_lease", query)
 	if err != nil {
 		return err
 	}
-	return nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, name, holder)
+	return err
 }
 
-// Consumer Functions
-func GetActiveConsumerByCG(consumersGroup string, stationId int) (bool, models.Consumer, error) {
+func DeleteAllSharedLocks(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return false, models.Consumer{}, err
-	}
-	defer conn.Release()
 
-	query := `SELECT * FROM consumers WHERE consumers_group = $1 AND station_id = $2 AND type = 'application' AND is_active = true LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumer_by_cg", query)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, consumersGroup, stationId)
+	defer conn.Release()
+	query := `DELETE FROM shared_locks WHERE tenant_name=$1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_all_shared_locks", query)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return err
 	}
-	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return false, models.Consumer{}, err
-	}
-	if len(consumers) == 0 {
-		return false, models.Consumer{}, nil
+		return err
 	}
-	return true, consumers[0], nil
+	return nil
 }
 
-func InsertNewConsumer(name string,
-	stationId int,
-	consumerType string,
-	connectionIdObj string,
-	cgName string,
-	maxAckTime int,
-	maxMsgDeliveries int,
-	startConsumeFromSequence uint64,
-	lastMessages int64,
-	tenantName string,
-	partitionsList []int,
-	version int, sdk string, appId string) (models.Consumer, error) {
+func releaseStuckedSharedLocks(lockedAt time.Time) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Consumer{}, err
+		return err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO consumers ( 
-		name, 
-		station_id,
-		connection_id,
-		consumers_group,
-		max_ack_time_ms,
-		is_active, 
-		updated_at,
-		max_msg_deliveries,
-		start_consume_from_seq,
-		last_msgs,
-		type,
-		tenant_name, 
-		partitions,
-		version,
-		sdk,
-		app_id)
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) 
-	RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_consumer", query)
-	if err != nil {
-		return models.Consumer{}, err
-	}
-
-	var consumerId int
-	updatedAt := time.Now()
-	isActive := true
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name,
-		name, stationId, connectionIdObj, cgName, maxAckTime, isActive, updatedAt, maxMsgDeliveries, startConsumeFromSequence, lastMessages, consumerType, tenantName, partitionsList, version, sdk, appId)
-	if err != nil {
-		return models.Consumer{}, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&consumerId)
-		if err != nil {
-			return models.Consumer{}, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return models.Consumer{}, err
-	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				return models.Consumer{}, errors.New(pgErr.Detail)
-			} else {
-				return models.Consumer{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Consumer{}, err
-		}
+	query := `UPDATE shared_locks SET lock_held = false WHERE locked_at < $1 AND lock_held = true`
+	stmt, err := conn.Conn().Prepare(ctx, "set_lock_held_by_name_and_by_locked_at_shared_lock", query)
+	if err != nil {
+		return err
 	}
-
-	newConsumer := models.Consumer{
-		ID:                  consumerId,
-		Name:                name,
-		StationId:           stationId,
-		Type:                consumerType,
-		ConnectionId:        connectionIdObj,
-		ConsumersGroup:      cgName,
-		IsActive:            isActive,
-		UpdatedAt:           time.Now(),
-		MaxAckTimeMs:        int64(maxAckTime),
-		MaxMsgDeliveries:    maxMsgDeliveries,
-		StartConsumeFromSeq: startConsumeFromSequence,
-		LastMessages:        lastMessages,
-		TenantName:          tenantName,
-		PartitionsList:      partitionsList,
+	_, err = conn.Conn().Query(ctx, stmt.Name, lockedAt)
+	if err != nil {
+		return err
 	}
-	return newConsumer, nil
+	return nil
 }
 
-func GetConsumers() ([]models.Consumer, error) {
+func releaseStuckedStationLocks(lockedAt time.Time) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Consumer{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * From consumers AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "get_consumers", query)
+	query := `UPDATE stations SET functions_lock_held = false WHERE functions_locked_at < $1 AND functions_lock_held = true`
+	stmt, err := conn.Conn().Prepare(ctx, "set_functions_lock_held_at_station", query)
 	if err != nil {
-		return []models.Consumer{}, err
+		return err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	_, err = conn.Conn().Query(ctx, stmt.Name, lockedAt)
 	if err != nil {
-		return []models.Consumer{}, err
+		return err
 	}
-	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	return nil
+}
+
+func UnlockStuckLocks(lockedAt time.Time) error {
+	err := releaseStuckedStationLocks(lockedAt)
 	if err != nil {
-		return []models.Consumer{}, err
+		return fmt.Errorf("releaseStuckedStationLocks: %v", err)
 	}
-	if len(consumers) == 0 {
-		return []models.Consumer{}, err
+	err = releaseStuckedSharedLocks(lockedAt)
+	if err != nil {
+		return fmt.Errorf("releaseStuckedSharedLocks: %v", err)
 	}
-	return consumers, nil
+	return nil
 }
 
-func GetAllConsumersByStation(stationId int) ([]models.ExtendedConsumer, error) {
+func GetMemphisFunctionsByMemphis() ([]models.Function, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedConsumer{}, err
+		return []models.Function{}, err
 	}
 	defer conn.Release()
-	query := `SELECT DISTINCT ON (c.name, c.consumers_group) c.id, c.name, c.updated_at, c.is_active, c.consumers_group, c.max_ack_time_ms, c.max_msg_deliveries, s.name, c.partitions,
-				COUNT (CASE WHEN c.is_active THEN 1 END) OVER (PARTITION BY c.name) AS count, c.version, c.sdk
-				FROM consumers AS c
-				LEFT JOIN stations AS s ON s.id = c.station_id
-				WHERE c.station_id = $1 AND c.type = 'application' ORDER BY c.name, c.consumers_group, c.updated_at DESC
-				LIMIT 5000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_consumers_by_station", query)
+	query := `SELECT * FROM functions WHERE by_memphis = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_memphis_functions_by_memphis", query)
 	if err != nil {
-		return []models.ExtendedConsumer{}, err
+		return []models.Function{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return []models.ExtendedConsumer{}, err
+		return []models.Function{}, err
 	}
+
 	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ExtendedConsumer])
+	functions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Function])
 	if err != nil {
-		return []models.ExtendedConsumer{}, err
+		return []models.Function{}, err
 	}
-	if len(consumers) == 0 {
-		return []models.ExtendedConsumer{}, nil
+	if len(functions) == 0 {
+		return []models.Function{}, err
 	}
-	return consumers, nil
+	return functions, nil
 }
 
-func GetConsumersForGraph(tenantName string) ([]models.ConsumerForGraph, error) {
+func InsertRole(name, tenantName, roleType string) (models.Role, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ConsumerForGraph{}, err
+		return models.Role{}, err
 	}
 	defer conn.Release()
-	query := `SELECT c.name, c.consumers_group, c.station_id, c.app_id, c.type
-				FROM consumers AS c
-				WHERE c.tenant_name = $1 AND c.is_active = true
-				ORDER BY c.name, c.station_id DESC
-				LIMIT 10000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_consumers_for_graph", query)
+	query := `INSERT INTO roles (name, tenant_name, type) VALUES($1, $2, $3) RETURNING id`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_role", query)
 	if err != nil {
-		return []models.ConsumerForGraph{}, err
+		return models.Role{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	tenantName = strings.ToLower(tenantName)
+	var role models.Role
+	err = conn.Conn().QueryRow(ctx, stmt.Name, name, tenantName, roleType).Scan(&role.ID)
 	if err != nil {
-		return []models.ConsumerForGraph{}, err
+		return models.Role{}, err
 	}
-	defer rows.Close()
-	cgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConsumerForGraph])
+	role.Name = name
+	role.TenantName = tenantName
+	role.Type = roleType
+	return role, nil
+}
+
+func InsertPermissions(allowReadPermissions, allowWritePermissions, denyReadPermissions, denyWritePermissions []string, roleID int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ConsumerForGraph{}, err
+		return err
 	}
-	if len(cgs) == 0 {
-		return []models.ConsumerForGraph{}, nil
+	defer conn.Release()
+
+	query := `INSERT INTO permissions (pattern, role_id, type, restriction_type) VALUES`
+	var values []interface{}
+	i := 0
+	for _, permission := range allowReadPermissions {
+		values = append(values, permission, roleID, "read", "allow")
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
+		i++
 	}
-	return cgs, nil
+	for _, permission := range allowWritePermissions {
+		values = append(values, permission, roleID, "write", "allow")
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
+		i++
+	}
+	for _, permission := range denyReadPermissions {
+		values = append(values, permission, roleID, "read", "deny")
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
+		i++
+	}
+	for _, permission := range denyWritePermissions {
+		values = append(values, permission, roleID, "write", "deny")
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
+		i++
+	}
+	query = query[:len(query)-1]
+
+	stmt, err := conn.Conn().Prepare(ctx, "insert_permissions", query)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Conn().Query(ctx, stmt.Name, values...)
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func DeleteConsumerByNameStationIDAndConnID(connectionId, name string, stationId int) (bool, models.Consumer, error) {
+func CreateNewRole(name, tenantName, roleType string, allowReadPermissions, allowWritePermissions, denyReadPermissions, denyWritePermissions []string) (models.Role, models.Permissions, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return models.Role{}, models.Permissions{}, err
 	}
 	defer conn.Release()
-	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE connection_id = $1 AND name = $2 AND station_id = $3 LIMIT 1) RETURNING *`
-	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+
+	var role models.Role
+
+	tx, err := conn.Conn().Begin(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return models.Role{}, models.Permissions{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, connectionId, name, stationId)
+	defer func() {
+		// if err != nil {
+		// 	tx.Rollback(ctx)
+		// } else {
+		// 	tx.Commit(ctx)
+		// }
+		tx.Commit(ctx)
+	}()
+
+	query := `INSERT INTO roles (name, tenant_name, type) VALUES($1, $2, $3) RETURNING id`
+	stmt, err := tx.Prepare(ctx, "create_role", query)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return models.Role{}, models.Permissions{}, err
 	}
-	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+
+	err = tx.QueryRow(ctx, stmt.Name, name, tenantName, roleType).Scan(&role.ID)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return models.Role{}, models.Permissions{}, err
 	}
-	if len(consumers) == 0 {
-		return false, models.Consumer{}, err
+	role.Name = name
+	role.TenantName = tenantName
+	role.Type = roleType
+
+	query = `INSERT INTO permissions (pattern, role_id, type, restriction_type, tenant_name) VALUES `
+	var values []interface{}
+	i := 0
+
+	for _, permission := range allowReadPermissions {
+		values = append(values, permission, role.ID, "read", "allow", tenantName)
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+		i++
 	}
-	return true, consumers[0], nil
+	for _, permission := range allowWritePermissions {
+		values = append(values, permission, role.ID, "write", "allow", tenantName)
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+		i++
+	}
+	for _, permission := range denyReadPermissions {
+		values = append(values, permission, role.ID, "read", "deny", tenantName)
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+		i++
+	}
+	for _, permission := range denyWritePermissions {
+		values = append(values, permission, role.ID, "write", "deny", tenantName)
+		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
+		i++
+	}
+	query = query[:len(query)-1]
+	stmt, err = tx.Prepare(ctx, "insert_permissions_create_new_roll", query)
+	if err != nil {
+		return models.Role{}, models.Permissions{}, err
+	}
+
+	_, err = tx.Exec(ctx, stmt.Name, values...)
+	if err != nil {
+		return models.Role{}, models.Permissions{}, err
+	}
+	permissionsRes := models.Permissions{
+		AllowReadPermissions:  allowReadPermissions,
+		AllowWritePermissions: allowWritePermissions,
+		DenyReadPermissions:   denyReadPermissions,
+		DenyWritePermissions:  denyWritePermissions,
+	}
+	return role, permissionsRes, nil
 }
 
-func DeleteConsumerByNameStationIDAndType(consumerType, name string, stationId int) (bool, models.Consumer, error) {
+func GetAllPermissions() ([]models.Permission, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return []models.Permission{}, err
 	}
 	defer conn.Release()
-	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE type = $1 AND name = $2 AND station_id = $3 LIMIT 1) RETURNING *`
-	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+
+	query := `SELECT * FROM permissions`
+	stmt, err := conn.Conn().Prepare(ctx, "get_all_permissions", query)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return []models.Permission{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, consumerType, name, stationId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return []models.Permission{}, err
 	}
 	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
 	if err != nil {
-		return false, models.Consumer{}, err
+		return []models.Permission{}, err
 	}
-	if len(consumers) == 0 {
-		return false, models.Consumer{}, err
+	if len(permissions) == 0 {
+		return []models.Permission{}, nil
 	}
-	return true, consumers[0], nil
+	return permissions, nil
 }
 
-func DeleteConsumerByNameAndStationId(name string, stationId int) (bool, models.Consumer, error) {
+func GetPermissionsByRoleID(id int) (bool, models.Permissions, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return false, models.Permissions{}, err
 	}
 	defer conn.Release()
-	query := ` DELETE FROM consumers WHERE ctid = ( SELECT ctid FROM consumers WHERE name = $1 AND station_id = $2 LIMIT 1) RETURNING *`
-	deleteStmt, err := conn.Conn().Prepare(ctx, "delete_consumers", query)
+
+	query := `SELECT * FROM permissions WHERE role_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_permissions_by_role_id", query)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return false, models.Permissions{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, deleteStmt.Name, name, stationId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return false, models.Permissions{}, err
 	}
 	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	allPermissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
 	if err != nil {
-		return false, models.Consumer{}, err
+		return false, models.Permissions{}, err
 	}
-	if len(consumers) == 0 {
-		return false, models.Consumer{}, err
+	if len(allPermissions) == 0 {
+		return false, models.Permissions{}, nil
 	}
-	return true, consumers[0], nil
+	var permissions models.Permissions
+	for _, p := range allPermissions {
+		if p.Type == "read" {
+			if p.RestrictionType == "allow" {
+				permissions.AllowReadPermissions = append(permissions.AllowReadPermissions, p.Pattern)
+			} else if p.RestrictionType == "deny" {
+				permissions.DenyReadPermissions = append(permissions.DenyReadPermissions, p.Pattern)
+			}
+		} else if p.Type == "write" {
+			if p.RestrictionType == "allow" {
+				permissions.AllowWritePermissions = append(permissions.AllowWritePermissions, p.Pattern)
+			} else if p.RestrictionType == "deny" {
+				permissions.DenyWritePermissions = append(permissions.DenyWritePermissions, p.Pattern)
+			}
+		}
+	}
+	return true, permissions, nil
 }
 
-func DeleteAllConsumersByStationID(stationId int) error {
+func UpdateUserRole(tenantName, username string, roleIDs []int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	tenantName = strings.ToLower(tenantName)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM consumers WHERE station_id = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_consumers_by_station_id", query)
+
+	query := `UPDATE users SET roles = $1 WHERE username = $2 AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "update_user_role", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
+	_, err = conn.Conn().Query(ctx, stmt.Name, roleIDs, username, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func DeleteDLSMessagesByStationID(stationId int) error {
+func UpdatePermissions(tenantName, username string, readPermissions, writePermissions []string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+
+	tenantName = strings.ToLower(tenantName)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM dls_messages WHERE station_id = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_messages_from_chosen_dls", query)
+
+	query := `UPDATE permissions SET read_permissions = $1, write_permissions = $2 WHERE role_id = (SELECT id FROM roles WHERE username = $3 AND tenant_name = $4)`
+	stmt, err := conn.Conn().Prepare(ctx, "update_user_permissions", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, stationId)
+	_, err = conn.Conn().Query(ctx, stmt.Name, readPermissions, writePermissions, username, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func CountActiveConsumersInCG(consumersGroup string, stationId int) (int64, error) {
-	var count int64
+func CheckUserStationPermissions(rolesId []int, stationName, operation string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return 0, err
-	}
-	defer conn.Release()
-	query := `SELECT COUNT(*) FROM consumers WHERE station_id = $1 AND consumers_group = $2 AND is_active = true`
-	stmt, err := conn.Conn().Prepare(ctx, "count_active_consumers_in_cg", query)
-	if err != nil {
-		return 0, err
-	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId, consumersGroup).Scan(&count)
-	if err != nil {
-		return 0, err
-	}
 
-	return count, nil
-}
-
-func CountActiveConsumersByStationID(stationId int) (int64, error) {
-	var activeCount int64
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM consumers WHERE station_id = $1 AND is_active = true AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "count_active_consumers_by_station_id", query)
+	// a grant on the environment of the station or on its project allows the station as well
+	query := `SELECT (SELECT COUNT(*)
+	FROM permissions
+	WHERE role_id = ANY($1)
+	  AND type = $2
+	  AND restriction_type = 'allow'
+	  AND (
+		(position('*' in pattern) > 0 AND $3 ~ pattern) OR
+		(position('*' in pattern) = 0 AND $3 = pattern)
+	  )) + (SELECT COUNT(*)
+	FROM project_permissions AS pp
+	INNER JOIN projects AS e ON pp.project_id = e.id OR pp.project_id = e.parent_id
+	INNER JOIN project_stations AS ps ON ps.project_id = e.id
+	INNER JOIN stations AS s ON s.id = ps.station_id
+	WHERE pp.role_id = ANY($1)
+	  AND pp.type::text = $2
+	  AND s.name = $3
+	  AND s.tenant_name = pp.tenant_name
+	  AND s.is_deleted = false);`
+	stmt, err := conn.Conn().Prepare(ctx, "check_user_station_permissions", query)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&activeCount)
+	var count int
+	err = conn.Conn().QueryRow(ctx, stmt.Name, rolesId, operation, stationName).Scan(&count)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-
-	return activeCount, nil
+	if count == 0 {
+		return false, nil
+	}
+	return true, nil
 }
 
-func CountAllActiveConsumers() (int64, error) {
-	var consumersCount int64
+func CheckTenantPermissionsUsage(tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	tenantName = strings.ToLower(tenantName)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM consumers WHERE is_active = true AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "count_all_active_consumers", query)
+
+	query := `SELECT EXISTS (SELECT 1 FROM roles WHERE tenant_name = $1 );`
+	stmt, err := conn.Conn().Prepare(ctx, "check_tenant_permissions_usage", query)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&consumersCount)
+	var exists bool
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&exists)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-
-	return consumersCount, nil
+	return exists, nil
 }
 
-func GetConsumerGroupMembers(cgName string, stationId int) ([]models.CgMember, error) {
+func GetUserPermissions(userRoles []int, tenantName string) ([]models.Permission, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	tenantName = strings.ToLower(tenantName)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.CgMember{}, err
+		return []models.Permission{}, err
 	}
+
 	defer conn.Release()
-	query := `
-		SELECT
-			c.name,
-			c.connection_id,
-			c.is_active,
-			c.max_msg_deliveries,
-			c.max_ack_time_ms,
-			c.partitions,
-			COUNT (CASE WHEN c.is_active THEN 1 END) OVER (PARTITION BY c.name) AS count
-		FROM
-			consumers AS c
-		WHERE
-			c.consumers_group = $1
-			AND c.station_id = $2
-		ORDER BY
-			c.name, c.updated_at DESC
-	`
-	stmt, err := conn.Conn().Prepare(ctx, "get_consumer_group_members", query)
+
+	query := `SELECT * FROM permissions WHERE role_id = ANY($1) AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_permissions", query)
 	if err != nil {
-		return []models.CgMember{}, err
+		return []models.Permission{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, cgName, stationId)
+
+	rows, err := conn.Conn().Query(ctx, stmt.Name, userRoles, tenantName)
 	if err != nil {
-		return []models.CgMember{}, err
+		return []models.Permission{}, err
 	}
 	defer rows.Close()
-
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CgMember])
+	allPermissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
 	if err != nil {
-		return []models.CgMember{}, err
+		return []models.Permission{}, err
 	}
-	if len(consumers) == 0 {
-		return []models.CgMember{}, nil
+	if len(allPermissions) == 0 {
+		return []models.Permission{}, nil
+	} else {
+		return allPermissions, nil
 	}
-	return consumers, nil
+
 }
 
-func UpdateCosnumersActiveAndGetDetails(connectionId string, isActive bool) ([]models.LightConsumer, error) {
+func GetStationsByPattern(patterns []string, tenantName string) ([]models.Station, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return []models.LightConsumer{}, err
+	tenantName = strings.ToLower(tenantName)
+
+	var stationsList []models.Station
+	var plainNames []string
+	var regexPatterns []string
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "*") {
+			regexPatterns = append(regexPatterns, pattern)
+		} else {
+			plainNames = append(plainNames, pattern)
+		}
 	}
-	defer conn.Release()
-	query := `
-	WITH updated_consumers AS (
-		UPDATE consumers
-		SET is_active = $2
-		WHERE connection_id = $1 AND is_active = true
-		RETURNING *
-	)
-	SELECT DISTINCT ON (c.name) c.name, s.name, COUNT(*) OVER (PARTITION BY c.name)
-	FROM updated_consumers AS c
-	LEFT JOIN stations AS s ON s.id = c.station_id
-	GROUP BY c.name, c.id, s.id
-	LIMIT 5000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_consumers_by_connection_id_with_station_details", query)
+
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.LightConsumer{}, err
+		return []models.Station{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, connectionId, isActive)
-	if err != nil {
-		return []models.LightConsumer{}, err
+	defer conn.Release()
+
+	if len(regexPatterns) > 0 {
+		query := `SELECT * FROM stations WHERE name ~ ANY($1) AND tenant_name = $2`
+		stmt, err := conn.Conn().Prepare(ctx, "get_stations_by_pattern", query)
+		if err != nil {
+			return []models.Station{}, err
+		}
+
+		rows, err := conn.Conn().Query(ctx, stmt.Name, regexPatterns, tenantName)
+		if err != nil {
+			return []models.Station{}, err
+		}
+		defer rows.Close()
+
+		stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+		if err != nil {
+			return []models.Station{}, err
+		}
+		if len(stations) != 0 {
+			stationsList = append(stationsList, stations...)
+		}
 	}
-	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightConsumer])
-	if err != nil {
-		return []models.LightConsumer{}, err
+
+	if len(plainNames) > 0 {
+		query := `SELECT * FROM stations WHERE name = ANY($1) AND tenant_name = $2`
+		stmt, err := conn.Conn().Prepare(ctx, "get_stations_by_plain_names", query)
+		if err != nil {
+			return []models.Station{}, err
+		}
+
+		rows, err := conn.Conn().Query(ctx, stmt.Name, plainNames, tenantName)
+		if err != nil {
+			return []models.Station{}, err
+		}
+		defer rows.Close()
+
+		stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+		if err != nil {
+			return []models.Station{}, err
+		}
+		if len(stations) != 0 {
+			stationsList = append(stationsList, stations...)
+		}
 	}
-	if len(consumers) == 0 {
-		return []models.LightConsumer{}, nil
+
+	if len(stationsList) == 0 {
+		return []models.Station{}, nil
+	} else {
+		return stationsList, nil
 	}
-	return consumers, nil
 }
 
-func GetActiveConsumerByStationID(consumerName string, stationId int) (bool, models.Consumer, error) {
+func RemoveRoleAndPermissions(roleID []int, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	tenantName = strings.ToLower(tenantName)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM consumers WHERE name = $1 AND station_id = $2 AND is_active = true AND type = 'application' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumer_by_station_id", query)
-	if err != nil {
-		return false, models.Consumer{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, consumerName, stationId)
+
+	tx, err := conn.Conn().Begin(ctx)
 	if err != nil {
-		return false, models.Consumer{}, err
+		return err
 	}
-	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Consumer])
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	query := `DELETE FROM permissions WHERE role_id = ANY($1) AND tenant_name = $2`
+	stmt, err := tx.Prepare(ctx, "remove_permissions_by_role_id", query)
 	if err != nil {
-		return false, models.Consumer{}, err
-	}
-	if len(consumers) == 0 {
-		return false, models.Consumer{}, nil
+		return err
 	}
-	return true, consumers[0], nil
-}
-
-func UpdateConsumersConnection(connectionId string, isActive bool) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	_, err = tx.Exec(ctx, stmt.Name, roleID, tenantName)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
-	query := `UPDATE consumers SET is_active = $1 WHERE connection_id = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_consumers_connection", query)
+
+	query = `DELETE FROM roles WHERE id = ANY($1) AND tenant_name = $2`
+	stmt, err = tx.Prepare(ctx, "remove_role_by_id", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, isActive, connectionId)
+	_, err = tx.Exec(ctx, stmt.Name, roleID, tenantName)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func UpdateConsumersOfDeletedUser(userId int) error {
+func RemovePermissionsByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	tenantName = strings.ToLower(tenantName)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `UPDATE consumers SET created_by = 0, created_by_username = CONCAT(created_by_username, '(deleted)') WHERE created_by = $1 AND created_by_username NOT LIKE '%(deleted)'`
-	stmt, err := conn.Conn().Prepare(ctx, "update_consumers_of_deleted_user", query)
+
+	query := `DELETE FROM permissions WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_permissions_by_tenant", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId)
+	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func RemoveConsumersByTenant(tenantName string) error {
+func RemoveRolesByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	tenantName = strings.ToLower(tenantName)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM consumers WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_consumers_by_tenant", query)
+
+	query := `DELETE FROM roles WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_roles_by_tenant", query)
 	if err != nil {
 		return err
 	}
@@ -3584,3275 +10308,3267 @@ func RemoveConsumersByTenant(tenantName string) error {
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func KillConsumersByConnections(connectionIds []string) error {
+// Api Keys Functions
+func InsertNewApiKey(name, username, keyPrefix, keyHash string, scopes []string, expiresAt *time.Time, tenantName string) (models.ApiKey, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.ApiKey{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE consumers SET is_active = false WHERE connection_id = ANY($1)`
-	stmt, err := conn.Conn().Prepare(ctx, "kill_consumers_by_connections", query)
+
+	query := `INSERT INTO api_keys(name, username, key_prefix, key_hash, scopes, expires_at, created_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_new_api_key", query)
 	if err != nil {
-		return err
+		return models.ApiKey{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, connectionIds)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, username, keyPrefix, keyHash, scopes, expiresAt, time.Now(), tenantName)
 	if err != nil {
-		return err
+		return models.ApiKey{}, err
 	}
-	return nil
+	defer rows.Close()
+	apiKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ApiKey])
+	if err != nil {
+		return models.ApiKey{}, err
+	}
+	if len(apiKeys) == 0 {
+		return models.ApiKey{}, errors.New("api key was not created")
+	}
+	return apiKeys[0], nil
 }
 
-func GetActiveCgsByName(names []string, tenantName string) ([]models.LightConsumer, error) {
+func GetApiKeysByUsername(username, tenantName string) ([]models.ApiKey, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.LightConsumer{}, err
+		return []models.ApiKey{}, err
 	}
 	defer conn.Release()
-	query := `
-		SELECT c.consumers_group, s.name, COUNT(*)
-		FROM consumers AS c
-		LEFT JOIN stations AS s ON s.id = c.station_id
-		WHERE c.tenant_name = $1 AND c.consumers_group = ANY($2) AND c.is_active = true AND c.type = 'application'
-		GROUP BY c.consumers_group, s.name, c.station_id;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_consumers_by_name", query)
+
+	query := `SELECT * FROM api_keys WHERE username = $1 AND tenant_name = $2 ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_api_keys_by_username", query)
 	if err != nil {
-		return []models.LightConsumer{}, err
+		return []models.ApiKey{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, names)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return []models.LightConsumer{}, err
+		return []models.ApiKey{}, err
 	}
 	defer rows.Close()
-	consumers, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.LightConsumer])
+	apiKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ApiKey])
 	if err != nil {
-		return []models.LightConsumer{}, err
+		return []models.ApiKey{}, err
 	}
-	if len(consumers) == 0 {
-		return []models.LightConsumer{}, nil
+	if len(apiKeys) == 0 {
+		return []models.ApiKey{}, nil
 	}
-	return consumers, nil
+	return apiKeys, nil
 }
 
-// Schema Functions
-func GetSchemaByName(name string, tenantName string) (bool, models.Schema, error) {
+func GetApiKeyByHash(keyHash string) (bool, models.ApiKey, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Schema{}, err
+		return false, models.ApiKey{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM schemas WHERE name = $1 AND tenant_name = $2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_schema_by_name", query)
+
+	query := `SELECT * FROM api_keys WHERE key_hash = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_api_key_by_hash", query)
 	if err != nil {
-		return false, models.Schema{}, err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+		return false, models.ApiKey{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, keyHash)
 	if err != nil {
-		return false, models.Schema{}, err
+		return false, models.ApiKey{}, err
 	}
 	defer rows.Close()
-	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Schema])
+	apiKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ApiKey])
 	if err != nil {
-		return false, models.Schema{}, err
+		return false, models.ApiKey{}, err
 	}
-	if len(schemas) == 0 {
-		return false, models.Schema{}, nil
+	if len(apiKeys) == 0 {
+		return false, models.ApiKey{}, nil
 	}
-	return true, schemas[0], nil
+	return true, apiKeys[0], nil
 }
 
-func GetSchemaVersionsBySchemaID(id int) ([]models.SchemaVersion, error) {
+func UpdateApiKeyLastUsed(id int, lastUsedAt time.Time) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.SchemaVersion{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM schema_versions WHERE schema_id=$1 ORDER BY created_at DESC`
-	stmt, err := conn.Conn().Prepare(ctx, "get_schema_versions_by_schema_id", query)
+
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "update_api_key_last_used", query)
 	if err != nil {
-		cancelfunc()
-		return []models.SchemaVersion{}, err
+		return err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, lastUsedAt)
 	if err != nil {
-		return []models.SchemaVersion{}, err
+		return err
 	}
-	defer rows.Close()
-	schemaVersionsRes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
+	return nil
+}
+
+func RevokeApiKey(id int, username, tenantName string) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.SchemaVersion{}, err
-	}
-	if len(schemaVersionsRes) == 0 {
-		return []models.SchemaVersion{}, nil
+		return false, err
 	}
-	schemaVersions := []models.SchemaVersion{}
-	for _, v := range schemaVersionsRes {
-		version := models.SchemaVersion{
-			ID:                v.ID,
-			VersionNumber:     v.VersionNumber,
-			Active:            v.Active,
-			CreatedBy:         v.CreatedBy,
-			CreatedByUsername: v.CreatedByUsername,
-			CreatedAt:         v.CreatedAt,
-			SchemaContent:     v.SchemaContent,
-			SchemaId:          v.SchemaId,
-			MessageStructName: v.MessageStructName,
-			Descriptor:        string(v.Descriptor),
-			TenantName:        strings.ToLower(v.TenantName),
-		}
+	defer conn.Release()
 
-		schemaVersions = append(schemaVersions, version)
+	query := `UPDATE api_keys SET revoked = TRUE WHERE id = $1 AND username = $2 AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "revoke_api_key", query)
+	if err != nil {
+		return false, err
 	}
-	return schemaVersions, nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, username, tenantName)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
 }
 
-func GetActiveVersionBySchemaID(id int) (models.SchemaVersion, error) {
+// Connection Tokens Functions
+func GetActiveConnectionTokensByUsername(username, tenantName string) ([]models.ConnectionToken, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.SchemaVersion{}, err
+		return []models.ConnectionToken{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM schema_versions WHERE schema_id=$1 AND active=true LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_version_by_schema_id", query)
+
+	query := `SELECT * FROM connection_tokens WHERE username = $1 AND tenant_name = $2 AND (expires_at IS NULL OR expires_at > NOW()) ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_connection_tokens_by_username", query)
 	if err != nil {
-		return models.SchemaVersion{}, err
+		return []models.ConnectionToken{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return models.SchemaVersion{}, err
+		return []models.ConnectionToken{}, err
 	}
 	defer rows.Close()
-	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
+	tokens, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConnectionToken])
 	if err != nil {
-		return models.SchemaVersion{}, err
+		return []models.ConnectionToken{}, err
 	}
-	if len(schemas) == 0 {
-		return models.SchemaVersion{}, nil
+	err = decryptConnectionTokens(tokens)
+	if err != nil {
+		return []models.ConnectionToken{}, err
 	}
-	schemaVersion := models.SchemaVersion{
-		ID:                schemas[0].ID,
-		VersionNumber:     schemas[0].VersionNumber,
-		Active:            schemas[0].Active,
-		CreatedBy:         schemas[0].CreatedBy,
-		CreatedByUsername: schemas[0].CreatedByUsername,
-		CreatedAt:         schemas[0].CreatedAt,
-		SchemaContent:     schemas[0].SchemaContent,
-		SchemaId:          schemas[0].SchemaId,
-		MessageStructName: schemas[0].MessageStructName,
-		Descriptor:        string(schemas[0].Descriptor),
-		TenantName:        strings.ToLower(schemas[0].TenantName),
+	if len(tokens) == 0 {
+		return []models.ConnectionToken{}, nil
 	}
-
-	return schemaVersion, nil
+	return tokens, nil
 }
 
-func UpdateSchemasOfDeletedUser(userId int, tenantName string) error {
+// RotateConnectionToken moves the currently active tokens of the user into the grace window and inserts the new one,
+// previousToken is inserted with the same grace window when the user had no tokens of its own yet
+func RotateConnectionToken(username, newToken, previousToken string, graceUntil time.Time, createdBy, tenantName string) (err error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := ` UPDATE schemas
-	SET created_by_username = CONCAT(created_by_username, '(deleted)')
-	WHERE created_by_username = (
-		SELECT username FROM users WHERE id = $1
-	)
-	AND created_by_username NOT LIKE '%(deleted)'
-	AND tenant_name = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_schemas_of_deleted_user", query)
+
+	tx, err := conn.Conn().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	query := `UPDATE connection_tokens SET expires_at = $3 WHERE username = $1 AND tenant_name = $2 AND (expires_at IS NULL OR expires_at > $3)`
+	stmt, err := tx.Prepare(ctx, "expire_connection_tokens_by_username", query)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, username, tenantName, graceUntil)
+	if err != nil {
+		return err
+	}
+
+	newToken, err = encryptSecret(newToken)
+	if err != nil {
+		return err
+	}
+	previousToken, err = encryptSecret(previousToken)
 	if err != nil {
 		return err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	query = `INSERT INTO connection_tokens(username, token, created_by, created_at, expires_at, tenant_name) VALUES($1, $2, $3, $4, $5, $6)`
+	stmt, err = tx.Prepare(ctx, "insert_connection_token", query)
+	if err != nil {
+		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+	if previousToken != "" {
+		_, err = tx.Exec(ctx, stmt.Name, username, previousToken, createdBy, time.Now(), graceUntil, tenantName)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx, stmt.Name, username, newToken, createdBy, time.Now(), nil, tenantName)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func RemoveSchemasByTenant(tenantName string) error {
+func DeleteConnectionToken(id int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := `DELETE FROM schemas WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_schemas_by_tenant", query)
+
+	query := `DELETE FROM connection_tokens WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_connection_token", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UpdateSchemaVersionsOfDeletedUser(userId int, tenantName string) error {
+func DeleteConnectionTokensByUsername(username, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-	query := ` UPDATE schema_versions
-	SET created_by_username = CONCAT(created_by_username, '(deleted)')
-	WHERE created_by_username = (
-		SELECT username FROM users WHERE id = $1
-	)
-	AND created_by_username NOT LIKE '%(deleted)'
-	AND tenant_name = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_schema_versions_of_deleted_user", query)
+
+	query := `DELETE FROM connection_tokens WHERE username = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_connection_tokens_by_username", query)
 	if err != nil {
 		return err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, userId, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func RemoveSchemaVersionsByTenant(tenantName string) error {
+func DeleteExpiredConnectionTokens() error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	query := `DELETE FROM schema_versions WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_versions_by_tenant", query)
+	query := `DELETE FROM connection_tokens WHERE expires_at IS NOT NULL AND expires_at < NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_expired_connection_tokens", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func GetSchemaVersionByNumberAndID(version int, schemaId int) (bool, models.SchemaVersion, error) {
+func InsertRevokedConnectionToken(username, tokenHash, revokedBy, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.SchemaVersion{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM schema_versions WHERE schema_id=$1 AND version_number=$2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_version_by_number_and_id", query)
-	if err != nil {
-		return false, models.SchemaVersion{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaId, version)
-	if err != nil {
-		return false, models.SchemaVersion{}, err
-	}
-	defer rows.Close()
-	schemas, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaVersionResponse])
-	if err != nil {
-		return false, models.SchemaVersion{}, err
-	}
-	if len(schemas) == 0 {
-		return false, models.SchemaVersion{}, nil
-	}
-	schemaVersion := models.SchemaVersion{
-		ID:                schemas[0].ID,
-		VersionNumber:     schemas[0].VersionNumber,
-		Active:            schemas[0].Active,
-		CreatedBy:         schemas[0].CreatedBy,
-		CreatedByUsername: schemas[0].CreatedByUsername,
-		CreatedAt:         schemas[0].CreatedAt,
-		SchemaContent:     schemas[0].SchemaContent,
-		SchemaId:          schemas[0].SchemaId,
-		MessageStructName: schemas[0].MessageStructName,
-		Descriptor:        string(schemas[0].Descriptor),
-		TenantName:        strings.ToLower(schemas[0].TenantName),
-	}
-	return true, schemaVersion, nil
-}
 
-func UpdateSchemaActiveVersion(schemaId int, versionNumber int) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	query := `INSERT INTO revoked_connection_tokens(username, token_hash, revoked_by, revoked_at, tenant_name) VALUES($1, $2, $3, $4, $5)
+	ON CONFLICT (username, token_hash, tenant_name) DO NOTHING`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_revoked_connection_token", query)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
-	query := `UPDATE schema_versions
-		SET active = CASE
-		WHEN version_number = $2 THEN true
-		ELSE false
-		END
-	WHERE schema_id = $1
-`
-	stmt, err := conn.Conn().Prepare(ctx, "update_schema_active_version", query)
-	if err != nil {
-		return err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, schemaId, versionNumber)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tokenHash, revokedBy, time.Now(), tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func GetShcemaVersionsCount(schemaId int, tenantName string) (int, error) {
+// GetConnectionTokensForValidation resolves the tenant of a connecting user and returns its active tokens together
+// with the hashes of its revoked tokens in a single round trip, tenantId -1 or an unknown id fall back to defaultTenant
+func GetConnectionTokensForValidation(username string, tenantId int, defaultTenant string) (string, []string, []string, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return "", []string{}, []string{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM schema_versions WHERE schema_id=$1 AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_schema_versions_count", query)
+
+	query := `WITH tenant AS (
+	              SELECT COALESCE((SELECT name FROM tenants WHERE id = $2), $3) AS name
+	          )
+	          SELECT tenant.name,
+	                 ARRAY(SELECT ct.token FROM connection_tokens AS ct
+	                       WHERE ct.username = $1 AND ct.tenant_name = tenant.name AND (ct.expires_at IS NULL OR ct.expires_at > NOW())
+	                       ORDER BY ct.created_at DESC),
+	                 ARRAY(SELECT rct.token_hash FROM revoked_connection_tokens AS rct
+	                       WHERE rct.username = $1 AND rct.tenant_name = tenant.name)
+	          FROM tenant`
+	stmt, err := conn.Conn().Prepare(ctx, "get_connection_tokens_for_validation", query)
 	if err != nil {
-		return 0, err
-	}
-	var count int
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+		return "", []string{}, []string{}, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, schemaId, tenantName).Scan(&count)
+	var tenantName string
+	var tokens, revokedHashes []string
+	err = conn.Conn().QueryRow(ctx, stmt.Name, username, tenantId, defaultTenant).Scan(&tenantName, &tokens, &revokedHashes)
 	if err != nil {
-		return 0, err
+		return "", []string{}, []string{}, err
 	}
-
-	return count, nil
+	for i := range tokens {
+		tokens[i], err = decryptSecret(tokens[i])
+		if err != nil {
+			return "", []string{}, []string{}, err
+		}
+	}
+	return tenantName, tokens, revokedHashes, nil
 }
 
-func GetAllSchemasDetails(tenantName string) ([]models.ExtendedSchema, error) {
+func GetRevokedConnectionTokensByUsername(username, tenantName string) ([]models.RevokedConnectionToken, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.ExtendedSchema{}, err
+		return []models.RevokedConnectionToken{}, err
 	}
 	defer conn.Release()
-	query := `SELECT s.id, s.name, s.type, sv.created_by, s.created_by_username, sv.created_at, asv.version_number
-	          FROM schemas AS s
-	          LEFT JOIN schema_versions AS sv ON s.id = sv.schema_id AND sv.version_number = 1
-	          LEFT JOIN schema_versions AS asv ON s.id = asv.schema_id AND asv.active = true
-	          WHERE asv.id IS NOT NULL AND s.tenant_name = $1
-	          ORDER BY sv.created_at DESC`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_schemas_details", query)
+
+	query := `SELECT * FROM revoked_connection_tokens WHERE username = $1 AND tenant_name = $2 ORDER BY revoked_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_revoked_connection_tokens_by_username", query)
 	if err != nil {
-		return []models.ExtendedSchema{}, err
+		return []models.RevokedConnectionToken{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return []models.ExtendedSchema{}, err
-	}
-	if err == pgx.ErrNoRows {
-		return []models.ExtendedSchema{}, nil
+		return []models.RevokedConnectionToken{}, err
 	}
 	defer rows.Close()
-	schemas := []models.ExtendedSchema{}
-	for rows.Next() {
-		var sc models.ExtendedSchema
-		err := rows.Scan(&sc.ID, &sc.Name, &sc.Type, &sc.CreatedBy, &sc.CreatedByUsername, &sc.CreatedAt, &sc.ActiveVersionNumber)
-		if err != nil {
-			return []models.ExtendedSchema{}, err
-		}
-		schemas = append(schemas, sc)
+	tokens, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.RevokedConnectionToken])
+	if err != nil {
+		return []models.RevokedConnectionToken{}, err
 	}
-	if len(schemas) == 0 {
-		return []models.ExtendedSchema{}, nil
+	if len(tokens) == 0 {
+		return []models.RevokedConnectionToken{}, nil
 	}
-	return schemas, nil
+	return tokens, nil
 }
 
-func FindAndDeleteSchema(schemaIds []int) error {
+// Scram Credentials Functions
+func UpsertScramCredential(username, secretId, salt string, iterations int, storedKey, serverKey, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	removeSchemaVersionsQuery := `DELETE FROM schema_versions
-	WHERE schema_id = ANY($1)`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_versions", removeSchemaVersionsQuery)
-	if err != nil {
-		return err
-	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, schemaIds)
+	query := `INSERT INTO scram_credentials(username, secret_id, salt, iterations, stored_key, server_key, created_at, tenant_name) VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (username, secret_id, tenant_name) DO UPDATE SET salt = EXCLUDED.salt, iterations = EXCLUDED.iterations, stored_key = EXCLUDED.stored_key, server_key = EXCLUDED.server_key, created_at = EXCLUDED.created_at`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_scram_credential", query)
 	if err != nil {
 		return err
 	}
-
-	removeSchemasQuery := `DELETE FROM schemas
-	WHERE id = ANY($1)`
-
-	stmt, err = conn.Conn().Prepare(ctx, "remove_schemas", removeSchemasQuery)
-	if err != nil {
-		return err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, schemaIds)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, secretId, salt, iterations, storedKey, serverKey, time.Now(), tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func InsertNewSchema(schemaName string, schemaType string, createdByUsername string, tenantName string) (models.Schema, int64, error) {
+func GetScramCredentialsByUsername(username, tenantName string) ([]models.ScramCredential, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Schema{}, 0, err
+		return []models.ScramCredential{}, err
 	}
 	defer conn.Release()
 
-	query := `INSERT INTO schemas ( 
-		name, 
-		type,
-		created_by_username,
-		tenant_name) 
-    VALUES($1, $2, $3, $4) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_schema", query)
+	query := `SELECT * FROM scram_credentials WHERE username = $1 AND tenant_name = $2 ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_scram_credentials_by_username", query)
 	if err != nil {
-		return models.Schema{}, 0, err
+		return []models.ScramCredential{}, err
 	}
-
-	var schemaId int
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaName, schemaType, createdByUsername, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return models.Schema{}, 0, err
+		return []models.ScramCredential{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&schemaId)
-		if err != nil {
-			return models.Schema{}, 0, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Schema{}, 0, errors.New("Schema " + schemaName + " already exists")
-				} else {
-					return models.Schema{}, 0, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Schema{}, 0, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Schema{}, 0, err
-		}
+	credentials, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ScramCredential])
+	if err != nil {
+		return []models.ScramCredential{}, err
 	}
-
-	rowsAffected := rows.CommandTag().RowsAffected()
-	newSchema := models.Schema{
-		ID:                schemaId,
-		Name:              schemaName,
-		Type:              schemaType,
-		CreatedByUsername: createdByUsername,
+	if len(credentials) == 0 {
+		return []models.ScramCredential{}, nil
 	}
-	return newSchema, rowsAffected, nil
+	return credentials, nil
 }
 
-func InsertNewSchemaVersion(schemaVersionNumber int, userId int, username string, schemaContent string, schemaId int, messageStructName string, descriptor string, active bool, tenantName string) (models.SchemaVersion, int64, error) {
+// GetScramCredentialsForValidation resolves the tenant of a connecting user the same way GetConnectionTokensForValidation
+// does and returns the SCRAM credentials of the user, tenantId -1 or an unknown id fall back to defaultTenant
+func GetScramCredentialsForValidation(username string, tenantId int, defaultTenant string) (string, []models.ScramCredential, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.SchemaVersion{}, 0, err
+		return "", []models.ScramCredential{}, err
 	}
 	defer conn.Release()
 
-	query := `INSERT INTO schema_versions ( 
-		version_number,
-		active,
-		created_by,
-		created_by_username,
-		created_at,
-		schema_content,
-		schema_id,
-		msg_struct_name,
-		descriptor,
-		tenant_name)
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_schema_version", query)
+	query := `SELECT COALESCE((SELECT name FROM tenants WHERE id = $2), $3)`
+	stmt, err := conn.Conn().Prepare(ctx, "get_scram_credentials_tenant", query)
 	if err != nil {
-		return models.SchemaVersion{}, 0, err
+		return "", []models.ScramCredential{}, err
 	}
-
-	var schemaVersionId int
-	createdAt := time.Now()
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	var tenantName string
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantId, defaultTenant).Scan(&tenantName)
+	if err != nil {
+		return "", []models.ScramCredential{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaVersionNumber, active, userId, username, createdAt, schemaContent, schemaId, messageStructName, []byte(descriptor), tenantName)
+	query = `SELECT * FROM scram_credentials WHERE username = $1 AND tenant_name = $2 ORDER BY created_at DESC`
+	stmt, err = conn.Conn().Prepare(ctx, "get_scram_credentials_by_username", query)
 	if err != nil {
-		return models.SchemaVersion{}, 0, err
+		return "", []models.ScramCredential{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return "", []models.ScramCredential{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&schemaVersionId)
-		if err != nil {
-			return models.SchemaVersion{}, 0, err
-		}
+	credentials, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ScramCredential])
+	if err != nil {
+		return "", []models.ScramCredential{}, err
 	}
+	return tenantName, credentials, nil
+}
 
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.SchemaVersion{}, 0, errors.New("version already exists")
-				} else {
-					return models.SchemaVersion{}, 0, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.SchemaVersion{}, 0, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.SchemaVersion{}, 0, err
-		}
-
+// DeleteScramCredentialsExcept removes the SCRAM credentials of the user whose secret is not one of secretIds,
+// it keeps the rows of the secrets that are still in use after a token rotation
+func DeleteScramCredentialsExcept(username string, secretIds []string, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
 	}
+	defer conn.Release()
 
-	rowsAffected := rows.CommandTag().RowsAffected()
-	newSchemaVersion := models.SchemaVersion{
-		ID:                schemaVersionId,
-		VersionNumber:     schemaVersionNumber,
-		Active:            active,
-		CreatedBy:         userId,
-		CreatedByUsername: username,
-		CreatedAt:         time.Now(),
-		SchemaContent:     schemaContent,
-		SchemaId:          schemaId,
-		MessageStructName: messageStructName,
-		Descriptor:        descriptor,
+	query := `DELETE FROM scram_credentials WHERE username = $1 AND tenant_name = $2 AND NOT (secret_id = ANY($3))`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_scram_credentials_except", query)
+	if err != nil {
+		return err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName, secretIds)
+	if err != nil {
+		return err
 	}
-	return newSchemaVersion, rowsAffected, nil
+	return nil
 }
 
-func CountAllSchemasByTenant(tenantName string) (int64, error) {
-	var count int64
+func DeleteScramCredentialsByUsername(username, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM schemas WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_total_schemas_by_tenant", query)
+
+	query := `DELETE FROM scram_credentials WHERE username = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_scram_credentials_by_username", query)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return 0, err
+		return err
 	}
-
-	return count, nil
+	return nil
 }
 
-// Integration Functions
-func GetIntegration(name string, tenantName string) (bool, models.Integration, error) {
+func RemoveScramCredentialsByTenant(tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	query := `DELETE FROM scram_credentials WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_scram_credentials_by_tenant", query)
+	if err != nil {
+		return err
+	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Dynamic Credentials Functions
+func InsertDynamicCredential(username, createdBy string, expiresAt time.Time, tenantName string) (models.DynamicCredential, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Integration{}, err
+		return models.DynamicCredential{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM integrations WHERE name=$1 AND tenant_name=$2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_integration", query)
+
+	query := `INSERT INTO dynamic_credentials(username, created_by, created_at, expires_at, tenant_name) VALUES($1, $2, $3, $4, $5) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_dynamic_credential", query)
 	if err != nil {
-		return false, models.Integration{}, err
+		return models.DynamicCredential{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, createdBy, time.Now(), expiresAt, tenantName)
 	if err != nil {
-		return false, models.Integration{}, err
+		return models.DynamicCredential{}, err
 	}
 	defer rows.Close()
-	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	credentials, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DynamicCredential])
 	if err != nil {
-		return false, models.Integration{}, err
+		return models.DynamicCredential{}, err
 	}
-	if len(integrations) == 0 {
-		return false, models.Integration{}, nil
+	if len(credentials) == 0 {
+		return models.DynamicCredential{}, errors.New("dynamic credential was not created")
 	}
-	return true, integrations[0], nil
+	return credentials[0], nil
 }
 
-func GetAllIntegrations() (bool, []models.Integration, error) {
+func UpdateDynamicCredentialExpiration(username string, expiresAt time.Time, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM integrations`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_integrations", query)
+
+	query := `UPDATE dynamic_credentials SET expires_at = $3 WHERE username = $1 AND tenant_name = $2 AND expires_at > NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "update_dynamic_credential_expiration", query)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return false, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, username, tenantName, expiresAt)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func GetDynamicCredential(username, tenantName string) (bool, models.DynamicCredential, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.DynamicCredential{}, err
+	}
+	defer conn.Release()
+
+	query := `SELECT * FROM dynamic_credentials WHERE username = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dynamic_credential", query)
+	if err != nil {
+		return false, models.DynamicCredential{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if err != nil {
+		return false, models.DynamicCredential{}, err
 	}
 	defer rows.Close()
-	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	credentials, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DynamicCredential])
 	if err != nil {
-		return false, []models.Integration{}, err
+		return false, models.DynamicCredential{}, err
 	}
-	if len(integrations) == 0 {
-		return false, []models.Integration{}, nil
+	if len(credentials) == 0 {
+		return false, models.DynamicCredential{}, nil
 	}
-	return true, integrations, nil
+	return true, credentials[0], nil
 }
 
-func GetAllIntegrationsByTenant(tenantName string) (bool, []models.Integration, error) {
+func GetExpiredDynamicCredentials() ([]models.DynamicCredential, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return []models.DynamicCredential{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM integrations WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_integrations_by_tenant", query)
+
+	query := `SELECT * FROM dynamic_credentials WHERE expires_at < NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "get_expired_dynamic_credentials", query)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return []models.DynamicCredential{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return false, []models.Integration{}, err
+		return []models.DynamicCredential{}, err
 	}
 	defer rows.Close()
-	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	credentials, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DynamicCredential])
 	if err != nil {
-		return false, []models.Integration{}, err
+		return []models.DynamicCredential{}, err
 	}
-	if len(integrations) == 0 {
-		return false, []models.Integration{}, nil
+	if len(credentials) == 0 {
+		return []models.DynamicCredential{}, nil
 	}
-	return true, integrations, nil
+	return credentials, nil
 }
 
-func DeleteIntegration(name string, tenantName string) error {
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
+func DeleteDynamicCredential(username, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	removeIntegrationQuery := `DELETE FROM integrations WHERE name = $1 AND tenant_name = $2`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_integration", removeIntegrationQuery)
+	query := `DELETE FROM dynamic_credentials WHERE username = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_dynamic_credential", query)
 	if err != nil {
 		return err
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func InsertNewIntegration(tenantName string, name string, keys map[string]interface{}, properties map[string]bool) (models.Integration, error) {
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
+// Alert Rules Functions
+func InsertAlertRule(name, alertType, stationName string, threshold float64, channels []models.AlertChannel, createdBy, tenantName string) (models.AlertRule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Integration{}, err
+		return models.AlertRule{}, err
 	}
 	defer conn.Release()
 
-	query := `INSERT INTO integrations ( 
-		name, 
-		keys,
-		properties,
-		tenant_name,
-		is_valid) 
-    VALUES($1, $2, $3, $4, $5) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_integration", query)
+	query := `INSERT INTO alert_rules(name, alert_type, station_name, threshold, channels, created_by, created_at, updated_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $7, $8) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_alert_rule", query)
 	if err != nil {
-		return models.Integration{}, err
+		return models.AlertRule{}, err
 	}
-
-	var integrationId int
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, keys, properties, tenantName, true)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, alertType, stationName, threshold, channels, createdBy, time.Now(), tenantName)
 	if err != nil {
-		return models.Integration{}, err
+		return models.AlertRule{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&integrationId)
-		if err != nil {
-			return models.Integration{}, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AlertRule])
+	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Integration{}, errors.New("Integration " + name + " already exists")
-				} else {
-					return models.Integration{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Integration{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Integration{}, err
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.AlertRule{}, errors.New("alert rule " + name + " already exists")
 		}
+		return models.AlertRule{}, err
 	}
-	newIntegration := models.Integration{
-		ID:         integrationId,
-		Name:       name,
-		Keys:       keys,
-		Properties: properties,
-		TenantName: tenantName,
-		IsValid:    true,
+	if len(rules) == 0 {
+		return models.AlertRule{}, errors.New("alert rule was not created")
 	}
-	return newIntegration, nil
+	return rules[0], nil
 }
 
-func UpdateIntegration(tenantName string, name string, keys map[string]interface{}, properties map[string]bool) (models.Integration, error) {
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
+func GetAlertRuleById(id int, tenantName string) (bool, models.AlertRule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Integration{}, err
+		return false, models.AlertRule{}, err
 	}
 	defer conn.Release()
-	query := `
-	INSERT INTO integrations(name, keys, properties, tenant_name)
-	VALUES($1, $2, $3, $4)
-	ON CONFLICT(name, tenant_name) DO UPDATE
-	SET keys = excluded.keys, properties = excluded.properties
-	RETURNING id, name, keys, properties, tenant_name, is_valid
-`
-	stmt, err := conn.Conn().Prepare(ctx, "update_integration", query)
+	query := `SELECT * FROM alert_rules WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_alert_rule_by_id", query)
 	if err != nil {
-		return models.Integration{}, err
+		return false, models.AlertRule{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, keys, properties, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return models.Integration{}, err
+		return false, models.AlertRule{}, err
 	}
 	defer rows.Close()
-	integrations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Integration])
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AlertRule])
 	if err != nil {
-		return models.Integration{}, err
+		return false, models.AlertRule{}, err
 	}
-	if len(integrations) == 0 {
-		return models.Integration{}, err
+	if len(rules) == 0 {
+		return false, models.AlertRule{}, nil
 	}
-	return integrations[0], nil
+	return true, rules[0], nil
 }
 
-func UpdateIsValidIntegration(tenantName, integrationName string, isValid bool) error {
+func GetAlertRulesByTenant(tenantName string) ([]models.AlertRule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE integrations SET is_valid = $2 WHERE name = $1 AND tenant_name=$3`
-	stmt, err := conn.Conn().Prepare(ctx, "update_is_valid_integration", query)
+	query := `SELECT * FROM alert_rules WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_alert_rules_by_tenant", query)
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, integrationName, isValid, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
-	return nil
+	defer rows.Close()
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AlertRule])
+	if err != nil {
+		return []models.AlertRule{}, err
+	}
+	return rules, nil
 }
 
-// User Functions
-func UpdatePendingUser(tenantName, username string, pending bool) error {
+func GetEnabledAlertRules() ([]models.AlertRule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET pending = $2 WHERE username = $1 AND tenant_name=$3`
-	stmt, err := conn.Conn().Prepare(ctx, "update_pending_user", query)
+	query := `SELECT * FROM alert_rules WHERE enabled = true ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_alert_rules", query)
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.AlertRule{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, username, pending, tenantName)
+	defer rows.Close()
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AlertRule])
 	if err != nil {
-		return err
+		return []models.AlertRule{}, err
 	}
-
-	return nil
-
+	return rules, nil
 }
-func CreateUser(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string, pending bool, team, position, owner, description string) (models.User, error) {
+
+func UpdateAlertRule(id int, name string, threshold float64, channels []models.AlertChannel, enabled bool, tenantName string) (models.AlertRule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return models.User{}, err
-	}
-	defer conn.Release()
-
-	query := `INSERT INTO users ( 
-		username,
-		password,
-		type,
-		already_logged_in,
-		created_at,
-		avatar_id,
-		full_name, 
-		subscription,
-		skip_get_started,
-		tenant_name,
-		pending,
-		team, 
-		position,
-		owner,
-		description) 
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "create_new_user", query)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.User{}, err
-	}
-	createdAt := time.Now()
-	skipGetStarted := false
-	alreadyLoggedIn := false
-
-	var userId int
+		return models.AlertRule{}, err
+	}
+	defer conn.Release()
+	query := `UPDATE alert_rules SET name = $2, threshold = $3, channels = $4, enabled = $5, updated_at = $6
+	WHERE id = $1 AND tenant_name = $7 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_alert_rule", query)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, hashedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName, pending, team, position, owner, description)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, name, threshold, channels, enabled, time.Now(), tenantName)
 	if err != nil {
-		return models.User{}, err
+		return models.AlertRule{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&userId)
-		if err != nil {
-			return models.User{}, err
+	rules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AlertRule])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.AlertRule{}, errors.New("alert rule " + name + " already exists")
 		}
+		return models.AlertRule{}, err
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, errors.New("alert rule was not updated")
 	}
+	return rules[0], nil
+}
 
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.User{}, errors.New("User " + username + " already exists")
-				} else {
-					return models.User{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.User{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.User{}, err
-		}
+func UpdateAlertRuleState(id int, state string, lastTriggeredAt *time.Time) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	defer conn.Release()
+	query := `UPDATE alert_rules SET state = $2, last_triggered_at = COALESCE($3, last_triggered_at) WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "update_alert_rule_state", query)
+	if err != nil {
+		return err
 	}
-	newUser := models.User{
-		ID:              userId,
-		Username:        username,
-		Password:        hashedPassword,
-		FullName:        fullName,
-		Subscribtion:    subscription,
-		UserType:        userType,
-		CreatedAt:       createdAt,
-		AlreadyLoggedIn: alreadyLoggedIn,
-		AvatarId:        avatarId,
-		TenantName:      tenantName,
-		Pending:         pending,
-		Team:            team,
-		Position:        position,
-		Owner:           owner,
-		Description:     description,
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, state, lastTriggeredAt)
+	if err != nil {
+		return err
 	}
-	return newUser, nil
+	return nil
 }
 
-func UpsertUserUpdatePassword(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string) (bool, error) {
+func DeleteAlertRule(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return false, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO users (
-		username,
-		password,
-		type,
-		already_logged_in,
-		created_at,
-		avatar_id,
-		full_name, 
-		subscription,
-		skip_get_started,
-		tenant_name
-	) 
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
-	ON CONFLICT (username, tenant_name) DO UPDATE SET password = excluded.password
-	RETURNING id, CASE WHEN xmax = 0 THEN 'insert' ELSE 'update' END AS action`
-
-	stmt, err := conn.Conn().Prepare(ctx, "upsert_new_user_update_password", query)
+	query := `DELETE FROM alert_rules WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_alert_rule", query)
 	if err != nil {
 		return false, err
 	}
-	createdAt := time.Now()
-	skipGetStarted := false
-	alreadyLoggedIn := false
-
-	var userId int
-	var op string
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, hashedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName)
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
 	if err != nil {
 		return false, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&userId, &op)
-		if err != nil {
-			return false, err
-		}
-	}
+	return res.RowsAffected() > 0, nil
+}
 
-	didCreate := false
-	if op == "insert" {
-		didCreate = true
+// Webhooks Functions
+func InsertWebhook(name, url, secret string, eventTypes []string, createdBy, tenantName string) (models.Webhook, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Webhook{}, err
 	}
+	defer conn.Release()
 
-	if err := rows.Err(); err != nil {
+	query := `INSERT INTO webhooks(name, url, secret, event_types, created_by, created_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_webhook", query)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, url, secret, eventTypes, createdBy, time.Now(), tenantName)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	defer rows.Close()
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
+	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return false, errors.New("User " + username + " already exists")
-				} else {
-					return false, errors.New(pgErr.Detail)
-				}
-			} else {
-				return false, errors.New(pgErr.Message)
-			}
-		} else {
-			return false, err
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.Webhook{}, errors.New("webhook " + name + " already exists")
 		}
+		return models.Webhook{}, err
 	}
-
-	return didCreate, nil
+	if len(webhooks) == 0 {
+		return models.Webhook{}, errors.New("webhook was not created")
+	}
+	return webhooks[0], nil
 }
 
-func ChangeUserPassword(username string, hashedPassword string, tenantName string) error {
+func GetWebhookById(id int, tenantName string) (bool, models.Webhook, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.Webhook{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET password = $2 WHERE username = $1 AND tenant_name=$3`
-	stmt, err := conn.Conn().Prepare(ctx, "change_user_password", query)
+	query := `SELECT * FROM webhooks WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_webhook_by_id", query)
 	if err != nil {
-		return err
+		return false, models.Webhook{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, username, hashedPassword, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return err
+		return false, models.Webhook{}, err
 	}
-	return nil
+	defer rows.Close()
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
+	if err != nil {
+		return false, models.Webhook{}, err
+	}
+	if len(webhooks) == 0 {
+		return false, models.Webhook{}, nil
+	}
+	return true, webhooks[0], nil
 }
 
-func GetRootUser(tenantName string) (bool, models.User, error) {
+func GetWebhooksByTenant(tenantName string) ([]models.Webhook, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM users WHERE type = 'root' and tenant_name =$1 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_root_user", query)
+	query := `SELECT * FROM webhooks WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_webhooks_by_tenant", query)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
 	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
 	if err != nil {
-		return false, models.User{}, err
-	}
-	if len(users) == 0 {
-		return false, models.User{}, nil
+		return []models.Webhook{}, err
 	}
-	return true, users[0], nil
+	return webhooks, nil
 }
 
-func GetUserByUsername(username string, tenantName string) (bool, models.User, error) {
+func GetEnabledWebhooksByEventType(eventType, tenantName string) ([]models.Webhook, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM users WHERE username = $1 AND tenant_name = $2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_by_username", query)
+	query := `SELECT * FROM webhooks WHERE tenant_name = $1 AND enabled = true AND $2 = ANY(event_types)`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_webhooks_by_event_type", query)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, eventType)
 	if err != nil {
-		return false, models.User{}, err
+		return []models.Webhook{}, err
 	}
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
 	if err != nil {
-		return false, models.User{}, err
-	}
-	if len(users) == 0 {
-		return false, models.User{}, nil
+		return []models.Webhook{}, err
 	}
-	return true, users[0], nil
+	return webhooks, nil
 }
 
-func GetUserWithPermissionsByUsername(username, tenantName string) (bool, models.UserWithPermissions, error) {
+func UpdateWebhook(id int, url string, eventTypes []string, enabled bool, tenantName string) (models.Webhook, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.UserWithPermissions{}, err
+		return models.Webhook{}, err
 	}
 	defer conn.Release()
-
-	query := `
-        SELECT
-            u.id,
-            u.username,
-            u.password,
-            u.type AS user_type,
-            u.already_logged_in,
-            u.created_at,
-            u.avatar_id,
-            u.full_name,
-            u.subscription,
-            u.skip_get_started,
-            u.tenant_name,
-            u.pending,
-            u.position,
-            u.team,
-            u.owner,
-            u.description,
-            u.last_login,
-            (
-				SELECT ARRAY_AGG(p.pattern)
-				FROM permissions p
-				WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
-			) AS allow_read_patterns,
-			(
-				SELECT ARRAY_AGG(p.pattern)
-				FROM permissions p
-				WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
-			) AS allow_write_patterns
-			(
-				SELECT ARRAY_AGG(p.pattern)
-				FROM permissions p
-				WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
-			) AS deny_read_patterns,
-			(
-				SELECT ARRAY_AGG(p.pattern)
-				FROM permissions p
-				WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
-			) AS deny_write_patterns
-			FROM
-				users u
-        WHERE u.username = $1 AND tenant_name = $2
-        LIMIT 1
-    `
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions_by_username", query)
+	query := `UPDATE webhooks SET url = $2, event_types = $3, enabled = $4 WHERE id = $1 AND tenant_name = $5 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_webhook", query)
 	if err != nil {
-		return false, models.UserWithPermissions{}, err
+		return models.Webhook{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, url, eventTypes, enabled, tenantName)
 	if err != nil {
-		return false, models.UserWithPermissions{}, err
+		return models.Webhook{}, err
 	}
 	defer rows.Close()
+	webhooks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Webhook])
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if len(webhooks) == 0 {
+		return models.Webhook{}, errors.New("webhook was not updated")
+	}
+	return webhooks[0], nil
+}
 
-	var userWithPermissions models.UserWithPermissions
-	for rows.Next() {
-		err := rows.Scan(
-			&userWithPermissions.ID,
-			&userWithPermissions.Username,
-			&userWithPermissions.Password,
-			&userWithPermissions.UserType,
-			&userWithPermissions.AlreadyLoggedIn,
-			&userWithPermissions.CreatedAt,
-			&userWithPermissions.AvatarId,
-			&userWithPermissions.FullName,
-			&userWithPermissions.Subscribtion,
-			&userWithPermissions.SkipGetStarted,
-			&userWithPermissions.TenantName,
-			&userWithPermissions.Pending,
-			&userWithPermissions.Position,
-			&userWithPermissions.Team,
-			&userWithPermissions.Owner,
-			&userWithPermissions.Description,
-			&userWithPermissions.LastLogin,
-			&userWithPermissions.Permissions.AllowReadPermissions,
-			&userWithPermissions.Permissions.AllowWritePermissions,
-			&userWithPermissions.Permissions.DenyReadPermissions,
-			&userWithPermissions.Permissions.DenyWritePermissions,
-		)
-		if err != nil {
-			return false, models.UserWithPermissions{}, err
-		}
+func DeleteWebhook(id int, tenantName string) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM webhooks WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_webhook", query)
+	if err != nil {
+		return false, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	if err := rows.Err(); err != nil {
-		return false, models.UserWithPermissions{}, err
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
+	if err != nil {
+		return false, err
 	}
-
-	return true, userWithPermissions, nil
+	return res.RowsAffected() > 0, nil
 }
 
-func GetUserForLogin(username string) (bool, models.User, error) {
+// AMQP Bridges Functions
+func InsertAmqpBridge(name, direction, url, queue, exchange, routingKey, stationName, createdBy, tenantName string) (models.AmqpBridge, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.User{}, err
+		return models.AmqpBridge{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM users WHERE username = $1 AND NOT type = 'application' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_for_login", query)
+
+	query := `INSERT INTO amqp_bridges(name, direction, url, queue, exchange, routing_key, station_name, created_by, created_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_amqp_bridge", query)
 	if err != nil {
-		return false, models.User{}, err
+		return models.AmqpBridge{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, direction, url, queue, exchange, routingKey, stationName, createdBy, time.Now(), tenantName)
 	if err != nil {
-		return false, models.User{}, err
+		return models.AmqpBridge{}, err
 	}
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	bridges, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AmqpBridge])
 	if err != nil {
-		return false, models.User{}, err
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.AmqpBridge{}, errors.New("amqp bridge " + name + " already exists")
+		}
+		return models.AmqpBridge{}, err
 	}
-	if len(users) == 0 {
-		return false, models.User{}, nil
+	if len(bridges) == 0 {
+		return models.AmqpBridge{}, errors.New("amqp bridge was not created")
 	}
-	return true, users[0], nil
+	return bridges[0], nil
 }
 
-func GetUserForLoginByUsernameAndTenant(username, tenantname string) (bool, models.User, error) {
+func GetAmqpBridgeById(id int, tenantName string) (bool, models.AmqpBridge, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.User{}, err
+		return false, models.AmqpBridge{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM users WHERE username = $1 AND tenant_name = $2  AND NOT type = 'application' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_for_login_by_username_and_tenant", query)
+	query := `SELECT * FROM amqp_bridges WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_amqp_bridge_by_id", query)
 	if err != nil {
-		return false, models.User{}, err
+		return false, models.AmqpBridge{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantname)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return false, models.User{}, err
+		return false, models.AmqpBridge{}, err
 	}
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	bridges, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AmqpBridge])
 	if err != nil {
-		return false, models.User{}, err
+		return false, models.AmqpBridge{}, err
 	}
-	if len(users) == 0 {
-		return false, models.User{}, nil
+	if len(bridges) == 0 {
+		return false, models.AmqpBridge{}, nil
 	}
-	return true, users[0], nil
+	return true, bridges[0], nil
 }
 
-func GetAllUsers(tenantName string) ([]models.FilteredGenericUser, error) {
+func GetAmqpBridgesByTenant(tenantName string) ([]models.AmqpBridge, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.FilteredGenericUser{}, err
+		return []models.AmqpBridge{}, err
 	}
 	defer conn.Release()
-	query := `SELECT s.id, s.username, s.type, s.created_at, s.avatar_id, s.full_name, s.pending, s.position, s.team, s.owner, s.description, s.last_login, s.role_id FROM users AS s WHERE tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_users", query)
+	query := `SELECT * FROM amqp_bridges WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_amqp_bridges_by_tenant", query)
 	if err != nil {
-		return []models.FilteredGenericUser{}, err
+		return []models.AmqpBridge{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
 	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.FilteredGenericUser{}, err
+		return []models.AmqpBridge{}, err
 	}
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredGenericUser])
+	bridges, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AmqpBridge])
 	if err != nil {
-		return []models.FilteredGenericUser{}, err
-	}
-	if len(users) == 0 {
-		return []models.FilteredGenericUser{}, nil
+		return []models.AmqpBridge{}, err
 	}
-	return users, nil
+	return bridges, nil
 }
 
-func CountAllUsers() (int64, error) {
-	var count int64
+func GetEnabledAmqpBridges() ([]models.AmqpBridge, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return []models.AmqpBridge{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM users WHERE username NOT LIKE '$%'` // filter memphis internal users
-	stmt, err := conn.Conn().Prepare(ctx, "get_total_users", query)
+	query := `SELECT * FROM amqp_bridges WHERE enabled = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_amqp_bridges", query)
 	if err != nil {
-		return 0, err
+		return []models.AmqpBridge{}, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name).Scan(&count)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return 0, err
+		return []models.AmqpBridge{}, err
 	}
-
-	return count, nil
+	defer rows.Close()
+	bridges, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AmqpBridge])
+	if err != nil {
+		return []models.AmqpBridge{}, err
+	}
+	return bridges, nil
 }
 
-func CountAllUsersByTenant(tenantName string, filterOutApplicationUsers bool) (int64, error) {
-	var count int64
+func UpdateAmqpBridgeEnabled(id int, enabled bool, tenantName string) (models.AmqpBridge, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return models.AmqpBridge{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM users WHERE tenant_name = $1 AND username NOT LIKE '$%'` // filter memphis internal users`
-	preparedStmtName := "get_total_users_by_tenant"
-	if filterOutApplicationUsers {
-		query = `SELECT COUNT(*) FROM users WHERE tenant_name = $1 AND username NOT LIKE '$%' AND type != 'application'` // filter memphis internal users`
-		preparedStmtName = "get_total_users_by_tenant_filtered"
+	query := `UPDATE amqp_bridges SET enabled = $2 WHERE id = $1 AND tenant_name = $3 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_amqp_bridge_enabled", query)
+	if err != nil {
+		return models.AmqpBridge{}, err
 	}
-	stmt, err := conn.Conn().Prepare(ctx, preparedStmtName, query)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, enabled, tenantName)
 	if err != nil {
-		return 0, err
+		return models.AmqpBridge{}, err
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	defer rows.Close()
+	bridges, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AmqpBridge])
 	if err != nil {
-		return 0, err
+		return models.AmqpBridge{}, err
 	}
-
-	return count, nil
+	if len(bridges) == 0 {
+		return models.AmqpBridge{}, errors.New("amqp bridge was not updated")
+	}
+	return bridges[0], nil
 }
 
-func GetAllUsersByTypeAndTenantName(userType []string, tenantName string) ([]models.User, error) {
+func DeleteAmqpBridge(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.User{}, err
+		return false, err
 	}
 	defer conn.Release()
-	var rows pgx.Rows
-	query := `SELECT * FROM users WHERE type=ANY($1) AND tenant_name=$2 AND username NOT LIKE '$%'` // filter memphis internal users
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type_and_tenant_name", query)
+	query := `DELETE FROM amqp_bridges WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_amqp_bridge", query)
 	if err != nil {
-		return []models.User{}, err
+		return false, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err = conn.Conn().Query(ctx, stmt.Name, userType, tenantName)
-	if err != nil {
-		return []models.User{}, err
-	}
-
-	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return []models.User{}, err
-	}
-	if len(users) == 0 {
-		return []models.User{}, nil
+		return false, err
 	}
-	return users, nil
+	return res.RowsAffected() > 0, nil
 }
 
-func GetAllUsersByTenantName(tenantName string) ([]models.User, error) {
+// CDC Connectors Functions
+func InsertCdcConnector(name, sourceType, connectionUrl, database string, tables []string, slotName, stationName, createdBy, tenantName, transform string) (models.CdcConnector, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.User{}, err
+		return models.CdcConnector{}, err
 	}
 	defer conn.Release()
-	var rows pgx.Rows
-	query := `SELECT * FROM users WHERE tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type_and_tenant_name", query)
+
+	query := `INSERT INTO cdc_connectors(name, source_type, connection_url, database, tables, slot_name, station_name, created_by, created_at, tenant_name, transform)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_cdc_connector", query)
 	if err != nil {
-		return []models.User{}, err
+		return models.CdcConnector{}, err
 	}
-
-	rows, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, sourceType, connectionUrl, database, tables, slotName, stationName, createdBy, time.Now(), tenantName, transform)
 	if err != nil {
-		return []models.User{}, err
+		return models.CdcConnector{}, err
 	}
-
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	connectors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CdcConnector])
 	if err != nil {
-		return []models.User{}, err
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.CdcConnector{}, errors.New("cdc connector " + name + " already exists")
+		}
+		return models.CdcConnector{}, err
 	}
-	if len(users) == 0 {
-		return []models.User{}, nil
+	if len(connectors) == 0 {
+		return models.CdcConnector{}, errors.New("cdc connector was not created")
 	}
-	return users, nil
+	return connectors[0], nil
 }
 
-func GetAllUsersByType(userType []string) ([]models.User, error) {
+func GetCdcConnectorById(id int, tenantName string) (bool, models.CdcConnector, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.User{}, err
+		return false, models.CdcConnector{}, err
 	}
 	defer conn.Release()
-	var rows pgx.Rows
-	query := `SELECT * FROM users WHERE type=ANY($1) AND username NOT LIKE '$%'` // filter memphis internal users
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_users_by_type", query)
+	query := `SELECT * FROM cdc_connectors WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_cdc_connector_by_id", query)
 	if err != nil {
-		return []models.User{}, err
+		return false, models.CdcConnector{}, err
 	}
-	rows, err = conn.Conn().Query(ctx, stmt.Name, userType)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return []models.User{}, err
+		return false, models.CdcConnector{}, err
 	}
-
 	defer rows.Close()
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	connectors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CdcConnector])
 	if err != nil {
-		return []models.User{}, err
+		return false, models.CdcConnector{}, err
 	}
-	if len(users) == 0 {
-		return []models.User{}, nil
+	if len(connectors) == 0 {
+		return false, models.CdcConnector{}, nil
 	}
-	return users, nil
+	return true, connectors[0], nil
 }
 
-func UpdateUserAlreadyLoggedIn(userId int) error {
+func GetCdcConnectorsByTenant(tenantName string) ([]models.CdcConnector, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.CdcConnector{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET already_logged_in = true WHERE id = $1`
-	stmt, _ := conn.Conn().Prepare(ctx, "update_user_already_logged_in", query)
-	conn.Conn().Query(ctx, stmt.Name, userId)
-	return nil
+	query := `SELECT * FROM cdc_connectors WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_cdc_connectors_by_tenant", query)
+	if err != nil {
+		return []models.CdcConnector{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	if err != nil {
+		return []models.CdcConnector{}, err
+	}
+	defer rows.Close()
+	connectors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CdcConnector])
+	if err != nil {
+		return []models.CdcConnector{}, err
+	}
+	return connectors, nil
 }
 
-func UpdateLastLoginUser(userId int) (time.Time, error) {
+func GetEnabledCdcConnectors() ([]models.CdcConnector, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return time.Time{}, err
+		return []models.CdcConnector{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET last_login = NOW() WHERE id = $1 RETURNING last_login`
-	stmt, err := conn.Conn().Prepare(ctx, "update_last_login_in_user", query)
+	query := `SELECT * FROM cdc_connectors WHERE enabled = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_cdc_connectors", query)
 	if err != nil {
-		return time.Time{}, err
+		return []models.CdcConnector{}, err
 	}
-	var lastLogin time.Time
-	if err := conn.Conn().QueryRow(ctx, stmt.Name, userId).Scan(&lastLogin); err != nil {
-		return time.Time{}, err
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.CdcConnector{}, err
 	}
-	return lastLogin, nil
+	defer rows.Close()
+	connectors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CdcConnector])
+	if err != nil {
+		return []models.CdcConnector{}, err
+	}
+	return connectors, nil
 }
 
-func UpdateSkipGetStarted(username string, tenantName string) error {
+func UpdateCdcConnectorEnabled(id int, enabled bool, tenantName string) (models.CdcConnector, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.CdcConnector{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET skip_get_started = true WHERE username = $1 AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "update_skip_get_started", query)
+	query := `UPDATE cdc_connectors SET enabled = $2 WHERE id = $1 AND tenant_name = $3 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_cdc_connector_enabled", query)
 	if err != nil {
-		return err
+		return models.CdcConnector{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, username, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, enabled, tenantName)
 	if err != nil {
-		return err
+		return models.CdcConnector{}, err
 	}
-	return nil
+	defer rows.Close()
+	connectors, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CdcConnector])
+	if err != nil {
+		return models.CdcConnector{}, err
+	}
+	if len(connectors) == 0 {
+		return models.CdcConnector{}, errors.New("cdc connector was not updated")
+	}
+	return connectors[0], nil
 }
 
-func DeleteUser(username string, tenantName string) error {
+func UpdateCdcConnectorPosition(id int, position string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	removeUserQuery := `DELETE FROM users WHERE username = $1 AND tenant_name=$2`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_user", removeUserQuery)
+	query := `UPDATE cdc_connectors SET position = $2 WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "update_cdc_connector_position", query)
 	if err != nil {
 		return err
 	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, position)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func DeleteUsersByTenant(tenantName string) ([]string, error) {
+func DeleteCdcConnector(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	defer conn.Release()
-
-	removeUserQuery := `DELETE FROM users WHERE tenant_name = $1 RETURNING username`
-	rows, err := conn.Query(ctx, removeUserQuery, tenantName)
+	query := `DELETE FROM cdc_connectors WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_cdc_connector", query)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer rows.Close()
-
-	var users_list []string
-
-	for rows.Next() {
-		var usermame string
-		err := rows.Scan(&usermame)
-		if err != nil {
-			return nil, err
-		}
-		if !strings.HasPrefix(usermame, "$") { // skip memphis internal users
-			users_list = append(users_list, usermame)
-		}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	return users_list, err
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
 }
 
-func EditAvatar(username string, avatarId int, tenantName string) error {
+// ClickHouse Sinks Functions
+func InsertClickhouseSink(name, url, database, tableName, stationName string, batchSize, flushIntervalMs int, createdBy, tenantName, transform string) (models.ClickhouseSink, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.ClickhouseSink{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE users SET avatar_id = $2 WHERE username = $1 AND tenant_name=$3`
-	stmt, err := conn.Conn().Prepare(ctx, "edit_avatar", query)
+
+	query := `INSERT INTO clickhouse_sinks(name, url, database, table_name, station_name, batch_size, flush_interval_ms, created_by, created_at, tenant_name, transform)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_clickhouse_sink", query)
 	if err != nil {
-		return err
+		return models.ClickhouseSink{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, username, avatarId, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, url, database, tableName, stationName, batchSize, flushIntervalMs, createdBy, time.Now(), tenantName, transform)
 	if err != nil {
-		return err
+		return models.ClickhouseSink{}, err
 	}
-	return nil
+	defer rows.Close()
+	sinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ClickhouseSink])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.ClickhouseSink{}, errors.New("clickhouse sink " + name + " already exists")
+		}
+		return models.ClickhouseSink{}, err
+	}
+	if len(sinks) == 0 {
+		return models.ClickhouseSink{}, errors.New("clickhouse sink was not created")
+	}
+	return sinks[0], nil
 }
 
-func GetAllActiveUsersStations(tenantName string) ([]models.FilteredUser, error) {
+func GetClickhouseSinkById(id int, tenantName string) (bool, models.ClickhouseSink, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return false, models.ClickhouseSink{}, err
 	}
 	defer conn.Release()
-	query := `
-	SELECT DISTINCT u.username
-	FROM users AS u
-	JOIN stations AS s ON u.id = s.created_by
-	WHERE s.tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
-
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_users_stations", query)
+	query := `SELECT * FROM clickhouse_sinks WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_clickhouse_sink_by_id", query)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return false, models.ClickhouseSink{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return false, models.ClickhouseSink{}, err
 	}
 	defer rows.Close()
-	userList, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredUser])
+	sinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ClickhouseSink])
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return false, models.ClickhouseSink{}, err
 	}
-	if len(userList) == 0 {
-		return []models.FilteredUser{}, nil
+	if len(sinks) == 0 {
+		return false, models.ClickhouseSink{}, nil
 	}
-	return userList, nil
+	return true, sinks[0], nil
 }
 
-func GetAllActiveUsersSchemaVersions(tenantName string) ([]models.FilteredUser, error) {
+func GetClickhouseSinksByTenant(tenantName string) ([]models.ClickhouseSink, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return []models.ClickhouseSink{}, err
 	}
 	defer conn.Release()
-	query := `
-	SELECT DISTINCT u.username
-	FROM users AS u
-	JOIN schema_versions AS s ON u.id = s.created_by
-	WHERE s.tenant_name=$1 AND username NOT LIKE '$%'` // filter memphis internal users
-
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_active_users_schema_versions", query)
+	query := `SELECT * FROM clickhouse_sinks WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_clickhouse_sinks_by_tenant", query)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return []models.ClickhouseSink{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
 	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.FilteredUser{}, err
+		return []models.ClickhouseSink{}, err
 	}
 	defer rows.Close()
-	userList, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.FilteredUser])
+	sinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ClickhouseSink])
 	if err != nil {
-		return []models.FilteredUser{}, err
-	}
-	if len(userList) == 0 {
-		return []models.FilteredUser{}, nil
+		return []models.ClickhouseSink{}, err
 	}
-	return userList, nil
+	return sinks, nil
 }
 
-func UpsertBatchOfUsers(users []models.User) error {
+func GetEnabledClickhouseSinks() ([]models.ClickhouseSink, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.ClickhouseSink{}, err
 	}
 	defer conn.Release()
-	valueStrings := make([]string, 0, len(users))
-	valueArgs := make([]interface{}, 0, len(users)*6)
-	for i, user := range users {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
-		valueArgs = append(valueArgs, user.Username)
-		valueArgs = append(valueArgs, user.Password)
-		valueArgs = append(valueArgs, user.UserType)
-		valueArgs = append(valueArgs, user.CreatedAt)
-		valueArgs = append(valueArgs, user.AvatarId)
-		valueArgs = append(valueArgs, user.FullName)
+	query := `SELECT * FROM clickhouse_sinks WHERE enabled = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_clickhouse_sinks", query)
+	if err != nil {
+		return []models.ClickhouseSink{}, err
 	}
-	query := fmt.Sprintf("INSERT INTO users (username, password, type, created_at, avatar_id, full_name) VALUES %s ON CONFLICT (username) DO UPDATE SET password = EXCLUDED.password", strings.Join(valueStrings, ","))
-	stmt, err := conn.Conn().Prepare(ctx, "update_batch_of_users", query)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return err
+		return []models.ClickhouseSink{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, valueArgs...)
+	defer rows.Close()
+	sinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ClickhouseSink])
 	if err != nil {
-		return err
+		return []models.ClickhouseSink{}, err
 	}
-	return nil
+	return sinks, nil
 }
 
-// Tags Functions
-func InsertNewTag(name string, color string, stationArr []int, schemaArr []int, userArr []int, tenantName string) (models.Tag, error) {
+func UpdateClickhouseSink(id int, enabled bool, batchSize, flushIntervalMs int, tenantName string) (models.ClickhouseSink, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Tag{}, err
+		return models.ClickhouseSink{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO tags ( 
-		name,
-		color,
-		users,
-		stations,
-		schemas,
-		tenant_name) 
-    VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_new_tag", query)
+	query := `UPDATE clickhouse_sinks SET enabled = $2, batch_size = $3, flush_interval_ms = $4 WHERE id = $1 AND tenant_name = $5 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_clickhouse_sink", query)
 	if err != nil {
-		return models.Tag{}, err
+		return models.ClickhouseSink{}, err
 	}
-
-	var tagId int
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, color, userArr, stationArr, schemaArr, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, enabled, batchSize, flushIntervalMs, tenantName)
 	if err != nil {
-		return models.Tag{}, err
+		return models.ClickhouseSink{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&tagId)
-		if err != nil {
-			return models.Tag{}, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Tag{}, errors.New("Tag" + name + " already exists")
-				} else {
-					return models.Tag{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Tag{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Tag{}, err
-		}
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	sinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ClickhouseSink])
+	if err != nil {
+		return models.ClickhouseSink{}, err
 	}
-	newTag := models.Tag{
-		ID:         tagId,
-		Name:       name,
-		Color:      color,
-		Stations:   stationArr,
-		Schemas:    schemaArr,
-		Users:      userArr,
-		TenantName: tenantName,
+	if len(sinks) == 0 {
+		return models.ClickhouseSink{}, errors.New("clickhouse sink was not updated")
 	}
-	return newTag, nil
+	return sinks[0], nil
 }
 
-func InsertEntityToTag(tagName string, entity string, entity_id int, tenantName, color string) error {
-	var entityDBList string
-	switch entity {
-	case "station":
-		entityDBList = "stations"
-	case "schema":
-		entityDBList = "schemas"
-	case "user":
-		entityDBList = "users"
-	}
+func DeleteClickhouseSink(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer conn.Release()
-	if color == "" {
-		query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_APPEND(` + entityDBList + `, $1) WHERE name = $2 AND tenant_name = $3`
-		stmt, err := conn.Conn().Prepare(ctx, "insert_entity_to_tag", query)
-		if err != nil {
-			return err
-		}
-		_, err = conn.Conn().Query(ctx, stmt.Name, entity_id, tagName, tenantName)
-		if err != nil {
-			return err
-		}
-	} else {
-		query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_APPEND(` + entityDBList + `, $1) , color = $4 WHERE name = $2 AND tenant_name = $3`
-		stmt, err := conn.Conn().Prepare(ctx, "insert_entity_to_tag", query)
-		if err != nil {
-			return err
-		}
-		_, err = conn.Conn().Query(ctx, stmt.Name, entity_id, tagName, tenantName, color)
-		if err != nil {
-			return err
-		}
+	query := `DELETE FROM clickhouse_sinks WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_clickhouse_sink", query)
+	if err != nil {
+		return false, err
 	}
-	return nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
 }
 
-func RemoveAllTagsFromEntity(entity string, entity_id int) error {
+// Catalog Exporters Functions
+func InsertCatalogExporter(name, exporterType, url, token, serviceName string, intervalSec int, createdBy, tenantName string) (models.CatalogExporter, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.CatalogExporter{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE tags SET ` + entity + ` = ARRAY_REMOVE(` + entity + `, $1) WHERE $1 = ANY(` + entity + `)`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_all_tags_from_entity", query)
+
+	query := `INSERT INTO catalog_exporters(name, type, url, token, service_name, interval_sec, created_by, created_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_catalog_exporter", query)
 	if err != nil {
-		return err
+		return models.CatalogExporter{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, entity_id)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, exporterType, url, token, serviceName, intervalSec, createdBy, time.Now(), tenantName)
 	if err != nil {
-		return err
+		return models.CatalogExporter{}, err
 	}
-	return nil
+	defer rows.Close()
+	exporters, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CatalogExporter])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.CatalogExporter{}, errors.New("catalog exporter " + name + " already exists")
+		}
+		return models.CatalogExporter{}, err
+	}
+	if len(exporters) == 0 {
+		return models.CatalogExporter{}, errors.New("catalog exporter was not created")
+	}
+	return exporters[0], nil
 }
 
-func RemoveTagFromEntity(tagName string, entity string, entity_id int) error {
-	var entityDBList string
-	switch entity {
-	case "station":
-		entityDBList = "stations"
-	case "schema":
-		entityDBList = "schemas"
-	case "user":
-		entityDBList = "users"
-	}
+func GetCatalogExporterById(id int, tenantName string) (bool, models.CatalogExporter, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.CatalogExporter{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE tags SET ` + entityDBList + ` = ARRAY_REMOVE(` + entityDBList + `, $2) WHERE name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_tag_from_entity", query)
+	query := `SELECT * FROM catalog_exporters WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_catalog_exporter_by_id", query)
 	if err != nil {
-		return err
+		return false, models.CatalogExporter{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tagName, entity_id)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return err
+		return false, models.CatalogExporter{}, err
 	}
-	return nil
+	defer rows.Close()
+	exporters, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CatalogExporter])
+	if err != nil {
+		return false, models.CatalogExporter{}, err
+	}
+	if len(exporters) == 0 {
+		return false, models.CatalogExporter{}, nil
+	}
+	return true, exporters[0], nil
 }
 
-func GetTagsByEntityID(entity string, id int) ([]models.Tag, error) {
-	var entityDBList string
-	switch entity {
-	case "station":
-		entityDBList = "stations"
-	case "schema":
-		entityDBList = "schemas"
-	case "user":
-		entityDBList = "users"
-	}
+func GetCatalogExportersByTenant(tenantName string) ([]models.CatalogExporter, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Tag{}, err
+		return []models.CatalogExporter{}, err
 	}
 	defer conn.Release()
-	uid, err := uuid.NewV4()
+	query := `SELECT * FROM catalog_exporters WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_catalog_exporters_by_tenant", query)
 	if err != nil {
-		return []models.Tag{}, err
+		return []models.CatalogExporter{}, err
 	}
-	query := `SELECT * FROM tags AS t WHERE $1 = ANY(t.` + entityDBList + `) LIMIT 1000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_id"+uid.String(), query)
-	if err != nil {
-		return []models.Tag{}, err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return nil, err
+		return []models.CatalogExporter{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	exporters, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CatalogExporter])
 	if err != nil {
-		return []models.Tag{}, err
+		return []models.CatalogExporter{}, err
 	}
-	if len(tags) == 0 {
-		return []models.Tag{}, err
-	}
-	return tags, nil
+	return exporters, nil
 }
 
-func GetTagsByEntityIDLight(entity string, id int) ([]models.CreateTag, error) {
-	var entityDBList string
-	switch entity {
-	case "station":
-		entityDBList = "stations"
-	case "schema":
-		entityDBList = "schemas"
-	case "user":
-		entityDBList = "users"
-	}
+func GetEnabledCatalogExporters() ([]models.CatalogExporter, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.CreateTag{}, err
+		return []models.CatalogExporter{}, err
 	}
 	defer conn.Release()
-	uid, err := uuid.NewV4()
-	if err != nil {
-		return []models.CreateTag{}, err
-	}
-	query := `SELECT t.name, t.color FROM tags AS t WHERE $1 = ANY(t.` + entityDBList + `) LIMIT 1000;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_id_light"+uid.String(), query)
+	query := `SELECT * FROM catalog_exporters WHERE enabled = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_catalog_exporters", query)
 	if err != nil {
-		return []models.CreateTag{}, err
+		return []models.CatalogExporter{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return nil, err
+		return []models.CatalogExporter{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CreateTag])
+	exporters, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CatalogExporter])
 	if err != nil {
-		return []models.CreateTag{}, err
-	}
-	if len(tags) == 0 {
-		return []models.CreateTag{}, err
+		return []models.CatalogExporter{}, err
 	}
-	return tags, nil
+	return exporters, nil
 }
 
-func GetTagsByEntityType(entity string, tenantName string) ([]models.Tag, error) {
-	var entityDBList string
-	switch entity {
-	case "station":
-		entityDBList = "stations"
-	case "schema":
-		entityDBList = "schemas"
-	case "user":
-		entityDBList = "users"
-	default:
-		entityDBList = ""
-	}
-
+func UpdateCatalogExporter(id int, enabled bool, intervalSec int, tenantName string) (models.CatalogExporter, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Tag{}, err
-	}
-	defer conn.Release()
-	var query string
-	if entityDBList == "" { // Get All
-		query = `SELECT * FROM tags WHERE tenant_name=$1`
-	} else {
-		query = fmt.Sprintf(`SELECT * FROM tags WHERE %s IS NOT NULL AND array_length(%s, 1) > 0 AND tenant_name=$1`, entityDBList, entityDBList)
+		return models.CatalogExporter{}, err
 	}
-	stmt, err := conn.Conn().Prepare(ctx, "get_tags_by_entity_type", query)
+	defer conn.Release()
+	query := `UPDATE catalog_exporters SET enabled = $2, interval_sec = $3 WHERE id = $1 AND tenant_name = $4 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_catalog_exporter", query)
 	if err != nil {
-		return []models.Tag{}, err
+		return models.CatalogExporter{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, enabled, intervalSec, tenantName)
 	if err != nil {
-		return nil, err
+		return models.CatalogExporter{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	exporters, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CatalogExporter])
 	if err != nil {
-		return []models.Tag{}, err
+		return models.CatalogExporter{}, err
 	}
-	if len(tags) == 0 {
-		return []models.Tag{}, nil
+	if len(exporters) == 0 {
+		return models.CatalogExporter{}, errors.New("catalog exporter was not updated")
 	}
-	return tags, nil
+	return exporters[0], nil
 }
 
-func GetAllUsedTags(tenantName string) ([]models.Tag, error) {
+func DeleteCatalogExporter(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(schemas, 1) > 0 OR ARRAY_LENGTH(stations, 1) > 0 OR ARRAY_LENGTH(users, 1) > 0) AND tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_tags", query)
+	query := `DELETE FROM catalog_exporters WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_catalog_exporter", query)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return []models.Tag{}, err
-	}
-	if len(tags) == 0 {
-		return []models.Tag{}, nil
+		return false, err
 	}
-	return tags, nil
+	return res.RowsAffected() > 0, nil
 }
 
-func GetAllUsedStationsTags(tenantName string) ([]models.Tag, error) {
+// Managed Resources Functions
+func UpsertManagedResource(kind, name, owner string, generation, observedGeneration int64, appliedETag, phase, message, tenantName string) (models.ManagedResource, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return nil, err
+		return models.ManagedResource{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(stations, 1) > 0) AND tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_stations_tags", query)
+
+	query := `INSERT INTO managed_resources(kind, name, owner, generation, observed_generation, applied_etag, phase, message, updated_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (kind, name, tenant_name) DO UPDATE SET owner = $3, generation = $4, observed_generation = $5, applied_etag = $6, phase = $7, message = $8, updated_at = $9
+	RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_managed_resource", query)
 	if err != nil {
-		return nil, err
+		return models.ManagedResource{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, kind, name, owner, generation, observedGeneration, appliedETag, phase, message, time.Now(), tenantName)
 	if err != nil {
-		return nil, err
+		return models.ManagedResource{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	resources, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ManagedResource])
 	if err != nil {
-		return []models.Tag{}, err
+		return models.ManagedResource{}, err
 	}
-	if len(tags) == 0 {
-		return []models.Tag{}, nil
+	if len(resources) == 0 {
+		return models.ManagedResource{}, errors.New("managed resource was not updated")
 	}
-	return tags, nil
+	return resources[0], nil
 }
 
-func GetAllUsedSchemasTags(tenantName string) ([]models.Tag, error) {
+func GetManagedResource(kind, name, tenantName string) (bool, models.ManagedResource, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return nil, err
+		return false, models.ManagedResource{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tags WHERE (ARRAY_LENGTH(schemas, 1) > 0) AND tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_used_schemas_tags", query)
+	query := `SELECT * FROM managed_resources WHERE kind = $1 AND name = $2 AND tenant_name = $3 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_managed_resource", query)
 	if err != nil {
-		return nil, err
+		return false, models.ManagedResource{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, kind, name, tenantName)
 	if err != nil {
-		return nil, err
+		return false, models.ManagedResource{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	resources, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ManagedResource])
 	if err != nil {
-		return []models.Tag{}, err
+		return false, models.ManagedResource{}, err
 	}
-	if len(tags) == 0 {
-		return []models.Tag{}, nil
+	if len(resources) == 0 {
+		return false, models.ManagedResource{}, nil
 	}
-	return tags, nil
+	return true, resources[0], nil
 }
 
-func GetTagByName(name string, tenantName string) (bool, models.Tag, error) {
+// GetManagedResourcesByTenant returns the managed resources of a tenant, an empty owner returns those of every owner
+func GetManagedResourcesByTenant(tenantName, owner string) ([]models.ManagedResource, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Tag{}, err
+		return []models.ManagedResource{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tags WHERE name=$1 AND tenant_name=$2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_tag_by_name", query)
+	query := `SELECT * FROM managed_resources WHERE tenant_name = $1 AND ($2 = '' OR owner = $2) ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_managed_resources_by_tenant", query)
 	if err != nil {
-		return false, models.Tag{}, err
+		return []models.ManagedResource{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, owner)
 	if err != nil {
-		return false, models.Tag{}, err
+		return []models.ManagedResource{}, err
 	}
 	defer rows.Close()
-	tags, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tag])
+	resources, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ManagedResource])
 	if err != nil {
-		return false, models.Tag{}, err
-	}
-	if len(tags) == 0 {
-		return false, models.Tag{}, nil
+		return []models.ManagedResource{}, err
 	}
-	return true, tags[0], nil
+	return resources, nil
 }
 
-// Image Functions
-func InsertImage(name string, base64Encoding string, tenantName string) error {
+func DeleteManagedResource(kind, name, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	query := `DELETE FROM managed_resources WHERE kind = $1 AND name = $2 AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_managed_resource", query)
+	if err != nil {
+		return err
+	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	err := UpsertConfiguration(name, base64Encoding, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, kind, name, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func DeleteImage(name string, tenantName string) error {
+// GetInventory reads all the tenant's resources within a single read only transaction so the result is a consistent snapshot
+func GetInventory(tenantName string) (models.Inventory, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	inventory := models.Inventory{TenantName: tenantName}
+	query := `SELECT name, retention_type::text, retention_value, storage_type::text, replicas, COALESCE(partitions, '{}'), COALESCE(schema_name, ''), dls_station, is_native, created_by_username, created_at, updated_at
+		FROM stations WHERE tenant_name = $1 AND is_deleted = false ORDER BY name`
+	rows, err := tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Stations, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.InventoryStation, error) {
+		var s models.InventoryStation
+		err := row.Scan(&s.Name, &s.RetentionType, &s.RetentionValue, &s.StorageType, &s.Replicas, &s.PartitionsList, &s.SchemaName, &s.DlsStation, &s.IsNative, &s.CreatedByUsername, &s.CreatedAt, &s.UpdatedAt)
+		return s, err
+	})
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	query = `SELECT sc.name, sc.type::text, sc.created_by_username, COALESCE(MAX(v.version_number) FILTER (WHERE v.active), 0), COUNT(v.version_number),
+			(SELECT COUNT(*) FROM stations st WHERE st.schema_name = sc.name AND st.tenant_name = sc.tenant_name AND st.is_deleted = false)
+		FROM schemas sc
+		LEFT JOIN schema_versions v ON v.schema_id = sc.id
+		WHERE sc.tenant_name = $1
+		GROUP BY sc.id
+		ORDER BY sc.name`
+	rows, err = tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Schemas, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.InventorySchema])
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	query = `SELECT p.name, s.name, p.type::text, p.connection_id, p.is_active, p.sdk, p.app_id, p.updated_at
+		FROM producers AS p
+		JOIN stations AS s ON s.id = p.station_id
+		WHERE p.tenant_name = $1 AND s.is_deleted = false
+		ORDER BY s.name, p.name`
+	rows, err = tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Producers, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.InventoryProducer])
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	query = `SELECT c.name, c.consumers_group, s.name, c.type::text, c.connection_id, c.is_active, c.sdk, c.app_id, c.updated_at
+		FROM consumers AS c
+		JOIN stations AS s ON s.id = c.station_id
+		WHERE c.tenant_name = $1 AND s.is_deleted = false
+		ORDER BY s.name, c.consumers_group, c.name`
+	rows, err = tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Consumers, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.InventoryConsumer])
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	query = `SELECT username, type::text, COALESCE(full_name, ''), team, pending, created_at, last_login FROM users WHERE tenant_name = $1 ORDER BY username`
+	rows, err = tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Users, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.InventoryUser])
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	query = `SELECT name, is_valid FROM integrations WHERE tenant_name = $1 ORDER BY name`
+	rows, err = tx.Query(ctx, query, tenantName)
+	if err != nil {
+		return models.Inventory{}, err
+	}
+	inventory.Integrations, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.InventoryIntegration, error) {
+		var i models.InventoryIntegration
+		err := row.Scan(&i.Name, &i.IsValid)
+		return i, err
+	})
+	if err != nil {
+		return models.Inventory{}, err
+	}
+
+	return inventory, tx.Commit(ctx)
+}
 
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+// Station Storage Keys Functions
+func UpsertStationStorageKey(stationName, wrappedKey, tenantName string) (err error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
 
-	removeImageQuery := `DELETE FROM configurations
-	WHERE key = $1 AND tenant_name=$2`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_image", removeImageQuery)
+	tx, err := conn.Conn().Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
+	// a station created again under the same name starts a new key history
+	err = deleteStationStorageKeyHistory(ctx, tx, stationName, tenantName)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO station_storage_keys(station_name, wrapped_key, tenant_name) VALUES($1, $2, $3)
+	ON CONFLICT(station_name, tenant_name) DO UPDATE SET wrapped_key = EXCLUDED.wrapped_key, version = 1, created_at = NOW(), rotated_at = NOW()`
+	stmt, err := tx.Prepare(ctx, "upsert_station_storage_key", query)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, stationName, wrappedKey, tenantName)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func GetImage(name string, tenantName string) (bool, models.Image, error) {
+func deleteStationStorageKeyHistory(ctx context.Context, tx pgx.Tx, stationName, tenantName string) error {
+	query := `DELETE FROM station_storage_key_versions WHERE station_name = $1 AND tenant_name = $2`
+	stmt, err := tx.Prepare(ctx, "delete_station_storage_key_versions", query)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, stationName, tenantName)
+	if err != nil {
+		return err
+	}
+	query = `DELETE FROM station_storage_key_rewraps WHERE station_name = $1 AND tenant_name = $2`
+	stmt, err = tx.Prepare(ctx, "delete_station_storage_key_rewraps", query)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, stationName, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetStationStorageKey(stationName, tenantName string) (bool, models.StationStorageKey, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Image{}, err
+		return false, models.StationStorageKey{}, err
 	}
 	defer conn.Release()
-	query := `SELECT id, key, value FROM configurations WHERE key = $1 AND tenant_name =$2 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_image", query)
+	query := `SELECT * FROM station_storage_keys WHERE station_name = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_storage_key", query)
 	if err != nil {
-		return false, models.Image{}, err
+		return false, models.StationStorageKey{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationName, tenantName)
 	if err != nil {
-		return false, models.Image{}, err
+		return false, models.StationStorageKey{}, err
 	}
 	defer rows.Close()
-	images, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Image])
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationStorageKey])
 	if err != nil {
-		return false, models.Image{}, err
+		return false, models.StationStorageKey{}, err
 	}
-	if len(images) == 0 {
-		return false, models.Image{}, nil
+	if len(keys) == 0 {
+		return false, models.StationStorageKey{}, nil
 	}
-	return true, images[0], nil
+	return true, keys[0], nil
 }
 
-// dls Functions
-func InsertSchemaverseDlsMsg(stationId int, messageSeq int, producerName string, poisonedCgs []string, messageDetails models.MessagePayload, validationError string, tenantName string, partitionNumber int) (models.DlsMessage, error) {
+// GetStationStorageKeyVersions returns the retired keys of a station, newest first
+func GetStationStorageKeyVersions(stationName, tenantName string) ([]models.StationStorageKeyVersion, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	connection, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.DlsMessage{}, err
+		return []models.StationStorageKeyVersion{}, err
 	}
-	defer connection.Release()
-
-	query := `INSERT INTO dls_messages( 
-			station_id,
-			message_seq,
-			poisoned_cgs,
-			message_details,
-			updated_at,
-			message_type,
-			validation_error,
-			tenant_name,
-			producer_name,
-			partition_number) 
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id`
-
-	stmt, err := connection.Conn().Prepare(ctx, "insert_dls_messages", query)
+	defer conn.Release()
+	query := `SELECT * FROM station_storage_key_versions WHERE station_name = $1 AND tenant_name = $2 ORDER BY version DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_storage_key_versions", query)
 	if err != nil {
-		return models.DlsMessage{}, err
+		return []models.StationStorageKeyVersion{}, err
 	}
-	updatedAt := time.Now()
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := connection.Conn().Query(ctx, stmt.Name, stationId, messageSeq, poisonedCgs, messageDetails, updatedAt, "schema", validationError, tenantName, producerName, partitionNumber)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationName, tenantName)
 	if err != nil {
-		return models.DlsMessage{}, err
+		return []models.StationStorageKeyVersion{}, err
 	}
 	defer rows.Close()
-	var messagePayloadId int
-	for rows.Next() {
-		err := rows.Scan(&messagePayloadId)
-		if err != nil {
-			return models.DlsMessage{}, err
-		}
-	}
-
+	versions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationStorageKeyVersion])
 	if err != nil {
-		return models.DlsMessage{}, err
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
-	}
-	deadLetterPayload := models.DlsMessage{
-		ID:           messagePayloadId,
-		StationId:    stationId,
-		MessageSeq:   messageSeq,
-		ProducerName: producerName,
-		PoisonedCgs:  poisonedCgs,
-		MessageDetails: models.MessagePayload{
-			TimeSent: messageDetails.TimeSent,
-			Size:     messageDetails.Size,
-			Data:     messageDetails.Data,
-			Headers:  messageDetails.Headers,
-		},
-		UpdatedAt:       updatedAt,
-		TenantName:      tenantName,
-		PartitionNumber: partitionNumber,
+		return []models.StationStorageKeyVersion{}, err
 	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if !strings.Contains(pgErr.Detail, "already exists") {
-					return models.DlsMessage{}, errors.New("schemaverse dls already exists")
-				} else {
-					return models.DlsMessage{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.DlsMessage{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.DlsMessage{}, err
-		}
+	if len(versions) == 0 {
+		return []models.StationStorageKeyVersion{}, nil
 	}
-
-	return deadLetterPayload, nil
+	return versions, nil
 }
 
-func GetMsgByStationIdAndMsgSeq(stationId, messageSeq, partitionNumber int) (bool, models.DlsMessage, error) {
+// RotateStationStorageKey retires the current key into the key history and makes wrappedKey the next version
+func RotateStationStorageKey(stationName, wrappedKey, tenantName string) (rotated bool, key models.StationStorageKey, err error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, models.StationStorageKey{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Conn().Begin(ctx)
+	if err != nil {
+		return false, models.StationStorageKey{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
 
-	connection, err := MetadataDbClient.Client.Acquire(ctx)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	query := `INSERT INTO station_storage_key_versions(station_name, tenant_name, version, wrapped_key)
+	SELECT station_name, tenant_name, version, wrapped_key FROM station_storage_keys WHERE station_name = $1 AND tenant_name = $2`
+	stmt, err := tx.Prepare(ctx, "retire_station_storage_key", query)
+	if err != nil {
+		return false, models.StationStorageKey{}, err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, stationName, tenantName)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return false, models.StationStorageKey{}, err
 	}
-	defer connection.Release()
-
-	query := `SELECT * FROM dls_messages WHERE station_id = $1 AND message_seq = $2 AND partition_number = $3 LIMIT 1`
 
-	stmt, err := connection.Conn().Prepare(ctx, "get_dls_messages_by_station_id_and_message_seq", query)
+	query = `UPDATE station_storage_keys SET wrapped_key = $2, version = version + 1, rotated_at = NOW()
+	WHERE station_name = $1 AND tenant_name = $3 RETURNING *`
+	stmt, err = tx.Prepare(ctx, "rotate_station_storage_key", query)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return false, models.StationStorageKey{}, err
 	}
-
-	rows, err := connection.Conn().Query(ctx, stmt.Name, stationId, messageSeq, partitionNumber)
+	rows, err := tx.Query(ctx, stmt.Name, stationName, wrappedKey, tenantName)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return false, models.StationStorageKey{}, err
 	}
 	defer rows.Close()
-
-	message, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationStorageKey])
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return false, models.StationStorageKey{}, err
 	}
-	if len(message) == 0 {
-		return false, models.DlsMessage{}, nil
+	if len(keys) == 0 {
+		return false, models.StationStorageKey{}, nil
 	}
-
-	return true, message[0], nil
+	return true, keys[0], nil
 }
 
-func StorePoisonMsg(stationId, messageSeq int, cgName string, producerName string, poisonedCgs []string, messageDetails models.MessagePayload, tenantName string, partitionNumber int, validationError string) (int, bool, error) {
+// UpsertStationStorageKeyRewrap records the key version a broker has re-wrapped its replicas of the station with
+func UpsertStationStorageKeyRewrap(stationName, tenantName, brokerName string, version int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	updated := false
-	connection, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-	defer connection.Release()
-
-	tx, err := connection.Conn().Begin(ctx)
+	defer conn.Release()
+	query := `INSERT INTO station_storage_key_rewraps(station_name, tenant_name, broker_name, version) VALUES($1, $2, $3, $4)
+	ON CONFLICT(station_name, tenant_name, broker_name) DO UPDATE SET version = GREATEST(station_storage_key_rewraps.version, EXCLUDED.version), rewrapped_at = NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_station_storage_key_rewrap", query)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-	defer tx.Rollback(ctx)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, tenantName, brokerName, version)
+	if err != nil {
+		return err
+	}
+	return nil
+}
 
-	query := `SELECT EXISTS(SELECT 1 FROM consumers WHERE tenant_name = $1 AND consumers_group = $2 AND type = 'application')`
-	stmt, err := tx.Prepare(ctx, "check_if_consumer_exists", query)
+func GetStationStorageKeyRewraps(stationName, tenantName string) ([]models.StationStorageKeyRewrap, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, updated, err
+		return []models.StationStorageKeyRewrap{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM station_storage_key_rewraps WHERE station_name = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_storage_key_rewraps", query)
+	if err != nil {
+		return []models.StationStorageKeyRewrap{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	checkRows, err := tx.Query(ctx, stmt.Name, tenantName, cgName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationName, tenantName)
 	if err != nil {
-		return 0, updated, err
+		return []models.StationStorageKeyRewrap{}, err
 	}
-	var exists bool
-	for checkRows.Next() {
-		checkRows.Scan(&exists)
+	defer rows.Close()
+	rewraps, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationStorageKeyRewrap])
+	if err != nil {
+		return []models.StationStorageKeyRewrap{}, err
 	}
-	if !exists {
-		return 0, updated, err
+	if len(rewraps) == 0 {
+		return []models.StationStorageKeyRewrap{}, nil
 	}
-	checkRows.Close()
+	return rewraps, nil
+}
 
-	query = `SELECT * FROM dls_messages WHERE station_id = $1 AND message_seq = $2 AND tenant_name =$3 AND partition_number = $4 LIMIT 1 FOR UPDATE`
-	stmt, err = tx.Prepare(ctx, "handle_insert_dls_message", query)
+// DeleteStationStorageKeyVersionsBelow drops the retired keys no broker needs anymore
+func DeleteStationStorageKeyVersionsBelow(stationName, tenantName string, version int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-	rows, err := tx.Query(ctx, stmt.Name, stationId, messageSeq, tenantName, partitionNumber)
+	defer conn.Release()
+	query := `DELETE FROM station_storage_key_versions WHERE station_name = $1 AND tenant_name = $2 AND version < $3`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_station_storage_key_versions_below", query)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-
-	message, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, tenantName, version)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-	defer rows.Close()
-
-	var dlsMsgId int
-	if len(message) == 0 { // then insert
-		query = `INSERT INTO dls_messages( 
-			station_id,
-			message_seq,
-			producer_name,
-			poisoned_cgs,
-			message_details,
-			updated_at,
-			message_type,
-			validation_error,
-			tenant_name,
-			partition_number
-			) 
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id`
+	return nil
+}
 
-		stmt, err := tx.Prepare(ctx, "insert_dls_message", query)
-		if err != nil {
-			return 0, updated, err
-		}
-		updatedAt := time.Now()
-		if tenantName != conf.GlobalAccount {
-			tenantName = strings.ToLower(tenantName)
-		}
-		rows, err := tx.Query(ctx, stmt.Name, stationId, messageSeq, producerName, poisonedCgs, messageDetails, updatedAt, "poison", validationError, tenantName, partitionNumber)
-		if err != nil {
-			return 0, updated, err
-		}
-		defer rows.Close()
+func DeleteStationStorageKey(stationName, tenantName string) (err error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
 
-		for rows.Next() {
-			err := rows.Scan(&dlsMsgId)
-			if err != nil {
-				return 0, updated, err
-			}
-		}
-		if err := rows.Err(); err != nil {
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) {
-				if pgErr.Detail != "" {
-					if strings.Contains(pgErr.Detail, "already exists") {
-						return 0, updated, errors.New("dls_messages row already exists")
-					} else {
-						return 0, updated, errors.New(pgErr.Detail)
-					}
-				} else {
-					return 0, updated, errors.New(pgErr.Message)
-				}
-			} else {
-				return 0, updated, err
-			}
-		}
-	} else { // then update
-		query = `UPDATE dls_messages SET poisoned_cgs = ARRAY_APPEND(poisoned_cgs, $1), updated_at = $4, validation_error = $6 WHERE station_id=$2 AND message_seq=$3 AND not($1 = ANY(poisoned_cgs)) AND tenant_name=$5 RETURNING id`
-		stmt, err := tx.Prepare(ctx, "update_poisoned_cgs", query)
-		if err != nil {
-			return 0, updated, err
-		}
-		updatedAt := time.Now()
-		if tenantName != conf.GlobalAccount {
-			tenantName = strings.ToLower(tenantName)
-		}
-		rows, err = tx.Query(ctx, stmt.Name, poisonedCgs[0], stationId, messageSeq, updatedAt, tenantName, validationError)
+	tx, err := conn.Conn().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err != nil {
-			return 0, updated, err
+			tx.Rollback(ctx)
+			return
 		}
-		defer rows.Close()
+		err = tx.Commit(ctx)
+	}()
 
-		for rows.Next() {
-			err := rows.Scan(&dlsMsgId)
-			if err != nil {
-				return 0, updated, err
-			}
-		}
-		if err := rows.Err(); err != nil {
-			var pgErr *pgconn.PgError
-			if errors.As(err, &pgErr) {
-				return 0, updated, errors.New(pgErr.Message)
-			} else {
-				return 0, updated, err
-			}
-		}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	err = tx.Commit(ctx)
+	err = deleteStationStorageKeyHistory(ctx, tx, stationName, tenantName)
 	if err != nil {
-		return 0, updated, err
+		return err
 	}
-
-	return dlsMsgId, updated, nil
+	query := `DELETE FROM station_storage_keys WHERE station_name = $1 AND tenant_name = $2`
+	stmt, err := tx.Prepare(ctx, "delete_station_storage_key", query)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, stmt.Name, stationName, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func GetTotalPoisonMsgsPerCg(cgName string, stationId int) (int, error) {
+// Jobs Functions
+func InsertJob(jobType string, params []byte, brokerInCharge, createdBy, tenantName string) (models.Job, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return models.Job{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM dls_messages WHERE $1 = ANY(poisoned_cgs) AND station_id = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_total_poison_msgs_per_cg", query)
+	query := `INSERT INTO jobs(type, params, status, broker_in_charge, created_by, tenant_name) VALUES($1, $2, 'running', $3, $4, $5) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_job", query)
 	if err != nil {
-		return 0, err
+		return models.Job{}, err
 	}
-	var count int
-	err = conn.Conn().QueryRow(ctx, stmt.Name, cgName, stationId).Scan(&count)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, jobType, params, brokerInCharge, createdBy, tenantName)
 	if err != nil {
-		return 0, err
+		return models.Job{}, err
 	}
-
-	return count, nil
+	defer rows.Close()
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Job])
+	if err != nil {
+		return models.Job{}, err
+	}
+	if len(jobs) == 0 {
+		return models.Job{}, errors.New("failed creating the job")
+	}
+	return jobs[0], nil
 }
 
-func DeleteOldDlsMessageByRetention(updatedAt time.Time, tenantName string) error {
+func GetJobById(id int) (bool, models.Job, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.Job{}, err
 	}
 	defer conn.Release()
-
-	query := `DELETE FROM dls_messages WHERE tenant_name = $1 AND updated_at < $2`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_old_dls_messages", query)
+	query := `SELECT * FROM jobs WHERE id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_job_by_id", query)
 	if err != nil {
-		return err
+		return false, models.Job{}, err
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName, updatedAt)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
 	if err != nil {
-		return err
+		return false, models.Job{}, err
 	}
-	return nil
+	defer rows.Close()
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Job])
+	if err != nil {
+		return false, models.Job{}, err
+	}
+	if len(jobs) == 0 {
+		return false, models.Job{}, nil
+	}
+	return true, jobs[0], nil
 }
 
-func DropDlsMessages(messageIds []int) error {
+// GetJobs returns the latest jobs of the tenant, an empty job type or status matches all jobs
+func GetJobs(tenantName, jobType, status string, limit int) ([]models.Job, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return errors.New("dropSchemaDlsMsg: " + err.Error())
+		return []models.Job{}, err
 	}
 	defer conn.Release()
+	query := `SELECT * FROM jobs WHERE tenant_name = $1 AND ($2 = '' OR type = $2) AND ($3 = '' OR status = $3) ORDER BY id DESC LIMIT $4`
+	stmt, err := conn.Conn().Prepare(ctx, "get_jobs", query)
+	if err != nil {
+		return []models.Job{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, jobType, status, limit)
+	if err != nil {
+		return []models.Job{}, err
+	}
+	defer rows.Close()
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Job])
+	if err != nil {
+		return []models.Job{}, err
+	}
+	return jobs, nil
+}
 
-	query := `DELETE FROM dls_messages where id=ANY($1)`
-	stmt, err := conn.Conn().Prepare(ctx, "drop_dls_schema_msg", query)
+func UpdateJobProgress(id int, progress float64, result []byte) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, messageIds)
+	defer conn.Release()
+	query := `UPDATE jobs SET progress = $2, result = $3, updated_at = NOW() WHERE id = $1 AND status = 'running'`
+	stmt, err := conn.Conn().Prepare(ctx, "update_job_progress", query)
 	if err != nil {
-		return errors.New("dropSchemaDlsMsg: " + err.Error())
+		return err
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, progress, result)
+	if err != nil {
+		return err
 	}
 	return nil
 }
 
-func PurgeDlsMsgsFromStation(station_id int) error {
+func FinishJob(id int, status string, progress float64, result []byte, errMsg string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return errors.New("PurgeDlsMsgsFromStation: " + err.Error())
+		return err
 	}
 	defer conn.Release()
-
-	query := `DELETE FROM dls_messages where station_id=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "purge_dls_messages", query)
+	query := `UPDATE jobs SET status = $2, progress = $3, result = $4, error = $5, updated_at = NOW(), finished_at = NOW() WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "finish_job", query)
 	if err != nil {
 		return err
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, station_id)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, status, progress, result, errMsg)
 	if err != nil {
-		return errors.New("PurgeDlsMsgsFromStation: " + err.Error())
+		return err
 	}
 	return nil
 }
 
-func PurgeDlsMsgsFromPartition(station_id, partitionNumber int) error {
+// RestartJob moves a failed or cancelled job back to running, false is returned when the job is in any other state.
+// The progress and result are kept so the job can continue from its last reported state.
+func RestartJob(id int, brokerInCharge string) (bool, models.Job, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return errors.New("PurgeDlsMsgsFromPartition: " + err.Error())
+		return false, models.Job{}, err
 	}
 	defer conn.Release()
-
-	query := `DELETE FROM dls_messages where station_id=$1 AND partition_number = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "purge_dls_messages", query)
+	query := `UPDATE jobs SET status = 'running', error = '', attempts = attempts + 1, broker_in_charge = $2, updated_at = NOW(), finished_at = NULL
+	WHERE id = $1 AND status IN ('failed', 'cancelled') RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "restart_job", query)
 	if err != nil {
-		return err
+		return false, models.Job{}, err
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, station_id, partitionNumber)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, brokerInCharge)
+	if err != nil {
+		return false, models.Job{}, err
+	}
+	defer rows.Close()
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Job])
 	if err != nil {
-		return errors.New("PurgeDlsMsgsFromPartition: " + err.Error())
+		return false, models.Job{}, err
 	}
-	return nil
+	if len(jobs) == 0 {
+		return false, models.Job{}, nil
+	}
+	return true, jobs[0], nil
 }
 
-func RemoveCgFromDlsMsg(msgId int, cgName string, tenantName string) error {
+// FailInterruptedJobs marks the jobs which were running on the broker when it went down as failed, so they can be retried
+func FailInterruptedJobs(brokerName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	query := `WITH removed_value AS (UPDATE dls_messages SET poisoned_cgs = ARRAY_REMOVE(poisoned_cgs, $1) WHERE id = $2 AND tenant_name = $3 RETURNING *) 
-	DELETE FROM dls_messages WHERE (SELECT array_length(poisoned_cgs, 1)) <= 1 AND id = $2;`
-
-	stmt, err := conn.Conn().Prepare(ctx, "get_msg_by_id_and_remove msg", query)
+	query := `UPDATE jobs SET status = 'failed', error = 'the job was interrupted by a broker restart', updated_at = NOW(), finished_at = NOW()
+	WHERE broker_in_charge = $1 AND status = 'running'`
+	stmt, err := conn.Conn().Prepare(ctx, "fail_interrupted_jobs", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, cgName, msgId, tenantName)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, brokerName)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func CountDlsMsgsByStationAndPartition(stationId, partitionNumber int) (int, error) {
+// RemoveOldJobs deletes the jobs which finished before the retention period and returns them
+func RemoveOldJobs(retention time.Duration) ([]models.Job, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return []models.Job{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) from dls_messages where station_id=$1`
-	stmtName := "count_dls_msgs_by_station_and_partition"
-	if partitionNumber > -1 {
-		query = `SELECT COUNT(*) from dls_messages where station_id=$1 AND partition_number = $2`
-		stmtName = "count_all_dls_msgs_by_station"
-	}
-
-	stmt, err := conn.Conn().Prepare(ctx, stmtName, query)
+	query := `DELETE FROM jobs WHERE finished_at < $1 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_old_jobs", query)
 	if err != nil {
-		return 0, err
+		return []models.Job{}, err
 	}
-
-	var count uint64
-	if partitionNumber == -1 {
-		err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&count)
-	} else {
-		err = conn.Conn().QueryRow(ctx, stmt.Name, stationId, partitionNumber).Scan(&count)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, time.Now().Add(-retention))
+	if err != nil {
+		return []models.Job{}, err
 	}
+	defer rows.Close()
+	jobs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Job])
 	if err != nil {
-		return 0, err
+		return []models.Job{}, err
 	}
-
-	return int(count), nil
+	return jobs, nil
 }
 
-func GetDlsMessageById(messageId int) (bool, models.DlsMessage, error) {
+// Teams
+
+func InsertTeam(name, description, createdByUsername, tenantName string) (models.Team, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return models.Team{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * from dls_messages where id=$1 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_id", query)
+	query := `INSERT INTO teams (name, description, created_by_username, tenant_name) VALUES($1, $2, $3, $4)
+	RETURNING *, ARRAY(SELECT u.username FROM users AS u WHERE u.team = teams.name AND u.tenant_name = teams.tenant_name ORDER BY u.username)`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_team", query)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return models.Team{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, messageId)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, description, createdByUsername, tenantName)
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		return models.Team{}, err
 	}
 	defer rows.Close()
-	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	teams, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Team])
 	if err != nil {
-		return false, models.DlsMessage{}, err
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.Team{}, fmt.Errorf("team %v already exists", name)
+		}
+		return models.Team{}, err
 	}
-	if len(dlsMsgs) == 0 {
-		return false, models.DlsMessage{}, nil
+	if len(teams) == 0 {
+		return models.Team{}, fmt.Errorf("team %v was not created", name)
 	}
-	return true, dlsMsgs[0], nil
+	return teams[0], nil
 }
 
-func GetTotalDlsMessages(tenantName string) (uint64, error) {
+func GetTeams(tenantName string) ([]models.Team, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return []models.Team{}, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM dls_messages WHERE tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_total_dls_msgs", query)
+	query := `SELECT t.*, ARRAY(SELECT u.username FROM users AS u WHERE u.team = t.name AND u.tenant_name = t.tenant_name ORDER BY u.username)
+	FROM teams AS t WHERE t.tenant_name = $1 ORDER BY t.name`
+	stmt, err := conn.Conn().Prepare(ctx, "get_teams", query)
 	if err != nil {
-		return 0, err
+		return []models.Team{}, err
 	}
-	var count uint64
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&count)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return 0, err
+		return []models.Team{}, err
 	}
-
-	return count, nil
+	defer rows.Close()
+	teams, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Team])
+	if err != nil {
+		return []models.Team{}, err
+	}
+	return teams, nil
 }
 
-func GetStationIdsFromDlsMsgs(tenantName string) (map[int]string, error) {
+func GetTeamByName(name, tenantName string) (bool, models.Team, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	stationIds := map[int]string{}
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return stationIds, err
+		return false, models.Team{}, err
 	}
 	defer conn.Release()
-	query := `SELECT DISTINCT station_id FROM dls_messages WHERE tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_station_ids_in_dls_messages", query)
+	query := `SELECT t.*, ARRAY(SELECT u.username FROM users AS u WHERE u.team = t.name AND u.tenant_name = t.tenant_name ORDER BY u.username)
+	FROM teams AS t WHERE t.name = $1 AND t.tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_team_by_name", query)
 	if err != nil {
-		return stationIds, err
+		return false, models.Team{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName)
 	if err != nil {
-		return stationIds, err
+		return false, models.Team{}, err
 	}
 	defer rows.Close()
+	teams, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Team])
+	if err != nil {
+		return false, models.Team{}, err
+	}
+	if len(teams) == 0 {
+		return false, models.Team{}, nil
+	}
+	return true, teams[0], nil
+}
 
-	for rows.Next() {
-		var stationId int
-		err := rows.Scan(&stationId)
+// DeleteTeam removes a team and releases its members and the stations and schemas it owned
+func DeleteTeam(name, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := []string{
+		`UPDATE users SET team = '' WHERE team = $1 AND tenant_name = $2`,
+		`UPDATE stations SET owner_team = '' WHERE owner_team = $1 AND tenant_name = $2`,
+		`UPDATE schemas SET owner_team = '' WHERE owner_team = $1 AND tenant_name = $2`,
+		`DELETE FROM teams WHERE name = $1 AND tenant_name = $2`,
+	}
+	for _, query := range queries {
+		_, err = tx.Exec(ctx, query, name, tenantName)
 		if err != nil {
-			return stationIds, err
+			return err
 		}
-		stationIds[stationId] = ""
 	}
-	return stationIds, nil
+	return tx.Commit(ctx)
 }
 
-func DeleteDlsMsgsByTenant(tenantName string) error {
+func SetUsersTeam(usernames []string, team, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	removeUserQuery := `DELETE FROM dls_messages WHERE tenant_name = $1`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_dls_msgs_by_tenant", removeUserQuery)
+	query := `UPDATE users SET team = $2 WHERE username = ANY($1) AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "set_users_team", query)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, usernames, team, tenantName)
 	if err != nil {
 		return err
 	}
-
 	return nil
-
 }
 
-func GetMinMaxIdsOfDlsMsgsByUpdatedAt(tenantName string, updatedAt time.Time, stationId int) (int, int, error) {
+// Ownership
+
+func TransferStationOwnership(stationName string, userId int, username, team, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return -1, -1, err
+		return err
 	}
 	defer conn.Release()
-	query := `
-		WITH limited_rows AS (
-		SELECT id
-		FROM dls_messages
-		WHERE tenant_name = $1 AND updated_at <= $2 AND message_type = 'poison' AND station_id = $3
-		)
-		SELECT
-		(SELECT MIN(id) FROM limited_rows) AS min_id,
-		(SELECT MAX(id) FROM limited_rows) AS max_id
-		FROM limited_rows;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_min_max_id_dls_msgs_by_updated_at", query)
+	query := `UPDATE stations SET created_by = $2, created_by_username = $3, owner_team = $4, updated_at = NOW() WHERE name = $1 AND is_deleted = false AND tenant_name = $5`
+	stmt, err := conn.Conn().Prepare(ctx, "transfer_station_ownership", query)
 	if err != nil {
-		return -1, -1, err
+		return err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, updatedAt, stationId)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, userId, username, team, tenantName)
 	if err != nil {
-		return -1, -1, err
-	}
-	defer rows.Close()
-
-	var minId, maxId int
-	if rows.Next() {
-		err := rows.Scan(&minId, &maxId)
-		if err != nil {
-			return -1, -1, err
-		}
+		return err
 	}
-	return minId, maxId, nil
+	return nil
 }
 
-func GetDlsMsgsBatch(tenantName string, min, max, stationId int) (bool, []models.DlsMessage, error) {
+func UpdateStationMetadata(stationName, description, slaTier string, labels map[string]string, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.DlsMessage{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `
-		SELECT *
-		FROM dls_messages
-		WHERE tenant_name = $1 AND message_type = 'poison' AND station_id = $2 AND id > $3 AND id <= $4 ORDER BY id ASC LIMIT 10
-		`
-	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msgs_batch_between_min_max_id", query)
+	query := `UPDATE stations SET description = $2, sla_tier = $3, labels = $4, updated_at = NOW() WHERE name = $1 AND is_deleted = false AND tenant_name = $5`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_metadata", query)
 	if err != nil {
-		return false, []models.DlsMessage{}, err
+		return err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, stationId, min, max)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, description, slaTier, labels, tenantName)
 	if err != nil {
-		return false, []models.DlsMessage{}, err
+		return err
 	}
-	defer rows.Close()
+	return nil
+}
 
-	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+func UpdateStationReadOnly(stationName string, readOnly bool, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.DlsMessage{}, err
+		return err
 	}
-	if len(dlsMsgs) == 0 {
-		return false, []models.DlsMessage{}, nil
+	defer conn.Release()
+	query := `UPDATE stations SET read_only = $2, updated_at = NOW() WHERE name = $1 AND is_deleted = false AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_read_only", query)
+	if err != nil {
+		return err
 	}
-	return true, dlsMsgs, nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationName, readOnly, tenantName)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func GetDlsMsgsByStationId(stationId int) ([]models.DlsMessage, error) {
+func GetReadOnlyStations() ([]models.Station, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return []models.Station{}, err
 	}
 	defer conn.Release()
-	query := `SELECT *
-			FROM dls_messages AS dlsm
-			WHERE
-			dlsm.station_id = $1
-			ORDER BY dlsm.updated_at DESC limit 1000
-			`
-	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_station_id", query)
+	query := `SELECT * FROM stations WHERE read_only = true AND is_deleted = false`
+	stmt, err := conn.Conn().Prepare(ctx, "get_read_only_stations", query)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return []models.Station{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return []models.Station{}, err
 	}
 	defer rows.Close()
-	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return []models.Station{}, err
 	}
-	if len(dlsMsgs) == 0 {
-		return []models.DlsMessage{}, nil
+	if len(stations) == 0 {
+		return []models.Station{}, nil
 	}
-
-	return dlsMsgs, nil
+	return stations, nil
 }
 
-func GetDlsMsgsByStationAndPartition(stationId, partitionNumber int) ([]models.DlsMessage, error) {
+func TransferSchemaOwnership(schemaName, username, team, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT *
-			FROM dls_messages AS dlsm
-			WHERE dlsm.station_id = $1 AND dlsm.partition_number = $2
-			ORDER BY updated_at DESC limit 1000;
-			`
-	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msg_by_station_and_partition", query)
+	query := `UPDATE schemas SET created_by_username = $2, owner_team = $3 WHERE name = $1 AND tenant_name = $4`
+	stmt, err := conn.Conn().Prepare(ctx, "transfer_schema_ownership", query)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, partitionNumber)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, schemaName, username, team, tenantName)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return err
 	}
-	defer rows.Close()
-	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	return nil
+}
+
+// TransferProducerOwnership moves every connection of a producer at the station to the new owner
+func TransferProducerOwnership(stationId int, producerName, username, team string) (int64, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.DlsMessage{}, err
+		return 0, err
 	}
-	if len(dlsMsgs) == 0 {
-		return []models.DlsMessage{}, nil
+	defer conn.Release()
+	query := `UPDATE producers SET owner = $3, owner_team = $4 WHERE station_id = $1 AND name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "transfer_producer_ownership", query)
+	if err != nil {
+		return 0, err
 	}
-
-	return dlsMsgs, nil
+	tag, err := conn.Conn().Exec(ctx, stmt.Name, stationId, producerName, username, team)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
 
-// Tenants functions
-func UpsertTenant(name string, encryptrdInternalWSPass string) (models.Tenant, error) {
+// TransferUserResources moves the stations, schemas and producers owned by a user to another user and its team
+func TransferUserResources(fromUserId int, fromUsername string, toUserId int, toUsername, toTeam, tenantName string) (models.UserResourcesTransfer, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Tenant{}, err
+		return models.UserResourcesTransfer{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO tenants (name, internal_ws_pass) VALUES($1, $2) ON CONFLICT (name) DO NOTHING`
-
-	stmt, err := conn.Conn().Prepare(ctx, "upsert_tenant", query)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	tx, err := conn.Begin(ctx)
 	if err != nil {
-		return models.Tenant{}, err
+		return models.UserResourcesTransfer{}, err
 	}
+	defer tx.Rollback(ctx)
 
-	var tenantId int
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, encryptrdInternalWSPass)
+	var transfer models.UserResourcesTransfer
+	tag, err := tx.Exec(ctx, `UPDATE stations SET created_by = $3, created_by_username = $4, owner_team = $5, updated_at = NOW()
+	WHERE created_by = $1 AND created_by_username = $2 AND is_deleted = false AND tenant_name = $6`, fromUserId, fromUsername, toUserId, toUsername, toTeam, tenantName)
 	if err != nil {
-		return models.Tenant{}, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&tenantId)
-		if err != nil {
-			return models.Tenant{}, err
-		}
+		return models.UserResourcesTransfer{}, err
 	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Tenant{}, errors.New("Tenant " + name + " already exists")
-				} else {
-					return models.Tenant{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Tenant{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Tenant{}, err
-		}
+	transfer.Stations = tag.RowsAffected()
+	tag, err = tx.Exec(ctx, `UPDATE schemas SET created_by_username = $2, owner_team = $3 WHERE created_by_username = $1 AND tenant_name = $4`, fromUsername, toUsername, toTeam, tenantName)
+	if err != nil {
+		return models.UserResourcesTransfer{}, err
 	}
-
-	newTenant := models.Tenant{
-		Name: name,
+	transfer.Schemas = tag.RowsAffected()
+	tag, err = tx.Exec(ctx, `UPDATE producers SET owner = $2, owner_team = $3 WHERE owner = $1 AND tenant_name = $4`, fromUsername, toUsername, toTeam, tenantName)
+	if err != nil {
+		return models.UserResourcesTransfer{}, err
 	}
+	transfer.Producers = tag.RowsAffected()
 
-	//After creation a tenant we update the users table and create fk
-	//(we can't do alter and create fk in tables before global tenant exists in tenants table)
-	queryAlterUsersTable := `
-	ALTER TABLE IF EXISTS users ADD CONSTRAINT fk_tenant_name_users FOREIGN KEY (tenant_name) REFERENCES tenants (name);
-	ALTER TABLE IF EXISTS configurations ADD CONSTRAINT fk_tenant_name_configurations FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS schemas ADD CONSTRAINT fk_tenant_name_schemas FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS tags ADD CONSTRAINT fk_tenant_name_tags FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS consumers ADD CONSTRAINT fk_tenant_name_consumers FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS stations ADD CONSTRAINT fk_tenant_name_stations FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS schema_versions ADD CONSTRAINT fk_tenant_name_schemaverse FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-	ALTER TABLE IF EXISTS producers ADD CONSTRAINT fk_tenant_name_producers FOREIGN KEY(tenant_name) REFERENCES tenants(name);`
-	// we remove alter from dls_msgs table because dls msgs with nats compatability
-	// TODO: add this alter and solve dls messages nats compatability
-	// ALTER TABLE IF EXISTS dls_messages ADD CONSTRAINT fk_tenant_name_dls_msgs FOREIGN KEY(tenant_name) REFERENCES tenants(name);
-
-	_, err = conn.Conn().Exec(ctx, queryAlterUsersTable)
+	err = tx.Commit(ctx)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		errPg := errors.As(err, &pgErr)
-		if errPg && !strings.Contains(pgErr.Message, "already exists") {
-			return models.Tenant{}, err
-		}
+		return models.UserResourcesTransfer{}, err
 	}
-	return newTenant, nil
+	return transfer, nil
 }
 
-func UpsertBatchOfTenants(tenants []models.TenantForUpsert) error {
+// User Invitations Functions
+func UpsertUserInvitation(username, email, invitedBy string, expiresAt time.Time, tenantName string) (models.UserInvitation, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.UserInvitation{}, err
 	}
 	defer conn.Release()
-
-	valueStrings := make([]string, 0, len(tenants))
-	valueArgs := make([]interface{}, 0, len(tenants))
-	for i, tenant := range tenants {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		valueArgs = append(valueArgs, tenant.Name)
-		valueArgs = append(valueArgs, tenant.InternalWSPass)
+	// a new id is drawn on every upsert so links sent with a previous invitation stop working
+	query := `INSERT INTO user_invitations (username, email, invited_by, expires_at, tenant_name) VALUES($1, $2, $3, $4, $5)
+	ON CONFLICT (username, tenant_name) DO UPDATE SET id = DEFAULT, email = EXCLUDED.email, invited_by = EXCLUDED.invited_by, created_at = NOW(), expires_at = EXCLUDED.expires_at
+	RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_user_invitation", query)
+	if err != nil {
+		return models.UserInvitation{}, err
 	}
-	query := fmt.Sprintf("INSERT INTO tenants (name, internal_ws_pass) VALUES %s ON CONFLICT (name) DO NOTHING", strings.Join(valueStrings, ","))
-	stmt, err := conn.Conn().Prepare(ctx, "upsert_tenants", query)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, email, invitedBy, expiresAt, tenantName)
 	if err != nil {
-		return err
+		return models.UserInvitation{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, valueArgs...)
+	defer rows.Close()
+	invitations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserInvitation])
 	if err != nil {
-		return err
+		return models.UserInvitation{}, err
 	}
-
-	return nil
+	if len(invitations) == 0 {
+		return models.UserInvitation{}, fmt.Errorf("invitation of user %v was not created", username)
+	}
+	return invitations[0], nil
 }
 
-func GetGlobalTenant() (bool, models.Tenant, error) {
+func GetUserInvitationByID(id int, tenantName string) (bool, models.UserInvitation, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tenants WHERE name = '$memphis' LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_global_tenant", query)
+	query := `SELECT * FROM user_invitations WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_invitation_by_id", query)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
 	defer rows.Close()
-	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	invitations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserInvitation])
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
-	if len(tenants) == 0 {
-		return false, models.Tenant{}, nil
+	if len(invitations) == 0 {
+		return false, models.UserInvitation{}, nil
 	}
-	return true, tenants[0], nil
+	return true, invitations[0], nil
 }
 
-func GetAllTenants() ([]models.Tenant, error) {
+func GetUserInvitationByUsername(username, tenantName string) (bool, models.UserInvitation, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tenants`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_tenants", query)
+	query := `SELECT * FROM user_invitations WHERE username = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_invitation_by_username", query)
 	if err != nil {
-		return []models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return []models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
 	defer rows.Close()
-	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	invitations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserInvitation])
 	if err != nil {
-		return []models.Tenant{}, err
+		return false, models.UserInvitation{}, err
 	}
-	if len(tenants) == 0 {
-		return []models.Tenant{}, nil
+	if len(invitations) == 0 {
+		return false, models.UserInvitation{}, nil
 	}
-	return tenants, nil
+	return true, invitations[0], nil
 }
 
-func GetAllTenantsWithoutGlobal() ([]models.Tenant, error) {
+func GetUserInvitations(tenantName string) ([]models.UserInvitation, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Tenant{}, err
+		return []models.UserInvitation{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tenants WHERE name != $1 AND name != $2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_tenants_without_global", query)
+	query := `SELECT * FROM user_invitations WHERE tenant_name = $1 ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_user_invitations", query)
 	if err != nil {
-		return []models.Tenant{}, err
+		return []models.UserInvitation{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, conf.MemphisGlobalAccountName, conf.GlobalAccount)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.Tenant{}, err
+		return []models.UserInvitation{}, err
 	}
 	defer rows.Close()
-	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	invitations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserInvitation])
 	if err != nil {
-		return []models.Tenant{}, err
+		return []models.UserInvitation{}, err
 	}
-	if len(tenants) == 0 {
-		return []models.Tenant{}, nil
-	}
-	return tenants, nil
+	return invitations, nil
 }
 
-func IsTenantExists(tenantName string) (bool, error) {
+func DeleteUserInvitation(username, tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, err
+		return err
 	}
 	defer conn.Release()
-
-	query := `SELECT EXISTS(SELECT 1 FROM tenants WHERE name = $1 LIMIT 1)`
-	stmt, err := conn.Conn().Prepare(ctx, "is_tenant_exists", query)
+	query := `DELETE FROM user_invitations WHERE username = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_user_invitation", query)
 	if err != nil {
-		return false, err
+		return err
 	}
-
-	var exists bool
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&exists)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return false, err
+		return err
 	}
+	return nil
+}
 
-	return exists, nil
+// Sessions Functions
+func InsertSession(id, username, ip, userAgent string, expiresAt time.Time, tenantName string) (models.Session, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Session{}, err
+	}
+	defer conn.Release()
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	query := `INSERT INTO sessions (id, username, ip, user_agent, expires_at, tenant_name) VALUES($1, $2, $3, $4, $5, $6) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_session", query)
+	if err != nil {
+		return models.Session{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, username, ip, userAgent, expiresAt, tenantName)
+	if err != nil {
+		return models.Session{}, err
+	}
+	defer rows.Close()
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Session])
+	if err != nil {
+		return models.Session{}, err
+	}
+	if len(sessions) == 0 {
+		return models.Session{}, fmt.Errorf("session of user %v was not created", username)
+	}
+	return sessions[0], nil
 }
 
-func GetTenantById(id int) (bool, models.Tenant, error) {
+func GetSessionByID(id string) (bool, models.Session, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.Session{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tenants AS c WHERE id = $1 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_tenant_by_id", query)
+	query := `SELECT * FROM sessions WHERE id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_session_by_id", query)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.Session{}, err
 	}
 	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.Session{}, err
 	}
 	defer rows.Close()
-	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Session])
 	if err != nil {
-		return false, models.Tenant{}, err
+		return false, models.Session{}, err
 	}
-	if len(tenants) == 0 {
-		return false, models.Tenant{}, nil
+	if len(sessions) == 0 {
+		return false, models.Session{}, nil
 	}
-	return true, tenants[0], nil
+	return true, sessions[0], nil
 }
 
-func GetTenantByName(name string) (bool, models.Tenant, error) {
+func GetActiveSessionsByUsername(username, tenantName string) ([]models.Session, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return []models.Session{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM tenants AS c WHERE name = $1 LIMIT 1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_tenant_by_name", query)
+	query := `SELECT * FROM sessions WHERE username = $1 AND tenant_name = $2 AND expires_at > NOW() ORDER BY last_seen_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_sessions_by_username", query)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return []models.Session{}, err
 	}
-
-	if name != conf.GlobalAccount {
-		name = strings.ToLower(name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, username, tenantName)
 	if err != nil {
-		return false, models.Tenant{}, err
+		return []models.Session{}, err
 	}
 	defer rows.Close()
-	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Tenant])
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Session])
 	if err != nil {
-		return false, models.Tenant{}, err
+		return []models.Session{}, err
 	}
-	if len(tenants) == 0 {
-		return false, models.Tenant{}, nil
-	}
-	return true, tenants[0], nil
+	return sessions, nil
 }
 
-func CreateTenant(name, firebaseOrganizationId, encryptrdInternalWSPass, organizationName string, brandData models.BrandColors) (models.Tenant, error) {
+func UpdateSessionLastSeen(id string, lastSeenAt time.Time) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Tenant{}, err
+		return err
 	}
 	defer conn.Release()
-
-	var colors any
-	colors = brandData.Colors
-	if brandData.Colors == nil || len(brandData.Colors) == 0 {
-		colors = []string{}
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "update_session_last_seen", query)
+	if err != nil {
+		return err
 	}
-
-	query := `INSERT INTO tenants 
-	(id, name, firebase_organization_id, internal_ws_pass, organization_name, dark_icon, light_icon, brand_colors)
-	VALUES(nextval('tenants_seq'),$1, $2, $3, $4, $5, $6, $7)`
-
-	stmt, err := conn.Conn().Prepare(ctx, "create_new_tenant", query)
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, lastSeenAt)
 	if err != nil {
-		return models.Tenant{}, err
+		return err
 	}
+	return nil
+}
 
-	var tenantId int
-	rows, err := conn.Conn().Query(ctx, stmt.Name, name, firebaseOrganizationId, encryptrdInternalWSPass, organizationName, brandData.DarkIcon, brandData.LightIcon, colors)
+func ExtendSession(id string, expiresAt time.Time) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Tenant{}, err
+		return false, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&tenantId)
-		if err != nil {
-			return models.Tenant{}, err
-		}
+	defer conn.Release()
+	query := `UPDATE sessions SET expires_at = $2, last_seen_at = NOW() WHERE id = $1 AND expires_at > NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "extend_session", query)
+	if err != nil {
+		return false, err
 	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
 
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.Tenant{}, errors.New("Tenant " + name + " already exists")
-				} else {
-					return models.Tenant{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.Tenant{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.Tenant{}, err
-		}
-
+func DeleteSession(id, username, tenantName string) (bool, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return false, err
 	}
-
-	newTenant := models.Tenant{
-		Name: name,
+	defer conn.Release()
+	query := `DELETE FROM sessions WHERE id = $1 AND username = $2 AND tenant_name = $3`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_session", query)
+	if err != nil {
+		return false, err
 	}
-
-	return newTenant, nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, username, tenantName)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
 }
 
-func RemoveTenant(tenantName string) error {
+// DeleteUserSessions removes all the sessions of a user except the one given in keepID, which may be empty
+func DeleteUserSessions(username, keepID, tenantName string) (int64, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer conn.Release()
-
-	removeTenants := `DELETE FROM tenants WHERE name = $1`
-
-	stmt, err := conn.Conn().Prepare(ctx, "remove_tenant", removeTenants)
+	query := `DELETE FROM sessions WHERE username = $1 AND tenant_name = $2 AND id != $3`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_user_sessions", query)
 	if err != nil {
-		return err
+		return 0, err
 	}
-
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	res, err := conn.Conn().Exec(ctx, stmt.Name, username, tenantName, keepID)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	return res.RowsAffected(), nil
+}
 
-	return nil
+func DeleteExpiredSessions() (int64, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	query := `DELETE FROM sessions WHERE expires_at < NOW()`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_expired_sessions", query)
+	if err != nil {
+		return 0, err
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
 }
 
-func RemoveTagsResourcesByTenant(tenantName string) error {
+func RemoveSessionsByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	query := `DELETE FROM tags WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_tenant_resources_by_tenant", query)
+	query := `DELETE FROM sessions WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_sessions_by_tenant", query)
 	if err != nil {
 		return err
 	}
-
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
 	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
 		return err
@@ -6860,1602 +13576,1697 @@ func RemoveTagsResourcesByTenant(tenantName string) error {
 	return nil
 }
 
-func SetTenantSequence(sequence int) error {
+// searchQueries hold the typeahead query of every searchable resource type, each one selects the
+// name, a short description, the station of a producer and the total number of matches, names
+// starting with the search term come first
+var searchQueries = map[string]string{
+	models.SearchTypeStation: `SELECT name, description, '', COUNT(*) OVER()
+		FROM stations
+		WHERE tenant_name = $1 AND is_deleted = false AND name ILIKE '%' || $2 || '%'
+		ORDER BY name ILIKE $2 || '%' DESC, name
+		LIMIT $3 OFFSET $4`,
+	models.SearchTypeSchema: `SELECT name, type::text, '', COUNT(*) OVER()
+		FROM schemas
+		WHERE tenant_name = $1 AND name ILIKE '%' || $2 || '%'
+		ORDER BY name ILIKE $2 || '%' DESC, name
+		LIMIT $3 OFFSET $4`,
+	models.SearchTypeTag: `SELECT name, color, '', COUNT(*) OVER()
+		FROM tags
+		WHERE tenant_name = $1 AND name ILIKE '%' || $2 || '%'
+		ORDER BY name ILIKE $2 || '%' DESC, name
+		LIMIT $3 OFFSET $4`,
+	models.SearchTypeProducer: `SELECT p.name, p.type::text, s.name, COUNT(*) OVER()
+		FROM producers AS p
+		INNER JOIN stations AS s ON s.id = p.station_id
+		WHERE p.tenant_name = $1 AND s.is_deleted = false AND p.name ILIKE '%' || $2 || '%'
+		GROUP BY p.name, p.type, s.name
+		ORDER BY p.name ILIKE $2 || '%' DESC, p.name, s.name
+		LIMIT $3 OFFSET $4`,
+	models.SearchTypeUser: `SELECT username, COALESCE(full_name, ''), '', COUNT(*) OVER()
+		FROM users
+		WHERE tenant_name = $1 AND (username ILIKE '%' || $2 || '%' OR full_name ILIKE '%' || $2 || '%')
+		ORDER BY username ILIKE $2 || '%' DESC, username
+		LIMIT $3 OFFSET $4`,
+}
+
+// escapeLikePattern makes the wildcards of a user input match literally inside a LIKE pattern
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func SearchResources(resourceType, search, tenantName string, limit, offset int) ([]models.SearchResult, int, error) {
+	query, ok := searchQueries[resourceType]
+	if !ok {
+		return []models.SearchResult{}, 0, fmt.Errorf("unsupported search type %v", resourceType)
+	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.SearchResult{}, 0, err
 	}
 	defer conn.Release()
-
-	tenant_seq := fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS tenants_seq start %v INCREMENT 1;", sequence)
-
-	_, err = conn.Conn().Exec(ctx, tenant_seq)
+	stmt, err := conn.Conn().Prepare(ctx, "search_"+resourceType, query)
 	if err != nil {
-		return err
+		return []models.SearchResult{}, 0, err
 	}
-	return nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, escapeLikePattern(search), limit, offset)
+	if err != nil {
+		return []models.SearchResult{}, 0, err
+	}
+	defer rows.Close()
+	results := []models.SearchResult{}
+	total := 0
+	for rows.Next() {
+		result := models.SearchResult{Type: resourceType}
+		err = rows.Scan(&result.Name, &result.Description, &result.StationName, &total)
+		if err != nil {
+			return []models.SearchResult{}, 0, err
+		}
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		return []models.SearchResult{}, 0, err
+	}
+	return results, total, nil
 }
 
-func GetAllUsersInDB() (bool, []models.User, error) {
+// Comments Functions
+func InsertComment(entityType string, entityID, versionNumber, parentID int, body string, mentions []string, username, tenantName string) (models.Comment, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, nil, err
+		return models.Comment{}, err
 	}
-
 	defer conn.Release()
-	query := `SELECT * FROM users`
-	stmt, err := conn.Conn().Prepare(ctx, "get_user", query)
+	query := `INSERT INTO comments (entity_type, entity_id, version_number, parent_id, body, mentions, created_by_username, tenant_name)
+	VALUES($1, $2, $3, NULLIF($4, 0), $5, $6, $7, $8) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_comment", query)
 	if err != nil {
-		return false, nil, err
+		return models.Comment{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, entityType, entityID, versionNumber, parentID, body, mentions, username, tenantName)
 	if err != nil {
-		return false, nil, err
+		return models.Comment{}, err
 	}
 	defer rows.Close()
-
-	users, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.User])
+	comments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Comment])
 	if err != nil {
-		return false, nil, err
+		return models.Comment{}, err
 	}
-	if len(users) == 0 {
-		return false, nil, nil
+	if len(comments) == 0 {
+		return models.Comment{}, fmt.Errorf("comment on %v %v was not created", entityType, entityID)
 	}
-	return true, users, nil
+	return comments[0], nil
 }
 
-func GetAllUsersAndPermissions() (bool, []models.UserWithPermissions, error) {
+func GetCommentByID(id int, tenantName string) (bool, models.Comment, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, nil, err
+		return false, models.Comment{}, err
 	}
-
 	defer conn.Release()
-	query := `
-		SELECT
-		u.id,
-		u.username,
-		u.password,
-		u.type AS user_type,
-		u.already_logged_in,
-		u.created_at,
-		u.avatar_id,
-		u.full_name,
-		u.subscription,
-		u.skip_get_started,
-		u.tenant_name,
-		u.pending,
-		u.position,
-		u.team,
-		u.owner,
-		u.description,
-		u.last_login,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
-		) AS allow_read_patterns,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
-		) AS allow_write_patterns
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
-		) AS deny_read_patterns,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
-		) AS deny_write_patterns
-		FROM users WHERE username NOT LIKE '$%';` // filter memphis internal users
-
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions", query)
+	query := `SELECT * FROM comments WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_comment_by_id", query)
 	if err != nil {
-		return false, nil, err
+		return false, models.Comment{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return false, nil, err
+		return false, models.Comment{}, err
 	}
 	defer rows.Close()
-
-	var usersWithPermissions []models.UserWithPermissions
-	for rows.Next() {
-		var userWithPermissions models.UserWithPermissions
-		err := rows.Scan(
-			&userWithPermissions.ID,
-			&userWithPermissions.Username,
-			&userWithPermissions.Password,
-			&userWithPermissions.UserType,
-			&userWithPermissions.AlreadyLoggedIn,
-			&userWithPermissions.CreatedAt,
-			&userWithPermissions.AvatarId,
-			&userWithPermissions.FullName,
-			&userWithPermissions.Subscribtion,
-			&userWithPermissions.SkipGetStarted,
-			&userWithPermissions.TenantName,
-			&userWithPermissions.Pending,
-			&userWithPermissions.Position,
-			&userWithPermissions.Team,
-			&userWithPermissions.Owner,
-			&userWithPermissions.Description,
-			&userWithPermissions.LastLogin,
-			&userWithPermissions.Permissions.AllowReadPermissions,
-			&userWithPermissions.Permissions.AllowWritePermissions,
-			&userWithPermissions.Permissions.DenyReadPermissions,
-			&userWithPermissions.Permissions.DenyWritePermissions,
-		)
-		if err != nil {
-			return false, nil, err
-		}
-		usersWithPermissions = append(usersWithPermissions, userWithPermissions)
+	comments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Comment])
+	if err != nil {
+		return false, models.Comment{}, err
 	}
-
-	if len(usersWithPermissions) == 0 {
-		return false, nil, nil
+	if len(comments) == 0 {
+		return false, models.Comment{}, nil
 	}
-
-	return true, usersWithPermissions, nil
+	return true, comments[0], nil
 }
 
-func GetAllUsersAndPermissionsByTenant(tenantName string) (bool, []models.UserWithPermissions, error) {
+// GetCommentsByEntity returns the comments of a station or a schema ordered by creation, a positive
+// version number returns only the comments of that schema version
+func GetCommentsByEntity(entityType string, entityID, versionNumber int, tenantName string) ([]models.Comment, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, nil, err
+		return []models.Comment{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM comments WHERE entity_type = $1 AND entity_id = $2 AND ($3 = 0 OR version_number = $3) AND tenant_name = $4 ORDER BY created_at, id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_comments_by_entity", query)
+	if err != nil {
+		return []models.Comment{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, entityType, entityID, versionNumber, tenantName)
+	if err != nil {
+		return []models.Comment{}, err
 	}
+	defer rows.Close()
+	comments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Comment])
+	if err != nil {
+		return []models.Comment{}, err
+	}
+	return comments, nil
+}
 
+func UpdateCommentBody(id int, body string, mentions []string, tenantName string) (models.Comment, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.Comment{}, err
+	}
 	defer conn.Release()
-	query := `
-		SELECT
-		u.id,
-		u.username,
-		u.password,
-		u.type AS user_type,
-		u.already_logged_in,
-		u.created_at,
-		u.avatar_id,
-		u.full_name,
-		u.subscription,
-		u.skip_get_started,
-		u.tenant_name,
-		u.pending,
-		u.position,
-		u.team,
-		u.owner,
-		u.description,
-		u.last_login,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'allow'
-		) AS allow_read_permissions,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'allow'
-		) AS allow_write_permissions,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'read' AND p.restriction_type = 'deny'
-		) AS deny_read_permissions,
-		(
-			SELECT ARRAY_AGG(p.pattern)
-			FROM permissions p
-			WHERE p.role_id = ANY(u.roles) AND p.type = 'write' AND p.restriction_type = 'deny'
-		) AS deny_write_permissions
-		FROM users u WHERE username NOT LIKE '$%' AND tenant_name=$1;` // filter memphis internal users
+	query := `UPDATE comments SET body = $2, mentions = $3, updated_at = NOW() WHERE id = $1 AND tenant_name = $4 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_comment_body", query)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, body, mentions, tenantName)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	defer rows.Close()
+	comments, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Comment])
+	if err != nil {
+		return models.Comment{}, err
+	}
+	if len(comments) == 0 {
+		return models.Comment{}, fmt.Errorf("comment %v does not exist", id)
+	}
+	return comments[0], nil
+}
 
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_with_permissions", query)
+// DeleteComment removes a comment, its replies are removed with it
+func DeleteComment(id int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, nil, err
+		return err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+	defer conn.Release()
+	query := `DELETE FROM comments WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_comment", query)
 	if err != nil {
-		return false, nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var usersWithPermissions []models.UserWithPermissions
-	for rows.Next() {
-		var userWithPermissions models.UserWithPermissions
-		err := rows.Scan(
-			&userWithPermissions.ID,
-			&userWithPermissions.Username,
-			&userWithPermissions.Password,
-			&userWithPermissions.UserType,
-			&userWithPermissions.AlreadyLoggedIn,
-			&userWithPermissions.CreatedAt,
-			&userWithPermissions.AvatarId,
-			&userWithPermissions.FullName,
-			&userWithPermissions.Subscribtion,
-			&userWithPermissions.SkipGetStarted,
-			&userWithPermissions.TenantName,
-			&userWithPermissions.Pending,
-			&userWithPermissions.Position,
-			&userWithPermissions.Team,
-			&userWithPermissions.Owner,
-			&userWithPermissions.Description,
-			&userWithPermissions.LastLogin,
-			&userWithPermissions.Permissions.AllowReadPermissions,
-			&userWithPermissions.Permissions.AllowWritePermissions,
-			&userWithPermissions.Permissions.DenyReadPermissions,
-			&userWithPermissions.Permissions.DenyWritePermissions,
-		)
-		if err != nil {
-			return false, nil, err
-		}
-		usersWithPermissions = append(usersWithPermissions, userWithPermissions)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	if len(usersWithPermissions) == 0 {
-		return false, nil, nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
+	if err != nil {
+		return err
 	}
-
-	return true, usersWithPermissions, nil
+	return nil
 }
 
-func DeleteOldProducersAndConsumers(timeInterval time.Time, tenantName string) error {
+func RemoveCommentsByTenant(tenantName string) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	queries := []string{
-		`DELETE FROM producers WHERE is_active = false AND updated_at < $1 AND tenant_name = $2`,
-		`DELETE FROM consumers
-		WHERE is_active = false AND updated_at < $1 AND tenant_name = $2
-		AND id NOT IN (
-			SELECT MIN(id)
-			FROM consumers
-			WHERE is_active = false AND updated_at < $1 AND tenant_name = $2
-			GROUP BY consumers_group
-		)`,
+	query := `DELETE FROM comments WHERE tenant_name = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_comments_by_tenant", query)
+	if err != nil {
+		return err
 	}
-
-	batch := &pgx.Batch{}
-	for _, q := range queries {
-		batch.Queue(q, timeInterval, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-
-	br := conn.SendBatch(ctx, batch)
-
-	_, err = br.Exec()
+	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
 	if err != nil {
 		return err
 	}
-
 	return nil
 }
 
-func RemovePoisonedCg(stationId int, cgName string) error {
+// Connection Stats Functions
+func UpsertConnectionStats(stats []models.ConnectionStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	connection, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-	defer connection.Release()
+	defer conn.Release()
+	query := `INSERT INTO connection_stats (connection_id, broker, username, in_msgs, in_bytes, out_msgs, out_bytes, in_bytes_per_sec, out_bytes_per_sec, errors, last_error, last_error_at, updated_at, tenant_name)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (connection_id, broker, tenant_name) DO UPDATE SET username = EXCLUDED.username, in_msgs = EXCLUDED.in_msgs, in_bytes = EXCLUDED.in_bytes,
+		out_msgs = EXCLUDED.out_msgs, out_bytes = EXCLUDED.out_bytes, in_bytes_per_sec = EXCLUDED.in_bytes_per_sec, out_bytes_per_sec = EXCLUDED.out_bytes_per_sec,
+		errors = EXCLUDED.errors, last_error = EXCLUDED.last_error, last_error_at = EXCLUDED.last_error_at, updated_at = EXCLUDED.updated_at`
+	batch := &pgx.Batch{}
+	for _, st := range stats {
+		tenantName := st.TenantName
+		if tenantName != conf.GlobalAccount {
+			tenantName = strings.ToLower(tenantName)
+		}
+		batch.Queue(query, st.ConnectionId, st.Broker, st.Username, st.InMsgs, st.InBytes, st.OutMsgs, st.OutBytes, st.InBytesPerSec, st.OutBytesPerSec, st.Errors, st.LastError, st.LastErrorAt, st.UpdatedAt, tenantName)
+	}
+	return conn.Conn().SendBatch(ctx, batch).Close()
+}
 
-	tx, err := connection.Conn().Begin(ctx)
+func GetConnectionStatsByBroker(broker string) ([]models.ConnectionStats, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.ConnectionStats{}, err
 	}
-	defer tx.Rollback(ctx)
+	defer conn.Release()
+	query := `SELECT * FROM connection_stats WHERE broker = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_connection_stats_by_broker", query)
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, broker)
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	defer rows.Close()
+	stats, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConnectionStats])
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	return stats, nil
+}
 
-	query := `UPDATE dls_messages SET poisoned_cgs = ARRAY_REMOVE(poisoned_cgs, $1) WHERE station_id=$2 ;`
+// GetConnectionStatsByStation returns the stats rows of every broker for the connections of the active producers and
+// consumers of a station
+func GetConnectionStatsByStation(stationId int, tenantName string) ([]models.ConnectionStats, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT cs.* FROM connection_stats AS cs
+		WHERE cs.tenant_name = $2 AND cs.connection_id IN (
+			SELECT connection_id FROM producers WHERE station_id = $1 AND is_active = true
+			UNION
+			SELECT connection_id FROM consumers WHERE station_id = $1 AND is_active = true
+		)`
+	stmt, err := conn.Conn().Prepare(ctx, "get_connection_stats_by_station", query)
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, tenantName)
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	defer rows.Close()
+	stats, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ConnectionStats])
+	if err != nil {
+		return []models.ConnectionStats{}, err
+	}
+	return stats, nil
+}
 
-	stmt, err := tx.Prepare(ctx, "update_dls_message", query)
+func DeleteConnectionStatsOlderThan(updatedBefore time.Time) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
+	defer conn.Release()
+	_, err = conn.Conn().Exec(ctx, `DELETE FROM connection_stats WHERE updated_at < $1`, updatedBefore)
+	return err
+}
 
-	rows, err := tx.Query(ctx, stmt.Name, cgName, stationId)
+// Consumer Group Rebalances Functions
+func InsertCgRebalance(stationId int, cgName, reason, consumerName, connectionId string, activeMembers int, tenantName string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-	rows.Close()
-
-	query = `DELETE FROM dls_messages WHERE message_type = 'poison' AND poisoned_cgs = '{}' OR poisoned_cgs IS NULL;`
-	stmt, err = tx.Prepare(ctx, "delete_dls_message", query)
+	defer conn.Release()
+	query := `INSERT INTO cg_rebalances (station_id, cg_name, reason, consumer_name, connection_id, active_members, created_at, tenant_name)
+		VALUES($1, $2, $3, $4, $5, $6, NOW(), $7)`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_cg_rebalance", query)
 	if err != nil {
 		return err
 	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationId, cgName, reason, consumerName, connectionId, activeMembers, tenantName)
+	return err
+}
 
-	rows, err = tx.Query(ctx, stmt.Name)
+// InsertCgRebalancesByConnections records a rebalance for every consumer group which has a member on one of the
+// connections, the active members are counted after the members' state has been changed
+func InsertCgRebalancesByConnections(connectionIds []string, reason string) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-	rows.Close()
-
-	err = tx.Commit(ctx)
+	defer conn.Release()
+	query := `
+	INSERT INTO cg_rebalances (station_id, cg_name, reason, consumer_name, connection_id, active_members, created_at, tenant_name)
+	SELECT DISTINCT ON (c.station_id, c.consumers_group, c.name)
+		c.station_id,
+		c.consumers_group,
+		$2,
+		c.name,
+		c.connection_id,
+		(SELECT COUNT(DISTINCT m.name) FROM consumers AS m
+			WHERE m.station_id = c.station_id AND m.consumers_group = c.consumers_group AND m.is_active = true),
+		NOW(),
+		c.tenant_name
+	FROM consumers AS c
+	WHERE c.connection_id = ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_cg_rebalances_by_connections", query)
 	if err != nil {
 		return err
 	}
-
-	return nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, connectionIds, reason)
+	return err
 }
 
-func DeleteConfByTenantName(tenantName string) error {
+func GetCgRebalances(stationId int, cgName string, limit int) ([]models.CgRebalance, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.CgRebalance{}, err
+	}
+	defer conn.Release()
+	query := `SELECT * FROM cg_rebalances WHERE station_id = $1 AND cg_name = $2 ORDER BY created_at DESC, id DESC LIMIT $3`
+	stmt, err := conn.Conn().Prepare(ctx, "get_cg_rebalances", query)
+	if err != nil {
+		return []models.CgRebalance{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, cgName, limit)
+	if err != nil {
+		return []models.CgRebalance{}, err
+	}
+	defer rows.Close()
+	rebalances, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.CgRebalance])
+	if err != nil {
+		return []models.CgRebalance{}, err
+	}
+	if len(rebalances) == 0 {
+		return []models.CgRebalance{}, nil
+	}
+	return rebalances, nil
+}
 
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+func RemoveCgRebalancesByTenantAndCreatedAt(tenantName string, createdBefore time.Time) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	_, err = conn.Conn().Exec(ctx, `DELETE FROM cg_rebalances WHERE tenant_name = $1 AND created_at < $2`, tenantName, createdBefore)
+	return err
+}
 
-	query := `DELETE FROM configurations
-	WHERE tenant_name=$1`
+// GetDlsMsgsBatchAfterId returns the next dls messages of a station in id order, an empty ids list matches all of them
+func GetDlsMsgsBatchAfterId(tenantName string, stationId, afterId int, ids []int, limit int) ([]models.DlsMessage, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.DlsMessage{}, err
+	}
+	defer conn.Release()
+	query := `
+		SELECT *
+		FROM dls_messages
+		WHERE tenant_name = $1 AND station_id = $2 AND id > $3 AND (cardinality($4::INTEGER[]) = 0 OR id = ANY($4))
+		ORDER BY id ASC LIMIT $5`
+	stmt, err := conn.Conn().Prepare(ctx, "get_dls_msgs_batch_after_id", query)
+	if err != nil {
+		return []models.DlsMessage{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	if ids == nil {
+		ids = []int{}
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName, stationId, afterId, ids, limit)
+	if err != nil {
+		return []models.DlsMessage{}, err
+	}
+	defer rows.Close()
+	dlsMsgs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.DlsMessage])
+	if err != nil {
+		return []models.DlsMessage{}, err
+	}
+	return dlsMsgs, nil
+}
 
-	stmt, err := conn.Conn().Prepare(ctx, "remove_conf_by_tenant_name", query)
+func CountDlsMsgsByIds(tenantName string, stationId int, ids []int) (int, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	tenantName = strings.ToLower(tenantName)
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	defer conn.Release()
+	query := `SELECT COUNT(*) FROM dls_messages WHERE tenant_name = $1 AND station_id = $2 AND (cardinality($3::INTEGER[]) = 0 OR id = ANY($3))`
+	stmt, err := conn.Conn().Prepare(ctx, "count_dls_msgs_by_ids", query)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	if ids == nil {
+		ids = []int{}
+	}
+	var count int
+	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName, stationId, ids).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
-func DeleteIntegrationsByTenantName(tenantName string) error {
+func UpsertStationLifecyclePolicy(stationId, deleteAfterIdleDays, archiveAfterInactiveDays int, expiresAt *time.Time, notifyBeforeHours int, createdBy, tenantName string) (models.StationLifecyclePolicy, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return models.StationLifecyclePolicy{}, err
+	}
+	defer conn.Release()
+	// a changed policy starts over, notifications sent for the previous rules don't count anymore
+	query := `INSERT INTO station_lifecycle_policies (station_id, delete_after_idle_days, archive_after_inactive_days, expires_at, notify_before_hours, created_by, tenant_name)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (station_id) DO UPDATE SET delete_after_idle_days = $2, archive_after_inactive_days = $3, expires_at = $4, notify_before_hours = $5,
+	inactive_since = NULL, notified_action = '', notified_due_at = NULL, notified_at = NULL, updated_at = NOW()
+	RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_station_lifecycle_policy", query)
+	if err != nil {
+		return models.StationLifecyclePolicy{}, err
+	}
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, deleteAfterIdleDays, archiveAfterInactiveDays, expiresAt, notifyBeforeHours, createdBy, tenantName)
+	if err != nil {
+		return models.StationLifecyclePolicy{}, err
+	}
+	defer rows.Close()
+	policies, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationLifecyclePolicy])
+	if err != nil {
+		return models.StationLifecyclePolicy{}, err
+	}
+	if len(policies) == 0 {
+		return models.StationLifecyclePolicy{}, errors.New("station lifecycle policy was not saved")
+	}
+	return policies[0], nil
+}
 
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+func GetStationLifecyclePolicy(stationId int) (bool, models.StationLifecyclePolicy, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.StationLifecyclePolicy{}, err
 	}
 	defer conn.Release()
+	query := `SELECT * FROM station_lifecycle_policies WHERE station_id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_lifecycle_policy", query)
+	if err != nil {
+		return false, models.StationLifecyclePolicy{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return false, models.StationLifecyclePolicy{}, err
+	}
+	defer rows.Close()
+	policies, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationLifecyclePolicy])
+	if err != nil {
+		return false, models.StationLifecyclePolicy{}, err
+	}
+	if len(policies) == 0 {
+		return false, models.StationLifecyclePolicy{}, nil
+	}
+	return true, policies[0], nil
+}
 
-	query := `DELETE FROM integrations
-	WHERE tenant_name=$1`
+// GetActiveStationLifecyclePolicies returns the policies of the stations which are not deleted
+func GetActiveStationLifecyclePolicies() ([]models.StationLifecyclePolicy, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.StationLifecyclePolicy{}, err
+	}
+	defer conn.Release()
+	query := `SELECT p.* FROM station_lifecycle_policies AS p
+	INNER JOIN stations AS s ON s.id = p.station_id
+	WHERE s.is_deleted = false`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_station_lifecycle_policies", query)
+	if err != nil {
+		return []models.StationLifecyclePolicy{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.StationLifecyclePolicy{}, err
+	}
+	defer rows.Close()
+	policies, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationLifecyclePolicy])
+	if err != nil {
+		return []models.StationLifecyclePolicy{}, err
+	}
+	if len(policies) == 0 {
+		return []models.StationLifecyclePolicy{}, nil
+	}
+	return policies, nil
+}
 
-	stmt, err := conn.Conn().Prepare(ctx, "remove_integrations_by_tenant_name", query)
+func UpdateStationLifecyclePolicyState(id int, inactiveSince *time.Time, notifiedAction string, notifiedDueAt, notifiedAt *time.Time) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
-	tenantName = strings.ToLower(tenantName)
-	_, err = conn.Conn().Exec(ctx, stmt.Name, tenantName)
+	defer conn.Release()
+	query := `UPDATE station_lifecycle_policies SET inactive_since = $2, notified_action = $3, notified_due_at = $4, notified_at = $5 WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "update_station_lifecycle_policy_state", query)
 	if err != nil {
 		return err
 	}
-	return nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id, inactiveSince, notifiedAction, notifiedDueAt, notifiedAt)
+	return err
 }
 
-// Async tasks functions
-func UpsertAsyncTask(task, brokerInCharge string, createdAt time.Time, tenantName string, stationId int, username string) (models.AsyncTask, error) {
+func DeleteStationLifecyclePolicy(stationId int) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.AsyncTask{}, err
+		return false, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO async_tasks (name, broker_in_charge, created_at, updated_at, tenant_name, station_id, created_by, status) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
-	stmt, err := conn.Conn().Prepare(ctx, "upsert_async_task", query)
+	query := `DELETE FROM station_lifecycle_policies WHERE station_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_station_lifecycle_policy", query)
 	if err != nil {
-		return models.AsyncTask{}, err
+		return false, err
 	}
-
-	updatedAt := createdAt
-	var asyncTask models.AsyncTask
-	err = conn.Conn().QueryRow(ctx, stmt.Name, task, brokerInCharge, createdAt, updatedAt, tenantName, stationId, username, "running").Scan(
-		&asyncTask.ID,
-		&asyncTask.Name,
-		&asyncTask.BrokrInCharge,
-		&asyncTask.CreatedAt,
-		&asyncTask.UpdatedAt,
-		&asyncTask.Data,
-		&asyncTask.TenantName,
-		&asyncTask.StationId,
-		&asyncTask.CreatedBy,
-		&asyncTask.Status,
-		&asyncTask.FailureReason,
-	)
-
+	tag, err := conn.Conn().Exec(ctx, stmt.Name, stationId)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			ctx, cancelfunc = context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-			defer cancelfunc()
-
-			conn, err = MetadataDbClient.Client.Acquire(ctx)
-			if err != nil {
-				return models.AsyncTask{}, err
-			}
-			defer conn.Release()
-			// The task already exists, retrieve the existing task
-			queryExistingTask := `
-				SELECT *
-				FROM async_tasks
-				WHERE name = $1 AND tenant_name = $2 AND station_id = $3;
-			`
-			existingStmt, err := conn.Conn().Prepare(ctx, "select_existing_async_task", queryExistingTask)
-			if err != nil {
-				return models.AsyncTask{}, err
-			}
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
 
-			err = conn.Conn().QueryRow(ctx, existingStmt.Name, task, tenantName, stationId).Scan(
-				&asyncTask.ID,
-				&asyncTask.Name,
-				&asyncTask.BrokrInCharge,
-				&asyncTask.CreatedAt,
-				&asyncTask.UpdatedAt,
-				&asyncTask.Data,
-				&asyncTask.TenantName,
-				&asyncTask.StationId,
-				&asyncTask.CreatedBy,
-			)
-			if err != nil {
-				return models.AsyncTask{}, err
-			}
-		} else {
-			return models.AsyncTask{}, err
+// UpsertStationIndexConfig replaces the extractors of a station, the existing index is dropped
+// since its values were extracted by the previous extractors and the station is indexed again from its start
+func UpsertStationIndexConfig(stationId int, extractors []models.StationIndexExtractor, updatedBy, tenantName string) (models.StationIndexConfig, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	var config models.StationIndexConfig
+	err := WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DELETE FROM station_message_index WHERE station_id = $1`, stationId)
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `INSERT INTO station_index_configs (station_id, extractors, updated_by, tenant_name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (station_id) DO UPDATE SET extractors = $2, scanned_seqs = '{}', updated_by = $3, updated_at = NOW()
+		RETURNING *`, stationId, extractors, updatedBy, tenantName)
+		if err != nil {
+			return err
 		}
+		defer rows.Close()
+		config, err = pgx.CollectOneRow(rows, pgx.RowToStructByPos[models.StationIndexConfig])
+		return err
+	})
+	if err != nil {
+		return models.StationIndexConfig{}, err
 	}
-
-	return asyncTask, nil
+	return config, nil
 }
 
-func GetAsyncTasksByName(task string) (bool, []models.AsyncTask, error) {
+func GetStationIndexConfig(stationId int) (bool, models.StationIndexConfig, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return false, models.StationIndexConfig{}, err
 	}
 	defer conn.Release()
-
-	query := `SELECT * FROM async_tasks WHERE name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_async_tasks_by_name", query)
+	query := `SELECT * FROM station_index_configs WHERE station_id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_index_config", query)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return false, models.StationIndexConfig{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, task)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return false, models.StationIndexConfig{}, err
 	}
 	defer rows.Close()
-	asyncTask, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AsyncTask])
+	configs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationIndexConfig])
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return false, models.StationIndexConfig{}, err
 	}
-	if len(asyncTask) == 0 {
-		return false, []models.AsyncTask{}, nil
+	if len(configs) == 0 {
+		return false, models.StationIndexConfig{}, nil
 	}
-	return true, asyncTask, nil
+	return true, configs[0], nil
 }
 
-func GetAsyncTaskByNameAndBrokerName(task, brokerName string) (bool, []models.AsyncTask, error) {
+// GetActiveStationIndexConfigs returns the index configurations of the stations which are not deleted
+func GetActiveStationIndexConfigs() ([]models.StationIndexConfig, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return []models.StationIndexConfig{}, err
 	}
 	defer conn.Release()
-
-	query := `SELECT * FROM async_tasks WHERE name = $1 AND broker_in_charge = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_async_task_by_name_and_broker_name", query)
+	query := `SELECT c.* FROM station_index_configs AS c
+	INNER JOIN stations AS s ON s.id = c.station_id
+	WHERE s.is_deleted = false`
+	stmt, err := conn.Conn().Prepare(ctx, "get_active_station_index_configs", query)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return []models.StationIndexConfig{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, task, brokerName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return []models.StationIndexConfig{}, err
 	}
 	defer rows.Close()
-	asyncTask, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.AsyncTask])
+	configs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationIndexConfig])
 	if err != nil {
-		return false, []models.AsyncTask{}, err
+		return []models.StationIndexConfig{}, err
 	}
-	if len(asyncTask) == 0 {
-		return false, []models.AsyncTask{}, nil
+	if len(configs) == 0 {
+		return []models.StationIndexConfig{}, nil
 	}
-	return true, asyncTask, nil
+	return configs, nil
 }
 
-func GetActiveAndUpdatedAsyncTasks(tenantName string) ([]models.AsyncTaskRes, error) {
+func DeleteStationIndexConfig(stationId int) (bool, error) {
+	var removed bool
+	err := WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM station_index_configs WHERE station_id = $1`, stationId)
+		if err != nil {
+			return err
+		}
+		removed = tag.RowsAffected() > 0
+		_, err = tx.Exec(ctx, `DELETE FROM station_message_index WHERE station_id = $1`, stationId)
+		return err
+	})
+	return removed, err
+}
+
+// InsertStationMessageIndexEntries stores the entries of a scanned batch together with the scan position,
+// so a batch is never indexed twice
+func InsertStationMessageIndexEntries(configId, stationId int, entries []models.StationMessageIndexEntry, scannedSeqs map[string]uint64, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	extractors := make([]string, 0, len(entries))
+	values := make([]string, 0, len(entries))
+	partitions := make([]int, 0, len(entries))
+	seqs := make([]int64, 0, len(entries))
+	producedAt := make([]time.Time, 0, len(entries))
+	for _, entry := range entries {
+		extractors = append(extractors, entry.Extractor)
+		values = append(values, entry.Value)
+		partitions = append(partitions, entry.PartitionNumber)
+		seqs = append(seqs, entry.MessageSeq)
+		producedAt = append(producedAt, entry.ProducedAt)
+	}
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		if len(entries) > 0 {
+			_, err := tx.Exec(ctx, `INSERT INTO station_message_index (station_id, extractor, value, partition_number, message_seq, produced_at, tenant_name)
+			SELECT $1, e.extractor, e.value, e.partition_number, e.message_seq, e.produced_at, $7
+			FROM UNNEST($2::VARCHAR[], $3::VARCHAR[], $4::INTEGER[], $5::BIGINT[], $6::TIMESTAMPTZ[]) AS e(extractor, value, partition_number, message_seq, produced_at)`,
+				stationId, extractors, values, partitions, seqs, producedAt, tenantName)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, `UPDATE station_index_configs SET scanned_seqs = $2 WHERE id = $1`, configId, scannedSeqs)
+		return err
+	})
+}
+
+// DeleteStationMessageIndexBelowSeq drops the entries of messages which left the partition stream
+func DeleteStationMessageIndexBelowSeq(stationId, partitionNumber int, seq uint64) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.AsyncTaskRes{}, err
+		return err
 	}
 	defer conn.Release()
-	query := `SELECT
-	a.id,
-	a.name,
-	a.created_at,
-	a.created_by,
-	s.name AS station_name,
-	a.meta_data,
-	a.status,
-	a.failure_reason
-	FROM
-	async_tasks AS a
-	LEFT JOIN
-	stations AS s ON a.station_id = s.id
-	WHERE
-	a.tenant_name = $1
-	AND (a.status = 'running' OR a.id IN (
-		SELECT
-			id
-		FROM
-			async_tasks
-		WHERE
-			tenant_name = $1
-			AND status <> 'running'
-		ORDER BY
-			created_at DESC
-		LIMIT 10
-	))
-	ORDER BY
-	CASE
-		WHEN a.status = 'running' THEN 0
-		ELSE 1
-	END,
-	created_at DESC;`
-	stmt, err := conn.Conn().Prepare(ctx, "get_active_and_updated_async_tasks", query)
+	query := `DELETE FROM station_message_index WHERE station_id = $1 AND partition_number = $2 AND message_seq < $3`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_station_message_index_below_seq", query)
 	if err != nil {
-		return []models.AsyncTaskRes{}, err
+		return err
 	}
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationId, partitionNumber, int64(seq))
+	return err
+}
 
-	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
+func SearchStationMessageIndex(stationId int, extractor, value string, limit int) ([]models.StationMessageIndexEntry, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.AsyncTaskRes{}, err
+		return []models.StationMessageIndexEntry{}, err
 	}
-	defer rows.Close()
-
-	var asyncTasks []models.AsyncTaskRes
-	for rows.Next() {
-		var task models.AsyncTaskRes
-		var sName pgtype.Varchar
-
-		err := rows.Scan(
-			&task.ID,
-			&task.Name,
-			&task.CreatedAt,
-			&task.CreatedBy,
-			&sName,
-			&task.Data,
-			&task.Status,
-			&task.FailureReason,
-		)
-		if err != nil {
-			return []models.AsyncTaskRes{}, err
-		}
-
-		if sName.Status == pgtype.Present {
-			task.StationName = sName.String
-		} else {
-			task.StationName = "" // Handle NULL value
-		}
-
-		asyncTasks = append(asyncTasks, task)
+	defer conn.Release()
+	query := `SELECT extractor, value, partition_number, message_seq, produced_at FROM station_message_index
+	WHERE station_id = $1 AND extractor = $2 AND value = $3
+	ORDER BY produced_at DESC, id DESC LIMIT $4`
+	stmt, err := conn.Conn().Prepare(ctx, "search_station_message_index", query)
+	if err != nil {
+		return []models.StationMessageIndexEntry{}, err
 	}
-
-	if err := rows.Err(); err != nil {
-		return []models.AsyncTaskRes{}, err
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, extractor, value, limit)
+	if err != nil {
+		return []models.StationMessageIndexEntry{}, err
 	}
-	if len(asyncTasks) == 0 {
-		return []models.AsyncTaskRes{}, nil
+	defer rows.Close()
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationMessageIndexEntry])
+	if err != nil {
+		return []models.StationMessageIndexEntry{}, err
 	}
-	return asyncTasks, nil
+	if len(entries) == 0 {
+		return []models.StationMessageIndexEntry{}, nil
+	}
+	return entries, nil
 }
 
-func UpdateAsyncTask(task, tenantName string, updatedAt time.Time, metaData interface{}, stationId int) error {
+// Schema Registry Syncs Functions
+func InsertSchemaRegistrySync(name, registryType, url, username, password, subjectPrefix, conflictPolicy string, pushBack bool, intervalSec int, createdBy, tenantName string) (models.SchemaRegistrySync, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.SchemaRegistrySync{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE async_tasks SET updated_at = $1 ,meta_data = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5`
-	stmt, err := conn.Conn().Prepare(ctx, "edit_async_task_by_task_and_tenant_name_and_station_id", query)
+
+	query := `INSERT INTO schema_registry_syncs(name, type, url, username, password, subject_prefix, conflict_policy, push_back, interval_sec, created_by, created_at, tenant_name)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_schema_registry_sync", query)
 	if err != nil {
-		return err
+		return models.SchemaRegistrySync{}, err
 	}
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, updatedAt, metaData, task, tenantName, stationId)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, registryType, url, username, password, subjectPrefix, conflictPolicy, pushBack, intervalSec, createdBy, time.Now(), tenantName)
 	if err != nil {
-		return err
+		return models.SchemaRegistrySync{}, err
 	}
-	return nil
+	defer rows.Close()
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaRegistrySync])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.SchemaRegistrySync{}, errors.New("schema registry sync " + name + " already exists")
+		}
+		return models.SchemaRegistrySync{}, err
+	}
+	if len(syncs) == 0 {
+		return models.SchemaRegistrySync{}, errors.New("schema registry sync was not created")
+	}
+	return syncs[0], nil
 }
 
-func UpdateStatusAsyncTask(task, tenantName, status string, stationId int, failureReason, functionName string) error {
+func GetSchemaRegistrySyncById(id int, tenantName string) (bool, models.SchemaRegistrySync, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, models.SchemaRegistrySync{}, err
 	}
 	defer conn.Release()
-	var query string
-	var preparedStmt string
-	if stationId == -1 {
-		query = `UPDATE async_tasks SET status = $1, failure_reason = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5 AND meta_data->>'name' = $6`
-		preparedStmt = "edit_status_functions_async_task_by_task_and_tenant_name_and_station_id"
-	} else {
-		query = `UPDATE async_tasks SET status = $1, failure_reason = $2 WHERE name = $3 AND tenant_name=$4 AND station_id = $5`
-		preparedStmt = "edit_status_resend_async_task_by_task_and_tenant_name_and_station_id"
-
+	query := `SELECT * FROM schema_registry_syncs WHERE id = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_registry_sync_by_id", query)
+	if err != nil {
+		return false, models.SchemaRegistrySync{}, err
 	}
-	stmt, err := conn.Conn().Prepare(ctx, preparedStmt, query)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return err
+		return false, models.SchemaRegistrySync{}, err
 	}
-	tenantName = strings.ToLower(tenantName)
-	if stationId == -1 {
-		_, err = conn.Conn().Query(ctx, stmt.Name, status, failureReason, task, tenantName, stationId, functionName)
-		if err != nil {
-			return err
-		}
-	} else {
-		_, err = conn.Conn().Query(ctx, stmt.Name, status, failureReason, task, tenantName, stationId)
-		if err != nil {
-			return err
-		}
+	defer rows.Close()
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaRegistrySync])
+	if err != nil {
+		return false, models.SchemaRegistrySync{}, err
 	}
-
-	return nil
+	if len(syncs) == 0 {
+		return false, models.SchemaRegistrySync{}, nil
+	}
+	return true, syncs[0], nil
 }
 
-func RemoveOldAsyncTasks() error {
+func GetSchemaRegistrySyncsByTenant(tenantName string) ([]models.SchemaRegistrySync, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.SchemaRegistrySync{}, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM async_tasks
-	WHERE status != 'running' AND id NOT IN (
-		SELECT id
-		FROM async_tasks
-		WHERE status != 'running'
-		ORDER BY updated_at DESC
-		LIMIT 10
-	);`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_old_async_task", query)
+	query := `SELECT * FROM schema_registry_syncs WHERE tenant_name = $1 ORDER BY id`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_registry_syncs_by_tenant", query)
 	if err != nil {
-		return err
+		return []models.SchemaRegistrySync{}, err
 	}
-	_, err = conn.Conn().Exec(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return []models.SchemaRegistrySync{}, err
 	}
-	return nil
+	defer rows.Close()
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaRegistrySync])
+	if err != nil {
+		return []models.SchemaRegistrySync{}, err
+	}
+	return syncs, nil
 }
 
-func RemoveAllAsyncTasks(duration time.Duration) ([]int, error) {
-	sub := time.Now().Add(-duration)
+func GetEnabledSchemaRegistrySyncs() ([]models.SchemaRegistrySync, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []int{}, err
+		return []models.SchemaRegistrySync{}, err
 	}
 	defer conn.Release()
-	query := `WITH deleted_rows AS (
-		DELETE FROM async_tasks
-		WHERE updated_at <= $1
-		RETURNING station_id
-	  )
-	  SELECT ARRAY_AGG(station_id) AS deleted_station_ids
-	  FROM deleted_rows;`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_all_async_tasks", query)
+	query := `SELECT * FROM schema_registry_syncs WHERE enabled = true`
+	stmt, err := conn.Conn().Prepare(ctx, "get_enabled_schema_registry_syncs", query)
 	if err != nil {
-		return []int{}, err
+		return []models.SchemaRegistrySync{}, err
 	}
-
-	var stationIds []int
-	rows, err := conn.Conn().Query(ctx, stmt.Name, sub)
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
 	if err != nil {
-		return []int{}, err
+		return []models.SchemaRegistrySync{}, err
 	}
 	defer rows.Close()
-
-	for rows.Next() {
-		err := rows.Scan(&stationIds)
-		if err != nil {
-			return []int{}, err
-		}
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaRegistrySync])
+	if err != nil {
+		return []models.SchemaRegistrySync{}, err
 	}
-	return stationIds, nil
+	return syncs, nil
 }
 
-func CreateUserIfNotExist(username string, userType string, hashedPassword string, fullName string, subscription bool, avatarId int, tenantName string, pending bool, team, position, owner, description string) (models.User, error) {
+func UpdateSchemaRegistrySync(id int, enabled bool, intervalSec int, conflictPolicy string, pushBack bool, tenantName string) (models.SchemaRegistrySync, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.User{}, err
+		return models.SchemaRegistrySync{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO users ( 
-		username,
-		password,
-		type,
-		already_logged_in,
-		created_at,
-		avatar_id,
-		full_name, 
-		subscription,
-		skip_get_started,
-		tenant_name,
-		pending,
-		team, 
-		position,
-		owner,
-		description) 
-    VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) 
-	ON CONFLICT (username, tenant_name) DO NOTHING
-	RETURNING id`
-
-	stmt, err := conn.Conn().Prepare(ctx, "create_new_user_if_not_exist", query)
+	query := `UPDATE schema_registry_syncs SET enabled = $2, interval_sec = $3, conflict_policy = $4, push_back = $5 WHERE id = $1 AND tenant_name = $6 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_schema_registry_sync", query)
 	if err != nil {
-		return models.User{}, err
+		return models.SchemaRegistrySync{}, err
 	}
-	createdAt := time.Now()
-	skipGetStarted := false
-	alreadyLoggedIn := false
-
-	var userId int
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, username, hashedPassword, userType, alreadyLoggedIn, createdAt, avatarId, fullName, subscription, skipGetStarted, tenantName, pending, team, position, owner, description)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, enabled, intervalSec, conflictPolicy, pushBack, tenantName)
 	if err != nil {
-		return models.User{}, err
+		return models.SchemaRegistrySync{}, err
 	}
 	defer rows.Close()
-	for rows.Next() {
-		err := rows.Scan(&userId)
-		if err != nil {
-			return models.User{}, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Detail != "" {
-				if strings.Contains(pgErr.Detail, "already exists") {
-					return models.User{}, errors.New("User " + username + " already exists")
-				} else {
-					return models.User{}, errors.New(pgErr.Detail)
-				}
-			} else {
-				return models.User{}, errors.New(pgErr.Message)
-			}
-		} else {
-			return models.User{}, err
-		}
-	}
-	if tenantName != conf.GlobalAccount {
-		tenantName = strings.ToLower(tenantName)
+	syncs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SchemaRegistrySync])
+	if err != nil {
+		return models.SchemaRegistrySync{}, err
 	}
-	newUser := models.User{
-		ID:              userId,
-		Username:        username,
-		Password:        hashedPassword,
-		FullName:        fullName,
-		Subscribtion:    subscription,
-		UserType:        userType,
-		CreatedAt:       createdAt,
-		AlreadyLoggedIn: alreadyLoggedIn,
-		AvatarId:        avatarId,
-		TenantName:      tenantName,
-		Pending:         pending,
-		Team:            team,
-		Position:        position,
-		Owner:           owner,
-		Description:     description,
+	if len(syncs) == 0 {
+		return models.SchemaRegistrySync{}, errors.New("schema registry sync was not updated")
 	}
-	return newUser, nil
+	return syncs[0], nil
 }
 
-func CountProudcersForStation(stationId int) (int64, error) {
+func DeleteSchemaRegistrySync(id int, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*) FROM producers WHERE station_id=$1 AND type = 'application'`
-	stmt, err := conn.Conn().Prepare(ctx, "get_count_producers_for_station", query)
+	query := `DELETE FROM schema_registry_syncs WHERE id = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_schema_registry_sync", query)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-	var count int64
-	err = conn.Conn().QueryRow(ctx, stmt.Name, stationId).Scan(&count)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, id, tenantName)
 	if err != nil {
-		return 0, err
+		return false, err
 	}
-
-	return count, nil
+	return res.RowsAffected() > 0, nil
 }
 
-// Shared Locks Functions
-func GetAndLockSharedLock(name string, tenantName string) (bool, bool, models.SharedLock, error) {
+// Station Contract Reports Functions
+func UpsertStationContractReportSchedule(stationId, intervalHours int, recipients []string, createdBy, tenantName string) (models.StationContractReportSchedule, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, false, models.SharedLock{}, err
+		return models.StationContractReportSchedule{}, err
 	}
 	defer conn.Release()
-	tx, err := conn.Begin(ctx)
+	query := `INSERT INTO station_contract_report_schedules (station_id, interval_hours, recipients, created_by, tenant_name)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (station_id) DO UPDATE SET interval_hours = $2, recipients = $3
+	RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_station_contract_report_schedule", query)
 	if err != nil {
-		return false, false, models.SharedLock{}, err
+		return models.StationContractReportSchedule{}, err
 	}
-	defer tx.Rollback(ctx)
-
 	if tenantName != conf.GlobalAccount {
 		tenantName = strings.ToLower(tenantName)
 	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, intervalHours, recipients, createdBy, tenantName)
+	if err != nil {
+		return models.StationContractReportSchedule{}, err
+	}
+	defer rows.Close()
+	schedules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationContractReportSchedule])
+	if err != nil {
+		return models.StationContractReportSchedule{}, err
+	}
+	if len(schedules) == 0 {
+		return models.StationContractReportSchedule{}, errors.New("station contract report schedule was not saved")
+	}
+	return schedules[0], nil
+}
 
-	selectQuery := `SELECT * FROM shared_locks WHERE name = $1 AND tenant_name = $2 FOR UPDATE LIMIT 1`
-	stmt, err := tx.Prepare(ctx, "get_and_lock_shared_lock", selectQuery)
+func GetStationContractReportSchedule(stationId int) (bool, models.StationContractReportSchedule, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, false, models.SharedLock{}, err
+		return false, models.StationContractReportSchedule{}, err
 	}
-	rows, err := tx.Query(ctx, stmt.Name, name, tenantName)
+	defer conn.Release()
+	query := `SELECT * FROM station_contract_report_schedules WHERE station_id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_contract_report_schedule", query)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return false, false, models.SharedLock{}, nil
-		} else {
-			return false, false, models.SharedLock{}, err
-		}
+		return false, models.StationContractReportSchedule{}, err
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return false, models.StationContractReportSchedule{}, err
 	}
 	defer rows.Close()
-	sharedLocks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.SharedLock])
-	if err != nil && err != pgx.ErrNoRows {
-		return false, false, models.SharedLock{}, err
+	schedules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationContractReportSchedule])
+	if err != nil {
+		return false, models.StationContractReportSchedule{}, err
 	}
-	var sharedLock models.SharedLock
-	lockTime := time.Now()
-	newLock := false
-	if len(sharedLocks) == 0 || err == pgx.ErrNoRows {
-		insterQuery := `INSERT INTO shared_locks(name, tenant_name, lock_held, locked_at) VALUES($1, $2, $3, $4) RETURNING *`
-
-		stmt, err := conn.Conn().Prepare(ctx, "insert_new_shared_lock_and_lock", insterQuery)
-		if err != nil {
-			return false, false, models.SharedLock{}, err
-		}
-
-		newSharedLock := models.SharedLock{}
-		if tenantName != conf.GlobalAccount {
-			tenantName = strings.ToLower(tenantName)
-		}
-		rows, err := conn.Conn().Query(ctx, stmt.Name, name, tenantName, true, lockTime)
-		if err != nil {
-			return false, false, models.SharedLock{}, err
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			err := rows.Scan(&newSharedLock.ID, &newSharedLock.Name, &newSharedLock.TenantName, &newSharedLock.LockHeld, &newSharedLock.LockedAt)
-			if err != nil {
-				return false, false, models.SharedLock{}, err
-			}
-		}
-		sharedLock = newSharedLock
-		newLock = true
-	} else {
-		sharedLock = sharedLocks[0]
+	if len(schedules) == 0 {
+		return false, models.StationContractReportSchedule{}, nil
 	}
+	return true, schedules[0], nil
+}
 
-	if !sharedLock.LockHeld {
-		updateQuery := "UPDATE shared_locks SET lock_held = TRUE, locked_at = $1 WHERE id = $2"
-		lockStmt, err := tx.Prepare(ctx, "lock_shared_lock", updateQuery)
-		if err != nil {
-			return false, false, models.SharedLock{}, err
-		}
-		_, err = tx.Exec(ctx, lockStmt.Name, lockTime, sharedLock.ID)
-		if err != nil {
-			return false, false, models.SharedLock{}, err
-		}
-		sharedLock.LockHeld = true
-		sharedLock.LockedAt = lockTime
-	} else if !newLock {
-		return true, false, sharedLock, nil
+// GetDueStationContractReportSchedules returns the schedules of live stations whose interval passed since their last report
+func GetDueStationContractReportSchedules() ([]models.StationContractReportSchedule, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return []models.StationContractReportSchedule{}, err
 	}
-
-	err = tx.Commit(ctx)
+	defer conn.Release()
+	query := `SELECT r.* FROM station_contract_report_schedules AS r
+	INNER JOIN stations AS s ON s.id = r.station_id
+	WHERE s.is_deleted = false AND (r.last_generated_at IS NULL OR r.last_generated_at + r.interval_hours * INTERVAL '1 hour' <= NOW())`
+	stmt, err := conn.Conn().Prepare(ctx, "get_due_station_contract_report_schedules", query)
 	if err != nil {
-		return false, false, models.SharedLock{}, err
+		return []models.StationContractReportSchedule{}, err
 	}
-	return true, true, sharedLock, nil
+	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if err != nil {
+		return []models.StationContractReportSchedule{}, err
+	}
+	defer rows.Close()
+	schedules, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationContractReportSchedule])
+	if err != nil {
+		return []models.StationContractReportSchedule{}, err
+	}
+	return schedules, nil
 }
 
-func SharedLockUnlock(name, tenantName string) error {
+func DeleteStationContractReportSchedule(stationId int) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer conn.Release()
-
-	query := `UPDATE shared_locks SET lock_held = FALSE WHERE name = $1 AND tenant_name=$2`
-	stmt, err := conn.Conn().Prepare(ctx, "unlock_shared_lock", query)
+	query := `DELETE FROM station_contract_report_schedules WHERE station_id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_station_contract_report_schedule", query)
 	if err != nil {
-		return err
+		return false, err
 	}
-	tenantName = strings.ToLower(tenantName)
-	_, err = conn.Conn().Query(ctx, stmt.Name, name, tenantName)
+	res, err := conn.Conn().Exec(ctx, stmt.Name, stationId)
 	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// InsertStationContractReport stores the report, marks the schedule as run and keeps only the newest keep reports of the station
+func InsertStationContractReport(stationId int, report models.StationContractReport, keep int, tenantName string) (models.StationContractReportRecord, error) {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	var record models.StationContractReportRecord
+	err := WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `INSERT INTO station_contract_reports (station_id, health, report, generated_at, tenant_name) VALUES ($1, $2, $3, $4, $5) RETURNING *`,
+			stationId, report.Health, report, report.GeneratedAt, tenantName)
+		if err != nil {
+			return err
+		}
+		records, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationContractReportRecord])
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return errors.New("station contract report was not saved")
+		}
+		record = records[0]
+		_, err = tx.Exec(ctx, `UPDATE station_contract_report_schedules SET last_generated_at = $2 WHERE station_id = $1`, stationId, report.GeneratedAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM station_contract_reports WHERE station_id = $1 AND id NOT IN
+			(SELECT id FROM station_contract_reports WHERE station_id = $1 ORDER BY generated_at DESC LIMIT $2)`, stationId, keep)
 		return err
-	}
-	return nil
+	})
+	return record, err
 }
 
-func DeleteAllSharedLocks(tenantName string) error {
+func GetStationContractReports(stationId, limit int) ([]models.StationContractReportRecord, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.StationContractReportRecord{}, err
 	}
 	defer conn.Release()
-	query := `DELETE FROM shared_locks WHERE tenant_name=$1`
-	stmt, err := conn.Conn().Prepare(ctx, "delete_all_shared_locks", query)
+	query := `SELECT * FROM station_contract_reports WHERE station_id = $1 ORDER BY generated_at DESC LIMIT $2`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_contract_reports", query)
 	if err != nil {
-		return err
+		return []models.StationContractReportRecord{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, limit)
 	if err != nil {
-		return err
+		return []models.StationContractReportRecord{}, err
 	}
-	return nil
+	defer rows.Close()
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.StationContractReportRecord])
+	if err != nil {
+		return []models.StationContractReportRecord{}, err
+	}
+	if len(records) == 0 {
+		return []models.StationContractReportRecord{}, nil
+	}
+	return records, nil
 }
 
-func releaseStuckedSharedLocks(lockedAt time.Time) error {
+// GetSchemaDlsMessagesCountByProducer counts the schema validation failures of a station since the given time per producer
+func GetSchemaDlsMessagesCountByProducer(stationId int, since time.Time) (map[string]int, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer conn.Release()
-	query := `UPDATE shared_locks SET lock_held = false WHERE locked_at < $1 AND lock_held = true`
-	stmt, err := conn.Conn().Prepare(ctx, "set_lock_held_by_name_and_by_locked_at_shared_lock", query)
+	query := `SELECT producer_name, COUNT(*) FROM dls_messages WHERE station_id = $1 AND message_type = 'schema' AND updated_at >= $2 GROUP BY producer_name`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_dls_messages_count_by_producer", query)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, lockedAt)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId, since)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+	counts := make(map[string]int)
+	for rows.Next() {
+		var producerName string
+		var count int
+		err = rows.Scan(&producerName, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[producerName] = count
+	}
+	return counts, rows.Err()
 }
 
-func releaseStuckedStationLocks(lockedAt time.Time) error {
+// Projects Functions
+func InsertProject(name, path string, parentId *int, description string, tags []string, maxStations int, createdBy, tenantName string) (models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return models.Project{}, err
 	}
 	defer conn.Release()
-	query := `UPDATE stations SET functions_lock_held = false WHERE functions_locked_at < $1 AND functions_lock_held = true`
-	stmt, err := conn.Conn().Prepare(ctx, "set_functions_lock_held_at_station", query)
+	query := `INSERT INTO projects (name, path, parent_id, description, tags, max_stations, created_by, tenant_name)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "insert_project", query)
 	if err != nil {
-		return err
+		return models.Project{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, lockedAt)
-	if err != nil {
-		return err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	return nil
-}
-
-func UnlockStuckLocks(lockedAt time.Time) error {
-	err := releaseStuckedStationLocks(lockedAt)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, name, path, parentId, description, tags, maxStations, createdBy, tenantName)
 	if err != nil {
-		return fmt.Errorf("releaseStuckedStationLocks: %v", err)
+		return models.Project{}, err
 	}
-	err = releaseStuckedSharedLocks(lockedAt)
+	defer rows.Close()
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
 	if err != nil {
-		return fmt.Errorf("releaseStuckedSharedLocks: %v", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return models.Project{}, fmt.Errorf("%v already exists", path)
+		}
+		return models.Project{}, err
 	}
-	return nil
+	if len(projects) == 0 {
+		return models.Project{}, fmt.Errorf("%v was not created", path)
+	}
+	return projects[0], nil
 }
 
-func GetMemphisFunctionsByMemphis() ([]models.Function, error) {
+func GetProjectByPath(path, tenantName string) (bool, models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Function{}, err
+		return false, models.Project{}, err
 	}
 	defer conn.Release()
-	query := `SELECT * FROM functions WHERE by_memphis = true`
-	stmt, err := conn.Conn().Prepare(ctx, "get_memphis_functions_by_memphis", query)
+	query := `SELECT * FROM projects WHERE path = $1 AND tenant_name = $2 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_project_by_path", query)
 	if err != nil {
-		return []models.Function{}, err
+		return false, models.Project{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, path, tenantName)
 	if err != nil {
-		return []models.Function{}, err
+		return false, models.Project{}, err
 	}
-
 	defer rows.Close()
-	functions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Function])
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
 	if err != nil {
-		return []models.Function{}, err
+		return false, models.Project{}, err
 	}
-	if len(functions) == 0 {
-		return []models.Function{}, err
+	if len(projects) == 0 {
+		return false, models.Project{}, nil
 	}
-	return functions, nil
+	return true, projects[0], nil
 }
 
-func InsertRole(name, tenantName, roleType string) (models.Role, error) {
+func GetProjectById(id int) (bool, models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Role{}, err
+		return false, models.Project{}, err
 	}
 	defer conn.Release()
-	query := `INSERT INTO roles (name, tenant_name, type) VALUES($1, $2, $3) RETURNING id`
-	stmt, err := conn.Conn().Prepare(ctx, "insert_role", query)
+	query := `SELECT * FROM projects WHERE id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_project_by_id", query)
 	if err != nil {
-		return models.Role{}, err
+		return false, models.Project{}, err
 	}
-	tenantName = strings.ToLower(tenantName)
-	var role models.Role
-	err = conn.Conn().QueryRow(ctx, stmt.Name, name, tenantName, roleType).Scan(&role.ID)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
 	if err != nil {
-		return models.Role{}, err
+		return false, models.Project{}, err
 	}
-	role.Name = name
-	role.TenantName = tenantName
-	role.Type = roleType
-	return role, nil
+	defer rows.Close()
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
+	if err != nil {
+		return false, models.Project{}, err
+	}
+	if len(projects) == 0 {
+		return false, models.Project{}, nil
+	}
+	return true, projects[0], nil
 }
 
-func InsertPermissions(allowReadPermissions, allowWritePermissions, denyReadPermissions, denyWritePermissions []string, roleID int, tenantName string) error {
+func GetProjectsByTenant(tenantName string) ([]models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.Project{}, err
 	}
 	defer conn.Release()
-
-	query := `INSERT INTO permissions (pattern, role_id, type, restriction_type) VALUES`
-	var values []interface{}
-	i := 0
-	for _, permission := range allowReadPermissions {
-		values = append(values, permission, roleID, "read", "allow")
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
-		i++
-	}
-	for _, permission := range allowWritePermissions {
-		values = append(values, permission, roleID, "write", "allow")
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
-		i++
-	}
-	for _, permission := range denyReadPermissions {
-		values = append(values, permission, roleID, "read", "deny")
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
-		i++
+	query := `SELECT * FROM projects WHERE tenant_name = $1 ORDER BY path`
+	stmt, err := conn.Conn().Prepare(ctx, "get_projects_by_tenant", query)
+	if err != nil {
+		return []models.Project{}, err
 	}
-	for _, permission := range denyWritePermissions {
-		values = append(values, permission, roleID, "write", "deny")
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d),", i*4+1, i*4+2, i*4+3, i*4+4)
-		i++
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	query = query[:len(query)-1]
-
-	stmt, err := conn.Conn().Prepare(ctx, "insert_permissions", query)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return []models.Project{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, values...)
+	defer rows.Close()
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
 	if err != nil {
-		return err
+		return []models.Project{}, err
 	}
-
-	return nil
+	if len(projects) == 0 {
+		return []models.Project{}, nil
+	}
+	return projects, nil
 }
 
-func CreateNewRole(name, tenantName, roleType string, allowReadPermissions, allowWritePermissions, denyReadPermissions, denyWritePermissions []string) (models.Role, models.Permissions, error) {
+func UpdateProject(id int, description string, tags []string, maxStations int) (models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Role{}, models.Permissions{}, err
+		return models.Project{}, err
 	}
 	defer conn.Release()
-
-	var role models.Role
-
-	tx, err := conn.Conn().Begin(ctx)
+	query := `UPDATE projects SET description = $2, tags = $3, max_stations = $4 WHERE id = $1 RETURNING *`
+	stmt, err := conn.Conn().Prepare(ctx, "update_project", query)
 	if err != nil {
-		return models.Role{}, models.Permissions{}, err
+		return models.Project{}, err
 	}
-	defer func() {
-		// if err != nil {
-		// 	tx.Rollback(ctx)
-		// } else {
-		// 	tx.Commit(ctx)
-		// }
-		tx.Commit(ctx)
-	}()
-
-	query := `INSERT INTO roles (name, tenant_name, type) VALUES($1, $2, $3) RETURNING id`
-	stmt, err := tx.Prepare(ctx, "create_role", query)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, id, description, tags, maxStations)
 	if err != nil {
-		return models.Role{}, models.Permissions{}, err
+		return models.Project{}, err
 	}
-
-	err = tx.QueryRow(ctx, stmt.Name, name, tenantName, roleType).Scan(&role.ID)
+	defer rows.Close()
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
 	if err != nil {
-		return models.Role{}, models.Permissions{}, err
-	}
-	role.Name = name
-	role.TenantName = tenantName
-	role.Type = roleType
-
-	query = `INSERT INTO permissions (pattern, role_id, type, restriction_type, tenant_name) VALUES `
-	var values []interface{}
-	i := 0
-
-	for _, permission := range allowReadPermissions {
-		values = append(values, permission, role.ID, "read", "allow", tenantName)
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
-		i++
-	}
-	for _, permission := range allowWritePermissions {
-		values = append(values, permission, role.ID, "write", "allow", tenantName)
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
-		i++
-	}
-	for _, permission := range denyReadPermissions {
-		values = append(values, permission, role.ID, "read", "deny", tenantName)
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
-		i++
-	}
-	for _, permission := range denyWritePermissions {
-		values = append(values, permission, role.ID, "write", "deny", tenantName)
-		query += fmt.Sprintf(" ($%d, $%d, $%d, $%d, $%d),", i*5+1, i*5+2, i*5+3, i*5+4, i*5+5)
-		i++
+		return models.Project{}, err
 	}
-	query = query[:len(query)-1]
-	stmt, err = tx.Prepare(ctx, "insert_permissions_create_new_roll", query)
-	if err != nil {
-		return models.Role{}, models.Permissions{}, err
+	if len(projects) == 0 {
+		return models.Project{}, errors.New("project does not exist")
 	}
+	return projects[0], nil
+}
 
-	_, err = tx.Exec(ctx, stmt.Name, values...)
+// DeleteProject removes the project together with its environments, the stations themselves are only unassigned
+func DeleteProject(id int) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return models.Role{}, models.Permissions{}, err
+		return err
 	}
-	permissionsRes := models.Permissions{
-		AllowReadPermissions:  allowReadPermissions,
-		AllowWritePermissions: allowWritePermissions,
-		DenyReadPermissions:   denyReadPermissions,
-		DenyWritePermissions:  denyWritePermissions,
+	defer conn.Release()
+	query := `DELETE FROM projects WHERE id = $1`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_project", query)
+	if err != nil {
+		return err
 	}
-	return role, permissionsRes, nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, id)
+	return err
 }
 
-func GetAllPermissions() ([]models.Permission, error) {
+func GetProjectStationsByTenant(tenantName string) ([]models.ProjectStation, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.ProjectStation{}, err
 	}
 	defer conn.Release()
-
-	query := `SELECT * FROM permissions`
-	stmt, err := conn.Conn().Prepare(ctx, "get_all_permissions", query)
+	query := `SELECT ps.project_id, ps.station_id, s.name FROM project_stations AS ps
+	INNER JOIN stations AS s ON s.id = ps.station_id
+	WHERE ps.tenant_name = $1 AND s.is_deleted = false
+	ORDER BY s.name`
+	stmt, err := conn.Conn().Prepare(ctx, "get_project_stations_by_tenant", query)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.ProjectStation{}, err
 	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.ProjectStation{}, err
 	}
 	defer rows.Close()
-	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
+	projectStations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ProjectStation])
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.ProjectStation{}, err
 	}
-	if len(permissions) == 0 {
-		return []models.Permission{}, nil
+	if len(projectStations) == 0 {
+		return []models.ProjectStation{}, nil
 	}
-	return permissions, nil
+	return projectStations, nil
 }
 
-func GetPermissionsByRoleID(id int) (bool, models.Permissions, error) {
+// GetStationEnvironment returns the environment a station is assigned to
+func GetStationEnvironment(stationId int) (bool, models.Project, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return false, models.Permissions{}, err
+		return false, models.Project{}, err
 	}
 	defer conn.Release()
-
-	query := `SELECT * FROM permissions WHERE role_id = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "get_permissions_by_role_id", query)
-	if err != nil {
-		return false, models.Permissions{}, err
-	}
-	rows, err := conn.Conn().Query(ctx, stmt.Name, id)
-	if err != nil {
-		return false, models.Permissions{}, err
-	}
-	defer rows.Close()
-	allPermissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
+	query := `SELECT p.* FROM projects AS p INNER JOIN project_stations AS ps ON ps.project_id = p.id WHERE ps.station_id = $1 LIMIT 1`
+	stmt, err := conn.Conn().Prepare(ctx, "get_station_environment", query)
 	if err != nil {
-		return false, models.Permissions{}, err
-	}
-	if len(allPermissions) == 0 {
-		return false, models.Permissions{}, nil
+		return false, models.Project{}, err
 	}
-	var permissions models.Permissions
-	for _, p := range allPermissions {
-		if p.Type == "read" {
-			if p.RestrictionType == "allow" {
-				permissions.AllowReadPermissions = append(permissions.AllowReadPermissions, p.Pattern)
-			} else if p.RestrictionType == "deny" {
-				permissions.DenyReadPermissions = append(permissions.DenyReadPermissions, p.Pattern)
-			}
-		} else if p.Type == "write" {
-			if p.RestrictionType == "allow" {
-				permissions.AllowWritePermissions = append(permissions.AllowWritePermissions, p.Pattern)
-			} else if p.RestrictionType == "deny" {
-				permissions.DenyWritePermissions = append(permissions.DenyWritePermissions, p.Pattern)
-			}
-		}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, stationId)
+	if err != nil {
+		return false, models.Project{}, err
 	}
-	return true, permissions, nil
+	defer rows.Close()
+	projects, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Project])
+	if err != nil {
+		return false, models.Project{}, err
+	}
+	if len(projects) == 0 {
+		return false, models.Project{}, nil
+	}
+	return true, projects[0], nil
 }
 
-func UpdateUserRole(tenantName, username string, roleIDs []int) error {
+// AssignStationsToEnvironment moves the stations into the environment, a station belongs to a single environment.
+// It fails with ErrProjectQuotaExceeded when the environment or its project would hold more stations than allowed
+func AssignStationsToEnvironment(environment, project models.Project, stationIds []int) error {
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO project_stations (station_id, project_id, tenant_name) SELECT UNNEST($1::INTEGER[]), $2, $3
+		ON CONFLICT (station_id) DO UPDATE SET project_id = EXCLUDED.project_id`, stationIds, environment.ID, environment.TenantName)
+		if err != nil {
+			return err
+		}
+		var environmentCount, projectCount int
+		err = tx.QueryRow(ctx, `SELECT COUNT(*) FILTER (WHERE ps.project_id = $1), COUNT(*) FROM project_stations AS ps
+		INNER JOIN projects AS e ON e.id = ps.project_id
+		INNER JOIN stations AS s ON s.id = ps.station_id
+		WHERE e.parent_id = $2 AND s.is_deleted = false`, environment.ID, project.ID).Scan(&environmentCount, &projectCount)
+		if err != nil {
+			return err
+		}
+		if (environment.MaxStations > 0 && environmentCount > environment.MaxStations) || (project.MaxStations > 0 && projectCount > project.MaxStations) {
+			return ErrProjectQuotaExceeded
+		}
+		return nil
+	})
+}
+
+func UnassignStations(stationIds []int) error {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	tenantName = strings.ToLower(tenantName)
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Release()
-
-	query := `UPDATE users SET roles = $1 WHERE username = $2 AND tenant_name = $3`
-	stmt, err := conn.Conn().Prepare(ctx, "update_user_role", query)
-	if err != nil {
-		return err
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, roleIDs, username, tenantName)
+	query := `DELETE FROM project_stations WHERE station_id = ANY($1)`
+	stmt, err := conn.Conn().Prepare(ctx, "unassign_project_stations", query)
 	if err != nil {
 		return err
 	}
-	return nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, stationIds)
+	return err
 }
 
-func UpdatePermissions(tenantName, username string, readPermissions, writePermissions []string) error {
+func GetProjectPermissionsByTenant(tenantName string) ([]models.ProjectPermission, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	tenantName = strings.ToLower(tenantName)
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []models.ProjectPermission{}, err
 	}
 	defer conn.Release()
-
-	query := `UPDATE permissions SET read_permissions = $1, write_permissions = $2 WHERE role_id = (SELECT id FROM roles WHERE username = $3 AND tenant_name = $4)`
-	stmt, err := conn.Conn().Prepare(ctx, "update_user_permissions", query)
+	query := `SELECT pp.project_id, r.name, pp.type::text FROM project_permissions AS pp
+	INNER JOIN roles AS r ON r.id = pp.role_id
+	WHERE pp.tenant_name = $1
+	ORDER BY r.name, pp.type`
+	stmt, err := conn.Conn().Prepare(ctx, "get_project_permissions_by_tenant", query)
 	if err != nil {
-		return err
+		return []models.ProjectPermission{}, err
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, readPermissions, writePermissions, username, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, tenantName)
 	if err != nil {
-		return err
+		return []models.ProjectPermission{}, err
 	}
-	return nil
+	defer rows.Close()
+	permissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.ProjectPermission])
+	if err != nil {
+		return []models.ProjectPermission{}, err
+	}
+	if len(permissions) == 0 {
+		return []models.ProjectPermission{}, nil
+	}
+	return permissions, nil
 }
 
-func CheckUserStationPermissions(rolesId []int, stationName, operation string) (bool, error) {
+// UpsertProjectPermission grants the role read or write access to the stations of the project, it returns false when the role does not exist
+func UpsertProjectPermission(projectId int, roleName, permissionType, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return false, err
 	}
 	defer conn.Release()
-	query := `SELECT COUNT(*)
-	FROM permissions
-	WHERE role_id = ANY($1)
-	  AND type = $2
-	  AND restriction_type = 'allow'
-	  AND (
-		(position('*' in pattern) > 0 AND $3 ~ pattern) OR
-		(position('*' in pattern) = 0 AND $3 = pattern)
-	  );`
-	stmt, err := conn.Conn().Prepare(ctx, "check_user_station_permissions", query)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	var roleId int
+	err = conn.Conn().QueryRow(ctx, `SELECT id FROM roles WHERE name = $1 AND tenant_name = $2`, roleName, tenantName).Scan(&roleId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
-	var count int
-	err = conn.Conn().QueryRow(ctx, stmt.Name, rolesId, operation, stationName).Scan(&count)
+	query := `INSERT INTO project_permissions (project_id, role_id, type, tenant_name) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (project_id, role_id, type) DO NOTHING`
+	stmt, err := conn.Conn().Prepare(ctx, "upsert_project_permission", query)
 	if err != nil {
 		return false, err
 	}
-	if count == 0 {
-		return false, nil
+	_, err = conn.Conn().Exec(ctx, stmt.Name, projectId, roleId, permissionType, tenantName)
+	if err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
-func CheckTenantPermissionsUsage(tenantName string) (bool, error) {
+func DeleteProjectPermission(projectId int, roleName, permissionType, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
 		return false, err
 	}
 	defer conn.Release()
-
-	query := `SELECT EXISTS (SELECT 1 FROM roles WHERE tenant_name = $1 );`
-	stmt, err := conn.Conn().Prepare(ctx, "check_tenant_permissions_usage", query)
+	query := `DELETE FROM project_permissions AS pp USING roles AS r
+	WHERE pp.role_id = r.id AND pp.project_id = $1 AND r.name = $2 AND pp.type::text = $3 AND r.tenant_name = $4`
+	stmt, err := conn.Conn().Prepare(ctx, "delete_project_permission", query)
 	if err != nil {
 		return false, err
 	}
-	var exists bool
-	err = conn.Conn().QueryRow(ctx, stmt.Name, tenantName).Scan(&exists)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	res, err := conn.Conn().Exec(ctx, stmt.Name, projectId, roleName, permissionType, tenantName)
 	if err != nil {
 		return false, err
 	}
-	return exists, nil
+	return res.RowsAffected() > 0, nil
 }
 
-func GetUserPermissions(userRoles []int, tenantName string) ([]models.Permission, error) {
+// GetStationsByProjectGrants returns the stations the roles may access through grants on their environments or projects
+func GetStationsByProjectGrants(rolesId []int, permissionType, tenantName string) ([]models.Station, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.Station{}, err
 	}
-
 	defer conn.Release()
-
-	query := `SELECT * FROM permissions WHERE role_id = ANY($1) AND tenant_name = $2`
-	stmt, err := conn.Conn().Prepare(ctx, "get_user_permissions", query)
+	query := `SELECT * FROM stations WHERE tenant_name = $3 AND is_deleted = false AND id IN (
+		SELECT ps.station_id FROM project_stations AS ps
+		INNER JOIN projects AS e ON e.id = ps.project_id
+		INNER JOIN project_permissions AS pp ON pp.project_id = e.id OR pp.project_id = e.parent_id
+		WHERE pp.role_id = ANY($1) AND pp.type::text = $2)`
+	stmt, err := conn.Conn().Prepare(ctx, "get_stations_by_project_grants", query)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.Station{}, err
 	}
-
-	rows, err := conn.Conn().Query(ctx, stmt.Name, userRoles, tenantName)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	rows, err := conn.Conn().Query(ctx, stmt.Name, rolesId, permissionType, tenantName)
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.Station{}, err
 	}
 	defer rows.Close()
-	allPermissions, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Permission])
+	stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
 	if err != nil {
-		return []models.Permission{}, err
+		return []models.Station{}, err
 	}
-	if len(allPermissions) == 0 {
-		return []models.Permission{}, nil
-	} else {
-		return allPermissions, nil
+	if len(stations) == 0 {
+		return []models.Station{}, nil
 	}
-
+	return stations, nil
 }
 
-func GetStationsByPattern(patterns []string, tenantName string) ([]models.Station, error) {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-
-	var stationsList []models.Station
-	var plainNames []string
-	var regexPatterns []string
-	for _, pattern := range patterns {
-		if strings.Contains(pattern, "*") {
-			regexPatterns = append(regexPatterns, pattern)
-		} else {
-			plainNames = append(plainNames, pattern)
-		}
-	}
+// Schema Aliases Functions
 
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
-	if err != nil {
-		return []models.Station{}, err
+// RenameSchema renames the schema and the stations which use it, the old name is kept as an alias
+// so clients which still use it keep working
+func RenameSchema(schemaId int, oldName, newName, tenantName string) error {
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	defer conn.Release()
-
-	if len(regexPatterns) > 0 {
-		query := `SELECT * FROM stations WHERE name ~ ANY($1) AND tenant_name = $2`
-		stmt, err := conn.Conn().Prepare(ctx, "get_stations_by_pattern", query)
-		if err != nil {
-			return []models.Station{}, err
-		}
-
-		rows, err := conn.Conn().Query(ctx, stmt.Name, regexPatterns, tenantName)
-		if err != nil {
-			return []models.Station{}, err
-		}
-		defer rows.Close()
-
-		stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+	return WithTransaction(func(ctx context.Context, tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `UPDATE schemas SET name = $2 WHERE id = $1`, schemaId, newName)
 		if err != nil {
-			return []models.Station{}, err
-		}
-		if len(stations) != 0 {
-			stationsList = append(stationsList, stations...)
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrSchemaExists
+			}
+			return err
 		}
-	}
-
-	if len(plainNames) > 0 {
-		query := `SELECT * FROM stations WHERE name = ANY($1) AND tenant_name = $2`
-		stmt, err := conn.Conn().Prepare(ctx, "get_stations_by_plain_names", query)
-		if err != nil {
-			return []models.Station{}, err
+		if res.RowsAffected() == 0 {
+			return fmt.Errorf("schema %v does not exist", oldName)
 		}
-
-		rows, err := conn.Conn().Query(ctx, stmt.Name, plainNames, tenantName)
+		_, err = tx.Exec(ctx, `UPDATE stations SET schema_name = $2 WHERE schema_name = $1 AND tenant_name = $3`, oldName, newName, tenantName)
 		if err != nil {
-			return []models.Station{}, err
+			return err
 		}
-		defer rows.Close()
-
-		stations, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Station])
+		_, err = tx.Exec(ctx, `DELETE FROM schema_aliases WHERE name = $1 AND tenant_name = $2`, newName, tenantName)
 		if err != nil {
-			return []models.Station{}, err
-		}
-		if len(stations) != 0 {
-			stationsList = append(stationsList, stations...)
+			return err
 		}
-	}
-
-	if len(stationsList) == 0 {
-		return []models.Station{}, nil
-	} else {
-		return stationsList, nil
-	}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_aliases (name, schema_id, tenant_name) VALUES ($1, $2, $3)
+		ON CONFLICT (name, tenant_name) DO UPDATE SET schema_id = EXCLUDED.schema_id, created_at = NOW()`, oldName, schemaId, tenantName)
+		return err
+	})
 }
 
-func RemoveRoleAndPermissions(roleID []int, tenantName string) error {
+func GetSchemaAliases(schemaId int) ([]string, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return []string{}, err
 	}
 	defer conn.Release()
-
-	tx, err := conn.Conn().Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
-		}
-	}()
-
-	query := `DELETE FROM permissions WHERE role_id = ANY($1) AND tenant_name = $2`
-	stmt, err := tx.Prepare(ctx, "remove_permissions_by_role_id", query)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Exec(ctx, stmt.Name, roleID, tenantName)
+	query := `SELECT name FROM schema_aliases WHERE schema_id = $1 ORDER BY created_at DESC`
+	stmt, err := conn.Conn().Prepare(ctx, "get_schema_aliases", query)
 	if err != nil {
-		return err
+		return []string{}, err
 	}
-
-	query = `DELETE FROM roles WHERE id = ANY($1) AND tenant_name = $2`
-	stmt, err = tx.Prepare(ctx, "remove_role_by_id", query)
+	rows, err := conn.Conn().Query(ctx, stmt.Name, schemaId)
 	if err != nil {
-		return err
+		return []string{}, err
 	}
-	_, err = tx.Exec(ctx, stmt.Name, roleID, tenantName)
-	if err != nil {
-		return err
+	defer rows.Close()
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		err = rows.Scan(&alias)
+		if err != nil {
+			return []string{}, err
+		}
+		aliases = append(aliases, alias)
 	}
-
-	return nil
+	return aliases, rows.Err()
 }
 
-func RemovePermissionsByTenant(tenantName string) error {
+func RemoveSchemaAlias(name, tenantName string) (bool, error) {
 	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
 	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	conn, err := acquireMetadataDbConn(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer conn.Release()
-
-	query := `DELETE FROM permissions WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_permissions_by_tenant", query)
-	if err != nil {
-		return err
-	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func RemoveRolesByTenant(tenantName string) error {
-	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
-	defer cancelfunc()
-	tenantName = strings.ToLower(tenantName)
-	conn, err := MetadataDbClient.Client.Acquire(ctx)
+	query := `DELETE FROM schema_aliases WHERE name = $1 AND tenant_name = $2`
+	stmt, err := conn.Conn().Prepare(ctx, "remove_schema_alias", query)
 	if err != nil {
-		return err
+		return false, err
 	}
-	defer conn.Release()
-
-	query := `DELETE FROM roles WHERE tenant_name = $1`
-	stmt, err := conn.Conn().Prepare(ctx, "remove_roles_by_tenant", query)
-	if err != nil {
-		return err
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
 	}
-	_, err = conn.Conn().Query(ctx, stmt.Name, tenantName)
+	res, err := conn.Conn().Exec(ctx, stmt.Name, name, tenantName)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	return nil
+	return res.RowsAffected() > 0, nil
 }
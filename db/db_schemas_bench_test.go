@@ -0,0 +1,122 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+const schemasBenchmarkCount = 200
+
+// setupSchemasBenchmark seeds a fresh tenant with schemas and tags, it runs only when MEMPHIS_BENCH_METADATA_DB is set
+// since it writes to the metadata db configured by the METADATA_DB_* settings, so point those at a disposable instance
+func setupSchemasBenchmark(b *testing.B) string {
+	b.Helper()
+	if os.Getenv("MEMPHIS_BENCH_METADATA_DB") == "" {
+		b.Skip("set MEMPHIS_BENCH_METADATA_DB to benchmark against the configured metadata db")
+	}
+	if MetadataDbClient.Client == nil {
+		_, err := InitalizeMetadataDbConnection()
+		if err != nil {
+			b.Fatalf("InitalizeMetadataDbConnection: %v", err)
+		}
+	}
+	tenantName := fmt.Sprintf("schemas-bench-%d", time.Now().UnixNano())
+	_, err := UpsertTenant(tenantName, "")
+	if err != nil {
+		b.Fatalf("UpsertTenant: %v", err)
+	}
+	for i := 0; i < schemasBenchmarkCount; i++ {
+		tags := []models.CreateTag{{Name: fmt.Sprintf("tag-%d", i%10), Color: "0, 165, 255"}}
+		_, err := InsertNewSchemaWithVersion(fmt.Sprintf("schema-%d", i), "json", 0, "bench", `{"type": "object"}`, "", "", tags, tenantName)
+		if err != nil {
+			b.Fatalf("InsertNewSchemaWithVersion: %v", err)
+		}
+	}
+	return tenantName
+}
+
+// getAllSchemasDetailsPerSchema lists the schemas the way it was done before the usage and tags were aggregated
+// into the list query, one query for the usage and one for the tags of every schema
+func getAllSchemasDetailsPerSchema(tenantName string) ([]models.ExtendedSchema, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), DbOperationTimeout*time.Second)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT s.id, s.name, s.type, sv.created_by, s.created_by_username, sv.created_at, asv.version_number, s.owner_team
+	          FROM schemas AS s
+	          LEFT JOIN schema_versions AS sv ON s.id = sv.schema_id AND sv.version_number = 1
+	          LEFT JOIN schema_versions AS asv ON s.id = asv.schema_id AND asv.active = true
+	          WHERE asv.id IS NOT NULL AND s.tenant_name = $1
+	          ORDER BY sv.created_at DESC`
+	rows, err := conn.Query(ctx, query, tenantName)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	schemas := []models.ExtendedSchema{}
+	for rows.Next() {
+		var sc models.ExtendedSchema
+		err := rows.Scan(&sc.ID, &sc.Name, &sc.Type, &sc.CreatedBy, &sc.CreatedByUsername, &sc.CreatedAt, &sc.ActiveVersionNumber, &sc.OwnerTeam)
+		if err != nil {
+			rows.Close()
+			conn.Release()
+			return nil, err
+		}
+		schemas = append(schemas, sc)
+	}
+	rows.Close()
+	conn.Release()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range schemas {
+		count, err := GetCountStationsUsingSchema(schemas[i].Name, tenantName)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i].Used = count > 0
+		schemas[i].Tags, err = GetTagsByEntityIDLight("schema", schemas[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
+
+func BenchmarkSchemasList(b *testing.B) {
+	tenantName := setupSchemasBenchmark(b)
+
+	b.Run("aggregated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := GetAllSchemasDetails(tenantName); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("per_schema", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := getAllSchemasDetailsPerSchema(tenantName); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BackupMetadata dumps every metadata table from a single snapshot in dependency order.
+// write is called once per table with the COPY text output of the table.
+func BackupMetadata(write func(table models.MetadataTableBackup, data []byte) error) ([]models.MetadataTableBackup, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), metadataMigrationTimeout)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tables, err := getMetadataTablesInDependencyOrder(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]models.MetadataTableBackup, 0, len(tables))
+	for _, table := range tables {
+		columns, err := getTableColumns(ctx, tx, table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", table, err)
+		}
+		var buf bytes.Buffer
+		res, err := tx.Conn().PgConn().CopyTo(ctx, &buf, fmt.Sprintf("COPY %s (%s) TO STDOUT", pgx.Identifier{table}.Sanitize(), quoteColumns(columns)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", table, err)
+		}
+		backup := models.MetadataTableBackup{Name: table, Columns: columns, Rows: res.RowsAffected()}
+		if err = write(backup, buf.Bytes()); err != nil {
+			return nil, fmt.Errorf("%s: %v", table, err)
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+// RestoreMetadata replaces the content of the metadata tables with a backup taken by BackupMetadata.
+// All tables are emptied and refilled in a single transaction, read returns the COPY text output of a table.
+func RestoreMetadata(tables []models.MetadataTableBackup, read func(table string) ([]byte, error)) error {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), metadataMigrationTimeout)
+	defer cancelfunc()
+	conn, err := acquireMetadataDbConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	currentTables, err := getMetadataTablesInDependencyOrder(ctx, tx)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	quotedTables := make([]string, len(currentTables))
+	for i, table := range currentTables {
+		existing[table] = true
+		quotedTables[i] = pgx.Identifier{table}.Sanitize()
+	}
+	for _, table := range tables {
+		if !existing[table.Name] {
+			return fmt.Errorf("%s: table does not exist in this cluster", table.Name)
+		}
+	}
+	if len(quotedTables) > 0 {
+		_, err = tx.Exec(ctx, fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE", strings.Join(quotedTables, ", ")))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range tables {
+		data, err := read(table.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", table.Name, err)
+		}
+		res, err := tx.Conn().PgConn().CopyFrom(ctx, bytes.NewReader(data), fmt.Sprintf("COPY %s (%s) FROM STDIN", pgx.Identifier{table.Name}.Sanitize(), quoteColumns(table.Columns)))
+		if err != nil {
+			return fmt.Errorf("%s: %v", table.Name, err)
+		}
+		if res.RowsAffected() != table.Rows {
+			return fmt.Errorf("%s: restored %v rows out of %v", table.Name, res.RowsAffected(), table.Rows)
+		}
+		err = resetTableSequences(ctx, tx, tx, table.Name)
+		if err != nil {
+			return fmt.Errorf("%s: failed resetting sequences: %v", table.Name, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func quoteColumns(columns []string) string {
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = pgx.Identifier{column}.Sanitize()
+	}
+	return strings.Join(quotedColumns, ", ")
+}
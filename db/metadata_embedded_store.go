@@ -0,0 +1,122 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+const (
+	embeddedMetadataStoreVersion         = embeddedpostgres.V16
+	embeddedMetadataStoreStartTimeoutSec = 60
+)
+
+// embeddedMetadataStore is the postgres the broker runs itself when METADATA_DB_EMBEDDED is set, so a single
+// broker needs no external db. Every query of this package runs on it unchanged, and the metadata can later be
+// copied to an external postgres like any other instance
+var embeddedMetadataStore *embeddedPostgres
+
+type embeddedPostgres struct {
+	postgres *embeddedpostgres.EmbeddedPostgres
+	logFile  *os.File
+}
+
+// startEmbeddedMetadataStore starts postgres on the metadata db port with its data under METADATA_DB_EMBEDDED_DIR.
+// The binaries are extracted from the archive in the cache directory, which is downloaded on the first start
+// unless it was placed there in advance for hosts without internet access
+func startEmbeddedMetadataStore() error {
+	if configuration.METADATA_DB_URL != "" {
+		return errors.New("METADATA_DB_URL can not be set together with METADATA_DB_EMBEDDED")
+	}
+	port, err := strconv.ParseUint(configuration.METADATA_DB_PORT, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid METADATA_DB_PORT %v", configuration.METADATA_DB_PORT)
+	}
+
+	dir := configuration.METADATA_DB_EMBEDDED_DIR
+	dataDir := filepath.Join(dir, "data")
+	// the library re-initializes a data directory of another major version, which would wipe the metadata
+	if pgVersion, err := os.ReadFile(filepath.Join(dataDir, "PG_VERSION")); err == nil {
+		version := strings.TrimSpace(string(pgVersion))
+		if !strings.HasPrefix(string(embeddedMetadataStoreVersion), version+".") {
+			return fmt.Errorf("the embedded metadata store at %v was created by postgres %v, migrate it with migrate-metadata before upgrading", dataDir, version)
+		}
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	stopStaleEmbeddedMetadataStore(dir, dataDir)
+	logFile, err := os.OpenFile(filepath.Join(dir, "postgres.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	config := embeddedpostgres.DefaultConfig().
+		Version(embeddedMetadataStoreVersion).
+		Port(uint32(port)).
+		Username(configuration.METADATA_DB_USER).
+		Password(configuration.METADATA_DB_PASS).
+		Database(configuration.METADATA_DB_DBNAME).
+		DataPath(dataDir).
+		BinariesPath(filepath.Join(dir, "bin")).
+		RuntimePath(filepath.Join(dir, "runtime")).
+		CachePath(filepath.Join(dir, "cache")).
+		StartTimeout(embeddedMetadataStoreStartTimeoutSec * time.Second).
+		Logger(logFile)
+	if configuration.METADATA_DB_BINARIES_URL != "" {
+		config = config.BinaryRepositoryURL(configuration.METADATA_DB_BINARIES_URL)
+	}
+	store := embeddedpostgres.NewDatabase(config)
+	err = store.Start()
+	if err != nil {
+		logFile.Close()
+		return fmt.Errorf("failed starting the embedded metadata store, see %v: %v", logFile.Name(), err.Error())
+	}
+
+	embeddedMetadataStore = &embeddedPostgres{postgres: store, logFile: logFile}
+	configuration.METADATA_DB_HOST = "localhost"
+	configuration.METADATA_DB_TLS_ENABLED = false
+	return nil
+}
+
+// stopStaleEmbeddedMetadataStore stops a postgres left running by a broker which crashed or exited before it could
+// stop it, otherwise the metadata db port stays taken
+func stopStaleEmbeddedMetadataStore(dir, dataDir string) {
+	if _, err := os.Stat(filepath.Join(dataDir, "postmaster.pid")); err != nil {
+		return
+	}
+	pgCtl := filepath.Join(dir, "bin", "bin", "pg_ctl")
+	if _, err := os.Stat(pgCtl); err != nil {
+		return
+	}
+	exec.Command(pgCtl, "stop", "-w", "-m", "fast", "-D", dataDir).Run()
+}
+
+func stopEmbeddedMetadataStore() error {
+	if embeddedMetadataStore == nil {
+		return nil
+	}
+	err := embeddedMetadataStore.postgres.Stop()
+	embeddedMetadataStore.logFile.Close()
+	embeddedMetadataStore = nil
+	return err
+}
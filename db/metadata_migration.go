@@ -0,0 +1,306 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const metadataMigrationTimeout = 30 * time.Minute
+
+type TableMigrationReport struct {
+	Table      string
+	SourceRows int64
+	TargetRows int64
+	Copied     bool
+	Error      string
+}
+
+// MigrateMetadata copies every metadata table from the source db to the target db.
+// An empty sourceUrl means the configured metadata db.
+// The target tables must be empty, all tables are copied in a single transaction and row counts are verified before commit.
+// On dry run nothing is written to the target and the report only holds the source row counts.
+func MigrateMetadata(sourceUrl, targetUrl string, dryRun bool) ([]TableMigrationReport, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), metadataMigrationTimeout)
+	defer cancelfunc()
+
+	var sourceConfig *pgxpool.Config
+	var err error
+	if sourceUrl == "" {
+		sourceConfig, err = metadataDbPoolConfig()
+	} else {
+		sourceConfig, err = pgxpool.ParseConfig(sourceUrl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	source, err := pgxpool.NewWithConfig(ctx, sourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	defer source.Close()
+
+	targetConfig, err := pgxpool.ParseConfig(targetUrl)
+	if err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+	target, err := pgxpool.NewWithConfig(ctx, targetConfig)
+	if err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+	defer target.Close()
+
+	if err = source.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	if err = target.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+
+	sourceConn, err := source.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceConn.Release()
+	// a single snapshot so rows written during the migration can not break foreign keys or counts
+	sourceTx, err := sourceConn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer sourceTx.Rollback(ctx)
+
+	tables, err := getMetadataTablesInDependencyOrder(ctx, sourceTx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]TableMigrationReport, len(tables))
+	for i, table := range tables {
+		reports[i].Table = table
+		err = sourceTx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", pgx.Identifier{table}.Sanitize())).Scan(&reports[i].SourceRows)
+		if err != nil {
+			return reports, fmt.Errorf("%s: %v", table, err)
+		}
+	}
+
+	if !dryRun {
+		cancelCreate := func() {}
+		err = createTables(MetadataStorage{Client: target, Ctx: ctx, Cancel: cancelCreate})
+		if err != nil {
+			return reports, fmt.Errorf("target: failed creating tables: %v", err)
+		}
+	}
+
+	targetTables := map[string]bool{}
+	rows, err := target.Query(ctx, `SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_type = 'BASE TABLE'`)
+	if err != nil {
+		return reports, fmt.Errorf("target: %v", err)
+	}
+	targetTableNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return reports, fmt.Errorf("target: %v", err)
+	}
+	for _, table := range targetTableNames {
+		targetTables[table] = true
+	}
+
+	failed := false
+	for i := range reports {
+		if !targetTables[reports[i].Table] {
+			// on dry run the missing tables would be created by the migration
+			if !dryRun {
+				reports[i].Error = "table does not exist in the target"
+				failed = true
+			}
+			continue
+		}
+		err = target.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", pgx.Identifier{reports[i].Table}.Sanitize())).Scan(&reports[i].TargetRows)
+		if err != nil {
+			return reports, fmt.Errorf("target: %s: %v", reports[i].Table, err)
+		}
+		if reports[i].TargetRows > 0 {
+			reports[i].Error = "table in the target is not empty"
+			failed = true
+		}
+	}
+	if failed {
+		return reports, errors.New("the target db is not ready for migration")
+	}
+	if dryRun {
+		return reports, nil
+	}
+
+	targetConn, err := target.Acquire(ctx)
+	if err != nil {
+		return reports, err
+	}
+	defer targetConn.Release()
+	targetTx, err := targetConn.Begin(ctx)
+	if err != nil {
+		return reports, err
+	}
+	defer targetTx.Rollback(ctx)
+
+	for i := range reports {
+		table := reports[i].Table
+		columns, err := getTableColumns(ctx, sourceTx, table)
+		if err != nil {
+			reports[i].Error = err.Error()
+			return reports, fmt.Errorf("%s: %v", table, err)
+		}
+		err = copyMetadataTable(ctx, sourceTx, targetTx, table, columns)
+		if err != nil {
+			reports[i].Error = err.Error()
+			return reports, fmt.Errorf("%s: %v", table, err)
+		}
+		err = targetTx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", pgx.Identifier{table}.Sanitize())).Scan(&reports[i].TargetRows)
+		if err != nil {
+			reports[i].Error = err.Error()
+			return reports, fmt.Errorf("%s: %v", table, err)
+		}
+		if reports[i].TargetRows != reports[i].SourceRows {
+			reports[i].Error = fmt.Sprintf("copied %v rows out of %v", reports[i].TargetRows, reports[i].SourceRows)
+			return reports, fmt.Errorf("%s: row count mismatch", table)
+		}
+		err = resetTableSequences(ctx, sourceTx, targetTx, table)
+		if err != nil {
+			reports[i].Error = err.Error()
+			return reports, fmt.Errorf("%s: failed resetting sequences: %v", table, err)
+		}
+	}
+
+	err = targetTx.Commit(ctx)
+	if err != nil {
+		return reports, err
+	}
+	for i := range reports {
+		reports[i].Copied = true
+	}
+	return reports, nil
+}
+
+// getMetadataTablesInDependencyOrder returns the tables so that every table comes after the tables it references
+func getMetadataTablesInDependencyOrder(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	rows, err := tx.Query(ctx, `SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_type = 'BASE TABLE' ORDER BY table_name`)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `SELECT c.conrelid::regclass::text, c.confrelid::regclass::text FROM pg_constraint c
+		JOIN pg_namespace n ON n.oid = c.connamespace WHERE c.contype = 'f' AND n.nspname = 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	type foreignKey struct {
+		Table      string
+		References string
+	}
+	foreignKeys, err := pgx.CollectRows(rows, pgx.RowToStructByPos[foreignKey])
+	if err != nil {
+		return nil, err
+	}
+	dependencies := map[string][]string{}
+	for _, fk := range foreignKeys {
+		if fk.Table != fk.References {
+			dependencies[fk.Table] = append(dependencies[fk.Table], fk.References)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	state := map[string]int{} // 1 - visiting, 2 - done
+	var visit func(table string) error
+	visit = func(table string) error {
+		switch state[table] {
+		case 1:
+			return fmt.Errorf("circular foreign keys on table %v", table)
+		case 2:
+			return nil
+		}
+		state[table] = 1
+		for _, dependency := range dependencies[table] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[table] = 2
+		ordered = append(ordered, table)
+		return nil
+	}
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func getTableColumns(ctx context.Context, tx pgx.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(ctx, `SELECT column_name FROM information_schema.columns WHERE table_schema = 'public' AND table_name = $1 ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// copyMetadataTable streams the table rows from the source to the target using COPY
+func copyMetadataTable(ctx context.Context, sourceTx, targetTx pgx.Tx, table string, columns []string) error {
+	columnList := quoteColumns(columns)
+	tableName := pgx.Identifier{table}.Sanitize()
+
+	reader, writer := io.Pipe()
+	copyToErr := make(chan error, 1)
+	go func() {
+		_, err := sourceTx.Conn().PgConn().CopyTo(ctx, writer, fmt.Sprintf("COPY %s (%s) TO STDOUT", tableName, columnList))
+		writer.CloseWithError(err)
+		copyToErr <- err
+	}()
+
+	_, err := targetTx.Conn().PgConn().CopyFrom(ctx, reader, fmt.Sprintf("COPY %s (%s) FROM STDIN", tableName, columnList))
+	reader.CloseWithError(err)
+	if errTo := <-copyToErr; errTo != nil {
+		return errTo
+	}
+	return err
+}
+
+// resetTableSequences moves the serial sequences of the target past the copied ids
+func resetTableSequences(ctx context.Context, sourceTx, targetTx pgx.Tx, table string) error {
+	rows, err := sourceTx.Query(ctx, `SELECT column_name FROM information_schema.columns WHERE table_schema = 'public' AND table_name = $1 AND column_default LIKE 'nextval%'`, table)
+	if err != nil {
+		return err
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	tableName := pgx.Identifier{table}.Sanitize()
+	for _, column := range columns {
+		columnName := pgx.Identifier{column}.Sanitize()
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence($1, $2), GREATEST(COALESCE(MAX(%s), 0), 1), MAX(%s) IS NOT NULL) FROM %s`, columnName, columnName, tableName)
+		_, err = targetTx.Exec(ctx, query, tableName, column)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,543 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package db
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Secrets (user passwords, connection tokens and integration credentials) are envelope encrypted before
+// they are written: every value gets its own data key which is wrapped by the configured KMS.
+// Values without the envelope prefix are returned as is, so existing rows keep working and get
+// encrypted the next time they are written.
+const (
+	secretEnvelopePrefix = "kms:v1:"
+	secretEnvelopeSep    = ":"
+	secretDataKeyLen     = 32
+	kmsRequestTimeout    = 10 * time.Second
+
+	unwrappedKeysCacheSize = 1024
+	unwrappedKeysCacheTTL  = 10 * time.Minute
+)
+
+var secretIntegrationKeys = []string{"auth_token", "access_key", "secret_key", "token", "password", "api_key", "routing_key"}
+
+type kmsProvider interface {
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+var (
+	secretsKms         kmsProvider
+	secretsKmsInitOnce sync.Once
+	secretsKmsInitErr  error
+	unwrappedKeysCache = newUnwrappedKeysLru(unwrappedKeysCacheSize, unwrappedKeysCacheTTL)
+)
+
+// unwrappedKeysLru keeps the most recently used unwrapped data keys so reads do not call the KMS
+// for every value, keys expire after ttl so a key revoked in the KMS stops being usable
+type unwrappedKeysLru struct {
+	mu      sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type unwrappedKeyEntry struct {
+	wrappedKey string
+	dataKey    []byte
+	expiresAt  time.Time
+}
+
+func newUnwrappedKeysLru(maxSize int, ttl time.Duration) *unwrappedKeysLru {
+	return &unwrappedKeysLru{
+		maxSize: maxSize,
+		ttl:     ttl,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *unwrappedKeysLru) get(wrappedKey string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[wrappedKey]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*unwrappedKeyEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.ll.Remove(e)
+		delete(c.entries, wrappedKey)
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return entry.dataKey, true
+}
+
+func (c *unwrappedKeysLru) add(wrappedKey string, dataKey []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := time.Now().Add(c.ttl)
+	if e, ok := c.entries[wrappedKey]; ok {
+		entry := e.Value.(*unwrappedKeyEntry)
+		entry.dataKey = dataKey
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.entries[wrappedKey] = c.ll.PushFront(&unwrappedKeyEntry{wrappedKey: wrappedKey, dataKey: dataKey, expiresAt: expiresAt})
+	for c.ll.Len() > c.maxSize {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*unwrappedKeyEntry).wrappedKey)
+	}
+}
+
+func getSecretsKms() (kmsProvider, error) {
+	secretsKmsInitOnce.Do(func() {
+		switch strings.ToLower(configuration.SECRETS_KMS_PROVIDER) {
+		case "":
+			secretsKms = nil
+		case "local":
+			secretsKms, secretsKmsInitErr = newLocalKms(configuration.SECRETS_KMS_LOCAL_KEY_FILE)
+		case "aws":
+			secretsKms, secretsKmsInitErr = newAwsKms(configuration.SECRETS_KMS_AWS_KEY_ID, configuration.SECRETS_KMS_AWS_REGION)
+		case "vault":
+			secretsKms, secretsKmsInitErr = newVaultTransitKms(configuration.SECRETS_KMS_VAULT_ADDR, configuration.SECRETS_KMS_VAULT_TOKEN, configuration.SECRETS_KMS_VAULT_KEY)
+		default:
+			secretsKmsInitErr = fmt.Errorf("unsupported secrets KMS provider %v", configuration.SECRETS_KMS_PROVIDER)
+		}
+	})
+	return secretsKms, secretsKmsInitErr
+}
+
+func aesGcmSeal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func aesGcmOpen(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted secret is too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+func encryptSecret(plaintext string) (string, error) {
+	kms, err := getSecretsKms()
+	if err != nil {
+		return "", err
+	}
+	if kms == nil || plaintext == "" || strings.HasPrefix(plaintext, secretEnvelopePrefix) {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, secretDataKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrappedKey, err := kms.WrapKey(dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := aesGcmSeal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return secretEnvelopePrefix + base64.RawURLEncoding.EncodeToString(wrappedKey) + secretEnvelopeSep + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, secretEnvelopePrefix) {
+		return value, nil
+	}
+	kms, err := getSecretsKms()
+	if err != nil {
+		return "", err
+	}
+	if kms == nil {
+		return "", errors.New("an encrypted secret was found but no secrets KMS provider is configured")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, secretEnvelopePrefix), secretEnvelopeSep)
+	if len(parts) != 2 {
+		return "", errors.New("encrypted secret is malformed")
+	}
+	dataKey, ok := unwrappedKeysCache.get(parts[0])
+	if !ok {
+		wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[0])
+		if err != nil {
+			return "", err
+		}
+		dataKey, err = kms.UnwrapKey(wrappedKey)
+		if err != nil {
+			return "", err
+		}
+		unwrappedKeysCache.add(parts[0], dataKey)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aesGcmOpen(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// StorageEncryptionAvailable reports whether a KMS provider is configured for wrapping station storage keys
+func StorageEncryptionAvailable() bool {
+	kms, err := getSecretsKms()
+	return err == nil && kms != nil
+}
+
+// WrapStorageKey wraps a station storage key with the secrets KMS so only the wrapped form is persisted
+func WrapStorageKey(key []byte) (string, error) {
+	kms, err := getSecretsKms()
+	if err != nil {
+		return "", err
+	}
+	if kms == nil {
+		return "", errors.New("encryption at rest requires a secrets KMS provider")
+	}
+	wrappedKey, err := kms.WrapKey(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(wrappedKey), nil
+}
+
+func UnwrapStorageKey(wrappedKey string) ([]byte, error) {
+	kms, err := getSecretsKms()
+	if err != nil {
+		return nil, err
+	}
+	if kms == nil {
+		return nil, errors.New("an encrypted station was found but no secrets KMS provider is configured")
+	}
+	rawKey, err := base64.RawURLEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return kms.UnwrapKey(rawKey)
+}
+
+func decryptUsersSecrets(users []models.User) error {
+	for i := range users {
+		password, err := decryptSecret(users[i].Password)
+		if err != nil {
+			return err
+		}
+		users[i].Password = password
+	}
+	return nil
+}
+
+func decryptConnectionTokens(tokens []models.ConnectionToken) error {
+	for i := range tokens {
+		token, err := decryptSecret(tokens[i].Token)
+		if err != nil {
+			return err
+		}
+		tokens[i].Token = token
+	}
+	return nil
+}
+
+func isSecretIntegrationKey(key string) bool {
+	for _, secretKey := range secretIntegrationKeys {
+		if key == secretKey {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptIntegrationKeys returns a copy of the keys since callers keep using the plaintext map
+func encryptIntegrationKeys(keys map[string]interface{}) (map[string]interface{}, error) {
+	encryptedKeys := make(map[string]interface{}, len(keys))
+	for key, value := range keys {
+		strValue, ok := value.(string)
+		if !ok || !isSecretIntegrationKey(key) {
+			encryptedKeys[key] = value
+			continue
+		}
+		encryptedValue, err := encryptSecret(strValue)
+		if err != nil {
+			return nil, err
+		}
+		encryptedKeys[key] = encryptedValue
+	}
+	return encryptedKeys, nil
+}
+
+func decryptIntegrationsKeys(integrations []models.Integration) error {
+	for _, integration := range integrations {
+		for key, value := range integration.Keys {
+			strValue, ok := value.(string)
+			if !ok {
+				continue
+			}
+			decryptedValue, err := decryptSecret(strValue)
+			if err != nil {
+				return err
+			}
+			integration.Keys[key] = decryptedValue
+		}
+	}
+	return nil
+}
+
+// Local key file provider
+type localKms struct {
+	key []byte
+}
+
+func newLocalKms(keyFile string) (*localKms, error) {
+	if keyFile == "" {
+		return nil, errors.New("SECRETS_KMS_LOCAL_KEY_FILE has to be set when using the local secrets KMS")
+	}
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	// the key file holds either raw 32 bytes, hex or base64 encoded key
+	trimmed := strings.TrimSpace(string(content))
+	var key []byte
+	switch {
+	case len(content) == secretDataKeyLen:
+		key = content
+	case len(trimmed) == hex.EncodedLen(secretDataKeyLen):
+		key, err = hex.DecodeString(trimmed)
+	default:
+		key, err = base64.StdEncoding.DecodeString(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != secretDataKeyLen {
+		return nil, fmt.Errorf("the local secrets KMS key has to be %v bytes long", secretDataKeyLen)
+	}
+	return &localKms{key: key}, nil
+}
+
+func (lk *localKms) WrapKey(dataKey []byte) ([]byte, error) {
+	return aesGcmSeal(lk.key, dataKey)
+}
+
+func (lk *localKms) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	return aesGcmOpen(lk.key, wrappedKey)
+}
+
+// AWS KMS provider, calls the KMS JSON API directly to avoid pulling in the whole KMS sdk
+type awsKms struct {
+	keyId    string
+	region   string
+	endpoint string
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+func newAwsKms(keyId, region string) (*awsKms, error) {
+	if keyId == "" || region == "" {
+		return nil, errors.New("SECRETS_KMS_AWS_KEY_ID and SECRETS_KMS_AWS_REGION have to be set when using the AWS secrets KMS")
+	}
+	return &awsKms{
+		keyId:    keyId,
+		region:   region,
+		endpoint: fmt.Sprintf("https://kms.%v.amazonaws.com/", region),
+		signer:   v4.NewSigner(),
+		client:   &http.Client{Timeout: kmsRequestTimeout},
+	}, nil
+}
+
+func (ak *awsKms) call(target string, reqBody map[string]string) (map[string]interface{}, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), kmsRequestTimeout)
+	defer cancelfunc()
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(ak.region))
+	if err != nil {
+		return nil, err
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ak.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+target)
+	payloadHash := sha256.Sum256(body)
+	err = ak.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "kms", ak.region, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return doKmsRequest(ak.client, req)
+}
+
+func (ak *awsKms) WrapKey(dataKey []byte) ([]byte, error) {
+	res, err := ak.call("Encrypt", map[string]string{"KeyId": ak.keyId, "Plaintext": base64.StdEncoding.EncodeToString(dataKey)})
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := res["CiphertextBlob"].(string)
+	if !ok {
+		return nil, errors.New("AWS KMS encrypt response is missing CiphertextBlob")
+	}
+	return base64.StdEncoding.DecodeString(blob)
+}
+
+func (ak *awsKms) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	res, err := ak.call("Decrypt", map[string]string{"KeyId": ak.keyId, "CiphertextBlob": base64.StdEncoding.EncodeToString(wrappedKey)})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := res["Plaintext"].(string)
+	if !ok {
+		return nil, errors.New("AWS KMS decrypt response is missing Plaintext")
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// Vault transit provider
+type vaultTransitKms struct {
+	addr   string
+	token  string
+	key    string
+	client *http.Client
+}
+
+func newVaultTransitKms(addr, token, key string) (*vaultTransitKms, error) {
+	if addr == "" || token == "" || key == "" {
+		return nil, errors.New("SECRETS_KMS_VAULT_ADDR, SECRETS_KMS_VAULT_TOKEN and SECRETS_KMS_VAULT_KEY have to be set when using the Vault secrets KMS")
+	}
+	return &vaultTransitKms{addr: strings.TrimSuffix(addr, "/"), token: token, key: key, client: &http.Client{Timeout: kmsRequestTimeout}}, nil
+}
+
+func (vk *vaultTransitKms) call(operation string, reqBody map[string]string) (map[string]interface{}, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%v/v1/transit/%v/%v", vk.addr, operation, vk.key)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", vk.token)
+	res, err := doKmsRequest(vk.client, req)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := res["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Vault transit response is missing data")
+	}
+	return data, nil
+}
+
+func (vk *vaultTransitKms) WrapKey(dataKey []byte) ([]byte, error) {
+	data, err := vk.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)})
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, ok := data["ciphertext"].(string)
+	if !ok {
+		return nil, errors.New("Vault transit encrypt response is missing ciphertext")
+	}
+	return []byte(ciphertext), nil
+}
+
+func (vk *vaultTransitKms) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	data, err := vk.call("decrypt", map[string]string{"ciphertext": string(wrappedKey)})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := data["plaintext"].(string)
+	if !ok {
+		return nil, errors.New("Vault transit decrypt response is missing plaintext")
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+func doKmsRequest(client *http.Client, req *http.Request) (map[string]interface{}, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("KMS request failed with status %v: %v", res.StatusCode, string(resBody))
+	}
+	var parsed map[string]interface{}
+	err = json.Unmarshal(resBody, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useLocalTestKms points the secrets encryption at a fresh local KMS key for the duration of the test
+func useLocalTestKms(t *testing.T) {
+	t.Helper()
+	key := make([]byte, secretDataKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "kms.key")
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, localKeyFile := configuration.SECRETS_KMS_PROVIDER, configuration.SECRETS_KMS_LOCAL_KEY_FILE
+	configuration.SECRETS_KMS_PROVIDER = "local"
+	configuration.SECRETS_KMS_LOCAL_KEY_FILE = keyFile
+	secretsKms, secretsKmsInitOnce, secretsKmsInitErr = nil, sync.Once{}, nil
+	unwrappedKeysCache = newUnwrappedKeysLru(unwrappedKeysCacheSize, unwrappedKeysCacheTTL)
+	t.Cleanup(func() {
+		configuration.SECRETS_KMS_PROVIDER = provider
+		configuration.SECRETS_KMS_LOCAL_KEY_FILE = localKeyFile
+		secretsKms, secretsKmsInitOnce, secretsKmsInitErr = nil, sync.Once{}, nil
+		unwrappedKeysCache = newUnwrappedKeysLru(unwrappedKeysCacheSize, unwrappedKeysCacheTTL)
+	})
+}
+
+func TestSecretEnvelopeRoundTrip(t *testing.T) {
+	useLocalTestKms(t)
+	cases := []struct {
+		name      string
+		plaintext string
+		encrypted bool
+	}{
+		{name: "token", plaintext: "connection-token", encrypted: true},
+		{name: "unicode", plaintext: "סוד-秘密-🔑", encrypted: true},
+		{name: "long value", plaintext: strings.Repeat("x", 64*1024), encrypted: true},
+		{name: "separator inside the value", plaintext: "a:b:kms:v1:c", encrypted: true},
+		{name: "empty value is kept as is", plaintext: "", encrypted: false},
+		{name: "enveloped value is not wrapped twice", plaintext: secretEnvelopePrefix + "abc:def", encrypted: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			encrypted, err := encryptSecret(tc.plaintext)
+			if err != nil {
+				t.Fatalf("encryptSecret: %v", err)
+			}
+			if tc.encrypted != (encrypted != tc.plaintext) {
+				t.Fatalf("encrypted = %v, want %v", encrypted != tc.plaintext, tc.encrypted)
+			}
+			if !tc.encrypted {
+				return
+			}
+			if !strings.HasPrefix(encrypted, secretEnvelopePrefix) || strings.Contains(encrypted, tc.plaintext) {
+				t.Fatalf("value is not enveloped: %v", encrypted)
+			}
+			again, err := encryptSecret(tc.plaintext)
+			if err != nil {
+				t.Fatalf("encryptSecret: %v", err)
+			}
+			if again == encrypted {
+				t.Fatalf("every value has to get its own data key")
+			}
+			decrypted, err := decryptSecret(encrypted)
+			if err != nil {
+				t.Fatalf("decryptSecret: %v", err)
+			}
+			if decrypted != tc.plaintext {
+				t.Fatalf("decryptSecret = %q, want %q", decrypted, tc.plaintext)
+			}
+		})
+	}
+}
+
+func TestDecryptSecret(t *testing.T) {
+	useLocalTestKms(t)
+	encrypted, err := encryptSecret("connection-token")
+	if err != nil {
+		t.Fatalf("encryptSecret: %v", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(encrypted, secretEnvelopePrefix), secretEnvelopeSep)
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed[len(sealed)-1] ^= 0xff
+	tampered := base64.RawURLEncoding.EncodeToString(sealed)
+
+	cases := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "enveloped value", value: encrypted, want: "connection-token"},
+		{name: "plaintext rows written before encryption", value: "legacy-token", want: "legacy-token"},
+		{name: "missing sealed value", value: secretEnvelopePrefix + parts[0], wantErr: true},
+		{name: "tampered sealed value", value: secretEnvelopePrefix + parts[0] + secretEnvelopeSep + tampered, wantErr: true},
+		{name: "wrapped key is not base64", value: secretEnvelopePrefix + "!!!" + secretEnvelopeSep + parts[1], wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decryptSecret(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("decryptSecret error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Fatalf("decryptSecret = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	// a value sealed under another KMS key can not be opened once its data key is not cached anymore
+	useLocalTestKms(t)
+	if _, err := decryptSecret(encrypted); err == nil {
+		t.Fatalf("decryptSecret succeeded with another KMS key")
+	}
+}
+
+func TestUnwrappedKeysLru(t *testing.T) {
+	cache := newUnwrappedKeysLru(2, time.Hour)
+	cache.add("a", []byte("1"))
+	cache.add("b", []byte("2"))
+	if _, ok := cache.get("a"); !ok {
+		t.Fatalf("a is missing")
+	}
+	// b is now the least recently used key
+	cache.add("c", []byte("3"))
+	if _, ok := cache.get("b"); ok {
+		t.Fatalf("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("%v is missing", key)
+		}
+	}
+
+	expiring := newUnwrappedKeysLru(2, time.Millisecond)
+	expiring.add("a", []byte("1"))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := expiring.get("a"); ok {
+		t.Fatalf("a did not expire")
+	}
+	if expiring.ll.Len() != 0 || len(expiring.entries) != 0 {
+		t.Fatalf("the expired key is still held")
+	}
+}
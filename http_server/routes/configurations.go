@@ -22,4 +22,6 @@ func InitializeConfigurationsRoutes(router *gin.RouterGroup, h *server.Handlers)
 	configurationsRoutes := router.Group("/configurations")
 	configurationsRoutes.PUT("/editClusterConfig", configurationsHandler.EditClusterConfig)
 	configurationsRoutes.GET("/getClusterConfig", configurationsHandler.GetClusterConfig)
+	configurationsRoutes.POST("/purgeStaleClients", configurationsHandler.PurgeStaleClients)
+	router.GET("/config", configurationsHandler.GetEffectiveConfig)
 }
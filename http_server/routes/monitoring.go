@@ -25,7 +25,11 @@ func InitializeMonitoringRoutes(router *gin.RouterGroup, h *server.Handlers) {
 	monitoringRoutes.GET("/getStationOverviewData", monitoringHandler.GetStationOverviewData)
 	monitoringRoutes.GET("/getSystemLogs", monitoringHandler.GetSystemLogs)
 	monitoringRoutes.GET("/downloadSystemLogs", monitoringHandler.DownloadSystemLogs)
+	monitoringRoutes.GET("/browseSystemLogs", monitoringHandler.BrowseSystemLogs)
 	monitoringRoutes.GET("/getAvailableReplicas", monitoringHandler.GetAvailableReplicas)
 	monitoringRoutes.GET("/getSystemGeneralInfo", monitoringHandler.GetSystemGeneralInfo)
+	monitoringRoutes.GET("/getK8sComponents", monitoringHandler.GetK8sComponents)
+	monitoringRoutes.GET("/getGrafanaDashboard", monitoringHandler.GetGrafanaDashboard)
+	monitoringRoutes.GET("/getZombieCandidates", monitoringHandler.GetZombieCandidates)
 	server.AddMonitoringCloudRoutes(monitoringRoutes, monitoringHandler)
 }
@@ -23,11 +23,32 @@ import (
 func InitializeHttpRoutes(handlers *server.Handlers) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(server.RequestID)
 	server.SetCors(router)
-	mainRouter := router.Group("/api")
-	mainRouter.Use(middlewares.Authenticate)
 
 	utils.InitializeValidations()
+	initializeApiRoutes(router.Group(middlewares.ApiV1Prefix), handlers)
+	legacyRouter := router.Group(middlewares.LegacyApiPrefix)
+	legacyRouter.Use(middlewares.DeprecatedApi)
+	initializeApiRoutes(legacyRouter, handlers)
+	// the document is public so clients can be generated before logging in
+	router.GET(middlewares.ApiV1Prefix+"/openapi.json", server.OpenApiDocument(router, middlewares.ApiV1Prefix))
+	router.GET(middlewares.LegacyApiPrefix+"/openapi.json", server.OpenApiDocument(router, middlewares.ApiV1Prefix))
+	// probes are registered before the UI routes so they are not served by its index.html fallback
+	router.GET("/healthz", handlers.Monitoring.Healthz)
+	router.GET("/readyz", handlers.Monitoring.Readyz)
+	ui.InitializeUIRoutes(router)
+
+	return router
+}
+
+// initializeApiRoutes registers the whole api under one prefix, every version serves the same handlers until a
+// breaking change gives the new version its own
+func initializeApiRoutes(mainRouter *gin.RouterGroup, handlers *server.Handlers) {
+	mainRouter.Use(server.ErrorCodes)
+	mainRouter.Use(middlewares.Authenticate)
+	mainRouter.Use(server.AdminPolicies)
+
 	InitializeUserMgmtRoutes(mainRouter)
 	InitializeStationsRoutes(mainRouter, handlers)
 	InitializeMonitoringRoutes(mainRouter, handlers)
@@ -39,13 +60,31 @@ func InitializeHttpRoutes(handlers *server.Handlers) *gin.Engine {
 	server.InitializeBillingRoutes(mainRouter, handlers)
 	InitializeAsyncTasksRoutes(mainRouter, handlers)
 	InitializeFunctionsRoutes(mainRouter, handlers)
-	ui.InitializeUIRoutes(router)
+	InitializeApiKeysRoutes(mainRouter, handlers)
+	InitializeVaultRoutes(mainRouter, handlers)
+	InitializeAuditLogsRoutes(mainRouter, handlers)
+	InitializeAlertsRoutes(mainRouter, handlers)
+	InitializeWebhooksRoutes(mainRouter, handlers)
+	InitializeAmqpBridgesRoutes(mainRouter, handlers)
+	InitializeGatewayRoutes(mainRouter, handlers)
+	InitializeCdcConnectorsRoutes(mainRouter, handlers)
+	InitializeClickhouseSinksRoutes(mainRouter, handlers)
+	InitializeCatalogExportersRoutes(mainRouter, handlers)
+	InitializeSchemaRegistrySyncsRoutes(mainRouter, handlers)
+	InitializeResourcesRoutes(mainRouter, handlers)
+	InitializeBackupsRoutes(mainRouter, handlers)
+	InitializeJobsRoutes(mainRouter, handlers)
+	InitializeTeamsRoutes(mainRouter, handlers)
+	InitializeProjectsRoutes(mainRouter, handlers)
+	InitializeOwnershipRoutes(mainRouter, handlers)
+	InitializeSearchRoutes(mainRouter, handlers)
+	InitializeCommentsRoutes(mainRouter, handlers)
+	InitializeConnectionsRoutes(mainRouter, handlers)
+	InitializeConsumersRoutes(mainRouter, handlers)
 
 	mainRouter.GET("/status", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Ok",
 		})
 	})
-
-	return router
 }
@@ -23,8 +23,14 @@ func InitializeSchemasRoutes(router *gin.RouterGroup, h *server.Handlers) {
 	schemasRoutes.POST("/createNewSchema", schemasHandler.CreateNewSchema)
 	schemasRoutes.GET("/getAllSchemas", schemasHandler.GetAllSchemas)
 	schemasRoutes.GET("/getSchemaDetails", schemasHandler.GetSchemaDetails)
+	schemasRoutes.GET("/getActiveSchemaVersion", schemasHandler.GetActiveSchemaVersion)
 	schemasRoutes.DELETE("/removeSchema", schemasHandler.RemoveSchema)
 	schemasRoutes.POST("/createNewVersion", schemasHandler.CreateNewVersion)
 	schemasRoutes.PUT("/rollBackVersion", schemasHandler.RollBackVersion)
 	schemasRoutes.POST("/validateSchema", schemasHandler.ValidateSchema)
+	schemasRoutes.PUT("/renameSchema", schemasHandler.RenameSchema)
+	schemasRoutes.DELETE("/removeSchemaAlias", schemasHandler.RemoveSchemaAlias)
+	schemasRoutes.POST("/inferSchema", schemasHandler.InferSchema)
+	schemasRoutes.POST("/listGrpcReflectionMessages", schemasHandler.ListGrpcReflectionMessages)
+	schemasRoutes.POST("/importGrpcReflectionSchemas", schemasHandler.ImportGrpcReflectionSchemas)
 }
@@ -27,15 +27,37 @@ func InitializeStationsRoutes(router *gin.RouterGroup, h *server.Handlers) {
 	stationsRoutes.GET("/getPoisonMessageJourney", stationsHandler.GetPoisonMessageJourney)
 	stationsRoutes.POST("/createStation", stationsHandler.CreateStation)
 	stationsRoutes.POST("/resendPoisonMessages", stationsHandler.ResendPoisonMessages)
+	stationsRoutes.POST("/resendDlsMessagesToStation", stationsHandler.ResendDlsMessagesToStation)
 	stationsRoutes.DELETE("/removeStation", stationsHandler.RemoveStation)
 	stationsRoutes.POST("/useSchema", stationsHandler.UseSchema)
 	stationsRoutes.DELETE("/removeSchemaFromStation", stationsHandler.RemoveSchemaFromStation)
 	stationsRoutes.GET("/getUpdatesForSchemaByStation", stationsHandler.GetUpdatesForSchemaByStation)
+	stationsRoutes.GET("/getStationMessagesTail", stationsHandler.GetStationMessagesTail)
+	stationsRoutes.GET("/exportMessages", stationsHandler.ExportMessages)
+	stationsRoutes.POST("/importMessages", stationsHandler.ImportMessages)
 	stationsRoutes.PUT("/updateDlsConfig", stationsHandler.UpdateDlsConfig)
+	stationsRoutes.PUT("/updateMessageTransform", stationsHandler.UpdateMessageTransform)
+	stationsRoutes.PUT("/updateAckRetentionLimit", stationsHandler.UpdateAckRetentionLimit)
+	stationsRoutes.PUT("/updateStation", stationsHandler.UpdateStation)
+	stationsRoutes.PUT("/updateReadOnly", stationsHandler.UpdateStationReadOnly)
+	stationsRoutes.GET("/getLifecyclePolicy", stationsHandler.GetStationLifecyclePolicy)
+	stationsRoutes.PUT("/updateLifecyclePolicy", stationsHandler.UpdateStationLifecyclePolicy)
+	stationsRoutes.DELETE("/removeLifecyclePolicy", stationsHandler.RemoveStationLifecyclePolicy)
+	stationsRoutes.GET("/getIndexExtractors", stationsHandler.GetStationIndexExtractors)
+	stationsRoutes.PUT("/updateIndexExtractors", stationsHandler.UpdateStationIndexExtractors)
+	stationsRoutes.GET("/searchMessagesByKey", stationsHandler.SearchStationMessagesByKey)
+	stationsRoutes.GET("/getDataProfile", stationsHandler.GetStationDataProfile)
+	stationsRoutes.GET("/getContractReport", stationsHandler.GetStationContractReport)
+	stationsRoutes.GET("/getContractReportsHistory", stationsHandler.GetStationContractReportsHistory)
+	stationsRoutes.PUT("/updateContractReportSchedule", stationsHandler.UpdateStationContractReportSchedule)
+	stationsRoutes.DELETE("/removeContractReportSchedule", stationsHandler.RemoveStationContractReportSchedule)
+	stationsRoutes.POST("/rotateStorageKey", stationsHandler.RotateStorageKey)
 	stationsRoutes.POST("/dropDlsMessages", stationsHandler.DropDlsMessages)
+	stationsRoutes.GET("/getPurgeConfirmationToken", stationsHandler.GetPurgeConfirmationToken)
 	stationsRoutes.DELETE("/purgeStation", stationsHandler.PurgeStation)
 	stationsRoutes.DELETE("/removeMessages", stationsHandler.RemoveMessages)
 	stationsRoutes.POST("/produce", stationsHandler.Produce)
+	stationsRoutes.POST("/startBenchmark", stationsHandler.StartStationBenchmark)
 	stationsRoutes.POST("/attachDlsStation", stationsHandler.AttachDlsStation)
 	stationsRoutes.DELETE("/detachDlsStation", stationsHandler.DetachDlsStation)
 	server.InitializeCloudStationRoutes(stationsHandler, stationsRoutes)
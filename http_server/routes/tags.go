@@ -25,4 +25,8 @@ func InitializeTagsRoutes(router *gin.RouterGroup, h *server.Handlers) {
 	tagsRoutes.POST("/createNewTag", tagsHandler.CreateNewTag)
 	tagsRoutes.PUT("/updateTagsForEntity", tagsHandler.UpdateTagsForEntity)
 	tagsRoutes.GET("/getUsedTags", tagsHandler.GetUsedTags)
+	tagsRoutes.PUT("/renameTag", tagsHandler.RenameTag)
+	tagsRoutes.PUT("/mergeTags", tagsHandler.MergeTags)
+	tagsRoutes.GET("/getTagResources", tagsHandler.GetTagResources)
+	tagsRoutes.DELETE("/removeUnusedTags", tagsHandler.RemoveUnusedTags)
 }
@@ -39,6 +39,21 @@ func InitializeUserMgmtRoutes(router *gin.RouterGroup) {
 	userMgmtRoutes.POST("/skipGetStarted", userMgmtHandler.SkipGetStarted)
 	userMgmtRoutes.GET("/getFilterDetails", userMgmtHandler.GetFilterDetails)
 	userMgmtRoutes.PUT("/changePassword", userMgmtHandler.ChangePassword)
+	userMgmtRoutes.PUT("/changeMyPassword", userMgmtHandler.ChangeMyPassword)
+	userMgmtRoutes.PUT("/editMyProfile", userMgmtHandler.EditMyProfile)
+	userMgmtRoutes.POST("/uploadAvatar", userMgmtHandler.UploadAvatar)
+	userMgmtRoutes.GET("/getAvatar", userMgmtHandler.GetAvatar)
+	userMgmtRoutes.DELETE("/removeAvatar", userMgmtHandler.RemoveAvatar)
+	userMgmtRoutes.POST("/inviteUser", userMgmtHandler.InviteUser)
+	userMgmtRoutes.POST("/resendInvitation", userMgmtHandler.ResendInvitation)
+	userMgmtRoutes.GET("/getInvitations", userMgmtHandler.GetInvitations)
+	userMgmtRoutes.POST("/approveInvitation", userMgmtHandler.ApproveInvitation)
+	userMgmtRoutes.GET("/getSessions", userMgmtHandler.GetSessions)
+	userMgmtRoutes.DELETE("/revokeSession", userMgmtHandler.RevokeSession)
+	userMgmtRoutes.DELETE("/revokeAllSessions", userMgmtHandler.RevokeAllSessions)
 	userMgmtRoutes.POST("/sendTrace", userMgmtHandler.SendTrace)
+	userMgmtRoutes.POST("/rotateConnectionToken", userMgmtHandler.RotateConnectionToken)
+	userMgmtRoutes.POST("/revokeConnectionToken", userMgmtHandler.RevokeConnectionToken)
+	userMgmtRoutes.GET("/getRevokedConnectionTokens", userMgmtHandler.GetRevokedConnectionTokens)
 	server.AddUsrMgmtCloudRoutes(userMgmtRoutes, userMgmtHandler)
 }
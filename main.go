@@ -14,10 +14,15 @@
 package main
 
 //go:generate go run server/errors_gen.go
+//go:generate go run server/openapi_gen.go
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
 	"github.com/memphisdev/memphis/analytics"
@@ -32,6 +37,9 @@ import (
 
 var usageStr = `
 Usage: nats-server [options]
+       nats-server --dev [--dev_seed]
+       nats-server migrate-metadata --to <url> [--from <url>] [--dry-run]
+       nats-server backup [--incremental] [--list] [--restore <id>] --password <password>
 
 Server Options:
     -a, --addr, --net <host>         Bind to host address (default: 0.0.0.0)
@@ -89,10 +97,34 @@ Cluster Options:
 Profiling Options:
         --profile <port>             Profiling HTTP port
 
+Memphis Settings Options:
+        --app_config <file>          Json/yaml file with memphis settings (default: $MEMPHIS_CONFIG_FILE)
+        --set <KEY=VALUE>            Override a memphis setting, can be repeated
+                                     Precedence: --set, then environment variables, then the settings file, then defaults
+
+Local Playground Options:
+        --dev                        Run a single local broker with debug logging and root/memphis SDK credentials.
+                                     Unless METADATA_DB_URL or METADATA_DB_HOST is set, the metadata is kept in the
+                                     embedded store under $DEV_DATA_DIR/metadata (default: <tmp>/memphis_dev)
+        --dev_seed                   With --dev, create demo stations and a schema and produce demo messages
+
 Common Options:
     -h, --help                       Show this message
     -v, --version                    Show version
         --help_tls                   TLS help
+
+Metadata Migration Options (migrate-metadata):
+        --from <url>                 Source metadata db connection string (default: the configured metadata db)
+        --to <url>                   Target metadata db connection string, its tables must be empty
+        --dry-run                    Check both dbs and report row counts without copying
+
+Backup Options (backup):
+        --url <url>                  Memphis REST API address (default: http://localhost:9000)
+        --username <user>            Management user (default: root)
+        --password <password>        Management user password
+        --incremental                Back up only the messages stored since the last backup
+        --list                       List the backups and the status of the running operation
+        --restore <id>               Restore the backup into this cluster, it must not have stations
 `
 
 // usage will print out the flag options for the server.
@@ -102,6 +134,7 @@ func usage() {
 }
 
 func runMemphis(s *server.Server) {
+	analytics.SetEnabledCategories(s.Opts().AnalyticsCategories)
 	err := analytics.InitializeAnalytics(s.MemphisVersion(), s.GetCustomDeploymentId())
 	if err != nil {
 		s.Errorf("Failed initializing analytics: " + err.Error())
@@ -132,11 +165,20 @@ func runMemphis(s *server.Server) {
 		s.Errorf("Failed create default entities: " + err.Error())
 	}
 
+	err = s.SeedDevData()
+	if err != nil {
+		s.Errorf("Failed seeding dev data: " + err.Error())
+	}
+
 	go http_server.InitializeHttpServer(s)
 
 	var env string
 	var message string
-	if os.Getenv("DOCKER_ENV") != "" {
+	if server.DevModeEnabled() {
+		env = "Dev"
+		message = "\n**********\n\nDashboard/CLI: http://localhost:" + fmt.Sprint(s.Opts().UiPort) + "\nBroker: localhost:" + fmt.Sprint(s.Opts().Port) + " (client connections)\nREST gateway: localhost:" + fmt.Sprint(s.Opts().RestGwPort) + " (Data and management via HTTP)\nUI/CLI/SDK root username - root\nUI/CLI/SDK root password - memphis\nData directory - " + s.Opts().StoreDir + "\n\nDocs: https://docs.memphis.dev/memphis/getting-started/2-hello-world  \n\n**********"
+		s.Noticef(message)
+	} else if os.Getenv("DOCKER_ENV") != "" {
 		env = "Docker"
 		if isUserPassBased {
 			message = "\n**********\n\nDashboard/CLI: http://localhost:" + fmt.Sprint(s.Opts().UiPort) + "\nBroker: localhost:" + fmt.Sprint(s.Opts().Port) + " (client connections)\nREST gateway: localhost:" + fmt.Sprint(s.Opts().RestGwPort) + " (Data and management via HTTP)\nUI/CLI/SDK root username - root\nUI/CLI/SDK root password - memphis\n\nDocs: https://docs.memphis.dev/memphis/getting-started/2-hello-world  \n\n**********"
@@ -159,14 +201,137 @@ func runMemphis(s *server.Server) {
 	s.Noticef("*** Memphis broker is ready, ENV: %s :-) ***", env)
 }
 
+// runMigrateMetadata copies the metadata db to another db and exits
+func runMigrateMetadata(exe string, args []string) {
+	fs := flag.NewFlagSet(exe+" migrate-metadata", flag.ExitOnError)
+	fs.Usage = usage
+	from := fs.String("from", "", "")
+	to := fs.String("to", "", "")
+	dryRun := fs.Bool("dry-run", false, "")
+	if err := fs.Parse(args); err != nil {
+		server.PrintAndDie(fmt.Sprintf("%s: %s", exe, err))
+	}
+	if *to == "" {
+		server.PrintAndDie(fmt.Sprintf("%s: migrate-metadata: --to is required", exe))
+	}
+
+	reports, err := db.MigrateMetadata(*from, *to, *dryRun)
+	for _, report := range reports {
+		status := "ok"
+		if report.Error != "" {
+			status = report.Error
+		} else if !report.Copied {
+			status = "not copied"
+		}
+		fmt.Printf("%-40s source: %-8d target: %-8d %s\n", report.Table, report.SourceRows, report.TargetRows, status)
+	}
+	if err != nil {
+		server.PrintAndDie(fmt.Sprintf("%s: migrate-metadata: %s", exe, err))
+	}
+	if *dryRun {
+		fmt.Println("Dry run finished, the target db is ready for migration")
+	} else {
+		fmt.Println("Metadata migration finished successfully")
+	}
+	os.Exit(0)
+}
+
+// callBackupsApi sends a request to the backups api of a running broker and decodes the json response
+func callBackupsApi(url, token, method, path string, body, resp interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(url, "/")+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s", apiErr.Message)
+		}
+		return fmt.Errorf("%s %s returned %d", method, path, res.StatusCode)
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// runBackup starts a backup or a restore on a running broker through its api and exits
+func runBackup(exe string, args []string) {
+	fs := flag.NewFlagSet(exe+" backup", flag.ExitOnError)
+	fs.Usage = usage
+	url := fs.String("url", "http://localhost:9000", "")
+	username := fs.String("username", "root", "")
+	password := fs.String("password", "", "")
+	incremental := fs.Bool("incremental", false, "")
+	list := fs.Bool("list", false, "")
+	restore := fs.String("restore", "", "")
+	if err := fs.Parse(args); err != nil {
+		server.PrintAndDie(fmt.Sprintf("%s: %s", exe, err))
+	}
+	if *password == "" {
+		server.PrintAndDie(fmt.Sprintf("%s: backup: --password is required", exe))
+	}
+
+	var login struct {
+		Jwt string `json:"jwt"`
+	}
+	err := callBackupsApi(*url, "", http.MethodPost, "/api/usermgmt/login", map[string]string{"username": *username, "password": *password}, &login)
+	if err != nil {
+		server.PrintAndDie(fmt.Sprintf("%s: backup: login failed: %s", exe, err))
+	}
+
+	var resp interface{}
+	switch {
+	case *list:
+		err = callBackupsApi(*url, login.Jwt, http.MethodGet, "/api/backups/getBackups", nil, &resp)
+	case *restore != "":
+		err = callBackupsApi(*url, login.Jwt, http.MethodPost, "/api/backups/restoreBackup", map[string]string{"backup_id": *restore}, &resp)
+	default:
+		err = callBackupsApi(*url, login.Jwt, http.MethodPost, "/api/backups/createBackup", map[string]bool{"incremental": *incremental}, &resp)
+	}
+	if err != nil {
+		server.PrintAndDie(fmt.Sprintf("%s: backup: %s", exe, err))
+	}
+	out, _ := json.MarshalIndent(resp, "", "  ")
+	fmt.Println(string(out))
+	os.Exit(0)
+}
+
 func main() {
 	exe := "nats-server"
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate-metadata" {
+		runMigrateMetadata(exe, os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		runBackup(exe, os.Args[2:])
+	}
+
 	// Create a FlagSet and sets the usage
 	fs := flag.NewFlagSet(exe, flag.ExitOnError)
 	fs.Usage = usage
 
-	metadataDb, rootUserCreated, err := server.InitializeMetadataStorage()
+	metadataDb, _, err := server.InitializeMetadataStorage()
 	if err != nil {
 		server.PrintAndDie(fmt.Sprintf("%s: %s", exe, err))
 	}
@@ -176,14 +341,6 @@ func main() {
 		server.PrintAndDie(fmt.Sprintf("Failed initializing InitializeCloudComponents: %s: %s", exe, err))
 	}
 
-	// create users from env or config file
-	var lenUsers int
-	var errCreateUsers error
-	// create only on the first system load
-	if rootUserCreated {
-		lenUsers, errCreateUsers = server.CreateUsersFromConfigOnFirstSystemLoad()
-	}
-
 	// Configure the options from the flags/config file
 	opts, err := server.ConfigureOptions(fs, os.Args[1:],
 		server.PrintServerAndExit,
@@ -195,6 +352,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%s: configuration file %s is valid\n", exe, opts.ConfigFile)
 		os.Exit(0)
 	}
+	server.ApplyDevModeOptions(opts)
 
 	// Create the server with appropriate options.
 	s, err := server.NewServer(opts)
@@ -209,14 +367,6 @@ func main() {
 		server.PrintAndDie(err.Error())
 	}
 
-	// we do this check here and not below the function creating the users - CreateUsersFromConfigOnFirstSystemLoad because we need the s *Server for logs
-	if errCreateUsers != nil {
-		s.Warnf("[tenant: %v]Failed create users from config file %v", s.MemphisGlobalAccountString(), errCreateUsers.Error())
-	}
-	if lenUsers > 0 {
-		s.Noticef("[tenant: %v]loaded %d users from config file", s.MemphisGlobalAccountString(), lenUsers)
-	}
-
 	// Adjust MAXPROCS if running under linux/cgroups quotas.
 	undo, err := maxprocs.Set(maxprocs.Logger(s.Debugf))
 	if err != nil {
@@ -225,6 +375,7 @@ func main() {
 		defer undo()
 	}
 	s.Noticef("Established connection with the meta-data storage")
+	db.SetMetadataDbLogger(s)
 
 	runMemphis(s)
 	defer db.CloseMetadataDb(metadataDb, s)
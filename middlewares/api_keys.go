@@ -0,0 +1,104 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+)
+
+var errApiKeyScope = errors.New("api key scope does not allow this route")
+
+func HashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func isApiKey(tokenString string) bool {
+	return strings.HasPrefix(tokenString, models.ApiKeyPrefix)
+}
+
+func apiKeyScopeAllows(scopes []string, method, path string) bool {
+	// api keys are not allowed to manage users or other api keys
+	if strings.HasPrefix(path, "/api/usermgmt/") || strings.HasPrefix(path, "/api/apikeys/") {
+		return false
+	}
+	// the bulk apply and reconcile can carry users as well, so it is left to user sessions like the rest of user management
+	if strings.HasPrefix(path, "/api/resources/users/") || path == "/api/resources/apply" || path == "/api/resources/reconcile" {
+		return false
+	}
+	segments := strings.Split(path, "/")
+	isGatewayRoute := strings.HasPrefix(path, "/api/gateway/")
+	// resource routes end with the resource name rather than an action, only their method tells a read apart
+	isResourceRoute := strings.HasPrefix(path, "/api/resources/")
+	// gateway routes move messages, none of them is a read-only route
+	isReadRoute := !isGatewayRoute && (method == "GET" || (!isResourceRoute && strings.HasPrefix(segments[len(segments)-1], "get")))
+	for _, scope := range scopes {
+		switch scope {
+		case models.ApiKeyScopeReadOnly:
+			if isReadRoute {
+				return true
+			}
+		case models.ApiKeyScopeSchemaAdmin:
+			if isReadRoute || strings.HasPrefix(path, "/api/schemas/") || strings.HasPrefix(path, "/api/resources/schemas/") {
+				return true
+			}
+		case models.ApiKeyScopeStationAdmin:
+			if isReadRoute || strings.HasPrefix(path, "/api/stations/") || strings.HasPrefix(path, "/api/tags/") || strings.HasPrefix(path, "/api/resources/stations/") {
+				return true
+			}
+		case models.ApiKeyScopeCredentialsAdmin:
+			if strings.HasPrefix(path, "/api/vault/") {
+				return true
+			}
+		case models.ApiKeyScopeDataPlane:
+			if isGatewayRoute {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func verifyApiKey(key, method, path string) (models.User, error) {
+	exist, apiKey, err := db.GetApiKeyByHash(HashApiKey(key))
+	if err != nil {
+		return models.User{}, err
+	}
+	if !exist || apiKey.Revoked {
+		return models.User{}, errors.New("api key does not exist or was revoked")
+	}
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		return models.User{}, errors.New("api key expired")
+	}
+	if !apiKeyScopeAllows(apiKey.Scopes, method, path) {
+		return models.User{}, errApiKeyScope
+	}
+
+	exist, user, err := memphis_cache.GetUser(apiKey.Username, apiKey.TenantName, false)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !exist {
+		return models.User{}, errors.New("api key owner does not exist")
+	}
+
+	go db.UpdateApiKeyLastUsed(apiKey.ID, time.Now())
+	return user, nil
+}
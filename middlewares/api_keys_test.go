@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+func TestApiKeyScopeAllows(t *testing.T) {
+	cases := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{name: "read-only reads with GET", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/stations/getAllStations", want: true},
+		{name: "read-only reads through a get action", scopes: []string{models.ApiKeyScopeReadOnly}, method: "POST", path: "/api/stations/getStation", want: true},
+		{name: "read-only can not write", scopes: []string{models.ApiKeyScopeReadOnly}, method: "POST", path: "/api/stations/createStation", want: false},
+		{name: "read-only can not write a resource named get", scopes: []string{models.ApiKeyScopeReadOnly}, method: "PUT", path: "/api/resources/stations/getter", want: false},
+		{name: "read-only can not use the gateway", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/gateway/stations/fetch", want: false},
+		{name: "schema-admin writes schemas", scopes: []string{models.ApiKeyScopeSchemaAdmin}, method: "POST", path: "/api/schemas/createNewSchema", want: true},
+		{name: "schema-admin writes schema resources", scopes: []string{models.ApiKeyScopeSchemaAdmin}, method: "PUT", path: "/api/resources/schemas/orders", want: true},
+		{name: "schema-admin can not write stations", scopes: []string{models.ApiKeyScopeSchemaAdmin}, method: "POST", path: "/api/stations/createStation", want: false},
+		{name: "station-admin writes stations", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "DELETE", path: "/api/stations/removeStation", want: true},
+		{name: "station-admin writes tags", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/tags/createNewTag", want: true},
+		{name: "station-admin can not write schemas", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/schemas/createNewSchema", want: false},
+		{name: "credentials-admin writes the vault", scopes: []string{models.ApiKeyScopeCredentialsAdmin}, method: "POST", path: "/api/vault/createSecret", want: true},
+		{name: "credentials-admin can not read stations", scopes: []string{models.ApiKeyScopeCredentialsAdmin}, method: "GET", path: "/api/stations/getAllStations", want: false},
+		{name: "data-plane uses the gateway", scopes: []string{models.ApiKeyScopeDataPlane}, method: "POST", path: "/api/gateway/stations/produce", want: true},
+		{name: "data-plane can not read stations", scopes: []string{models.ApiKeyScopeDataPlane}, method: "GET", path: "/api/stations/getAllStations", want: false},
+		{name: "read-only reads resources with GET", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/resources/stations/orders", want: true},
+		{name: "read-only can not post to a resource named get", scopes: []string{models.ApiKeyScopeReadOnly}, method: "POST", path: "/api/resources/stations/getOrders", want: false},
+		{name: "schema-admin reads stations", scopes: []string{models.ApiKeyScopeSchemaAdmin}, method: "POST", path: "/api/stations/getStation", want: true},
+		{name: "station-admin writes station resources", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "DELETE", path: "/api/resources/stations/orders", want: true},
+		{name: "station-admin can not write schema resources", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "PUT", path: "/api/resources/schemas/orders", want: false},
+		{name: "station-admin can not use the gateway", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/gateway/transaction", want: false},
+		{name: "station-admin needs the whole path segment", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/stationsync/createStation", want: false},
+		{name: "credentials-admin renews credentials", scopes: []string{models.ApiKeyScopeCredentialsAdmin}, method: "POST", path: "/api/vault/renewCredentials", want: true},
+		{name: "credentials-admin can not use the gateway", scopes: []string{models.ApiKeyScopeCredentialsAdmin}, method: "POST", path: "/api/gateway/produce", want: false},
+		{name: "data-plane opens the gateway websocket", scopes: []string{models.ApiKeyScopeDataPlane}, method: "GET", path: "/api/gateway/ws", want: true},
+		{name: "data-plane can not write the vault", scopes: []string{models.ApiKeyScopeDataPlane}, method: "POST", path: "/api/vault/createCredentials", want: false},
+		{name: "scopes add up", scopes: []string{models.ApiKeyScopeSchemaAdmin, models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/stations/createStation", want: true},
+		{name: "no scopes", scopes: []string{}, method: "GET", path: "/api/stations/getAllStations", want: false},
+		{name: "unknown scope", scopes: []string{"admin"}, method: "GET", path: "/api/stations/getAllStations", want: false},
+		{name: "user management is never allowed", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/usermgmt/getAllUsers", want: false},
+		{name: "api keys management is never allowed", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/apikeys/createApiKey", want: false},
+		{name: "user resources are never allowed", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/resources/users/admin", want: false},
+		{name: "bulk apply is never allowed", scopes: []string{models.ApiKeyScopeStationAdmin, models.ApiKeyScopeSchemaAdmin}, method: "POST", path: "/api/resources/apply", want: false},
+		{name: "bulk apply can not be read either", scopes: []string{models.ApiKeyScopeReadOnly}, method: "GET", path: "/api/resources/apply", want: false},
+		{name: "user management is never allowed for data-plane", scopes: []string{models.ApiKeyScopeDataPlane}, method: "POST", path: "/api/usermgmt/addUser", want: false},
+		{name: "reconcile is never allowed", scopes: []string{models.ApiKeyScopeStationAdmin}, method: "POST", path: "/api/resources/reconcile", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := apiKeyScopeAllows(tc.scopes, tc.method, tc.path); got != tc.want {
+				t.Fatalf("apiKeyScopeAllows(%v, %v, %v) = %v, want %v", tc.scopes, tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+}
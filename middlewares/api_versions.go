@@ -0,0 +1,42 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ApiV1Prefix is the prefix of the stable api. Within a version routes, request fields and response fields are
+	// only ever added, anything breaking goes to the next version
+	ApiV1Prefix = "/api/v1"
+	// LegacyApiPrefix serves the same handlers as the latest version for clients written before versioning
+	LegacyApiPrefix = "/api"
+)
+
+// unversionedApiPath maps a versioned path to its legacy form, the access rules are written against those
+func unversionedApiPath(path string) string {
+	if strings.HasPrefix(path, ApiV1Prefix+"/") {
+		return LegacyApiPrefix + strings.TrimPrefix(path, ApiV1Prefix)
+	}
+	return path
+}
+
+// DeprecatedApi marks the responses of the unversioned routes as deprecated and points to their versioned successor
+func DeprecatedApi(c *gin.Context) {
+	successor := ApiV1Prefix + strings.TrimPrefix(c.Request.URL.Path, LegacyApiPrefix)
+	c.Header("Deprecation", "true")
+	c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+	c.Next()
+}
@@ -39,6 +39,8 @@ var noNeedAuthRoutes = []string{
 
 var refreshTokenRoute string = "/api/usermgmt/refreshtoken"
 
+var gatewayWSRoute string = "/api/gateway/ws"
+
 var configuration = conf.GetConfig()
 
 func isAuthNeeded(path string) bool {
@@ -51,6 +53,11 @@ func isAuthNeeded(path string) bool {
 	return true
 }
 
+// IsPublicRoute reports whether a route of the api is served without authentication
+func IsPublicRoute(path string) bool {
+	return !isAuthNeeded(unversionedApiPath(strings.ToLower(path)))
+}
+
 func extractToken(authHeader string) (string, error) {
 	if authHeader == "" {
 		return "", errors.New("unsupported auth header")
@@ -65,7 +72,7 @@ func extractToken(authHeader string) (string, error) {
 	return tokenString, nil
 }
 
-func verifyToken(tokenString string, secret string) (models.User, error) {
+func verifyToken(tokenString string, secret string) (models.User, string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("verifyToken: unexpected signing method: %v", token.Header["alg"])
@@ -73,12 +80,17 @@ func verifyToken(tokenString string, secret string) (models.User, error) {
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, "", err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok && !token.Valid {
-		return models.User{}, err
+		return models.User{}, "", err
+	}
+	// tokens issued before the sessions registry carry no session and are refused
+	sessionID, ok := claims["session_id"].(string)
+	if !ok || sessionID == "" {
+		return models.User{}, "", errors.New("verifyToken: token has no session")
 	}
 
 	if claims["tenant_name"] == nil {
@@ -97,24 +109,38 @@ func verifyToken(tokenString string, secret string) (models.User, error) {
 		TenantName:      claims["tenant_name"].(string),
 	}
 
-	return user, nil
+	return user, sessionID, nil
 }
 
 func Authenticate(c *gin.Context) {
-	path := strings.ToLower(c.Request.URL.Path)
+	path := unversionedApiPath(strings.ToLower(c.Request.URL.Path))
 	needToAuthenticate := isAuthNeeded(path)
 	var tokenString string
 	var err error
 	var user models.User
+	var sessionID string
 	shouldCheckUser := false
 	if needToAuthenticate {
-		tokenString, err = extractToken(c.GetHeader("authorization"))
+		authHeader := c.GetHeader("authorization")
+		// browsers can not set headers on a websocket handshake, so the gateway socket also takes the token as a query param
+		if authHeader == "" && path == gatewayWSRoute {
+			authHeader = "Bearer " + c.Query("token")
+		}
+		tokenString, err = extractToken(authHeader)
 		if err != nil || tokenString == "" {
 			c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
 			return
 		}
 
-		user, err = verifyToken(tokenString, configuration.JWT_SECRET)
+		if isApiKey(tokenString) {
+			user, err = verifyApiKey(tokenString, c.Request.Method, path)
+			if err == errApiKeyScope {
+				c.AbortWithStatusJSON(403, gin.H{"message": "Forbidden"})
+				return
+			}
+		} else {
+			user, sessionID, err = verifyToken(tokenString, configuration.JWT_SECRET)
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
 			return
@@ -128,7 +154,7 @@ func Authenticate(c *gin.Context) {
 			return
 		}
 
-		user, err = verifyToken(tokenString, configuration.REFRESH_JWT_SECRET)
+		user, sessionID, err = verifyToken(tokenString, configuration.REFRESH_JWT_SECRET)
 		if err != nil {
 			c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
 			return
@@ -154,6 +180,19 @@ func Authenticate(c *gin.Context) {
 		}
 	}
 
+	if sessionID != "" {
+		err = verifySession(sessionID, user)
+		if err == errSessionRevoked {
+			c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		c.Set("session_id", sessionID)
+	}
+
 	c.Set("user", user)
 	c.Next()
 }
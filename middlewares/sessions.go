@@ -0,0 +1,113 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package middlewares
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/conf"
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+// sessionLastSeenInterval bounds the writes of the last seen time since the UI polls many routes
+const sessionLastSeenInterval = time.Minute
+
+// verified sessions are trusted for sessionCacheTTL without reading them again, so a session revoked on another
+// broker stays usable on this one for at most that long
+const (
+	sessionCacheTTL  = 10 * time.Second
+	sessionCacheSize = 10000
+)
+
+var errSessionRevoked = errors.New("session was revoked or expired")
+
+type cachedSession struct {
+	session  models.Session
+	cachedAt time.Time
+}
+
+var sessionsCache = struct {
+	sync.Mutex
+	entries map[string]cachedSession
+}{entries: map[string]cachedSession{}}
+
+func sessionMatches(session models.Session, user models.User, now time.Time) bool {
+	return session.ExpiresAt.After(now) && session.Username == strings.ToLower(user.Username) && session.TenantName == user.TenantName
+}
+
+func cachedSessionValid(sessionID string, user models.User, now time.Time) bool {
+	sessionsCache.Lock()
+	defer sessionsCache.Unlock()
+	entry, ok := sessionsCache.entries[sessionID]
+	if !ok {
+		return false
+	}
+	if now.Sub(entry.cachedAt) > sessionCacheTTL {
+		delete(sessionsCache.entries, sessionID)
+		return false
+	}
+	return sessionMatches(entry.session, user, now)
+}
+
+func cacheSession(session models.Session, now time.Time) {
+	sessionsCache.Lock()
+	defer sessionsCache.Unlock()
+	if len(sessionsCache.entries) >= sessionCacheSize {
+		for id, entry := range sessionsCache.entries {
+			if now.Sub(entry.cachedAt) > sessionCacheTTL {
+				delete(sessionsCache.entries, id)
+			}
+		}
+		if len(sessionsCache.entries) >= sessionCacheSize {
+			return
+		}
+	}
+	sessionsCache.entries[session.ID] = cachedSession{session: session, cachedAt: now}
+}
+
+// ForgetUserSessions drops the cached sessions of a user, it is called when sessions of the user are revoked
+func ForgetUserSessions(username, tenantName string) {
+	username = strings.ToLower(username)
+	if tenantName != conf.GlobalAccount {
+		tenantName = strings.ToLower(tenantName)
+	}
+	sessionsCache.Lock()
+	defer sessionsCache.Unlock()
+	for id, entry := range sessionsCache.entries {
+		if entry.session.Username == username && entry.session.TenantName == tenantName {
+			delete(sessionsCache.entries, id)
+		}
+	}
+}
+
+func verifySession(sessionID string, user models.User) error {
+	now := time.Now()
+	if cachedSessionValid(sessionID, user, now) {
+		return nil
+	}
+	exist, session, err := db.GetSessionByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if !exist || !sessionMatches(session, user, now) {
+		return errSessionRevoked
+	}
+	if now.Sub(session.LastSeenAt) > sessionLastSeenInterval {
+		go db.UpdateSessionLastSeen(session.ID, now)
+	}
+	cacheSession(session, now)
+	return nil
+}
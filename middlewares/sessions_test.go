@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"testing"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+func TestCachedSessionValid(t *testing.T) {
+	now := time.Now()
+	user := models.User{Username: "Alice", TenantName: "tenant"}
+	session := models.Session{ID: "session", Username: "alice", TenantName: "tenant", ExpiresAt: now.Add(time.Hour)}
+
+	cases := []struct {
+		name    string
+		session models.Session
+		user    models.User
+		at      time.Time
+		want    bool
+	}{
+		{name: "cached session", session: session, user: user, at: now, want: true},
+		{name: "cache entry is too old", session: session, user: user, at: now.Add(sessionCacheTTL + time.Second), want: false},
+		{name: "session expired", session: models.Session{ID: "session", Username: "alice", TenantName: "tenant", ExpiresAt: now.Add(time.Second)}, user: user, at: now.Add(2 * time.Second), want: false},
+		{name: "session of another user", session: session, user: models.User{Username: "bob", TenantName: "tenant"}, at: now, want: false},
+		{name: "session of another tenant", session: session, user: models.User{Username: "alice", TenantName: "other"}, at: now, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ForgetUserSessions(tc.session.Username, tc.session.TenantName)
+			cacheSession(tc.session, now)
+			if got := cachedSessionValid(tc.session.ID, tc.user, tc.at); got != tc.want {
+				t.Fatalf("cachedSessionValid = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	cacheSession(session, now)
+	ForgetUserSessions("ALICE", "tenant")
+	if cachedSessionValid(session.ID, user, now) {
+		t.Fatalf("the session of a user that was forgotten is still cached")
+	}
+}
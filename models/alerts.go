@@ -0,0 +1,85 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	AlertTypeDlsDepth                    = "dls_depth"
+	AlertTypeConsumerLag                 = "consumer_lag"
+	AlertTypeSchemaValidationFailureRate = "schema_validation_failure_rate"
+	AlertTypeComponentDown               = "component_down"
+
+	AlertChannelSlack     = "slack"
+	AlertChannelPagerDuty = "pagerduty"
+	AlertChannelOpsgenie  = "opsgenie"
+	AlertChannelWebhook   = "webhook"
+
+	AlertStateOk     = "ok"
+	AlertStateFiring = "firing"
+)
+
+type AlertChannel struct {
+	Type       string `json:"type"`
+	Url        string `json:"url,omitempty"`
+	RoutingKey string `json:"routing_key,omitempty"`
+	ApiKey     string `json:"api_key,omitempty"`
+}
+
+type AlertRule struct {
+	ID              int            `json:"id"`
+	Name            string         `json:"name"`
+	AlertType       string         `json:"alert_type"`
+	StationName     string         `json:"station_name"`
+	Threshold       float64        `json:"threshold"`
+	Channels        []AlertChannel `json:"channels"`
+	Enabled         bool           `json:"enabled"`
+	State           string         `json:"state"`
+	LastTriggeredAt *time.Time     `json:"last_triggered_at"`
+	CreatedBy       string         `json:"created_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	TenantName      string         `json:"tenant_name"`
+}
+
+type CreateAlertRuleSchema struct {
+	Name        string         `json:"name" binding:"required,min=1,max=128"`
+	AlertType   string         `json:"alert_type" binding:"required"`
+	StationName string         `json:"station_name"`
+	Threshold   float64        `json:"threshold" binding:"min=0"`
+	Channels    []AlertChannel `json:"channels" binding:"required,min=1"`
+}
+
+type UpdateAlertRuleSchema struct {
+	ID        int            `json:"id" binding:"required"`
+	Name      string         `json:"name" binding:"max=128"`
+	Threshold *float64       `json:"threshold"`
+	Channels  []AlertChannel `json:"channels"`
+	Enabled   *bool          `json:"enabled"`
+}
+
+type RemoveAlertRuleSchema struct {
+	ID int `json:"id" binding:"required"`
+}
+
+type AlertEvent struct {
+	RuleID      int       `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	AlertType   string    `json:"alert_type"`
+	State       string    `json:"state"`
+	StationName string    `json:"station_name,omitempty"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"`
+	Message     string    `json:"message"`
+	TenantName  string    `json:"tenant_name"`
+	Time        time.Time `json:"time"`
+}
@@ -0,0 +1,69 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	AmqpBridgeInbound  = "inbound"
+	AmqpBridgeOutbound = "outbound"
+)
+
+type AmqpBridge struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Direction   string    `json:"direction"`
+	Url         string    `json:"-"`
+	Queue       string    `json:"queue"`
+	Exchange    string    `json:"exchange"`
+	RoutingKey  string    `json:"routing_key"`
+	StationName string    `json:"station_name"`
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	TenantName  string    `json:"tenant_name"`
+}
+
+type AmqpBridgeStatus struct {
+	Connected       bool      `json:"connected"`
+	MessagesBridged uint64    `json:"messages_bridged"`
+	Errors          uint64    `json:"errors"`
+	Reconnects      uint64    `json:"reconnects"`
+	LastError       string    `json:"last_error"`
+	LastErrorAt     time.Time `json:"last_error_at"`
+	LastConnectedAt time.Time `json:"last_connected_at"`
+	LastMessageAt   time.Time `json:"last_message_at"`
+}
+
+type ExtendedAmqpBridge struct {
+	AmqpBridge
+	Status AmqpBridgeStatus `json:"status"`
+}
+
+type CreateAmqpBridgeSchema struct {
+	Name        string `json:"name" binding:"required,min=1,max=128"`
+	Direction   string `json:"direction" binding:"required"`
+	Url         string `json:"url" binding:"required"`
+	Queue       string `json:"queue"`
+	Exchange    string `json:"exchange"`
+	RoutingKey  string `json:"routing_key"`
+	StationName string `json:"station_name" binding:"required"`
+}
+
+type UpdateAmqpBridgeSchema struct {
+	ID      int   `json:"id" binding:"required"`
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+type RemoveAmqpBridgeSchema struct {
+	ID int `json:"id" binding:"required"`
+}
@@ -23,8 +23,27 @@ type AuditLog struct {
 	CreatedByUsername string    `json:"created_by_username"`
 	CreatedAt         time.Time `json:"created_at"`
 	TenantName        string    `json:"tenant_name"`
+	ActorIp           string    `json:"actor_ip"`
+	RequestId         string    `json:"request_id"`
 }
 
 type GetAllAuditLogsByStationSchema struct {
 	StationName string `form:"station_name" binding:"required"`
 }
+
+type SearchAuditLogsSchema struct {
+	StationName string    `form:"station_name"`
+	Actor       string    `form:"actor"`
+	Search      string    `form:"search"`
+	From        time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page        int       `form:"page" binding:"min=0"`
+	PageSize    int       `form:"page_size" binding:"min=0,max=1000"`
+}
+
+type SearchAuditLogsResponse struct {
+	AuditLogs []AuditLog `json:"audit_logs"`
+	Total     int        `json:"total"`
+	Page      int        `json:"page"`
+	PageSize  int        `json:"page_size"`
+}
@@ -0,0 +1,68 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type BackupManifest struct {
+	ID          string                `json:"id"`
+	ParentID    string                `json:"parent_id"`
+	Incremental bool                  `json:"incremental"`
+	Status      string                `json:"status"`
+	Error       string                `json:"error"`
+	CreatedBy   string                `json:"created_by"`
+	CreatedAt   time.Time             `json:"created_at"`
+	CompletedAt time.Time             `json:"completed_at"`
+	Tables      []MetadataTableBackup `json:"tables"`
+	Streams     []StreamBackup        `json:"streams"`
+}
+
+type MetadataTableBackup struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+type StreamBackup struct {
+	TenantName string          `json:"tenant_name"`
+	Name       string          `json:"name"`
+	Config     json.RawMessage `json:"config"`
+	FirstSeq   uint64          `json:"first_seq"`
+	LastSeq    uint64          `json:"last_seq"`
+	Messages   int             `json:"messages"`
+	Chunks     []string        `json:"chunks"`
+}
+
+type BackupOperation struct {
+	Type       string    `json:"type"`
+	BackupID   string    `json:"backup_id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type CreateBackupSchema struct {
+	Incremental bool `json:"incremental"`
+}
+
+type RestoreBackupSchema struct {
+	BackupID string `json:"backup_id" binding:"required"`
+}
+
+type GetBackupsResponse struct {
+	Backups   []BackupManifest `json:"backups"`
+	Operation *BackupOperation `json:"operation"`
+}
@@ -0,0 +1,101 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	CdcSourcePostgres = "postgres"
+	CdcSourceMongoDB  = "mongodb"
+
+	CdcOpCreate   = "c"
+	CdcOpUpdate   = "u"
+	CdcOpDelete   = "d"
+	CdcOpTruncate = "t"
+
+	MessageTransformNone           = ""
+	MessageTransformDebeziumUnwrap = "debezium_unwrap"
+	MessageTransformDebeziumWrap   = "debezium_wrap"
+)
+
+type CdcConnector struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	SourceType    string    `json:"source_type"`
+	ConnectionUrl string    `json:"-"`
+	Database      string    `json:"database"`
+	Tables        []string  `json:"tables"`
+	SlotName      string    `json:"slot_name"`
+	Position      string    `json:"-"`
+	StationName   string    `json:"station_name"`
+	Enabled       bool      `json:"enabled"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	TenantName    string    `json:"tenant_name"`
+	Transform     string    `json:"transform"`
+}
+
+type CdcConnectorStatus struct {
+	Connected      bool      `json:"connected"`
+	EventsProduced uint64    `json:"events_produced"`
+	Errors         uint64    `json:"errors"`
+	Reconnects     uint64    `json:"reconnects"`
+	Position       string    `json:"position"`
+	LastError      string    `json:"last_error"`
+	LastErrorAt    time.Time `json:"last_error_at"`
+	LastEventAt    time.Time `json:"last_event_at"`
+}
+
+type ExtendedCdcConnector struct {
+	CdcConnector
+	Status CdcConnectorStatus `json:"status"`
+}
+
+type CdcEventSource struct {
+	Connector string `json:"connector"`
+	Type      string `json:"type"`
+	Database  string `json:"database"`
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table"`
+	Position  string `json:"position"`
+}
+
+type CdcChangeEvent struct {
+	Op     string          `json:"op"`
+	TsMs   int64           `json:"ts_ms"`
+	Source CdcEventSource  `json:"source"`
+	Key    json.RawMessage `json:"key"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+type CreateCdcConnectorSchema struct {
+	Name          string   `json:"name" binding:"required,min=1,max=128"`
+	SourceType    string   `json:"source_type" binding:"required"`
+	ConnectionUrl string   `json:"connection_url" binding:"required"`
+	Database      string   `json:"database"`
+	Tables        []string `json:"tables"`
+	StationName   string   `json:"station_name" binding:"required"`
+	Transform     string   `json:"transform"`
+}
+
+type UpdateCdcConnectorSchema struct {
+	ID      int   `json:"id" binding:"required"`
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+type RemoveCdcConnectorSchema struct {
+	ID int `json:"id" binding:"required"`
+}
@@ -0,0 +1,62 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	CommentEntityStation = "station"
+	CommentEntitySchema  = "schema"
+)
+
+type Comment struct {
+	ID                int       `json:"id"`
+	EntityType        string    `json:"entity_type"`
+	EntityID          int       `json:"entity_id"`
+	VersionNumber     int       `json:"version_number,omitempty"`
+	ParentID          *int      `json:"parent_id,omitempty"`
+	Body              string    `json:"body"`
+	Mentions          []string  `json:"mentions"`
+	CreatedByUsername string    `json:"created_by_username"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	TenantName        string    `json:"tenant_name"`
+}
+
+// CommentThread is a top level comment together with its replies, oldest first
+type CommentThread struct {
+	Comment
+	Replies []Comment `json:"replies"`
+}
+
+type CreateCommentSchema struct {
+	EntityType    string `json:"entity_type" binding:"required"`
+	EntityName    string `json:"entity_name" binding:"required"`
+	VersionNumber int    `json:"version_number" binding:"min=0"`
+	ParentID      int    `json:"parent_id" binding:"min=0"`
+	Body          string `json:"body" binding:"required"`
+}
+
+type GetCommentsSchema struct {
+	EntityType    string `form:"entity_type" json:"entity_type" binding:"required"`
+	EntityName    string `form:"entity_name" json:"entity_name" binding:"required"`
+	VersionNumber int    `form:"version_number" json:"version_number" binding:"min=0"`
+}
+
+type EditCommentSchema struct {
+	ID   int    `json:"id" binding:"required"`
+	Body string `json:"body" binding:"required"`
+}
+
+type RemoveCommentSchema struct {
+	ID int `json:"id" binding:"required"`
+}
@@ -12,7 +12,8 @@
 package models
 
 type GlobalConfigurationsUpdate struct {
-	Notifications bool `json:"notifications"`
+	Notifications        bool   `json:"notifications"`
+	ScramServerSignature string `json:"scram_server_signature,omitempty"`
 }
 
 type SdkClientsUpdates struct {
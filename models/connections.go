@@ -0,0 +1,64 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+type Connection struct {
+	ConnectionId  string             `json:"connection_id"`
+	Broker        string             `json:"broker"`
+	ClientAddress string             `json:"client_address"`
+	Username      string             `json:"username"`
+	Sdk           string             `json:"sdk"`
+	SdkVersion    string             `json:"sdk_version"`
+	ConnectedAt   time.Time          `json:"connected_at"`
+	InMsgs        int64              `json:"in_msgs"`
+	OutMsgs       int64              `json:"out_msgs"`
+	InThroughput  Throughput         `json:"in_throughput"`
+	OutThroughput Throughput         `json:"out_throughput"`
+	Stats         ConnectionStats    `json:"stats"`
+	Producers     []ConnectionClient `json:"producers"`
+	Consumers     []ConnectionClient `json:"consumers"`
+}
+
+// ConnectionStats are the counters a broker keeps for a connection id, they survive reconnects of the SDK
+type ConnectionStats struct {
+	ConnectionId   string    `json:"connection_id"`
+	Broker         string    `json:"broker"`
+	Username       string    `json:"username"`
+	InMsgs         int64     `json:"in_msgs"`
+	InBytes        int64     `json:"in_bytes"`
+	OutMsgs        int64     `json:"out_msgs"`
+	OutBytes       int64     `json:"out_bytes"`
+	InBytesPerSec  int64     `json:"in_bytes_per_sec"`
+	OutBytesPerSec int64     `json:"out_bytes_per_sec"`
+	Errors         int64     `json:"errors"`
+	LastError      string    `json:"last_error"`
+	LastErrorAt    time.Time `json:"last_error_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	TenantName     string    `json:"tenant_name"`
+}
+
+type GetConnectionsSchema struct {
+	Username    string `form:"username" json:"username"`
+	StationName string `form:"station_name" json:"station_name"`
+}
+
+type DisconnectConnectionSchema struct {
+	ConnectionId string `json:"connection_id" binding:"required"`
+}
+
+type DisconnectConnectionResponse struct {
+	ConnectionId        string `json:"connection_id"`
+	ClosedConnections   int    `json:"closed_connections"`
+	DisconnectedClients int    `json:"disconnected_clients"`
+}
@@ -33,6 +33,7 @@ type Consumer struct {
 	Version             int       `json:"version"`
 	Sdk                 string    `json:"sdk"`
 	AppId               string    `json:"app_id"`
+	ClientId            string    `json:"client_id"`
 }
 
 type ExtendedConsumer struct {
@@ -142,3 +143,46 @@ type ConsumerForGraph struct {
 	AppId     string `json:"app_id"`
 	Type      string `json:"type"`
 }
+
+type CgRebalance struct {
+	ID            int       `json:"id"`
+	StationId     int       `json:"station_id"`
+	CgName        string    `json:"cg_name"`
+	Reason        string    `json:"reason"`
+	ConsumerName  string    `json:"consumer_name"`
+	ConnectionId  string    `json:"connection_id"`
+	ActiveMembers int       `json:"active_members"`
+	CreatedAt     time.Time `json:"created_at"`
+	TenantName    string    `json:"tenant_name"`
+}
+
+type GetCgRebalanceInfoSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	CgName      string `form:"cg_name" json:"cg_name" binding:"required"`
+}
+
+type CgMemberAssignment struct {
+	Name         string `json:"name"`
+	ConnectionId string `json:"connection_id"`
+	IsActive     bool   `json:"is_active"`
+	Partitions   []int  `json:"partitions"`
+}
+
+type CgPartitionAssignment struct {
+	Partition      int      `json:"partition"`
+	Members        []string `json:"members"`
+	NumPending     uint64   `json:"num_pending"`
+	NumAckPending  int      `json:"num_ack_pending"`
+	NumWaiting     int      `json:"num_waiting"`
+	NumRedelivered int      `json:"num_redelivered"`
+}
+
+type CgRebalanceInfo struct {
+	StationName     string                  `json:"station_name"`
+	CgName          string                  `json:"cg_name"`
+	ActiveMembers   int                     `json:"active_members"`
+	Members         []CgMemberAssignment    `json:"members"`
+	Partitions      []CgPartitionAssignment `json:"partitions"`
+	LastRebalanceAt *time.Time              `json:"last_rebalance_at"`
+	History         []CgRebalance           `json:"history"`
+}
@@ -154,3 +154,19 @@ type DlsMessageResponseWithFunc struct {
 	ValidationError string              `json:"validation_error"`
 	FunctionName    string              `json:"function_name"`
 }
+
+type DlsMessagesCount struct {
+	TenantName  string `json:"tenant_name"`
+	StationName string `json:"station_name"`
+	MessageType string `json:"message_type"`
+	Count       int    `json:"count"`
+}
+
+type ResendDlsMessagesToStationSchema struct {
+	StationName       string `json:"station_name" binding:"required"`
+	TargetStationName string `json:"target_station_name" binding:"required"`
+	DlsMessageIds     []int  `json:"dls_message_ids"`
+	Transform         string `json:"transform"`
+	ValidateSchema    bool   `json:"validate_schema"`
+	RemoveResent      bool   `json:"remove_resent"`
+}
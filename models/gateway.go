@@ -0,0 +1,70 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+type GatewayProduceSchema struct {
+	StationName string            `json:"station_name" binding:"required"`
+	Message     string            `json:"message" binding:"required"`
+	Headers     map[string]string `json:"headers"`
+}
+
+type GatewayProduceResponse struct {
+	Sequence  uint64 `json:"sequence"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+type GatewayFetchSchema struct {
+	StationName   string `json:"station_name" binding:"required"`
+	ConsumerGroup string `json:"consumer_group" binding:"required"`
+	BatchSize     int    `json:"batch_size"`
+	WaitMs        int    `json:"wait_ms"`
+}
+
+type GatewayMessage struct {
+	AckId   string            `json:"ack_id"`
+	Data    string            `json:"data"`
+	Headers map[string]string `json:"headers"`
+}
+
+type GatewayAckSchema struct {
+	AckIds []string `json:"ack_ids" binding:"required,min=1"`
+}
+
+type GatewayTransactionSchema struct {
+	AckIds  []string               `json:"ack_ids" binding:"required,min=1"`
+	Produce []GatewayProduceSchema `json:"produce" binding:"dive"`
+}
+
+type GatewayTransactionResponse struct {
+	TransactionId string                   `json:"transaction_id"`
+	Produced      []GatewayProduceResponse `json:"produced"`
+}
+
+type GatewayWSRequest struct {
+	Type          string            `json:"type"`
+	Id            string            `json:"id"`
+	StationName   string            `json:"station_name"`
+	ConsumerGroup string            `json:"consumer_group"`
+	Message       string            `json:"message"`
+	Headers       map[string]string `json:"headers"`
+	BatchSize     int               `json:"batch_size"`
+	AckIds        []string          `json:"ack_ids"`
+}
+
+type GatewayWSResponse struct {
+	Type         string          `json:"type"`
+	Id           string          `json:"id,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Sequence     uint64          `json:"sequence,omitempty"`
+	Subscription string          `json:"subscription,omitempty"`
+	Message      *GatewayMessage `json:"message,omitempty"`
+}
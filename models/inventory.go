@@ -0,0 +1,109 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+type InventoryStation struct {
+	Name              string    `json:"name"`
+	RetentionType     string    `json:"retention_type"`
+	RetentionValue    int       `json:"retention_value"`
+	StorageType       string    `json:"storage_type"`
+	Replicas          int       `json:"replicas"`
+	PartitionsList    []int     `json:"partitions_list"`
+	SchemaName        string    `json:"schema_name"`
+	DlsStation        string    `json:"dls_station"`
+	IsNative          bool      `json:"is_native"`
+	CreatedByUsername string    `json:"created_by_username"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	ActiveProducers   int       `json:"active_producers"`
+	ActiveConsumers   int       `json:"active_consumers"`
+	ConsumerGroups    int       `json:"consumer_groups"`
+	Healthy           bool      `json:"healthy"`
+	Issues            []string  `json:"issues"`
+}
+
+type InventorySchema struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	CreatedByUsername string `json:"created_by_username"`
+	ActiveVersion     int    `json:"active_version"`
+	VersionsCount     int    `json:"versions_count"`
+	UsedByStations    int    `json:"used_by_stations"`
+}
+
+type InventoryProducer struct {
+	Name         string    `json:"name"`
+	StationName  string    `json:"station_name"`
+	Type         string    `json:"type"`
+	ConnectionId string    `json:"connection_id"`
+	IsActive     bool      `json:"is_active"`
+	Sdk          string    `json:"sdk"`
+	AppId        string    `json:"app_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type InventoryConsumer struct {
+	Name           string    `json:"name"`
+	ConsumersGroup string    `json:"consumers_group"`
+	StationName    string    `json:"station_name"`
+	Type           string    `json:"type"`
+	ConnectionId   string    `json:"connection_id"`
+	IsActive       bool      `json:"is_active"`
+	Sdk            string    `json:"sdk"`
+	AppId          string    `json:"app_id"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type InventoryUser struct {
+	Username  string    `json:"username"`
+	UserType  string    `json:"user_type"`
+	FullName  string    `json:"full_name"`
+	Team      string    `json:"team"`
+	Pending   bool      `json:"pending"`
+	CreatedAt time.Time `json:"created_at"`
+	LastLogin time.Time `json:"last_login"`
+}
+
+type InventoryIntegration struct {
+	Name    string   `json:"name"`
+	IsValid bool     `json:"is_valid"`
+	Healthy bool     `json:"healthy"`
+	Issues  []string `json:"issues"`
+}
+
+type InventoryCounts struct {
+	Stations          int `json:"stations"`
+	UnhealthyStations int `json:"unhealthy_stations"`
+	Schemas           int `json:"schemas"`
+	Producers         int `json:"producers"`
+	ActiveProducers   int `json:"active_producers"`
+	Consumers         int `json:"consumers"`
+	ActiveConsumers   int `json:"active_consumers"`
+	Users             int `json:"users"`
+	Integrations      int `json:"integrations"`
+}
+
+// Inventory is a snapshot of a tenant's resources, everything besides the health flags is read in a single
+// repeatable read transaction
+type Inventory struct {
+	TenantName   string                 `json:"tenant_name"`
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Counts       InventoryCounts        `json:"counts"`
+	Stations     []InventoryStation     `json:"stations"`
+	Schemas      []InventorySchema      `json:"schemas"`
+	Producers    []InventoryProducer    `json:"producers"`
+	Consumers    []InventoryConsumer    `json:"consumers"`
+	Users        []InventoryUser        `json:"users"`
+	Integrations []InventoryIntegration `json:"integrations"`
+}
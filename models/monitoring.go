@@ -41,6 +41,7 @@ type SystemComponents struct {
 	Ports       []int      `json:"ports"`
 	DesiredPods int        `json:"desired_pods"`
 	ActualPods  int        `json:"actual_pods"`
+	Restarts    int        `json:"restarts"`
 	Hosts       []string   `json:"hosts"`
 }
 
@@ -55,6 +56,7 @@ type SystemComponentsStatus struct {
 type GetStationOverviewDataSchema struct {
 	StationName     string `form:"station_name" json:"station_name"  binding:"required"`
 	PartitionNumber int    `form:"partition_number" json:"partition_number"  binding:"required"`
+	StationClientsFilter
 }
 
 type SystemLogsRequest struct {
@@ -108,3 +110,38 @@ type Throughput struct {
 	BytesPerSec int64 `json:"bytes_per_sec"`
 }
 
+
+type BrowseSystemLogsSchema struct {
+	LogType   string    `form:"log_type" json:"log_type"`
+	LogSource string    `form:"log_source" json:"log_source"`
+	Search    string    `form:"search" json:"search"`
+	From      time.Time `form:"from" json:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" json:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor    uint64    `form:"cursor" json:"cursor"`
+	PageSize  int       `form:"page_size" json:"page_size" binding:"omitempty,min=1,max=1000"`
+}
+
+type BrowseSystemLogsResponse struct {
+	Logs       []Log  `json:"logs"`
+	NextCursor uint64 `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+type K8sWorkload struct {
+	Name            string `json:"name"`
+	Kind            string `json:"kind"`
+	DesiredReplicas int    `json:"desired_replicas"`
+	ReadyReplicas   int    `json:"ready_replicas"`
+	UpdatedReplicas int    `json:"updated_replicas"`
+	Restarts        int    `json:"restarts"`
+}
+
+type K8sComponentsResponse struct {
+	Namespace string        `json:"namespace"`
+	Cached    bool          `json:"cached"`
+	Workloads []K8sWorkload `json:"workloads"`
+}
+
+type GetGrafanaDashboardSchema struct {
+	StationName string `form:"station_name" json:"station_name"`
+}
@@ -28,6 +28,9 @@ type Producer struct {
 	Version        int       `json:"version"`
 	Sdk            string    `json:"sdk"`
 	AppId          string    `json:"app_id"`
+	Owner          string    `json:"owner"`
+	OwnerTeam      string    `json:"owner_team"`
+	ClientId       string    `json:"client_id"`
 }
 
 type ExtendedProducer struct {
@@ -77,3 +80,13 @@ type ProducerForGraphWithCount struct {
 	AppId     string `json:"app_id"`
 	Count     int    `json:"count"`
 }
+
+type PurgedClients struct {
+	Producers  int64 `json:"deleted_producers"`
+	Consumers  int64 `json:"deleted_consumers"`
+	StationIds []int `json:"-"`
+}
+
+type PurgeStaleClientsSchema struct {
+	OlderThanHours int `json:"older_than_hours"`
+}
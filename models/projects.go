@@ -0,0 +1,96 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	ProjectKindProject     = "project"
+	ProjectKindEnvironment = "environment"
+)
+
+// Project is a node of the project -> environment -> stations hierarchy, environments have a parent project
+// and only environments hold stations. Path is "project" or "project/environment" and is unique per tenant
+type Project struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	ParentId    *int      `json:"parent_id"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags"`
+	MaxStations int       `json:"max_stations"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	TenantName  string    `json:"tenant_name"`
+}
+
+type ProjectStation struct {
+	ProjectId   int    `json:"project_id"`
+	StationId   int    `json:"station_id"`
+	StationName string `json:"station_name"`
+}
+
+type ProjectPermission struct {
+	ProjectId int    `json:"project_id"`
+	RoleName  string `json:"role_name"`
+	Type      string `json:"type"`
+}
+
+type ProjectRolePermission struct {
+	RoleName  string `json:"role_name"`
+	Type      string `json:"type"`
+	Inherited bool   `json:"inherited"`
+}
+
+type ExtendedProject struct {
+	Project
+	Kind          string                  `json:"kind"`
+	EffectiveTags []string                `json:"effective_tags"`
+	StationsCount int                     `json:"stations_count"`
+	Stations      []string                `json:"stations,omitempty"`
+	Permissions   []ProjectRolePermission `json:"permissions"`
+	Environments  []ExtendedProject       `json:"environments,omitempty"`
+}
+
+type CreateProjectSchema struct {
+	Name        string   `json:"name" binding:"required"`
+	Parent      string   `json:"parent"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	MaxStations int      `json:"max_stations"`
+}
+
+type UpdateProjectSchema struct {
+	Path        string   `json:"path" binding:"required"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	MaxStations int      `json:"max_stations"`
+}
+
+type RemoveProjectSchema struct {
+	Path string `json:"path" binding:"required"`
+}
+
+type AssignProjectStationsSchema struct {
+	Environment  string   `json:"environment" binding:"required"`
+	StationNames []string `json:"station_names" binding:"required"`
+}
+
+type UnassignProjectStationsSchema struct {
+	StationNames []string `json:"station_names" binding:"required"`
+}
+
+type ProjectPermissionSchema struct {
+	Path     string `json:"path" binding:"required"`
+	RoleName string `json:"role_name" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+}
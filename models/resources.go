@@ -0,0 +1,126 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ResourceActionCreated   = "created"
+	ResourceActionUpdated   = "updated"
+	ResourceActionUnchanged = "unchanged"
+	ResourceActionDeleted   = "deleted"
+	ResourceActionFailed    = "error"
+)
+
+type StationResource struct {
+	Name                 string            `json:"name"`
+	RetentionType        string            `json:"retention_type"`
+	RetentionValue       int               `json:"retention_value"`
+	StorageType          string            `json:"storage_type"`
+	Replicas             int               `json:"replicas"`
+	PartitionsNumber     int               `json:"partitions_number"`
+	IdempotencyWindow    int64             `json:"idempotency_window_in_ms"`
+	TieredStorageEnabled bool              `json:"tiered_storage_enabled"`
+	SchemaName           string            `json:"schema_name"`
+	DlsConfiguration     *DlsConfiguration `json:"dls_configuration"`
+	DlsStation           string            `json:"dls_station"`
+	MessageTransform     string            `json:"message_transform"`
+	Tags                 []string          `json:"tags"`
+}
+
+type SchemaResource struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	SchemaContent     string   `json:"schema_content"`
+	MessageStructName string   `json:"message_struct_name"`
+	ActiveVersion     int      `json:"active_version"`
+	Tags              []string `json:"tags"`
+}
+
+type UserResource struct {
+	Username    string `json:"username"`
+	UserType    string `json:"user_type"`
+	Password    string `json:"password,omitempty"`
+	FullName    string `json:"full_name"`
+	Team        string `json:"team"`
+	Position    string `json:"position"`
+	Description string `json:"description"`
+	AvatarId    int    `json:"avatar_id"`
+}
+
+type ApplyResourcesSchema struct {
+	Schemas  []SchemaResource  `json:"schemas"`
+	Stations []StationResource `json:"stations"`
+	Users    []UserResource    `json:"users"`
+	DryRun   bool              `json:"dry_run"`
+}
+
+type ResourceApplyResult struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	ETag   string `json:"etag,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	ResourceKindStation = "station"
+	ResourceKindSchema  = "schema"
+	ResourceKindUser    = "user"
+
+	ManagedResourcePhaseSynced   = "Synced"
+	ManagedResourcePhaseConflict = "Conflict"
+	ManagedResourcePhaseFailed   = "Failed"
+)
+
+type ManagedResource struct {
+	ID                 int       `json:"id"`
+	Kind               string    `json:"kind"`
+	Name               string    `json:"name"`
+	Owner              string    `json:"owner"`
+	Generation         int64     `json:"generation"`
+	ObservedGeneration int64     `json:"observed_generation"`
+	AppliedETag        string    `json:"applied_etag"`
+	Phase              string    `json:"phase"`
+	Message            string    `json:"message"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	TenantName         string    `json:"tenant_name"`
+}
+
+type ManagedResourceStatus struct {
+	ManagedResource
+	LiveETag string `json:"live_etag"`
+	Drifted  bool   `json:"drifted"`
+}
+
+type ReconcileResourceSchema struct {
+	Kind       string          `json:"kind" binding:"required"`
+	Name       string          `json:"name" binding:"required"`
+	Owner      string          `json:"owner" binding:"required"`
+	Generation int64           `json:"generation"`
+	Spec       json.RawMessage `json:"spec" binding:"required"`
+	Force      bool            `json:"force"`
+}
+
+type RemoveManagedResourceSchema struct {
+	Kind  string `json:"kind" binding:"required"`
+	Name  string `json:"name" binding:"required"`
+	Owner string `json:"owner" binding:"required"`
+}
+
+type WatchManagedResourcesResponse struct {
+	ResourceVersion string                  `json:"resource_version"`
+	Resources       []ManagedResourceStatus `json:"resources"`
+}
@@ -0,0 +1,88 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	SchemaRegistryConfluent = "confluent"
+	SchemaRegistryApicurio  = "apicurio"
+)
+
+// conflict policies decide what happens to a subject which exists on both sides with different content
+const (
+	SchemaRegistryConflictSkip       = "skip"
+	SchemaRegistryConflictRemoteWins = "remote_wins"
+	SchemaRegistryConflictLocalWins  = "local_wins"
+)
+
+type SchemaRegistrySync struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Url            string    `json:"url"`
+	Username       string    `json:"username"`
+	Password       string    `json:"-"`
+	SubjectPrefix  string    `json:"subject_prefix"`
+	ConflictPolicy string    `json:"conflict_policy"`
+	PushBack       bool      `json:"push_back"`
+	IntervalSec    int       `json:"interval_sec"`
+	Enabled        bool      `json:"enabled"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	TenantName     string    `json:"tenant_name"`
+}
+
+type SchemaRegistrySyncStatus struct {
+	Syncing     bool      `json:"syncing"`
+	Syncs       uint64    `json:"syncs"`
+	Errors      uint64    `json:"errors"`
+	Imported    int       `json:"imported"`
+	Pushed      int       `json:"pushed"`
+	Conflicts   []string  `json:"conflicts"`
+	LastSyncAt  time.Time `json:"last_sync_at"`
+	LastError   string    `json:"last_error"`
+	LastErrorAt time.Time `json:"last_error_at"`
+}
+
+type ExtendedSchemaRegistrySync struct {
+	SchemaRegistrySync
+	Status SchemaRegistrySyncStatus `json:"status"`
+}
+
+type CreateSchemaRegistrySyncSchema struct {
+	Name           string `json:"name" binding:"required,min=1,max=128"`
+	Type           string `json:"type" binding:"required"`
+	Url            string `json:"url" binding:"required"`
+	Username       string `json:"username"`
+	Password       string `json:"password"`
+	SubjectPrefix  string `json:"subject_prefix"`
+	ConflictPolicy string `json:"conflict_policy"`
+	PushBack       bool   `json:"push_back"`
+	IntervalSec    int    `json:"interval_sec"`
+}
+
+type UpdateSchemaRegistrySyncSchema struct {
+	ID             int     `json:"id" binding:"required"`
+	Enabled        *bool   `json:"enabled"`
+	IntervalSec    *int    `json:"interval_sec"`
+	ConflictPolicy *string `json:"conflict_policy"`
+	PushBack       *bool   `json:"push_back"`
+}
+
+type RemoveSchemaRegistrySyncSchema struct {
+	ID int `json:"id" binding:"required"`
+}
+
+type RunSchemaRegistrySyncSchema struct {
+	ID int `json:"id" binding:"required"`
+}
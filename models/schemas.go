@@ -21,6 +21,7 @@ type Schema struct {
 	Type              string `json:"type"`
 	CreatedByUsername string `json:"created_by_username"`
 	TenantName        string `json:"tenant_name"`
+	OwnerTeam         string `json:"owner_team"`
 }
 
 type SchemaVersion struct {
@@ -57,6 +58,8 @@ type CreateNewSchema struct {
 	SchemaContent     string      `json:"schema_content"`
 	Tags              []CreateTag `json:"tags"`
 	MessageStructName string      `json:"message_struct_name"`
+	// DescriptorSet is a base64 encoded compiled FileDescriptorSet sent instead of the proto source
+	DescriptorSet string `json:"descriptor_set"`
 }
 
 type ExtendedSchema struct {
@@ -69,6 +72,7 @@ type ExtendedSchema struct {
 	ActiveVersionNumber int         `json:"active_version_number"`
 	Used                bool        `json:"used"`
 	Tags                []CreateTag `json:"tags"`
+	OwnerTeam           string      `json:"owner_team"`
 }
 
 type ExtendedSchemaDetails struct {
@@ -79,6 +83,8 @@ type ExtendedSchemaDetails struct {
 	UsedStations      []string        `json:"used_stations"`
 	Tags              []CreateTag     `json:"tags"`
 	CreatedByUsername string          `json:"created_by_username"`
+	OwnerTeam         string          `json:"owner_team"`
+	Comments          []CommentThread `json:"comments,omitempty"`
 }
 
 type SchemaUpdateType int
@@ -107,7 +113,19 @@ type SchemaUpdateVersion struct {
 }
 
 type GetSchemaDetails struct {
-	SchemaName string `form:"schema_name" json:"schema_name"`
+	SchemaName    string `form:"schema_name" json:"schema_name"`
+	VersionNumber int    `form:"version_number" json:"version_number" binding:"min=0"`
+}
+
+type GetActiveSchemaVersionSchema struct {
+	SchemaName string `form:"schema_name" json:"schema_name" binding:"required"`
+}
+
+// SchemaVersionDetails is a single schema version without the versions list and the stations using the schema
+type SchemaVersionDetails struct {
+	SchemaName string        `json:"schema_name"`
+	Type       string        `json:"type"`
+	Version    SchemaVersion `json:"version"`
 }
 
 type RemoveSchema struct {
@@ -118,14 +136,95 @@ type CreateNewVersion struct {
 	SchemaName        string `json:"schema_name"`
 	SchemaContent     string `json:"schema_content"`
 	MessageStructName string `json:"message_struct_name"`
+	DescriptorSet     string `json:"descriptor_set"`
 }
 
 type RollBackVersion struct {
 	SchemaName    string `json:"schema_name"`
 	VersionNumber int    `json:"version_number"`
+	// ActiveVersion is the version the caller saw as active, the rollback is refused when it is no longer active
+	ActiveVersion int `json:"active_version"`
 }
 
 type ValidateSchema struct {
 	SchemaType    string `json:"schema_type"`
 	SchemaContent string `json:"schema_content"`
+	SchemaName    string `json:"schema_name"`
+}
+
+type SchemaLintWarning struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type InferSchemaSchema struct {
+	SchemaName  string `json:"schema_name" binding:"required,min=1,max=32"`
+	SchemaType  string `json:"schema_type" binding:"required"`
+	StationName string `json:"station_name"`
+	SampleSize  int    `json:"sample_size"`
+	// Samples are uploaded json messages used instead of reading the station
+	Samples           []string `json:"samples"`
+	MessageStructName string   `json:"message_struct_name"`
+}
+
+type InferredSchemaResponse struct {
+	SchemaName        string `json:"schema_name"`
+	Type              string `json:"type"`
+	VersionNumber     int    `json:"version_number"`
+	Active            bool   `json:"active"`
+	SchemaContent     string `json:"schema_content"`
+	MessageStructName string `json:"message_struct_name"`
+	SampledMessages   int    `json:"sampled_messages"`
+	DecodedMessages   int    `json:"decoded_messages"`
+}
+
+type GrpcReflectionTargetSchema struct {
+	Address string `json:"address" binding:"required"`
+	UseTls  bool   `json:"use_tls"`
+}
+
+type GrpcReflectionMethod struct {
+	Name       string `json:"name"`
+	InputType  string `json:"input_type"`
+	OutputType string `json:"output_type"`
+}
+
+type GrpcReflectionService struct {
+	Name    string                 `json:"name"`
+	Methods []GrpcReflectionMethod `json:"methods"`
+}
+
+type GrpcReflectionMessageImport struct {
+	MessageName string `json:"message_name" binding:"required"`
+	SchemaName  string `json:"schema_name" binding:"required,min=1,max=32"`
+}
+
+type ImportGrpcReflectionSchemasSchema struct {
+	Address  string                        `json:"address" binding:"required"`
+	UseTls   bool                          `json:"use_tls"`
+	Messages []GrpcReflectionMessageImport `json:"messages" binding:"required,min=1,max=50,dive"`
+}
+
+type GrpcReflectionImportResult struct {
+	SchemaName    string `json:"schema_name"`
+	MessageName   string `json:"message_name"`
+	VersionNumber int    `json:"version_number"`
+	// Created is false when the message was added as a new inactive version of an existing schema
+	Created bool `json:"created"`
+}
+
+type RenameSchemaSchema struct {
+	SchemaName string `json:"schema_name" binding:"required"`
+	NewName    string `json:"new_name" binding:"required"`
+}
+
+type RemoveSchemaAliasSchema struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+type RenamedSchemaResponse struct {
+	SchemaName string   `json:"schema_name"`
+	Aliases    []string `json:"aliases"`
+	Stations   []string `json:"stations"`
 }
@@ -0,0 +1,63 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+const (
+	SearchTypeStation  = "station"
+	SearchTypeSchema   = "schema"
+	SearchTypeTag      = "tag"
+	SearchTypeProducer = "producer"
+	SearchTypeUser     = "user"
+)
+
+type SearchSchema struct {
+	Query    string   `form:"query" json:"query" binding:"required,min=1,max=128"`
+	Types    []string `form:"type" json:"types"`
+	Page     int      `form:"page" json:"page" binding:"min=0"`
+	PageSize int      `form:"page_size" json:"page_size" binding:"min=0,max=100"`
+}
+
+type SearchResult struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	StationName string `json:"station_name,omitempty"`
+}
+
+type SearchResultsPage struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+}
+
+type SearchResponse struct {
+	Query    string                       `json:"query"`
+	Page     int                          `json:"page"`
+	PageSize int                          `json:"page_size"`
+	Results  map[string]SearchResultsPage `json:"results"`
+}
+
+// ListOptionsSchema sorts, pages and trims the resources lists, fields is a comma separated list of
+// the top level fields to return. Lists are paged only when page or page_size is given
+type ListOptionsSchema struct {
+	SortBy    string `form:"sort_by" json:"sort_by"`
+	SortOrder string `form:"sort_order" json:"sort_order"`
+	Page      int    `form:"page" json:"page" binding:"min=0"`
+	PageSize  int    `form:"page_size" json:"page_size" binding:"min=0,max=1000"`
+	Fields    string `form:"fields" json:"fields"`
+}
+
+type ListPage struct {
+	Items    interface{} `json:"items"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
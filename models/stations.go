@@ -41,56 +41,73 @@ type MessageDetails struct {
 }
 
 type Station struct {
-	ID                          int       `json:"id"`
-	Name                        string    `json:"name"`
-	RetentionType               string    `json:"retention_type"`
-	RetentionValue              int       `json:"retention_value"`
-	StorageType                 string    `json:"storage_type"`
-	Replicas                    int       `json:"replicas"`
-	CreatedBy                   int       `json:"created_by,omitempty"`
-	CreatedByUsername           string    `json:"created_by_username"`
-	CreatedAt                   time.Time `json:"created_at"`
-	UpdatedAt                   time.Time `json:"updated_at,omitempty"`
-	IsDeleted                   bool      `json:"is_deleted,omitempty"`
-	SchemaName                  string    `json:"schema_name,omitempty"`
-	SchemaVersionNumber         int       `json:"schema_vesrion_number,omitempty"`
-	IdempotencyWindow           int64     `json:"idempotency_window_in_ms,omitempty"`
-	IsNative                    bool      `json:"is_native"`
-	DlsConfigurationPoison      bool      `json:"dls_configuration_poison,omitempty"`
-	DlsConfigurationSchemaverse bool      `json:"dls_configuration_schemaverse,omitempty"`
-	TieredStorageEnabled        bool      `json:"tiered_storage_enabled"`
-	TenantName                  string    `json:"tenant_name"`
-	ResendDisabled              bool      `json:"resend_disabled"`
-	PartitionsList              []int     `json:"partitions_list"`
-	Version                     int       `json:"version"`
-	DlsStation                  string    `json:"dls_station"`
-	FunctionsLockHeld           bool      `json:"functions_lock_held"`
-	FunctionsLockedAt           time.Time `json:"functions_locked_at,omitempty"`
+	ID                          int               `json:"id"`
+	Name                        string            `json:"name"`
+	RetentionType               string            `json:"retention_type"`
+	RetentionValue              int               `json:"retention_value"`
+	StorageType                 string            `json:"storage_type"`
+	Replicas                    int               `json:"replicas"`
+	CreatedBy                   int               `json:"created_by,omitempty"`
+	CreatedByUsername           string            `json:"created_by_username"`
+	CreatedAt                   time.Time         `json:"created_at"`
+	UpdatedAt                   time.Time         `json:"updated_at,omitempty"`
+	IsDeleted                   bool              `json:"is_deleted,omitempty"`
+	SchemaName                  string            `json:"schema_name,omitempty"`
+	SchemaVersionNumber         int               `json:"schema_vesrion_number,omitempty"`
+	IdempotencyWindow           int64             `json:"idempotency_window_in_ms,omitempty"`
+	IsNative                    bool              `json:"is_native"`
+	DlsConfigurationPoison      bool              `json:"dls_configuration_poison,omitempty"`
+	DlsConfigurationSchemaverse bool              `json:"dls_configuration_schemaverse,omitempty"`
+	TieredStorageEnabled        bool              `json:"tiered_storage_enabled"`
+	TenantName                  string            `json:"tenant_name"`
+	ResendDisabled              bool              `json:"resend_disabled"`
+	PartitionsList              []int             `json:"partitions_list"`
+	Version                     int               `json:"version"`
+	DlsStation                  string            `json:"dls_station"`
+	FunctionsLockHeld           bool              `json:"functions_lock_held"`
+	FunctionsLockedAt           time.Time         `json:"functions_locked_at,omitempty"`
+	MessageTransform            string            `json:"message_transform"`
+	AckRetentionLimitType       string            `json:"ack_retention_limit_type"`
+	AckRetentionLimitValue      int               `json:"ack_retention_limit_value"`
+	OwnerTeam                   string            `json:"owner_team"`
+	Description                 string            `json:"description"`
+	SlaTier                     string            `json:"sla_tier"`
+	Labels                      map[string]string `json:"labels"`
+	ReadOnly                    bool              `json:"read_only"`
 }
 
 type GetStationResponseSchema struct {
-	ID                   int              `json:"id"`
-	Name                 string           `json:"name"`
-	RetentionType        string           `json:"retention_type"`
-	RetentionValue       int              `json:"retention_value"`
-	StorageType          string           `json:"storage_type"`
-	Replicas             int              `json:"replicas"`
-	CreatedBy            int              `json:"created_by"`
-	CreatedByUsername    string           `json:"created_by_username"`
-	CreatedAt            time.Time        `json:"created_at"`
-	LastUpdate           time.Time        `json:"last_update"`
-	IsDeleted            bool             `json:"is_deleted"`
-	Tags                 []CreateTag      `json:"tags"`
-	IdempotencyWindow    int64            `json:"idempotency_window_in_ms" `
-	IsNative             bool             `json:"is_native"`
-	DlsConfiguration     DlsConfiguration `json:"dls_configuration"`
-	TieredStorageEnabled bool             `json:"tiered_storage_enabled"`
-	ResendDisabled       bool             `json:"resend_disabled"`
-	PartitionsList       []int            `json:"partitions_list"`
-	PartitionsNumber     int              `json:"partitions_number"`
-	DlsStation           string           `json:"dls_station"`
-	FunctionsLockHeld    bool             `json:"functions_lock_held"`
-	FunctionsLockedAt    time.Time        `json:"functions_locked_at"`
+	ID                   int               `json:"id"`
+	Name                 string            `json:"name"`
+	RetentionType        string            `json:"retention_type"`
+	RetentionValue       int               `json:"retention_value"`
+	StorageType          string            `json:"storage_type"`
+	Replicas             int               `json:"replicas"`
+	CreatedBy            int               `json:"created_by"`
+	CreatedByUsername    string            `json:"created_by_username"`
+	CreatedAt            time.Time         `json:"created_at"`
+	LastUpdate           time.Time         `json:"last_update"`
+	IsDeleted            bool              `json:"is_deleted"`
+	Tags                 []CreateTag       `json:"tags"`
+	IdempotencyWindow    int64             `json:"idempotency_window_in_ms" `
+	IsNative             bool              `json:"is_native"`
+	DlsConfiguration     DlsConfiguration  `json:"dls_configuration"`
+	TieredStorageEnabled bool              `json:"tiered_storage_enabled"`
+	ResendDisabled       bool              `json:"resend_disabled"`
+	PartitionsList       []int             `json:"partitions_list"`
+	PartitionsNumber     int               `json:"partitions_number"`
+	DlsStation           string            `json:"dls_station"`
+	FunctionsLockHeld    bool              `json:"functions_lock_held"`
+	FunctionsLockedAt    time.Time         `json:"functions_locked_at"`
+	MessageTransform     string            `json:"message_transform"`
+	AckRetentionLimit    AckLimit          `json:"ack_retention_limit"`
+	EncryptionEnabled    bool              `json:"encryption_enabled"`
+	OwnerTeam            string            `json:"owner_team"`
+	Description          string            `json:"description"`
+	SlaTier              string            `json:"sla_tier"`
+	Labels               map[string]string `json:"labels"`
+	ReadOnly             bool              `json:"read_only"`
+	Comments             []CommentThread   `json:"comments"`
 }
 
 type ExtendedStation struct {
@@ -120,35 +137,43 @@ type ExtendedStation struct {
 }
 
 type ExtendedStationLight struct {
-	ID                          int         `json:"id"`
-	Name                        string      `json:"name"`
-	RetentionType               string      `json:"retention_type,omitempty"`
-	RetentionValue              int         `json:"retention_value,omitempty"`
-	StorageType                 string      `json:"storage_type,omitempty"`
-	Replicas                    int         `json:"replicas,omitempty"`
-	CreatedBy                   int         `json:"created_by,omitempty"`
-	CreatedByUsername           string      `json:"created_by_username"`
-	CreatedAt                   time.Time   `json:"created_at"`
-	UpdatedAt                   time.Time   `json:"updated_at,omitempty"`
-	IsDeleted                   bool        `json:"is_deleted,omitempty"`
-	TotalMessages               int         `json:"total_messages"`
-	SchemaName                  string      `json:"schema_name,omitempty"`
-	SchemaVersionNumber         int         `json:"schema_vesrion_number,omitempty"`
-	Tags                        []CreateTag `json:"tags,omitempty"`
-	IdempotencyWindow           int64       `json:"idempotency_window_in_ms,omitempty"`
-	IsNative                    bool        `json:"is_native"`
-	DlsConfigurationPoison      bool        `json:"dls_configuration_poison,omitempty"`
-	DlsConfigurationSchemaverse bool        `json:"dls_configuration_schemaverse,omitempty"`
-	HasDlsMsgs                  bool        `json:"has_dls_messages"`
-	Activity                    bool        `json:"activity"`
-	TieredStorageEnabled        bool        `json:"tiered_storage_enabled,omitempty"`
-	TenantName                  string      `json:"tenant_name"`
-	ResendDisabled              bool        `json:"resend_disabled"`
-	PartitionsList              []int       `json:"partitions_list"`
-	Version                     int         `json:"version"`
-	DlsStation                  string      `json:"dls_station"`
-	FunctionsLockHeld           bool        `json:"functions_lock_held"`
-	FunctionsLockedAt           time.Time   `json:"functions_locked_at"`
+	ID                          int               `json:"id"`
+	Name                        string            `json:"name"`
+	RetentionType               string            `json:"retention_type,omitempty"`
+	RetentionValue              int               `json:"retention_value,omitempty"`
+	StorageType                 string            `json:"storage_type,omitempty"`
+	Replicas                    int               `json:"replicas,omitempty"`
+	CreatedBy                   int               `json:"created_by,omitempty"`
+	CreatedByUsername           string            `json:"created_by_username"`
+	CreatedAt                   time.Time         `json:"created_at"`
+	UpdatedAt                   time.Time         `json:"updated_at,omitempty"`
+	IsDeleted                   bool              `json:"is_deleted,omitempty"`
+	TotalMessages               int               `json:"total_messages"`
+	SchemaName                  string            `json:"schema_name,omitempty"`
+	SchemaVersionNumber         int               `json:"schema_vesrion_number,omitempty"`
+	Tags                        []CreateTag       `json:"tags,omitempty"`
+	IdempotencyWindow           int64             `json:"idempotency_window_in_ms,omitempty"`
+	IsNative                    bool              `json:"is_native"`
+	DlsConfigurationPoison      bool              `json:"dls_configuration_poison,omitempty"`
+	DlsConfigurationSchemaverse bool              `json:"dls_configuration_schemaverse,omitempty"`
+	HasDlsMsgs                  bool              `json:"has_dls_messages"`
+	Activity                    bool              `json:"activity"`
+	TieredStorageEnabled        bool              `json:"tiered_storage_enabled,omitempty"`
+	TenantName                  string            `json:"tenant_name"`
+	ResendDisabled              bool              `json:"resend_disabled"`
+	PartitionsList              []int             `json:"partitions_list"`
+	Version                     int               `json:"version"`
+	DlsStation                  string            `json:"dls_station"`
+	FunctionsLockHeld           bool              `json:"functions_lock_held"`
+	FunctionsLockedAt           time.Time         `json:"functions_locked_at"`
+	MessageTransform            string            `json:"message_transform"`
+	AckRetentionLimitType       string            `json:"ack_retention_limit_type"`
+	AckRetentionLimitValue      int               `json:"ack_retention_limit_value"`
+	OwnerTeam                   string            `json:"owner_team"`
+	Description                 string            `json:"description"`
+	SlaTier                     string            `json:"sla_tier"`
+	Labels                      map[string]string `json:"labels"`
+	ReadOnly                    bool              `json:"read_only"`
 }
 
 type StationLight struct {
@@ -192,6 +217,21 @@ type CreateStationSchema struct {
 	TieredStorageEnabled bool             `json:"tiered_storage_enabled"`
 	PartitionsNumber     int              `json:"partitions_number"`
 	DlsStation           string           `json:"dls_station"`
+	AckRetentionLimit    AckLimit         `json:"ack_retention_limit"`
+	EncryptionEnabled    bool             `json:"encryption_enabled"`
+	ExpiresAt            *time.Time       `json:"expires_at"`
+}
+
+// AckLimit caps an ack based station by age, count or size so messages
+// of a consumer group which stopped acking are not kept forever
+type AckLimit struct {
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+type UpdateAckRetentionLimitSchema struct {
+	StationName       string   `json:"station_name" binding:"required"`
+	AckRetentionLimit AckLimit `json:"ack_retention_limit"`
 }
 
 type AttachDetachDlsStationSchema struct {
@@ -210,6 +250,11 @@ type UpdateDlsConfigSchema struct {
 	Schemaverse bool   `json:"schemaverse"`
 }
 
+type UpdateMessageTransformSchema struct {
+	StationName      string `json:"station_name" binding:"required"`
+	MessageTransform string `json:"message_transform"`
+}
+
 type DropDlsMessagesSchema struct {
 	DlsMsgType    string `json:"dls_type" binding:"required"`
 	DlsMessageIds []int  `json:"dls_message_ids" binding:"required"`
@@ -217,10 +262,22 @@ type DropDlsMessagesSchema struct {
 }
 
 type PurgeStationSchema struct {
-	StationName    string `json:"station_name" binding:"required"`
-	PurgeDls       bool   `json:"purge_dls"`
-	PurgeStation   bool   `json:"purge_station"`
-	PartitionsList []int  `json:"partitions_list"`
+	StationName       string    `json:"station_name" binding:"required"`
+	PurgeDls          bool      `json:"purge_dls"`
+	PurgeStation      bool      `json:"purge_station"`
+	PartitionsList    []int     `json:"partitions_list"`
+	BeforeSeq         uint64    `json:"before_seq"`
+	BeforeTime        time.Time `json:"before_time"`
+	ConfirmationToken string    `json:"confirmation_token" binding:"required"`
+}
+
+type GetPurgeConfirmationTokenSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+}
+
+type PurgeConfirmationToken struct {
+	ConfirmationToken string    `json:"confirmation_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
 }
 
 type RemoveMessagesSchema struct {
@@ -283,3 +340,374 @@ type GetUpdatesForSchema struct {
 type PartitionsUpdate struct {
 	PartitionsList []int `json:"partitions_list"`
 }
+
+type TailedMessage struct {
+	Cursor      uint64            `json:"cursor"`
+	Partition   int               `json:"partition"`
+	ProducedBy  string            `json:"produced_by"`
+	TimeSent    time.Time         `json:"created_at"`
+	Size        int               `json:"size"`
+	Headers     map[string]string `json:"headers"`
+	Data        string            `json:"data"`
+	Truncated   bool              `json:"truncated"`
+	Decoded     any               `json:"decoded,omitempty"`
+	SchemaError string            `json:"schema_error,omitempty"`
+}
+
+type StationMessagesTail struct {
+	Messages   []TailedMessage `json:"messages"`
+	NextCursor uint64          `json:"next_cursor"`
+	Dropped    uint64          `json:"dropped"`
+}
+
+type GetStationMessagesTailSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	Cursor      uint64 `form:"cursor" json:"cursor"`
+	TimeoutSec  int    `form:"timeout_sec" json:"timeout_sec"`
+}
+
+// StationClientsFilter narrows down the producers and consumer groups listed in the station overview,
+// a zero limit returns all of them
+type StationClientsFilter struct {
+	Limit  int    `form:"clients_limit" json:"clients_limit"`
+	Offset int    `form:"clients_offset" json:"clients_offset"`
+	Search string `form:"clients_search" json:"clients_search"`
+	Status string `form:"clients_status" json:"clients_status"`
+}
+
+type StationClientsCount struct {
+	Connected    int `json:"connected"`
+	Disconnected int `json:"disconnected"`
+	Deleted      int `json:"deleted"`
+}
+
+type StationStorageKey struct {
+	ID          int       `json:"id"`
+	StationName string    `json:"station_name"`
+	WrappedKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	RotatedAt   time.Time `json:"rotated_at"`
+	TenantName  string    `json:"tenant_name"`
+	Version     int       `json:"version"`
+}
+
+type StationStorageKeyVersion struct {
+	ID          int       `json:"id"`
+	StationName string    `json:"station_name"`
+	TenantName  string    `json:"tenant_name"`
+	Version     int       `json:"version"`
+	WrappedKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type StationStorageKeyRewrap struct {
+	ID          int       `json:"id"`
+	StationName string    `json:"station_name"`
+	TenantName  string    `json:"tenant_name"`
+	BrokerName  string    `json:"broker_name"`
+	Version     int       `json:"version"`
+	RewrappedAt time.Time `json:"rewrapped_at"`
+}
+
+type RotateStationStorageKeySchema struct {
+	StationName string `json:"station_name" binding:"required"`
+}
+
+type StationStorageKeyUpdate struct {
+	StationName string `json:"station_name"`
+	TenantName  string `json:"tenant_name"`
+	Operation   string `json:"operation"`
+}
+
+type ExportStationMessagesSchema struct {
+	StationName     string    `form:"station_name" json:"station_name" binding:"required"`
+	Format          string    `form:"format" json:"format"`
+	From            time.Time `form:"from" json:"from"`
+	To              time.Time `form:"to" json:"to"`
+	PartitionNumber int       `form:"partition_number" json:"partition_number"`
+	StartSeq        uint64    `form:"start_seq" json:"start_seq"`
+}
+
+const (
+	StationSlaTierCritical = "critical"
+	StationSlaTierHigh     = "high"
+	StationSlaTierStandard = "standard"
+	StationSlaTierLow      = "low"
+)
+
+type UpdateStationSchema struct {
+	StationName string            `json:"station_name" binding:"required"`
+	Description *string           `json:"description"`
+	SlaTier     *string           `json:"sla_tier"`
+	Labels      map[string]string `json:"labels"`
+	Owner       string            `json:"owner"`
+	OwnerTeam   string            `json:"owner_team"`
+}
+
+// StationFilterSchema narrows the stations list, labels are given as key or key=value and must all match
+type StationFilterSchema struct {
+	Team    string   `form:"team" json:"team"`
+	Search  string   `form:"search" json:"search"`
+	Owner   string   `form:"owner" json:"owner"`
+	SlaTier string   `form:"sla_tier" json:"sla_tier"`
+	Labels  []string `form:"label" json:"labels"`
+	TagFilterSchema
+}
+
+type UpdateStationReadOnlySchema struct {
+	StationName string `json:"station_name" binding:"required"`
+	ReadOnly    *bool  `json:"read_only" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+type StationReadOnlyUpdate struct {
+	StationName string `json:"station_name"`
+	TenantName  string `json:"tenant_name"`
+	ReadOnly    bool   `json:"read_only"`
+}
+
+const (
+	StationLifecycleActionDelete  = "delete"
+	StationLifecycleActionArchive = "archive"
+)
+
+type StationLifecyclePolicy struct {
+	ID                       int        `json:"id"`
+	StationId                int        `json:"station_id"`
+	DeleteAfterIdleDays      int        `json:"delete_after_idle_days"`
+	ArchiveAfterInactiveDays int        `json:"archive_after_inactive_days"`
+	ExpiresAt                *time.Time `json:"expires_at"`
+	NotifyBeforeHours        int        `json:"notify_before_hours"`
+	InactiveSince            *time.Time `json:"inactive_since"`
+	NotifiedAction           string     `json:"notified_action"`
+	NotifiedDueAt            *time.Time `json:"notified_due_at"`
+	NotifiedAt               *time.Time `json:"notified_at"`
+	CreatedBy                string     `json:"created_by"`
+	CreatedAt                time.Time  `json:"created_at"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+	TenantName               string     `json:"tenant_name"`
+}
+
+type UpdateStationLifecyclePolicySchema struct {
+	StationName              string     `json:"station_name" binding:"required"`
+	DeleteAfterIdleDays      int        `json:"delete_after_idle_days"`
+	ArchiveAfterInactiveDays int        `json:"archive_after_inactive_days"`
+	ExpiresAt                *time.Time `json:"expires_at"`
+	NotifyBeforeHours        *int       `json:"notify_before_hours"`
+}
+
+type GetStationLifecyclePolicySchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+}
+
+type RemoveStationLifecyclePolicySchema struct {
+	StationName string `json:"station_name" binding:"required"`
+}
+
+type StationLifecycleNextAction struct {
+	Action string    `json:"action"`
+	Reason string    `json:"reason"`
+	DueAt  time.Time `json:"due_at"`
+}
+
+type StationIndexExtractor struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type StationIndexConfig struct {
+	ID          int                     `json:"id"`
+	StationId   int                     `json:"station_id"`
+	Extractors  []StationIndexExtractor `json:"extractors"`
+	ScannedSeqs map[string]uint64       `json:"scanned_seqs"`
+	UpdatedBy   string                  `json:"updated_by"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	TenantName  string                  `json:"tenant_name"`
+}
+
+type StationMessageIndexEntry struct {
+	Extractor       string    `json:"extractor"`
+	Value           string    `json:"value"`
+	PartitionNumber int       `json:"partition_number"`
+	MessageSeq      int64     `json:"message_seq"`
+	ProducedAt      time.Time `json:"produced_at"`
+}
+
+type UpdateStationIndexExtractorsSchema struct {
+	StationName string                  `json:"station_name" binding:"required"`
+	Extractors  []StationIndexExtractor `json:"extractors"`
+}
+
+type GetStationIndexExtractorsSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+}
+
+type SearchStationMessagesByKeySchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	Key         string `form:"key" json:"key" binding:"required"`
+	Value       string `form:"value" json:"value" binding:"required"`
+}
+
+type GetStationDataProfileSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	SampleSize  int    `form:"sample_size" json:"sample_size"`
+}
+
+type StationSizeBucket struct {
+	UpToBytes int `json:"up_to_bytes"`
+	Count     int `json:"count"`
+}
+
+type StationSizeProfile struct {
+	MinBytes int                 `json:"min_bytes"`
+	MaxBytes int                 `json:"max_bytes"`
+	AvgBytes int                 `json:"avg_bytes"`
+	P50Bytes int                 `json:"p50_bytes"`
+	P90Bytes int                 `json:"p90_bytes"`
+	P99Bytes int                 `json:"p99_bytes"`
+	Buckets  []StationSizeBucket `json:"buckets"`
+}
+
+type StationFieldProfile struct {
+	Path              string         `json:"path"`
+	Presence          int            `json:"presence"`
+	PresenceRate      float64        `json:"presence_rate"`
+	Types             map[string]int `json:"types"`
+	InferredType      string         `json:"inferred_type"`
+	Cardinality       int            `json:"cardinality"`
+	CardinalityCapped bool           `json:"cardinality_capped"`
+	SampleValues      []string       `json:"sample_values"`
+}
+
+type StationDataProfile struct {
+	StationName     string                `json:"station_name"`
+	SampledMessages int                   `json:"sampled_messages"`
+	DecodedMessages int                   `json:"decoded_messages"`
+	Format          string                `json:"format"`
+	Sizes           StationSizeProfile    `json:"sizes"`
+	Fields          []StationFieldProfile `json:"fields"`
+	FieldsCapped    bool                  `json:"fields_capped"`
+	SampledFrom     time.Time             `json:"sampled_from"`
+	SampledTo       time.Time             `json:"sampled_to"`
+}
+
+const (
+	StationContractHealthy    = "healthy"
+	StationContractAtRisk     = "at_risk"
+	StationContractViolated   = "violated"
+	StationContractNoContract = "no_contract"
+)
+
+type StationContractReportSchedule struct {
+	ID              int        `json:"id"`
+	StationId       int        `json:"station_id"`
+	IntervalHours   int        `json:"interval_hours"`
+	Recipients      []string   `json:"recipients"`
+	LastGeneratedAt *time.Time `json:"last_generated_at"`
+	CreatedBy       string     `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	TenantName      string     `json:"tenant_name"`
+}
+
+type StationContractSchema struct {
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	AttachedVersion int    `json:"attached_version"`
+	ActiveVersion   int    `json:"active_version"`
+}
+
+type StationContractValidation struct {
+	MessagesProduced   uint64  `json:"messages_produced"`
+	ValidationFailures int     `json:"validation_failures"`
+	FailureRate        float64 `json:"failure_rate"`
+}
+
+type StationContractProducer struct {
+	Name               string    `json:"name"`
+	Sdk                string    `json:"sdk"`
+	SdkVersion         int       `json:"sdk_version"`
+	IsActive           bool      `json:"is_active"`
+	LastSeenAt         time.Time `json:"last_seen_at"`
+	ValidationFailures int       `json:"validation_failures"`
+}
+
+type StationContractConsumerGroup struct {
+	Name          string `json:"name"`
+	Lag           uint64 `json:"lag"`
+	AckPending    int    `json:"ack_pending"`
+	Redelivered   int    `json:"redelivered"`
+	ActiveMembers int    `json:"active_members"`
+}
+
+type StationContractReport struct {
+	StationName    string                         `json:"station_name"`
+	Health         string                         `json:"health"`
+	Findings       []string                       `json:"findings"`
+	PeriodStart    time.Time                      `json:"period_start"`
+	GeneratedAt    time.Time                      `json:"generated_at"`
+	Schema         *StationContractSchema         `json:"schema"`
+	Validation     StationContractValidation      `json:"validation"`
+	Producers      []StationContractProducer      `json:"producers"`
+	ConsumerGroups []StationContractConsumerGroup `json:"consumer_groups"`
+	// PartitionLastSeqs is where the next report starts counting produced messages
+	PartitionLastSeqs map[int]uint64 `json:"partition_last_seqs"`
+}
+
+type StationContractReportRecord struct {
+	ID          int64                 `json:"id"`
+	StationId   int                   `json:"station_id"`
+	Health      string                `json:"health"`
+	Report      StationContractReport `json:"report"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	TenantName  string                `json:"tenant_name"`
+}
+
+type GetStationContractReportSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	Generate    bool   `form:"generate" json:"generate"`
+}
+
+type GetStationContractReportsSchema struct {
+	StationName string `form:"station_name" json:"station_name" binding:"required"`
+	Limit       int    `form:"limit" json:"limit"`
+}
+
+type UpdateStationContractReportScheduleSchema struct {
+	StationName   string   `json:"station_name" binding:"required"`
+	IntervalHours int      `json:"interval_hours" binding:"required"`
+	Recipients    []string `json:"recipients"`
+}
+
+type RemoveStationContractReportScheduleSchema struct {
+	StationName string `json:"station_name" binding:"required"`
+}
+
+type StartStationBenchmarkSchema struct {
+	StationName   string `json:"station_name" binding:"required"`
+	MessagesCount int    `json:"messages_count" binding:"required,min=1,max=1000000"`
+	MessageSize   int    `json:"message_size" binding:"min=0,max=1048576"`
+	Rate          int    `json:"rate" binding:"min=0"`
+	Producers     int    `json:"producers" binding:"min=0,max=64"`
+	Consumers     int    `json:"consumers" binding:"min=0,max=64"`
+}
+
+type StationBenchmarkLatency struct {
+	P50Ms float64 `json:"p50_ms"`
+	P90Ms float64 `json:"p90_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+type StationBenchmarkResult struct {
+	Produced          int                     `json:"produced"`
+	Consumed          int                     `json:"consumed"`
+	Failed            int                     `json:"failed"`
+	Failures          []string                `json:"failures"`
+	ElapsedSec        float64                 `json:"elapsed_sec"`
+	ProduceRate       float64                 `json:"produce_rate"`
+	ProduceMBps       float64                 `json:"produce_mbps"`
+	ProduceLatency    StationBenchmarkLatency `json:"produce_latency"`
+	EndToEndLatency   StationBenchmarkLatency `json:"end_to_end_latency"`
+	ConsumeIncomplete bool                    `json:"consume_incomplete"`
+}
@@ -42,3 +42,43 @@ type UpdateTagsForEntitySchema struct {
 type GetTagsSchema struct {
 	EntityType string `json:"entity_type"`
 }
+
+type RenameTagSchema struct {
+	Name    string `json:"name" binding:"required"`
+	NewName string `json:"new_name" binding:"required,min=1,max=20"`
+}
+
+type MergeTagsSchema struct {
+	SourceName string `json:"source_name" binding:"required"`
+	TargetName string `json:"target_name" binding:"required"`
+}
+
+type GetTagResourcesSchema struct {
+	Name       string `form:"name" json:"name" binding:"required"`
+	EntityType string `form:"entity_type" json:"entity_type"`
+	Page       int    `form:"page" json:"page" binding:"min=0"`
+	PageSize   int    `form:"page_size" json:"page_size" binding:"min=0,max=1000"`
+}
+
+type TagResource struct {
+	EntityType string `json:"entity_type"`
+	Name       string `json:"name"`
+}
+
+type GetTagResourcesResponse struct {
+	Name      string        `json:"name"`
+	Resources []TagResource `json:"resources"`
+	Total     int           `json:"total"`
+	Page      int           `json:"page"`
+	PageSize  int           `json:"page_size"`
+}
+
+type RemoveUnusedTagsSchema struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// TagFilterSchema filters a resources list by tags, tags_operator is "or" (the default) or "and"
+type TagFilterSchema struct {
+	Tags         []string `form:"tag" json:"tags"`
+	TagsOperator string   `form:"tags_operator" json:"tags_operator"`
+}
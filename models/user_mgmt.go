@@ -87,7 +87,8 @@ type AuthenticateNatsSchema struct {
 }
 
 type RemoveUserSchema struct {
-	Username string `json:"username" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	TransferTo string `json:"transfer_to"`
 }
 
 type EditAvatarSchema struct {
@@ -95,7 +96,8 @@ type EditAvatarSchema struct {
 }
 
 type EditAnalyticsSchema struct {
-	SendAnalytics bool `json:"send_analytics"`
+	SendAnalytics bool            `json:"send_analytics"`
+	Categories    map[string]bool `json:"categories"`
 }
 
 type GetFilterDetailsSchema struct {
@@ -111,6 +113,19 @@ type ChangePasswordSchema struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ChangeMyPasswordSchema struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type EditMyProfileSchema struct {
+	FullName string `json:"full_name"`
+}
+
+type GetAvatarSchema struct {
+	Username string `form:"username" json:"username"`
+}
+
 type SendTraceSchema struct {
 	TraceName   string                 `json:"trace_name" binding:"required"`
 	TraceParams map[string]interface{} `json:"trace_params" binding:"required"`
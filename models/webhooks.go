@@ -0,0 +1,64 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package models
+
+import "time"
+
+const (
+	EventStationCreated         = "station.created"
+	EventStationDeleted         = "station.deleted"
+	EventSchemaVersionActivated = "schema.version_activated"
+	EventConsumerCreated        = "consumer.created"
+	EventConsumerDestroyed      = "consumer.destroyed"
+)
+
+type BrokerEvent struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	TenantName string                 `json:"tenant_name"`
+	Time       time.Time              `json:"time"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+type Webhook struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	TenantName string    `json:"tenant_name"`
+}
+
+type CreateWebhookSchema struct {
+	Name       string   `json:"name" binding:"required,min=1,max=128"`
+	Url        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+}
+
+type UpdateWebhookSchema struct {
+	ID         int      `json:"id" binding:"required"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+type RemoveWebhookSchema struct {
+	ID int `json:"id" binding:"required"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
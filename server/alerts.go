@@ -0,0 +1,377 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	alertsEvaluationInterval = time.Minute
+	alertDeliveryAttempts    = 4
+	alertDeliveryTimeout     = 10 * time.Second
+	pagerDutyEventsUrl       = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsUrl        = "https://api.opsgenie.com/v2/alerts"
+)
+
+var alertsHttpClient = &http.Client{Timeout: alertDeliveryTimeout}
+
+// schema validation failures are counted from the dls, the previous sample is kept to turn it into a rate
+var lastSchemaFailuresSample = struct {
+	sync.Mutex
+	counts map[stationMetricKey]float64
+	time   time.Time
+}{counts: make(map[stationMetricKey]float64)}
+
+type alertsSnapshot struct {
+	dlsDepth           map[stationMetricKey]float64
+	schemaFailureRate  map[stationMetricKey]float64
+	consumerLag        map[stationMetricKey]float64
+	downComponents     []string
+	componentsReported bool
+}
+
+func validateAlertType(alertType string) error {
+	switch alertType {
+	case models.AlertTypeDlsDepth, models.AlertTypeConsumerLag, models.AlertTypeSchemaValidationFailureRate, models.AlertTypeComponentDown:
+		return nil
+	default:
+		return fmt.Errorf("alert type %v is not supported, supported types are %v, %v, %v and %v", alertType, models.AlertTypeDlsDepth, models.AlertTypeConsumerLag, models.AlertTypeSchemaValidationFailureRate, models.AlertTypeComponentDown)
+	}
+}
+
+func validateAlertChannels(channels []models.AlertChannel) error {
+	for _, channel := range channels {
+		switch channel.Type {
+		case models.AlertChannelSlack, models.AlertChannelWebhook:
+			u, err := url.Parse(channel.Url)
+			if err != nil || u.Host == _EMPTY_ || (u.Scheme != "https" && u.Scheme != "http") {
+				return fmt.Errorf("%v channel requires a valid url", channel.Type)
+			}
+		case models.AlertChannelPagerDuty:
+			if channel.RoutingKey == _EMPTY_ {
+				return fmt.Errorf("%v channel requires a routing key", channel.Type)
+			}
+		case models.AlertChannelOpsgenie:
+			if channel.ApiKey == _EMPTY_ {
+				return fmt.Errorf("%v channel requires an api key", channel.Type)
+			}
+		default:
+			return fmt.Errorf("channel type %v is not supported, supported types are %v, %v, %v and %v", channel.Type, models.AlertChannelSlack, models.AlertChannelPagerDuty, models.AlertChannelOpsgenie, models.AlertChannelWebhook)
+		}
+	}
+	return nil
+}
+
+func hideAlertChannelsSecrets(channels []models.AlertChannel) []models.AlertChannel {
+	hidden := make([]models.AlertChannel, 0, len(channels))
+	for _, channel := range channels {
+		if len(channel.RoutingKey) > 4 {
+			channel.RoutingKey = hideIntegrationSecretKey(channel.RoutingKey)
+		}
+		if len(channel.ApiKey) > 4 {
+			channel.ApiKey = hideIntegrationSecretKey(channel.ApiKey)
+		}
+		hidden = append(hidden, channel)
+	}
+	return hidden
+}
+
+func alertStationKey(tenantName, stationName string) stationMetricKey {
+	sn, err := StationNameFromStr(stationName)
+	if err == nil {
+		stationName = sn.Intern()
+	}
+	return stationMetricKey{tenantName: tenantName, stationName: stationName}
+}
+
+func (s *Server) collectAlertsSnapshot(rules []models.AlertRule) (alertsSnapshot, error) {
+	snapshot := alertsSnapshot{
+		dlsDepth:          make(map[stationMetricKey]float64),
+		schemaFailureRate: make(map[stationMetricKey]float64),
+		consumerLag:       make(map[stationMetricKey]float64),
+	}
+	needed := make(map[string]bool)
+	for _, rule := range rules {
+		needed[rule.AlertType] = true
+	}
+
+	if needed[models.AlertTypeDlsDepth] || needed[models.AlertTypeSchemaValidationFailureRate] {
+		counts, err := db.GetDlsMessagesCountByStation()
+		if err != nil {
+			return snapshot, err
+		}
+		schemaFailures := make(map[stationMetricKey]float64)
+		for _, count := range counts {
+			key := stationMetricKey{tenantName: count.TenantName, stationName: count.StationName}
+			snapshot.dlsDepth[key] += float64(count.Count)
+			if count.MessageType == "schema" {
+				schemaFailures[key] += float64(count.Count)
+			}
+		}
+
+		lastSchemaFailuresSample.Lock()
+		now := time.Now()
+		if !lastSchemaFailuresSample.time.IsZero() {
+			minutes := now.Sub(lastSchemaFailuresSample.time).Minutes()
+			for key, current := range schemaFailures {
+				// old dls messages are removed by retention, a drop in the count is not a negative rate
+				delta := current - lastSchemaFailuresSample.counts[key]
+				if delta > 0 && minutes > 0 {
+					snapshot.schemaFailureRate[key] = delta / minutes
+				}
+			}
+		}
+		lastSchemaFailuresSample.counts = schemaFailures
+		lastSchemaFailuresSample.time = now
+		lastSchemaFailuresSample.Unlock()
+	}
+
+	if needed[models.AlertTypeConsumerLag] {
+		jsi, err := s.Jsz(&JSzOptions{Accounts: true, Streams: true, Consumer: true})
+		if err != nil {
+			return snapshot, err
+		}
+		for _, account := range jsi.AccountDetails {
+			for _, stream := range account.Streams {
+				if strings.HasPrefix(stream.Name, "$memphis") {
+					continue
+				}
+				stationName, _ := splitPartitionStreamName(stream.Name)
+				key := stationMetricKey{tenantName: account.Name, stationName: stationName.Intern()}
+				for _, consumer := range stream.Consumer {
+					if consumer == nil {
+						continue
+					}
+					if lag := float64(consumer.NumPending); lag > snapshot.consumerLag[key] {
+						snapshot.consumerLag[key] = lag
+					}
+				}
+			}
+		}
+	}
+
+	if needed[models.AlertTypeComponentDown] {
+		components, _, err := MonitoringHandler{S: s}.GetSystemComponents()
+		if err != nil {
+			return snapshot, err
+		}
+		for _, comp := range components {
+			if comp.Status == unhealthyStatus {
+				snapshot.downComponents = append(snapshot.downComponents, comp.Name)
+			}
+		}
+		snapshot.componentsReported = true
+	}
+	return snapshot, nil
+}
+
+func stationValueAboveThreshold(values map[stationMetricKey]float64, rule models.AlertRule) (float64, []string) {
+	var max float64
+	var stations []string
+	if rule.StationName != _EMPTY_ {
+		value := values[alertStationKey(rule.TenantName, rule.StationName)]
+		if value > rule.Threshold {
+			return value, []string{rule.StationName}
+		}
+		return value, nil
+	}
+	for key, value := range values {
+		if key.tenantName != rule.TenantName {
+			continue
+		}
+		if value > max {
+			max = value
+		}
+		if value > rule.Threshold {
+			stations = append(stations, StationNameFromStreamName(key.stationName).Ext())
+		}
+	}
+	return max, stations
+}
+
+// evaluate returns the observed value, whether the rule condition holds and a human readable description
+func (snapshot alertsSnapshot) evaluate(rule models.AlertRule) (float64, bool, string) {
+	switch rule.AlertType {
+	case models.AlertTypeDlsDepth:
+		value, stations := stationValueAboveThreshold(snapshot.dlsDepth, rule)
+		return value, len(stations) > 0, fmt.Sprintf("Dead-letter messages above %v in stations: %v", rule.Threshold, strings.Join(stations, ", "))
+	case models.AlertTypeConsumerLag:
+		value, stations := stationValueAboveThreshold(snapshot.consumerLag, rule)
+		return value, len(stations) > 0, fmt.Sprintf("Consumer group lag above %v in stations: %v", rule.Threshold, strings.Join(stations, ", "))
+	case models.AlertTypeSchemaValidationFailureRate:
+		value, stations := stationValueAboveThreshold(snapshot.schemaFailureRate, rule)
+		return value, len(stations) > 0, fmt.Sprintf("Schema validation failures above %v per minute in stations: %v", rule.Threshold, strings.Join(stations, ", "))
+	case models.AlertTypeComponentDown:
+		if !snapshot.componentsReported {
+			return 0, false, _EMPTY_
+		}
+		return float64(len(snapshot.downComponents)), len(snapshot.downComponents) > 0, fmt.Sprintf("Unhealthy system components: %v", strings.Join(snapshot.downComponents, ", "))
+	}
+	return 0, false, _EMPTY_
+}
+
+func (s *Server) EvaluateAlertRules() {
+	reportBackgroundTaskAlive("EvaluateAlertRules", alertsEvaluationInterval)
+	ticker := time.NewTicker(alertsEvaluationInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("EvaluateAlertRules", alertsEvaluationInterval)
+		if !s.leadsBackgroundTask("EvaluateAlertRules", alertsEvaluationInterval) {
+			continue
+		}
+		rules, err := db.GetEnabledAlertRules()
+		if err != nil {
+			s.Errorf("EvaluateAlertRules at GetEnabledAlertRules: %v", err.Error())
+			continue
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		snapshot, err := s.collectAlertsSnapshot(rules)
+		if err != nil {
+			s.Errorf("EvaluateAlertRules at collectAlertsSnapshot: %v", err.Error())
+			continue
+		}
+		for _, rule := range rules {
+			value, firing, description := snapshot.evaluate(rule)
+			state := models.AlertStateOk
+			if firing {
+				state = models.AlertStateFiring
+			}
+			// notifications are sent only on transitions so a firing rule doesn't flood its channels
+			if state == rule.State {
+				continue
+			}
+			var triggeredAt *time.Time
+			now := time.Now()
+			if firing {
+				triggeredAt = &now
+			} else {
+				description = fmt.Sprintf("Alert %v has been resolved", rule.Name)
+			}
+			err = db.UpdateAlertRuleState(rule.ID, state, triggeredAt)
+			if err != nil {
+				s.Errorf("[tenant: %v]EvaluateAlertRules at UpdateAlertRuleState: %v", rule.TenantName, err.Error())
+				continue
+			}
+			event := models.AlertEvent{
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				AlertType:   rule.AlertType,
+				State:       state,
+				StationName: rule.StationName,
+				Threshold:   rule.Threshold,
+				Value:       value,
+				Message:     description,
+				TenantName:  rule.TenantName,
+				Time:        now,
+			}
+			for _, channel := range rule.Channels {
+				go s.deliverAlert(channel, event)
+			}
+		}
+	}
+}
+
+func (s *Server) deliverAlert(channel models.AlertChannel, event models.AlertEvent) {
+	backoff := time.Second
+	var err error
+	for attempt := 1; attempt <= alertDeliveryAttempts; attempt++ {
+		err = sendAlertToChannel(channel, event)
+		if err == nil {
+			return
+		}
+		if attempt < alertDeliveryAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	s.Errorf("[tenant: %v]deliverAlert: failed delivering alert %v to %v after %v attempts: %v", event.TenantName, event.RuleName, channel.Type, alertDeliveryAttempts, err.Error())
+}
+
+func sendAlertToChannel(channel models.AlertChannel, event models.AlertEvent) error {
+	title := fmt.Sprintf("[%v] %v", strings.ToUpper(event.State), event.RuleName)
+	dedupKey := fmt.Sprintf("memphis-%v-%v", event.TenantName, event.RuleID)
+	switch channel.Type {
+	case models.AlertChannelSlack:
+		return postAlertJson(channel.Url, nil, map[string]interface{}{
+			"text": fmt.Sprintf("*%v*\n%v", title, event.Message),
+		})
+	case models.AlertChannelPagerDuty:
+		action := "trigger"
+		if event.State == models.AlertStateOk {
+			action = "resolve"
+		}
+		return postAlertJson(pagerDutyEventsUrl, nil, map[string]interface{}{
+			"routing_key":  channel.RoutingKey,
+			"event_action": action,
+			"dedup_key":    dedupKey,
+			"payload": map[string]interface{}{
+				"summary":        title + ": " + event.Message,
+				"source":         "memphis",
+				"severity":       "error",
+				"custom_details": event,
+			},
+		})
+	case models.AlertChannelOpsgenie:
+		headers := map[string]string{"Authorization": "GenieKey " + channel.ApiKey}
+		if event.State == models.AlertStateOk {
+			return postAlertJson(opsgenieAlertsUrl+"/"+url.PathEscape(dedupKey)+"/close?identifierType=alias", headers, map[string]interface{}{
+				"source": "memphis",
+			})
+		}
+		return postAlertJson(opsgenieAlertsUrl, headers, map[string]interface{}{
+			"message":     title,
+			"alias":       dedupKey,
+			"description": event.Message,
+			"source":      "memphis",
+		})
+	case models.AlertChannelWebhook:
+		return postAlertJson(channel.Url, nil, event)
+	}
+	return fmt.Errorf("unsupported channel type %v", channel.Type)
+}
+
+func postAlertJson(endpoint string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := alertsHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%v responded with status %v", endpoint, resp.StatusCode)
+	}
+	return nil
+}
@@ -1024,7 +1024,13 @@ func (s *Server) processClientOrLeafAuthentication(c *client, opts *Options) (au
 		return true
 	}
 	if user != nil {
-		ok = comparePasswords(user.Password, c.opts.Password)
+		// ** added by Memphis
+		if isScramCredentials(c.opts.Password) {
+			ok = s.verifyScramPassword(c, c.opts.Username, user.Password)
+		} else {
+			ok = comparePasswords(user.Password, c.opts.Password)
+		}
+		// ** added by Memphis
 		// If we are authorized, register the user which will properly setup any permissions
 		// for pub/sub authorizations.
 		if ok {
@@ -1043,11 +1049,11 @@ func (s *Server) processClientOrLeafAuthentication(c *client, opts *Options) (au
 				if len(tokenSplit) != 2 {
 					return false
 				}
-				return comparePasswords(token, tokenSplit[1])
+				return s.validateConnectionToken(c, tokenSplit[0], token, tokenSplit[1])
 			}
+			nameSplit := strings.Split(c.opts.Name, connectItemSep)
+			return s.validateConnectionToken(c, nameSplit[len(nameSplit)-1], token, c.opts.Token)
 			// ** added by Memphis
-
-			return comparePasswords(token, c.opts.Token)
 		} else if username != _EMPTY_ {
 			if username != c.opts.Username {
 				return false
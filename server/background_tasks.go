@@ -31,6 +31,8 @@ import (
 )
 
 const CONN_STATUS_SUBJ = "$memphis_connection_status"
+const CONN_LIST_SUBJ = "$memphis_connections_list"
+const CONN_DISCONNECT_SUBJ = "$memphis_connection_disconnect"
 const INTEGRATIONS_UPDATES_SUBJ = "$memphis_integration_updates"
 const CONFIGURATIONS_RELOAD_SIGNAL_SUBJ = "$memphis_config_reload_signal"
 const NOTIFICATION_EVENTS_SUBJ = "$memphis_notifications"
@@ -48,6 +50,9 @@ const FUNCTIONS_DLS_CONSUMER = "$memphis_functions_dls_consumer"
 const CACHE_UDATES_SUBJ = "$memphis_cache_updates"
 const NOTIFICATIONS_BUFFER_CONSUMER = "$memphis_notifications_buffer_consumer"
 const FUNCTION_TASKS_CONSUMER = "$memphis_function_tasks_consumer"
+const STATION_STORAGE_KEYS_UPDATES_SUBJ = "$memphis_station_storage_keys_updates"
+const JOBS_UPDATES_SUBJ = "$memphis_jobs_updates"
+const STATION_READ_ONLY_UPDATES_SUBJ = "$memphis_station_read_only_updates"
 
 var LastReadThroughputMap map[string]models.Throughput
 var LastWriteThroughputMap map[string]models.Throughput
@@ -89,6 +94,32 @@ func (s *Server) ListenForZombieConnCheckRequests() error {
 	return nil
 }
 
+// ListenForConnectionsRequests answers the connections listing and force disconnect requests of the management api
+func (s *Server) ListenForConnectionsRequests() error {
+	for _, subj := range []string{CONN_LIST_SUBJ, CONN_DISCONNECT_SUBJ} {
+		_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), subj, subj+"_sid", func(_ *client, subject, reply string, msg []byte) {
+			go func(subject, reply string, msg []byte) {
+				var req connectionsRequest
+				err := json.Unmarshal(msg, &req)
+				if err != nil {
+					s.Errorf("ListenForConnectionsRequests: %v", err.Error())
+					return
+				}
+				bytes, err := json.Marshal(s.answerConnectionsRequest(subject, req))
+				if err != nil {
+					s.Errorf("[tenant: %v]ListenForConnectionsRequests: %v", req.TenantName, err.Error())
+					return
+				}
+				s.sendInternalAccountMsgWithReply(s.MemphisGlobalAccount(), reply, _EMPTY_, nil, bytes, true)
+			}(subject, reply, copyBytes(msg))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Server) ListenForCacheUpdates() error {
 	_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), CACHE_UDATES_SUBJ, CACHE_UDATES_SUBJ+"_sid", func(_ *client, subject, reply string, msg []byte) {
 		go func(msg []byte) {
@@ -137,6 +168,8 @@ func (s *Server) ListenForIntegrationsUpdateEvents() error {
 				CacheDetails("s3", integrationUpdate.Keys, integrationUpdate.Properties, integrationUpdate.TenantName)
 			case "github":
 				CacheDetails("github", integrationUpdate.Keys, integrationUpdate.Properties, integrationUpdate.TenantName)
+			case "smtp":
+				// the smtp keys are read from the db when an email is sent, there is nothing to cache
 			default:
 				s.Warnf("[tenant: %v] ListenForIntegrationsUpdateEvents: %s %s", integrationUpdate.TenantName, strings.ToLower(integrationUpdate.Name), "unknown integration")
 				return
@@ -312,6 +345,16 @@ func (s *Server) StartBackgroundTasks() error {
 		return errors.New("Failed subscribing for zombie conns check requests: " + err.Error())
 	}
 
+	err = s.ListenForConnectionsRequests()
+	if err != nil {
+		return errors.New("Failed subscribing for connections requests: " + err.Error())
+	}
+
+	err = s.ListenForSdkHeartbeats()
+	if err != nil {
+		return errors.New("Failed subscribing for sdk heartbeats: " + err.Error())
+	}
+
 	err = s.ListenForIntegrationsUpdateEvents()
 	if err != nil {
 		return errors.New("Failed subscribing for integrations updates: " + err.Error())
@@ -357,6 +400,41 @@ func (s *Server) StartBackgroundTasks() error {
 		return errors.New("Failed to subscribing for functions counter updates" + err.Error())
 	}
 
+	err = s.ListenForStationStorageKeyUpdates()
+	if err != nil {
+		return errors.New("Failed subscribing for station storage key updates: " + err.Error())
+	}
+
+	err = s.ListenForStationReadOnlyUpdates()
+	if err != nil {
+		return errors.New("Failed subscribing for station read-only updates: " + err.Error())
+	}
+
+	err = s.ListenForJobUpdates()
+	if err != nil {
+		return errors.New("Failed subscribing for jobs updates: " + err.Error())
+	}
+
+	err = s.InitializeAuditLogsExport()
+	if err != nil {
+		return errors.New("Failed initializing audit logs export: " + err.Error())
+	}
+
+	err = s.InitializeLineage()
+	if err != nil {
+		return errors.New("Failed initializing OpenLineage: " + err.Error())
+	}
+
+	err = s.InitializeExternalAuthorizer()
+	if err != nil {
+		return errors.New("Failed initializing the external authorizer: " + err.Error())
+	}
+
+	err = s.InitializeAdminPolicies()
+	if err != nil {
+		return errors.New("Failed initializing admin policies: " + err.Error())
+	}
+
 	go s.ConsumeSchemaverseDlsMessages()
 	go s.ConsumeNackedDlsMessages()
 	go s.ConsumeUnackedMsgs()
@@ -377,6 +455,28 @@ func (s *Server) StartBackgroundTasks() error {
 	go s.ScaleFunctionWorkers()
 	go s.ConnectorsDeadPodsRescheduler()
 	go s.removeOldAsyncTasks()
+	go s.RemoveExpiredConnectionTokens()
+	go s.TrackConnectionStats()
+	go s.LogSlowStationOperations()
+	go s.RemoveExpiredDynamicCredentials()
+	go s.RemoveExpiredSessions()
+	go s.StartK8sComponentsWatcher()
+	go s.EvaluateAlertRules()
+	go s.EnforceStationLifecyclePolicies()
+	subscribeToBrokerEvents(s.deliverEventToWebhooks)
+	go s.DispatchBrokerEvents()
+	go s.ManageAmqpBridges()
+	go s.ManageCdcConnectors()
+	go s.ManageClickhouseSinks()
+	go s.ManageCatalogExporters()
+	go s.ManageSchemaRegistrySyncs()
+	go s.CompactStations()
+	go s.IndexStationMessages()
+	go s.ManageStationContractReports()
+	go s.ForwardEdgeStations()
+	go s.RemoveOldJobs()
+	go s.RewrapOutdatedStationStreams()
+	backgroundTasksStarted.Store(true)
 
 	return nil
 }
@@ -702,8 +802,10 @@ func (s *Server) ConsumeNackedDlsMessages() {
 }
 
 func (s *Server) RemoveOldDlsMsgs() {
+	reportBackgroundTaskAlive("RemoveOldDlsMsgs", 2*time.Minute)
 	ticker := time.NewTicker(2 * time.Minute)
 	for range ticker.C {
+		reportBackgroundTaskAlive("RemoveOldDlsMsgs", 2*time.Minute)
 		for tenantName, rt := range s.opts.DlsRetentionHours {
 			configurationTime := time.Now().Add(time.Hour * time.Duration(-rt))
 			err := db.DeleteOldDlsMessageByRetention(configurationTime, tenantName)
@@ -714,20 +816,38 @@ func (s *Server) RemoveOldDlsMsgs() {
 	}
 }
 
+// purgeStaleProducersAndConsumers removes the producers and consumers which are disconnected since before olderThan
+func purgeStaleProducersAndConsumers(tenantName string, olderThan time.Time) (models.PurgedClients, error) {
+	purged, err := db.DeleteOldProducersAndConsumers(olderThan, tenantName)
+	if err != nil {
+		return models.PurgedClients{}, err
+	}
+	for _, stationId := range purged.StationIds {
+		producersStateForget(stationId)
+	}
+	return purged, nil
+}
+
 func (s *Server) RemoveOldProducersAndConsumersAndAuditLogs() {
 	ticker := time.NewTicker(15 * time.Minute)
 	for range ticker.C {
 		for tenantName, rt := range s.opts.GCProducersConsumersRetentionHours {
 			configurationTime := time.Now().Add(time.Hour * time.Duration(-rt))
-			err := db.DeleteOldProducersAndConsumers(configurationTime, tenantName)
+			purged, err := purgeStaleProducersAndConsumers(tenantName, configurationTime)
 			if err != nil {
-				serv.Errorf("[tenant: %v]RemoveOldProducersAndConsumersAndAuditLogs at DeleteOldProducersAndConsumers : %v", tenantName, err.Error())
+				serv.Errorf("[tenant: %v]RemoveOldProducersAndConsumersAndAuditLogs at purgeStaleProducersAndConsumers : %v", tenantName, err.Error())
+			} else if purged.Producers > 0 || purged.Consumers > 0 {
+				serv.Debugf("[tenant: %v]RemoveOldProducersAndConsumersAndAuditLogs: removed %v producers and %v consumers", tenantName, purged.Producers, purged.Consumers)
 			}
 			time := time.Now().Add(-time.Hour * 3 * 24)
 			err = db.RemoveAuditLogsByTenantAndCreatedAt(tenantName, time)
 			if err != nil {
 				serv.Errorf("[tenant: %v]RemoveOldProducersAndConsumersAndAuditLogs at RemoveAuditLogsByTenantAndCreatedAt : %v", tenantName, err.Error())
 			}
+			err = db.RemoveCgRebalancesByTenantAndCreatedAt(tenantName, time)
+			if err != nil {
+				serv.Errorf("[tenant: %v]RemoveOldProducersAndConsumersAndAuditLogs at RemoveCgRebalancesByTenantAndCreatedAt : %v", tenantName, err.Error())
+			}
 		}
 	}
 }
@@ -937,8 +1057,10 @@ func fetchMessages[T any](s *Server,
 }
 
 func (s *Server) ReleaseStuckLocks() {
+	reportBackgroundTaskAlive("ReleaseStuckLocks", 30*time.Second)
 	ticker := time.NewTicker(30 * time.Second)
 	for range ticker.C {
+		reportBackgroundTaskAlive("ReleaseStuckLocks", 30*time.Second)
 		time := time.Now().Add(-10 * time.Minute)
 		err := db.UnlockStuckLocks(time)
 		if err != nil {
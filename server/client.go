@@ -289,9 +289,12 @@ type client struct {
 
 // ** added by Memphis
 type memphisClientInfo struct {
-	username     string
-	connectionId string `json:"connection_id,omitempty"`
-	isNative     bool
+	username             string
+	connectionId         string `json:"connection_id,omitempty"`
+	isNative             bool
+	scramServerSignature string
+	scramServerFirst     string
+	compression          string
 }
 
 // ** added by Memphis
@@ -2190,6 +2193,8 @@ func (c *client) authViolation() {
 	}
 	if c.isMqtt() {
 		c.mqttEnqueueConnAck(mqttConnAckRCNotAuthorized, false)
+	} else if scramReply := c.scramServerFirstReply(); scramReply != _EMPTY_ { // ** added by Memphis
+		c.sendErr("Authorization Violation: " + scramReply)
 	} else {
 		c.sendErr("Authorization Violation")
 	}
@@ -2403,7 +2408,15 @@ func (c *client) sendErr(err string) {
 	if !c.isMqtt() {
 		c.enqueueProto([]byte(fmt.Sprintf(errProto, err)))
 	}
+	// ** added by Memphis
+	var tenantName string
+	if c.acc != nil {
+		tenantName = c.acc.GetName()
+	}
+	connectionId := c.memphisInfo.connectionId
+	// added by Memphis **
 	c.mu.Unlock()
+	recordConnectionError(tenantName, connectionId, err) // ** added by Memphis
 }
 
 func (c *client) sendOK() {
@@ -3816,6 +3829,10 @@ func (c *client) processInboundClientMsg(msg []byte) (bool, bool) {
 	// *** added by Memphis
 	accName := c.Account().GetName()
 	subj := string(c.pa.subject)
+	if stationName, readOnly := readOnlyStationOfSubject(accName, subj); readOnly {
+		c.rejectReadOnlyStationMsg(stationName, string(c.pa.reply))
+		return false, false
+	}
 	if !strings.HasPrefix(subj, "$JS") && !strings.HasPrefix(subj, "_INBOX") && accName != MEMPHIS_GLOBAL_ACCOUNT && accName != globalAccountName && accName != DEFAULT_SYSTEM_ACCOUNT {
 		hdrBytes, msgBytes := c.msgParts(msg)
 		IncrementEventCounter(accName, "produced_event", 0, 1, subj, msgBytes, hdrBytes)
@@ -301,6 +301,7 @@ type consumer struct {
 	js                *jetStream
 	mset              *stream
 	acc               *Account
+	latencyStats      *stationLatencyStats // ** added by memphis
 	srv               *Server
 	client            *client
 	sysc              *client
@@ -2683,6 +2684,7 @@ func (o *consumer) processAckMsg(sseq, dseq, dc uint64, doSample bool) {
 			if doSample {
 				o.sampleAck(sseq, dseq, dc)
 			}
+			o.recordStationOperation(stationOpAck, time.Duration(time.Now().UnixNano()-p.Timestamp), 0) // ** added by memphis
 			if o.maxp > 0 && len(o.pending) >= o.maxp {
 				needSignal = true
 			}
@@ -3888,7 +3890,9 @@ func (o *consumer) loopAndGatherMsgs(qch chan struct{}) {
 			delay      time.Duration
 			sz         int
 			wrn, wrb   int
-			redelivery bool // *** Added by Memphis
+			redelivery bool          // *** Added by Memphis
+			fetched    time.Time     // ** added by memphis
+			throttled  time.Duration // ** added by memphis
 		)
 
 		o.mu.Lock()
@@ -3913,6 +3917,7 @@ func (o *consumer) loopAndGatherMsgs(qch chan struct{}) {
 		}
 
 		// Grab our next msg.
+		fetched = time.Now()                       // ** added by memphis
 		pmsg, dc, redelivery, err = o.getNextMsg() // ** redelivery added by memphis
 
 		// We can release the lock now under getNextMsg so need to check this condition again here.
@@ -3998,6 +4003,7 @@ func (o *consumer) loopAndGatherMsgs(qch chan struct{}) {
 				case <-time.After(delay):
 				}
 				o.mu.Lock()
+				throttled += delay // ** added by memphis
 			}
 		}
 
@@ -4018,11 +4024,13 @@ func (o *consumer) loopAndGatherMsgs(qch chan struct{}) {
 				case <-time.After(delay):
 				}
 				o.mu.Lock()
+				throttled += delay // ** added by memphis
 			}
 		}
 
 		// Do actual delivery.
 		o.deliverMsg(dsubj, ackReply, pmsg, dc, rp)
+		o.recordStationOperation(stationOpConsume, time.Since(fetched)-throttled, sz) // ** added by memphis
 
 		// If given request fulfilled batch size, but there are still pending bytes, send information about it.
 		if wrn <= 0 && wrb > 0 {
@@ -26,6 +26,8 @@ var SourceCodeManagementFunctionsMap map[string]map[string]interface{}
 const PoisonMAlert = "poison_message_alert"
 const SchemaVAlert = "schema_validation_fail_alert"
 const DisconEAlert = "disconnection_events_alert"
+const StationLifecycleAlert = "station_lifecycle_alert"
+const SchemaChangeAlert = "schema_change_alert"
 
 func InitializeIntegrations() error {
 	IntegrationsConcurrentCache = NewConcurrentMap[map[string]interface{}]()
@@ -259,7 +259,13 @@ func (s *Server) decryptMeta(sc StoreCipher, ekey, buf []byte, acc, context stri
 		StoreCipher
 	}
 	var prfs []prfWithCipher
-	if prf := s.jsKeyGen(s.getOpts().JetStreamKey, acc); prf == nil {
+	// ** added by memphis - fall back to the station storage keys when no broker wide key is set
+	jsKey, jsOldKey := s.getOpts().JetStreamKey, s.getOpts().JetStreamOldKey
+	if jsKey == _EMPTY_ {
+		jsKey, jsOldKey = s.stationStoreKeys(acc, context)
+	}
+	// ** added by memphis
+	if prf := s.jsKeyGen(jsKey, acc); prf == nil {
 		return nil, false, errNoEncryption
 	} else {
 		// First of all, try our current encryption keys with both
@@ -267,7 +273,7 @@ func (s *Server) decryptMeta(sc StoreCipher, ekey, buf []byte, acc, context stri
 		prfs = append(prfs, prfWithCipher{prf, sc})
 		prfs = append(prfs, prfWithCipher{prf, osc})
 	}
-	if prf := s.jsKeyGen(s.getOpts().JetStreamOldKey, acc); prf != nil {
+	if prf := s.jsKeyGen(jsOldKey, acc); prf != nil {
 		// Then, if we have an old encryption key, try with also with
 		// both store cipher algorithms.
 		prfs = append(prfs, prfWithCipher{prf, sc})
@@ -0,0 +1,289 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// When ADMIN_POLICY_FILE is set, every management call of the api is evaluated by an embedded Rego policy before its
+// handler runs. The file, or every .rego file of a directory, is loaded on startup and ADMIN_POLICY_QUERY (by default
+// data.memphis.admin) is evaluated against an input carrying the user, the action, the request body and the stations
+// the call targets together with their tags. The result is either a boolean or an object with allow and reason, so a
+// policy can for instance allow only admins of a team to remove stations or forbid attaching schemas to stations
+// tagged prod. Every decision is logged and denials are written to the audit logs of the targeted stations.
+const (
+	adminPolicyMaxBodySize = 1024 * 1024
+	adminPolicyMaxStations = 100
+	adminPolicyLoadTimeout = 30 * time.Second
+)
+
+type adminPolicyStation struct {
+	Name   string   `json:"name"`
+	Exists bool     `json:"exists"`
+	Tags   []string `json:"tags"`
+}
+
+type adminPolicyInput struct {
+	TenantName string                 `json:"tenant_name"`
+	Username   string                 `json:"username"`
+	UserType   string                 `json:"user_type"`
+	Team       string                 `json:"team"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	Resource   string                 `json:"resource"`
+	Action     string                 `json:"action"`
+	Stations   []adminPolicyStation   `json:"stations"`
+	Body       map[string]interface{} `json:"body,omitempty"`
+}
+
+var adminPolicyQuery *rego.PreparedEvalQuery
+
+func (s *Server) InitializeAdminPolicies() error {
+	if configuration.ADMIN_POLICY_FILE == _EMPTY_ {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), adminPolicyLoadTimeout)
+	defer cancel()
+	query, err := rego.New(
+		rego.Query(configuration.ADMIN_POLICY_QUERY),
+		rego.Load([]string{configuration.ADMIN_POLICY_FILE}, nil),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load the admin policy from %v: %v", configuration.ADMIN_POLICY_FILE, err.Error())
+	}
+	adminPolicyQuery = &query
+	s.Noticef("Admin policy loaded from %v", configuration.ADMIN_POLICY_FILE)
+	return nil
+}
+
+func evaluateAdminPolicy(input adminPolicyInput) (externalAuthzDecision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(configuration.ADMIN_POLICY_TIMEOUT_MS)*time.Millisecond)
+	defer cancel()
+	// the input goes through json so the policy sees the same field names as in the docs
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	var regoInput map[string]interface{}
+	err = json.Unmarshal(raw, &regoInput)
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	rs, err := adminPolicyQuery.Eval(ctx, rego.EvalInput(regoInput))
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return externalAuthzDecision{reason: "no policy decision"}, nil
+	}
+	return parseAdminPolicyResult(rs[0].Expressions[0].Value)
+}
+
+// parseAdminPolicyResult accepts a boolean or an object with allow and reason, like the external authorizer does
+func parseAdminPolicyResult(value interface{}) (externalAuthzDecision, error) {
+	switch result := value.(type) {
+	case bool:
+		return externalAuthzDecision{allowed: result}, nil
+	case map[string]interface{}:
+		allowed, _ := result["allow"].(bool)
+		reason, _ := result["reason"].(string)
+		return externalAuthzDecision{allowed: allowed, reason: reason}, nil
+	default:
+		return externalAuthzDecision{}, fmt.Errorf("invalid admin policy result: %v", value)
+	}
+}
+
+// AdminPolicies evaluates mutating management calls against the admin policy, reads and the data plane routes
+// (gateway, ui produce) are left to the station permissions and the external authorizer
+func AdminPolicies(c *gin.Context) {
+	if adminPolicyQuery == nil || !isAdminPolicyAction(c) {
+		c.Next()
+		return
+	}
+	value, ok := c.Get("user")
+	if !ok {
+		c.Next()
+		return
+	}
+	user, ok := value.(models.User)
+	if !ok || user.Username == _EMPTY_ || strings.HasPrefix(user.Username, "$") {
+		c.Next()
+		return
+	}
+
+	input := adminPolicyInput{
+		TenantName: user.TenantName,
+		Username:   user.Username,
+		UserType:   user.UserType,
+		Team:       user.Team,
+		Method:     c.Request.Method,
+		Path:       c.FullPath(),
+		Resource:   path.Base(path.Dir(c.FullPath())),
+		Action:     path.Base(c.FullPath()),
+	}
+	body, err := readAdminPolicyBody(c)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]AdminPolicies at readAdminPolicyBody: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	input.Body = body
+	input.Stations, err = adminPolicyStations(c, user.TenantName, body)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]AdminPolicies at adminPolicyStations: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	decision, err := evaluateAdminPolicy(input)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]AdminPolicies: could not evaluate %v: %v", user.TenantName, user.Username, input.Action, err.Error())
+		if configuration.ADMIN_POLICY_FAIL_OPEN {
+			c.Next()
+			return
+		}
+		abortWithError(c, NewMemphisError(ErrCodeForbidden, fmt.Sprintf("could not authorize %v, the admin policy could not be evaluated", input.Action)))
+		return
+	}
+
+	stationNames := make([]string, 0, len(input.Stations))
+	for _, station := range input.Stations {
+		stationNames = append(stationNames, station.Name)
+	}
+	if decision.allowed {
+		serv.Noticef("[tenant: %v][user: %v]AdminPolicies: %v %v was allowed (stations: %v)", user.TenantName, user.Username, input.Method, input.Action, stationNames)
+		c.Next()
+		return
+	}
+
+	message := fmt.Sprintf("%v was denied for user %v by the admin policy", input.Action, user.Username)
+	if decision.reason != _EMPTY_ {
+		message += ": " + decision.reason
+	}
+	serv.Noticef("[tenant: %v][user: %v]AdminPolicies: %v %v was denied (stations: %v): %v", user.TenantName, user.Username, input.Method, input.Action, stationNames, decision.reason)
+	for _, station := range input.Stations {
+		if station.Exists {
+			createAuditLogFromRequest(c, user, station.Name, message)
+		}
+	}
+	abortWithError(c, NewMemphisError(ErrCodeForbidden, message))
+}
+
+func isAdminPolicyAction(c *gin.Context) bool {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+		return false
+	}
+	route := c.FullPath()
+	if route == _EMPTY_ || strings.Contains(route, "/gateway/") || strings.HasSuffix(route, "/stations/produce") {
+		return false
+	}
+	return true
+}
+
+// readAdminPolicyBody decodes a json body for the policy input and puts it back for the handler
+func readAdminPolicyBody(c *gin.Context) (map[string]interface{}, error) {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, adminPolicyMaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), c.Request.Body))
+	if len(raw) > adminPolicyMaxBodySize {
+		return nil, nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		// the handler reports malformed bodies
+		return nil, nil
+	}
+	redactAdminPolicyBody(body)
+	return body, nil
+}
+
+// redactAdminPolicyBody keeps credentials, e.g. user passwords or integration keys, from leaving the broker
+func redactAdminPolicyBody(body map[string]interface{}) {
+	for key, value := range body {
+		lowerKey := strings.ToLower(key)
+		if strings.Contains(lowerKey, "password") || strings.Contains(lowerKey, "secret") || strings.Contains(lowerKey, "token") || strings.HasSuffix(lowerKey, "_key") {
+			body[key] = "[redacted]"
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			redactAdminPolicyBody(nested)
+		}
+	}
+}
+
+// adminPolicyStations resolves the stations a call targets from station_name and station_names in the body or query
+func adminPolicyStations(c *gin.Context, tenantName string, body map[string]interface{}) ([]adminPolicyStation, error) {
+	var names []string
+	if name := c.Query("station_name"); name != _EMPTY_ {
+		names = append(names, name)
+	}
+	if name, ok := body["station_name"].(string); ok && name != _EMPTY_ {
+		names = append(names, name)
+	}
+	if list, ok := body["station_names"].([]interface{}); ok {
+		for _, item := range list {
+			if name, ok := item.(string); ok && name != _EMPTY_ {
+				names = append(names, name)
+			}
+		}
+	}
+
+	stations := []adminPolicyStation{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if len(stations) >= adminPolicyMaxStations {
+			break
+		}
+		stationName, err := StationNameFromStr(name)
+		if err != nil || seen[stationName.Ext()] {
+			continue
+		}
+		seen[stationName.Ext()] = true
+		station := adminPolicyStation{Name: stationName.Ext(), Tags: []string{}}
+		exist, dbStation, err := db.GetStationByName(stationName.Ext(), tenantName)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			station.Exists = true
+			tags, err := db.GetTagsByEntityID("station", dbStation.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, tag := range tags {
+				station.Tags = append(station.Tags, tag.Name)
+			}
+		}
+		stations = append(stations, station)
+	}
+	return stations, nil
+}
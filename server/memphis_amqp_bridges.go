@@ -0,0 +1,465 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	amqpBridgesReconcileInterval = 30 * time.Second
+	amqpBridgeMinBackoff         = time.Second
+	amqpBridgeMaxBackoff         = time.Minute
+	amqpBridgeHeartbeat          = 10 * time.Second
+	amqpBridgePrefetch           = 100
+	amqpBridgeFetchBatch         = 100
+	amqpBridgeFetchExpires       = 2 * time.Second
+	amqpBridgeConfirmTimeout     = 10 * time.Second
+	amqpBridgeProducerName       = "amqp-bridge"
+)
+
+type amqpBridgeRunner struct {
+	bridge     models.AmqpBridge
+	stop       chan struct{}
+	done       chan struct{}
+	connected  atomic.Bool
+	messages   atomic.Uint64
+	errors     atomic.Uint64
+	reconnects atomic.Uint64
+
+	mu              sync.Mutex
+	lastError       string
+	lastErrorAt     time.Time
+	lastConnectedAt time.Time
+	lastMessageAt   time.Time
+}
+
+var amqpBridges = struct {
+	sync.Mutex
+	runners map[int]*amqpBridgeRunner
+}{runners: make(map[int]*amqpBridgeRunner)}
+
+func validateAmqpBridge(bridge models.CreateAmqpBridgeSchema) error {
+	u, err := url.Parse(bridge.Url)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+		return errors.New("url must be a valid amqp:// or amqps:// url")
+	}
+	switch bridge.Direction {
+	case models.AmqpBridgeInbound:
+		if bridge.Queue == _EMPTY_ {
+			return errors.New("queue is required for inbound bridges")
+		}
+	case models.AmqpBridgeOutbound:
+		if bridge.Exchange == _EMPTY_ && bridge.RoutingKey == _EMPTY_ {
+			return errors.New("exchange or routing_key is required for outbound bridges")
+		}
+	default:
+		return fmt.Errorf("direction must be either %v or %v", models.AmqpBridgeInbound, models.AmqpBridgeOutbound)
+	}
+	return nil
+}
+
+func amqpBridgeDurableName(bridgeName string) string {
+	return getInternalConsumerName(amqpBridgeProducerName + "-" + bridgeName)
+}
+
+func (r *amqpBridgeRunner) recordError(err error) {
+	r.errors.Add(1)
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.lastErrorAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *amqpBridgeRunner) recordMessage() {
+	r.messages.Add(1)
+	r.mu.Lock()
+	r.lastMessageAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *amqpBridgeRunner) recordConnected() {
+	r.connected.Store(true)
+	r.mu.Lock()
+	r.lastConnectedAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *amqpBridgeRunner) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *amqpBridgeRunner) status() models.AmqpBridgeStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return models.AmqpBridgeStatus{
+		Connected:       r.connected.Load(),
+		MessagesBridged: r.messages.Load(),
+		Errors:          r.errors.Load(),
+		Reconnects:      r.reconnects.Load(),
+		LastError:       r.lastError,
+		LastErrorAt:     r.lastErrorAt,
+		LastConnectedAt: r.lastConnectedAt,
+		LastMessageAt:   r.lastMessageAt,
+	}
+}
+
+// getAmqpBridgeStatus returns the runtime status of a bridge, bridges run on the leader only so other nodes report them as disconnected
+func getAmqpBridgeStatus(id int) models.AmqpBridgeStatus {
+	amqpBridges.Lock()
+	runner, ok := amqpBridges.runners[id]
+	amqpBridges.Unlock()
+	if !ok {
+		return models.AmqpBridgeStatus{}
+	}
+	return runner.status()
+}
+
+func (s *Server) ManageAmqpBridges() {
+	reportBackgroundTaskAlive("ManageAmqpBridges", amqpBridgesReconcileInterval)
+	ticker := time.NewTicker(amqpBridgesReconcileInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageAmqpBridges", amqpBridgesReconcileInterval)
+		s.reconcileAmqpBridges()
+	}
+}
+
+// reconcileAmqpBridges starts the enabled bridges which are not running yet and stops the ones which were disabled or removed
+func (s *Server) reconcileAmqpBridges() {
+	desired := make(map[int]models.AmqpBridge)
+	if s.leadsBackgroundTask("ManageAmqpBridges", amqpBridgesReconcileInterval) {
+		bridges, err := db.GetEnabledAmqpBridges()
+		if err != nil {
+			s.Errorf("reconcileAmqpBridges at GetEnabledAmqpBridges: %v", err.Error())
+			return
+		}
+		for _, bridge := range bridges {
+			desired[bridge.ID] = bridge
+		}
+	}
+
+	amqpBridges.Lock()
+	defer amqpBridges.Unlock()
+	for id, runner := range amqpBridges.runners {
+		if _, ok := desired[id]; !ok {
+			close(runner.stop)
+			<-runner.done
+			delete(amqpBridges.runners, id)
+		}
+	}
+	for id, bridge := range desired {
+		if _, ok := amqpBridges.runners[id]; ok {
+			continue
+		}
+		runner := &amqpBridgeRunner{bridge: bridge, stop: make(chan struct{}), done: make(chan struct{})}
+		amqpBridges.runners[id] = runner
+		go s.runAmqpBridge(runner)
+	}
+}
+
+func (s *Server) runAmqpBridge(r *amqpBridgeRunner) {
+	defer close(r.done)
+	lineage := amqpBridgeLineageRunner(r.bridge)
+	emitLineageEvent(lineage, lineageEventStart)
+	defer emitLineageEvent(lineage, lineageEventComplete)
+	backoff := amqpBridgeMinBackoff
+	for {
+		var connected bool
+		var err error
+		if r.bridge.Direction == models.AmqpBridgeInbound {
+			connected, err = s.runInboundAmqpBridge(r)
+		} else {
+			connected, err = s.runOutboundAmqpBridge(r)
+		}
+		r.connected.Store(false)
+		if r.stopped() {
+			return
+		}
+		if err != nil {
+			r.recordError(err)
+			s.Warnf("[tenant: %v]runAmqpBridge: bridge %v: %v", r.bridge.TenantName, r.bridge.Name, err.Error())
+		}
+		if connected {
+			backoff = amqpBridgeMinBackoff
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(backoff):
+		}
+		r.reconnects.Add(1)
+		backoff *= 2
+		if backoff > amqpBridgeMaxBackoff {
+			backoff = amqpBridgeMaxBackoff
+		}
+	}
+}
+
+func (s *Server) dialAmqpBridge(bridge models.AmqpBridge) (*amqp.Connection, error) {
+	amqpUrl, err := DecryptAES(getAESKey(), bridge.Url)
+	if err != nil {
+		return nil, err
+	}
+	return amqp.DialConfig(amqpUrl, amqp.Config{
+		Heartbeat:  amqpBridgeHeartbeat,
+		Properties: amqp.Table{"connection_name": "memphis-" + amqpBridgeProducerName + "-" + bridge.Name},
+	})
+}
+
+func amqpCloseError(amqpErr *amqp.Error) error {
+	if amqpErr == nil {
+		return errors.New("amqp connection closed")
+	}
+	return amqpErr
+}
+
+func (s *Server) getAmqpBridgeStation(bridge models.AmqpBridge) (StationName, models.Station, error) {
+	stationName, err := StationNameFromStr(bridge.StationName)
+	if err != nil {
+		return StationName{}, models.Station{}, err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), bridge.TenantName)
+	if err != nil {
+		return StationName{}, models.Station{}, err
+	}
+	if !exist {
+		return StationName{}, models.Station{}, fmt.Errorf("station %v does not exist", stationName.Ext())
+	}
+	return stationName, station, nil
+}
+
+// runInboundAmqpBridge consumes a RabbitMQ queue into a station, a delivery is acked only after the station persisted it
+func (s *Server) runInboundAmqpBridge(r *amqpBridgeRunner) (bool, error) {
+	stationName, station, err := s.getAmqpBridgeStation(r.bridge)
+	if err != nil {
+		return false, err
+	}
+	conn, err := s.dialAmqpBridge(r.bridge)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	err = ch.Qos(amqpBridgePrefetch, 0, false)
+	if err != nil {
+		return false, err
+	}
+	deliveries, err := ch.Consume(r.bridge.Queue, "memphis-"+amqpBridgeProducerName+"-"+r.bridge.Name, false, false, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	r.recordConnected()
+
+	for {
+		select {
+		case <-r.stop:
+			return true, nil
+		case amqpErr := <-closed:
+			return true, amqpCloseError(amqpErr)
+		case d, ok := <-deliveries:
+			if !ok {
+				return true, errors.New("amqp deliveries channel closed")
+			}
+			_, err := s.produceToStation(r.bridge.TenantName, stationName, station, d.Body, amqpDeliveryHeaders(r.bridge, d))
+			if err != nil {
+				r.recordError(err)
+				d.Nack(false, true)
+				continue
+			}
+			err = d.Ack(false)
+			if err != nil {
+				return true, err
+			}
+			r.recordMessage()
+		}
+	}
+}
+
+func amqpDeliveryHeaders(bridge models.AmqpBridge, d amqp.Delivery) map[string]string {
+	hdrs := make(map[string]string, len(d.Headers)+4)
+	for k, v := range d.Headers {
+		switch val := v.(type) {
+		case string:
+			hdrs[k] = val
+		case []byte:
+			hdrs[k] = string(val)
+		case bool, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+			hdrs[k] = fmt.Sprint(val)
+		}
+	}
+	if d.MessageId != _EMPTY_ {
+		hdrs["amqp-message-id"] = d.MessageId
+	}
+	if d.ContentType != _EMPTY_ {
+		hdrs["content-type"] = d.ContentType
+	}
+	hdrs["$memphis_producedBy"] = amqpBridgeProducerName + "-" + bridge.Name
+	hdrs["$memphis_connectionId"] = amqpBridgeProducerName
+	return hdrs
+}
+
+// runOutboundAmqpBridge pulls a station through a durable consumer and publishes to RabbitMQ with publisher confirms,
+// a message is acked on the station only after the broker confirmed it
+func (s *Server) runOutboundAmqpBridge(r *amqpBridgeRunner) (bool, error) {
+	stationName, station, err := s.getAmqpBridgeStation(r.bridge)
+	if err != nil {
+		return false, err
+	}
+	account, err := s.lookupAccount(r.bridge.TenantName)
+	if err != nil {
+		return false, err
+	}
+	durable := amqpBridgeDurableName(r.bridge.Name)
+	streams := stationStreamsAndFilters(stationName, station)
+	for streamName, filter := range streams {
+		err = s.memphisAddConsumer(r.bridge.TenantName, streamName, &ConsumerConfig{
+			Durable:       durable,
+			DeliverPolicy: DeliverNew,
+			AckPolicy:     AckExplicit,
+			AckWait:       amqpBridgeConfirmTimeout * 3,
+			FilterSubject: filter,
+			MaxAckPending: amqpBridgeFetchBatch * 10,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	conn, err := s.dialAmqpBridge(r.bridge)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	err = ch.Confirm(false)
+	if err != nil {
+		return false, err
+	}
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	r.recordConnected()
+
+	for {
+		for streamName := range streams {
+			select {
+			case <-r.stop:
+				return true, nil
+			case amqpErr := <-closed:
+				return true, amqpCloseError(amqpErr)
+			default:
+			}
+			msgs, err := s.fetchStationMessages(account, streamName, durable, amqpBridgeFetchBatch, amqpBridgeFetchExpires)
+			if err != nil {
+				return true, err
+			}
+			for _, msg := range msgs {
+				data, ok := applyMessageTransform(station.MessageTransform, msg.Data)
+				if !ok {
+					s.ackStationMessage(account, msg)
+					continue
+				}
+				msg.Data = data
+				err = s.publishToAmqp(ch, r.bridge, msg)
+				if err != nil {
+					s.nackStationMessage(account, msg)
+					return true, err
+				}
+				s.ackStationMessage(account, msg)
+				r.recordMessage()
+			}
+		}
+	}
+}
+
+func (s *Server) publishToAmqp(ch *amqp.Channel, bridge models.AmqpBridge, msg stationFetchedMsg) error {
+	headers := make(amqp.Table, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), amqpBridgeConfirmTimeout)
+	defer cancel()
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, bridge.Exchange, bridge.RoutingKey, false, false, amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         msg.Data,
+	})
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("message was nacked by the amqp broker")
+	}
+	return nil
+}
+
+// removeAmqpBridgeConsumers deletes the durable consumers an outbound bridge created on its station
+func (s *Server) removeAmqpBridgeConsumers(bridge models.AmqpBridge) error {
+	if bridge.Direction != models.AmqpBridgeOutbound {
+		return nil
+	}
+	stationName, station, err := s.getAmqpBridgeStation(bridge)
+	if err != nil {
+		return err
+	}
+	durable := amqpBridgeDurableName(bridge.Name)
+	for streamName := range stationStreamsAndFilters(stationName, station) {
+		err = s.memphisRemoveConsumer(bridge.TenantName, streamName, durable)
+		if err != nil && !IsNatsErr(err, JSConsumerNotFoundErr) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeAmqpBridgesMetrics(mw *metricsWriter) {
+	amqpBridges.Lock()
+	defer amqpBridges.Unlock()
+	for _, runner := range amqpBridges.runners {
+		labels := []string{"tenant", runner.bridge.TenantName, "bridge", runner.bridge.Name, "direction", runner.bridge.Direction, "station", runner.bridge.StationName}
+		connected := float64(0)
+		if runner.connected.Load() {
+			connected = 1
+		}
+		mw.add("memphis_amqp_bridge_connected", "gauge", "Whether the AMQP bridge is connected to its broker", connected, labels...)
+		mw.add("memphis_amqp_bridge_messages_total", "counter", "Messages moved by the AMQP bridge", float64(runner.messages.Load()), labels...)
+		mw.add("memphis_amqp_bridge_errors_total", "counter", "Errors encountered by the AMQP bridge", float64(runner.errors.Load()), labels...)
+		mw.add("memphis_amqp_bridge_reconnects_total", "counter", "Reconnection attempts of the AMQP bridge", float64(runner.reconnects.Load()), labels...)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	auditExportBatchSize        = 100
+	auditExportMinRetryInterval = time.Second
+	auditExportMaxRetryInterval = time.Minute
+	auditExportTimeout          = 10 * time.Second
+	// facility authpriv (10), severity informational (6)
+	auditSyslogPriority = 10*8 + 6
+	auditCefSeverity    = 3
+)
+
+var auditLogsExportCh chan models.AuditLog
+
+type auditLogsSink interface {
+	// send delivers the entries in order and returns how many of them were delivered
+	send(entries [][]byte) (int, error)
+}
+
+type syslogAuditSink struct {
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+type webhookAuditSink struct {
+	url        string
+	authHeader string
+	format     string
+	client     *http.Client
+}
+
+func newAuditLogsSink() (auditLogsSink, error) {
+	switch strings.ToLower(configuration.AUDIT_EXPORT_TYPE) {
+	case "syslog":
+		endpoint, err := url.Parse(configuration.AUDIT_EXPORT_ENDPOINT)
+		if err != nil {
+			return nil, err
+		}
+		switch endpoint.Scheme {
+		case "udp", "tcp", "tls":
+		default:
+			return nil, fmt.Errorf("unsupported syslog scheme %v, use udp://, tcp:// or tls://", endpoint.Scheme)
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "memphis"
+		}
+		return &syslogAuditSink{network: endpoint.Scheme, address: endpoint.Host, hostname: hostname}, nil
+	case "webhook":
+		if !strings.HasPrefix(configuration.AUDIT_EXPORT_ENDPOINT, "https://") && !strings.HasPrefix(configuration.AUDIT_EXPORT_ENDPOINT, "http://") {
+			return nil, errors.New("webhook endpoint has to be an http(s) url")
+		}
+		return &webhookAuditSink{
+			url:        configuration.AUDIT_EXPORT_ENDPOINT,
+			authHeader: configuration.AUDIT_EXPORT_AUTH_HEADER,
+			format:     strings.ToLower(configuration.AUDIT_EXPORT_FORMAT),
+			client:     &http.Client{Timeout: auditExportTimeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audit export type %v", configuration.AUDIT_EXPORT_TYPE)
+	}
+}
+
+func (ss *syslogAuditSink) send(entries [][]byte) (int, error) {
+	if ss.conn == nil {
+		var conn net.Conn
+		var err error
+		dialer := &net.Dialer{Timeout: auditExportTimeout}
+		if ss.network == "tls" {
+			conn, err = tls.DialWithDialer(dialer, "tcp", ss.address, &tls.Config{})
+		} else {
+			conn, err = dialer.Dial(ss.network, ss.address)
+		}
+		if err != nil {
+			return 0, err
+		}
+		ss.conn = conn
+	}
+
+	for i, entry := range entries {
+		frame := fmt.Sprintf("<%d>1 %v %v memphis - audit - %s", auditSyslogPriority, time.Now().UTC().Format(time.RFC3339), ss.hostname, entry)
+		if ss.network != "udp" {
+			// RFC 6587 octet counting, so messages containing new lines are not split
+			frame = fmt.Sprintf("%d %v", len(frame), frame)
+		}
+		ss.conn.SetWriteDeadline(time.Now().Add(auditExportTimeout))
+		_, err := ss.conn.Write([]byte(frame))
+		if err != nil {
+			ss.conn.Close()
+			ss.conn = nil
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+func (ws *webhookAuditSink) send(entries [][]byte) (int, error) {
+	var body []byte
+	contentType := "application/json"
+	if ws.format == "cef" {
+		contentType = "text/plain"
+		body = bytes.Join(entries, []byte("\n"))
+	} else {
+		body = append([]byte("["), bytes.Join(entries, []byte(","))...)
+		body = append(body, ']')
+	}
+
+	req, err := http.NewRequest("POST", ws.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if ws.authHeader != _EMPTY_ {
+		req.Header.Set("Authorization", ws.authHeader)
+	}
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("webhook responded with status %v", resp.StatusCode)
+	}
+	return len(entries), nil
+}
+
+func cefHeaderEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func cefExtensionEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "=", `\=`)
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(value)
+}
+
+func formatAuditLog(auditLog models.AuditLog, format, version string) ([]byte, error) {
+	if format != "cef" {
+		return json.Marshal(auditLog)
+	}
+
+	extensions := []string{
+		"rt=" + strconv.FormatInt(auditLog.CreatedAt.UnixMilli(), 10),
+		"suid=" + strconv.Itoa(auditLog.CreatedBy),
+		"suser=" + cefExtensionEscape(auditLog.CreatedByUsername),
+		"cs1Label=tenant",
+		"cs1=" + cefExtensionEscape(auditLog.TenantName),
+	}
+	if auditLog.StationName != _EMPTY_ {
+		extensions = append(extensions, "cs2Label=station", "cs2="+cefExtensionEscape(auditLog.StationName))
+	}
+	if auditLog.RequestId != _EMPTY_ {
+		extensions = append(extensions, "cs3Label=requestId", "cs3="+cefExtensionEscape(auditLog.RequestId))
+	}
+	if auditLog.ActorIp != _EMPTY_ {
+		extensions = append(extensions, "src="+cefExtensionEscape(auditLog.ActorIp))
+	}
+	extensions = append(extensions, "msg="+cefExtensionEscape(auditLog.Message))
+
+	cef := fmt.Sprintf("CEF:0|Memphis|Memphis|%v|audit|%v|%d|%v", cefHeaderEscape(version), cefHeaderEscape(auditLog.Message), auditCefSeverity, strings.Join(extensions, " "))
+	return []byte(cef), nil
+}
+
+// exportAuditLogs hands newly written audit logs to the exporter without blocking the caller,
+// when the buffer is full the entry is dropped
+func exportAuditLogs(auditLogs []interface{}) {
+	if auditLogsExportCh == nil {
+		return
+	}
+	for _, log := range auditLogs {
+		auditLog, ok := log.(models.AuditLog)
+		if !ok {
+			continue
+		}
+		select {
+		case auditLogsExportCh <- auditLog:
+		default:
+			serv.Warnf("[tenant: %v]exportAuditLogs: export buffer is full, audit log dropped", auditLog.TenantName)
+		}
+	}
+}
+
+func (s *Server) InitializeAuditLogsExport() error {
+	if configuration.AUDIT_EXPORT_TYPE == _EMPTY_ {
+		return nil
+	}
+	format := strings.ToLower(configuration.AUDIT_EXPORT_FORMAT)
+	if format != "json" && format != "cef" {
+		return fmt.Errorf("unsupported audit export format %v", configuration.AUDIT_EXPORT_FORMAT)
+	}
+	sink, err := newAuditLogsSink()
+	if err != nil {
+		return err
+	}
+	auditLogsExportCh = make(chan models.AuditLog, configuration.AUDIT_EXPORT_BUFFER_SIZE)
+	go s.ExportAuditLogs(sink, format)
+	return nil
+}
+
+// ExportAuditLogs ships buffered audit logs to the configured sink, failed deliveries stay in the buffer
+// and are retried with an exponential backoff
+func (s *Server) ExportAuditLogs(sink auditLogsSink, format string) {
+	version := strings.TrimSpace(s.MemphisVersion())
+	retryInterval := auditExportMinRetryInterval
+	var pending [][]byte
+	for {
+		if len(pending) == 0 {
+			auditLog := <-auditLogsExportCh
+			entry, err := formatAuditLog(auditLog, format, version)
+			if err != nil {
+				s.Errorf("[tenant: %v]ExportAuditLogs at formatAuditLog: %v", auditLog.TenantName, err.Error())
+				continue
+			}
+			pending = append(pending, entry)
+		}
+	fill:
+		for len(pending) < configuration.AUDIT_EXPORT_BUFFER_SIZE {
+			select {
+			case auditLog := <-auditLogsExportCh:
+				entry, err := formatAuditLog(auditLog, format, version)
+				if err != nil {
+					s.Errorf("[tenant: %v]ExportAuditLogs at formatAuditLog: %v", auditLog.TenantName, err.Error())
+					continue
+				}
+				pending = append(pending, entry)
+			default:
+				break fill
+			}
+		}
+
+		batch := pending
+		if len(batch) > auditExportBatchSize {
+			batch = batch[:auditExportBatchSize]
+		}
+		sent, err := sink.send(batch)
+		pending = pending[sent:]
+		if err != nil {
+			s.Warnf("ExportAuditLogs: failed to export %v audit logs, retrying in %v: %v", len(pending), retryInterval, err.Error())
+			time.Sleep(retryInterval)
+			retryInterval *= 2
+			if retryInterval > auditExportMaxRetryInterval {
+				retryInterval = auditExportMaxRetryInterval
+			}
+			continue
+		}
+		retryInterval = auditExportMinRetryInterval
+	}
+}
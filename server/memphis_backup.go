@@ -0,0 +1,586 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gin-gonic/gin"
+)
+
+// A backup is a full copy of the metadata db and the messages of every station, kept under memphis/backups/<id>/ in the s3 integration of the admin's tenant.
+// Incremental backups only hold the messages stored since their parent, the metadata is always copied in full since it is small.
+// Restoring republishes the messages into freshly created streams, so sequences and timestamps start over in the restored cluster.
+const (
+	backupsPrefix          = "memphis/backups/"
+	backupManifestFile     = "manifest.json"
+	backupChunkSize        = 1000
+	backupFetchTimeout     = 3 * time.Second
+	backupRestoreWindow    = 256
+	backupRestoreAckWait   = 30 * time.Second
+	backupStatusInProgress = "in_progress"
+	backupStatusCompleted  = "completed"
+	backupStatusFailed     = "failed"
+)
+
+type BackupsHandler struct{}
+
+var (
+	backupOperationLock    sync.Mutex
+	currentBackupOperation *models.BackupOperation
+)
+
+type backupStore struct {
+	svc    *s3.Client
+	bucket string
+}
+
+func newBackupStore(tenantName string) (*backupStore, error) {
+	svc, bucketName, err := newTenantS3Client(tenantName)
+	if err == errNoS3Integration {
+		return nil, errors.New("backups are kept in s3, an s3 integration has to be configured first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &backupStore{svc: svc, bucket: bucketName}, nil
+}
+
+func (bs *backupStore) put(key string, data []byte) error {
+	_, err := manager.NewUploader(bs.svc).Upload(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bs.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (bs *backupStore) get(key string) ([]byte, error) {
+	obj, err := bs.svc.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(bs.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+func (bs *backupStore) putManifest(manifest *models.BackupManifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return bs.put(backupsPrefix+manifest.ID+"/"+backupManifestFile, data)
+}
+
+func (bs *backupStore) getManifest(id string) (models.BackupManifest, error) {
+	var manifest models.BackupManifest
+	data, err := bs.get(backupsPrefix + id + "/" + backupManifestFile)
+	if err != nil {
+		return manifest, fmt.Errorf("backup %v: %v", id, err)
+	}
+	err = json.Unmarshal(data, &manifest)
+	return manifest, err
+}
+
+// listManifests returns the backups sorted from the oldest to the newest
+func (bs *backupStore) listManifests() ([]models.BackupManifest, error) {
+	manifests := []models.BackupManifest{}
+	paginator := s3.NewListObjectsV2Paginator(bs.svc, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bs.bucket),
+		Prefix:    aws.String(backupsPrefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range page.CommonPrefixes {
+			id := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(prefix.Prefix), backupsPrefix), "/")
+			manifest, err := bs.getManifest(id)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, manifest)
+		}
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].CreatedAt.Before(manifests[j].CreatedAt) })
+	return manifests, nil
+}
+
+func startBackupOperation(opType, backupId string) (*models.BackupOperation, error) {
+	backupOperationLock.Lock()
+	defer backupOperationLock.Unlock()
+	if currentBackupOperation != nil && currentBackupOperation.Status == backupStatusInProgress {
+		return nil, fmt.Errorf("a %v of backup %v is already running", currentBackupOperation.Type, currentBackupOperation.BackupID)
+	}
+	currentBackupOperation = &models.BackupOperation{Type: opType, BackupID: backupId, Status: backupStatusInProgress, StartedAt: time.Now()}
+	return currentBackupOperation, nil
+}
+
+func finishBackupOperation(op *models.BackupOperation, err error) {
+	backupOperationLock.Lock()
+	defer backupOperationLock.Unlock()
+	op.FinishedAt = time.Now()
+	if err != nil {
+		op.Status = backupStatusFailed
+		op.Error = err.Error()
+		return
+	}
+	op.Status = backupStatusCompleted
+}
+
+func getBackupOperation() *models.BackupOperation {
+	backupOperationLock.Lock()
+	defer backupOperationLock.Unlock()
+	if currentBackupOperation == nil {
+		return nil
+	}
+	op := *currentBackupOperation
+	return &op
+}
+
+func backupStreamKey(tenantName, streamName string) string {
+	return tenantName + "/" + streamName
+}
+
+// backupStreamsState records the streams and their last sequences before the metadata snapshot is taken,
+// so the messages in the backup never reference metadata that is missing from it
+func (s *Server) backupStreamsState() ([]models.StreamBackup, error) {
+	tenants, err := db.GetAllTenants()
+	if err != nil {
+		return nil, err
+	}
+	streams := []models.StreamBackup{}
+	for _, tenant := range tenants {
+		infos, err := s.memphisAllStreamsInfo(tenant.Name)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %v: %v", tenant.Name, err)
+		}
+		for _, info := range infos {
+			// internal streams are created by the broker on startup
+			if strings.HasPrefix(info.Config.Name, "$memphis") {
+				continue
+			}
+			config, err := json.Marshal(info.Config)
+			if err != nil {
+				return nil, err
+			}
+			streams = append(streams, models.StreamBackup{
+				TenantName: tenant.Name,
+				Name:       info.Config.Name,
+				Config:     config,
+				FirstSeq:   info.State.FirstSeq,
+				LastSeq:    info.State.LastSeq,
+			})
+		}
+	}
+	return streams, nil
+}
+
+// backupStreamMessages writes the messages between fromSeq and the recorded last sequence as ndjson chunks
+func (s *Server) backupStreamMessages(store *backupStore, backupId string, stream *models.StreamBackup, fromSeq uint64) error {
+	startSeq := fromSeq
+	if stream.FirstSeq > startSeq {
+		startSeq = stream.FirstSeq
+	}
+	for startSeq <= stream.LastSeq && stream.LastSeq > 0 {
+		amount := backupChunkSize
+		if remaining := stream.LastSeq - startSeq + 1; remaining < uint64(amount) {
+			amount = int(remaining)
+		}
+		msgs, err := s.memphisGetMsgs(stream.TenantName, _EMPTY_, stream.Name, startSeq, amount, backupFetchTimeout, true, false, 1)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			// the rest of the messages were removed by the retention policy while backing up
+			break
+		}
+		sort.Slice(msgs, func(i, j int) bool { return msgs[i].Sequence < msgs[j].Sequence })
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		count := 0
+		for _, msg := range msgs {
+			if msg.Sequence > stream.LastSeq {
+				break
+			}
+			if err := encoder.Encode(msg); err != nil {
+				return err
+			}
+			count++
+		}
+		chunk := fmt.Sprintf("%v%v/streams/%v/%v.ndjson", backupsPrefix, backupId, backupStreamKey(stream.TenantName, stream.Name), startSeq)
+		if err := store.put(chunk, buf.Bytes()); err != nil {
+			return err
+		}
+		stream.Chunks = append(stream.Chunks, chunk)
+		stream.Messages += count
+		startSeq = msgs[len(msgs)-1].Sequence + 1
+	}
+	return nil
+}
+
+func (s *Server) runBackup(store *backupStore, manifest *models.BackupManifest) error {
+	parentSeqs := map[string]uint64{}
+	if manifest.Incremental {
+		manifests, err := store.listManifests()
+		if err != nil {
+			return err
+		}
+		for i := len(manifests) - 1; i >= 0; i-- {
+			if manifests[i].Status == backupStatusCompleted {
+				manifest.ParentID = manifests[i].ID
+				for _, stream := range manifests[i].Streams {
+					parentSeqs[backupStreamKey(stream.TenantName, stream.Name)] = stream.LastSeq
+				}
+				break
+			}
+		}
+		if manifest.ParentID == _EMPTY_ {
+			return errors.New("there is no completed backup to base an incremental backup on")
+		}
+	}
+
+	streams, err := s.backupStreamsState()
+	if err != nil {
+		return err
+	}
+	tables, err := db.BackupMetadata(func(table models.MetadataTableBackup, data []byte) error {
+		return store.put(fmt.Sprintf("%v%v/metadata/%v.copy", backupsPrefix, manifest.ID, table.Name), data)
+	})
+	if err != nil {
+		return fmt.Errorf("metadata: %v", err)
+	}
+	manifest.Tables = tables
+
+	for i := range streams {
+		fromSeq := uint64(1)
+		if parentSeq, ok := parentSeqs[backupStreamKey(streams[i].TenantName, streams[i].Name)]; ok {
+			fromSeq = parentSeq + 1
+		}
+		err = s.backupStreamMessages(store, manifest.ID, &streams[i], fromSeq)
+		if err != nil {
+			return fmt.Errorf("stream %v of tenant %v: %v", streams[i].Name, streams[i].TenantName, err)
+		}
+	}
+	manifest.Streams = streams
+	return nil
+}
+
+// backupChain returns the backups needed for restoring the given one, starting from its full backup
+func backupChain(store *backupStore, backupId string) ([]models.BackupManifest, error) {
+	chain := []models.BackupManifest{}
+	for id := backupId; id != _EMPTY_; {
+		manifest, err := store.getManifest(id)
+		if err != nil {
+			return nil, err
+		}
+		if manifest.Status != backupStatusCompleted {
+			return nil, fmt.Errorf("backup %v is %v", manifest.ID, manifest.Status)
+		}
+		chain = append([]models.BackupManifest{manifest}, chain...)
+		id = manifest.ParentID
+	}
+	return chain, nil
+}
+
+// restoreStreamMessages republishes messages in windows and waits for the stream to acknowledge each window
+func (s *Server) restoreStreamMessages(account *Account, msgs []StoredMsg) error {
+	reply := s.getJsApiReplySubject()
+	respCh := make(chan []byte, backupRestoreWindow)
+	sub, err := s.subscribeOnAcc(account, reply+".*", reply+"_sid", func(_ *client, _, _ string, msg []byte) {
+		select {
+		case respCh <- copyBytes(msg):
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer s.unsubscribeOnAcc(account, sub)
+
+	for start := 0; start < len(msgs); start += backupRestoreWindow {
+		end := start + backupRestoreWindow
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		for i, msg := range msgs[start:end] {
+			hdrs := map[string]string{}
+			if len(msg.Header) > 0 {
+				hdrs, err = DecodeHeader(msg.Header)
+				if err != nil {
+					return fmt.Errorf("message %v: %v", msg.Sequence, err)
+				}
+			}
+			err = s.sendInternalAccountMsgWithReply(account, msg.Subject, reply+"."+strconv.Itoa(i), hdrs, msg.Data, true)
+			if err != nil {
+				return err
+			}
+		}
+		timeout := time.NewTimer(backupRestoreAckWait)
+		for acked := start; acked < end; acked++ {
+			select {
+			case rawResp := <-respCh:
+				var resp JSPubAckResponse
+				if err := json.Unmarshal(rawResp, &resp); err != nil {
+					timeout.Stop()
+					return err
+				}
+				if err := resp.ToError(); err != nil {
+					timeout.Stop()
+					return err
+				}
+			case <-timeout.C:
+				return errors.New("timeout waiting for the restored messages to be acknowledged")
+			}
+		}
+		timeout.Stop()
+	}
+	return nil
+}
+
+func (s *Server) restoreStreamChunk(store *backupStore, account *Account, chunk string) error {
+	data, err := store.get(chunk)
+	if err != nil {
+		return err
+	}
+	msgs := []StoredMsg{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var msg StoredMsg
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return s.restoreStreamMessages(account, msgs)
+}
+
+// runRestore restores the metadata of the requested backup and rebuilds the streams from the whole backup chain.
+// The cluster has to be fresh, the brokers should be restarted afterwards so they load the restored metadata.
+func (s *Server) runRestore(store *backupStore, backupId string) error {
+	stations, err := db.GetAllStations()
+	if err != nil {
+		return err
+	}
+	if len(stations) > 0 {
+		return errors.New("backups can only be restored into a fresh cluster without stations")
+	}
+	chain, err := backupChain(store, backupId)
+	if err != nil {
+		return err
+	}
+	target := chain[len(chain)-1]
+
+	err = db.RestoreMetadata(target.Tables, func(table string) ([]byte, error) {
+		return store.get(fmt.Sprintf("%v%v/metadata/%v.copy", backupsPrefix, target.ID, table))
+	})
+	if err != nil {
+		return fmt.Errorf("metadata: %v", err)
+	}
+
+	for _, stream := range target.Streams {
+		var config StreamConfig
+		if err := json.Unmarshal(stream.Config, &config); err != nil {
+			return fmt.Errorf("stream %v of tenant %v: %v", stream.Name, stream.TenantName, err)
+		}
+		err = s.memphisAddStream(stream.TenantName, &config)
+		if err != nil && !IsNatsErr(err, JSStreamNameExistErr) {
+			return fmt.Errorf("stream %v of tenant %v: %v", stream.Name, stream.TenantName, err)
+		}
+		account, err := s.lookupAccount(stream.TenantName)
+		if err != nil {
+			return err
+		}
+		key := backupStreamKey(stream.TenantName, stream.Name)
+		for _, manifest := range chain {
+			for _, chunkStream := range manifest.Streams {
+				if backupStreamKey(chunkStream.TenantName, chunkStream.Name) != key {
+					continue
+				}
+				for _, chunk := range chunkStream.Chunks {
+					if err := s.restoreStreamChunk(store, account, chunk); err != nil {
+						return fmt.Errorf("stream %v of tenant %v: %v", stream.Name, stream.TenantName, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func backupsAdmin(c *gin.Context, funcName string) (models.User, bool) {
+	user, ok := resourceUser(c, funcName)
+	if !ok {
+		return user, false
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]%v: only management users can manage backups", user.TenantName, user.Username, funcName)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can manage backups"})
+		return user, false
+	}
+	return user, true
+}
+
+func (bh BackupsHandler) GetBackups(c *gin.Context) {
+	user, ok := backupsAdmin(c, "GetBackups")
+	if !ok {
+		return
+	}
+	store, err := newBackupStore(user.TenantName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetBackups at newBackupStore: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	manifests, err := store.listManifests()
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetBackups at listManifests: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, models.GetBackupsResponse{Backups: manifests, Operation: getBackupOperation()})
+}
+
+func (bh BackupsHandler) CreateBackup(c *gin.Context) {
+	var body models.CreateBackupSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := backupsAdmin(c, "CreateBackup")
+	if !ok {
+		return
+	}
+	store, err := newBackupStore(user.TenantName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateBackup at newBackupStore: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	manifest := models.BackupManifest{
+		ID:          time.Now().UTC().Format("20060102T150405Z"),
+		Incremental: body.Incremental,
+		Status:      backupStatusInProgress,
+		CreatedBy:   user.Username,
+		CreatedAt:   time.Now(),
+	}
+	op, err := startBackupOperation("backup", manifest.ID)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateBackup: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	err = store.putManifest(&manifest)
+	if err != nil {
+		finishBackupOperation(op, err)
+		serv.Errorf("[tenant: %v][user: %v]CreateBackup at putManifest: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	go func() {
+		err := serv.runBackup(store, &manifest)
+		manifest.CompletedAt = time.Now()
+		manifest.Status = backupStatusCompleted
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateBackup at runBackup: backup %v: %v", user.TenantName, user.Username, manifest.ID, err.Error())
+			manifest.Status = backupStatusFailed
+			manifest.Error = err.Error()
+		}
+		if errPut := store.putManifest(&manifest); errPut != nil && err == nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateBackup at putManifest: backup %v: %v", user.TenantName, user.Username, manifest.ID, errPut.Error())
+			err = errPut
+		}
+		finishBackupOperation(op, err)
+	}()
+
+	message := fmt.Sprintf("Backup %v has been started by user %v", manifest.ID, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, manifest)
+}
+
+func (bh BackupsHandler) RestoreBackup(c *gin.Context) {
+	var body models.RestoreBackupSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := backupsAdmin(c, "RestoreBackup")
+	if !ok {
+		return
+	}
+	store, err := newBackupStore(user.TenantName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RestoreBackup at newBackupStore: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	if _, err = backupChain(store, body.BackupID); err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RestoreBackup at backupChain: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	op, err := startBackupOperation("restore", body.BackupID)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RestoreBackup: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	message := fmt.Sprintf("Restore of backup %v has been started by user %v", body.BackupID, user.Username)
+	// no audit log, the audit logs table is replaced by the restore
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+
+	go func() {
+		err := serv.runRestore(store, body.BackupID)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RestoreBackup at runRestore: backup %v: %v", user.TenantName, user.Username, body.BackupID, err.Error())
+		} else {
+			serv.Noticef("Backup %v has been restored, restart the brokers to load the restored metadata", body.BackupID)
+		}
+		finishBackupOperation(op, err)
+	}()
+
+	c.IndentedJSON(200, getBackupOperation())
+}
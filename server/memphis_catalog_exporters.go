@@ -0,0 +1,691 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	catalogExportersTickInterval  = 30 * time.Second
+	catalogExporterRequestTimeout = 30 * time.Second
+	catalogExporterDefaultSec     = 3600
+	catalogExporterMinSec         = 60
+	catalogExporterMaxSec         = 7 * 24 * 3600
+	catalogExporterDefaultService = "memphis"
+	catalogExporterTagsGroup      = "Memphis"
+	dataHubPlatformUrn            = "urn:li:dataPlatform:memphis"
+)
+
+// catalogStation is the catalog view of a station, shared by all the exporters
+type catalogStation struct {
+	Name           string
+	Owner          string
+	Tags           []string
+	Partitions     int
+	Replicas       int
+	RetentionType  string
+	RetentionValue int
+	StorageType    string
+	SchemaName     string
+	SchemaType     string
+	SchemaVersion  int
+	SchemaContent  string
+	CreatedAt      time.Time
+}
+
+// catalogClient publishes stations to a data catalog, upserts are idempotent so every sync sends the full estate
+type catalogClient interface {
+	prepare(ctx context.Context, stations []catalogStation) error
+	upsertStation(ctx context.Context, station catalogStation) error
+	removeStation(ctx context.Context, stationName string) error
+}
+
+type catalogExporterState struct {
+	exporter   models.CatalogExporter
+	syncing    atomic.Bool
+	syncs      atomic.Uint64
+	errors     atomic.Uint64
+	lastRunAt  time.Time
+	mu         sync.Mutex
+	exported   map[string]bool
+	lastSyncAt time.Time
+	lastError  string
+	lastErrAt  time.Time
+}
+
+var catalogExporters = struct {
+	sync.Mutex
+	states map[int]*catalogExporterState
+}{states: make(map[int]*catalogExporterState)}
+
+func validateCatalogExporterInterval(intervalSec int) error {
+	if intervalSec < catalogExporterMinSec || intervalSec > catalogExporterMaxSec {
+		return fmt.Errorf("interval_sec must be between %v and %v", catalogExporterMinSec, catalogExporterMaxSec)
+	}
+	return nil
+}
+
+// validateCatalogExporter checks the exporter definition and fills in the defaults of the optional fields
+func validateCatalogExporter(exporter *models.CreateCatalogExporterSchema) error {
+	if exporter.Type != models.CatalogExporterOpenMetadata && exporter.Type != models.CatalogExporterDataHub {
+		return fmt.Errorf("type must be either %v or %v", models.CatalogExporterOpenMetadata, models.CatalogExporterDataHub)
+	}
+	u, err := url.Parse(exporter.Url)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("url must be a valid http:// or https:// url")
+	}
+	exporter.Url = strings.TrimSuffix(exporter.Url, "/")
+	if exporter.Type == models.CatalogExporterOpenMetadata && exporter.Token == _EMPTY_ {
+		return errors.New("token is required for OpenMetadata, use the token of a bot account")
+	}
+	if exporter.ServiceName == _EMPTY_ {
+		exporter.ServiceName = catalogExporterDefaultService
+	}
+	if strings.ContainsAny(exporter.ServiceName, ".,()\" ") {
+		return errors.New("service_name must not contain dots, commas, quotes, spaces or parentheses")
+	}
+	if exporter.IntervalSec == 0 {
+		exporter.IntervalSec = catalogExporterDefaultSec
+	}
+	return validateCatalogExporterInterval(exporter.IntervalSec)
+}
+
+func (st *catalogExporterState) recordError(err error) {
+	st.errors.Add(1)
+	st.mu.Lock()
+	st.lastError = err.Error()
+	st.lastErrAt = time.Now()
+	st.mu.Unlock()
+}
+
+func (st *catalogExporterState) status() models.CatalogExporterStatus {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return models.CatalogExporterStatus{
+		Syncing:          st.syncing.Load(),
+		Syncs:            st.syncs.Load(),
+		Errors:           st.errors.Load(),
+		StationsExported: len(st.exported),
+		LastSyncAt:       st.lastSyncAt,
+		LastError:        st.lastError,
+		LastErrorAt:      st.lastErrAt,
+	}
+}
+
+// getCatalogExporterStatus returns the sync status of an exporter, exporters run on the leader only
+func getCatalogExporterStatus(id int) models.CatalogExporterStatus {
+	catalogExporters.Lock()
+	state, ok := catalogExporters.states[id]
+	catalogExporters.Unlock()
+	if !ok {
+		return models.CatalogExporterStatus{}
+	}
+	return state.status()
+}
+
+func (s *Server) ManageCatalogExporters() {
+	reportBackgroundTaskAlive("ManageCatalogExporters", catalogExportersTickInterval)
+	ticker := time.NewTicker(catalogExportersTickInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageCatalogExporters", catalogExportersTickInterval)
+		s.scheduleCatalogExporters(0)
+	}
+}
+
+// scheduleCatalogExporters starts the syncs which are due, forceId starts the sync of that exporter regardless of its interval
+func (s *Server) scheduleCatalogExporters(forceId int) {
+	desired := make(map[int]models.CatalogExporter)
+	if s.leadsBackgroundTask("ManageCatalogExporters", catalogExportersTickInterval) {
+		exporters, err := db.GetEnabledCatalogExporters()
+		if err != nil {
+			s.Errorf("scheduleCatalogExporters at GetEnabledCatalogExporters: %v", err.Error())
+			return
+		}
+		for _, exporter := range exporters {
+			desired[exporter.ID] = exporter
+		}
+	}
+
+	catalogExporters.Lock()
+	defer catalogExporters.Unlock()
+	for id := range catalogExporters.states {
+		if _, ok := desired[id]; !ok {
+			delete(catalogExporters.states, id)
+		}
+	}
+	for id, exporter := range desired {
+		state, ok := catalogExporters.states[id]
+		if !ok {
+			state = &catalogExporterState{exporter: exporter, exported: make(map[string]bool)}
+			catalogExporters.states[id] = state
+		}
+		state.exporter = exporter
+		due := time.Since(state.lastRunAt) >= time.Duration(exporter.IntervalSec)*time.Second
+		if (due || id == forceId) && state.syncing.CompareAndSwap(false, true) {
+			state.lastRunAt = time.Now()
+			go s.runCatalogExport(state, exporter)
+		}
+	}
+}
+
+func newCatalogClient(exporter models.CatalogExporter) (catalogClient, error) {
+	token := _EMPTY_
+	if exporter.Token != _EMPTY_ {
+		decrypted, err := DecryptAES(getAESKey(), exporter.Token)
+		if err != nil {
+			return nil, err
+		}
+		token = decrypted
+	}
+	base := catalogHttpClient{baseUrl: exporter.Url, token: token, http: &http.Client{Timeout: catalogExporterRequestTimeout}}
+	if exporter.Type == models.CatalogExporterDataHub {
+		return &dataHubClient{catalogHttpClient: base, instance: exporter.ServiceName}, nil
+	}
+	return &openMetadataClient{catalogHttpClient: base, service: exporter.ServiceName}, nil
+}
+
+// runCatalogExport upserts every station of the tenant and removes the ones exported by the previous sync which are
+// gone, the exported set lives in memory so stations removed while the broker was down stay in the catalog
+func (s *Server) runCatalogExport(state *catalogExporterState, exporter models.CatalogExporter) {
+	defer state.syncing.Store(false)
+	err := s.exportCatalog(state, exporter)
+	if err != nil {
+		state.recordError(err)
+		s.Warnf("[tenant: %v]runCatalogExport: exporter %v: %v", exporter.TenantName, exporter.Name, err.Error())
+		return
+	}
+	state.syncs.Add(1)
+}
+
+func (s *Server) exportCatalog(state *catalogExporterState, exporter models.CatalogExporter) error {
+	client, err := newCatalogClient(exporter)
+	if err != nil {
+		return err
+	}
+	stations, err := collectCatalogStations(exporter.TenantName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), catalogExporterRequestTimeout*time.Duration(len(stations)+2))
+	defer cancel()
+	err = client.prepare(ctx, stations)
+	if err != nil {
+		return err
+	}
+
+	exported := make(map[string]bool, len(stations))
+	var firstErr error
+	for _, station := range stations {
+		err = client.upsertStation(ctx, station)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("station %v: %v", station.Name, err.Error())
+			}
+			continue
+		}
+		exported[station.Name] = true
+	}
+
+	state.mu.Lock()
+	previous := state.exported
+	state.mu.Unlock()
+	for stationName := range previous {
+		if exported[stationName] {
+			continue
+		}
+		err = client.removeStation(ctx, stationName)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("station %v: %v", stationName, err.Error())
+		}
+	}
+
+	state.mu.Lock()
+	state.exported = exported
+	state.lastSyncAt = time.Now()
+	state.mu.Unlock()
+	return firstErr
+}
+
+func collectCatalogStations(tenantName string) ([]catalogStation, error) {
+	stations, err := db.GetActiveStationsPerTenant(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := db.GetAllUsedStationsTags(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	stationTags := make(map[int][]string)
+	for _, tag := range tags {
+		for _, stationId := range tag.Stations {
+			stationTags[stationId] = append(stationTags[stationId], tag.Name)
+		}
+	}
+
+	catalogStations := make([]catalogStation, 0, len(stations))
+	for _, station := range stations {
+		partitions := len(station.PartitionsList)
+		if partitions == 0 {
+			partitions = 1
+		}
+		cs := catalogStation{
+			Name:           station.Name,
+			Owner:          station.CreatedByUsername,
+			Tags:           stationTags[station.ID],
+			Partitions:     partitions,
+			Replicas:       station.Replicas,
+			RetentionType:  station.RetentionType,
+			RetentionValue: station.RetentionValue,
+			StorageType:    station.StorageType,
+			CreatedAt:      station.CreatedAt,
+		}
+		sort.Strings(cs.Tags)
+		if station.SchemaName != _EMPTY_ {
+			exist, schema, err := db.GetSchemaByName(station.SchemaName, tenantName)
+			if err != nil {
+				return nil, err
+			}
+			if exist {
+				exist, version, err := db.GetSchemaVersionByNumberAndID(station.SchemaVersionNumber, schema.ID)
+				if err != nil {
+					return nil, err
+				}
+				if exist {
+					cs.SchemaName = schema.Name
+					cs.SchemaType = schema.Type
+					cs.SchemaVersion = version.VersionNumber
+					cs.SchemaContent = version.SchemaContent
+				}
+			}
+		}
+		catalogStations = append(catalogStations, cs)
+	}
+	return catalogStations, nil
+}
+
+type jsonSchemaField struct {
+	Name string
+	Type string
+}
+
+// topLevelJsonSchemaFields lists the top level properties of a json schema, catalogs show them as the dataset columns
+func topLevelJsonSchemaFields(content string) []jsonSchemaField {
+	var schema struct {
+		Properties map[string]struct {
+			Type interface{} `json:"type"`
+		} `json:"properties"`
+	}
+	if json.Unmarshal([]byte(content), &schema) != nil {
+		return nil
+	}
+	fields := make([]jsonSchemaField, 0, len(schema.Properties))
+	for name, property := range schema.Properties {
+		fieldType := "string"
+		switch t := property.Type.(type) {
+		case string:
+			fieldType = t
+		case []interface{}:
+			for _, v := range t {
+				if str, ok := v.(string); ok && str != "null" {
+					fieldType = str
+					break
+				}
+			}
+		}
+		fields = append(fields, jsonSchemaField{Name: name, Type: fieldType})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+type catalogHttpClient struct {
+	baseUrl string
+	token   string
+	http    *http.Client
+}
+
+var errCatalogNotFound = errors.New("not found in the catalog")
+
+func (cc catalogHttpClient) do(ctx context.Context, method, path string, body interface{}, out interface{}, headers map[string]string) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, cc.baseUrl+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cc.token != _EMPTY_ {
+		req.Header.Set("Authorization", "Bearer "+cc.token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := cc.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errCatalogNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errMsg := strings.TrimSpace(string(respBody))
+		if len(errMsg) > 512 {
+			errMsg = errMsg[:512]
+		}
+		return fmt.Errorf("%v %v responded with status %v: %v", method, path, resp.StatusCode, errMsg)
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// openMetadataClient registers the stations as topics of a custom messaging service
+type openMetadataClient struct {
+	catalogHttpClient
+	service string
+	userIds map[string]string
+}
+
+// openMetadataFqnPart quotes a name which holds a dot, the way OpenMetadata builds fully qualified names
+func openMetadataFqnPart(name string) string {
+	if strings.Contains(name, ".") {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+func openMetadataSchemaType(schemaType string) string {
+	switch schemaType {
+	case "json":
+		return "JSON"
+	case "avro":
+		return "Avro"
+	case "protobuf":
+		return "Protobuf"
+	default:
+		return "Other"
+	}
+}
+
+func (oc *openMetadataClient) prepare(ctx context.Context, stations []catalogStation) error {
+	err := oc.do(ctx, http.MethodPut, "/api/v1/services/messagingServices", map[string]interface{}{
+		"name":        oc.service,
+		"serviceType": "CustomMessaging",
+		"description": "Memphis stations",
+		"connection": map[string]interface{}{
+			"config": map[string]interface{}{"type": "CustomMessaging"},
+		},
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string]bool)
+	for _, station := range stations {
+		for _, tag := range station.Tags {
+			tags[tag] = true
+		}
+	}
+	if len(tags) > 0 {
+		err = oc.do(ctx, http.MethodPut, "/api/v1/classifications", map[string]interface{}{
+			"name":        catalogExporterTagsGroup,
+			"description": "Tags of Memphis stations",
+		}, nil, nil)
+		if err != nil {
+			return err
+		}
+		for tag := range tags {
+			err = oc.do(ctx, http.MethodPut, "/api/v1/tags", map[string]interface{}{
+				"classification": catalogExporterTagsGroup,
+				"name":           tag,
+				"description":    "Memphis tag " + tag,
+			}, nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	oc.userIds = make(map[string]string)
+	return nil
+}
+
+// userId resolves a memphis username to an OpenMetadata user, owners without a catalog account are left out
+func (oc *openMetadataClient) userId(ctx context.Context, username string) (string, error) {
+	if id, ok := oc.userIds[username]; ok {
+		return id, nil
+	}
+	var user struct {
+		ID string `json:"id"`
+	}
+	err := oc.do(ctx, http.MethodGet, "/api/v1/users/name/"+url.PathEscape(strings.ToLower(username)), nil, &user, nil)
+	if err != nil && err != errCatalogNotFound {
+		return _EMPTY_, err
+	}
+	oc.userIds[username] = user.ID
+	return user.ID, nil
+}
+
+func (oc *openMetadataClient) upsertStation(ctx context.Context, station catalogStation) error {
+	topic := map[string]interface{}{
+		"name":              station.Name,
+		"service":           oc.service,
+		"partitions":        station.Partitions,
+		"replicationFactor": station.Replicas,
+		"cleanupPolicies":   []string{"delete"},
+		"topicConfig": map[string]interface{}{
+			"retention_type":  station.RetentionType,
+			"retention_value": station.RetentionValue,
+			"storage_type":    station.StorageType,
+		},
+	}
+	if station.RetentionType == "message_age_sec" {
+		topic["retentionTime"] = float64(station.RetentionValue) * 1000
+	}
+	if station.SchemaContent != _EMPTY_ {
+		topic["messageSchema"] = map[string]interface{}{
+			"schemaText": station.SchemaContent,
+			"schemaType": openMetadataSchemaType(station.SchemaType),
+		}
+	}
+	tagLabels := make([]map[string]interface{}, 0, len(station.Tags))
+	for _, tag := range station.Tags {
+		tagLabels = append(tagLabels, map[string]interface{}{
+			"tagFQN":    catalogExporterTagsGroup + "." + openMetadataFqnPart(tag),
+			"source":    "Classification",
+			"labelType": "Automated",
+			"state":     "Confirmed",
+		})
+	}
+	topic["tags"] = tagLabels
+	if station.Owner != _EMPTY_ {
+		id, err := oc.userId(ctx, station.Owner)
+		if err != nil {
+			return err
+		}
+		if id != _EMPTY_ {
+			topic["owners"] = []map[string]interface{}{{"id": id, "type": "user"}}
+		}
+	}
+	return oc.do(ctx, http.MethodPut, "/api/v1/topics", topic, nil, nil)
+}
+
+func (oc *openMetadataClient) removeStation(ctx context.Context, stationName string) error {
+	fqn := openMetadataFqnPart(oc.service) + "." + openMetadataFqnPart(stationName)
+	err := oc.do(ctx, http.MethodDelete, "/api/v1/topics/name/"+url.PathEscape(fqn)+"?hardDelete=false", nil, nil, nil)
+	if err == errCatalogNotFound {
+		return nil
+	}
+	return err
+}
+
+// dataHubClient ingests the stations as datasets of a memphis platform through the GMS rest api
+type dataHubClient struct {
+	catalogHttpClient
+	instance string
+}
+
+func (dc *dataHubClient) datasetUrn(stationName string) string {
+	return fmt.Sprintf("urn:li:dataset:(%v,%v.%v,PROD)", dataHubPlatformUrn, dc.instance, stationName)
+}
+
+func (dc *dataHubClient) ingestAspect(ctx context.Context, urn, aspectName string, aspect interface{}) error {
+	value, err := json.Marshal(aspect)
+	if err != nil {
+		return err
+	}
+	return dc.do(ctx, http.MethodPost, "/aspects?action=ingestProposal", map[string]interface{}{
+		"proposal": map[string]interface{}{
+			"entityType": "dataset",
+			"entityUrn":  urn,
+			"changeType": "UPSERT",
+			"aspectName": aspectName,
+			"aspect": map[string]interface{}{
+				"value":       string(value),
+				"contentType": "application/json",
+			},
+		},
+	}, nil, map[string]string{"X-RestLi-Protocol-Version": "2.0.0"})
+}
+
+func dataHubFieldType(jsonType string) string {
+	switch jsonType {
+	case "integer", "number":
+		return "com.linkedin.schema.NumberType"
+	case "boolean":
+		return "com.linkedin.schema.BooleanType"
+	case "object":
+		return "com.linkedin.schema.RecordType"
+	case "array":
+		return "com.linkedin.schema.ArrayType"
+	default:
+		return "com.linkedin.schema.StringType"
+	}
+}
+
+func (dc *dataHubClient) prepare(ctx context.Context, stations []catalogStation) error {
+	return nil
+}
+
+func (dc *dataHubClient) upsertStation(ctx context.Context, station catalogStation) error {
+	urn := dc.datasetUrn(station.Name)
+	now := time.Now().UnixMilli()
+	err := dc.ingestAspect(ctx, urn, "status", map[string]interface{}{"removed": false})
+	if err != nil {
+		return err
+	}
+	err = dc.ingestAspect(ctx, urn, "subTypes", map[string]interface{}{"typeNames": []string{"Topic"}})
+	if err != nil {
+		return err
+	}
+	err = dc.ingestAspect(ctx, urn, "datasetProperties", map[string]interface{}{
+		"name": station.Name,
+		"customProperties": map[string]string{
+			"partitions":      strconv.Itoa(station.Partitions),
+			"replicas":        strconv.Itoa(station.Replicas),
+			"retention_type":  station.RetentionType,
+			"retention_value": strconv.Itoa(station.RetentionValue),
+			"storage_type":    station.StorageType,
+		},
+		"created": map[string]interface{}{"time": station.CreatedAt.UnixMilli()},
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := make([]map[string]string, 0, len(station.Tags))
+	for _, tag := range station.Tags {
+		tags = append(tags, map[string]string{"tag": "urn:li:tag:" + tag})
+	}
+	err = dc.ingestAspect(ctx, urn, "globalTags", map[string]interface{}{"tags": tags})
+	if err != nil {
+		return err
+	}
+	if station.Owner != _EMPTY_ {
+		err = dc.ingestAspect(ctx, urn, "ownership", map[string]interface{}{
+			"owners":       []map[string]string{{"owner": "urn:li:corpuser:" + station.Owner, "type": "DATAOWNER"}},
+			"lastModified": map[string]interface{}{"time": now, "actor": "urn:li:corpuser:memphis"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if station.SchemaContent == _EMPTY_ {
+		return nil
+	}
+	fields := []map[string]interface{}{}
+	if station.SchemaType == "json" {
+		for _, field := range topLevelJsonSchemaFields(station.SchemaContent) {
+			fields = append(fields, map[string]interface{}{
+				"fieldPath":      field.Name,
+				"nativeDataType": field.Type,
+				"type":           map[string]interface{}{"type": map[string]interface{}{dataHubFieldType(field.Type): map[string]interface{}{}}},
+			})
+		}
+	}
+	hash := sha256.Sum256([]byte(station.SchemaContent))
+	return dc.ingestAspect(ctx, urn, "schemaMetadata", map[string]interface{}{
+		"schemaName":     station.SchemaName,
+		"platform":       dataHubPlatformUrn,
+		"version":        station.SchemaVersion,
+		"hash":           hex.EncodeToString(hash[:]),
+		"platformSchema": map[string]interface{}{"com.linkedin.schema.OtherSchema": map[string]string{"rawSchema": station.SchemaContent}},
+		"fields":         fields,
+	})
+}
+
+func (dc *dataHubClient) removeStation(ctx context.Context, stationName string) error {
+	return dc.ingestAspect(ctx, dc.datasetUrn(stationName), "status", map[string]interface{}{"removed": true})
+}
+
+func (s *Server) writeCatalogExportersMetrics(mw *metricsWriter) {
+	catalogExporters.Lock()
+	defer catalogExporters.Unlock()
+	for _, state := range catalogExporters.states {
+		labels := []string{"tenant", state.exporter.TenantName, "exporter", state.exporter.Name, "type", state.exporter.Type}
+		mw.add("memphis_catalog_exporter_syncs_total", "counter", "Successful syncs of the catalog exporter", float64(state.syncs.Load()), labels...)
+		mw.add("memphis_catalog_exporter_errors_total", "counter", "Failed syncs of the catalog exporter", float64(state.errors.Load()), labels...)
+		mw.add("memphis_catalog_exporter_stations", "gauge", "Stations published by the last sync of the catalog exporter", float64(state.status().StationsExported), labels...)
+	}
+}
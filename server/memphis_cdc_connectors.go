@@ -0,0 +1,811 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/jackc/pgx/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	cdcConnectorsReconcileInterval = 30 * time.Second
+	cdcMinBackoff                  = time.Second
+	cdcMaxBackoff                  = time.Minute
+	cdcPostgresPollInterval        = time.Second
+	cdcPostgresBatchChanges        = 500
+	cdcPositionSaveInterval        = 5 * time.Second
+	cdcSourceConnectTimeout        = 10 * time.Second
+	cdcProducerName                = "cdc"
+	cdcPostgresPlugin              = "wal2json"
+	cdcPostgresTimestampLayout     = "2006-01-02 15:04:05.999999-07"
+)
+
+var cdcSlotNameInvalidChars = regexp.MustCompile(`[^a-z0-9_]`)
+
+type cdcConnectorRunner struct {
+	connector  models.CdcConnector
+	stop       chan struct{}
+	done       chan struct{}
+	connected  atomic.Bool
+	events     atomic.Uint64
+	errors     atomic.Uint64
+	reconnects atomic.Uint64
+
+	mu          sync.Mutex
+	position    string
+	lastError   string
+	lastErrorAt time.Time
+	lastEventAt time.Time
+}
+
+var cdcConnectors = struct {
+	sync.Mutex
+	runners map[int]*cdcConnectorRunner
+}{runners: make(map[int]*cdcConnectorRunner)}
+
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type wal2jsonChange struct {
+	Action    string           `json:"action"`
+	Timestamp string           `json:"timestamp"`
+	Schema    string           `json:"schema"`
+	Table     string           `json:"table"`
+	Columns   []wal2jsonColumn `json:"columns"`
+	Identity  []wal2jsonColumn `json:"identity"`
+	Pk        []wal2jsonColumn `json:"pk"`
+}
+
+type mongoChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	Ns            struct {
+		Db   string `bson:"db"`
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey              bson.Raw            `bson:"documentKey"`
+	FullDocument             bson.Raw            `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.Raw            `bson:"fullDocumentBeforeChange"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+}
+
+func validateCdcConnector(connector models.CreateCdcConnectorSchema) error {
+	switch connector.SourceType {
+	case models.CdcSourcePostgres:
+		if !strings.HasPrefix(connector.ConnectionUrl, "postgres://") && !strings.HasPrefix(connector.ConnectionUrl, "postgresql://") {
+			return errors.New("connection_url must be a postgres:// url")
+		}
+		for _, table := range connector.Tables {
+			if len(strings.Split(table, ".")) != 2 {
+				return fmt.Errorf("table %v must be given as schema.table", table)
+			}
+		}
+	case models.CdcSourceMongoDB:
+		if !strings.HasPrefix(connector.ConnectionUrl, "mongodb://") && !strings.HasPrefix(connector.ConnectionUrl, "mongodb+srv://") {
+			return errors.New("connection_url must be a mongodb:// or mongodb+srv:// url")
+		}
+		if connector.Database == _EMPTY_ {
+			return errors.New("database is required for mongodb sources")
+		}
+	default:
+		return fmt.Errorf("source_type must be either %v or %v", models.CdcSourcePostgres, models.CdcSourceMongoDB)
+	}
+	return nil
+}
+
+// cdcSlotName derives a replication slot name, slots only allow lower case letters, digits and underscores
+func cdcSlotName(tenantName, connectorName string) string {
+	name := cdcSlotNameInvalidChars.ReplaceAllString(strings.ToLower("memphis_cdc_"+tenantName+"_"+connectorName), "_")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+func (r *cdcConnectorRunner) recordError(err error) {
+	r.errors.Add(1)
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.lastErrorAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *cdcConnectorRunner) recordEvent(position string) {
+	r.events.Add(1)
+	r.mu.Lock()
+	r.position = position
+	r.lastEventAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *cdcConnectorRunner) getPosition() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.position
+}
+
+func (r *cdcConnectorRunner) status() models.CdcConnectorStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return models.CdcConnectorStatus{
+		Connected:      r.connected.Load(),
+		EventsProduced: r.events.Load(),
+		Errors:         r.errors.Load(),
+		Reconnects:     r.reconnects.Load(),
+		Position:       r.position,
+		LastError:      r.lastError,
+		LastErrorAt:    r.lastErrorAt,
+		LastEventAt:    r.lastEventAt,
+	}
+}
+
+func getCdcConnectorStatus(connector models.CdcConnector) models.CdcConnectorStatus {
+	cdcConnectors.Lock()
+	runner, ok := cdcConnectors.runners[connector.ID]
+	cdcConnectors.Unlock()
+	if !ok {
+		return models.CdcConnectorStatus{Position: connector.Position}
+	}
+	return runner.status()
+}
+
+func (s *Server) ManageCdcConnectors() {
+	reportBackgroundTaskAlive("ManageCdcConnectors", cdcConnectorsReconcileInterval)
+	ticker := time.NewTicker(cdcConnectorsReconcileInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageCdcConnectors", cdcConnectorsReconcileInterval)
+		s.reconcileCdcConnectors()
+	}
+}
+
+// reconcileCdcConnectors runs the enabled connectors on the leader only, a source must have a single reader
+func (s *Server) reconcileCdcConnectors() {
+	desired := make(map[int]models.CdcConnector)
+	if s.leadsBackgroundTask("ManageCdcConnectors", cdcConnectorsReconcileInterval) {
+		connectors, err := db.GetEnabledCdcConnectors()
+		if err != nil {
+			s.Errorf("reconcileCdcConnectors at GetEnabledCdcConnectors: %v", err.Error())
+			return
+		}
+		for _, connector := range connectors {
+			desired[connector.ID] = connector
+		}
+	}
+
+	cdcConnectors.Lock()
+	defer cdcConnectors.Unlock()
+	for id, runner := range cdcConnectors.runners {
+		if _, ok := desired[id]; !ok {
+			close(runner.stop)
+			<-runner.done
+			delete(cdcConnectors.runners, id)
+		}
+	}
+	for id, connector := range desired {
+		if _, ok := cdcConnectors.runners[id]; ok {
+			continue
+		}
+		runner := &cdcConnectorRunner{connector: connector, position: connector.Position, stop: make(chan struct{}), done: make(chan struct{})}
+		cdcConnectors.runners[id] = runner
+		go s.runCdcConnector(runner)
+	}
+}
+
+func (s *Server) runCdcConnector(r *cdcConnectorRunner) {
+	defer close(r.done)
+	lineage := cdcConnectorLineageRunner(r.connector)
+	emitLineageEvent(lineage, lineageEventStart)
+	defer emitLineageEvent(lineage, lineageEventComplete)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-r.done:
+		}
+	}()
+
+	backoff := cdcMinBackoff
+	for {
+		connected, err := s.runCdcSession(ctx, r)
+		r.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.recordError(err)
+			s.Warnf("[tenant: %v]runCdcConnector: connector %v: %v", r.connector.TenantName, r.connector.Name, err.Error())
+		}
+		if connected {
+			backoff = cdcMinBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		r.reconnects.Add(1)
+		backoff *= 2
+		if backoff > cdcMaxBackoff {
+			backoff = cdcMaxBackoff
+		}
+	}
+}
+
+func (s *Server) runCdcSession(ctx context.Context, r *cdcConnectorRunner) (bool, error) {
+	stationName, err := StationNameFromStr(r.connector.StationName)
+	if err != nil {
+		return false, err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), r.connector.TenantName)
+	if err != nil {
+		return false, err
+	}
+	if !exist {
+		return false, fmt.Errorf("station %v does not exist", stationName.Ext())
+	}
+	connectionUrl, err := DecryptAES(getAESKey(), r.connector.ConnectionUrl)
+	if err != nil {
+		return false, err
+	}
+	if r.connector.SourceType == models.CdcSourcePostgres {
+		return s.runPostgresCdcSession(ctx, r, connectionUrl, stationName, station)
+	}
+	return s.runMongoCdcSession(ctx, r, connectionUrl, stationName, station)
+}
+
+func (s *Server) produceCdcEvent(r *cdcConnectorRunner, stationName StationName, station models.Station, event models.CdcChangeEvent, msgId string) error {
+	payload, ok, err := encodeCdcEvent(r.connector.Transform, event)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		r.recordEvent(event.Source.Position)
+		return nil
+	}
+	hdrs := map[string]string{
+		"$memphis_producedBy":   cdcProducerName + "-" + r.connector.Name,
+		"$memphis_connectionId": cdcProducerName,
+		// the msg id lets the station drop events which are read again after a restart
+		JSMsgId: msgId,
+	}
+	_, err = s.produceToStation(r.connector.TenantName, stationName, station, payload, hdrs)
+	if err != nil {
+		return err
+	}
+	r.recordEvent(event.Source.Position)
+	return nil
+}
+
+func ensurePostgresCdcSlot(ctx context.Context, conn *pgx.Conn, slotName string) error {
+	var exists bool
+	err := conn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`, slotName).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err = conn.Exec(ctx, `SELECT pg_create_logical_replication_slot($1, $2)`, slotName, cdcPostgresPlugin)
+	return err
+}
+
+func postgresCdcPeekQuery(slotName string, tables []string) (string, []interface{}) {
+	query := `SELECT lsn::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'format-version', '2', 'include-transaction', 'true', 'include-timestamp', 'true', 'include-pk', 'true'`
+	args := []interface{}{slotName, cdcPostgresBatchChanges}
+	if len(tables) > 0 {
+		query += `, 'add-tables', $3`
+		args = append(args, strings.Join(tables, ","))
+	}
+	return query + `)`, args
+}
+
+func wal2jsonColumnsToJson(columns []wal2jsonColumn) json.RawMessage {
+	if len(columns) == 0 {
+		return json.RawMessage("null")
+	}
+	row := make(map[string]json.RawMessage, len(columns))
+	for _, column := range columns {
+		row[column.Name] = column.Value
+	}
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+func postgresCdcEvent(connector models.CdcConnector, lsn string, change wal2jsonChange) (models.CdcChangeEvent, bool) {
+	event := models.CdcChangeEvent{
+		Source: models.CdcEventSource{
+			Connector: connector.Name,
+			Type:      models.CdcSourcePostgres,
+			Database:  connector.Database,
+			Schema:    change.Schema,
+			Table:     change.Table,
+			Position:  lsn,
+		},
+		Before: json.RawMessage("null"),
+		After:  json.RawMessage("null"),
+	}
+	ts, err := time.Parse(cdcPostgresTimestampLayout, change.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+	event.TsMs = ts.UnixMilli()
+
+	keySource := change.Columns
+	switch change.Action {
+	case "I":
+		event.Op = models.CdcOpCreate
+		event.After = wal2jsonColumnsToJson(change.Columns)
+	case "U":
+		event.Op = models.CdcOpUpdate
+		event.After = wal2jsonColumnsToJson(change.Columns)
+		if len(change.Identity) > 0 {
+			event.Before = wal2jsonColumnsToJson(change.Identity)
+		}
+	case "D":
+		event.Op = models.CdcOpDelete
+		event.Before = wal2jsonColumnsToJson(change.Identity)
+		keySource = change.Identity
+	case "T":
+		event.Op = models.CdcOpTruncate
+	default:
+		return models.CdcChangeEvent{}, false
+	}
+
+	keyColumns := make([]wal2jsonColumn, 0, len(change.Pk))
+	for _, pk := range change.Pk {
+		for _, column := range keySource {
+			if column.Name == pk.Name {
+				keyColumns = append(keyColumns, column)
+			}
+		}
+	}
+	event.Key = wal2jsonColumnsToJson(keyColumns)
+	return event, true
+}
+
+// runPostgresCdcSession polls the replication slot, changes are peeked and the slot is advanced
+// only after a whole transaction was produced so a crash in between re-reads it instead of losing it
+func (s *Server) runPostgresCdcSession(ctx context.Context, r *cdcConnectorRunner, connectionUrl string, stationName StationName, station models.Station) (bool, error) {
+	conn, err := pgx.Connect(ctx, connectionUrl)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+	err = ensurePostgresCdcSlot(ctx, conn, r.connector.SlotName)
+	if err != nil {
+		return false, err
+	}
+	r.connected.Store(true)
+
+	query, args := postgresCdcPeekQuery(r.connector.SlotName, r.connector.Tables)
+	for {
+		rows, err := conn.Query(ctx, query, args...)
+		if err != nil {
+			return true, err
+		}
+		type walRow struct {
+			Lsn  string
+			Data string
+		}
+		changes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[walRow])
+		if err != nil {
+			return true, err
+		}
+
+		lastCommitLsn := _EMPTY_
+		for _, row := range changes {
+			var change wal2jsonChange
+			err = json.Unmarshal([]byte(row.Data), &change)
+			if err != nil {
+				return true, err
+			}
+			switch change.Action {
+			case "B":
+				continue
+			case "C":
+				lastCommitLsn = row.Lsn
+				continue
+			}
+			event, ok := postgresCdcEvent(r.connector, row.Lsn, change)
+			if !ok {
+				continue
+			}
+			err = s.produceCdcEvent(r, stationName, station, event, r.connector.Name+":"+row.Lsn)
+			if err != nil {
+				return true, err
+			}
+		}
+
+		if lastCommitLsn != _EMPTY_ {
+			_, err = conn.Exec(ctx, `SELECT pg_replication_slot_advance($1, $2::pg_lsn)`, r.connector.SlotName, lastCommitLsn)
+			if err != nil {
+				return true, err
+			}
+			err = db.UpdateCdcConnectorPosition(r.connector.ID, lastCommitLsn)
+			if err != nil {
+				s.Warnf("[tenant: %v]runPostgresCdcSession at UpdateCdcConnectorPosition: connector %v: %v", r.connector.TenantName, r.connector.Name, err.Error())
+			}
+		}
+		if len(changes) == 0 {
+			select {
+			case <-ctx.Done():
+				return true, nil
+			case <-time.After(cdcPostgresPollInterval):
+			}
+		}
+	}
+}
+
+func mongoDocToJson(doc bson.Raw) json.RawMessage {
+	if len(doc) == 0 {
+		return json.RawMessage("null")
+	}
+	raw, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return raw
+}
+
+func mongoCdcEvent(connector models.CdcConnector, position string, change mongoChangeEvent) (models.CdcChangeEvent, bool) {
+	event := models.CdcChangeEvent{
+		TsMs: int64(change.ClusterTime.T) * 1000,
+		Source: models.CdcEventSource{
+			Connector: connector.Name,
+			Type:      models.CdcSourceMongoDB,
+			Database:  change.Ns.Db,
+			Table:     change.Ns.Coll,
+			Position:  position,
+		},
+		Key:    mongoDocToJson(change.DocumentKey),
+		Before: mongoDocToJson(change.FullDocumentBeforeChange),
+		After:  mongoDocToJson(change.FullDocument),
+	}
+	switch change.OperationType {
+	case "insert":
+		event.Op = models.CdcOpCreate
+	case "update", "replace":
+		event.Op = models.CdcOpUpdate
+	case "delete":
+		event.Op = models.CdcOpDelete
+	case "drop":
+		event.Op = models.CdcOpTruncate
+	default:
+		return models.CdcChangeEvent{}, false
+	}
+	return event, true
+}
+
+// runMongoCdcSession tails a change stream, the resume token is persisted so a restart continues where it stopped
+func (s *Server) runMongoCdcSession(ctx context.Context, r *cdcConnectorRunner, connectionUrl string, stationName StationName, station models.Station) (bool, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionUrl))
+	if err != nil {
+		return false, err
+	}
+	defer client.Disconnect(context.Background())
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup).SetFullDocumentBeforeChange(options.WhenAvailable)
+	if position := r.getPosition(); position != _EMPTY_ {
+		token, err := base64.StdEncoding.DecodeString(position)
+		if err != nil {
+			return false, err
+		}
+		opts.SetStartAfter(bson.Raw(token))
+	}
+	pipeline := mongo.Pipeline{}
+	if len(r.connector.Tables) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "ns.coll", Value: bson.D{{Key: "$in", Value: r.connector.Tables}}}}}})
+	}
+	stream, err := client.Database(r.connector.Database).Watch(ctx, pipeline, opts)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.Background())
+	r.connected.Store(true)
+
+	savedPosition := r.getPosition()
+	lastSave := time.Now()
+	savePosition := func() {
+		position := r.getPosition()
+		if position == savedPosition {
+			return
+		}
+		err := db.UpdateCdcConnectorPosition(r.connector.ID, position)
+		if err != nil {
+			s.Warnf("[tenant: %v]runMongoCdcSession at UpdateCdcConnectorPosition: connector %v: %v", r.connector.TenantName, r.connector.Name, err.Error())
+			return
+		}
+		savedPosition = position
+		lastSave = time.Now()
+	}
+	defer savePosition()
+
+	for stream.Next(ctx) {
+		var change mongoChangeEvent
+		err = stream.Decode(&change)
+		if err != nil {
+			return true, err
+		}
+		if change.OperationType == "invalidate" {
+			return true, errors.New("change stream was invalidated")
+		}
+		token := []byte(stream.ResumeToken())
+		position := base64.StdEncoding.EncodeToString(token)
+		event, ok := mongoCdcEvent(r.connector, position, change)
+		if ok {
+			tokenHash := sha256.Sum256(token)
+			err = s.produceCdcEvent(r, stationName, station, event, r.connector.Name+":"+hex.EncodeToString(tokenHash[:16]))
+			if err != nil {
+				return true, err
+			}
+		} else {
+			r.mu.Lock()
+			r.position = position
+			r.mu.Unlock()
+		}
+		if time.Since(lastSave) > cdcPositionSaveInterval {
+			savePosition()
+		}
+	}
+	if ctx.Err() != nil {
+		return true, nil
+	}
+	if err := stream.Err(); err != nil {
+		return true, err
+	}
+	return true, errors.New("change stream was closed")
+}
+
+// prepareCdcSource checks the source is reachable, creates the replication slot for postgres
+// and returns the columns of the captured table when a single one is configured
+func prepareCdcSource(connector models.CdcConnector, connectionUrl string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cdcSourceConnectTimeout)
+	defer cancel()
+	if connector.SourceType == models.CdcSourceMongoDB {
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionUrl))
+		if err != nil {
+			return nil, err
+		}
+		defer client.Disconnect(context.Background())
+		return nil, client.Ping(ctx, nil)
+	}
+
+	conn, err := pgx.Connect(ctx, connectionUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+	err = ensurePostgresCdcSlot(ctx, conn, connector.SlotName)
+	if err != nil {
+		return nil, err
+	}
+	if len(connector.Tables) != 1 {
+		return nil, nil
+	}
+	parts := strings.Split(connector.Tables[0], ".")
+	rows, err := conn.Query(ctx, `SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2`, parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, dataType string
+		err = rows.Scan(&name, &dataType)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns[name] = dataType
+	}
+	rows.Close()
+	return columns, rows.Err()
+}
+
+func dropPostgresCdcSlot(connector models.CdcConnector) error {
+	connectionUrl, err := DecryptAES(getAESKey(), connector.ConnectionUrl)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cdcSourceConnectTimeout)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, connectionUrl)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, `SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = $1`, connector.SlotName)
+	return err
+}
+
+func postgresTypeToJsonSchema(dataType string) interface{} {
+	switch dataType {
+	case "smallint", "integer", "bigint":
+		return []string{"integer", "null"}
+	case "numeric", "real", "double precision":
+		return []string{"number", "null"}
+	case "boolean":
+		return []string{"boolean", "null"}
+	case "json", "jsonb":
+		return nil
+	default:
+		return []string{"string", "null"}
+	}
+}
+
+// generateCdcSchemaContent builds the json schema of the events the connector produces, the row shape is only known for
+// a single postgres table
+func generateCdcSchemaContent(connector models.CdcConnector, columns map[string]string) (string, error) {
+	row := map[string]interface{}{"type": []string{"object", "null"}}
+	properties := make(map[string]interface{}, len(columns))
+	if len(columns) > 0 {
+		for name, dataType := range columns {
+			property := map[string]interface{}{}
+			if t := postgresTypeToJsonSchema(dataType); t != nil {
+				property["type"] = t
+			}
+			properties[name] = property
+		}
+		row["properties"] = properties
+	}
+	ops := map[string]interface{}{"type": "string", "enum": []string{models.CdcOpCreate, models.CdcOpUpdate, models.CdcOpDelete, models.CdcOpTruncate}}
+
+	var schema map[string]interface{}
+	switch connector.Transform {
+	case models.MessageTransformDebeziumUnwrap:
+		properties[debeziumDeletedField] = map[string]interface{}{"type": "string"}
+		schema = map[string]interface{}{
+			"title":      fmt.Sprintf("%v row", connector.Name),
+			"type":       "object",
+			"properties": properties,
+		}
+	case models.MessageTransformDebeziumWrap:
+		schema = map[string]interface{}{
+			"title":    fmt.Sprintf("%v change event", connector.Name),
+			"type":     "object",
+			"required": []string{"op", "ts_ms", "source"},
+			"properties": map[string]interface{}{
+				"op":    ops,
+				"ts_ms": map[string]interface{}{"type": "integer"},
+				"source": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"connector", "name", "db"},
+					"properties": map[string]interface{}{
+						"version":    map[string]interface{}{"type": "string"},
+						"connector":  map[string]interface{}{"type": "string"},
+						"name":       map[string]interface{}{"type": "string"},
+						"ts_ms":      map[string]interface{}{"type": "integer"},
+						"db":         map[string]interface{}{"type": "string"},
+						"schema":     map[string]interface{}{"type": "string"},
+						"table":      map[string]interface{}{"type": "string"},
+						"collection": map[string]interface{}{"type": "string"},
+						"position":   map[string]interface{}{"type": "string"},
+					},
+				},
+				"before":      row,
+				"after":       row,
+				"transaction": map[string]interface{}{"type": []string{"object", "null"}},
+			},
+		}
+	default:
+		schema = map[string]interface{}{
+			"title":    fmt.Sprintf("%v change event", connector.Name),
+			"type":     "object",
+			"required": []string{"op", "ts_ms", "source"},
+			"properties": map[string]interface{}{
+				"op":    ops,
+				"ts_ms": map[string]interface{}{"type": "integer"},
+				"source": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"connector", "type", "table"},
+					"properties": map[string]interface{}{
+						"connector": map[string]interface{}{"type": "string"},
+						"type":      map[string]interface{}{"type": "string"},
+						"database":  map[string]interface{}{"type": "string"},
+						"schema":    map[string]interface{}{"type": "string"},
+						"table":     map[string]interface{}{"type": "string"},
+						"position":  map[string]interface{}{"type": "string"},
+					},
+				},
+				"key":    map[string]interface{}{"type": []string{"object", "null"}},
+				"before": row,
+				"after":  row,
+			},
+		}
+	}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	content, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return string(content), nil
+}
+
+// attachCdcGeneratedSchema creates the change events schema and attaches it to the station, a station which already
+// enforces a schema is left as is
+func (s *Server) attachCdcGeneratedSchema(connector models.CdcConnector, user models.User, stationName StationName, station models.Station, columns map[string]string) error {
+	if station.SchemaName != _EMPTY_ {
+		return nil
+	}
+	content, err := generateCdcSchemaContent(connector, columns)
+	if err != nil {
+		return err
+	}
+	schemaName := strings.ToLower("cdc-" + connector.Name)
+	versionNumber := 1
+	schema, err := db.InsertNewSchemaWithVersion(schemaName, "json", user.ID, user.Username, content, _EMPTY_, _EMPTY_, nil, user.TenantName)
+	if errors.Is(err, db.ErrSchemaExists) {
+		_, schema, err = db.GetSchemaByName(schemaName, user.TenantName)
+		if err != nil {
+			return err
+		}
+		activeVersion, err := getActiveVersionBySchemaId(schema.ID)
+		if err != nil {
+			return err
+		}
+		versionNumber = activeVersion.VersionNumber
+	} else if err != nil {
+		return err
+	}
+	err = db.AttachSchemaToStation(stationName.Ext(), schemaName, versionNumber, user.TenantName)
+	if err != nil {
+		return err
+	}
+
+	updateContent, err := generateSchemaUpdateInit(schema)
+	if err != nil {
+		return err
+	}
+	s.updateStationProducersOfSchemaChange(user.TenantName, stationName, models.SchemaUpdate{UpdateType: models.SchemaUpdateTypeInit, Init: *updateContent})
+	return nil
+}
+
+func (s *Server) writeCdcConnectorsMetrics(mw *metricsWriter) {
+	cdcConnectors.Lock()
+	defer cdcConnectors.Unlock()
+	for _, runner := range cdcConnectors.runners {
+		labels := []string{"tenant", runner.connector.TenantName, "connector", runner.connector.Name, "source", runner.connector.SourceType, "station", runner.connector.StationName}
+		connected := float64(0)
+		if runner.connected.Load() {
+			connected = 1
+		}
+		mw.add("memphis_cdc_connector_connected", "gauge", "Whether the CDC connector is connected to its source", connected, labels...)
+		mw.add("memphis_cdc_connector_events_total", "counter", "Change events produced by the CDC connector", float64(runner.events.Load()), labels...)
+		mw.add("memphis_cdc_connector_errors_total", "counter", "Errors encountered by the CDC connector", float64(runner.errors.Load()), labels...)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cgRebalanceMemberJoined       = "member_joined"
+	cgRebalanceMemberLeft         = "member_left"
+	cgRebalanceMemberDisconnected = "member_disconnected"
+	cgRebalanceMemberReconnected  = "member_reconnected"
+	cgRebalanceMemberKilled       = "member_killed"
+	cgRebalanceHistoryLimit       = 50
+)
+
+// recordCgRebalance stores a membership change of a consumer group together with the number of members which are
+// active after it, it is best effort and never fails the operation which caused it
+func recordCgRebalance(tenantName string, stationId int, cgName, reason, consumerName, connectionId string) {
+	activeMembers, err := db.CountActiveConsumersInCG(cgName, stationId)
+	if err != nil {
+		serv.Errorf("[tenant: %v]recordCgRebalance at CountActiveConsumersInCG: Consumer group %v: %v", tenantName, cgName, err.Error())
+		return
+	}
+	err = db.InsertCgRebalance(stationId, cgName, reason, consumerName, connectionId, int(activeMembers), tenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v]recordCgRebalance at InsertCgRebalance: Consumer group %v: %v", tenantName, cgName, err.Error())
+		return
+	}
+	serv.publishCgRebalanceWSUpdates(tenantName)
+}
+
+// recordCgRebalancesByConnections is recordCgRebalance for all the groups with members on the given connections,
+// an empty tenantName is used when the connections may belong to several tenants
+func recordCgRebalancesByConnections(tenantName, reason string, connectionIds ...string) {
+	err := db.InsertCgRebalancesByConnections(connectionIds, reason)
+	if err != nil {
+		serv.Errorf("[tenant: %v]recordCgRebalancesByConnections at InsertCgRebalancesByConnections: %v", tenantName, err.Error())
+		return
+	}
+	serv.publishCgRebalanceWSUpdates(tenantName)
+}
+
+// publishCgRebalanceWSUpdates pushes the rebalance info right away to the UI clients registered on this broker,
+// the clients of the other brokers get it on the next tick
+func (s *Server) publishCgRebalanceWSUpdates(tenantName string) {
+	subs := s.memphis.ws.subscriptions
+	if subs == nil {
+		return
+	}
+	keys, values := subs.Array()
+	for i, f := range values {
+		if tokenAt(keys[i], 1) != memphisWS_Subj_CgRebalanceData {
+			continue
+		}
+		subs.Lock()
+		for tenant, filler := range f.tenants {
+			if tenantName == _EMPTY_ || tenant == tenantName {
+				go s.publishWSUpdate(subs, keys[i], tenant, filler, false)
+			}
+		}
+		subs.Unlock()
+	}
+}
+
+// cgRebalanceWSParamsFromSubj parses cg_rebalance_data.<station name>?cg_name=<consumer group name>
+func cgRebalanceWSParamsFromSubj(subj string) (string, string, error) {
+	subjWithoutQuery, query, _ := strings.Cut(subj, "?")
+	stationName := strings.Join(strings.Split(subjWithoutQuery, ".")[1:], ".")
+	if stationName == _EMPTY_ {
+		return _EMPTY_, _EMPTY_, errors.New("invalid station name")
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return _EMPTY_, _EMPTY_, err
+	}
+	cgName := values.Get("cg_name")
+	if cgName == _EMPTY_ {
+		return _EMPTY_, _EMPTY_, errors.New("invalid consumer group name")
+	}
+	return stationName, cgName, nil
+}
+
+// getCgRebalanceInfo describes how the members of a consumer group currently share its partitions. Every active member
+// pulls from all the partitions of the group and the broker hands each message to one of the waiting pulls, so a
+// member which gets nothing is either inactive, has no pull waiting or is outpaced by the other members
+func (ch ConsumersHandler) getCgRebalanceInfo(stationName StationName, station models.Station, cgName string) (models.CgRebalanceInfo, error) {
+	members, err := GetConsumerGroupMembers(cgName, station)
+	if err != nil {
+		return models.CgRebalanceInfo{}, err
+	}
+	history, err := db.GetCgRebalances(station.ID, cgName, cgRebalanceHistoryLimit)
+	if err != nil {
+		return models.CgRebalanceInfo{}, err
+	}
+	if len(members) == 0 && len(history) == 0 {
+		return models.CgRebalanceInfo{}, fmt.Errorf("Consumer group %v does not exist at station %v", cgName, stationName.Ext())
+	}
+
+	partitions := station.PartitionsList
+	if len(members) > 0 {
+		partitions = members[0].PartitionsList
+	}
+	activeNames := []string{}
+	info := models.CgRebalanceInfo{
+		StationName: stationName.Ext(),
+		CgName:      cgName,
+		Members:     []models.CgMemberAssignment{},
+		Partitions:  []models.CgPartitionAssignment{},
+		History:     history,
+	}
+	for _, member := range members {
+		assignment := models.CgMemberAssignment{
+			Name:         member.Name,
+			ConnectionId: member.ConnectionID,
+			IsActive:     member.IsActive,
+			Partitions:   []int{},
+		}
+		if member.IsActive {
+			assignment.Partitions = append(assignment.Partitions, partitions...)
+			activeNames = append(activeNames, member.Name)
+		}
+		info.Members = append(info.Members, assignment)
+	}
+	info.ActiveMembers = len(activeNames)
+
+	// stations which were created before partitions existed have a single stream, it is reported as partition 0
+	partitionsToDescribe := partitions
+	if len(partitionsToDescribe) == 0 {
+		partitionsToDescribe = []int{0}
+	}
+	for _, p := range partitionsToDescribe {
+		partitionsList := []int{p}
+		if p == 0 {
+			partitionsList = []int{}
+		}
+		assignment := models.CgPartitionAssignment{Partition: p, Members: activeNames}
+		cgInfo, err := ch.S.GetCgInfo(station.TenantName, stationName, cgName, partitionsList)
+		if err == nil { // the group may not exist in nats anymore once all of its members are gone
+			assignment.NumPending = cgInfo.NumPending
+			assignment.NumAckPending = cgInfo.NumAckPending
+			assignment.NumWaiting = cgInfo.NumWaiting
+			assignment.NumRedelivered = cgInfo.NumRedelivered
+		}
+		info.Partitions = append(info.Partitions, assignment)
+	}
+
+	if len(history) > 0 {
+		lastRebalanceAt := history[0].CreatedAt
+		info.LastRebalanceAt = &lastRebalanceAt
+	}
+	return info, nil
+}
+
+func (ch ConsumersHandler) GetCgRebalanceInfo(c *gin.Context) {
+	var body models.GetCgRebalanceInfoSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetCgRebalanceInfo at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetCgRebalanceInfo at StationNameFromStr: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetCgRebalanceInfo at GetStationByName: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]GetCgRebalanceInfo: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	cgName := strings.ToLower(body.CgName)
+	info, err := ch.getCgRebalanceInfo(stationName, station, cgName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			serv.Warnf("[tenant: %v][user: %v]GetCgRebalanceInfo: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]GetCgRebalanceInfo at getCgRebalanceInfo: Consumer group %v at station %v: %v", user.TenantName, user.Username, cgName, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, info)
+}
@@ -0,0 +1,105 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/nats-io/nuid"
+)
+
+// Payloads above the broker max payload are kept in the tenant's s3 bucket and the station gets a small reference message instead (claim-check).
+// The broker stores and resolves the payloads itself so producing and consuming through the gateway stays the same for any payload size.
+// Claimed payloads are not removed by the broker, a lifecycle rule on the memphis/<tenant>/<station>/claim-check/ prefix should expire them.
+const (
+	claimCheckHeader     = "$memphis_claim_check"
+	claimCheckSizeHeader = "$memphis_claim_check_size"
+)
+
+var ErrClaimCheckUnavailable = errors.New("message size exceeds the max payload and no s3 integration is configured for storing large messages")
+
+type claimCheckReference struct {
+	ClaimCheck string `json:"claim_check"`
+	Size       int    `json:"size"`
+}
+
+func claimCheckMaxSize() int {
+	return configuration.CLAIM_CHECK_MAX_SIZE_MB * 1024 * 1024
+}
+
+func claimCheckObjectKey(tenantName string, stationName StationName, id string) string {
+	if tenantName == serv.MemphisGlobalAccountString() {
+		tenantName = "global"
+	}
+	return "memphis/" + tenantName + "/" + stationName.Ext() + "/claim-check/" + id
+}
+
+// storeClaimCheck uploads an oversized payload and returns the reference message and headers to publish in its place
+func (s *Server) storeClaimCheck(tenantName string, stationName StationName, payload []byte, hdrs map[string]string) ([]byte, error) {
+	if len(payload) > claimCheckMaxSize() {
+		return nil, fmt.Errorf("message size exceeds the max size of %v MB for large messages", configuration.CLAIM_CHECK_MAX_SIZE_MB)
+	}
+	svc, bucketName, err := newTenantS3Client(tenantName)
+	if err == errNoS3Integration {
+		return nil, ErrClaimCheckUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := claimCheckObjectKey(tenantName, stationName, nuid.Next())
+	_, err = manager.NewUploader(svc).Upload(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(payload),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed storing the large message: %v", err.Error())
+	}
+	hdrs[claimCheckHeader] = key
+	hdrs[claimCheckSizeHeader] = strconv.Itoa(len(payload))
+	return json.Marshal(claimCheckReference{ClaimCheck: key, Size: len(payload)})
+}
+
+// loadClaimCheck returns the payload a claim-check message refers to
+func loadClaimCheck(tenantName, key string) ([]byte, error) {
+	svc, bucketName, err := newTenantS3Client(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := svc.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(io.LimitReader(obj.Body, int64(claimCheckMaxSize())))
+}
+
+// resolveClaimCheck swaps a claim-check reference for the stored payload, other messages are returned as is
+func resolveClaimCheck(tenantName string, hdrs map[string]string, data []byte) ([]byte, error) {
+	key, ok := hdrs[claimCheckHeader]
+	if !ok {
+		return data, nil
+	}
+	return loadClaimCheck(tenantName, key)
+}
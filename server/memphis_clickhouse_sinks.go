@@ -0,0 +1,712 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	clickhouseSinksReconcileInterval = 30 * time.Second
+	clickhouseSinkMinBackoff         = time.Second
+	clickhouseSinkMaxBackoff         = time.Minute
+	clickhouseSinkRequestTimeout     = 30 * time.Second
+	clickhouseSinkFetchBatch         = 1000
+	clickhouseSinkFetchExpires       = time.Second
+	clickhouseSinkDefaultBatchSize   = 1000
+	clickhouseSinkMaxBatchSize       = 100000
+	clickhouseSinkDefaultFlushMs     = 5000
+	clickhouseSinkMinFlushMs         = 100
+	clickhouseSinkMaxFlushMs         = 300000
+	clickhouseSinkDefaultDatabase    = "default"
+	clickhouseSinkConsumerName       = "clickhouse-sink"
+	clickhouseSinkDedupWindow        = 1000
+
+	clickhouseMsgIdColumn      = "memphis_msg_id"
+	clickhouseProducedAtColumn = "memphis_produced_at"
+	clickhousePayloadColumn    = "payload"
+)
+
+var clickhouseIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+var clickhouseIdentifierInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type clickhouseSinkRunner struct {
+	sink     models.ClickhouseSink
+	stop     chan struct{}
+	done     chan struct{}
+	running  atomic.Bool
+	messages atomic.Uint64
+	batches  atomic.Uint64
+	skipped  atomic.Uint64
+	errors   atomic.Uint64
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+	lastFlushAt time.Time
+}
+
+var clickhouseSinks = struct {
+	sync.Mutex
+	runners map[int]*clickhouseSinkRunner
+}{runners: make(map[int]*clickhouseSinkRunner)}
+
+type clickhouseColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// clickhouseClient talks to the ClickHouse HTTP interface, credentials are taken from the url user info
+type clickhouseClient struct {
+	endpoint string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func validateClickhouseSinkLimits(batchSize, flushIntervalMs int) error {
+	if batchSize < 1 || batchSize > clickhouseSinkMaxBatchSize {
+		return fmt.Errorf("batch_size must be between 1 and %v", clickhouseSinkMaxBatchSize)
+	}
+	if flushIntervalMs < clickhouseSinkMinFlushMs || flushIntervalMs > clickhouseSinkMaxFlushMs {
+		return fmt.Errorf("flush_interval_ms must be between %v and %v", clickhouseSinkMinFlushMs, clickhouseSinkMaxFlushMs)
+	}
+	return nil
+}
+
+// validateClickhouseSink checks the sink definition and fills in the defaults of the optional fields
+func validateClickhouseSink(sink *models.CreateClickhouseSinkSchema) error {
+	u, err := url.Parse(sink.Url)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("url must be a valid http:// or https:// url of the ClickHouse HTTP interface")
+	}
+	if sink.Database == _EMPTY_ {
+		sink.Database = clickhouseSinkDefaultDatabase
+	}
+	if sink.TableName == _EMPTY_ {
+		sink.TableName = clickhouseIdentifierInvalidChars.ReplaceAllString(strings.ToLower(sink.StationName), "_")
+	}
+	if !clickhouseIdentifierRegex.MatchString(sink.Database) {
+		return errors.New("database may contain only letters, digits and underscores and must not start with a digit")
+	}
+	if !clickhouseIdentifierRegex.MatchString(sink.TableName) {
+		return errors.New("table_name may contain only letters, digits and underscores and must not start with a digit")
+	}
+	if sink.BatchSize == 0 {
+		sink.BatchSize = clickhouseSinkDefaultBatchSize
+	}
+	if sink.FlushIntervalMs == 0 {
+		sink.FlushIntervalMs = clickhouseSinkDefaultFlushMs
+	}
+	return validateClickhouseSinkLimits(sink.BatchSize, sink.FlushIntervalMs)
+}
+
+func clickhouseSinkDurableName(sinkName string) string {
+	return getInternalConsumerName(clickhouseSinkConsumerName + "-" + sinkName)
+}
+
+func quoteClickhouseIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+func clickhouseTableRef(sink models.ClickhouseSink) string {
+	return quoteClickhouseIdentifier(sink.Database) + "." + quoteClickhouseIdentifier(sink.TableName)
+}
+
+func newClickhouseClient(rawUrl string) (*clickhouseClient, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	client := &clickhouseClient{http: &http.Client{Timeout: clickhouseSinkRequestTimeout}}
+	if u.User != nil {
+		client.user = u.User.Username()
+		client.password, _ = u.User.Password()
+		u.User = nil
+	}
+	u.Path = "/"
+	client.endpoint = u.String()
+	return client, nil
+}
+
+func (cc *clickhouseClient) exec(ctx context.Context, query string, settings map[string]string, body []byte) ([]byte, error) {
+	params := url.Values{}
+	for k, v := range settings {
+		params.Set(k, v)
+	}
+	var reqBody io.Reader
+	if body != nil {
+		params.Set("query", query)
+		reqBody = bytes.NewReader(body)
+	} else {
+		reqBody = strings.NewReader(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.endpoint+"?"+params.Encode(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if cc.user != _EMPTY_ {
+		req.Header.Set("X-ClickHouse-User", cc.user)
+		req.Header.Set("X-ClickHouse-Key", cc.password)
+	}
+	resp, err := cc.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errMsg := strings.TrimSpace(string(respBody))
+		if len(errMsg) > 512 {
+			errMsg = errMsg[:512]
+		}
+		return nil, fmt.Errorf("clickhouse responded with status %v: %v", resp.StatusCode, errMsg)
+	}
+	return respBody, nil
+}
+
+func (cc *clickhouseClient) describeTable(ctx context.Context, sink models.ClickhouseSink) ([]clickhouseColumn, error) {
+	resp, err := cc.exec(ctx, "DESCRIBE TABLE "+clickhouseTableRef(sink)+" FORMAT JSONEachRow", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var columns []clickhouseColumn
+	decoder := json.NewDecoder(bytes.NewReader(resp))
+	for decoder.More() {
+		var column clickhouseColumn
+		err = decoder.Decode(&column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func jsonSchemaTypeToClickhouse(schemaType interface{}, required bool) string {
+	nullable := !required
+	var types []string
+	switch t := schemaType.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+	chType := "String"
+	found := false
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+			continue
+		}
+		if found {
+			// a union of several types is kept as its json text
+			chType = "String"
+			continue
+		}
+		found = true
+		switch t {
+		case "integer":
+			chType = "Int64"
+		case "number":
+			chType = "Float64"
+		case "boolean":
+			chType = "Bool"
+		default:
+			chType = "String"
+		}
+	}
+	if nullable {
+		return "Nullable(" + chType + ")"
+	}
+	return chType
+}
+
+// clickhouseColumnsForStation derives the table columns from the top level properties of the station json schema,
+// stations without a json schema are stored as a single payload column
+func clickhouseColumnsForStation(tenantName string, station models.Station) ([]clickhouseColumn, error) {
+	payloadColumns := []clickhouseColumn{{Name: clickhousePayloadColumn, Type: "String"}}
+	if station.SchemaName == _EMPTY_ {
+		return payloadColumns, nil
+	}
+	exist, schema, err := db.GetSchemaByName(station.SchemaName, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist || schema.Type != "json" {
+		return payloadColumns, nil
+	}
+	activeVersion, err := getActiveVersionBySchemaId(schema.ID)
+	if err != nil {
+		return nil, err
+	}
+	var jsonSchema struct {
+		Properties map[string]struct {
+			Type interface{} `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	err = json.Unmarshal([]byte(activeVersion.SchemaContent), &jsonSchema)
+	if err != nil {
+		return nil, err
+	}
+	if len(jsonSchema.Properties) == 0 {
+		return payloadColumns, nil
+	}
+	required := make(map[string]bool, len(jsonSchema.Required))
+	for _, name := range jsonSchema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(jsonSchema.Properties))
+	for name := range jsonSchema.Properties {
+		if name == clickhouseMsgIdColumn || name == clickhouseProducedAtColumn {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	columns := make([]clickhouseColumn, 0, len(names))
+	for _, name := range names {
+		columns = append(columns, clickhouseColumn{Name: name, Type: jsonSchemaTypeToClickhouse(jsonSchema.Properties[name].Type, required[name])})
+	}
+	return columns, nil
+}
+
+// prepareClickhouseSinkTable creates the destination table unless it already exists, the table is a ReplacingMergeTree
+// keyed by the message id so rows which are inserted twice collapse into one
+func prepareClickhouseSinkTable(sink models.ClickhouseSink, rawUrl string, columns []clickhouseColumn) error {
+	client, err := newClickhouseClient(rawUrl)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clickhouseSinkRequestTimeout)
+	defer cancel()
+	definitions := []string{
+		quoteClickhouseIdentifier(clickhouseMsgIdColumn) + " String",
+		quoteClickhouseIdentifier(clickhouseProducedAtColumn) + " DateTime64(3, 'UTC')",
+	}
+	for _, column := range columns {
+		definitions = append(definitions, quoteClickhouseIdentifier(column.Name)+" "+column.Type)
+	}
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (%v) ENGINE = ReplacingMergeTree ORDER BY %v SETTINGS non_replicated_deduplication_window = %v",
+		clickhouseTableRef(sink), strings.Join(definitions, ", "), quoteClickhouseIdentifier(clickhouseMsgIdColumn), clickhouseSinkDedupWindow)
+	_, err = client.exec(ctx, query, nil, nil)
+	return err
+}
+
+func (r *clickhouseSinkRunner) recordError(err error) {
+	r.errors.Add(1)
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.lastErrorAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *clickhouseSinkRunner) recordFlush(messages int) {
+	r.messages.Add(uint64(messages))
+	r.batches.Add(1)
+	r.mu.Lock()
+	r.lastFlushAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *clickhouseSinkRunner) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *clickhouseSinkRunner) status() models.ClickhouseSinkStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return models.ClickhouseSinkStatus{
+		Running:          r.running.Load(),
+		MessagesInserted: r.messages.Load(),
+		BatchesInserted:  r.batches.Load(),
+		MessagesSkipped:  r.skipped.Load(),
+		Errors:           r.errors.Load(),
+		LastError:        r.lastError,
+		LastErrorAt:      r.lastErrorAt,
+		LastFlushAt:      r.lastFlushAt,
+	}
+}
+
+// getClickhouseSinkStatus returns the runtime status of a sink, sinks run on the leader only
+func getClickhouseSinkStatus(id int) models.ClickhouseSinkStatus {
+	clickhouseSinks.Lock()
+	runner, ok := clickhouseSinks.runners[id]
+	clickhouseSinks.Unlock()
+	if !ok {
+		return models.ClickhouseSinkStatus{}
+	}
+	return runner.status()
+}
+
+func (s *Server) ManageClickhouseSinks() {
+	reportBackgroundTaskAlive("ManageClickhouseSinks", clickhouseSinksReconcileInterval)
+	ticker := time.NewTicker(clickhouseSinksReconcileInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageClickhouseSinks", clickhouseSinksReconcileInterval)
+		s.reconcileClickhouseSinks()
+	}
+}
+
+// reconcileClickhouseSinks starts the enabled sinks, stops the removed or disabled ones and restarts the sinks whose
+// batching settings were changed
+func (s *Server) reconcileClickhouseSinks() {
+	desired := make(map[int]models.ClickhouseSink)
+	if s.leadsBackgroundTask("ManageClickhouseSinks", clickhouseSinksReconcileInterval) {
+		sinks, err := db.GetEnabledClickhouseSinks()
+		if err != nil {
+			s.Errorf("reconcileClickhouseSinks at GetEnabledClickhouseSinks: %v", err.Error())
+			return
+		}
+		for _, sink := range sinks {
+			desired[sink.ID] = sink
+		}
+	}
+
+	clickhouseSinks.Lock()
+	defer clickhouseSinks.Unlock()
+	for id, runner := range clickhouseSinks.runners {
+		sink, ok := desired[id]
+		if ok && sink.BatchSize == runner.sink.BatchSize && sink.FlushIntervalMs == runner.sink.FlushIntervalMs {
+			continue
+		}
+		close(runner.stop)
+		<-runner.done
+		delete(clickhouseSinks.runners, id)
+	}
+	for id, sink := range desired {
+		if _, ok := clickhouseSinks.runners[id]; ok {
+			continue
+		}
+		runner := &clickhouseSinkRunner{sink: sink, stop: make(chan struct{}), done: make(chan struct{})}
+		clickhouseSinks.runners[id] = runner
+		go s.runClickhouseSink(runner)
+	}
+}
+
+func (s *Server) runClickhouseSink(r *clickhouseSinkRunner) {
+	defer close(r.done)
+	lineage := clickhouseSinkLineageRunner(r.sink)
+	emitLineageEvent(lineage, lineageEventStart)
+	defer emitLineageEvent(lineage, lineageEventComplete)
+	backoff := clickhouseSinkMinBackoff
+	for {
+		flushed, err := s.runClickhouseSinkSession(r)
+		r.running.Store(false)
+		if r.stopped() {
+			return
+		}
+		if err != nil {
+			r.recordError(err)
+			s.Warnf("[tenant: %v]runClickhouseSink: sink %v: %v", r.sink.TenantName, r.sink.Name, err.Error())
+		}
+		if flushed {
+			backoff = clickhouseSinkMinBackoff
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > clickhouseSinkMaxBackoff {
+			backoff = clickhouseSinkMaxBackoff
+		}
+	}
+}
+
+func (s *Server) getClickhouseSinkStation(sink models.ClickhouseSink) (StationName, models.Station, error) {
+	stationName, err := StationNameFromStr(sink.StationName)
+	if err != nil {
+		return StationName{}, models.Station{}, err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), sink.TenantName)
+	if err != nil {
+		return StationName{}, models.Station{}, err
+	}
+	if !exist {
+		return StationName{}, models.Station{}, fmt.Errorf("station %v does not exist", stationName.Ext())
+	}
+	return stationName, station, nil
+}
+
+// runClickhouseSinkSession pulls the station in batches of up to batch_size messages or flush_interval_ms, whichever
+// comes first, and acks a batch only after ClickHouse accepted the insert
+func (s *Server) runClickhouseSinkSession(r *clickhouseSinkRunner) (bool, error) {
+	stationName, station, err := s.getClickhouseSinkStation(r.sink)
+	if err != nil {
+		return false, err
+	}
+	account, err := s.lookupAccount(r.sink.TenantName)
+	if err != nil {
+		return false, err
+	}
+	rawUrl, err := DecryptAES(getAESKey(), r.sink.Url)
+	if err != nil {
+		return false, err
+	}
+	client, err := newClickhouseClient(rawUrl)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clickhouseSinkRequestTimeout)
+	columns, err := client.describeTable(ctx, r.sink)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+
+	// the sink transform takes precedence over the one of the station
+	transform := r.sink.Transform
+	if transform == models.MessageTransformNone {
+		transform = station.MessageTransform
+	}
+	flushInterval := time.Duration(r.sink.FlushIntervalMs) * time.Millisecond
+	durable := clickhouseSinkDurableName(r.sink.Name)
+	streams := stationStreamsAndFilters(stationName, station)
+	for streamName, filter := range streams {
+		err = s.memphisAddConsumer(r.sink.TenantName, streamName, &ConsumerConfig{
+			Durable:       durable,
+			DeliverPolicy: DeliverAll,
+			AckPolicy:     AckExplicit,
+			AckWait:       flushInterval + 3*clickhouseSinkRequestTimeout,
+			FilterSubject: filter,
+			MaxAckPending: r.sink.BatchSize * 2,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	r.running.Store(true)
+
+	flushed := false
+	for {
+		batch := make([]stationFetchedMsg, 0, r.sink.BatchSize)
+		deadline := time.Now().Add(flushInterval)
+		for len(batch) < r.sink.BatchSize && time.Now().Before(deadline) {
+			for streamName := range streams {
+				if r.stopped() {
+					for _, msg := range batch {
+						s.nackStationMessage(account, msg)
+					}
+					return flushed, nil
+				}
+				remaining := r.sink.BatchSize - len(batch)
+				if remaining <= 0 {
+					break
+				}
+				if remaining > clickhouseSinkFetchBatch {
+					remaining = clickhouseSinkFetchBatch
+				}
+				expires := time.Until(deadline) / time.Duration(len(streams))
+				if expires > clickhouseSinkFetchExpires {
+					expires = clickhouseSinkFetchExpires
+				}
+				if expires < 10*time.Millisecond {
+					expires = 10 * time.Millisecond
+				}
+				msgs, err := s.fetchStationMessages(account, streamName, durable, remaining, expires)
+				if err != nil {
+					for _, msg := range batch {
+						s.nackStationMessage(account, msg)
+					}
+					return flushed, err
+				}
+				batch = append(batch, msgs...)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		inserted, err := s.insertClickhouseBatch(client, r, columns, transform, batch)
+		if err != nil {
+			for _, msg := range batch {
+				s.nackStationMessage(account, msg)
+			}
+			return flushed, err
+		}
+		for _, msg := range batch {
+			s.ackStationMessage(account, msg)
+		}
+		r.skipped.Add(uint64(len(batch) - inserted))
+		r.recordFlush(inserted)
+		flushed = true
+	}
+}
+
+// clickhouseMsgId identifies a message for deduplication, the producer supplied msg id wins over the stream position
+func clickhouseMsgId(msg stationFetchedMsg) (string, time.Time) {
+	sseq, _, _, ts, _ := replyInfo(msg.ReplySubject)
+	producedAt := time.Unix(0, ts)
+	if id, ok := msg.Headers[JSMsgId]; ok && id != _EMPTY_ {
+		return id, producedAt
+	}
+	tokens := strings.Split(msg.ReplySubject, ".")
+	streamName := _EMPTY_
+	if len(tokens) > 2 {
+		streamName = tokens[2]
+	}
+	return streamName + ":" + strconv.FormatUint(sseq, 10), producedAt
+}
+
+func isClickhouseStringColumn(columnType string) bool {
+	return columnType == "String" || columnType == "Nullable(String)"
+}
+
+// clickhouseRow maps a message onto the table columns, json object fields fill the columns of the same name and a
+// payload column receives the raw message when the message has no such field
+func clickhouseRow(columns []clickhouseColumn, transform string, msg stationFetchedMsg) (map[string]interface{}, bool) {
+	data, ok := applyMessageTransform(transform, msg.Data)
+	if !ok {
+		return nil, false
+	}
+	msgId, producedAt := clickhouseMsgId(msg)
+	row := map[string]interface{}{
+		clickhouseMsgIdColumn:      msgId,
+		clickhouseProducedAtColumn: producedAt.UTC().Format("2006-01-02 15:04:05.000"),
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		fields = nil
+	}
+	mapped := false
+	for _, column := range columns {
+		if column.Name == clickhouseMsgIdColumn || column.Name == clickhouseProducedAtColumn {
+			continue
+		}
+		value, ok := fields[column.Name]
+		if !ok {
+			if column.Name == clickhousePayloadColumn {
+				row[column.Name] = string(data)
+				mapped = true
+			}
+			continue
+		}
+		mapped = true
+		trimmed := bytes.TrimSpace(value)
+		if isClickhouseStringColumn(column.Type) && len(trimmed) > 0 && trimmed[0] != '"' && !bytes.Equal(trimmed, []byte("null")) {
+			// nested objects and arrays land in string columns as their json text
+			row[column.Name] = string(trimmed)
+			continue
+		}
+		row[column.Name] = value
+	}
+	return row, mapped
+}
+
+// insertClickhouseBatch inserts the batch with a deduplication token derived from its message ids, so a batch which
+// is retried after an unknown insert outcome is dropped by ClickHouse instead of being written twice
+func (s *Server) insertClickhouseBatch(client *clickhouseClient, r *clickhouseSinkRunner, columns []clickhouseColumn, transform string, batch []stationFetchedMsg) (int, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	hash := sha256.New()
+	inserted := 0
+	for _, msg := range batch {
+		row, ok := clickhouseRow(columns, transform, msg)
+		if !ok {
+			continue
+		}
+		err := encoder.Encode(row)
+		if err != nil {
+			return 0, err
+		}
+		hash.Write([]byte(row[clickhouseMsgIdColumn].(string)))
+		hash.Write([]byte{0})
+		inserted++
+	}
+	if inserted < len(batch) {
+		s.Warnf("[tenant: %v]insertClickhouseBatch: sink %v: %v messages were filtered by the transform or do not match any column of table %v and were skipped", r.sink.TenantName, r.sink.Name, len(batch)-inserted, r.sink.TableName)
+	}
+	if inserted == 0 {
+		return 0, nil
+	}
+	settings := map[string]string{
+		"insert_deduplication_token":       hex.EncodeToString(hash.Sum(nil)),
+		"insert_deduplicate":               "1",
+		"input_format_skip_unknown_fields": "1",
+		"date_time_input_format":           "best_effort",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clickhouseSinkRequestTimeout)
+	defer cancel()
+	_, err := client.exec(ctx, "INSERT INTO "+clickhouseTableRef(r.sink)+" FORMAT JSONEachRow", settings, body.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// removeClickhouseSinkConsumers deletes the durable consumers a sink created on its station
+func (s *Server) removeClickhouseSinkConsumers(sink models.ClickhouseSink) error {
+	stationName, station, err := s.getClickhouseSinkStation(sink)
+	if err != nil {
+		return err
+	}
+	durable := clickhouseSinkDurableName(sink.Name)
+	for streamName := range stationStreamsAndFilters(stationName, station) {
+		err = s.memphisRemoveConsumer(sink.TenantName, streamName, durable)
+		if err != nil && !IsNatsErr(err, JSConsumerNotFoundErr) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeClickhouseSinksMetrics(mw *metricsWriter) {
+	clickhouseSinks.Lock()
+	defer clickhouseSinks.Unlock()
+	for _, runner := range clickhouseSinks.runners {
+		labels := []string{"tenant", runner.sink.TenantName, "sink", runner.sink.Name, "station", runner.sink.StationName, "table", runner.sink.Database + "." + runner.sink.TableName}
+		running := float64(0)
+		if runner.running.Load() {
+			running = 1
+		}
+		mw.add("memphis_clickhouse_sink_running", "gauge", "Whether the ClickHouse sink is consuming its station", running, labels...)
+		mw.add("memphis_clickhouse_sink_messages_total", "counter", "Messages inserted into ClickHouse by the sink", float64(runner.messages.Load()), labels...)
+		mw.add("memphis_clickhouse_sink_batches_total", "counter", "Batches inserted into ClickHouse by the sink", float64(runner.batches.Load()), labels...)
+		mw.add("memphis_clickhouse_sink_skipped_total", "counter", "Messages skipped by the sink because the transform filtered them or they matched no column", float64(runner.skipped.Load()), labels...)
+		mw.add("memphis_clickhouse_sink_errors_total", "counter", "Errors encountered by the ClickHouse sink", float64(runner.errors.Load()), labels...)
+	}
+}
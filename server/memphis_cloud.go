@@ -434,7 +434,7 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 				Hosts:       hosts,
 			})
 		}
-	} else if configuration.LOCAL_CLUSTER_ENV { // TODO not fully supported - currently shows the current broker stats only
+	} else if configuration.LOCAL_CLUSTER_ENV { // resources are of the current broker only, pods count reflects the whole cluster
 		metricsEnabled = true
 		hosts = []string{"localhost"}
 		maxCpu := float64(runtime.GOMAXPROCS(0))
@@ -484,13 +484,14 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 			Healthy: true,
 		}
 		comp.Status = checkPodStatus(comp.CPU.Percentage, comp.Memory.Percentage, comp.Storage.Percentage)
+		desiredBrokers, actualBrokers := mh.S.brokersCount()
 		components = append(components, models.SystemComponents{
 			Name:        "memphis",
 			Components:  getComponentsStructByOneComp(comp),
 			Status:      comp.Status,
 			Ports:       []int{mh.S.opts.UiPort, mh.S.opts.Port, mh.S.opts.Websocket.Port, mh.S.opts.HTTPPort},
-			DesiredPods: 1,
-			ActualPods:  1,
+			DesiredPods: desiredBrokers,
+			ActualPods:  actualBrokers,
 			Hosts:       hosts,
 		})
 		resp, err := http.Get(fmt.Sprintf("http://localhost:%v/monitoring/getResourcesUtilization", mh.S.opts.RestGwPort))
@@ -657,19 +658,18 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 				return components, metricsEnabled, err
 			}
 		}
-		deploymentsClient := clientset.AppsV1().Deployments(mh.S.opts.K8sNamespace)
-		deploymentsList, err := deploymentsClient.List(context.TODO(), metav1.ListOptions{})
+		deploymentsList, err := listK8sDeployments(mh.S.opts.K8sNamespace)
 		if err != nil {
 			return components, metricsEnabled, err
 		}
 
-		pods, err := clientset.CoreV1().Pods(mh.S.opts.K8sNamespace).List(context.TODO(), metav1.ListOptions{})
+		pods, err := listK8sPods(mh.S.opts.K8sNamespace)
 		if err != nil {
 			return components, metricsEnabled, err
 		}
 		minikubeCheck := false
 		isMinikube := false
-		for _, pod := range pods.Items {
+		for _, pod := range pods {
 			if pod.Status.Phase != v1.PodRunning {
 				allComponents = append(allComponents, defaultSystemComp(pod.Name, false))
 				continue
@@ -809,7 +809,7 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 			portsMap[pod.Name] = ports
 		}
 
-		for _, d := range deploymentsList.Items {
+		for _, d := range deploymentsList {
 			desired := int(*d.Spec.Replicas)
 			actual := int(d.Status.ReadyReplicas)
 			relevantComponents := getRelevantComponents(d.Name, allComponents, desired)
@@ -855,16 +855,16 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 				Ports:       relevantPorts,
 				DesiredPods: desired,
 				ActualPods:  actual,
+				Restarts:    workloadRestarts(d.Spec.Selector, pods),
 				Hosts:       hosts,
 			})
 		}
 
-		statefulsetsClient := clientset.AppsV1().StatefulSets(mh.S.opts.K8sNamespace)
-		statefulsetsList, err := statefulsetsClient.List(context.TODO(), metav1.ListOptions{})
+		statefulsetsList, err := listK8sStatefulSets(mh.S.opts.K8sNamespace)
 		if err != nil {
 			return components, metricsEnabled, err
 		}
-		for _, s := range statefulsetsList.Items {
+		for _, s := range statefulsetsList {
 			desired := int(*s.Spec.Replicas)
 			actual := int(s.Status.ReadyReplicas)
 			relevantComponents := getRelevantComponents(s.Name, allComponents, desired)
@@ -910,6 +910,7 @@ func (mh MonitoringHandler) GetSystemComponents() ([]models.SystemComponents, bo
 				Ports:       relevantPorts,
 				DesiredPods: desired,
 				ActualPods:  actual,
+				Restarts:    workloadRestarts(s.Spec.Selector, pods),
 				Hosts:       hosts,
 			})
 		}
@@ -1199,8 +1200,8 @@ func SetCors(router *gin.Engine) {
 			return true
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", RequestIdHeader},
+		ExposeHeaders:    []string{"Content-Length", RequestIdHeader, "Deprecation", "Link"},
 		AllowCredentials: true,
 		AllowWildcard:    true,
 		AllowWebSockets:  true,
@@ -1232,7 +1233,13 @@ func (umh UserMgmtHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, refreshToken, err := CreateTokens(user)
+	sessionID, err := createSession(c, user)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]Login at createSession: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	token, refreshToken, err := CreateTokens(user, sessionID)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]Login at CreateTokens: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -1300,6 +1307,7 @@ func (umh UserMgmtHandler) Login(c *gin.Context) {
 		"avatar_id":               user.AvatarId,
 		"last_login":              lastLogin,
 		"send_analytics":          shouldSendAnalytics,
+		"analytics_categories":    analytics.EnabledCategories(),
 		"env":                     env,
 		"full_name":               user.FullName,
 		"skip_get_started":        user.SkipGetStarted,
@@ -1498,6 +1506,15 @@ func (umh UserMgmtHandler) AddUser(c *gin.Context) {
 		newUser.Roles = []int{roleID}
 	}
 
+	if userType == "application" && configuration.USER_PASS_BASED_AUTH {
+		err = storeScramCredential(newUser.Username, user.TenantName, scramPasswordSecret, body.Password)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]AddUser at storeScramCredential: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
 	err = memphis_cache.SetUser(newUser)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]AddUser at writing to the user cache error: %v", user.TenantName, user.Username, err)
@@ -1522,6 +1539,7 @@ func (umh UserMgmtHandler) AddUser(c *gin.Context) {
 	}
 
 	serv.Noticef("[tenant: %v][user: %v]User %v has been created", user.TenantName, user.Username, username)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("User %v has been created by user %v", username, user.Username))
 	permissions := ExternalPermissions(internalPermissions)
 	c.IndentedJSON(200, gin.H{
 		"id":                      newUser.ID,
@@ -1578,6 +1596,23 @@ func (umh UserMgmtHandler) RemoveUser(c *gin.Context) {
 		return
 	}
 
+	if body.TransferTo != _EMPTY_ {
+		transfer, errMsg, err := transferUserResources(userToRemove, body.TransferTo)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RemoveUser at transferUserResources: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if errMsg != _EMPTY_ {
+			serv.Warnf("[tenant: %v][user: %v]RemoveUser: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		message := fmt.Sprintf("%v stations, %v schemas and %v producers of user %v have been transferred to user %v by user %v", transfer.Stations, transfer.Schemas, transfer.Producers, username, strings.ToLower(body.TransferTo), user.Username)
+		serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+
 	err = updateDeletedUserResources(userToRemove)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]RemoveUser at updateDeletedUserResources: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
@@ -1592,6 +1627,13 @@ func (umh UserMgmtHandler) RemoveUser(c *gin.Context) {
 		return
 	}
 
+	err = db.DeleteScramCredentialsByUsername(username, userToRemove.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveUser at DeleteScramCredentialsByUsername: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
 	//TODO - at the future, do not remove the role and permissions from the DB, just remove the role from the user
 	err = db.RemoveRoleAndPermissions(userToRemove.Roles, userToRemove.TenantName)
 	if err != nil {
@@ -1621,6 +1663,7 @@ func (umh UserMgmtHandler) RemoveUser(c *gin.Context) {
 	}
 
 	serv.Noticef("[tenant: %v][user: %v]User %v has been deleted by user %v", user.TenantName, user.Username, username, user.Username)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("User %v has been deleted by user %v", username, user.Username))
 	c.IndentedJSON(200, gin.H{})
 }
 
@@ -1671,6 +1714,8 @@ func shouldCreateSystemTasksStream() bool {
 	return false
 }
 
+// EditAnalytics turns analytics categories on and off, categories missing from the request keep their value
+// and send_analytics=false turns all of them off
 func (umh UserMgmtHandler) EditAnalytics(c *gin.Context) {
 	var body models.EditAnalyticsSchema
 	ok := utils.Validate(c, &body, false, nil)
@@ -1678,25 +1723,51 @@ func (umh UserMgmtHandler) EditAnalytics(c *gin.Context) {
 		return
 	}
 
-	flag := "false"
-	if body.SendAnalytics {
-		flag = "true"
+	categories := analytics.EnabledCategories()
+	if body.Categories == nil {
+		for category := range categories {
+			categories[category] = body.SendAnalytics
+		}
+	}
+	for category, enabled := range body.Categories {
+		if _, ok := categories[category]; !ok {
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": fmt.Sprintf("Unknown analytics category %v, has to be one of %v", category, strings.Join(analytics.Categories, ", "))})
+			return
+		}
+		categories[category] = enabled && body.SendAnalytics
+	}
+	if !body.SendAnalytics {
+		// sent before the categories are turned off so the opt out itself is counted
+		user, _ := getUserDetailsFromMiddleware(c)
+		analyticsParams := make(map[string]interface{})
+		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-disable-analytics")
 	}
 
-	err := db.EditConfigurationValue("analytics", flag, serv.MemphisGlobalAccountString())
+	for category, enabled := range categories {
+		err := db.UpsertConfiguration("analytics_"+category, strconv.FormatBool(enabled), serv.MemphisGlobalAccountString())
+		if err != nil {
+			serv.Errorf("EditAnalytics: " + err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+	err := db.UpsertConfiguration("analytics", strconv.FormatBool(body.SendAnalytics), serv.MemphisGlobalAccountString())
 	if err != nil {
 		serv.Errorf("EditAnalytics: " + err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	analytics.SetEnabledCategories(categories)
 
-	if !body.SendAnalytics {
-		user, _ := getUserDetailsFromMiddleware(c)
-		analyticsParams := make(map[string]interface{})
-		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-disable-analytics")
+	// the other brokers pick the change up on reload
+	err = serv.SendReloadSignal()
+	if err != nil {
+		serv.Errorf("EditAnalytics at SendReloadSignal: " + err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
 	}
 
-	c.IndentedJSON(200, gin.H{})
+	c.IndentedJSON(200, gin.H{"send_analytics": analytics.AnyCategoryEnabled(), "categories": categories})
 }
 
 func (s *Server) GetCustomDeploymentId() string {
@@ -1807,26 +1878,33 @@ func (umh UserMgmtHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 	username := user.Username
-	_, systemKey, err := db.GetSystemKey("analytics", serv.MemphisGlobalAccountString())
+	sendAnalytics, _ := shouldSendAnalytics()
+	exist, user, err := memphis_cache.GetUser(username, user.TenantName, true)
 	if err != nil {
 		serv.Errorf("RefreshToken: User " + username + ": " + err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
-	sendAnalytics, _ := strconv.ParseBool(systemKey.Value)
-	exist, user, err := memphis_cache.GetUser(username, user.TenantName, true)
+	if !exist {
+		serv.Warnf("RefreshToken: user " + username + " does not exist")
+		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	sessionID := getSessionIDFromMiddleware(c)
+	extended, err := db.ExtendSession(sessionID, time.Now().Add(time.Minute*time.Duration(REFRESH_JWT_EXPIRES_IN_MINUTES)))
 	if err != nil {
-		serv.Errorf("RefreshToken: User " + username + ": " + err.Error())
+		serv.Errorf("RefreshToken at ExtendSession: User " + username + ": " + err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
-	if !exist {
-		serv.Warnf("RefreshToken: user " + username + " does not exist")
+	if !extended {
+		serv.Warnf("RefreshToken: session of user " + username + " was revoked or expired")
 		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
 		return
 	}
 
-	token, refreshToken, err := CreateTokens(user)
+	token, refreshToken, err := CreateTokens(user, sessionID)
 	if err != nil {
 		serv.Errorf("RefreshToken: User " + username + ": " + err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -1878,6 +1956,7 @@ func (umh UserMgmtHandler) RefreshToken(c *gin.Context) {
 		"already_logged_in":       user.AlreadyLoggedIn,
 		"avatar_id":               user.AvatarId,
 		"send_analytics":          sendAnalytics,
+		"analytics_categories":    analytics.EnabledCategories(),
 		"env":                     env,
 		"namespace":               serv.opts.K8sNamespace,
 		"full_name":               user.FullName,
@@ -2043,7 +2122,7 @@ func shouldSendAnalytics() (bool, error) {
 	if configuration.ENV == "staging" || configuration.ENV == "dev" {
 		return false, nil
 	}
-	return true, nil
+	return analytics.AnyCategoryEnabled(), nil
 	// exist, systemKey, err := db.GetSystemKey("analytics", serv.MemphisGlobalAccountString())
 	// if err != nil {
 	// 	return false, err
@@ -2198,6 +2277,12 @@ func (sh StationsHandler) Produce(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	err = authorizeExternally(externalAuthzInput{TenantName: user.TenantName, Username: user.Username, StationName: stationName.Ext(), Action: externalAuthzProduce, Source: "ui"})
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]Produce at authorizeExternally: %v", user.TenantName, user.Username, err.Error())
+		abortWithError(c, err)
+		return
+	}
 
 	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
 	if err != nil {
@@ -2356,7 +2441,7 @@ func GetAllFirstActiveFunctionsIDByStationID(stationId int, tenantName string) (
 	return map[int]int{}, nil
 }
 
-func (s *Server) CreateStream(tenantName string, sn StationName, retentionType string, retentionValue int, storageType string, idempotencyW int64, replicas int, tieredStorageEnabled bool, partition_number int, functionsEnabled bool) error {
+func (s *Server) CreateStream(tenantName string, sn StationName, retentionType string, retentionValue int, storageType string, idempotencyW int64, replicas int, tieredStorageEnabled bool, partition_number int, functionsEnabled bool, requestID string) error {
 	var maxMsgs int
 	if retentionType == "messages" && retentionValue > 0 {
 		maxMsgs = retentionValue
@@ -2398,7 +2483,7 @@ func (s *Server) CreateStream(tenantName string, sn StationName, retentionType s
 	}
 
 	return s.
-		memphisAddStream(tenantName, &StreamConfig{
+		memphisAddStreamWithHeaders(tenantName, &StreamConfig{
 			Name:                 internName,
 			Subjects:             []string{internName + ".>"},
 			Retention:            retentionPolicy,
@@ -2413,7 +2498,7 @@ func (s *Server) CreateStream(tenantName string, sn StationName, retentionType s
 			NoAck:                false,
 			Duplicates:           idempotencyWindow,
 			TieredStorageEnabled: tieredStorageEnabled,
-		})
+		}, requestIDHeaders(requestID))
 }
 
 func (s *Server) ConsumeFunctionTasks() {
@@ -2465,70 +2550,6 @@ func getUsageLimitProduersLimitPerStation(tenantName, stationName string) (float
 	return -1, nil
 }
 
-func createUser(userName, userType, password string) error {
-	username := strings.ToLower(userName)
-	usernameError := validateUsername(username)
-	if usernameError != nil {
-		return usernameError
-	}
-
-	userTypeToLower := strings.ToLower(userType)
-	userTypeError := validateUserType(userTypeToLower)
-	if userTypeError != nil {
-		return userTypeError
-	}
-
-	avatarId := 1
-	if password == _EMPTY_ {
-		return fmt.Errorf("Password was not provided for user %s", username)
-	}
-	passwordErr := validatePassword(password)
-	if passwordErr != nil {
-		return passwordErr
-	}
-
-	if userType == "management" {
-		hashedPwd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
-		if err != nil {
-			return err
-		}
-		password = string(hashedPwd)
-	}
-
-	fullName := _EMPTY_
-	subscription := false
-	pending := false
-	team := _EMPTY_
-	position := _EMPTY_
-	owner := _EMPTY_
-	description := _EMPTY_
-	var err error
-	if userType == "application" {
-		if configuration.USER_PASS_BASED_AUTH {
-			if password == _EMPTY_ {
-				return fmt.Errorf("Password was not provided for user for user %v", username)
-			}
-			password, err = EncryptAES([]byte(password))
-			if err != nil {
-				return err
-			}
-		}
-	}
-	_, err = db.CreateUser(username, userType, password, fullName, subscription, avatarId, serv.MemphisGlobalAccountString(), pending, team, position, owner, description)
-	if err != nil {
-		return err
-	}
-
-	shouldSendAnalytics, _ := shouldSendAnalytics()
-	if shouldSendAnalytics {
-		analyticsParams := map[string]interface{}{
-			"username": username,
-		}
-		analytics.SendEvent(serv.MemphisGlobalAccountString(), username, analyticsParams, "user-add-user")
-	}
-	return nil
-}
-
 func convertToUsers(usersData []interface{}) []userDetails {
 	var users []userDetails
 	for _, userData := range usersData {
@@ -2576,21 +2597,26 @@ func parseYamlFile(initialConfigFile string) (configUsers, error) {
 		return configUsers{}, err
 	}
 
-	usersMapData := map[interface{}]interface{}{}
 	usersMapDataMgmt := []interface{}{}
 	usersMapDataClient := []interface{}{}
 
+	// the users are declared under "users" in the broker config and under "auth" in the helm values,
+	// application users are listed as "client" in the former and as "app" in the latter
 	usersMap, usersOk := data["users"].(map[interface{}]interface{})
+	if !usersOk {
+		usersMap, usersOk = data["auth"].(map[interface{}]interface{})
+	}
 	if usersOk {
-		usersMapData = usersMap
-		usersMgmtMap, usersMgmtOk := usersMapData["mgmt"].([]interface{})
+		usersMgmtMap, usersMgmtOk := usersMap["mgmt"].([]interface{})
 		if usersMgmtOk {
 			usersMapDataMgmt = usersMgmtMap
 		}
 
-		usersClientMap, usersClientOk := usersMapData["client"].([]interface{})
-		if usersClientOk {
-			usersMapDataClient = usersClientMap
+		for _, key := range []string{"client", "app"} {
+			usersClientMap, usersClientOk := usersMap[key].([]interface{})
+			if usersClientOk {
+				usersMapDataClient = append(usersMapDataClient, usersClientMap...)
+			}
 		}
 	}
 
@@ -2607,9 +2633,10 @@ func parseYamlFile(initialConfigFile string) (configUsers, error) {
 	return confUsers, nil
 }
 
-func CreateUsersFromConfigOnFirstSystemLoad() (int, error) {
+// readConfigUsers returns the users declared in the initial config of the deployment,
+// the mounted initial.conf on k8s or the INITIAL_CONFIG_FILE environment variable elsewhere
+func readConfigUsers() (configUsers, error) {
 	var confUsers configUsers
-	lenUsers := 0
 	var initialConfigFile string
 	k8sEnv := true
 	if configuration.DOCKER_ENV == "true" || configuration.LOCAL_CLUSTER_ENV {
@@ -2619,7 +2646,7 @@ func CreateUsersFromConfigOnFirstSystemLoad() (int, error) {
 	if configuration.DEV_ENV == "true" && !k8sEnv || configuration.DOCKER_ENV == "true" {
 		initialConfigFile = os.Getenv("INITIAL_CONFIG_FILE")
 		if initialConfigFile == _EMPTY_ {
-			return 0, nil
+			return confUsers, nil
 		}
 	}
 
@@ -2627,46 +2654,31 @@ func CreateUsersFromConfigOnFirstSystemLoad() (int, error) {
 		// for local env with launch json
 		err := json.Unmarshal([]byte(initialConfigFile), &confUsers)
 		if err != nil {
-			return 0, err
+			return configUsers{}, err
 		}
 	} else if configuration.DOCKER_ENV == "true" {
 		var err error
 		confUsers, err = parseYamlFile(initialConfigFile)
 		if err != nil {
-			return 0, err
+			return configUsers{}, err
 		}
 	} else {
 		yamlFilePath := "/etc/nats-config/initial.conf"
 		yamlData, err := os.ReadFile(yamlFilePath)
 		if err != nil {
 			if !os.IsNotExist(err) {
-				return 0, err
+				return configUsers{}, err
 			}
-			return 0, nil
+			return confUsers, nil
 		}
 
 		confUsers, err = parseYamlFile(string(yamlData))
 		if err != nil {
-			return 0, err
-		}
-	}
-
-	for _, mgmtUser := range confUsers.Users.Mgmt {
-		err := createUser(mgmtUser.User, "management", mgmtUser.Password)
-		if err != nil {
-			return 0, err
+			return configUsers{}, err
 		}
 	}
 
-	for _, user := range confUsers.Users.Client {
-		err := createUser(user.User, "application", user.Password)
-		if err != nil {
-			return 0, err
-		}
-	}
-	lenUsers = len(confUsers.Users.Mgmt) + len(confUsers.Users.Client)
-
-	return lenUsers, nil
+	return confUsers, nil
 }
 
 func (s *Server) GetConnectorsByStationAndPartition(stationID, partitionNumber, numOfPartitions int) ([]map[string]string, error) {
@@ -0,0 +1,143 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Producers may compress their payloads, the codec travels with each message in the $memphis_compression header.
+// SDKs offer the codecs they support when creating a producer and the broker answers with the one the connection should use,
+// the header is still what the broker relies on when it needs the original payload back.
+const (
+	memphisCompressionHeader = "$memphis_compression"
+	compressionGzip          = "gzip"
+	compressionZstd          = "zstd"
+	compressionSnappy        = "snappy"
+	// guards the broker against payloads which inflate far beyond the max payload
+	maxDecompressedPayloadSize = 64 * 1024 * 1024
+)
+
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedPayloadSize))
+
+func isSupportedCompression(codec string) bool {
+	switch codec {
+	case compressionGzip, compressionZstd, compressionSnappy:
+		return true
+	}
+	return false
+}
+
+// enabledCompressionCodecs returns the codecs allowed by COMPRESSION_CODECS, unknown codecs are ignored
+func enabledCompressionCodecs() map[string]bool {
+	enabled := map[string]bool{}
+	for _, codec := range strings.Split(configuration.COMPRESSION_CODECS, ",") {
+		codec = strings.ToLower(strings.TrimSpace(codec))
+		if isSupportedCompression(codec) {
+			enabled[codec] = true
+		}
+	}
+	return enabled
+}
+
+// negotiateCompression picks the first codec offered by the client which is enabled on the broker,
+// an empty result means the connection should produce uncompressed payloads
+func negotiateCompression(c *client, offered []string) string {
+	if len(offered) == 0 {
+		return _EMPTY_
+	}
+	enabled := enabledCompressionCodecs()
+	for _, codec := range offered {
+		codec = strings.ToLower(strings.TrimSpace(codec))
+		if enabled[codec] {
+			if c != nil {
+				c.mu.Lock()
+				c.memphisInfo.compression = codec
+				c.mu.Unlock()
+			}
+			return codec
+		}
+	}
+	return _EMPTY_
+}
+
+func decompressPayload(codec string, data []byte) ([]byte, error) {
+	switch strings.ToLower(codec) {
+	case _EMPTY_:
+		return data, nil
+	case compressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r)
+	case compressionZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	case compressionSnappy:
+		n, err := s2.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxDecompressedPayloadSize {
+			return nil, fmt.Errorf("decompressed payload exceeds %v bytes", maxDecompressedPayloadSize)
+		}
+		return s2.Decode(nil, data)
+	default:
+		return nil, fmt.Errorf("unsupported compression %v", codec)
+	}
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxDecompressedPayloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDecompressedPayloadSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %v bytes", maxDecompressedPayloadSize)
+	}
+	return data, nil
+}
+
+// decompressStationMessage returns the payload as produced before compression, messages without the compression header are returned as is
+func decompressStationMessage(hdrs map[string]string, data []byte) ([]byte, error) {
+	codec, ok := hdrs[memphisCompressionHeader]
+	if !ok {
+		return data, nil
+	}
+	return decompressPayload(codec, data)
+}
+
+// decompressHexPayload is the flavour used for payloads kept hex encoded (e.g. dls messages),
+// the payload is returned untouched when it can not be decompressed so it can still be inspected
+func decompressHexPayload(hdrs map[string]string, hexData string) string {
+	if _, ok := hdrs[memphisCompressionHeader]; !ok {
+		return hexData
+	}
+	data, err := hex.DecodeString(hexData)
+	if err != nil {
+		return hexData
+	}
+	data, err = decompressStationMessage(hdrs, data)
+	if err != nil {
+		return hexData
+	}
+	return hex.EncodeToString(data)
+}
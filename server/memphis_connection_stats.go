@@ -0,0 +1,273 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const connectionStatsSampleInterval = 10 * time.Second
+
+type connectionStatsKey struct {
+	tenantName   string
+	connectionId string
+}
+
+type clientCounters struct {
+	inMsgs   int64
+	inBytes  int64
+	outMsgs  int64
+	outBytes int64
+}
+
+// connectionStats accumulates the traffic and errors of the SDK connections of this broker by connection id, the
+// counters of a client are sampled every connectionStatsSampleInterval so at most one interval of a closed client is
+// lost. Entries changed since the last persistence are marked dirty
+var connectionStats = struct {
+	sync.Mutex
+	stats   map[connectionStatsKey]*models.ConnectionStats
+	dirty   map[connectionStatsKey]bool
+	clients map[uint64]clientCounters
+}{
+	stats:   make(map[connectionStatsKey]*models.ConnectionStats),
+	dirty:   make(map[connectionStatsKey]bool),
+	clients: make(map[uint64]clientCounters),
+}
+
+// connectionStatsEntry returns the entry of a connection, connectionStats must be locked
+func connectionStatsEntry(key connectionStatsKey, username string) *models.ConnectionStats {
+	entry, ok := connectionStats.stats[key]
+	if !ok {
+		entry = &models.ConnectionStats{ConnectionId: key.connectionId, TenantName: key.tenantName}
+		connectionStats.stats[key] = entry
+	}
+	if username != _EMPTY_ {
+		entry.Username = username
+	}
+	connectionStats.dirty[key] = true
+	return entry
+}
+
+// recordConnectionError counts an error sent to or reported about a connection
+func recordConnectionError(tenantName, connectionId, errMsg string) {
+	if connectionId == _EMPTY_ {
+		return
+	}
+	connectionStats.Lock()
+	defer connectionStats.Unlock()
+	entry := connectionStatsEntry(connectionStatsKey{tenantName: tenantName, connectionId: connectionId}, _EMPTY_)
+	entry.Errors++
+	entry.LastError = errMsg
+	entry.LastErrorAt = time.Now()
+}
+
+// getConnectionStats returns a copy of the stats this broker holds for a connection
+func getConnectionStats(tenantName, connectionId string) models.ConnectionStats {
+	connectionStats.Lock()
+	defer connectionStats.Unlock()
+	entry, ok := connectionStats.stats[connectionStatsKey{tenantName: tenantName, connectionId: connectionId}]
+	if !ok {
+		return models.ConnectionStats{ConnectionId: connectionId, Broker: serv.Name(), TenantName: tenantName}
+	}
+	stats := *entry
+	stats.Broker = serv.Name()
+	return stats
+}
+
+// sampleConnectionStats adds the traffic of every SDK client since the previous sample to its connection
+func (s *Server) sampleConnectionStats(interval time.Duration) {
+	s.mu.RLock()
+	clients := make([]*client, 0, len(s.clients))
+	for _, c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.RUnlock()
+
+	type sample struct {
+		key      connectionStatsKey
+		username string
+		cid      uint64
+		counters clientCounters
+	}
+	samples := make([]sample, 0, len(clients))
+	for _, c := range clients {
+		c.mu.Lock()
+		if c.kind != CLIENT || c.acc == nil || c.memphisInfo.connectionId == _EMPTY_ {
+			c.mu.Unlock()
+			continue
+		}
+		samples = append(samples, sample{
+			key:      connectionStatsKey{tenantName: c.acc.GetName(), connectionId: c.memphisInfo.connectionId},
+			username: c.memphisInfo.username,
+			cid:      c.cid,
+			counters: clientCounters{
+				inMsgs:   atomic.LoadInt64(&c.inMsgs),
+				inBytes:  atomic.LoadInt64(&c.inBytes),
+				outMsgs:  c.outMsgs,
+				outBytes: c.outBytes,
+			},
+		})
+		c.mu.Unlock()
+	}
+
+	now := time.Now()
+	seconds := int64(interval.Seconds())
+	connectionStats.Lock()
+	defer connectionStats.Unlock()
+	for _, entry := range connectionStats.stats {
+		entry.InBytesPerSec, entry.OutBytesPerSec = 0, 0
+	}
+	seen := make(map[uint64]clientCounters, len(samples))
+	for _, smp := range samples {
+		prev := connectionStats.clients[smp.cid]
+		seen[smp.cid] = smp.counters
+		entry := connectionStatsEntry(smp.key, smp.username)
+		entry.InMsgs += smp.counters.inMsgs - prev.inMsgs
+		entry.InBytes += smp.counters.inBytes - prev.inBytes
+		entry.OutMsgs += smp.counters.outMsgs - prev.outMsgs
+		entry.OutBytes += smp.counters.outBytes - prev.outBytes
+		if seconds > 0 {
+			entry.InBytesPerSec += (smp.counters.inBytes - prev.inBytes) / seconds
+			entry.OutBytesPerSec += (smp.counters.outBytes - prev.outBytes) / seconds
+		}
+		entry.UpdatedAt = now
+	}
+	connectionStats.clients = seen
+}
+
+// persistConnectionStats writes the entries changed since the last call and drops the ones idle for longer than the
+// retention from memory and from the db
+func (s *Server) persistConnectionStats() {
+	retention := time.Duration(configuration.CONN_STATS_RETENTION_HOURS) * time.Hour
+	connectionStats.Lock()
+	changed := make([]models.ConnectionStats, 0, len(connectionStats.dirty))
+	for key := range connectionStats.dirty {
+		if entry, ok := connectionStats.stats[key]; ok {
+			stats := *entry
+			stats.Broker = s.Name()
+			if stats.UpdatedAt.IsZero() {
+				stats.UpdatedAt = time.Now()
+			}
+			changed = append(changed, stats)
+		}
+	}
+	connectionStats.dirty = make(map[connectionStatsKey]bool)
+	for key, entry := range connectionStats.stats {
+		if time.Since(entry.UpdatedAt) > retention && time.Since(entry.LastErrorAt) > retention {
+			delete(connectionStats.stats, key)
+		}
+	}
+	connectionStats.Unlock()
+
+	err := db.UpsertConnectionStats(changed)
+	if err != nil {
+		s.Errorf("persistConnectionStats at UpsertConnectionStats: %v", err.Error())
+		// marked again so the next persistence retries them
+		connectionStats.Lock()
+		for _, stats := range changed {
+			connectionStats.dirty[connectionStatsKey{tenantName: stats.TenantName, connectionId: stats.ConnectionId}] = true
+		}
+		connectionStats.Unlock()
+	}
+	err = db.DeleteConnectionStatsOlderThan(time.Now().Add(-retention))
+	if err != nil {
+		s.Errorf("persistConnectionStats at DeleteConnectionStatsOlderThan: %v", err.Error())
+	}
+}
+
+// loadConnectionStats restores the counters this broker persisted before a restart
+func (s *Server) loadConnectionStats() {
+	stored, err := db.GetConnectionStatsByBroker(s.Name())
+	if err != nil {
+		s.Errorf("loadConnectionStats at GetConnectionStatsByBroker: %v", err.Error())
+		return
+	}
+	connectionStats.Lock()
+	defer connectionStats.Unlock()
+	for _, stats := range stored {
+		entry := stats
+		entry.InBytesPerSec, entry.OutBytesPerSec = 0, 0
+		connectionStats.stats[connectionStatsKey{tenantName: stats.TenantName, connectionId: stats.ConnectionId}] = &entry
+	}
+}
+
+// TrackConnectionStats samples the SDK connections of the broker and persists their stats every
+// CONN_STATS_PERSIST_SEC
+func (s *Server) TrackConnectionStats() {
+	s.loadConnectionStats()
+	sampleTicker := time.NewTicker(connectionStatsSampleInterval)
+	defer sampleTicker.Stop()
+	persistTicker := time.NewTicker(time.Duration(configuration.CONN_STATS_PERSIST_SEC) * time.Second)
+	defer persistTicker.Stop()
+	for {
+		select {
+		case <-s.quitCh:
+			s.sampleConnectionStats(connectionStatsSampleInterval)
+			s.persistConnectionStats()
+			return
+		case <-sampleTicker.C:
+			s.sampleConnectionStats(connectionStatsSampleInterval)
+		case <-persistTicker.C:
+			s.persistConnectionStats()
+		}
+	}
+}
+
+// mergeConnectionStats sums the rows the brokers persisted for each connection, the noisiest connections first
+func mergeConnectionStats(rows []models.ConnectionStats) []models.ConnectionStats {
+	merged := make(map[string]*models.ConnectionStats)
+	var order []string
+	for _, row := range rows {
+		entry, ok := merged[row.ConnectionId]
+		if !ok {
+			copied := row
+			copied.Broker = _EMPTY_
+			merged[row.ConnectionId] = &copied
+			order = append(order, row.ConnectionId)
+			continue
+		}
+		entry.InMsgs += row.InMsgs
+		entry.InBytes += row.InBytes
+		entry.OutMsgs += row.OutMsgs
+		entry.OutBytes += row.OutBytes
+		entry.InBytesPerSec += row.InBytesPerSec
+		entry.OutBytesPerSec += row.OutBytesPerSec
+		entry.Errors += row.Errors
+		if row.LastErrorAt.After(entry.LastErrorAt) {
+			entry.LastError = row.LastError
+			entry.LastErrorAt = row.LastErrorAt
+		}
+		if row.UpdatedAt.After(entry.UpdatedAt) {
+			entry.UpdatedAt = row.UpdatedAt
+		}
+		if entry.Username == _EMPTY_ {
+			entry.Username = row.Username
+		}
+	}
+	result := make([]models.ConnectionStats, 0, len(order))
+	for _, connectionId := range order {
+		result = append(result, *merged[connectionId])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].InBytes+result[i].OutBytes != result[j].InBytes+result[j].OutBytes {
+			return result[i].InBytes+result[i].OutBytes > result[j].InBytes+result[j].OutBytes
+		}
+		return result[i].Errors > result[j].Errors
+	})
+	return result
+}
@@ -0,0 +1,312 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const connectionTokenRandomBytesLen = 24
+
+func hashConnectionToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateConnectionToken() (string, error) {
+	raw := make([]byte, connectionTokenRandomBytesLen)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// usableConnectionTokens returns the secrets a user may connect with, its own tokens or the global connection token
+// for users which never had their token rotated, without the ones that have been revoked
+func usableConnectionTokens(tokens []string, globalToken string, revokedHashes map[string]bool) []string {
+	if len(tokens) == 0 {
+		tokens = []string{globalToken}
+	}
+	usable := []string{}
+	for _, token := range tokens {
+		if revokedHashes[hashConnectionToken(token)] {
+			continue
+		}
+		usable = append(usable, token)
+	}
+	return usable
+}
+
+// validateConnectionToken checks the token or SCRAM proof presented on a new connection against the tokens of the user
+// and the revocation list, connUsername may carry the tenant id of the user as a $<id> suffix
+func (s *Server) validateConnectionToken(c *client, connUsername, globalToken, clientToken string) bool {
+	isScram := isScramCredentials(clientToken)
+	if db.MetadataDbClient.Client == nil {
+		if isScram {
+			return c.verifyScramProof(connUsername, scramSecretsFromConfig(connUsername, globalToken), clientToken)
+		}
+		return comparePasswords(globalToken, clientToken)
+	}
+	username, tenantId, err := getUserAndTenantIdFromString(connUsername)
+	if err != nil {
+		return false
+	}
+	username = strings.ToLower(username)
+	tenantName, encryptedTokens, revokedHashesList, err := db.GetConnectionTokensForValidation(username, tenantId, s.MemphisGlobalAccountString())
+	if err != nil {
+		s.Errorf("[tenant id: %v][user: %v]validateConnectionToken at GetConnectionTokensForValidation: %v", tenantId, username, err.Error())
+		return false
+	}
+	revokedHashes := make(map[string]bool, len(revokedHashesList))
+	for _, hash := range revokedHashesList {
+		revokedHashes[hash] = true
+	}
+	if !isScram && revokedHashes[hashConnectionToken(clientToken)] {
+		s.Warnf("[tenant: %v][user: %v]validateConnectionToken: connection attempt with a revoked token", tenantName, username)
+		return false
+	}
+
+	tokens := []string{}
+	for _, encryptedToken := range encryptedTokens {
+		token, err := DecryptAES(getAESKey(), encryptedToken)
+		if err != nil {
+			s.Errorf("[tenant: %v][user: %v]validateConnectionToken at DecryptAES: %v", tenantName, username, err.Error())
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) == 0 && len(encryptedTokens) > 0 {
+		return false
+	}
+	usableTokens := usableConnectionTokens(tokens, globalToken, revokedHashes)
+	if isScram {
+		// the global token is configuration the server holds anyway, the tokens of the user are checked against their stored keys
+		if len(encryptedTokens) == 0 {
+			return c.verifyScramProof(connUsername, scramSecretsFromConfig(connUsername, usableTokens...), clientToken)
+		}
+		credentials, err := db.GetScramCredentialsByUsername(username, tenantName)
+		if err != nil {
+			s.Errorf("[tenant: %v][user: %v]validateConnectionToken at GetScramCredentialsByUsername: %v", tenantName, username, err.Error())
+			return false
+		}
+		secretIds := make(map[string]bool, len(usableTokens))
+		for _, token := range usableTokens {
+			secretIds[hashConnectionToken(token)] = true
+		}
+		return c.verifyScramProof(connUsername, scramSecretsOf(connUsername, credentials, secretIds), clientToken)
+	}
+	for _, token := range usableTokens {
+		if len(encryptedTokens) == 0 {
+			if comparePasswords(token, clientToken) {
+				return true
+			}
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(clientToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) RemoveExpiredConnectionTokens() {
+	ticker := time.NewTicker(15 * time.Minute)
+	for range ticker.C {
+		err := db.DeleteExpiredConnectionTokens()
+		if err != nil {
+			s.Errorf("RemoveExpiredConnectionTokens at DeleteExpiredConnectionTokens: %v", err.Error())
+		}
+	}
+}
+
+func getConnectionTokenUser(c *gin.Context, funcName, username string, user models.User) (models.User, bool) {
+	if configuration.USER_PASS_BASED_AUTH {
+		errMsg := "Connection tokens are not in use when user/password based authentication is enabled"
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return models.User{}, false
+	}
+	exist, tokenUser, err := memphis_cache.GetUser(username, user.TenantName, false)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at GetUser: User %v: %v", user.TenantName, user.Username, funcName, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return models.User{}, false
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("User %v does not exist", username)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return models.User{}, false
+	}
+	if tokenUser.UserType != "root" && tokenUser.UserType != "application" {
+		errMsg := fmt.Sprintf("User %v is not a client user, only root and application users have connection tokens", username)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return models.User{}, false
+	}
+	return tokenUser, true
+}
+
+func (umh UserMgmtHandler) RotateConnectionToken(c *gin.Context) {
+	var body models.RotateConnectionTokenSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RotateConnectionToken at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username := strings.ToLower(body.Username)
+	tokenUser, ok := getConnectionTokenUser(c, "RotateConnectionToken", username, user)
+	if !ok {
+		return
+	}
+
+	activeTokens, err := db.GetActiveConnectionTokensByUsername(tokenUser.Username, tokenUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at GetActiveConnectionTokensByUsername: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	// the first rotation keeps the global token valid for this user during the grace window
+	previousToken := _EMPTY_
+	if len(activeTokens) == 0 {
+		previousToken, err = EncryptAES([]byte(configuration.CONNECTION_TOKEN))
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at EncryptAES: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	newToken, err := generateConnectionToken()
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at generateConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	encryptedToken, err := EncryptAES([]byte(newToken))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at EncryptAES: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	graceUntil := time.Now().Add(time.Duration(body.GracePeriodMinutes) * time.Minute)
+	err = db.RotateConnectionToken(tokenUser.Username, encryptedToken, previousToken, graceUntil, user.Username, tokenUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at RotateConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = syncConnectionTokenScramCredentials(tokenUser.Username, tokenUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateConnectionToken at syncConnectionTokenScramCredentials: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Connection token of user %v has been rotated by user %v, previous tokens are valid until %v", username, user.Username, graceUntil.Format(time.RFC3339))
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	c.IndentedJSON(200, gin.H{"username": username, "connection_token": newToken, "previous_tokens_valid_until": graceUntil})
+}
+
+func (umh UserMgmtHandler) RevokeConnectionToken(c *gin.Context) {
+	var body models.RevokeConnectionTokenSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RevokeConnectionToken at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username := strings.ToLower(body.Username)
+	tokenUser, ok := getConnectionTokenUser(c, "RevokeConnectionToken", username, user)
+	if !ok {
+		return
+	}
+
+	err = db.InsertRevokedConnectionToken(tokenUser.Username, hashConnectionToken(body.Token), user.Username, tokenUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeConnectionToken at InsertRevokedConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	activeTokens, err := db.GetActiveConnectionTokensByUsername(tokenUser.Username, tokenUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeConnectionToken at GetActiveConnectionTokensByUsername: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	for _, token := range activeTokens {
+		decryptedToken, err := DecryptAES(getAESKey(), token.Token)
+		if err != nil || decryptedToken != body.Token {
+			continue
+		}
+		err = db.DeleteConnectionToken(token.ID)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RevokeConnectionToken at DeleteConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	message := fmt.Sprintf("A connection token of user %v has been revoked by user %v", username, user.Username)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (umh UserMgmtHandler) GetRevokedConnectionTokens(c *gin.Context) {
+	var body models.GetRevokedConnectionTokensSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetRevokedConnectionTokens at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	revokedTokens, err := db.GetRevokedConnectionTokensByUsername(strings.ToLower(body.Username), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetRevokedConnectionTokens at GetRevokedConnectionTokensByUsername: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, revokedTokens)
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUsableConnectionTokens(t *testing.T) {
+	const globalToken = "global-token"
+	cases := []struct {
+		name    string
+		tokens  []string
+		revoked []string
+		want    []string
+	}{
+		{name: "no tokens falls back to the global token", want: []string{globalToken}},
+		{name: "own tokens replace the global token", tokens: []string{"new", "old"}, want: []string{"new", "old"}},
+		{name: "revoked token is dropped", tokens: []string{"new", "old"}, revoked: []string{"old"}, want: []string{"new"}},
+		{name: "all tokens revoked", tokens: []string{"new", "old"}, revoked: []string{"new", "old"}, want: []string{}},
+		{name: "revoked global token", revoked: []string{globalToken}, want: []string{}},
+		{name: "revocations of unknown tokens are ignored", tokens: []string{"new"}, revoked: []string{"other"}, want: []string{"new"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			revokedHashes := map[string]bool{}
+			for _, token := range tc.revoked {
+				revokedHashes[hashConnectionToken(token)] = true
+			}
+			got := usableConnectionTokens(tc.tokens, globalToken, revokedHashes)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("usableConnectionTokens = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
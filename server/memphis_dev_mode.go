@@ -0,0 +1,186 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	devDemoSchemaName      = "demo-orders"
+	devDemoProducerName    = "demo-producer"
+	devDemoConnectionId    = "memphis-dev-mode"
+	devDemoProducerVersion = 3
+	devDemoProduceInterval = 2 * time.Second
+	devDemoSchemaContent   = `{
+		"$id": "https://example.com/order.schema.json",
+		"description": "A demo order produced by the dev mode demo producer",
+		"type": "object",
+		"properties": {
+			"order_id": {
+			"type": "number"
+			},
+			"customer": {
+			"type": "string"
+			},
+			"amount": {
+			"type": "number"
+			}
+		},
+		"required": [ "order_id", "amount" ]
+	}`
+)
+
+var devDemoStations = []struct {
+	name   string
+	schema string
+}{
+	{name: "demo-orders", schema: devDemoSchemaName},
+	{name: "demo-events"},
+}
+
+var devDemoCustomers = []string{"alice", "bob", "carol", "dave"}
+
+// DevModeEnabled reports whether the broker runs as the local playground started with --dev
+func DevModeEnabled() bool {
+	return configuration.DEV_MODE
+}
+
+// ApplyDevModeOptions turns on debug logging and keeps the streams of the playground under DEV_DATA_DIR
+func ApplyDevModeOptions(opts *Options) {
+	if !configuration.DEV_MODE {
+		return
+	}
+	opts.Debug = true
+	opts.JetStream = true
+	if opts.StoreDir == _EMPTY_ {
+		opts.StoreDir = configuration.DEV_DATA_DIR
+	}
+}
+
+// SeedDevData creates the demo schema and stations of the playground when --dev_seed is set and starts a producer
+// which keeps sending demo messages to them. Entities left by a previous run are reused
+func (s *Server) SeedDevData() error {
+	if !configuration.DEV_MODE || !configuration.DEV_SEED_DATA {
+		return nil
+	}
+	tenantName := s.MemphisGlobalAccountString()
+	exist, user, err := db.GetRootUser(tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errors.New("root user does not exist")
+	}
+
+	_, err = db.InsertNewSchemaWithVersion(devDemoSchemaName, "json", user.ID, user.Username, devDemoSchemaContent, _EMPTY_, _EMPTY_, schemaTagsToCreate(nil), tenantName)
+	if err != nil && !errors.Is(err, db.ErrSchemaExists) {
+		return err
+	}
+
+	var stations []models.Station
+	for _, demo := range devDemoStations {
+		station, err := s.seedDevStation(user, demo.name, demo.schema)
+		if err != nil {
+			return fmt.Errorf("station %v: %v", demo.name, err.Error())
+		}
+		stations = append(stations, station)
+	}
+
+	go s.runDevDemoProducer(tenantName, stations)
+	return nil
+}
+
+func (s *Server) seedDevStation(user models.User, name, schemaName string) (models.Station, error) {
+	stationName, err := StationNameFromStr(name)
+	if err != nil {
+		return models.Station{}, err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		return models.Station{}, err
+	}
+	if !exist {
+		schemaVersionNumber := 0
+		if schemaName != _EMPTY_ {
+			schemaVersionNumber = 1
+		}
+		_, _, err = CreateDefaultStation(user.TenantName, s, stationName, user, schemaName, schemaVersionNumber)
+		if err != nil {
+			return models.Station{}, err
+		}
+		exist, station, err = db.GetStationByName(stationName.Ext(), user.TenantName)
+		if err != nil {
+			return models.Station{}, err
+		}
+		if !exist {
+			return models.Station{}, errors.New("station was not created")
+		}
+	}
+
+	exist, _, err = db.GetProducerByStationIDAndConnectionId(devDemoProducerName, station.ID, devDemoConnectionId)
+	if err != nil {
+		return models.Station{}, err
+	}
+	if !exist {
+		producer, err := db.InsertNewProducer(devDemoProducerName, station.ID, "application", devDemoConnectionId, user.TenantName, station.PartitionsList, devDemoProducerVersion, "go", _EMPTY_, user.Username, _EMPTY_)
+		if err != nil {
+			return models.Station{}, err
+		}
+		producersStateAdd(station.Name, producer)
+	}
+	return station, nil
+}
+
+// runDevDemoProducer sends a demo order to every seeded station until the server shuts down
+func (s *Server) runDevDemoProducer(tenantName string, stations []models.Station) {
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		s.Errorf("[tenant: %v]runDevDemoProducer at lookupAccount: %v", tenantName, err.Error())
+		return
+	}
+	hdrs := map[string]string{
+		"$memphis_producedBy":   devDemoProducerName,
+		"$memphis_connectionId": devDemoConnectionId,
+	}
+
+	ticker := time.NewTicker(devDemoProduceInterval)
+	defer ticker.Stop()
+	for orderId := 1; ; orderId++ {
+		select {
+		case <-s.quitCh:
+			return
+		case <-ticker.C:
+		}
+		msg := fmt.Sprintf(`{"order_id":%v,"customer":"%v","amount":%.2f}`, orderId, devDemoCustomers[rand.Intn(len(devDemoCustomers))], rand.Float64()*100)
+		for _, station := range stations {
+			stationName, err := StationNameFromStr(station.Name)
+			if err != nil {
+				continue
+			}
+			subject := fmt.Sprintf("%s.final", stationName.Intern())
+			if station.Version > 0 && len(station.PartitionsList) > 0 {
+				subject = fmt.Sprintf("%s$%v.final", stationName.Intern(), station.PartitionsList[rand.Intn(len(station.PartitionsList))])
+			}
+			err = s.sendInternalAccountMsgWithHeadersWithEcho(account, subject, msg, hdrs)
+			if err != nil {
+				s.Debugf("[tenant: %v]runDevDemoProducer: station %v: %v", tenantName, station.Name, err.Error())
+			}
+		}
+	}
+}
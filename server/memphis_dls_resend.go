@@ -0,0 +1,249 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dead-letter messages can be produced to any station instead of being redelivered to the consumer groups which failed
+// them, optionally rewritten by one of the message transforms first. It runs as a resend_dls_messages job which walks the
+// messages in id order, so a retried job continues after the last message it handled.
+const (
+	resendDlsMessagesJob  = "resend_dls_messages"
+	dlsResendConnectionId = "memphis-dls-resend"
+	dlsResendBatchSize    = 100
+	dlsResendMaxFailures  = 100
+)
+
+// errDlsResendFiltered marks the messages the transform dropped, they are counted as skipped and not as failures
+var errDlsResendFiltered = errors.New("filtered out by the transform")
+
+type resendDlsMessagesParams struct {
+	StationName       string `json:"station_name"`
+	TargetStationName string `json:"target_station_name"`
+	DlsMessageIds     []int  `json:"dls_message_ids"`
+	Transform         string `json:"transform"`
+	ValidateSchema    bool   `json:"validate_schema"`
+	RemoveResent      bool   `json:"remove_resent"`
+}
+
+type resendDlsMessagesResult struct {
+	Total    int      `json:"total"`
+	LastId   int      `json:"last_id"`
+	Resent   int      `json:"resent"`
+	Skipped  int      `json:"skipped"`
+	Failed   int      `json:"failed"`
+	Failures []string `json:"failures"`
+}
+
+func init() {
+	registerJobType(resendDlsMessagesJob, jobType{run: runResendDlsMessages})
+}
+
+func (s *Server) resendDlsMessage(producedBy string, dlsMsg models.DlsMessage, targetName StationName, target models.Station, transform string, validateSchema bool) error {
+	data, err := hex.DecodeString(dlsMsg.MessageDetails.Data)
+	if err != nil {
+		return err
+	}
+	data, ok := applyMessageTransform(transform, data)
+	if !ok {
+		return errDlsResendFiltered
+	}
+	record := stationImportRecord{headers: dlsMsg.MessageDetails.Headers, payload: data}
+	return s.produceStationRecord(producedBy, dlsResendConnectionId, targetName, target, record, validateSchema)
+}
+
+func runResendDlsMessages(ctx context.Context, jc *jobContext) error {
+	var params resendDlsMessagesParams
+	if err := jc.params(&params); err != nil {
+		return err
+	}
+	var result resendDlsMessagesResult
+	if err := jc.lastResult(&result); err != nil {
+		return err
+	}
+	if result.Failures == nil {
+		result.Failures = []string{}
+	}
+	tenantName := jc.job.TenantName
+	exist, source, err := db.GetStationByName(params.StationName, tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("station %v does not exist", params.StationName)
+	}
+	targetName, err := StationNameFromStr(params.TargetStationName)
+	if err != nil {
+		return err
+	}
+	exist, target, err := db.GetStationByName(targetName.Ext(), tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("station %v does not exist", targetName.Ext())
+	}
+	if result.Total == 0 {
+		result.Total, err = db.CountDlsMsgsByIds(tenantName, source.ID, params.DlsMessageIds)
+		if err != nil {
+			return err
+		}
+	}
+	progress := func() float64 {
+		if result.Total == 0 {
+			return 0
+		}
+		return float64(result.Resent+result.Skipped+result.Failed) * 100 / float64(result.Total)
+	}
+
+	for {
+		dlsMsgs, err := db.GetDlsMsgsBatchAfterId(tenantName, source.ID, result.LastId, params.DlsMessageIds, dlsResendBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(dlsMsgs) == 0 {
+			break
+		}
+		resentIds := []int{}
+		size := int64(0)
+		for _, dlsMsg := range dlsMsgs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			err := jc.s.resendDlsMessage(jc.job.CreatedBy, dlsMsg, targetName, target, params.Transform, params.ValidateSchema)
+			switch {
+			case err == nil:
+				result.Resent++
+				resentIds = append(resentIds, dlsMsg.ID)
+				size += int64(dlsMsg.MessageDetails.Size)
+			case errors.Is(err, errDlsResendFiltered):
+				result.Skipped++
+			default:
+				result.Failed++
+				if len(result.Failures) < dlsResendMaxFailures {
+					result.Failures = append(result.Failures, fmt.Sprintf("message %v: %v", dlsMsg.ID, err.Error()))
+				}
+			}
+			result.LastId = dlsMsg.ID
+			jc.setProgress(progress(), result)
+		}
+		if len(resentIds) > 0 {
+			IncrementEventCounter(tenantName, "dls-resend", size, int64(len(resentIds)), _EMPTY_, []byte{}, []byte{})
+			if params.RemoveResent {
+				err = db.DropDlsMessages(resentIds)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	jc.setProgress(progress(), result)
+	jc.s.Noticef("[tenant: %v][user: %v]: Resend job %v from station %v to station %v completed, %v messages resent, %v skipped and %v failed", tenantName, jc.job.CreatedBy, jc.job.ID, source.Name, targetName.Ext(), result.Resent, result.Skipped, result.Failed)
+	return nil
+}
+
+// ResendDlsMessagesToStation starts a job which produces dead-letter messages of a station to a target station,
+// all of the station's dead-letter messages are resent when no ids are given
+func (sh StationsHandler) ResendDlsMessagesToStation(c *gin.Context) {
+	var body models.ResendDlsMessagesToStationSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ResendDlsMessagesToStation at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if IsStorageLimitExceeded(user.TenantName) {
+		serv.Warnf("[tenant: %v][user: %v]ResendDlsMessagesToStation at IsStorageLimitExceeded: %s", user.TenantName, user.Username, ErrUpgradePlan.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": ErrUpgradePlan.Error()})
+		return
+	}
+	// the transforms which need metadata only a cdc connector has can not be applied to stored messages
+	err = validateMessageTransform(body.Transform, models.MessageTransformDebeziumUnwrap)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ResendDlsMessagesToStation at validateMessageTransform: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "ResendDlsMessagesToStation", body.StationName, "read")
+	if !ok {
+		return
+	}
+	targetName, _, ok := getGatewayStation(c, user, "ResendDlsMessagesToStation", body.TargetStationName, "write")
+	if !ok {
+		return
+	}
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, id := range body.DlsMessageIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		count, err := db.CountDlsMsgsByIds(user.TenantName, station.ID, ids)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]ResendDlsMessagesToStation at CountDlsMsgsByIds: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if count != len(ids) {
+			errMsg := fmt.Sprintf("%v of the dead-letter messages do not exist at station %v", len(ids)-count, stationName.Ext())
+			serv.Warnf("[tenant: %v][user: %v]ResendDlsMessagesToStation: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+	}
+
+	job, err := sh.S.startJob(resendDlsMessagesJob, resendDlsMessagesParams{
+		StationName:       stationName.Ext(),
+		TargetStationName: targetName.Ext(),
+		DlsMessageIds:     ids,
+		Transform:         body.Transform,
+		ValidateSchema:    body.ValidateSchema,
+		RemoveResent:      body.RemoveResent,
+	}, user)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ResendDlsMessagesToStation at startJob: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	messages := "all dead-letter messages"
+	if len(ids) > 0 {
+		messages = fmt.Sprintf("%v dead-letter messages", len(ids))
+	}
+	message := fmt.Sprintf("Resend job %v of %v from station %v to station %v has been started by user %v", job.ID, messages, stationName.Ext(), targetName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	if targetName.Ext() != stationName.Ext() {
+		createAuditLogFromRequest(c, user, targetName.Ext(), message)
+	}
+
+	c.IndentedJSON(200, job)
+}
@@ -0,0 +1,323 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/nats-io/nats.go"
+)
+
+// EdgeOpts turn the broker into an edge broker which forwards the messages produced into its stations to a central cluster.
+// Messages are produced locally as usual and stay in the local stations until the central cluster acknowledged them,
+// so producers keep working while the link is down and the backlog is forwarded once it is back.
+// Configured inside the cluster block:
+//
+//	cluster {
+//	  edge {
+//	    central_url: "nats://central-memphis:6666"
+//	    username: "edge$1"
+//	    password: "..."
+//	    stations: ["sensors", "events"]
+//	  }
+//	}
+type EdgeOpts struct {
+	CentralURL      string
+	Username        string
+	Password        string
+	ConnectionToken string
+	// Stations limits the forwarded stations, all stations are forwarded when empty
+	Stations        []string
+	ForwardInterval time.Duration
+	BatchSize       int
+}
+
+const (
+	edgeForwarderConsumer      = "$memphis_edge_forwarder"
+	edgeDefaultForwardInterval = time.Second
+	edgeDefaultBatchSize       = 100
+	edgeCentralRequestTimeout  = 5 * time.Second
+	edgeForwarderAckWait       = 30 * time.Second
+)
+
+func parseEdge(e *EdgeOpts, tk token, v interface{}) (retErr error) {
+	var lt token
+	defer convertPanicToError(&lt, &retErr)
+
+	em, ok := v.(map[string]interface{})
+	if !ok {
+		return &configErr{tk, fmt.Sprintf("Expected map to define edge, got %T", v)}
+	}
+	for mk, mv := range em {
+		tk, mv = unwrapValue(mv, &lt)
+		switch strings.ToLower(mk) {
+		case "central_url", "url":
+			e.CentralURL = mv.(string)
+		case "username", "user":
+			e.Username = mv.(string)
+		case "password":
+			e.Password = mv.(string)
+		case "connection_token", "token":
+			e.ConnectionToken = mv.(string)
+		case "stations":
+			for _, iv := range mv.([]interface{}) {
+				_, sv := unwrapValue(iv, &lt)
+				e.Stations = append(e.Stations, strings.ToLower(sv.(string)))
+			}
+		case "forward_interval":
+			switch mv := mv.(type) {
+			case string:
+				d, err := time.ParseDuration(mv)
+				if err != nil {
+					return &configErr{tk, err.Error()}
+				}
+				e.ForwardInterval = d
+			case int64:
+				e.ForwardInterval = time.Duration(mv) * time.Second
+			default:
+				return &configErr{tk, fmt.Sprintf("field %q should be a duration, got %T", mk, mv)}
+			}
+		case "batch_size":
+			e.BatchSize = int(mv.(int64))
+		default:
+			if !tk.IsUsedVariable() {
+				return &configErr{tk, fmt.Sprintf("unknown field %q", mk)}
+			}
+		}
+	}
+	if e.CentralURL == _EMPTY_ {
+		return &configErr{tk, "edge requires central_url"}
+	}
+	return nil
+}
+
+type edgeForwarder struct {
+	s    *Server
+	opts EdgeOpts
+	nc   *nats.Conn
+	// stations already created on the central cluster
+	centralStations map[string]bool
+}
+
+// ForwardEdgeStations runs on edge brokers only and keeps forwarding the local stations to the central cluster
+func (s *Server) ForwardEdgeStations() {
+	opts := s.getOpts().Cluster.Edge
+	if opts.CentralURL == _EMPTY_ {
+		return
+	}
+	if opts.ForwardInterval <= 0 {
+		opts.ForwardInterval = edgeDefaultForwardInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = edgeDefaultBatchSize
+	}
+	f := &edgeForwarder{s: s, opts: opts, centralStations: map[string]bool{}}
+	defer func() {
+		if f.nc != nil {
+			f.nc.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(opts.ForwardInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !s.isRunning() {
+			return
+		}
+		if !s.leadsBackgroundTask("ForwardEdgeStations", opts.ForwardInterval) { // logic happens once only on the leader
+			continue
+		}
+		err := f.connect()
+		if err != nil {
+			// the messages wait in the local stations until the central cluster is reachable
+			s.Debugf("ForwardEdgeStations: central cluster %v is unreachable: %v", opts.CentralURL, err.Error())
+			continue
+		}
+		err = f.forwardStations()
+		if err != nil {
+			s.Warnf("ForwardEdgeStations: %v", err.Error())
+		}
+	}
+}
+
+func (f *edgeForwarder) connect() error {
+	if f.nc != nil {
+		if f.nc.IsConnected() {
+			return nil
+		}
+		if !f.nc.IsClosed() {
+			return nats.ErrConnectionReconnecting
+		}
+	}
+	connOpts := []nats.Option{
+		nats.Name("memphis-edge-" + f.s.Name()),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(false),
+	}
+	if f.opts.ConnectionToken != _EMPTY_ {
+		connOpts = append(connOpts, nats.Token(f.opts.ConnectionToken))
+	} else if f.opts.Username != _EMPTY_ {
+		connOpts = append(connOpts, nats.UserInfo(f.opts.Username, f.opts.Password))
+	}
+	nc, err := nats.Connect(f.opts.CentralURL, connOpts...)
+	if err != nil {
+		return err
+	}
+	f.nc = nc
+	f.s.Noticef("ForwardEdgeStations: connected to the central cluster %v", f.opts.CentralURL)
+	return nil
+}
+
+func (f *edgeForwarder) shouldForward(stationName string) bool {
+	if len(f.opts.Stations) == 0 {
+		return true
+	}
+	for _, name := range f.opts.Stations {
+		if name == stationName {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *edgeForwarder) forwardStations() error {
+	tenantName := f.s.MemphisGlobalAccountString()
+	account, err := f.s.lookupAccount(tenantName)
+	if err != nil {
+		return err
+	}
+	stations, err := db.GetActiveStationsPerTenant(tenantName)
+	if err != nil {
+		return err
+	}
+	for _, station := range stations {
+		if !station.IsNative || !f.shouldForward(station.Name) {
+			continue
+		}
+		stationName, err := StationNameFromStr(station.Name)
+		if err != nil {
+			continue
+		}
+		err = f.ensureCentralStation(station)
+		if err != nil {
+			f.s.Warnf("ForwardEdgeStations at ensureCentralStation: station %v: %v", station.Name, err.Error())
+			continue
+		}
+		for streamName, filter := range stationStreamsAndFilters(stationName, station) {
+			err = f.forwardStream(account, tenantName, streamName, filter)
+			if err != nil {
+				// the rest of the stream is retried on the next round
+				return fmt.Errorf("forwarding station %v: %v", station.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// ensureCentralStation creates the station on the central cluster with the same layout as the local one,
+// so every local stream is forwarded into the stream of the same name
+func (f *edgeForwarder) ensureCentralStation(station models.Station) error {
+	if f.centralStations[station.Name] {
+		return nil
+	}
+	req := createStationRequest{
+		StationName:       station.Name,
+		RetentionType:     station.RetentionType,
+		RetentionValue:    station.RetentionValue,
+		StorageType:       station.StorageType,
+		Replicas:          1,
+		IdempotencyWindow: station.IdempotencyWindow,
+		Username:          f.opts.Username,
+		PartitionsNumber:  len(station.PartitionsList),
+	}
+	if req.PartitionsNumber == 0 {
+		req.PartitionsNumber = 1
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := f.nc.Request(memphisStationCreations, data, edgeCentralRequestTimeout)
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) > 0 && !strings.Contains(strings.ToLower(string(resp.Data)), "already exist") {
+		return fmt.Errorf("%s", resp.Data)
+	}
+	f.centralStations[station.Name] = true
+	return nil
+}
+
+func (f *edgeForwarder) forwardStream(account *Account, tenantName, streamName, filter string) error {
+	err := f.s.memphisAddConsumer(tenantName, streamName, &ConsumerConfig{
+		Durable:       edgeForwarderConsumer,
+		DeliverPolicy: DeliverAll,
+		AckPolicy:     AckExplicit,
+		AckWait:       edgeForwarderAckWait,
+		MaxDeliver:    -1,
+		FilterSubject: filter,
+		MaxAckPending: -1,
+	})
+	if err != nil && !IsNatsErr(err, JSConsumerNameExistErr, JSConsumerAlreadyExists) {
+		return err
+	}
+
+	for {
+		msgs, err := f.s.fetchStationMessages(account, streamName, edgeForwarderConsumer, f.opts.BatchSize, f.opts.ForwardInterval)
+		if err != nil {
+			return err
+		}
+		for i, msg := range msgs {
+			err = f.forward(streamName, filter, msg)
+			if err != nil {
+				// keep the order, the rest of the batch is redelivered with the failed message
+				for _, m := range msgs[i:] {
+					f.s.nackStationMessage(account, m)
+				}
+				return err
+			}
+			f.s.ackStationMessage(account, msg)
+		}
+		if len(msgs) < f.opts.BatchSize {
+			return nil
+		}
+	}
+}
+
+// forward publishes a message into the central stream, the message id makes retries after a lost ack harmless
+func (f *edgeForwarder) forward(streamName, subject string, msg stationFetchedMsg) error {
+	m := nats.NewMsg(subject)
+	for k, v := range msg.Headers {
+		m.Header[k] = []string{v}
+	}
+	if _, ok := msg.Headers[JSMsgId]; !ok {
+		sseq, _, _ := ackReplyInfo(msg.ReplySubject)
+		m.Header[JSMsgId] = []string{fmt.Sprintf("edge-%v-%v-%v", f.s.Name(), streamName, sseq)}
+	}
+	m.Data = msg.Data
+	resp, err := f.nc.RequestMsg(m, edgeCentralRequestTimeout)
+	if err != nil {
+		return err
+	}
+	var pubAck JSPubAckResponse
+	err = json.Unmarshal(resp.Data, &pubAck)
+	if err != nil {
+		return err
+	}
+	return pubAck.ToError()
+}
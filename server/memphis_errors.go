@@ -0,0 +1,207 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode is the machine readable identifier of a failure, returned next to the human readable message so
+// SDKs and the UI can branch on it instead of parsing text
+type ErrorCode string
+
+const (
+	ErrCodeInternal           ErrorCode = "internal_error"
+	ErrCodeInvalidRequest     ErrorCode = "invalid_request"
+	ErrCodeUnauthorized       ErrorCode = "unauthorized"
+	ErrCodeForbidden          ErrorCode = "forbidden"
+	ErrCodeNotFound           ErrorCode = "not_found"
+	ErrCodeAlreadyExists      ErrorCode = "already_exists"
+	ErrCodeConflict           ErrorCode = "conflict"
+	ErrCodePreconditionFailed ErrorCode = "precondition_failed"
+	ErrCodeSchemaValidation   ErrorCode = "schema_validation_failed"
+	ErrCodeRequestFailed      ErrorCode = "request_failed"
+	ErrCodeUpgradeRequired    ErrorCode = "upgrade_required"
+)
+
+type errorCatalogEntry struct {
+	Status  int
+	Message string
+	Hint    string
+}
+
+var errorCatalog = map[ErrorCode]errorCatalogEntry{
+	ErrCodeInternal:           {Status: 500, Message: "Server error", Hint: "Retry the request, if it keeps failing check the broker logs"},
+	ErrCodeInvalidRequest:     {Status: 400, Message: "Invalid request", Hint: "Check the request body and parameters against the API reference"},
+	ErrCodeUnauthorized:       {Status: 401, Message: "Unauthorized", Hint: "Log in again or refresh the access token"},
+	ErrCodeForbidden:          {Status: 403, Message: "Forbidden", Hint: "Ask an admin of the account for the required permissions"},
+	ErrCodeNotFound:           {Status: http.StatusNotFound, Message: "Resource not found", Hint: "Check the name of the resource, it may have been removed"},
+	ErrCodeAlreadyExists:      {Status: SHOWABLE_ERROR_STATUS_CODE, Message: "Resource already exists", Hint: "Pick another name or update the existing resource"},
+	ErrCodeConflict:           {Status: http.StatusConflict, Message: "The resource was changed by another request", Hint: "Reload the resource and apply the change again"},
+	ErrCodePreconditionFailed: {Status: http.StatusPreconditionFailed, Message: "The resource does not match the requested version", Hint: "Reload the resource to get its current version"},
+	ErrCodeSchemaValidation:   {Status: SCHEMA_VALIDATION_ERROR_STATUS_CODE, Message: "Invalid schema", Hint: "Fix the schema content, the message describes the first problem found"},
+	ErrCodeRequestFailed:      {Status: SHOWABLE_ERROR_STATUS_CODE, Message: "The request could not be completed", Hint: "The message describes what has to change before retrying"},
+	ErrCodeUpgradeRequired:    {Status: SHOWABLE_ERROR_STATUS_CODE, Message: "The SDK is too old for this broker", Hint: "Upgrade the Memphis SDK to its latest version"},
+}
+
+// MemphisError is an error of the catalog with a message specific to the failure
+type MemphisError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *MemphisError) Error() string {
+	return e.Message
+}
+
+// NewMemphisError returns a catalog error, an empty message falls back to the generic message of the code
+func NewMemphisError(code ErrorCode, message string) *MemphisError {
+	if message == _EMPTY_ {
+		message = errorCatalog[code].Message
+	}
+	return &MemphisError{Code: code, Message: message}
+}
+
+// errorCodeOf maps an error to its code, errors outside the catalog are classified by the sentinel they wrap
+func errorCodeOf(err error) ErrorCode {
+	var memphisErr *MemphisError
+	switch {
+	case errors.As(err, &memphisErr):
+		return memphisErr.Code
+	case errors.Is(err, db.ErrSchemaActiveVersionConflict), errors.Is(err, ErrSchemaVersionContention):
+		return ErrCodeConflict
+	case errors.Is(err, db.ErrSchemaExists), errors.Is(err, db.ErrSchemaVersionExists):
+		return ErrCodeAlreadyExists
+	case errors.Is(err, db.ErrSchemaVersionNotFound), errors.Is(err, ErrNoSchema):
+		return ErrCodeNotFound
+	}
+	var descriptorErr schemaDescriptorError
+	if errors.As(err, &descriptorErr) {
+		return ErrCodeSchemaValidation
+	}
+	return errorCodeFromMessage(err.Error(), ErrCodeRequestFailed)
+}
+
+// errorCodeFromStatus classifies the responses of handlers that abort with a status and a free text message
+func errorCodeFromStatus(status int, message string) ErrorCode {
+	switch {
+	case status == 400:
+		return ErrCodeInvalidRequest
+	case status == 401:
+		return ErrCodeUnauthorized
+	case status == 403:
+		return ErrCodeForbidden
+	case status == http.StatusNotFound:
+		return ErrCodeNotFound
+	case status == http.StatusConflict:
+		return ErrCodeConflict
+	case status == http.StatusPreconditionFailed:
+		return ErrCodePreconditionFailed
+	case status == SCHEMA_VALIDATION_ERROR_STATUS_CODE:
+		return ErrCodeSchemaValidation
+	case status >= 500:
+		return ErrCodeInternal
+	}
+	return errorCodeFromMessage(message, ErrCodeRequestFailed)
+}
+
+func errorCodeFromMessage(message string, fallback ErrorCode) ErrorCode {
+	message = strings.ToLower(message)
+	switch {
+	case strings.Contains(message, "does not exist"), strings.Contains(message, "not found"):
+		return ErrCodeNotFound
+	case strings.Contains(message, "already exist"):
+		return ErrCodeAlreadyExists
+	}
+	return fallback
+}
+
+func errorResponse(code ErrorCode, message string) gin.H {
+	entry := errorCatalog[code]
+	if message == _EMPTY_ {
+		message = entry.Message
+	}
+	return gin.H{"message": message, "code": code, "hint": entry.Hint}
+}
+
+// abortWithError answers with the status, code and hint of the error's catalog entry. The error text is shown to
+// the user, server failures are passed as ErrCodeInternal errors which only expose the generic message
+func abortWithError(c *gin.Context, err error) {
+	code := errorCodeOf(err)
+	message := err.Error()
+	if code == ErrCodeInternal {
+		message = errorCatalog[ErrCodeInternal].Message
+	}
+	c.AbortWithStatusJSON(errorCatalog[code].Status, errorResponse(code, message))
+}
+
+// errorCodesWriter holds back error bodies so ErrorCodes can add the code and hint before they are sent
+type errorCodesWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorCodesWriter) Write(data []byte) (int, error) {
+	if w.Status() < 400 {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *errorCodesWriter) WriteString(s string) (int, error) {
+	if w.Status() < 400 {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// ErrorCodes completes the error responses of the api with the code and hint of the catalog and the request id, and
+// logs them under the request id. Handlers which already set a code keep it
+func ErrorCodes(c *gin.Context) {
+	writer := &errorCodesWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	if writer.body.Len() == 0 {
+		return
+	}
+	body := writer.body.Bytes()
+	requestID := requestIDFromContext(c)
+	var response map[string]interface{}
+	if json.Unmarshal(body, &response) == nil && response != nil {
+		message, _ := response["message"].(string)
+		if _, ok := response["code"]; !ok {
+			code := errorCodeFromStatus(writer.Status(), message)
+			response["code"] = code
+			response["hint"] = errorCatalog[code].Hint
+		}
+		if requestID != _EMPTY_ {
+			response["request_id"] = requestID
+		}
+		if completed, err := json.Marshal(response); err == nil {
+			body = completed
+		}
+		if serv != nil && requestID != _EMPTY_ {
+			serv.Warnf("[request: %v]%v %v answered %v: %v", requestID, c.Request.Method, c.Request.URL.Path, writer.Status(), message)
+		}
+	}
+	writer.ResponseWriter.Write(body)
+}
@@ -0,0 +1,71 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gofrs/uuid"
+)
+
+const brokerEventsQueueSize = 4096
+
+type brokerEventHandler func(event models.BrokerEvent)
+
+// brokerEvents fans out resource lifecycle events to in-process subscribers,
+// publishing never blocks the request that caused the event
+var brokerEvents = struct {
+	sync.RWMutex
+	handlers []brokerEventHandler
+	queue    chan models.BrokerEvent
+}{queue: make(chan models.BrokerEvent, brokerEventsQueueSize)}
+
+func subscribeToBrokerEvents(handler brokerEventHandler) {
+	brokerEvents.Lock()
+	defer brokerEvents.Unlock()
+	brokerEvents.handlers = append(brokerEvents.handlers, handler)
+}
+
+func publishBrokerEvent(tenantName, eventType string, data map[string]interface{}) {
+	if tenantName == _EMPTY_ {
+		tenantName = serv.MemphisGlobalAccountString()
+	}
+	event := models.BrokerEvent{
+		Type:       eventType,
+		TenantName: tenantName,
+		Time:       time.Now(),
+		Data:       data,
+	}
+	uid, err := uuid.NewV4()
+	if err == nil {
+		event.ID = uid.String()
+	}
+	select {
+	case brokerEvents.queue <- event:
+	default:
+		serv.Warnf("[tenant: %v]publishBrokerEvent: events queue is full, event %v has been dropped", tenantName, eventType)
+	}
+}
+
+func (s *Server) DispatchBrokerEvents() {
+	for event := range brokerEvents.queue {
+		brokerEvents.RLock()
+		handlers := brokerEvents.handlers
+		brokerEvents.RUnlock()
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// When EXTERNAL_AUTHZ_URL is set, producers and consumers are authorized by an external policy engine on top of the
+// station permissions of their user. The broker posts an OPA compatible input document to the url, e.g.
+// http://opa:8181/v1/data/memphis/allow, and accepts either a boolean result or an object with allow and reason.
+// Decisions are cached for EXTERNAL_AUTHZ_CACHE_SEC, a negative value disables the cache. When the authorizer can
+// not be reached the request is denied unless EXTERNAL_AUTHZ_FAIL_OPEN is set.
+const (
+	externalAuthzProduce = "produce"
+	externalAuthzConsume = "consume"
+
+	externalAuthzCacheMaxEntries = 10000
+	externalAuthzMaxResponseSize = 1024 * 1024
+)
+
+type externalAuthzInput struct {
+	TenantName   string `json:"tenant_name"`
+	Username     string `json:"username"`
+	StationName  string `json:"station_name"`
+	Action       string `json:"action"`
+	ClientName   string `json:"client_name,omitempty"`
+	ConnectionId string `json:"connection_id,omitempty"`
+	Source       string `json:"source"`
+}
+
+type externalAuthzRequest struct {
+	Input externalAuthzInput `json:"input"`
+}
+
+type externalAuthzDecision struct {
+	allowed   bool
+	reason    string
+	expiresAt time.Time
+}
+
+var externalAuthzClient *http.Client
+
+var externalAuthzCache = struct {
+	sync.Mutex
+	decisions map[externalAuthzInput]externalAuthzDecision
+}{decisions: map[externalAuthzInput]externalAuthzDecision{}}
+
+func (s *Server) InitializeExternalAuthorizer() error {
+	if configuration.EXTERNAL_AUTHZ_URL == _EMPTY_ {
+		return nil
+	}
+	u, err := url.Parse(configuration.EXTERNAL_AUTHZ_URL)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("EXTERNAL_AUTHZ_URL has to be an http(s) url")
+	}
+	externalAuthzClient = &http.Client{Timeout: time.Duration(configuration.EXTERNAL_AUTHZ_TIMEOUT_MS) * time.Millisecond}
+	return nil
+}
+
+// authorizeExternally asks the external authorizer whether the user may produce to or consume from the station,
+// nil is returned when no authorizer is configured. Internal users are not sent to the authorizer
+func authorizeExternally(input externalAuthzInput) error {
+	if externalAuthzClient == nil || strings.HasPrefix(input.Username, "$") {
+		return nil
+	}
+	decision, cached := cachedExternalAuthzDecision(input)
+	if !cached {
+		var err error
+		decision, err = requestExternalAuthzDecision(input)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]authorizeExternally: %v to station %v: %v", input.TenantName, input.Username, input.Action, input.StationName, err.Error())
+			if configuration.EXTERNAL_AUTHZ_FAIL_OPEN {
+				return nil
+			}
+			return NewMemphisError(ErrCodeForbidden, fmt.Sprintf("could not authorize %v at station %v, the external authorizer is unavailable", input.Action, input.StationName))
+		}
+		cacheExternalAuthzDecision(input, decision)
+	}
+	if decision.allowed {
+		return nil
+	}
+	message := fmt.Sprintf("%v at station %v was denied for user %v by the external authorizer", input.Action, input.StationName, input.Username)
+	if decision.reason != _EMPTY_ {
+		message += ": " + decision.reason
+	}
+	return NewMemphisError(ErrCodeForbidden, message)
+}
+
+func cachedExternalAuthzDecision(input externalAuthzInput) (externalAuthzDecision, bool) {
+	externalAuthzCache.Lock()
+	defer externalAuthzCache.Unlock()
+	decision, ok := externalAuthzCache.decisions[input]
+	if !ok || time.Now().After(decision.expiresAt) {
+		return externalAuthzDecision{}, false
+	}
+	return decision, true
+}
+
+func cacheExternalAuthzDecision(input externalAuthzInput, decision externalAuthzDecision) {
+	if configuration.EXTERNAL_AUTHZ_CACHE_SEC < 0 {
+		return
+	}
+	decision.expiresAt = time.Now().Add(time.Duration(configuration.EXTERNAL_AUTHZ_CACHE_SEC) * time.Second)
+	externalAuthzCache.Lock()
+	defer externalAuthzCache.Unlock()
+	if len(externalAuthzCache.decisions) >= externalAuthzCacheMaxEntries {
+		now := time.Now()
+		for key, cached := range externalAuthzCache.decisions {
+			if now.After(cached.expiresAt) {
+				delete(externalAuthzCache.decisions, key)
+			}
+		}
+		if len(externalAuthzCache.decisions) >= externalAuthzCacheMaxEntries {
+			externalAuthzCache.decisions = map[externalAuthzInput]externalAuthzDecision{}
+		}
+	}
+	externalAuthzCache.decisions[input] = decision
+}
+
+func requestExternalAuthzDecision(input externalAuthzInput) (externalAuthzDecision, error) {
+	body, err := json.Marshal(externalAuthzRequest{Input: input})
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, configuration.EXTERNAL_AUTHZ_URL, bytes.NewReader(body))
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if configuration.EXTERNAL_AUTHZ_AUTH_HEADER != _EMPTY_ {
+		req.Header.Set("Authorization", configuration.EXTERNAL_AUTHZ_AUTH_HEADER)
+	}
+	resp, err := externalAuthzClient.Do(req)
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return externalAuthzDecision{}, fmt.Errorf("authorizer responded with status %v", resp.StatusCode)
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, externalAuthzMaxResponseSize))
+	if err != nil {
+		return externalAuthzDecision{}, err
+	}
+	return parseExternalAuthzDecision(respBody)
+}
+
+// parseExternalAuthzDecision reads {"result": true} or {"result": {"allow": true, "reason": "..."}}, a missing result
+// means the policy is not defined and is a deny like in OPA
+func parseExternalAuthzDecision(body []byte) (externalAuthzDecision, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return externalAuthzDecision{}, fmt.Errorf("invalid authorizer response: %v", err.Error())
+	}
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return externalAuthzDecision{reason: "no policy decision"}, nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(resp.Result, &allowed); err == nil {
+		return externalAuthzDecision{allowed: allowed}, nil
+	}
+	var result struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return externalAuthzDecision{}, fmt.Errorf("invalid authorizer result: %v", string(resp.Result))
+	}
+	return externalAuthzDecision{allowed: result.Allow, reason: result.Reason}, nil
+}
@@ -0,0 +1,309 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	gatewayDefaultBatchSize   = 10
+	gatewayMaxBatchSize       = 1000
+	gatewayDefaultWaitMs      = 5000
+	gatewayMaxWaitMs          = 30000
+	gatewayConnectionId       = "rest_gateway"
+	gatewayConsumerAckWait    = 30 * time.Second
+	gatewayConsumerMaxDeliver = 10
+)
+
+func validateGatewayHeaders(hdrs map[string]string) error {
+	for key := range hdrs {
+		if strings.HasPrefix(key, "$memphis") {
+			return fmt.Errorf("header %v is reserved, headers can not start with $memphis", key)
+		}
+	}
+	return nil
+}
+
+func validateGatewayFetchLimits(batchSize, waitMs int) (int, time.Duration, error) {
+	if batchSize == 0 {
+		batchSize = gatewayDefaultBatchSize
+	}
+	if batchSize < 0 || batchSize > gatewayMaxBatchSize {
+		return 0, 0, fmt.Errorf("batch_size must be between 1 and %v", gatewayMaxBatchSize)
+	}
+	if waitMs == 0 {
+		waitMs = gatewayDefaultWaitMs
+	}
+	if waitMs < 0 || waitMs > gatewayMaxWaitMs {
+		return 0, 0, fmt.Errorf("wait_ms must be between 1 and %v", gatewayMaxWaitMs)
+	}
+	return batchSize, time.Duration(waitMs) * time.Millisecond, nil
+}
+
+func (s *Server) gatewayProduce(tenantName, producedBy string, stationName StationName, station models.Station, message string, hdrs map[string]string) (models.GatewayProduceResponse, error) {
+	err := validateStationMessage(tenantName, station, []byte(message), hdrs)
+	if err != nil {
+		return models.GatewayProduceResponse{}, err
+	}
+	msgHdrs := make(map[string]string, len(hdrs)+4)
+	for k, v := range hdrs {
+		msgHdrs[k] = v
+	}
+	msgHdrs["$memphis_producedBy"] = producedBy
+	msgHdrs["$memphis_connectionId"] = gatewayConnectionId
+	payload := []byte(message)
+	if maxPayload := s.getOpts().MaxPayload; maxPayload > 0 && len(payload) > int(maxPayload) {
+		payload, err = s.storeClaimCheck(tenantName, stationName, payload, msgHdrs)
+		if err != nil {
+			return models.GatewayProduceResponse{}, err
+		}
+	}
+	pubAck, err := s.produceToStation(tenantName, stationName, station, payload, msgHdrs)
+	if err != nil {
+		return models.GatewayProduceResponse{}, err
+	}
+	return models.GatewayProduceResponse{Sequence: pubAck.Sequence, Duplicate: pubAck.Duplicate}, nil
+}
+
+// ensureGatewayConsumer creates the durable pull consumer backing a gateway consumer group,
+// a consumer group already created by an SDK under the same name is reused as is
+func (s *Server) ensureGatewayConsumer(tenantName, streamName, filter, durable string) error {
+	err := s.memphisAddConsumer(tenantName, streamName, &ConsumerConfig{
+		Durable:       durable,
+		DeliverPolicy: DeliverNew,
+		AckPolicy:     AckExplicit,
+		AckWait:       gatewayConsumerAckWait,
+		MaxDeliver:    gatewayConsumerMaxDeliver,
+		FilterSubject: filter,
+		MaxAckPending: -1,
+	})
+	if err != nil && !IsNatsErr(err, JSConsumerNameExistErr, JSConsumerAlreadyExists) {
+		return err
+	}
+	return nil
+}
+
+// gatewayFetch long-polls the station for up to batchSize messages, the batch is split evenly between the partitions
+func (s *Server) gatewayFetch(tenantName string, stationName StationName, station models.Station, consumerGroup string, batchSize int, wait time.Duration) ([]models.GatewayMessage, error) {
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	durable := getInternalConsumerName(consumerGroup)
+	streams := stationStreamsAndFilters(stationName, station)
+	for streamName, filter := range streams {
+		err = s.ensureGatewayConsumer(tenantName, streamName, filter, durable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	perStream := (batchSize + len(streams) - 1) / len(streams)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		fetchErr error
+	)
+	messages := make([]models.GatewayMessage, 0, batchSize)
+	for streamName := range streams {
+		wg.Add(1)
+		go func(streamName string) {
+			defer wg.Done()
+			msgs, err := s.fetchStationMessages(account, streamName, durable, perStream, wait)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fetchErr = err
+				return
+			}
+			for _, msg := range msgs {
+				payload, err := resolveClaimCheck(tenantName, msg.Headers, msg.Data)
+				if err != nil {
+					// the reference is handed over as is so the message is not lost
+					s.Errorf("[tenant: %v]gatewayFetch at resolveClaimCheck: station %v: %v", tenantName, stationName.Ext(), err.Error())
+					payload = msg.Data
+				}
+				data, ok := applyMessageTransform(station.MessageTransform, payload)
+				if !ok {
+					s.ackStationMessage(account, msg)
+					continue
+				}
+				messages = append(messages, models.GatewayMessage{AckId: msg.ReplySubject, Data: string(data), Headers: msg.Headers})
+			}
+		}(streamName)
+	}
+	wg.Wait()
+	if fetchErr != nil && len(messages) == 0 {
+		return nil, fetchErr
+	}
+	return messages, nil
+}
+
+func (s *Server) gatewayAck(tenantName string, ackIds []string, nack bool) error {
+	if err := validateGatewayAckIds(ackIds); err != nil {
+		return err
+	}
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return err
+	}
+	for _, ackId := range ackIds {
+		msg := stationFetchedMsg{ReplySubject: ackId}
+		if nack {
+			s.nackStationMessage(account, msg)
+		} else {
+			s.ackStationMessage(account, msg)
+		}
+	}
+	return nil
+}
+
+// parseGatewayAckId returns the stream and the consumer which delivered the message of an ack id
+func parseGatewayAckId(ackId string) (string, string, error) {
+	if !strings.HasPrefix(ackId, jsAckPre) || !IsValidPublishSubject(ackId) || numTokens(ackId) != expectedNumReplyTokens {
+		return _EMPTY_, _EMPTY_, fmt.Errorf("%v is not a valid ack id", ackId)
+	}
+	// the delivery count, the stream sequence and the consumer sequence
+	for _, index := range []uint8{5, 6, 7} {
+		if parseAckReplyNum(tokenAt(ackId, index)) <= 0 {
+			return _EMPTY_, _EMPTY_, fmt.Errorf("%v is not a valid ack id", ackId)
+		}
+	}
+	return tokenAt(ackId, 3), tokenAt(ackId, 4), nil
+}
+
+func validateGatewayAckIds(ackIds []string) error {
+	for _, ackId := range ackIds {
+		if _, _, err := parseGatewayAckId(ackId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gatewayAckIdsStreams returns the distinct streams which delivered the messages of the ack ids, with the station of each
+func gatewayAckIdsStreams(ackIds []string) (map[string]StationName, error) {
+	streams := make(map[string]StationName)
+	for _, ackId := range ackIds {
+		streamName, _, err := parseGatewayAckId(ackId)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := streams[streamName]; !ok {
+			streams[streamName], _ = splitPartitionStreamName(streamName)
+		}
+	}
+	return streams, nil
+}
+
+// checkGatewayAckIdsStream makes sure a stream of ack ids is one of the streams of the station it was resolved to,
+// so internal streams can not be acked through a station of a similar name
+func checkGatewayAckIdsStream(stationName StationName, station models.Station, streamName string) error {
+	if _, ok := stationStreamsAndFilters(stationName, station)[streamName]; !ok {
+		return fmt.Errorf("ack ids of stream %v do not belong to station %v", streamName, stationName.Ext())
+	}
+	return nil
+}
+
+// resolveGatewayAckIds checks the user may consume from the stations which delivered the messages of the ack ids,
+// the same checks a fetch from those stations runs. userErr is set when an ack id is invalid or not allowed
+// and err when a lookup failed
+func resolveGatewayAckIds(user models.User, ackIds []string) (userErr error, err error) {
+	streams, userErr := gatewayAckIdsStreams(ackIds)
+	if userErr != nil {
+		return userErr, nil
+	}
+	for streamName, ackStationName := range streams {
+		stationName, station, stationUserErr, stationErr := resolveGatewayStation(user, ackStationName.Ext(), "read")
+		if stationUserErr != nil || stationErr != nil {
+			return stationUserErr, stationErr
+		}
+		userErr = checkGatewayAckIdsStream(stationName, station, streamName)
+		if userErr != nil {
+			return userErr, nil
+		}
+	}
+	return nil, nil
+}
+
+// gatewayTransactionId identifies the input messages of a transaction regardless of how many times they were delivered,
+// so a retried transaction produces its outputs with the same message ids
+func gatewayTransactionId(ackIds []string) string {
+	inputs := make([]string, 0, len(ackIds))
+	for _, ackId := range ackIds {
+		sseq, _, _ := ackReplyInfo(ackId)
+		inputs = append(inputs, fmt.Sprintf("%v.%v.%v", tokenAt(ackId, 3), tokenAt(ackId, 4), sseq))
+	}
+	sort.Strings(inputs)
+	sum := sha256.Sum256([]byte(strings.Join(inputs, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+type gatewayTransactionOutput struct {
+	stationName StationName
+	station     models.Station
+	message     string
+	headers     map[string]string
+}
+
+// gatewayTransaction produces the outputs one by one and acks the inputs only once every output was stored.
+// It is not atomic: an output is visible to consumers as soon as it is stored, and when a later output fails the stored
+// ones stay while the inputs are nacked for redelivery. Outputs carry a message id derived from the inputs, so a retry
+// of the same inputs, by the caller or by the worker they are redelivered to, does not store them again. Processing is
+// exactly once only as long as that retry happens within the idempotency window of the output stations
+func (s *Server) gatewayTransaction(tenantName, producedBy string, ackIds []string, outputs []gatewayTransactionOutput) (models.GatewayTransactionResponse, error) {
+	err := validateGatewayAckIds(ackIds)
+	if err != nil {
+		return models.GatewayTransactionResponse{}, err
+	}
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return models.GatewayTransactionResponse{}, err
+	}
+
+	resp := models.GatewayTransactionResponse{TransactionId: gatewayTransactionId(ackIds), Produced: make([]models.GatewayProduceResponse, 0, len(outputs))}
+	for i, output := range outputs {
+		hdrs := make(map[string]string, len(output.headers)+1)
+		for k, v := range output.headers {
+			hdrs[k] = v
+		}
+		if _, ok := hdrs[JSMsgId]; !ok {
+			hdrs[JSMsgId] = fmt.Sprintf("%v-%v", resp.TransactionId, i)
+		}
+		produced, err := s.gatewayProduce(tenantName, producedBy, output.stationName, output.station, output.message, hdrs)
+		if err != nil {
+			// the inputs are redelivered right away, outputs stored so far are deduplicated on the retry
+			for _, ackId := range ackIds {
+				s.nackStationMessage(account, stationFetchedMsg{ReplySubject: ackId})
+			}
+			return models.GatewayTransactionResponse{}, fmt.Errorf("transaction %v failed producing to station %v: %w. %v of %v outputs were stored and stay visible, the inputs were nacked. A retry within the idempotency window of the output stations does not store them again", resp.TransactionId, output.stationName.Ext(), err, i, len(outputs))
+		}
+		resp.Produced = append(resp.Produced, produced)
+	}
+
+	for _, ackId := range ackIds {
+		err = s.ackStationMessageSync(account, stationFetchedMsg{ReplySubject: ackId}, stationProduceAckTimeout)
+		if err != nil {
+			return models.GatewayTransactionResponse{}, fmt.Errorf("transaction %v outputs were produced but acking the inputs failed, retry the transaction: %v", resp.TransactionId, err.Error())
+		}
+	}
+	return resp, nil
+}
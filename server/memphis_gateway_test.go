@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+func TestValidateGatewayAckIds(t *testing.T) {
+	cases := []struct {
+		name    string
+		ackIds  []string
+		wantErr bool
+	}{
+		{name: "ack id", ackIds: []string{"$JS.ACK.orders.group.1.5.5.1697000000000000000.0"}},
+		{name: "ack ids of several streams", ackIds: []string{"$JS.ACK.orders$1.group.1.5.5.1697000000000000000.0", "$JS.ACK.orders$2.group.2.7.9.1697000000000000000.3"}},
+		{name: "not an ack subject", ackIds: []string{"orders.final"}, wantErr: true},
+		{name: "wildcard", ackIds: []string{"$JS.ACK.orders.group.1.5.5.*.0"}, wantErr: true},
+		{name: "missing tokens", ackIds: []string{"$JS.ACK.orders.group.1.5.5"}, wantErr: true},
+		{name: "extra tokens", ackIds: []string{"$JS.ACK.domain.hash.orders.group.1.5.5.1697000000000000000.0.token"}, wantErr: true},
+		{name: "no stream sequence", ackIds: []string{"$JS.ACK.orders.group.1.x.5.1697000000000000000.0"}, wantErr: true},
+		{name: "one invalid ack id fails all", ackIds: []string{"$JS.ACK.orders.group.1.5.5.1697000000000000000.0", "$JS.API.STREAM.DELETE.orders"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateGatewayAckIds(tc.ackIds)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validateGatewayAckIds error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGatewayAckIdsStreams(t *testing.T) {
+	streams, err := gatewayAckIdsStreams([]string{
+		"$JS.ACK.orders$1.group.1.5.5.1697000000000000000.0",
+		"$JS.ACK.orders$1.group.1.6.6.1697000000000000000.0",
+		"$JS.ACK.orders$2.group.1.2.7.1697000000000000000.0",
+		"$JS.ACK.my#events.group.1.2.2.1697000000000000000.0",
+	})
+	if err != nil {
+		t.Fatalf("gatewayAckIdsStreams: %v", err)
+	}
+	want := map[string]string{"orders$1": "orders", "orders$2": "orders", "my#events": "my.events"}
+	if len(streams) != len(want) {
+		t.Fatalf("got streams %v, want %v", streams, want)
+	}
+	for streamName, stationName := range want {
+		if streams[streamName].Ext() != stationName {
+			t.Fatalf("stream %v belongs to station %q, want %q", streamName, streams[streamName].Ext(), stationName)
+		}
+	}
+}
+
+func TestCheckGatewayAckIdsStream(t *testing.T) {
+	cases := []struct {
+		name       string
+		station    string
+		version    int
+		partitions []int
+		streamName string
+		wantErr    bool
+	}{
+		{name: "station without partitions", station: "orders", streamName: "orders"},
+		{name: "partition of the station", station: "orders", version: 1, partitions: []int{1, 2}, streamName: "orders$2"},
+		{name: "unknown partition", station: "orders", version: 1, partitions: []int{1, 2}, streamName: "orders$3", wantErr: true},
+		{name: "partition of a station without partitions", station: "orders", streamName: "orders$1", wantErr: true},
+		{name: "another station", station: "orders", streamName: "payments", wantErr: true},
+		{name: "internal stream", station: "orders", streamName: fmt.Sprintf(dlsStreamName, "orders"), wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stationName := StationNameFromStreamName(tc.station)
+			station := models.Station{Name: tc.station, Version: tc.version, PartitionsList: tc.partitions}
+			err := checkGatewayAckIdsStream(stationName, station, tc.streamName)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("checkGatewayAckIdsStream error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGatewayTransactionId(t *testing.T) {
+	first := "$JS.ACK.orders.group.1.5.5.1697000000000000000.0"
+	second := "$JS.ACK.orders$2.group.1.7.9.1697000000000000000.3"
+	id := gatewayTransactionId([]string{first, second})
+	if len(id) != 32 {
+		t.Fatalf("transaction id %q is not 16 hex encoded bytes", id)
+	}
+
+	cases := []struct {
+		name   string
+		ackIds []string
+		same   bool
+	}{
+		{name: "order of the inputs does not matter", ackIds: []string{second, first}, same: true},
+		{name: "redelivery of the same inputs", ackIds: []string{"$JS.ACK.orders.group.4.5.11.1698000000000000000.2", "$JS.ACK.orders$2.group.2.7.12.1698000000000000000.0"}, same: true},
+		{name: "another input", ackIds: []string{first, "$JS.ACK.orders$2.group.1.8.10.1697000000000000000.3"}, same: false},
+		{name: "same sequence on another consumer", ackIds: []string{first, "$JS.ACK.orders$2.other.1.7.9.1697000000000000000.3"}, same: false},
+		{name: "a subset of the inputs", ackIds: []string{first}, same: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := gatewayTransactionId(tc.ackIds); (got == id) != tc.same {
+				t.Fatalf("gatewayTransactionId = %v, the id of the original inputs is %v, want same %v", got, id, tc.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+const (
+	gatewayWSRateLimit         = 100
+	gatewayWSRateBurst         = 200
+	gatewayWSPingInterval      = 30 * time.Second
+	gatewayWSPongWait          = 60 * time.Second
+	gatewayWSWriteWait         = 10 * time.Second
+	gatewayWSFetchWait         = 5 * time.Second
+	gatewayWSStationCacheTTL   = 10 * time.Second
+	gatewayWSReadLimitOverhead = 64 * 1024
+
+	gatewayWSTypeProduce     = "produce"
+	gatewayWSTypeSubscribe   = "subscribe"
+	gatewayWSTypeUnsubscribe = "unsubscribe"
+	gatewayWSTypeAck         = "ack"
+	gatewayWSTypeNack        = "nack"
+	gatewayWSTypeMessage     = "message"
+	gatewayWSTypeError       = "error"
+)
+
+// the socket is authenticated by a bearer token and not by cookies, so any origin may open it
+var gatewayWSUpgrader = gorillaws.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+type gatewayWSStation struct {
+	stationName StationName
+	station     models.Station
+	expiresAt   time.Time
+}
+
+type gatewayWSInFlight struct {
+	subscription string
+	sentAt       time.Time
+}
+
+type gatewayWSConn struct {
+	conn    *gorillaws.Conn
+	user    models.User
+	limiter *rate.Limiter
+	writeMu sync.Mutex
+	closed  chan struct{}
+
+	mu            sync.Mutex
+	stations      map[string]gatewayWSStation
+	subscriptions map[string]chan struct{}
+	inFlight      map[string]gatewayWSInFlight
+}
+
+func (gh GatewayHandler) WebSocket(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GatewayWebSocket at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	conn, err := gatewayWSUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayWebSocket at Upgrade: %v", user.TenantName, user.Username, err.Error())
+		return
+	}
+
+	wc := &gatewayWSConn{
+		conn:          conn,
+		user:          user,
+		limiter:       rate.NewLimiter(gatewayWSRateLimit, gatewayWSRateBurst),
+		closed:        make(chan struct{}),
+		stations:      make(map[string]gatewayWSStation),
+		subscriptions: make(map[string]chan struct{}),
+		inFlight:      make(map[string]gatewayWSInFlight),
+	}
+	go wc.pingLoop()
+	wc.readLoop()
+}
+
+func (wc *gatewayWSConn) write(resp models.GatewayWSResponse) error {
+	wc.writeMu.Lock()
+	defer wc.writeMu.Unlock()
+	wc.conn.SetWriteDeadline(time.Now().Add(gatewayWSWriteWait))
+	return wc.conn.WriteJSON(resp)
+}
+
+func (wc *gatewayWSConn) writeError(id string, err error) {
+	wc.write(models.GatewayWSResponse{Type: gatewayWSTypeError, Id: id, Error: err.Error()})
+}
+
+func (wc *gatewayWSConn) pingLoop() {
+	ticker := time.NewTicker(gatewayWSPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wc.closed:
+			return
+		case <-ticker.C:
+			wc.writeMu.Lock()
+			err := wc.conn.WriteControl(gorillaws.PingMessage, nil, time.Now().Add(gatewayWSWriteWait))
+			wc.writeMu.Unlock()
+			if err != nil {
+				wc.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (wc *gatewayWSConn) readLoop() {
+	defer func() {
+		close(wc.closed)
+		wc.conn.Close()
+	}()
+	maxPayload := int64(serv.getOpts().MaxPayload)
+	wc.conn.SetReadLimit(maxPayload + gatewayWSReadLimitOverhead)
+	wc.conn.SetReadDeadline(time.Now().Add(gatewayWSPongWait))
+	wc.conn.SetPongHandler(func(string) error {
+		return wc.conn.SetReadDeadline(time.Now().Add(gatewayWSPongWait))
+	})
+
+	for {
+		var req models.GatewayWSRequest
+		err := wc.conn.ReadJSON(&req)
+		if err != nil {
+			if _, ok := err.(*gorillaws.CloseError); !ok && !errors.Is(err, gorillaws.ErrReadLimit) {
+				serv.Debugf("[tenant: %v][user: %v]GatewayWebSocket at ReadJSON: %v", wc.user.TenantName, wc.user.Username, err.Error())
+			}
+			return
+		}
+		if !wc.limiter.Allow() {
+			wc.writeError(req.Id, errors.New("rate limit exceeded"))
+			continue
+		}
+		wc.handleRequest(req)
+	}
+}
+
+func (wc *gatewayWSConn) handleRequest(req models.GatewayWSRequest) {
+	switch req.Type {
+	case gatewayWSTypeProduce:
+		wc.produce(req)
+	case gatewayWSTypeSubscribe:
+		wc.subscribe(req)
+	case gatewayWSTypeUnsubscribe:
+		wc.unsubscribe(req)
+	case gatewayWSTypeAck, gatewayWSTypeNack:
+		wc.ack(req)
+	default:
+		wc.writeError(req.Id, fmt.Errorf("unsupported request type %v", req.Type))
+	}
+}
+
+// resolveStation caches station lookups for a short while since a socket usually produces to the same stations over and over
+func (wc *gatewayWSConn) resolveStation(id, stationNameStr, operation string) (StationName, models.Station, bool) {
+	key := operation + "/" + stationNameStr
+	wc.mu.Lock()
+	cached, ok := wc.stations[key]
+	wc.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.stationName, cached.station, true
+	}
+
+	stationName, station, userErr, err := resolveGatewayStation(wc.user, stationNameStr, operation)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GatewayWebSocket at resolveGatewayStation: Station %v: %v", wc.user.TenantName, wc.user.Username, stationNameStr, err.Error())
+		wc.writeError(id, errors.New("Server error"))
+		return StationName{}, models.Station{}, false
+	}
+	if userErr != nil {
+		wc.writeError(id, userErr)
+		return StationName{}, models.Station{}, false
+	}
+
+	wc.mu.Lock()
+	wc.stations[key] = gatewayWSStation{stationName: stationName, station: station, expiresAt: time.Now().Add(gatewayWSStationCacheTTL)}
+	wc.mu.Unlock()
+	return stationName, station, true
+}
+
+func (wc *gatewayWSConn) produce(req models.GatewayWSRequest) {
+	err := validateGatewayHeaders(req.Headers)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	stationName, station, ok := wc.resolveStation(req.Id, req.StationName, "write")
+	if !ok {
+		return
+	}
+	resp, err := serv.gatewayProduce(wc.user.TenantName, wc.user.Username, stationName, station, req.Message, req.Headers)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	wc.write(models.GatewayWSResponse{Type: req.Type, Id: req.Id, Sequence: resp.Sequence})
+}
+
+func gatewayWSSubscriptionKey(stationName, consumerGroup string) string {
+	return stationName + "/" + consumerGroup
+}
+
+func (wc *gatewayWSConn) subscribe(req models.GatewayWSRequest) {
+	err := validateName(req.ConsumerGroup, "consumer group")
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	batchSize, _, err := validateGatewayFetchLimits(req.BatchSize, 0)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	stationName, station, ok := wc.resolveStation(req.Id, req.StationName, "read")
+	if !ok {
+		return
+	}
+
+	key := gatewayWSSubscriptionKey(stationName.Ext(), req.ConsumerGroup)
+	wc.mu.Lock()
+	if _, exist := wc.subscriptions[key]; exist {
+		wc.mu.Unlock()
+		wc.writeError(req.Id, fmt.Errorf("already subscribed to station %v with consumer group %v", stationName.Ext(), req.ConsumerGroup))
+		return
+	}
+	stop := make(chan struct{})
+	wc.subscriptions[key] = stop
+	wc.mu.Unlock()
+
+	wc.write(models.GatewayWSResponse{Type: req.Type, Id: req.Id, Subscription: key})
+	go wc.deliverLoop(key, stop, stationName, station, req.ConsumerGroup, batchSize)
+}
+
+func (wc *gatewayWSConn) unsubscribe(req models.GatewayWSRequest) {
+	stationName, err := StationNameFromStr(req.StationName)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	key := gatewayWSSubscriptionKey(stationName.Ext(), req.ConsumerGroup)
+	wc.mu.Lock()
+	stop, exist := wc.subscriptions[key]
+	delete(wc.subscriptions, key)
+	wc.mu.Unlock()
+	if !exist {
+		wc.writeError(req.Id, fmt.Errorf("not subscribed to station %v with consumer group %v", stationName.Ext(), req.ConsumerGroup))
+		return
+	}
+	close(stop)
+	wc.write(models.GatewayWSResponse{Type: req.Type, Id: req.Id, Subscription: key})
+}
+
+func (wc *gatewayWSConn) ack(req models.GatewayWSRequest) {
+	// the same checks a fetch runs, through the station lookups cache of the socket
+	streams, err := gatewayAckIdsStreams(req.AckIds)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	for streamName, ackStationName := range streams {
+		stationName, station, ok := wc.resolveStation(req.Id, ackStationName.Ext(), "read")
+		if !ok {
+			return
+		}
+		err = checkGatewayAckIdsStream(stationName, station, streamName)
+		if err != nil {
+			wc.writeError(req.Id, err)
+			return
+		}
+	}
+	err = serv.gatewayAck(wc.user.TenantName, req.AckIds, req.Type == gatewayWSTypeNack)
+	if err != nil {
+		wc.writeError(req.Id, err)
+		return
+	}
+	wc.mu.Lock()
+	for _, ackId := range req.AckIds {
+		delete(wc.inFlight, ackId)
+	}
+	wc.mu.Unlock()
+	wc.write(models.GatewayWSResponse{Type: req.Type, Id: req.Id})
+}
+
+// inFlightCount returns how many messages of a subscription wait for an ack,
+// messages older than the ack wait are dropped since the broker redelivers them anyway
+func (wc *gatewayWSConn) inFlightCount(subscription string) int {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	count := 0
+	for ackId, msg := range wc.inFlight {
+		if msg.subscription != subscription {
+			continue
+		}
+		if time.Since(msg.sentAt) > gatewayConsumerAckWait {
+			delete(wc.inFlight, ackId)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// deliverLoop pushes messages to the socket while keeping at most batchSize of them unacked
+func (wc *gatewayWSConn) deliverLoop(key string, stop chan struct{}, stationName StationName, station models.Station, consumerGroup string, batchSize int) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-wc.closed:
+			return
+		default:
+		}
+
+		free := batchSize - wc.inFlightCount(key)
+		if free <= 0 {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		msgs, err := serv.gatewayFetch(wc.user.TenantName, stationName, station, consumerGroup, free, gatewayWSFetchWait)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]GatewayWebSocket at gatewayFetch: Station %v: %v", wc.user.TenantName, wc.user.Username, stationName.Ext(), err.Error())
+			wc.write(models.GatewayWSResponse{Type: gatewayWSTypeError, Subscription: key, Error: err.Error()})
+			wc.mu.Lock()
+			if wc.subscriptions[key] == stop {
+				delete(wc.subscriptions, key)
+			}
+			wc.mu.Unlock()
+			return
+		}
+		for i := range msgs {
+			wc.mu.Lock()
+			wc.inFlight[msgs[i].AckId] = gatewayWSInFlight{subscription: key, sentAt: time.Now()}
+			wc.mu.Unlock()
+			err = wc.write(models.GatewayWSResponse{Type: gatewayWSTypeMessage, Subscription: key, Message: &msgs[i]})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
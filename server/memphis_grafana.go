@@ -0,0 +1,201 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	grafanaSchemaVersion = 38
+	grafanaPanelWidth    = 12
+	grafanaPanelHeight   = 8
+)
+
+type grafanaPanelQuery struct {
+	title string
+	unit  string
+	expr  string
+}
+
+func grafanaTarget(expr, legend string) map[string]interface{} {
+	return map[string]interface{}{
+		"datasource":   map[string]interface{}{"type": "prometheus", "uid": "${datasource}"},
+		"expr":         expr,
+		"legendFormat": legend,
+		"refId":        "A",
+	}
+}
+
+func grafanaTimeseriesPanel(id, x, y int, query grafanaPanelQuery, legend string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "timeseries",
+		"title":      query.title,
+		"datasource": map[string]interface{}{"type": "prometheus", "uid": "${datasource}"},
+		"gridPos":    map[string]interface{}{"x": x, "y": y, "w": grafanaPanelWidth, "h": grafanaPanelHeight},
+		"fieldConfig": map[string]interface{}{
+			"defaults":  map[string]interface{}{"unit": query.unit},
+			"overrides": []interface{}{},
+		},
+		"targets": []interface{}{grafanaTarget(query.expr, legend)},
+	}
+}
+
+// buildGrafanaDashboard generates a dashboard over the metrics exposed on /metrics,
+// the station variable is prefilled with the tenant stations and a row is repeated per selected station
+func buildGrafanaDashboard(tenantName string, stationNames []string) map[string]interface{} {
+	tenantFilter := fmt.Sprintf(`tenant="%v"`, escapeMetricLabel(tenantName))
+	stationFilter := tenantFilter + `, station=~"$station"`
+
+	brokerQueries := []grafanaPanelQuery{
+		{title: "Client connections", unit: "short", expr: fmt.Sprintf(`memphis_tenant_connections{%v}`, tenantFilter)},
+		{title: "Broker CPU", unit: "percent", expr: `memphis_process_cpu_percent`},
+		{title: "Broker memory", unit: "bytes", expr: `memphis_process_resident_memory_bytes`},
+		{title: "Dead-letter messages", unit: "short", expr: fmt.Sprintf(`sum by (station, type) (memphis_station_dls_messages{%v})`, stationFilter)},
+	}
+	stationQueries := []grafanaPanelQuery{
+		{title: "Produce rate", unit: "ops", expr: fmt.Sprintf(`sum(rate(memphis_station_messages_total{%v}[5m]))`, stationFilter)},
+		{title: "Stored messages", unit: "short", expr: fmt.Sprintf(`sum(memphis_station_messages{%v})`, stationFilter)},
+		{title: "Stored bytes", unit: "bytes", expr: fmt.Sprintf(`sum(memphis_station_bytes{%v})`, stationFilter)},
+		{title: "Consumer group lag", unit: "short", expr: fmt.Sprintf(`sum by (consumer_group) (memphis_consumer_group_lag{%v})`, stationFilter)},
+		{title: "Unacknowledged messages", unit: "short", expr: fmt.Sprintf(`sum by (consumer_group) (memphis_consumer_group_ack_pending{%v})`, stationFilter)},
+		{title: "Schema validation failures", unit: "ops", expr: fmt.Sprintf(`sum(rate(memphis_schema_validation_failures_total{%v}[5m]))`, stationFilter)},
+	}
+
+	panels := []interface{}{}
+	id := 1
+	y := 0
+	for i, query := range brokerQueries {
+		legend := "{{instance}}"
+		if query.title == "Dead-letter messages" {
+			legend = "{{station}} {{type}}"
+		}
+		panels = append(panels, grafanaTimeseriesPanel(id, (i%2)*grafanaPanelWidth, y+(i/2)*grafanaPanelHeight, query, legend))
+		id++
+	}
+	y += ((len(brokerQueries) + 1) / 2) * grafanaPanelHeight
+
+	panels = append(panels, map[string]interface{}{
+		"id":        id,
+		"type":      "row",
+		"title":     "Station $station",
+		"repeat":    "station",
+		"collapsed": false,
+		"gridPos":   map[string]interface{}{"x": 0, "y": y, "w": 2 * grafanaPanelWidth, "h": 1},
+		"panels":    []interface{}{},
+	})
+	id++
+	y++
+	for i, query := range stationQueries {
+		panels = append(panels, grafanaTimeseriesPanel(id, (i%2)*grafanaPanelWidth, y+(i/2)*grafanaPanelHeight, query, "{{consumer_group}}"))
+		id++
+	}
+
+	sort.Strings(stationNames)
+	options := []interface{}{}
+	for i, name := range stationNames {
+		options = append(options, map[string]interface{}{"text": name, "value": name, "selected": i == 0})
+	}
+	current := map[string]interface{}{}
+	if len(stationNames) > 0 {
+		current = map[string]interface{}{"text": stationNames[0], "value": stationNames[0]}
+	}
+
+	return map[string]interface{}{
+		"title":         fmt.Sprintf("Memphis - %v", tenantName),
+		"uid":           fmt.Sprintf("memphis-%v", tenantName),
+		"tags":          []string{"memphis"},
+		"schemaVersion": grafanaSchemaVersion,
+		"editable":      true,
+		"refresh":       "30s",
+		"time":          map[string]interface{}{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				map[string]interface{}{
+					"name":       "station",
+					"label":      "Station",
+					"type":       "custom",
+					"multi":      true,
+					"includeAll": true,
+					"query":      strings.Join(stationNames, ","),
+					"options":    options,
+					"current":    current,
+				},
+			},
+		},
+		"panels": panels,
+	}
+}
+
+func (mh MonitoringHandler) GetGrafanaDashboard(c *gin.Context) {
+	var body models.GetGrafanaDashboardSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetGrafanaDashboard at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationNames := []string{}
+	if body.StationName != _EMPTY_ {
+		stationName, err := StationNameFromStr(body.StationName)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]GetGrafanaDashboard at StationNameFromStr: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		exist, _, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]GetGrafanaDashboard at GetStationByName: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if !exist {
+			errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+			serv.Warnf("[tenant: %v][user: %v]GetGrafanaDashboard: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		stationNames = append(stationNames, stationName.Ext())
+	} else {
+		stations, err := db.GetActiveStationsPerTenant(user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]GetGrafanaDashboard at GetActiveStationsPerTenant: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		for _, station := range stations {
+			stationNames = append(stationNames, StationNameFromStreamName(station.Name).Ext())
+		}
+	}
+
+	c.IndentedJSON(200, gin.H{"dashboard": buildGrafanaDashboard(user.TenantName, stationNames), "overwrite": true})
+}
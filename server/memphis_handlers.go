@@ -27,21 +27,40 @@ import (
 )
 
 type Handlers struct {
-	Producers      ProducersHandler
-	Consumers      ConsumersHandler
-	AuditLogs      AuditLogsHandler
-	Stations       StationsHandler
-	Monitoring     MonitoringHandler
-	PoisonMsgs     PoisonMessagesHandler
-	Tags           TagsHandler
-	Schemas        SchemasHandler
-	Integrations   IntegrationsHandler
-	Configurations ConfigurationsHandler
-	Tenants        TenantHandler
-	Billing        BillingHandler
-	userMgmt       UserMgmtHandler
-	AsyncTasks     AsyncTasksHandler
-	Functions      FunctionsHandler
+	Producers           ProducersHandler
+	Consumers           ConsumersHandler
+	AuditLogs           AuditLogsHandler
+	Stations            StationsHandler
+	Monitoring          MonitoringHandler
+	PoisonMsgs          PoisonMessagesHandler
+	Tags                TagsHandler
+	Schemas             SchemasHandler
+	Integrations        IntegrationsHandler
+	Configurations      ConfigurationsHandler
+	Tenants             TenantHandler
+	Billing             BillingHandler
+	userMgmt            UserMgmtHandler
+	AsyncTasks          AsyncTasksHandler
+	Functions           FunctionsHandler
+	ApiKeys             ApiKeysHandler
+	Vault               VaultHandler
+	Alerts              AlertsHandler
+	Webhooks            WebhooksHandler
+	AmqpBridges         AmqpBridgesHandler
+	Gateway             GatewayHandler
+	CdcConnectors       CdcConnectorsHandler
+	ClickhouseSinks     ClickhouseSinksHandler
+	CatalogExporters    CatalogExportersHandler
+	SchemaRegistrySyncs SchemaRegistrySyncsHandler
+	Resources           ResourcesHandler
+	Backups             BackupsHandler
+	Jobs                JobsHandler
+	Teams               TeamsHandler
+	Projects            ProjectsHandler
+	Ownership           OwnershipHandler
+	Search              SearchHandler
+	Comments            CommentsHandler
+	Connections         ConnectionsHandler
 }
 
 var serv *Server
@@ -58,6 +77,7 @@ type srvMemphis struct {
 
 type memphisWS struct {
 	subscriptions *concurrentMap[memphisWSReqTenantsToFiller]
+	deltas        *concurrentMap[*memphisWSDeltaState]
 	quitCh        chan struct{}
 }
 
@@ -136,7 +156,7 @@ func CreateDefaultStation(tenantName string, s *Server, sn StationName, user mod
 
 	stationName := sn.Ext()
 	replicas := getDefaultReplicas()
-	err = s.CreateStream(tenantName, sn, "message_age_sec", 3600, "file", 120000, replicas, false, 1, true)
+	err = s.CreateStream(tenantName, sn, "message_age_sec", 3600, "file", 120000, replicas, false, 1, true, _EMPTY_)
 	if err != nil {
 		return models.Station{}, false, err
 	}
@@ -189,22 +209,10 @@ func CreateDefaultSchema(username, tenantName string, userId int) (string, error
 		},
 		"required": [ "locality" ]
 	}`
-	newSchema, rowsUpdated, err := db.InsertNewSchema(defaultSchemaName, defualtSchemaType, username, tenantName)
-	if err != nil {
-		return _EMPTY_, err
+	newSchema, err := db.InsertNewSchemaWithVersion(defaultSchemaName, defualtSchemaType, userId, username, defualtSchemaContent, _EMPTY_, _EMPTY_, schemaTagsToCreate(nil), tenantName)
+	if errors.Is(err, db.ErrSchemaExists) {
+		return _EMPTY_, fmt.Errorf("Schema with the name %v already exists ", defaultSchemaName)
 	}
-
-	if rowsUpdated == 1 {
-		_, _, err = db.InsertNewSchemaVersion(1, userId, username, defualtSchemaContent, newSchema.ID, _EMPTY_, _EMPTY_, true, tenantName)
-		if err != nil {
-			return _EMPTY_, err
-		}
-	} else {
-		errMsg := fmt.Sprintf("Schema with the name %v already exists ", newSchema.Name)
-		return _EMPTY_, fmt.Errorf(errMsg)
-	}
-
-	err = CreateDefaultTags("schema", newSchema.ID, tenantName)
 	if err != nil {
 		return _EMPTY_, err
 	}
@@ -212,9 +220,8 @@ func CreateDefaultSchema(username, tenantName string, userId int) (string, error
 }
 
 func CreateDefaultTags(tagType string, id int, tenantName string) error {
-	defaultTags := models.CreateTag{Name: "default"}
-	color := "0, 165, 255"
-	err := AddTagsToEntity([]models.CreateTag{defaultTags}, tagType, id, tenantName, color)
+	defaultTags := models.CreateTag{Name: defaultTagName}
+	err := AddTagsToEntity([]models.CreateTag{defaultTags}, tagType, id, tenantName, defaultTagColor)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,217 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertsHandler struct{}
+
+func (ah AlertsHandler) CreateAlertRule(c *gin.Context) {
+	var body models.CreateAlertRuleSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateAlertRule at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateAlertType(body.AlertType)
+	if err == nil {
+		err = validateAlertChannels(body.Channels)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateAlertRule: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	if body.StationName != _EMPTY_ {
+		if body.AlertType == models.AlertTypeComponentDown {
+			errMsg := "Component down alerts can not be scoped to a station"
+			serv.Warnf("[tenant: %v][user: %v]CreateAlertRule: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		stationName, err := StationNameFromStr(body.StationName)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]CreateAlertRule at StationNameFromStr: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		exist, _, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateAlertRule at GetStationByName: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if !exist {
+			errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+			serv.Warnf("[tenant: %v][user: %v]CreateAlertRule: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		body.StationName = stationName.Ext()
+	}
+
+	rule, err := db.InsertAlertRule(body.Name, body.AlertType, body.StationName, body.Threshold, body.Channels, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("Alert rule %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateAlertRule: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateAlertRule at InsertAlertRule: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Alert rule %v has been created", user.TenantName, user.Username, rule.Name)
+	createAuditLogFromRequest(c, user, body.StationName, fmt.Sprintf("Alert rule %v has been created by user %v", rule.Name, user.Username))
+	rule.Channels = hideAlertChannelsSecrets(rule.Channels)
+	c.IndentedJSON(200, rule)
+}
+
+func (ah AlertsHandler) GetAlertRules(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetAlertRules at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	rules, err := db.GetAlertRulesByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAlertRules at GetAlertRulesByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	for i := range rules {
+		rules[i].Channels = hideAlertChannelsSecrets(rules[i].Channels)
+	}
+
+	c.IndentedJSON(200, rules)
+}
+
+func (ah AlertsHandler) UpdateAlertRule(c *gin.Context) {
+	var body models.UpdateAlertRuleSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateAlertRule at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, rule, err := db.GetAlertRuleById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateAlertRule at GetAlertRuleById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Alert rule %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateAlertRule: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.Name != _EMPTY_ {
+		rule.Name = body.Name
+	}
+	if body.Threshold != nil {
+		if *body.Threshold < 0 {
+			errMsg := "Threshold can not be negative"
+			serv.Warnf("[tenant: %v][user: %v]UpdateAlertRule: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		rule.Threshold = *body.Threshold
+	}
+	if len(body.Channels) > 0 {
+		err = validateAlertChannels(body.Channels)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]UpdateAlertRule at validateAlertChannels: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		rule.Channels = body.Channels
+	}
+	if body.Enabled != nil {
+		rule.Enabled = *body.Enabled
+	}
+
+	rule, err = db.UpdateAlertRule(rule.ID, rule.Name, rule.Threshold, rule.Channels, rule.Enabled, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("Alert rule %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]UpdateAlertRule: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]UpdateAlertRule at UpdateAlertRule: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Alert rule %v has been updated", user.TenantName, user.Username, rule.Name)
+	createAuditLogFromRequest(c, user, rule.StationName, fmt.Sprintf("Alert rule %v has been updated by user %v", rule.Name, user.Username))
+	rule.Channels = hideAlertChannelsSecrets(rule.Channels)
+	c.IndentedJSON(200, rule)
+}
+
+func (ah AlertsHandler) RemoveAlertRule(c *gin.Context) {
+	var body models.RemoveAlertRuleSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveAlertRule at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, err := db.DeleteAlertRule(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveAlertRule at DeleteAlertRule: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Alert rule %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveAlertRule: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Alert rule %v has been removed", user.TenantName, user.Username, body.ID)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Alert rule %v has been removed by user %v", body.ID, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
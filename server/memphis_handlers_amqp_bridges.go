@@ -0,0 +1,197 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmqpBridgesHandler struct{}
+
+func (ah AmqpBridgesHandler) CreateAmqpBridge(c *gin.Context) {
+	var body models.CreateAmqpBridgeSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateAmqpBridge at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.Name, "amqp bridge")
+	if err == nil {
+		err = validateAmqpBridge(body)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateAmqpBridge: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateAmqpBridge at StationNameFromStr: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, _, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateAmqpBridge at GetStationByName: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]CreateAmqpBridge: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	encryptedUrl, err := EncryptAES([]byte(body.Url))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateAmqpBridge at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	bridge, err := db.InsertAmqpBridge(body.Name, body.Direction, encryptedUrl, body.Queue, body.Exchange, body.RoutingKey, stationName.Ext(), user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("AMQP bridge %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateAmqpBridge: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateAmqpBridge at InsertAmqpBridge: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.reconcileAmqpBridges()
+
+	serv.Noticef("[tenant: %v][user: %v]AMQP bridge %v has been created", user.TenantName, user.Username, bridge.Name)
+	createAuditLogFromRequest(c, user, stationName.Ext(), fmt.Sprintf("AMQP bridge %v has been created by user %v", bridge.Name, user.Username))
+	c.IndentedJSON(200, bridge)
+}
+
+func (ah AmqpBridgesHandler) GetAmqpBridges(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetAmqpBridges at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	bridges, err := db.GetAmqpBridgesByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAmqpBridges at GetAmqpBridgesByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	extendedBridges := make([]models.ExtendedAmqpBridge, 0, len(bridges))
+	for _, bridge := range bridges {
+		extendedBridges = append(extendedBridges, models.ExtendedAmqpBridge{AmqpBridge: bridge, Status: getAmqpBridgeStatus(bridge.ID)})
+	}
+	c.IndentedJSON(200, extendedBridges)
+}
+
+func (ah AmqpBridgesHandler) UpdateAmqpBridge(c *gin.Context) {
+	var body models.UpdateAmqpBridgeSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateAmqpBridge at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, _, err := db.GetAmqpBridgeById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateAmqpBridge at GetAmqpBridgeById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("AMQP bridge %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateAmqpBridge: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	bridge, err := db.UpdateAmqpBridgeEnabled(body.ID, *body.Enabled, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateAmqpBridge at UpdateAmqpBridgeEnabled: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.reconcileAmqpBridges()
+
+	serv.Noticef("[tenant: %v][user: %v]AMQP bridge %v has been updated", user.TenantName, user.Username, bridge.Name)
+	createAuditLogFromRequest(c, user, bridge.StationName, fmt.Sprintf("AMQP bridge %v has been updated by user %v", bridge.Name, user.Username))
+	c.IndentedJSON(200, bridge)
+}
+
+func (ah AmqpBridgesHandler) RemoveAmqpBridge(c *gin.Context) {
+	var body models.RemoveAmqpBridgeSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveAmqpBridge at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, bridge, err := db.GetAmqpBridgeById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveAmqpBridge at GetAmqpBridgeById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("AMQP bridge %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveAmqpBridge: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	_, err = db.DeleteAmqpBridge(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveAmqpBridge at DeleteAmqpBridge: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	serv.reconcileAmqpBridges()
+	err = serv.removeAmqpBridgeConsumers(bridge)
+	if err != nil {
+		// the bridge is already gone, a leftover consumer only holds messages until the station is removed
+		serv.Warnf("[tenant: %v][user: %v]RemoveAmqpBridge at removeAmqpBridgeConsumers: %v", user.TenantName, user.Username, err.Error())
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]AMQP bridge %v has been removed", user.TenantName, user.Username, bridge.Name)
+	createAuditLogFromRequest(c, user, bridge.StationName, fmt.Sprintf("AMQP bridge %v has been removed by user %v", bridge.Name, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
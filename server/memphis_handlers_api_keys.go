@@ -0,0 +1,148 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/middlewares"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiKeysHandler struct{}
+
+const (
+	apiKeyRandomBytesLen = 32
+	apiKeyVisiblePrefix  = 6
+)
+
+func validateApiKeyScopes(scopes []string) error {
+	for _, scope := range scopes {
+		switch scope {
+		case models.ApiKeyScopeReadOnly, models.ApiKeyScopeSchemaAdmin, models.ApiKeyScopeStationAdmin, models.ApiKeyScopeCredentialsAdmin, models.ApiKeyScopeDataPlane:
+		default:
+			return fmt.Errorf("scope %v is not supported, supported scopes are %v, %v, %v, %v and %v", scope, models.ApiKeyScopeReadOnly, models.ApiKeyScopeSchemaAdmin, models.ApiKeyScopeStationAdmin, models.ApiKeyScopeCredentialsAdmin, models.ApiKeyScopeDataPlane)
+		}
+	}
+	return nil
+}
+
+func generateApiKey() (string, error) {
+	raw := make([]byte, apiKeyRandomBytesLen)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return models.ApiKeyPrefix + hex.EncodeToString(raw), nil
+}
+
+func (akh ApiKeysHandler) CreateApiKey(c *gin.Context) {
+	var body models.CreateApiKeySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateApiKey at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateApiKeyScopes(body.Scopes)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateApiKey at validateApiKeyScopes: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if body.ExpiresInDays > 0 {
+		expiration := time.Now().AddDate(0, 0, body.ExpiresInDays)
+		expiresAt = &expiration
+	}
+
+	key, err := generateApiKey()
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateApiKey at generateApiKey: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	keyPrefix := key[:len(models.ApiKeyPrefix)+apiKeyVisiblePrefix]
+	apiKey, err := db.InsertNewApiKey(body.Name, user.Username, keyPrefix, middlewares.HashApiKey(key), body.Scopes, expiresAt, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateApiKey at InsertNewApiKey: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]API key %v has been created", user.TenantName, user.Username, apiKey.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("API key %v has been created by user %v", apiKey.Name, user.Username))
+	// the key itself is returned only once, only its hash is kept
+	c.IndentedJSON(200, models.CreateApiKeyResponse{ApiKey: apiKey, Key: key})
+}
+
+func (akh ApiKeysHandler) GetApiKeys(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetApiKeys at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	apiKeys, err := db.GetApiKeysByUsername(user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetApiKeys at GetApiKeysByUsername: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, apiKeys)
+}
+
+func (akh ApiKeysHandler) RevokeApiKey(c *gin.Context) {
+	var body models.RevokeApiKeySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RevokeApiKey at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, err := db.RevokeApiKey(body.ID, user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeApiKey at RevokeApiKey: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("API key %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RevokeApiKey: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]API key %v has been revoked", user.TenantName, user.Username, body.ID)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("API key %v has been revoked by user %v", body.ID, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
@@ -12,14 +12,66 @@
 package server
 
 import (
+	"time"
+
 	"github.com/memphisdev/memphis/db"
 	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
 )
 
 type AuditLogsHandler struct{}
 
+const (
+	requestIdHeader              = "X-Request-Id"
+	auditLogsDefaultPageSize     = 50
+	auditLogsSearchDefaultWindow = 30 * 24 * time.Hour
+)
+
 func CreateAuditLogs(auditLogs []interface{}) error {
-	return db.InsertAuditLogs(auditLogs)
+	err := db.InsertAuditLogs(auditLogs)
+	if err != nil {
+		return err
+	}
+	exportAuditLogs(auditLogs)
+	return nil
+}
+
+func getRequestId(c *gin.Context) string {
+	if requestId := c.GetString("request_id"); requestId != _EMPTY_ {
+		return requestId
+	}
+	if requestId := c.GetHeader(requestIdHeader); requestId != _EMPTY_ {
+		return requestId
+	}
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return _EMPTY_
+	}
+	c.Set("request_id", uid.String())
+	return uid.String()
+}
+
+// createAuditLogFromRequest writes an audit log for a mutating API call, the actor IP and request ID are taken from the request
+func createAuditLogFromRequest(c *gin.Context, user models.User, stationName, message string) {
+	var auditLogs []interface{}
+	newAuditLog := models.AuditLog{
+		StationName:       stationName,
+		Message:           message,
+		CreatedBy:         user.ID,
+		CreatedByUsername: user.Username,
+		CreatedAt:         time.Now(),
+		TenantName:        user.TenantName,
+		ActorIp:           c.ClientIP(),
+		RequestId:         getRequestId(c),
+	}
+	auditLogs = append(auditLogs, newAuditLog)
+	err := CreateAuditLogs(auditLogs)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]createAuditLogFromRequest at CreateAuditLogs: %v", user.TenantName, user.Username, err.Error())
+	}
 }
 
 func (ah AuditLogsHandler) GetAuditLogsByStation(stationName string, tenantName string) ([]models.AuditLog, error) {
@@ -29,3 +81,44 @@ func (ah AuditLogsHandler) GetAuditLogsByStation(stationName string, tenantName
 func RemoveAllAuditLogsByStation(stationName string, tenantName string) error {
 	return db.RemoveAllAuditLogsByStation(stationName, tenantName)
 }
+
+func (ah AuditLogsHandler) SearchAuditLogs(c *gin.Context) {
+	var body models.SearchAuditLogsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("SearchAuditLogs at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	if body.PageSize == 0 {
+		body.PageSize = auditLogsDefaultPageSize
+	}
+	if body.Page == 0 {
+		body.Page = 1
+	}
+	if body.To.IsZero() {
+		body.To = time.Now()
+	}
+	if body.From.IsZero() {
+		body.From = body.To.Add(-auditLogsSearchDefaultWindow)
+	}
+	if body.From.After(body.To) {
+		serv.Warnf("[tenant: %v][user: %v]SearchAuditLogs: from has to be before to", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "from has to be before to"})
+		return
+	}
+
+	auditLogs, total, err := db.SearchAuditLogs(user.TenantName, body.StationName, body.Actor, body.Search, body.From, body.To, body.PageSize, (body.Page-1)*body.PageSize)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]SearchAuditLogs at SearchAuditLogs: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, models.SearchAuditLogsResponse{AuditLogs: auditLogs, Total: total, Page: body.Page, PageSize: body.PageSize})
+}
@@ -0,0 +1,219 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogExportersHandler struct{}
+
+func (ch CatalogExportersHandler) CreateCatalogExporter(c *gin.Context) {
+	var body models.CreateCatalogExporterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateCatalogExporter at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.Name, "catalog exporter")
+	if err == nil {
+		err = validateCatalogExporter(&body)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateCatalogExporter: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	encryptedToken := _EMPTY_
+	if body.Token != _EMPTY_ {
+		encryptedToken, err = EncryptAES([]byte(body.Token))
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCatalogExporter at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+	exporter, err := db.InsertCatalogExporter(body.Name, body.Type, body.Url, encryptedToken, body.ServiceName, body.IntervalSec, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("Catalog exporter %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateCatalogExporter: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateCatalogExporter at InsertCatalogExporter: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleCatalogExporters(exporter.ID)
+
+	serv.Noticef("[tenant: %v][user: %v]Catalog exporter %v has been created", user.TenantName, user.Username, exporter.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Catalog exporter %v has been created by user %v", exporter.Name, user.Username))
+	c.IndentedJSON(200, exporter)
+}
+
+func (ch CatalogExportersHandler) GetCatalogExporters(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetCatalogExporters at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exporters, err := db.GetCatalogExportersByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetCatalogExporters at GetCatalogExportersByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	extendedExporters := make([]models.ExtendedCatalogExporter, 0, len(exporters))
+	for _, exporter := range exporters {
+		extendedExporters = append(extendedExporters, models.ExtendedCatalogExporter{CatalogExporter: exporter, Status: getCatalogExporterStatus(exporter.ID)})
+	}
+	c.IndentedJSON(200, extendedExporters)
+}
+
+func (ch CatalogExportersHandler) UpdateCatalogExporter(c *gin.Context) {
+	var body models.UpdateCatalogExporterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateCatalogExporter at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, exporter, err := db.GetCatalogExporterById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateCatalogExporter at GetCatalogExporterById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Catalog exporter %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateCatalogExporter: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.Enabled != nil {
+		exporter.Enabled = *body.Enabled
+	}
+	if body.IntervalSec != nil {
+		exporter.IntervalSec = *body.IntervalSec
+	}
+	err = validateCatalogExporterInterval(exporter.IntervalSec)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateCatalogExporter: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	exporter, err = db.UpdateCatalogExporter(body.ID, exporter.Enabled, exporter.IntervalSec, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateCatalogExporter at UpdateCatalogExporter: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleCatalogExporters(0)
+
+	serv.Noticef("[tenant: %v][user: %v]Catalog exporter %v has been updated", user.TenantName, user.Username, exporter.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Catalog exporter %v has been updated by user %v", exporter.Name, user.Username))
+	c.IndentedJSON(200, exporter)
+}
+
+func (ch CatalogExportersHandler) RemoveCatalogExporter(c *gin.Context) {
+	var body models.RemoveCatalogExporterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveCatalogExporter at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, exporter, err := db.GetCatalogExporterById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveCatalogExporter at GetCatalogExporterById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Catalog exporter %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveCatalogExporter: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	_, err = db.DeleteCatalogExporter(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveCatalogExporter at DeleteCatalogExporter: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleCatalogExporters(0)
+
+	serv.Noticef("[tenant: %v][user: %v]Catalog exporter %v has been removed", user.TenantName, user.Username, exporter.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Catalog exporter %v has been removed by user %v", exporter.Name, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (ch CatalogExportersHandler) SyncCatalogExporter(c *gin.Context) {
+	var body models.SyncCatalogExporterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("SyncCatalogExporter at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, exporter, err := db.GetCatalogExporterById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]SyncCatalogExporter at GetCatalogExporterById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist || !exporter.Enabled {
+		errMsg := fmt.Sprintf("Catalog exporter %v does not exist or is disabled", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]SyncCatalogExporter: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	// the sync runs on the leader, other nodes only refresh their schedule
+	go serv.scheduleCatalogExporters(exporter.ID)
+	c.IndentedJSON(200, gin.H{})
+}
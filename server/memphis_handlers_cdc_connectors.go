@@ -0,0 +1,220 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CdcConnectorsHandler struct{}
+
+func (ch CdcConnectorsHandler) CreateCdcConnector(c *gin.Context) {
+	var body models.CreateCdcConnectorSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateCdcConnector at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.Name, "cdc connector")
+	if err == nil {
+		err = validateCdcConnector(body)
+	}
+	if err == nil {
+		err = validateMessageTransform(body.Transform, models.MessageTransformDebeziumWrap, models.MessageTransformDebeziumUnwrap)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateCdcConnector: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "CreateCdcConnector", body.StationName, "write")
+	if !ok {
+		return
+	}
+
+	connector := models.CdcConnector{
+		Name:        body.Name,
+		SourceType:  body.SourceType,
+		Database:    body.Database,
+		Tables:      body.Tables,
+		StationName: stationName.Ext(),
+		TenantName:  user.TenantName,
+		Transform:   body.Transform,
+	}
+	if connector.Tables == nil {
+		connector.Tables = []string{}
+	}
+	if connector.SourceType == models.CdcSourcePostgres {
+		connector.SlotName = cdcSlotName(user.TenantName, body.Name)
+		if u, err := url.Parse(body.ConnectionUrl); err == nil {
+			connector.Database = strings.TrimPrefix(u.Path, "/")
+		}
+	}
+	columns, err := prepareCdcSource(connector, body.ConnectionUrl)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not prepare the %v source: %v", body.SourceType, err.Error())
+		serv.Warnf("[tenant: %v][user: %v]CreateCdcConnector at prepareCdcSource: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	encryptedUrl, err := EncryptAES([]byte(body.ConnectionUrl))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateCdcConnector at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	connector, err = db.InsertCdcConnector(connector.Name, connector.SourceType, encryptedUrl, connector.Database, connector.Tables, connector.SlotName, connector.StationName, user.Username, user.TenantName, connector.Transform)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("CDC connector %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateCdcConnector: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateCdcConnector at InsertCdcConnector: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = serv.attachCdcGeneratedSchema(connector, user, stationName, station, columns)
+	if err != nil {
+		// the connector works without the schema, it can be attached by hand later
+		serv.Warnf("[tenant: %v][user: %v]CreateCdcConnector at attachCdcGeneratedSchema: %v", user.TenantName, user.Username, err.Error())
+	}
+	go serv.reconcileCdcConnectors()
+
+	serv.Noticef("[tenant: %v][user: %v]CDC connector %v has been created", user.TenantName, user.Username, connector.Name)
+	createAuditLogFromRequest(c, user, stationName.Ext(), fmt.Sprintf("CDC connector %v has been created by user %v", connector.Name, user.Username))
+	c.IndentedJSON(200, connector)
+}
+
+func (ch CdcConnectorsHandler) GetCdcConnectors(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetCdcConnectors at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	connectors, err := db.GetCdcConnectorsByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetCdcConnectors at GetCdcConnectorsByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	extendedConnectors := make([]models.ExtendedCdcConnector, 0, len(connectors))
+	for _, connector := range connectors {
+		extendedConnectors = append(extendedConnectors, models.ExtendedCdcConnector{CdcConnector: connector, Status: getCdcConnectorStatus(connector)})
+	}
+	c.IndentedJSON(200, extendedConnectors)
+}
+
+func (ch CdcConnectorsHandler) UpdateCdcConnector(c *gin.Context) {
+	var body models.UpdateCdcConnectorSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateCdcConnector at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, _, err := db.GetCdcConnectorById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateCdcConnector at GetCdcConnectorById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("CDC connector %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateCdcConnector: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	connector, err := db.UpdateCdcConnectorEnabled(body.ID, *body.Enabled, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateCdcConnector at UpdateCdcConnectorEnabled: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.reconcileCdcConnectors()
+
+	serv.Noticef("[tenant: %v][user: %v]CDC connector %v has been updated", user.TenantName, user.Username, connector.Name)
+	createAuditLogFromRequest(c, user, connector.StationName, fmt.Sprintf("CDC connector %v has been updated by user %v", connector.Name, user.Username))
+	c.IndentedJSON(200, connector)
+}
+
+func (ch CdcConnectorsHandler) RemoveCdcConnector(c *gin.Context) {
+	var body models.RemoveCdcConnectorSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveCdcConnector at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, connector, err := db.GetCdcConnectorById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveCdcConnector at GetCdcConnectorById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("CDC connector %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveCdcConnector: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	_, err = db.DeleteCdcConnector(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveCdcConnector at DeleteCdcConnector: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	serv.reconcileCdcConnectors()
+	if connector.SourceType == models.CdcSourcePostgres {
+		// an abandoned slot makes postgres retain wal forever
+		err = dropPostgresCdcSlot(connector)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]RemoveCdcConnector at dropPostgresCdcSlot: replication slot %v has to be dropped by hand: %v", user.TenantName, user.Username, connector.SlotName, err.Error())
+		}
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]CDC connector %v has been removed", user.TenantName, user.Username, connector.Name)
+	createAuditLogFromRequest(c, user, connector.StationName, fmt.Sprintf("CDC connector %v has been removed by user %v", connector.Name, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
@@ -0,0 +1,215 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClickhouseSinksHandler struct{}
+
+func (ch ClickhouseSinksHandler) CreateClickhouseSink(c *gin.Context) {
+	var body models.CreateClickhouseSinkSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateClickhouseSink at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.Name, "clickhouse sink")
+	if err == nil {
+		err = validateClickhouseSink(&body)
+	}
+	if err == nil {
+		err = validateMessageTransform(body.Transform, models.MessageTransformDebeziumUnwrap)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateClickhouseSink: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "CreateClickhouseSink", body.StationName, "read")
+	if !ok {
+		return
+	}
+
+	columns, err := clickhouseColumnsForStation(user.TenantName, station)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateClickhouseSink at clickhouseColumnsForStation: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	sink := models.ClickhouseSink{Name: body.Name, Database: body.Database, TableName: body.TableName}
+	err = prepareClickhouseSinkTable(sink, body.Url, columns)
+	if err != nil {
+		errMsg := fmt.Sprintf("could not create table %v.%v: %v", body.Database, body.TableName, err.Error())
+		serv.Warnf("[tenant: %v][user: %v]CreateClickhouseSink at prepareClickhouseSinkTable: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	encryptedUrl, err := EncryptAES([]byte(body.Url))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateClickhouseSink at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	sink, err = db.InsertClickhouseSink(body.Name, encryptedUrl, body.Database, body.TableName, stationName.Ext(), body.BatchSize, body.FlushIntervalMs, user.Username, user.TenantName, body.Transform)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("ClickHouse sink %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateClickhouseSink: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateClickhouseSink at InsertClickhouseSink: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.reconcileClickhouseSinks()
+
+	serv.Noticef("[tenant: %v][user: %v]ClickHouse sink %v has been created", user.TenantName, user.Username, sink.Name)
+	createAuditLogFromRequest(c, user, stationName.Ext(), fmt.Sprintf("ClickHouse sink %v has been created by user %v", sink.Name, user.Username))
+	c.IndentedJSON(200, sink)
+}
+
+func (ch ClickhouseSinksHandler) GetClickhouseSinks(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetClickhouseSinks at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	sinks, err := db.GetClickhouseSinksByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetClickhouseSinks at GetClickhouseSinksByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	extendedSinks := make([]models.ExtendedClickhouseSink, 0, len(sinks))
+	for _, sink := range sinks {
+		extendedSinks = append(extendedSinks, models.ExtendedClickhouseSink{ClickhouseSink: sink, Status: getClickhouseSinkStatus(sink.ID)})
+	}
+	c.IndentedJSON(200, extendedSinks)
+}
+
+func (ch ClickhouseSinksHandler) UpdateClickhouseSink(c *gin.Context) {
+	var body models.UpdateClickhouseSinkSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateClickhouseSink at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, sink, err := db.GetClickhouseSinkById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateClickhouseSink at GetClickhouseSinkById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("ClickHouse sink %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateClickhouseSink: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.Enabled != nil {
+		sink.Enabled = *body.Enabled
+	}
+	if body.BatchSize != nil {
+		sink.BatchSize = *body.BatchSize
+	}
+	if body.FlushIntervalMs != nil {
+		sink.FlushIntervalMs = *body.FlushIntervalMs
+	}
+	err = validateClickhouseSinkLimits(sink.BatchSize, sink.FlushIntervalMs)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateClickhouseSink: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	sink, err = db.UpdateClickhouseSink(body.ID, sink.Enabled, sink.BatchSize, sink.FlushIntervalMs, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateClickhouseSink at UpdateClickhouseSink: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.reconcileClickhouseSinks()
+
+	serv.Noticef("[tenant: %v][user: %v]ClickHouse sink %v has been updated", user.TenantName, user.Username, sink.Name)
+	createAuditLogFromRequest(c, user, sink.StationName, fmt.Sprintf("ClickHouse sink %v has been updated by user %v", sink.Name, user.Username))
+	c.IndentedJSON(200, sink)
+}
+
+func (ch ClickhouseSinksHandler) RemoveClickhouseSink(c *gin.Context) {
+	var body models.RemoveClickhouseSinkSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveClickhouseSink at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, sink, err := db.GetClickhouseSinkById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveClickhouseSink at GetClickhouseSinkById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("ClickHouse sink %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveClickhouseSink: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	_, err = db.DeleteClickhouseSink(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveClickhouseSink at DeleteClickhouseSink: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	serv.reconcileClickhouseSinks()
+	err = serv.removeClickhouseSinkConsumers(sink)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RemoveClickhouseSink at removeClickhouseSinkConsumers: %v", user.TenantName, user.Username, err.Error())
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]ClickHouse sink %v has been removed", user.TenantName, user.Username, sink.Name)
+	createAuditLogFromRequest(c, user, sink.StationName, fmt.Sprintf("ClickHouse sink %v has been removed by user %v", sink.Name, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
@@ -0,0 +1,459 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const commentBodyMaxLength = 4000
+
+type CommentsHandler struct{}
+
+var commentMentionRegex = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._+\-@]+)`)
+
+// commentEntity is the station or schema a comment is attached to
+type commentEntity struct {
+	entityType string
+	id         int
+	name       string
+	// stationName is used for the audit log of station comments
+	stationName string
+}
+
+func (e commentEntity) describe(versionNumber int) string {
+	if versionNumber > 0 {
+		return fmt.Sprintf("%v %v version %v", e.entityType, e.name, versionNumber)
+	}
+	return fmt.Sprintf("%v %v", e.entityType, e.name)
+}
+
+// resolveCommentEntity returns a non empty message when the requested entity or schema version does not exist
+func resolveCommentEntity(entityType, entityName string, versionNumber int, tenantName string) (commentEntity, string, error) {
+	entityType = strings.ToLower(entityType)
+	switch entityType {
+	case models.CommentEntityStation:
+		if versionNumber > 0 {
+			return commentEntity{}, "version_number can only be set on schema comments", nil
+		}
+		stationName, err := StationNameFromStr(entityName)
+		if err != nil {
+			return commentEntity{}, err.Error(), nil
+		}
+		exist, station, err := db.GetStationByName(stationName.Ext(), tenantName)
+		if err != nil {
+			return commentEntity{}, _EMPTY_, err
+		}
+		if !exist {
+			return commentEntity{}, fmt.Sprintf("Station %v does not exist", entityName), nil
+		}
+		return commentEntity{entityType: entityType, id: station.ID, name: station.Name, stationName: station.Name}, _EMPTY_, nil
+	case models.CommentEntitySchema:
+		schemaName := strings.ToLower(entityName)
+		exist, schema, err := db.GetSchemaByName(schemaName, tenantName)
+		if err != nil {
+			return commentEntity{}, _EMPTY_, err
+		}
+		if !exist {
+			return commentEntity{}, fmt.Sprintf("Schema %v does not exist", schemaName), nil
+		}
+		if versionNumber > 0 {
+			exist, _, err = db.GetSchemaVersionByNumberAndID(versionNumber, schema.ID)
+			if err != nil {
+				return commentEntity{}, _EMPTY_, err
+			}
+			if !exist {
+				return commentEntity{}, fmt.Sprintf("Version %v of schema %v does not exist", versionNumber, schemaName), nil
+			}
+		}
+		return commentEntity{entityType: entityType, id: schema.ID, name: schema.Name}, _EMPTY_, nil
+	default:
+		return commentEntity{}, "entity type can be one of the following station/schema", nil
+	}
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == _EMPTY_ {
+		return _EMPTY_, fmt.Errorf("comment can not be empty")
+	}
+	if len(body) > commentBodyMaxLength {
+		return _EMPTY_, fmt.Errorf("comment can not be longer than %v characters", commentBodyMaxLength)
+	}
+	return body, nil
+}
+
+// commentMentions returns the existing users mentioned in a comment with @username
+func commentMentions(body, tenantName string) ([]string, error) {
+	mentions := []string{}
+	seen := make(map[string]bool)
+	for _, match := range commentMentionRegex.FindAllStringSubmatch(body, -1) {
+		username := strings.ToLower(strings.TrimRight(match[1], ".,-"))
+		if username == _EMPTY_ || seen[username] {
+			continue
+		}
+		seen[username] = true
+		exist, _, err := db.GetUserByUsername(username, tenantName)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			mentions = append(mentions, username)
+		}
+	}
+	return mentions, nil
+}
+
+// mentionEmail returns the address a mentioned user is notified at, usernames of management users are
+// usually their email, otherwise the address the user was invited with is used
+func mentionEmail(username, tenantName string) (string, error) {
+	if address, err := mail.ParseAddress(username); err == nil {
+		return address.Address, nil
+	}
+	exist, invitation, err := db.GetUserInvitationByUsername(username, tenantName)
+	if err != nil || !exist {
+		return _EMPTY_, err
+	}
+	return invitation.Email, nil
+}
+
+// notifyCommentMentions emails the users mentioned in a comment, it runs in the background and only
+// logs failures since notifications are best effort
+func notifyCommentMentions(tenantName, author string, mentions []string, entity commentEntity, comment models.Comment) {
+	if len(mentions) == 0 {
+		return
+	}
+	go func() {
+		smtpEnabled, err := IsSmtpEnabled(tenantName)
+		if err != nil {
+			serv.Warnf("[tenant: %v]notifyCommentMentions at IsSmtpEnabled: %v", tenantName, err.Error())
+			return
+		}
+		if !smtpEnabled {
+			return
+		}
+		subject := fmt.Sprintf("%v mentioned you on %v", author, entity.describe(comment.VersionNumber))
+		body := fmt.Sprintf("Hi,\n\n%v mentioned you in a comment on %v:\n\n%v\n", author, entity.describe(comment.VersionNumber), comment.Body)
+		for _, username := range mentions {
+			if username == author {
+				continue
+			}
+			to, err := mentionEmail(username, tenantName)
+			if err != nil {
+				serv.Warnf("[tenant: %v]notifyCommentMentions at mentionEmail: User %v: %v", tenantName, username, err.Error())
+				continue
+			}
+			if to == _EMPTY_ {
+				continue
+			}
+			err = sendEmail(tenantName, to, subject, body)
+			if err != nil {
+				serv.Warnf("[tenant: %v]notifyCommentMentions at sendEmail: User %v: %v", tenantName, username, err.Error())
+			}
+		}
+	}()
+}
+
+// commentThreads groups the comments, ordered by creation, under their top level comment
+func commentThreads(comments []models.Comment) []models.CommentThread {
+	threads := []models.CommentThread{}
+	threadIndex := make(map[int]int)
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			threadIndex[comment.ID] = len(threads)
+			threads = append(threads, models.CommentThread{Comment: comment, Replies: []models.Comment{}})
+			continue
+		}
+		if i, ok := threadIndex[*comment.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, comment)
+		}
+	}
+	return threads
+}
+
+func getCommentThreads(entityType string, entityID, versionNumber int, tenantName string) ([]models.CommentThread, error) {
+	comments, err := db.GetCommentsByEntity(entityType, entityID, versionNumber, tenantName)
+	if err != nil {
+		return []models.CommentThread{}, err
+	}
+	return commentThreads(comments), nil
+}
+
+func (ch CommentsHandler) CreateComment(c *gin.Context) {
+	var body models.CreateCommentSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateComment at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	commentBody, err := validateCommentBody(body.Body)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateComment at validateCommentBody: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	entity, errMsg, err := resolveCommentEntity(body.EntityType, body.EntityName, body.VersionNumber, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateComment at resolveCommentEntity: %v %v: %v", user.TenantName, user.Username, body.EntityType, body.EntityName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]CreateComment: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	versionNumber := body.VersionNumber
+	if body.ParentID > 0 {
+		exist, parent, err := db.GetCommentByID(body.ParentID, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateComment at GetCommentByID: Comment %v: %v", user.TenantName, user.Username, body.ParentID, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if !exist || parent.EntityType != entity.entityType || parent.EntityID != entity.id {
+			errMsg := fmt.Sprintf("Comment %v does not exist on %v", body.ParentID, entity.describe(0))
+			serv.Warnf("[tenant: %v][user: %v]CreateComment: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		if parent.ParentID != nil {
+			errMsg := "Replies can only be added to top level comments"
+			serv.Warnf("[tenant: %v][user: %v]CreateComment: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		// replies belong to the schema version of the thread
+		versionNumber = parent.VersionNumber
+	}
+
+	mentions, err := commentMentions(commentBody, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateComment at commentMentions: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	comment, err := db.InsertComment(entity.entityType, entity.id, versionNumber, body.ParentID, commentBody, mentions, user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateComment at InsertComment: %v %v: %v", user.TenantName, user.Username, entity.entityType, entity.name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Comment %v has been added to %v by user %v", comment.ID, entity.describe(versionNumber), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, entity.stationName, message)
+	notifyCommentMentions(user.TenantName, user.Username, mentions, entity, comment)
+
+	c.IndentedJSON(200, comment)
+}
+
+func (ch CommentsHandler) GetComments(c *gin.Context) {
+	var body models.GetCommentsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetComments at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	entity, errMsg, err := resolveCommentEntity(body.EntityType, body.EntityName, body.VersionNumber, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetComments at resolveCommentEntity: %v %v: %v", user.TenantName, user.Username, body.EntityType, body.EntityName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]GetComments: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	threads, err := getCommentThreads(entity.entityType, entity.id, body.VersionNumber, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetComments at getCommentThreads: %v %v: %v", user.TenantName, user.Username, entity.entityType, entity.name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, threads)
+}
+
+func (ch CommentsHandler) EditComment(c *gin.Context) {
+	var body models.EditCommentSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("EditComment at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	commentBody, err := validateCommentBody(body.Body)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]EditComment at validateCommentBody: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, comment, err := db.GetCommentByID(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditComment at GetCommentByID: Comment %v: %v", user.TenantName, user.Username, body.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Comment %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]EditComment: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if comment.CreatedByUsername != user.Username {
+		errMsg := "Only the author of a comment can edit it"
+		serv.Warnf("[tenant: %v][user: %v]EditComment: Comment %v: %v", user.TenantName, user.Username, body.ID, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	mentions, err := commentMentions(commentBody, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditComment at commentMentions: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	updated, err := db.UpdateCommentBody(comment.ID, commentBody, mentions, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditComment at UpdateCommentBody: Comment %v: %v", user.TenantName, user.Username, body.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	// only users mentioned for the first time are notified
+	previous := make(map[string]bool, len(comment.Mentions))
+	for _, username := range comment.Mentions {
+		previous[username] = true
+	}
+	newMentions := []string{}
+	for _, username := range mentions {
+		if !previous[username] {
+			newMentions = append(newMentions, username)
+		}
+	}
+	if len(newMentions) > 0 {
+		entity, errMsg, err := commentEntityByID(comment, user.TenantName)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]EditComment at commentEntityByID: Comment %v: %v", user.TenantName, user.Username, body.ID, err.Error())
+		} else if errMsg != _EMPTY_ {
+			serv.Warnf("[tenant: %v][user: %v]EditComment: %v", user.TenantName, user.Username, errMsg)
+		} else {
+			notifyCommentMentions(user.TenantName, user.Username, newMentions, entity, updated)
+		}
+	}
+
+	c.IndentedJSON(200, updated)
+}
+
+// commentEntityByID resolves the station or schema of an existing comment
+func commentEntityByID(comment models.Comment, tenantName string) (commentEntity, string, error) {
+	switch comment.EntityType {
+	case models.CommentEntityStation:
+		exist, station, err := db.GetStationById(comment.EntityID, tenantName)
+		if err != nil {
+			return commentEntity{}, _EMPTY_, err
+		}
+		if !exist {
+			return commentEntity{}, fmt.Sprintf("Station of comment %v does not exist", comment.ID), nil
+		}
+		return commentEntity{entityType: comment.EntityType, id: station.ID, name: station.Name, stationName: station.Name}, _EMPTY_, nil
+	default:
+		exist, schema, err := db.GetSchemaByID(comment.EntityID, tenantName)
+		if err != nil {
+			return commentEntity{}, _EMPTY_, err
+		}
+		if !exist {
+			return commentEntity{}, fmt.Sprintf("Schema of comment %v does not exist", comment.ID), nil
+		}
+		return commentEntity{entityType: comment.EntityType, id: schema.ID, name: schema.Name}, _EMPTY_, nil
+	}
+}
+
+func (ch CommentsHandler) RemoveComment(c *gin.Context) {
+	var body models.RemoveCommentSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveComment at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, comment, err := db.GetCommentByID(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveComment at GetCommentByID: Comment %v: %v", user.TenantName, user.Username, body.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Comment %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveComment: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if comment.CreatedByUsername != user.Username && user.UserType != "root" && user.UserType != "management" {
+		errMsg := "Only the author of a comment or a management user can remove it"
+		serv.Warnf("[tenant: %v][user: %v]RemoveComment: Comment %v: %v", user.TenantName, user.Username, body.ID, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	err = db.DeleteComment(comment.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveComment at DeleteComment: Comment %v: %v", user.TenantName, user.Username, body.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationName := _EMPTY_
+	entity, errMsg, err := commentEntityByID(comment, user.TenantName)
+	if err == nil && errMsg == _EMPTY_ {
+		stationName = entity.stationName
+	}
+	message := fmt.Sprintf("Comment %v has been removed by user %v", comment.ID, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
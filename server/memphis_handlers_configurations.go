@@ -16,7 +16,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/memphisdev/memphis/conf"
 	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
 )
 
 type ConfigurationsHandler struct{ S *Server }
@@ -43,12 +48,18 @@ func changeLogsRetention(logsRetention int) error {
 		return err
 	}
 
+	v, err := serv.Varz(nil)
+	if err != nil {
+		return err
+	}
+
 	retentionDur := time.Duration(logsRetention) * time.Hour * 24
 	err = serv.memphisUpdateStream(serv.MemphisGlobalAccountString(), &StreamConfig{
 		Name:         syslogsStreamName,
 		Subjects:     []string{syslogsStreamName + ".>"},
 		Retention:    LimitsPolicy,
 		MaxAge:       retentionDur,
+		MaxBytes:     serv.syslogsMaxBytes(v.JetStream.Config.MaxStore),
 		MaxConsumers: -1,
 		Discard:      DiscardOld,
 		Storage:      FileStorage,
@@ -87,3 +98,59 @@ func changeMaxMsgSize(newSize int) error {
 
 	return nil
 }
+
+// PurgeStaleClients runs the producers and consumers cleanup on demand, by default with the tenant's configured retention
+func (ch ConfigurationsHandler) PurgeStaleClients(c *gin.Context) {
+	var body models.PurgeStaleClientsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("PurgeStaleClients at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if body.OlderThanHours < 0 {
+		serv.Warnf("[tenant: %v][user: %v]PurgeStaleClients: older_than_hours can not be negative", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "older_than_hours can not be negative"})
+		return
+	}
+
+	retentionHours := body.OlderThanHours
+	if retentionHours == 0 {
+		retentionHours = ch.S.opts.GCProducersConsumersRetentionHours[user.TenantName]
+		if retentionHours == 0 {
+			retentionHours = DEFAULT_GC_PRODUCER_CONSUMER_RETENTION_HOURS
+		}
+	}
+	purged, err := purgeStaleProducersAndConsumers(user.TenantName, time.Now().Add(-time.Duration(retentionHours)*time.Hour))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]PurgeStaleClients at purgeStaleProducersAndConsumers: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, purged)
+}
+
+// GetEffectiveConfig shows the settings this broker runs with and where each was taken from, to help debugging deployments
+func (ch ConfigurationsHandler) GetEffectiveConfig(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetEffectiveConfig at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]GetEffectiveConfig: only management users can view the broker configuration", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can view the broker configuration"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{
+		"server_name": ch.S.opts.ServerName,
+		"config":      conf.EffectiveConfig(),
+	})
+}
@@ -14,15 +14,23 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"sort"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/memphisdev/memphis/analytics"
 	"github.com/memphisdev/memphis/db"
 	"github.com/memphisdev/memphis/memphis_cache"
 	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
 
 	"errors"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
 type ConnectionsHandler struct{}
@@ -31,6 +39,7 @@ const (
 	connectItemSep                      = "::"
 	userNameItemSep                     = "$"
 	connectConfigUpdatesSubjectTemplate = "$memphis_configurations_updates.init.%s"
+	connectionsCollectTimeout           = 3 * time.Second
 )
 
 func updateNewClientWithConfig(c *client, connId string) {
@@ -42,7 +51,8 @@ func updateNewClientWithConfig(c *client, connId string) {
 	}
 
 	config := models.GlobalConfigurationsUpdate{
-		Notifications: slackEnabled,
+		Notifications:        slackEnabled,
+		ScramServerSignature: c.memphisInfo.scramServerSignature,
 	}
 
 	sendConnectUpdate(c, config, connId)
@@ -119,7 +129,10 @@ func handleConnectMessage(client *client) error {
 				s.Errorf("[tenant: %v][user: %v]handleConnectMessage at UpdateProducersCounsumersConnection: %v", tenantName, username, err.Error())
 				return
 			}
-			if !exist {
+			producersStateSetConnectionActive(true, connectionId)
+			if exist {
+				recordCgRebalancesByConnections(tenantName, cgRebalanceMemberReconnected, connectionId)
+			} else {
 				shouldSendAnalytics, _ := shouldSendAnalytics()
 				if shouldSendAnalytics { // exist indicates it is a reconnect
 					splitted := strings.Split(lang, ".")
@@ -136,7 +149,7 @@ func handleConnectMessage(client *client) error {
 		updateNewClientWithConfig(client, connectionId)
 	}
 
-	client.memphisInfo = memphisClientInfo{username: username, connectionId: connectionId, isNative: isNativeMemphisClient}
+	client.memphisInfo = memphisClientInfo{username: username, connectionId: connectionId, isNative: isNativeMemphisClient, scramServerSignature: client.memphisInfo.scramServerSignature}
 	return nil
 }
 
@@ -150,6 +163,7 @@ func (mci *memphisClientInfo) updateDisconnection(tenantName string, notify func
 		if err != nil {
 			return err
 		}
+		producersStateSetConnectionActive(false, mci.connectionId)
 		var producerNames, consumerNames string
 		if len(producers) > 0 {
 			for i := 0; i < len(producers); i++ {
@@ -193,7 +207,300 @@ func (mci *memphisClientInfo) updateDisconnection(tenantName string, notify func
 		if err != nil {
 			return err
 		}
+		producersStateSetConnectionActive(false, mci.connectionId)
+	}
+	recordCgRebalancesByConnections(tenantName, cgRebalanceMemberDisconnected, mci.connectionId)
+
+	return nil
+}
+
+// connectionsRequest is sent to every broker, each one answers with its own connections of the tenant
+type connectionsRequest struct {
+	TenantName   string `json:"tenant_name"`
+	ConnectionId string `json:"connection_id,omitempty"`
+	Username     string `json:"username,omitempty"`
+}
+
+type connectionsReply struct {
+	Connections []models.Connection `json:"connections"`
+	Closed      int                 `json:"closed"`
+}
+
+// localSdkConnections returns the clients of this broker which belong to the tenant and identified themselves with a
+// memphis connection id, an empty connectionId matches all of them
+func (s *Server) localSdkConnections(tenantName, connectionId, username string) []*client {
+	s.mu.RLock()
+	clients := make([]*client, 0, len(s.clients))
+	for _, c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.RUnlock()
+
+	var matching []*client
+	for _, c := range clients {
+		c.mu.Lock()
+		match := c.kind == CLIENT && c.acc != nil && c.acc.GetName() == tenantName && c.memphisInfo.connectionId != _EMPTY_ &&
+			(connectionId == _EMPTY_ || c.memphisInfo.connectionId == connectionId) &&
+			(username == _EMPTY_ || connectionUsername(c.memphisInfo.username) == username)
+		c.mu.Unlock()
+		if match {
+			matching = append(matching, c)
+		}
+	}
+	return matching
+}
+
+// connectionUsername strips the tenant id SDKs may add to the username they connect with
+func connectionUsername(username string) string {
+	username, _, err := getUserAndTenantIdFromString(username)
+	if err != nil {
+		return _EMPTY_
+	}
+	return strings.ToLower(username)
+}
+
+func throughputSince(bytes int64, since time.Duration) models.Throughput {
+	throughput := models.Throughput{Bytes: bytes}
+	if seconds := int64(since.Seconds()); seconds > 0 {
+		throughput.BytesPerSec = bytes / seconds
+	}
+	return throughput
+}
+
+func (s *Server) describeConnection(c *client, now time.Time) models.Connection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	uptime := now.Sub(c.start)
+	// like in Connz, the inbound counters are updated outside of the client's lock
+	inBytes := atomic.LoadInt64(&c.inBytes)
+	return models.Connection{
+		ConnectionId:  c.memphisInfo.connectionId,
+		Broker:        s.Name(),
+		ClientAddress: net.JoinHostPort(c.host, strconv.Itoa(int(c.port))),
+		Username:      c.memphisInfo.username,
+		Sdk:           c.opts.Lang,
+		SdkVersion:    c.opts.Version,
+		ConnectedAt:   c.start,
+		InMsgs:        atomic.LoadInt64(&c.inMsgs),
+		OutMsgs:       c.outMsgs,
+		InThroughput:  throughputSince(inBytes, uptime),
+		OutThroughput: throughputSince(c.outBytes, uptime),
+	}
+}
+
+// answerConnectionsRequest lists or closes the local connections the request asks for
+func (s *Server) answerConnectionsRequest(subject string, req connectionsRequest) connectionsReply {
+	reply := connectionsReply{Connections: []models.Connection{}}
+	clients := s.localSdkConnections(req.TenantName, req.ConnectionId, req.Username)
+	if subject == CONN_DISCONNECT_SUBJ {
+		for _, c := range clients {
+			c.closeConnection(Kicked)
+		}
+		reply.Closed = len(clients)
+		return reply
 	}
+	now := time.Now()
+	for _, c := range clients {
+		conn := s.describeConnection(c, now)
+		conn.Stats = getConnectionStats(req.TenantName, conn.ConnectionId)
+		reply.Connections = append(reply.Connections, conn)
+	}
+	return reply
+}
 
+// requestConnectionsFromBrokers sends the request to every broker of the cluster and collects the replies until all
+// brokers answered or connectionsCollectTimeout passed
+func (s *Server) requestConnectionsFromBrokers(subject string, req connectionsRequest) (connectionsReply, error) {
+	var result connectionsReply
+	var lock sync.Mutex
+	brokers := s.NumRemotes() + 1
+	answered := make(chan struct{}, brokers)
+	replySubject := subject + "_reply_" + s.memphis.nuid.Next()
+	sub, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), replySubject, replySubject+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		go func(msg []byte) {
+			var brokerReply connectionsReply
+			err := json.Unmarshal(msg, &brokerReply)
+			if err != nil {
+				s.Errorf("[tenant: %v]requestConnectionsFromBrokers: %v", req.TenantName, err.Error())
+				return
+			}
+			lock.Lock()
+			result.Connections = append(result.Connections, brokerReply.Connections...)
+			result.Closed += brokerReply.Closed
+			lock.Unlock()
+			answered <- struct{}{}
+		}(copyBytes(msg))
+	})
+	if err != nil {
+		return connectionsReply{}, err
+	}
+	defer s.unsubscribeOnAcc(s.MemphisGlobalAccount(), sub)
+
+	rawReq, err := json.Marshal(req)
+	if err != nil {
+		return connectionsReply{}, err
+	}
+	err = s.sendInternalAccountMsgWithReply(s.MemphisGlobalAccount(), subject, replySubject, nil, rawReq, true)
+	if err != nil {
+		return connectionsReply{}, err
+	}
+	timeout := time.After(connectionsCollectTimeout)
+	for i := 0; i < brokers; i++ {
+		select {
+		case <-answered:
+		case <-timeout:
+			s.Warnf("[tenant: %v]requestConnectionsFromBrokers: %v of %v brokers answered in time", req.TenantName, i, brokers)
+			i = brokers
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	return result, nil
+}
+
+// attachConnectionClients fills the producers and consumers each connection is serving
+func attachConnectionClients(connections []models.Connection) error {
+	if len(connections) == 0 {
+		return nil
+	}
+	connectionIds := make([]string, 0, len(connections))
+	for _, conn := range connections {
+		connectionIds = append(connectionIds, conn.ConnectionId)
+	}
+	clients, err := db.GetActiveClientsByConnections(connectionIds)
+	if err != nil {
+		return err
+	}
+	for i := range connections {
+		connections[i].Producers = []models.ConnectionClient{}
+		connections[i].Consumers = []models.ConnectionClient{}
+		for _, connClient := range clients {
+			if connClient.ConnectionId != connections[i].ConnectionId {
+				continue
+			}
+			if connClient.ClientType == "consumer" {
+				connections[i].Consumers = append(connections[i].Consumers, connClient)
+			} else {
+				connections[i].Producers = append(connections[i].Producers, connClient)
+			}
+		}
+	}
 	return nil
 }
+
+func connectionServesStation(conn models.Connection, stationName string) bool {
+	for _, clients := range [][]models.ConnectionClient{conn.Producers, conn.Consumers} {
+		for _, connClient := range clients {
+			if connClient.StationName == stationName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetAllConnections lists the active SDK connections of the tenant on all brokers of the cluster
+func (ch ConnectionsHandler) GetAllConnections(c *gin.Context) {
+	var body models.GetConnectionsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetAllConnections at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	result, err := serv.requestConnectionsFromBrokers(CONN_LIST_SUBJ, connectionsRequest{TenantName: user.TenantName})
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAllConnections at requestConnectionsFromBrokers: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = attachConnectionClients(result.Connections)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAllConnections at attachConnectionClients: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	connections := []models.Connection{}
+	stationName := strings.ToLower(body.StationName)
+	for _, conn := range result.Connections {
+		if body.Username != _EMPTY_ && conn.Username != strings.ToLower(body.Username) {
+			continue
+		}
+		if stationName != _EMPTY_ && !connectionServesStation(conn, stationName) {
+			continue
+		}
+		connections = append(connections, conn)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].ConnectedAt.Before(connections[j].ConnectedAt)
+	})
+	c.IndentedJSON(200, connections)
+}
+
+// DisconnectConnection closes an SDK connection on every broker it is connected to. Its producers and consumers are
+// marked as disconnected, an SDK which reconnects automatically will attach them again
+func (ch ConnectionsHandler) DisconnectConnection(c *gin.Context) {
+	var body models.DisconnectConnectionSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("DisconnectConnection at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]DisconnectConnection: only management users can disconnect connections", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can disconnect connections"})
+		return
+	}
+
+	// the clients are collected before the connection is closed so they can be audited afterwards
+	clients, err := db.GetActiveClientsByConnections([]string{body.ConnectionId})
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]DisconnectConnection at GetActiveClientsByConnections: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	result, err := serv.requestConnectionsFromBrokers(CONN_DISCONNECT_SUBJ, connectionsRequest{TenantName: user.TenantName, ConnectionId: body.ConnectionId})
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]DisconnectConnection at requestConnectionsFromBrokers: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if result.Closed == 0 {
+		errMsg := fmt.Sprintf("Connection %v does not exist", body.ConnectionId)
+		serv.Warnf("[tenant: %v][user: %v]DisconnectConnection: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	disconnected := 0
+	for _, connClient := range clients {
+		if connClient.TenantName != user.TenantName {
+			continue
+		}
+		disconnected++
+		clientType := "Producer"
+		if connClient.ClientType == "consumer" {
+			clientType = "Consumer"
+		}
+		createAuditLogFromRequest(c, user, connClient.StationName, fmt.Sprintf("%v %v has been disconnected by user %v, its connection %v was closed", clientType, connClient.Name, user.Username, body.ConnectionId))
+	}
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Connection %v has been closed by user %v", body.ConnectionId, user.Username))
+
+	c.IndentedJSON(200, models.DisconnectConnectionResponse{
+		ConnectionId:        body.ConnectionId,
+		ClosedConnections:   result.Closed,
+		DisconnectedClients: disconnected,
+	})
+}
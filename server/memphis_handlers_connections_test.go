@@ -0,0 +1,42 @@
+package server
+
+import "testing"
+
+func TestLocalSdkConnectionsOfUser(t *testing.T) {
+	tenant, other := NewAccount("tenant"), NewAccount("other")
+	newClient := func(acc *Account, username, connectionId string) *client {
+		return &client{kind: CLIENT, acc: acc, memphisInfo: memphisClientInfo{username: username, connectionId: connectionId}}
+	}
+	s := &Server{clients: map[uint64]*client{
+		1: newClient(tenant, "dyn-abc", "conn-1"),
+		2: newClient(tenant, "DYN-ABC$2", "conn-2"),
+		3: newClient(tenant, "dyn-abcd", "conn-3"),
+		4: newClient(other, "dyn-abc", "conn-4"),
+		5: newClient(tenant, "dyn-abc", _EMPTY_),
+	}}
+
+	cases := []struct {
+		name         string
+		connectionId string
+		username     string
+		want         map[string]bool
+	}{
+		{name: "every connection of the tenant", want: map[string]bool{"conn-1": true, "conn-2": true, "conn-3": true}},
+		{name: "connections of the user", username: "dyn-abc", want: map[string]bool{"conn-1": true, "conn-2": true}},
+		{name: "connection of the user by id", username: "dyn-abc", connectionId: "conn-2", want: map[string]bool{"conn-2": true}},
+		{name: "connection of another user", username: "dyn-abc", connectionId: "conn-3", want: map[string]bool{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clients := s.localSdkConnections("tenant", tc.connectionId, tc.username)
+			if len(clients) != len(tc.want) {
+				t.Fatalf("got %v connections, want %v", len(clients), len(tc.want))
+			}
+			for _, c := range clients {
+				if !tc.want[c.memphisInfo.connectionId] {
+					t.Fatalf("connection %v does not match", c.memphisInfo.connectionId)
+				}
+			}
+		})
+	}
+}
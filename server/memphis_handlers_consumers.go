@@ -77,11 +77,11 @@ func GetConsumerGroupMembers(cgName string, station models.Station) ([]models.Cg
 }
 
 func (s *Server) createConsumerDirectV0(c *client, reply, tenantName string, ccr createConsumerRequestV0, requestVersion int) {
-	_, err := s.createConsumerDirectCommon(c, ccr.Name, ccr.StationName, ccr.ConsumerGroup, ccr.ConsumerType, ccr.ConnectionId, tenantName, ccr.Username, ccr.MaxAckTimeMillis, ccr.MaxMsgDeliveries, requestVersion, 1, -1, ccr.ConnectionId, "")
+	_, err := s.createConsumerDirectCommon(c, ccr.Name, ccr.StationName, ccr.ConsumerGroup, ccr.ConsumerType, ccr.ConnectionId, tenantName, ccr.Username, ccr.MaxAckTimeMillis, ccr.MaxMsgDeliveries, requestVersion, 1, -1, ccr.ConnectionId, "", "")
 	respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
 }
 
-func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationName, cGroup, cType, connectionId, tenantName, userName string, maxAckTime, maxMsgDeliveries, requestVersion int, startConsumeFromSequence uint64, lastMessages int64, appId, sdkLang string) ([]int, error) {
+func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationName, cGroup, cType, connectionId, tenantName, userName string, maxAckTime, maxMsgDeliveries, requestVersion int, startConsumeFromSequence uint64, lastMessages int64, appId, sdkLang, clientId string) ([]int, error) {
 	name := strings.ToLower(consumerName)
 	err := validateConsumerName(name)
 	if err != nil {
@@ -122,6 +122,11 @@ func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationNam
 		serv.Warnf("[tenant: %v][user: %v] createConsumerDirectCommon at GetUser from cache: %s", tenantName, userName, err.Error())
 		return []int{}, err
 	}
+	err = authorizeExternally(externalAuthzInput{TenantName: user.TenantName, Username: user.Username, StationName: stationName.Ext(), Action: externalAuthzConsume, ClientName: name, ConnectionId: connectionId, Source: "sdk"})
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]createConsumerDirectCommon at authorizeExternally: Consumer %v: %v", user.TenantName, user.Username, consumerName, err.Error())
+		return []int{}, err
+	}
 
 	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
 	if err != nil {
@@ -145,6 +150,7 @@ func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationNam
 		if created {
 			message := fmt.Sprintf("Station %v has been created by user %v", stationName.Ext(), user.Username)
 			serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+			publishBrokerEvent(user.TenantName, models.EventStationCreated, map[string]interface{}{"station_name": stationName.Ext(), "created_by": user.Username})
 			var auditLogs []interface{}
 			newAuditLog := models.AuditLog{
 				StationName:       stationName.Ext(),
@@ -264,13 +270,13 @@ func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationNam
 	}
 
 	if strings.HasPrefix(user.Username, "$") {
-		newConsumer, err = db.InsertNewConsumer(name, station.ID, "connector", connectionId, consumerGroup, maxAckTime, maxMsgDeliveries, startConsumeFromSequence, lastMessages, tenantName, station.PartitionsList, requestVersion, sdkName, appId)
+		newConsumer, err = db.InsertNewConsumer(name, station.ID, "connector", connectionId, consumerGroup, maxAckTime, maxMsgDeliveries, startConsumeFromSequence, lastMessages, tenantName, station.PartitionsList, requestVersion, sdkName, appId, clientId)
 		if err != nil {
 			serv.Errorf("[tenant: %v]createConsumerDirectCommon at InsertNewConsumer: Consumer %v at station %v :%v", user.TenantName, consumerName, cStationName, err.Error())
 			return []int{}, err
 		}
 	} else {
-		newConsumer, err = db.InsertNewConsumer(name, station.ID, consumerType, connectionId, consumerGroup, maxAckTime, maxMsgDeliveries, startConsumeFromSequence, lastMessages, tenantName, station.PartitionsList, requestVersion, sdkName, appId)
+		newConsumer, err = db.InsertNewConsumer(name, station.ID, consumerType, connectionId, consumerGroup, maxAckTime, maxMsgDeliveries, startConsumeFromSequence, lastMessages, tenantName, station.PartitionsList, requestVersion, sdkName, appId, clientId)
 		if err != nil {
 			serv.Errorf("[tenant: %v]createConsumerDirectCommon at InsertNewConsumer: Consumer %v at station %v :%v", user.TenantName, consumerName, cStationName, err.Error())
 			return []int{}, err
@@ -292,6 +298,8 @@ func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationNam
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]createConsumerDirectCommon at CreateAuditLogs: Consumer %v at station %v: %v", user.TenantName, user.Username, consumerName, cStationName, err.Error())
 	}
+	publishBrokerEvent(user.TenantName, models.EventConsumerCreated, map[string]interface{}{"station_name": stationName.Ext(), "consumer_name": name, "consumer_group": consumerGroup})
+	go recordCgRebalance(user.TenantName, station.ID, consumerGroup, cgRebalanceMemberJoined, name, connectionId)
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -302,6 +310,19 @@ func (s *Server) createConsumerDirectCommon(c *client, consumerName, cStationNam
 	return station.PartitionsList, nil
 }
 
+func validateConsumerStartOptions(startConsumeFromSequence uint64, lastMessages int64) error {
+	if startConsumeFromSequence <= 0 {
+		return errors.New("startConsumeFromSequence has to be a positive number")
+	}
+	if lastMessages < -1 {
+		return errors.New("min value for LastMessages is -1")
+	}
+	if startConsumeFromSequence > 1 && lastMessages > -1 {
+		return errors.New("consumer creation options can't contain both startConsumeFromSequence and lastMessages")
+	}
+	return nil
+}
+
 func (s *Server) createConsumerDirect(c *client, reply string, msg []byte) {
 	var ccr createConsumerRequestV3
 	var resp createConsumerResponse
@@ -323,6 +344,11 @@ func (s *Server) createConsumerDirect(c *client, reply string, msg []byte) {
 					respondWithRespErr(serv.MemphisGlobalAccountString(), s, reply, err, &resp)
 					return
 				}
+				if err := validateSdkRequestVersion(0); err != nil {
+					s.Warnf("[tenant: %v][user: %v]createConsumerDirect: Consumer %v at station %v: %v", tenantName, ccrV0.Username, ccrV0.Name, ccrV0.StationName, err.Error())
+					respondWithRespErr(serv.MemphisGlobalAccountString(), s, reply, err, &resp)
+					return
+				}
 				s.createConsumerDirectV0(c, reply, tenantName, ccrV0, ccr.RequestVersion)
 				return
 			}
@@ -359,28 +385,21 @@ func (s *Server) createConsumerDirect(c *client, reply string, msg []byte) {
 	}
 
 	ccr.TenantName = tenantName
-	if ccr.StartConsumeFromSequence <= 0 {
-		err := errors.New("startConsumeFromSequence has to be a positive number")
-		serv.Warnf("[tenant: %v]createConsumerDirect: %v", tenantName, err.Error())
-		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
-		return
-	}
-
-	if ccr.LastMessages < -1 {
-		err := errors.New("min value for LastMessages is -1")
-		serv.Warnf("[tenant: %v]createConsumerDirect: %v", tenantName, err.Error())
-		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
+	err = validateSdkRequestVersion(ccr.RequestVersion)
+	if err != nil {
+		s.Warnf("[tenant: %v][user: %v]createConsumerDirect: Consumer %v at station %v: %v", tenantName, ccr.Username, ccr.Name, ccr.StationName, err.Error())
+		respondWithRespErr(serv.MemphisGlobalAccountString(), s, reply, err, &resp)
 		return
 	}
-
-	if ccr.StartConsumeFromSequence > 1 && ccr.LastMessages > -1 {
-		err := errors.New("consumer creation options can't contain both startConsumeFromSequence and lastMessages")
+	err = validateConsumerStartOptions(ccr.StartConsumeFromSequence, ccr.LastMessages)
+	if err != nil {
 		serv.Warnf("[tenant: %v]createConsumerDirect: %v", tenantName, err.Error())
 		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
 		return
 	}
+	protocolVersion, capabilities := negotiateSdkProtocol(ccr.RequestVersion, lastConsumerCreationReqVersion, ccr.Capabilities)
 
-	partitions, err := s.createConsumerDirectCommon(c, ccr.Name, ccr.StationName, ccr.ConsumerGroup, ccr.ConsumerType, ccr.ConnectionId, tenantName, ccr.Username, ccr.MaxAckTimeMillis, ccr.MaxMsgDeliveries, ccr.RequestVersion, ccr.StartConsumeFromSequence, ccr.LastMessages, ccr.AppId, ccr.SdkLang)
+	partitions, err := s.createConsumerDirectCommon(c, ccr.Name, ccr.StationName, ccr.ConsumerGroup, ccr.ConsumerType, ccr.ConnectionId, tenantName, ccr.Username, ccr.MaxAckTimeMillis, ccr.MaxMsgDeliveries, ccr.RequestVersion, ccr.StartConsumeFromSequence, ccr.LastMessages, ccr.AppId, ccr.SdkLang, ccr.ClientId)
 	if err != nil {
 		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
 	}
@@ -394,7 +413,7 @@ func (s *Server) createConsumerDirect(c *client, reply string, msg []byte) {
 
 	schemaUpdate, err := getSchemaUpdateInitFromStation(sn, ccr.TenantName)
 	if err == ErrNoSchema {
-		v1Resp := createConsumerResponseV1{PartitionsUpdate: models.PartitionsUpdate{PartitionsList: partitions}, Err: _EMPTY_}
+		v1Resp := createConsumerResponseV1{PartitionsUpdate: models.PartitionsUpdate{PartitionsList: partitions}, ProtocolVersion: protocolVersion, Capabilities: capabilities, HeartbeatMs: sdkHeartbeatIntervalMs(capabilities), Err: _EMPTY_}
 		respondWithResp(s.MemphisGlobalAccountString(), s, reply, &v1Resp)
 		return
 	}
@@ -410,20 +429,20 @@ func (s *Server) createConsumerDirect(c *client, reply string, msg []byte) {
 	if len(partitions) == 0 && ccr.RequestVersion < 2 {
 		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
 	} else {
-		v1Resp := createConsumerResponseV1{SchemaUpdate: *schemaUpdate, PartitionsUpdate: models.PartitionsUpdate{PartitionsList: partitions}, Err: _EMPTY_}
+		v1Resp := createConsumerResponseV1{SchemaUpdate: *schemaUpdate, PartitionsUpdate: models.PartitionsUpdate{PartitionsList: partitions}, ProtocolVersion: protocolVersion, Capabilities: capabilities, HeartbeatMs: sdkHeartbeatIntervalMs(capabilities), Err: _EMPTY_}
 		respondWithResp(s.MemphisGlobalAccountString(), s, reply, &v1Resp)
 	}
 }
 
-func (ch ConsumersHandler) GetCgsByStation(stationName StationName, station models.Station) ([]models.Cg, []models.Cg, []models.Cg, error) { // for socket io endpoint
+func (ch ConsumersHandler) GetCgsByStation(stationName StationName, station models.Station, filter models.StationClientsFilter) ([]models.Cg, []models.Cg, []models.Cg, models.StationClientsCount, error) { // for socket io endpoint
 	var cgs []models.Cg
 	consumers, err := db.GetAllConsumersByStation(station.ID)
 	if err != nil {
-		return cgs, cgs, cgs, err
+		return cgs, cgs, cgs, models.StationClientsCount{}, err
 	}
 
 	if len(consumers) == 0 {
-		return []models.Cg{}, []models.Cg{}, []models.Cg{}, nil
+		return []models.Cg{}, []models.Cg{}, []models.Cg{}, models.StationClientsCount{}, nil
 	}
 
 	m := make(map[string]*models.Cg)
@@ -487,11 +506,59 @@ func (ch ConsumersHandler) GetCgsByStation(stationName StationName, station mode
 		}
 	}
 
-	var connectedCgs []models.Cg
-	var disconnectedCgs []models.Cg
-	var deletedCgs []models.Cg
+	var connectedCgs []*models.Cg
+	var disconnectedCgs []*models.Cg
+	var deletedCgs []*models.Cg
 
+	// filtering and paging happen before fetching the groups' info so only the returned page costs a nats request
 	for _, cg := range m {
+		if !stationClientNameMatches(filter, cg.Name) {
+			continue
+		}
+		if len(cg.ConnectedConsumers) > 0 {
+			if stationClientsStatusIncluded(filter, stationClientsStatusConnected) {
+				cg.IsActive = true
+				connectedCgs = append(connectedCgs, cg)
+			}
+		} else if len(cg.DisconnectedConsumers) > 0 {
+			if stationClientsStatusIncluded(filter, stationClientsStatusDisconnected) {
+				disconnectedCgs = append(disconnectedCgs, cg)
+			}
+		} else if stationClientsStatusIncluded(filter, stationClientsStatusDeleted) {
+			deletedCgs = append(deletedCgs, cg)
+		}
+	}
+
+	count := models.StationClientsCount{
+		Connected:    len(connectedCgs),
+		Disconnected: len(disconnectedCgs),
+		Deleted:      len(deletedCgs),
+	}
+	connected, err := ch.fillCgsInfo(stationName, station, paginateStationClients(sortCgsByStatusChange(connectedCgs), filter))
+	if err != nil {
+		return []models.Cg{}, []models.Cg{}, []models.Cg{}, models.StationClientsCount{}, err
+	}
+	disconnected, err := ch.fillCgsInfo(stationName, station, paginateStationClients(sortCgsByStatusChange(disconnectedCgs), filter))
+	if err != nil {
+		return []models.Cg{}, []models.Cg{}, []models.Cg{}, models.StationClientsCount{}, err
+	}
+	deleted, err := ch.fillCgsInfo(stationName, station, paginateStationClients(sortCgsByStatusChange(deletedCgs), filter))
+	if err != nil {
+		return []models.Cg{}, []models.Cg{}, []models.Cg{}, models.StationClientsCount{}, err
+	}
+	return connected, disconnected, deleted, count, nil
+}
+
+func sortCgsByStatusChange(cgs []*models.Cg) []*models.Cg {
+	sort.Slice(cgs, func(i, j int) bool {
+		return cgs[j].LastStatusChangeDate.Before(cgs[i].LastStatusChangeDate)
+	})
+	return cgs
+}
+
+func (ch ConsumersHandler) fillCgsInfo(stationName StationName, station models.Station, cgs []*models.Cg) ([]models.Cg, error) {
+	filled := []models.Cg{}
+	for _, cg := range cgs {
 		cgInfo, err := ch.S.GetCgInfo(station.TenantName, stationName, cg.Name, cg.PartitionsList)
 		if err != nil {
 			continue // ignoring cases where the consumer exist in memphis but not in nats
@@ -499,45 +566,15 @@ func (ch ConsumersHandler) GetCgsByStation(stationName StationName, station mode
 
 		totalPoisonMsgs, err := db.GetTotalPoisonMsgsPerCg(cg.Name, station.ID)
 		if err != nil {
-			return []models.Cg{}, []models.Cg{}, []models.Cg{}, err
+			return []models.Cg{}, err
 		}
 
 		cg.InProcessMessages = cgInfo.NumAckPending
 		cg.UnprocessedMessages = int(cgInfo.NumPending)
 		cg.PoisonMessages = totalPoisonMsgs
-
-		if len(cg.ConnectedConsumers) > 0 {
-			cg.IsActive = true
-			connectedCgs = append(connectedCgs, *cg)
-		} else if len(cg.DisconnectedConsumers) > 0 {
-			disconnectedCgs = append(disconnectedCgs, *cg)
-		} else {
-			deletedCgs = append(deletedCgs, *cg)
-		}
-	}
-
-	if len(connectedCgs) == 0 {
-		connectedCgs = []models.Cg{}
-	}
-
-	if len(disconnectedCgs) == 0 {
-		disconnectedCgs = []models.Cg{}
-	}
-
-	if len(deletedCgs) == 0 {
-		deletedCgs = []models.Cg{}
+		filled = append(filled, *cg)
 	}
-
-	sort.Slice(connectedCgs, func(i, j int) bool {
-		return connectedCgs[j].LastStatusChangeDate.Before(connectedCgs[i].LastStatusChangeDate)
-	})
-	sort.Slice(disconnectedCgs, func(i, j int) bool {
-		return disconnectedCgs[j].LastStatusChangeDate.Before(disconnectedCgs[i].LastStatusChangeDate)
-	})
-	sort.Slice(deletedCgs, func(i, j int) bool {
-		return deletedCgs[j].LastStatusChangeDate.Before(deletedCgs[i].LastStatusChangeDate)
-	})
-	return connectedCgs, disconnectedCgs, deletedCgs, nil
+	return filled, nil
 }
 
 func (ch ConsumersHandler) GetDelayedCgsByTenant(tenantName string, streams []*StreamInfo) ([]models.DelayedCgResp, error) {
@@ -781,6 +818,8 @@ func (s *Server) destroyCGFromNats(c *client, reply, userName, tenantName string
 		}
 	}
 
+	publishBrokerEvent(tenantName, models.EventConsumerDestroyed, map[string]interface{}{"station_name": stationName.Ext(), "consumer_name": name, "consumer_group": consumer.ConsumersGroup, "consumer_group_removed": deleted})
+	go recordCgRebalance(tenantName, station.ID, consumer.ConsumersGroup, cgRebalanceMemberLeft, name, consumer.ConnectionId)
 	respondWithErr(serv.MemphisGlobalAccountString(), s, reply, nil)
 
 }
@@ -838,6 +877,8 @@ func (s *Server) destroyCGFromNatsInternal(username, tenantName string, stationN
 		}
 	}
 
+	publishBrokerEvent(tenantName, models.EventConsumerDestroyed, map[string]interface{}{"station_name": stationName.Ext(), "consumer_name": name, "consumer_group": consumer.ConsumersGroup, "consumer_group_removed": deleted})
+	go recordCgRebalance(tenantName, station.ID, consumer.ConsumersGroup, cgRebalanceMemberLeft, name, consumer.ConnectionId)
 	return nil
 
 }
@@ -853,3 +894,173 @@ func comparePartitionsList(pList1, pList2 []int) bool {
 	}
 	return true
 }
+
+func (s *Server) createConsumersBatchDirect(c *client, reply string, msg []byte) {
+	var req createConsumersBatchRequest
+	var resp createConsumersBatchResponse
+
+	tenantName, message, err := s.getTenantNameAndMessage(msg)
+	if err != nil {
+		s.Errorf("createConsumersBatchDirect at getTenantNameAndMessage: %v", err.Error())
+		return
+	}
+	if err := json.Unmarshal([]byte(message), &req); err != nil {
+		s.Errorf("[tenant: %v]createConsumersBatchDirect at json.Unmarshal: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+	if len(req.Consumers) > maxRegistrationBatchSize {
+		err = fmt.Errorf("a batch can contain up to %v consumers", maxRegistrationBatchSize)
+		s.Warnf("[tenant: %v]createConsumersBatchDirect: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+
+	// every consumer may create its own consumer group in jetstream so they are created one by one,
+	// the schema of each station is fetched once for the whole batch
+	resp.Consumers = make([]createConsumerResponseV1, len(req.Consumers))
+	schemaUpdates := map[string]*models.SchemaUpdateInit{}
+	for i, ccr := range req.Consumers {
+		err := validateSdkRequestVersion(ccr.RequestVersion)
+		if err == nil {
+			err = requireSdkCapability(ccr.RequestVersion, sdkCapabilityBatchRegistration)
+		}
+		if err == nil {
+			err = validateConsumerStartOptions(ccr.StartConsumeFromSequence, ccr.LastMessages)
+		}
+		if err != nil {
+			serv.Warnf("[tenant: %v]createConsumersBatchDirect: %v", tenantName, err.Error())
+			resp.Consumers[i].SetError(err)
+			continue
+		}
+		sn, err := StationNameFromStr(ccr.StationName)
+		if err != nil {
+			s.Warnf("[tenant: %v][user: %v]createConsumersBatchDirect at StationNameFromStr: Consumer %v at station %v: %v", tenantName, ccr.Username, ccr.Name, ccr.StationName, err.Error())
+			resp.Consumers[i].SetError(err)
+			continue
+		}
+		partitions, err := s.createConsumerDirectCommon(c, ccr.Name, ccr.StationName, ccr.ConsumerGroup, ccr.ConsumerType, ccr.ConnectionId, tenantName, ccr.Username, ccr.MaxAckTimeMillis, ccr.MaxMsgDeliveries, ccr.RequestVersion, ccr.StartConsumeFromSequence, ccr.LastMessages, ccr.AppId, ccr.SdkLang, ccr.ClientId)
+		if err != nil {
+			resp.Consumers[i].SetError(err)
+			continue
+		}
+		resp.Consumers[i].PartitionsUpdate = models.PartitionsUpdate{PartitionsList: partitions}
+		resp.Consumers[i].ProtocolVersion, resp.Consumers[i].Capabilities = negotiateSdkProtocol(ccr.RequestVersion, lastConsumerCreationReqVersion, ccr.Capabilities)
+		resp.Consumers[i].HeartbeatMs = sdkHeartbeatIntervalMs(resp.Consumers[i].Capabilities)
+
+		schemaUpdate, ok := schemaUpdates[sn.Ext()]
+		if !ok {
+			schemaUpdate, err = getSchemaUpdateInitFromStation(sn, tenantName)
+			if err != nil && err != ErrNoSchema {
+				s.Errorf("[tenant: %v][user: %v]createConsumersBatchDirect at getSchemaUpdateInitFromStation: Consumer %v at station %v: %v", tenantName, ccr.Username, ccr.Name, ccr.StationName, err.Error())
+				resp.Consumers[i].SetError(err)
+				continue
+			}
+			schemaUpdates[sn.Ext()] = schemaUpdate
+		}
+		if schemaUpdate != nil {
+			resp.Consumers[i].SchemaUpdate = *schemaUpdate
+		}
+	}
+	respondWithResp(s.MemphisGlobalAccountString(), s, reply, &resp)
+}
+
+func (s *Server) destroyConsumersBatchDirect(c *client, reply string, msg []byte) {
+	var req destroyConsumersBatchRequest
+	var resp destroyBatchResponse
+
+	tenantName, message, err := s.getTenantNameAndMessage(msg)
+	if err != nil {
+		s.Errorf("destroyConsumersBatchDirect at getTenantNameAndMessage: %v", err.Error())
+		respondWithErr(serv.MemphisGlobalAccountString(), s, reply, err)
+		return
+	}
+	if err := json.Unmarshal([]byte(message), &req); err != nil {
+		s.Errorf("[tenant: %v]destroyConsumersBatchDirect at json.Unmarshal: %v", tenantName, err.Error())
+		respondWithRespErr(serv.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+	if len(req.Consumers) > maxRegistrationBatchSize {
+		err = fmt.Errorf("a batch can contain up to %v consumers", maxRegistrationBatchSize)
+		s.Warnf("[tenant: %v]destroyConsumersBatchDirect: %v", tenantName, err.Error())
+		respondWithRespErr(serv.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+
+	resp.Errors = make([]string, len(req.Consumers))
+	type consumersBatchGroup struct {
+		stationName  StationName
+		connectionId string
+		indexes      []int
+	}
+	var groups []*consumersBatchGroup
+	groupsByKey := map[string]*consumersBatchGroup{}
+	for i, dcr := range req.Consumers {
+		sn, err := StationNameFromStr(dcr.StationName)
+		if err != nil {
+			serv.Warnf("[tenant: %v]destroyConsumersBatchDirect at StationNameFromStr: Station %v: %v", tenantName, dcr.StationName, err.Error())
+			resp.Errors[i] = err.Error()
+			continue
+		}
+		connectionId := dcr.ConnectionId
+		if connectionId == _EMPTY_ {
+			connectionId = c.memphisInfo.connectionId
+		}
+		key := sn.Ext() + "|" + connectionId
+		group, ok := groupsByKey[key]
+		if !ok {
+			group = &consumersBatchGroup{stationName: sn, connectionId: connectionId}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.indexes = append(group.indexes, i)
+	}
+
+	for _, group := range groups {
+		_, station, err := db.GetStationByName(group.stationName.Ext(), tenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v]destroyConsumersBatchDirect at GetStationByName: Station %v: %v", tenantName, group.stationName.Ext(), err.Error())
+			for _, i := range group.indexes {
+				resp.Errors[i] = err.Error()
+			}
+			continue
+		}
+		names := make([]string, 0, len(group.indexes))
+		for _, i := range group.indexes {
+			names = append(names, strings.ToLower(req.Consumers[i].ConsumerName))
+		}
+		consumers, err := db.DeleteConsumersByNamesStationIDAndConnID(group.connectionId, names, station.ID)
+		if err != nil {
+			serv.Errorf("[tenant: %v]destroyConsumersBatchDirect at DeleteConsumersByNamesStationIDAndConnID: Station %v: %v", tenantName, group.stationName.Ext(), err.Error())
+			for _, i := range group.indexes {
+				resp.Errors[i] = err.Error()
+			}
+			continue
+		}
+		deletedConsumers := map[string]models.Consumer{}
+		for _, consumer := range consumers {
+			deletedConsumers[consumer.Name] = consumer
+		}
+
+		for j, i := range group.indexes {
+			consumer, ok := deletedConsumers[names[j]]
+			if !ok {
+				// either it does not exist or the same consumer was listed twice
+				resp.Errors[i] = fmt.Sprintf("Consumer %v at station %v does not exist", req.Consumers[i].ConsumerName, req.Consumers[i].StationName)
+				continue
+			}
+			delete(deletedConsumers, names[j])
+			username := c.memphisInfo.username
+			if username == _EMPTY_ {
+				username = req.Consumers[i].Username
+			}
+			err = s.destroyCGFromNatsInternal(username, tenantName, group.stationName, consumer, station)
+			if err != nil {
+				serv.Errorf("[tenant: %v]destroyConsumersBatchDirect at destroyCGFromNatsInternal: Consumer %v at station %v: %v", tenantName, consumer.Name, station.Name, err.Error())
+				resp.Errors[i] = err.Error()
+			}
+		}
+	}
+
+	respondWithResp(serv.MemphisGlobalAccountString(), s, reply, &resp)
+}
@@ -185,6 +185,8 @@ func (s *Server) handleSchemaverseDlsMsg(msg []byte) error {
 		serv.Errorf("[tenant: %v]handleSchemaverseDlsMsg: %v", tenantName, err.Error())
 		return err
 	}
+	incrementSchemaValidationFailures(tenantName, stationName.Ext())
+	recordConnectionError(tenantName, message.Producer.ConnectionId, message.ValidationError)
 	data, err := hex.DecodeString(message.Message.Data)
 	if err != nil {
 		serv.Errorf("[tenant: %v]handleSchemaverseDlsMsg at DecodeString: %v", tenantName, err.Error())
@@ -401,7 +403,7 @@ func (pmh PoisonMessagesHandler) GetDlsMessageDetailsById(messageId int, dlsType
 	msgDetails := models.MessagePayload{
 		TimeSent: dlsMessage.MessageDetails.TimeSent,
 		Size:     dlsMessage.MessageDetails.Size,
-		Data:     dlsMessage.MessageDetails.Data,
+		Data:     decompressHexPayload(dlsMessage.MessageDetails.Headers, dlsMessage.MessageDetails.Data),
 		Headers:  dlsMessage.MessageDetails.Headers,
 	}
 	dlsMsg := models.DlsMessage{
@@ -0,0 +1,248 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GatewayHandler struct{}
+
+// resolveGatewayStation resolves the station a gateway request targets and checks the user may access it,
+// userErr is set when the request itself is invalid and err when the lookup failed
+func resolveGatewayStation(user models.User, stationNameStr, operation string) (stationName StationName, station models.Station, userErr error, err error) {
+	stationName, userErr = StationNameFromStr(stationNameStr)
+	if userErr != nil {
+		return StationName{}, models.Station{}, userErr, nil
+	}
+	allowed, _, err := ValidateStationPermissions(user.Roles, stationName.Ext(), user.TenantName, operation)
+	if err != nil {
+		return StationName{}, models.Station{}, nil, err
+	}
+	if !allowed {
+		return StationName{}, models.Station{}, fmt.Errorf("user %v is not allowed to access station %v", user.Username, stationName.Ext()), nil
+	}
+	action := externalAuthzConsume
+	if operation == "write" {
+		action = externalAuthzProduce
+	}
+	userErr = authorizeExternally(externalAuthzInput{TenantName: user.TenantName, Username: user.Username, StationName: stationName.Ext(), Action: action, Source: "gateway"})
+	if userErr != nil {
+		return StationName{}, models.Station{}, userErr, nil
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		return StationName{}, models.Station{}, nil, err
+	}
+	if !exist {
+		return StationName{}, models.Station{}, fmt.Errorf("Station %v does not exist", stationName.Ext()), nil
+	}
+	return stationName, station, nil, nil
+}
+
+// getGatewayStation is resolveGatewayStation for http requests, on failure the response is already written
+func getGatewayStation(c *gin.Context, user models.User, funcName, stationNameStr, operation string) (StationName, models.Station, bool) {
+	stationName, station, userErr, err := resolveGatewayStation(user, stationNameStr, operation)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at resolveGatewayStation: Station %v: %v", user.TenantName, user.Username, funcName, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return StationName{}, models.Station{}, false
+	}
+	if userErr != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at resolveGatewayStation: %v", user.TenantName, user.Username, funcName, userErr.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": userErr.Error()})
+		return StationName{}, models.Station{}, false
+	}
+	return stationName, station, true
+}
+
+// checkGatewayAckIds is resolveGatewayAckIds for http requests, on failure the response is already written
+func checkGatewayAckIds(c *gin.Context, user models.User, funcName string, ackIds []string) bool {
+	userErr, err := resolveGatewayAckIds(user, ackIds)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at resolveGatewayAckIds: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return false
+	}
+	if userErr != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at resolveGatewayAckIds: %v", user.TenantName, user.Username, funcName, userErr.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": userErr.Error()})
+		return false
+	}
+	return true
+}
+
+func (gh GatewayHandler) Produce(c *gin.Context) {
+	var body models.GatewayProduceSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GatewayProduce at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateGatewayHeaders(body.Headers)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayProduce at validateGatewayHeaders: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "GatewayProduce", body.StationName, "write")
+	if !ok {
+		return
+	}
+
+	resp, err := serv.gatewayProduce(user.TenantName, user.Username, stationName, station, body.Message, body.Headers)
+	if errors.Is(err, ErrMessageSchemaValidation) {
+		serv.Warnf("[tenant: %v][user: %v]GatewayProduce at gatewayProduce: Station %v: %v", user.TenantName, user.Username, stationName.Ext(), err.Error())
+		c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayProduce at gatewayProduce: Station %v: %v", user.TenantName, user.Username, stationName.Ext(), err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(200, resp)
+}
+
+func (gh GatewayHandler) Fetch(c *gin.Context) {
+	var body models.GatewayFetchSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GatewayFetch at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.ConsumerGroup, "consumer group")
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayFetch at validateName: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	batchSize, wait, err := validateGatewayFetchLimits(body.BatchSize, body.WaitMs)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayFetch at validateGatewayFetchLimits: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "GatewayFetch", body.StationName, "read")
+	if !ok {
+		return
+	}
+
+	messages, err := serv.gatewayFetch(user.TenantName, stationName, station, body.ConsumerGroup, batchSize, wait)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GatewayFetch at gatewayFetch: Station %v: %v", user.TenantName, user.Username, stationName.Ext(), err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"messages": messages})
+}
+
+func (gh GatewayHandler) Ack(c *gin.Context) {
+	gh.ackOrNack(c, "GatewayAck", false)
+}
+
+func (gh GatewayHandler) Nack(c *gin.Context) {
+	gh.ackOrNack(c, "GatewayNack", true)
+}
+
+func (gh GatewayHandler) ackOrNack(c *gin.Context, funcName string, nack bool) {
+	var body models.GatewayAckSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("%v at getUserDetailsFromMiddleware: %v", funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	if !checkGatewayAckIds(c, user, funcName, body.AckIds) {
+		return
+	}
+	err = serv.gatewayAck(user.TenantName, body.AckIds, nack)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at gatewayAck: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (gh GatewayHandler) Transaction(c *gin.Context) {
+	var body models.GatewayTransactionSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GatewayTransaction at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	if !checkGatewayAckIds(c, user, "GatewayTransaction", body.AckIds) {
+		return
+	}
+	outputs := make([]gatewayTransactionOutput, 0, len(body.Produce))
+	for _, output := range body.Produce {
+		err = validateGatewayHeaders(output.Headers)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]GatewayTransaction at validateGatewayHeaders: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		stationName, station, ok := getGatewayStation(c, user, "GatewayTransaction", output.StationName, "write")
+		if !ok {
+			return
+		}
+		outputs = append(outputs, gatewayTransactionOutput{stationName: stationName, station: station, message: output.Message, headers: output.Headers})
+	}
+
+	resp, err := serv.gatewayTransaction(user.TenantName, user.Username, body.AckIds, outputs)
+	if errors.Is(err, ErrMessageSchemaValidation) {
+		serv.Warnf("[tenant: %v][user: %v]GatewayTransaction at gatewayTransaction: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GatewayTransaction at gatewayTransaction: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(200, resp)
+}
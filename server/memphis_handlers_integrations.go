@@ -36,6 +36,7 @@ var integrationsAuditLogLabelToSubjectMap = map[string]string{
 	"slack":  integrationsAuditLogsStream + ".%s.slack",
 	"s3":     integrationsAuditLogsStream + ".%s.s3",
 	"github": integrationsAuditLogsStream + ".%s.github",
+	"smtp":   integrationsAuditLogsStream + ".%s.smtp",
 }
 
 func (it IntegrationsHandler) CreateIntegration(c *gin.Context) {
@@ -125,6 +126,22 @@ func (it IntegrationsHandler) CreateIntegration(c *gin.Context) {
 			return
 		}
 		integration = githubIntegration
+	case "smtp":
+		smtpIntegration, errorCode, err := it.handleCreateSmtpIntegration(user.TenantName, body)
+		if err != nil {
+			if errorCode == 500 {
+				serv.Errorf("[tenant: %v][user: %v]CreateIntegration at handleCreateSmtpIntegration: %v", user.TenantName, user.Username, err.Error())
+				message = "Server error"
+			} else {
+				message = err.Error()
+				serv.Warnf("[tenant: %v][user: %v]CreateIntegration at handleCreateSmtpIntegration: %v", user.TenantName, user.Username, message)
+				auditLog := fmt.Sprintf("Error while trying to connect with SMTP: %v", message)
+				it.Errorf(integrationType, user.TenantName, auditLog)
+			}
+			c.AbortWithStatusJSON(errorCode, gin.H{"message": message})
+			return
+		}
+		integration = smtpIntegration
 	default:
 		serv.Warnf("[tenant: %v][user: %v]CreateIntegration: Unsupported integration type - %v", user.TenantName, user.Username, integrationType)
 		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Unsupported integration type - " + integrationType})
@@ -132,6 +149,7 @@ func (it IntegrationsHandler) CreateIntegration(c *gin.Context) {
 	}
 	auditLog := fmt.Sprintf("Integration with %v created successfully", integrationType)
 	it.Noticef(integrationType, user.TenantName, auditLog)
+	createAuditLogFromRequest(c, user, _EMPTY_, auditLog+" by user "+user.Username)
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		user, _ := getUserDetailsFromMiddleware(c)
@@ -276,6 +294,22 @@ func (it IntegrationsHandler) UpdateIntegration(c *gin.Context) {
 			return
 		}
 		integration = githubIntegration
+	case "smtp":
+		smtpIntegration, errorCode, err := it.handleUpdateSmtpIntegration(user.TenantName, body)
+		if err != nil {
+			if errorCode == 500 {
+				serv.Errorf("[tenant: %v][user: %v]UpdateIntegration at handleUpdateSmtpIntegration: %v", user.TenantName, user.Username, err.Error())
+				message = "Server error"
+			} else {
+				message = err.Error()
+				serv.Warnf("[tenant: %v][user: %v]UpdateIntegration at handleUpdateSmtpIntegration: %v", user.TenantName, user.Username, message)
+				auditLog := fmt.Sprintf("Error while trying to connect with SMTP: %v", message)
+				it.Errorf(integrationType, user.TenantName, auditLog)
+			}
+			c.AbortWithStatusJSON(errorCode, gin.H{"message": message})
+			return
+		}
+		integration = smtpIntegration
 	default:
 		serv.Warnf("[tenant: %v]UpdateIntegration: Unsupported integration type - %v", user.TenantName, body.Name)
 		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Unsupported integration type - " + body.Name})
@@ -284,6 +318,7 @@ func (it IntegrationsHandler) UpdateIntegration(c *gin.Context) {
 
 	auditLog := fmt.Sprintf("Integration with %v updated successfully", integrationType)
 	it.Noticef(integrationType, user.TenantName, auditLog)
+	createAuditLogFromRequest(c, user, _EMPTY_, auditLog+" by user "+user.Username)
 	c.IndentedJSON(200, integration)
 }
 
@@ -392,6 +427,7 @@ func (it IntegrationsHandler) DisconnectIntegration(c *gin.Context) {
 
 	auditLog := fmt.Sprintf("Integration with %v has been disconnected by user %v", integrationType, user.Username)
 	it.Noticef(integrationType, user.TenantName, auditLog)
+	createAuditLogFromRequest(c, user, _EMPTY_, auditLog)
 
 	c.IndentedJSON(200, gin.H{})
 }
@@ -468,6 +504,10 @@ func (it IntegrationsHandler) GetIntegrationDetails(c *gin.Context) {
 		integration.Keys["secret_key"] = hideIntegrationSecretKey(integration.Keys["secret_key"].(string))
 	}
 
+	if integration.Name == "smtp" {
+		integration.Keys = hideSmtpPassword(GetKeysAsStringMap(integration.Keys))
+	}
+
 	sourceCodeIntegration, branchesMap, err := getSourceCodeDetails(user.TenantName, body, "get_all_repos")
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetIntegrationDetails at getSourceCodeDetails: Integration %v: %v", user.TenantName, user.Username, body.Name, err.Error())
@@ -526,6 +566,9 @@ func (it IntegrationsHandler) GetAllIntegrations(c *gin.Context) {
 		if integrations[i].Name == "s3" && integrations[i].Keys["secret_key"] != _EMPTY_ {
 			integrations[i].Keys["secret_key"] = hideIntegrationSecretKey(integrations[i].Keys["secret_key"].(string))
 		}
+		if integrations[i].Name == "smtp" {
+			integrations[i].Keys = hideSmtpPassword(GetKeysAsStringMap(integrations[i].Keys))
+		}
 		if integrations[i].Name == "github" && integrations[i].Keys["installation_id"] != _EMPTY_ {
 			memphisFuncs, err := db.GetMemphisFunctionsByMemphis()
 			if err != nil {
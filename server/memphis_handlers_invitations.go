@@ -0,0 +1,368 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	invitationTokenPurpose      = "invitation"
+	INVITATION_EXPIRES_IN_HOURS = 72
+)
+
+var errInvalidInvitation = errors.New("The invitation is invalid, expired or has already been used")
+
+// invitationSecret keeps invitation tokens from being accepted as session tokens and vice versa
+func invitationSecret() []byte {
+	return []byte(configuration.JWT_SECRET + "_" + invitationTokenPurpose)
+}
+
+func createInvitationToken(invitation models.UserInvitation) (string, error) {
+	claims := jwt.MapClaims{
+		"purpose":       invitationTokenPurpose,
+		"invitation_id": invitation.ID,
+		"username":      invitation.Username,
+		"tenant_name":   invitation.TenantName,
+		"exp":           invitation.ExpiresAt.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(invitationSecret())
+}
+
+func verifyInvitationToken(tokenString string) (models.UserInvitation, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return invitationSecret(), nil
+	})
+	if err != nil || !token.Valid {
+		return models.UserInvitation{}, errInvalidInvitation
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != invitationTokenPurpose {
+		return models.UserInvitation{}, errInvalidInvitation
+	}
+	id, idOk := claims["invitation_id"].(float64)
+	username, usernameOk := claims["username"].(string)
+	tenantName, tenantOk := claims["tenant_name"].(string)
+	if !idOk || !usernameOk || !tenantOk {
+		return models.UserInvitation{}, errInvalidInvitation
+	}
+
+	exist, invitation, err := db.GetUserInvitationByID(int(id), tenantName)
+	if err != nil {
+		return models.UserInvitation{}, err
+	}
+	// the id changes whenever the invitation is resent, so older links are refused here
+	if !exist || invitation.Username != username || time.Now().After(invitation.ExpiresAt) {
+		return models.UserInvitation{}, errInvalidInvitation
+	}
+	return invitation, nil
+}
+
+func invitationUIUrl(uiUrl string) string {
+	if uiUrl == _EMPTY_ {
+		uiUrl = serv.opts.UiHost
+	}
+	return strings.TrimSuffix(uiUrl, "/")
+}
+
+func sendInvitationEmail(invitation models.UserInvitation, uiUrl string) error {
+	token, err := createInvitationToken(invitation)
+	if err != nil {
+		return err
+	}
+	link := invitationUIUrl(uiUrl) + "/invitation?token=" + url.QueryEscape(token)
+	subject := "You have been invited to Memphis"
+	body := fmt.Sprintf("Hi,\n\n%v has invited you to join Memphis as %v.\n\nChoose your password and activate your user here:\n%v\n\nThe invitation expires on %v.\n",
+		invitation.InvitedBy, invitation.Username, link, invitation.ExpiresAt.UTC().Format(time.RFC1123))
+	return sendEmail(invitation.TenantName, invitation.Email, subject, body)
+}
+
+// unusablePassword is set on invited users until they choose their own password
+func unusablePassword() (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(raw)), bcrypt.MinCost)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return string(hashedPwd), nil
+}
+
+func (umh UserMgmtHandler) InviteUser(c *gin.Context) {
+	var body models.InviteUserSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("InviteUser at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	username := strings.ToLower(body.Username)
+	fullName := strings.ToLower(body.FullName)
+	team := strings.ToLower(body.Team)
+	position := strings.ToLower(body.Position)
+	description := strings.ToLower(body.Description)
+	for _, err := range []error{validateUsername(username), validateUserFullName(fullName), validateUserTeam(team), validateUserPosition(position), validateUserDescription(description)} {
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]InviteUser: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	email, err := mail.ParseAddress(body.Email)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid email address %v", body.Email)
+		serv.Warnf("[tenant: %v][user: %v]InviteUser: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	smtpEnabled, err := IsSmtpEnabled(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at IsSmtpEnabled: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !smtpEnabled {
+		errMsg := "Inviting users by email requires an SMTP integration"
+		serv.Warnf("[tenant: %v][user: %v]InviteUser: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	exist, _, err := memphis_cache.GetUser(username, user.TenantName, true)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at GetUser: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if exist {
+		errMsg := fmt.Sprintf("A user with the name %v already exists", username)
+		serv.Warnf("[tenant: %v][user: %v]InviteUser: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	password, err := unusablePassword()
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at unusablePassword: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	newUser, err := db.CreateUser(username, "management", password, fullName, false, 1, user.TenantName, true, team, position, user.Username, description)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exist") {
+			serv.Warnf("[tenant: %v][user: %v]InviteUser at CreateUser: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at CreateUser: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = memphis_cache.SetUser(newUser)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at writing to the user cache error: %v", user.TenantName, user.Username, err)
+	}
+
+	invitation, err := db.UpsertUserInvitation(username, email.Address, user.Username, time.Now().Add(INVITATION_EXPIRES_IN_HOURS*time.Hour), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InviteUser at UpsertUserInvitation: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("User %v has been invited by user %v", username, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	// the user stays pending when the email fails, so the invitation can be resent once the smtp integration is fixed
+	err = sendInvitationEmail(invitation, body.UIUrl)
+	if err != nil {
+		errMsg := fmt.Sprintf("User %v was created but the invitation email could not be sent: %v", username, err.Error())
+		serv.Warnf("[tenant: %v][user: %v]InviteUser at sendInvitationEmail: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{
+		"id":          newUser.ID,
+		"username":    newUser.Username,
+		"full_name":   newUser.FullName,
+		"user_type":   newUser.UserType,
+		"created_at":  newUser.CreatedAt,
+		"avatar_id":   newUser.AvatarId,
+		"position":    newUser.Position,
+		"team":        newUser.Team,
+		"pending":     newUser.Pending,
+		"owner":       newUser.Owner,
+		"description": newUser.Description,
+		"invitation":  invitation,
+	})
+}
+
+func (umh UserMgmtHandler) ResendInvitation(c *gin.Context) {
+	var body models.ResendInvitationSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ResendInvitation at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username := strings.ToLower(body.Username)
+	exist, invitation, err := db.GetUserInvitationByUsername(username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ResendInvitation at GetUserInvitationByUsername: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("User %v has no pending invitation", username)
+		serv.Warnf("[tenant: %v][user: %v]ResendInvitation: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	invitation, err = db.UpsertUserInvitation(username, invitation.Email, user.Username, time.Now().Add(INVITATION_EXPIRES_IN_HOURS*time.Hour), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ResendInvitation at UpsertUserInvitation: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = sendInvitationEmail(invitation, body.UIUrl)
+	if err != nil {
+		errMsg := fmt.Sprintf("The invitation email could not be sent: %v", err.Error())
+		serv.Warnf("[tenant: %v][user: %v]ResendInvitation at sendInvitationEmail: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("Invitation of user %v has been resent by user %v", username, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	c.IndentedJSON(200, invitation)
+}
+
+func (umh UserMgmtHandler) GetInvitations(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetInvitations at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	invitations, err := db.GetUserInvitations(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetInvitations at GetUserInvitations: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, invitations)
+}
+
+// ApproveInvitation is called without a session, the signed token of the invitation link identifies the user
+func (umh UserMgmtHandler) ApproveInvitation(c *gin.Context) {
+	var body models.ApproveInvitationSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	invitation, err := verifyInvitationToken(body.Token)
+	if err != nil {
+		if err == errInvalidInvitation {
+			serv.Warnf("ApproveInvitation at verifyInvitationToken: %v", err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		serv.Errorf("ApproveInvitation at verifyInvitationToken: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, invitedUser, err := db.GetUserByUsername(invitation.Username, invitation.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v]ApproveInvitation at GetUserByUsername: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist || !invitedUser.Pending {
+		serv.Warnf("[tenant: %v]ApproveInvitation: User %v: %v", invitation.TenantName, invitation.Username, errInvalidInvitation.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errInvalidInvitation.Error()})
+		return
+	}
+	err = validatePassword(body.Password)
+	if err != nil {
+		serv.Warnf("[tenant: %v]ApproveInvitation at validatePassword: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.MinCost)
+	if err != nil {
+		serv.Errorf("[tenant: %v]ApproveInvitation at GenerateFromPassword: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = db.ChangeUserPassword(invitedUser.Username, string(hashedPwd), invitedUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v]ApproveInvitation at ChangeUserPassword: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = db.UpdatePendingUser(invitedUser.TenantName, invitedUser.Username, false)
+	if err != nil {
+		serv.Errorf("[tenant: %v]ApproveInvitation at UpdatePendingUser: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = db.DeleteUserInvitation(invitedUser.Username, invitedUser.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v]ApproveInvitation at DeleteUserInvitation: User %v: %v", invitation.TenantName, invitation.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	refreshCachedUsers(invitedUser.TenantName, []string{invitedUser.Username})
+
+	message := fmt.Sprintf("User %v has accepted the invitation of user %v", invitedUser.Username, invitation.InvitedBy)
+	serv.Noticef("[tenant: %v][user: %v]: %v", invitedUser.TenantName, invitedUser.Username, message)
+	createAuditLogFromRequest(c, invitedUser, _EMPTY_, message)
+	c.IndentedJSON(200, gin.H{"username": invitedUser.Username})
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
@@ -474,6 +475,71 @@ func getFakeProdsAndConsForPreview() ([]map[string]interface{}, []map[string]int
 	return connectedProducers, disconnectedProducers, connectedCgs, disconnectedCgs
 }
 
+const (
+	stationClientsStatusConnected    = "connected"
+	stationClientsStatusDisconnected = "disconnected"
+	stationClientsStatusDeleted      = "deleted"
+)
+
+func validateStationClientsFilter(filter models.StationClientsFilter) error {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return errors.New("clients limit and offset can not be negative")
+	}
+	switch filter.Status {
+	case _EMPTY_, stationClientsStatusConnected, stationClientsStatusDisconnected, stationClientsStatusDeleted:
+		return nil
+	default:
+		return fmt.Errorf("clients status has to be one of %v, %v or %v", stationClientsStatusConnected, stationClientsStatusDisconnected, stationClientsStatusDeleted)
+	}
+}
+
+// stationClientsFilterFromQuery parses the url query a ws subscription may append to its subject, e.g.
+// station_overview_data.<station>.<partition>?clients_limit=50&clients_search=billing
+func stationClientsFilterFromQuery(query string) (models.StationClientsFilter, error) {
+	var filter models.StationClientsFilter
+	if query == _EMPTY_ {
+		return filter, nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return filter, err
+	}
+	if v := values.Get("clients_limit"); v != _EMPTY_ {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid clients limit - %v", v)
+		}
+	}
+	if v := values.Get("clients_offset"); v != _EMPTY_ {
+		filter.Offset, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid clients offset - %v", v)
+		}
+	}
+	filter.Search = values.Get("clients_search")
+	filter.Status = values.Get("clients_status")
+	return filter, validateStationClientsFilter(filter)
+}
+
+func stationClientNameMatches(filter models.StationClientsFilter, name string) bool {
+	return filter.Search == _EMPTY_ || strings.Contains(strings.ToLower(name), strings.ToLower(filter.Search))
+}
+
+func stationClientsStatusIncluded(filter models.StationClientsFilter, status string) bool {
+	return filter.Status == _EMPTY_ || filter.Status == status
+}
+
+func paginateStationClients[T any](clients []T, filter models.StationClientsFilter) []T {
+	if filter.Offset >= len(clients) {
+		return []T{}
+	}
+	clients = clients[filter.Offset:]
+	if filter.Limit > 0 && len(clients) > filter.Limit {
+		clients = clients[:filter.Limit]
+	}
+	return clients
+}
+
 func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 	stationsHandler := StationsHandler{S: mh.S}
 	producersHandler := ProducersHandler{S: mh.S}
@@ -499,6 +565,12 @@ func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	err = validateStationClientsFilter(body.StationClientsFilter)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetStationOverviewData at validateStationClientsFilter: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
 	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetStationOverviewData at GetStationByName: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
@@ -533,8 +605,9 @@ func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 	}
 
 	connectedProducers, disconnectedProducers, deletedProducers := make([]models.ExtendedProducerResponse, 0), make([]models.ExtendedProducerResponse, 0), make([]models.ExtendedProducerResponse, 0)
+	var producersCount, cgsCount models.StationClientsCount
 	if station.IsNative {
-		connectedProducers, disconnectedProducers, deletedProducers, err = producersHandler.GetProducersByStation(station)
+		connectedProducers, disconnectedProducers, deletedProducers, producersCount, err = producersHandler.GetProducersByStation(station, body.StationClientsFilter)
 		if err != nil {
 			serv.Errorf("[tenant: %v][user: %v]GetStationOverviewData at GetProducersByStation: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
 			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -659,7 +732,7 @@ func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 
 	// Only native stations have CGs
 	if station.IsNative {
-		connectedCgs, disconnectedCgs, deletedCgs, err = consumersHandler.GetCgsByStation(stationName, station)
+		connectedCgs, disconnectedCgs, deletedCgs, cgsCount, err = consumersHandler.GetCgsByStation(stationName, station, body.StationClientsFilter)
 		if err != nil {
 			serv.Errorf("[tenant: %v][user: %v]GetStationOverviewData at GetCgsByStation: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
 			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -709,6 +782,12 @@ func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	connectionsStats, err := db.GetConnectionStatsByStation(station.ID, station.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationOverviewData at GetConnectionStatsByStation: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
 	var response gin.H
 
 	// Check when the schema object in station is not empty, not optional for non native stations
@@ -844,9 +923,29 @@ func (mh MonitoringHandler) GetStationOverviewData(c *gin.Context) {
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-enter-station-overview")
 	}
 
+	response["producers_count"] = producersCount
+	response["cgs_count"] = cgsCount
+	response["read_only"] = station.ReadOnly
+	// persisted stats, they lag behind the brokers by up to CONN_STATS_PERSIST_SEC
+	response["connections_stats"] = mergeConnectionStats(connectionsStats)
 	c.IndentedJSON(200, response)
 }
 
+// brokersCount returns the desired cluster size according to the JetStream meta group and the number of brokers currently connected
+func (s *Server) brokersCount() (int, int) {
+	if !s.JetStreamIsClustered() {
+		return 1, 1
+	}
+	actual := s.NumRemotes() + 1
+	desired := actual
+	if js := s.getJetStream(); js != nil {
+		if mg := js.getMetaGroup(); mg != nil {
+			desired = mg.ClusterSize()
+		}
+	}
+	return desired, actual
+}
+
 func min(x, y uint64) uint64 {
 	if x < y {
 		return x
@@ -958,31 +1057,7 @@ cleanup:
 			return models.SystemLogsResponse{}, err
 		}
 
-		splittedSubj := strings.Split(msg.Subject, tsep)
-		var (
-			logSource string
-			logType   string
-		)
-
-		if len(splittedSubj) == 2 {
-			// old version's logs
-			logSource = "broker"
-			logType = splittedSubj[1]
-		} else if len(splittedSubj) == 3 {
-			// old version's logs
-			logSource, logType = splittedSubj[1], splittedSubj[2]
-		} else {
-			logSource, logType = splittedSubj[1], splittedSubj[3]
-		}
-
-		data := string(msg.Data)
-		resMsgs = append(resMsgs, models.Log{
-			MessageSeq: int(msg.Sequence),
-			Type:       logType,
-			Data:       data,
-			Source:     logSource,
-			TimeSent:   msg.Time,
-		})
+		resMsgs = append(resMsgs, systemLogFromStoredMsg(msg))
 	}
 
 	if getAll {
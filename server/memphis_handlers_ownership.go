@@ -0,0 +1,319 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OwnershipHandler struct{}
+
+type resourceOwner struct {
+	userId   int
+	username string
+	team     string
+}
+
+func ownershipAdmin(c *gin.Context, funcName string) (models.User, bool) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("%v at getUserDetailsFromMiddleware: %v", funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return user, false
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]%v: only management users can transfer ownership", user.TenantName, user.Username, funcName)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can transfer ownership"})
+		return user, false
+	}
+	return user, true
+}
+
+// resolveNewOwner applies the requested user and team on the current owner of a resource,
+// a non empty message is returned when the request can not be applied
+func resolveNewOwner(tenantName string, current resourceOwner, toUsername, toTeam string) (resourceOwner, string, error) {
+	toUsername = strings.ToLower(strings.TrimSpace(toUsername))
+	toTeam = strings.ToLower(strings.TrimSpace(toTeam))
+	if toUsername == _EMPTY_ && toTeam == _EMPTY_ {
+		return current, "to_username or to_team has to be provided", nil
+	}
+	owner := current
+	if toUsername != _EMPTY_ {
+		exist, user, err := db.GetUserByUsername(toUsername, tenantName)
+		if err != nil {
+			return current, _EMPTY_, err
+		}
+		if !exist {
+			return current, fmt.Sprintf("User %v does not exist", toUsername), nil
+		}
+		owner = resourceOwner{userId: user.ID, username: user.Username, team: user.Team}
+	}
+	if toTeam != _EMPTY_ {
+		exist, _, err := db.GetTeamByName(toTeam, tenantName)
+		if err != nil {
+			return current, _EMPTY_, err
+		}
+		if !exist {
+			return current, fmt.Sprintf("Team %v does not exist", toTeam), nil
+		}
+		owner.team = toTeam
+	}
+	return owner, _EMPTY_, nil
+}
+
+func ownershipTransferMessage(resource string, owner resourceOwner, username string) string {
+	target := fmt.Sprintf("user %v", owner.username)
+	if owner.team != _EMPTY_ {
+		target = fmt.Sprintf("%v and team %v", target, owner.team)
+	}
+	return fmt.Sprintf("Ownership of %v has been transferred to %v by user %v", resource, target, username)
+}
+
+func (oh OwnershipHandler) TransferStationOwnership(c *gin.Context) {
+	var body models.TransferStationOwnershipSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := ownershipAdmin(c, "TransferStationOwnership")
+	if !ok {
+		return
+	}
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]TransferStationOwnership at StationNameFromStr: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferStationOwnership at GetStationByName: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]TransferStationOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	current := resourceOwner{userId: station.CreatedBy, username: station.CreatedByUsername, team: station.OwnerTeam}
+	owner, errMsg, err := resolveNewOwner(user.TenantName, current, body.ToUsername, body.ToTeam)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferStationOwnership at resolveNewOwner: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]TransferStationOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	err = db.TransferStationOwnership(station.Name, owner.userId, owner.username, owner.team, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferStationOwnership at TransferStationOwnership: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := ownershipTransferMessage("station "+station.Name, owner, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, station.Name, message)
+
+	c.IndentedJSON(200, gin.H{"station_name": station.Name, "created_by_username": owner.username, "owner_team": owner.team})
+}
+
+func (oh OwnershipHandler) TransferSchemaOwnership(c *gin.Context) {
+	var body models.TransferSchemaOwnershipSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := ownershipAdmin(c, "TransferSchemaOwnership")
+	if !ok {
+		return
+	}
+	schemaName := strings.ToLower(body.SchemaName)
+	exist, schema, err := db.GetSchemaByName(schemaName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferSchemaOwnership at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Schema %v does not exist", schemaName)
+		serv.Warnf("[tenant: %v][user: %v]TransferSchemaOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	current := resourceOwner{username: schema.CreatedByUsername, team: schema.OwnerTeam}
+	owner, errMsg, err := resolveNewOwner(user.TenantName, current, body.ToUsername, body.ToTeam)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferSchemaOwnership at resolveNewOwner: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]TransferSchemaOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	err = db.TransferSchemaOwnership(schema.Name, owner.username, owner.team, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferSchemaOwnership at TransferSchemaOwnership: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := ownershipTransferMessage("schema "+schema.Name, owner, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{"schema_name": schema.Name, "created_by_username": owner.username, "owner_team": owner.team})
+}
+
+func (oh OwnershipHandler) TransferProducerOwnership(c *gin.Context) {
+	var body models.TransferProducerOwnershipSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := ownershipAdmin(c, "TransferProducerOwnership")
+	if !ok {
+		return
+	}
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]TransferProducerOwnership at StationNameFromStr: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferProducerOwnership at GetStationByName: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]TransferProducerOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	producerName := strings.ToLower(body.ProducerName)
+	exist, producer, err := db.GetProducerByNameAndStationID(producerName, station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferProducerOwnership at GetProducerByNameAndStationID: Producer %v at station %v: %v", user.TenantName, user.Username, producerName, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Producer %v does not exist at station %v", producerName, body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]TransferProducerOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	current := resourceOwner{username: producer.Owner, team: producer.OwnerTeam}
+	owner, errMsg, err := resolveNewOwner(user.TenantName, current, body.ToUsername, body.ToTeam)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferProducerOwnership at resolveNewOwner: Producer %v at station %v: %v", user.TenantName, user.Username, producerName, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]TransferProducerOwnership: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	_, err = db.TransferProducerOwnership(station.ID, producerName, owner.username, owner.team)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferProducerOwnership at TransferProducerOwnership: Producer %v at station %v: %v", user.TenantName, user.Username, producerName, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := ownershipTransferMessage("producer "+producerName, owner, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, station.Name, message)
+
+	c.IndentedJSON(200, gin.H{"station_name": station.Name, "producer_name": producerName, "owner": owner.username, "owner_team": owner.team})
+}
+
+// transferUserResources moves everything owned by a user to another user of the tenant,
+// a non empty message is returned when the target user can not take them
+func transferUserResources(from models.User, toUsername string) (models.UserResourcesTransfer, string, error) {
+	toUsername = strings.ToLower(toUsername)
+	if toUsername == from.Username {
+		return models.UserResourcesTransfer{}, "Resources can not be transferred to the same user", nil
+	}
+	exist, to, err := db.GetUserByUsername(toUsername, from.TenantName)
+	if err != nil {
+		return models.UserResourcesTransfer{}, _EMPTY_, err
+	}
+	if !exist {
+		return models.UserResourcesTransfer{}, fmt.Sprintf("User %v does not exist", toUsername), nil
+	}
+	transfer, err := db.TransferUserResources(from.ID, from.Username, to.ID, to.Username, to.Team, from.TenantName)
+	return transfer, _EMPTY_, err
+}
+
+func (oh OwnershipHandler) TransferUserResources(c *gin.Context) {
+	var body models.TransferUserResourcesSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := ownershipAdmin(c, "TransferUserResources")
+	if !ok {
+		return
+	}
+	fromUsername := strings.ToLower(body.FromUsername)
+	exist, from, err := db.GetUserByUsername(fromUsername, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferUserResources at GetUserByUsername: User %v: %v", user.TenantName, user.Username, fromUsername, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("User %v does not exist", fromUsername)
+		serv.Warnf("[tenant: %v][user: %v]TransferUserResources: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	transfer, errMsg, err := transferUserResources(from, body.ToUsername)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]TransferUserResources at transferUserResources: User %v: %v", user.TenantName, user.Username, fromUsername, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if errMsg != _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]TransferUserResources: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("%v stations, %v schemas and %v producers of user %v have been transferred to user %v by user %v", transfer.Stations, transfer.Schemas, transfer.Producers, fromUsername, strings.ToLower(body.ToUsername), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, transfer)
+}
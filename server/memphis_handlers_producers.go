@@ -44,54 +44,47 @@ func validateProducerType(producerType string) error {
 	return nil
 }
 
-func (s *Server) createProducerDirectCommon(c *client, pName, pType, pConnectionId string, pStationName StationName, username string, tenantName string, version int, appId, sdkLang string) (bool, bool, error, models.Station) {
-	name := strings.ToLower(pName)
-	err := validateProducerName(name)
-	if err != nil {
-		serv.Warnf("createProducerDirectCommon at validateProducerName: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
-		return false, false, err, models.Station{}
-	}
-	producerType := strings.ToLower(pType)
-	err = validateProducerType(producerType)
-	if err != nil {
-		serv.Warnf("createProducerDirectCommon at validateProducerType: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
-		return false, false, err, models.Station{}
-	}
-
-	exist, user, err := memphis_cache.GetUser(username, tenantName, false)
-	if err != nil {
-		serv.Errorf("createProducerDirectCommon at GetUser: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
-		return false, false, err, models.Station{}
-	}
-	if !exist {
-		serv.Warnf("createProducerDirectCommon: User %v does not exist", username)
-		return false, false, errors.New("User " + username + " does not exist"), models.Station{}
+func producerSdkName(c *client, sdkLang string) string {
+	if sdkLang != "" {
+		return sdkLang
+	}
+	switch c.opts.Lang {
+	case "nats.js":
+		return "node.js"
+	case "python3":
+		return "python"
+	default:
+		return c.opts.Lang
 	}
+}
 
+// getProducerStation returns the station a producer is created at, creating a default station if needed
+func (s *Server) getProducerStation(user models.User, pName string, pStationName StationName, version int) (models.Station, error) {
 	exist, station, err := db.GetStationByName(pStationName.Ext(), user.TenantName)
 	if err != nil {
-		serv.Errorf("[tenant: %v][user: %v]createProducerDirectCommon at GetStationByName: Producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
-		return false, false, err, models.Station{}
+		serv.Errorf("[tenant: %v][user: %v]getProducerStation at GetStationByName: Producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
+		return models.Station{}, err
 	}
 	if !exist {
 		if version < 2 {
 			err := errors.New("this station does not exist, a default station can not be created automatically, please upgrade your SDK version")
-			serv.Warnf("[tenant: %v]createProducerDirectCommon : Producer %v at station %v : %v", user.TenantName, pName, pStationName, err.Error())
-			return false, false, err, models.Station{}
+			serv.Warnf("[tenant: %v]getProducerStation : Producer %v at station %v : %v", user.TenantName, pName, pStationName, err.Error())
+			return models.Station{}, err
 		}
 		var created bool
 		station, created, err = CreateDefaultStation(user.TenantName, s, pStationName, user, _EMPTY_, 0)
 		if err != nil {
 			if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "max amount") || strings.Contains(err.Error(), "not allowed") {
-				serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon at CreateDefaultStation: creating default station error - producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
+				serv.Warnf("[tenant: %v][user: %v]getProducerStation at CreateDefaultStation: creating default station error - producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
 			} else {
-				serv.Errorf("[tenant: %v][user: %v]createProducerDirectCommon at CreateDefaultStation: creating default station error - producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
+				serv.Errorf("[tenant: %v][user: %v]getProducerStation at CreateDefaultStation: creating default station error - producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
 			}
-			return false, false, err, models.Station{}
+			return models.Station{}, err
 		}
 		if created {
 			message := "Station " + pStationName.Ext() + " has been created by user " + user.Username
 			serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+			publishBrokerEvent(user.TenantName, models.EventStationCreated, map[string]interface{}{"station_name": pStationName.Ext(), "created_by": user.Username})
 			var auditLogs []interface{}
 			newAuditLog := models.AuditLog{
 				StationName:       pStationName.Ext(),
@@ -104,7 +97,7 @@ func (s *Server) createProducerDirectCommon(c *client, pName, pType, pConnection
 			auditLogs = append(auditLogs, newAuditLog)
 			err = CreateAuditLogs(auditLogs)
 			if err != nil {
-				serv.Errorf("[tenant: %v][user: %v]createProducerDirectCommon: Producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
+				serv.Errorf("[tenant: %v][user: %v]getProducerStation: Producer %v at station %v: %v", user.TenantName, user.Username, pName, pStationName.external, err.Error())
 			}
 
 			shouldSendAnalytics, _ := shouldSendAnalytics()
@@ -116,51 +109,86 @@ func (s *Server) createProducerDirectCommon(c *client, pName, pType, pConnection
 	} else {
 		if version < 2 && len(station.PartitionsList) > 0 {
 			err := errors.New("to produce to this station please upgrade your SDK version")
-			serv.Warnf("[tenant: %v]createProducerDirectCommon : Producer %v at station %v : %v", user.TenantName, pName, pStationName, err.Error())
-			return false, false, err, models.Station{}
+			serv.Warnf("[tenant: %v]getProducerStation : Producer %v at station %v : %v", user.TenantName, pName, pStationName, err.Error())
+			return models.Station{}, err
 		}
 		allowed, _, err := ValidateStationPermissions(user.Roles, pStationName.Ext(), user.TenantName, "write")
 		if err != nil {
-			serv.Errorf("[tenant: %v][user:%v]createProducerDirectCommon at ValidateStationPermissions: Station %v: %v", user.TenantName, user.Username, pStationName.Ext(), err.Error())
-			return false, false, err, models.Station{}
+			serv.Errorf("[tenant: %v][user:%v]getProducerStation at ValidateStationPermissions: Station %v: %v", user.TenantName, user.Username, pStationName.Ext(), err.Error())
+			return models.Station{}, err
 		}
 		if !allowed {
 			errMsg := fmt.Sprintf("user %v is not allowed to access station %v", user.Username, pStationName.Ext())
-			serv.Warnf("[tenant: %v][user:%v]createProducerDirectCommon: %v", user.TenantName, user.Username, errMsg)
-			return false, false, errors.New(errMsg), models.Station{}
+			serv.Warnf("[tenant: %v][user:%v]getProducerStation: %v", user.TenantName, user.Username, errMsg)
+			return models.Station{}, errors.New(errMsg)
 		}
 	}
 
+	return station, nil
+}
+
+func (s *Server) createProducerDirectCommon(c *client, pName, pType, pConnectionId string, pStationName StationName, username string, tenantName string, version int, appId, sdkLang, clientId string) (bool, bool, error, models.Station) {
+	name := strings.ToLower(pName)
+	err := validateProducerName(name)
+	if err != nil {
+		serv.Warnf("createProducerDirectCommon at validateProducerName: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
+		return false, false, err, models.Station{}
+	}
+	producerType := strings.ToLower(pType)
+	err = validateProducerType(producerType)
+	if err != nil {
+		serv.Warnf("createProducerDirectCommon at validateProducerType: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
+		return false, false, err, models.Station{}
+	}
+
+	exist, user, err := memphis_cache.GetUser(username, tenantName, false)
+	if err != nil {
+		serv.Errorf("createProducerDirectCommon at GetUser: Producer %v at station %v: %v", pName, pStationName.external, err.Error())
+		return false, false, err, models.Station{}
+	}
+	if !exist {
+		serv.Warnf("createProducerDirectCommon: User %v does not exist", username)
+		return false, false, errors.New("User " + username + " does not exist"), models.Station{}
+	}
+	err = authorizeExternally(externalAuthzInput{TenantName: user.TenantName, Username: user.Username, StationName: pStationName.Ext(), Action: externalAuthzProduce, ClientName: name, ConnectionId: pConnectionId, Source: "sdk"})
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon at authorizeExternally: Producer %v: %v", user.TenantName, user.Username, pName, err.Error())
+		return false, false, err, models.Station{}
+	}
+
+	station, err := s.getProducerStation(user, pName, pStationName, version)
+	if err != nil {
+		return false, false, err, models.Station{}
+	}
+	if station.ReadOnly {
+		err = stationReadOnlyError(pStationName)
+		serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon: Producer %v: %v", user.TenantName, user.Username, pName, err.Error())
+		return false, false, err, models.Station{}
+	}
+
 	err = validateProducersCount(station.ID, user.TenantName)
 	if err != nil {
 		serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon at validateProducersCount at station %s: %v", user.TenantName, user.Username, pStationName.Ext(), err.Error())
 		return false, false, err, models.Station{}
 	}
 
-	sdkName := sdkLang
-	if sdkLang == "" {
-		switch c.opts.Lang {
-		case "nats.js":
-			sdkName = "node.js"
-		case "python3":
-			sdkName = "python"
-		default:
-			sdkName = c.opts.Lang
-		}
-	}
+	sdkName := producerSdkName(c, sdkLang)
 
 	if strings.HasPrefix(user.Username, "$") && name != "gui" {
-		_, err := db.InsertNewProducer(name, station.ID, "connector", pConnectionId, station.TenantName, station.PartitionsList, version, sdkName, appId)
+		_, err := db.InsertNewProducer(name, station.ID, "connector", pConnectionId, station.TenantName, station.PartitionsList, version, sdkName, appId, user.Username, clientId)
 		if err != nil {
 			serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon at InsertNewProducer: %v", user.TenantName, user.Username, err.Error())
 			return false, false, err, models.Station{}
 		}
 	} else {
-		newProducer, err := db.InsertNewProducer(name, station.ID, producerType, pConnectionId, station.TenantName, station.PartitionsList, version, sdkName, appId)
+		newProducer, err := db.InsertNewProducer(name, station.ID, producerType, pConnectionId, station.TenantName, station.PartitionsList, version, sdkName, appId, user.Username, clientId)
 		if err != nil {
 			serv.Warnf("[tenant: %v][user: %v]createProducerDirectCommon at InsertNewProducer: %v", user.TenantName, user.Username, err.Error())
 			return false, false, err, models.Station{}
 		}
+		newProducer.Version = version
+		newProducer.Sdk = sdkName
+		producersStateAdd(station.Name, newProducer)
 		message := "Producer " + name + " connected"
 		var auditLogs []interface{}
 		newAuditLog := models.AuditLog{
@@ -200,7 +228,7 @@ func (s *Server) createProducerDirectV0(c *client, reply string, cpr createProdu
 		return
 	}
 	_, _, err, _ = s.createProducerDirectCommon(c, cpr.Name,
-		cpr.ProducerType, cpr.ConnectionId, sn, cpr.Username, tenantName, 0, cpr.ConnectionId, "")
+		cpr.ProducerType, cpr.ConnectionId, sn, cpr.Username, tenantName, 0, cpr.ConnectionId, "", "")
 	respondWithErr(s.MemphisGlobalAccountString(), s, reply, err)
 }
 
@@ -225,6 +253,11 @@ func (s *Server) createProducerDirect(c *client, reply string, msg []byte) {
 					respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
 					return
 				}
+				if err := validateSdkRequestVersion(0); err != nil {
+					s.Warnf("[tenant: %v][user: %v]createProducerDirect: Producer %v at station %v: %v", tenantName, cprV0.Username, cprV0.Name, cprV0.StationName, err.Error())
+					respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+					return
+				}
 				s.createProducerDirectV0(c, reply, cprV0, tenantName)
 				return
 			}
@@ -251,6 +284,12 @@ func (s *Server) createProducerDirect(c *client, reply string, msg []byte) {
 		}
 	}
 	cpr.TenantName = tenantName
+	err = validateSdkRequestVersion(cpr.RequestVersion)
+	if err != nil {
+		s.Warnf("[tenant: %v][user: %v]createProducerDirect: Producer %v at station %v: %v", cpr.TenantName, cpr.Username, cpr.Name, cpr.StationName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
 	sn, err := StationNameFromStr(cpr.StationName)
 	if err != nil {
 		s.Warnf("[tenant: %v][user: %v]createProducerDirect at StationNameFromStr: Producer %v at station %v: %v", cpr.TenantName, cpr.Username, cpr.Name, cpr.StationName, err.Error())
@@ -258,7 +297,7 @@ func (s *Server) createProducerDirect(c *client, reply string, msg []byte) {
 		return
 	}
 
-	clusterSendNotification, schemaVerseToDls, err, station := s.createProducerDirectCommon(c, cpr.Name, cpr.ProducerType, cpr.ConnectionId, sn, cpr.Username, tenantName, cpr.RequestVersion, cpr.AppId, cpr.SdkLang)
+	clusterSendNotification, schemaVerseToDls, err, station := s.createProducerDirectCommon(c, cpr.Name, cpr.ProducerType, cpr.ConnectionId, sn, cpr.Username, tenantName, cpr.RequestVersion, cpr.AppId, cpr.SdkLang, cpr.ClientId)
 	if err != nil {
 		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
 		return
@@ -275,6 +314,9 @@ func (s *Server) createProducerDirect(c *client, reply string, msg []byte) {
 	resp.PartitionsUpdate = partitions
 	resp.SchemaVerseToDls = schemaVerseToDls
 	resp.ClusterSendNotification = clusterSendNotification
+	resp.Compression = negotiateCompression(c, cpr.Compression)
+	resp.ProtocolVersion, resp.Capabilities = negotiateSdkProtocol(cpr.RequestVersion, lastProducerCreationReqVersion, cpr.Capabilities)
+	resp.HeartbeatMs = sdkHeartbeatIntervalMs(resp.Capabilities)
 	schemaUpdate, err := getSchemaUpdateInitFromStation(sn, cpr.TenantName)
 	if err == ErrNoSchema {
 		respondWithResp(s.MemphisGlobalAccountString(), s, reply, &resp)
@@ -290,10 +332,10 @@ func (s *Server) createProducerDirect(c *client, reply string, msg []byte) {
 	respondWithResp(s.MemphisGlobalAccountString(), s, reply, &resp)
 }
 
-func (ph ProducersHandler) GetProducersByStation(station models.Station) ([]models.ExtendedProducerResponse, []models.ExtendedProducerResponse, []models.ExtendedProducerResponse, error) { // for socket io endpoint
-	producers, err := db.GetAllProducersByStationID(station.ID)
+func (ph ProducersHandler) GetProducersByStation(station models.Station, filter models.StationClientsFilter) ([]models.ExtendedProducerResponse, []models.ExtendedProducerResponse, []models.ExtendedProducerResponse, models.StationClientsCount, error) { // for socket io endpoint
+	producers, err := getStationProducersState(station.ID)
 	if err != nil {
-		return []models.ExtendedProducerResponse{}, []models.ExtendedProducerResponse{}, []models.ExtendedProducerResponse{}, err
+		return []models.ExtendedProducerResponse{}, []models.ExtendedProducerResponse{}, []models.ExtendedProducerResponse{}, models.StationClientsCount{}, err
 	}
 
 	var connectedProducers []models.ExtendedProducerResponse
@@ -302,7 +344,7 @@ func (ph ProducersHandler) GetProducersByStation(station models.Station) ([]mode
 	producersNames := []string{}
 
 	for _, producer := range producers {
-		if slices.Contains(producersNames, producer.Name) {
+		if slices.Contains(producersNames, producer.Name) || !stationClientNameMatches(filter, producer.Name) {
 			continue
 		}
 		needToUpdateVersion := false
@@ -324,8 +366,10 @@ func (ph ProducersHandler) GetProducersByStation(station models.Station) ([]mode
 
 		producersNames = append(producersNames, producer.Name)
 		if producer.IsActive {
-			connectedProducers = append(connectedProducers, producerExtendedRes)
-		} else {
+			if stationClientsStatusIncluded(filter, stationClientsStatusConnected) {
+				connectedProducers = append(connectedProducers, producerExtendedRes)
+			}
+		} else if stationClientsStatusIncluded(filter, stationClientsStatusDisconnected) {
 			disconnectedProducers = append(disconnectedProducers, producerExtendedRes)
 		}
 	}
@@ -351,7 +395,15 @@ func (ph ProducersHandler) GetProducersByStation(station models.Station) ([]mode
 	sort.Slice(deletedProducers, func(i, j int) bool {
 		return deletedProducers[j].UpdatedAt.Before(deletedProducers[i].UpdatedAt)
 	})
-	return connectedProducers, disconnectedProducers, deletedProducers, nil
+	count := models.StationClientsCount{
+		Connected:    len(connectedProducers),
+		Disconnected: len(disconnectedProducers),
+		Deleted:      len(deletedProducers),
+	}
+	connectedProducers = paginateStationClients(connectedProducers, filter)
+	disconnectedProducers = paginateStationClients(disconnectedProducers, filter)
+	deletedProducers = paginateStationClients(deletedProducers, filter)
+	return connectedProducers, disconnectedProducers, deletedProducers, count, nil
 }
 
 func (s *Server) destroyProducerDirect(c *client, reply string, msg []byte) {
@@ -411,6 +463,7 @@ func (s *Server) destroyProducerDirect(c *client, reply string, msg []byte) {
 		respondWithErr(s.MemphisGlobalAccountString(), s, reply, errors.New(errMsg))
 		return
 	}
+	producersStateRemove(station.ID, dpr.ConnectionId, name)
 
 	username := c.memphisInfo.username
 	if username == _EMPTY_ {
@@ -473,6 +526,7 @@ func (s *Server) destroyProducerDirectV0(c *client, reply string, dpr destroyPro
 		respondWithErr(MEMPHIS_GLOBAL_ACCOUNT, s, reply, errors.New(errMsg))
 		return
 	}
+	producersStateForget(station.ID)
 
 	username := c.memphisInfo.username
 	if username == _EMPTY_ {
@@ -507,3 +561,326 @@ func (s *Server) destroyProducerDirectV0(c *client, reply string, dpr destroyPro
 
 	respondWithErr(MEMPHIS_GLOBAL_ACCOUNT, s, reply, nil)
 }
+
+type producersBatchGroup struct {
+	stationName  StationName
+	username     string
+	connectionId string
+	indexes      []int
+}
+
+func (s *Server) createProducersBatchDirect(c *client, reply string, msg []byte) {
+	var req createProducersBatchRequest
+	var resp createProducersBatchResponse
+
+	tenantName, message, err := s.getTenantNameAndMessage(msg)
+	if err != nil {
+		s.Errorf("createProducersBatchDirect at getTenantNameAndMessage: %v", err.Error())
+		return
+	}
+	if err := json.Unmarshal([]byte(message), &req); err != nil {
+		s.Errorf("[tenant: %v]createProducersBatchDirect at json.Unmarshal: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+	if len(req.Producers) > maxRegistrationBatchSize {
+		err = fmt.Errorf("a batch can contain up to %v producers", maxRegistrationBatchSize)
+		s.Warnf("[tenant: %v]createProducersBatchDirect: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+
+	resp.Producers = make([]createProducerResponse, len(req.Producers))
+	// producers are grouped by station and user so every station is resolved and validated once
+	var groups []*producersBatchGroup
+	groupsByKey := map[string]*producersBatchGroup{}
+	for i, cpr := range req.Producers {
+		err := validateSdkRequestVersion(cpr.RequestVersion)
+		if err == nil {
+			err = requireSdkCapability(cpr.RequestVersion, sdkCapabilityBatchRegistration)
+		}
+		if err != nil {
+			s.Warnf("[tenant: %v][user: %v]createProducersBatchDirect: Producer %v at station %v: %v", tenantName, cpr.Username, cpr.Name, cpr.StationName, err.Error())
+			resp.Producers[i].SetError(err)
+			continue
+		}
+		sn, err := StationNameFromStr(cpr.StationName)
+		if err != nil {
+			s.Warnf("[tenant: %v][user: %v]createProducersBatchDirect at StationNameFromStr: Producer %v at station %v: %v", tenantName, cpr.Username, cpr.Name, cpr.StationName, err.Error())
+			resp.Producers[i].SetError(err)
+			continue
+		}
+		key := sn.Ext() + "|" + cpr.Username
+		group, ok := groupsByKey[key]
+		if !ok {
+			group = &producersBatchGroup{stationName: sn, username: cpr.Username}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.indexes = append(group.indexes, i)
+	}
+
+	for _, group := range groups {
+		s.createProducersBatchAtStation(c, tenantName, group, req.Producers, resp.Producers)
+	}
+	respondWithResp(s.MemphisGlobalAccountString(), s, reply, &resp)
+}
+
+// createProducersBatchAtStation creates the producers of a single station and user with one bulk insert
+func (s *Server) createProducersBatchAtStation(c *client, tenantName string, group *producersBatchGroup, requests []createProducerRequestV3, responses []createProducerResponse) {
+	setGroupErr := func(indexes []int, err error) {
+		for _, i := range indexes {
+			responses[i].SetError(err)
+		}
+	}
+
+	exist, user, err := memphis_cache.GetUser(group.username, tenantName, false)
+	if err != nil {
+		serv.Errorf("[tenant: %v]createProducersBatchAtStation at GetUser: Station %v: %v", tenantName, group.stationName.Ext(), err.Error())
+		setGroupErr(group.indexes, err)
+		return
+	}
+	if !exist {
+		serv.Warnf("[tenant: %v]createProducersBatchAtStation: User %v does not exist", tenantName, group.username)
+		setGroupErr(group.indexes, errors.New("User "+group.username+" does not exist"))
+		return
+	}
+
+	// the station is created with the most restrictive sdk version in the group
+	version := requests[group.indexes[0]].RequestVersion
+	for _, i := range group.indexes {
+		if requests[i].RequestVersion < version {
+			version = requests[i].RequestVersion
+		}
+	}
+	station, err := s.getProducerStation(user, requests[group.indexes[0]].Name, group.stationName, version)
+	if err != nil {
+		setGroupErr(group.indexes, err)
+		return
+	}
+	err = validateProducersCount(station.ID, user.TenantName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]createProducersBatchAtStation at validateProducersCount at station %s: %v", user.TenantName, user.Username, group.stationName.Ext(), err.Error())
+		setGroupErr(group.indexes, err)
+		return
+	}
+
+	isConnectorUser := strings.HasPrefix(user.Username, "$")
+	var producers []models.Producer
+	var createdIndexes []int
+	for _, i := range group.indexes {
+		name := strings.ToLower(requests[i].Name)
+		err := validateProducerName(name)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]createProducersBatchAtStation at validateProducerName: Producer %v at station %v: %v", user.TenantName, user.Username, requests[i].Name, group.stationName.Ext(), err.Error())
+			responses[i].SetError(err)
+			continue
+		}
+		producerType := strings.ToLower(requests[i].ProducerType)
+		err = validateProducerType(producerType)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]createProducersBatchAtStation at validateProducerType: Producer %v at station %v: %v", user.TenantName, user.Username, requests[i].Name, group.stationName.Ext(), err.Error())
+			responses[i].SetError(err)
+			continue
+		}
+		err = authorizeExternally(externalAuthzInput{TenantName: user.TenantName, Username: user.Username, StationName: group.stationName.Ext(), Action: externalAuthzProduce, ClientName: name, ConnectionId: requests[i].ConnectionId, Source: "sdk"})
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]createProducersBatchAtStation at authorizeExternally: Producer %v: %v", user.TenantName, user.Username, requests[i].Name, err.Error())
+			responses[i].SetError(err)
+			continue
+		}
+		if isConnectorUser && name != "gui" {
+			producerType = "connector"
+		}
+		producers = append(producers, models.Producer{
+			Name:           name,
+			StationId:      station.ID,
+			Type:           producerType,
+			ConnectionId:   requests[i].ConnectionId,
+			TenantName:     station.TenantName,
+			PartitionsList: station.PartitionsList,
+			Version:        requests[i].RequestVersion,
+			Sdk:            producerSdkName(c, requests[i].SdkLang),
+			AppId:          requests[i].AppId,
+			Owner:          user.Username,
+			ClientId:       requests[i].ClientId,
+		})
+		createdIndexes = append(createdIndexes, i)
+	}
+	if len(producers) == 0 {
+		return
+	}
+
+	newProducers, err := db.InsertNewProducers(producers)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]createProducersBatchAtStation at InsertNewProducers: %v", user.TenantName, user.Username, err.Error())
+		setGroupErr(createdIndexes, err)
+		return
+	}
+	producersStateAdd(station.Name, newProducers...)
+
+	shouldSendAnalytics, _ := shouldSendAnalytics()
+	var auditLogs []interface{}
+	for _, producer := range newProducers {
+		if isConnectorUser && producer.Name != "gui" {
+			continue
+		}
+		auditLogs = append(auditLogs, models.AuditLog{
+			StationName:       group.stationName.Ext(),
+			Message:           "Producer " + producer.Name + " connected",
+			CreatedBy:         user.ID,
+			CreatedByUsername: user.Username,
+			CreatedAt:         time.Now(),
+			TenantName:        user.TenantName,
+		})
+		if shouldSendAnalytics {
+			analyticsParams := map[string]interface{}{"producer-name": producer.Name, "ip": serv.getIp()}
+			analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-create-producer-sdk")
+			if strings.HasPrefix(producer.Name, "rest_gateway") {
+				analytics.SendEvent(user.TenantName, user.Username, map[string]interface{}{}, "user-send-messages-via-rest-gw")
+			}
+		}
+	}
+	if len(auditLogs) > 0 {
+		err = CreateAuditLogs(auditLogs)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]createProducersBatchAtStation at CreateAuditLogs: Station %v: %v", user.TenantName, user.Username, group.stationName.Ext(), err.Error())
+		}
+	}
+
+	firstFunctions, err := GetAllFirstActiveFunctionsIDByStationID(station.ID, tenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]createProducersBatchAtStation at GetAllFirstActiveFunctionsIDByStationID: Station %v: %v", user.TenantName, user.Username, group.stationName.Ext(), err.Error())
+		setGroupErr(createdIndexes, fmt.Errorf("got an error while getting the functions data"))
+		return
+	}
+	schemaUpdate, err := getSchemaUpdateInitFromStation(group.stationName, tenantName)
+	if err != nil && err != ErrNoSchema {
+		serv.Errorf("[tenant: %v][user: %v]createProducersBatchAtStation at getSchemaUpdateInitFromStation: Station %v: %v", user.TenantName, user.Username, group.stationName.Ext(), err.Error())
+		setGroupErr(createdIndexes, err)
+		return
+	}
+	clusterSendNotification := shouldSendNotification(user.TenantName, SchemaVAlert)
+	for _, i := range createdIndexes {
+		responses[i].StationPartitionsFirstFunctions = firstFunctions
+		responses[i].StationVersion = station.Version
+		responses[i].PartitionsUpdate = models.PartitionsUpdate{PartitionsList: station.PartitionsList}
+		responses[i].SchemaVerseToDls = station.DlsConfigurationSchemaverse
+		responses[i].ClusterSendNotification = clusterSendNotification
+		responses[i].ProtocolVersion, responses[i].Capabilities = negotiateSdkProtocol(requests[i].RequestVersion, lastProducerCreationReqVersion, requests[i].Capabilities)
+		responses[i].HeartbeatMs = sdkHeartbeatIntervalMs(responses[i].Capabilities)
+		if schemaUpdate != nil {
+			responses[i].SchemaUpdate = *schemaUpdate
+		}
+	}
+}
+
+func (s *Server) destroyProducersBatchDirect(c *client, reply string, msg []byte) {
+	var req destroyProducersBatchRequest
+	var resp destroyBatchResponse
+
+	tenantName, message, err := s.getTenantNameAndMessage(msg)
+	if err != nil {
+		s.Errorf("destroyProducersBatchDirect at getTenantNameAndMessage: %v", err.Error())
+		respondWithErr(s.MemphisGlobalAccountString(), s, reply, err)
+		return
+	}
+	if err := json.Unmarshal([]byte(message), &req); err != nil {
+		s.Errorf("[tenant: %v]destroyProducersBatchDirect at json.Unmarshal: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+	if len(req.Producers) > maxRegistrationBatchSize {
+		err = fmt.Errorf("a batch can contain up to %v producers", maxRegistrationBatchSize)
+		s.Warnf("[tenant: %v]destroyProducersBatchDirect: %v", tenantName, err.Error())
+		respondWithRespErr(s.MemphisGlobalAccountString(), s, reply, err, &resp)
+		return
+	}
+
+	resp.Errors = make([]string, len(req.Producers))
+	var groups []*producersBatchGroup
+	groupsByKey := map[string]*producersBatchGroup{}
+	for i, dpr := range req.Producers {
+		sn, err := StationNameFromStr(dpr.StationName)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]destroyProducersBatchDirect at StationNameFromStr: Producer %v at station %v: %v", tenantName, dpr.Username, dpr.ProducerName, dpr.StationName, err.Error())
+			resp.Errors[i] = err.Error()
+			continue
+		}
+		connectionId := dpr.ConnectionId
+		if connectionId == _EMPTY_ {
+			connectionId = c.memphisInfo.connectionId
+		}
+		username := c.memphisInfo.username
+		if username == _EMPTY_ {
+			username = dpr.Username
+		}
+		key := sn.Ext() + "|" + connectionId + "|" + username
+		group, ok := groupsByKey[key]
+		if !ok {
+			group = &producersBatchGroup{stationName: sn, username: username, connectionId: connectionId}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.indexes = append(group.indexes, i)
+	}
+
+	var auditLogs []interface{}
+	for _, group := range groups {
+		_, station, err := db.GetStationByName(group.stationName.Ext(), tenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]destroyProducersBatchDirect at GetStationByName: Station %v: %v", tenantName, group.username, group.stationName.Ext(), err.Error())
+			for _, i := range group.indexes {
+				resp.Errors[i] = err.Error()
+			}
+			continue
+		}
+		names := make([]string, 0, len(group.indexes))
+		for _, i := range group.indexes {
+			names = append(names, strings.ToLower(req.Producers[i].ProducerName))
+		}
+		deletedNames, err := db.DeleteProducersByNamesStationIDAndConnID(names, station.ID, group.connectionId)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]destroyProducersBatchDirect at DeleteProducersByNamesStationIDAndConnID: Station %v: %v", tenantName, group.username, group.stationName.Ext(), err.Error())
+			for _, i := range group.indexes {
+				resp.Errors[i] = err.Error()
+			}
+			continue
+		}
+		producersStateRemove(station.ID, group.connectionId, deletedNames...)
+
+		_, user, err := memphis_cache.GetUser(group.username, tenantName, false)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]destroyProducersBatchDirect at GetUser: Station %v: %v", tenantName, group.username, group.stationName.Ext(), err.Error())
+		}
+		for j, i := range group.indexes {
+			if !slices.Contains(deletedNames, names[j]) {
+				resp.Errors[i] = fmt.Sprintf("Producer %v at station %v does not exist", names[j], req.Producers[i].StationName)
+				continue
+			}
+			message := "Producer " + names[j] + " has been destroyed"
+			serv.Noticef("[tenant: %v][user: %v]: %v", tenantName, group.username, message)
+			auditLogs = append(auditLogs, models.AuditLog{
+				StationName:       group.stationName.Ext(),
+				Message:           message,
+				CreatedBy:         user.ID,
+				CreatedByUsername: user.Username,
+				CreatedAt:         time.Now(),
+				TenantName:        user.TenantName,
+			})
+		}
+		shouldSendAnalytics, _ := shouldSendAnalytics()
+		if shouldSendAnalytics && len(deletedNames) > 0 {
+			analyticsParams := map[string]interface{}{"producers-count": len(deletedNames)}
+			analytics.SendEvent(user.TenantName, group.username, analyticsParams, "user-remove-producer-sdk")
+		}
+	}
+	if len(auditLogs) > 0 {
+		err = CreateAuditLogs(auditLogs)
+		if err != nil {
+			serv.Errorf("[tenant: %v]destroyProducersBatchDirect at CreateAuditLogs: %v", tenantName, err.Error())
+		}
+	}
+
+	respondWithResp(s.MemphisGlobalAccountString(), s, reply, &resp)
+}
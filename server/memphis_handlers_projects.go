@@ -0,0 +1,568 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectsHandler struct{}
+
+const projectPathDelimiter = "/"
+
+func projectsAdmin(c *gin.Context, funcName string) (models.User, bool) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("%v at getUserDetailsFromMiddleware: %v", funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return user, false
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]%v: only management users can manage projects", user.TenantName, user.Username, funcName)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can manage projects"})
+		return user, false
+	}
+	return user, true
+}
+
+// normalizeProjectTags lowercases and dedups the tags of a project, they follow the limits of station tags
+func normalizeProjectTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == _EMPTY_ || len(tag) > 20 {
+			return nil, fmt.Errorf("tag %v has to be between 1 and 20 characters", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+func validateProjectSettings(description string, maxStations int) error {
+	err := validateUserDescription(description)
+	if err != nil {
+		return err
+	}
+	if maxStations < 0 {
+		return errors.New("max_stations can not be negative, 0 means unlimited")
+	}
+	return nil
+}
+
+// effectiveProjectTags returns the tags an environment passes to its stations, its own tags and those of its project
+func effectiveProjectTags(environment models.Project, project models.Project) []string {
+	tags, _ := normalizeProjectTags(append(append([]string{}, project.Tags...), environment.Tags...))
+	return tags
+}
+
+// applyProjectTags adds the inherited tags the stations do not have yet, tags which were removed
+// from a project stay on its stations since they may have been set on the station directly
+func applyProjectTags(tags []string, stationIds []int, tenantName string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	for _, stationId := range stationIds {
+		current, err := db.GetTagsByEntityIDLight("station", stationId)
+		if err != nil {
+			return err
+		}
+		has := map[string]bool{}
+		for _, tag := range current {
+			has[tag.Name] = true
+		}
+		var missing []models.CreateTag
+		for _, tag := range tags {
+			if !has[tag] {
+				missing = append(missing, models.CreateTag{Name: tag, Color: defaultTagColor})
+			}
+		}
+		err = AddTagsToEntity(missing, "station", stationId, tenantName, _EMPTY_)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getProjectByPath resolves a project or an environment together with the project it belongs to,
+// for a project both returned values are the same
+func getProjectByPath(c *gin.Context, user models.User, funcName, path string) (models.Project, models.Project, bool) {
+	path = strings.ToLower(strings.Trim(strings.TrimSpace(path), projectPathDelimiter))
+	exist, node, err := db.GetProjectByPath(path, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at GetProjectByPath: %v: %v", user.TenantName, user.Username, funcName, path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return models.Project{}, models.Project{}, false
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Project %v does not exist", path)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return models.Project{}, models.Project{}, false
+	}
+	if node.ParentId == nil {
+		return node, node, true
+	}
+	exist, project, err := db.GetProjectById(*node.ParentId)
+	if err != nil || !exist {
+		if err == nil {
+			err = errors.New("parent project is missing")
+		}
+		serv.Errorf("[tenant: %v][user: %v]%v at GetProjectById: %v: %v", user.TenantName, user.Username, funcName, path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return models.Project{}, models.Project{}, false
+	}
+	return node, project, true
+}
+
+// getProjectStationIds resolves station names, on failure the response is already written
+func getProjectStationIds(c *gin.Context, user models.User, funcName string, stationNames []string) ([]int, bool) {
+	stationIds := make([]int, 0, len(stationNames))
+	for _, name := range stationNames {
+		stationName, err := StationNameFromStr(name)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]%v at StationNameFromStr: %v", user.TenantName, user.Username, funcName, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return nil, false
+		}
+		exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]%v at GetStationByName: Station %v: %v", user.TenantName, user.Username, funcName, name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return nil, false
+		}
+		if !exist {
+			errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+			serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return nil, false
+		}
+		stationIds = append(stationIds, station.ID)
+	}
+	return stationIds, true
+}
+
+// projectScopeStations returns the ids of the stations in a project or an environment
+func projectScopeStations(node models.Project, tenantName string) ([]int, error) {
+	projectStations, err := db.GetProjectStationsByTenant(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := db.GetProjectsByTenant(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	inScope := map[int]bool{node.ID: true}
+	for _, project := range projects {
+		if project.ParentId != nil && *project.ParentId == node.ID {
+			inScope[project.ID] = true
+		}
+	}
+	var stationIds []int
+	for _, projectStation := range projectStations {
+		if inScope[projectStation.ProjectId] {
+			stationIds = append(stationIds, projectStation.StationId)
+		}
+	}
+	return stationIds, nil
+}
+
+// buildProjectsTree nests the environments under their projects and resolves the inherited tags and permissions
+func buildProjectsTree(projects []models.Project, projectStations []models.ProjectStation, permissions []models.ProjectPermission) []models.ExtendedProject {
+	stationsByProject := map[int][]string{}
+	for _, projectStation := range projectStations {
+		stationsByProject[projectStation.ProjectId] = append(stationsByProject[projectStation.ProjectId], projectStation.StationName)
+	}
+	permissionsByProject := map[int][]models.ProjectRolePermission{}
+	for _, permission := range permissions {
+		permissionsByProject[permission.ProjectId] = append(permissionsByProject[permission.ProjectId], models.ProjectRolePermission{RoleName: permission.RoleName, Type: permission.Type})
+	}
+
+	tree := []models.ExtendedProject{}
+	index := map[int]int{}
+	for _, project := range projects {
+		if project.ParentId != nil {
+			continue
+		}
+		index[project.ID] = len(tree)
+		rolePermissions := permissionsByProject[project.ID]
+		if rolePermissions == nil {
+			rolePermissions = []models.ProjectRolePermission{}
+		}
+		tree = append(tree, models.ExtendedProject{
+			Project:       project,
+			Kind:          models.ProjectKindProject,
+			EffectiveTags: effectiveProjectTags(project, project),
+			Permissions:   rolePermissions,
+			Environments:  []models.ExtendedProject{},
+		})
+	}
+	for _, environment := range projects {
+		if environment.ParentId == nil {
+			continue
+		}
+		i, ok := index[*environment.ParentId]
+		if !ok {
+			continue
+		}
+		project := &tree[i]
+		rolePermissions := append([]models.ProjectRolePermission{}, permissionsByProject[environment.ID]...)
+		for _, permission := range project.Permissions {
+			permission.Inherited = true
+			rolePermissions = append(rolePermissions, permission)
+		}
+		stations := stationsByProject[environment.ID]
+		if stations == nil {
+			stations = []string{}
+		}
+		project.StationsCount += len(stations)
+		project.Environments = append(project.Environments, models.ExtendedProject{
+			Project:       environment,
+			Kind:          models.ProjectKindEnvironment,
+			EffectiveTags: effectiveProjectTags(environment, project.Project),
+			StationsCount: len(stations),
+			Stations:      stations,
+			Permissions:   rolePermissions,
+		})
+	}
+	return tree
+}
+
+func (ph ProjectsHandler) GetProjects(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetProjects at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	projects, err := db.GetProjectsByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetProjects at GetProjectsByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	projectStations, err := db.GetProjectStationsByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetProjects at GetProjectStationsByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	permissions, err := db.GetProjectPermissionsByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetProjects at GetProjectPermissionsByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, buildProjectsTree(projects, projectStations, permissions))
+}
+
+func (ph ProjectsHandler) CreateProject(c *gin.Context) {
+	var body models.CreateProjectSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, "CreateProject")
+	if !ok {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(body.Name))
+	err := validateName(name, "project")
+	if err == nil {
+		err = validateProjectSettings(body.Description, body.MaxStations)
+	}
+	var tags []string
+	if err == nil {
+		tags, err = normalizeProjectTags(body.Tags)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateProject: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	path := name
+	kind := "Project"
+	var parentId *int
+	if strings.TrimSpace(body.Parent) != _EMPTY_ {
+		parent, _, ok := getProjectByPath(c, user, "CreateProject", body.Parent)
+		if !ok {
+			return
+		}
+		if parent.ParentId != nil {
+			errMsg := fmt.Sprintf("%v is an environment, environments can only be created in a project", parent.Path)
+			serv.Warnf("[tenant: %v][user: %v]CreateProject: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		parentId = &parent.ID
+		path = parent.Path + projectPathDelimiter + name
+		kind = "Environment"
+	}
+
+	project, err := db.InsertProject(name, path, parentId, body.Description, tags, body.MaxStations, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			serv.Warnf("[tenant: %v][user: %v]CreateProject: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateProject at InsertProject: %v: %v", user.TenantName, user.Username, path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("%v %v has been created by user %v", kind, path, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, project)
+}
+
+func (ph ProjectsHandler) UpdateProject(c *gin.Context) {
+	var body models.UpdateProjectSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, "UpdateProject")
+	if !ok {
+		return
+	}
+	err := validateProjectSettings(body.Description, body.MaxStations)
+	var tags []string
+	if err == nil {
+		tags, err = normalizeProjectTags(body.Tags)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateProject: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	node, _, ok := getProjectByPath(c, user, "UpdateProject", body.Path)
+	if !ok {
+		return
+	}
+
+	node, err = db.UpdateProject(node.ID, body.Description, tags, body.MaxStations)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateProject at UpdateProject: %v: %v", user.TenantName, user.Username, node.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationIds, err := projectScopeStations(node, user.TenantName)
+	if err == nil {
+		err = applyProjectTags(tags, stationIds, user.TenantName)
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateProject at applyProjectTags: %v: %v", user.TenantName, user.Username, node.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Project %v has been updated by user %v", node.Path, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, node)
+}
+
+func (ph ProjectsHandler) RemoveProject(c *gin.Context) {
+	var body models.RemoveProjectSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, "RemoveProject")
+	if !ok {
+		return
+	}
+	node, _, ok := getProjectByPath(c, user, "RemoveProject", body.Path)
+	if !ok {
+		return
+	}
+	err := db.DeleteProject(node.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveProject at DeleteProject: %v: %v", user.TenantName, user.Username, node.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Project %v has been removed by user %v", node.Path, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (ph ProjectsHandler) AssignStations(c *gin.Context) {
+	var body models.AssignProjectStationsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, "AssignProjectStations")
+	if !ok {
+		return
+	}
+	environment, project, ok := getProjectByPath(c, user, "AssignProjectStations", body.Environment)
+	if !ok {
+		return
+	}
+	if environment.ParentId == nil {
+		errMsg := fmt.Sprintf("%v is a project, stations are assigned to the environments of a project", environment.Path)
+		serv.Warnf("[tenant: %v][user: %v]AssignProjectStations: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	stationIds, ok := getProjectStationIds(c, user, "AssignProjectStations", body.StationNames)
+	if !ok || len(stationIds) == 0 {
+		if ok {
+			c.IndentedJSON(200, gin.H{})
+		}
+		return
+	}
+
+	err := db.AssignStationsToEnvironment(environment, project, stationIds)
+	if errors.Is(err, db.ErrProjectQuotaExceeded) {
+		errMsg := fmt.Sprintf("Assigning the stations exceeds the max stations of %v or of its project", environment.Path)
+		serv.Warnf("[tenant: %v][user: %v]AssignProjectStations: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]AssignProjectStations at AssignStationsToEnvironment: %v: %v", user.TenantName, user.Username, environment.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = applyProjectTags(effectiveProjectTags(environment, project), stationIds, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]AssignProjectStations at applyProjectTags: %v: %v", user.TenantName, user.Username, environment.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Stations %v have been assigned to %v by user %v", strings.Join(body.StationNames, ", "), environment.Path, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (ph ProjectsHandler) UnassignStations(c *gin.Context) {
+	var body models.UnassignProjectStationsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, "UnassignProjectStations")
+	if !ok {
+		return
+	}
+	stationIds, ok := getProjectStationIds(c, user, "UnassignProjectStations", body.StationNames)
+	if !ok || len(stationIds) == 0 {
+		if ok {
+			c.IndentedJSON(200, gin.H{})
+		}
+		return
+	}
+	err := db.UnassignStations(stationIds)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UnassignProjectStations at UnassignStations: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Stations %v have been removed from their projects by user %v", strings.Join(body.StationNames, ", "), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (ph ProjectsHandler) GrantPermission(c *gin.Context) {
+	ph.setProjectPermission(c, "GrantProjectPermission", true)
+}
+
+func (ph ProjectsHandler) RevokePermission(c *gin.Context) {
+	ph.setProjectPermission(c, "RevokeProjectPermission", false)
+}
+
+// setProjectPermission grants or revokes the read or write access of a role to every station of a project or an environment
+func (ph ProjectsHandler) setProjectPermission(c *gin.Context, funcName string, grant bool) {
+	var body models.ProjectPermissionSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := projectsAdmin(c, funcName)
+	if !ok {
+		return
+	}
+	permissionType := strings.ToLower(body.Type)
+	if permissionType != "read" && permissionType != "write" {
+		errMsg := "type has to be read or write"
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	node, _, ok := getProjectByPath(c, user, funcName, body.Path)
+	if !ok {
+		return
+	}
+
+	var changed bool
+	var err error
+	if grant {
+		changed, err = db.UpsertProjectPermission(node.ID, body.RoleName, permissionType, user.TenantName)
+	} else {
+		changed, err = db.DeleteProjectPermission(node.ID, body.RoleName, permissionType, user.TenantName)
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v: %v: %v", user.TenantName, user.Username, funcName, node.Path, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !changed {
+		errMsg := fmt.Sprintf("Role %v does not exist", body.RoleName)
+		if !grant {
+			errMsg = fmt.Sprintf("Role %v has no %v permission on %v", body.RoleName, permissionType, node.Path)
+		}
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	var message string
+	if grant {
+		message = fmt.Sprintf("Role %v has been granted %v permission on %v by user %v", body.RoleName, permissionType, node.Path, user.Username)
+	} else {
+		message = fmt.Sprintf("The %v permission of role %v on %v has been revoked by user %v", permissionType, body.RoleName, node.Path, user.Username)
+	}
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
@@ -74,7 +74,30 @@ func GetUserAllowedStations(userRoles []int, tenantName string) ([]models.Statio
 		return nil, nil, err
 	}
 
-	return allowReadStations, allowWriteStations, nil
+	projectReadStations, err := db.GetStationsByProjectGrants(userRoles, "read", tenantName)
+	if err != nil {
+		return nil, nil, err
+	}
+	projectWriteStations, err := db.GetStationsByProjectGrants(userRoles, "write", tenantName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mergeStationsById(allowReadStations, projectReadStations), mergeStationsById(allowWriteStations, projectWriteStations), nil
+}
+
+func mergeStationsById(stations, more []models.Station) []models.Station {
+	seen := make(map[int]bool, len(stations))
+	for _, station := range stations {
+		seen[station.ID] = true
+	}
+	for _, station := range more {
+		if !seen[station.ID] {
+			seen[station.ID] = true
+			stations = append(stations, station)
+		}
+	}
+	return stations
 }
 
 func GetPatternWithDots(pattern string) string {
@@ -145,6 +168,11 @@ func GetAllMemphisAndNatsInternalSubjects() []string {
 	subjects = append(subjects, memphisSchemaCreations)
 	subjects = append(subjects, memphisStationCreations)
 	subjects = append(subjects, memphisStationDestructions)
+	subjects = append(subjects, memphisProducerBatchCreations)
+	subjects = append(subjects, memphisProducerBatchDestructions)
+	subjects = append(subjects, memphisConsumerBatchCreations)
+	subjects = append(subjects, memphisConsumerBatchDestructions)
+	subjects = append(subjects, memphisSdkHeartbeats)
 
 	// Nats subjects
 	subjects = append(subjects, inboxSubject)
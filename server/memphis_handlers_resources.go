@@ -0,0 +1,1319 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const resourceTagColor = "101, 87, 255" // default memphis-purple color
+
+type ResourcesHandler struct{}
+
+// resourceSpecError is a failure caused by the requested definition itself,
+// it is returned to the caller instead of being logged as a server error
+type resourceSpecError struct {
+	error
+}
+
+func resourceSpecErrorf(format string, a ...interface{}) error {
+	return resourceSpecError{fmt.Errorf(format, a...)}
+}
+
+// resourceETag hashes the json form of a resource, struct fields are always marshaled in the same order
+// and lists are kept sorted so an unchanged resource keeps its tag between reads
+func resourceETag(resource interface{}) string {
+	content, _ := json.Marshal(resource)
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func etagListContains(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkResourcePreconditions evaluates If-Match and If-None-Match against the current etag, an empty etag means the resource does not exist
+func checkResourcePreconditions(c *gin.Context, etag string) bool {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != _EMPTY_ {
+		if etag == _EMPTY_ || !etagListContains(ifMatch, etag) {
+			return false
+		}
+	}
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != _EMPTY_ && etag != _EMPTY_ && etagListContains(ifNoneMatch, etag) {
+		return false
+	}
+	return true
+}
+
+func normalizeResourceTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == _EMPTY_ || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func getResourceTags(entity string, id int) ([]string, error) {
+	tags, err := db.GetTagsByEntityIDLight(entity, id)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return normalizeResourceTags(names), nil
+}
+
+func syncResourceTags(entity string, id int, tenantName string, current, desired []string) error {
+	wanted := make(map[string]bool)
+	for _, tag := range desired {
+		wanted[tag] = true
+	}
+	for _, tag := range current {
+		if wanted[tag] {
+			delete(wanted, tag)
+			continue
+		}
+		err := db.RemoveTagFromEntity(tag, entity, id)
+		if err != nil {
+			return err
+		}
+	}
+	tagsToAdd := make([]models.CreateTag, 0, len(wanted))
+	for _, tag := range desired {
+		if wanted[tag] {
+			tagsToAdd = append(tagsToAdd, models.CreateTag{Name: tag, Color: resourceTagColor})
+		}
+	}
+	return AddTagsToEntity(tagsToAdd, entity, id, tenantName, resourceTagColor)
+}
+
+func equalResourceTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Stations
+
+func getStationResource(tenantName string, sn StationName) (models.StationResource, bool, error) {
+	exist, station, err := db.GetStationByName(sn.Ext(), tenantName)
+	if err != nil || !exist {
+		return models.StationResource{}, exist, err
+	}
+	tags, err := getResourceTags("station", station.ID)
+	if err != nil {
+		return models.StationResource{}, false, err
+	}
+	partitionsNumber := len(station.PartitionsList)
+	if partitionsNumber == 0 {
+		// stations created before partitioning have an empty partitions list
+		partitionsNumber = 1
+	}
+	return models.StationResource{
+		Name:                 station.Name,
+		RetentionType:        station.RetentionType,
+		RetentionValue:       station.RetentionValue,
+		StorageType:          station.StorageType,
+		Replicas:             station.Replicas,
+		PartitionsNumber:     partitionsNumber,
+		IdempotencyWindow:    station.IdempotencyWindow,
+		TieredStorageEnabled: station.TieredStorageEnabled,
+		SchemaName:           station.SchemaName,
+		DlsConfiguration:     &models.DlsConfiguration{Poison: station.DlsConfigurationPoison, Schemaverse: station.DlsConfigurationSchemaverse},
+		DlsStation:           station.DlsStation,
+		MessageTransform:     station.MessageTransform,
+		Tags:                 tags,
+	}, true, nil
+}
+
+// normalizeStationResource fills in the same defaults CreateStation uses, so leaving a field out
+// of the definition never shows up as a difference from the stored station
+func normalizeStationResource(tenantName string, desired *models.StationResource) error {
+	desired.RetentionType = strings.ToLower(desired.RetentionType)
+	if desired.RetentionType == _EMPTY_ {
+		desired.RetentionType = "message_age_sec"
+	}
+	err := validateRetentionType(desired.RetentionType)
+	if err != nil {
+		return err
+	}
+	if desired.RetentionValue <= 0 && retentionTypeHasValue(desired.RetentionType) {
+		desired.RetentionType = "message_age_sec"
+		desired.RetentionValue = 3600 // 1 hour
+	}
+	if !validateRetentionPolicyUsage(tenantName, desired.RetentionType, desired.RetentionValue) {
+		return errors.New("this retention type or value is not supported in your pricing plan")
+	}
+
+	desired.StorageType = getStationStorageType(desired.StorageType)
+	if desired.StorageType == _EMPTY_ {
+		desired.StorageType = "file"
+	}
+	err = validateStorageType(desired.StorageType)
+	if err != nil {
+		return err
+	}
+
+	desired.Replicas = GetStationReplicas(desired.Replicas)
+	err = validateReplicas(desired.Replicas)
+	if err != nil {
+		return err
+	}
+	if desired.PartitionsNumber <= 0 {
+		desired.PartitionsNumber = 1
+	}
+
+	err = validateIdempotencyWindow(desired.RetentionType, desired.RetentionValue, desired.IdempotencyWindow)
+	if err != nil {
+		return err
+	}
+	if desired.IdempotencyWindow <= 0 {
+		desired.IdempotencyWindow = 120000 // default
+	} else if desired.IdempotencyWindow < 100 {
+		desired.IdempotencyWindow = 100 // minimum is 100 millis
+	}
+
+	desired.SchemaName = strings.ToLower(desired.SchemaName)
+	if desired.DlsConfiguration == nil {
+		desired.DlsConfiguration = &models.DlsConfiguration{Poison: true, Schemaverse: true}
+	}
+	if desired.DlsStation != _EMPTY_ {
+		dlsStationName, err := StationNameFromStr(desired.DlsStation)
+		if err != nil {
+			return err
+		}
+		desired.DlsStation = dlsStationName.Ext()
+	}
+	err = validateMessageTransform(desired.MessageTransform, models.MessageTransformDebeziumUnwrap)
+	if err != nil {
+		return err
+	}
+	desired.Tags = normalizeResourceTags(desired.Tags)
+	return nil
+}
+
+func (s *Server) applyStationResource(user models.User, desired models.StationResource, dryRun bool) (string, error) {
+	stationName, err := StationNameFromStr(desired.Name)
+	if err != nil {
+		return _EMPTY_, resourceSpecError{err}
+	}
+	desired.Name = stationName.Ext()
+	err = normalizeStationResource(user.TenantName, &desired)
+	if err != nil {
+		return _EMPTY_, resourceSpecError{err}
+	}
+
+	current, exist, err := getStationResource(user.TenantName, stationName)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if !exist {
+		if dryRun {
+			return models.ResourceActionCreated, nil
+		}
+		return models.ResourceActionCreated, s.createStationResource(user, stationName, desired)
+	}
+
+	// these are baked into the underlying streams, changing them means recreating the station
+	immutable := map[string]bool{
+		"retention":                desired.RetentionType != current.RetentionType || desired.RetentionValue != current.RetentionValue,
+		"storage_type":             desired.StorageType != current.StorageType,
+		"replicas":                 desired.Replicas != current.Replicas,
+		"partitions_number":        desired.PartitionsNumber != current.PartitionsNumber,
+		"idempotency_window_in_ms": desired.IdempotencyWindow != current.IdempotencyWindow,
+		"tiered_storage_enabled":   desired.TieredStorageEnabled != current.TieredStorageEnabled,
+	}
+	for _, field := range []string{"retention", "storage_type", "replicas", "partitions_number", "idempotency_window_in_ms", "tiered_storage_enabled"} {
+		if immutable[field] {
+			return _EMPTY_, resourceSpecErrorf("%v of station %v can not be changed in place, the station has to be recreated", field, desired.Name)
+		}
+	}
+
+	schemaChanged := desired.SchemaName != current.SchemaName
+	dlsConfigChanged := *desired.DlsConfiguration != *current.DlsConfiguration
+	dlsStationChanged := desired.DlsStation != current.DlsStation
+	transformChanged := desired.MessageTransform != current.MessageTransform
+	tagsChanged := !equalResourceTags(desired.Tags, current.Tags)
+	if !schemaChanged && !dlsConfigChanged && !dlsStationChanged && !transformChanged && !tagsChanged {
+		return models.ResourceActionUnchanged, nil
+	}
+	if dryRun {
+		return models.ResourceActionUpdated, nil
+	}
+
+	_, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if schemaChanged {
+		if desired.SchemaName == _EMPTY_ {
+			err = removeSchemaFromStation(s, stationName, true, user.TenantName)
+		} else {
+			err = s.attachSchemaToStationResource(user, stationName, desired.SchemaName)
+		}
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	if dlsConfigChanged {
+		err = db.UpdateStationDlsConfig(station.Name, desired.DlsConfiguration.Poison, desired.DlsConfiguration.Schemaverse, station.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		s.SendUpdateToClients(models.SdkClientsUpdates{
+			StationName: stationName.Intern(),
+			Type:        schemaToDlsUpdateType,
+			Update:      desired.DlsConfiguration.Schemaverse,
+		})
+	}
+	if dlsStationChanged {
+		err = validateDlsStationResource(user.TenantName, desired.DlsStation)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		err = db.UpdateStationsDls([]string{station.Name}, desired.DlsStation, station.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	if transformChanged {
+		err = db.UpdateStationMessageTransform(station.Name, desired.MessageTransform, station.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	if tagsChanged {
+		err = syncResourceTags("station", station.ID, station.TenantName, current.Tags, desired.Tags)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	return models.ResourceActionUpdated, nil
+}
+
+func validateDlsStationResource(tenantName, dlsStation string) error {
+	if dlsStation == _EMPTY_ {
+		return nil
+	}
+	exist, _, err := db.GetStationByName(dlsStation, tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return resourceSpecErrorf("dead-letter station %v does not exist, it has to be declared as well", dlsStation)
+	}
+	return nil
+}
+
+func (s *Server) attachSchemaToStationResource(user models.User, sn StationName, schemaName string) error {
+	exist, schema, err := db.GetSchemaByName(schemaName, user.TenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return resourceSpecErrorf("schema %v does not exist", schemaName)
+	}
+	schemaVersion, err := getActiveVersionBySchemaId(schema.ID)
+	if err != nil {
+		return err
+	}
+	err = db.AttachSchemaToStation(sn.Ext(), schema.Name, schemaVersion.VersionNumber, user.TenantName)
+	if err != nil {
+		return err
+	}
+	updateContent, err := generateSchemaUpdateInit(schema)
+	if err != nil {
+		return err
+	}
+	s.updateStationProducersOfSchemaChange(user.TenantName, sn, models.SchemaUpdate{
+		UpdateType: models.SchemaUpdateTypeInit,
+		Init:       *updateContent,
+	})
+	return nil
+}
+
+func (s *Server) createStationResource(user models.User, sn StationName, desired models.StationResource) error {
+	allowed, reloadNeeded, err := ValidateStationPermissions(user.Roles, sn.Ext(), user.TenantName, "write")
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return resourceSpecErrorf("user %v is not allowed to create station %v", user.Username, sn.Ext())
+	}
+	if reloadNeeded {
+		defer func() {
+			err := s.SendReloadSignal()
+			if err != nil {
+				s.Errorf("[tenant: %v][user: %v]createStationResource at SendReloadSignal: Station %v: %v", user.TenantName, user.Username, sn.Ext(), err.Error())
+			}
+		}()
+	}
+
+	stationsCount, err := db.CountStationsByTenant(user.TenantName)
+	if err != nil {
+		return err
+	}
+	canCreate, stationsLimit := ValidataUsageLimitOfFeature(user.TenantName, "feature-stations-limitation", stationsCount+1)
+	if !canCreate {
+		return resourceSpecErrorf("cannot create station (max amount of stations for this plan :%v)", stationsLimit)
+	}
+	canCreate, _ = ValidataUsageLimitOfFeature(user.TenantName, "feature-partitions-per-station", desired.PartitionsNumber)
+	if !canCreate {
+		return resourceSpecErrorf("this amount of partitions you are trying to create for a single station is not supported on your pricing plan")
+	}
+
+	schemaVersionNumber := 0
+	if desired.SchemaName != _EMPTY_ {
+		exist, schema, err := db.GetSchemaByName(desired.SchemaName, user.TenantName)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return resourceSpecErrorf("schema %v does not exist", desired.SchemaName)
+		}
+		if !ValidataAccessToFeature(user.TenantName, "feature-schemaverse-enforcement") {
+			return resourceSpecErrorf("cannot create station with schema enforcement, please upgrade your plan to enjoy this feature")
+		}
+		schemaVersion, err := getActiveVersionBySchemaId(schema.ID)
+		if err != nil {
+			return err
+		}
+		schemaVersionNumber = schemaVersion.VersionNumber
+	}
+	err = validateDlsStationResource(user.TenantName, desired.DlsStation)
+	if err != nil {
+		return err
+	}
+
+	partitionsList := make([]int, 0, desired.PartitionsNumber)
+	for p := 1; p <= desired.PartitionsNumber; p++ {
+		err = s.CreateStream(user.TenantName, sn, desired.RetentionType, desired.RetentionValue, desired.StorageType, desired.IdempotencyWindow, desired.Replicas, desired.TieredStorageEnabled, p, true, _EMPTY_)
+		if err != nil {
+			for _, partition := range partitionsList {
+				removeErr := s.RemoveStream(user.TenantName, fmt.Sprintf("%v$%v", sn.Intern(), partition), _EMPTY_)
+				if removeErr != nil {
+					s.Errorf("[tenant: %v][user: %v]createStationResource at RemoveStream: Station %v: %v", user.TenantName, user.Username, sn.Ext(), removeErr.Error())
+				}
+			}
+			if IsNatsErr(err, JSInsufficientResourcesErr) {
+				return resourceSpecErrorf("station %v can not be created, probably since replicas count is larger than the cluster size", sn.Ext())
+			}
+			return err
+		}
+		partitionsList = append(partitionsList, p)
+	}
+
+	newStation, rowsUpdated, err := db.InsertNewStation(sn.Ext(), user.ID, user.Username, desired.RetentionType, desired.RetentionValue, desired.StorageType, desired.Replicas, desired.SchemaName, schemaVersionNumber, desired.IdempotencyWindow, true, *desired.DlsConfiguration, desired.TieredStorageEnabled, user.TenantName, partitionsList, 2, desired.DlsStation)
+	if err != nil {
+		return err
+	}
+	if rowsUpdated == 0 {
+		return resourceSpecErrorf("station %v already exists", sn.Ext())
+	}
+	if desired.MessageTransform != _EMPTY_ {
+		err = db.UpdateStationMessageTransform(newStation.Name, desired.MessageTransform, user.TenantName)
+		if err != nil {
+			return err
+		}
+	}
+	// no default tag here, it would show up as drift against the declared tags
+	err = syncResourceTags("station", newStation.ID, user.TenantName, nil, desired.Tags)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Station %v has been created by %v", sn.Ext(), user.Username)
+	s.notifyOperationalEvent(user.TenantName, StationCreatedTitle, message, StationLifecycleAlert)
+	publishBrokerEvent(user.TenantName, models.EventStationCreated, map[string]interface{}{"station_name": sn.Ext(), "created_by": user.Username})
+	return nil
+}
+
+func (s *Server) deleteStationResource(user models.User, sn StationName) error {
+	exist, station, err := db.GetStationByName(sn.Ext(), user.TenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return nil
+	}
+	err = removeStationResources(s, station, true, _EMPTY_)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteStationsByNames([]string{station.Name}, user.TenantName)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Station %v has been deleted by user %v", station.Name, user.Username)
+	s.notifyOperationalEvent(user.TenantName, StationDeletedTitle, message, StationLifecycleAlert)
+	publishBrokerEvent(user.TenantName, models.EventStationDeleted, map[string]interface{}{"station_name": station.Name, "deleted_by": user.Username})
+	s.SendUpdateToClients(models.SdkClientsUpdates{
+		StationName: sn.Intern(),
+		Type:        removeStationUpdateType,
+	})
+	return nil
+}
+
+// Schemas
+
+func getSchemaResource(tenantName, name string) (models.SchemaResource, bool, error) {
+	exist, schema, err := db.GetSchemaByName(name, tenantName)
+	if err != nil || !exist {
+		return models.SchemaResource{}, exist, err
+	}
+	activeVersion, err := getActiveVersionBySchemaId(schema.ID)
+	if err != nil {
+		return models.SchemaResource{}, false, err
+	}
+	tags, err := getResourceTags("schema", schema.ID)
+	if err != nil {
+		return models.SchemaResource{}, false, err
+	}
+	return models.SchemaResource{
+		Name:              schema.Name,
+		Type:              schema.Type,
+		SchemaContent:     activeVersion.SchemaContent,
+		MessageStructName: activeVersion.MessageStructName,
+		ActiveVersion:     activeVersion.VersionNumber,
+		Tags:              tags,
+	}, true, nil
+}
+
+func (s *Server) applySchemaResource(user models.User, desired models.SchemaResource, dryRun bool) (string, error) {
+	desired.Name = strings.ToLower(desired.Name)
+	desired.Type = strings.ToLower(desired.Type)
+	desired.Tags = normalizeResourceTags(desired.Tags)
+	err := validateSchemaName(desired.Name)
+	if err == nil {
+		err = validateSchemaType(desired.Type)
+	}
+	if err == nil && desired.Type == "protobuf" {
+		err = validateMessageStructName(desired.MessageStructName)
+	}
+	if err == nil {
+		err = validateSchemaContent(desired.SchemaContent, desired.Type)
+	}
+	if err != nil {
+		return _EMPTY_, resourceSpecError{err}
+	}
+
+	current, exist, err := getSchemaResource(user.TenantName, desired.Name)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if !exist {
+		if dryRun {
+			return models.ResourceActionCreated, nil
+		}
+		err = s.createNewSchema(CreateSchemaReq{
+			Name:              desired.Name,
+			Type:              desired.Type,
+			CreatedByUsername: user.Username,
+			SchemaContent:     desired.SchemaContent,
+			MessageStructName: desired.MessageStructName,
+		}, user.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		_, schema, err := db.GetSchemaByName(desired.Name, user.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		// createNewSchema always adds the default tag, the declared tags replace it
+		return models.ResourceActionCreated, syncResourceTags("schema", schema.ID, user.TenantName, []string{"default"}, desired.Tags)
+	}
+
+	if desired.Type != current.Type {
+		return _EMPTY_, resourceSpecErrorf("type of schema %v can not be changed in place, the schema has to be recreated", desired.Name)
+	}
+	contentChanged := desired.SchemaContent != current.SchemaContent || desired.MessageStructName != current.MessageStructName
+	tagsChanged := !equalResourceTags(desired.Tags, current.Tags)
+	if !contentChanged && !tagsChanged {
+		return models.ResourceActionUnchanged, nil
+	}
+	if dryRun {
+		return models.ResourceActionUpdated, nil
+	}
+
+	_, schema, err := db.GetSchemaByName(desired.Name, user.TenantName)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if contentChanged {
+		err = s.activateSchemaResourceVersion(user, schema, desired)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	if tagsChanged {
+		err = syncResourceTags("schema", schema.ID, user.TenantName, current.Tags, desired.Tags)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	return models.ResourceActionUpdated, nil
+}
+
+// activateSchemaResourceVersion reactivates an existing version with the desired content, or creates a new one,
+// so flipping a definition back and forth does not keep adding versions
+func (s *Server) activateSchemaResourceVersion(user models.User, schema models.Schema, desired models.SchemaResource) error {
+	versions, err := getSchemaVersionsBySchemaId(schema.ID)
+	if err != nil {
+		return err
+	}
+	versionNumber := 0
+	activeVersion := 0
+	for _, version := range versions {
+		if versionNumber == 0 && version.SchemaContent == desired.SchemaContent && version.MessageStructName == desired.MessageStructName {
+			versionNumber = version.VersionNumber
+		}
+		if version.Active {
+			activeVersion = version.VersionNumber
+		}
+	}
+
+	if versionNumber == 0 {
+		newSchemaVersion, err := insertNextSchemaVersion(user, schema, desired.SchemaContent, desired.MessageStructName, _EMPTY_)
+		if err != nil {
+			var descriptorErr schemaDescriptorError
+			if errors.As(err, &descriptorErr) || errors.Is(err, ErrSchemaVersionContention) {
+				return resourceSpecError{err}
+			}
+			return err
+		}
+		versionNumber = newSchemaVersion.VersionNumber
+	}
+
+	err = db.UpdateSchemaActiveVersion(schema.ID, versionNumber, activeVersion)
+	if errors.Is(err, db.ErrSchemaActiveVersionConflict) || errors.Is(err, db.ErrSchemaVersionNotFound) {
+		return resourceSpecError{err}
+	}
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Version %v of schema %v has been activated by user %v", versionNumber, schema.Name, user.Username)
+	s.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+	publishBrokerEvent(user.TenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": schema.Name, "version_number": versionNumber, "activated_by": user.Username})
+	return nil
+}
+
+func (s *Server) deleteSchemaResource(user models.User, name string) error {
+	exist, schema, err := db.GetSchemaByName(name, user.TenantName)
+	if err != nil || !exist {
+		return err
+	}
+	stationNames, err := db.GetStationNamesUsingSchema(schema.Name, user.TenantName)
+	if err != nil {
+		return err
+	}
+	err = db.FindAndDeleteSchema([]int{schema.ID})
+	if err != nil {
+		return err
+	}
+	notifyStationsOfSchemaRemoval(s, stationNames, user.TenantName)
+	s.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, fmt.Sprintf("Schema %v has been deleted by user %v", schema.Name, user.Username), SchemaChangeAlert)
+	return nil
+}
+
+// Users
+
+func getUserResource(tenantName, username string) (models.UserResource, bool, error) {
+	exist, user, err := db.GetUserByUsername(username, tenantName)
+	if err != nil || !exist {
+		return models.UserResource{}, exist, err
+	}
+	return models.UserResource{
+		Username:    user.Username,
+		UserType:    user.UserType,
+		FullName:    user.FullName,
+		Team:        user.Team,
+		Position:    user.Position,
+		Description: user.Description,
+		AvatarId:    user.AvatarId,
+	}, true, nil
+}
+
+func normalizeUserResource(desired *models.UserResource) error {
+	desired.Username = strings.ToLower(desired.Username)
+	desired.UserType = strings.ToLower(desired.UserType)
+	desired.Team = strings.ToLower(desired.Team)
+	desired.Position = strings.ToLower(desired.Position)
+	desired.FullName = strings.ToLower(desired.FullName)
+	desired.Description = strings.ToLower(desired.Description)
+	if desired.UserType == "application" {
+		desired.FullName = _EMPTY_
+	}
+	if desired.AvatarId <= 0 {
+		desired.AvatarId = 1
+	}
+	err := validateUsername(desired.Username)
+	if err == nil {
+		err = validateUserType(desired.UserType)
+	}
+	if err == nil {
+		err = validateUserTeam(desired.Team)
+	}
+	if err == nil {
+		err = validateUserPosition(desired.Position)
+	}
+	if err == nil {
+		err = validateUserFullName(desired.FullName)
+	}
+	if err == nil {
+		err = validateUserDescription(desired.Description)
+	}
+	if err == nil && desired.Password != _EMPTY_ {
+		err = validatePassword(desired.Password)
+	}
+	return err
+}
+
+// storedUserPassword returns the value kept in the users table for a password, management users
+// keep a bcrypt hash and application users keep the encrypted password when user/pass auth is on
+func storedUserPassword(userType, password string) (string, error) {
+	if userType == "management" {
+		hashedPwd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		return string(hashedPwd), err
+	}
+	if configuration.USER_PASS_BASED_AUTH {
+		return EncryptAES([]byte(password))
+	}
+	return _EMPTY_, nil
+}
+
+func userPasswordMatches(user models.User, password string) bool {
+	if user.UserType == "management" {
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+	}
+	if !configuration.USER_PASS_BASED_AUTH {
+		return true
+	}
+	decrypted, err := DecryptAES(getAESKey(), user.Password)
+	return err == nil && decrypted == password
+}
+
+func (s *Server) applyUserResource(user models.User, desired models.UserResource, dryRun bool) (string, error) {
+	err := normalizeUserResource(&desired)
+	if err != nil {
+		return _EMPTY_, resourceSpecError{err}
+	}
+
+	exist, existingUser, err := db.GetUserByUsername(desired.Username, user.TenantName)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if !exist {
+		if desired.Password == _EMPTY_ {
+			return _EMPTY_, resourceSpecErrorf("password was not provided for user %v", desired.Username)
+		}
+		if dryRun {
+			return models.ResourceActionCreated, nil
+		}
+		return models.ResourceActionCreated, s.createUserResource(user, desired)
+	}
+
+	if existingUser.UserType == "root" {
+		return _EMPTY_, resourceSpecErrorf("the root user can not be managed as a resource")
+	}
+	if desired.UserType != existingUser.UserType {
+		return _EMPTY_, resourceSpecErrorf("user_type of user %v can not be changed in place, the user has to be recreated", desired.Username)
+	}
+	current, _, err := getUserResource(user.TenantName, desired.Username)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	passwordChanged := desired.Password != _EMPTY_ && !userPasswordMatches(existingUser, desired.Password)
+	desiredProfile := desired
+	desiredProfile.Password = _EMPTY_
+	profileChanged := desiredProfile != current
+	if !passwordChanged && !profileChanged {
+		return models.ResourceActionUnchanged, nil
+	}
+	if passwordChanged && user.UserType != "root" && user.Username != existingUser.Username {
+		return _EMPTY_, resourceSpecErrorf("password of user %v can be changed only by the user or the root user", desired.Username)
+	}
+	if dryRun {
+		return models.ResourceActionUpdated, nil
+	}
+
+	if profileChanged {
+		err = db.UpdateUserProfile(desired.Username, desired.FullName, desired.Team, desired.Position, desired.Description, desired.AvatarId, user.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+	}
+	if passwordChanged {
+		password, err := storedUserPassword(existingUser.UserType, desired.Password)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		err = db.ChangeUserPassword(desired.Username, password, user.TenantName)
+		if err != nil {
+			return _EMPTY_, err
+		}
+		if existingUser.UserType == "application" && configuration.USER_PASS_BASED_AUTH {
+			err = storeScramCredential(desired.Username, user.TenantName, scramPasswordSecret, desired.Password)
+			if err != nil {
+				return _EMPTY_, err
+			}
+			err = s.SendReloadSignal()
+			if err != nil {
+				return _EMPTY_, err
+			}
+		}
+	}
+	_, updatedUser, err := memphis_cache.GetUser(desired.Username, user.TenantName, true)
+	if err != nil {
+		s.Errorf("[tenant: %v][user: %v]applyUserResource at refreshing the user cache: %v", user.TenantName, user.Username, err.Error())
+	} else if err = memphis_cache.SetUser(updatedUser); err != nil {
+		s.Errorf("[tenant: %v][user: %v]applyUserResource at writing to the user cache error: %v", user.TenantName, user.Username, err.Error())
+	}
+	return models.ResourceActionUpdated, nil
+}
+
+func (s *Server) createUserResource(user models.User, desired models.UserResource) error {
+	password, err := storedUserPassword(desired.UserType, desired.Password)
+	if err != nil {
+		return err
+	}
+	newUser, err := db.CreateUser(desired.Username, desired.UserType, password, desired.FullName, false, desired.AvatarId, user.TenantName, false, desired.Team, desired.Position, user.Username, desired.Description)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exist") {
+			return resourceSpecError{err}
+		}
+		return err
+	}
+	err = memphis_cache.SetUser(newUser)
+	if err != nil {
+		s.Errorf("[tenant: %v][user: %v]createUserResource at writing to the user cache error: %v", user.TenantName, user.Username, err.Error())
+	}
+	if desired.UserType == "application" && configuration.USER_PASS_BASED_AUTH {
+		err = storeScramCredential(newUser.Username, user.TenantName, scramPasswordSecret, desired.Password)
+		if err != nil {
+			return err
+		}
+		return s.SendReloadSignal()
+	}
+	return nil
+}
+
+func (s *Server) deleteUserResource(user models.User, username string) error {
+	if user.Username == username {
+		return resourceSpecErrorf("you can not remove your own user")
+	}
+	exist, userToRemove, err := memphis_cache.GetUser(username, user.TenantName, false)
+	if err != nil || !exist {
+		return err
+	}
+	if userToRemove.UserType == "root" {
+		return resourceSpecErrorf("you can not remove the root user")
+	}
+	err = updateDeletedUserResources(userToRemove)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteUser(username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteScramCredentialsByUsername(username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	err = db.RemoveRoleAndPermissions(userToRemove.Roles, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	SendUserDeleteCacheUpdate([]string{username}, user.TenantName)
+	if userToRemove.UserType == "application" && configuration.USER_PASS_BASED_AUTH {
+		return s.SendReloadSignal()
+	}
+	return nil
+}
+
+// Handlers
+
+// respondWithResource writes the current state of a resource along with its etag
+func respondWithResource(c *gin.Context, action string, resource interface{}) {
+	etag := resourceETag(resource)
+	c.Header("ETag", etag)
+	c.IndentedJSON(200, gin.H{"action": action, "etag": etag, "resource": resource})
+}
+
+func abortWithResourceError(c *gin.Context, user models.User, funcName string, err error) {
+	var specErr resourceSpecError
+	if errors.As(err, &specErr) {
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	serv.Errorf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, err.Error())
+	c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+}
+
+// putResource runs a create-or-update under the request preconditions and answers with the resulting state
+func putResource(c *gin.Context, user models.User, funcName, kind, name string, get func() (interface{}, bool, error), apply func() (string, error)) {
+	current, exist, err := get()
+	if err != nil {
+		abortWithResourceError(c, user, funcName+" at get", err)
+		return
+	}
+	etag := _EMPTY_
+	if exist {
+		etag = resourceETag(current)
+	}
+	if !checkResourcePreconditions(c, etag) {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("%v %v does not match the requested version", kind, name)})
+		return
+	}
+
+	action, err := apply()
+	if err != nil {
+		abortWithResourceError(c, user, funcName, err)
+		return
+	}
+	resource, _, err := get()
+	if err != nil {
+		abortWithResourceError(c, user, funcName+" at get", err)
+		return
+	}
+	if action != models.ResourceActionUnchanged {
+		message := fmt.Sprintf("%v %v has been %v by user %v", kind, name, action, user.Username)
+		serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+	respondWithResource(c, action, resource)
+}
+
+func getResource(c *gin.Context, user models.User, funcName, kind, name string, get func() (interface{}, bool, error)) {
+	resource, exist, err := get()
+	if err != nil {
+		abortWithResourceError(c, user, funcName, err)
+		return
+	}
+	if !exist {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("%v %v does not exist", kind, name)})
+		return
+	}
+	etag := resourceETag(resource)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != _EMPTY_ && etagListContains(ifNoneMatch, etag) {
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+	respondWithResource(c, models.ResourceActionUnchanged, resource)
+}
+
+func deleteResource(c *gin.Context, user models.User, funcName, kind, name string, get func() (interface{}, bool, error), remove func() error) {
+	current, exist, err := get()
+	if err != nil {
+		abortWithResourceError(c, user, funcName+" at get", err)
+		return
+	}
+	etag := _EMPTY_
+	if exist {
+		etag = resourceETag(current)
+	}
+	if !checkResourcePreconditions(c, etag) {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"message": fmt.Sprintf("%v %v does not match the requested version", kind, name)})
+		return
+	}
+	// deleting a missing resource is not an error, so a retried delete converges
+	if !exist {
+		c.IndentedJSON(200, gin.H{"action": models.ResourceActionUnchanged})
+		return
+	}
+	err = remove()
+	if err != nil {
+		abortWithResourceError(c, user, funcName, err)
+		return
+	}
+	message := fmt.Sprintf("%v %v has been deleted by user %v", kind, name, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	c.IndentedJSON(200, gin.H{"action": models.ResourceActionDeleted})
+}
+
+func resourceUser(c *gin.Context, funcName string) (models.User, bool) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("%v at getUserDetailsFromMiddleware: %v", funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return models.User{}, false
+	}
+	return user, true
+}
+
+func stationResourceGetter(c *gin.Context, user models.User) (StationName, func() (interface{}, bool, error), bool) {
+	stationName, err := StationNameFromStr(c.Param("name"))
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]StationResource at StationNameFromStr: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return StationName{}, nil, false
+	}
+	return stationName, func() (interface{}, bool, error) { return getStationResource(user.TenantName, stationName) }, true
+}
+
+func (rh ResourcesHandler) GetStationResource(c *gin.Context) {
+	user, ok := resourceUser(c, "GetStationResource")
+	if !ok {
+		return
+	}
+	stationName, get, ok := stationResourceGetter(c, user)
+	if !ok {
+		return
+	}
+	getResource(c, user, "GetStationResource", "Station", stationName.Ext(), get)
+}
+
+func (rh ResourcesHandler) PutStationResource(c *gin.Context) {
+	var body models.StationResource
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "PutStationResource")
+	if !ok {
+		return
+	}
+	stationName, get, ok := stationResourceGetter(c, user)
+	if !ok {
+		return
+	}
+	body.Name = stationName.Ext()
+	putResource(c, user, "PutStationResource", "Station", stationName.Ext(), get, func() (string, error) {
+		return serv.applyStationResource(user, body, false)
+	})
+}
+
+func (rh ResourcesHandler) DeleteStationResource(c *gin.Context) {
+	user, ok := resourceUser(c, "DeleteStationResource")
+	if !ok {
+		return
+	}
+	stationName, get, ok := stationResourceGetter(c, user)
+	if !ok {
+		return
+	}
+	deleteResource(c, user, "DeleteStationResource", "Station", stationName.Ext(), get, func() error {
+		return serv.deleteStationResource(user, stationName)
+	})
+}
+
+func (rh ResourcesHandler) GetSchemaResource(c *gin.Context) {
+	user, ok := resourceUser(c, "GetSchemaResource")
+	if !ok {
+		return
+	}
+	name := strings.ToLower(c.Param("name"))
+	getResource(c, user, "GetSchemaResource", "Schema", name, func() (interface{}, bool, error) { return getSchemaResource(user.TenantName, name) })
+}
+
+func (rh ResourcesHandler) PutSchemaResource(c *gin.Context) {
+	var body models.SchemaResource
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "PutSchemaResource")
+	if !ok {
+		return
+	}
+	body.Name = strings.ToLower(c.Param("name"))
+	get := func() (interface{}, bool, error) { return getSchemaResource(user.TenantName, body.Name) }
+	putResource(c, user, "PutSchemaResource", "Schema", body.Name, get, func() (string, error) {
+		return serv.applySchemaResource(user, body, false)
+	})
+}
+
+func (rh ResourcesHandler) DeleteSchemaResource(c *gin.Context) {
+	user, ok := resourceUser(c, "DeleteSchemaResource")
+	if !ok {
+		return
+	}
+	name := strings.ToLower(c.Param("name"))
+	get := func() (interface{}, bool, error) { return getSchemaResource(user.TenantName, name) }
+	deleteResource(c, user, "DeleteSchemaResource", "Schema", name, get, func() error {
+		return serv.deleteSchemaResource(user, name)
+	})
+}
+
+func (rh ResourcesHandler) GetUserResource(c *gin.Context) {
+	user, ok := resourceUser(c, "GetUserResource")
+	if !ok {
+		return
+	}
+	username := strings.ToLower(c.Param("name"))
+	getResource(c, user, "GetUserResource", "User", username, func() (interface{}, bool, error) { return getUserResource(user.TenantName, username) })
+}
+
+func (rh ResourcesHandler) PutUserResource(c *gin.Context) {
+	var body models.UserResource
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "PutUserResource")
+	if !ok {
+		return
+	}
+	body.Username = strings.ToLower(c.Param("name"))
+	get := func() (interface{}, bool, error) { return getUserResource(user.TenantName, body.Username) }
+	putResource(c, user, "PutUserResource", "User", body.Username, get, func() (string, error) {
+		return serv.applyUserResource(user, body, false)
+	})
+}
+
+func (rh ResourcesHandler) DeleteUserResource(c *gin.Context) {
+	user, ok := resourceUser(c, "DeleteUserResource")
+	if !ok {
+		return
+	}
+	username := strings.ToLower(c.Param("name"))
+	get := func() (interface{}, bool, error) { return getUserResource(user.TenantName, username) }
+	deleteResource(c, user, "DeleteUserResource", "User", username, get, func() error {
+		return serv.deleteUserResource(user, username)
+	})
+}
+
+// ApplyResources converges schemas, then stations, then users to the given state, resources which are not listed are left untouched
+func (rh ResourcesHandler) ApplyResources(c *gin.Context) {
+	var body models.ApplyResourcesSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "ApplyResources")
+	if !ok {
+		return
+	}
+
+	// stations pointing at a dead-letter station go last so the dead-letter station exists by then
+	stations := make([]models.StationResource, 0, len(body.Stations))
+	for _, station := range body.Stations {
+		if station.DlsStation == _EMPTY_ {
+			stations = append(stations, station)
+		}
+	}
+	for _, station := range body.Stations {
+		if station.DlsStation != _EMPTY_ {
+			stations = append(stations, station)
+		}
+	}
+
+	results := make([]models.ResourceApplyResult, 0, len(body.Schemas)+len(stations)+len(body.Users))
+	failed := false
+	record := func(kind, name string, action string, err error, get func() (interface{}, bool, error)) {
+		result := models.ResourceApplyResult{Kind: kind, Name: name, Action: action}
+		if err != nil {
+			failed = true
+			result.Action = models.ResourceActionFailed
+			var specErr resourceSpecError
+			if errors.As(err, &specErr) {
+				result.Error = err.Error()
+			} else {
+				serv.Errorf("[tenant: %v][user: %v]ApplyResources: %v %v: %v", user.TenantName, user.Username, kind, name, err.Error())
+				result.Error = "Server error"
+			}
+		} else if !body.DryRun {
+			resource, exist, err := get()
+			if err == nil && exist {
+				result.ETag = resourceETag(resource)
+			}
+			if action != models.ResourceActionUnchanged {
+				message := fmt.Sprintf("%v %v has been %v by user %v", kind, name, action, user.Username)
+				serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+				createAuditLogFromRequest(c, user, _EMPTY_, message)
+			}
+		}
+		results = append(results, result)
+	}
+
+	for _, schema := range body.Schemas {
+		name := strings.ToLower(schema.Name)
+		action, err := serv.applySchemaResource(user, schema, body.DryRun)
+		record("Schema", name, action, err, func() (interface{}, bool, error) { return getSchemaResource(user.TenantName, name) })
+	}
+	for _, station := range stations {
+		stationName, err := StationNameFromStr(station.Name)
+		if err != nil {
+			record("Station", station.Name, _EMPTY_, resourceSpecError{err}, nil)
+			continue
+		}
+		action, err := serv.applyStationResource(user, station, body.DryRun)
+		record("Station", stationName.Ext(), action, err, func() (interface{}, bool, error) { return getStationResource(user.TenantName, stationName) })
+	}
+	for _, userResource := range body.Users {
+		username := strings.ToLower(userResource.Username)
+		action, err := serv.applyUserResource(user, userResource, body.DryRun)
+		record("User", username, action, err, func() (interface{}, bool, error) { return getUserResource(user.TenantName, username) })
+	}
+
+	status := 200
+	if failed {
+		status = SHOWABLE_ERROR_STATUS_CODE
+	}
+	c.IndentedJSON(status, gin.H{"dry_run": body.DryRun, "results": results})
+}
+
+// fillInventoryHealth aggregates the clients per station and sets the health flags,
+// stream existence is checked against the broker after the metadata snapshot was taken
+func fillInventoryHealth(inventory *models.Inventory, streams []*StreamInfo) {
+	streamExists := make(map[string]bool, len(streams))
+	for _, stream := range streams {
+		streamExists[stream.Config.Name] = true
+	}
+	schemaExists := make(map[string]bool, len(inventory.Schemas))
+	for _, schema := range inventory.Schemas {
+		schemaExists[schema.Name] = true
+	}
+	stationExists := make(map[string]bool, len(inventory.Stations))
+	for _, station := range inventory.Stations {
+		stationExists[station.Name] = true
+	}
+
+	activeProducers := make(map[string]int)
+	for _, p := range inventory.Producers {
+		inventory.Counts.Producers++
+		if p.IsActive {
+			inventory.Counts.ActiveProducers++
+			activeProducers[p.StationName]++
+		}
+	}
+	activeConsumers := make(map[string]int)
+	cgs := make(map[string]map[string]bool)
+	for _, c := range inventory.Consumers {
+		inventory.Counts.Consumers++
+		if c.IsActive {
+			inventory.Counts.ActiveConsumers++
+			activeConsumers[c.StationName]++
+		}
+		if cgs[c.StationName] == nil {
+			cgs[c.StationName] = make(map[string]bool)
+		}
+		cgs[c.StationName][c.ConsumersGroup] = true
+	}
+
+	for i := range inventory.Stations {
+		station := &inventory.Stations[i]
+		station.ActiveProducers = activeProducers[station.Name]
+		station.ActiveConsumers = activeConsumers[station.Name]
+		station.ConsumerGroups = len(cgs[station.Name])
+		station.Issues = []string{}
+		stationName, err := StationNameFromStr(station.Name)
+		if err != nil {
+			station.Issues = append(station.Issues, fmt.Sprintf("invalid station name: %v", err.Error()))
+		} else if len(station.PartitionsList) == 0 {
+			if !streamExists[stationName.Intern()] {
+				station.Issues = append(station.Issues, "station stream is missing")
+			}
+		} else {
+			for _, p := range station.PartitionsList {
+				if !streamExists[fmt.Sprintf("%v$%v", stationName.Intern(), p)] {
+					station.Issues = append(station.Issues, fmt.Sprintf("stream of partition %v is missing", p))
+				}
+			}
+		}
+		if station.SchemaName != _EMPTY_ && !schemaExists[station.SchemaName] {
+			station.Issues = append(station.Issues, fmt.Sprintf("attached schema %v does not exist", station.SchemaName))
+		}
+		if station.DlsStation != _EMPTY_ && !stationExists[station.DlsStation] {
+			station.Issues = append(station.Issues, fmt.Sprintf("dls station %v does not exist", station.DlsStation))
+		}
+		station.Healthy = len(station.Issues) == 0
+		if !station.Healthy {
+			inventory.Counts.UnhealthyStations++
+		}
+	}
+
+	for i := range inventory.Integrations {
+		integration := &inventory.Integrations[i]
+		integration.Issues = []string{}
+		if !integration.IsValid {
+			integration.Issues = append(integration.Issues, "integration credentials are not valid")
+		}
+		integration.Healthy = len(integration.Issues) == 0
+	}
+
+	inventory.Counts.Stations = len(inventory.Stations)
+	inventory.Counts.Schemas = len(inventory.Schemas)
+	inventory.Counts.Users = len(inventory.Users)
+	inventory.Counts.Integrations = len(inventory.Integrations)
+}
+
+func (rh ResourcesHandler) GetInventory(c *gin.Context) {
+	user, ok := resourceUser(c, "GetInventory")
+	if !ok {
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]GetInventory: only admin users can read the resources inventory", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only admin users can read the resources inventory"})
+		return
+	}
+
+	inventory, err := db.GetInventory(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetInventory at GetInventory: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	inventory.GeneratedAt = time.Now()
+
+	streams, err := serv.memphisAllStreamsInfo(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetInventory at memphisAllStreamsInfo: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	fillInventoryHealth(&inventory, streams)
+
+	c.IndentedJSON(200, inventory)
+}
@@ -0,0 +1,389 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	watchManagedResourcesPollInterval = 5 * time.Second
+	watchManagedResourcesMaxTimeout   = 60 * time.Second
+)
+
+// resourceKindOps binds a kind of managed resource to the declarative resource functions
+type resourceKindOps struct {
+	normalizeName func(name string) (string, error)
+	get           func(tenantName, name string) (interface{}, bool, error)
+	apply         func(user models.User, name string, spec json.RawMessage) (string, error)
+	remove        func(user models.User, name string) error
+}
+
+func getResourceKindOps(kind string) (resourceKindOps, error) {
+	switch kind {
+	case models.ResourceKindStation:
+		return resourceKindOps{
+			normalizeName: func(name string) (string, error) {
+				stationName, err := StationNameFromStr(name)
+				return stationName.Ext(), err
+			},
+			get: func(tenantName, name string) (interface{}, bool, error) {
+				stationName, err := StationNameFromStr(name)
+				if err != nil {
+					return nil, false, err
+				}
+				return getStationResource(tenantName, stationName)
+			},
+			apply: func(user models.User, name string, spec json.RawMessage) (string, error) {
+				var station models.StationResource
+				if err := json.Unmarshal(spec, &station); err != nil {
+					return _EMPTY_, resourceSpecError{err}
+				}
+				station.Name = name
+				return serv.applyStationResource(user, station, false)
+			},
+			remove: func(user models.User, name string) error {
+				stationName, err := StationNameFromStr(name)
+				if err != nil {
+					return err
+				}
+				return serv.deleteStationResource(user, stationName)
+			},
+		}, nil
+	case models.ResourceKindSchema:
+		return resourceKindOps{
+			normalizeName: func(name string) (string, error) { return strings.ToLower(name), nil },
+			get: func(tenantName, name string) (interface{}, bool, error) {
+				return getSchemaResource(tenantName, name)
+			},
+			apply: func(user models.User, name string, spec json.RawMessage) (string, error) {
+				var schema models.SchemaResource
+				if err := json.Unmarshal(spec, &schema); err != nil {
+					return _EMPTY_, resourceSpecError{err}
+				}
+				schema.Name = name
+				return serv.applySchemaResource(user, schema, false)
+			},
+			remove: func(user models.User, name string) error {
+				return serv.deleteSchemaResource(user, name)
+			},
+		}, nil
+	case models.ResourceKindUser:
+		return resourceKindOps{
+			normalizeName: func(name string) (string, error) { return strings.ToLower(name), nil },
+			get: func(tenantName, name string) (interface{}, bool, error) {
+				return getUserResource(tenantName, name)
+			},
+			apply: func(user models.User, name string, spec json.RawMessage) (string, error) {
+				var userResource models.UserResource
+				if err := json.Unmarshal(spec, &userResource); err != nil {
+					return _EMPTY_, resourceSpecError{err}
+				}
+				userResource.Username = name
+				return serv.applyUserResource(user, userResource, false)
+			},
+			remove: func(user models.User, name string) error {
+				return serv.deleteUserResource(user, name)
+			},
+		}, nil
+	}
+	return resourceKindOps{}, fmt.Errorf("unsupported resource kind %v, it can be one of the following station/schema/user", kind)
+}
+
+// resourceConflictError is returned when a managed resource is owned by someone else or has drifted
+type resourceConflictError struct {
+	error
+}
+
+// managedResourceStatus compares a managed resource with its live state, it has drifted when it was
+// changed or removed by anyone else since the last successful apply
+func managedResourceStatus(record models.ManagedResource) (models.ManagedResourceStatus, error) {
+	status := models.ManagedResourceStatus{ManagedResource: record}
+	ops, err := getResourceKindOps(record.Kind)
+	if err != nil {
+		return status, err
+	}
+	live, exist, err := ops.get(record.TenantName, record.Name)
+	if err != nil {
+		return status, err
+	}
+	if exist {
+		status.LiveETag = resourceETag(live)
+	}
+	status.Drifted = record.AppliedETag != _EMPTY_ && record.AppliedETag != status.LiveETag
+	return status, nil
+}
+
+// reconcileManagedResource applies a spec on behalf of a reconciler and records the outcome as the status of the resource,
+// resources which already exist are adopted by their first reconcile
+func reconcileManagedResource(user models.User, body models.ReconcileResourceSchema) (models.ManagedResourceStatus, string, error) {
+	kind := strings.ToLower(body.Kind)
+	ops, err := getResourceKindOps(kind)
+	if err != nil {
+		return models.ManagedResourceStatus{}, _EMPTY_, resourceSpecError{err}
+	}
+	name, err := ops.normalizeName(body.Name)
+	if err != nil {
+		return models.ManagedResourceStatus{}, _EMPTY_, resourceSpecError{err}
+	}
+
+	exist, record, err := db.GetManagedResource(kind, name, user.TenantName)
+	if err != nil {
+		return models.ManagedResourceStatus{}, _EMPTY_, err
+	}
+	if !exist {
+		record = models.ManagedResource{Kind: kind, Name: name, Owner: body.Owner, TenantName: user.TenantName}
+	}
+	if record.Owner != body.Owner && !body.Force {
+		status, _ := managedResourceStatus(record)
+		return status, _EMPTY_, resourceConflictError{fmt.Errorf("%v %v is managed by %v", kind, name, record.Owner)}
+	}
+	status, err := managedResourceStatus(record)
+	if err != nil {
+		return status, _EMPTY_, err
+	}
+
+	if status.Drifted && !body.Force {
+		message := fmt.Sprintf("%v %v was changed outside of %v after generation %v was applied", kind, name, record.Owner, record.ObservedGeneration)
+		record, err = db.UpsertManagedResource(kind, name, record.Owner, body.Generation, record.ObservedGeneration, record.AppliedETag, models.ManagedResourcePhaseConflict, message, user.TenantName)
+		if err != nil {
+			return status, _EMPTY_, err
+		}
+		status.ManagedResource = record
+		return status, _EMPTY_, resourceConflictError{errors.New(message)}
+	}
+	if exist && !status.Drifted && record.Owner == body.Owner && record.Phase == models.ManagedResourcePhaseSynced && record.ObservedGeneration == body.Generation {
+		return status, models.ResourceActionUnchanged, nil
+	}
+
+	action, err := ops.apply(user, name, body.Spec)
+	if err != nil {
+		message := "Server error"
+		var specErr resourceSpecError
+		if errors.As(err, &specErr) {
+			message = err.Error()
+		}
+		record, upsertErr := db.UpsertManagedResource(kind, name, body.Owner, body.Generation, record.ObservedGeneration, record.AppliedETag, models.ManagedResourcePhaseFailed, message, user.TenantName)
+		if upsertErr != nil {
+			serv.Errorf("[tenant: %v][user: %v]reconcileManagedResource at UpsertManagedResource: %v %v: %v", user.TenantName, user.Username, kind, name, upsertErr.Error())
+		} else {
+			status.ManagedResource = record
+		}
+		return status, _EMPTY_, err
+	}
+
+	live, _, err := ops.get(user.TenantName, name)
+	if err != nil {
+		return status, _EMPTY_, err
+	}
+	message := fmt.Sprintf("generation %v has been applied, %v %v was %v", body.Generation, kind, name, action)
+	record, err = db.UpsertManagedResource(kind, name, body.Owner, body.Generation, body.Generation, resourceETag(live), models.ManagedResourcePhaseSynced, message, user.TenantName)
+	if err != nil {
+		return status, _EMPTY_, err
+	}
+	status, err = managedResourceStatus(record)
+	return status, action, err
+}
+
+func (rh ResourcesHandler) ReconcileResource(c *gin.Context) {
+	var body models.ReconcileResourceSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "ReconcileResource")
+	if !ok {
+		return
+	}
+
+	status, action, err := reconcileManagedResource(user, body)
+	if err != nil {
+		var conflictErr resourceConflictError
+		var specErr resourceSpecError
+		switch {
+		case errors.As(err, &conflictErr):
+			serv.Warnf("[tenant: %v][user: %v]ReconcileResource: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": err.Error(), "status": status})
+		case errors.As(err, &specErr):
+			serv.Warnf("[tenant: %v][user: %v]ReconcileResource: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error(), "status": status})
+		default:
+			serv.Errorf("[tenant: %v][user: %v]ReconcileResource: %v %v: %v", user.TenantName, user.Username, body.Kind, body.Name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		}
+		return
+	}
+
+	if action != models.ResourceActionUnchanged {
+		message := fmt.Sprintf("%v %v has been %v by user %v on behalf of %v", status.Kind, status.Name, action, user.Username, status.Owner)
+		serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+	c.IndentedJSON(200, status)
+}
+
+func (rh ResourcesHandler) GetManagedResourceStatus(c *gin.Context) {
+	user, ok := resourceUser(c, "GetManagedResourceStatus")
+	if !ok {
+		return
+	}
+	kind := strings.ToLower(c.Query("kind"))
+	ops, err := getResourceKindOps(kind)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetManagedResourceStatus: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	name, err := ops.normalizeName(c.Query("name"))
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetManagedResourceStatus: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	exist, record, err := db.GetManagedResource(kind, name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetManagedResourceStatus at GetManagedResource: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": fmt.Sprintf("%v %v is not managed", kind, name)})
+		return
+	}
+	status, err := managedResourceStatus(record)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetManagedResourceStatus at managedResourceStatus: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, status)
+}
+
+func listManagedResourceStatuses(tenantName, owner string) (models.WatchManagedResourcesResponse, error) {
+	records, err := db.GetManagedResourcesByTenant(tenantName, owner)
+	if err != nil {
+		return models.WatchManagedResourcesResponse{}, err
+	}
+	statuses := make([]models.ManagedResourceStatus, 0, len(records))
+	for _, record := range records {
+		status, err := managedResourceStatus(record)
+		if err != nil {
+			return models.WatchManagedResourcesResponse{}, err
+		}
+		statuses = append(statuses, status)
+	}
+	return models.WatchManagedResourcesResponse{ResourceVersion: strings.Trim(resourceETag(statuses), `"`), Resources: statuses}, nil
+}
+
+// WatchManagedResources lists the managed resources along with a resource version, when the caller passes the version it
+// already has the request is held until the list changes, for example by a manual change in the UI, or the timeout passes
+func (rh ResourcesHandler) WatchManagedResources(c *gin.Context) {
+	user, ok := resourceUser(c, "WatchManagedResources")
+	if !ok {
+		return
+	}
+	owner := c.Query("owner")
+	resourceVersion := c.Query("resource_version")
+	timeout := watchManagedResourcesMaxTimeout
+	if timeoutSec, err := strconv.Atoi(c.Query("timeout_sec")); err == nil && timeoutSec >= 0 && time.Duration(timeoutSec)*time.Second < timeout {
+		timeout = time.Duration(timeoutSec) * time.Second
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		list, err := listManagedResourceStatuses(user.TenantName, owner)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]WatchManagedResources at listManagedResourceStatuses: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if resourceVersion == _EMPTY_ || list.ResourceVersion != resourceVersion || !time.Now().Add(watchManagedResourcesPollInterval).Before(deadline) {
+			c.IndentedJSON(200, list)
+			return
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-time.After(watchManagedResourcesPollInterval):
+		}
+	}
+}
+
+// RemoveManagedResource deletes a managed resource and stops tracking it, it is meant to run from the finalizer of the custom resource
+func (rh ResourcesHandler) RemoveManagedResource(c *gin.Context) {
+	var body models.RemoveManagedResourceSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := resourceUser(c, "RemoveManagedResource")
+	if !ok {
+		return
+	}
+
+	kind := strings.ToLower(body.Kind)
+	ops, err := getResourceKindOps(kind)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RemoveManagedResource: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	name, err := ops.normalizeName(body.Name)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RemoveManagedResource: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, record, err := db.GetManagedResource(kind, name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveManagedResource at GetManagedResource: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if exist && record.Owner != body.Owner {
+		errMsg := fmt.Sprintf("%v %v is managed by %v", kind, name, record.Owner)
+		serv.Warnf("[tenant: %v][user: %v]RemoveManagedResource: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": errMsg})
+		return
+	}
+	// only resources this owner manages are deleted, anything else is left in place
+	if exist {
+		err = ops.remove(user, name)
+		if err != nil {
+			abortWithResourceError(c, user, "RemoveManagedResource", err)
+			return
+		}
+		err = db.DeleteManagedResource(kind, name, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RemoveManagedResource at DeleteManagedResource: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		message := fmt.Sprintf("%v %v has been deleted by user %v on behalf of %v", kind, name, user.Username, body.Owner)
+		serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+	c.IndentedJSON(200, gin.H{})
+}
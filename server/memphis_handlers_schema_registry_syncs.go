@@ -0,0 +1,227 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SchemaRegistrySyncsHandler struct{}
+
+func (sh SchemaRegistrySyncsHandler) CreateSchemaRegistrySync(c *gin.Context) {
+	var body models.CreateSchemaRegistrySyncSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateSchemaRegistrySync at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateName(body.Name, "schema registry sync")
+	if err == nil {
+		err = validateSchemaRegistrySync(&body)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateSchemaRegistrySync: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	encryptedPassword := _EMPTY_
+	if body.Password != _EMPTY_ {
+		encryptedPassword, err = EncryptAES([]byte(body.Password))
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateSchemaRegistrySync at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+	registrySync, err := db.InsertSchemaRegistrySync(body.Name, body.Type, body.Url, body.Username, encryptedPassword, body.SubjectPrefix, body.ConflictPolicy, body.PushBack, body.IntervalSec, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("Schema registry sync %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateSchemaRegistrySync: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateSchemaRegistrySync at InsertSchemaRegistrySync: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleSchemaRegistrySyncs(registrySync.ID)
+
+	serv.Noticef("[tenant: %v][user: %v]Schema registry sync %v has been created", user.TenantName, user.Username, registrySync.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Schema registry sync %v has been created by user %v", registrySync.Name, user.Username))
+	c.IndentedJSON(200, registrySync)
+}
+
+func (sh SchemaRegistrySyncsHandler) GetSchemaRegistrySyncs(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetSchemaRegistrySyncs at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	registrySyncs, err := db.GetSchemaRegistrySyncsByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetSchemaRegistrySyncs at GetSchemaRegistrySyncsByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	extendedSyncs := make([]models.ExtendedSchemaRegistrySync, 0, len(registrySyncs))
+	for _, registrySync := range registrySyncs {
+		extendedSyncs = append(extendedSyncs, models.ExtendedSchemaRegistrySync{SchemaRegistrySync: registrySync, Status: getSchemaRegistrySyncStatus(registrySync.ID)})
+	}
+	c.IndentedJSON(200, extendedSyncs)
+}
+
+func (sh SchemaRegistrySyncsHandler) UpdateSchemaRegistrySync(c *gin.Context) {
+	var body models.UpdateSchemaRegistrySyncSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateSchemaRegistrySync at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, registrySync, err := db.GetSchemaRegistrySyncById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateSchemaRegistrySync at GetSchemaRegistrySyncById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Schema registry sync %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateSchemaRegistrySync: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.Enabled != nil {
+		registrySync.Enabled = *body.Enabled
+	}
+	if body.IntervalSec != nil {
+		registrySync.IntervalSec = *body.IntervalSec
+	}
+	if body.ConflictPolicy != nil {
+		registrySync.ConflictPolicy = *body.ConflictPolicy
+	}
+	if body.PushBack != nil {
+		registrySync.PushBack = *body.PushBack
+	}
+	err = validateSchemaRegistrySyncInterval(registrySync.IntervalSec)
+	if err == nil {
+		err = validateSchemaRegistryConflictPolicy(registrySync.ConflictPolicy)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateSchemaRegistrySync: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	registrySync, err = db.UpdateSchemaRegistrySync(body.ID, registrySync.Enabled, registrySync.IntervalSec, registrySync.ConflictPolicy, registrySync.PushBack, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateSchemaRegistrySync at UpdateSchemaRegistrySync: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleSchemaRegistrySyncs(0)
+
+	serv.Noticef("[tenant: %v][user: %v]Schema registry sync %v has been updated", user.TenantName, user.Username, registrySync.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Schema registry sync %v has been updated by user %v", registrySync.Name, user.Username))
+	c.IndentedJSON(200, registrySync)
+}
+
+func (sh SchemaRegistrySyncsHandler) RemoveSchemaRegistrySync(c *gin.Context) {
+	var body models.RemoveSchemaRegistrySyncSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveSchemaRegistrySync at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, registrySync, err := db.GetSchemaRegistrySyncById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveSchemaRegistrySync at GetSchemaRegistrySyncById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Schema registry sync %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveSchemaRegistrySync: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	_, err = db.DeleteSchemaRegistrySync(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveSchemaRegistrySync at DeleteSchemaRegistrySync: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	go serv.scheduleSchemaRegistrySyncs(0)
+
+	serv.Noticef("[tenant: %v][user: %v]Schema registry sync %v has been removed", user.TenantName, user.Username, registrySync.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Schema registry sync %v has been removed by user %v", registrySync.Name, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (sh SchemaRegistrySyncsHandler) RunSchemaRegistrySync(c *gin.Context) {
+	var body models.RunSchemaRegistrySyncSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RunSchemaRegistrySync at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, registrySync, err := db.GetSchemaRegistrySyncById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RunSchemaRegistrySync at GetSchemaRegistrySyncById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist || !registrySync.Enabled {
+		errMsg := fmt.Sprintf("Schema registry sync %v does not exist or is disabled", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RunSchemaRegistrySync: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	go serv.scheduleSchemaRegistrySyncs(registrySync.ID)
+	c.IndentedJSON(200, gin.H{})
+}
@@ -41,10 +41,12 @@ const (
 	schemaObjectName                    = "Schema"
 	SCHEMA_VALIDATION_ERROR_STATUS_CODE = 555
 	schemaUpdatesSubjectTemplate        = "$memphis_schema_updates_%s"
+	schemaVersionAllocationAttempts     = 5
 )
 
 var (
-	ErrNoSchema = errors.New("no schemas found")
+	ErrNoSchema                = errors.New("no schemas found")
+	ErrSchemaVersionContention = errors.New("the schema is being updated concurrently, please try again")
 )
 
 func validateProtobufContent(schemaContent string) error {
@@ -185,6 +187,40 @@ func validateMessageStructName(messageStructName string) error {
 	return nil
 }
 
+// schemaDescriptorError marks a new version whose content could not be compiled into a descriptor
+type schemaDescriptorError struct {
+	error
+}
+
+// insertNextSchemaVersion stores the content under the next free version number of the schema. Brokers share no
+// lock, so the unique (version_number, schema_id) index decides between concurrent writers and the loser retries
+// with a fresh number. A protobuf descriptor is compiled from the content unless one was uploaded
+func insertNextSchemaVersion(user models.User, schema models.Schema, schemaContent, messageStructName, uploadedDescriptor string) (models.SchemaVersion, error) {
+	for attempt := 0; attempt < schemaVersionAllocationAttempts; attempt++ {
+		lastVersionNumber, err := db.GetLastSchemaVersionNumber(schema.ID, user.TenantName)
+		if err != nil {
+			return models.SchemaVersion{}, err
+		}
+		versionNumber := lastVersionNumber + 1
+		descriptor := uploadedDescriptor
+		if schema.Type == "protobuf" && descriptor == _EMPTY_ {
+			descriptor, err = generateSchemaDescriptor(schema.Name, versionNumber, schemaContent, schema.Type)
+			if err != nil {
+				return models.SchemaVersion{}, schemaDescriptorError{err}
+			}
+		}
+		newSchemaVersion, _, err := db.InsertNewSchemaVersion(versionNumber, user.ID, user.Username, schemaContent, schema.ID, messageStructName, descriptor, false, user.TenantName)
+		if errors.Is(err, db.ErrSchemaVersionExists) {
+			continue
+		}
+		if err != nil {
+			return models.SchemaVersion{}, err
+		}
+		return newSchemaVersion, nil
+	}
+	return models.SchemaVersion{}, ErrSchemaVersionContention
+}
+
 func generateSchemaUpdateInit(schema models.Schema) (*models.SchemaUpdateInit, error) {
 	activeVersion, err := getActiveVersionBySchemaId(schema.ID)
 	if err != nil {
@@ -321,6 +357,7 @@ func (sh SchemasHandler) getExtendedSchemaDetailsUpdateAvailable(schemaVersion i
 		UsedStations:      stations,
 		Tags:              tags,
 		CreatedByUsername: schema.CreatedByUsername,
+		OwnerTeam:         schema.OwnerTeam,
 	}
 
 	return extedndedSchemaDetails, nil
@@ -344,6 +381,11 @@ func (sh SchemasHandler) getExtendedSchemaDetails(schema models.Schema, tenantNa
 		return models.ExtendedSchemaDetails{}, err
 	}
 
+	comments, err := getCommentThreads(models.CommentEntitySchema, schema.ID, 0, tenantName)
+	if err != nil {
+		return models.ExtendedSchemaDetails{}, err
+	}
+
 	extedndedSchemaDetails = models.ExtendedSchemaDetails{
 		ID:                schema.ID,
 		SchemaName:        schema.Name,
@@ -352,6 +394,8 @@ func (sh SchemasHandler) getExtendedSchemaDetails(schema models.Schema, tenantNa
 		UsedStations:      stations,
 		Tags:              tags,
 		CreatedByUsername: schema.CreatedByUsername,
+		OwnerTeam:         schema.OwnerTeam,
+		Comments:          comments,
 	}
 
 	return extedndedSchemaDetails, nil
@@ -362,31 +406,6 @@ func (sh SchemasHandler) GetAllSchemasDetails(tenantName string) ([]models.Exten
 	if err != nil {
 		return []models.ExtendedSchema{}, err
 	}
-	if len(schemas) == 0 {
-		return []models.ExtendedSchema{}, nil
-	}
-
-	for i, schema := range schemas {
-		stations, err := db.GetCountStationsUsingSchema(schema.Name, tenantName)
-		if err != nil {
-			return []models.ExtendedSchema{}, err
-		}
-		if stations > 0 {
-			schema.Used = true
-		} else {
-			schema.Used = false
-		}
-		tagsHandler := TagsHandler{S: sh.S}
-		tags, err := tagsHandler.GetTagsByEntityWithID("schema", schemas[i].ID)
-		if err != nil {
-			return []models.ExtendedSchema{}, err
-		}
-		schema.Tags = tags
-		schemas[i] = schema
-	}
-	if err != nil {
-		return []models.ExtendedSchema{}, err
-	}
 	return schemas, nil
 }
 
@@ -440,6 +459,15 @@ func (sh SchemasHandler) CreateNewSchema(c *gin.Context) {
 	}
 
 	schemaContent := body.SchemaContent
+	descriptor := _EMPTY_
+	if body.DescriptorSet != _EMPTY_ {
+		schemaContent, descriptor, err = schemaContentFromDescriptorSet(schemaType, body.SchemaContent, body.DescriptorSet, messageStructName)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]CreateNewSchema at schemaContentFromDescriptorSet: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+			c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
 	err = validateSchemaContent(schemaContent, schemaType)
 	if err != nil {
 		serv.Warnf("[tenant: %v][user: %v]CreateNewSchema at validateSchemaContent: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
@@ -447,8 +475,7 @@ func (sh SchemasHandler) CreateNewSchema(c *gin.Context) {
 		return
 	}
 	schemaVersionNumber := 1
-	descriptor := _EMPTY_
-	if schemaType == "protobuf" {
+	if schemaType == "protobuf" && descriptor == _EMPTY_ {
 		descriptor, err = generateSchemaDescriptor(schemaName, schemaVersionNumber, schemaContent, schemaType)
 		if err != nil {
 			serv.Warnf("[tenant: %v][user: %v]CreateNewSchema at generateSchemaDescriptor: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
@@ -457,44 +484,24 @@ func (sh SchemasHandler) CreateNewSchema(c *gin.Context) {
 		}
 	}
 
-	newSchema, rowsUpdated, err := db.InsertNewSchema(schemaName, schemaType, user.Username, tenantName)
-	if err != nil {
-		serv.Errorf("[tenant: %v][user: %v]CreateNewSchema at InsertNewSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
-		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-		return
-	}
-
-	if rowsUpdated == 1 {
-		_, _, err = db.InsertNewSchemaVersion(schemaVersionNumber, user.ID, user.Username, schemaContent, newSchema.ID, messageStructName, descriptor, true, tenantName)
-		if err != nil {
-			serv.Errorf("[tenant: %v][user: %v]CreateNewSchema at InsertNewSchemaVersion: %v", user.TenantName, user.Username, err.Error())
-			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-			return
-		}
-		message := fmt.Sprintf("[tenant: %v][user: %v]Schema %v has been created by %v", user.TenantName, user.Username, schemaName, user.Username)
-		serv.Noticef(message)
-	} else {
+	newSchema, err := db.InsertNewSchemaWithVersion(schemaName, schemaType, user.ID, user.Username, schemaContent, messageStructName, descriptor, schemaTagsToCreate(body.Tags), tenantName)
+	if errors.Is(err, db.ErrSchemaExists) {
 		errMsg := fmt.Sprintf("Schema with the name %v already exists", schemaName)
 		serv.Warnf("[tenant: %v][user: %v]CreateNewSchema: %v", user.TenantName, user.Username, errMsg)
 		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
 		return
 	}
-
-	if len(body.Tags) > 0 {
-		err = AddTagsToEntity(body.Tags, "schema", newSchema.ID, tenantName, _EMPTY_)
-		if err != nil {
-			serv.Errorf("[tenant: %v][user: %v]CreateNewSchema at AddTagsToEntity: Failed creating tag at schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
-			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-			return
-		}
-	} else {
-		err = CreateDefaultTags("schema", newSchema.ID, tenantName)
-		if err != nil {
-			serv.Errorf("[tenant: %v][user: %v]createNewSchema at CreateDefaultTags: %v", tenantName, user.Username, err.Error())
-			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-			return
-		}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateNewSchema at InsertNewSchemaWithVersion: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
 	}
+	serv.Noticef("[tenant: %v][user: %v]Schema %v has been created by %v", user.TenantName, user.Username, schemaName, user.Username)
+
+	message := fmt.Sprintf("Schema %v has been created by user %v", newSchema.Name, user.Username)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+	publishBrokerEvent(user.TenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": newSchema.Name, "version_number": 1, "activated_by": user.Username})
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -505,6 +512,21 @@ func (sh SchemasHandler) CreateNewSchema(c *gin.Context) {
 	c.IndentedJSON(200, newSchema)
 }
 
+var schemasSortKeys = listSortKeys[models.ExtendedSchema]{
+	"name": func(a, b models.ExtendedSchema) int {
+		return compareListStrings(a.Name, b.Name)
+	},
+	"created_at": func(a, b models.ExtendedSchema) int {
+		return compareListTimes(a.CreatedAt, b.CreatedAt)
+	},
+	"type": func(a, b models.ExtendedSchema) int {
+		return compareListStrings(a.Type, b.Type)
+	},
+	"active_version_number": func(a, b models.ExtendedSchema) int {
+		return compareListInts(a.ActiveVersionNumber, b.ActiveVersionNumber)
+	},
+}
+
 func (sh SchemasHandler) GetAllSchemas(c *gin.Context) {
 	user, err := getUserDetailsFromMiddleware(c)
 	if err != nil {
@@ -512,12 +534,33 @@ func (sh SchemasHandler) GetAllSchemas(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	team, ok := teamFilterFromRequest(c, user, "GetAllSchemas")
+	if !ok {
+		return
+	}
+	tags, ok := tagFilterFromRequest(c, user, "GetAllSchemas")
+	if !ok {
+		return
+	}
+	listOpts, ok := listOptionsFromRequest(c, user, "GetAllSchemas", schemasSortKeys)
+	if !ok {
+		return
+	}
 	schemas, err := sh.GetAllSchemasDetails(user.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetAllSchemas at db.GetAllSchemasDetails: %v", user.TenantName, user.Username, err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	if team != _EMPTY_ || !tags.empty() {
+		filteredSchemas := []models.ExtendedSchema{}
+		for _, schema := range schemas {
+			if (team == _EMPTY_ || schema.OwnerTeam == team) && tags.matches(schema.Tags) {
+				filteredSchemas = append(filteredSchemas, schema)
+			}
+		}
+		schemas = filteredSchemas
+	}
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -525,7 +568,14 @@ func (sh SchemasHandler) GetAllSchemas(c *gin.Context) {
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-enter-schemas-page")
 	}
 
-	c.IndentedJSON(200, schemas)
+	schemas, total := applyListOptions(schemas, listOpts, schemasSortKeys)
+	response, err := listResponse(schemas, total, listOpts)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAllSchemas at listResponse: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, response)
 }
 
 func (sh SchemasHandler) GetSchemaDetails(c *gin.Context) {
@@ -541,6 +591,10 @@ func (sh SchemasHandler) GetSchemaDetails(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	if body.VersionNumber > 0 {
+		respondWithSchemaVersion(c, user, schemaName, body.VersionNumber, "GetSchemaDetails")
+		return
+	}
 	exist, schema, err := db.GetSchemaByName(schemaName, user.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetSchemaDetails at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
@@ -571,26 +625,52 @@ func (sh SchemasHandler) GetSchemaDetails(c *gin.Context) {
 	c.IndentedJSON(200, schemaDetails)
 }
 
-func deleteSchemaFromStations(s *Server, schemaName string, tenantName string) error {
-	stationNames, err := db.GetStationNamesUsingSchema(schemaName, tenantName)
+// respondWithSchemaVersion returns a single version of a schema, version number 0 stands for the active version
+func respondWithSchemaVersion(c *gin.Context, user models.User, schemaName string, versionNumber int, funcName string) {
+	exist, details, err := db.GetSchemaVersionByName(schemaName, versionNumber, user.TenantName)
 	if err != nil {
-		return err
+		serv.Errorf("[tenant: %v][user: %v]%v at GetSchemaVersionByName: Schema %v: %v", user.TenantName, user.Username, funcName, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
 	}
-	for _, name := range stationNames {
-		sn, err := StationNameFromStr(name)
-		if err != nil {
-			return err
+	if !exist {
+		errMsg := fmt.Sprintf("Schema %v does not exist", schemaName)
+		if versionNumber > 0 {
+			errMsg = fmt.Sprintf("Version %v of schema %v does not exist", versionNumber, schemaName)
 		}
-		removeSchemaFromStation(s, sn, false, tenantName)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
 	}
+	c.IndentedJSON(200, details)
+}
 
-	err = db.RemoveSchemaFromAllUsingStations(schemaName, tenantName)
+func (sh SchemasHandler) GetActiveSchemaVersion(c *gin.Context) {
+	var body models.GetActiveSchemaVersionSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
 	if err != nil {
-		s.Errorf("[tenant: %v]deleteSchemaFromStations at RemoveSchemaFromAllUsingStations: Schema %v: %v", tenantName, schemaName, err.Error())
-		return err
+		serv.Errorf("GetActiveSchemaVersion at getUserDetailsFromMiddleware: Schema %v: %v", body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
 	}
+	respondWithSchemaVersion(c, user, strings.ToLower(body.SchemaName), 0, "GetActiveSchemaVersion")
+}
 
-	return nil
+// notifyStationsOfSchemaRemoval tells the producers of the stations that used a removed schema to drop it, the
+// stations themselves are detached by db.FindAndDeleteSchema
+func notifyStationsOfSchemaRemoval(s *Server, stationNames []string, tenantName string) {
+	for _, name := range stationNames {
+		sn, err := StationNameFromStr(name)
+		if err != nil {
+			s.Warnf("[tenant: %v]notifyStationsOfSchemaRemoval at StationNameFromStr: Station %v: %v", tenantName, name, err.Error())
+			continue
+		}
+		removeSchemaFromStation(s, sn, false, tenantName)
+	}
 }
 
 func (sh SchemasHandler) RemoveSchema(c *gin.Context) {
@@ -600,6 +680,7 @@ func (sh SchemasHandler) RemoveSchema(c *gin.Context) {
 		return
 	}
 	var schemaIds []int
+	var stationNames []string
 	user, err := getUserDetailsFromMiddleware(c)
 	if err != nil {
 		serv.Errorf("RemoveSchema: %v", err.Error())
@@ -617,14 +698,13 @@ func (sh SchemasHandler) RemoveSchema(c *gin.Context) {
 			return
 		}
 		if exist {
-			DeleteTagsFromSchema(schema.ID)
-			err := deleteSchemaFromStations(sh.S, schema.Name, tenantName)
+			names, err := db.GetStationNamesUsingSchema(schema.Name, tenantName)
 			if err != nil {
-				serv.Errorf("[tenant: %v][user: %v]RemoveSchema at deleteSchemaFromStations: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+				serv.Errorf("[tenant: %v][user: %v]RemoveSchema at GetStationNamesUsingSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
 				c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 				return
 			}
-
+			stationNames = append(stationNames, names...)
 			schemaIds = append(schemaIds, schema.ID)
 		}
 	}
@@ -636,11 +716,18 @@ func (sh SchemasHandler) RemoveSchema(c *gin.Context) {
 			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 			return
 		}
+		notifyStationsOfSchemaRemoval(sh.S, stationNames, tenantName)
 		for _, name := range body.SchemaNames {
 			serv.Noticef("[tenant: %v][user: %v]Schema %v has been deleted", user.TenantName, user.Username, name)
+			sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, fmt.Sprintf("Schema %v has been deleted by user %v", name, user.Username), SchemaChangeAlert)
 		}
 	}
 
+	if len(schemaIds) > 0 {
+		message := fmt.Sprintf("Schemas %v have been deleted by user %v", strings.Join(body.SchemaNames, ", "), user.Username)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -687,43 +774,35 @@ func (sh SchemasHandler) CreateNewVersion(c *gin.Context) {
 		}
 	}
 	schemaContent := body.SchemaContent
-	err = validateSchemaContent(schemaContent, schema.Type)
-	if err != nil {
-		serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at validateSchemaContent: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
-		c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
-		return
-	}
-
-	countVersions, err := db.GetShcemaVersionsCount(schema.ID, user.TenantName)
-	if err != nil {
-		serv.Errorf("[tenant: %v][user: %v]CreateNewVersion at GetShcemaVersionsCount: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
-		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-		return
-	}
-
-	versionNumber := countVersions + 1
 	descriptor := _EMPTY_
-	if schema.Type == "protobuf" {
-		descriptor, err = generateSchemaDescriptor(schemaName, versionNumber, schemaContent, schema.Type)
+	if body.DescriptorSet != _EMPTY_ {
+		schemaContent, descriptor, err = schemaContentFromDescriptorSet(schema.Type, body.SchemaContent, body.DescriptorSet, messageStructName)
 		if err != nil {
-			serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at generateSchemaDescriptor: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+			serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at schemaContentFromDescriptorSet: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
 			c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
 			return
 		}
 	}
-	newSchemaVersion, rowsUpdated, err := db.InsertNewSchemaVersion(versionNumber, user.ID, user.Username, schemaContent, schema.ID, messageStructName, descriptor, false, user.TenantName)
+	err = validateSchemaContent(schemaContent, schema.Type)
 	if err != nil {
-		serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at InsertNewSchemaVersion: %v", user.TenantName, user.Username, err.Error())
+		serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at validateSchemaContent: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
 		c.AbortWithStatusJSON(SCHEMA_VALIDATION_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
 		return
 	}
-	if rowsUpdated == 1 {
-		serv.Noticef("[tenant: %v][user: %v]Schema Version %v has been created by %v", user.TenantName, user.Username, strconv.Itoa(newSchemaVersion.VersionNumber), user.Username)
-	} else {
-		serv.Warnf("[tenant: %v][user: %v]CreateNewVersion: Schema %v: Version %v already exists", user.TenantName, user.Username, body.SchemaName, strconv.Itoa(newSchemaVersion.VersionNumber))
-		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Version already exists"})
+
+	newSchemaVersion, err := insertNextSchemaVersion(user, schema, schemaContent, messageStructName, descriptor)
+	if err != nil {
+		var descriptorErr schemaDescriptorError
+		if errors.As(err, &descriptorErr) || errors.Is(err, ErrSchemaVersionContention) {
+			serv.Warnf("[tenant: %v][user: %v]CreateNewVersion at insertNextSchemaVersion: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+			abortWithError(c, err)
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateNewVersion at insertNextSchemaVersion: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	serv.Noticef("[tenant: %v][user: %v]Schema Version %v has been created by %v", user.TenantName, user.Username, strconv.Itoa(newSchemaVersion.VersionNumber), user.Username)
 	extedndedSchemaDetails, err := sh.getExtendedSchemaDetails(schema, user.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]CreateNewVersion at getExtendedSchemaDetails: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
@@ -731,6 +810,10 @@ func (sh SchemasHandler) CreateNewVersion(c *gin.Context) {
 		return
 	}
 
+	message := fmt.Sprintf("Version %v of schema %v has been created by user %v", newSchemaVersion.VersionNumber, schema.Name, user.Username)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -791,7 +874,18 @@ func (sh SchemasHandler) RollBackVersion(c *gin.Context) {
 		return
 	}
 	if countVersions > 1 {
-		err = db.UpdateSchemaActiveVersion(schema.ID, body.VersionNumber)
+		err = db.UpdateSchemaActiveVersion(schema.ID, body.VersionNumber, body.ActiveVersion)
+		if errors.Is(err, db.ErrSchemaActiveVersionConflict) {
+			serv.Warnf("[tenant: %v][user: %v]RollBackVersion: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+			abortWithError(c, err)
+			return
+		}
+		if errors.Is(err, db.ErrSchemaVersionNotFound) {
+			errMsg := fmt.Sprintf("Schema %v version %v does not exist", body.SchemaName, strconv.Itoa(schemaVersion))
+			serv.Warnf("[tenant: %v][user: %v]RollBackVersion: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
 		if err != nil {
 			serv.Errorf("[tenant: %v][user: %v]RollBackVersion at UpdateSchemaActiveVersion: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
 			c.AbortWithStatusJSON(500, gin.H{"message": err.Error()})
@@ -805,6 +899,11 @@ func (sh SchemasHandler) RollBackVersion(c *gin.Context) {
 		return
 	}
 
+	message := fmt.Sprintf("Schema %v has been rolled back to version %v by user %v", schema.Name, body.VersionNumber, user.Username)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+	publishBrokerEvent(user.TenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": schema.Name, "version_number": body.VersionNumber, "activated_by": user.Username})
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -837,15 +936,36 @@ func (sh SchemasHandler) ValidateSchema(c *gin.Context) {
 		return
 	}
 
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ValidateSchema at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	// the active version of an existing schema is compared with the new content to catch breaking changes
+	previousContent := _EMPTY_
+	if body.SchemaName != _EMPTY_ {
+		exist, activeVersion, err := db.GetSchemaVersionByName(strings.ToLower(body.SchemaName), 0, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]ValidateSchema at GetSchemaVersionByName: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if exist && activeVersion.Type == schemaType {
+			previousContent = activeVersion.Version.SchemaContent
+		}
+	}
+	warnings := lintSchemaContent(schemaType, schemaContent, previousContent)
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
-		user, _ := getUserDetailsFromMiddleware(c)
 		analyticsParams := make(map[string]interface{})
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-validate-schema")
 	}
 
 	c.IndentedJSON(200, gin.H{
 		"is_valid": true,
+		"warnings": warnings,
 	})
 }
 
@@ -934,12 +1054,12 @@ func (s *Server) updateSchemaVersion(schemaID int, tenantName string, newSchemaR
 		return err
 	}
 
-	countVersions, err := db.GetShcemaVersionsCount(schemaID, user.TenantName)
+	lastVersionNumber, err := db.GetLastSchemaVersionNumber(schemaID, user.TenantName)
 	if err != nil {
-		s.Errorf("[tenant: %v][user: %v]updateSchemaVersion at db.GetShcemaVersionsCount: Schema %v: %v", tenantName, user.Username, newSchemaReq.Name, err.Error())
+		s.Errorf("[tenant: %v][user: %v]updateSchemaVersion at db.GetLastSchemaVersionNumber: Schema %v: %v", tenantName, user.Username, newSchemaReq.Name, err.Error())
 		return err
 	}
-	_, currentSchema, err := db.GetSchemaVersionByNumberAndID(countVersions, schemaID)
+	_, currentSchema, err := db.GetSchemaVersionByNumberAndID(lastVersionNumber, schemaID)
 	if err != nil {
 		s.Errorf("[tenant: %v][user: %v]updateSchemaVersion at db.GetSchemaVersionByNumberAndID: Schema %v: %v", tenantName, user.Username, newSchemaReq.Name, err.Error())
 		return err
@@ -951,31 +1071,15 @@ func (s *Server) updateSchemaVersion(schemaID int, tenantName string, newSchemaR
 		return errors.New(alreadyExistInDB)
 	}
 
-	versionNumber := countVersions + 1
-
-	descriptor := _EMPTY_
-	if newSchemaReq.Type == "protobuf" {
-		descriptor, err = generateSchemaDescriptor(newSchemaReq.Name, 1, newSchemaReq.SchemaContent, newSchemaReq.Type)
-		if err != nil {
-			s.Errorf("[tenant: %v][user: %v]CreateNewSchemaDirectn: could not create proto descriptor for %v: %v", tenantName, user.Username, newSchemaReq.Name, err.Error())
-			return err
-		}
-	}
-
-	newSchemaVersion, rowsUpdated, err := db.InsertNewSchemaVersion(versionNumber, user.ID, user.Username, newSchemaReq.SchemaContent, schemaID, newSchemaReq.MessageStructName, descriptor, false, tenantName)
+	schema := models.Schema{ID: schemaID, Name: newSchemaReq.Name, Type: newSchemaReq.Type}
+	newSchemaVersion, err := insertNextSchemaVersion(user, schema, newSchemaReq.SchemaContent, newSchemaReq.MessageStructName, _EMPTY_)
 	if err != nil {
 		s.Errorf("[tenant: %v][user: %v]updateSchemaVersion: %v", tenantName, user.Username, err.Error())
 		return err
 	}
-	if rowsUpdated == 1 {
-		message := fmt.Sprintf("[tenant: %v][user: %v]Schema Version %v has been created by %v", tenantName, user.Username, strconv.Itoa(newSchemaVersion.VersionNumber), user.Username)
-		s.Noticef(message)
-		return nil
-	} else {
-		s.Errorf("[tenant: %v][user: %v]updateSchemaVersion: schema update failed", tenantName, user.Username)
-		return errors.New("updateSchemaVersion: schema update failed")
-	}
-
+	message := fmt.Sprintf("[tenant: %v][user: %v]Schema Version %v has been created by %v", tenantName, user.Username, strconv.Itoa(newSchemaVersion.VersionNumber), user.Username)
+	s.Noticef(message)
+	return nil
 }
 
 func (s *Server) createNewSchema(newSchemaReq CreateSchemaReq, tenantName string) error {
@@ -996,27 +1100,26 @@ func (s *Server) createNewSchema(newSchemaReq CreateSchemaReq, tenantName string
 		}
 	}
 
-	newSchema, rowUpdated, err := db.InsertNewSchema(newSchemaReq.Name, newSchemaReq.Type, newSchemaReq.CreatedByUsername, tenantName)
+	newSchema, err := db.InsertNewSchemaWithVersion(newSchemaReq.Name, newSchemaReq.Type, user.ID, user.Username, newSchemaReq.SchemaContent, newSchemaReq.MessageStructName, descriptor, schemaTagsToCreate(nil), tenantName)
 	if err != nil {
-		s.Errorf("[tenant: %v][user: %v]createNewSchema at db.InsertNewSchema: %v", tenantName, user.Username, err.Error())
+		s.Errorf("[tenant: %v][user: %v]createNewSchema at db.InsertNewSchemaWithVersion: %v", tenantName, user.Username, err.Error())
 		return err
 	}
+	publishBrokerEvent(tenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": newSchema.Name, "version_number": schemaVersionNumber, "activated_by": user.Username})
 
-	if rowUpdated == 1 {
-		_, _, err := db.InsertNewSchemaVersion(schemaVersionNumber, user.ID, user.Username, newSchemaReq.SchemaContent, newSchema.ID, newSchemaReq.MessageStructName, descriptor, true, tenantName)
-		if err != nil {
-			s.Errorf("[tenant: %v][user: %v]createNewSchema at db.InsertNewSchemaVersion: %v", tenantName, user.Username, err.Error())
-			return err
-		}
-	}
+	return nil
+}
 
-	err = CreateDefaultTags("schema", newSchema.ID, tenantName)
-	if err != nil {
-		s.Errorf("[tenant: %v][user: %v]createNewSchema at CreateDefaultTags: %v", tenantName, user.Username, err.Error())
-		return err
+// schemaTagsToCreate lowercases the requested tags of a new schema, a schema created without tags gets the default one
+func schemaTagsToCreate(tags []models.CreateTag) []models.CreateTag {
+	if len(tags) == 0 {
+		return []models.CreateTag{{Name: defaultTagName, Color: defaultTagColor}}
 	}
-
-	return nil
+	schemaTags := make([]models.CreateTag, 0, len(tags))
+	for _, tag := range tags {
+		schemaTags = append(schemaTags, models.CreateTag{Name: strings.ToLower(tag.Name), Color: tag.Color})
+	}
+	return schemaTags
 }
 
 func getProtoMessageStructName(schema_content string) (string, error) {
@@ -0,0 +1,106 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const searchDefaultPageSize = 10
+
+type SearchHandler struct{}
+
+// searchTypes is the order in which the resource types are searched when the request does not scope them
+var searchTypes = []string{models.SearchTypeStation, models.SearchTypeSchema, models.SearchTypeTag, models.SearchTypeProducer, models.SearchTypeUser}
+
+// requestedSearchTypes accepts both repeated and comma separated type params, an empty list means all types
+func requestedSearchTypes(types []string) ([]string, error) {
+	requested := []string{}
+	seen := make(map[string]bool)
+	for _, param := range types {
+		for _, t := range strings.Split(param, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == _EMPTY_ || seen[t] {
+				continue
+			}
+			supported := false
+			for _, searchType := range searchTypes {
+				if t == searchType {
+					supported = true
+					break
+				}
+			}
+			if !supported {
+				return nil, fmt.Errorf("search type %v is not supported, it can be one of the following %v", t, strings.Join(searchTypes, "/"))
+			}
+			seen[t] = true
+			requested = append(requested, t)
+		}
+	}
+	if len(requested) == 0 {
+		return searchTypes, nil
+	}
+	return requested, nil
+}
+
+func (sh SearchHandler) Search(c *gin.Context) {
+	var body models.SearchSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("Search at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	query := strings.TrimSpace(body.Query)
+	if query == _EMPTY_ {
+		serv.Warnf("[tenant: %v][user: %v]Search: query can not be empty", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Query can not be empty"})
+		return
+	}
+	types, err := requestedSearchTypes(body.Types)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]Search at requestedSearchTypes: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	if body.PageSize == 0 {
+		body.PageSize = searchDefaultPageSize
+	}
+	if body.Page == 0 {
+		body.Page = 1
+	}
+
+	response := models.SearchResponse{Query: query, Page: body.Page, PageSize: body.PageSize, Results: make(map[string]models.SearchResultsPage, len(types))}
+	for _, searchType := range types {
+		results, total, err := db.SearchResources(searchType, query, user.TenantName, body.PageSize, (body.Page-1)*body.PageSize)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]Search at SearchResources: type %v: %v", user.TenantName, user.Username, searchType, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		response.Results[searchType] = models.SearchResultsPage{Results: results, Total: total}
+	}
+
+	c.IndentedJSON(200, response)
+}
@@ -0,0 +1,184 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/middlewares"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createSession registers a new UI session, its id is carried by both the access and the refresh tokens
+func createSession(c *gin.Context, user models.User) (string, error) {
+	raw := make([]byte, 16)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	sessionID := hex.EncodeToString(raw)
+	expiresAt := time.Now().Add(time.Minute * time.Duration(REFRESH_JWT_EXPIRES_IN_MINUTES))
+	_, err = db.InsertSession(sessionID, user.Username, c.ClientIP(), c.Request.UserAgent(), expiresAt, user.TenantName)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return sessionID, nil
+}
+
+func getSessionIDFromMiddleware(c *gin.Context) string {
+	return c.GetString("session_id")
+}
+
+// sessionsOwner returns the user whose sessions are managed, users manage their own sessions and the root user manages anyone's
+func sessionsOwner(c *gin.Context, user models.User, username string, funcName string) (string, bool) {
+	username = strings.ToLower(username)
+	if username == _EMPTY_ || username == strings.ToLower(user.Username) {
+		return strings.ToLower(user.Username), true
+	}
+	if user.UserType != "root" {
+		errMsg := "Sessions of other users can be managed only by the root user"
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return _EMPTY_, false
+	}
+	return username, true
+}
+
+func (umh UserMgmtHandler) GetSessions(c *gin.Context) {
+	var body models.GetSessionsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetSessions at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username, ok := sessionsOwner(c, user, body.Username, "GetSessions")
+	if !ok {
+		return
+	}
+
+	sessions, err := db.GetActiveSessionsByUsername(username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetSessions at GetActiveSessionsByUsername: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	currentSessionID := getSessionIDFromMiddleware(c)
+	res := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, models.SessionResponse{Session: session, Current: session.ID == currentSessionID})
+	}
+	c.IndentedJSON(200, res)
+}
+
+func (umh UserMgmtHandler) RevokeSession(c *gin.Context) {
+	var body models.RevokeSessionSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RevokeSession at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username, ok := sessionsOwner(c, user, body.Username, "RevokeSession")
+	if !ok {
+		return
+	}
+
+	deleted, err := db.DeleteSession(body.SessionID, username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeSession at DeleteSession: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	middlewares.ForgetUserSessions(username, user.TenantName)
+	if !deleted {
+		errMsg := fmt.Sprintf("Session %v does not exist", body.SessionID)
+		serv.Warnf("[tenant: %v][user: %v]RevokeSession: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("A session of user %v has been revoked by user %v", username, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (umh UserMgmtHandler) RevokeAllSessions(c *gin.Context) {
+	var body models.RevokeAllSessionsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RevokeAllSessions at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username, ok := sessionsOwner(c, user, body.Username, "RevokeAllSessions")
+	if !ok {
+		return
+	}
+
+	keepID := _EMPTY_
+	if body.KeepCurrent && username == strings.ToLower(user.Username) {
+		keepID = getSessionIDFromMiddleware(c)
+	}
+	revoked, err := db.DeleteUserSessions(username, keepID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeAllSessions at DeleteUserSessions: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	middlewares.ForgetUserSessions(username, user.TenantName)
+
+	message := fmt.Sprintf("%v sessions of user %v have been revoked by user %v", revoked, username, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	c.IndentedJSON(200, gin.H{"revoked": revoked})
+}
+
+// RemoveExpiredSessions drops the sessions that can not be refreshed anymore, users that never sign in again
+// would otherwise keep them forever
+func (s *Server) RemoveExpiredSessions() {
+	ticker := time.NewTicker(15 * time.Minute)
+	for range ticker.C {
+		if !s.leadsBackgroundTask("RemoveExpiredSessions", 15*time.Minute) { // logic happens once only on the leader
+			continue
+		}
+		removed, err := db.DeleteExpiredSessions()
+		if err != nil {
+			s.Errorf("RemoveExpiredSessions at DeleteExpiredSessions: %v", err.Error())
+			continue
+		}
+		if removed > 0 {
+			s.Debugf("RemoveExpiredSessions: %v expired sessions have been removed", removed)
+		}
+	}
+}
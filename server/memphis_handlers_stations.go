@@ -92,8 +92,8 @@ func validateStorageType(storageType string) error {
 }
 
 func validateRetentionType(retentionType string) error {
-	if retentionType != "message_age_sec" && retentionType != "messages" && retentionType != "bytes" && retentionType != "ack_based" {
-		return errors.New("retention type can be one of the following message_age_sec/messages/bytes/ack_based")
+	if retentionType != "message_age_sec" && retentionType != "messages" && retentionType != "bytes" && retentionType != "ack_based" && retentionType != "compacted" {
+		return errors.New("retention type can be one of the following message_age_sec/messages/bytes/ack_based/compacted")
 	}
 
 	return nil
@@ -124,8 +124,108 @@ func getRetentionPolicy(retentionType string) RetentionPolicy {
 	return LimitsPolicy
 }
 
+// retentionTypeHasValue reports whether the retention type is bounded by the retention value,
+// ack based and compacted stations keep their messages until they are acked or replaced
+func retentionTypeHasValue(retentionType string) bool {
+	return retentionType != "ack_based" && retentionType != "compacted"
+}
+
+func validateAckRetentionLimit(retentionType string, limit models.AckLimit, idempotencyWindow int64) error {
+	if limit.Type == _EMPTY_ {
+		return nil
+	}
+	if retentionType != "ack_based" {
+		return errors.New("ack retention limit can only be set on ack_based stations")
+	}
+	if limit.Type != "message_age_sec" && limit.Type != "messages" && limit.Type != "bytes" {
+		return errors.New("ack retention limit type can be one of the following message_age_sec/messages/bytes")
+	}
+	if limit.Value <= 0 {
+		return errors.New("ack retention limit value has to be positive")
+	}
+	if limit.Type == "message_age_sec" && int64(limit.Value)*1000 < idempotencyWindow {
+		return errors.New("idempotency window cannot be greater than the ack retention limit")
+	}
+	return nil
+}
+
+const (
+	stationDescriptionMaxLength = 1024
+	stationLabelsMaxCount       = 32
+	stationLabelKeyMaxLength    = 63
+	stationLabelValueMaxLength  = 256
+)
+
+var stationLabelKeyRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9_.\-/]*[a-z0-9])?$`)
+
+func validateStationSlaTier(slaTier string) error {
+	switch slaTier {
+	case _EMPTY_, models.StationSlaTierCritical, models.StationSlaTierHigh, models.StationSlaTierStandard, models.StationSlaTierLow:
+		return nil
+	}
+	return errors.New("sla tier can be one of the following critical/high/standard/low")
+}
+
+// normalizeStationLabels lowercases the label keys and trims the values, keys are limited to
+// lowercase alphanumerics with - _ . / inside so they can be used as list filters
+func normalizeStationLabels(labels map[string]string) (map[string]string, error) {
+	if len(labels) > stationLabelsMaxCount {
+		return nil, fmt.Errorf("a station can have up to %v labels", stationLabelsMaxCount)
+	}
+	normalized := make(map[string]string, len(labels))
+	for key, value := range labels {
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if len(key) > stationLabelKeyMaxLength || !stationLabelKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("label key %v is invalid, it has to be up to %v lowercase alphanumeric characters, - _ . / are allowed inside", key, stationLabelKeyMaxLength)
+		}
+		if len(value) > stationLabelValueMaxLength {
+			return nil, fmt.Errorf("the value of label %v can not be longer than %v characters", key, stationLabelValueMaxLength)
+		}
+		if _, ok := normalized[key]; ok {
+			return nil, fmt.Errorf("label %v is duplicated", key)
+		}
+		normalized[key] = value
+	}
+	return normalized, nil
+}
+
+// applyAckRetentionLimit sets the age/count/size limits on the streams of an ack based station,
+// messages acked by all the consumer groups are still removed right away by the interest policy
+func (s *Server) applyAckRetentionLimit(tenantName string, stationName StationName, partitionsList []int, limit models.AckLimit) error {
+	streamNames := []string{stationName.Intern()}
+	if len(partitionsList) > 0 {
+		streamNames = make([]string, 0, len(partitionsList))
+		for _, p := range partitionsList {
+			streamNames = append(streamNames, fmt.Sprintf("%v$%v", stationName.Intern(), p))
+		}
+	}
+
+	for _, streamName := range streamNames {
+		streamInfo, err := s.memphisStreamInfo(tenantName, streamName)
+		if err != nil {
+			return err
+		}
+		cfg := streamInfo.Config
+		cfg.MaxMsgs, cfg.MaxBytes, cfg.MaxAge = -1, -1, 0
+		switch limit.Type {
+		case "message_age_sec":
+			cfg.MaxAge = time.Duration(limit.Value) * time.Second
+		case "messages":
+			cfg.MaxMsgs = int64(limit.Value)
+		case "bytes":
+			cfg.MaxBytes = int64(limit.Value)
+		}
+		err = s.memphisUpdateStream(tenantName, &cfg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // TODO remove the station resources - functions, connectors
-func removeStationResources(s *Server, station models.Station, shouldDeleteStream bool) error {
+func removeStationResources(s *Server, station models.Station, shouldDeleteStream bool, requestID string) error {
 	stationName, err := StationNameFromStr(station.Name)
 	if err != nil {
 		return err
@@ -133,7 +233,7 @@ func removeStationResources(s *Server, station models.Station, shouldDeleteStrea
 
 	if shouldDeleteStream {
 		if len(station.PartitionsList) == 0 {
-			err = s.RemoveStream(station.TenantName, stationName.Intern())
+			err = s.RemoveStream(station.TenantName, stationName.Intern(), requestID)
 			if err != nil && !IsNatsErr(err, JSStreamNotFoundErr) {
 				s.Errorf("[tenant: %v]removeStationResources at RemoveStream: Station %v: %v", station.TenantName, station.Name, err.Error())
 				// TODO add retry
@@ -141,7 +241,7 @@ func removeStationResources(s *Server, station models.Station, shouldDeleteStrea
 		} else {
 			for _, p := range station.PartitionsList {
 				streamName := fmt.Sprintf("%v$%v", stationName.Intern(), p)
-				err = s.RemoveStream(station.TenantName, streamName)
+				err = s.RemoveStream(station.TenantName, streamName, requestID)
 				if err != nil && !IsNatsErr(err, JSStreamNotFoundErr) {
 					s.Errorf("[tenant: %v]removeStationResources at RemoveStream: Station %v: %v", station.TenantName, station.Name, err.Error())
 					// TODO add retry
@@ -150,33 +250,23 @@ func removeStationResources(s *Server, station models.Station, shouldDeleteStrea
 		}
 	}
 
-	DeleteTagsFromStation(station.ID)
-
-	err = db.DeleteDLSMessagesByStationID(station.ID)
-	if err != nil {
-		return err
-	}
-
-	err = db.DeleteProducersByStationID(station.ID)
+	err = s.removeStationStorageKey(station.TenantName, stationName)
 	if err != nil {
 		return err
 	}
+	s.forgetStationReadOnly(station.TenantName, stationName)
 
-	err = db.DeleteAllConsumersByStationID(station.ID)
+	err = db.DeleteStationReferences(station.ID, station.Name, station.TenantName)
 	if err != nil {
 		return err
 	}
+	producersStateForget(station.ID)
 
 	err = RemoveAllAuditLogsByStation(station.Name, station.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v]removeStationResources: Station %v: %v", station.TenantName, station.Name, err.Error())
 	}
 
-	err = db.RemoveDlsStationFromAllStations(station.Name, station.TenantName)
-	if err != nil {
-		return err
-	}
-
 	_, err = db.DeleteAndGetAttachedFunctionsByStation(station.TenantName, station.ID, station.PartitionsList)
 	if err != nil {
 		return err
@@ -378,6 +468,9 @@ func (s *Server) createStationDirectIntern(c *client,
 			return
 		}
 
+		// the schema may have been found by an old name, stations keep its current one
+		schemaName = schema.Name
+
 		canCreate := ValidataAccessToFeature(csr.TenantName, "feature-schemaverse-enforcement")
 		if !canCreate {
 			errMsg := fmt.Sprintf("cannot create station with schema enforcement, please upgrade your plan to enjoy this feature")
@@ -416,7 +509,7 @@ func (s *Server) createStationDirectIntern(c *client,
 			return
 		}
 
-		if csr.RetentionValue <= 0 && retentionType != "ack_based" {
+		if csr.RetentionValue <= 0 && retentionTypeHasValue(retentionType) {
 			retentionType = "message_age_sec"
 			retentionValue = 3600 // 1 hour
 		} else {
@@ -524,12 +617,12 @@ func (s *Server) createStationDirectIntern(c *client,
 
 	if shouldCreateStream {
 		for p := 1; p <= csr.PartitionsNumber; p++ {
-			err = s.CreateStream(csr.TenantName, stationName, retentionType, retentionValue, storageType, csr.IdempotencyWindow, replicas, csr.TieredStorageEnabled, p, true)
+			err = s.CreateStream(csr.TenantName, stationName, retentionType, retentionValue, storageType, csr.IdempotencyWindow, replicas, csr.TieredStorageEnabled, p, true, _EMPTY_)
 			if err != nil {
 				// remove all partitions that were created
 				for _, partition := range partitionsList {
 					streamName := fmt.Sprintf("%v$%v", stationName.Intern(), partition)
-					err = s.RemoveStream(csr.TenantName, streamName)
+					err = s.RemoveStream(csr.TenantName, streamName, _EMPTY_)
 					if err != nil {
 						serv.Errorf("[tenant: %v][user: %v]CreateStationDirect at RemoveStream: Station %v: %v", user.TenantName, user.Username, csr.StationName, err.Error())
 					}
@@ -566,6 +659,8 @@ func (s *Server) createStationDirectIntern(c *client,
 		}
 		message := "Station " + stationName.Ext() + " has been created by user " + username
 		serv.Noticef("[tenant:%v][user: %v] %v", user.TenantName, user.Username, message)
+		s.notifyOperationalEvent(user.TenantName, StationCreatedTitle, message, StationLifecycleAlert)
+		publishBrokerEvent(user.TenantName, models.EventStationCreated, map[string]interface{}{"station_name": stationName.Ext(), "created_by": username})
 		var auditLogs []interface{}
 		newAuditLog := models.AuditLog{
 			StationName:       stationName.Ext(),
@@ -626,6 +721,12 @@ func (sh StationsHandler) GetStation(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	comments, err := getCommentThreads(models.CommentEntityStation, station.ID, 0, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStation at getCommentThreads: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
 
 	if station.StorageType == "file" {
 		station.StorageType = "disk"
@@ -644,6 +745,19 @@ func (sh StationsHandler) GetStation(c *gin.Context) {
 		}
 	}
 
+	sn, err := StationNameFromStr(station.Name)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStation at StationNameFromStr: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	encryptionEnabled, err := isStationEncrypted(user.TenantName, sn)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStation at isStationEncrypted: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
 	stationResponse := models.GetStationResponseSchema{
 		ID:                   station.ID,
 		Name:                 station.Name,
@@ -667,6 +781,15 @@ func (sh StationsHandler) GetStation(c *gin.Context) {
 		DlsStation:           station.DlsStation,
 		FunctionsLockHeld:    station.FunctionsLockHeld,
 		FunctionsLockedAt:    station.FunctionsLockedAt,
+		MessageTransform:     station.MessageTransform,
+		AckRetentionLimit:    models.AckLimit{Type: station.AckRetentionLimitType, Value: station.AckRetentionLimitValue},
+		EncryptionEnabled:    encryptionEnabled,
+		OwnerTeam:            station.OwnerTeam,
+		Description:          station.Description,
+		SlaTier:              station.SlaTier,
+		Labels:               station.Labels,
+		ReadOnly:             station.ReadOnly,
+		Comments:             comments,
 	}
 
 	c.IndentedJSON(200, stationResponse)
@@ -697,17 +820,22 @@ func (sh StationsHandler) GetStationsDetails(tenantName string) ([]models.Extend
 				}
 			}
 		}
-		stationIdsDlsMsgs, err := db.GetStationIdsFromDlsMsgs(tenantName)
+		stationsDlsMsgs, err := db.GetDlsMsgsCountPerStation(tenantName)
+		if err != nil {
+			return []models.ExtendedStationDetails{}, err
+		}
+		stationsTags, err := db.GetEntitiesTags("station", tenantName)
+		if err != nil {
+			return []models.ExtendedStationDetails{}, err
+		}
+		activeStations, err := db.GetActiveStationIds(tenantName)
 		if err != nil {
 			return []models.ExtendedStationDetails{}, err
 		}
-		tagsHandler := TagsHandler{S: sh.S}
 		for _, station := range stations {
-			_, hasDlsMsgs := stationIdsDlsMsgs[station.ID]
-			tags, err := tagsHandler.GetTagsByEntityWithID("station", station.ID)
-			if err != nil {
-				return []models.ExtendedStationDetails{}, err
-			}
+			totalDlsMsgs := stationsDlsMsgs[station.ID]
+			hasDlsMsgs := totalDlsMsgs > 0
+			tags := entityTags(stationsTags, station.ID)
 			if station.StorageType == "file" {
 				station.StorageType = "disk"
 			}
@@ -716,23 +844,7 @@ func (sh StationsHandler) GetStationsDetails(tenantName string) ([]models.Extend
 				return []models.ExtendedStationDetails{}, err
 			}
 			totalMsgInfo := stationTotalMsgs[fullStationName.Intern()]
-
-			activity := false
-			activeCount, err := db.CountActiveProudcersByStationID(station.ID)
-			if err != nil {
-				return []models.ExtendedStationDetails{}, err
-			}
-			if activeCount > 0 {
-				activity = true
-			} else {
-				activeCount, err = db.CountActiveConsumersByStationID(station.ID)
-				if err != nil {
-					return []models.ExtendedStationDetails{}, err
-				}
-				if activeCount > 0 {
-					activity = true
-				}
-			}
+			activity := activeStations[station.ID]
 			if tenantInetgrations, ok := IntegrationsConcurrentCache.Load(tenantName); !ok {
 				station.TieredStorageEnabled = false
 			} else {
@@ -761,11 +873,11 @@ func (sh StationsHandler) GetStationsDetails(tenantName string) ([]models.Extend
 				ResendDisabled:       station.ResendDisabled,
 				PartitionsList:       station.PartitionsList,
 				Version:              station.Version,
-			}
-
-			totalDlsMsgs, err := db.CountDlsMsgsByStationAndPartition(station.ID, -1)
-			if err != nil {
-				return []models.ExtendedStationDetails{}, err
+				OwnerTeam:            station.OwnerTeam,
+				Description:          station.Description,
+				SlaTier:              station.SlaTier,
+				Labels:               station.Labels,
+				ReadOnly:             station.ReadOnly,
 			}
 
 			exStations = append(exStations, models.ExtendedStationDetails{Station: stationRes, HasDlsMsgs: hasDlsMsgs, TotalMessages: totalMsgInfo, Tags: tags, Activity: activity, PoisonMessages: totalDlsMsgs})
@@ -796,7 +908,6 @@ func (sh StationsHandler) GetAllStationsDetailsLight(shouldExtend bool, tenantNa
 		return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, nil
 	} else {
 		stationTotalMsgs := make(map[string]int)
-		tagsHandler := TagsHandler{S: sh.S}
 		if streamsInfo == nil {
 			streamsInfo, err = serv.memphisAllStreamsInfo(tenantName)
 			if err != nil {
@@ -819,6 +930,17 @@ func (sh StationsHandler) GetAllStationsDetailsLight(shouldExtend bool, tenantNa
 		if err != nil {
 			return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
 		}
+		activeStations, err := db.GetActiveStationIds(tenantName)
+		if err != nil {
+			return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
+		}
+		var stationsTags map[int][]models.CreateTag
+		if shouldExtend {
+			stationsTags, err = db.GetEntitiesTags("station", tenantName)
+			if err != nil {
+				return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
+			}
+		}
 
 		var extStations []models.ExtendedStationLight
 		for i := 0; i < len(stations); i++ {
@@ -828,11 +950,7 @@ func (sh StationsHandler) GetAllStationsDetailsLight(shouldExtend bool, tenantNa
 			}
 			_, hasDlsMsgs := stationIdsDlsMsgs[stations[i].ID]
 			if shouldExtend {
-				tags, err := tagsHandler.GetTagsByEntityWithID("station", stations[i].ID)
-				if err != nil {
-					return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
-				}
-				stations[i].Tags = tags
+				stations[i].Tags = entityTags(stationsTags, stations[i].ID)
 
 				if tenantInetgrations, ok := IntegrationsConcurrentCache.Load(tenantName); !ok {
 					stations[i].TieredStorageEnabled = false
@@ -850,31 +968,132 @@ func (sh StationsHandler) GetAllStationsDetailsLight(shouldExtend bool, tenantNa
 
 			stations[i].TotalMessages = stationTotalMsgs[fullStationName.Intern()]
 			stations[i].HasDlsMsgs = hasDlsMsgs
-
-			activity := false
-			activeCount, err := db.CountActiveProudcersByStationID(stations[i].ID)
-			if err != nil {
-				return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
-			}
-			if activeCount > 0 {
-				activity = true
-			} else {
-				activeCount, err = db.CountActiveConsumersByStationID(stations[i].ID)
-				if err != nil {
-					return []models.ExtendedStationLight{}, totalMessages, totalDlsMessages, err
-				}
-				if activeCount > 0 {
-					activity = true
-				}
-			}
-
-			stations[i].Activity = activity
+			stations[i].Activity = activeStations[stations[i].ID]
 			extStations = append(extStations, stations[i])
 		}
 		return extStations, totalMessages, totalDlsMessages, nil
 	}
 }
 
+type stationFilter struct {
+	team    string
+	search  string
+	owner   string
+	slaTier string
+	labels  map[string]*string
+	tags    tagFilter
+}
+
+// stationFilterFromRequest reads the optional filters of the stations list, it returns false when the
+// request was aborted
+func stationFilterFromRequest(c *gin.Context, user models.User, funcName string) (stationFilter, bool) {
+	var body models.StationFilterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return stationFilter{}, false
+	}
+	team, err := resolveTeamFilter(user, body.Team)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at resolveTeamFilter: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return stationFilter{}, false
+	}
+	tags, err := newTagFilter(body.TagFilterSchema)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at newTagFilter: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return stationFilter{}, false
+	}
+	filter := stationFilter{
+		team:    team,
+		search:  strings.ToLower(strings.TrimSpace(body.Search)),
+		owner:   strings.ToLower(strings.TrimSpace(body.Owner)),
+		slaTier: strings.ToLower(strings.TrimSpace(body.SlaTier)),
+		labels:  make(map[string]*string, len(body.Labels)),
+		tags:    tags,
+	}
+	for _, label := range body.Labels {
+		key, value, hasValue := strings.Cut(label, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == _EMPTY_ {
+			continue
+		}
+		if hasValue {
+			value = strings.TrimSpace(value)
+			filter.labels[key] = &value
+		} else {
+			filter.labels[key] = nil
+		}
+	}
+	return filter, true
+}
+
+func (f stationFilter) empty() bool {
+	return f.team == _EMPTY_ && f.search == _EMPTY_ && f.owner == _EMPTY_ && f.slaTier == _EMPTY_ && len(f.labels) == 0 && f.tags.empty()
+}
+
+// matches reports whether a station passes all the filters, the search term is looked up in the
+// name, the description and the labels of the station
+func (f stationFilter) matches(name, owner, team, description, slaTier string, labels map[string]string, tags []models.CreateTag) bool {
+	if f.team != _EMPTY_ && team != f.team {
+		return false
+	}
+	if !f.tags.matches(tags) {
+		return false
+	}
+	if f.owner != _EMPTY_ && owner != f.owner {
+		return false
+	}
+	if f.slaTier != _EMPTY_ && slaTier != f.slaTier {
+		return false
+	}
+	for key, value := range f.labels {
+		labelValue, ok := labels[key]
+		if !ok || (value != nil && labelValue != *value) {
+			return false
+		}
+	}
+	if f.search == _EMPTY_ {
+		return true
+	}
+	if strings.Contains(strings.ToLower(name), f.search) || strings.Contains(strings.ToLower(description), f.search) {
+		return true
+	}
+	for key, value := range labels {
+		if strings.Contains(key, f.search) || strings.Contains(strings.ToLower(value), f.search) {
+			return true
+		}
+	}
+	return false
+}
+
+var stationsDetailsSortKeys = listSortKeys[models.ExtendedStationDetails]{
+	"name": func(a, b models.ExtendedStationDetails) int {
+		return compareListStrings(a.Station.Name, b.Station.Name)
+	},
+	"created_at": func(a, b models.ExtendedStationDetails) int {
+		return compareListTimes(a.Station.CreatedAt, b.Station.CreatedAt)
+	},
+	"total_messages": func(a, b models.ExtendedStationDetails) int {
+		return compareListInts(a.TotalMessages, b.TotalMessages)
+	},
+	"poison_messages": func(a, b models.ExtendedStationDetails) int {
+		return compareListInts(a.PoisonMessages, b.PoisonMessages)
+	},
+}
+
+var stationsLightSortKeys = listSortKeys[models.ExtendedStationLight]{
+	"name": func(a, b models.ExtendedStationLight) int {
+		return compareListStrings(a.Name, b.Name)
+	},
+	"created_at": func(a, b models.ExtendedStationLight) int {
+		return compareListTimes(a.CreatedAt, b.CreatedAt)
+	},
+	"total_messages": func(a, b models.ExtendedStationLight) int {
+		return compareListInts(a.TotalMessages, b.TotalMessages)
+	},
+}
+
 func (sh StationsHandler) GetStations(c *gin.Context) {
 	user, err := getUserDetailsFromMiddleware(c)
 	if err != nil {
@@ -882,12 +1101,30 @@ func (sh StationsHandler) GetStations(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	filter, ok := stationFilterFromRequest(c, user, "GetStations")
+	if !ok {
+		return
+	}
+	listOpts, ok := listOptionsFromRequest(c, user, "GetStations", stationsDetailsSortKeys)
+	if !ok {
+		return
+	}
 	stations, err := sh.GetStationsDetails(user.TenantName)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetStations at GetStationsDetails: %v", user.TenantName, user.Username, err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	if !filter.empty() {
+		filteredStations := []models.ExtendedStationDetails{}
+		for _, station := range stations {
+			s := station.Station
+			if filter.matches(s.Name, s.CreatedByUsername, s.OwnerTeam, s.Description, s.SlaTier, s.Labels, station.Tags) {
+				filteredStations = append(filteredStations, station)
+			}
+		}
+		stations = filteredStations
+	}
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -895,9 +1132,22 @@ func (sh StationsHandler) GetStations(c *gin.Context) {
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-enter-stations-page")
 	}
 
-	c.IndentedJSON(200, gin.H{
-		"stations": stations,
-	})
+	stations, total := applyListOptions(stations, listOpts, stationsDetailsSortKeys)
+	items, err := listResponse(stations, total, listOptions{fields: listOpts.fields})
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStations at listResponse: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	response := gin.H{
+		"stations": items,
+	}
+	if listOpts.paged {
+		response["total"] = total
+		response["page"] = listOpts.page
+		response["page_size"] = listOpts.pageSize
+	}
+	c.IndentedJSON(200, response)
 }
 
 func (sh StationsHandler) GetAllStations(c *gin.Context) {
@@ -907,14 +1157,38 @@ func (sh StationsHandler) GetAllStations(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	filter, ok := stationFilterFromRequest(c, user, "GetAllStations")
+	if !ok {
+		return
+	}
+	listOpts, ok := listOptionsFromRequest(c, user, "GetAllStations", stationsLightSortKeys)
+	if !ok {
+		return
+	}
 	stations, _, _, err := sh.GetAllStationsDetailsLight(true, user.TenantName, nil)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]GetAllStations at GetAllStationsDetails: %v", user.TenantName, user.Username, err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	if !filter.empty() {
+		filteredStations := []models.ExtendedStationLight{}
+		for _, s := range stations {
+			if filter.matches(s.Name, s.CreatedByUsername, s.OwnerTeam, s.Description, s.SlaTier, s.Labels, s.Tags) {
+				filteredStations = append(filteredStations, s)
+			}
+		}
+		stations = filteredStations
+	}
+	stations, total := applyListOptions(stations, listOpts, stationsLightSortKeys)
+	response, err := listResponse(stations, total, listOpts)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAllStations at listResponse: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
 
-	c.IndentedJSON(200, stations)
+	c.IndentedJSON(200, response)
 }
 
 func (sh StationsHandler) CreateStation(c *gin.Context) {
@@ -1034,6 +1308,8 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 			return
 		}
 
+		schemaName = schema.Name
+
 		canCreate := ValidataAccessToFeature(tenantName, "feature-schemaverse-enforcement")
 		if !canCreate {
 			errMsg := fmt.Sprintf("cannot create station with schema enforcement, please upgrade your plan to enjoy this feature")
@@ -1071,7 +1347,7 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 			return
 		}
 
-		if body.RetentionValue <= 0 && retentionType != "ack_based" {
+		if body.RetentionValue <= 0 && retentionTypeHasValue(retentionType) {
 			retentionType = "message_age_sec"
 			body.RetentionValue = 3600 // 1 hour
 		}
@@ -1118,6 +1394,38 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 		body.IdempotencyWindow = 100 // minimum is 100 millis
 	}
 
+	body.AckRetentionLimit.Type = strings.ToLower(body.AckRetentionLimit.Type)
+	err = validateAckRetentionLimit(retentionType, body.AckRetentionLimit, body.IdempotencyWindow)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateStation at validateAckRetentionLimit: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	if body.ExpiresAt != nil {
+		err = validateStationLifecyclePolicy(0, 0, body.ExpiresAt, stationLifecycleDefaultNotifyBeforeHours)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]CreateStation at validateStationLifecyclePolicy: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	if body.EncryptionEnabled {
+		if body.StorageType != "file" {
+			errMsg := "Encryption at rest is supported only for disk stations"
+			serv.Warnf("[tenant: %v][user: %v]CreateStation: Station %v: %v", user.TenantName, user.Username, body.Name, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		if !db.StorageEncryptionAvailable() {
+			errMsg := "Encryption at rest requires a configured KMS"
+			serv.Warnf("[tenant: %v][user: %v]CreateStation: Station %v: %v", user.TenantName, user.Username, body.Name, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+	}
+
 	if body.DlsStation != _EMPTY_ {
 		dlsStationName, err := StationNameFromStr(body.DlsStation)
 		if err != nil {
@@ -1144,20 +1452,7 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 			if created {
 				message := fmt.Sprintf("Station %v has been created by user %v", dlsStationName.Ext(), user.Username)
 				serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
-				var auditLogs []interface{}
-				newAuditLog := models.AuditLog{
-					StationName:       dlsStationName.Ext(),
-					Message:           message,
-					CreatedBy:         user.ID,
-					CreatedByUsername: user.Username,
-					CreatedAt:         time.Now(),
-					TenantName:        user.TenantName,
-				}
-				auditLogs = append(auditLogs, newAuditLog)
-				err = CreateAuditLogs(auditLogs)
-				if err != nil {
-					serv.Errorf("[tenant: %v][user:%v]CreateStation: Station %v - create DLS audit logs error: %v", user.TenantName, user.Username, body.DlsStation, err.Error())
-				}
+				createAuditLogFromRequest(c, user, dlsStationName.Ext(), message)
 
 				shouldSendAnalytics, _ := shouldSendAnalytics()
 				if shouldSendAnalytics {
@@ -1169,13 +1464,27 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 		}
 	}
 
+	if body.EncryptionEnabled {
+		err = createStationStorageKey(tenantName, stationName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateStation at createStationStorageKey: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
 	for p := 1; p <= body.PartitionsNumber; p++ {
-		err = sh.S.CreateStream(tenantName, stationName, retentionType, body.RetentionValue, body.StorageType, body.IdempotencyWindow, body.Replicas, body.TieredStorageEnabled, p, true)
+		err = sh.S.CreateStream(tenantName, stationName, retentionType, body.RetentionValue, body.StorageType, body.IdempotencyWindow, body.Replicas, body.TieredStorageEnabled, p, true, requestIDFromContext(c))
 		if err != nil {
+			if body.EncryptionEnabled {
+				if err := sh.S.removeStationStorageKey(tenantName, stationName); err != nil {
+					serv.Errorf("[tenant: %v][user: %v]CreateStation at removeStationStorageKey: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+				}
+			}
 			// remove all partitions that were created
 			for _, partition := range partitionsList {
 				streamName := fmt.Sprintf("%v$%v", stationName.Intern(), partition)
-				err = sh.S.RemoveStream(tenantName, streamName)
+				err = sh.S.RemoveStream(tenantName, streamName, requestIDFromContext(c))
 				if err != nil {
 					serv.Errorf("[tenant: %v][user: %v]CreateStation at RemoveStream: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
 				}
@@ -1194,6 +1503,22 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 		partitionsList = append(partitionsList, p)
 	}
 
+	if body.AckRetentionLimit.Type != _EMPTY_ {
+		err = sh.S.applyAckRetentionLimit(tenantName, stationName, partitionsList, body.AckRetentionLimit)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateStation at applyAckRetentionLimit: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+			for _, partition := range partitionsList {
+				streamName := fmt.Sprintf("%v$%v", stationName.Intern(), partition)
+				err = sh.S.RemoveStream(tenantName, streamName, requestIDFromContext(c))
+				if err != nil {
+					serv.Errorf("[tenant: %v][user: %v]CreateStation at RemoveStream: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+				}
+			}
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
 	newStation, rowsUpdated, err := db.InsertNewStation(stationName.Ext(), user.ID, user.Username, retentionType, body.RetentionValue, body.StorageType, body.Replicas, schemaName, schemaVersionNumber, body.IdempotencyWindow, true, body.DlsConfiguration, body.TieredStorageEnabled, tenantName, partitionsList, 2, body.DlsStation)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]CreateStation at db.InsertNewStation: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
@@ -1209,6 +1534,15 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 		return
 	}
 
+	if body.AckRetentionLimit.Type != _EMPTY_ {
+		err = db.UpdateStationAckRetentionLimit(newStation.Name, body.AckRetentionLimit.Type, body.AckRetentionLimit.Value, tenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateStation at db.UpdateStationAckRetentionLimit: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
 	if len(body.Tags) > 0 {
 		err = AddTagsToEntity(body.Tags, "station", newStation.ID, newStation.TenantName, _EMPTY_)
 		if err != nil {
@@ -1224,22 +1558,19 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 			return
 		}
 	}
+	if body.ExpiresAt != nil {
+		_, err = db.UpsertStationLifecyclePolicy(newStation.ID, 0, 0, body.ExpiresAt, stationLifecycleDefaultNotifyBeforeHours, user.Username, tenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateStation at db.UpsertStationLifecyclePolicy: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
 	message := "Station " + stationName.Ext() + " has been created by " + user.Username
 	serv.Noticef("[tenant: %v][user: %v] %v ", user.TenantName, user.Username, message)
-	var auditLogs []interface{}
-	newAuditLog := models.AuditLog{
-		StationName:       stationName.Ext(),
-		Message:           message,
-		CreatedBy:         user.ID,
-		CreatedByUsername: user.Username,
-		CreatedAt:         time.Now(),
-		TenantName:        user.TenantName,
-	}
-	auditLogs = append(auditLogs, newAuditLog)
-	err = CreateAuditLogs(auditLogs)
-	if err != nil {
-		serv.Errorf("[tenant: %v][user: %v]CreateStation at CreateAuditLogs: Station %v: %v", user.TenantName, user.Username, body.Name, err.Error())
-	}
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	sh.S.notifyOperationalEvent(user.TenantName, StationCreatedTitle, message, StationLifecycleAlert)
+	publishBrokerEvent(user.TenantName, models.EventStationCreated, map[string]interface{}{"station_name": stationName.Ext(), "created_by": user.Username})
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -1266,6 +1597,8 @@ func (sh StationsHandler) CreateStation(c *gin.Context) {
 		"tiered_storage_enabled":        newStation.TieredStorageEnabled,
 		"resend_disabled":               newStation.ResendDisabled,
 		"dls_station":                   newStation.DlsStation,
+		"ack_retention_limit":           body.AckRetentionLimit,
+		"encryption_enabled":            body.EncryptionEnabled,
 	})
 }
 
@@ -1319,6 +1652,10 @@ func (sh StationsHandler) AttachDlsStation(c *gin.Context) {
 		return
 	}
 
+	message := fmt.Sprintf("Dead-letter station %v has been attached to stations %v by user %v", body.Name, strings.Join(body.StationNames, ", "), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
 	c.IndentedJSON(200, gin.H{
 		"id":                            station.ID,
 		"name":                          station.Name,
@@ -1363,6 +1700,10 @@ func (sh StationsHandler) DetachDlsStation(c *gin.Context) {
 		return
 	}
 
+	message := fmt.Sprintf("Dead-letter station %v has been detached from stations %v by user %v", body.Name, strings.Join(body.StationNames, ", "), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
 	c.IndentedJSON(200, gin.H{})
 }
 
@@ -1404,7 +1745,7 @@ func (sh StationsHandler) RemoveStation(c *gin.Context) {
 			return
 		}
 
-		err = removeStationResources(sh.S, station, true)
+		err = removeStationResources(sh.S, station, true, requestIDFromContext(c))
 		if err != nil {
 			serv.Errorf("[tenant: %v][user: %v]RemoveStation at removeStationResources: Station %v: %v", user.TenantName, user.Username, stationName.external, err.Error())
 			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -1419,6 +1760,13 @@ func (sh StationsHandler) RemoveStation(c *gin.Context) {
 		return
 	}
 
+	for _, name := range stationNames {
+		message := fmt.Sprintf("Station %v has been deleted by user %v", name, user.Username)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+		sh.S.notifyOperationalEvent(user.TenantName, StationDeletedTitle, message, StationLifecycleAlert)
+		publishBrokerEvent(user.TenantName, models.EventStationDeleted, map[string]interface{}{"station_name": name, "deleted_by": user.Username})
+	}
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -1547,7 +1895,7 @@ func (s *Server) removeStationDirectIntern(c *client,
 		return
 	}
 
-	err = removeStationResources(s, station, shouldDeleteStream)
+	err = removeStationResources(s, station, shouldDeleteStream, _EMPTY_)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]removeStationDirectIntern at removeStationResources: Station %v: %v", dsr.TenantName, dsr.Username, dsr.StationName, err.Error())
 		respondWithErr(s.MemphisGlobalAccountString(), s, reply, err)
@@ -1563,6 +1911,8 @@ func (s *Server) removeStationDirectIntern(c *client,
 
 	message := "Station " + stationName.Ext() + " has been deleted by user " + dsr.Username
 	serv.Noticef("[tenant: %v][user: %v] %v ", user.TenantName, user.Username, message)
+	s.notifyOperationalEvent(user.TenantName, StationDeletedTitle, message, StationLifecycleAlert)
+	publishBrokerEvent(user.TenantName, models.EventStationDeleted, map[string]interface{}{"station_name": stationName.Ext(), "deleted_by": dsr.Username})
 	if isNative {
 		var auditLogs []interface{}
 		newAuditLog := models.AuditLog{
@@ -1709,16 +2059,24 @@ func (sh StationsHandler) DropDlsMessages(c *gin.Context) {
 		return
 	}
 
-	err := db.DropDlsMessages(body.DlsMessageIds)
+	user, err := getUserDetailsFromMiddleware(c)
 	if err != nil {
-		serv.Errorf("DropDlsMessages at db.DropDlsMessages: %v", err.Error())
+		serv.Errorf("DropDlsMessages at getUserDetailsFromMiddleware: %v", err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
 
-	shouldSendAnalytics, _ := shouldSendAnalytics()
+	err = db.DropDlsMessages(body.DlsMessageIds)
+	if err != nil {
+		serv.Errorf("DropDlsMessages at db.DropDlsMessages: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("%v dead-letter messages have been dropped by user %v", len(body.DlsMessageIds), user.Username))
+
+	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
-		user, _ := getUserDetailsFromMiddleware(c)
 		analyticsParams := make(map[string]interface{})
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-ack-poison-message")
 	}
@@ -1818,6 +2176,10 @@ func (sh StationsHandler) ResendPoisonMessages(c *gin.Context) {
 
 		}
 	}
+	message := fmt.Sprintf("Dead-letter messages of station %v have been resent by user %v", stationName, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, station.Name, message)
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -1919,6 +2281,11 @@ func (sh StationsHandler) GetMessageDetails(c *gin.Context) {
 
 	connectionIdHeader := headersJson["$memphis_connectionId"]
 	producedByHeader := strings.ToLower(headersJson["$memphis_producedBy"])
+	data, err := decompressStationMessage(headersJson, sm.Data)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetMessageDetails at decompressStationMessage: Message ID: %v: %v", user.TenantName, user.Username, strconv.Itoa(msgId), err.Error())
+		data = sm.Data
+	}
 
 	for header := range headersJson {
 		if strings.HasPrefix(header, MEMPHIS_GLOBAL_ACCOUNT) {
@@ -1957,7 +2324,7 @@ func (sh StationsHandler) GetMessageDetails(c *gin.Context) {
 		Message: models.MessagePayload{
 			TimeSent: sm.Time,
 			Size:     len(sm.Subject) + len(sm.Data) + len(sm.Header),
-			Data:     hex.EncodeToString(sm.Data),
+			Data:     hex.EncodeToString(data),
 			Headers:  headersJson,
 		},
 		Producer: models.ProducerDetailsResp{
@@ -1997,6 +2364,7 @@ func (sh StationsHandler) UseSchema(c *gin.Context) {
 		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
 		return
 	}
+	schemaName = schema.Name
 
 	schemaVersion, err := getActiveVersionBySchemaId(schema.ID)
 	if err != nil {
@@ -2043,20 +2411,8 @@ func (sh StationsHandler) UseSchema(c *gin.Context) {
 		message := "Schema " + schemaName + " has been attached to station " + stationName.Ext() + " by user " + user.Username
 		serv.Noticef("[tenant: %v][user: %v] %v ", user.TenantName, user.Username, message)
 
-		var auditLogs []interface{}
-		newAuditLog := models.AuditLog{
-			StationName:       stationName.Intern(),
-			Message:           message,
-			CreatedBy:         user.ID,
-			CreatedByUsername: user.Username,
-			CreatedAt:         time.Now(),
-			TenantName:        user.TenantName,
-		}
-		auditLogs = append(auditLogs, newAuditLog)
-		err = CreateAuditLogs(auditLogs)
-		if err != nil {
-			serv.Errorf("[tenant: %v][user: %v]UseSchema at CreateAuditLogs: Schema %v at station %v - create audit logs: %v", user.TenantName, user.Username, body.SchemaName, stationName.Ext(), err.Error())
-		}
+		createAuditLogFromRequest(c, user, stationName.Intern(), message)
+		sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
 
 		updateContent, err := generateSchemaUpdateInit(schema)
 		if err != nil {
@@ -2127,6 +2483,7 @@ func (s *Server) useSchemaDirect(c *client, reply string, msg []byte) {
 		respondWithErr(s.MemphisGlobalAccountString(), s, reply, errors.New(errMsg))
 		return
 	}
+	schemaName = schema.Name
 
 	schemaVersion, err := getActiveVersionBySchemaId(schema.ID)
 	if err != nil {
@@ -2144,6 +2501,7 @@ func (s *Server) useSchemaDirect(c *client, reply string, msg []byte) {
 
 	message := fmt.Sprintf("Schema %v has been attached to station %v by user %v", schemaName, stationName.Ext(), asr.Username)
 	serv.Noticef("[tenant: %v][user: %v]: %v", asr.TenantName, asr.Username, message)
+	s.notifyOperationalEvent(asr.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
 	_, user, err := memphis_cache.GetUser(asr.Username, asr.TenantName, false)
 	if err != nil {
 		serv.Errorf("[tenant: %v][user: %v]useSchemaDirect at memphis_cache.GetUser: Schema %v at station %v: %v", asr.TenantName, asr.Username, asr.Name, asr.StationName, err.Error())
@@ -2291,20 +2649,8 @@ func (sh StationsHandler) RemoveSchemaFromStation(c *gin.Context) {
 	}
 	message := fmt.Sprintf("Schema %v has been deleted from station %v by user %v", station.SchemaName, stationName.Ext(), user.Username)
 	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
-	var auditLogs []interface{}
-	newAuditLog := models.AuditLog{
-		StationName:       stationName.Intern(),
-		Message:           message,
-		CreatedBy:         user.ID,
-		CreatedByUsername: user.Username,
-		CreatedAt:         time.Now(),
-		TenantName:        user.TenantName,
-	}
-	auditLogs = append(auditLogs, newAuditLog)
-	err = CreateAuditLogs(auditLogs)
-	if err != nil {
-		serv.Errorf("[tenant: %v][user: %v]RemoveSchemaFromStation: At station %v - create audit logs error: %v", user.TenantName, user.Username, body.StationName, err.Error())
-	}
+	createAuditLogFromRequest(c, user, stationName.Intern(), message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
 
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
@@ -2432,9 +2778,267 @@ func (sh StationsHandler) UpdateDlsConfig(c *gin.Context) {
 	}
 	serv.SendUpdateToClients(configUpdate)
 
+	message := fmt.Sprintf("Dead-letter configuration of station %v has been updated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
 	c.IndentedJSON(200, gin.H{"poison": body.Poison, "schemaverse": body.Schemaverse})
 }
 
+func (sh StationsHandler) UpdateMessageTransform(c *gin.Context) {
+	var body models.UpdateMessageTransformSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateMessageTransform at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateMessageTransform at StationNameFromStr: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	// wrapping needs the change event metadata only a cdc connector has, so stations can only unwrap
+	err = validateMessageTransform(body.MessageTransform, models.MessageTransformDebeziumUnwrap)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateMessageTransform at validateMessageTransform: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateMessageTransform at GetStationByName: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]UpdateMessageTransform: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if station.MessageTransform != body.MessageTransform {
+		err = db.UpdateStationMessageTransform(station.Name, body.MessageTransform, station.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateMessageTransform at db.UpdateStationMessageTransform: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	message := fmt.Sprintf("Message transform of station %v has been updated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, gin.H{"message_transform": body.MessageTransform})
+}
+
+func (sh StationsHandler) UpdateAckRetentionLimit(c *gin.Context) {
+	var body models.UpdateAckRetentionLimitSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateAckRetentionLimit at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateAckRetentionLimit at StationNameFromStr: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateAckRetentionLimit at GetStationByName: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]UpdateAckRetentionLimit: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if station.RetentionType != "ack_based" {
+		errMsg := fmt.Sprintf("Station %v is not an ack_based station", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]UpdateAckRetentionLimit: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	limit := body.AckRetentionLimit
+	limit.Type = strings.ToLower(limit.Type)
+	if limit.Type == _EMPTY_ {
+		limit.Value = 0
+	}
+	err = validateAckRetentionLimit(station.RetentionType, limit, station.IdempotencyWindow)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateAckRetentionLimit at validateAckRetentionLimit: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	if station.AckRetentionLimitType != limit.Type || station.AckRetentionLimitValue != limit.Value {
+		err = sh.S.applyAckRetentionLimit(user.TenantName, stationName, station.PartitionsList, limit)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateAckRetentionLimit at applyAckRetentionLimit: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		err = db.UpdateStationAckRetentionLimit(station.Name, limit.Type, limit.Value, station.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateAckRetentionLimit at db.UpdateStationAckRetentionLimit: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	message := fmt.Sprintf("Ack retention limit of station %v has been updated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, gin.H{"ack_retention_limit": limit})
+}
+
+func (sh StationsHandler) UpdateStation(c *gin.Context) {
+	var body models.UpdateStationSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateStation at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStation at StationNameFromStr: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateStation at GetStationByName: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]UpdateStation: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	description := station.Description
+	if body.Description != nil {
+		description = strings.TrimSpace(*body.Description)
+		if len(description) > stationDescriptionMaxLength {
+			errMsg := fmt.Sprintf("Station description can not be longer than %v characters", stationDescriptionMaxLength)
+			serv.Warnf("[tenant: %v][user: %v]UpdateStation: At station, %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+	}
+	slaTier := station.SlaTier
+	if body.SlaTier != nil {
+		slaTier = strings.ToLower(strings.TrimSpace(*body.SlaTier))
+		err = validateStationSlaTier(slaTier)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]UpdateStation at validateStationSlaTier: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	labels := station.Labels
+	if body.Labels != nil {
+		labels, err = normalizeStationLabels(body.Labels)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]UpdateStation at normalizeStationLabels: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	current := resourceOwner{userId: station.CreatedBy, username: station.CreatedByUsername, team: station.OwnerTeam}
+	owner := current
+	ownerChanged := body.Owner != _EMPTY_ || body.OwnerTeam != _EMPTY_
+	if ownerChanged {
+		if user.UserType != "root" && user.UserType != "management" {
+			serv.Warnf("[tenant: %v][user: %v]UpdateStation: only management users can transfer ownership", user.TenantName, user.Username)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can transfer ownership"})
+			return
+		}
+		var errMsg string
+		owner, errMsg, err = resolveNewOwner(user.TenantName, current, body.Owner, body.OwnerTeam)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateStation at resolveNewOwner: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if errMsg != _EMPTY_ {
+			serv.Warnf("[tenant: %v][user: %v]UpdateStation: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+	}
+
+	err = db.UpdateStationMetadata(station.Name, description, slaTier, labels, station.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateStation at db.UpdateStationMetadata: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	message := fmt.Sprintf("Station %v has been updated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	if ownerChanged {
+		err = db.TransferStationOwnership(station.Name, owner.userId, owner.username, owner.team, station.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateStation at db.TransferStationOwnership: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		message = ownershipTransferMessage("station "+station.Name, owner, user.Username)
+		serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	c.IndentedJSON(200, gin.H{
+		"station_name":        station.Name,
+		"description":         description,
+		"sla_tier":            slaTier,
+		"labels":              labels,
+		"created_by_username": owner.username,
+		"owner_team":          owner.team,
+	})
+}
+
 func (sh StationsHandler) PurgeStation(c *gin.Context) {
 	var body models.PurgeStationSchema
 	ok := utils.Validate(c, &body, false, nil)
@@ -2469,32 +3073,40 @@ func (sh StationsHandler) PurgeStation(c *gin.Context) {
 		return
 	}
 
+	err = verifyPurgeConfirmationToken(body.ConfirmationToken, user, stationName.Ext())
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]PurgeStation at verifyPurgeConfirmationToken: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	partialPurge := body.BeforeSeq > 0 || !body.BeforeTime.IsZero()
+	if partialPurge && body.PurgeDls {
+		errMsg := "before_seq and before_time can not be used when purging the dead-letter messages"
+		serv.Warnf("[tenant: %v][user: %v]PurgeStation: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if len(body.PartitionsList) == 0 {
+		body.PartitionsList = []int{-1}
+	}
+
+	purged := uint64(0)
 	if body.PurgeStation {
-		if len(station.PartitionsList) == 0 && body.PartitionsList[0] == -1 {
-			err = sh.S.PurgeStream(station.TenantName, stationName.Intern(), -1)
+		partitions := body.PartitionsList
+		if body.PartitionsList[0] == -1 {
+			partitions = station.PartitionsList
+			if len(partitions) == 0 {
+				partitions = []int{-1}
+			}
+		}
+		for _, p := range partitions {
+			n, err := sh.S.purgeStationPartition(station.TenantName, stationName, p, body.BeforeSeq, body.BeforeTime)
 			if err != nil && !IsNatsErr(err, JSStreamNotFoundErr) {
 				serv.Errorf("[tenant: %v][user: %v]PurgeStation: %v", user.TenantName, user.Username, err.Error())
 				c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 				return
 			}
-		} else if body.PartitionsList[0] == -1 {
-			for _, p := range station.PartitionsList {
-				err = sh.S.PurgeStream(station.TenantName, stationName.Intern(), p)
-				if err != nil && !IsNatsErr(err, JSStreamNotFoundErr) {
-					serv.Errorf("[tenant: %v][user: %v]PurgeStation: %v", user.TenantName, user.Username, err.Error())
-					c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-					return
-				}
-			}
-		} else {
-			for _, p := range body.PartitionsList {
-				err = sh.S.PurgeStream(station.TenantName, stationName.Intern(), p)
-				if err != nil && !IsNatsErr(err, JSStreamNotFoundErr) {
-					serv.Errorf("[tenant: %v][user: %v]PurgeStation: %v", user.TenantName, user.Username, err.Error())
-					c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
-					return
-				}
-			}
+			purged += n
 		}
 	}
 
@@ -2518,12 +3130,26 @@ func (sh StationsHandler) PurgeStation(c *gin.Context) {
 		}
 	}
 
+	message := fmt.Sprintf("Station %v has been purged by user %v", stationName.Ext(), user.Username)
+	if partialPurge {
+		var limits []string
+		if body.BeforeSeq > 0 {
+			limits = append(limits, fmt.Sprintf("before sequence %v", body.BeforeSeq))
+		}
+		if !body.BeforeTime.IsZero() {
+			limits = append(limits, fmt.Sprintf("before %v", body.BeforeTime.UTC().Format(time.RFC3339)))
+		}
+		message = fmt.Sprintf("%v messages stored %v have been purged from station %v by user %v", purged, strings.Join(limits, " and "), stationName.Ext(), user.Username)
+	}
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
 		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-purge-station")
 	}
-	c.IndentedJSON(200, gin.H{})
+	c.IndentedJSON(200, gin.H{"purged_messages": purged})
 }
 
 func (sh StationsHandler) RemoveMessages(c *gin.Context) {
@@ -2572,6 +3198,10 @@ func (sh StationsHandler) RemoveMessages(c *gin.Context) {
 		}
 	}
 
+	message := fmt.Sprintf("%v messages have been removed from station %v by user %v", len(body.Messages), stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
 	shouldSendAnalytics, _ := shouldSendAnalytics()
 	if shouldSendAnalytics {
 		analyticsParams := make(map[string]interface{})
@@ -2603,7 +3233,7 @@ func (s *Server) RemoveOldStations() {
 		return
 	}
 	for _, station := range stations {
-		err = removeStationResources(s, station, true)
+		err = removeStationResources(s, station, true, _EMPTY_)
 		if err != nil {
 			s.Errorf("[tenant: %v]RemoveOldStations: at removeStationResources: %v", station.TenantName, err.Error())
 			return
@@ -2740,3 +3370,45 @@ func (s *Server) handleResendAllFailure(taskName string, user models.User, stati
 		return
 	}
 }
+
+func (sh StationsHandler) GetStationMessagesTail(c *gin.Context) {
+	var body models.GetStationMessagesTailSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationMessagesTail at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetStationMessagesTail at StationNameFromStr: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	tail, err := sh.S.getStationMessagesTail(user.TenantName, stationName)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			serv.Warnf("[tenant: %v][user: %v]GetStationMessagesTail at getStationMessagesTail: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Station " + body.StationName + " does not exist"})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]GetStationMessagesTail at getStationMessagesTail: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	resp, err := tail.wait(body.Cursor, time.Duration(body.TimeoutSec)*time.Second)
+	if err == ErrStationTailClosed {
+		// the tail went idle while waiting, the client resumes from scratch on its next poll
+		resp = models.StationMessagesTail{Messages: []models.TailedMessage{}}
+	}
+
+	c.IndentedJSON(200, resp)
+}
@@ -16,7 +16,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/memphisdev/memphis/analytics"
 	"github.com/memphisdev/memphis/db"
@@ -28,6 +27,12 @@ import (
 
 type TagsHandler struct{ S *Server }
 
+const (
+	tagResourcesDefaultPageSize = 50
+	defaultTagName              = "default"
+	defaultTagColor             = "0, 165, 255"
+)
+
 func validateEntityType(entity string) error {
 	switch entity {
 	case "station", "schema", "user":
@@ -37,6 +42,72 @@ func validateEntityType(entity string) error {
 	}
 }
 
+type tagFilter struct {
+	tags     []string
+	matchAll bool
+}
+
+// newTagFilter accepts both repeated and comma separated tags
+func newTagFilter(body models.TagFilterSchema) (tagFilter, error) {
+	filter := tagFilter{}
+	switch strings.ToLower(body.TagsOperator) {
+	case _EMPTY_, "or":
+	case "and":
+		filter.matchAll = true
+	default:
+		return tagFilter{}, errors.New("tags operator can be one of the following and/or")
+	}
+	for _, param := range body.Tags {
+		for _, tag := range strings.Split(param, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag != _EMPTY_ {
+				filter.tags = append(filter.tags, tag)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// tagFilterFromRequest reads the optional tags filter of a resources list, it returns false when the
+// request was aborted
+func tagFilterFromRequest(c *gin.Context, user models.User, funcName string) (tagFilter, bool) {
+	var body models.TagFilterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return tagFilter{}, false
+	}
+	filter, err := newTagFilter(body)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at newTagFilter: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return tagFilter{}, false
+	}
+	return filter, true
+}
+
+func (f tagFilter) empty() bool {
+	return len(f.tags) == 0
+}
+
+func (f tagFilter) matches(tags []models.CreateTag) bool {
+	if f.empty() {
+		return true
+	}
+	attached := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		attached[tag.Name] = true
+	}
+	for _, tag := range f.tags {
+		if f.matchAll && !attached[tag] {
+			return false
+		}
+		if !f.matchAll && attached[tag] {
+			return true
+		}
+	}
+	return f.matchAll
+}
+
 func CreateTag(name string, entity_type string, entity_id int, color string, tenantName string) error {
 	name = strings.ToLower(name)
 	entity := strings.ToLower(entity_type)
@@ -158,6 +229,7 @@ func (th TagsHandler) CreateNewTag(c *gin.Context) {
 
 	message := fmt.Sprintf("[tenant: %v][user: %v]New Tag %v has been created ", user.TenantName, user.Username, newTag.Name)
 	serv.Noticef(message)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Tag %v has been created by user %v", newTag.Name, user.Username))
 
 	c.IndentedJSON(200, newTag)
 }
@@ -252,22 +324,7 @@ func (th TagsHandler) RemoveTag(c *gin.Context) {
 	}
 
 	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
-	if entity == "station" {
-		var auditLogs []interface{}
-		newAuditLog := models.AuditLog{
-			StationName:       stationName,
-			Message:           message,
-			CreatedBy:         user.ID,
-			CreatedByUsername: user.Username,
-			CreatedAt:         time.Now(),
-			TenantName:        user.TenantName,
-		}
-		auditLogs = append(auditLogs, newAuditLog)
-		err = CreateAuditLogs(auditLogs)
-		if err != nil {
-			serv.Warnf("[tenant: %v][user: %v]RemoveTag: Tag %v at %v %v - create audit logs error: %v", user.TenantName, user.Username, body.Name, entity, body.EntityName, err.Error())
-		}
-	}
+	createAuditLogFromRequest(c, user, stationName, message)
 	c.IndentedJSON(200, []string{})
 }
 
@@ -351,6 +408,10 @@ func (th TagsHandler) UpdateTagsForEntity(c *gin.Context) {
 		return
 	}
 	var message string
+	auditStationName := _EMPTY_
+	if entity == "station" {
+		auditStationName = stationName.Intern()
+	}
 
 	if len(body.TagsToAdd) > 0 {
 		for _, tagToAdd := range body.TagsToAdd {
@@ -381,23 +442,6 @@ func (th TagsHandler) UpdateTagsForEntity(c *gin.Context) {
 			analyticsParams := []analytics.EventParam{}
 			if entity == "station" {
 				message = "Tag " + name + " has been added to station " + stationName.Ext() + " by user " + user.Username
-
-				var auditLogs []interface{}
-				newAuditLog := models.AuditLog{
-					StationName:       stationName.Intern(),
-					Message:           message,
-					CreatedBy:         user.ID,
-					CreatedByUsername: user.Username,
-					CreatedAt:         time.Now(),
-					TenantName:        user.TenantName,
-				}
-
-				auditLogs = append(auditLogs, newAuditLog)
-				err = CreateAuditLogs(auditLogs)
-				if err != nil {
-					serv.Warnf("[tenant: %v][user: %v]UpdateTagsForEntity: %v %v - create audit logs error: %v", user.TenantName, user.Username, entity, body.EntityName, err.Error())
-				}
-
 				analyticsEventName = "user-tag-station"
 				param := analytics.EventParam{
 					Name:  "station-name",
@@ -429,6 +473,7 @@ func (th TagsHandler) UpdateTagsForEntity(c *gin.Context) {
 			}
 
 			serv.Noticef("[tenant: %v][user: %v] %v", user.TenantName, user.Username, message)
+			createAuditLogFromRequest(c, user, auditStationName, message)
 		}
 	}
 	if len(body.TagsToRemove) > 0 {
@@ -450,22 +495,6 @@ func (th TagsHandler) UpdateTagsForEntity(c *gin.Context) {
 			}
 			if entity == "station" {
 				message = "Tag " + name + " has been deletd from station " + stationName.Ext() + " by user " + user.Username
-
-				var auditLogs []interface{}
-				newAuditLog := models.AuditLog{
-					StationName:       stationName.Intern(),
-					Message:           message,
-					CreatedBy:         user.ID,
-					CreatedByUsername: user.Username,
-					CreatedAt:         time.Now(),
-					TenantName:        user.TenantName,
-				}
-
-				auditLogs = append(auditLogs, newAuditLog)
-				err = CreateAuditLogs(auditLogs)
-				if err != nil {
-					serv.Warnf("UpdateTagsForEntity: " + entity + " " + body.EntityName + " - create audit logs error: " + err.Error())
-				}
 			} else if entity == "schema" {
 				message = "Tag " + name + " has been deleted from schema " + schemaName + " by user " + user.Username
 			} else {
@@ -473,6 +502,7 @@ func (th TagsHandler) UpdateTagsForEntity(c *gin.Context) {
 
 			}
 			serv.Noticef("[tenant: %v][user: %v] %v", user.TenantName, user.Username, message)
+			createAuditLogFromRequest(c, user, auditStationName, message)
 		}
 	}
 	tags, err := th.GetTagsByEntityWithID(entity, entity_id)
@@ -502,6 +532,14 @@ func (th TagsHandler) GetTagsByEntityWithID(entity string, id int) ([]models.Cre
 	return tagsRes, nil
 }
 
+// entityTags picks the tags of one entity out of the result of db.GetEntitiesTags
+func entityTags(tags map[int][]models.CreateTag, id int) []models.CreateTag {
+	if entityTags, ok := tags[id]; ok {
+		return entityTags
+	}
+	return []models.CreateTag{}
+}
+
 func (th TagsHandler) GetTags(c *gin.Context) {
 	var body models.GetTagsSchema
 	ok := utils.Validate(c, &body, false, nil)
@@ -579,3 +617,220 @@ func (th TagsHandler) GetUsedTags(c *gin.Context) {
 
 	c.IndentedJSON(200, tagsRes)
 }
+
+func (th TagsHandler) RenameTag(c *gin.Context) {
+	var body models.RenameTagSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RenameTag at getUserDetailsFromMiddleware: Tag %v: %v", body.Name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	name := strings.ToLower(body.Name)
+	newName := strings.ToLower(strings.TrimSpace(body.NewName))
+	if newName == _EMPTY_ || newName == name {
+		errMsg := "The new name of the tag has to be different from its current name"
+		serv.Warnf("[tenant: %v][user: %v]RenameTag: Tag %v: %v", user.TenantName, user.Username, body.Name, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	exist, tag, err := db.GetTagByName(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameTag at db.GetTagByName: Tag %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Tag %v does not exist", name)
+		serv.Warnf("[tenant: %v][user: %v]RenameTag: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	exist, _, err = db.GetTagByName(newName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameTag at db.GetTagByName: Tag %v: %v", user.TenantName, user.Username, newName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if exist {
+		errMsg := fmt.Sprintf("Tag with the name %v already exists, use merge to combine the two tags", newName)
+		serv.Warnf("[tenant: %v][user: %v]RenameTag: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	renamed, err := db.RenameTag(name, newName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameTag at db.RenameTag: Tag %v: %v", user.TenantName, user.Username, body.Name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !renamed {
+		errMsg := fmt.Sprintf("Tag %v does not exist", name)
+		serv.Warnf("[tenant: %v][user: %v]RenameTag: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("Tag %v has been renamed to %v by user %v", name, newName, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, models.CreateTag{Name: newName, Color: tag.Color})
+}
+
+func (th TagsHandler) MergeTags(c *gin.Context) {
+	var body models.MergeTagsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("MergeTags at getUserDetailsFromMiddleware: Tag %v: %v", body.SourceName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	sourceName := strings.ToLower(body.SourceName)
+	targetName := strings.ToLower(body.TargetName)
+	if sourceName == targetName {
+		errMsg := "A tag can not be merged into itself"
+		serv.Warnf("[tenant: %v][user: %v]MergeTags: Tag %v: %v", user.TenantName, user.Username, sourceName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	var target models.Tag
+	for _, name := range []string{sourceName, targetName} {
+		exist, tag, err := db.GetTagByName(name, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]MergeTags at db.GetTagByName: Tag %v: %v", user.TenantName, user.Username, name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if !exist {
+			errMsg := fmt.Sprintf("Tag %v does not exist", name)
+			serv.Warnf("[tenant: %v][user: %v]MergeTags: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		target = tag
+	}
+
+	err = db.MergeTags(sourceName, targetName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]MergeTags at db.MergeTags: Tag %v into %v: %v", user.TenantName, user.Username, sourceName, targetName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Tag %v has been merged into tag %v by user %v", sourceName, targetName, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, models.CreateTag{Name: target.Name, Color: target.Color})
+}
+
+func (th TagsHandler) GetTagResources(c *gin.Context) {
+	var body models.GetTagResourcesSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetTagResources at getUserDetailsFromMiddleware: Tag %v: %v", body.Name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	name := strings.ToLower(body.Name)
+	entity := strings.ToLower(body.EntityType)
+	if entity != _EMPTY_ {
+		err = validateEntityType(entity)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]GetTagResources at validateEntityType: Tag %v: %v", user.TenantName, user.Username, name, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	exist, _, err := db.GetTagByName(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetTagResources at db.GetTagByName: Tag %v: %v", user.TenantName, user.Username, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Tag %v does not exist", name)
+		serv.Warnf("[tenant: %v][user: %v]GetTagResources: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.PageSize == 0 {
+		body.PageSize = tagResourcesDefaultPageSize
+	}
+	if body.Page == 0 {
+		body.Page = 1
+	}
+	resources, total, err := db.GetTagResources(name, entity, user.TenantName, body.PageSize, (body.Page-1)*body.PageSize)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetTagResources at db.GetTagResources: Tag %v: %v", user.TenantName, user.Username, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, models.GetTagResourcesResponse{Name: name, Resources: resources, Total: total, Page: body.Page, PageSize: body.PageSize})
+}
+
+func (th TagsHandler) RemoveUnusedTags(c *gin.Context) {
+	var body models.RemoveUnusedTagsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveUnusedTags at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	if body.DryRun {
+		tags, err := db.GetUnusedTags(user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]RemoveUnusedTags at db.GetUnusedTags: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		c.IndentedJSON(200, gin.H{"tags": names, "dry_run": true})
+		return
+	}
+
+	names, err := db.RemoveUnusedTags(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveUnusedTags at db.RemoveUnusedTags: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if len(names) > 0 {
+		message := fmt.Sprintf("Unused tags %v have been removed by user %v", strings.Join(names, ", "), user.Username)
+		serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+
+	c.IndentedJSON(200, gin.H{"tags": names, "dry_run": false})
+}
@@ -0,0 +1,300 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamsHandler struct{}
+
+const myTeamFilter = "mine"
+
+func teamsAdmin(c *gin.Context, funcName string) (models.User, bool) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("%v at getUserDetailsFromMiddleware: %v", funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return user, false
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]%v: only management users can manage teams", user.TenantName, user.Username, funcName)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only management users can manage teams"})
+		return user, false
+	}
+	return user, true
+}
+
+// refreshCachedUsers reloads the given users into the user cache after their team has changed
+func refreshCachedUsers(tenantName string, usernames []string) {
+	for _, username := range usernames {
+		exist, user, err := memphis_cache.GetUser(username, tenantName, true)
+		if err != nil {
+			serv.Errorf("[tenant: %v]refreshCachedUsers at GetUser: User %v: %v", tenantName, username, err.Error())
+			continue
+		}
+		if !exist {
+			continue
+		}
+		err = memphis_cache.SetUser(user)
+		if err != nil {
+			serv.Errorf("[tenant: %v]refreshCachedUsers at SetUser: User %v: %v", tenantName, username, err.Error())
+		}
+	}
+}
+
+// resolveTeamFilter turns the team query param of the resources lists into the name of a team,
+// an empty result means the list is not filtered
+func resolveTeamFilter(user models.User, team string) (string, error) {
+	team = strings.ToLower(strings.TrimSpace(team))
+	if team != myTeamFilter {
+		return team, nil
+	}
+	exist, cachedUser, err := memphis_cache.GetUser(user.Username, user.TenantName, false)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if !exist || cachedUser.Team == _EMPTY_ {
+		return _EMPTY_, errors.New("you are not a member of any team")
+	}
+	return cachedUser.Team, nil
+}
+
+// teamFilterFromRequest reads the optional team filter of a resources list, it returns false when the
+// request was aborted
+func teamFilterFromRequest(c *gin.Context, user models.User, funcName string) (string, bool) {
+	var body models.TeamFilterSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return _EMPTY_, false
+	}
+	team, err := resolveTeamFilter(user, body.Team)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at resolveTeamFilter: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return _EMPTY_, false
+	}
+	return team, true
+}
+
+// teamMembers lowercases the requested usernames and returns the first one which does not exist in the tenant
+func teamMembers(usernames []string, tenantName string) ([]string, string, error) {
+	members := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		username = strings.ToLower(username)
+		exist, _, err := db.GetUserByUsername(username, tenantName)
+		if err != nil {
+			return nil, _EMPTY_, err
+		}
+		if !exist {
+			return nil, username, nil
+		}
+		members = append(members, username)
+	}
+	return members, _EMPTY_, nil
+}
+
+func (th TeamsHandler) GetTeams(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetTeams at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	teams, err := db.GetTeams(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetTeams at GetTeams: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, teams)
+}
+
+func (th TeamsHandler) CreateTeam(c *gin.Context) {
+	var body models.CreateTeamSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := teamsAdmin(c, "CreateTeam")
+	if !ok {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(body.Name))
+	err := validateUserTeam(name)
+	if err == nil && (name == _EMPTY_ || name == myTeamFilter) {
+		err = fmt.Errorf("%v is not a valid team name", body.Name)
+	}
+	if err == nil {
+		err = validateUserDescription(body.Description)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateTeam: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	team, err := db.InsertTeam(name, body.Description, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			serv.Warnf("[tenant: %v][user: %v]CreateTeam: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateTeam at InsertTeam: Team %v: %v", user.TenantName, user.Username, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Team %v has been created by user %v", name, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, team)
+}
+
+func (th TeamsHandler) RemoveTeam(c *gin.Context) {
+	var body models.RemoveTeamSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := teamsAdmin(c, "RemoveTeam")
+	if !ok {
+		return
+	}
+	name := strings.ToLower(body.Name)
+	exist, team, err := db.GetTeamByName(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveTeam at GetTeamByName: Team %v: %v", user.TenantName, user.Username, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Team %v does not exist", name)
+		serv.Warnf("[tenant: %v][user: %v]RemoveTeam: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	err = db.DeleteTeam(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveTeam at DeleteTeam: Team %v: %v", user.TenantName, user.Username, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	refreshCachedUsers(user.TenantName, team.Members)
+
+	message := fmt.Sprintf("Team %v has been removed by user %v", name, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (th TeamsHandler) AddTeamMembers(c *gin.Context) {
+	th.setTeamMembers(c, "AddTeamMembers", true)
+}
+
+func (th TeamsHandler) RemoveTeamMembers(c *gin.Context) {
+	th.setTeamMembers(c, "RemoveTeamMembers", false)
+}
+
+// setTeamMembers moves users in or out of a team, a user belongs to a single team
+// so adding a member of another team moves it to this one
+func (th TeamsHandler) setTeamMembers(c *gin.Context, funcName string, add bool) {
+	var body models.TeamMembersSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, ok := teamsAdmin(c, funcName)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(body.TeamName)
+	exist, team, err := db.GetTeamByName(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at GetTeamByName: Team %v: %v", user.TenantName, user.Username, funcName, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Team %v does not exist", name)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	members, unknownUser, err := teamMembers(body.Usernames, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at teamMembers: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if unknownUser != _EMPTY_ {
+		errMsg := fmt.Sprintf("User %v does not exist", unknownUser)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	newTeam := name
+	if !add {
+		newTeam = _EMPTY_
+		current := map[string]bool{}
+		for _, member := range team.Members {
+			current[member] = true
+		}
+		var teamMembers []string
+		for _, member := range members {
+			if current[member] {
+				teamMembers = append(teamMembers, member)
+			}
+		}
+		members = teamMembers
+	}
+	if len(members) > 0 {
+		err = db.SetUsersTeam(members, newTeam, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]%v at SetUsersTeam: Team %v: %v", user.TenantName, user.Username, funcName, name, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		refreshCachedUsers(user.TenantName, members)
+
+		var message string
+		if add {
+			message = fmt.Sprintf("Users %v have been added to team %v by user %v", strings.Join(members, ", "), name, user.Username)
+		} else {
+			message = fmt.Sprintf("Users %v have been removed from team %v by user %v", strings.Join(members, ", "), name, user.Username)
+		}
+		serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+	}
+
+	_, team, err = db.GetTeamByName(name, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at GetTeamByName: Team %v: %v", user.TenantName, user.Username, funcName, name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, team)
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/memphisdev/memphis/analytics"
 	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/middlewares"
 	"github.com/memphisdev/memphis/models"
 	"github.com/memphisdev/memphis/utils"
 
@@ -107,11 +108,32 @@ func updateDeletedUserResources(user models.User) error {
 		return err
 	}
 
+	err = db.UpdateProducersOfDeletedUser(user.Username, tenantName)
+	if err != nil {
+		return err
+	}
+
 	err = db.UpdateAuditLogsOfDeletedUser(user.ID)
 	if err != nil {
 		return err
 	}
 
+	err = db.DeleteImage(avatarImageName(user.Username), tenantName)
+	if err != nil {
+		return err
+	}
+
+	err = db.DeleteUserInvitation(user.Username, tenantName)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DeleteUserSessions(user.Username, _EMPTY_, tenantName)
+	if err != nil {
+		return err
+	}
+	middlewares.ForgetUserSessions(user.Username, tenantName)
+
 	return nil
 }
 
@@ -141,6 +163,11 @@ func removeTenantResources(tenantName string, user models.User) error {
 		return err
 	}
 
+	err = db.RemoveCommentsByTenant(tenantName)
+	if err != nil {
+		return err
+	}
+
 	err = db.RemoveAuditLogsByTenant(tenantName)
 	if err != nil {
 		return err
@@ -206,6 +233,16 @@ func removeTenantResources(tenantName string, user models.User) error {
 		return err
 	}
 
+	err = db.RemoveScramCredentialsByTenant(tenantName)
+	if err != nil {
+		return err
+	}
+
+	err = db.RemoveSessionsByTenant(tenantName)
+	if err != nil {
+		return err
+	}
+
 	SendUserDeleteCacheUpdate(users_list, tenantName)
 
 	err = db.DeleteConfByTenantName(tenantName)
@@ -257,7 +294,7 @@ type userToTokens interface {
 	models.User
 }
 
-func CreateTokens[U userToTokens](user U) (string, string, error) {
+func CreateTokens[U userToTokens](user U, sessionID string) (string, string, error) {
 	atClaims := jwt.MapClaims{}
 	var at *jwt.Token
 	switch u := any(user).(type) {
@@ -270,6 +307,7 @@ func CreateTokens[U userToTokens](user U) (string, string, error) {
 		atClaims["avatar_id"] = u.AvatarId
 		atClaims["exp"] = time.Now().Add(time.Minute * time.Duration(JWT_EXPIRES_IN_MINUTES)).Unix()
 		atClaims["tenant_name"] = u.TenantName
+		atClaims["session_id"] = sessionID
 		at = jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
 	}
 	token, err := at.SignedString([]byte(configuration.JWT_SECRET))
@@ -347,6 +385,14 @@ func (umh UserMgmtHandler) ChangePassword(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
 		return
 	}
+	_, err = db.DeleteUserSessions(username, _EMPTY_, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditPassword at DeleteUserSessions: User %v: %v", user.TenantName, user.Username, body.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	middlewares.ForgetUserSessions(username, user.TenantName)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Password of user %v has been changed by user %v", username, user.Username))
 	c.IndentedJSON(200, gin.H{})
 }
 
@@ -417,7 +463,13 @@ func (umh UserMgmtHandler) AddUserSignUp(c *gin.Context) {
 	}
 
 	serv.Noticef("User %v has been signed up", username)
-	token, refreshToken, err := CreateTokens(newUser)
+	sessionID, err := createSession(c, newUser)
+	if err != nil {
+		serv.Errorf("CreateUserSignUp error at createSession: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	token, refreshToken, err := CreateTokens(newUser, sessionID)
 	if err != nil {
 		serv.Errorf("CreateUserSignUp error at CreateTokens: %v", err.Error())
 		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
@@ -0,0 +1,277 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/middlewares"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	avatarImagePrefix  = "avatar_"
+	avatarMaxSizeBytes = 1024 * 1024
+)
+
+var avatarContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+}
+
+func avatarImageName(username string) string {
+	return avatarImagePrefix + strings.ToLower(username)
+}
+
+// readAvatar reads an uploaded avatar and returns it as a data uri,
+// the type is detected from the content and not from the file extension
+func readAvatar(file *multipart.FileHeader) (string, error) {
+	if file.Size > avatarMaxSizeBytes {
+		return _EMPTY_, fmt.Errorf("avatar exceeds the maximum allowed size of %vKB", avatarMaxSizeBytes/1024)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return _EMPTY_, err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, avatarMaxSizeBytes+1))
+	if err != nil {
+		return _EMPTY_, err
+	}
+	if len(content) > avatarMaxSizeBytes {
+		return _EMPTY_, fmt.Errorf("avatar exceeds the maximum allowed size of %vKB", avatarMaxSizeBytes/1024)
+	}
+	contentType := http.DetectContentType(content)
+	if !avatarContentTypes[contentType] {
+		return _EMPTY_, errors.New("avatar must be a png or jpeg image")
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(content), nil
+}
+
+func (umh UserMgmtHandler) ChangeMyPassword(c *gin.Context) {
+	var body models.ChangeMyPasswordSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ChangeMyPassword at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "management" {
+		errMsg := "Change password: only management users can change their own password, the root password is set by the deployment"
+		serv.Warnf("[tenant: %v][user: %v]ChangeMyPassword: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	exist, dbUser, err := db.GetUserByUsername(user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ChangeMyPassword at GetUserByUsername: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		serv.Warnf("[tenant: %v][user: %v]ChangeMyPassword: user does not exist", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "User does not exist"})
+		return
+	}
+	if !userPasswordMatches(dbUser, body.OldPassword) {
+		errMsg := "The current password is incorrect"
+		serv.Warnf("[tenant: %v][user: %v]ChangeMyPassword: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if body.OldPassword == body.NewPassword {
+		errMsg := "The new password must be different from the current one"
+		serv.Warnf("[tenant: %v][user: %v]ChangeMyPassword: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	err = validatePassword(body.NewPassword)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ChangeMyPassword at validatePassword: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(body.NewPassword), bcrypt.MinCost)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ChangeMyPassword at GenerateFromPassword: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = db.ChangeUserPassword(dbUser.Username, string(hashedPwd), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ChangeMyPassword at ChangeUserPassword: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	// the other sessions of the user are signed out, the one changing the password stays
+	_, err = db.DeleteUserSessions(dbUser.Username, getSessionIDFromMiddleware(c), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ChangeMyPassword at DeleteUserSessions: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	middlewares.ForgetUserSessions(dbUser.Username, user.TenantName)
+	refreshCachedUsers(user.TenantName, []string{dbUser.Username})
+
+	message := fmt.Sprintf("User %v has changed their password", user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (umh UserMgmtHandler) EditMyProfile(c *gin.Context) {
+	var body models.EditMyProfileSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("EditMyProfile at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	fullName := strings.TrimSpace(body.FullName)
+	err = validateUserFullName(fullName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]EditMyProfile at validateUserFullName: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	// the token carries a snapshot of the user, the rest of the profile is taken from the db
+	exist, dbUser, err := db.GetUserByUsername(user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditMyProfile at GetUserByUsername: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		serv.Warnf("[tenant: %v][user: %v]EditMyProfile: user does not exist", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "User does not exist"})
+		return
+	}
+	err = db.UpdateUserProfile(dbUser.Username, fullName, dbUser.Team, dbUser.Position, dbUser.Description, dbUser.AvatarId, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]EditMyProfile at UpdateUserProfile: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	refreshCachedUsers(user.TenantName, []string{dbUser.Username})
+
+	c.IndentedJSON(200, gin.H{
+		"id":          dbUser.ID,
+		"username":    dbUser.Username,
+		"user_type":   dbUser.UserType,
+		"full_name":   fullName,
+		"team":        dbUser.Team,
+		"position":    dbUser.Position,
+		"description": dbUser.Description,
+		"avatar_id":   dbUser.AvatarId,
+	})
+}
+
+func (umh UserMgmtHandler) UploadAvatar(c *gin.Context) {
+	var file multipart.FileHeader
+	ok := utils.Validate(c, nil, true, &file)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UploadAvatar at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	image, err := readAvatar(&file)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UploadAvatar at readAvatar: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	err = db.InsertImage(avatarImageName(user.Username), image, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UploadAvatar at InsertImage: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"image": image})
+}
+
+func (umh UserMgmtHandler) GetAvatar(c *gin.Context) {
+	var body models.GetAvatarSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetAvatar at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	username := user.Username
+	if body.Username != _EMPTY_ {
+		username = body.Username
+	}
+
+	exist, image, err := db.GetImage(avatarImageName(username), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetAvatar at GetImage: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		c.IndentedJSON(200, gin.H{"image": _EMPTY_})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"image": image.Image})
+}
+
+func (umh UserMgmtHandler) RemoveAvatar(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveAvatar at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	err = db.DeleteImage(avatarImageName(user.Username), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveAvatar at DeleteImage: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{})
+}
@@ -0,0 +1,308 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VaultHandler exposes the credentials API called by Vault's database secrets engine plugin,
+// every issued application user is removed once its lease expires or is revoked
+type VaultHandler struct{}
+
+const (
+	dynamicUsernameDefaultPrefix = "vault"
+	dynamicUsernameSuffixLen     = 4
+	dynamicPasswordLen           = 20
+)
+
+func generateDynamicUsername(prefix string) (string, error) {
+	if prefix == _EMPTY_ {
+		prefix = dynamicUsernameDefaultPrefix
+	}
+	raw := make([]byte, dynamicUsernameSuffixLen)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	username := strings.ToLower(prefix) + "-" + hex.EncodeToString(raw)
+	err = validateUsername(username)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return username, nil
+}
+
+func generateDynamicPassword() string {
+	// makes sure the generated password satisfies validatePassword
+	for {
+		password := generateRandomPassword(dynamicPasswordLen)
+		if validatePassword(password) == nil {
+			return password
+		}
+	}
+}
+
+func removeDynamicUser(userToRemove models.User) error {
+	err := updateDeletedUserResources(userToRemove)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteUser(userToRemove.Username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteDynamicCredential(userToRemove.Username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteScramCredentialsByUsername(userToRemove.Username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	SendUserDeleteCacheUpdate([]string{userToRemove.Username}, userToRemove.TenantName)
+
+	if configuration.USER_PASS_BASED_AUTH {
+		return serv.SendReloadSignal()
+	}
+	err = db.DeleteConnectionTokensByUsername(userToRemove.Username, userToRemove.TenantName)
+	if err != nil {
+		return err
+	}
+	// without a reload nothing checks the live connections of the user again, so they are closed on every broker
+	_, err = serv.requestConnectionsFromBrokers(CONN_DISCONNECT_SUBJ, connectionsRequest{TenantName: userToRemove.TenantName, Username: userToRemove.Username})
+	return err
+}
+
+func (s *Server) RemoveExpiredDynamicCredentials() {
+	ticker := time.NewTicker(1 * time.Minute)
+	for range ticker.C {
+		if !s.leadsBackgroundTask("RemoveExpiredDynamicCredentials", time.Minute) { // logic happens once only on the leader
+			continue
+		}
+		credentials, err := db.GetExpiredDynamicCredentials()
+		if err != nil {
+			s.Errorf("RemoveExpiredDynamicCredentials at GetExpiredDynamicCredentials: %v", err.Error())
+			continue
+		}
+		for _, credential := range credentials {
+			exist, user, err := memphis_cache.GetUser(credential.Username, credential.TenantName, true)
+			if err != nil {
+				s.Errorf("[tenant: %v][user: %v]RemoveExpiredDynamicCredentials at GetUser: %v", credential.TenantName, credential.Username, err.Error())
+				continue
+			}
+			if !exist {
+				err = db.DeleteDynamicCredential(credential.Username, credential.TenantName)
+				if err != nil {
+					s.Errorf("[tenant: %v][user: %v]RemoveExpiredDynamicCredentials at DeleteDynamicCredential: %v", credential.TenantName, credential.Username, err.Error())
+				}
+				continue
+			}
+			err = removeDynamicUser(user)
+			if err != nil {
+				s.Errorf("[tenant: %v][user: %v]RemoveExpiredDynamicCredentials at removeDynamicUser: %v", credential.TenantName, credential.Username, err.Error())
+				continue
+			}
+			s.Noticef("[tenant: %v]Dynamic user %v has expired and was removed", credential.TenantName, credential.Username)
+		}
+	}
+}
+
+func (vh VaultHandler) CreateCredentials(c *gin.Context) {
+	var body models.CreateDynamicCredentialsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateCredentials at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	username, err := generateDynamicUsername(body.UsernamePrefix)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateCredentials at generateDynamicUsername: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	var password, encryptedPassword string
+	if configuration.USER_PASS_BASED_AUTH {
+		password = generateDynamicPassword()
+		encryptedPassword, err = EncryptAES([]byte(password))
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at EncryptAES: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	newUser, err := db.CreateUser(username, "application", encryptedPassword, _EMPTY_, false, 1, user.TenantName, false, _EMPTY_, _EMPTY_, user.Username, "issued by vault")
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateCredentials at CreateUser: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	expiresAt := time.Now().Add(time.Duration(body.TtlSeconds) * time.Second)
+	_, err = db.InsertDynamicCredential(username, user.Username, expiresAt, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateCredentials at InsertDynamicCredential: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	if configuration.USER_PASS_BASED_AUTH {
+		err = storeScramCredential(username, user.TenantName, scramPasswordSecret, password)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at storeScramCredential: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		err = serv.SendReloadSignal()
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at SendReloadSignal: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	} else {
+		// the dynamic user gets a connection token of its own instead of the global one
+		password, err = generateConnectionToken()
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at generateConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		encryptedToken, err := EncryptAES([]byte(password))
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at EncryptAES: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		err = db.RotateConnectionToken(username, encryptedToken, _EMPTY_, time.Now(), user.Username, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at RotateConnectionToken: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		err = storeScramCredential(username, user.TenantName, hashConnectionToken(password), password)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]CreateCredentials at storeScramCredential: User %v: %v", user.TenantName, user.Username, username, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	}
+
+	err = memphis_cache.SetUser(newUser)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateCredentials at writing to the user cache error: %v", user.TenantName, user.Username, err)
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Dynamic user %v has been created until %v", user.TenantName, user.Username, username, expiresAt.Format(time.RFC3339))
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Dynamic user %v has been created by user %v", username, user.Username))
+	c.IndentedJSON(200, gin.H{
+		"username":                username,
+		"broker_connection_creds": password,
+		"expires_at":              expiresAt,
+	})
+}
+
+func (vh VaultHandler) RenewCredentials(c *gin.Context) {
+	var body models.RenewDynamicCredentialsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RenewCredentials at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	username := strings.ToLower(body.Username)
+	expiresAt := time.Now().Add(time.Duration(body.TtlSeconds) * time.Second)
+	exist, err := db.UpdateDynamicCredentialExpiration(username, expiresAt, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenewCredentials at UpdateDynamicCredentialExpiration: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Dynamic user %v does not exist or has already expired", username)
+		serv.Warnf("[tenant: %v][user: %v]RenewCredentials: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"username": username, "expires_at": expiresAt})
+}
+
+func (vh VaultHandler) RevokeCredentials(c *gin.Context) {
+	var body models.RevokeDynamicCredentialsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RevokeCredentials at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	username := strings.ToLower(body.Username)
+	exist, _, err := db.GetDynamicCredential(username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeCredentials at GetDynamicCredential: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Dynamic user %v does not exist", username)
+		serv.Warnf("[tenant: %v][user: %v]RevokeCredentials: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	exist, userToRemove, err := memphis_cache.GetUser(username, user.TenantName, true)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeCredentials at GetUser: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		err = db.DeleteDynamicCredential(username, user.TenantName)
+	} else {
+		err = removeDynamicUser(userToRemove)
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RevokeCredentials at removeDynamicUser: User %v: %v", user.TenantName, user.Username, username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Dynamic user %v has been revoked", user.TenantName, user.Username, username)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Dynamic user %v has been revoked by user %v", username, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
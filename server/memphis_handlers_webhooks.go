@@ -0,0 +1,188 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhooksHandler struct{}
+
+func (wh WebhooksHandler) CreateWebhook(c *gin.Context) {
+	var body models.CreateWebhookSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CreateWebhook at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	err = validateWebhookUrl(body.Url)
+	if err == nil {
+		err = validateWebhookEventTypes(body.EventTypes)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]CreateWebhook: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateWebhook at generateWebhookSecret: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	encryptedSecret, err := EncryptAES([]byte(secret))
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CreateWebhook at EncryptAES: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	webhook, err := db.InsertWebhook(body.Name, body.Url, encryptedSecret, body.EventTypes, user.Username, user.TenantName)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			errMsg := fmt.Sprintf("Webhook %v already exists", body.Name)
+			serv.Warnf("[tenant: %v][user: %v]CreateWebhook: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		serv.Errorf("[tenant: %v][user: %v]CreateWebhook at InsertWebhook: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Webhook %v has been created", user.TenantName, user.Username, webhook.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Webhook %v has been created by user %v", webhook.Name, user.Username))
+	// the signing secret is returned only on creation
+	c.IndentedJSON(200, models.CreateWebhookResponse{Webhook: webhook, Secret: secret})
+}
+
+func (wh WebhooksHandler) GetWebhooks(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetWebhooks at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	webhooks, err := db.GetWebhooksByTenant(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetWebhooks at GetWebhooksByTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, webhooks)
+}
+
+func (wh WebhooksHandler) UpdateWebhook(c *gin.Context) {
+	var body models.UpdateWebhookSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateWebhook at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, webhook, err := db.GetWebhookById(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateWebhook at GetWebhookById: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Webhook %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]UpdateWebhook: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	if body.Url != _EMPTY_ {
+		err = validateWebhookUrl(body.Url)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]UpdateWebhook at validateWebhookUrl: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		webhook.Url = body.Url
+	}
+	if len(body.EventTypes) > 0 {
+		err = validateWebhookEventTypes(body.EventTypes)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]UpdateWebhook at validateWebhookEventTypes: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		webhook.EventTypes = body.EventTypes
+	}
+	if body.Enabled != nil {
+		webhook.Enabled = *body.Enabled
+	}
+
+	webhook, err = db.UpdateWebhook(webhook.ID, webhook.Url, webhook.EventTypes, webhook.Enabled, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateWebhook at UpdateWebhook: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Webhook %v has been updated", user.TenantName, user.Username, webhook.Name)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Webhook %v has been updated by user %v", webhook.Name, user.Username))
+	c.IndentedJSON(200, webhook)
+}
+
+func (wh WebhooksHandler) RemoveWebhook(c *gin.Context) {
+	var body models.RemoveWebhookSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveWebhook at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	exist, err := db.DeleteWebhook(body.ID, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveWebhook at DeleteWebhook: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Webhook %v does not exist", body.ID)
+		serv.Warnf("[tenant: %v][user: %v]RemoveWebhook: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	serv.Noticef("[tenant: %v][user: %v]Webhook %v has been removed", user.TenantName, user.Username, body.ID)
+	createAuditLogFromRequest(c, user, _EMPTY_, fmt.Sprintf("Webhook %v has been removed by user %v", body.ID, user.Username))
+	c.IndentedJSON(200, gin.H{})
+}
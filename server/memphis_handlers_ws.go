@@ -28,6 +28,7 @@ import (
 const (
 	memphisWS_SubscribeMsg              = "SUB"
 	memphisWS_UnsubscribeMsg            = "UNSUB"
+	memphisWS_RefreshMsg                = "REFRESH"
 	memphisWS_Subj_Subs                 = "$memphis_ws_subs.>"
 	memphisWs_Cgroup_Subs               = "$memphis_ws_subs_cg"
 	memphisWS_TemplSubj_Publish         = "$memphis_ws_pubs.%s"
@@ -36,6 +37,8 @@ const (
 	memphisWS_Subj_PoisonMsgJourneyData = "poison_message_journey_data"
 	memphisWS_Subj_AllStationsData      = "get_all_stations_data"
 	memphisWS_Subj_SysLogsData          = "syslogs_data"
+	memphisWS_Subj_SysLogsTail          = "syslogs_tail"
+	memphisWS_Subj_StationMessagesTail  = "station_messages_tail"
 	memphisWS_Subj_AllSchemasData       = "get_all_schema_data"
 	memphisWS_Subj_GetSystemMessages    = "get_system_messages"
 	memphisWS_subj_GetAsyncTasks        = "get_async_tasks"
@@ -43,8 +46,14 @@ const (
 	memphisWS_subj_GetAllFunctions      = "get_all_functions"
 	memphisWS_subj_GetGraphOverview     = "get_graph_overview"
 	memphisWS_subj_GetFunctionsOverview = "get_functions_overview"
+	memphisWS_subj_GetJobs              = "get_jobs"
+	memphisWS_Subj_CgRebalanceData      = "cg_rebalance_data"
+	memphisWS_MinRefreshInterval        = time.Second
 )
 
+// memphisWSLastRefresh rate limits the on demand refreshes of a subscription
+var memphisWSLastRefresh = NewConcurrentMap[time.Time]()
+
 type memphisWSReqFiller func(tenantName string) (any, error)
 type memphisWSReqTenantsToFiller struct {
 	tenants map[string]memphisWSReqFiller
@@ -53,6 +62,7 @@ type memphisWSReqTenantsToFiller struct {
 func (s *Server) initWS() {
 	ws := &s.memphis.ws
 	ws.subscriptions = NewConcurrentMap[memphisWSReqTenantsToFiller]()
+	ws.deltas = NewConcurrentMap[*memphisWSDeltaState]()
 	handlers := Handlers{
 		Producers:  ProducersHandler{S: s},
 		Consumers:  ConsumersHandler{S: s},
@@ -71,6 +81,7 @@ func (s *Server) initWS() {
 }
 
 func deleteTenantFromSub(tenantName string, subs *concurrentMap[memphisWSReqTenantsToFiller], key string) {
+	memphisWSLastRefresh.Delete(memphisWSDeltaKey(key, tenantName))
 	subs.Lock()
 	defer subs.Unlock()
 	if f, ok := subs.m[key]; ok {
@@ -94,42 +105,18 @@ func addTenantToSub(tenantName string, subs *concurrentMap[memphisWSReqTenantsTo
 }
 
 func memphisWSLoop(s *Server, subs *concurrentMap[memphisWSReqTenantsToFiller], quitCh chan struct{}) {
-	ticker := time.NewTicker(ws_updates_interval_sec * time.Second)
+	interval := time.Duration(configuration.WS_UPDATES_INTERVAL_SEC) * time.Second
+	reportBackgroundTaskAlive("memphisWSLoop", interval)
+	ticker := time.NewTicker(interval)
 	for {
 		select {
 		case <-ticker.C:
+			reportBackgroundTaskAlive("memphisWSLoop", interval)
 			keys, values := subs.Array()
 			for i, updateFiller := range values {
 				k := keys[i]
-				replySubj := fmt.Sprintf(memphisWS_TemplSubj_Publish, k+"."+s.opts.ServerName)
 				for tenant, filler := range updateFiller.tenants {
-					acc, err := s.lookupAccount(tenant)
-					if err != nil {
-						s.Warnf("[tenant: %v]memphisWSLoop at lookupAccount: %v ", tenant, err.Error())
-						deleteTenantFromSub(tenant, subs, k)
-						continue
-					}
-					if !acc.SubscriptionInterest(replySubj) {
-						s.Debugf("removing tenant %v ws subscription %s", tenant, replySubj)
-						deleteTenantFromSub(tenant, subs, k)
-						continue
-					}
-					update, err := filler(tenant)
-					if err != nil {
-						if !IsNatsErr(err, JSStreamNotFoundErr) && !strings.Contains(err.Error(), "not exist") && !strings.Contains(err.Error(), "alphanumeric") {
-							s.Errorf("[tenant: %v]memphisWSLoop at filler: %v", tenant, err.Error())
-						}
-						deleteTenantFromSub(tenant, subs, k)
-						continue
-					}
-					updateRaw, err := json.Marshal(update)
-					if err != nil {
-						s.Errorf("[tenant: %v]memphisWSLoop at json.Marshal: %v", tenant, err.Error())
-						deleteTenantFromSub(tenant, subs, k)
-						continue
-					}
-
-					s.sendInternalAccountMsgWithEcho(acc, replySubj, updateRaw)
+					s.publishWSUpdate(subs, k, tenant, filler, true)
 				}
 			}
 		case <-quitCh:
@@ -139,6 +126,63 @@ func memphisWSLoop(s *Server, subs *concurrentMap[memphisWSReqTenantsToFiller],
 	}
 }
 
+// publishWSUpdate sends the current data of a subscription to the full and delta subjects which have interest,
+// dropIdle removes the subscription when no client listens anymore
+func (s *Server) publishWSUpdate(subs *concurrentMap[memphisWSReqTenantsToFiller], k, tenant string, filler memphisWSReqFiller, dropIdle bool) {
+	replySubj := fmt.Sprintf(memphisWS_TemplSubj_Publish, k+"."+s.opts.ServerName)
+	deltaSubj := fmt.Sprintf(memphisWS_TemplSubj_PublishDelta, k+"."+s.opts.ServerName)
+	acc, err := s.lookupAccount(tenant)
+	if err != nil {
+		s.Warnf("[tenant: %v]memphisWSLoop at lookupAccount: %v ", tenant, err.Error())
+		deleteTenantFromSub(tenant, subs, k)
+		s.deleteWSDeltaState(k, tenant)
+		return
+	}
+	fullInterest := acc.SubscriptionInterest(replySubj)
+	deltaInterest := acc.SubscriptionInterest(deltaSubj)
+	if !fullInterest && !deltaInterest {
+		if !dropIdle {
+			return
+		}
+		s.Debugf("removing tenant %v ws subscription %s", tenant, replySubj)
+		deleteTenantFromSub(tenant, subs, k)
+		s.deleteWSDeltaState(k, tenant)
+		return
+	}
+	update, err := filler(tenant)
+	if err != nil {
+		if !IsNatsErr(err, JSStreamNotFoundErr) && !strings.Contains(err.Error(), "not exist") && !strings.Contains(err.Error(), "alphanumeric") {
+			s.Errorf("[tenant: %v]memphisWSLoop at filler: %v", tenant, err.Error())
+		}
+		deleteTenantFromSub(tenant, subs, k)
+		s.deleteWSDeltaState(k, tenant)
+		return
+	}
+	updateRaw, err := json.Marshal(update)
+	if err != nil {
+		s.Errorf("[tenant: %v]memphisWSLoop at json.Marshal: %v", tenant, err.Error())
+		deleteTenantFromSub(tenant, subs, k)
+		s.deleteWSDeltaState(k, tenant)
+		return
+	}
+
+	if fullInterest {
+		s.sendInternalAccountMsgWithEcho(acc, replySubj, updateRaw)
+	}
+	if deltaInterest {
+		deltaRaw, err := s.buildWSDeltaMsg(k, tenant, updateRaw)
+		if err != nil {
+			s.Errorf("[tenant: %v]memphisWSLoop at buildWSDeltaMsg: %v", tenant, err.Error())
+			return
+		}
+		if deltaRaw != nil {
+			s.sendInternalAccountMsgWithEcho(acc, deltaSubj, deltaRaw)
+		}
+	} else {
+		s.deleteWSDeltaState(k, tenant)
+	}
+}
+
 func tokensFromToEnd(subject string, index uint8) string {
 	ti, start := uint8(1), 0
 	for i := 0; i < len(subject); i++ {
@@ -182,6 +226,35 @@ func (s *Server) createWSRegistrationHandler(h *Handlers) simplifiedMsgHandler {
 					s.Errorf("[tenant: %v]memphis websocket: %v", tenantName, err.Error())
 				}
 			}
+			// a (re)registering client gets the full state right away instead of waiting for the next tick
+			s.requestWSFullResync(filteredSubj, tenantName)
+			go s.publishWSUpdate(subscriptions, filteredSubj, tenantName, reqFiller, false)
+
+		case memphisWS_RefreshMsg:
+			// an on demand update of a subscription, limited so a client can not turn it into a busy loop
+			f, ok := subscriptions.Load(filteredSubj)
+			if !ok {
+				s.Warnf("[tenant: %v]memphis websocket: refresh of an unknown subscription %v", tenantName, filteredSubj)
+				break
+			}
+			subscriptions.Lock()
+			reqFiller, ok := f.tenants[tenantName]
+			subscriptions.Unlock()
+			if !ok {
+				s.Warnf("[tenant: %v]memphis websocket: refresh of an unknown subscription %v", tenantName, filteredSubj)
+				break
+			}
+			refreshKey := memphisWSDeltaKey(filteredSubj, tenantName)
+			memphisWSLastRefresh.Lock()
+			lastRefresh := memphisWSLastRefresh.m[refreshKey]
+			allowed := time.Since(lastRefresh) >= memphisWS_MinRefreshInterval
+			if allowed {
+				memphisWSLastRefresh.m[refreshKey] = time.Now()
+			}
+			memphisWSLastRefresh.Unlock()
+			if allowed {
+				go s.publishWSUpdate(subscriptions, filteredSubj, tenantName, reqFiller, false)
+			}
 
 		default:
 			s.Errorf("[tenant: %v]memphis websocket: invalid sub/unsub operation", tenantName)
@@ -210,7 +283,12 @@ func memphisWSGetReqFillerFromSubj(s *Server, h *Handlers, subj string, tenantNa
 		}, nil
 
 	case memphisWS_Subj_StationOverviewData:
-		splitedResp := strings.Split(subj, ".")
+		subjWithoutQuery, query, _ := strings.Cut(subj, "?")
+		filter, err := stationClientsFilterFromQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		splitedResp := strings.Split(subjWithoutQuery, ".")
 		partitionNumberStr := splitedResp[len(splitedResp)-1]
 
 		partitionNumber, err := strconv.Atoi(partitionNumberStr)
@@ -223,7 +301,7 @@ func memphisWSGetReqFillerFromSubj(s *Server, h *Handlers, subj string, tenantNa
 			return nil, errors.New("invalid station name")
 		}
 		return func(string) (any, error) {
-			return memphisWSGetStationOverviewData(s, h, stationName, tenantName, partitionNumber)
+			return memphisWSGetStationOverviewData(s, h, stationName, tenantName, partitionNumber, filter)
 		}, nil
 
 	case memphisWS_Subj_PoisonMsgJourneyData:
@@ -250,6 +328,16 @@ func memphisWSGetReqFillerFromSubj(s *Server, h *Handlers, subj string, tenantNa
 		return func(string) (any, error) {
 			return memphisWSGetSystemLogs(h, logLevel, logSource)
 		}, nil
+	case memphisWS_Subj_SysLogsTail:
+		logLevel := tokenAt(subj, 2)
+		logSource := tokenAt(subj, 3)
+		return memphisWSGetSystemLogsTail(s, logLevel, logSource)
+	case memphisWS_Subj_StationMessagesTail:
+		stationName := strings.Join(strings.Split(subj, ".")[1:], ".")
+		if stationName == _EMPTY_ {
+			return nil, errors.New("invalid station name")
+		}
+		return memphisWSGetStationMessagesTail(s, subj, stationName, tenantName)
 	case memphisWS_Subj_AllSchemasData:
 		return func(string) (any, error) {
 			return h.Schemas.GetAllSchemasDetails(tenantName)
@@ -270,6 +358,29 @@ func memphisWSGetReqFillerFromSubj(s *Server, h *Handlers, subj string, tenantNa
 		return func(string) (any, error) {
 			return h.Monitoring.getGraphOverview(tenantName)
 		}, nil
+	case memphisWS_subj_GetJobs:
+		return func(string) (any, error) {
+			return h.Jobs.GetJobsForTenant(tenantName, _EMPTY_, _EMPTY_)
+		}, nil
+	case memphisWS_Subj_CgRebalanceData:
+		stationName, cgName, err := cgRebalanceWSParamsFromSubj(subj)
+		if err != nil {
+			return nil, err
+		}
+		return func(string) (any, error) {
+			sn, err := StationNameFromStr(stationName)
+			if err != nil {
+				return nil, err
+			}
+			exist, station, err := db.GetStationByName(sn.Ext(), tenantName)
+			if err != nil {
+				return nil, err
+			}
+			if !exist {
+				return nil, errors.New("Station " + stationName + " does not exist")
+			}
+			return h.Consumers.getCgRebalanceInfo(sn, station, strings.ToLower(cgName))
+		}, nil
 	case memphisWS_subj_GetFunctionsOverview:
 		return func(string) (any, error) {
 			stationName := tokenAt(subj, 2)
@@ -285,7 +396,7 @@ func memphisWSGetReqFillerFromSubj(s *Server, h *Handlers, subj string, tenantNa
 	}
 }
 
-func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string, tenantName string, partitionNumber int) (map[string]any, error) {
+func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string, tenantName string, partitionNumber int, filter models.StationClientsFilter) (map[string]any, error) {
 	sn, err := StationNameFromStr(stationName)
 	if err != nil {
 		return map[string]any{}, err
@@ -306,8 +417,9 @@ func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string,
 	}
 
 	connectedProducers, disconnectedProducers, deletedProducers := make([]models.ExtendedProducerResponse, 0), make([]models.ExtendedProducerResponse, 0), make([]models.ExtendedProducerResponse, 0)
+	var producersCount, cgsCount models.StationClientsCount
 	if station.IsNative {
-		connectedProducers, disconnectedProducers, deletedProducers, err = h.Producers.GetProducersByStation(station)
+		connectedProducers, disconnectedProducers, deletedProducers, producersCount, err = h.Producers.GetProducersByStation(station, filter)
 		if err != nil {
 			return map[string]any{}, err
 		}
@@ -358,7 +470,7 @@ func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string,
 	connectedCgs, disconnectedCgs, deletedCgs := make([]models.Cg, 0), make([]models.Cg, 0), make([]models.Cg, 0)
 	// Only native stations have CGs
 	if station.IsNative {
-		connectedCgs, disconnectedCgs, deletedCgs, err = h.Consumers.GetCgsByStation(sn, station)
+		connectedCgs, disconnectedCgs, deletedCgs, cgsCount, err = h.Consumers.GetCgsByStation(sn, station, filter)
 		if err != nil {
 			return map[string]any{}, err
 		}
@@ -475,6 +587,9 @@ func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string,
 			}
 		}
 
+		response["producers_count"] = producersCount
+		response["cgs_count"] = cgsCount
+		response["read_only"] = station.ReadOnly
 		return response, nil
 	}
 
@@ -525,6 +640,9 @@ func memphisWSGetStationOverviewData(s *Server, h *Handlers, stationName string,
 		"act_as_dls_station_in_stations":  usedAsDlsStations,
 	}
 
+	response["producers_count"] = producersCount
+	response["cgs_count"] = cgsCount
+	response["read_only"] = station.ReadOnly
 	return response, nil
 }
 
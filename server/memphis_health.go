@@ -0,0 +1,116 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthStatusOk    = "ok"
+	healthStatusError = "error"
+	// a background task is considered stuck after missing this many of its iterations
+	backgroundTaskMissedIterations = 3
+)
+
+type backgroundTaskLiveness struct {
+	interval time.Duration
+	lastSeen time.Time
+}
+
+type dependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	backgroundTasksStarted atomic.Bool
+	backgroundTasksMu      sync.Mutex
+	backgroundTasks        = make(map[string]backgroundTaskLiveness)
+)
+
+// reportBackgroundTaskAlive is called by periodic background tasks on every iteration, interval is the
+// expected time between two calls
+func reportBackgroundTaskAlive(name string, interval time.Duration) {
+	backgroundTasksMu.Lock()
+	defer backgroundTasksMu.Unlock()
+	backgroundTasks[name] = backgroundTaskLiveness{interval: interval, lastSeen: time.Now()}
+}
+
+func backgroundTasksStatus() dependencyStatus {
+	if !backgroundTasksStarted.Load() {
+		return dependencyStatus{Status: healthStatusError, Error: "background tasks have not been started yet"}
+	}
+	backgroundTasksMu.Lock()
+	defer backgroundTasksMu.Unlock()
+	for name, task := range backgroundTasks {
+		if time.Since(task.lastSeen) > backgroundTaskMissedIterations*task.interval {
+			return dependencyStatus{Status: healthStatusError, Error: "background task " + name + " is not running since " + task.lastSeen.Format(time.RFC3339)}
+		}
+	}
+	return dependencyStatus{Status: healthStatusOk}
+}
+
+func metadataDbStatus() dependencyStatus {
+	err := db.PingMetadataDb()
+	if err != nil {
+		return dependencyStatus{Status: healthStatusError, Error: err.Error()}
+	}
+	return dependencyStatus{Status: healthStatusOk}
+}
+
+func (s *Server) jetStreamStatus() dependencyStatus {
+	if !s.JetStreamEnabled() {
+		return dependencyStatus{Status: healthStatusError, Error: "jetstream is not enabled"}
+	}
+	if s.JetStreamIsClustered() && !s.JetStreamIsCurrent() {
+		return dependencyStatus{Status: healthStatusError, Error: "jetstream is not current with the meta leader"}
+	}
+	return dependencyStatus{Status: healthStatusOk}
+}
+
+func respondWithDependencies(c *gin.Context, dependencies map[string]dependencyStatus) {
+	status := healthStatusOk
+	for _, dependency := range dependencies {
+		if dependency.Status != healthStatusOk {
+			status = healthStatusError
+			break
+		}
+	}
+	code := 200
+	if status != healthStatusOk {
+		code = 503
+	}
+	c.JSON(code, gin.H{"status": status, "dependencies": dependencies})
+}
+
+// Healthz is the liveness probe, it fails only when the broker itself is stuck so a restart would help
+func (mh MonitoringHandler) Healthz(c *gin.Context) {
+	respondWithDependencies(c, map[string]dependencyStatus{
+		"background_tasks": backgroundTasksStatus(),
+	})
+}
+
+// Readyz is the readiness probe, it fails while one of the dependencies needed to serve requests is unavailable
+func (mh MonitoringHandler) Readyz(c *gin.Context) {
+	respondWithDependencies(c, map[string]dependencyStatus{
+		"metadata_db":      metadataDbStatus(),
+		"jetstream":        mh.S.jetStreamStatus(),
+		"background_tasks": backgroundTasksStatus(),
+	})
+}
@@ -29,6 +29,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/memphisdev/memphis/analytics"
 	"github.com/memphisdev/memphis/db"
 	"github.com/memphisdev/memphis/models"
 
@@ -77,6 +78,15 @@ const (
 	memphisStationDestructions  = "$memphis_station_destructions"
 )
 
+const (
+	memphisProducerBatchCreations    = "$memphis_producer_batch_creations"
+	memphisProducerBatchDestructions = "$memphis_producer_batch_destructions"
+	memphisConsumerBatchCreations    = "$memphis_consumer_batch_creations"
+	memphisConsumerBatchDestructions = "$memphis_consumer_batch_destructions"
+	maxRegistrationBatchSize         = 1000
+	memphisSdkHeartbeats             = "$memphis_sdk_heartbeats"
+)
+
 var noLimit = -1
 var enableJetStream = true
 
@@ -89,6 +99,11 @@ var memphisExportString = `[
 	{service: "$memphis_producer_destructions"},
 	{service: "$memphis_consumer_creations"},
 	{service: "$memphis_consumer_destructions"},
+	{service: "$memphis_producer_batch_creations"},
+	{service: "$memphis_producer_batch_destructions"},
+	{service: "$memphis_consumer_batch_creations"},
+	{service: "$memphis_consumer_batch_destructions"},
+	{service: "$memphis_sdk_heartbeats"},
 	{service: "$memphis_schema_attachments"},
 	{service: "$memphis_schema_detachments"},
 	{service: "$memphis_schema_creations"},
@@ -110,6 +125,11 @@ var memphisImportString = `[
 	{service: {account: "$memphis", subject: "$memphis_producer_destructions"}},
 	{service: {account: "$memphis", subject: "$memphis_consumer_creations"}},
 	{service: {account: "$memphis", subject: "$memphis_consumer_destructions"}},
+	{service: {account: "$memphis", subject: "$memphis_producer_batch_creations"}},
+	{service: {account: "$memphis", subject: "$memphis_producer_batch_destructions"}},
+	{service: {account: "$memphis", subject: "$memphis_consumer_batch_creations"}},
+	{service: {account: "$memphis", subject: "$memphis_consumer_batch_destructions"}},
+	{service: {account: "$memphis", subject: "$memphis_sdk_heartbeats"}},
 	{service: {account: "$memphis", subject: "$memphis_schema_attachments"}},
 	{service: {account: "$memphis", subject: "$memphis_schema_detachments"}},
 	{service: {account: "$memphis", subject: "$memphis_schema_creations"}},
@@ -176,6 +196,10 @@ func createReplyHandler(s *Server, respCh chan []byte) simplifiedMsgHandler {
 }
 
 func jsApiRequest[R any](tenantName string, s *Server, subject, kind string, msg []byte, resp *R) error {
+	return jsApiRequestWithHeaders(tenantName, s, subject, kind, nil, msg, resp)
+}
+
+func jsApiRequestWithHeaders[R any](tenantName string, s *Server, subject, kind string, hdr map[string]string, msg []byte, resp *R) error {
 	// use buffered lock to limit amount of concurrent jsapi requests
 	if s.memphis.jsApiMu != nil {
 		s.memphis.jsApiMu.Lock()
@@ -195,7 +219,7 @@ func jsApiRequest[R any](tenantName string, s *Server, subject, kind string, msg
 		return err
 	}
 
-	s.sendInternalAccountMsgWithReply(account, subject, reply, nil, msg, true)
+	s.sendInternalAccountMsgWithReply(account, subject, reply, hdr, msg, true)
 
 	// wait for response to arrive
 	var rawResp []byte
@@ -315,7 +339,7 @@ func tryCreateInternalJetStreamResources(s *Server, retentionDur time.Duration,
 			Subjects:     []string{syslogsStreamName + ".>"},
 			Retention:    LimitsPolicy,
 			MaxAge:       retentionDur,
-			MaxBytes:     v.JetStream.Config.MaxStore / 3, // tops third of the available storage
+			MaxBytes:     s.syslogsMaxBytes(v.JetStream.Config.MaxStore),
 			MaxConsumers: -1,
 			Discard:      DiscardOld,
 			Storage:      FileStorage,
@@ -702,6 +726,10 @@ func (s *Server) popFallbackLogs() {
 }
 
 func (s *Server) memphisAddStream(tenantName string, sc *StreamConfig) error {
+	return s.memphisAddStreamWithHeaders(tenantName, sc, nil)
+}
+
+func (s *Server) memphisAddStreamWithHeaders(tenantName string, sc *StreamConfig, hdr map[string]string) error {
 	requestSubject := fmt.Sprintf(JSApiStreamCreateT, sc.Name)
 
 	request, err := json.Marshal(sc)
@@ -710,7 +738,7 @@ func (s *Server) memphisAddStream(tenantName string, sc *StreamConfig) error {
 	}
 
 	var resp JSApiStreamCreateResponse
-	err = jsApiRequest(tenantName, s, requestSubject, kindCreateStream, request, &resp)
+	err = jsApiRequestWithHeaders(tenantName, s, requestSubject, kindCreateStream, hdr, request, &resp)
 	if err != nil {
 		return err
 	}
@@ -981,11 +1009,11 @@ func (s *Server) GetCgInfo(tenantName string, stationName StationName, cgName st
 	return cgInfo, nil
 }
 
-func (s *Server) RemoveStream(tenantName, streamName string) error {
+func (s *Server) RemoveStream(tenantName, streamName, requestID string) error {
 	requestSubject := fmt.Sprintf(JSApiStreamDeleteT, streamName)
 
 	var resp JSApiStreamDeleteResponse
-	err := jsApiRequest(tenantName, s, requestSubject, kindDeleteStream, []byte(_EMPTY_), &resp)
+	err := jsApiRequestWithHeaders(tenantName, s, requestSubject, kindDeleteStream, requestIDHeaders(requestID), []byte(_EMPTY_), &resp)
 	if err != nil {
 		return err
 	}
@@ -994,6 +1022,12 @@ func (s *Server) RemoveStream(tenantName, streamName string) error {
 }
 
 func (s *Server) PurgeStream(tenantName, streamName string, partitionNumber int) error {
+	_, err := s.PurgeStreamUpTo(tenantName, streamName, partitionNumber, 0)
+	return err
+}
+
+// PurgeStreamUpTo removes the messages of a station stream up to but not including seq, a zero seq removes all of them
+func (s *Server) PurgeStreamUpTo(tenantName, streamName string, partitionNumber int, seq uint64) (uint64, error) {
 	var streamAndPartition string
 	if partitionNumber == -1 {
 		streamAndPartition = streamName
@@ -1002,18 +1036,18 @@ func (s *Server) PurgeStream(tenantName, streamName string, partitionNumber int)
 	}
 	requestSubject := fmt.Sprintf(JSApiStreamPurgeT, streamAndPartition)
 
-	req := JSApiStreamPurgeRequest{Subject: streamAndPartition + ".final"}
+	req := JSApiStreamPurgeRequest{Subject: streamAndPartition + ".final", Sequence: seq}
 	rawRequest, err := json.Marshal(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	var resp JSApiStreamPurgeResponse
 	err = jsApiRequest(tenantName, s, requestSubject, kindPurgeStream, rawRequest, &resp)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return resp.ToError()
+	return resp.Purged, resp.ToError()
 }
 
 func (s *Server) Opts() *Options {
@@ -1280,13 +1314,8 @@ func (s *Server) GetMessagesFromPartition(station models.Station, streamName str
 			Size:       len(msg.Subject) + len(msg.Data) + len(msg.Header),
 		}
 
-		data := hex.EncodeToString(msg.Data)
-		if len(data) > 80 { // get the first chars for preview needs
-			data = data[0:80]
-		}
-		messageDetails.Data = data
-
 		var headersJson map[string]string
+		payload := msg.Data
 		if stationIsNative {
 			if msg.Header != nil {
 				headersJson, err = DecodeHeader(msg.Header)
@@ -1294,6 +1323,11 @@ func (s *Server) GetMessagesFromPartition(station models.Station, streamName str
 					return nil, err
 				}
 			}
+			payload, err = decompressStationMessage(headersJson, msg.Data)
+			if err != nil {
+				// show the stored bytes rather than failing the whole list
+				payload = msg.Data
+			}
 			connectionIdHeader := headersJson["$memphis_connectionId"]
 			producedByHeader := strings.ToLower(headersJson["$memphis_producedBy"])
 
@@ -1312,6 +1346,12 @@ func (s *Server) GetMessagesFromPartition(station models.Station, streamName str
 			messageDetails.Partition = partition
 		}
 
+		data := hex.EncodeToString(payload)
+		if len(data) > 80 { // get the first chars for preview needs
+			data = data[0:80]
+		}
+		messageDetails.Data = data
+
 		messages = append(messages, messageDetails)
 	}
 
@@ -1395,6 +1435,7 @@ func (s *Server) memphisGetMsgs(tenantName, filterSubj, streamName string, start
 			dataLen -= len(CR_LF)
 
 			respCh <- StoredMsg{
+				Subject:  subject,
 				Sequence: uint64(seq),
 				Header:   msg[:dataFirstIdx],
 				Data:     msg[dataFirstIdx : dataFirstIdx+dataLen],
@@ -1653,8 +1694,20 @@ func GetMemphisOpts(opts *Options) (*Options, error) {
 		opts.GCProducersConsumersRetentionHours = make(map[string]int)
 	}
 
+	analyticsCategories := make(map[string]bool)
+	for _, category := range analytics.Categories {
+		analyticsCategories[category] = true
+	}
+	analyticsDisabled := false
+
 	for _, conf := range configs {
 		switch conf.Key {
+		case "analytics":
+			// the single toggle which preceded the per category settings, still turns everything off
+			analyticsDisabled = conf.Value == "false"
+		case "analytics_" + analytics.CategoryUsage, "analytics_" + analytics.CategoryErrors, "analytics_" + analytics.CategoryFeatures:
+			v, _ := strconv.ParseBool(conf.Value)
+			analyticsCategories[strings.TrimPrefix(conf.Key, "analytics_")] = v
 		case "dls_retention":
 			v, _ := strconv.Atoi(conf.Value)
 			opts.DlsRetentionHours[conf.TenantName] = v
@@ -1678,6 +1731,12 @@ func GetMemphisOpts(opts *Options) (*Options, error) {
 			opts.GCProducersConsumersRetentionHours[conf.TenantName] = v
 		}
 	}
+	if analyticsDisabled {
+		for category := range analyticsCategories {
+			analyticsCategories[category] = false
+		}
+	}
+	opts.AnalyticsCategories = analyticsCategories
 
 	return opts, nil
 }
@@ -1949,11 +2008,11 @@ func (s *Server) MoveResourcesFromOldToNewDefaultAcc() error {
 			return err
 		}
 		stationsMap[station.ID] = station
-		err = s.CreateStream(MEMPHIS_GLOBAL_ACCOUNT, stationName, station.RetentionType, station.RetentionValue, station.StorageType, station.IdempotencyWindow, station.Replicas, station.TieredStorageEnabled, 0, false)
+		err = s.CreateStream(MEMPHIS_GLOBAL_ACCOUNT, stationName, station.RetentionType, station.RetentionValue, station.StorageType, station.IdempotencyWindow, station.Replicas, station.TieredStorageEnabled, 0, false, _EMPTY_)
 		if err != nil {
 			return err
 		}
-		err = s.RemoveStream(DEFAULT_GLOBAL_ACCOUNT, stationName.Intern())
+		err = s.RemoveStream(DEFAULT_GLOBAL_ACCOUNT, stationName.Intern(), _EMPTY_)
 		if err != nil {
 			return err
 		}
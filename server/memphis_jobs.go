@@ -0,0 +1,409 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jobs are long running operations (imports, backfills, replays) tracked in the metadata db.
+// A job runs on the broker which started it, its progress is persisted and pushed to the UI at most once per interval.
+// Cancel and retry requests are published to all brokers and handled by the one running the job.
+const (
+	jobStatusRunning          = "running"
+	jobStatusCompleted        = "completed"
+	jobStatusFailed           = "failed"
+	jobStatusCancelled        = "cancelled"
+	jobProgressUpdateInterval = time.Second
+	jobsRetention             = 7 * 24 * time.Hour
+	jobsListLimit             = 200
+)
+
+type JobsHandler struct{}
+
+type jobType struct {
+	run func(ctx context.Context, jc *jobContext) error
+	// local jobs depend on files kept by the broker which started them, so they are retried there only
+	local bool
+	// cleanup releases what the job kept on its broker once the job record is removed
+	cleanup func(job models.Job)
+}
+
+var jobTypes = map[string]jobType{}
+
+// registerJobType is called from init functions, before any job can start
+func registerJobType(name string, jt jobType) {
+	jobTypes[name] = jt
+}
+
+// runningJobs holds the cancel functions of the jobs running on this broker
+var runningJobs = NewConcurrentMap[context.CancelFunc]()
+
+type jobContext struct {
+	s          *Server
+	job        models.Job
+	mu         sync.Mutex
+	progress   float64
+	result     any
+	lastUpdate time.Time
+}
+
+func (jc *jobContext) params(v any) error {
+	return json.Unmarshal(jc.job.Params, v)
+}
+
+// lastResult loads the result reported by a previous attempt of a retried job
+func (jc *jobContext) lastResult(v any) error {
+	if len(jc.job.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(jc.job.Result, v)
+}
+
+// setProgress records the progress percentage along with the partial result of the job
+func (jc *jobContext) setProgress(progress float64, result any) {
+	jc.mu.Lock()
+	jc.progress = progress
+	jc.result = result
+	if time.Since(jc.lastUpdate) < jobProgressUpdateInterval {
+		jc.mu.Unlock()
+		return
+	}
+	jc.lastUpdate = time.Now()
+	jc.mu.Unlock()
+
+	rawResult, err := json.Marshal(result)
+	if err != nil {
+		jc.s.Errorf("[tenant: %v]setProgress at Marshal: job %v: %v", jc.job.TenantName, jc.job.ID, err.Error())
+		return
+	}
+	err = db.UpdateJobProgress(jc.job.ID, progress, rawResult)
+	if err != nil {
+		jc.s.Errorf("[tenant: %v]setProgress at UpdateJobProgress: job %v: %v", jc.job.TenantName, jc.job.ID, err.Error())
+		return
+	}
+	jc.s.publishJobsWSUpdate(jc.job.TenantName)
+}
+
+func (jc *jobContext) finalState() (float64, []byte) {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	rawResult, err := json.Marshal(jc.result)
+	if err != nil || jc.result == nil {
+		rawResult = []byte("{}")
+	}
+	return jc.progress, rawResult
+}
+
+func jobKey(id int) string {
+	return strconv.Itoa(id)
+}
+
+func (s *Server) startJob(jobTypeName string, params any, user models.User) (models.Job, error) {
+	if _, ok := jobTypes[jobTypeName]; !ok {
+		return models.Job{}, fmt.Errorf("unknown job type %v", jobTypeName)
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job, err := db.InsertJob(jobTypeName, rawParams, s.opts.ServerName, user.Username, user.TenantName)
+	if err != nil {
+		return models.Job{}, err
+	}
+	s.runJob(job)
+	return job, nil
+}
+
+func (s *Server) runJob(job models.Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runningJobs.Add(jobKey(job.ID), cancel)
+	jc := &jobContext{s: s, job: job, progress: job.Progress, result: job.Result}
+	go func() {
+		defer runningJobs.Delete(jobKey(job.ID))
+		defer cancel()
+		err := jobTypes[job.Type].run(ctx, jc)
+
+		status, errMsg := jobStatusCompleted, _EMPTY_
+		progress, result := jc.finalState()
+		switch {
+		case ctx.Err() != nil:
+			status = jobStatusCancelled
+		case err != nil:
+			status = jobStatusFailed
+			errMsg = err.Error()
+			s.Errorf("[tenant: %v][user: %v]runJob: %v job %v failed: %v", job.TenantName, job.CreatedBy, job.Type, job.ID, errMsg)
+		default:
+			progress = 100
+		}
+		err = db.FinishJob(job.ID, status, progress, result, errMsg)
+		if err != nil {
+			s.Errorf("[tenant: %v]runJob at FinishJob: job %v: %v", job.TenantName, job.ID, err.Error())
+		}
+		s.publishJobsWSUpdate(job.TenantName)
+	}()
+}
+
+func (s *Server) publishJobUpdate(jobId int, operation string) error {
+	msg, err := json.Marshal(models.JobUpdate{JobId: jobId, Operation: operation})
+	if err != nil {
+		return err
+	}
+	s.sendInternalAccountMsg(s.MemphisGlobalAccount(), JOBS_UPDATES_SUBJ, msg)
+	return nil
+}
+
+// retryJob runs a failed or cancelled job again with the same params, it is a no-op when the job is in any other state
+func (s *Server) retryJob(jobId int) {
+	restarted, job, err := db.RestartJob(jobId, s.opts.ServerName)
+	if err != nil {
+		s.Errorf("retryJob at RestartJob: job %v: %v", jobId, err.Error())
+		return
+	}
+	if !restarted {
+		return
+	}
+	s.runJob(job)
+	s.publishJobsWSUpdate(job.TenantName)
+}
+
+// ListenForJobUpdates cancels and retries the jobs which belong to this broker
+func (s *Server) ListenForJobUpdates() error {
+	_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), JOBS_UPDATES_SUBJ, JOBS_UPDATES_SUBJ+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		go func(msg []byte) {
+			var update models.JobUpdate
+			err := json.Unmarshal(msg, &update)
+			if err != nil {
+				s.Errorf("ListenForJobUpdates at Unmarshal: %v", err.Error())
+				return
+			}
+			switch update.Operation {
+			case "cancel":
+				if cancel, ok := runningJobs.Load(jobKey(update.JobId)); ok {
+					cancel()
+				}
+			case "retry":
+				exist, job, err := db.GetJobById(update.JobId)
+				if err != nil {
+					s.Errorf("ListenForJobUpdates at GetJobById: job %v: %v", update.JobId, err.Error())
+					return
+				}
+				if exist && job.BrokerInCharge == s.opts.ServerName {
+					s.retryJob(job.ID)
+				}
+			}
+		}(copyBytes(msg))
+	})
+	return err
+}
+
+// FailInterruptedJobs runs on startup, jobs do not survive a restart of the broker running them
+func (s *Server) FailInterruptedJobs() {
+	err := db.FailInterruptedJobs(s.opts.ServerName)
+	if err != nil {
+		s.Errorf("FailInterruptedJobs: %v", err.Error())
+	}
+}
+
+func (s *Server) RemoveOldJobs() {
+	ticker := time.NewTicker(time.Hour)
+	for range ticker.C {
+		jobs, err := db.RemoveOldJobs(jobsRetention)
+		if err != nil {
+			s.Errorf("RemoveOldJobs: %v", err.Error())
+			continue
+		}
+		for _, job := range jobs {
+			if jt, ok := jobTypes[job.Type]; ok && jt.cleanup != nil && job.BrokerInCharge == s.opts.ServerName {
+				jt.cleanup(job)
+			}
+		}
+	}
+}
+
+// publishJobsWSUpdate pushes the jobs list right away to the UI clients of the tenant instead of waiting for the next tick
+func (s *Server) publishJobsWSUpdate(tenantName string) {
+	subs := s.memphis.ws.subscriptions
+	if subs == nil {
+		return
+	}
+	f, ok := subs.Load(memphisWS_subj_GetJobs)
+	if !ok {
+		return
+	}
+	subs.Lock()
+	filler, ok := f.tenants[tenantName]
+	subs.Unlock()
+	if ok {
+		go s.publishWSUpdate(subs, memphisWS_subj_GetJobs, tenantName, filler, false)
+	}
+}
+
+func (jh JobsHandler) GetJobsForTenant(tenantName, jobType, status string) ([]models.Job, error) {
+	return db.GetJobs(tenantName, jobType, status, jobsListLimit)
+}
+
+// getTenantJob loads a job and makes sure it belongs to the tenant of the user
+func getTenantJob(c *gin.Context, user models.User, jobId int, funcName string) (models.Job, bool) {
+	exist, job, err := db.GetJobById(jobId)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]%v at GetJobById: job %v: %v", user.TenantName, user.Username, funcName, jobId, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return models.Job{}, false
+	}
+	if !exist || job.TenantName != user.TenantName {
+		errMsg := fmt.Sprintf("Job %v does not exist", jobId)
+		serv.Warnf("[tenant: %v][user: %v]%v: %v", user.TenantName, user.Username, funcName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return models.Job{}, false
+	}
+	return job, true
+}
+
+func (jh JobsHandler) GetJobs(c *gin.Context) {
+	var body models.GetJobsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetJobs at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	jobs, err := jh.GetJobsForTenant(user.TenantName, body.Type, body.Status)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetJobs at GetJobsForTenant: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"jobs": jobs})
+}
+
+func (jh JobsHandler) GetJob(c *gin.Context) {
+	var body models.GetJobSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetJob at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	job, ok := getTenantJob(c, user, body.JobId, "GetJob")
+	if !ok {
+		return
+	}
+
+	c.IndentedJSON(200, job)
+}
+
+func (jh JobsHandler) CancelJob(c *gin.Context) {
+	var body models.JobIdSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("CancelJob at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	job, ok := getTenantJob(c, user, body.JobId, "CancelJob")
+	if !ok {
+		return
+	}
+	if job.Status != jobStatusRunning {
+		errMsg := fmt.Sprintf("Job %v is %v and can not be cancelled", job.ID, job.Status)
+		serv.Warnf("[tenant: %v][user: %v]CancelJob: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	err = serv.publishJobUpdate(job.ID, "cancel")
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]CancelJob at publishJobUpdate: job %v: %v", user.TenantName, user.Username, job.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("%v job %v has been cancelled by user %v", job.Type, job.ID, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
+
+func (jh JobsHandler) RetryJob(c *gin.Context) {
+	var body models.JobIdSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RetryJob at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	job, ok := getTenantJob(c, user, body.JobId, "RetryJob")
+	if !ok {
+		return
+	}
+	if job.Status != jobStatusFailed && job.Status != jobStatusCancelled {
+		errMsg := fmt.Sprintf("Job %v is %v, only failed or cancelled jobs can be retried", job.ID, job.Status)
+		serv.Warnf("[tenant: %v][user: %v]RetryJob: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	jt, ok := jobTypes[job.Type]
+	if !ok {
+		serv.Errorf("[tenant: %v][user: %v]RetryJob: job %v has an unknown type %v", user.TenantName, user.Username, job.ID, job.Type)
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if jt.local && job.BrokerInCharge != serv.opts.ServerName {
+		err = serv.publishJobUpdate(job.ID, "retry")
+	} else {
+		serv.retryJob(job.ID)
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RetryJob at publishJobUpdate: job %v: %v", user.TenantName, user.Username, job.ID, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("%v job %v has been retried by user %v", job.Type, job.ID, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
@@ -186,6 +186,9 @@ func (s *Server) memphisJSApiWrapStreamCreate(sub *subscription, c *client, _ *A
 		return
 	}
 	cfg.Name = streamName
+	if requestID := requestIDFromMsg(c, rmsg); requestID != _EMPTY_ {
+		s.Noticef("[request: %v]memphisJSApiWrapStreamCreate: creating stream %v", requestID, streamName)
+	}
 
 	go memphisCreateNonNativeStationIfNeeded(s, reply, cfg, c)
 
@@ -193,6 +196,9 @@ func (s *Server) memphisJSApiWrapStreamCreate(sub *subscription, c *client, _ *A
 }
 
 func (s *Server) memphisJSApiWrapStreamDelete(sub *subscription, c *client, acc *Account, subject, reply string, rmsg []byte) {
+	if requestID := requestIDFromMsg(c, rmsg); requestID != _EMPTY_ {
+		s.Noticef("[request: %v]memphisJSApiWrapStreamDelete: deleting stream %v", requestID, streamNameFromSubject(subject))
+	}
 	go memphisDeleteNonNativeStationIfNeeded(s, reply, streamNameFromSubject(subject), c)
 
 	s.jsStreamDeleteRequestIntern(sub, c, acc, subject, reply, rmsg)
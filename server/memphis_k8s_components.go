@@ -0,0 +1,236 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	k8scache "k8s.io/client-go/tools/cache"
+)
+
+const k8sInformersResyncInterval = 5 * time.Minute
+
+// k8sComponentsWatcher keeps a local cache of the namespace workloads so the
+// overview and the components endpoint don't hit the k8s API on every request
+type k8sComponentsWatcher struct {
+	deployments  appslisters.DeploymentLister
+	statefulSets appslisters.StatefulSetLister
+	pods         corelisters.PodLister
+	synced       atomic.Bool
+}
+
+var k8sWatcher k8sComponentsWatcher
+
+func isK8sEnv() bool {
+	return configuration.DOCKER_ENV != "true" && !configuration.LOCAL_CLUSTER_ENV
+}
+
+func (s *Server) StartK8sComponentsWatcher() {
+	if !isK8sEnv() {
+		return
+	}
+	if clientset == nil {
+		err := clientSetClusterConfig()
+		if err != nil {
+			s.Warnf("StartK8sComponentsWatcher: failed creating k8s client: %v", err.Error())
+			return
+		}
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, k8sInformersResyncInterval, informers.WithNamespace(s.opts.K8sNamespace))
+	k8sWatcher.deployments = factory.Apps().V1().Deployments().Lister()
+	k8sWatcher.statefulSets = factory.Apps().V1().StatefulSets().Lister()
+	k8sWatcher.pods = factory.Core().V1().Pods().Lister()
+	deploymentsSynced := factory.Apps().V1().Deployments().Informer().HasSynced
+	statefulSetsSynced := factory.Apps().V1().StatefulSets().Informer().HasSynced
+	podsSynced := factory.Core().V1().Pods().Informer().HasSynced
+
+	stopCh := make(chan struct{})
+	factory.Start(stopCh)
+	if !k8scache.WaitForCacheSync(stopCh, deploymentsSynced, statefulSetsSynced, podsSynced) {
+		s.Warnf("StartK8sComponentsWatcher: failed syncing k8s informers caches")
+		close(stopCh)
+		return
+	}
+	k8sWatcher.synced.Store(true)
+	s.Noticef("Kubernetes components watcher started for namespace %v", s.opts.K8sNamespace)
+}
+
+func listK8sDeployments(namespace string) ([]appsv1.Deployment, error) {
+	if !k8sWatcher.synced.Load() {
+		list, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	cached, err := k8sWatcher.deployments.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]appsv1.Deployment, 0, len(cached))
+	for _, d := range cached {
+		deployments = append(deployments, *d)
+	}
+	sort.Slice(deployments, func(i, j int) bool { return deployments[i].Name < deployments[j].Name })
+	return deployments, nil
+}
+
+func listK8sStatefulSets(namespace string) ([]appsv1.StatefulSet, error) {
+	if !k8sWatcher.synced.Load() {
+		list, err := clientset.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	cached, err := k8sWatcher.statefulSets.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	statefulSets := make([]appsv1.StatefulSet, 0, len(cached))
+	for _, s := range cached {
+		statefulSets = append(statefulSets, *s)
+	}
+	sort.Slice(statefulSets, func(i, j int) bool { return statefulSets[i].Name < statefulSets[j].Name })
+	return statefulSets, nil
+}
+
+func listK8sPods(namespace string) ([]v1.Pod, error) {
+	if !k8sWatcher.synced.Load() {
+		list, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	cached, err := k8sWatcher.pods.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]v1.Pod, 0, len(cached))
+	for _, p := range cached {
+		pods = append(pods, *p)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// workloadRestarts sums the container restarts of the pods owned by a workload
+func workloadRestarts(selector *metav1.LabelSelector, pods []v1.Pod) int {
+	if selector == nil {
+		return 0
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || sel.Empty() {
+		return 0
+	}
+	restarts := 0
+	for _, pod := range pods {
+		if !sel.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			restarts += int(cs.RestartCount)
+		}
+	}
+	return restarts
+}
+
+func getK8sWorkloads(namespace string) ([]models.K8sWorkload, error) {
+	if clientset == nil {
+		err := clientSetClusterConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	pods, err := listK8sPods(namespace)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := listK8sDeployments(namespace)
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := listK8sStatefulSets(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := []models.K8sWorkload{}
+	for _, d := range deployments {
+		desired := 1
+		if d.Spec.Replicas != nil {
+			desired = int(*d.Spec.Replicas)
+		}
+		workloads = append(workloads, models.K8sWorkload{
+			Name:            d.Name,
+			Kind:            "Deployment",
+			DesiredReplicas: desired,
+			ReadyReplicas:   int(d.Status.ReadyReplicas),
+			UpdatedReplicas: int(d.Status.UpdatedReplicas),
+			Restarts:        workloadRestarts(d.Spec.Selector, pods),
+		})
+	}
+	for _, s := range statefulSets {
+		desired := 1
+		if s.Spec.Replicas != nil {
+			desired = int(*s.Spec.Replicas)
+		}
+		workloads = append(workloads, models.K8sWorkload{
+			Name:            s.Name,
+			Kind:            "StatefulSet",
+			DesiredReplicas: desired,
+			ReadyReplicas:   int(s.Status.ReadyReplicas),
+			UpdatedReplicas: int(s.Status.UpdatedReplicas),
+			Restarts:        workloadRestarts(s.Spec.Selector, pods),
+		})
+	}
+	return workloads, nil
+}
+
+func (mh MonitoringHandler) GetK8sComponents(c *gin.Context) {
+	if !isK8sEnv() {
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Kubernetes components are available only on Kubernetes deployments"})
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetK8sComponents: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	workloads, err := getK8sWorkloads(mh.S.opts.K8sNamespace)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetK8sComponents: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, models.K8sComponentsResponse{
+		Namespace: mh.S.opts.K8sNamespace,
+		Cached:    k8sWatcher.synced.Load(),
+		Workloads: workloads,
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The produce, consume and ack paths of every station are timed on the broker which leads its streams:
+//   - produce is the time from the broker receiving a message until the stream stored it, or proposed it to
+//     the replicas of a clustered stream
+//   - consume is the time the broker took to deliver a message once a consumer could take it, from reading it
+//     out of the stream until it was queued to the client, waits of rate limited or replaying consumers excluded
+//   - ack is the time from a delivery until the consumer group acked it
+//
+// Operations slower than SLOW_OPERATION_MS are counted per station and operation, and summed up in one log line per
+// slowStationOperationsLogInterval along with the slowest one's client and message size. A backlog or a slow consumer
+// would otherwise log a line per message, from the delivery path.
+const (
+	stationOpProduce = "produce"
+	stationOpConsume = "consume"
+	stationOpAck     = "ack"
+
+	slowStationOperationsLogInterval = 30 * time.Second
+)
+
+// upper bounds of the latency histograms buckets, anything slower falls into the implicit +Inf bucket
+var latencyBuckets = [...]time.Duration{
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type latencyHistogram struct {
+	// counts per bucket, not cumulative, the last one is the +Inf bucket
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+	sumNs  atomic.Uint64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	idx := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	h.counts[idx].Add(1)
+	h.sumNs.Add(uint64(d))
+}
+
+// slowOperations sums up the slow operations of a station since the last summary was logged
+type slowOperations struct {
+	sync.Mutex
+	count   uint64
+	slowest time.Duration
+	size    int
+	client  string
+}
+
+func (so *slowOperations) add(d time.Duration, size int, client string) {
+	so.Lock()
+	defer so.Unlock()
+	so.count++
+	if d > so.slowest {
+		so.slowest, so.size, so.client = d, size, client
+	}
+}
+
+// take returns the sums and starts over
+func (so *slowOperations) take() (uint64, time.Duration, int, string) {
+	so.Lock()
+	defer so.Unlock()
+	count, slowest, size, client := so.count, so.slowest, so.size, so.client
+	so.count, so.slowest, so.size, so.client = 0, 0, 0, _EMPTY_
+	return count, slowest, size, client
+}
+
+type stationLatencyStats struct {
+	tenantName  string
+	streamName  string
+	produce     latencyHistogram
+	consume     latencyHistogram
+	ack         latencyHistogram
+	slowProduce slowOperations
+	slowConsume slowOperations
+	slowAck     slowOperations
+}
+
+func (st *stationLatencyStats) histogram(op string) *latencyHistogram {
+	switch op {
+	case stationOpProduce:
+		return &st.produce
+	case stationOpConsume:
+		return &st.consume
+	default:
+		return &st.ack
+	}
+}
+
+func (st *stationLatencyStats) slowOperations(op string) *slowOperations {
+	switch op {
+	case stationOpProduce:
+		return &st.slowProduce
+	case stationOpConsume:
+		return &st.slowConsume
+	default:
+		return &st.slowAck
+	}
+}
+
+// stationsLatencies maps tenant/stream to its *stationLatencyStats
+var stationsLatencies sync.Map
+
+func stationLatencyStatsKey(tenantName, streamName string) string {
+	return tenantName + "/" + streamName
+}
+
+// getStationLatencyStats returns the stats of a station stream, internal streams are not timed and get nil
+func getStationLatencyStats(acc *Account, streamName string) *stationLatencyStats {
+	if acc == nil || streamName == _EMPTY_ || strings.HasPrefix(streamName, "$memphis") {
+		return nil
+	}
+	tenantName := acc.GetName()
+	key := stationLatencyStatsKey(tenantName, streamName)
+	if st, ok := stationsLatencies.Load(key); ok {
+		return st.(*stationLatencyStats)
+	}
+	st, _ := stationsLatencies.LoadOrStore(key, &stationLatencyStats{tenantName: tenantName, streamName: streamName})
+	return st.(*stationLatencyStats)
+}
+
+func removeStationLatencyStats(acc *Account, streamName string) {
+	if acc == nil {
+		return
+	}
+	stationsLatencies.Delete(stationLatencyStatsKey(acc.GetName(), streamName))
+}
+
+// observe times a single operation and reports whether it was slow enough to be counted as a slow operation
+func (st *stationLatencyStats) observe(op string, d time.Duration) bool {
+	st.histogram(op).observe(d)
+	threshold := configuration.SLOW_OPERATION_MS
+	return threshold > 0 && d >= time.Duration(threshold)*time.Millisecond
+}
+
+// recordStationProduce is called by the stream loop once an inbound message was handled
+func (s *Server) recordStationProduce(st *stationLatencyStats, received time.Time, hdr, msg []byte) {
+	if st == nil {
+		return
+	}
+	d := time.Since(received)
+	if st.observe(stationOpProduce, d) {
+		st.slowProduce.add(d, len(hdr)+len(msg), string(getHeader("$memphis_producedBy", hdr)))
+	}
+}
+
+// LogSlowStationOperations logs a summary of the slow operations of every station once per interval
+func (s *Server) LogSlowStationOperations() {
+	ticker := time.NewTicker(slowStationOperationsLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quitCh:
+			return
+		case <-ticker.C:
+			s.logSlowStationOperations()
+		}
+	}
+}
+
+// logSlowStationOperations logs one line per station and operation which had slow operations since the last call,
+// the client is the producer or the consumer group behind the slowest one
+func (s *Server) logSlowStationOperations() {
+	stationsLatencies.Range(func(_, v interface{}) bool {
+		st := v.(*stationLatencyStats)
+		for _, op := range []string{stationOpProduce, stationOpConsume, stationOpAck} {
+			count, slowest, size, client := st.slowOperations(op).take()
+			if count == 0 {
+				continue
+			}
+			stationName, partition := splitPartitionStreamName(st.streamName)
+			details := "client " + client
+			if size > 0 {
+				details += fmt.Sprintf(", message size %v bytes", size)
+			}
+			s.Warnf("[tenant: %v]%v slow %v operations on station %v (partition %v) in the last %v, the slowest took %v: %v", st.tenantName, count, op, stationName.Ext(), partition, slowStationOperationsLogInterval, slowest.Round(time.Microsecond), details)
+		}
+		return true
+	})
+}
+
+func (s *Server) writeStationsLatencyMetrics(mw *metricsWriter) {
+	var stats []*stationLatencyStats
+	stationsLatencies.Range(func(_, v interface{}) bool {
+		stats = append(stats, v.(*stationLatencyStats))
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].tenantName != stats[j].tenantName {
+			return stats[i].tenantName < stats[j].tenantName
+		}
+		return stats[i].streamName < stats[j].streamName
+	})
+	for _, op := range []string{stationOpProduce, stationOpConsume, stationOpAck} {
+		for _, st := range stats {
+			stationName, partition := splitPartitionStreamName(st.streamName)
+			mw.addHistogram("memphis_station_"+op+"_latency_seconds", "Latency of the station "+op+" path on this broker", st.histogram(op), "tenant", st.tenantName, "station", stationName.Ext(), "partition", partition)
+		}
+	}
+}
+
+// recordStationOperation times the deliveries and acks of a consumer, the lock of the consumer is held
+func (o *consumer) recordStationOperation(op string, d time.Duration, size int) {
+	if o.latencyStats == nil {
+		if o.mset == nil || o.srv == nil {
+			return
+		}
+		o.latencyStats = getStationLatencyStats(o.acc, o.stream)
+		if o.latencyStats == nil {
+			return
+		}
+	}
+	if o.latencyStats.observe(op, d) {
+		o.latencyStats.slowOperations(op).add(d, size, revertDelimiters(o.name))
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramObserve(t *testing.T) {
+	cases := []struct {
+		name   string
+		d      time.Duration
+		bucket int
+	}{
+		{name: "negative counts as zero", d: -time.Second, bucket: 0},
+		{name: "first bucket", d: 100 * time.Microsecond, bucket: 0},
+		{name: "upper bound is inclusive", d: time.Millisecond, bucket: 1},
+		{name: "between bounds", d: 30 * time.Millisecond, bucket: 6},
+		{name: "slower than every bound", d: time.Minute, bucket: len(latencyBuckets)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var h latencyHistogram
+			h.observe(tc.d)
+			for i := range h.counts {
+				want := uint64(0)
+				if i == tc.bucket {
+					want = 1
+				}
+				if got := h.counts[i].Load(); got != want {
+					t.Fatalf("bucket %v holds %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStationLatencyStatsSlowOperations(t *testing.T) {
+	threshold := configuration.SLOW_OPERATION_MS
+	defer func() { configuration.SLOW_OPERATION_MS = threshold }()
+
+	cases := []struct {
+		name      string
+		threshold int
+		durations []time.Duration
+		wantCount uint64
+		slowest   time.Duration
+	}{
+		{name: "fast operations", threshold: 1000, durations: []time.Duration{time.Millisecond, 999 * time.Millisecond}, wantCount: 0},
+		{name: "slow operations are summed up", threshold: 1000, durations: []time.Duration{time.Second, 3 * time.Second, 2 * time.Second, time.Millisecond}, wantCount: 3, slowest: 3 * time.Second},
+		{name: "negative threshold disables the slow operations", threshold: -1, durations: []time.Duration{time.Minute}, wantCount: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration.SLOW_OPERATION_MS = tc.threshold
+			st := &stationLatencyStats{tenantName: "tenant", streamName: "orders"}
+			for i, d := range tc.durations {
+				if st.observe(stationOpConsume, d) {
+					st.slowOperations(stationOpConsume).add(d, i, "group")
+				}
+			}
+			count, slowest, _, _ := st.slowOperations(stationOpConsume).take()
+			if count != tc.wantCount || slowest != tc.slowest {
+				t.Fatalf("got %v slow operations, the slowest %v, want %v and %v", count, slowest, tc.wantCount, tc.slowest)
+			}
+			if count, _, _, _ := st.slowOperations(stationOpConsume).take(); count != 0 {
+				t.Fatalf("take did not start over")
+			}
+			if count, _, _, _ := st.slowOperations(stationOpProduce).take(); count != 0 {
+				t.Fatalf("consume operations were counted as produce")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const listDefaultPageSize = 50
+
+type listOptions struct {
+	sortBy   string
+	desc     bool
+	paged    bool
+	page     int
+	pageSize int
+	fields   []string
+}
+
+// listSortKeys maps the sort_by values of a list to comparisons of two of its items, a negative
+// result sorts a before b
+type listSortKeys[T any] map[string]func(a, b T) int
+
+func compareListStrings(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareListInts(a, b int) int {
+	return a - b
+}
+
+func compareListTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// listOptionsFromRequest reads the sorting, paging and field selection of a resources list and validates
+// them against the sort keys and the json fields of T, it returns false when the request was aborted
+func listOptionsFromRequest[T any](c *gin.Context, user models.User, funcName string, keys listSortKeys[T]) (listOptions, bool) {
+	var body models.ListOptionsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return listOptions{}, false
+	}
+	opts, err := newListOptions[T](body, keys)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]%v at newListOptions: %v", user.TenantName, user.Username, funcName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return listOptions{}, false
+	}
+	return opts, true
+}
+
+func newListOptions[T any](body models.ListOptionsSchema, keys listSortKeys[T]) (listOptions, error) {
+	opts := listOptions{
+		sortBy:   strings.ToLower(strings.TrimSpace(body.SortBy)),
+		paged:    body.Page > 0 || body.PageSize > 0,
+		page:     body.Page,
+		pageSize: body.PageSize,
+	}
+	if opts.sortBy != _EMPTY_ {
+		if _, ok := keys[opts.sortBy]; !ok {
+			return listOptions{}, fmt.Errorf("sort_by has to be one of %v", strings.Join(sortedListKeys(keys), ", "))
+		}
+	}
+	switch strings.ToLower(body.SortOrder) {
+	case _EMPTY_, "asc":
+	case "desc":
+		opts.desc = true
+	default:
+		return listOptions{}, fmt.Errorf("sort_order has to be asc or desc")
+	}
+	if opts.page == 0 {
+		opts.page = 1
+	}
+	if opts.pageSize == 0 {
+		opts.pageSize = listDefaultPageSize
+	}
+
+	if strings.TrimSpace(body.Fields) == _EMPTY_ {
+		return opts, nil
+	}
+	var zero T
+	available := listItemFields(reflect.TypeOf(zero))
+	for _, field := range strings.Split(body.Fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == _EMPTY_ {
+			continue
+		}
+		if !available[field] {
+			return listOptions{}, fmt.Errorf("%v is not a field of the list items", field)
+		}
+		opts.fields = append(opts.fields, field)
+	}
+	return opts, nil
+}
+
+func sortedListKeys[T any](keys listSortKeys[T]) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listItemFields returns the top level json names of a struct, the fields of embedded structs included
+func listItemFields(t reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == _EMPTY_ {
+			for embedded := range listItemFields(field.Type) {
+				fields[embedded] = true
+			}
+			continue
+		}
+		if name == _EMPTY_ {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// applyListOptions sorts the items and cuts out the requested page, it returns the result together with
+// the number of items before paging
+func applyListOptions[T any](items []T, opts listOptions, keys listSortKeys[T]) ([]T, int) {
+	if opts.sortBy != _EMPTY_ {
+		compare := keys[opts.sortBy]
+		sort.SliceStable(items, func(i, j int) bool {
+			if opts.desc {
+				return compare(items[j], items[i]) < 0
+			}
+			return compare(items[i], items[j]) < 0
+		})
+	}
+	total := len(items)
+	if !opts.paged {
+		return items, total
+	}
+	start := (opts.page - 1) * opts.pageSize
+	if start >= total {
+		return items[:0], total
+	}
+	end := start + opts.pageSize
+	if end > total {
+		end = total
+	}
+	return items[start:end], total
+}
+
+// listResponse trims the items to the selected fields and wraps them in a page when paging was requested
+func listResponse[T any](items []T, total int, opts listOptions) (interface{}, error) {
+	var result interface{} = items
+	if len(opts.fields) > 0 {
+		trimmed := make([]map[string]json.RawMessage, 0, len(items))
+		for _, item := range items {
+			raw, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			var all map[string]json.RawMessage
+			err = json.Unmarshal(raw, &all)
+			if err != nil {
+				return nil, err
+			}
+			selected := make(map[string]json.RawMessage, len(opts.fields))
+			for _, field := range opts.fields {
+				if value, ok := all[field]; ok {
+					selected[field] = value
+				}
+			}
+			trimmed = append(trimmed, selected)
+		}
+		result = trimmed
+	}
+	if !opts.paged {
+		return result, nil
+	}
+	return models.ListPage{Items: result, Total: total, Page: opts.page, PageSize: opts.pageSize}, nil
+}
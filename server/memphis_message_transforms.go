@@ -0,0 +1,195 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+const debeziumDeletedField = "__deleted"
+
+// debeziumSourceConnectors maps the cdc source types to the connector names debezium reports in the source block
+var debeziumSourceConnectors = map[string]string{
+	models.CdcSourcePostgres: "postgresql",
+	models.CdcSourceMongoDB:  "mongodb",
+}
+
+type debeziumSource struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	Db        string `json:"db"`
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table,omitempty"`
+	// mongodb events name the collection instead of a table
+	Collection string `json:"collection,omitempty"`
+	Position   string `json:"position"`
+}
+
+type debeziumEnvelope struct {
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	Source      debeziumSource  `json:"source"`
+	Op          string          `json:"op"`
+	TsMs        int64           `json:"ts_ms"`
+	Transaction json.RawMessage `json:"transaction"`
+}
+
+func validateMessageTransform(transform string, allowed ...string) error {
+	if transform == models.MessageTransformNone {
+		return nil
+	}
+	for _, t := range allowed {
+		if transform == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("transform must be empty or one of: %v", strings.Join(allowed, ", "))
+}
+
+func isJsonNull(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// applyMessageTransform rewrites a consumed message according to the transform, ok is false for messages the transform
+// filters out, these should be acked without being delivered
+func applyMessageTransform(transform string, data []byte) ([]byte, bool) {
+	switch transform {
+	case models.MessageTransformDebeziumUnwrap:
+		return debeziumUnwrap(data)
+	default:
+		return data, true
+	}
+}
+
+// debeziumUnwrap replaces a debezium change event with the row state it carries, the same way debezium's
+// ExtractNewRecordState does: inserts and updates become the after image, deletes become the before image flagged
+// with __deleted, tombstones and truncates are dropped. Messages which are not change events pass through untouched.
+// Events with the schemas.enable json converter layout are unwrapped from their payload first.
+func debeziumUnwrap(data []byte) ([]byte, bool) {
+	if isJsonNull(data) {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return data, true
+	}
+	if payload, ok := fields["payload"]; ok {
+		if _, hasSchema := fields["schema"]; hasSchema {
+			if isJsonNull(payload) {
+				return nil, false
+			}
+			var payloadFields map[string]json.RawMessage
+			if json.Unmarshal(payload, &payloadFields) != nil {
+				return data, true
+			}
+			fields = payloadFields
+		}
+	}
+
+	rawOp, ok := fields["op"]
+	if !ok {
+		return data, true
+	}
+	_, hasBefore := fields["before"]
+	_, hasAfter := fields["after"]
+	if !hasBefore && !hasAfter {
+		return data, true
+	}
+	var op string
+	if json.Unmarshal(rawOp, &op) != nil {
+		return data, true
+	}
+
+	switch op {
+	case models.CdcOpCreate, models.CdcOpUpdate, "r":
+		if isJsonNull(fields["after"]) {
+			return nil, false
+		}
+		return fields["after"], true
+	case models.CdcOpDelete:
+		if isJsonNull(fields["before"]) {
+			return nil, false
+		}
+		var row map[string]json.RawMessage
+		if json.Unmarshal(fields["before"], &row) != nil {
+			return fields["before"], true
+		}
+		row[debeziumDeletedField] = json.RawMessage(`"true"`)
+		unwrapped, err := json.Marshal(row)
+		if err != nil {
+			return fields["before"], true
+		}
+		return unwrapped, true
+	default:
+		return nil, false
+	}
+}
+
+// debeziumWrap renders a change event in the debezium envelope layout of the json converter without schemas
+func debeziumWrap(event models.CdcChangeEvent) ([]byte, error) {
+	envelope := debeziumEnvelope{
+		Before: event.Before,
+		After:  event.After,
+		Source: debeziumSource{
+			Version:   "memphis",
+			Connector: debeziumSourceConnectors[event.Source.Type],
+			Name:      event.Source.Connector,
+			TsMs:      event.TsMs,
+			Db:        event.Source.Database,
+			Schema:    event.Source.Schema,
+			Position:  event.Source.Position,
+		},
+		Op:          event.Op,
+		TsMs:        event.TsMs,
+		Transaction: json.RawMessage("null"),
+	}
+	if event.Source.Type == models.CdcSourceMongoDB {
+		envelope.Source.Collection = event.Source.Table
+	} else {
+		envelope.Source.Table = event.Source.Table
+	}
+	if envelope.Before == nil {
+		envelope.Before = json.RawMessage("null")
+	}
+	if envelope.After == nil {
+		envelope.After = json.RawMessage("null")
+	}
+	return json.Marshal(envelope)
+}
+
+// encodeCdcEvent renders a change event the way the connector transform asks for, ok is false when the unwrapped
+// event carries no row (truncates)
+func encodeCdcEvent(transform string, event models.CdcChangeEvent) ([]byte, bool, error) {
+	switch transform {
+	case models.MessageTransformDebeziumWrap:
+		payload, err := debeziumWrap(event)
+		return payload, true, err
+	case models.MessageTransformDebeziumUnwrap:
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, false, err
+		}
+		payload, ok := debeziumUnwrap(payload)
+		return payload, ok, nil
+	default:
+		payload, err := json.Marshal(event)
+		return payload, true, err
+	}
+}
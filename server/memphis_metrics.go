@@ -0,0 +1,210 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/server/pse"
+)
+
+const MetricsPath = "/metrics"
+
+type stationMetricKey struct {
+	tenantName  string
+	stationName string
+}
+
+var schemaValidationFailures = struct {
+	sync.Mutex
+	counts map[stationMetricKey]uint64
+}{counts: make(map[stationMetricKey]uint64)}
+
+func incrementSchemaValidationFailures(tenantName, stationName string) {
+	schemaValidationFailures.Lock()
+	defer schemaValidationFailures.Unlock()
+	schemaValidationFailures.counts[stationMetricKey{tenantName: tenantName, stationName: stationName}]++
+}
+
+type metricsWriter struct {
+	sb      strings.Builder
+	written map[string]bool
+}
+
+func escapeMetricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// add writes a single sample in the Prometheus text exposition format, labels are given as name/value pairs
+func (mw *metricsWriter) add(name, metricType, help string, value float64, labels ...string) {
+	mw.header(name, metricType, help)
+	mw.sample(name, value, labels...)
+}
+
+func (mw *metricsWriter) header(name, metricType, help string) {
+	if !mw.written[name] {
+		mw.written[name] = true
+		fmt.Fprintf(&mw.sb, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, metricType)
+	}
+}
+
+func (mw *metricsWriter) sample(name string, value float64, labels ...string) {
+	mw.sb.WriteString(name)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf(`%v="%v"`, labels[i], escapeMetricLabel(labels[i+1])))
+		}
+		mw.sb.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	mw.sb.WriteString(" " + strconv.FormatFloat(value, 'f', -1, 64) + "\n")
+}
+
+// addHistogram writes the cumulative buckets, sum and count series of a latency histogram in seconds
+func (mw *metricsWriter) addHistogram(name, help string, h *latencyHistogram, labels ...string) {
+	mw.header(name, "histogram", help)
+	cumulative := uint64(0)
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		le := "+Inf"
+		if i < len(latencyBuckets) {
+			le = strconv.FormatFloat(latencyBuckets[i].Seconds(), 'f', -1, 64)
+		}
+		mw.sample(name+"_bucket", float64(cumulative), append(append([]string{}, labels...), "le", le)...)
+	}
+	mw.sample(name+"_sum", float64(h.sumNs.Load())/float64(time.Second), labels...)
+	mw.sample(name+"_count", float64(cumulative), labels...)
+}
+
+func splitPartitionStreamName(streamName string) (StationName, string) {
+	partition := "-1"
+	if idx := strings.LastIndex(streamName, "$"); idx > 0 {
+		partition = streamName[idx+1:]
+		streamName = streamName[:idx]
+	}
+	return StationNameFromStreamName(streamName), partition
+}
+
+func (s *Server) writeProcessMetrics(mw *metricsWriter) {
+	var pcpu float64
+	var rss, vss int64
+	pse.ProcUsage(&pcpu, &rss, &vss)
+	mw.add("memphis_process_cpu_percent", "gauge", "CPU usage of the broker process", pcpu)
+	mw.add("memphis_process_resident_memory_bytes", "gauge", "Resident memory of the broker process", float64(rss))
+	mw.add("memphis_process_virtual_memory_bytes", "gauge", "Virtual memory of the broker process", float64(vss))
+	mw.add("memphis_process_start_time_seconds", "gauge", "Start time of the broker process since unix epoch", float64(s.start.Unix()))
+}
+
+func (s *Server) writeConnectionsMetrics(mw *metricsWriter) {
+	mw.add("memphis_connections", "gauge", "Number of client connections to the broker", float64(s.NumClients()))
+	s.accounts.Range(func(k, v interface{}) bool {
+		acc := v.(*Account)
+		if num := acc.NumLocalConnections(); num > 0 {
+			mw.add("memphis_tenant_connections", "gauge", "Number of client connections per tenant", float64(num), "tenant", acc.GetName())
+		}
+		return true
+	})
+}
+
+func (s *Server) writeStationsMetrics(mw *metricsWriter) error {
+	jsi, err := s.Jsz(&JSzOptions{Accounts: true, Streams: true, Consumer: true})
+	if err != nil {
+		return err
+	}
+	for _, account := range jsi.AccountDetails {
+		for _, stream := range account.Streams {
+			if strings.HasPrefix(stream.Name, "$memphis") {
+				continue
+			}
+			// in a cluster every replica reports the stream, only the leader exposes it so series are not duplicated
+			if stream.Cluster != nil && stream.Cluster.Leader != _EMPTY_ && stream.Cluster.Leader != s.Name() {
+				continue
+			}
+			stationName, partition := splitPartitionStreamName(stream.Name)
+			labels := []string{"tenant", account.Name, "station", stationName.Ext(), "partition", partition}
+			mw.add("memphis_station_messages_total", "counter", "Total messages produced into the station", float64(stream.State.LastSeq), labels...)
+			mw.add("memphis_station_messages", "gauge", "Messages currently stored in the station", float64(stream.State.Msgs), labels...)
+			mw.add("memphis_station_bytes", "gauge", "Bytes currently stored in the station", float64(stream.State.Bytes), labels...)
+			for _, consumer := range stream.Consumer {
+				if consumer == nil {
+					continue
+				}
+				cgLabels := append(labels, "consumer_group", revertDelimiters(consumer.Name))
+				mw.add("memphis_consumer_group_lag", "gauge", "Messages not yet delivered to the consumer group", float64(consumer.NumPending), cgLabels...)
+				mw.add("memphis_consumer_group_ack_pending", "gauge", "Messages delivered to the consumer group and not yet acknowledged", float64(consumer.NumAckPending), cgLabels...)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeDlsMetrics(mw *metricsWriter) error {
+	counts, err := db.GetDlsMessagesCountByStation()
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		mw.add("memphis_station_dls_messages", "gauge", "Messages currently stored in the station dead-letter station", float64(count.Count), "tenant", count.TenantName, "station", count.StationName, "type", count.MessageType)
+	}
+
+	schemaValidationFailures.Lock()
+	keys := make([]stationMetricKey, 0, len(schemaValidationFailures.counts))
+	for key := range schemaValidationFailures.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tenantName != keys[j].tenantName {
+			return keys[i].tenantName < keys[j].tenantName
+		}
+		return keys[i].stationName < keys[j].stationName
+	})
+	for _, key := range keys {
+		mw.add("memphis_schema_validation_failures_total", "counter", "Messages rejected by the station schema, handled by this broker", float64(schemaValidationFailures.counts[key]), "tenant", key.tenantName, "station", key.stationName)
+	}
+	schemaValidationFailures.Unlock()
+	return nil
+}
+
+// HandleMetrics exposes broker and stations metrics in the Prometheus text format
+func (s *Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.httpReqStats[MetricsPath]++
+	s.mu.Unlock()
+
+	mw := &metricsWriter{written: make(map[string]bool)}
+	s.writeProcessMetrics(mw)
+	s.writeConnectionsMetrics(mw)
+	err := s.writeStationsMetrics(mw)
+	if err != nil {
+		s.Warnf("HandleMetrics at writeStationsMetrics: %v", err.Error())
+	}
+	err = s.writeDlsMetrics(mw)
+	if err != nil {
+		s.Warnf("HandleMetrics at writeDlsMetrics: %v", err.Error())
+	}
+	s.writeAmqpBridgesMetrics(mw)
+	s.writeCdcConnectorsMetrics(mw)
+	s.writeClickhouseSinksMetrics(mw)
+	s.writeCatalogExportersMetrics(mw)
+	s.writeSchemaRegistrySyncsMetrics(mw)
+	s.writeStationsLatencyMetrics(mw)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(mw.sb.String()))
+}
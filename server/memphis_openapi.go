@@ -0,0 +1,312 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+const openApiVersion = "3.0.3"
+
+var (
+	openApiPathParamRegex = regexp.MustCompile(`[:*]([^/]+)`)
+	openApiHandlerRegex   = regexp.MustCompile(`\.\(?\*?([A-Za-z0-9_]+Handler)\)?\.([A-Za-z0-9_]+)-fm$`)
+	timeType              = reflect.TypeOf(time.Time{})
+)
+
+// OpenApiDocument serves the OpenAPI 3 document of the routes registered under prefix. Paths and methods come from
+// the router, request bodies and query parameters from the models the handlers validate against (see
+// memphis_openapi_models.go). The document is built on the first request, once every route is registered
+func OpenApiDocument(router *gin.Engine, prefix string) gin.HandlerFunc {
+	var once sync.Once
+	var document []byte
+	var err error
+	return func(c *gin.Context) {
+		once.Do(func() {
+			document, err = json.Marshal(buildOpenApiDocument(router.Routes(), prefix))
+		})
+		if err != nil {
+			serv.Errorf("OpenApiDocument: %v", err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", document)
+	}
+}
+
+type openApiBuilder struct {
+	components map[string]interface{}
+}
+
+func buildOpenApiDocument(routes gin.RoutesInfo, prefix string) map[string]interface{} {
+	builder := openApiBuilder{components: map[string]interface{}{}}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+
+	paths := map[string]map[string]interface{}{}
+	operationIds := map[string]int{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix+"/") {
+			continue
+		}
+		path := openApiPathParamRegex.ReplaceAllString(route.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(route.Method)] = builder.operation(route, prefix, path, operationIds)
+	}
+
+	errorCodes := make([]string, 0, len(errorCatalog))
+	for code := range errorCatalog {
+		errorCodes = append(errorCodes, string(code))
+	}
+	sort.Strings(errorCodes)
+	builder.components["ErrorResponse"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"message", "code"},
+		"properties": map[string]interface{}{
+			"message":    map[string]interface{}{"type": "string"},
+			"code":       map[string]interface{}{"type": "string", "enum": errorCodes},
+			"hint":       map[string]interface{}{"type": "string"},
+			"request_id": map[string]interface{}{"type": "string"},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": openApiVersion,
+		"info": map[string]interface{}{
+			"title":   "Memphis management API",
+			"version": MEMPHIS_VERSION,
+		},
+		"servers": []map[string]interface{}{{"url": prefix}},
+		"paths":   trimOpenApiPrefix(paths, prefix),
+		"components": map[string]interface{}{
+			"schemas": builder.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []map[string]interface{}{{"bearerAuth": []string{}}},
+	}
+}
+
+func trimOpenApiPrefix(paths map[string]map[string]interface{}, prefix string) map[string]map[string]interface{} {
+	trimmed := make(map[string]map[string]interface{}, len(paths))
+	for path, operations := range paths {
+		trimmed[strings.TrimPrefix(path, prefix)] = operations
+	}
+	return trimmed
+}
+
+// openApiHandlerName returns the Handler.Method name gin reports for a route, routes served by closures have none
+func openApiHandlerName(handler string) (string, bool) {
+	match := openApiHandlerRegex.FindStringSubmatch(handler)
+	if match == nil {
+		return _EMPTY_, false
+	}
+	return match[1] + "." + match[2], true
+}
+
+func (b *openApiBuilder) operation(route gin.RouteInfo, prefix, path string, operationIds map[string]int) map[string]interface{} {
+	segments := strings.Split(strings.TrimPrefix(path, prefix+"/"), "/")
+	operation := map[string]interface{}{
+		"tags": []string{segments[0]},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "Success"},
+			"4XX": b.errorResponse("The request was rejected, see the error code"),
+			"5XX": b.errorResponse("Server error"),
+		},
+	}
+
+	handlerName, ok := openApiHandlerName(route.Handler)
+	operationId := strings.Join(segments, "_")
+	if ok {
+		operationId = handlerName[strings.Index(handlerName, ".")+1:]
+	}
+	operationId = strings.NewReplacer("{", "", "}", "").Replace(operationId)
+	if count := operationIds[operationId]; count > 0 {
+		operationIds[operationId] = count + 1
+		operationId = operationId + "_" + strings.ToLower(route.Method) + strings.Repeat("_", count-1)
+	} else {
+		operationIds[operationId] = 1
+	}
+	operation["operationId"] = operationId
+	if middlewares.IsPublicRoute(route.Path) {
+		operation["security"] = []map[string]interface{}{}
+	}
+
+	var parameters []map[string]interface{}
+	for _, match := range openApiPathParamRegex.FindAllStringSubmatch(route.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	if model, ok := openApiRequestModels[handlerName]; ok {
+		modelType := reflect.TypeOf(model)
+		if route.Method == http.MethodGet {
+			parameters = append(parameters, b.queryParameters(modelType)...)
+		} else {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": b.schema(modelType)},
+				},
+			}
+		}
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	return operation
+}
+
+func (b *openApiBuilder) errorResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"},
+			},
+		},
+	}
+}
+
+// queryParameters describes the fields gin binds from the query string of GET requests
+func (b *openApiBuilder) queryParameters(t reflect.Type) []map[string]interface{} {
+	var parameters []map[string]interface{}
+	for _, field := range openApiFields(t, "form") {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     field.name,
+			"in":       "query",
+			"required": field.required,
+			"schema":   b.schema(field.Type),
+		})
+	}
+	return parameters
+}
+
+type openApiField struct {
+	reflect.StructField
+	name     string
+	required bool
+}
+
+// openApiFields lists the exported fields of a struct under the name of the given tag, embedded structs without a
+// name of their own are flattened the way encoding/json and gin's form binding do
+func openApiFields(t reflect.Type, tag string) []openApiField {
+	var fields []openApiField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == _EMPTY_ {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, openApiFields(embedded, tag)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == _EMPTY_ {
+			name = field.Name
+		}
+		required := false
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				required = true
+			}
+		}
+		fields = append(fields, openApiField{StructField: field, name: name, required: required})
+	}
+	return fields
+}
+
+func (b *openApiBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != _EMPTY_:
+		if _, ok := b.components[t.Name()]; !ok {
+			// registered before the fields are walked so recursive types end in a reference
+			b.components[t.Name()] = map[string]interface{}{}
+			b.components[t.Name()] = b.objectSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return b.objectSchema(t)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	}
+	return map[string]interface{}{}
+}
+
+func (b *openApiBuilder) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, field := range openApiFields(t, "json") {
+		properties[field.name] = b.schema(field.Type)
+		if field.required {
+			required = append(required, field.name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
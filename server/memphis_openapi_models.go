@@ -0,0 +1,177 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+// Generated code, do not edit. Run go generate to update
+
+package server
+
+import "github.com/memphisdev/memphis/models"
+
+// openApiRequestModels maps a handler to the model its request is validated against
+var openApiRequestModels = map[string]interface{}{
+	"AlertsHandler.CreateAlertRule":                       models.CreateAlertRuleSchema{},
+	"AlertsHandler.RemoveAlertRule":                       models.RemoveAlertRuleSchema{},
+	"AlertsHandler.UpdateAlertRule":                       models.UpdateAlertRuleSchema{},
+	"AmqpBridgesHandler.CreateAmqpBridge":                 models.CreateAmqpBridgeSchema{},
+	"AmqpBridgesHandler.RemoveAmqpBridge":                 models.RemoveAmqpBridgeSchema{},
+	"AmqpBridgesHandler.UpdateAmqpBridge":                 models.UpdateAmqpBridgeSchema{},
+	"ApiKeysHandler.CreateApiKey":                         models.CreateApiKeySchema{},
+	"ApiKeysHandler.RevokeApiKey":                         models.RevokeApiKeySchema{},
+	"AsyncTasksHandler.GetAsyncTasks":                     models.AsyncTask{},
+	"AuditLogsHandler.SearchAuditLogs":                    models.SearchAuditLogsSchema{},
+	"BackupsHandler.CreateBackup":                         models.CreateBackupSchema{},
+	"BackupsHandler.RestoreBackup":                        models.RestoreBackupSchema{},
+	"CatalogExportersHandler.CreateCatalogExporter":       models.CreateCatalogExporterSchema{},
+	"CatalogExportersHandler.RemoveCatalogExporter":       models.RemoveCatalogExporterSchema{},
+	"CatalogExportersHandler.SyncCatalogExporter":         models.SyncCatalogExporterSchema{},
+	"CatalogExportersHandler.UpdateCatalogExporter":       models.UpdateCatalogExporterSchema{},
+	"CdcConnectorsHandler.CreateCdcConnector":             models.CreateCdcConnectorSchema{},
+	"CdcConnectorsHandler.RemoveCdcConnector":             models.RemoveCdcConnectorSchema{},
+	"CdcConnectorsHandler.UpdateCdcConnector":             models.UpdateCdcConnectorSchema{},
+	"ClickhouseSinksHandler.CreateClickhouseSink":         models.CreateClickhouseSinkSchema{},
+	"ClickhouseSinksHandler.RemoveClickhouseSink":         models.RemoveClickhouseSinkSchema{},
+	"ClickhouseSinksHandler.UpdateClickhouseSink":         models.UpdateClickhouseSinkSchema{},
+	"CommentsHandler.CreateComment":                       models.CreateCommentSchema{},
+	"CommentsHandler.EditComment":                         models.EditCommentSchema{},
+	"CommentsHandler.GetComments":                         models.GetCommentsSchema{},
+	"CommentsHandler.RemoveComment":                       models.RemoveCommentSchema{},
+	"ConfigurationsHandler.EditClusterConfig":             EditClusterConfigSchema{},
+	"ConfigurationsHandler.PurgeStaleClients":             models.PurgeStaleClientsSchema{},
+	"ConnectionsHandler.DisconnectConnection":             models.DisconnectConnectionSchema{},
+	"ConnectionsHandler.GetAllConnections":                models.GetConnectionsSchema{},
+	"ConsumersHandler.GetCgRebalanceInfo":                 models.GetCgRebalanceInfoSchema{},
+	"GatewayHandler.Fetch":                                models.GatewayFetchSchema{},
+	"GatewayHandler.Produce":                              models.GatewayProduceSchema{},
+	"GatewayHandler.Transaction":                          models.GatewayTransactionSchema{},
+	"GatewayHandler.ackOrNack":                            models.GatewayAckSchema{},
+	"IntegrationsHandler.CreateIntegration":               models.CreateIntegrationSchema{},
+	"IntegrationsHandler.DisconnectIntegration":           models.DisconnectIntegrationSchema{},
+	"IntegrationsHandler.GetIntegrationAuditLogs":         models.GetIntegrationsAuditLogsSchema{},
+	"IntegrationsHandler.GetIntegrationDetails":           models.GetIntegrationDetailsSchema{},
+	"IntegrationsHandler.RequestIntegration":              models.RequestIntegrationSchema{},
+	"IntegrationsHandler.UpdateIntegration":               models.CreateIntegrationSchema{},
+	"JobsHandler.CancelJob":                               models.JobIdSchema{},
+	"JobsHandler.GetJob":                                  models.GetJobSchema{},
+	"JobsHandler.GetJobs":                                 models.GetJobsSchema{},
+	"JobsHandler.RetryJob":                                models.JobIdSchema{},
+	"MonitoringHandler.BrowseSystemLogs":                  models.BrowseSystemLogsSchema{},
+	"MonitoringHandler.GetGrafanaDashboard":               models.GetGrafanaDashboardSchema{},
+	"MonitoringHandler.GetStationOverviewData":            models.GetStationOverviewDataSchema{},
+	"MonitoringHandler.GetSystemLogs":                     models.SystemLogsRequest{},
+	"OwnershipHandler.TransferProducerOwnership":          models.TransferProducerOwnershipSchema{},
+	"OwnershipHandler.TransferSchemaOwnership":            models.TransferSchemaOwnershipSchema{},
+	"OwnershipHandler.TransferStationOwnership":           models.TransferStationOwnershipSchema{},
+	"OwnershipHandler.TransferUserResources":              models.TransferUserResourcesSchema{},
+	"ProjectsHandler.AssignStations":                      models.AssignProjectStationsSchema{},
+	"ProjectsHandler.CreateProject":                       models.CreateProjectSchema{},
+	"ProjectsHandler.RemoveProject":                       models.RemoveProjectSchema{},
+	"ProjectsHandler.UnassignStations":                    models.UnassignProjectStationsSchema{},
+	"ProjectsHandler.UpdateProject":                       models.UpdateProjectSchema{},
+	"ProjectsHandler.setProjectPermission":                models.ProjectPermissionSchema{},
+	"ResourcesHandler.ApplyResources":                     models.ApplyResourcesSchema{},
+	"ResourcesHandler.PutSchemaResource":                  models.SchemaResource{},
+	"ResourcesHandler.PutStationResource":                 models.StationResource{},
+	"ResourcesHandler.PutUserResource":                    models.UserResource{},
+	"ResourcesHandler.ReconcileResource":                  models.ReconcileResourceSchema{},
+	"ResourcesHandler.RemoveManagedResource":              models.RemoveManagedResourceSchema{},
+	"SchemaRegistrySyncsHandler.CreateSchemaRegistrySync": models.CreateSchemaRegistrySyncSchema{},
+	"SchemaRegistrySyncsHandler.RemoveSchemaRegistrySync": models.RemoveSchemaRegistrySyncSchema{},
+	"SchemaRegistrySyncsHandler.RunSchemaRegistrySync":    models.RunSchemaRegistrySyncSchema{},
+	"SchemaRegistrySyncsHandler.UpdateSchemaRegistrySync": models.UpdateSchemaRegistrySyncSchema{},
+	"SchemasHandler.CreateNewSchema":                      models.CreateNewSchema{},
+	"SchemasHandler.CreateNewVersion":                     models.CreateNewVersion{},
+	"SchemasHandler.GetActiveSchemaVersion":               models.GetActiveSchemaVersionSchema{},
+	"SchemasHandler.GetSchemaDetails":                     models.GetSchemaDetails{},
+	"SchemasHandler.ImportGrpcReflectionSchemas":          models.ImportGrpcReflectionSchemasSchema{},
+	"SchemasHandler.InferSchema":                          models.InferSchemaSchema{},
+	"SchemasHandler.ListGrpcReflectionMessages":           models.GrpcReflectionTargetSchema{},
+	"SchemasHandler.RemoveSchema":                         models.RemoveSchema{},
+	"SchemasHandler.RemoveSchemaAlias":                    models.RemoveSchemaAliasSchema{},
+	"SchemasHandler.RenameSchema":                         models.RenameSchemaSchema{},
+	"SchemasHandler.RollBackVersion":                      models.RollBackVersion{},
+	"SchemasHandler.ValidateSchema":                       models.ValidateSchema{},
+	"SearchHandler.Search":                                models.SearchSchema{},
+	"StationsHandler.AttachDlsStation":                    models.AttachDetachDlsStationSchema{},
+	"StationsHandler.CreateStation":                       models.CreateStationSchema{},
+	"StationsHandler.DetachDlsStation":                    models.AttachDetachDlsStationSchema{},
+	"StationsHandler.DropDlsMessages":                     models.DropDlsMessagesSchema{},
+	"StationsHandler.ExportMessages":                      models.ExportStationMessagesSchema{},
+	"StationsHandler.GetMessageDetails":                   models.GetMessageDetailsSchema{},
+	"StationsHandler.GetPoisonMessageJourney":             models.GetPoisonMessageJourneySchema{},
+	"StationsHandler.GetPurgeConfirmationToken":           models.GetPurgeConfirmationTokenSchema{},
+	"StationsHandler.GetStation":                          models.GetStationSchema{},
+	"StationsHandler.GetStationContractReport":            models.GetStationContractReportSchema{},
+	"StationsHandler.GetStationContractReportsHistory":    models.GetStationContractReportsSchema{},
+	"StationsHandler.GetStationDataProfile":               models.GetStationDataProfileSchema{},
+	"StationsHandler.GetStationIndexExtractors":           models.GetStationIndexExtractorsSchema{},
+	"StationsHandler.GetStationLifecyclePolicy":           models.GetStationLifecyclePolicySchema{},
+	"StationsHandler.GetStationMessagesTail":              models.GetStationMessagesTailSchema{},
+	"StationsHandler.GetUpdatesForSchemaByStation":        models.GetUpdatesForSchema{},
+	"StationsHandler.Produce":                             ProduceSchema{},
+	"StationsHandler.PurgeStation":                        models.PurgeStationSchema{},
+	"StationsHandler.RemoveMessages":                      models.RemoveMessagesSchema{},
+	"StationsHandler.RemoveSchemaFromStation":             models.RemoveSchemaFromStation{},
+	"StationsHandler.RemoveStation":                       models.RemoveStationSchema{},
+	"StationsHandler.RemoveStationContractReportSchedule": models.RemoveStationContractReportScheduleSchema{},
+	"StationsHandler.RemoveStationLifecyclePolicy":        models.RemoveStationLifecyclePolicySchema{},
+	"StationsHandler.ResendDlsMessagesToStation":          models.ResendDlsMessagesToStationSchema{},
+	"StationsHandler.ResendPoisonMessages":                models.ResendPoisonMessagesSchema{},
+	"StationsHandler.RotateStorageKey":                    models.RotateStationStorageKeySchema{},
+	"StationsHandler.SearchStationMessagesByKey":          models.SearchStationMessagesByKeySchema{},
+	"StationsHandler.StartStationBenchmark":               models.StartStationBenchmarkSchema{},
+	"StationsHandler.UpdateAckRetentionLimit":             models.UpdateAckRetentionLimitSchema{},
+	"StationsHandler.UpdateDlsConfig":                     models.UpdateDlsConfigSchema{},
+	"StationsHandler.UpdateMessageTransform":              models.UpdateMessageTransformSchema{},
+	"StationsHandler.UpdateStation":                       models.UpdateStationSchema{},
+	"StationsHandler.UpdateStationContractReportSchedule": models.UpdateStationContractReportScheduleSchema{},
+	"StationsHandler.UpdateStationIndexExtractors":        models.UpdateStationIndexExtractorsSchema{},
+	"StationsHandler.UpdateStationLifecyclePolicy":        models.UpdateStationLifecyclePolicySchema{},
+	"StationsHandler.UpdateStationReadOnly":               models.UpdateStationReadOnlySchema{},
+	"StationsHandler.UseSchema":                           models.UseSchema{},
+	"TagsHandler.CreateNewTag":                            models.CreateTag{},
+	"TagsHandler.GetTagResources":                         models.GetTagResourcesSchema{},
+	"TagsHandler.GetTags":                                 models.GetTagsSchema{},
+	"TagsHandler.MergeTags":                               models.MergeTagsSchema{},
+	"TagsHandler.RemoveTag":                               models.RemoveTagSchema{},
+	"TagsHandler.RemoveUnusedTags":                        models.RemoveUnusedTagsSchema{},
+	"TagsHandler.RenameTag":                               models.RenameTagSchema{},
+	"TagsHandler.UpdateTagsForEntity":                     models.UpdateTagsForEntitySchema{},
+	"TeamsHandler.CreateTeam":                             models.CreateTeamSchema{},
+	"TeamsHandler.RemoveTeam":                             models.RemoveTeamSchema{},
+	"TeamsHandler.setTeamMembers":                         models.TeamMembersSchema{},
+	"UserMgmtHandler.AddUser":                             models.AddUserSchema{},
+	"UserMgmtHandler.AddUserSignUp":                       models.AddUserSchema{},
+	"UserMgmtHandler.ApproveInvitation":                   models.ApproveInvitationSchema{},
+	"UserMgmtHandler.ChangeMyPassword":                    models.ChangeMyPasswordSchema{},
+	"UserMgmtHandler.ChangePassword":                      models.ChangePasswordSchema{},
+	"UserMgmtHandler.EditAnalytics":                       models.EditAnalyticsSchema{},
+	"UserMgmtHandler.EditAvatar":                          models.EditAvatarSchema{},
+	"UserMgmtHandler.EditMyProfile":                       models.EditMyProfileSchema{},
+	"UserMgmtHandler.GetAvatar":                           models.GetAvatarSchema{},
+	"UserMgmtHandler.GetFilterDetails":                    models.GetFilterDetailsSchema{},
+	"UserMgmtHandler.GetRevokedConnectionTokens":          models.GetRevokedConnectionTokensSchema{},
+	"UserMgmtHandler.GetSessions":                         models.GetSessionsSchema{},
+	"UserMgmtHandler.InviteUser":                          models.InviteUserSchema{},
+	"UserMgmtHandler.Login":                               LoginSchema{},
+	"UserMgmtHandler.RemoveUser":                          models.RemoveUserSchema{},
+	"UserMgmtHandler.ResendInvitation":                    models.ResendInvitationSchema{},
+	"UserMgmtHandler.RevokeAllSessions":                   models.RevokeAllSessionsSchema{},
+	"UserMgmtHandler.RevokeConnectionToken":               models.RevokeConnectionTokenSchema{},
+	"UserMgmtHandler.RevokeSession":                       models.RevokeSessionSchema{},
+	"UserMgmtHandler.RotateConnectionToken":               models.RotateConnectionTokenSchema{},
+	"UserMgmtHandler.SendTrace":                           models.SendTraceSchema{},
+	"VaultHandler.CreateCredentials":                      models.CreateDynamicCredentialsSchema{},
+	"VaultHandler.RenewCredentials":                       models.RenewDynamicCredentialsSchema{},
+	"VaultHandler.RevokeCredentials":                      models.RevokeDynamicCredentialsSchema{},
+	"WebhooksHandler.CreateWebhook":                       models.CreateWebhookSchema{},
+	"WebhooksHandler.RemoveWebhook":                       models.RemoveWebhookSchema{},
+	"WebhooksHandler.UpdateWebhook":                       models.UpdateWebhookSchema{},
+}
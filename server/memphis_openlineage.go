@@ -0,0 +1,315 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/conf"
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	lineageBufferSize       = 1000
+	lineageTimeout          = 10 * time.Second
+	lineageMinRetryInterval = time.Second
+	lineageMaxRetryInterval = time.Minute
+	lineageEndpoint         = "/api/v1/lineage"
+	lineageProducer         = "https://github.com/memphisdev/memphis"
+	lineageSchemaUrl        = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/definitions/RunEvent"
+	lineageJobTypeFacetUrl  = "https://openlineage.io/spec/facets/2-0-2/JobTypeJobFacet.json#/$defs/JobTypeJobFacet"
+	lineageSchemaFacetUrl   = "https://openlineage.io/spec/facets/1-1-1/SchemaDatasetFacet.json#/$defs/SchemaDatasetFacet"
+
+	lineageEventStart    = "START"
+	lineageEventComplete = "COMPLETE"
+)
+
+var lineageEventsCh chan lineageRunEvent
+
+type lineageSchemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type lineageDataset struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+type lineageJob struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+type lineageRun struct {
+	RunId string `json:"runId"`
+}
+
+type lineageRunEvent struct {
+	EventType string           `json:"eventType"`
+	EventTime string           `json:"eventTime"`
+	Run       lineageRun       `json:"run"`
+	Job       lineageJob       `json:"job"`
+	Inputs    []lineageDataset `json:"inputs"`
+	Outputs   []lineageDataset `json:"outputs"`
+	Producer  string           `json:"producer"`
+	SchemaURL string           `json:"schemaURL"`
+}
+
+// lineageRunner describes a streaming job which moves data between stations and external systems, every connector
+// start is a run which completes when the connector stops
+type lineageRunner struct {
+	job     lineageJob
+	inputs  []lineageDataset
+	outputs []lineageDataset
+	runId   string
+}
+
+// lineageNamespace strips the credentials and path of a connection url, OpenLineage namespaces identify the datasource
+// by its scheme and address only
+func lineageNamespace(rawUrl, defaultScheme string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == _EMPTY_ {
+		return defaultScheme + "://unknown"
+	}
+	scheme := u.Scheme
+	if scheme == "postgresql" {
+		scheme = "postgres"
+	}
+	if strings.HasPrefix(scheme, "mongodb") {
+		scheme = "mongodb"
+	}
+	return scheme + "://" + u.Host
+}
+
+// lineageStationDataset names a station the way lineage tools see it, a station with a json schema carries its fields
+func lineageStationDataset(tenantName, stationNameStr string) lineageDataset {
+	name := stationNameStr
+	if tenantName != conf.MemphisGlobalAccountName {
+		name = tenantName + "." + stationNameStr
+	}
+	dataset := lineageDataset{Namespace: "memphis://" + configuration.OPENLINEAGE_NAMESPACE, Name: name}
+	stationName, err := StationNameFromStr(stationNameStr)
+	if err != nil {
+		return dataset
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), tenantName)
+	if err != nil || !exist || station.SchemaName == _EMPTY_ {
+		return dataset
+	}
+	fields := lineageStationSchemaFields(tenantName, station)
+	if len(fields) > 0 {
+		dataset.Facets = map[string]interface{}{
+			"schema": map[string]interface{}{
+				"_producer":  lineageProducer,
+				"_schemaURL": lineageSchemaFacetUrl,
+				"fields":     fields,
+			},
+		}
+	}
+	return dataset
+}
+
+func lineageStationSchemaFields(tenantName string, station models.Station) []lineageSchemaField {
+	exist, schema, err := db.GetSchemaByName(station.SchemaName, tenantName)
+	if err != nil || !exist || schema.Type != "json" {
+		return nil
+	}
+	exist, version, err := db.GetSchemaVersionByNumberAndID(station.SchemaVersionNumber, schema.ID)
+	if err != nil || !exist {
+		return nil
+	}
+	var fields []lineageSchemaField
+	for _, field := range topLevelJsonSchemaFields(version.SchemaContent) {
+		fields = append(fields, lineageSchemaField{Name: field.Name, Type: field.Type})
+	}
+	return fields
+}
+
+func newLineageRunner(jobType, name, tenantName string, inputs, outputs []lineageDataset) *lineageRunner {
+	return &lineageRunner{
+		job: lineageJob{
+			Namespace: configuration.OPENLINEAGE_NAMESPACE,
+			Name:      tenantName + "." + jobType + "." + name,
+			Facets: map[string]interface{}{
+				"jobType": map[string]interface{}{
+					"_producer":      lineageProducer,
+					"_schemaURL":     lineageJobTypeFacetUrl,
+					"processingType": "STREAMING",
+					"integration":    "MEMPHIS",
+					"jobType":        strings.ToUpper(strings.ReplaceAll(jobType, "-", "_")),
+				},
+			},
+		},
+		inputs:  inputs,
+		outputs: outputs,
+	}
+}
+
+func emitLineageEvent(runner *lineageRunner, eventType string) {
+	if lineageEventsCh == nil || runner == nil {
+		return
+	}
+	if eventType == lineageEventStart {
+		runner.runId = uuid.NewString()
+	}
+	event := lineageRunEvent{
+		EventType: eventType,
+		EventTime: time.Now().UTC().Format(time.RFC3339Nano),
+		Run:       lineageRun{RunId: runner.runId},
+		Job:       runner.job,
+		Inputs:    runner.inputs,
+		Outputs:   runner.outputs,
+		Producer:  lineageProducer,
+		SchemaURL: lineageSchemaUrl,
+	}
+	if event.Inputs == nil {
+		event.Inputs = []lineageDataset{}
+	}
+	if event.Outputs == nil {
+		event.Outputs = []lineageDataset{}
+	}
+	select {
+	case lineageEventsCh <- event:
+	default:
+		serv.Warnf("emitLineageEvent: lineage buffer is full, %v event of job %v dropped", eventType, runner.job.Name)
+	}
+}
+
+func amqpBridgeLineageRunner(bridge models.AmqpBridge) *lineageRunner {
+	if lineageEventsCh == nil {
+		return nil
+	}
+	amqpUrl, err := DecryptAES(getAESKey(), bridge.Url)
+	if err != nil {
+		amqpUrl = _EMPTY_
+	}
+	namespace := lineageNamespace(amqpUrl, "amqp")
+	stationDataset := lineageStationDataset(bridge.TenantName, bridge.StationName)
+	if bridge.Direction == models.AmqpBridgeInbound {
+		queue := lineageDataset{Namespace: namespace, Name: "queue." + bridge.Queue}
+		return newLineageRunner(amqpBridgeProducerName, bridge.Name, bridge.TenantName, []lineageDataset{queue}, []lineageDataset{stationDataset})
+	}
+	exchange := lineageDataset{Namespace: namespace, Name: "exchange." + bridge.Exchange + "." + bridge.RoutingKey}
+	return newLineageRunner(amqpBridgeProducerName, bridge.Name, bridge.TenantName, []lineageDataset{stationDataset}, []lineageDataset{exchange})
+}
+
+func cdcConnectorLineageRunner(connector models.CdcConnector) *lineageRunner {
+	if lineageEventsCh == nil {
+		return nil
+	}
+	connectionUrl, err := DecryptAES(getAESKey(), connector.ConnectionUrl)
+	if err != nil {
+		connectionUrl = _EMPTY_
+	}
+	namespace := lineageNamespace(connectionUrl, connector.SourceType)
+	var inputs []lineageDataset
+	for _, table := range connector.Tables {
+		inputs = append(inputs, lineageDataset{Namespace: namespace, Name: connector.Database + "." + table})
+	}
+	if len(inputs) == 0 {
+		// the connector follows the whole database
+		inputs = append(inputs, lineageDataset{Namespace: namespace, Name: connector.Database})
+	}
+	outputs := []lineageDataset{lineageStationDataset(connector.TenantName, connector.StationName)}
+	return newLineageRunner(cdcProducerName, connector.Name, connector.TenantName, inputs, outputs)
+}
+
+func clickhouseSinkLineageRunner(sink models.ClickhouseSink) *lineageRunner {
+	if lineageEventsCh == nil {
+		return nil
+	}
+	rawUrl, err := DecryptAES(getAESKey(), sink.Url)
+	if err != nil {
+		rawUrl = _EMPTY_
+	}
+	u, err := url.Parse(rawUrl)
+	namespace := "clickhouse://unknown"
+	if err == nil && u.Host != _EMPTY_ {
+		namespace = "clickhouse://" + u.Host
+	}
+	inputs := []lineageDataset{lineageStationDataset(sink.TenantName, sink.StationName)}
+	outputs := []lineageDataset{{Namespace: namespace, Name: sink.Database + "." + sink.TableName}}
+	return newLineageRunner(clickhouseSinkConsumerName, sink.Name, sink.TenantName, inputs, outputs)
+}
+
+func (s *Server) InitializeLineage() error {
+	if configuration.OPENLINEAGE_URL == _EMPTY_ {
+		return nil
+	}
+	u, err := url.Parse(configuration.OPENLINEAGE_URL)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("OPENLINEAGE_URL has to be an http(s) url")
+	}
+	lineageEventsCh = make(chan lineageRunEvent, lineageBufferSize)
+	go s.SendLineageEvents(strings.TrimSuffix(configuration.OPENLINEAGE_URL, "/") + lineageEndpoint)
+	return nil
+}
+
+// SendLineageEvents posts the buffered run events one by one so their order is kept, a failed event is retried with
+// an exponential backoff
+func (s *Server) SendLineageEvents(endpoint string) {
+	client := &http.Client{Timeout: lineageTimeout}
+	retryInterval := lineageMinRetryInterval
+	for event := range lineageEventsCh {
+		body, err := json.Marshal(event)
+		if err != nil {
+			s.Errorf("SendLineageEvents at Marshal: %v", err.Error())
+			continue
+		}
+		for {
+			err = postLineageEvent(client, endpoint, body)
+			if err == nil {
+				retryInterval = lineageMinRetryInterval
+				break
+			}
+			s.Warnf("SendLineageEvents: failed to send %v event of job %v, retrying in %v: %v", event.EventType, event.Job.Name, retryInterval, err.Error())
+			time.Sleep(retryInterval)
+			retryInterval *= 2
+			if retryInterval > lineageMaxRetryInterval {
+				retryInterval = lineageMaxRetryInterval
+			}
+		}
+	}
+}
+
+func postLineageEvent(client *http.Client, endpoint string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if configuration.OPENLINEAGE_API_KEY != _EMPTY_ {
+		req.Header.Set("Authorization", "Bearer "+configuration.OPENLINEAGE_API_KEY)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("lineage backend responded with status %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// A minimal parquet writer for flat schemas of required columns, enough for exporting messages without pulling a parquet library.
+// Every flush writes a row group with a single plain encoded, uncompressed data page per column, the footer is written on close.
+const (
+	parquetMagic = "PAR1"
+
+	parquetTypeInt64     int32 = 2
+	parquetTypeByteArray int32 = 6
+
+	parquetConvertedNone           int32 = -1
+	parquetConvertedUTF8           int32 = 0
+	parquetConvertedTimestampMilli int32 = 9
+
+	parquetRepetitionRequired int32 = 0
+	parquetEncodingPlain      int32 = 0
+	parquetEncodingRLE        int32 = 3
+	parquetPageTypeData       int32 = 0
+	parquetCodecUncompressed  int32 = 0
+)
+
+// thrift compact protocol types
+const (
+	thriftI32    byte = 5
+	thriftI64    byte = 6
+	thriftBinary byte = 8
+	thriftList   byte = 9
+	thriftStruct byte = 12
+)
+
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type parquetRowGroup struct {
+	chunks []parquetColumnChunk
+	rows   int64
+	size   int64
+}
+
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []parquetColumn
+	values    []bytes.Buffer
+	rows      int64
+	totalRows int64
+	rowGroups []parquetRowGroup
+}
+
+func newParquetWriter(w io.Writer, columns []parquetColumn) (*parquetWriter, error) {
+	pw := &parquetWriter{w: w, columns: columns, values: make([]bytes.Buffer, len(columns))}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) appendInt64(column int, v int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	pw.values[column].Write(b[:])
+}
+
+func (pw *parquetWriter) appendBytes(column int, v []byte) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(v)))
+	pw.values[column].Write(b[:])
+	pw.values[column].Write(v)
+}
+
+// endRow has to be called once a value was appended to every column
+func (pw *parquetWriter) endRow() {
+	pw.rows++
+}
+
+func (pw *parquetWriter) bufferedSize() int {
+	size := 0
+	for i := range pw.values {
+		size += pw.values[i].Len()
+	}
+	return size
+}
+
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	rowGroup := parquetRowGroup{rows: pw.rows}
+	for i := range pw.columns {
+		data := pw.values[i].Bytes()
+		var header thriftCompactWriter
+		header.structBegin()
+		header.i32Field(1, parquetPageTypeData)
+		header.i32Field(2, int32(len(data)))
+		header.i32Field(3, int32(len(data)))
+		header.field(5, thriftStruct)
+		header.structBegin()
+		header.i32Field(1, int32(pw.rows))
+		header.i32Field(2, parquetEncodingPlain)
+		header.i32Field(3, parquetEncodingRLE)
+		header.i32Field(4, parquetEncodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{offset: pw.offset, size: int64(header.buf.Len() + len(data)), values: pw.rows}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		rowGroup.chunks = append(rowGroup.chunks, chunk)
+		rowGroup.size += chunk.size
+		pw.values[i].Reset()
+	}
+	pw.rowGroups = append(pw.rowGroups, rowGroup)
+	pw.totalRows += pw.rows
+	pw.rows = 0
+	return nil
+}
+
+// close flushes the buffered rows and writes the file metadata, it does not close the underlying writer
+func (pw *parquetWriter) close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	var meta thriftCompactWriter
+	meta.structBegin()
+	meta.i32Field(1, 1)
+	meta.field(2, thriftList)
+	meta.listHeader(thriftStruct, len(pw.columns)+1)
+	meta.structBegin()
+	meta.binaryField(4, []byte("schema"))
+	meta.i32Field(5, int32(len(pw.columns)))
+	meta.structEnd()
+	for _, column := range pw.columns {
+		meta.structBegin()
+		meta.i32Field(1, column.physicalType)
+		meta.i32Field(3, parquetRepetitionRequired)
+		meta.binaryField(4, []byte(column.name))
+		if column.convertedType != parquetConvertedNone {
+			meta.i32Field(6, column.convertedType)
+		}
+		meta.structEnd()
+	}
+	meta.i64Field(3, pw.totalRows)
+	meta.field(4, thriftList)
+	meta.listHeader(thriftStruct, len(pw.rowGroups))
+	for _, rowGroup := range pw.rowGroups {
+		meta.structBegin()
+		meta.field(1, thriftList)
+		meta.listHeader(thriftStruct, len(rowGroup.chunks))
+		for i, chunk := range rowGroup.chunks {
+			meta.structBegin()
+			meta.i64Field(2, chunk.offset)
+			meta.field(3, thriftStruct)
+			meta.structBegin()
+			meta.i32Field(1, pw.columns[i].physicalType)
+			meta.field(2, thriftList)
+			meta.listHeader(thriftI32, 1)
+			meta.zigzag(int64(parquetEncodingPlain))
+			meta.field(3, thriftList)
+			meta.listHeader(thriftBinary, 1)
+			meta.binary([]byte(pw.columns[i].name))
+			meta.i32Field(4, parquetCodecUncompressed)
+			meta.i64Field(5, chunk.values)
+			meta.i64Field(6, chunk.size)
+			meta.i64Field(7, chunk.size)
+			meta.i64Field(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64Field(2, rowGroup.size)
+		meta.i64Field(3, rowGroup.rows)
+		meta.structEnd()
+	}
+	meta.binaryField(6, []byte("memphis"))
+	meta.structEnd()
+
+	if err := pw.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(meta.buf.Len()))
+	if err := pw.write(footerLen[:]); err != nil {
+		return err
+	}
+	return pw.write([]byte(parquetMagic))
+}
+
+// thriftCompactWriter encodes the parquet metadata structs using the thrift compact protocol
+type thriftCompactWriter struct {
+	buf       bytes.Buffer
+	lastField []int16
+}
+
+func (t *thriftCompactWriter) structBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftCompactWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+func (t *thriftCompactWriter) field(id int16, fieldType byte) {
+	last := &t.lastField[len(t.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftCompactWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func (t *thriftCompactWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftCompactWriter) binary(b []byte) {
+	t.varint(uint64(len(b)))
+	t.buf.Write(b)
+}
+
+func (t *thriftCompactWriter) listHeader(elemType byte, size int) {
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elemType)
+	t.varint(uint64(size))
+}
+
+func (t *thriftCompactWriter) i32Field(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftCompactWriter) i64Field(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftCompactWriter) binaryField(id int16, b []byte) {
+	t.field(id, thriftBinary)
+	t.binary(b)
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// thriftCompactReader walks thrift compact encoded structs, enough to check what thriftCompactWriter produced
+type thriftCompactReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftCompactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		panic("bad varint")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftCompactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftCompactReader) binary() []byte {
+	n := int(r.varint())
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *thriftCompactReader) listHeader() (byte, int) {
+	b := r.buf[r.pos]
+	r.pos++
+	size := int(b >> 4)
+	if size == 15 {
+		size = int(r.varint())
+	}
+	return b & 0x0f, size
+}
+
+// readStruct decodes a struct into field id -> value, i32/i64 as int64, binary as []byte,
+// structs as map[int16]interface{} and lists as []interface{}
+func (r *thriftCompactReader) readStruct() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		b := r.buf[r.pos]
+		r.pos++
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		fields[id] = r.readValue(b & 0x0f)
+	}
+}
+
+func (r *thriftCompactReader) readValue(fieldType byte) interface{} {
+	switch fieldType {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		return r.binary()
+	case thriftStruct:
+		return r.readStruct()
+	case thriftList:
+		elemType, size := r.listHeader()
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			list = append(list, r.readValue(elemType))
+		}
+		return list
+	default:
+		panic(fmt.Sprintf("unexpected thrift type %v", fieldType))
+	}
+}
+
+func TestThriftCompactWriterFields(t *testing.T) {
+	cases := []struct {
+		name  string
+		write func(w *thriftCompactWriter)
+		want  []byte
+	}{
+		{
+			name:  "short field header",
+			write: func(w *thriftCompactWriter) { w.i32Field(1, 3) },
+			want:  []byte{0x15, 0x06, 0x00},
+		},
+		{
+			name:  "negative zigzag value",
+			write: func(w *thriftCompactWriter) { w.i32Field(1, -1) },
+			want:  []byte{0x15, 0x01, 0x00},
+		},
+		{
+			name:  "long field header for a delta above 15",
+			write: func(w *thriftCompactWriter) { w.i64Field(20, 1) },
+			want:  []byte{0x06, 0x28, 0x02, 0x00},
+		},
+		{
+			name: "deltas are relative to the previous field",
+			write: func(w *thriftCompactWriter) {
+				w.i32Field(2, 0)
+				w.binaryField(4, []byte("ab"))
+			},
+			want: []byte{0x25, 0x00, 0x28, 0x02, 'a', 'b', 0x00},
+		},
+		{
+			name: "nested struct keeps its own field ids",
+			write: func(w *thriftCompactWriter) {
+				w.i32Field(5, 1)
+				w.field(6, thriftStruct)
+				w.structBegin()
+				w.i32Field(1, 1)
+				w.structEnd()
+				w.i32Field(7, 1)
+			},
+			want: []byte{0x55, 0x02, 0x1c, 0x15, 0x02, 0x00, 0x15, 0x02, 0x00},
+		},
+		{
+			name: "short list header",
+			write: func(w *thriftCompactWriter) {
+				w.field(1, thriftList)
+				w.listHeader(thriftI32, 2)
+				w.zigzag(1)
+				w.zigzag(2)
+			},
+			want: []byte{0x19, 0x25, 0x02, 0x04, 0x00},
+		},
+		{
+			name: "long list header from 15 elements",
+			write: func(w *thriftCompactWriter) {
+				w.field(1, thriftList)
+				w.listHeader(thriftI32, 15)
+				for i := 0; i < 15; i++ {
+					w.zigzag(0)
+				}
+			},
+			want: append(append([]byte{0x19, 0xf5, 0x0f}, make([]byte, 15)...), 0x00),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var w thriftCompactWriter
+			w.structBegin()
+			tc.write(&w)
+			w.structEnd()
+			if !bytes.Equal(w.buf.Bytes(), tc.want) {
+				t.Fatalf("got % x, want % x", w.buf.Bytes(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	columns := []parquetColumn{
+		{name: "seq", physicalType: parquetTypeInt64, convertedType: parquetConvertedNone},
+		{name: "data", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8},
+	}
+	cases := []struct {
+		name       string
+		rows       int
+		flushEvery int
+		rowGroups  int
+	}{
+		{name: "empty", rows: 0, rowGroups: 0},
+		{name: "single row group", rows: 3, rowGroups: 1},
+		{name: "flushed row groups", rows: 5, flushEvery: 2, rowGroups: 3},
+		{name: "flush on a row group boundary", rows: 4, flushEvery: 2, rowGroups: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			pw, err := newParquetWriter(&out, columns)
+			if err != nil {
+				t.Fatalf("newParquetWriter: %v", err)
+			}
+			for i := 0; i < tc.rows; i++ {
+				pw.appendInt64(0, int64(i))
+				pw.appendBytes(1, []byte(fmt.Sprintf("msg-%d", i)))
+				pw.endRow()
+				if tc.flushEvery > 0 && (i+1)%tc.flushEvery == 0 {
+					if err := pw.flushRowGroup(); err != nil {
+						t.Fatalf("flushRowGroup: %v", err)
+					}
+				}
+			}
+			if err := pw.close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			file := out.Bytes()
+			if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+				t.Fatalf("file is not framed by %v", parquetMagic)
+			}
+			footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8 : len(file)-4]))
+			footer := &thriftCompactReader{buf: file[len(file)-8-footerLen : len(file)-8]}
+			meta := footer.readStruct()
+			if footer.pos != footerLen {
+				t.Fatalf("footer length is %v, metadata takes %v", footerLen, footer.pos)
+			}
+			if meta[3].(int64) != int64(tc.rows) {
+				t.Fatalf("num_rows is %v, want %v", meta[3], tc.rows)
+			}
+			schema := meta[2].([]interface{})
+			if len(schema) != len(columns)+1 {
+				t.Fatalf("schema has %v elements, want %v", len(schema), len(columns)+1)
+			}
+			rowGroups := meta[4].([]interface{})
+			if len(rowGroups) != tc.rowGroups {
+				t.Fatalf("got %v row groups, want %v", len(rowGroups), tc.rowGroups)
+			}
+
+			// read the seq column back through the chunk offsets and page headers
+			seq := int64(0)
+			for _, rg := range rowGroups {
+				chunk := rg.(map[int16]interface{})[1].([]interface{})[0].(map[int16]interface{})
+				chunkMeta := chunk[3].(map[int16]interface{})
+				page := &thriftCompactReader{buf: file, pos: int(chunk[2].(int64))}
+				header := page.readStruct()
+				values := int(header[5].(map[int16]interface{})[1].(int64))
+				if int64(values) != chunkMeta[5].(int64) {
+					t.Fatalf("page holds %v values, chunk metadata says %v", values, chunkMeta[5])
+				}
+				for i := 0; i < values; i++ {
+					v := int64(binary.LittleEndian.Uint64(file[page.pos+i*8:]))
+					if v != seq {
+						t.Fatalf("seq value %v, want %v", v, seq)
+					}
+					seq++
+				}
+			}
+			if seq != int64(tc.rows) {
+				t.Fatalf("read %v values back, want %v", seq, tc.rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	// changes made by other brokers in the cluster are picked up by the periodic reload
+	producersStateReconcileInterval = 2 * time.Minute
+	producersStateMaxRows           = 5000
+)
+
+type stationProducersState struct {
+	producers []models.ExtendedProducer
+	loadedAt  time.Time
+}
+
+// producersState keeps the producers of the stations open in the ui in memory,
+// producer lifecycle changes are applied incrementally instead of re-querying on every ws update
+var producersState = struct {
+	sync.Mutex
+	stations map[int]*stationProducersState
+}{stations: map[int]*stationProducersState{}}
+
+func getStationProducersState(stationId int) ([]models.ExtendedProducer, error) {
+	producersState.Lock()
+	state, ok := producersState.stations[stationId]
+	if ok && time.Since(state.loadedAt) < producersStateReconcileInterval {
+		producers := make([]models.ExtendedProducer, len(state.producers))
+		copy(producers, state.producers)
+		producersState.Unlock()
+		return producers, nil
+	}
+	producersState.Unlock()
+
+	producersStateEvict()
+	producers, err := db.GetAllProducersByStationID(stationId)
+	if err != nil {
+		return []models.ExtendedProducer{}, err
+	}
+	loaded := make([]models.ExtendedProducer, len(producers))
+	copy(loaded, producers)
+	producersState.Lock()
+	producersState.stations[stationId] = &stationProducersState{producers: loaded, loadedAt: time.Now()}
+	producersState.Unlock()
+	return producers, nil
+}
+
+// refreshProducersCounts recomputes the per name counters and restores the order of the db query
+func (state *stationProducersState) refreshProducersCounts() {
+	connected := map[string]int{}
+	disconnected := map[string]int{}
+	for _, producer := range state.producers {
+		if producer.IsActive {
+			connected[producer.Name]++
+		} else {
+			disconnected[producer.Name]++
+		}
+	}
+	for i := range state.producers {
+		state.producers[i].ConnectedProducersCount = connected[state.producers[i].Name]
+		state.producers[i].DisconnedtedProducersCount = disconnected[state.producers[i].Name]
+	}
+	sort.SliceStable(state.producers, func(i, j int) bool {
+		a, b := state.producers[i], state.producers[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.IsActive != b.IsActive {
+			return a.IsActive
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	if len(state.producers) > producersStateMaxRows {
+		state.producers = state.producers[:producersStateMaxRows]
+	}
+}
+
+func producersStateAdd(stationName string, producers ...models.Producer) {
+	producersState.Lock()
+	defer producersState.Unlock()
+	for _, producer := range producers {
+		if producer.Type != "application" {
+			continue
+		}
+		state, ok := producersState.stations[producer.StationId]
+		if !ok {
+			continue
+		}
+		// a reconnecting client comes back with the id of its existing record
+		producers := state.producers[:0]
+		for _, existing := range state.producers {
+			if existing.ID != producer.ID {
+				producers = append(producers, existing)
+			}
+		}
+		state.producers = append(producers, models.ExtendedProducer{
+			ID:           producer.ID,
+			Name:         producer.Name,
+			Type:         producer.Type,
+			ConnectionId: producer.ConnectionId,
+			UpdatedAt:    producer.UpdatedAt,
+			StationName:  stationName,
+			IsActive:     producer.IsActive,
+			Version:      producer.Version,
+			Sdk:          producer.Sdk,
+		})
+		state.refreshProducersCounts()
+	}
+}
+
+func producersStateRemove(stationId int, connectionId string, names ...string) {
+	producersState.Lock()
+	defer producersState.Unlock()
+	state, ok := producersState.stations[stationId]
+	if !ok {
+		return
+	}
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+	}
+	producers := state.producers[:0]
+	for _, producer := range state.producers {
+		if producer.ConnectionId == connectionId && removed[producer.Name] {
+			continue
+		}
+		producers = append(producers, producer)
+	}
+	state.producers = producers
+	state.refreshProducersCounts()
+}
+
+func producersStateSetConnectionActive(isActive bool, connectionIds ...string) {
+	connections := map[string]bool{}
+	for _, connectionId := range connectionIds {
+		connections[connectionId] = true
+	}
+	producersState.Lock()
+	defer producersState.Unlock()
+	for _, state := range producersState.stations {
+		changed := false
+		for i := range state.producers {
+			if connections[state.producers[i].ConnectionId] && state.producers[i].IsActive != isActive {
+				state.producers[i].IsActive = isActive
+				state.producers[i].UpdatedAt = time.Now()
+				changed = true
+			}
+		}
+		if changed {
+			state.refreshProducersCounts()
+		}
+	}
+}
+
+// producersStateForget drops a station so its next read goes to the db
+func producersStateForget(stationId int) {
+	producersState.Lock()
+	defer producersState.Unlock()
+	delete(producersState.stations, stationId)
+}
+
+// producersStateEvict drops the stations nobody has read since the last reconciliation
+func producersStateEvict() {
+	producersState.Lock()
+	defer producersState.Unlock()
+	for stationId, state := range producersState.stations {
+		if time.Since(state.loadedAt) >= 2*producersStateReconcileInterval {
+			delete(producersState.stations, stationId)
+		}
+	}
+}
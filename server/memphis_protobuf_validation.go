@@ -0,0 +1,304 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Protobuf messages are validated by walking their wire format against rules compiled once per schema version,
+// instead of decoding them into dynamic messages. Nothing is allocated per message and the payload is never copied.
+// The rules accept and reject exactly what decoding does: numeric fields take any numeric wire type as long as the
+// value fits, packed or not, nested messages are checked recursively and required fields have to be present.
+
+// protobufMaxDepth bounds the nesting of messages and groups, like the protobuf runtime does
+const protobufMaxDepth = 10000
+
+var errProtobufMaxDepth = errors.New("exceeded maximum protobuf nesting depth")
+
+type protobufValueKind int
+
+const (
+	protobufLengthDelimited protobufValueKind = iota // strings and bytes
+	protobufMessage                                  // messages and groups
+	protobufNumeric
+)
+
+type protobufFieldRule struct {
+	name string
+	kind protobufValueKind
+	// the encoding numeric values use inside a packed field
+	packedType protowire.Type
+	// the largest value a 32 bit numeric field accepts, zero for 64 bit fields
+	max uint64
+	// int32 and enums are sign extended, so negative values are valid too
+	signed32 bool
+	message  *protobufMessageRule
+	// index into the required fields bitmap, -1 for optional fields
+	requiredIdx int
+}
+
+type protobufMessageRule struct {
+	name     string
+	fields   map[protowire.Number]*protobufFieldRule
+	required []*protobufFieldRule
+}
+
+func compileProtobufMessageRule(md *desc.MessageDescriptor) *protobufMessageRule {
+	return compileProtobufMessageRuleInto(md, map[string]*protobufMessageRule{})
+}
+
+// compileProtobufMessageRuleInto shares the rule of every message type through compiled, so recursive types terminate
+func compileProtobufMessageRuleInto(md *desc.MessageDescriptor, compiled map[string]*protobufMessageRule) *protobufMessageRule {
+	if rule, ok := compiled[md.GetFullyQualifiedName()]; ok {
+		return rule
+	}
+	rule := &protobufMessageRule{name: md.GetFullyQualifiedName(), fields: make(map[protowire.Number]*protobufFieldRule, len(md.GetFields()))}
+	compiled[md.GetFullyQualifiedName()] = rule
+	for _, fd := range md.GetFields() {
+		field := &protobufFieldRule{name: fd.GetName(), kind: protobufNumeric, requiredIdx: -1}
+		switch fd.GetType() {
+		case descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+			field.kind = protobufLengthDelimited
+		case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+			field.kind = protobufMessage
+			field.message = compileProtobufMessageRuleInto(fd.GetMessageType(), compiled)
+		case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, descriptorpb.FieldDescriptorProto_TYPE_FIXED64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+			field.packedType = protowire.Fixed64Type
+		case descriptorpb.FieldDescriptorProto_TYPE_FLOAT, descriptorpb.FieldDescriptorProto_TYPE_FIXED32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+			field.packedType = protowire.Fixed32Type
+			field.max = math.MaxUint32
+		case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32:
+			field.packedType = protowire.VarintType
+			field.max = math.MaxUint32
+		case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+			field.packedType = protowire.VarintType
+			field.signed32 = true
+		default:
+			field.packedType = protowire.VarintType
+		}
+		if fd.IsRequired() {
+			field.requiredIdx = len(rule.required)
+			rule.required = append(rule.required, field)
+		}
+		rule.fields[protowire.Number(fd.GetNumber())] = field
+	}
+	return rule
+}
+
+func (rule *protobufMessageRule) validate(b []byte) error {
+	return rule.walk(b, 0)
+}
+
+func (rule *protobufMessageRule) walk(b []byte, depth int) error {
+	if depth > protobufMaxDepth {
+		return errProtobufMaxDepth
+	}
+	// required fields beyond the bitmap are looked up once the message was walked
+	var seen uint64
+	for msg := b; len(msg) > 0; {
+		num, typ, n, err := consumeProtobufTag(msg)
+		if err != nil {
+			return err
+		}
+		msg = msg[n:]
+		field, known := rule.fields[num]
+		if !known {
+			// unknown fields are kept by decoders, they only have to be well formed
+			n, err = skipProtobufValue(typ, msg)
+			if err != nil {
+				return err
+			}
+			msg = msg[n:]
+			continue
+		}
+		n, err = field.consume(typ, msg, depth)
+		if err != nil {
+			return err
+		}
+		msg = msg[n:]
+		if field.requiredIdx >= 0 && field.requiredIdx < 64 {
+			seen |= 1 << field.requiredIdx
+		}
+	}
+	for i, field := range rule.required {
+		if i < 64 && seen&(1<<i) != 0 {
+			continue
+		}
+		if i >= 64 && protobufHasField(b, rule, field) {
+			continue
+		}
+		return fmt.Errorf("some required fields missing: %v", field.name)
+	}
+	return nil
+}
+
+// consume validates a single value of a known field and returns its length
+func (field *protobufFieldRule) consume(typ protowire.Type, b []byte, depth int) (int, error) {
+	switch typ {
+	case protowire.VarintType, protowire.Fixed32Type, protowire.Fixed64Type:
+		if field.kind != protobufNumeric {
+			return 0, fmt.Errorf("field %v requires length-delimited wire type", field.name)
+		}
+		v, n := consumeProtobufNumeric(typ, b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		return n, field.checkNumeric(v)
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		switch field.kind {
+		case protobufMessage:
+			return n, field.message.walk(v, depth+1)
+		case protobufNumeric:
+			// numeric fields are parsed as packed whether or not they are repeated
+			for len(v) > 0 {
+				num, m := consumeProtobufNumeric(field.packedType, v)
+				if m < 0 {
+					return 0, protowire.ParseError(m)
+				}
+				if err := field.checkNumeric(num); err != nil {
+					return 0, err
+				}
+				v = v[m:]
+			}
+		}
+		return n, nil
+	case protowire.StartGroupType:
+		if field.kind != protobufMessage {
+			return 0, fmt.Errorf("cannot parse field %v from group-encoded wire type", field.name)
+		}
+		dataEnd, n, err := findProtobufGroupEnd(b)
+		if err != nil {
+			return 0, err
+		}
+		return n, field.message.walk(b[:dataEnd], depth+1)
+	default:
+		return 0, fmt.Errorf("field %v has a bad wire type %v", field.name, typ)
+	}
+}
+
+func consumeProtobufNumeric(typ protowire.Type, b []byte) (uint64, int) {
+	switch typ {
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		return uint64(v), n
+	case protowire.Fixed64Type:
+		return protowire.ConsumeFixed64(b)
+	default:
+		return protowire.ConsumeVarint(b)
+	}
+}
+
+func (field *protobufFieldRule) checkNumeric(v uint64) error {
+	if field.signed32 {
+		if s := int64(v); s > math.MaxInt32 || s < math.MinInt32 {
+			return fmt.Errorf("value of field %v overflows its type", field.name)
+		}
+		return nil
+	}
+	if field.max > 0 && v > field.max {
+		return fmt.Errorf("value of field %v overflows its type", field.name)
+	}
+	return nil
+}
+
+// consumeProtobufTag reads a field tag the way decoders do, any number up to MaxInt32 is accepted
+func consumeProtobufTag(b []byte) (protowire.Number, protowire.Type, int, error) {
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, 0, 0, protowire.ParseError(n)
+	}
+	typ := protowire.Type(v & 7)
+	if v>>3 > math.MaxInt32 {
+		return 0, 0, 0, fmt.Errorf("tag number out of range: %d", v>>3)
+	}
+	if typ == protowire.EndGroupType || typ > protowire.Fixed32Type {
+		return 0, 0, 0, fmt.Errorf("bad wire type %v", typ)
+	}
+	return protowire.Number(v >> 3), typ, n, nil
+}
+
+// skipProtobufValue returns the length of a field value of the given wire type
+func skipProtobufValue(typ protowire.Type, b []byte) (int, error) {
+	var n int
+	switch typ {
+	case protowire.VarintType:
+		_, n = protowire.ConsumeVarint(b)
+	case protowire.Fixed32Type:
+		_, n = protowire.ConsumeFixed32(b)
+	case protowire.Fixed64Type:
+		_, n = protowire.ConsumeFixed64(b)
+	case protowire.BytesType:
+		_, n = protowire.ConsumeBytes(b)
+	case protowire.StartGroupType:
+		_, groupEnd, err := findProtobufGroupEnd(b)
+		return groupEnd, err
+	default:
+		return 0, fmt.Errorf("bad wire type %v", typ)
+	}
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return n, nil
+}
+
+// findProtobufGroupEnd returns where the content of a group ends and where the group ends after its end tag,
+// like decoders the end tag is not required to carry the number of the group
+func findProtobufGroupEnd(b []byte) (int, int, error) {
+	for i := 0; ; {
+		v, n := protowire.ConsumeVarint(b[i:])
+		if n < 0 {
+			return 0, 0, protowire.ParseError(n)
+		}
+		if v>>3 > math.MaxInt32 {
+			return 0, 0, fmt.Errorf("tag number out of range: %d", v>>3)
+		}
+		if protowire.Type(v&7) == protowire.EndGroupType {
+			return i, i + n, nil
+		}
+		m, err := skipProtobufValue(protowire.Type(v&7), b[i+n:])
+		if err != nil {
+			return 0, 0, err
+		}
+		i += n + m
+	}
+}
+
+// protobufHasField reports whether a field appears in an already walked message
+func protobufHasField(b []byte, rule *protobufMessageRule, want *protobufFieldRule) bool {
+	for len(b) > 0 {
+		num, typ, n, err := consumeProtobufTag(b)
+		if err != nil {
+			return false
+		}
+		if rule.fields[num] == want {
+			return true
+		}
+		b = b[n:]
+		n, err = skipProtobufValue(typ, b)
+		if err != nil {
+			return false
+		}
+		b = b[n:]
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nats-io/nuid"
+)
+
+const (
+	// RequestIdHeader carries the id of an api request, a valid id sent by the caller is kept so it can be
+	// followed from the client through every broker taking part in the request
+	RequestIdHeader = "X-Request-ID"
+	// memphisRequestIdHdr carries the id of the api request which triggered a broker to broker request
+	memphisRequestIdHdr = "Memphis-Request-Id"
+	requestIdContextKey = "request_id"
+)
+
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID assigns every api request an id, returned in the X-Request-ID header
+func RequestID(c *gin.Context) {
+	requestID := c.GetHeader(RequestIdHeader)
+	if !requestIdRegex.MatchString(requestID) {
+		requestID = nuid.Next()
+	}
+	c.Set(requestIdContextKey, requestID)
+	c.Header(RequestIdHeader, requestID)
+	c.Next()
+}
+
+func requestIDFromContext(c *gin.Context) string {
+	return c.GetString(requestIdContextKey)
+}
+
+// requestIDHeaders returns the headers threading the request id into a broker to broker request, nil without an id
+func requestIDHeaders(requestID string) map[string]string {
+	if requestID == _EMPTY_ {
+		return nil
+	}
+	return map[string]string{memphisRequestIdHdr: requestID}
+}
+
+// requestIDFromMsg returns the request id a broker to broker request was sent with, if any
+func requestIDFromMsg(c *client, rmsg []byte) string {
+	hdr, _ := c.msgParts(rmsg)
+	if len(hdr) == 0 {
+		return _EMPTY_
+	}
+	return string(getHeader(memphisRequestIdHdr, hdr))
+}
@@ -0,0 +1,83 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidDescriptorSet = errors.New("descriptor_set has to be a base64 encoded FileDescriptorSet")
+
+// protobufSourceFromDescriptorSet turns an uploaded FileDescriptorSet back into proto source so the version goes
+// through the same validation as a text upload, the uploaded set is kept as the version descriptor
+func protobufSourceFromDescriptorSet(encodedSet, messageStructName string) (string, string, error) {
+	raw, err := base64.StdEncoding.DecodeString(encodedSet)
+	if err != nil || len(raw) == 0 {
+		return _EMPTY_, _EMPTY_, ErrInvalidDescriptorSet
+	}
+	var set descriptor.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil || len(set.File) == 0 {
+		return _EMPTY_, _EMPTY_, ErrInvalidDescriptorSet
+	}
+	files, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return _EMPTY_, _EMPTY_, fmt.Errorf("your descriptor set is invalid: %v", err.Error())
+	}
+
+	var file *desc.FileDescriptor
+	for _, fd := range files {
+		for _, mt := range fd.GetMessageTypes() {
+			if mt.GetName() == messageStructName || mt.GetFullyQualifiedName() == messageStructName {
+				file = fd
+				break
+			}
+		}
+	}
+	if file == nil {
+		return _EMPTY_, _EMPTY_, fmt.Errorf("message struct %v was not found in the descriptor set", messageStructName)
+	}
+	source, err := protobufSourceFromFile(file)
+	if err != nil {
+		return _EMPTY_, _EMPTY_, err
+	}
+	return source, base64.StdEncoding.EncodeToString(raw), nil
+}
+
+func protobufSourceFromFile(file *desc.FileDescriptor) (string, error) {
+	// a version holds a single proto file, imported files would not resolve when the source is parsed again
+	if len(file.GetDependencies()) > 0 {
+		return _EMPTY_, fmt.Errorf("%v imports other proto files, only self contained files can be registered from compiled descriptors", file.GetName())
+	}
+	printer := protoprint.Printer{}
+	source, err := printer.PrintProtoToString(file)
+	if err != nil {
+		return _EMPTY_, fmt.Errorf("the descriptor of %v is invalid: %v", file.GetName(), err.Error())
+	}
+	return source, nil
+}
+
+func schemaContentFromDescriptorSet(schemaType, schemaContent, encodedSet, messageStructName string) (string, string, error) {
+	if schemaType != "protobuf" {
+		return _EMPTY_, _EMPTY_, errors.New("descriptor sets can only be uploaded for protobuf schemas")
+	}
+	if schemaContent != _EMPTY_ {
+		return _EMPTY_, _EMPTY_, errors.New("either schema content or a descriptor set can be uploaded, not both")
+	}
+	return protobufSourceFromDescriptorSet(encodedSet, messageStructName)
+}
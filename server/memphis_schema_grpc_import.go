@@ -0,0 +1,278 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+)
+
+const grpcReflectionTimeout = 15 * time.Second
+
+// the reflection services themselves are not offered for import
+var grpcReflectionServiceNames = map[string]bool{
+	"grpc.reflection.v1alpha.ServerReflection": true,
+	"grpc.reflection.v1.ServerReflection":      true,
+}
+
+func validateGrpcReflectionAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == _EMPTY_ || port == _EMPTY_ {
+		return errors.New("address has to be in the form host:port")
+	}
+	return nil
+}
+
+// withGrpcReflectionClient dials the service and runs f against its reflection api, the connection is closed when f returns
+func withGrpcReflectionClient(address string, useTls bool, f func(client *grpcreflect.Client) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcReflectionTimeout)
+	defer cancel()
+	creds := insecure.NewCredentials()
+	if useTls {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	if err != nil {
+		return fmt.Errorf("failed connecting to %v: %v", address, err.Error())
+	}
+	defer conn.Close()
+	client := grpcreflect.NewClient(ctx, rpb.NewServerReflectionClient(conn))
+	defer client.Reset()
+	return f(client)
+}
+
+func listGrpcReflectionServices(client *grpcreflect.Client) ([]models.GrpcReflectionService, error) {
+	names, err := client.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("server reflection is not available: %v", err.Error())
+	}
+	sort.Strings(names)
+	services := []models.GrpcReflectionService{}
+	for _, name := range names {
+		if grpcReflectionServiceNames[name] {
+			continue
+		}
+		sd, err := client.ResolveService(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed resolving service %v: %v", name, err.Error())
+		}
+		service := models.GrpcReflectionService{Name: name, Methods: []models.GrpcReflectionMethod{}}
+		for _, method := range sd.GetMethods() {
+			service.Methods = append(service.Methods, models.GrpcReflectionMethod{
+				Name:       method.GetName(),
+				InputType:  method.GetInputType().GetFullyQualifiedName(),
+				OutputType: method.GetOutputType().GetFullyQualifiedName(),
+			})
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+type grpcReflectedSchema struct {
+	schemaName        string
+	messageName       string
+	messageStructName string
+	content           string
+	descriptor        string
+}
+
+// resolveGrpcReflectedSchema pulls the file defining the message and renders it the way a text upload would be stored
+func resolveGrpcReflectedSchema(client *grpcreflect.Client, selected models.GrpcReflectionMessageImport) (grpcReflectedSchema, error) {
+	md, err := client.ResolveMessage(selected.MessageName)
+	if err != nil {
+		return grpcReflectedSchema{}, fmt.Errorf("message %v could not be resolved: %v", selected.MessageName, err.Error())
+	}
+	if md.GetParent() != md.GetFile() {
+		return grpcReflectedSchema{}, fmt.Errorf("message %v is nested, only top level messages can be imported", selected.MessageName)
+	}
+	content, err := protobufSourceFromFile(md.GetFile())
+	if err != nil {
+		return grpcReflectedSchema{}, err
+	}
+	set := &descriptor.FileDescriptorSet{File: []*descriptor.FileDescriptorProto{md.GetFile().AsFileDescriptorProto()}}
+	raw, err := proto.Marshal(set)
+	if err != nil {
+		return grpcReflectedSchema{}, err
+	}
+	return grpcReflectedSchema{
+		schemaName:        strings.ToLower(selected.SchemaName),
+		messageName:       md.GetFullyQualifiedName(),
+		messageStructName: md.GetName(),
+		content:           content,
+		descriptor:        base64.StdEncoding.EncodeToString(raw),
+	}, nil
+}
+
+func (sh SchemasHandler) ListGrpcReflectionMessages(c *gin.Context) {
+	var body models.GrpcReflectionTargetSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ListGrpcReflectionMessages at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]ListGrpcReflectionMessages: only admins can import schemas from grpc services", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
+		return
+	}
+	err = validateGrpcReflectionAddress(body.Address)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ListGrpcReflectionMessages: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	var services []models.GrpcReflectionService
+	err = withGrpcReflectionClient(body.Address, body.UseTls, func(client *grpcreflect.Client) error {
+		services, err = listGrpcReflectionServices(client)
+		return err
+	})
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ListGrpcReflectionMessages: Address %v: %v", user.TenantName, user.Username, body.Address, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	c.IndentedJSON(200, gin.H{"services": services})
+}
+
+func (sh SchemasHandler) ImportGrpcReflectionSchemas(c *gin.Context) {
+	var body models.ImportGrpcReflectionSchemasSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ImportGrpcReflectionSchemas at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: only admins can import schemas from grpc services", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized"})
+		return
+	}
+	err = validateGrpcReflectionAddress(body.Address)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	seenNames := make(map[string]bool)
+	for _, selected := range body.Messages {
+		schemaName := strings.ToLower(selected.SchemaName)
+		err = validateSchemaName(schemaName)
+		if err == nil && seenNames[schemaName] {
+			err = fmt.Errorf("schema name %v is used for more than one message", schemaName)
+		}
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: %v", user.TenantName, user.Username, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+			return
+		}
+		seenNames[schemaName] = true
+	}
+
+	// everything is resolved before the first schema is written so a bad selection does not leave a partial import
+	var reflected []grpcReflectedSchema
+	err = withGrpcReflectionClient(body.Address, body.UseTls, func(client *grpcreflect.Client) error {
+		for _, selected := range body.Messages {
+			schema, err := resolveGrpcReflectedSchema(client, selected)
+			if err != nil {
+				return err
+			}
+			if err := validateSchemaContent(schema.content, "protobuf"); err != nil {
+				return fmt.Errorf("message %v: %v", selected.MessageName, err.Error())
+			}
+			reflected = append(reflected, schema)
+		}
+		return nil
+	})
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: Address %v: %v", user.TenantName, user.Username, body.Address, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	for _, schema := range reflected {
+		exist, existing, err := db.GetSchemaByName(schema.schemaName, user.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, schema.schemaName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if exist && existing.Type != "protobuf" {
+			errMsg := fmt.Sprintf("Schema %v is of type %v", schema.schemaName, existing.Type)
+			serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+	}
+
+	results := []models.GrpcReflectionImportResult{}
+	for _, schema := range reflected {
+		result := models.GrpcReflectionImportResult{SchemaName: schema.schemaName, MessageName: schema.messageName}
+		exist, existing, err := db.GetSchemaByName(schema.schemaName, user.TenantName)
+		if err == nil && exist {
+			var version models.SchemaVersion
+			version, err = insertNextSchemaVersion(user, existing, schema.content, schema.messageStructName, schema.descriptor)
+			result.VersionNumber = version.VersionNumber
+		} else if err == nil {
+			_, err = db.InsertNewSchemaWithVersion(schema.schemaName, "protobuf", user.ID, user.Username, schema.content, schema.messageStructName, schema.descriptor, schemaTagsToCreate(nil), user.TenantName)
+			result.VersionNumber = 1
+			result.Created = true
+		}
+		if errors.Is(err, ErrSchemaVersionContention) || errors.Is(err, db.ErrSchemaExists) {
+			serv.Warnf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: Schema %v: %v", user.TenantName, user.Username, schema.schemaName, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": fmt.Sprintf("Schema %v is being changed concurrently, please try again", schema.schemaName)})
+			return
+		}
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]ImportGrpcReflectionSchemas: Schema %v: %v", user.TenantName, user.Username, schema.schemaName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		results = append(results, result)
+
+		message := fmt.Sprintf("Schema %v version %v has been imported from message %v of grpc service %v by user %v", schema.schemaName, result.VersionNumber, schema.messageName, body.Address, user.Username)
+		serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, _EMPTY_, message)
+		sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+	}
+
+	c.IndentedJSON(200, gin.H{"schemas": results})
+}
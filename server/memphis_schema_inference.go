@@ -0,0 +1,434 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/memphisdev/memphis/analytics"
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	inferSchemaMaxSamples      = 1000
+	inferSchemaDefaultProtoMsg = "Message"
+)
+
+var protoIdentifierInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// inferredNode merges every value seen at one position of the sampled messages
+type inferredNode struct {
+	types  map[string]int
+	fields map[string]*inferredNode
+	items  *inferredNode
+}
+
+func newInferredNode() *inferredNode {
+	return &inferredNode{types: make(map[string]int), fields: make(map[string]*inferredNode)}
+}
+
+func (n *inferredNode) observe(v interface{}, depth int) {
+	n.types[sampledValueType(v)]++
+	if depth >= stationProfileMaxDepth {
+		return
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			child, ok := n.fields[k]
+			if !ok {
+				child = newInferredNode()
+				n.fields[k] = child
+			}
+			child.observe(item, depth+1)
+		}
+	case []interface{}:
+		for _, item := range val {
+			if n.items == nil {
+				n.items = newInferredNode()
+			}
+			n.items.observe(item, depth+1)
+		}
+	}
+}
+
+// valueTypes are the non null types seen at the node, integers are folded into numbers when both were seen
+func (n *inferredNode) valueTypes() []string {
+	var types []string
+	for t := range n.types {
+		if t == "null" || (t == "integer" && n.types["number"] > 0) {
+			continue
+		}
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (n *inferredNode) fieldNames() []string {
+	names := make([]string, 0, len(n.fields))
+	for name := range n.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (n *inferredNode) seen() int {
+	total := 0
+	for _, count := range n.types {
+		total += count
+	}
+	return total
+}
+
+func (n *inferredNode) jsonSchema() map[string]interface{} {
+	schema := make(map[string]interface{})
+	types := n.valueTypes()
+	if n.types["null"] > 0 || len(types) == 0 {
+		types = append(types, "null")
+	}
+	if len(types) == 1 {
+		schema["type"] = types[0]
+	} else {
+		schema["type"] = types
+	}
+	if n.types["object"] > 0 {
+		properties := make(map[string]interface{})
+		required := []string{}
+		for _, name := range n.fieldNames() {
+			child := n.fields[name]
+			properties[name] = child.jsonSchema()
+			// a field is required only when every sampled object carried it
+			if child.seen() == n.types["object"] {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	if n.types["array"] > 0 && n.items != nil {
+		schema["items"] = n.items.jsonSchema()
+	}
+	return schema
+}
+
+func inferJsonSchema(root *inferredNode, title string) (string, error) {
+	schema := root.jsonSchema()
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = title
+	content, err := json.MarshalIndent(schema, _EMPTY_, "  ")
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return string(content), nil
+}
+
+func protoFieldName(name string, taken map[string]bool) string {
+	fieldName := protoIdentifierInvalidChars.ReplaceAllString(name, "_")
+	if fieldName == _EMPTY_ || (fieldName[0] >= '0' && fieldName[0] <= '9') || fieldName[0] == '_' {
+		fieldName = "f_" + strings.TrimLeft(fieldName, "_")
+	}
+	unique := fieldName
+	for i := 2; taken[strings.ToLower(unique)]; i++ {
+		unique = fieldName + "_" + strconv.Itoa(i)
+	}
+	taken[strings.ToLower(unique)] = true
+	return unique
+}
+
+func protoMessageName(fieldName string) string {
+	var name strings.Builder
+	for _, part := range strings.Split(fieldName, "_") {
+		if part == _EMPTY_ {
+			continue
+		}
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return name.String()
+}
+
+// protoScalarType maps a node holding a single scalar type, anything mixed or nested in arrays is kept as a string
+func protoScalarType(n *inferredNode) string {
+	types := n.valueTypes()
+	if len(types) != 1 {
+		return "string"
+	}
+	switch types[0] {
+	case "integer":
+		return "int64"
+	case "number":
+		return "double"
+	case "boolean":
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+func isProtoMessageNode(n *inferredNode) bool {
+	types := n.valueTypes()
+	return len(types) == 1 && types[0] == "object" && len(n.fields) > 0
+}
+
+func writeProtoMessage(b *strings.Builder, name string, n *inferredNode, indent string) {
+	b.WriteString(indent + "message " + name + " {\n")
+	takenFields := make(map[string]bool)
+	takenMessages := map[string]bool{name: true}
+	number := 1
+	for _, original := range n.fieldNames() {
+		child := n.fields[original]
+		fieldName := protoFieldName(original, takenFields)
+		label := _EMPTY_
+		valueNode := child
+		if types := child.valueTypes(); len(types) == 1 && types[0] == "array" && child.items != nil {
+			label = "repeated "
+			valueNode = child.items
+		}
+
+		fieldType := protoScalarType(valueNode)
+		if isProtoMessageNode(valueNode) {
+			baseType := protoMessageName(fieldName)
+			if label != _EMPTY_ {
+				baseType += "Item"
+			}
+			fieldType = baseType
+			for i := 2; takenMessages[fieldType]; i++ {
+				fieldType = baseType + strconv.Itoa(i)
+			}
+			takenMessages[fieldType] = true
+			writeProtoMessage(b, fieldType, valueNode, indent+"  ")
+		}
+
+		options := _EMPTY_
+		if fieldName != original {
+			options = fmt.Sprintf(" [json_name = %q]", original)
+		}
+		b.WriteString(fmt.Sprintf("%s  %s%s %s = %d%s;\n", indent, label, fieldType, fieldName, number, options))
+		number++
+	}
+	b.WriteString(indent + "}\n")
+}
+
+func inferProtobufSchema(root *inferredNode, messageStructName string) (string, error) {
+	if !isProtoMessageNode(root) {
+		return _EMPTY_, errors.New("protobuf schemas can only be inferred from messages that are json objects")
+	}
+	var b strings.Builder
+	b.WriteString("syntax = \"proto3\";\n\n")
+	writeProtoMessage(&b, messageStructName, root, _EMPTY_)
+	return b.String(), nil
+}
+
+func (sh SchemasHandler) InferSchema(c *gin.Context) {
+	var body models.InferSchemaSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("InferSchema at getUserDetailsFromMiddleware: Schema %v: %v", body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	schemaName := strings.ToLower(body.SchemaName)
+	err = validateSchemaName(schemaName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]InferSchema at validateSchemaName: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	schemaType := strings.ToLower(body.SchemaType)
+	if schemaType != "json" && schemaType != "protobuf" {
+		errMsg := "Schemas can only be inferred as json or protobuf"
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if (body.StationName == _EMPTY_) == (len(body.Samples) == 0) {
+		errMsg := "Either a station to sample or uploaded samples are required"
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if len(body.Samples) > inferSchemaMaxSamples {
+		errMsg := fmt.Sprintf("Up to %v samples can be uploaded", inferSchemaMaxSamples)
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	exist, schema, err := db.GetSchemaByName(schemaName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]InferSchema at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if exist && schema.Type != schemaType {
+		errMsg := fmt.Sprintf("Schema %v is of type %v", schemaName, schema.Type)
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	var samples [][]byte
+	var decode messageDecoder
+	if body.StationName != _EMPTY_ {
+		stationName, station, ok := getGatewayStation(c, user, "InferSchema", body.StationName, "read")
+		if !ok {
+			return
+		}
+		sampleSize := body.SampleSize
+		if sampleSize == 0 {
+			sampleSize = stationSampleDefaultSize
+		}
+		if sampleSize < 1 || sampleSize > stationSampleMaxSize {
+			errMsg := "sample_size has to be between 1 and " + strconv.Itoa(stationSampleMaxSize)
+			serv.Warnf("[tenant: %v][user: %v]InferSchema: Station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		decode, err = stationSampleDecoder(station)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]InferSchema at stationSampleDecoder: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		sampled, err := sh.S.sampleStationMessages(station, stationName, sampleSize)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]InferSchema at sampleStationMessages: Station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		for _, msg := range sampled {
+			samples = append(samples, msg.payload)
+		}
+	} else {
+		for _, sample := range body.Samples {
+			samples = append(samples, []byte(sample))
+		}
+	}
+
+	root := newInferredNode()
+	decoded := 0
+	for _, sample := range samples {
+		v, ok := decodeSampledMessage(sample, decode)
+		if !ok {
+			continue
+		}
+		decoded++
+		root.observe(v, 0)
+	}
+	if decoded == 0 {
+		errMsg := "None of the sampled messages could be read as json"
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	var schemaContent string
+	messageStructName := _EMPTY_
+	if schemaType == "protobuf" {
+		messageStructName = body.MessageStructName
+		if messageStructName == _EMPTY_ {
+			messageStructName = inferSchemaDefaultProtoMsg
+		}
+		schemaContent, err = inferProtobufSchema(root, messageStructName)
+	} else {
+		schemaContent, err = inferJsonSchema(root, schemaName)
+	}
+	if err == nil {
+		err = validateSchemaContent(schemaContent, schemaType)
+	}
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]InferSchema: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	// an existing schema gets the draft as an inactive version to review and roll forward to, a new schema starts with
+	// it as its first version since it is not attached to any station yet
+	response := models.InferredSchemaResponse{
+		SchemaName:        schemaName,
+		Type:              schemaType,
+		SchemaContent:     schemaContent,
+		MessageStructName: messageStructName,
+		SampledMessages:   len(samples),
+		DecodedMessages:   decoded,
+	}
+	var message string
+	if exist {
+		newSchemaVersion, err := insertNextSchemaVersion(user, schema, schemaContent, messageStructName, _EMPTY_)
+		if err != nil {
+			var descriptorErr schemaDescriptorError
+			if errors.As(err, &descriptorErr) || errors.Is(err, ErrSchemaVersionContention) {
+				serv.Warnf("[tenant: %v][user: %v]InferSchema at insertNextSchemaVersion: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+				abortWithError(c, err)
+				return
+			}
+			serv.Errorf("[tenant: %v][user: %v]InferSchema at insertNextSchemaVersion: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		response.VersionNumber = newSchemaVersion.VersionNumber
+		message = fmt.Sprintf("Version %v of schema %v has been inferred from %v messages by user %v", newSchemaVersion.VersionNumber, schemaName, decoded, user.Username)
+	} else {
+		descriptor := _EMPTY_
+		if schemaType == "protobuf" {
+			descriptor, err = generateSchemaDescriptor(schemaName, 1, schemaContent, schemaType)
+			if err != nil {
+				serv.Warnf("[tenant: %v][user: %v]InferSchema at generateSchemaDescriptor: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+				c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+				return
+			}
+		}
+		_, err = db.InsertNewSchemaWithVersion(schemaName, schemaType, user.ID, user.Username, schemaContent, messageStructName, descriptor, schemaTagsToCreate(nil), user.TenantName)
+		if errors.Is(err, db.ErrSchemaExists) {
+			errMsg := fmt.Sprintf("Schema with the name %v already exists", schemaName)
+			serv.Warnf("[tenant: %v][user: %v]InferSchema: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]InferSchema at InsertNewSchemaWithVersion: Schema %v: %v", user.TenantName, user.Username, schemaName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		response.VersionNumber = 1
+		response.Active = true
+		message = fmt.Sprintf("Schema %v has been inferred from %v messages by user %v", schemaName, decoded, user.Username)
+	}
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+
+	shouldSendAnalytics, _ := shouldSendAnalytics()
+	if shouldSendAnalytics {
+		analyticsParams := map[string]interface{}{"schema-name": schemaName, "schema-type": schemaType}
+		analytics.SendEvent(user.TenantName, user.Username, analyticsParams, "user-infer-schema")
+	}
+
+	c.IndentedJSON(200, response)
+}
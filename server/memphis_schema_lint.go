@@ -0,0 +1,264 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+const (
+	lintRuleMissingComment      = "missing_comment"
+	lintRuleSnakeCase           = "snake_case"
+	lintRulePascalCase          = "pascal_case"
+	lintRuleFieldNumberReuse    = "field_number_reuse"
+	lintRuleMissingType         = "missing_type"
+	lintRuleAdditionalProps     = "additional_properties"
+	lintRuleMissingRequiredList = "missing_required"
+)
+
+var (
+	snakeCaseRegex  = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	pascalCaseRegex = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+)
+
+// lintSchemaContent returns style warnings for a schema that already passed validation, previousContent is the
+// active version of the schema when one exists and is used to detect changes that break compatibility
+func lintSchemaContent(schemaType, schemaContent, previousContent string) []models.SchemaLintWarning {
+	var warnings []models.SchemaLintWarning
+	switch schemaType {
+	case "protobuf":
+		warnings = lintProtobufSchema(schemaContent, previousContent)
+	case "json":
+		warnings = lintJsonSchema(schemaContent)
+	case "avro":
+		warnings = lintAvroSchema(schemaContent)
+	}
+	if warnings == nil {
+		return []models.SchemaLintWarning{}
+	}
+	return warnings
+}
+
+func parseProtobufWithComments(schemaContent string) ([]*desc.MessageDescriptor, error) {
+	parser := protoparse.Parser{
+		IncludeSourceCodeInfo: true,
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(schemaContent)), nil
+		},
+	}
+	files, err := parser.ParseFiles(_EMPTY_)
+	if err != nil {
+		return nil, err
+	}
+	var messages []*desc.MessageDescriptor
+	var collect func(mds []*desc.MessageDescriptor)
+	collect = func(mds []*desc.MessageDescriptor) {
+		for _, md := range mds {
+			if md.IsMapEntry() {
+				continue
+			}
+			messages = append(messages, md)
+			collect(md.GetNestedMessageTypes())
+		}
+	}
+	for _, file := range files {
+		collect(file.GetMessageTypes())
+	}
+	return messages, nil
+}
+
+func protobufFieldNames(messages []*desc.MessageDescriptor) map[string]map[int32]string {
+	names := make(map[string]map[int32]string, len(messages))
+	for _, md := range messages {
+		fields := make(map[int32]string)
+		for _, fd := range md.GetFields() {
+			fields[fd.GetNumber()] = fd.GetName()
+		}
+		names[md.GetFullyQualifiedName()] = fields
+	}
+	return names
+}
+
+func lintProtobufSchema(schemaContent, previousContent string) []models.SchemaLintWarning {
+	messages, err := parseProtobufWithComments(schemaContent)
+	if err != nil {
+		return nil
+	}
+	var previousFields map[string]map[int32]string
+	if previousContent != _EMPTY_ {
+		previousMessages, err := parseProtobufWithComments(previousContent)
+		if err == nil {
+			previousFields = protobufFieldNames(previousMessages)
+		}
+	}
+
+	var warnings []models.SchemaLintWarning
+	for _, md := range messages {
+		messagePath := md.GetFullyQualifiedName()
+		if !pascalCaseRegex.MatchString(md.GetName()) {
+			warnings = append(warnings, models.SchemaLintWarning{Rule: lintRulePascalCase, Path: messagePath, Message: fmt.Sprintf("message %v should be named in PascalCase", md.GetName())})
+		}
+		for _, fd := range md.GetFields() {
+			fieldPath := messagePath + "." + fd.GetName()
+			if !snakeCaseRegex.MatchString(fd.GetName()) {
+				warnings = append(warnings, models.SchemaLintWarning{Rule: lintRuleSnakeCase, Path: fieldPath, Message: fmt.Sprintf("field %v should be named in snake_case", fd.GetName())})
+			}
+			info := fd.GetSourceInfo()
+			if info == nil || (strings.TrimSpace(info.GetLeadingComments()) == _EMPTY_ && strings.TrimSpace(info.GetTrailingComments()) == _EMPTY_) {
+				warnings = append(warnings, models.SchemaLintWarning{Rule: lintRuleMissingComment, Path: fieldPath, Message: fmt.Sprintf("field %v has no comment describing it", fd.GetName())})
+			}
+			if previousName, ok := previousFields[messagePath][fd.GetNumber()]; ok && previousName != fd.GetName() {
+				warnings = append(warnings, models.SchemaLintWarning{Rule: lintRuleFieldNumberReuse, Path: fieldPath, Message: fmt.Sprintf("field number %v was used by field %v in the active version, reserve it instead of reusing it", fd.GetNumber(), previousName)})
+			}
+		}
+	}
+	return warnings
+}
+
+func lintJsonSchema(schemaContent string) []models.SchemaLintWarning {
+	var root interface{}
+	err := json.Unmarshal([]byte(schemaContent), &root)
+	if err != nil {
+		return nil
+	}
+	var warnings []models.SchemaLintWarning
+	rootSchema, ok := root.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if _, ok := rootSchema["type"]; !ok {
+		warnings = append(warnings, models.SchemaLintWarning{Rule: lintRuleMissingType, Path: "#", Message: "the root schema does not declare a type, any JSON value is accepted"})
+	}
+	lintJsonSchemaNode(rootSchema, "#", &warnings)
+	return warnings
+}
+
+// lintJsonSchemaNode walks the object schemas, nested properties, array items and definitions
+func lintJsonSchemaNode(node map[string]interface{}, path string, warnings *[]models.SchemaLintWarning) {
+	properties, hasProperties := node["properties"].(map[string]interface{})
+	if node["type"] == "object" || hasProperties {
+		if additional, ok := node["additionalProperties"]; !ok || additional == true {
+			*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleAdditionalProps, Path: path, Message: "additionalProperties is not set to false, unknown properties are accepted"})
+		}
+		if required, ok := node["required"].([]interface{}); !ok || len(required) == 0 {
+			*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleMissingRequiredList, Path: path, Message: "no property is required, an empty object is accepted"})
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path + "/properties/" + name
+		if !snakeCaseRegex.MatchString(name) {
+			*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleSnakeCase, Path: propertyPath, Message: fmt.Sprintf("property %v should be named in snake_case", name)})
+		}
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if description, _ := property["description"].(string); strings.TrimSpace(description) == _EMPTY_ {
+			*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleMissingComment, Path: propertyPath, Message: fmt.Sprintf("property %v has no description", name)})
+		}
+		if _, ok := property["type"]; !ok && property["$ref"] == nil {
+			*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleMissingType, Path: propertyPath, Message: fmt.Sprintf("property %v does not declare a type, any JSON value is accepted", name)})
+		}
+		lintJsonSchemaNode(property, propertyPath, warnings)
+	}
+
+	if items, ok := node["items"].(map[string]interface{}); ok {
+		lintJsonSchemaNode(items, path+"/items", warnings)
+	}
+	for _, key := range []string{"definitions", "$defs"} {
+		definitions, ok := node[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		definitionNames := make([]string, 0, len(definitions))
+		for name := range definitions {
+			definitionNames = append(definitionNames, name)
+		}
+		sort.Strings(definitionNames)
+		for _, name := range definitionNames {
+			if definition, ok := definitions[name].(map[string]interface{}); ok {
+				lintJsonSchemaNode(definition, path+"/"+key+"/"+name, warnings)
+			}
+		}
+	}
+}
+
+func lintAvroSchema(schemaContent string) []models.SchemaLintWarning {
+	var root interface{}
+	err := json.Unmarshal([]byte(schemaContent), &root)
+	if err != nil {
+		return nil
+	}
+	var warnings []models.SchemaLintWarning
+	lintAvroNode(root, _EMPTY_, &warnings)
+	return warnings
+}
+
+// lintAvroNode walks the records of an avro schema, including the ones nested in unions, arrays and maps
+func lintAvroNode(node interface{}, path string, warnings *[]models.SchemaLintWarning) {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, branch := range n {
+			lintAvroNode(branch, path, warnings)
+		}
+	case map[string]interface{}:
+		switch n["type"] {
+		case "record":
+			name, _ := n["name"].(string)
+			recordPath := strings.TrimPrefix(path+"."+name, ".")
+			if !pascalCaseRegex.MatchString(name) {
+				*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRulePascalCase, Path: recordPath, Message: fmt.Sprintf("record %v should be named in PascalCase", name)})
+			}
+			fields, _ := n["fields"].([]interface{})
+			for _, f := range fields {
+				field, ok := f.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fieldName, _ := field["name"].(string)
+				fieldPath := recordPath + "." + fieldName
+				if !snakeCaseRegex.MatchString(fieldName) {
+					*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleSnakeCase, Path: fieldPath, Message: fmt.Sprintf("field %v should be named in snake_case", fieldName)})
+				}
+				if doc, _ := field["doc"].(string); strings.TrimSpace(doc) == _EMPTY_ {
+					*warnings = append(*warnings, models.SchemaLintWarning{Rule: lintRuleMissingComment, Path: fieldPath, Message: fmt.Sprintf("field %v has no doc", fieldName)})
+				}
+				lintAvroNode(field["type"], fieldPath, warnings)
+			}
+		case "array":
+			lintAvroNode(n["items"], path, warnings)
+		case "map":
+			lintAvroNode(n["values"], path, warnings)
+		default:
+			// a complex type can also be wrapped, e.g. {"type": {"type": "record", ...}}
+			if inner, ok := n["type"].(map[string]interface{}); ok {
+				lintAvroNode(inner, path, warnings)
+			}
+		}
+	}
+}
@@ -0,0 +1,590 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/memphis_cache"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+const (
+	schemaRegistrySyncsTickInterval  = 30 * time.Second
+	schemaRegistryRequestTimeout     = 30 * time.Second
+	schemaRegistrySyncDefaultSec     = 3600
+	schemaRegistrySyncMinSec         = 60
+	schemaRegistrySyncMaxSec         = 7 * 24 * 3600
+	schemaRegistryMaxReportedIssues  = 100
+	apicurioConfluentCompatiblePath  = "/apis/ccompat/v7"
+	schemaRegistryContentTypeHeader  = "application/vnd.schemaregistry.v1+json"
+	schemaRegistryDefaultSchemaType  = "AVRO"
+	schemaRegistrySubjectNameMaxSize = 128
+)
+
+var schemaRegistryInvalidNameChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+type schemaRegistrySyncState struct {
+	registrySync models.SchemaRegistrySync
+	syncing      atomic.Bool
+	syncs        atomic.Uint64
+	errors       atomic.Uint64
+	lastRunAt    time.Time
+	mu           sync.Mutex
+	imported     int
+	pushed       int
+	conflicts    []string
+	lastSyncAt   time.Time
+	lastError    string
+	lastErrAt    time.Time
+}
+
+var schemaRegistrySyncs = struct {
+	sync.Mutex
+	states map[int]*schemaRegistrySyncState
+}{states: make(map[int]*schemaRegistrySyncState)}
+
+func validateSchemaRegistrySyncInterval(intervalSec int) error {
+	if intervalSec < schemaRegistrySyncMinSec || intervalSec > schemaRegistrySyncMaxSec {
+		return fmt.Errorf("interval_sec must be between %v and %v", schemaRegistrySyncMinSec, schemaRegistrySyncMaxSec)
+	}
+	return nil
+}
+
+func validateSchemaRegistryConflictPolicy(policy string) error {
+	switch policy {
+	case models.SchemaRegistryConflictSkip, models.SchemaRegistryConflictRemoteWins, models.SchemaRegistryConflictLocalWins:
+		return nil
+	default:
+		return fmt.Errorf("conflict_policy must be one of %v, %v or %v", models.SchemaRegistryConflictSkip, models.SchemaRegistryConflictRemoteWins, models.SchemaRegistryConflictLocalWins)
+	}
+}
+
+// validateSchemaRegistrySync checks the sync definition and fills in the defaults of the optional fields
+func validateSchemaRegistrySync(registrySync *models.CreateSchemaRegistrySyncSchema) error {
+	if registrySync.Type != models.SchemaRegistryConfluent && registrySync.Type != models.SchemaRegistryApicurio {
+		return fmt.Errorf("type must be either %v or %v", models.SchemaRegistryConfluent, models.SchemaRegistryApicurio)
+	}
+	u, err := url.Parse(registrySync.Url)
+	if err != nil || u.Host == _EMPTY_ || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("url must be a valid http:// or https:// url")
+	}
+	registrySync.Url = strings.TrimSuffix(registrySync.Url, "/")
+	if registrySync.Password != _EMPTY_ && registrySync.Username == _EMPTY_ {
+		return errors.New("username is required when a password is set")
+	}
+	if registrySync.ConflictPolicy == _EMPTY_ {
+		registrySync.ConflictPolicy = models.SchemaRegistryConflictSkip
+	}
+	err = validateSchemaRegistryConflictPolicy(registrySync.ConflictPolicy)
+	if err != nil {
+		return err
+	}
+	if registrySync.IntervalSec == 0 {
+		registrySync.IntervalSec = schemaRegistrySyncDefaultSec
+	}
+	return validateSchemaRegistrySyncInterval(registrySync.IntervalSec)
+}
+
+func (st *schemaRegistrySyncState) recordError(err error) {
+	st.errors.Add(1)
+	st.mu.Lock()
+	st.lastError = err.Error()
+	st.lastErrAt = time.Now()
+	st.mu.Unlock()
+}
+
+func (st *schemaRegistrySyncState) status() models.SchemaRegistrySyncStatus {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	conflicts := append([]string{}, st.conflicts...)
+	return models.SchemaRegistrySyncStatus{
+		Syncing:     st.syncing.Load(),
+		Syncs:       st.syncs.Load(),
+		Errors:      st.errors.Load(),
+		Imported:    st.imported,
+		Pushed:      st.pushed,
+		Conflicts:   conflicts,
+		LastSyncAt:  st.lastSyncAt,
+		LastError:   st.lastError,
+		LastErrorAt: st.lastErrAt,
+	}
+}
+
+// getSchemaRegistrySyncStatus returns the status of a sync, syncs run on the leader only
+func getSchemaRegistrySyncStatus(id int) models.SchemaRegistrySyncStatus {
+	schemaRegistrySyncs.Lock()
+	state, ok := schemaRegistrySyncs.states[id]
+	schemaRegistrySyncs.Unlock()
+	if !ok {
+		return models.SchemaRegistrySyncStatus{Conflicts: []string{}}
+	}
+	return state.status()
+}
+
+func (s *Server) ManageSchemaRegistrySyncs() {
+	reportBackgroundTaskAlive("ManageSchemaRegistrySyncs", schemaRegistrySyncsTickInterval)
+	ticker := time.NewTicker(schemaRegistrySyncsTickInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageSchemaRegistrySyncs", schemaRegistrySyncsTickInterval)
+		s.scheduleSchemaRegistrySyncs(0)
+	}
+}
+
+// scheduleSchemaRegistrySyncs starts the syncs which are due, forceId starts that sync regardless of its interval
+func (s *Server) scheduleSchemaRegistrySyncs(forceId int) {
+	desired := make(map[int]models.SchemaRegistrySync)
+	if s.leadsBackgroundTask("ManageSchemaRegistrySyncs", schemaRegistrySyncsTickInterval) {
+		registrySyncs, err := db.GetEnabledSchemaRegistrySyncs()
+		if err != nil {
+			s.Errorf("scheduleSchemaRegistrySyncs at GetEnabledSchemaRegistrySyncs: %v", err.Error())
+			return
+		}
+		for _, registrySync := range registrySyncs {
+			desired[registrySync.ID] = registrySync
+		}
+	}
+
+	schemaRegistrySyncs.Lock()
+	defer schemaRegistrySyncs.Unlock()
+	for id := range schemaRegistrySyncs.states {
+		if _, ok := desired[id]; !ok {
+			delete(schemaRegistrySyncs.states, id)
+		}
+	}
+	for id, registrySync := range desired {
+		state, ok := schemaRegistrySyncs.states[id]
+		if !ok {
+			state = &schemaRegistrySyncState{registrySync: registrySync}
+			schemaRegistrySyncs.states[id] = state
+		}
+		state.registrySync = registrySync
+		due := time.Since(state.lastRunAt) >= time.Duration(registrySync.IntervalSec)*time.Second
+		if (due || id == forceId) && state.syncing.CompareAndSwap(false, true) {
+			state.lastRunAt = time.Now()
+			go s.runSchemaRegistrySync(state, registrySync)
+		}
+	}
+}
+
+type registrySchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type registrySchema struct {
+	Subject    string                    `json:"subject"`
+	Version    int                       `json:"version"`
+	ID         int                       `json:"id"`
+	SchemaType string                    `json:"schemaType"`
+	Schema     string                    `json:"schema"`
+	References []registrySchemaReference `json:"references"`
+}
+
+// schemaRegistryClient speaks the Confluent schema registry api, Apicurio serves the same api under its ccompat path
+type schemaRegistryClient struct {
+	baseUrl  string
+	username string
+	password string
+	http     *http.Client
+}
+
+func newSchemaRegistryClient(registrySync models.SchemaRegistrySync) (*schemaRegistryClient, error) {
+	password := _EMPTY_
+	if registrySync.Password != _EMPTY_ {
+		decrypted, err := DecryptAES(getAESKey(), registrySync.Password)
+		if err != nil {
+			return nil, err
+		}
+		password = decrypted
+	}
+	baseUrl := registrySync.Url
+	if registrySync.Type == models.SchemaRegistryApicurio && !strings.Contains(baseUrl, "/apis/ccompat/") {
+		baseUrl += apicurioConfluentCompatiblePath
+	}
+	return &schemaRegistryClient{baseUrl: baseUrl, username: registrySync.Username, password: password, http: &http.Client{Timeout: schemaRegistryRequestTimeout}}, nil
+}
+
+func (rc *schemaRegistryClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rc.baseUrl+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentTypeHeader)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentTypeHeader)
+	}
+	if rc.username != _EMPTY_ {
+		req.SetBasicAuth(rc.username, rc.password)
+	}
+	resp, err := rc.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errMsg := strings.TrimSpace(string(respBody))
+		if len(errMsg) > 512 {
+			errMsg = errMsg[:512]
+		}
+		return fmt.Errorf("%v %v responded with status %v: %v", method, path, resp.StatusCode, errMsg)
+	}
+	if out != nil && len(respBody) > 0 {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+func (rc *schemaRegistryClient) subjects(ctx context.Context) ([]string, error) {
+	var subjects []string
+	err := rc.do(ctx, http.MethodGet, "/subjects", nil, &subjects)
+	return subjects, err
+}
+
+func (rc *schemaRegistryClient) latest(ctx context.Context, subject string) (registrySchema, error) {
+	var schema registrySchema
+	err := rc.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &schema)
+	return schema, err
+}
+
+func (rc *schemaRegistryClient) register(ctx context.Context, subject, schemaType, content string) error {
+	body := map[string]interface{}{"schema": content}
+	// the registry treats a missing type as avro and older registries reject the field
+	if schemaType != schemaRegistryDefaultSchemaType {
+		body["schemaType"] = schemaType
+	}
+	return rc.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, nil)
+}
+
+func memphisSchemaTypeOfRegistry(schemaType string) (string, bool) {
+	switch strings.ToUpper(schemaType) {
+	case _EMPTY_, "AVRO":
+		return "avro", true
+	case "JSON":
+		return "json", true
+	case "PROTOBUF":
+		return "protobuf", true
+	default:
+		return _EMPTY_, false
+	}
+}
+
+func registrySchemaTypeOfMemphis(schemaType string) (string, bool) {
+	switch schemaType {
+	case "avro":
+		return "AVRO", true
+	case "json":
+		return "JSON", true
+	case "protobuf":
+		return "PROTOBUF", true
+	default:
+		return _EMPTY_, false
+	}
+}
+
+// schemaNameOfSubject maps a registry subject to a schema name, the subject prefix acts as the namespace of the
+// memphis schemas on the registry side so it is stripped on import and added back on push
+func schemaNameOfSubject(subject, prefix string) (string, error) {
+	name := strings.ToLower(strings.TrimPrefix(subject, prefix))
+	name = schemaRegistryInvalidNameChars.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "._-")
+	if len(name) > schemaRegistrySubjectNameMaxSize {
+		name = name[:schemaRegistrySubjectNameMaxSize]
+	}
+	err := validateSchemaName(name)
+	if err != nil {
+		return _EMPTY_, fmt.Errorf("subject %v can not be mapped to a schema name: %v", subject, err.Error())
+	}
+	return name, nil
+}
+
+func firstProtobufMessageName(content string) (string, error) {
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+	fds, err := parser.ParseFiles(_EMPTY_)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	messages := fds[0].GetMessageTypes()
+	if len(messages) == 0 {
+		return _EMPTY_, errors.New("the proto file has no messages")
+	}
+	return messages[0].GetName(), nil
+}
+
+func sameSchemaContent(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
+
+type schemaRegistrySyncResult struct {
+	imported  int
+	pushed    int
+	conflicts []string
+	firstErr  error
+}
+
+func (r *schemaRegistrySyncResult) conflict(subject, reason string) {
+	if len(r.conflicts) < schemaRegistryMaxReportedIssues {
+		r.conflicts = append(r.conflicts, subject+": "+reason)
+	}
+}
+
+func (r *schemaRegistrySyncResult) fail(err error) {
+	if r.firstErr == nil {
+		r.firstErr = err
+	}
+}
+
+func (s *Server) runSchemaRegistrySync(state *schemaRegistrySyncState, registrySync models.SchemaRegistrySync) {
+	defer state.syncing.Store(false)
+	result, err := s.syncSchemaRegistry(registrySync)
+	if err == nil {
+		err = result.firstErr
+	}
+	state.mu.Lock()
+	state.imported = result.imported
+	state.pushed = result.pushed
+	state.conflicts = result.conflicts
+	if result.conflicts == nil {
+		state.conflicts = []string{}
+	}
+	state.lastSyncAt = time.Now()
+	state.mu.Unlock()
+	if result.imported > 0 || result.pushed > 0 {
+		message := fmt.Sprintf("Schema registry sync %v imported %v and pushed %v schemas", registrySync.Name, result.imported, result.pushed)
+		s.Noticef("[tenant: %v]%v", registrySync.TenantName, message)
+		s.notifyOperationalEvent(registrySync.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+	}
+	if err != nil {
+		state.recordError(err)
+		s.Warnf("[tenant: %v]runSchemaRegistrySync: sync %v: %v", registrySync.TenantName, registrySync.Name, err.Error())
+		return
+	}
+	state.syncs.Add(1)
+}
+
+func (s *Server) syncSchemaRegistry(registrySync models.SchemaRegistrySync) (schemaRegistrySyncResult, error) {
+	var result schemaRegistrySyncResult
+	_, user, err := memphis_cache.GetUser(registrySync.CreatedBy, registrySync.TenantName, false)
+	if err != nil {
+		return result, fmt.Errorf("user %v who created the sync is not available: %v", registrySync.CreatedBy, err.Error())
+	}
+	client, err := newSchemaRegistryClient(registrySync)
+	if err != nil {
+		return result, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), schemaRegistryRequestTimeout)
+	subjects, err := client.subjects(ctx)
+	cancel()
+	if err != nil {
+		return result, err
+	}
+	sort.Strings(subjects)
+
+	remoteNames := make(map[string]bool)
+	for _, subject := range subjects {
+		if !strings.HasPrefix(subject, registrySync.SubjectPrefix) {
+			continue
+		}
+		schemaName, err := schemaNameOfSubject(subject, registrySync.SubjectPrefix)
+		if err != nil {
+			result.conflict(subject, err.Error())
+			continue
+		}
+		remoteNames[schemaName] = true
+		ctx, cancel := context.WithTimeout(context.Background(), schemaRegistryRequestTimeout)
+		remote, err := client.latest(ctx, subject)
+		cancel()
+		if err != nil {
+			result.fail(fmt.Errorf("subject %v: %v", subject, err.Error()))
+			continue
+		}
+		err = s.importRegistrySchema(client, registrySync, user, schemaName, remote, &result)
+		if err != nil {
+			result.fail(fmt.Errorf("subject %v: %v", subject, err.Error()))
+		}
+	}
+
+	if !registrySync.PushBack {
+		return result, nil
+	}
+	schemas, err := db.GetAllSchemasDetails(registrySync.TenantName)
+	if err != nil {
+		return result, err
+	}
+	for _, schema := range schemas {
+		if remoteNames[schema.Name] {
+			continue
+		}
+		registryType, ok := registrySchemaTypeOfMemphis(schema.Type)
+		if !ok {
+			continue
+		}
+		exist, activeVersion, err := db.GetSchemaVersionByNumberAndID(schema.ActiveVersionNumber, schema.ID)
+		if err != nil {
+			return result, err
+		}
+		if !exist {
+			continue
+		}
+		subject := registrySync.SubjectPrefix + schema.Name
+		ctx, cancel := context.WithTimeout(context.Background(), schemaRegistryRequestTimeout)
+		err = client.register(ctx, subject, registryType, activeVersion.SchemaContent)
+		cancel()
+		if err != nil {
+			result.fail(fmt.Errorf("subject %v: %v", subject, err.Error()))
+			continue
+		}
+		result.pushed++
+	}
+	return result, nil
+}
+
+// importRegistrySchema brings the latest version of a subject in, a schema whose versions already hold the content
+// is left alone so content pushed from memphis does not come back as a new version
+func (s *Server) importRegistrySchema(client *schemaRegistryClient, registrySync models.SchemaRegistrySync, user models.User, schemaName string, remote registrySchema, result *schemaRegistrySyncResult) error {
+	if len(remote.References) > 0 {
+		result.conflict(remote.Subject, "schemas with references are not supported")
+		return nil
+	}
+	schemaType, ok := memphisSchemaTypeOfRegistry(remote.SchemaType)
+	if !ok {
+		result.conflict(remote.Subject, "schema type "+remote.SchemaType+" is not supported")
+		return nil
+	}
+	err := validateSchemaContent(remote.Schema, schemaType)
+	if err != nil {
+		result.conflict(remote.Subject, err.Error())
+		return nil
+	}
+	messageStructName := _EMPTY_
+	if schemaType == "protobuf" {
+		messageStructName, err = firstProtobufMessageName(remote.Schema)
+		if err != nil {
+			result.conflict(remote.Subject, err.Error())
+			return nil
+		}
+	}
+
+	exist, schema, err := db.GetSchemaByName(schemaName, registrySync.TenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		descriptor := _EMPTY_
+		if schemaType == "protobuf" {
+			descriptor, err = generateSchemaDescriptor(schemaName, 1, remote.Schema, schemaType)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = db.InsertNewSchemaWithVersion(schemaName, schemaType, user.ID, user.Username, remote.Schema, messageStructName, descriptor, schemaTagsToCreate(nil), registrySync.TenantName)
+		if err != nil {
+			return err
+		}
+		result.imported++
+		publishBrokerEvent(registrySync.TenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": schemaName, "version_number": 1, "activated_by": user.Username})
+		return nil
+	}
+	if schema.Type != schemaType {
+		result.conflict(remote.Subject, fmt.Sprintf("schema %v is of type %v", schemaName, schema.Type))
+		return nil
+	}
+
+	versions, err := db.GetSchemaVersionsBySchemaID(schema.ID)
+	if err != nil {
+		return err
+	}
+	var activeVersion models.SchemaVersion
+	for _, version := range versions {
+		if version.Active {
+			activeVersion = version
+		}
+		if sameSchemaContent(version.SchemaContent, remote.Schema) {
+			return nil
+		}
+	}
+
+	switch registrySync.ConflictPolicy {
+	case models.SchemaRegistryConflictRemoteWins:
+		newVersion, err := insertNextSchemaVersion(user, schema, remote.Schema, messageStructName, _EMPTY_)
+		if err != nil {
+			return err
+		}
+		err = db.UpdateSchemaActiveVersion(schema.ID, newVersion.VersionNumber, activeVersion.VersionNumber)
+		if err != nil {
+			return err
+		}
+		result.imported++
+		publishBrokerEvent(registrySync.TenantName, models.EventSchemaVersionActivated, map[string]interface{}{"schema_name": schemaName, "version_number": newVersion.VersionNumber, "activated_by": user.Username})
+	case models.SchemaRegistryConflictLocalWins:
+		if !registrySync.PushBack {
+			result.conflict(remote.Subject, "the local schema differs and push back is disabled")
+			return nil
+		}
+		registryType, _ := registrySchemaTypeOfMemphis(schemaType)
+		ctx, cancel := context.WithTimeout(context.Background(), schemaRegistryRequestTimeout)
+		defer cancel()
+		err = client.register(ctx, remote.Subject, registryType, activeVersion.SchemaContent)
+		if err != nil {
+			return err
+		}
+		result.pushed++
+	default:
+		result.conflict(remote.Subject, fmt.Sprintf("version %v differs from the active version %v of schema %v", remote.Version, activeVersion.VersionNumber, schemaName))
+	}
+	return nil
+}
+
+func (s *Server) writeSchemaRegistrySyncsMetrics(mw *metricsWriter) {
+	schemaRegistrySyncs.Lock()
+	defer schemaRegistrySyncs.Unlock()
+	for _, state := range schemaRegistrySyncs.states {
+		labels := []string{"tenant", state.registrySync.TenantName, "sync", state.registrySync.Name, "type", state.registrySync.Type}
+		mw.add("memphis_schema_registry_sync_syncs_total", "counter", "Successful runs of the schema registry sync", float64(state.syncs.Load()), labels...)
+		mw.add("memphis_schema_registry_sync_errors_total", "counter", "Failed runs of the schema registry sync", float64(state.errors.Load()), labels...)
+		mw.add("memphis_schema_registry_sync_conflicts", "gauge", "Subjects left unsynced by the last run of the schema registry sync", float64(len(state.status().Conflicts)), labels...)
+	}
+}
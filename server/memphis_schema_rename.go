@@ -0,0 +1,165 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenameSchema renames a schema together with the stations attached to it, the old name stays
+// resolvable as an alias so producers and resources which still use it keep working
+func (sh SchemasHandler) RenameSchema(c *gin.Context) {
+	var body models.RenameSchemaSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RenameSchema at getUserDetailsFromMiddleware: Schema %v: %v", body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	newName := strings.ToLower(strings.TrimSpace(body.NewName))
+	err = validateSchemaName(newName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RenameSchema at validateSchemaName: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, schema, err := db.GetSchemaByName(strings.ToLower(body.SchemaName), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameSchema at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, body.SchemaName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Schema %v does not exist", body.SchemaName)
+		serv.Warnf("[tenant: %v][user: %v]RenameSchema: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if newName == schema.Name {
+		errMsg := "The new name of the schema has to be different from its current name"
+		serv.Warnf("[tenant: %v][user: %v]RenameSchema: Schema %v: %v", user.TenantName, user.Username, schema.Name, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	taken, other, err := db.GetSchemaByName(newName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameSchema at GetSchemaByName: Schema %v: %v", user.TenantName, user.Username, newName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if taken && other.ID != schema.ID {
+		errMsg := fmt.Sprintf("The name %v is already used by schema %v", newName, other.Name)
+		serv.Warnf("[tenant: %v][user: %v]RenameSchema: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	err = db.RenameSchema(schema.ID, schema.Name, newName, user.TenantName)
+	if errors.Is(err, db.ErrSchemaExists) {
+		errMsg := fmt.Sprintf("Schema %v already exists", newName)
+		serv.Warnf("[tenant: %v][user: %v]RenameSchema: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameSchema at RenameSchema: Schema %v: %v", user.TenantName, user.Username, schema.Name, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	oldName := schema.Name
+	schema.Name = newName
+
+	stationNames, err := db.GetStationNamesUsingSchema(newName, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameSchema at GetStationNamesUsingSchema: Schema %v: %v", user.TenantName, user.Username, newName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	sh.S.notifyStationsOfSchemaRename(schema, stationNames, user.TenantName)
+	aliases, err := db.GetSchemaAliases(schema.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RenameSchema at GetSchemaAliases: Schema %v: %v", user.TenantName, user.Username, newName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Schema %v has been renamed to %v by user %v", oldName, newName, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+	sh.S.notifyOperationalEvent(user.TenantName, SchemaChangedTitle, message, SchemaChangeAlert)
+
+	c.IndentedJSON(200, models.RenamedSchemaResponse{SchemaName: newName, Aliases: aliases, Stations: stationNames})
+}
+
+// notifyStationsOfSchemaRename sends the producers of the attached stations the schema under its new name
+func (s *Server) notifyStationsOfSchemaRename(schema models.Schema, stationNames []string, tenantName string) {
+	updateContent, err := generateSchemaUpdateInit(schema)
+	if err != nil {
+		s.Errorf("[tenant: %v]notifyStationsOfSchemaRename at generateSchemaUpdateInit: Schema %v: %v", tenantName, schema.Name, err.Error())
+		return
+	}
+	for _, name := range stationNames {
+		stationName, err := StationNameFromStr(name)
+		if err != nil {
+			s.Errorf("[tenant: %v]notifyStationsOfSchemaRename at StationNameFromStr: Station %v: %v", tenantName, name, err.Error())
+			continue
+		}
+		s.updateStationProducersOfSchemaChange(tenantName, stationName, models.SchemaUpdate{UpdateType: models.SchemaUpdateTypeInit, Init: *updateContent})
+	}
+}
+
+// RemoveSchemaAlias releases an old name of a renamed schema, clients which still use it stop resolving the schema
+func (sh SchemasHandler) RemoveSchemaAlias(c *gin.Context) {
+	var body models.RemoveSchemaAliasSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveSchemaAlias at getUserDetailsFromMiddleware: Alias %v: %v", body.Alias, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	alias := strings.ToLower(body.Alias)
+	removed, err := db.RemoveSchemaAlias(alias, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveSchemaAlias at RemoveSchemaAlias: Alias %v: %v", user.TenantName, user.Username, alias, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !removed {
+		errMsg := fmt.Sprintf("%v is not an old name of a schema", alias)
+		serv.Warnf("[tenant: %v][user: %v]RemoveSchemaAlias: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("Schema alias %v has been removed by user %v", alias, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, _EMPTY_, message)
+
+	c.IndentedJSON(200, gin.H{})
+}
@@ -0,0 +1,266 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/hamba/avro/v2"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const schemaValidatorsCacheTTL = 10 * time.Second
+
+var ErrMessageSchemaValidation = errors.New("schema validation has failed")
+
+type messageValidator func(msg []byte) error
+
+type schemaValidatorsCacheEntry struct {
+	validate  messageValidator
+	versionId int
+	expiresAt time.Time
+}
+
+// compiled validators are kept for a short while so producing through the gateways does not hit the db per message,
+// a schema change is picked up once the entry expires. The validator itself is compiled once per schema version,
+// an expired entry whose active version did not change keeps it
+var schemaValidatorsCache = struct {
+	sync.Mutex
+	entries map[string]schemaValidatorsCacheEntry
+}{entries: make(map[string]schemaValidatorsCacheEntry)}
+
+func compileMessageValidator(schemaType, schemaContent, messageStructName string) (messageValidator, error) {
+	switch schemaType {
+	case "json":
+		schema, err := jsonschema.CompileString("schema.json", schemaContent)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) error {
+			var v interface{}
+			err := json.Unmarshal(msg, &v)
+			if err != nil {
+				return errors.New("message is not a valid json")
+			}
+			return schema.Validate(v)
+		}, nil
+	case "graphql":
+		schema, err := graphql.ParseSchema(schemaContent, nil)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) error {
+			errs := schema.Validate(string(msg))
+			if len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		}, nil
+	case "avro":
+		schema, err := avro.Parse(schemaContent)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) error {
+			var v interface{}
+			return avro.Unmarshal(schema, msg, &v)
+		}, nil
+	case "protobuf":
+		md, err := findProtobufMessageDescriptor(schemaContent, messageStructName)
+		if err != nil {
+			return nil, err
+		}
+		rule := compileProtobufMessageRule(md)
+		return func(msg []byte) error {
+			return rule.validate(msg)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %v", schemaType)
+	}
+}
+
+func findProtobufMessageDescriptor(schemaContent, messageStructName string) (*desc.MessageDescriptor, error) {
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(schemaContent)), nil
+		},
+	}
+	fds, err := parser.ParseFiles(_EMPTY_)
+	if err != nil {
+		return nil, err
+	}
+	for _, mt := range fds[0].GetMessageTypes() {
+		if mt.GetName() == messageStructName || mt.GetFullyQualifiedName() == messageStructName {
+			return mt, nil
+		}
+	}
+	return nil, fmt.Errorf("message struct %v was not found in the schema", messageStructName)
+}
+
+type messageDecoder func(msg []byte) (any, error)
+
+// compileMessageDecoder is the displaying counterpart of compileMessageValidator,
+// besides validating the message it returns its content in a json friendly form
+func compileMessageDecoder(schemaType, schemaContent, messageStructName string) (messageDecoder, error) {
+	switch schemaType {
+	case "json":
+		schema, err := jsonschema.CompileString("schema.json", schemaContent)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) (any, error) {
+			var v interface{}
+			err := json.Unmarshal(msg, &v)
+			if err != nil {
+				return nil, errors.New("message is not a valid json")
+			}
+			return v, schema.Validate(v)
+		}, nil
+	case "graphql":
+		schema, err := graphql.ParseSchema(schemaContent, nil)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) (any, error) {
+			errs := schema.Validate(string(msg))
+			if len(errs) > 0 {
+				return nil, errs[0]
+			}
+			return string(msg), nil
+		}, nil
+	case "avro":
+		schema, err := avro.Parse(schemaContent)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) (any, error) {
+			var v interface{}
+			err := avro.Unmarshal(schema, msg, &v)
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}, nil
+	case "protobuf":
+		md, err := findProtobufMessageDescriptor(schemaContent, messageStructName)
+		if err != nil {
+			return nil, err
+		}
+		return func(msg []byte) (any, error) {
+			dm := dynamic.NewMessage(md)
+			err := dm.Unmarshal(msg)
+			if err != nil {
+				return nil, err
+			}
+			raw, err := dm.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			var v interface{}
+			err = json.Unmarshal(raw, &v)
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %v", schemaType)
+	}
+}
+
+// getStationMessageDecoder compiles a decoder for the active version of the station's schema, it is not cached since
+// its callers keep the decoder for as long as they need it
+func getStationMessageDecoder(tenantName string, station models.Station) (messageDecoder, error) {
+	exist, schema, err := db.GetSchemaByName(station.SchemaName, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, ErrNoSchema
+	}
+	activeVersion, err := getActiveVersionBySchemaId(schema.ID)
+	if err != nil {
+		return nil, err
+	}
+	return compileMessageDecoder(schema.Type, activeVersion.SchemaContent, activeVersion.MessageStructName)
+}
+
+func getStationMessageValidator(tenantName string, station models.Station) (messageValidator, error) {
+	key := tenantName + "/" + station.SchemaName
+	schemaValidatorsCache.Lock()
+	entry, ok := schemaValidatorsCache.entries[key]
+	schemaValidatorsCache.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.validate, nil
+	}
+
+	exist, schema, err := db.GetSchemaByName(station.SchemaName, tenantName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, ErrNoSchema
+	}
+	activeVersion, err := getActiveVersionBySchemaId(schema.ID)
+	if err != nil {
+		return nil, err
+	}
+	validate := entry.validate
+	if !ok || entry.versionId != activeVersion.ID {
+		validate, err = compileMessageValidator(schema.Type, activeVersion.SchemaContent, activeVersion.MessageStructName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	schemaValidatorsCache.Lock()
+	schemaValidatorsCache.entries[key] = schemaValidatorsCacheEntry{validate: validate, versionId: activeVersion.ID, expiresAt: time.Now().Add(schemaValidatorsCacheTTL)}
+	schemaValidatorsCache.Unlock()
+	return validate, nil
+}
+
+// validateStationMessage validates a message against the schema attached to the station, stations without a schema accept anything.
+// compressed messages are validated by their original payload
+func validateStationMessage(tenantName string, station models.Station, msg []byte, hdrs map[string]string) error {
+	if station.SchemaName == _EMPTY_ {
+		return nil
+	}
+	validate, err := getStationMessageValidator(tenantName, station)
+	if err == ErrNoSchema {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	msg, err = decompressStationMessage(hdrs, msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMessageSchemaValidation, err.Error())
+	}
+	err = validate(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMessageSchemaValidation, err.Error())
+	}
+	return nil
+}
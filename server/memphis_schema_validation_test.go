@@ -0,0 +1,139 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const testProtobufSchema = `syntax = "proto2";
+message Order {
+	required string id = 1;
+	optional int64 amount = 2;
+	repeated int32 quantities = 3;
+	optional Customer customer = 4;
+	repeated Order children = 5;
+	optional double price = 6;
+	map<string, string> labels = 7;
+}
+message Customer {
+	optional string name = 1;
+	optional fixed32 tier = 2;
+}`
+
+func testProtobufOrder(t testing.TB) []byte {
+	md, err := findProtobufMessageDescriptor(testProtobufSchema, "Order")
+	if err != nil {
+		t.Fatalf("findProtobufMessageDescriptor: %v", err)
+	}
+	order := dynamic.NewMessage(md)
+	order.SetFieldByName("id", "order-1")
+	order.SetFieldByName("amount", int64(4200))
+	order.SetFieldByName("quantities", []int32{1, 2, 3})
+	order.SetFieldByName("price", 12.5)
+	order.SetFieldByName("labels", map[string]string{"region": "eu"})
+	customer := dynamic.NewMessage(md.GetFile().FindMessage("Customer"))
+	customer.SetFieldByName("name", "memphis")
+	customer.SetFieldByName("tier", uint32(2))
+	order.SetFieldByName("customer", customer)
+	child := dynamic.NewMessage(md)
+	child.SetFieldByName("id", "order-2")
+	order.SetFieldByName("children", []interface{}{child})
+	b, err := order.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return b
+}
+
+func TestProtobufValidationMatchesDecoding(t *testing.T) {
+	md, err := findProtobufMessageDescriptor(testProtobufSchema, "Order")
+	if err != nil {
+		t.Fatalf("findProtobufMessageDescriptor: %v", err)
+	}
+	rule := compileProtobufMessageRule(md)
+	valid := testProtobufOrder(t)
+
+	packed := protowire.AppendTag(nil, 1, protowire.BytesType)
+	packed = protowire.AppendString(packed, "order-3")
+	packed = protowire.AppendTag(packed, 3, protowire.BytesType)
+	packed = protowire.AppendBytes(packed, protowire.AppendVarint(protowire.AppendVarint(nil, 7), 8))
+
+	unknown := protowire.AppendTag(append([]byte{}, valid...), 99, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+
+	wrongWireType := protowire.AppendTag(append([]byte{}, valid...), 2, protowire.Fixed32Type)
+	wrongWireType = protowire.AppendFixed32(wrongWireType, 1)
+
+	missingRequired := protowire.AppendTag(nil, 2, protowire.VarintType)
+	missingRequired = protowire.AppendVarint(missingRequired, 1)
+
+	badNested := protowire.AppendTag(nil, 1, protowire.BytesType)
+	badNested = protowire.AppendString(badNested, "order-4")
+	badNested = protowire.AppendTag(badNested, 4, protowire.BytesType)
+	badNested = protowire.AppendBytes(badNested, []byte{0x15, 0x01})
+
+	numericOnString := protowire.AppendTag(append([]byte{}, valid...), 1, protowire.Fixed32Type)
+	numericOnString = protowire.AppendFixed32(numericOnString, 1)
+
+	overflow := protowire.AppendTag(append([]byte{}, valid...), 3, protowire.VarintType)
+	overflow = protowire.AppendVarint(overflow, 1<<40)
+
+	nestedMissingRequired := protowire.AppendTag(append([]byte{}, valid...), 5, protowire.BytesType)
+	nestedMissingRequired = protowire.AppendBytes(nestedMissingRequired, missingRequired)
+
+	cases := map[string][]byte{
+		"valid":            valid,
+		"packed":           packed,
+		"unknown field":    unknown,
+		"wrong wire type":  wrongWireType,
+		"numeric string":   numericOnString,
+		"overflow":         overflow,
+		"nested required":  nestedMissingRequired,
+		"missing required": missingRequired,
+		"truncated":        valid[:len(valid)-3],
+		"bad nested":       badNested,
+		"garbage":          []byte{0xff, 0xff, 0xff},
+	}
+	for name, msg := range cases {
+		decodeErr := dynamic.NewMessage(md).Unmarshal(msg)
+		validateErr := rule.validate(msg)
+		if (decodeErr == nil) != (validateErr == nil) {
+			t.Errorf("%v: decoding returned %v while validation returned %v", name, decodeErr, validateErr)
+		}
+	}
+}
+
+func BenchmarkProtobufValidation(b *testing.B) {
+	md, err := findProtobufMessageDescriptor(testProtobufSchema, "Order")
+	if err != nil {
+		b.Fatalf("findProtobufMessageDescriptor: %v", err)
+	}
+	msg := testProtobufOrder(b)
+	rule := compileProtobufMessageRule(md)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := rule.validate(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProtobufDecoding(b *testing.B) {
+	md, err := findProtobufMessageDescriptor(testProtobufSchema, "Order")
+	if err != nil {
+		b.Fatalf("findProtobufMessageDescriptor: %v", err)
+	}
+	msg := testProtobufOrder(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := dynamic.NewMessage(md).Unmarshal(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// SCRAM-SHA-256 (RFC 7677) adapted to the CONNECT handshake. A client first connects with scram-sha-256:<client nonce>
+// in the password/token field and the server rejects it with "Authorization Violation: SCRAM s=<base64 salt>,i=<iterations>",
+// the client then reconnects with scram-sha-256:<client nonce>:<base64 client proof> computed for the nonce of the new INFO.
+// The server keeps only the salt, iteration count, StoredKey and ServerKey of every secret of a user (RFC 5802),
+// users without stored keys get a salt derived from the encryption key so the reply does not tell whether a user exists.
+const (
+	scramMechanismPrefix   = "scram-sha-256:"
+	scramItemSep           = ":"
+	scramIterations        = 4096
+	scramSaltLen           = 16
+	scramSaltLabel         = "memphis-scram-salt:"
+	scramPasswordSecret    = "password"
+	scramServerFirstPrefix = "SCRAM "
+)
+
+// scramSecrets are the keys a user may authenticate with, all of them share the salt and iteration count of the user
+type scramSecrets struct {
+	salt       []byte
+	iterations int
+	keys       []scramKeyPair
+}
+
+type scramKeyPair struct {
+	storedKey []byte
+	serverKey []byte
+}
+
+func isScramCredentials(cred string) bool {
+	return strings.HasPrefix(cred, scramMechanismPrefix)
+}
+
+// isScramClientFirst reports credentials that carry only the client nonce, the client asks for its salt
+func isScramClientFirst(cred string) bool {
+	if !isScramCredentials(cred) {
+		return false
+	}
+	nonce := strings.TrimPrefix(cred, scramMechanismPrefix)
+	return nonce != _EMPTY_ && !strings.Contains(nonce, scramItemSep)
+}
+
+func newScramSalt() ([]byte, error) {
+	salt := make([]byte, scramSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// derivedScramSalt is the salt of users without stored keys, it can not be told apart from a random one
+// without the encryption key
+func derivedScramSalt(username string) []byte {
+	return scramHmac(getAESKey(), scramSaltLabel+username)[:scramSaltLen]
+}
+
+func scramHmac(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func newScramKeyPair(secret string, salt []byte, iterations int) scramKeyPair {
+	saltedPassword := pbkdf2.Key([]byte(secret), salt, iterations, sha256.Size, sha256.New)
+	clientKey := scramHmac(saltedPassword, "Client Key")
+	stored := sha256.Sum256(clientKey)
+	return scramKeyPair{storedKey: stored[:], serverKey: scramHmac(saltedPassword, "Server Key")}
+}
+
+// scramSecretsOf picks the stored keys of the secrets in secretIds, the salt of the newest credentials is the salt of the user
+func scramSecretsOf(username string, credentials []models.ScramCredential, secretIds map[string]bool) scramSecrets {
+	secrets := scramSecrets{salt: derivedScramSalt(username), iterations: scramIterations}
+	if len(credentials) == 0 {
+		return secrets
+	}
+	salt, err := base64.StdEncoding.DecodeString(credentials[0].Salt)
+	if err != nil {
+		return secrets
+	}
+	secrets.salt, secrets.iterations = salt, credentials[0].Iterations
+	for _, cred := range credentials {
+		if !secretIds[cred.SecretId] || cred.Salt != credentials[0].Salt || cred.Iterations != secrets.iterations {
+			continue
+		}
+		storedKey, err := base64.StdEncoding.DecodeString(cred.StoredKey)
+		if err != nil {
+			continue
+		}
+		serverKey, err := base64.StdEncoding.DecodeString(cred.ServerKey)
+		if err != nil {
+			continue
+		}
+		secrets.keys = append(secrets.keys, scramKeyPair{storedKey: storedKey, serverKey: serverKey})
+	}
+	return secrets
+}
+
+// scramSecretsFromConfig derives the keys of secrets that come from the server configuration, like the global
+// connection token or the root password, these are held by the server anyway and have no stored keys
+func scramSecretsFromConfig(username string, configured ...string) scramSecrets {
+	secrets := scramSecrets{salt: derivedScramSalt(username), iterations: scramIterations}
+	for _, secret := range configured {
+		if secret == _EMPTY_ || isBcrypt(secret) {
+			continue
+		}
+		secrets.keys = append(secrets.keys, newScramKeyPair(secret, secrets.salt, secrets.iterations))
+	}
+	return secrets
+}
+
+func scramServerFirst(salt []byte, iterations int) string {
+	return fmt.Sprintf("s=%v,i=%v", base64.StdEncoding.EncodeToString(salt), iterations)
+}
+
+func scramAuthMessage(username, clientNonce, serverNonce string) string {
+	return username + "," + clientNonce + "," + serverNonce
+}
+
+func parseScramCredentials(cred string) (string, []byte, bool) {
+	if !isScramCredentials(cred) {
+		return _EMPTY_, nil, false
+	}
+	parts := strings.Split(strings.TrimPrefix(cred, scramMechanismPrefix), scramItemSep)
+	if len(parts) != 2 || parts[0] == _EMPTY_ {
+		return _EMPTY_, nil, false
+	}
+	proof, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(proof) != sha256.Size {
+		return _EMPTY_, nil, false
+	}
+	return parts[0], proof, true
+}
+
+// storeScramCredential persists the SCRAM keys of a secret of the user, the first secret of a user gets a random salt
+// which the following ones share
+func storeScramCredential(username, tenantName, secretId, secret string) error {
+	credentials, err := db.GetScramCredentialsByUsername(username, tenantName)
+	if err != nil {
+		return err
+	}
+	var salt []byte
+	iterations := scramIterations
+	if len(credentials) > 0 {
+		salt, err = base64.StdEncoding.DecodeString(credentials[0].Salt)
+		iterations = credentials[0].Iterations
+	} else {
+		salt, err = newScramSalt()
+	}
+	if err != nil {
+		return err
+	}
+	keys := newScramKeyPair(secret, salt, iterations)
+	return db.UpsertScramCredential(username, secretId, base64.StdEncoding.EncodeToString(salt), iterations, base64.StdEncoding.EncodeToString(keys.storedKey), base64.StdEncoding.EncodeToString(keys.serverKey), tenantName)
+}
+
+// syncConnectionTokenScramCredentials stores the SCRAM keys of the active connection tokens of the user
+// and removes the keys of the tokens that are gone
+func syncConnectionTokenScramCredentials(username, tenantName string) error {
+	activeTokens, err := db.GetActiveConnectionTokensByUsername(username, tenantName)
+	if err != nil {
+		return err
+	}
+	secretIds := []string{scramPasswordSecret}
+	for _, activeToken := range activeTokens {
+		token, err := DecryptAES(getAESKey(), activeToken.Token)
+		if err != nil {
+			return err
+		}
+		secretId := hashConnectionToken(token)
+		err = storeScramCredential(username, tenantName, secretId, token)
+		if err != nil {
+			return err
+		}
+		secretIds = append(secretIds, secretId)
+	}
+	return db.DeleteScramCredentialsExcept(username, secretIds, tenantName)
+}
+
+// isConfiguredUser reports the users the server configures itself, root and the internal users are not application
+// users of the users table and their password comes from the server configuration
+func isConfiguredUser(username string) bool {
+	return username == ROOT_USERNAME || strings.HasPrefix(username, "$")
+}
+
+// verifyScramPassword checks SCRAM credentials sent in user/pass mode against the stored password keys of the user
+func (s *Server) verifyScramPassword(c *client, connUsername, configuredPassword string) bool {
+	username, tenantId, err := getUserAndTenantIdFromString(connUsername)
+	if err != nil {
+		return false
+	}
+	username = strings.ToLower(username)
+	if db.MetadataDbClient.Client == nil || isConfiguredUser(username) {
+		return c.verifyScramProof(connUsername, scramSecretsFromConfig(connUsername, configuredPassword), c.opts.Password)
+	}
+	tenantName, credentials, err := db.GetScramCredentialsForValidation(username, tenantId, s.MemphisGlobalAccountString())
+	if err != nil {
+		s.Errorf("[tenant id: %v][user: %v]verifyScramPassword at GetScramCredentialsForValidation: %v", tenantId, username, err.Error())
+		return false
+	}
+	secrets := scramSecretsOf(connUsername, credentials, map[string]bool{scramPasswordSecret: true})
+	if len(secrets.keys) == 0 && !isScramClientFirst(c.opts.Password) {
+		s.Warnf("[tenant: %v][user: %v]verifyScramPassword: the user has no SCRAM keys, set its password again to use SCRAM", tenantName, username)
+	}
+	return c.verifyScramProof(connUsername, secrets, c.opts.Password)
+}
+
+// verifyScramProof checks the client proof against the keys of the user, on success the server signature is kept
+// so the client can verify the server as well. Credentials without a proof are answered with the salt of the user
+func (c *client) verifyScramProof(username string, secrets scramSecrets, cred string) bool {
+	if !configuration.SCRAM_AUTH_ENABLED || len(c.nonce) == 0 {
+		c.Debugf("SCRAM credentials sent while SCRAM authentication is disabled")
+		return false
+	}
+	if isScramClientFirst(cred) {
+		c.memphisInfo.scramServerFirst = scramServerFirst(secrets.salt, secrets.iterations)
+		return false
+	}
+	clientNonce, proof, ok := parseScramCredentials(cred)
+	if !ok {
+		c.Debugf("SCRAM credentials are malformed")
+		return false
+	}
+
+	authMessage := scramAuthMessage(username, clientNonce, string(c.nonce))
+	for _, keys := range secrets.keys {
+		clientSignature := scramHmac(keys.storedKey, authMessage)
+		clientKey := make([]byte, len(proof))
+		for i := range proof {
+			clientKey[i] = proof[i] ^ clientSignature[i]
+		}
+		computedStoredKey := sha256.Sum256(clientKey)
+		if subtle.ConstantTimeCompare(computedStoredKey[:], keys.storedKey) != 1 {
+			continue
+		}
+		c.memphisInfo.scramServerSignature = base64.StdEncoding.EncodeToString(scramHmac(keys.serverKey, authMessage))
+		return true
+	}
+	return false
+}
+
+// scramServerFirstReply is the salt reply of a client that asked for it, clients which did not reach the SCRAM
+// verification get a derived salt as well
+func (c *client) scramServerFirstReply() string {
+	if c.memphisInfo.scramServerFirst != _EMPTY_ {
+		return scramServerFirstPrefix + c.memphisInfo.scramServerFirst
+	}
+	if !configuration.SCRAM_AUTH_ENABLED || !(isScramClientFirst(c.opts.Password) || isScramClientFirst(c.opts.Token)) {
+		return _EMPTY_
+	}
+	return scramServerFirstPrefix + scramServerFirst(derivedScramSalt(c.opts.Username), scramIterations)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/memphisdev/memphis/models"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// testScramCredentials computes the credentials a client sends for the given salt and server nonce
+func testScramCredentials(username, secret string, salt []byte, clientNonce, serverNonce string) string {
+	saltedPassword := pbkdf2.Key([]byte(secret), salt, scramIterations, sha256.Size, sha256.New)
+	clientKey := scramHmac(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	clientSignature := scramHmac(storedKey[:], scramAuthMessage(username, clientNonce, serverNonce))
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+	return scramMechanismPrefix + clientNonce + scramItemSep + base64.StdEncoding.EncodeToString(proof)
+}
+
+func testScramCredential(secretId, secret string, salt []byte) models.ScramCredential {
+	keys := newScramKeyPair(secret, salt, scramIterations)
+	return models.ScramCredential{
+		Username:   "app",
+		SecretId:   secretId,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Iterations: scramIterations,
+		StoredKey:  base64.StdEncoding.EncodeToString(keys.storedKey),
+		ServerKey:  base64.StdEncoding.EncodeToString(keys.serverKey),
+	}
+}
+
+func TestParseScramCredentials(t *testing.T) {
+	validProof := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	cases := []struct {
+		name            string
+		cred            string
+		wantNonce       string
+		wantOk          bool
+		wantClientFirst bool
+	}{
+		{name: "valid", cred: scramMechanismPrefix + "abc" + scramItemSep + validProof, wantNonce: "abc", wantOk: true},
+		{name: "plain token", cred: "memphis", wantOk: false},
+		{name: "client first", cred: scramMechanismPrefix + "abc", wantOk: false, wantClientFirst: true},
+		{name: "empty client first", cred: scramMechanismPrefix, wantOk: false},
+		{name: "empty nonce", cred: scramMechanismPrefix + scramItemSep + validProof, wantOk: false},
+		{name: "proof is not base64", cred: scramMechanismPrefix + "abc" + scramItemSep + "!!!", wantOk: false},
+		{name: "short proof", cred: scramMechanismPrefix + "abc" + scramItemSep + base64.StdEncoding.EncodeToString([]byte("short")), wantOk: false},
+		{name: "extra item", cred: scramMechanismPrefix + "abc" + scramItemSep + validProof + scramItemSep + "x", wantOk: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			nonce, proof, ok := parseScramCredentials(tc.cred)
+			if ok != tc.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOk)
+			}
+			if ok && (nonce != tc.wantNonce || len(proof) != sha256.Size) {
+				t.Fatalf("got nonce %q and a %v bytes proof", nonce, len(proof))
+			}
+			if got := isScramClientFirst(tc.cred); got != tc.wantClientFirst {
+				t.Fatalf("isScramClientFirst = %v, want %v", got, tc.wantClientFirst)
+			}
+		})
+	}
+}
+
+func TestScramSecretsOf(t *testing.T) {
+	salt, otherSalt := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+	token := testScramCredential(hashConnectionToken("token"), "token", salt)
+	password := testScramCredential(scramPasswordSecret, "password", salt)
+	staleSalt := testScramCredential(hashConnectionToken("stale"), "stale", otherSalt)
+
+	cases := []struct {
+		name        string
+		credentials []models.ScramCredential
+		secretIds   map[string]bool
+		wantSalt    []byte
+		wantKeys    int
+	}{
+		{name: "no credentials get the derived salt", secretIds: map[string]bool{scramPasswordSecret: true}, wantSalt: derivedScramSalt("app$1")},
+		{name: "password", credentials: []models.ScramCredential{token, password}, secretIds: map[string]bool{scramPasswordSecret: true}, wantSalt: salt, wantKeys: 1},
+		{name: "tokens which are not usable are skipped", credentials: []models.ScramCredential{token, password}, secretIds: map[string]bool{hashConnectionToken("revoked"): true}, wantSalt: salt},
+		{name: "keys of another salt are skipped", credentials: []models.ScramCredential{token, staleSalt}, secretIds: map[string]bool{token.SecretId: true, staleSalt.SecretId: true}, wantSalt: salt, wantKeys: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			secrets := scramSecretsOf("app$1", tc.credentials, tc.secretIds)
+			if !bytes.Equal(secrets.salt, tc.wantSalt) || secrets.iterations != scramIterations {
+				t.Fatalf("got salt %x and %v iterations, want salt %x", secrets.salt, secrets.iterations, tc.wantSalt)
+			}
+			if len(secrets.keys) != tc.wantKeys {
+				t.Fatalf("got %v keys, want %v", len(secrets.keys), tc.wantKeys)
+			}
+		})
+	}
+}
+
+func TestScramSecretsFromConfig(t *testing.T) {
+	secrets := scramSecretsFromConfig("root$1", "memphis", _EMPTY_, "$2a$10$Vz3f7dEZ7P3R5b1Ur7fIvOe8dI7yH0k0W0V1fGf0l0Y0cWz0H6c2y")
+	if len(secrets.keys) != 1 {
+		t.Fatalf("got %v keys, want only the key of the plaintext secret", len(secrets.keys))
+	}
+	if !bytes.Equal(secrets.salt, derivedScramSalt("root$1")) || bytes.Equal(secrets.salt, derivedScramSalt("app$1")) {
+		t.Fatalf("the salt is not derived from the username")
+	}
+}
+
+func TestVerifyScramProof(t *testing.T) {
+	const (
+		username    = "app$1"
+		secret      = "connection-token"
+		serverNonce = "server-nonce"
+	)
+	salt, err := newScramSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := scramSecrets{salt: salt, iterations: scramIterations, keys: []scramKeyPair{newScramKeyPair("previous-token", salt, scramIterations), newScramKeyPair(secret, salt, scramIterations)}}
+
+	cases := []struct {
+		name        string
+		disabled    bool
+		serverNonce string
+		username    string
+		secrets     scramSecrets
+		cred        string
+		want        bool
+	}{
+		{name: "valid proof", serverNonce: serverNonce, username: username, secrets: secrets, cred: testScramCredentials(username, secret, salt, "client-nonce", serverNonce), want: true},
+		{name: "wrong secret", serverNonce: serverNonce, username: username, secrets: secrets, cred: testScramCredentials(username, "other-token", salt, "client-nonce", serverNonce), want: false},
+		{name: "wrong salt", serverNonce: serverNonce, username: username, secrets: secrets, cred: testScramCredentials(username, secret, derivedScramSalt(username), "client-nonce", serverNonce), want: false},
+		{name: "signed for another username", serverNonce: serverNonce, username: "app$2", secrets: secrets, cred: testScramCredentials(username, secret, salt, "client-nonce", serverNonce), want: false},
+		{name: "replayed for another server nonce", serverNonce: "next-nonce", username: username, secrets: secrets, cred: testScramCredentials(username, secret, salt, "client-nonce", serverNonce), want: false},
+		{name: "no server nonce", serverNonce: "", username: username, secrets: secrets, cred: testScramCredentials(username, secret, salt, "client-nonce", ""), want: false},
+		{name: "no keys", serverNonce: serverNonce, username: username, secrets: scramSecrets{salt: salt, iterations: scramIterations}, cred: testScramCredentials(username, secret, salt, "client-nonce", serverNonce), want: false},
+		{name: "malformed credentials", serverNonce: serverNonce, username: username, secrets: secrets, cred: scramMechanismPrefix + "client-nonce" + scramItemSep, want: false},
+		{name: "scram disabled", disabled: true, serverNonce: serverNonce, username: username, secrets: secrets, cred: testScramCredentials(username, secret, salt, "client-nonce", serverNonce), want: false},
+	}
+
+	enabled := configuration.SCRAM_AUTH_ENABLED
+	defer func() { configuration.SCRAM_AUTH_ENABLED = enabled }()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			configuration.SCRAM_AUTH_ENABLED = !tc.disabled
+			c := &client{srv: &Server{}, nonce: []byte(tc.serverNonce)}
+			if got := c.verifyScramProof(tc.username, tc.secrets, tc.cred); got != tc.want {
+				t.Fatalf("verifyScramProof = %v, want %v", got, tc.want)
+			}
+			if tc.want && c.memphisInfo.scramServerSignature == _EMPTY_ {
+				t.Fatalf("the server signature was not kept")
+			}
+		})
+	}
+}
+
+func TestScramServerFirstReply(t *testing.T) {
+	enabled := configuration.SCRAM_AUTH_ENABLED
+	defer func() { configuration.SCRAM_AUTH_ENABLED = enabled }()
+	configuration.SCRAM_AUTH_ENABLED = true
+
+	salt := []byte("0123456789abcdef")
+	c := &client{srv: &Server{}, nonce: []byte("server-nonce")}
+	if c.verifyScramProof("app$1", scramSecrets{salt: salt, iterations: scramIterations}, scramMechanismPrefix+"client-nonce") {
+		t.Fatalf("credentials without a proof were accepted")
+	}
+	want := scramServerFirstPrefix + "s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+	if got := c.scramServerFirstReply(); got != want {
+		t.Fatalf("scramServerFirstReply = %q, want %q", got, want)
+	}
+
+	// a client which never reached the SCRAM verification, like an unknown user, gets a derived salt
+	unknown := &client{srv: &Server{}, nonce: []byte("server-nonce")}
+	unknown.opts.Username, unknown.opts.Password = "nobody$1", scramMechanismPrefix+"client-nonce"
+	want = scramServerFirstPrefix + scramServerFirst(derivedScramSalt("nobody$1"), scramIterations)
+	if got := unknown.scramServerFirstReply(); got != want {
+		t.Fatalf("scramServerFirstReply = %q, want %q", got, want)
+	}
+
+	plain := &client{srv: &Server{}}
+	plain.opts.Password = "memphis"
+	if got := plain.scramServerFirstReply(); got != _EMPTY_ {
+		t.Fatalf("a plain password got the SCRAM reply %q", got)
+	}
+}
@@ -77,25 +77,33 @@ type createProducerRequestV2 struct {
 }
 
 type createProducerRequestV3 struct {
-	Name           string `json:"name"`
-	StationName    string `json:"station_name"`
-	ConnectionId   string `json:"connection_id"`
-	ProducerType   string `json:"producer_type"`
-	RequestVersion int    `json:"req_version"`
-	Username       string `json:"username"`
-	TenantName     string `json:"tenant_name"`
-	AppId          string `json:"app_id"`
-	SdkLang        string `json:"sdk_lang"`
+	Name           string   `json:"name"`
+	StationName    string   `json:"station_name"`
+	ConnectionId   string   `json:"connection_id"`
+	ProducerType   string   `json:"producer_type"`
+	RequestVersion int      `json:"req_version"`
+	Username       string   `json:"username"`
+	TenantName     string   `json:"tenant_name"`
+	AppId          string   `json:"app_id"`
+	SdkLang        string   `json:"sdk_lang"`
+	Compression    []string `json:"compression"`
+	Capabilities   []string `json:"capabilities"`
+	ClientId       string   `json:"client_id"`
 }
 
 type createConsumerResponse struct {
-	Err string `json:"error"`
+	Err     string    `json:"error"`
+	ErrCode ErrorCode `json:"error_code,omitempty"`
 }
 
 type createConsumerResponseV1 struct {
 	SchemaUpdate     models.SchemaUpdateInit `json:"schema_update"`
 	PartitionsUpdate models.PartitionsUpdate `json:"partitions_update"`
+	ProtocolVersion  int                     `json:"protocol_version,omitempty"`
+	Capabilities     []string                `json:"capabilities,omitempty"`
+	HeartbeatMs      int                     `json:"heartbeat_interval_ms,omitempty"`
 	Err              string                  `json:"error"`
+	ErrCode          ErrorCode               `json:"error_code,omitempty"`
 }
 
 type createProducerResponse struct {
@@ -105,7 +113,12 @@ type createProducerResponse struct {
 	ClusterSendNotification         bool                    `json:"send_notification"`
 	StationVersion                  int                     `json:"station_version"`
 	StationPartitionsFirstFunctions map[int]int             `json:"station_partitions_first_functions"`
+	Compression                     string                  `json:"compression,omitempty"`
+	ProtocolVersion                 int                     `json:"protocol_version,omitempty"`
+	Capabilities                    []string                `json:"capabilities,omitempty"`
+	HeartbeatMs                     int                     `json:"heartbeat_interval_ms,omitempty"`
 	Err                             string                  `json:"error"`
+	ErrCode                         ErrorCode               `json:"error_code,omitempty"`
 }
 
 type destroyProducerRequestV0 struct {
@@ -167,20 +180,22 @@ type createConsumerRequestV2 struct {
 }
 
 type createConsumerRequestV3 struct {
-	Name                     string `json:"name"`
-	StationName              string `json:"station_name"`
-	ConnectionId             string `json:"connection_id"`
-	ConsumerType             string `json:"consumer_type"`
-	ConsumerGroup            string `json:"consumers_group"`
-	MaxAckTimeMillis         int    `json:"max_ack_time_ms"`
-	MaxMsgDeliveries         int    `json:"max_msg_deliveries"`
-	Username                 string `json:"username"`
-	StartConsumeFromSequence uint64 `json:"start_consume_from_sequence"`
-	LastMessages             int64  `json:"last_messages"`
-	RequestVersion           int    `json:"req_version"`
-	TenantName               string `json:"tenant_name"`
-	AppId                    string `json:"app_id"`
-	SdkLang                  string `json:"sdk_lang"`
+	Name                     string   `json:"name"`
+	StationName              string   `json:"station_name"`
+	ConnectionId             string   `json:"connection_id"`
+	ConsumerType             string   `json:"consumer_type"`
+	ConsumerGroup            string   `json:"consumers_group"`
+	MaxAckTimeMillis         int      `json:"max_ack_time_ms"`
+	MaxMsgDeliveries         int      `json:"max_msg_deliveries"`
+	Username                 string   `json:"username"`
+	StartConsumeFromSequence uint64   `json:"start_consume_from_sequence"`
+	LastMessages             int64    `json:"last_messages"`
+	RequestVersion           int      `json:"req_version"`
+	TenantName               string   `json:"tenant_name"`
+	AppId                    string   `json:"app_id"`
+	SdkLang                  string   `json:"sdk_lang"`
+	Capabilities             []string `json:"capabilities"`
+	ClientId                 string   `json:"client_id"`
 }
 
 type attachSchemaRequest struct {
@@ -212,6 +227,41 @@ type destroyConsumerRequestV1 struct {
 	RequestVersion int    `json:"req_version"`
 }
 
+type createProducersBatchRequest struct {
+	Producers []createProducerRequestV3 `json:"producers"`
+}
+
+type createProducersBatchResponse struct {
+	Producers []createProducerResponse `json:"producers"`
+	Err       string                   `json:"error"`
+	ErrCode   ErrorCode                `json:"error_code,omitempty"`
+}
+
+type destroyProducersBatchRequest struct {
+	Producers []destroyProducerRequestV1 `json:"producers"`
+}
+
+type createConsumersBatchRequest struct {
+	Consumers []createConsumerRequestV3 `json:"consumers"`
+}
+
+type createConsumersBatchResponse struct {
+	Consumers []createConsumerResponseV1 `json:"consumers"`
+	Err       string                     `json:"error"`
+	ErrCode   ErrorCode                  `json:"error_code,omitempty"`
+}
+
+type destroyConsumersBatchRequest struct {
+	Consumers []destroyConsumerRequestV1 `json:"consumers"`
+}
+
+// destroyBatchResponse holds an error per requested item, empty for the items destroyed successfully
+type destroyBatchResponse struct {
+	Errors  []string  `json:"errors"`
+	Err     string    `json:"error"`
+	ErrCode ErrorCode `json:"error_code,omitempty"`
+}
+
 type CreateSchemaReq struct {
 	Name              string `json:"name"`
 	Type              string `json:"type"`
@@ -221,26 +271,47 @@ type CreateSchemaReq struct {
 }
 
 type SchemaResponse struct {
-	Err string `json:"error"`
+	Err     string    `json:"error"`
+	ErrCode ErrorCode `json:"error_code,omitempty"`
 }
 
 func (cpr *createProducerResponse) SetError(err error) {
 	cpr.Err = err.Error()
+	cpr.ErrCode = errorCodeOf(err)
 }
 
 func (ccr *createConsumerResponse) SetError(err error) {
 	ccr.Err = err.Error()
+	ccr.ErrCode = errorCodeOf(err)
 }
 
 func (ccr *createConsumerResponseV1) SetError(err error) {
 	ccr.Err = err.Error()
+	ccr.ErrCode = errorCodeOf(err)
+}
+
+func (cpr *createProducersBatchResponse) SetError(err error) {
+	cpr.Err = err.Error()
+	cpr.ErrCode = errorCodeOf(err)
+}
+
+func (ccr *createConsumersBatchResponse) SetError(err error) {
+	ccr.Err = err.Error()
+	ccr.ErrCode = errorCodeOf(err)
+}
+
+func (dr *destroyBatchResponse) SetError(err error) {
+	dr.Err = err.Error()
+	dr.ErrCode = errorCodeOf(err)
 }
 
 func (csresp *SchemaResponse) SetError(err error) {
 	if err != nil {
 		csresp.Err = err.Error()
+		csresp.ErrCode = errorCodeOf(err)
 	} else {
 		csresp.Err = _EMPTY_
+		csresp.ErrCode = _EMPTY_
 	}
 }
 
@@ -269,6 +340,20 @@ func (s *Server) initializeSDKHandlers() {
 		"memphis_consumer_destructions_listeners_group",
 		destroyConsumerHandler(s))
 
+	// batch registration, lets an sdk register or destroy many producers/consumers in a single request
+	s.queueSubscribe(s.MemphisGlobalAccountString(), memphisProducerBatchCreations,
+		"memphis_producer_batch_creations_listeners_group",
+		createProducersBatchHandler(s))
+	s.queueSubscribe(s.MemphisGlobalAccountString(), memphisProducerBatchDestructions,
+		"memphis_producer_batch_destructions_listeners_group",
+		destroyProducersBatchHandler(s))
+	s.queueSubscribe(s.MemphisGlobalAccountString(), memphisConsumerBatchCreations,
+		"memphis_consumer_batch_creations_listeners_group",
+		createConsumersBatchHandler(s))
+	s.queueSubscribe(s.MemphisGlobalAccountString(), memphisConsumerBatchDestructions,
+		"memphis_consumer_batch_destructions_listeners_group",
+		destroyConsumersBatchHandler(s))
+
 	// schemas
 	s.queueSubscribe(s.MemphisGlobalAccountString(), "$memphis_schema_attachments",
 		"memphis_schema_attachments_listeners_group",
@@ -324,6 +409,30 @@ func destroyConsumerHandler(s *Server) simplifiedMsgHandler {
 	}
 }
 
+func createProducersBatchHandler(s *Server) simplifiedMsgHandler {
+	return func(c *client, subject, reply string, msg []byte) {
+		go s.createProducersBatchDirect(c, reply, copyBytes(msg))
+	}
+}
+
+func destroyProducersBatchHandler(s *Server) simplifiedMsgHandler {
+	return func(c *client, subject, reply string, msg []byte) {
+		go s.destroyProducersBatchDirect(c, reply, copyBytes(msg))
+	}
+}
+
+func createConsumersBatchHandler(s *Server) simplifiedMsgHandler {
+	return func(c *client, subject, reply string, msg []byte) {
+		go s.createConsumersBatchDirect(c, reply, copyBytes(msg))
+	}
+}
+
+func destroyConsumersBatchHandler(s *Server) simplifiedMsgHandler {
+	return func(c *client, subject, reply string, msg []byte) {
+		go s.destroyConsumersBatchDirect(c, reply, copyBytes(msg))
+	}
+}
+
 func attachSchemaHandler(s *Server) simplifiedMsgHandler {
 	return func(c *client, subject, reply string, msg []byte) {
 		go s.useSchemaDirect(c, reply, copyBytes(msg))
@@ -0,0 +1,185 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+// SDKs which negotiated the heartbeats capability publish their connection id to $memphis_sdk_heartbeats every
+// SDK_HEARTBEAT_INTERVAL_SEC. Every broker records the heartbeats and the one leading the check marks the producers and
+// consumers of a connection inactive once it is silent for SDK_HEARTBEAT_TIMEOUT_SEC, and active again on its next
+// heartbeat. Connections which send heartbeats are left out of the zombie strikes, those only cover older SDKs.
+
+type sdkHeartbeatRequest struct {
+	ConnectionId string `json:"connection_id"`
+}
+
+type sdkHeartbeatState struct {
+	tenantName string
+	lastSeen   time.Time
+	killed     bool
+}
+
+var sdkHeartbeats = struct {
+	sync.Mutex
+	conns map[string]*sdkHeartbeatState
+}{conns: make(map[string]*sdkHeartbeatState)}
+
+func sdkHeartbeatTimeout() time.Duration {
+	return time.Duration(configuration.SDK_HEARTBEAT_TIMEOUT_SEC) * time.Second
+}
+
+// sdkHeartbeatIntervalMs is the interval returned to SDKs which negotiated the heartbeats capability
+func sdkHeartbeatIntervalMs(capabilities []string) int {
+	for _, capability := range capabilities {
+		if capability == sdkCapabilityHeartbeats {
+			return configuration.SDK_HEARTBEAT_INTERVAL_SEC * 1000
+		}
+	}
+	return 0
+}
+
+func (s *Server) ListenForSdkHeartbeats() error {
+	// not a queue subscription, every broker keeps the heartbeats so a new leader of the check starts with them
+	_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), memphisSdkHeartbeats, memphisSdkHeartbeats+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		go s.recordSdkHeartbeat(copyBytes(msg))
+	})
+	return err
+}
+
+func (s *Server) recordSdkHeartbeat(msg []byte) {
+	tenantName, message, err := s.getTenantNameAndMessage(msg)
+	if err != nil {
+		s.Errorf("recordSdkHeartbeat at getTenantNameAndMessage: %v", err.Error())
+		return
+	}
+	var req sdkHeartbeatRequest
+	if err := json.Unmarshal([]byte(message), &req); err != nil || req.ConnectionId == _EMPTY_ {
+		s.Warnf("[tenant: %v]recordSdkHeartbeat: invalid heartbeat %v", tenantName, message)
+		return
+	}
+
+	now := time.Now()
+	sdkHeartbeats.Lock()
+	state, exist := sdkHeartbeats.conns[req.ConnectionId]
+	if !exist {
+		state = &sdkHeartbeatState{tenantName: tenantName}
+		sdkHeartbeats.conns[req.ConnectionId] = state
+	}
+	revived := exist && (state.killed || now.Sub(state.lastSeen) >= sdkHeartbeatTimeout())
+	state.lastSeen = now
+	state.killed = false
+	sdkHeartbeats.Unlock()
+
+	if revived && holdsBackgroundTaskLease("CheckSdkHeartbeats") {
+		_, err = db.UpdateProducersCounsumersConnection(req.ConnectionId, true)
+		if err != nil {
+			s.Errorf("[tenant: %v]recordSdkHeartbeat at UpdateProducersCounsumersConnection: %v", tenantName, err.Error())
+			return
+		}
+		producersStateSetConnectionActive(true, req.ConnectionId)
+		recordCgRebalancesByConnections(tenantName, cgRebalanceMemberReconnected, req.ConnectionId)
+	}
+}
+
+// sdkHeartbeatsTracked reports whether the liveness of a connection is decided by its heartbeats
+func sdkHeartbeatsTracked(connectionId string) bool {
+	sdkHeartbeats.Lock()
+	defer sdkHeartbeats.Unlock()
+	_, exist := sdkHeartbeats.conns[connectionId]
+	return exist
+}
+
+// expiredSdkHeartbeats returns the connections which stopped sending heartbeats and forgets the ones silent for
+// longer than ZOMBIE_CANDIDATE_TTL_MIN, when markKilled is set the returned connections are not returned again
+func expiredSdkHeartbeats(markKilled bool) []string {
+	var expired []string
+	timeout := sdkHeartbeatTimeout()
+	ttl := time.Duration(configuration.ZOMBIE_CANDIDATE_TTL_MIN) * time.Minute
+	sdkHeartbeats.Lock()
+	defer sdkHeartbeats.Unlock()
+	for connectionId, state := range sdkHeartbeats.conns {
+		silence := time.Since(state.lastSeen)
+		if silence >= ttl && silence >= timeout {
+			delete(sdkHeartbeats.conns, connectionId)
+			continue
+		}
+		if silence >= timeout && !state.killed {
+			expired = append(expired, connectionId)
+			state.killed = markKilled
+		}
+	}
+	return expired
+}
+
+func (s *Server) CheckSdkHeartbeats() {
+	interval := time.Duration(configuration.SDK_HEARTBEAT_INTERVAL_SEC) * time.Second
+	for range time.Tick(interval) {
+		leader := s.leadsBackgroundTask("CheckSdkHeartbeats", interval)
+		expired := expiredSdkHeartbeats(leader)
+		if !leader || len(expired) == 0 {
+			continue
+		}
+
+		s.Warnf("%v connections stopped sending heartbeats, marking their producers and consumers as disconnected", len(expired))
+		clients, err := db.GetActiveClientsByConnections(expired)
+		if err != nil {
+			s.Warnf("CheckSdkHeartbeats at GetActiveClientsByConnections: %v", err.Error())
+		}
+		err = db.KillProducersByConnections(expired)
+		if err != nil {
+			s.Errorf("CheckSdkHeartbeats at KillProducersByConnections: %v", err.Error())
+		} else {
+			producersStateSetConnectionActive(false, expired...)
+		}
+		err = db.KillConsumersByConnections(expired)
+		if err != nil {
+			s.Errorf("CheckSdkHeartbeats at KillConsumersByConnections: %v", err.Error())
+		} else {
+			recordCgRebalancesByConnections(_EMPTY_, cgRebalanceMemberDisconnected, expired...)
+		}
+		auditKilledConnectionClients(clients, fmt.Sprintf("did not send a heartbeat for %v seconds", configuration.SDK_HEARTBEAT_TIMEOUT_SEC))
+	}
+}
+
+// auditKilledConnectionClients writes an audit log for each producer and consumer disconnected by the broker,
+// reason completes "<client> has been disconnected since its connection <id> ..."
+func auditKilledConnectionClients(clients []models.ConnectionClient, reason string) {
+	if len(clients) == 0 {
+		return
+	}
+	var auditLogs []interface{}
+	for _, connClient := range clients {
+		clientType := "Producer"
+		if connClient.ClientType == "consumer" {
+			clientType = "Consumer"
+		}
+		auditLogs = append(auditLogs, models.AuditLog{
+			StationName:       connClient.StationName,
+			Message:           fmt.Sprintf("%v %v has been disconnected since its connection %v %v", clientType, connClient.Name, connClient.ConnectionId, reason),
+			CreatedByUsername: "system",
+			CreatedAt:         time.Now(),
+			TenantName:        connClient.TenantName,
+		})
+	}
+	err := CreateAuditLogs(auditLogs)
+	if err != nil {
+		serv.Warnf("auditKilledConnectionClients at CreateAuditLogs: %v", err.Error())
+	}
+}
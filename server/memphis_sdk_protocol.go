@@ -0,0 +1,82 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/utils/strings/slices"
+)
+
+// SDKs declare the version of their handshake with req_version and may list the capabilities they want to use.
+// The broker answers with the version both sides speak and the capabilities it enabled, so features introduced in
+// newer versions are switched on per connection instead of changing the behavior seen by older SDKs.
+// MIN_SDK_REQ_VERSION rejects SDKs which are too old with an upgrade_required error.
+const (
+	sdkCapabilityPartitions        = "partitions"
+	sdkCapabilityCompression       = "compression"
+	sdkCapabilityBatchRegistration = "batch_registration"
+	sdkCapabilityHeartbeats        = "heartbeats"
+)
+
+// sdkCapabilitiesMinVersion holds the first request version each capability is available at
+var sdkCapabilitiesMinVersion = map[string]int{
+	sdkCapabilityPartitions:        2,
+	sdkCapabilityCompression:       4,
+	sdkCapabilityBatchRegistration: 4,
+	sdkCapabilityHeartbeats:        4,
+}
+
+func sdkUpgradeRequiredError(requestVersion, requiredVersion int, feature string) error {
+	return NewMemphisError(ErrCodeUpgradeRequired, fmt.Sprintf("%v requires sdk request version %v or newer but the sdk sent version %v, upgrade the memphis sdk", feature, requiredVersion, requestVersion))
+}
+
+// validateSdkRequestVersion rejects handshakes of SDKs older than MIN_SDK_REQ_VERSION or with a malformed version
+func validateSdkRequestVersion(requestVersion int) error {
+	if requestVersion < 0 {
+		return NewMemphisError(ErrCodeInvalidRequest, fmt.Sprintf("invalid sdk request version %v", requestVersion))
+	}
+	if requestVersion < configuration.MIN_SDK_REQ_VERSION {
+		return sdkUpgradeRequiredError(requestVersion, configuration.MIN_SDK_REQ_VERSION, "this broker")
+	}
+	return nil
+}
+
+// negotiateSdkProtocol returns the version both sides speak, newer SDKs are answered with the latest version the
+// broker knows, and the requested capabilities available at that version. SDKs which did not ask for capabilities
+// get none back, so their responses stay unchanged
+func negotiateSdkProtocol(requestVersion, latestVersion int, requested []string) (int, []string) {
+	version := requestVersion
+	if version > latestVersion {
+		version = latestVersion
+	}
+	var enabled []string
+	for _, capability := range requested {
+		capability = strings.ToLower(strings.TrimSpace(capability))
+		minVersion, known := sdkCapabilitiesMinVersion[capability]
+		if !known || version < minVersion || slices.Contains(enabled, capability) {
+			continue
+		}
+		enabled = append(enabled, capability)
+	}
+	return version, enabled
+}
+
+// requireSdkCapability fails requests for a feature the declared request version does not include
+func requireSdkCapability(requestVersion int, capability string) error {
+	minVersion := sdkCapabilitiesMinVersion[capability]
+	if requestVersion < minVersion {
+		return sdkUpgradeRequiredError(requestVersion, minVersion, strings.ReplaceAll(capability, "_", " "))
+	}
+	return nil
+}
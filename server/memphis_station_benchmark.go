@@ -0,0 +1,364 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// A station benchmark produces synthetic messages into a station and optionally consumes them back through its own
+// consumer, to measure the throughput and latencies a deployment can sustain before real traffic reaches it.
+// It runs as a station_benchmark job, the produced messages stay in the station like any other message.
+const (
+	stationBenchmarkJob            = "station_benchmark"
+	stationBenchmarkConnectionId   = "memphis-benchmark"
+	stationBenchmarkConsumerName   = "memphis-benchmark"
+	stationBenchmarkSentAtHeader   = "$memphis_benchmark_sent_at"
+	stationBenchmarkJobHeader      = "$memphis_benchmark_job"
+	stationBenchmarkDefaultSize    = 1024
+	stationBenchmarkFetchBatch     = 100
+	stationBenchmarkFetchExpires   = time.Second
+	stationBenchmarkDrainTimeout   = 10 * time.Second
+	stationBenchmarkMaxFailures    = 100
+	stationBenchmarkProgressPeriod = 100
+)
+
+type stationBenchmarkParams struct {
+	StationName   string `json:"station_name"`
+	MessagesCount int    `json:"messages_count"`
+	MessageSize   int    `json:"message_size"`
+	Rate          int    `json:"rate"`
+	Producers     int    `json:"producers"`
+	Consumers     int    `json:"consumers"`
+}
+
+func init() {
+	registerJobType(stationBenchmarkJob, jobType{run: runStationBenchmark})
+}
+
+// stationBenchmarkRecorder collects the outcome of the producers and consumers of a benchmark
+type stationBenchmarkRecorder struct {
+	mu               sync.Mutex
+	produceLatencies []time.Duration
+	e2eLatencies     []time.Duration
+	failed           int
+	failures         []string
+	produced         atomic.Int64
+	consumed         atomic.Int64
+}
+
+func (r *stationBenchmarkRecorder) produceDone(latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failed++
+		if len(r.failures) < stationBenchmarkMaxFailures {
+			r.failures = append(r.failures, err.Error())
+		}
+		return
+	}
+	r.produced.Add(1)
+	r.produceLatencies = append(r.produceLatencies, latency)
+}
+
+func (r *stationBenchmarkRecorder) consumeDone(latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consumed.Add(1)
+	r.e2eLatencies = append(r.e2eLatencies, latency)
+}
+
+// counts is the partial result reported while the benchmark runs, the latencies are computed once it completes
+func (r *stationBenchmarkRecorder) counts(elapsed time.Duration) models.StationBenchmarkResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return models.StationBenchmarkResult{
+		Produced:   int(r.produced.Load()),
+		Consumed:   int(r.consumed.Load()),
+		Failed:     r.failed,
+		Failures:   append([]string{}, r.failures...),
+		ElapsedSec: elapsed.Seconds(),
+	}
+}
+
+func (r *stationBenchmarkRecorder) result(elapsed time.Duration, messageSize int) models.StationBenchmarkResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := models.StationBenchmarkResult{
+		Produced:        int(r.produced.Load()),
+		Consumed:        int(r.consumed.Load()),
+		Failed:          r.failed,
+		Failures:        append([]string{}, r.failures...),
+		ElapsedSec:      elapsed.Seconds(),
+		ProduceLatency:  benchmarkLatency(r.produceLatencies),
+		EndToEndLatency: benchmarkLatency(r.e2eLatencies),
+	}
+	if elapsed > 0 {
+		result.ProduceRate = float64(result.Produced) / elapsed.Seconds()
+		result.ProduceMBps = result.ProduceRate * float64(messageSize) / (1024 * 1024)
+	}
+	return result
+}
+
+func benchmarkLatency(latencies []time.Duration) models.StationBenchmarkLatency {
+	if len(latencies) == 0 {
+		return models.StationBenchmarkLatency{}
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) float64 {
+		idx := int(p*float64(len(sorted))+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		return float64(sorted[idx]) / float64(time.Millisecond)
+	}
+	return models.StationBenchmarkLatency{
+		P50Ms: percentile(0.5),
+		P90Ms: percentile(0.9),
+		P99Ms: percentile(0.99),
+		MaxMs: float64(sorted[len(sorted)-1]) / float64(time.Millisecond),
+	}
+}
+
+func benchmarkPayload(size int) []byte {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	payload := make([]byte, size)
+	for i := range payload {
+		payload[i] = letters[rand.Intn(len(letters))]
+	}
+	return payload
+}
+
+func runStationBenchmark(ctx context.Context, jc *jobContext) error {
+	var params stationBenchmarkParams
+	if err := jc.params(&params); err != nil {
+		return err
+	}
+	s := jc.s
+	tenantName := jc.job.TenantName
+	stationName, err := StationNameFromStr(params.StationName)
+	if err != nil {
+		return err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("station %v does not exist", stationName.Ext())
+	}
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return err
+	}
+
+	jobId := strconv.Itoa(jc.job.ID)
+	recorder := &stationBenchmarkRecorder{failures: []string{}}
+	consumeCtx, stopConsumers := context.WithCancel(ctx)
+	defer stopConsumers()
+	var consumersWg sync.WaitGroup
+	if params.Consumers > 0 {
+		// a consumer of its own per run, so other benchmarks and the station's consumer groups do not share its messages
+		durable := getInternalConsumerName(stationBenchmarkConsumerName + "-" + jobId)
+		streams := stationStreamsAndFilters(stationName, station)
+		for streamName, filter := range streams {
+			err = s.memphisAddConsumer(tenantName, streamName, &ConsumerConfig{
+				Durable:       durable,
+				DeliverPolicy: DeliverNew,
+				AckPolicy:     AckNone,
+				FilterSubject: filter,
+			})
+			if err != nil {
+				return err
+			}
+			defer func(streamName string) {
+				err := s.memphisRemoveConsumer(tenantName, streamName, durable)
+				if err != nil {
+					s.Warnf("[tenant: %v]runStationBenchmark at memphisRemoveConsumer: station %v: %v", tenantName, stationName.Ext(), err.Error())
+				}
+			}(streamName)
+			for i := 0; i < params.Consumers; i++ {
+				consumersWg.Add(1)
+				go func(streamName string) {
+					defer consumersWg.Done()
+					for consumeCtx.Err() == nil {
+						msgs, err := s.fetchStationMessages(account, streamName, durable, stationBenchmarkFetchBatch, stationBenchmarkFetchExpires)
+						if err != nil {
+							s.Warnf("[tenant: %v]runStationBenchmark at fetchStationMessages: station %v: %v", tenantName, stationName.Ext(), err.Error())
+							time.Sleep(stationBenchmarkFetchExpires)
+							continue
+						}
+						receivedAt := time.Now()
+						for _, msg := range msgs {
+							if msg.Headers[stationBenchmarkJobHeader] != jobId {
+								continue
+							}
+							sentAt, err := strconv.ParseInt(msg.Headers[stationBenchmarkSentAtHeader], 10, 64)
+							if err != nil {
+								continue
+							}
+							recorder.consumeDone(receivedAt.Sub(time.Unix(0, sentAt)))
+						}
+					}
+				}(streamName)
+			}
+		}
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if params.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(params.Rate), params.Producers)
+	}
+	payload := benchmarkPayload(params.MessageSize)
+	start := time.Now()
+	var next atomic.Int64
+	var producersWg sync.WaitGroup
+	for i := 0; i < params.Producers; i++ {
+		producersWg.Add(1)
+		go func() {
+			defer producersWg.Done()
+			for {
+				n := next.Add(1)
+				if n > int64(params.MessagesCount) || ctx.Err() != nil {
+					return
+				}
+				if err := limiter.Wait(ctx); err != nil {
+					return
+				}
+				sentAt := time.Now()
+				hdrs := map[string]string{
+					"$memphis_producedBy":        jc.job.CreatedBy,
+					"$memphis_connectionId":      stationBenchmarkConnectionId,
+					stationBenchmarkJobHeader:    jobId,
+					stationBenchmarkSentAtHeader: strconv.FormatInt(sentAt.UnixNano(), 10),
+				}
+				_, err := s.produceToStation(tenantName, stationName, station, payload, hdrs)
+				recorder.produceDone(time.Since(sentAt), err)
+				if n%stationBenchmarkProgressPeriod == 0 {
+					jc.setProgress(float64(n)*100/float64(params.MessagesCount), recorder.counts(time.Since(start)))
+				}
+			}
+		}()
+	}
+	producersWg.Wait()
+	produceElapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	incomplete := false
+	if params.Consumers > 0 {
+		// the consumers get until the drain timeout without any new message to catch up with the producers
+		lastConsumed, lastProgress := recorder.consumed.Load(), time.Now()
+		for recorder.consumed.Load() < recorder.produced.Load() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if consumed := recorder.consumed.Load(); consumed != lastConsumed {
+				lastConsumed, lastProgress = consumed, time.Now()
+			} else if time.Since(lastProgress) > stationBenchmarkDrainTimeout {
+				incomplete = true
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	stopConsumers()
+	consumersWg.Wait()
+
+	result := recorder.result(produceElapsed, params.MessageSize)
+	result.ConsumeIncomplete = incomplete
+	jc.setProgress(100, result)
+	s.Noticef("[tenant: %v][user: %v]: Benchmark job %v of station %v completed, %v messages produced at %.0f messages per second with a p99 latency of %.2fms", tenantName, jc.job.CreatedBy, jc.job.ID, stationName.Ext(), result.Produced, result.ProduceRate, result.ProduceLatency.P99Ms)
+	return nil
+}
+
+// StartStationBenchmark starts a job which produces synthetic messages into a station, and consumes them back when
+// consumers were requested, its result holds the throughput and the latency percentiles
+func (sh StationsHandler) StartStationBenchmark(c *gin.Context) {
+	var body models.StartStationBenchmarkSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("StartStationBenchmark at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if IsStorageLimitExceeded(user.TenantName) {
+		serv.Warnf("[tenant: %v][user: %v]StartStationBenchmark at IsStorageLimitExceeded: %s", user.TenantName, user.Username, ErrUpgradePlan.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": ErrUpgradePlan.Error()})
+		return
+	}
+	if body.MessageSize == 0 {
+		body.MessageSize = stationBenchmarkDefaultSize
+	}
+	if body.Producers == 0 {
+		body.Producers = 1
+	}
+	if maxPayload := sh.S.getOpts().MaxPayload; maxPayload > 0 && body.MessageSize > int(maxPayload) {
+		errMsg := fmt.Sprintf("The message size can not exceed the max payload of %v bytes", maxPayload)
+		serv.Warnf("[tenant: %v][user: %v]StartStationBenchmark: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "StartStationBenchmark", body.StationName, "write")
+	if !ok {
+		return
+	}
+	if station.ReadOnly {
+		errMsg := fmt.Sprintf("Station %v is read only", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]StartStationBenchmark: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	job, err := sh.S.startJob(stationBenchmarkJob, stationBenchmarkParams{
+		StationName:   stationName.Ext(),
+		MessagesCount: body.MessagesCount,
+		MessageSize:   body.MessageSize,
+		Rate:          body.Rate,
+		Producers:     body.Producers,
+		Consumers:     body.Consumers,
+	}, user)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]StartStationBenchmark at startJob: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Benchmark job %v of %v messages of %v bytes on station %v has been started by user %v", job.ID, body.MessagesCount, body.MessageSize, stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, job)
+}
@@ -0,0 +1,163 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const compactionFetchBatch = 1000
+
+// streamCompactionState is what the compactor remembers about a stream between runs,
+// the latest sequence of every key and how far the stream was already scanned
+type streamCompactionState struct {
+	scannedSeq uint64
+	keys       map[string]uint64
+}
+
+// compactionStates is only touched by the CompactStations goroutine
+var compactionStates = make(map[string]*streamCompactionState)
+
+// CompactStations keeps only the latest message per key on compacted stations,
+// the key is taken from the COMPACTION_KEY_HEADER header and messages without it are never removed
+func (s *Server) CompactStations() {
+	interval := time.Duration(configuration.COMPACTION_INTERVAL_SEC) * time.Second
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if !s.leadsBackgroundTask("CompactStations", interval) { // logic happens once only on the leader
+			// another broker may have compacted the streams in the meantime
+			compactionStates = make(map[string]*streamCompactionState)
+			continue
+		}
+
+		stations, err := db.GetCompactedStations()
+		if err != nil {
+			s.Errorf("CompactStations at GetCompactedStations: %v", err.Error())
+			continue
+		}
+
+		active := make(map[string]bool)
+		for _, station := range stations {
+			stationName, err := StationNameFromStr(station.Name)
+			if err != nil {
+				continue
+			}
+			partitions := station.PartitionsList
+			if len(partitions) == 0 {
+				partitions = []int{-1}
+			}
+			for _, partition := range partitions {
+				streamName := stationName.Intern()
+				if partition != -1 {
+					streamName = fmt.Sprintf("%v$%v", stationName.Intern(), partition)
+				}
+				stateKey := station.TenantName + ":" + streamName
+				active[stateKey] = true
+				state, ok := compactionStates[stateKey]
+				if !ok {
+					state = &streamCompactionState{keys: make(map[string]uint64)}
+					compactionStates[stateKey] = state
+				}
+				removed, err := s.compactStream(station, stationName, partition, streamName, state)
+				if err != nil {
+					s.Errorf("[tenant: %v]CompactStations at compactStream: station %v: %v", station.TenantName, station.Name, err.Error())
+					continue
+				}
+				if removed > 0 {
+					s.Debugf("[tenant: %v]CompactStations: removed %v replaced messages from station %v", station.TenantName, removed, station.Name)
+				}
+			}
+		}
+
+		for stateKey := range compactionStates {
+			if !active[stateKey] {
+				delete(compactionStates, stateKey)
+			}
+		}
+	}
+}
+
+func (s *Server) compactStream(station models.Station, stationName StationName, partition int, streamName string, state *streamCompactionState) (int, error) {
+	streamInfo, err := s.memphisStreamInfo(station.TenantName, streamName)
+	if err != nil {
+		return 0, err
+	}
+	lastSeq := streamInfo.State.LastSeq
+	if lastSeq < state.scannedSeq {
+		// the stream was recreated, start over
+		state.scannedSeq = 0
+		state.keys = make(map[string]uint64)
+	}
+
+	filterSubj := streamName + ".final"
+	if !station.IsNative {
+		filterSubj = _EMPTY_
+	}
+
+	removed := 0
+	for {
+		startSeq := state.scannedSeq + 1
+		if startSeq < streamInfo.State.FirstSeq {
+			startSeq = streamInfo.State.FirstSeq
+		}
+		if startSeq > lastSeq {
+			break
+		}
+		amount := compactionFetchBatch
+		if lastSeq-startSeq+1 < uint64(amount) {
+			amount = int(lastSeq - startSeq + 1)
+		}
+
+		msgs, err := s.memphisGetMsgs(station.TenantName, filterSubj, streamName, startSeq, amount, 2*time.Second, true, false, 1)
+		if err != nil {
+			return removed, err
+		}
+		if len(msgs) == 0 {
+			state.scannedSeq = lastSeq
+			break
+		}
+		sort.Slice(msgs, func(i, j int) bool { return msgs[i].Sequence < msgs[j].Sequence })
+
+		for _, msg := range msgs {
+			if msg.Sequence > lastSeq {
+				break
+			}
+			state.scannedSeq = msg.Sequence
+			if len(msg.Header) == 0 {
+				continue
+			}
+			headers, err := DecodeHeader(msg.Header)
+			if err != nil {
+				continue
+			}
+			key, ok := headers[configuration.COMPACTION_KEY_HEADER]
+			if !ok || key == _EMPTY_ {
+				continue
+			}
+			if prevSeq, ok := state.keys[key]; ok {
+				err = s.RemoveMsg(station.TenantName, stationName, prevSeq, partition)
+				if err != nil && !IsNatsErr(err, JSNoMessageFoundErr) && !IsNatsErr(err, JSSequenceNotFoundErrF) {
+					return removed, err
+				}
+				removed++
+			}
+			state.keys[key] = msg.Sequence
+		}
+	}
+
+	return removed, nil
+}
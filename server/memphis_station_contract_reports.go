@@ -0,0 +1,469 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	stationContractReportsTickInterval = time.Minute
+	stationContractReportsKept         = 50
+	stationContractReportMinHours      = 1
+	stationContractReportMaxHours      = 7 * 24
+	stationContractMaxFailureRate      = 0.01
+	stationContractMaxConsumerLag      = 10000
+)
+
+// ManageStationContractReports generates the scheduled data contract reports and emails them to their recipients
+func (s *Server) ManageStationContractReports() {
+	reportBackgroundTaskAlive("ManageStationContractReports", stationContractReportsTickInterval)
+	ticker := time.NewTicker(stationContractReportsTickInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("ManageStationContractReports", stationContractReportsTickInterval)
+		if !s.leadsBackgroundTask("ManageStationContractReports", stationContractReportsTickInterval) {
+			continue
+		}
+		schedules, err := db.GetDueStationContractReportSchedules()
+		if err != nil {
+			s.Errorf("ManageStationContractReports at GetDueStationContractReportSchedules: %v", err.Error())
+			continue
+		}
+		for _, schedule := range schedules {
+			exist, station, err := db.GetStationById(schedule.StationId, schedule.TenantName)
+			if err != nil {
+				s.Errorf("[tenant: %v]ManageStationContractReports at GetStationById: station id %v: %v", schedule.TenantName, schedule.StationId, err.Error())
+				continue
+			}
+			if !exist {
+				continue
+			}
+			record, err := s.generateStationContractReport(station)
+			if err != nil {
+				s.Errorf("[tenant: %v]ManageStationContractReports at generateStationContractReport: station %v: %v", schedule.TenantName, station.Name, err.Error())
+				continue
+			}
+			s.emailStationContractReport(schedule, record.Report)
+		}
+	}
+}
+
+// generateStationContractReport builds the report of the period since the previous one and stores it,
+// the first report of a station covers everything since the station was created
+func (s *Server) generateStationContractReport(station models.Station) (models.StationContractReportRecord, error) {
+	stationName, err := StationNameFromStr(station.Name)
+	if err != nil {
+		return models.StationContractReportRecord{}, err
+	}
+	previous, err := db.GetStationContractReports(station.ID, 1)
+	if err != nil {
+		return models.StationContractReportRecord{}, err
+	}
+
+	report := models.StationContractReport{
+		StationName:       stationName.Ext(),
+		Findings:          []string{},
+		PeriodStart:       station.CreatedAt,
+		GeneratedAt:       time.Now(),
+		Producers:         []models.StationContractProducer{},
+		ConsumerGroups:    []models.StationContractConsumerGroup{},
+		PartitionLastSeqs: make(map[int]uint64),
+	}
+	previousLastSeqs := map[int]uint64{}
+	if len(previous) > 0 {
+		report.PeriodStart = previous[0].GeneratedAt
+		previousLastSeqs = previous[0].Report.PartitionLastSeqs
+	}
+
+	if station.SchemaName != _EMPTY_ {
+		exist, schema, err := db.GetSchemaByName(station.SchemaName, station.TenantName)
+		if err != nil {
+			return models.StationContractReportRecord{}, err
+		}
+		if exist {
+			activeVersion, err := db.GetActiveVersionBySchemaID(schema.ID)
+			if err != nil {
+				return models.StationContractReportRecord{}, err
+			}
+			report.Schema = &models.StationContractSchema{
+				Name:            schema.Name,
+				Type:            schema.Type,
+				AttachedVersion: station.SchemaVersionNumber,
+				ActiveVersion:   activeVersion.VersionNumber,
+			}
+		}
+	}
+
+	for partition, streamName := range stationIndexPartitions(stationName, station) {
+		streamInfo, err := s.memphisStreamInfo(station.TenantName, streamName)
+		if err != nil {
+			return models.StationContractReportRecord{}, err
+		}
+		lastSeq := streamInfo.State.LastSeq
+		report.PartitionLastSeqs[partition] = lastSeq
+		if prevSeq, ok := previousLastSeqs[partition]; ok && prevSeq <= lastSeq {
+			report.Validation.MessagesProduced += lastSeq - prevSeq
+		} else if len(previous) > 0 {
+			report.Validation.MessagesProduced += lastSeq
+		} else {
+			report.Validation.MessagesProduced += streamInfo.State.Msgs
+		}
+	}
+
+	failuresByProducer, err := db.GetSchemaDlsMessagesCountByProducer(station.ID, report.PeriodStart)
+	if err != nil {
+		return models.StationContractReportRecord{}, err
+	}
+	for _, count := range failuresByProducer {
+		report.Validation.ValidationFailures += count
+	}
+	attempts := report.Validation.MessagesProduced + uint64(report.Validation.ValidationFailures)
+	if attempts > 0 {
+		report.Validation.FailureRate = float64(report.Validation.ValidationFailures) / float64(attempts)
+	}
+
+	producers, err := db.GetAllProducersByStationID(station.ID)
+	if err != nil {
+		return models.StationContractReportRecord{}, err
+	}
+	for _, producer := range producers {
+		report.Producers = append(report.Producers, models.StationContractProducer{
+			Name:               producer.Name,
+			Sdk:                producer.Sdk,
+			SdkVersion:         producer.Version,
+			IsActive:           producer.IsActive,
+			LastSeenAt:         producer.UpdatedAt,
+			ValidationFailures: failuresByProducer[producer.Name],
+		})
+	}
+	sort.Slice(report.Producers, func(i, j int) bool {
+		return report.Producers[i].ValidationFailures > report.Producers[j].ValidationFailures
+	})
+
+	consumers, err := db.GetAllConsumersByStation(station.ID)
+	if err != nil {
+		return models.StationContractReportRecord{}, err
+	}
+	groups := make(map[string]*models.StationContractConsumerGroup)
+	groupNames := []string{}
+	for _, consumer := range consumers {
+		group, ok := groups[consumer.ConsumersGroup]
+		if !ok {
+			cgInfo, err := s.GetCgInfo(station.TenantName, stationName, consumer.ConsumersGroup, consumer.PartitionsList)
+			if err != nil {
+				return models.StationContractReportRecord{}, err
+			}
+			group = &models.StationContractConsumerGroup{
+				Name:        consumer.ConsumersGroup,
+				Lag:         cgInfo.NumPending,
+				AckPending:  cgInfo.NumAckPending,
+				Redelivered: cgInfo.NumRedelivered,
+			}
+			groups[consumer.ConsumersGroup] = group
+			groupNames = append(groupNames, consumer.ConsumersGroup)
+		}
+		if consumer.IsActive {
+			group.ActiveMembers++
+		}
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		report.ConsumerGroups = append(report.ConsumerGroups, *groups[name])
+	}
+
+	assessStationContract(&report)
+	return db.InsertStationContractReport(station.ID, report, stationContractReportsKept, station.TenantName)
+}
+
+// assessStationContract sets the health of the report, the worst finding wins
+func assessStationContract(report *models.StationContractReport) {
+	if report.Schema == nil {
+		report.Health = models.StationContractNoContract
+		report.Findings = append(report.Findings, "No schema is attached to the station, messages are not validated")
+	} else {
+		report.Health = models.StationContractHealthy
+		if report.Schema.AttachedVersion < report.Schema.ActiveVersion {
+			report.Health = models.StationContractAtRisk
+			report.Findings = append(report.Findings, fmt.Sprintf("The station is attached to version %v of schema %v while version %v is active", report.Schema.AttachedVersion, report.Schema.Name, report.Schema.ActiveVersion))
+		}
+		if report.Validation.ValidationFailures > 0 {
+			finding := fmt.Sprintf("%v messages failed schema validation (%.2f%% of produce attempts)", report.Validation.ValidationFailures, report.Validation.FailureRate*100)
+			if report.Validation.FailureRate > stationContractMaxFailureRate {
+				report.Health = models.StationContractViolated
+			} else if report.Health == models.StationContractHealthy {
+				report.Health = models.StationContractAtRisk
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	for _, group := range report.ConsumerGroups {
+		if group.Lag <= stationContractMaxConsumerLag {
+			continue
+		}
+		if report.Health == models.StationContractHealthy {
+			report.Health = models.StationContractAtRisk
+		}
+		report.Findings = append(report.Findings, fmt.Sprintf("Consumer group %v lags %v messages behind", group.Name, group.Lag))
+	}
+}
+
+func (s *Server) emailStationContractReport(schedule models.StationContractReportSchedule, report models.StationContractReport) {
+	if len(schedule.Recipients) == 0 {
+		return
+	}
+	enabled, err := IsSmtpEnabled(schedule.TenantName)
+	if err != nil {
+		s.Errorf("[tenant: %v]emailStationContractReport at IsSmtpEnabled: station %v: %v", schedule.TenantName, report.StationName, err.Error())
+		return
+	}
+	if !enabled {
+		return
+	}
+	subject := fmt.Sprintf("Memphis data contract report: station %v is %v", report.StationName, strings.ReplaceAll(report.Health, "_", " "))
+	body := formatStationContractReport(report)
+	for _, recipient := range schedule.Recipients {
+		err = sendEmail(schedule.TenantName, recipient, subject, body)
+		if err != nil {
+			s.Warnf("[tenant: %v]emailStationContractReport: station %v: sending to %v failed: %v", schedule.TenantName, report.StationName, recipient, err.Error())
+		}
+	}
+}
+
+func formatStationContractReport(report models.StationContractReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Data contract report of station %v\n", report.StationName)
+	fmt.Fprintf(&b, "Period: %v - %v\n", report.PeriodStart.UTC().Format(time.RFC3339), report.GeneratedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Health: %v\n\n", report.Health)
+	if len(report.Findings) > 0 {
+		b.WriteString("Findings:\n")
+		for _, finding := range report.Findings {
+			fmt.Fprintf(&b, "  - %v\n", finding)
+		}
+		b.WriteString("\n")
+	}
+	if report.Schema != nil {
+		fmt.Fprintf(&b, "Schema: %v (%v), attached version %v, active version %v\n", report.Schema.Name, report.Schema.Type, report.Schema.AttachedVersion, report.Schema.ActiveVersion)
+	}
+	fmt.Fprintf(&b, "Messages produced: %v, validation failures: %v\n\n", report.Validation.MessagesProduced, report.Validation.ValidationFailures)
+	if len(report.Producers) > 0 {
+		b.WriteString("Producers:\n")
+		for _, producer := range report.Producers {
+			fmt.Fprintf(&b, "  - %v (%v v%v), active: %v, last seen: %v, validation failures: %v\n", producer.Name, producer.Sdk, producer.SdkVersion, producer.IsActive, producer.LastSeenAt.UTC().Format(time.RFC3339), producer.ValidationFailures)
+		}
+		b.WriteString("\n")
+	}
+	if len(report.ConsumerGroups) > 0 {
+		b.WriteString("Consumer groups:\n")
+		for _, group := range report.ConsumerGroups {
+			fmt.Fprintf(&b, "  - %v, lag: %v, ack pending: %v, redelivered: %v, active members: %v\n", group.Name, group.Lag, group.AckPending, group.Redelivered, group.ActiveMembers)
+		}
+	}
+	return b.String()
+}
+
+func validateStationContractReportSchedule(intervalHours int, recipients []string) error {
+	if intervalHours < stationContractReportMinHours || intervalHours > stationContractReportMaxHours {
+		return fmt.Errorf("interval_hours must be between %v and %v", stationContractReportMinHours, stationContractReportMaxHours)
+	}
+	for _, recipient := range recipients {
+		if err := validateEmail(recipient); err != nil {
+			return fmt.Errorf("recipient %v is not a valid email address", recipient)
+		}
+	}
+	return nil
+}
+
+func (sh StationsHandler) GetStationContractReport(c *gin.Context) {
+	var body models.GetStationContractReportSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationContractReport at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	_, station, ok := getGatewayStation(c, user, "GetStationContractReport", body.StationName, "read")
+	if !ok {
+		return
+	}
+
+	scheduled, schedule, err := db.GetStationContractReportSchedule(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationContractReport at GetStationContractReportSchedule: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	var scheduleRes *models.StationContractReportSchedule
+	if scheduled {
+		scheduleRes = &schedule
+	}
+
+	var record models.StationContractReportRecord
+	if body.Generate {
+		record, err = sh.S.generateStationContractReport(station)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]GetStationContractReport at generateStationContractReport: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+	} else {
+		records, err := db.GetStationContractReports(station.ID, 1)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]GetStationContractReport at GetStationContractReports: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		if len(records) == 0 {
+			errMsg := "No data contract report was generated for station " + body.StationName + " yet"
+			serv.Warnf("[tenant: %v][user: %v]GetStationContractReport: %v", user.TenantName, user.Username, errMsg)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+			return
+		}
+		record = records[0]
+	}
+
+	c.IndentedJSON(200, gin.H{"report": record.Report, "schedule": scheduleRes})
+}
+
+func (sh StationsHandler) GetStationContractReportsHistory(c *gin.Context) {
+	var body models.GetStationContractReportsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationContractReportsHistory at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	_, station, ok := getGatewayStation(c, user, "GetStationContractReportsHistory", body.StationName, "read")
+	if !ok {
+		return
+	}
+	limit := body.Limit
+	if limit == 0 {
+		limit = stationContractReportsKept
+	}
+	if limit < 1 || limit > stationContractReportsKept {
+		errMsg := "limit has to be between 1 and " + strconv.Itoa(stationContractReportsKept)
+		serv.Warnf("[tenant: %v][user: %v]GetStationContractReportsHistory: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	records, err := db.GetStationContractReports(station.ID, limit)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationContractReportsHistory at GetStationContractReports: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	reports := make([]models.StationContractReport, 0, len(records))
+	for _, record := range records {
+		reports = append(reports, record.Report)
+	}
+	c.IndentedJSON(200, reports)
+}
+
+func (sh StationsHandler) UpdateStationContractReportSchedule(c *gin.Context) {
+	var body models.UpdateStationContractReportScheduleSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateStationContractReportSchedule at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "UpdateStationContractReportSchedule", body.StationName, "write")
+	if !ok {
+		return
+	}
+	recipients := []string{}
+	for _, recipient := range body.Recipients {
+		recipient = strings.ToLower(strings.TrimSpace(recipient))
+		if recipient != _EMPTY_ {
+			recipients = append(recipients, recipient)
+		}
+	}
+	err = validateStationContractReportSchedule(body.IntervalHours, recipients)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationContractReportSchedule: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	schedule, err := db.UpsertStationContractReportSchedule(station.ID, body.IntervalHours, recipients, user.Username, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateStationContractReportSchedule at UpsertStationContractReportSchedule: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Data contract report of station %v has been scheduled every %v hours by user %v", stationName.Ext(), body.IntervalHours, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	c.IndentedJSON(200, schedule)
+}
+
+func (sh StationsHandler) RemoveStationContractReportSchedule(c *gin.Context) {
+	var body models.RemoveStationContractReportScheduleSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveStationContractReportSchedule at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "RemoveStationContractReportSchedule", body.StationName, "write")
+	if !ok {
+		return
+	}
+
+	deleted, err := db.DeleteStationContractReportSchedule(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveStationContractReportSchedule at DeleteStationContractReportSchedule: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !deleted {
+		errMsg := "Station " + body.StationName + " has no data contract report schedule"
+		serv.Warnf("[tenant: %v][user: %v]RemoveStationContractReportSchedule: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("Data contract report schedule of station %v has been removed by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]%v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	c.IndentedJSON(200, gin.H{})
+}
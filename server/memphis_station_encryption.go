@@ -0,0 +1,678 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Encrypted stations get their own storage key which is wrapped by the secrets KMS and kept in the metadata db.
+// The key feeds the JetStream file store encryption of the station streams, so producers and consumers are not aware of it.
+// Raft logs of replicated streams are still only encrypted when the broker wide JetStream key is set.
+const stationStorageKeyLen = 32
+
+type stationStorageKey struct {
+	version int
+	key     []byte
+}
+
+// stationStorageKeys holds the current key of a station, the retired keys which are still kept (newest first)
+// and the key version every broker has re-wrapped its replicas of the station with
+type stationStorageKeys struct {
+	current   []byte
+	version   int
+	retired   []stationStorageKey
+	rewrapped map[string]int
+}
+
+var (
+	stationStorageKeysCache  = map[string]stationStorageKeys{}
+	stationStorageKeysCacheL sync.RWMutex
+)
+
+// stationInternFromContext strips the partition suffix of a stream name and the consumer part of a consumer key context
+func stationInternFromContext(context string) string {
+	if i := strings.Index(context, tsep); i != -1 {
+		context = context[:i]
+	}
+	if i := strings.Index(context, "$"); i != -1 {
+		context = context[:i]
+	}
+	return context
+}
+
+func unwrapStationStorageKeys(storageKey models.StationStorageKey, versions []models.StationStorageKeyVersion, rewraps []models.StationStorageKeyRewrap) (stationStorageKeys, error) {
+	keys := stationStorageKeys{version: storageKey.Version, rewrapped: make(map[string]int, len(rewraps))}
+	var err error
+	keys.current, err = db.UnwrapStorageKey(storageKey.WrappedKey)
+	if err != nil {
+		return stationStorageKeys{}, err
+	}
+	for _, version := range versions {
+		key, err := db.UnwrapStorageKey(version.WrappedKey)
+		if err != nil {
+			return stationStorageKeys{}, err
+		}
+		keys.retired = append(keys.retired, stationStorageKey{version: version.Version, key: key})
+	}
+	for _, rewrap := range rewraps {
+		keys.rewrapped[rewrap.BrokerName] = rewrap.Version
+	}
+	return keys, nil
+}
+
+// getStationStorageKeys returns the keys of the station owning the stream, no current key means the station is not encrypted
+func getStationStorageKeys(tenantName, streamName string) (stationStorageKeys, error) {
+	intern := stationInternFromContext(streamName)
+	if intern == _EMPTY_ || tenantName == _EMPTY_ {
+		return stationStorageKeys{}, nil
+	}
+	cacheKey := tenantName + ":" + intern
+	stationStorageKeysCacheL.RLock()
+	keys, ok := stationStorageKeysCache[cacheKey]
+	stationStorageKeysCacheL.RUnlock()
+	if ok || db.MetadataDbClient.Client == nil {
+		return keys, nil
+	}
+
+	stationName := StationNameFromStreamName(intern).Ext()
+	exist, storageKey, err := db.GetStationStorageKey(stationName, tenantName)
+	if err != nil || !exist {
+		return stationStorageKeys{}, err
+	}
+	versions, err := db.GetStationStorageKeyVersions(stationName, tenantName)
+	if err != nil {
+		return stationStorageKeys{}, err
+	}
+	rewraps, err := db.GetStationStorageKeyRewraps(stationName, tenantName)
+	if err != nil {
+		return stationStorageKeys{}, err
+	}
+	keys, err = unwrapStationStorageKeys(storageKey, versions, rewraps)
+	if err != nil {
+		return stationStorageKeys{}, err
+	}
+	stationStorageKeysCacheL.Lock()
+	stationStorageKeysCache[cacheKey] = keys
+	stationStorageKeysCacheL.Unlock()
+	return keys, nil
+}
+
+func forgetStationStorageKeys(tenantName string, stationName StationName) {
+	stationStorageKeysCacheL.Lock()
+	delete(stationStorageKeysCache, tenantName+":"+stationName.Intern())
+	stationStorageKeysCacheL.Unlock()
+}
+
+// stationStoreKeys returns the current and old JetStream keys of an encrypted station stream or key context,
+// both are empty for streams which are not owned by an encrypted station.
+// The old key is the one this broker last re-wrapped its replicas with, or the newest retired key when that is unknown.
+func (s *Server) stationStoreKeys(tenantName, context string) (string, string) {
+	keys, err := getStationStorageKeys(tenantName, context)
+	if err != nil {
+		s.Errorf("[tenant: %v]stationStoreKeys at getStationStorageKeys: %v: %v", tenantName, context, err.Error())
+		return _EMPTY_, _EMPTY_
+	}
+	if keys.current == nil {
+		return _EMPTY_, _EMPTY_
+	}
+	if len(keys.retired) == 0 {
+		return hex.EncodeToString(keys.current), _EMPTY_
+	}
+	old := keys.retired[0].key
+	if version, ok := keys.rewrapped[s.getOpts().ServerName]; ok {
+		for _, retired := range keys.retired {
+			if retired.version == version {
+				old = retired.key
+				break
+			}
+		}
+	}
+	return hex.EncodeToString(keys.current), hex.EncodeToString(old)
+}
+
+func isStationEncrypted(tenantName string, stationName StationName) (bool, error) {
+	keys, err := getStationStorageKeys(tenantName, stationName.Intern())
+	if err != nil {
+		return false, err
+	}
+	return keys.current != nil, nil
+}
+
+// createStationStorageKey has to run before the station streams are created since their stores pick the key up on creation
+func createStationStorageKey(tenantName string, stationName StationName) error {
+	key := make([]byte, stationStorageKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrappedKey, err := db.WrapStorageKey(key)
+	if err != nil {
+		return err
+	}
+	err = db.UpsertStationStorageKey(stationName.Ext(), wrappedKey, tenantName)
+	if err != nil {
+		return err
+	}
+	stationStorageKeysCacheL.Lock()
+	stationStorageKeysCache[tenantName+":"+stationName.Intern()] = stationStorageKeys{current: key, version: 1, rewrapped: map[string]int{}}
+	stationStorageKeysCacheL.Unlock()
+	return nil
+}
+
+func (s *Server) removeStationStorageKey(tenantName string, stationName StationName) error {
+	exist, _, err := db.GetStationStorageKey(stationName.Ext(), tenantName)
+	if err != nil || !exist {
+		return err
+	}
+	err = db.DeleteStationStorageKey(stationName.Ext(), tenantName)
+	if err != nil {
+		return err
+	}
+	forgetStationStorageKeys(tenantName, stationName)
+	s.publishStationStorageKeyUpdate(tenantName, stationName, "delete")
+	return nil
+}
+
+func (s *Server) publishStationStorageKeyUpdate(tenantName string, stationName StationName, operation string) {
+	update := models.StationStorageKeyUpdate{StationName: stationName.Ext(), TenantName: tenantName, Operation: operation}
+	msg, err := json.Marshal(update)
+	if err != nil {
+		s.Errorf("[tenant: %v]publishStationStorageKeyUpdate: %v", tenantName, err.Error())
+		return
+	}
+	s.sendInternalAccountMsg(s.MemphisGlobalAccount(), STATION_STORAGE_KEYS_UPDATES_SUBJ, msg)
+}
+
+// ListenForStationStorageKeyUpdates refreshes the cached keys on every update and re-wraps the local replicas of a station after its key was rotated
+func (s *Server) ListenForStationStorageKeyUpdates() error {
+	_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), STATION_STORAGE_KEYS_UPDATES_SUBJ, STATION_STORAGE_KEYS_UPDATES_SUBJ+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		go func(msg []byte) {
+			var update models.StationStorageKeyUpdate
+			err := json.Unmarshal(msg, &update)
+			if err != nil {
+				s.Errorf("ListenForStationStorageKeyUpdates at Unmarshal: %v", err.Error())
+				return
+			}
+			stationName, err := StationNameFromStr(update.StationName)
+			if err != nil {
+				s.Errorf("[tenant: %v]ListenForStationStorageKeyUpdates at StationNameFromStr: %v", update.TenantName, err.Error())
+				return
+			}
+			forgetStationStorageKeys(update.TenantName, stationName)
+			if update.Operation != "rotate" {
+				return
+			}
+			err = s.rewrapLocalStationStreams(update.TenantName, stationName)
+			if err != nil {
+				s.Errorf("[tenant: %v]ListenForStationStorageKeyUpdates at rewrapLocalStationStreams: station %v: %v", update.TenantName, update.StationName, err.Error())
+			}
+		}(copyBytes(msg))
+	})
+	return err
+}
+
+// rewrapLocalStationStreams moves the station streams stored on this broker to the current station key,
+// records the version this broker is on and drops the retired keys no broker needs anymore
+func (s *Server) rewrapLocalStationStreams(tenantName string, stationName StationName) error {
+	keys, err := getStationStorageKeys(tenantName, stationName.Intern())
+	if err != nil || keys.current == nil {
+		return err
+	}
+	acc, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return err
+	}
+	prf := s.jsKeyGen(hex.EncodeToString(keys.current), acc.Name)
+	oldprfs := make([]keyGen, 0, len(keys.retired))
+	for _, retired := range keys.retired {
+		oldprfs = append(oldprfs, s.jsKeyGen(hex.EncodeToString(retired.key), acc.Name))
+	}
+	rewrapped := false
+	for _, mset := range acc.streams() {
+		if stationInternFromContext(mset.name()) != stationName.Intern() {
+			continue
+		}
+		mset.mu.RLock()
+		fs, ok := mset.store.(*fileStore)
+		mset.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		err = fs.rewrapEncryptionKeys(prf, oldprfs)
+		if err != nil {
+			return err
+		}
+		rewrapped = true
+	}
+	if !rewrapped {
+		return nil
+	}
+
+	err = db.UpsertStationStorageKeyRewrap(stationName.Ext(), tenantName, s.getOpts().ServerName, keys.version)
+	if err != nil {
+		return err
+	}
+	forgetStationStorageKeys(tenantName, stationName)
+	return s.pruneStationStorageKeyVersions(tenantName, stationName)
+}
+
+// stationReplicaBrokers returns the names of the brokers storing a replica of any of the station streams,
+// false is returned when one of them is not known yet
+func (s *Server) stationReplicaBrokers(tenantName string, stationName StationName) ([]string, bool) {
+	if !s.JetStreamIsClustered() {
+		return []string{s.getOpts().ServerName}, true
+	}
+	js := s.getJetStream()
+	if js == nil {
+		return nil, false
+	}
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+	if js.cluster == nil {
+		return nil, false
+	}
+	brokers := map[string]bool{}
+	for streamName, sa := range js.cluster.streams[tenantName] {
+		if stationInternFromContext(streamName) != stationName.Intern() || sa.Group == nil {
+			continue
+		}
+		for _, peer := range sa.Group.Peers {
+			ni, ok := s.nodeToInfo.Load(peer)
+			if !ok {
+				return nil, false
+			}
+			brokers[ni.(nodeInfo).name] = true
+		}
+	}
+	names := make([]string, 0, len(brokers))
+	for name := range brokers {
+		names = append(names, name)
+	}
+	return names, len(names) > 0
+}
+
+// pruneStationStorageKeyVersions drops the retired keys older than the oldest version any replica of the station is still wrapped with
+func (s *Server) pruneStationStorageKeyVersions(tenantName string, stationName StationName) error {
+	brokers, ok := s.stationReplicaBrokers(tenantName, stationName)
+	if !ok {
+		return nil
+	}
+	rewraps, err := db.GetStationStorageKeyRewraps(stationName.Ext(), tenantName)
+	if err != nil {
+		return err
+	}
+	rewrapped := make(map[string]int, len(rewraps))
+	for _, rewrap := range rewraps {
+		rewrapped[rewrap.BrokerName] = rewrap.Version
+	}
+	minVersion := 0
+	for i, broker := range brokers {
+		version, ok := rewrapped[broker]
+		if !ok {
+			// the broker has not re-wrapped its replicas yet
+			return nil
+		}
+		if i == 0 || version < minVersion {
+			minVersion = version
+		}
+	}
+	err = db.DeleteStationStorageKeyVersionsBelow(stationName.Ext(), tenantName, minVersion)
+	if err != nil {
+		return err
+	}
+	forgetStationStorageKeys(tenantName, stationName)
+	s.publishStationStorageKeyUpdate(tenantName, stationName, "prune")
+	return nil
+}
+
+// RewrapOutdatedStationStreams catches up on key rotations which happened while this broker was down
+func (s *Server) RewrapOutdatedStationStreams() {
+	if db.MetadataDbClient.Client == nil {
+		return
+	}
+	type localStation struct {
+		tenantName  string
+		stationName StationName
+	}
+	stations := map[localStation]bool{}
+	s.accounts.Range(func(_, v interface{}) bool {
+		acc := v.(*Account)
+		for _, mset := range acc.streams() {
+			mset.mu.RLock()
+			fs, ok := mset.store.(*fileStore)
+			mset.mu.RUnlock()
+			if !ok {
+				continue
+			}
+			fs.mu.RLock()
+			encrypted := fs.prf != nil
+			fs.mu.RUnlock()
+			if !encrypted {
+				continue
+			}
+			stations[localStation{acc.Name, StationNameFromStreamName(stationInternFromContext(mset.name()))}] = true
+		}
+		return true
+	})
+	for station := range stations {
+		keys, err := getStationStorageKeys(station.tenantName, station.stationName.Intern())
+		if err != nil {
+			s.Errorf("[tenant: %v]RewrapOutdatedStationStreams at getStationStorageKeys: station %v: %v", station.tenantName, station.stationName.Ext(), err.Error())
+			continue
+		}
+		if keys.current == nil {
+			continue
+		}
+		if version, ok := keys.rewrapped[s.getOpts().ServerName]; ok && version >= keys.version {
+			continue
+		}
+		err = s.rewrapLocalStationStreams(station.tenantName, station.stationName)
+		if err != nil {
+			s.Errorf("[tenant: %v]RewrapOutdatedStationStreams at rewrapLocalStationStreams: station %v: %v", station.tenantName, station.stationName.Ext(), err.Error())
+		}
+	}
+}
+
+// rewrapEncryptionKeys re-seals the key files of the stream, its blocks and its consumers with keys derived from prf.
+// The asset keys inside the key files are kept, so no message or state file has to be rewritten.
+// Key files are opened with the key the store currently uses or any of oldprfs, files prf already opens are left as is.
+func (fs *fileStore) rewrapEncryptionKeys(prf keyGen, oldprfs []keyGen) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.prf == nil {
+		return errNoEncryption
+	}
+
+	sc := fs.fcfg.Cipher
+	openKeyFile := func(kprf keyGen, context string, ekey []byte) ([]byte, error) {
+		rb, err := kprf([]byte(context))
+		if err != nil {
+			return nil, err
+		}
+		kek, err := genEncryptionKey(sc, rb)
+		if err != nil {
+			return nil, err
+		}
+		ns := kek.NonceSize()
+		if len(ekey) < ns {
+			return nil, errBadKeySize
+		}
+		return kek.Open(nil, ekey[:ns], ekey[ns:], nil)
+	}
+	candidates := append([]keyGen{fs.prf}, oldprfs...)
+	rewrap := func(keyFile, context string) error {
+		ekey, err := os.ReadFile(keyFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if _, err := openKeyFile(prf, context, ekey); err == nil {
+			// already sealed with the new key by an earlier attempt
+			return nil
+		}
+		var seed []byte
+		for _, kprf := range candidates {
+			if kprf == nil {
+				continue
+			}
+			if seed, err = openKeyFile(kprf, context, ekey); err == nil {
+				break
+			}
+		}
+		if seed == nil {
+			return fmt.Errorf("key file %v can not be opened with the current or any of the previous station keys", keyFile)
+		}
+		rb, err := prf([]byte(context))
+		if err != nil {
+			return err
+		}
+		nkek, err := genEncryptionKey(sc, rb)
+		if err != nil {
+			return err
+		}
+		// the nonce is kept since blocks derive their stream cipher from it
+		ns := nkek.NonceSize()
+		nonce := append(make([]byte, 0, ns+len(seed)+nkek.Overhead()), ekey[:ns]...)
+		return writeKeyFileAtomic(keyFile, nkek.Seal(nonce, nonce, seed, nil))
+	}
+
+	err := rewrap(filepath.Join(fs.fcfg.StoreDir, JetStreamMetaFileKey), fs.cfg.Name)
+	if err != nil {
+		return err
+	}
+	mdir := filepath.Join(fs.fcfg.StoreDir, msgDir)
+	for _, mb := range fs.blks {
+		err = rewrap(filepath.Join(mdir, fmt.Sprintf(keyScan, mb.index)), fmt.Sprintf("%s:%d", fs.cfg.Name, mb.index))
+		if err != nil {
+			return err
+		}
+	}
+	for _, cs := range fs.cfs {
+		o, ok := cs.(*consumerFileStore)
+		if !ok {
+			continue
+		}
+		o.mu.Lock()
+		err = rewrap(filepath.Join(o.odir, JetStreamMetaFileKey), fs.cfg.Name+tsep+o.name)
+		if err == nil {
+			o.prf = prf
+		}
+		o.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	fs.oldprf = fs.prf
+	fs.prf = prf
+	return nil
+}
+
+// writeKeyFileAtomic replaces a key file through a synced temp file so a crash never leaves a partially written key behind
+func writeKeyFileAtomic(keyFile string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(keyFile), filepath.Base(keyFile)+tsep)
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName)
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmpName, defaultFilePerms)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpName, keyFile)
+}
+
+// sealWithStationKey encrypts data leaving the stream store (e.g. on its way to tiered storage) with the station key
+func sealWithStationKey(tenantName, streamName string, data []byte) ([]byte, bool, error) {
+	keys, err := getStationStorageKeys(tenantName, streamName)
+	if err != nil || keys.current == nil {
+		return data, false, err
+	}
+	sealed, err := aesGcmSealBytes(keys.current, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return sealed, true, nil
+}
+
+func openWithStationKey(tenantName, streamName string, sealed []byte) ([]byte, error) {
+	keys, err := getStationStorageKeys(tenantName, streamName)
+	if err != nil {
+		return nil, err
+	}
+	if keys.current == nil {
+		return nil, errors.New("station storage key is missing")
+	}
+	data, err := aesGcmOpenBytes(keys.current, sealed)
+	for _, retired := range keys.retired {
+		if err == nil {
+			break
+		}
+		// sealed before the key was rotated
+		data, err = aesGcmOpenBytes(retired.key, sealed)
+	}
+	return data, err
+}
+
+func aesGcmSealBytes(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func aesGcmOpenBytes(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted payload is too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+func (sh StationsHandler) RotateStorageKey(c *gin.Context) {
+	var body models.RotateStationStorageKeySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RotateStorageKey at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]RotateStorageKey: only admin users can rotate station storage keys", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only admin users can rotate station storage keys"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]RotateStorageKey at StationNameFromStr: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, storageKey, err := db.GetStationStorageKey(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateStorageKey at GetStationStorageKey: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v is not encrypted", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]RotateStorageKey: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	key := make([]byte, stationStorageKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateStorageKey at rand.Read: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	wrappedKey, err := db.WrapStorageKey(key)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateStorageKey at WrapStorageKey: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	exist, storageKey, err = db.RotateStationStorageKey(stationName.Ext(), wrappedKey, user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RotateStorageKey at RotateStationStorageKey: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v is not encrypted", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]RotateStorageKey: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	// every broker, this one included, re-wraps the replicas it stores
+	forgetStationStorageKeys(user.TenantName, stationName)
+	sh.S.publishStationStorageKeyUpdate(user.TenantName, stationName, "rotate")
+
+	message := fmt.Sprintf("Storage key of station %v has been rotated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, storageKey)
+}
+
+// sealTieredStorageObject envelope encrypts a tiered storage object of an encrypted station.
+// Each object gets its own data key, its KMS wrapped form is kept in the object metadata.
+func sealTieredStorageObject(tenantName, streamName string, data []byte) ([]byte, map[string]string, error) {
+	keys, err := getStationStorageKeys(tenantName, streamName)
+	if err != nil || keys.current == nil {
+		return data, nil, err
+	}
+	dataKey := make([]byte, stationStorageKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err := db.WrapStorageKey(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed, err := aesGcmSealBytes(dataKey, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealed, map[string]string{"memphis-encryption": "aes-256-gcm", "memphis-wrapped-key": wrappedKey}, nil
+}
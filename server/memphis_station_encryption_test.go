@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func testStationStorageKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, stationStorageKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// cacheTestStationStorageKeys makes the keys of a station available without the metadata db
+func cacheTestStationStorageKeys(t *testing.T, tenantName, stationName string, keys stationStorageKeys) {
+	t.Helper()
+	sn := StationNameFromStreamName(stationName)
+	stationStorageKeysCacheL.Lock()
+	stationStorageKeysCache[tenantName+":"+sn.Intern()] = keys
+	stationStorageKeysCacheL.Unlock()
+	t.Cleanup(func() { forgetStationStorageKeys(tenantName, sn) })
+}
+
+func TestSealAndOpenWithStationKey(t *testing.T) {
+	oldKey, currentKey := testStationStorageKey(t), testStationStorageKey(t)
+	cacheTestStationStorageKeys(t, "tenant", "orders", stationStorageKeys{current: oldKey, version: 1})
+	data := []byte("tiered storage object")
+
+	sealedWithOld, encrypted, err := sealWithStationKey("tenant", "orders$1", data)
+	if err != nil || !encrypted {
+		t.Fatalf("sealWithStationKey: encrypted %v, err %v", encrypted, err)
+	}
+	if bytes.Contains(sealedWithOld, data) {
+		t.Fatalf("the sealed data holds the plaintext")
+	}
+
+	// the key is rotated, objects sealed before are opened with the retired key
+	cacheTestStationStorageKeys(t, "tenant", "orders", stationStorageKeys{current: currentKey, version: 2, retired: []stationStorageKey{{version: 1, key: oldKey}}})
+	sealed, _, err := sealWithStationKey("tenant", "orders", data)
+	if err != nil {
+		t.Fatalf("sealWithStationKey: %v", err)
+	}
+	for name, s := range map[string][]byte{"current key": sealed, "retired key": sealedWithOld} {
+		opened, err := openWithStationKey("tenant", "orders$2", s)
+		if err != nil {
+			t.Fatalf("openWithStationKey of data sealed with the %v: %v", name, err)
+		}
+		if !bytes.Equal(opened, data) {
+			t.Fatalf("openWithStationKey of data sealed with the %v = %q, want %q", name, opened, data)
+		}
+	}
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := openWithStationKey("tenant", "orders", tampered); err == nil {
+		t.Fatalf("tampered data was opened")
+	}
+	if _, err := openWithStationKey("tenant", "orders", sealed[:4]); err == nil {
+		t.Fatalf("truncated data was opened")
+	}
+
+	// the retired key is gone once every replica was re-wrapped
+	cacheTestStationStorageKeys(t, "tenant", "orders", stationStorageKeys{current: currentKey, version: 2})
+	if _, err := openWithStationKey("tenant", "orders", sealedWithOld); err == nil {
+		t.Fatalf("data sealed with a pruned key was opened")
+	}
+}
+
+func TestSealWithStationKeyOfUnencryptedStation(t *testing.T) {
+	data := []byte("plain")
+	sealed, encrypted, err := sealWithStationKey("tenant", "payments", data)
+	if err != nil || encrypted || !bytes.Equal(sealed, data) {
+		t.Fatalf("sealWithStationKey of a station without a key: encrypted %v, err %v", encrypted, err)
+	}
+	if _, err := openWithStationKey("tenant", "payments", data); err == nil {
+		t.Fatalf("openWithStationKey succeeded without a station key")
+	}
+}
+
+func TestStationStoreKeys(t *testing.T) {
+	keyV1, keyV2, keyV3 := testStationStorageKey(t), testStationStorageKey(t), testStationStorageKey(t)
+	s := &Server{}
+	s.opts = &Options{ServerName: "broker-0"}
+
+	cases := []struct {
+		name    string
+		keys    stationStorageKeys
+		wantOld []byte
+	}{
+		{name: "no retired key", keys: stationStorageKeys{current: keyV3, version: 3}},
+		{name: "newest retired key when the broker did not re-wrap yet", keys: stationStorageKeys{current: keyV3, version: 3, retired: []stationStorageKey{{version: 2, key: keyV2}, {version: 1, key: keyV1}}}, wantOld: keyV2},
+		{name: "key the broker re-wrapped its replicas with", keys: stationStorageKeys{current: keyV3, version: 3, retired: []stationStorageKey{{version: 2, key: keyV2}, {version: 1, key: keyV1}}, rewrapped: map[string]int{"broker-0": 1, "broker-1": 2}}, wantOld: keyV1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cacheTestStationStorageKeys(t, "tenant", "orders", tc.keys)
+			current, old := s.stationStoreKeys("tenant", "orders$1")
+			if current != hex.EncodeToString(keyV3) {
+				t.Fatalf("the current key is not the station key")
+			}
+			wantOld := _EMPTY_
+			if tc.wantOld != nil {
+				wantOld = hex.EncodeToString(tc.wantOld)
+			}
+			if old != wantOld {
+				t.Fatalf("got the old key %v, want %v", old, wantOld)
+			}
+		})
+	}
+
+	if current, old := s.stationStoreKeys("tenant", "payments"); current != _EMPTY_ || old != _EMPTY_ {
+		t.Fatalf("a station without a key got store keys")
+	}
+}
@@ -0,0 +1,410 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nats-io/nuid"
+)
+
+// Station exports are streamed partition by partition in sequence order, every record carries its partition and sequence.
+// A broken download is resumed by asking for the partition of the last received record and its sequence + 1,
+// partitions after it are exported from their start again.
+const (
+	exportFormatNdjson    = "ndjson"
+	exportFormatCsv       = "csv"
+	exportFormatParquet   = "parquet"
+	exportBatchSize       = 1000
+	exportFetchTimeout    = 2 * time.Second
+	exportParquetRowGroup = 16 * 1024 * 1024
+)
+
+var exportContentTypes = map[string]string{
+	exportFormatNdjson:  "application/x-ndjson",
+	exportFormatCsv:     "text/csv",
+	exportFormatParquet: "application/vnd.apache.parquet",
+}
+
+type stationExportRecord struct {
+	Partition       int               `json:"partition"`
+	Seq             uint64            `json:"seq"`
+	Time            time.Time         `json:"time"`
+	Subject         string            `json:"subject"`
+	Headers         map[string]string `json:"headers"`
+	Payload         string            `json:"payload"`
+	PayloadEncoding string            `json:"payload_encoding"`
+	rawPayload      []byte
+}
+
+type stationExportWriter interface {
+	writeRecord(record stationExportRecord) error
+	flush() error
+	close() error
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonExportWriter) writeRecord(record stationExportRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonExportWriter) flush() error { return nil }
+
+func (w *ndjsonExportWriter) close() error { return nil }
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCsvExportWriter(w io.Writer) (*csvExportWriter, error) {
+	cw := &csvExportWriter{writer: csv.NewWriter(w)}
+	err := cw.writer.Write([]string{"partition", "seq", "time", "subject", "headers", "payload", "payload_encoding"})
+	return cw, err
+}
+
+func (w *csvExportWriter) writeRecord(record stationExportRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+	return w.writer.Write([]string{
+		strconv.Itoa(record.Partition),
+		strconv.FormatUint(record.Seq, 10),
+		record.Time.UTC().Format(time.RFC3339Nano),
+		record.Subject,
+		string(headers),
+		record.Payload,
+		record.PayloadEncoding,
+	})
+}
+
+func (w *csvExportWriter) flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvExportWriter) close() error {
+	return w.flush()
+}
+
+type parquetExportWriter struct {
+	writer *parquetWriter
+}
+
+func newParquetExportWriter(w io.Writer) (*parquetExportWriter, error) {
+	pw, err := newParquetWriter(w, []parquetColumn{
+		{name: "partition", physicalType: parquetTypeInt64, convertedType: parquetConvertedNone},
+		{name: "seq", physicalType: parquetTypeInt64, convertedType: parquetConvertedNone},
+		{name: "time", physicalType: parquetTypeInt64, convertedType: parquetConvertedTimestampMilli},
+		{name: "subject", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8},
+		{name: "headers", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8},
+		{name: "payload", physicalType: parquetTypeByteArray, convertedType: parquetConvertedNone},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &parquetExportWriter{writer: pw}, nil
+}
+
+func (w *parquetExportWriter) writeRecord(record stationExportRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+	w.writer.appendInt64(0, int64(record.Partition))
+	w.writer.appendInt64(1, int64(record.Seq))
+	w.writer.appendInt64(2, record.Time.UnixMilli())
+	w.writer.appendBytes(3, []byte(record.Subject))
+	w.writer.appendBytes(4, headers)
+	w.writer.appendBytes(5, record.rawPayload)
+	w.writer.endRow()
+	return nil
+}
+
+// flush only cuts a row group once enough rows were buffered, small row groups make the file slow to scan
+func (w *parquetExportWriter) flush() error {
+	if w.writer.bufferedSize() < exportParquetRowGroup {
+		return nil
+	}
+	return w.writer.flushRowGroup()
+}
+
+func (w *parquetExportWriter) close() error {
+	return w.writer.close()
+}
+
+func newStationExportWriter(format string, w io.Writer) (stationExportWriter, error) {
+	switch format {
+	case exportFormatNdjson:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w)}, nil
+	case exportFormatCsv:
+		return newCsvExportWriter(w)
+	case exportFormatParquet:
+		return newParquetExportWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %v, supported formats are ndjson, csv and parquet", format)
+	}
+}
+
+type stationExportQuery struct {
+	from            time.Time
+	to              time.Time
+	partitionNumber int
+	startSeq        uint64
+}
+
+// stationExportPartitions returns the partitions of the station in export order, 0 stands for a station without partitions
+func stationExportPartitions(station models.Station) []int {
+	if station.Version == 0 || len(station.PartitionsList) == 0 {
+		return []int{0}
+	}
+	partitions := append([]int{}, station.PartitionsList...)
+	sort.Ints(partitions)
+	return partitions
+}
+
+func stationExportStream(stationName StationName, partition int) string {
+	if partition == 0 {
+		return stationName.Intern()
+	}
+	return stationName.Intern() + "$" + strconv.Itoa(partition)
+}
+
+// streamSeqAtTime returns the sequence of the first message stored at or after the given time
+func (s *Server) streamSeqAtTime(tenantName, streamName string, t time.Time) (uint64, bool, error) {
+	cc := ConsumerConfig{
+		Name:          "$memphis_fetch_messages_consumer_" + nuid.Next(),
+		DeliverPolicy: DeliverByStartTime,
+		OptStartTime:  &t,
+		AckPolicy:     AckNone,
+		Replicas:      1,
+	}
+	err := s.memphisAddConsumer(tenantName, streamName, &cc)
+	if err != nil {
+		return 0, false, err
+	}
+	defer s.memphisRemoveConsumer(tenantName, streamName, cc.Name)
+
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return 0, false, err
+	}
+	seqCh := make(chan uint64, 1)
+	reply := cc.Name + "_reply"
+	sub, err := s.subscribeOnAcc(account, reply, reply+"_sid", func(_ *client, _, msgReply string, _ []byte) {
+		seq, _, _ := ackReplyInfo(msgReply)
+		if seq == 0 {
+			return
+		}
+		select {
+		case seqCh <- seq:
+		default:
+		}
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	defer s.unsubscribeOnAcc(account, sub)
+
+	s.sendInternalAccountMsgWithReply(account, fmt.Sprintf(JSApiRequestNextT, streamName, cc.Name), reply, nil, []byte("1"), true)
+	timer := time.NewTimer(exportFetchTimeout)
+	defer timer.Stop()
+	select {
+	case seq := <-seqCh:
+		return seq, true, nil
+	case <-timer.C:
+		return 0, false, nil
+	}
+}
+
+func stationExportRecordFromMsg(partition int, msg StoredMsg) (stationExportRecord, error) {
+	record := stationExportRecord{Partition: partition, Seq: msg.Sequence, Time: msg.Time, Subject: msg.Subject, Headers: map[string]string{}}
+	if len(msg.Header) > 0 {
+		hdrs, err := DecodeHeader(msg.Header)
+		if err != nil {
+			return record, err
+		}
+		record.Headers = hdrs
+	}
+	payload, err := decompressStationMessage(record.Headers, msg.Data)
+	if err != nil {
+		return record, err
+	}
+	// the payload is exported decompressed
+	delete(record.Headers, memphisCompressionHeader)
+	record.rawPayload = payload
+	if utf8.Valid(payload) {
+		record.Payload = string(payload)
+		record.PayloadEncoding = "utf8"
+	} else {
+		record.Payload = base64.StdEncoding.EncodeToString(payload)
+		record.PayloadEncoding = "base64"
+	}
+	return record, nil
+}
+
+// exportStationMessages writes the station messages matching the query, flush is called after every batch so the download progresses
+func (s *Server) exportStationMessages(w stationExportWriter, flush func(), tenantName string, stationName StationName, station models.Station, query stationExportQuery) error {
+	for _, partition := range stationExportPartitions(station) {
+		if partition < query.partitionNumber {
+			continue
+		}
+		streamName := stationExportStream(stationName, partition)
+		info, err := s.memphisStreamInfo(tenantName, streamName)
+		if err != nil {
+			return err
+		}
+		lastSeq := info.State.LastSeq
+		startSeq := info.State.FirstSeq
+		if partition == query.partitionNumber && query.startSeq > startSeq {
+			startSeq = query.startSeq
+		}
+		if !query.from.IsZero() {
+			seq, found, err := s.streamSeqAtTime(tenantName, streamName, query.from)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			if seq > startSeq {
+				startSeq = seq
+			}
+		}
+
+		done := false
+		for !done && lastSeq > 0 && startSeq <= lastSeq {
+			amount := exportBatchSize
+			if remaining := lastSeq - startSeq + 1; remaining < uint64(amount) {
+				amount = int(remaining)
+			}
+			msgs, err := s.memphisGetMsgs(tenantName, _EMPTY_, streamName, startSeq, amount, exportFetchTimeout, true, false, 1)
+			if err != nil {
+				return err
+			}
+			if len(msgs) == 0 {
+				break
+			}
+			sort.Slice(msgs, func(i, j int) bool { return msgs[i].Sequence < msgs[j].Sequence })
+			for _, msg := range msgs {
+				if msg.Sequence > lastSeq || (!query.to.IsZero() && msg.Time.After(query.to)) {
+					done = true
+					break
+				}
+				record, err := stationExportRecordFromMsg(partition, msg)
+				if err != nil {
+					return fmt.Errorf("partition %v message %v: %v", partition, msg.Sequence, err)
+				}
+				if err = w.writeRecord(record); err != nil {
+					return err
+				}
+			}
+			startSeq = msgs[len(msgs)-1].Sequence + 1
+			if err = w.flush(); err != nil {
+				return err
+			}
+			flush()
+		}
+	}
+	return w.close()
+}
+
+func (sh StationsHandler) ExportMessages(c *gin.Context) {
+	var body models.ExportStationMessagesSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ExportMessages at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	format := strings.ToLower(body.Format)
+	if format == _EMPTY_ {
+		format = exportFormatNdjson
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		errMsg := fmt.Sprintf("Unsupported export format %v, supported formats are ndjson, csv and parquet", body.Format)
+		serv.Warnf("[tenant: %v][user: %v]ExportMessages: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	if !body.From.IsZero() && !body.To.IsZero() && body.To.Before(body.From) {
+		errMsg := "The end of the time range has to be after its start"
+		serv.Warnf("[tenant: %v][user: %v]ExportMessages: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ExportMessages at StationNameFromStr: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ExportMessages at GetStationByName: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]ExportMessages: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v.%v\"", stationName.Ext(), format))
+	c.Status(200)
+	writer, err := newStationExportWriter(format, c.Writer)
+	if err == nil {
+		err = sh.S.exportStationMessages(writer, c.Writer.Flush, station.TenantName, stationName, station, stationExportQuery{
+			from:            body.From,
+			to:              body.To,
+			partitionNumber: body.PartitionNumber,
+			startSeq:        body.StartSeq,
+		})
+	}
+	if err != nil {
+		// the download has already started, the client sees a truncated file and resumes from its last record
+		serv.Errorf("[tenant: %v][user: %v]ExportMessages at exportStationMessages: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.Abort()
+		return
+	}
+
+	message := fmt.Sprintf("Messages of station %v have been exported as %v by user %v", stationName.Ext(), format, user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+}
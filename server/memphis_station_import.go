@@ -0,0 +1,410 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// Imports read files in the export format: ndjson lines carrying a payload field (with optional headers and payload_encoding),
+// where any other json line is produced as is, or csv files with a header row holding at least a payload column.
+// The upload is kept in a temp file and produced by an import_messages job, a retried job skips the lines it already handled.
+const (
+	importMessagesJob    = "import_messages"
+	importConnectionId   = "memphis-import"
+	importMaxFailures    = 100
+	importMaxLineSize    = 64 * 1024 * 1024
+	importDefaultPayload = "utf8"
+)
+
+type importMessagesParams struct {
+	StationName    string `json:"station_name"`
+	Format         string `json:"format"`
+	FileName       string `json:"file_name"`
+	FilePath       string `json:"file_path"`
+	FileSize       int64  `json:"file_size"`
+	ValidateSchema bool   `json:"validate_schema"`
+	RateLimit      int    `json:"rate_limit"`
+}
+
+type importMessagesResult struct {
+	Lines    int      `json:"lines"`
+	Produced int      `json:"produced"`
+	Failed   int      `json:"failed"`
+	Failures []string `json:"failures"`
+}
+
+func init() {
+	registerJobType(importMessagesJob, jobType{
+		run:   runStationImport,
+		local: true,
+		cleanup: func(job models.Job) {
+			var params importMessagesParams
+			if json.Unmarshal(job.Params, &params) == nil && params.FilePath != _EMPTY_ {
+				os.Remove(params.FilePath)
+			}
+		},
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+type stationImportRecord struct {
+	headers map[string]string
+	payload []byte
+}
+
+func decodeImportPayload(payload, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case _EMPTY_, importDefaultPayload:
+		return []byte(payload), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(payload)
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %v", encoding)
+	}
+}
+
+func parseNdjsonImportLine(line []byte) (stationImportRecord, error) {
+	var raw struct {
+		Headers         map[string]string `json:"headers"`
+		Payload         *string           `json:"payload"`
+		PayloadEncoding string            `json:"payload_encoding"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		// a line which is valid json but not an object (array, string) is produced as is
+		if !json.Valid(line) {
+			return stationImportRecord{}, errors.New("invalid json")
+		}
+		return stationImportRecord{payload: line}, nil
+	}
+	if raw.Payload == nil {
+		return stationImportRecord{payload: line}, nil
+	}
+	payload, err := decodeImportPayload(*raw.Payload, raw.PayloadEncoding)
+	if err != nil {
+		return stationImportRecord{}, err
+	}
+	return stationImportRecord{headers: raw.Headers, payload: payload}, nil
+}
+
+// readImportRecords calls produce for every record of the file, parsing errors of a single record are reported to produce as well
+func readImportRecords(format string, r io.Reader, produce func(line int, record stationImportRecord, err error) error) error {
+	switch format {
+	case exportFormatNdjson:
+		reader := bufio.NewReaderSize(r, 64*1024)
+		for line := 1; ; line++ {
+			data, err := reader.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if len(data) > importMaxLineSize {
+				return fmt.Errorf("line %v is larger than %v bytes", line, importMaxLineSize)
+			}
+			if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+				record, parseErr := parseNdjsonImportLine(trimmed)
+				if produceErr := produce(line, record, parseErr); produceErr != nil {
+					return produceErr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	case exportFormatCsv:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		columns, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("failed reading the csv header row: %v", err)
+		}
+		payloadIdx, headersIdx, encodingIdx := -1, -1, -1
+		for i, column := range columns {
+			switch strings.TrimSpace(strings.ToLower(column)) {
+			case "payload":
+				payloadIdx = i
+			case "headers":
+				headersIdx = i
+			case "payload_encoding":
+				encodingIdx = i
+			}
+		}
+		if payloadIdx == -1 {
+			return errors.New("the csv file has no payload column")
+		}
+		for line := 2; ; line++ {
+			row, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			var record stationImportRecord
+			var parseErr error
+			field := func(idx int) string {
+				if idx == -1 || idx >= len(row) {
+					return _EMPTY_
+				}
+				return row[idx]
+			}
+			if rawHeaders := field(headersIdx); rawHeaders != _EMPTY_ {
+				parseErr = json.Unmarshal([]byte(rawHeaders), &record.headers)
+			}
+			if parseErr == nil {
+				record.payload, parseErr = decodeImportPayload(field(payloadIdx), field(encodingIdx))
+			}
+			if produceErr := produce(line, record, parseErr); produceErr != nil {
+				return produceErr
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported import format %v, supported formats are ndjson and csv", format)
+	}
+}
+
+// produceStationRecord produces a message on behalf of the broker, connectionId names the operation in the message headers
+func (s *Server) produceStationRecord(producedBy, connectionId string, stationName StationName, station models.Station, record stationImportRecord, validateSchema bool) error {
+	hdrs := make(map[string]string, len(record.headers)+2)
+	for k, v := range record.headers {
+		// headers set by the broker on the original messages are not produced again
+		if strings.HasPrefix(k, "$memphis") {
+			continue
+		}
+		hdrs[k] = v
+	}
+	if validateSchema {
+		if err := validateStationMessage(station.TenantName, station, record.payload, hdrs); err != nil {
+			return err
+		}
+	}
+	hdrs["$memphis_producedBy"] = producedBy
+	hdrs["$memphis_connectionId"] = connectionId
+	payload := record.payload
+	if maxPayload := s.getOpts().MaxPayload; maxPayload > 0 && len(payload) > int(maxPayload) {
+		var err error
+		payload, err = s.storeClaimCheck(station.TenantName, stationName, payload, hdrs)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.produceToStation(station.TenantName, stationName, station, payload, hdrs)
+	return err
+}
+
+func runStationImport(ctx context.Context, jc *jobContext) error {
+	var params importMessagesParams
+	if err := jc.params(&params); err != nil {
+		return err
+	}
+	var result importMessagesResult
+	if err := jc.lastResult(&result); err != nil {
+		return err
+	}
+	if result.Failures == nil {
+		result.Failures = []string{}
+	}
+	stationName, err := StationNameFromStr(params.StationName)
+	if err != nil {
+		return err
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), jc.job.TenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("station %v does not exist", stationName.Ext())
+	}
+	file, err := os.Open(params.FilePath)
+	if err != nil {
+		return fmt.Errorf("the uploaded file is no longer available: %v", err)
+	}
+	defer file.Close()
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if params.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(params.RateLimit), 1)
+	}
+	reader := &countingReader{r: file}
+	progress := func() float64 {
+		if params.FileSize == 0 {
+			return 0
+		}
+		return float64(reader.n) * 100 / float64(params.FileSize)
+	}
+	skipLines := result.Lines
+	err = readImportRecords(params.Format, reader, func(line int, record stationImportRecord, parseErr error) error {
+		if line <= skipLines {
+			return nil
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if parseErr == nil {
+			parseErr = jc.s.produceStationRecord(jc.job.CreatedBy, importConnectionId, stationName, station, record, params.ValidateSchema)
+		}
+		result.Lines = line
+		if parseErr != nil {
+			result.Failed++
+			if len(result.Failures) < importMaxFailures {
+				result.Failures = append(result.Failures, fmt.Sprintf("line %v: %v", line, parseErr.Error()))
+			}
+		} else {
+			result.Produced++
+		}
+		jc.setProgress(progress(), result)
+		return nil
+	})
+	jc.setProgress(progress(), result)
+	if err != nil {
+		return err
+	}
+	// the file is kept after a failure or cancellation so the job can be retried
+	os.Remove(params.FilePath)
+	jc.s.Noticef("[tenant: %v][user: %v]: Import job %v into station %v completed, %v messages produced and %v failed", jc.job.TenantName, jc.job.CreatedBy, jc.job.ID, stationName.Ext(), result.Produced, result.Failed)
+	return nil
+}
+
+func (sh StationsHandler) ImportMessages(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("ImportMessages at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationNameStr := c.PostForm("station_name")
+	uploadedFile, err := c.FormFile("file")
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ImportMessages at FormFile: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Could not complete uploading your file, please check your file"})
+		return
+	}
+	format := strings.ToLower(c.PostForm("format"))
+	if format == _EMPTY_ {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(uploadedFile.Filename)), ".")
+		if format == "jsonl" || format == "json" {
+			format = exportFormatNdjson
+		}
+	}
+	if format != exportFormatNdjson && format != exportFormatCsv {
+		errMsg := "You can import only ndjson or csv files"
+		serv.Warnf("[tenant: %v][user: %v]ImportMessages: At station %v: %v", user.TenantName, user.Username, stationNameStr, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	validateSchema := true
+	if raw := c.PostForm("validate_schema"); raw != _EMPTY_ {
+		validateSchema, err = strconv.ParseBool(raw)
+		if err != nil {
+			serv.Warnf("[tenant: %v][user: %v]ImportMessages: At station %v: invalid validate_schema: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "validate_schema has to be true or false"})
+			return
+		}
+	}
+	rateLimit := 0
+	if raw := c.PostForm("rate_limit"); raw != _EMPTY_ {
+		rateLimit, err = strconv.Atoi(raw)
+		if err != nil || rateLimit < 0 {
+			serv.Warnf("[tenant: %v][user: %v]ImportMessages: At station %v: invalid rate_limit %v", user.TenantName, user.Username, stationNameStr, raw)
+			c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "rate_limit has to be a positive number of messages per second"})
+			return
+		}
+	}
+
+	stationName, err := StationNameFromStr(stationNameStr)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]ImportMessages at StationNameFromStr: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, _, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ImportMessages at GetStationByName: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]ImportMessages: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	// the multipart temp files are removed once the request returns, the background job reads its own copy
+	tmpFile, err := os.CreateTemp(_EMPTY_, "memphis-import-*")
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]ImportMessages at CreateTemp: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	defer tmpFile.Close()
+	upload, err := uploadedFile.Open()
+	if err == nil {
+		_, err = io.Copy(tmpFile, upload)
+		upload.Close()
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		serv.Errorf("[tenant: %v][user: %v]ImportMessages at copying the upload: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	job, err := sh.S.startJob(importMessagesJob, importMessagesParams{
+		StationName:    stationName.Ext(),
+		Format:         format,
+		FileName:       uploadedFile.Filename,
+		FilePath:       tmpFile.Name(),
+		FileSize:       uploadedFile.Size,
+		ValidateSchema: validateSchema,
+		RateLimit:      rateLimit,
+	}, user)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		serv.Errorf("[tenant: %v][user: %v]ImportMessages at startJob: At station %v: %v", user.TenantName, user.Username, stationNameStr, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Import job %v of file %v into station %v has been started by user %v", job.ID, uploadedFile.Filename, stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, job)
+}
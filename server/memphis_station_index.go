@@ -0,0 +1,532 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	stationIndexInterval          = 10 * time.Second
+	stationIndexFetchBatch        = 1000
+	stationIndexMaxBatchesPerPass = 10
+	stationIndexMaxExtractors     = 10
+	stationIndexMaxValueLength    = 256
+	stationIndexSearchLimit       = 100
+)
+
+var stationIndexExtractorNameRegex = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// indexPathSegment is a step of an extractor path, either an object field or an array index
+type indexPathSegment struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// parseIndexPath parses the JSONPath subset extractors support: $.field, $.field[0] and $[0].field,
+// protobuf and avro messages are matched by the field names of their decoded json form
+func parseIndexPath(path string) ([]indexPathSegment, error) {
+	if !strings.HasPrefix(path, "$") || len(path) < 2 {
+		return nil, fmt.Errorf("path %v has to start with $. or $[", path)
+	}
+	var segments []indexPathSegment
+	rest := path[1:]
+	for rest != _EMPTY_ {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %v has an empty field name", path)
+			}
+			segments = append(segments, indexPathSegment{field: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("path %v has an unclosed [", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %v has an invalid array index", path)
+			}
+			segments = append(segments, indexPathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %v is not supported, use $.field and [index] steps", path)
+		}
+	}
+	return segments, nil
+}
+
+func validateStationIndexExtractors(extractors []models.StationIndexExtractor) error {
+	if len(extractors) > stationIndexMaxExtractors {
+		return fmt.Errorf("a station can have up to %v index extractors", stationIndexMaxExtractors)
+	}
+	names := make(map[string]bool, len(extractors))
+	for _, extractor := range extractors {
+		if !stationIndexExtractorNameRegex.MatchString(extractor.Name) {
+			return fmt.Errorf("extractor name %v is invalid, use up to 64 lowercase letters, digits and underscores", extractor.Name)
+		}
+		if names[extractor.Name] {
+			return fmt.Errorf("extractor name %v is used more than once", extractor.Name)
+		}
+		names[extractor.Name] = true
+		if _, err := parseIndexPath(extractor.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexValues returns the scalar values found at the path, an array of scalars yields all of its items
+func indexValues(v interface{}, segments []indexPathSegment) []string {
+	for _, segment := range segments {
+		if segment.isIndex {
+			arr, ok := v.([]interface{})
+			if !ok || segment.index >= len(arr) {
+				return nil
+			}
+			v = arr[segment.index]
+			continue
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if v, ok = obj[segment.field]; !ok {
+			return nil
+		}
+	}
+
+	var items []interface{}
+	if arr, ok := v.([]interface{}); ok {
+		items = arr
+	} else {
+		items = []interface{}{v}
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		var value string
+		switch val := item.(type) {
+		case string:
+			value = val
+		case float64:
+			value = strconv.FormatFloat(val, 'f', -1, 64)
+		case json.Number:
+			value = val.String()
+		case bool:
+			value = strconv.FormatBool(val)
+		case int, int32, int64, uint32, uint64, float32:
+			value = fmt.Sprint(val)
+		default:
+			continue
+		}
+		if value == _EMPTY_ || len(value) > stationIndexMaxValueLength {
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+type compiledIndexExtractor struct {
+	name     string
+	segments []indexPathSegment
+}
+
+// stationIndexPartitions maps the partitions of a station to their stream names, -1 stands for a station without partitions
+func stationIndexPartitions(stationName StationName, station models.Station) map[int]string {
+	if station.Version == 0 || len(station.PartitionsList) == 0 {
+		return map[int]string{-1: stationName.Intern()}
+	}
+	partitions := make(map[int]string, len(station.PartitionsList))
+	for _, p := range station.PartitionsList {
+		partitions[p] = fmt.Sprintf("%v$%v", stationName.Intern(), p)
+	}
+	return partitions
+}
+
+// IndexStationMessages extracts the configured business keys of new station messages into the metadata db,
+// it runs on the leader only and keeps its scan position in the db so a new leader continues where the old one stopped
+func (s *Server) IndexStationMessages() {
+	ticker := time.NewTicker(stationIndexInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !s.leadsBackgroundTask("IndexStationMessages", stationIndexInterval) {
+			continue
+		}
+		configs, err := db.GetActiveStationIndexConfigs()
+		if err != nil {
+			s.Errorf("IndexStationMessages at GetActiveStationIndexConfigs: %v", err.Error())
+			continue
+		}
+		for _, config := range configs {
+			err = s.indexStation(config)
+			if err != nil {
+				s.Errorf("[tenant: %v]IndexStationMessages at indexStation: station id %v: %v", config.TenantName, config.StationId, err.Error())
+			}
+		}
+	}
+}
+
+func (s *Server) indexStation(config models.StationIndexConfig) error {
+	if len(config.Extractors) == 0 {
+		return nil
+	}
+	exist, station, err := db.GetStationById(config.StationId, config.TenantName)
+	if err != nil || !exist {
+		return err
+	}
+	stationName, err := StationNameFromStr(station.Name)
+	if err != nil {
+		return err
+	}
+	extractors := make([]compiledIndexExtractor, 0, len(config.Extractors))
+	for _, extractor := range config.Extractors {
+		segments, err := parseIndexPath(extractor.Path)
+		if err != nil {
+			return err
+		}
+		extractors = append(extractors, compiledIndexExtractor{name: extractor.Name, segments: segments})
+	}
+	var decode messageDecoder
+	if station.SchemaName != _EMPTY_ {
+		decode, err = getStationMessageDecoder(station.TenantName, station)
+		if err != nil && !errors.Is(err, ErrNoSchema) {
+			return err
+		}
+	}
+
+	scannedSeqs := config.ScannedSeqs
+	if scannedSeqs == nil {
+		scannedSeqs = make(map[string]uint64)
+	}
+	for partition, streamName := range stationIndexPartitions(stationName, station) {
+		err = s.indexStationPartition(config, station, extractors, decode, partition, streamName, scannedSeqs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) indexStationPartition(config models.StationIndexConfig, station models.Station, extractors []compiledIndexExtractor, decode messageDecoder, partition int, streamName string, scannedSeqs map[string]uint64) error {
+	streamInfo, err := s.memphisStreamInfo(station.TenantName, streamName)
+	if err != nil {
+		return err
+	}
+	partitionKey := strconv.Itoa(partition)
+	scannedSeq := scannedSeqs[partitionKey]
+	lastSeq := streamInfo.State.LastSeq
+	if lastSeq < scannedSeq {
+		// the stream was recreated, its old entries point at messages which are gone
+		err = db.DeleteStationMessageIndexBelowSeq(station.ID, partition, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		scannedSeq = 0
+	} else if streamInfo.State.FirstSeq > 1 {
+		err = db.DeleteStationMessageIndexBelowSeq(station.ID, partition, streamInfo.State.FirstSeq)
+		if err != nil {
+			return err
+		}
+	}
+
+	filterSubj := streamName + ".final"
+	if !station.IsNative {
+		filterSubj = _EMPTY_
+	}
+	for batch := 0; batch < stationIndexMaxBatchesPerPass; batch++ {
+		startSeq := scannedSeq + 1
+		if startSeq < streamInfo.State.FirstSeq {
+			startSeq = streamInfo.State.FirstSeq
+		}
+		if startSeq > lastSeq {
+			return nil
+		}
+		amount := stationIndexFetchBatch
+		if lastSeq-startSeq+1 < uint64(amount) {
+			amount = int(lastSeq - startSeq + 1)
+		}
+		msgs, err := s.memphisGetMsgs(station.TenantName, filterSubj, streamName, startSeq, amount, 2*time.Second, true, false, 1)
+		if err != nil {
+			return err
+		}
+		sort.Slice(msgs, func(i, j int) bool { return msgs[i].Sequence < msgs[j].Sequence })
+
+		var entries []models.StationMessageIndexEntry
+		newScannedSeq := lastSeq
+		if len(msgs) > 0 && msgs[len(msgs)-1].Sequence < lastSeq {
+			newScannedSeq = msgs[len(msgs)-1].Sequence
+		}
+		for _, msg := range msgs {
+			if msg.Sequence > newScannedSeq {
+				break
+			}
+			v, ok := decodeStationIndexPayload(msg, decode)
+			if !ok {
+				continue
+			}
+			for _, extractor := range extractors {
+				for _, value := range indexValues(v, extractor.segments) {
+					entries = append(entries, models.StationMessageIndexEntry{
+						Extractor:       extractor.name,
+						Value:           value,
+						PartitionNumber: partition,
+						MessageSeq:      int64(msg.Sequence),
+						ProducedAt:      msg.Time,
+					})
+				}
+			}
+		}
+		scannedSeq = newScannedSeq
+		scannedSeqs[partitionKey] = scannedSeq
+		err = db.InsertStationMessageIndexEntries(config.ID, station.ID, entries, scannedSeqs, station.TenantName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeStationIndexPayload(msg StoredMsg, decode messageDecoder) (interface{}, bool) {
+	var hdrs map[string]string
+	if len(msg.Header) > 0 {
+		var err error
+		hdrs, err = DecodeHeader(msg.Header)
+		if err != nil {
+			return nil, false
+		}
+	}
+	payload, err := decompressStationMessage(hdrs, msg.Data)
+	if err != nil {
+		return nil, false
+	}
+	if decode != nil {
+		// a message failing validation can still be decoded, its keys are indexed anyway
+		v, _ := decode(payload)
+		return v, v != nil
+	}
+	// numbers are kept as written so long ids are indexed without losing precision
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// storedMsgDetails turns an indexed message into the message browser list form
+func storedMsgDetails(msg *StoredMsg, partition int) models.MessageDetails {
+	details := models.MessageDetails{
+		MessageSeq: int(msg.Sequence),
+		TimeSent:   msg.Time,
+		Size:       len(msg.Subject) + len(msg.Data) + len(msg.Header),
+		Partition:  partition,
+	}
+	payload := msg.Data
+	if len(msg.Header) > 0 {
+		hdrs, err := DecodeHeader(msg.Header)
+		if err == nil {
+			if decompressed, err := decompressStationMessage(hdrs, msg.Data); err == nil {
+				payload = decompressed
+			}
+			details.ProducedBy = strings.ToLower(hdrs["$memphis_producedBy"])
+			details.ConnectionId = hdrs["$memphis_connectionId"]
+			for header := range hdrs {
+				if strings.HasPrefix(header, MEMPHIS_GLOBAL_ACCOUNT) {
+					delete(hdrs, header)
+				}
+			}
+			details.Headers = hdrs
+		}
+	}
+	data := hex.EncodeToString(payload)
+	if len(data) > 80 { // get the first chars for preview needs
+		data = data[0:80]
+	}
+	details.Data = data
+	return details
+}
+
+func (sh StationsHandler) UpdateStationIndexExtractors(c *gin.Context) {
+	var body models.UpdateStationIndexExtractorsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateStationIndexExtractors at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "UpdateStationIndexExtractors", body.StationName, "write")
+	if !ok {
+		return
+	}
+	for i := range body.Extractors {
+		body.Extractors[i].Name = strings.ToLower(strings.TrimSpace(body.Extractors[i].Name))
+		body.Extractors[i].Path = strings.TrimSpace(body.Extractors[i].Path)
+	}
+	err = validateStationIndexExtractors(body.Extractors)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationIndexExtractors at validateStationIndexExtractors: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	var message string
+	if len(body.Extractors) == 0 {
+		_, err = db.DeleteStationIndexConfig(station.ID)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateStationIndexExtractors at DeleteStationIndexConfig: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		message = fmt.Sprintf("Index extractors of station %v have been removed by user %v", stationName.Ext(), user.Username)
+	} else {
+		_, err = db.UpsertStationIndexConfig(station.ID, body.Extractors, user.Username, station.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateStationIndexExtractors at UpsertStationIndexConfig: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		message = fmt.Sprintf("Index extractors of station %v have been updated by user %v, the station messages are being indexed again", stationName.Ext(), user.Username)
+	}
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	if body.Extractors == nil {
+		body.Extractors = []models.StationIndexExtractor{}
+	}
+	c.IndentedJSON(200, gin.H{"station_name": stationName.Ext(), "extractors": body.Extractors})
+}
+
+func (sh StationsHandler) GetStationIndexExtractors(c *gin.Context) {
+	var body models.GetStationIndexExtractorsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationIndexExtractors at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "GetStationIndexExtractors", body.StationName, "read")
+	if !ok {
+		return
+	}
+	exist, config, err := db.GetStationIndexConfig(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationIndexExtractors at GetStationIndexConfig: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		c.IndentedJSON(200, gin.H{"station_name": stationName.Ext(), "extractors": []models.StationIndexExtractor{}})
+		return
+	}
+	c.IndentedJSON(200, gin.H{
+		"station_name": stationName.Ext(),
+		"extractors":   config.Extractors,
+		"scanned_seqs": config.ScannedSeqs,
+		"updated_by":   config.UpdatedBy,
+		"updated_at":   config.UpdatedAt,
+	})
+}
+
+// SearchStationMessagesByKey looks a business key up in the station index instead of scanning the station,
+// messages which were indexed but removed from the station since are skipped
+func (sh StationsHandler) SearchStationMessagesByKey(c *gin.Context) {
+	var body models.SearchStationMessagesByKeySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("SearchStationMessagesByKey at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "SearchStationMessagesByKey", body.StationName, "read")
+	if !ok {
+		return
+	}
+	key := strings.ToLower(strings.TrimSpace(body.Key))
+	exist, config, err := db.GetStationIndexConfig(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]SearchStationMessagesByKey at GetStationIndexConfig: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	found := false
+	for _, extractor := range config.Extractors {
+		if extractor.Name == key {
+			found = true
+			break
+		}
+	}
+	if !exist || !found {
+		errMsg := fmt.Sprintf("Station %v has no index extractor named %v", stationName.Ext(), key)
+		serv.Warnf("[tenant: %v][user: %v]SearchStationMessagesByKey: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	entries, err := db.SearchStationMessageIndex(station.ID, key, body.Value, stationIndexSearchLimit)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]SearchStationMessagesByKey at SearchStationMessageIndex: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	messages := make([]models.MessageDetails, 0, len(entries))
+	for _, entry := range entries {
+		msg, err := sh.S.GetMessage(station.TenantName, stationName, uint64(entry.MessageSeq), entry.PartitionNumber)
+		if err != nil {
+			if IsNatsErr(err, JSNoMessageFoundErr) {
+				continue
+			}
+			serv.Errorf("[tenant: %v][user: %v]SearchStationMessagesByKey at GetMessage: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		messages = append(messages, storedMsgDetails(msg, entry.PartitionNumber))
+	}
+
+	c.IndentedJSON(200, gin.H{"messages": messages, "scanned_seqs": config.ScannedSeqs})
+}
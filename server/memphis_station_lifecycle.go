@@ -0,0 +1,359 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	stationLifecycleEvaluationInterval       = 10 * time.Minute
+	stationLifecycleDefaultNotifyBeforeHours = 24
+	stationLifecycleMaxNotifyBeforeHours     = 24 * 30
+	stationLifecycleMaxDays                  = 3650
+	stationLifecycleActor                    = "lifecycle_policy"
+)
+
+func validateStationLifecyclePolicy(deleteAfterIdleDays, archiveAfterInactiveDays int, expiresAt *time.Time, notifyBeforeHours int) error {
+	if deleteAfterIdleDays < 0 || deleteAfterIdleDays > stationLifecycleMaxDays {
+		return fmt.Errorf("delete_after_idle_days has to be between 0 and %v", stationLifecycleMaxDays)
+	}
+	if archiveAfterInactiveDays < 0 || archiveAfterInactiveDays > stationLifecycleMaxDays {
+		return fmt.Errorf("archive_after_inactive_days has to be between 0 and %v", stationLifecycleMaxDays)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.New("expires_at has to be in the future")
+	}
+	if notifyBeforeHours < 1 || notifyBeforeHours > stationLifecycleMaxNotifyBeforeHours {
+		return fmt.Errorf("notify_before_hours has to be between 1 and %v", stationLifecycleMaxNotifyBeforeHours)
+	}
+	if deleteAfterIdleDays == 0 && archiveAfterInactiveDays == 0 && expiresAt == nil {
+		return errors.New("a lifecycle policy needs at least one of delete_after_idle_days, archive_after_inactive_days or expires_at")
+	}
+	return nil
+}
+
+// stationLastActivity is the time the last message was stored in any of the station partitions,
+// the creation time is used for stations which never got a message
+func (s *Server) stationLastActivity(station models.Station, stationName StationName) (time.Time, error) {
+	lastActivity := station.CreatedAt
+	for streamName := range stationStreamsAndFilters(stationName, station) {
+		info, err := s.memphisStreamInfo(station.TenantName, streamName)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.State.LastTime.After(lastActivity) {
+			lastActivity = info.State.LastTime
+		}
+	}
+	return lastActivity, nil
+}
+
+// nextStationLifecycleAction returns the earliest action due by the policy rules, nil when none applies.
+// The returned inactive since is the state the policy should keep for the archive rule.
+func (s *Server) nextStationLifecycleAction(policy models.StationLifecyclePolicy, station models.Station, stationName StationName, now time.Time) (*models.StationLifecycleNextAction, *time.Time, error) {
+	var next *models.StationLifecycleNextAction
+	consider := func(action, reason string, dueAt time.Time) {
+		dueAt = dueAt.Truncate(time.Second)
+		// deletion wins a tie since archiving a station which is about to be deleted is pointless
+		if next == nil || dueAt.Before(next.DueAt) || (dueAt.Equal(next.DueAt) && action == models.StationLifecycleActionDelete) {
+			next = &models.StationLifecycleNextAction{Action: action, Reason: reason, DueAt: dueAt}
+		}
+	}
+
+	if policy.ExpiresAt != nil {
+		consider(models.StationLifecycleActionDelete, "the station expiry date was reached", *policy.ExpiresAt)
+	}
+	if policy.DeleteAfterIdleDays > 0 {
+		lastActivity, err := s.stationLastActivity(station, stationName)
+		if err != nil {
+			return nil, nil, err
+		}
+		consider(models.StationLifecycleActionDelete, fmt.Sprintf("no messages were produced for %v days", policy.DeleteAfterIdleDays), lastActivity.Add(time.Duration(policy.DeleteAfterIdleDays)*24*time.Hour))
+	}
+
+	var inactiveSince *time.Time
+	if policy.ArchiveAfterInactiveDays > 0 && !station.ReadOnly {
+		producers, err := db.CountActiveProudcersByStationID(station.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		consumers, err := db.CountActiveConsumersByStationID(station.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if producers == 0 && consumers == 0 {
+			inactiveSince = policy.InactiveSince
+			if inactiveSince == nil {
+				inactiveSince = &now
+			}
+			consider(models.StationLifecycleActionArchive, fmt.Sprintf("there were no active producers or consumers for %v days", policy.ArchiveAfterInactiveDays), inactiveSince.Add(time.Duration(policy.ArchiveAfterInactiveDays)*24*time.Hour))
+		}
+	}
+	return next, inactiveSince, nil
+}
+
+func sameTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func (s *Server) EnforceStationLifecyclePolicies() {
+	reportBackgroundTaskAlive("EnforceStationLifecyclePolicies", stationLifecycleEvaluationInterval)
+	ticker := time.NewTicker(stationLifecycleEvaluationInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reportBackgroundTaskAlive("EnforceStationLifecyclePolicies", stationLifecycleEvaluationInterval)
+		if !s.leadsBackgroundTask("EnforceStationLifecyclePolicies", stationLifecycleEvaluationInterval) {
+			continue
+		}
+		policies, err := db.GetActiveStationLifecyclePolicies()
+		if err != nil {
+			s.Errorf("EnforceStationLifecyclePolicies at GetActiveStationLifecyclePolicies: %v", err.Error())
+			continue
+		}
+		for _, policy := range policies {
+			err = s.enforceStationLifecyclePolicy(policy, time.Now())
+			if err != nil {
+				s.Errorf("[tenant: %v]EnforceStationLifecyclePolicies at enforceStationLifecyclePolicy: station id %v: %v", policy.TenantName, policy.StationId, err.Error())
+			}
+		}
+	}
+}
+
+// enforceStationLifecyclePolicy announces an action once it is within the notice period of the policy
+// and takes it only after both its due time and a full notice period since the announcement have passed
+func (s *Server) enforceStationLifecyclePolicy(policy models.StationLifecyclePolicy, now time.Time) error {
+	exist, station, err := db.GetStationById(policy.StationId, policy.TenantName)
+	if err != nil || !exist {
+		return err
+	}
+	stationName, err := StationNameFromStr(station.Name)
+	if err != nil {
+		return err
+	}
+	next, inactiveSince, err := s.nextStationLifecycleAction(policy, station, stationName, now)
+	if err != nil {
+		return err
+	}
+
+	notifiedAction, notifiedDueAt, notifiedAt := policy.NotifiedAction, policy.NotifiedDueAt, policy.NotifiedAt
+	noticePeriod := time.Duration(policy.NotifyBeforeHours) * time.Hour
+	announced := next != nil && notifiedAction == next.Action && notifiedDueAt != nil && notifiedDueAt.Equal(next.DueAt) && notifiedAt != nil
+	switch {
+	case next == nil || (!announced && now.Before(next.DueAt.Add(-noticePeriod))):
+		notifiedAction, notifiedDueAt, notifiedAt = _EMPTY_, nil, nil
+	case !announced:
+		notifiedAction, notifiedDueAt, notifiedAt = next.Action, &next.DueAt, &now
+		actionAt := next.DueAt
+		if earliest := now.Add(noticePeriod); earliest.After(actionAt) {
+			actionAt = earliest
+		}
+		action := "deleted"
+		if next.Action == models.StationLifecycleActionArchive {
+			action = "archived"
+		}
+		message := fmt.Sprintf("Station %v will be %v by its lifecycle policy at %v since %v", stationName.Ext(), action, actionAt.UTC().Format(time.RFC3339), next.Reason)
+		s.Noticef("[tenant: %v]enforceStationLifecyclePolicy: %v", station.TenantName, message)
+		s.notifyOperationalEvent(station.TenantName, StationLifecycleScheduledTitle, message, StationLifecycleAlert)
+	case !now.Before(next.DueAt) && !now.Before(notifiedAt.Add(noticePeriod)):
+		if next.Action == models.StationLifecycleActionDelete {
+			// the policy is removed together with the station references
+			return s.deleteStationByLifecyclePolicy(station, stationName, next.Reason)
+		}
+		err = s.archiveStationByLifecyclePolicy(station, stationName, next.Reason)
+		if err != nil {
+			return err
+		}
+		inactiveSince, notifiedAction, notifiedDueAt, notifiedAt = nil, _EMPTY_, nil, nil
+	}
+
+	if sameTimePtr(inactiveSince, policy.InactiveSince) && notifiedAction == policy.NotifiedAction && sameTimePtr(notifiedDueAt, policy.NotifiedDueAt) && sameTimePtr(notifiedAt, policy.NotifiedAt) {
+		return nil
+	}
+	return db.UpdateStationLifecyclePolicyState(policy.ID, inactiveSince, notifiedAction, notifiedDueAt, notifiedAt)
+}
+
+func (s *Server) deleteStationByLifecyclePolicy(station models.Station, stationName StationName, reason string) error {
+	err := removeStationResources(s, station, true, _EMPTY_)
+	if err != nil {
+		return err
+	}
+	err = db.DeleteStationsByNames([]string{station.Name}, station.TenantName)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Station %v has been deleted by its lifecycle policy since %v", stationName.Ext(), reason)
+	s.Noticef("[tenant: %v]deleteStationByLifecyclePolicy: %v", station.TenantName, message)
+	s.notifyOperationalEvent(station.TenantName, StationDeletedTitle, message, StationLifecycleAlert)
+	publishBrokerEvent(station.TenantName, models.EventStationDeleted, map[string]interface{}{"station_name": stationName.Ext(), "deleted_by": stationLifecycleActor})
+	s.SendUpdateToClients(models.SdkClientsUpdates{
+		StationName: stationName.Intern(),
+		Type:        removeStationUpdateType,
+	})
+	return nil
+}
+
+// archiveStationByLifecyclePolicy turns the station read-only, its messages stay available to consumers
+func (s *Server) archiveStationByLifecyclePolicy(station models.Station, stationName StationName, reason string) error {
+	err := db.UpdateStationReadOnly(station.Name, true, station.TenantName)
+	if err != nil {
+		return err
+	}
+	setStationReadOnlyState(station.TenantName, stationName, true)
+	s.publishStationReadOnlyUpdate(station.TenantName, stationName, true)
+
+	message := fmt.Sprintf("Station %v has been archived (set to read-only) by its lifecycle policy since %v", stationName.Ext(), reason)
+	s.Noticef("[tenant: %v]archiveStationByLifecyclePolicy: %v", station.TenantName, message)
+	s.notifyOperationalEvent(station.TenantName, StationArchivedTitle, message, StationLifecycleAlert)
+	err = CreateAuditLogs([]interface{}{models.AuditLog{
+		StationName:       stationName.Ext(),
+		Message:           message,
+		CreatedBy:         0,
+		CreatedByUsername: stationLifecycleActor,
+		CreatedAt:         time.Now(),
+		TenantName:        station.TenantName,
+	}})
+	if err != nil {
+		s.Warnf("[tenant: %v]archiveStationByLifecyclePolicy at CreateAuditLogs: station %v: %v", station.TenantName, station.Name, err.Error())
+	}
+	return nil
+}
+
+func (sh StationsHandler) UpdateStationLifecyclePolicy(c *gin.Context) {
+	var body models.UpdateStationLifecyclePolicySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateStationLifecyclePolicy at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "UpdateStationLifecyclePolicy", body.StationName, "write")
+	if !ok {
+		return
+	}
+
+	notifyBeforeHours := stationLifecycleDefaultNotifyBeforeHours
+	if body.NotifyBeforeHours != nil {
+		notifyBeforeHours = *body.NotifyBeforeHours
+	}
+	err = validateStationLifecyclePolicy(body.DeleteAfterIdleDays, body.ArchiveAfterInactiveDays, body.ExpiresAt, notifyBeforeHours)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationLifecyclePolicy at validateStationLifecyclePolicy: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+
+	policy, err := db.UpsertStationLifecyclePolicy(station.ID, body.DeleteAfterIdleDays, body.ArchiveAfterInactiveDays, body.ExpiresAt, notifyBeforeHours, user.Username, station.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateStationLifecyclePolicy at UpsertStationLifecyclePolicy: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	message := fmt.Sprintf("Lifecycle policy of station %v has been updated by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, policy)
+}
+
+func (sh StationsHandler) GetStationLifecyclePolicy(c *gin.Context) {
+	var body models.GetStationLifecyclePolicySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationLifecyclePolicy at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "GetStationLifecyclePolicy", body.StationName, "read")
+	if !ok {
+		return
+	}
+
+	exist, policy, err := db.GetStationLifecyclePolicy(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationLifecyclePolicy at GetStationLifecyclePolicy: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v has no lifecycle policy", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]GetStationLifecyclePolicy: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+	next, _, err := sh.S.nextStationLifecycleAction(policy, station, stationName, time.Now())
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationLifecyclePolicy at nextStationLifecycleAction: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, gin.H{"policy": policy, "next_action": next})
+}
+
+func (sh StationsHandler) RemoveStationLifecyclePolicy(c *gin.Context) {
+	var body models.RemoveStationLifecyclePolicySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("RemoveStationLifecyclePolicy at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "RemoveStationLifecyclePolicy", body.StationName, "write")
+	if !ok {
+		return
+	}
+
+	removed, err := db.DeleteStationLifecyclePolicy(station.ID)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]RemoveStationLifecyclePolicy at DeleteStationLifecyclePolicy: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !removed {
+		errMsg := fmt.Sprintf("Station %v has no lifecycle policy", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]RemoveStationLifecyclePolicy: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	message := fmt.Sprintf("Lifecycle policy of station %v has been removed by user %v", stationName.Ext(), user.Username)
+	serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+	createAuditLogFromRequest(c, user, stationName.Ext(), message)
+
+	c.IndentedJSON(200, gin.H{})
+}
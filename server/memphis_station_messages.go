@@ -0,0 +1,181 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+)
+
+const stationProduceAckTimeout = 5 * time.Second
+
+type stationFetchedMsg struct {
+	Headers      map[string]string
+	Data         []byte
+	ReplySubject string
+}
+
+// stationProduceSubject returns the subject a message should be produced to,
+// partitioned stations get a random partition like the SDKs do
+func stationProduceSubject(stationName StationName, station models.Station) string {
+	if station.Version == 0 || len(station.PartitionsList) == 0 {
+		return fmt.Sprintf("%s.final", stationName.Intern())
+	}
+	partition := station.PartitionsList[rand.Intn(len(station.PartitionsList))]
+	return fmt.Sprintf("%s$%v.final", stationName.Intern(), partition)
+}
+
+// stationStreamsAndFilters returns the streams backing a station together with the subject consumers should filter on
+func stationStreamsAndFilters(stationName StationName, station models.Station) map[string]string {
+	streams := make(map[string]string)
+	if station.Version == 0 || len(station.PartitionsList) == 0 {
+		streams[stationName.Intern()] = stationName.Intern() + ".final"
+		return streams
+	}
+	for _, p := range station.PartitionsList {
+		streamName := stationName.Intern() + "$" + strconv.Itoa(p)
+		streams[streamName] = streamName + ".final"
+	}
+	return streams
+}
+
+// produceToStation publishes a message into a station and waits until the stream persisted it
+func (s *Server) produceToStation(tenantName string, stationName StationName, station models.Station, payload []byte, hdrs map[string]string) (*PubAck, error) {
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return nil, err
+	}
+	reply := s.getJsApiReplySubject()
+	respCh := make(chan []byte, 1)
+	sub, err := s.subscribeOnAcc(account, reply, reply+"_sid", func(_ *client, _, _ string, msg []byte) {
+		select {
+		case respCh <- copyBytes(msg):
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer s.unsubscribeOnAcc(account, sub)
+
+	err = s.sendInternalAccountMsgWithReply(account, stationProduceSubject(stationName, station), reply, hdrs, payload, true)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(stationProduceAckTimeout)
+	defer timeout.Stop()
+	select {
+	case rawResp := <-respCh:
+		var resp JSPubAckResponse
+		err = json.Unmarshal(rawResp, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if err := resp.ToError(); err != nil {
+			return nil, err
+		}
+		return resp.PubAck, nil
+	case <-timeout.C:
+		return nil, fmt.Errorf("timeout waiting for station %v to acknowledge the message", stationName.Ext())
+	}
+}
+
+// fetchStationMessages pulls up to batch messages through a durable pull consumer, the caller is responsible for acking them
+func (s *Server) fetchStationMessages(account *Account, streamName, durable string, batch int, expires time.Duration) ([]stationFetchedMsg, error) {
+	reply := s.getJsApiReplySubject()
+	msgsCh := make(chan stationFetchedMsg, batch)
+	sub, err := s.subscribeOnAcc(account, reply, reply+"_sid", func(c *client, _, msgReply string, msg []byte) {
+		// status messages (request timeout, no messages) carry no reply subject
+		if msgReply == _EMPTY_ {
+			return
+		}
+		rawHdr, data := c.msgParts(msg)
+		hdrs, err := DecodeHeader(rawHdr)
+		if err != nil {
+			hdrs = make(map[string]string)
+		}
+		select {
+		case msgsCh <- stationFetchedMsg{Headers: hdrs, Data: copyBytes(bytes.TrimSuffix(data, []byte(CR_LF))), ReplySubject: msgReply}:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer s.unsubscribeOnAcc(account, sub)
+
+	req, err := json.Marshal(JSApiConsumerGetNextRequest{Batch: batch, Expires: expires})
+	if err != nil {
+		return nil, err
+	}
+	err = s.sendInternalAccountMsgWithReply(account, fmt.Sprintf(JSApiRequestNextT, streamName, durable), reply, nil, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(expires + time.Second)
+	defer timeout.Stop()
+	msgs := make([]stationFetchedMsg, 0, batch)
+	for len(msgs) < batch {
+		select {
+		case msg := <-msgsCh:
+			msgs = append(msgs, msg)
+		case <-timeout.C:
+			return msgs, nil
+		}
+	}
+	return msgs, nil
+}
+
+func (s *Server) ackStationMessage(account *Account, msg stationFetchedMsg) {
+	s.sendInternalAccountMsgWithEcho(account, msg.ReplySubject, []byte(_EMPTY_))
+}
+
+// ackStationMessageSync acks a message and waits for the stream to confirm the ack was applied
+func (s *Server) ackStationMessageSync(account *Account, msg stationFetchedMsg, timeout time.Duration) error {
+	reply := s.getJsApiReplySubject()
+	respCh := make(chan struct{}, 1)
+	sub, err := s.subscribeOnAcc(account, reply, reply+"_sid", func(_ *client, _, _ string, _ []byte) {
+		select {
+		case respCh <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer s.unsubscribeOnAcc(account, sub)
+
+	err = s.sendInternalAccountMsgWithReply(account, msg.ReplySubject, reply, nil, []byte(_EMPTY_), true)
+	if err != nil {
+		return err
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-respCh:
+		return nil
+	case <-t.C:
+		return fmt.Errorf("timeout waiting for the ack of %v to be confirmed", msg.ReplySubject)
+	}
+}
+
+func (s *Server) nackStationMessage(account *Account, msg stationFetchedMsg) {
+	s.sendInternalAccountMsgWithEcho(account, msg.ReplySubject, AckNak)
+}
@@ -0,0 +1,360 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	stationSampleDefaultSize     = 500
+	stationSampleMaxSize         = 5000
+	stationProfileMaxFields      = 500
+	stationProfileMaxDepth       = 10
+	stationProfileCardinalityCap = 1000
+	stationProfileSampleValues   = 5
+	stationProfileMaxValueLength = 128
+)
+
+var stationSizeBuckets = []int{128, 1024, 10 * 1024, 100 * 1024, 1024 * 1024, math.MaxInt32}
+
+type stationSampledMsg struct {
+	payload   []byte
+	headers   map[string]string
+	partition int
+	seq       uint64
+	time      time.Time
+}
+
+// sampleStationMessages reads the latest messages of a station, the sample is split evenly between its partitions
+func (s *Server) sampleStationMessages(station models.Station, stationName StationName, size int) ([]stationSampledMsg, error) {
+	partitions := stationIndexPartitions(stationName, station)
+	perPartition := (size + len(partitions) - 1) / len(partitions)
+	var sample []stationSampledMsg
+	for partition, streamName := range partitions {
+		streamInfo, err := s.memphisStreamInfo(station.TenantName, streamName)
+		if err != nil {
+			return nil, err
+		}
+		if streamInfo.State.Msgs == 0 {
+			continue
+		}
+		lastSeq := streamInfo.State.LastSeq
+		startSeq := streamInfo.State.FirstSeq
+		if lastSeq-startSeq+1 > uint64(perPartition) {
+			startSeq = lastSeq - uint64(perPartition) + 1
+		}
+		filterSubj := streamName + ".final"
+		if !station.IsNative {
+			filterSubj = _EMPTY_
+		}
+		msgs, err := s.memphisGetMsgs(station.TenantName, filterSubj, streamName, startSeq, int(lastSeq-startSeq+1), 5*time.Second, true, false, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			var hdrs map[string]string
+			if len(msg.Header) > 0 {
+				hdrs, err = DecodeHeader(msg.Header)
+				if err != nil {
+					continue
+				}
+			}
+			payload, err := decompressStationMessage(hdrs, msg.Data)
+			if err != nil {
+				payload = msg.Data
+			}
+			sample = append(sample, stationSampledMsg{payload: payload, headers: hdrs, partition: partition, seq: msg.Sequence, time: msg.Time})
+		}
+	}
+	sort.Slice(sample, func(i, j int) bool { return sample[i].time.Before(sample[j].time) })
+	if len(sample) > size {
+		sample = sample[len(sample)-size:]
+	}
+	return sample, nil
+}
+
+// decodeSampledMessage decodes a message by the station schema when one is attached and as json otherwise,
+// json numbers are kept as written so integers and decimals can be told apart
+func decodeSampledMessage(payload []byte, decode messageDecoder) (interface{}, bool) {
+	if decode != nil {
+		v, _ := decode(payload)
+		return v, v != nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func sampledValueType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case float32:
+		return "number"
+	case int, int32, int64, uint32, uint64:
+		return "integer"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+type fieldProfileState struct {
+	profile  models.StationFieldProfile
+	distinct map[string]struct{}
+}
+
+type stationProfiler struct {
+	fields       map[string]*fieldProfileState
+	fieldsCapped bool
+}
+
+// observe records a value found at path, objects and arrays are walked so nested fields get their own profile,
+// array items are profiled under the array path followed by []
+func (p *stationProfiler) observe(path string, v interface{}, depth int, seen map[string]bool) {
+	if path != _EMPTY_ {
+		state, ok := p.fields[path]
+		if !ok {
+			if len(p.fields) >= stationProfileMaxFields {
+				p.fieldsCapped = true
+				return
+			}
+			state = &fieldProfileState{profile: models.StationFieldProfile{Path: path, Types: map[string]int{}}, distinct: map[string]struct{}{}}
+			p.fields[path] = state
+		}
+		// a field repeated inside an array counts once per message
+		if !seen[path] {
+			seen[path] = true
+			state.profile.Presence++
+		}
+		valueType := sampledValueType(v)
+		state.profile.Types[valueType]++
+		if valueType != "object" && valueType != "array" {
+			value := fmt.Sprint(v)
+			if len(value) > stationProfileMaxValueLength {
+				value = value[:stationProfileMaxValueLength]
+			}
+			if _, ok := state.distinct[value]; !ok {
+				if len(state.distinct) < stationProfileCardinalityCap {
+					state.distinct[value] = struct{}{}
+					if len(state.profile.SampleValues) < stationProfileSampleValues {
+						state.profile.SampleValues = append(state.profile.SampleValues, value)
+					}
+				} else {
+					state.profile.CardinalityCapped = true
+				}
+			}
+		}
+	}
+	if depth >= stationProfileMaxDepth {
+		return
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			childPath := k
+			if path != _EMPTY_ {
+				childPath = path + "." + k
+			}
+			p.observe(childPath, item, depth+1, seen)
+		}
+	case []interface{}:
+		for _, item := range val {
+			p.observe(path+"[]", item, depth+1, seen)
+		}
+	}
+}
+
+// inferredFieldType is the single type seen for a field, nulls are ignored and integers widen to numbers
+func inferredFieldType(types map[string]int) string {
+	var found []string
+	for t := range types {
+		if t != "null" {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "null"
+	case 1:
+		return found[0]
+	case 2:
+		if (found[0] == "integer" && found[1] == "number") || (found[0] == "number" && found[1] == "integer") {
+			return "number"
+		}
+	}
+	return "mixed"
+}
+
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func profileStationSample(sample []stationSampledMsg, decode messageDecoder) models.StationDataProfile {
+	profile := models.StationDataProfile{SampledMessages: len(sample), Format: "json", Fields: []models.StationFieldProfile{}}
+	if decode != nil {
+		profile.Format = "schema"
+	}
+	if len(sample) == 0 {
+		profile.Sizes.Buckets = []models.StationSizeBucket{}
+		return profile
+	}
+	profile.SampledFrom = sample[0].time
+	profile.SampledTo = sample[len(sample)-1].time
+
+	profiler := stationProfiler{fields: make(map[string]*fieldProfileState)}
+	sizes := make([]int, 0, len(sample))
+	total := 0
+	for _, msg := range sample {
+		sizes = append(sizes, len(msg.payload))
+		total += len(msg.payload)
+		v, ok := decodeSampledMessage(msg.payload, decode)
+		if !ok {
+			continue
+		}
+		profile.DecodedMessages++
+		profiler.observe(_EMPTY_, v, 0, make(map[string]bool))
+	}
+
+	sort.Ints(sizes)
+	profile.Sizes = models.StationSizeProfile{
+		MinBytes: sizes[0],
+		MaxBytes: sizes[len(sizes)-1],
+		AvgBytes: total / len(sizes),
+		P50Bytes: percentile(sizes, 0.5),
+		P90Bytes: percentile(sizes, 0.9),
+		P99Bytes: percentile(sizes, 0.99),
+	}
+	i := 0
+	for _, upTo := range stationSizeBuckets {
+		bucket := models.StationSizeBucket{UpToBytes: upTo}
+		for i < len(sizes) && sizes[i] <= upTo {
+			bucket.Count++
+			i++
+		}
+		profile.Sizes.Buckets = append(profile.Sizes.Buckets, bucket)
+	}
+
+	for _, state := range profiler.fields {
+		field := state.profile
+		if profile.DecodedMessages > 0 {
+			field.PresenceRate = math.Round(float64(field.Presence)/float64(profile.DecodedMessages)*10000) / 10000
+		}
+		field.Cardinality = len(state.distinct)
+		field.InferredType = inferredFieldType(field.Types)
+		if field.SampleValues == nil {
+			field.SampleValues = []string{}
+		}
+		profile.Fields = append(profile.Fields, field)
+	}
+	sort.Slice(profile.Fields, func(i, j int) bool { return profile.Fields[i].Path < profile.Fields[j].Path })
+	profile.FieldsCapped = profiler.fieldsCapped
+	return profile
+}
+
+// stationSampleDecoder returns the decoder of the schema attached to the station, nil means messages are read as json
+func stationSampleDecoder(station models.Station) (messageDecoder, error) {
+	if station.SchemaName == _EMPTY_ {
+		return nil, nil
+	}
+	decode, err := getStationMessageDecoder(station.TenantName, station)
+	if errors.Is(err, ErrNoSchema) {
+		return nil, nil
+	}
+	return decode, err
+}
+
+func (sh StationsHandler) GetStationDataProfile(c *gin.Context) {
+	var body models.GetStationDataProfileSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetStationDataProfile at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, station, ok := getGatewayStation(c, user, "GetStationDataProfile", body.StationName, "read")
+	if !ok {
+		return
+	}
+	sampleSize := body.SampleSize
+	if sampleSize == 0 {
+		sampleSize = stationSampleDefaultSize
+	}
+	if sampleSize < 1 || sampleSize > stationSampleMaxSize {
+		errMsg := "sample_size has to be between 1 and " + strconv.Itoa(stationSampleMaxSize)
+		serv.Warnf("[tenant: %v][user: %v]GetStationDataProfile: At station %v: %v", user.TenantName, user.Username, body.StationName, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	decode, err := stationSampleDecoder(station)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationDataProfile at stationSampleDecoder: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	sample, err := sh.S.sampleStationMessages(station, stationName, sampleSize)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetStationDataProfile at sampleStationMessages: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	profile := profileStationSample(sample, decode)
+	profile.StationName = stationName.Ext()
+	c.IndentedJSON(200, profile)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Purging a station can not be undone, so it takes two requests: the user first asks for a confirmation token of the
+// station and then sends it along with the purge. The token is signed, short lived and bound to the user and station.
+const (
+	purgeTokenPurpose = "purge_station"
+	purgeTokenTTL     = 5 * time.Minute
+)
+
+var errInvalidPurgeToken = errors.New("The confirmation token is invalid or expired, please request a new one")
+
+func purgeTokenSecret() []byte {
+	return []byte(configuration.JWT_SECRET + "_" + purgeTokenPurpose)
+}
+
+func createPurgeConfirmationToken(user models.User, stationName string) (models.PurgeConfirmationToken, error) {
+	expiresAt := time.Now().Add(purgeTokenTTL)
+	claims := jwt.MapClaims{
+		"purpose":      purgeTokenPurpose,
+		"tenant_name":  user.TenantName,
+		"username":     user.Username,
+		"station_name": stationName,
+		"exp":          expiresAt.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(purgeTokenSecret())
+	if err != nil {
+		return models.PurgeConfirmationToken{}, err
+	}
+	return models.PurgeConfirmationToken{ConfirmationToken: token, ExpiresAt: expiresAt}, nil
+}
+
+func verifyPurgeConfirmationToken(tokenString string, user models.User, stationName string) error {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return purgeTokenSecret(), nil
+	})
+	if err != nil || !token.Valid {
+		return errInvalidPurgeToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purgeTokenPurpose || claims["tenant_name"] != user.TenantName ||
+		claims["username"] != user.Username || claims["station_name"] != stationName {
+		return errInvalidPurgeToken
+	}
+	return nil
+}
+
+// purgeStationPartition removes the messages of a partition which are older than beforeSeq and beforeTime,
+// zero values do not limit the purge. Stations without partitions are purged with partition -1.
+func (s *Server) purgeStationPartition(tenantName string, stationName StationName, partition int, beforeSeq uint64, beforeTime time.Time) (uint64, error) {
+	seq := beforeSeq
+	if !beforeTime.IsZero() {
+		streamPartition := partition
+		if streamPartition == -1 {
+			streamPartition = 0
+		}
+		timeSeq, found, err := s.streamSeqAtTime(tenantName, stationExportStream(stationName, streamPartition), beforeTime)
+		if err != nil {
+			return 0, err
+		}
+		// when no message is stored after beforeTime all of them are older
+		if found && (seq == 0 || timeSeq < seq) {
+			seq = timeSeq
+		}
+	}
+	if seq == 1 {
+		return 0, nil
+	}
+	return s.PurgeStreamUpTo(tenantName, stationName.Intern(), partition, seq)
+}
+
+func (sh StationsHandler) GetPurgeConfirmationToken(c *gin.Context) {
+	var body models.GetPurgeConfirmationTokenSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetPurgeConfirmationToken at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]GetPurgeConfirmationToken at StationNameFromStr: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, _, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetPurgeConfirmationToken at GetStationByName: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", stationName.Ext())
+		serv.Warnf("[tenant: %v][user: %v]GetPurgeConfirmationToken: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	token, err := createPurgeConfirmationToken(user, stationName.Ext())
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetPurgeConfirmationToken at createPurgeConfirmationToken: At station %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, token)
+}
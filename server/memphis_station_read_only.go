@@ -0,0 +1,198 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Read-only stations keep serving their consumers while every produce is rejected,
+// the set is kept in memory on every broker since it is consulted on each inbound station message.
+var (
+	readOnlyStations      = map[string]struct{}{}
+	readOnlyStationsL     sync.RWMutex
+	readOnlyStationsCount int32
+)
+
+func readOnlyStationKey(tenantName, intern string) string {
+	return tenantName + ":" + intern
+}
+
+func setStationReadOnlyState(tenantName string, stationName StationName, readOnly bool) {
+	key := readOnlyStationKey(tenantName, stationName.Intern())
+	readOnlyStationsL.Lock()
+	if readOnly {
+		readOnlyStations[key] = struct{}{}
+	} else {
+		delete(readOnlyStations, key)
+	}
+	atomic.StoreInt32(&readOnlyStationsCount, int32(len(readOnlyStations)))
+	readOnlyStationsL.Unlock()
+}
+
+// readOnlyStationOfSubject returns the station a produce subject belongs to when that station is read-only
+func readOnlyStationOfSubject(tenantName, subject string) (StationName, bool) {
+	if atomic.LoadInt32(&readOnlyStationsCount) == 0 || !strings.HasSuffix(subject, ".final") {
+		return StationName{}, false
+	}
+	intern := stationInternFromContext(strings.TrimSuffix(subject, ".final"))
+	readOnlyStationsL.RLock()
+	_, ok := readOnlyStations[readOnlyStationKey(tenantName, intern)]
+	readOnlyStationsL.RUnlock()
+	if !ok {
+		return StationName{}, false
+	}
+	return StationNameFromStreamName(intern), true
+}
+
+func stationReadOnlyError(stationName StationName) error {
+	return fmt.Errorf("Station %v is read-only and does not accept new messages", stationName.Ext())
+}
+
+// rejectReadOnlyStationMsg answers the publisher the same way JetStream answers a rejected publish,
+// so SDKs waiting on the ack fail right away instead of timing out
+func (c *client) rejectReadOnlyStationMsg(stationName StationName, reply string) {
+	errMsg := stationReadOnlyError(stationName).Error()
+	if reply == _EMPTY_ {
+		if c.kind == CLIENT {
+			c.sendErr(errMsg)
+		}
+		return
+	}
+	resp := JSPubAckResponse{Error: &ApiError{Code: 400, Description: errMsg}}
+	c.srv.sendInternalAccountMsg(c.acc, reply, resp)
+}
+
+func (s *Server) publishStationReadOnlyUpdate(tenantName string, stationName StationName, readOnly bool) {
+	update := models.StationReadOnlyUpdate{StationName: stationName.Ext(), TenantName: tenantName, ReadOnly: readOnly}
+	msg, err := json.Marshal(update)
+	if err != nil {
+		s.Errorf("[tenant: %v]publishStationReadOnlyUpdate: %v", tenantName, err.Error())
+		return
+	}
+	s.sendInternalAccountMsg(s.MemphisGlobalAccount(), STATION_READ_ONLY_UPDATES_SUBJ, msg)
+}
+
+func (s *Server) forgetStationReadOnly(tenantName string, stationName StationName) {
+	setStationReadOnlyState(tenantName, stationName, false)
+	s.publishStationReadOnlyUpdate(tenantName, stationName, false)
+}
+
+// ListenForStationReadOnlyUpdates loads the read-only stations and keeps them in sync with the other brokers
+func (s *Server) ListenForStationReadOnlyUpdates() error {
+	_, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), STATION_READ_ONLY_UPDATES_SUBJ, STATION_READ_ONLY_UPDATES_SUBJ+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		go func(msg []byte) {
+			var update models.StationReadOnlyUpdate
+			err := json.Unmarshal(msg, &update)
+			if err != nil {
+				s.Errorf("ListenForStationReadOnlyUpdates at Unmarshal: %v", err.Error())
+				return
+			}
+			stationName, err := StationNameFromStr(update.StationName)
+			if err != nil {
+				s.Errorf("[tenant: %v]ListenForStationReadOnlyUpdates at StationNameFromStr: %v", update.TenantName, err.Error())
+				return
+			}
+			setStationReadOnlyState(update.TenantName, stationName, update.ReadOnly)
+		}(copyBytes(msg))
+	})
+	if err != nil {
+		return err
+	}
+
+	stations, err := db.GetReadOnlyStations()
+	if err != nil {
+		return err
+	}
+	for _, station := range stations {
+		stationName, err := StationNameFromStr(station.Name)
+		if err != nil {
+			s.Errorf("[tenant: %v]ListenForStationReadOnlyUpdates at StationNameFromStr: %v", station.TenantName, err.Error())
+			continue
+		}
+		setStationReadOnlyState(station.TenantName, stationName, true)
+	}
+	return nil
+}
+
+func (sh StationsHandler) UpdateStationReadOnly(c *gin.Context) {
+	var body models.UpdateStationReadOnlySchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("UpdateStationReadOnly at getUserDetailsFromMiddleware: At station %v: %v", body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if user.UserType != "root" && user.UserType != "management" {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationReadOnly: only admin users can change the read-only mode of a station", user.TenantName, user.Username)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": "Only admin users can change the read-only mode of a station"})
+		return
+	}
+
+	stationName, err := StationNameFromStr(body.StationName)
+	if err != nil {
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationReadOnly at StationNameFromStr: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": err.Error()})
+		return
+	}
+	exist, station, err := db.GetStationByName(stationName.Ext(), user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]UpdateStationReadOnly at GetStationByName: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	if !exist {
+		errMsg := fmt.Sprintf("Station %v does not exist", body.StationName)
+		serv.Warnf("[tenant: %v][user: %v]UpdateStationReadOnly: %v", user.TenantName, user.Username, errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	readOnly := *body.ReadOnly
+	if station.ReadOnly != readOnly {
+		err = db.UpdateStationReadOnly(station.Name, readOnly, station.TenantName)
+		if err != nil {
+			serv.Errorf("[tenant: %v][user: %v]UpdateStationReadOnly at UpdateStationReadOnly: At station, %v: %v", user.TenantName, user.Username, body.StationName, err.Error())
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+			return
+		}
+		setStationReadOnlyState(station.TenantName, stationName, readOnly)
+		sh.S.publishStationReadOnlyUpdate(station.TenantName, stationName, readOnly)
+
+		message := fmt.Sprintf("Station %v has been set to read-only by user %v", stationName.Ext(), user.Username)
+		if !readOnly {
+			message = fmt.Sprintf("Station %v is writable again, read-only mode was turned off by user %v", stationName.Ext(), user.Username)
+		}
+		if reason := strings.TrimSpace(body.Reason); reason != _EMPTY_ {
+			message += ", reason: " + reason
+		}
+		serv.Noticef("[tenant: %v][user: %v]: %v", user.TenantName, user.Username, message)
+		createAuditLogFromRequest(c, user, stationName.Ext(), message)
+	}
+
+	c.IndentedJSON(200, gin.H{"station_name": station.Name, "read_only": readOnly})
+}
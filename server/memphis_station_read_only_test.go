@@ -0,0 +1,47 @@
+package server
+
+import "testing"
+
+func TestReadOnlyStationOfSubject(t *testing.T) {
+	if _, ok := readOnlyStationOfSubject("tenant", "orders.final"); ok {
+		t.Fatalf("a station is read-only while no station is")
+	}
+	setStationReadOnlyState("tenant", StationNameFromStreamName("orders"), true)
+	setStationReadOnlyState("tenant", StationNameFromStreamName("my#events"), true)
+	defer func() {
+		setStationReadOnlyState("tenant", StationNameFromStreamName("orders"), false)
+		setStationReadOnlyState("tenant", StationNameFromStreamName("my#events"), false)
+	}()
+
+	cases := []struct {
+		name    string
+		tenant  string
+		subject string
+		station string
+		want    bool
+	}{
+		{name: "produce subject", tenant: "tenant", subject: "orders.final", station: "orders", want: true},
+		{name: "produce subject of a partition", tenant: "tenant", subject: "orders$2.final", station: "orders", want: true},
+		{name: "station name with a dot", tenant: "tenant", subject: "my#events.final", station: "my.events", want: true},
+		{name: "station of another tenant", tenant: "other", subject: "orders.final", want: false},
+		{name: "station that is not read-only", tenant: "tenant", subject: "payments.final", want: false},
+		{name: "not a produce subject", tenant: "tenant", subject: "orders", want: false},
+		{name: "station with a read-only name prefix", tenant: "tenant", subject: "orders2.final", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stationName, ok := readOnlyStationOfSubject(tc.tenant, tc.subject)
+			if ok != tc.want {
+				t.Fatalf("readOnlyStationOfSubject = %v, want %v", ok, tc.want)
+			}
+			if ok && stationName.Ext() != tc.station {
+				t.Fatalf("got station %q, want %q", stationName.Ext(), tc.station)
+			}
+		})
+	}
+
+	setStationReadOnlyState("tenant", StationNameFromStreamName("orders"), false)
+	if _, ok := readOnlyStationOfSubject("tenant", "orders.final"); ok {
+		t.Fatalf("the station is still read-only")
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	stationTailBufferSize    = 500
+	stationTailPreviewSize   = 1024
+	stationTailMaxDecodeSize = 16 * 1024
+	stationTailIdleTimeout   = time.Minute
+	stationTailMaxWait       = 30 * time.Second
+	stationTailPushDelay     = 500 * time.Millisecond
+)
+
+var ErrStationTailClosed = errors.New("station tail has been closed")
+
+type stationTailEntry struct {
+	msg         models.TailedMessage
+	raw         []byte
+	decoded     bool
+	compression string
+}
+
+// stationMessagesTail follows the messages produced into a station through a core subscription on the
+// station's streams subjects, so tailing does not create any consumer on the stream itself.
+// only the latest stationTailBufferSize messages are kept, slow readers are told how many they missed
+type stationMessagesTail struct {
+	sync.Mutex
+	key              string
+	tenantName       string
+	station          models.Station
+	account          *Account
+	subs             []*subscription
+	buffer           []stationTailEntry
+	lastCursor       uint64
+	lastRead         time.Time
+	newMsgs          chan struct{}
+	decoder          messageDecoder
+	decoderErr       error
+	decoderExpiresAt time.Time
+	listeners        map[string]func()
+	pushPending      bool
+	closed           bool
+}
+
+var stationTails = struct {
+	sync.Mutex
+	tails map[string]*stationMessagesTail
+}{tails: make(map[string]*stationMessagesTail)}
+
+// getStationMessagesTail returns the running tail of a station or starts a new one, tails which are not read for
+// stationTailIdleTimeout are stopped
+func (s *Server) getStationMessagesTail(tenantName string, stationName StationName) (*stationMessagesTail, error) {
+	key := tenantName + "/" + stationName.Ext()
+	stationTails.Lock()
+	defer stationTails.Unlock()
+	if t, ok := stationTails.tails[key]; ok {
+		t.Lock()
+		t.lastRead = time.Now()
+		t.Unlock()
+		return t, nil
+	}
+
+	exist, station, err := db.GetStationByName(stationName.Ext(), tenantName)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.New("station " + stationName.Ext() + " does not exist")
+	}
+	account, err := s.lookupAccount(tenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &stationMessagesTail{
+		key:        key,
+		tenantName: tenantName,
+		station:    station,
+		account:    account,
+		buffer:     make([]stationTailEntry, 0, stationTailBufferSize),
+		lastRead:   time.Now(),
+		newMsgs:    make(chan struct{}),
+		listeners:  make(map[string]func()),
+	}
+	for streamName, filterSubject := range stationStreamsAndFilters(stationName, station) {
+		partition := 0
+		if idx := strings.LastIndex(streamName, "$"); idx != -1 {
+			partition, _ = strconv.Atoi(streamName[idx+1:])
+		}
+		uid, err := uuid.NewV4()
+		if err != nil {
+			t.stop(s)
+			return nil, err
+		}
+		sub, err := s.subscribeOnAcc(account, filterSubject, "station_tail_"+uid.String(), func(c *client, _, _ string, msg []byte) {
+			rawHdr, data := c.msgParts(msg)
+			t.add(partition, rawHdr, data)
+		})
+		if err != nil {
+			t.stop(s)
+			return nil, err
+		}
+		t.subs = append(t.subs, sub)
+	}
+	stationTails.tails[key] = t
+	go s.reapStationMessagesTail(t)
+	return t, nil
+}
+
+func (s *Server) reapStationMessagesTail(t *stationMessagesTail) {
+	ticker := time.NewTicker(stationTailIdleTimeout / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.Lock()
+		idle := time.Since(t.lastRead) > stationTailIdleTimeout
+		closed := t.closed
+		t.Unlock()
+		if closed {
+			return
+		}
+		if idle {
+			stationTails.Lock()
+			if stationTails.tails[t.key] == t {
+				delete(stationTails.tails, t.key)
+			}
+			stationTails.Unlock()
+			t.stop(s)
+			return
+		}
+	}
+}
+
+func (t *stationMessagesTail) stop(s *Server) {
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return
+	}
+	t.closed = true
+	subs := t.subs
+	t.subs = nil
+	close(t.newMsgs)
+	t.Unlock()
+	for _, sub := range subs {
+		err := s.unsubscribeOnAcc(t.account, sub)
+		if err != nil {
+			s.Warnf("[tenant: %v]stationMessagesTail.stop at unsubscribeOnAcc: %v", t.tenantName, err.Error())
+		}
+	}
+}
+
+// add runs on the internal client's delivery path and therefore only copies what is needed,
+// decoding is left to the readers
+func (t *stationMessagesTail) add(partition int, rawHdr, data []byte) {
+	msg := models.TailedMessage{
+		Partition: partition,
+		TimeSent:  time.Now(),
+		Size:      len(rawHdr) + len(data),
+		Headers:   map[string]string{},
+	}
+	var compression string
+	if len(rawHdr) > 0 {
+		headers, err := DecodeHeader(rawHdr)
+		if err == nil {
+			msg.ProducedBy = headers["$memphis_producedBy"]
+			compression = headers[memphisCompressionHeader]
+			for k, v := range headers {
+				if !strings.HasPrefix(k, "$memphis") {
+					msg.Headers[k] = v
+				}
+			}
+		}
+	}
+	entry := stationTailEntry{msg: msg, compression: compression}
+	if compression == _EMPTY_ {
+		entry.msg.Data, entry.msg.Truncated = stationTailPreview(data)
+	}
+	if len(data) <= stationTailMaxDecodeSize {
+		entry.raw = copyBytes(data)
+	}
+
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return
+	}
+	t.lastCursor++
+	entry.msg.Cursor = t.lastCursor
+	if len(t.buffer) == stationTailBufferSize {
+		copy(t.buffer, t.buffer[1:])
+		t.buffer = t.buffer[:len(t.buffer)-1]
+	}
+	t.buffer = append(t.buffer, entry)
+	close(t.newMsgs)
+	t.newMsgs = make(chan struct{})
+	if len(t.listeners) > 0 && !t.pushPending {
+		t.pushPending = true
+		time.AfterFunc(stationTailPushDelay, t.notifyListeners)
+	}
+	t.Unlock()
+}
+
+func (t *stationMessagesTail) notifyListeners() {
+	t.Lock()
+	t.pushPending = false
+	listeners := make([]func(), 0, len(t.listeners))
+	for _, l := range t.listeners {
+		listeners = append(listeners, l)
+	}
+	t.Unlock()
+	for _, l := range listeners {
+		l()
+	}
+}
+
+func (t *stationMessagesTail) setListener(key string, listener func()) {
+	t.Lock()
+	t.listeners[key] = listener
+	t.Unlock()
+}
+
+func (t *stationMessagesTail) removeListener(key string) {
+	t.Lock()
+	delete(t.listeners, key)
+	t.Unlock()
+}
+
+func (t *stationMessagesTail) getDecoder() (messageDecoder, error) {
+	if t.station.SchemaName == _EMPTY_ {
+		return nil, nil
+	}
+	if time.Now().Before(t.decoderExpiresAt) {
+		return t.decoder, t.decoderErr
+	}
+	t.decoder, t.decoderErr = getStationMessageDecoder(t.tenantName, t.station)
+	if t.decoderErr == ErrNoSchema {
+		t.decoder, t.decoderErr = nil, nil
+	}
+	t.decoderExpiresAt = time.Now().Add(schemaValidatorsCacheTTL)
+	return t.decoder, t.decoderErr
+}
+
+// read returns the buffered messages which came after the given cursor, a zero cursor returns everything in the buffer
+func (t *stationMessagesTail) read(cursor uint64) models.StationMessagesTail {
+	t.Lock()
+	defer t.Unlock()
+	t.lastRead = time.Now()
+	resp := models.StationMessagesTail{Messages: []models.TailedMessage{}, NextCursor: t.lastCursor}
+	if cursor > t.lastCursor {
+		// the tail was restarted since the previous read
+		cursor = 0
+	}
+	if len(t.buffer) == 0 {
+		return resp
+	}
+	first := t.buffer[0].msg.Cursor
+	if cursor > 0 && cursor+1 < first {
+		resp.Dropped = first - cursor - 1
+	}
+	start := 0
+	if cursor >= first {
+		start = int(cursor - first + 1)
+	}
+	if start >= len(t.buffer) {
+		return resp
+	}
+
+	decoder, decoderErr := t.getDecoder()
+	for i := start; i < len(t.buffer); i++ {
+		entry := &t.buffer[i]
+		if entry.compression != _EMPTY_ {
+			entry.decompress()
+		}
+		if !entry.decoded && (decoder != nil || decoderErr != nil) {
+			switch {
+			case decoderErr != nil:
+				entry.msg.SchemaError = decoderErr.Error()
+			case entry.raw == nil:
+				entry.msg.SchemaError = "the message is too large to be decoded"
+			default:
+				decoded, err := decoder(entry.raw)
+				entry.msg.Decoded = decoded
+				if err != nil {
+					entry.msg.SchemaError = err.Error()
+				}
+			}
+			entry.decoded = true
+			entry.raw = nil
+		}
+		resp.Messages = append(resp.Messages, entry.msg)
+	}
+	return resp
+}
+
+// wait is the long poll flavour of read, it blocks until messages after the cursor arrive or the timeout passes
+func (t *stationMessagesTail) wait(cursor uint64, timeout time.Duration) (models.StationMessagesTail, error) {
+	if timeout > stationTailMaxWait {
+		timeout = stationTailMaxWait
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		t.Lock()
+		closed := t.closed
+		hasNew := t.lastCursor > cursor || (cursor > 0 && cursor > t.lastCursor)
+		newMsgs := t.newMsgs
+		t.Unlock()
+		if closed {
+			return models.StationMessagesTail{}, ErrStationTailClosed
+		}
+		if hasNew || timeout <= 0 {
+			return t.read(cursor), nil
+		}
+		select {
+		case <-newMsgs:
+		case <-timer.C:
+			return t.read(cursor), nil
+		}
+	}
+}
+
+// memphisWSGetStationMessagesTail returns a websocket filler which sends the messages produced since its previous call,
+// besides the regular ws loop ticks the update is pushed shortly after new messages arrive
+func memphisWSGetStationMessagesTail(s *Server, subj, stationName, tenantName string) (memphisWSReqFiller, error) {
+	sn, err := StationNameFromStr(stationName)
+	if err != nil {
+		return nil, err
+	}
+	var lock sync.Mutex
+	var cursor uint64
+	var current *stationMessagesTail
+	subs := s.memphis.ws.subscriptions
+	var filler memphisWSReqFiller
+	push := func() {
+		subs.Lock()
+		_, ok := subs.m[subj].tenants[tenantName]
+		subs.Unlock()
+		if !ok {
+			lock.Lock()
+			if current != nil {
+				current.removeListener(subj)
+			}
+			lock.Unlock()
+			return
+		}
+		s.publishWSUpdate(subs, subj, tenantName, filler, true)
+	}
+	filler = func(string) (any, error) {
+		tail, err := s.getStationMessagesTail(tenantName, sn)
+		if err != nil {
+			return nil, err
+		}
+		tail.setListener(subj, push)
+		lock.Lock()
+		defer lock.Unlock()
+		current = tail
+		resp := tail.read(cursor)
+		cursor = resp.NextCursor
+		return resp, nil
+	}
+	return filler, nil
+}
+
+func stationTailPreview(data []byte) (string, bool) {
+	if len(data) <= stationTailPreviewSize {
+		return string(data), false
+	}
+	preview := data[:stationTailPreviewSize]
+	// do not cut a multi byte character in the middle
+	for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(preview); i++ {
+		preview = preview[:len(preview)-1]
+	}
+	return string(preview), true
+}
+
+// decompress replaces the compressed payload kept by add with the original one, it runs once per entry under the tail lock
+func (e *stationTailEntry) decompress() {
+	codec := e.compression
+	e.compression, e.msg.Truncated = _EMPTY_, true
+	if e.raw == nil {
+		return
+	}
+	data, err := decompressPayload(codec, e.raw)
+	if err != nil {
+		e.msg.SchemaError = err.Error()
+		e.raw = nil
+		e.decoded = true
+		return
+	}
+	e.msg.Data, e.msg.Truncated = stationTailPreview(data)
+	e.raw = nil
+	if len(data) <= stationTailMaxDecodeSize {
+		e.raw = data
+	}
+}
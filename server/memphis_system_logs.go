@@ -0,0 +1,270 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/models"
+	"github.com/memphisdev/memphis/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	systemLogsDefaultPageSize  = 100
+	systemLogsTailMaxBatch     = 1000
+	systemLogsFetchTimeout     = 2 * time.Second
+	systemLogsFetchIdleTimeout = 250 * time.Millisecond
+)
+
+type systemLogsQuery struct {
+	filterSubject string
+	search        string
+	from          time.Time
+	to            time.Time
+	cursor        uint64
+	pageSize      int
+}
+
+// syslogsMaxBytes caps the system logs stream, by default to a third of the available storage
+func (s *Server) syslogsMaxBytes(maxStore int64) int64 {
+	if s.opts.LogsRetentionMaxMb > 0 {
+		return int64(s.opts.LogsRetentionMaxMb) * 1024 * 1024
+	}
+	return maxStore / 3
+}
+
+func systemLogsFilterSubject(logType, logSource string) string {
+	if logSource == _EMPTY_ || logSource == "empty" {
+		logSource = "*"
+	}
+	switch logType {
+	case "err":
+		return fmt.Sprintf("%s.%s.%s", syslogsStreamName, logSource, syslogsErrSubject)
+	case "warn":
+		return fmt.Sprintf("%s.%s.%s", syslogsStreamName, logSource, syslogsWarnSubject)
+	case "info":
+		return fmt.Sprintf("%s.%s.%s", syslogsStreamName, logSource, syslogsInfoSubject)
+	case "sys":
+		return fmt.Sprintf("%s.%s.%s", syslogsStreamName, logSource, syslogsSysSubject)
+	case "external":
+		return fmt.Sprintf("%s.%s.%s", syslogsStreamName, logSource, syslogsExternalSubject)
+	default:
+		if logSource == "*" {
+			return _EMPTY_
+		}
+		return fmt.Sprintf("%s.%s.>", syslogsStreamName, logSource)
+	}
+}
+
+func systemLogFromStoredMsg(msg StoredMsg) models.Log {
+	splittedSubj := strings.Split(msg.Subject, tsep)
+	var (
+		logSource string
+		logType   string
+	)
+
+	if len(splittedSubj) == 2 {
+		// old version's logs
+		logSource = "broker"
+		logType = splittedSubj[1]
+	} else if len(splittedSubj) == 3 {
+		// old version's logs
+		logSource, logType = splittedSubj[1], splittedSubj[2]
+	} else {
+		logSource, logType = splittedSubj[1], splittedSubj[3]
+	}
+
+	return models.Log{
+		MessageSeq: int(msg.Sequence),
+		Type:       logType,
+		Data:       string(msg.Data),
+		Source:     logSource,
+		TimeSent:   msg.Time,
+	}
+}
+
+// fetchSystemLogs reads up to amount logs using a short lived consumer, it returns once the batch is
+// complete or no more logs arrived within the idle timeout
+func (s *Server) fetchSystemLogs(cc ConsumerConfig, amount int) ([]StoredMsg, error) {
+	cc.Name = "$memphis_fetch_logs_consumer_" + s.memphis.nuid.Next()
+	cc.AckPolicy = AckNone
+	cc.Replicas = 1
+	err := s.memphisAddConsumer(s.MemphisGlobalAccountString(), syslogsStreamName, &cc)
+	if err != nil {
+		return nil, err
+	}
+	defer s.memphisRemoveConsumer(s.MemphisGlobalAccountString(), syslogsStreamName, cc.Name)
+
+	responseChan := make(chan StoredMsg, amount)
+	subject := fmt.Sprintf(JSApiRequestNextT, syslogsStreamName, cc.Name)
+	reply := cc.Name + "_reply"
+	sub, err := s.subscribeOnAcc(s.MemphisGlobalAccount(), reply, reply+"_sid", func(_ *client, subject, reply string, msg []byte) {
+		rawTs := tokenAt(reply, 8)
+		seq, _, _ := ackReplyInfo(reply)
+		intTs, err := strconv.Atoi(rawTs)
+		if err != nil {
+			s.Errorf("fetchSystemLogs: %v", err.Error())
+			return
+		}
+		select {
+		case responseChan <- StoredMsg{Subject: subject, Sequence: seq, Data: copyBytes(msg), Time: time.Unix(0, int64(intTs))}:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer s.unsubscribeOnAcc(s.MemphisGlobalAccount(), sub)
+
+	s.sendInternalAccountMsgWithReply(s.MemphisGlobalAccount(), subject, reply, nil, []byte(strconv.Itoa(amount)), true)
+
+	var msgs []StoredMsg
+	timer := time.NewTimer(systemLogsFetchTimeout)
+	defer timer.Stop()
+	for len(msgs) < amount {
+		select {
+		case <-timer.C:
+			return msgs, nil
+		case msg := <-responseChan:
+			msgs = append(msgs, msg)
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(systemLogsFetchIdleTimeout)
+		}
+	}
+	return msgs, nil
+}
+
+// browseSystemLogs walks the system logs stream forward from the query cursor (or start time) and returns
+// a page of logs matching the filters together with the cursor of the next page
+func (s *Server) browseSystemLogs(query systemLogsQuery) (models.BrowseSystemLogsResponse, error) {
+	response := models.BrowseSystemLogsResponse{Logs: []models.Log{}}
+	streamInfo, err := s.memphisStreamInfo(s.MemphisGlobalAccountString(), syslogsStreamName)
+	if err != nil {
+		return response, err
+	}
+
+	nextSeq := query.cursor
+	if nextSeq < streamInfo.State.FirstSeq {
+		nextSeq = streamInfo.State.FirstSeq
+	}
+	useStartTime := query.cursor == 0 && !query.from.IsZero()
+	search := strings.ToLower(query.search)
+	done := false
+	for !done && len(response.Logs) < query.pageSize && nextSeq <= streamInfo.State.LastSeq {
+		cc := ConsumerConfig{FilterSubject: query.filterSubject}
+		if useStartTime {
+			from := query.from
+			cc.DeliverPolicy = DeliverByStartTime
+			cc.OptStartTime = &from
+			useStartTime = false
+		} else {
+			cc.DeliverPolicy = DeliverByStartSequence
+			cc.OptStartSeq = nextSeq
+		}
+
+		msgs, err := s.fetchSystemLogs(cc, query.pageSize)
+		if err != nil {
+			return response, err
+		}
+		if len(msgs) < query.pageSize {
+			done = true
+		}
+		sort.Slice(msgs, func(i, j int) bool {
+			return msgs[i].Sequence < msgs[j].Sequence
+		})
+
+		for _, msg := range msgs {
+			if !query.to.IsZero() && msg.Time.After(query.to) {
+				done = true
+				break
+			}
+			nextSeq = msg.Sequence + 1
+			if search != _EMPTY_ && !strings.Contains(strings.ToLower(string(msg.Data)), search) {
+				continue
+			}
+			response.Logs = append(response.Logs, systemLogFromStoredMsg(msg))
+			if len(response.Logs) == query.pageSize {
+				break
+			}
+		}
+	}
+
+	response.NextCursor = nextSeq
+	response.HasMore = !done && nextSeq <= streamInfo.State.LastSeq
+	return response, nil
+}
+
+func (mh MonitoringHandler) BrowseSystemLogs(c *gin.Context) {
+	var body models.BrowseSystemLogsSchema
+	ok := utils.Validate(c, &body, false, nil)
+	if !ok {
+		return
+	}
+
+	if body.PageSize == 0 {
+		body.PageSize = systemLogsDefaultPageSize
+	}
+	if !body.From.IsZero() && !body.To.IsZero() && body.To.Before(body.From) {
+		errMsg := "The end of the time range has to be after its start"
+		serv.Warnf("BrowseSystemLogs: %v", errMsg)
+		c.AbortWithStatusJSON(SHOWABLE_ERROR_STATUS_CODE, gin.H{"message": errMsg})
+		return
+	}
+
+	response, err := mh.S.browseSystemLogs(systemLogsQuery{
+		filterSubject: systemLogsFilterSubject(body.LogType, body.LogSource),
+		search:        body.Search,
+		from:          body.From,
+		to:            body.To,
+		cursor:        body.Cursor,
+		pageSize:      body.PageSize,
+	})
+	if err != nil {
+		serv.Errorf("BrowseSystemLogs at browseSystemLogs: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+
+	c.IndentedJSON(200, response)
+}
+
+// memphisWSGetSystemLogsTail returns a websocket filler which on every update sends only the logs written since the previous one
+func memphisWSGetSystemLogsTail(s *Server, logLevel, logSource string) (memphisWSReqFiller, error) {
+	streamInfo, err := s.memphisStreamInfo(s.MemphisGlobalAccountString(), syslogsStreamName)
+	if err != nil {
+		return nil, err
+	}
+	cursor := streamInfo.State.LastSeq + 1
+	filterSubject := systemLogsFilterSubject(logLevel, logSource)
+	return func(string) (any, error) {
+		response, err := s.browseSystemLogs(systemLogsQuery{
+			filterSubject: filterSubject,
+			cursor:        cursor,
+			pageSize:      systemLogsTailMaxBatch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if response.NextCursor > cursor {
+			cursor = response.NextCursor
+		}
+		return response, nil
+	}, nil
+}
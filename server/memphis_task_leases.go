@@ -0,0 +1,91 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+)
+
+// a lease outlives its holder by at least this long, so a slow iteration does not hand the task to another broker
+const backgroundTaskLeaseMinTTL = 30 * time.Second
+
+var heldBackgroundTaskLeases = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// backgroundTaskLeaseTTL covers two iterations of the task, a single missed iteration does not hand the task over
+func backgroundTaskLeaseTTL(interval time.Duration) time.Duration {
+	ttl := 2 * interval
+	if ttl < backgroundTaskLeaseMinTTL {
+		ttl = backgroundTaskLeaseMinTTL
+	}
+	return ttl
+}
+
+// leadsBackgroundTask reports whether this broker should run the current iteration of a task which has to run on
+// a single broker. Within a cluster the task follows the jetstream meta leader, and a lease in the metadata db
+// covers brokers which share the metadata db without being clustered together and leadership changes, a new
+// holder takes over once the lease of the previous one expires
+func (s *Server) leadsBackgroundTask(task string, interval time.Duration) bool {
+	if s.JetStreamIsClustered() && !s.JetStreamIsLeader() {
+		s.releaseBackgroundTaskLease(task)
+		return false
+	}
+	acquired, err := db.AcquireBackgroundTaskLease(task, s.ID(), backgroundTaskLeaseTTL(interval))
+	if err != nil {
+		s.Warnf("leadsBackgroundTask at AcquireBackgroundTaskLease: task %v: %v", task, err.Error())
+		return false
+	}
+
+	heldBackgroundTaskLeases.Lock()
+	held := heldBackgroundTaskLeases.names[task]
+	if acquired {
+		heldBackgroundTaskLeases.names[task] = true
+	} else {
+		delete(heldBackgroundTaskLeases.names, task)
+	}
+	heldBackgroundTaskLeases.Unlock()
+	if acquired && !held {
+		s.Noticef("This broker now runs the background task %v", task)
+	} else if !acquired && held {
+		s.Warnf("The lease of the background task %v was taken by another broker", task)
+	}
+	return acquired
+}
+
+// holdsBackgroundTaskLease reports whether this broker ran the last iteration of the task
+func holdsBackgroundTaskLease(task string) bool {
+	heldBackgroundTaskLeases.Lock()
+	defer heldBackgroundTaskLeases.Unlock()
+	return heldBackgroundTaskLeases.names[task]
+}
+
+// releaseBackgroundTaskLease hands the task over right away instead of letting the lease expire
+func (s *Server) releaseBackgroundTaskLease(task string) {
+	heldBackgroundTaskLeases.Lock()
+	held := heldBackgroundTaskLeases.names[task]
+	delete(heldBackgroundTaskLeases.names, task)
+	heldBackgroundTaskLeases.Unlock()
+	if !held {
+		return
+	}
+	err := db.ReleaseBackgroundTaskLease(task, s.ID())
+	if err != nil {
+		s.Warnf("releaseBackgroundTaskLease at ReleaseBackgroundTaskLease: task %v: %v", task, err.Error())
+		return
+	}
+	s.Noticef("This broker stopped running the background task %v", task)
+}
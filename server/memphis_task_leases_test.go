@@ -0,0 +1,26 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackgroundTaskLeaseTTL(t *testing.T) {
+	cases := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{name: "short interval gets the minimum", interval: time.Second, want: backgroundTaskLeaseMinTTL},
+		{name: "twice the interval equals the minimum", interval: backgroundTaskLeaseMinTTL / 2, want: backgroundTaskLeaseMinTTL},
+		{name: "long interval covers two iterations", interval: time.Minute, want: 2 * time.Minute},
+		{name: "hourly task", interval: time.Hour, want: 2 * time.Hour},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := backgroundTaskLeaseTTL(tc.interval); got != tc.want {
+				t.Fatalf("backgroundTaskLeaseTTL(%v) = %v, want %v", tc.interval, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+// readProvisionedUsers parses the users declared in USERS_PROVISIONING_FILE, the path can point to a
+// yaml/json file or to a directory such as a mounted k8s secret, where every key is parsed as a file
+func readProvisionedUsers(path string) (configUsers, error) {
+	var confUsers configUsers
+	info, err := os.Stat(path)
+	if err != nil {
+		return configUsers{}, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return configUsers{}, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			// secret volumes keep their data under hidden ..data entries
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			filePath := filepath.Join(path, entry.Name())
+			fileInfo, err := os.Stat(filePath)
+			if err != nil || fileInfo.IsDir() {
+				continue
+			}
+			files = append(files, filePath)
+		}
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return configUsers{}, err
+		}
+		fileUsers, err := parseYamlFile(string(data))
+		if err != nil {
+			return configUsers{}, err
+		}
+		confUsers.Users.Mgmt = append(confUsers.Users.Mgmt, fileUsers.Users.Mgmt...)
+		confUsers.Users.Client = append(confUsers.Users.Client, fileUsers.Users.Client...)
+	}
+	return confUsers, nil
+}
+
+func declaredUsers() ([]models.UserResource, error) {
+	confUsers, err := readConfigUsers()
+	if err != nil {
+		return nil, err
+	}
+	if configuration.USERS_PROVISIONING_FILE != _EMPTY_ {
+		provisionedUsers, err := readProvisionedUsers(configuration.USERS_PROVISIONING_FILE)
+		if err != nil {
+			return nil, err
+		}
+		confUsers.Users.Mgmt = append(confUsers.Users.Mgmt, provisionedUsers.Users.Mgmt...)
+		confUsers.Users.Client = append(confUsers.Users.Client, provisionedUsers.Users.Client...)
+	}
+
+	users := make([]models.UserResource, 0, len(confUsers.Users.Mgmt)+len(confUsers.Users.Client))
+	for _, user := range confUsers.Users.Mgmt {
+		users = append(users, models.UserResource{Username: user.User, UserType: "management", Password: user.Password})
+	}
+	for _, user := range confUsers.Users.Client {
+		users = append(users, models.UserResource{Username: user.User, UserType: "application", Password: user.Password})
+	}
+	return users, nil
+}
+
+// ProvisionConfigUsers reconciles the management and application users declared in the deployment config
+// into the users table on every boot, missing users are created and the passwords of existing ones are
+// updated to the declared ones, users which are not declared are left untouched
+func (s *Server) ProvisionConfigUsers() {
+	tenantName := s.MemphisGlobalAccountString()
+	users, err := declaredUsers()
+	if err != nil {
+		s.Errorf("[tenant: %v]ProvisionConfigUsers at declaredUsers: %v", tenantName, err.Error())
+		return
+	}
+	if len(users) == 0 {
+		return
+	}
+	exist, rootUser, err := db.GetRootUser(tenantName)
+	if err != nil {
+		s.Errorf("[tenant: %v]ProvisionConfigUsers at GetRootUser: %v", tenantName, err.Error())
+		return
+	}
+	if !exist {
+		s.Errorf("[tenant: %v]ProvisionConfigUsers: root user does not exist", tenantName)
+		return
+	}
+
+	actions := map[string]int{}
+	for _, desired := range users {
+		if strings.ToLower(desired.Username) == ROOT_USERNAME {
+			s.Warnf("[tenant: %v]ProvisionConfigUsers: the root user can not be provisioned, its password is set by ROOT_PASSWORD", tenantName)
+			continue
+		}
+		// the profile of an existing user is kept as edited in the UI, only the password is reconciled
+		current, exist, err := getUserResource(tenantName, strings.ToLower(desired.Username))
+		if err != nil {
+			s.Errorf("[tenant: %v]ProvisionConfigUsers at getUserResource: User %v: %v", tenantName, desired.Username, err.Error())
+			continue
+		}
+		if exist && current.UserType == desired.UserType {
+			password := desired.Password
+			desired = current
+			desired.Password = password
+		}
+		action, err := s.applyUserResource(rootUser, desired, false)
+		if err != nil {
+			s.Warnf("[tenant: %v]ProvisionConfigUsers: User %v: %v", tenantName, desired.Username, err.Error())
+			continue
+		}
+		actions[action]++
+	}
+	if actions[models.ResourceActionCreated] > 0 || actions[models.ResourceActionUpdated] > 0 {
+		s.Noticef("[tenant: %v]Provisioned users from config: %v created, %v updated, %v unchanged", tenantName, actions[models.ResourceActionCreated], actions[models.ResourceActionUpdated], actions[models.ResourceActionUnchanged])
+	}
+}
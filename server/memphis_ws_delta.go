@@ -0,0 +1,225 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Ws clients which subscribe to the delta subject get the same data as the full subject as a stream of patches:
+//   - {"type": "full", "version": n, "data": {...}} carries the whole state, it is sent when a client
+//     registers (SUB) and every memphisWS_FullResyncTicks updates so a client which missed a patch recovers
+//   - {"type": "delta", "version": n, "base_version": n-1, "changes": {...}} carries a merge patch (RFC 7386)
+//     against the state of base_version, a client which does not hold base_version should SUB again
+//
+// Lists of objects which all have a unique "id" (or "name") are patched by key rather than replaced:
+// {"$key": "id", "$set": [changed or added items], "$delete": [removed keys], "$order": [all keys, only when the order changed]}
+// Nothing is sent when the state did not change.
+const (
+	memphisWS_TemplSubj_PublishDelta = "$memphis_ws_pubs.delta.%s"
+	memphisWS_FullResyncTicks        = 15
+	memphisWS_DeltaTypeFull          = "full"
+	memphisWS_DeltaTypeDelta         = "delta"
+)
+
+type memphisWSDeltaMsg struct {
+	Type        string `json:"type"`
+	Version     uint64 `json:"version"`
+	BaseVersion uint64 `json:"base_version,omitempty"`
+	Data        any    `json:"data,omitempty"`
+	Changes     any    `json:"changes,omitempty"`
+}
+
+type memphisWSDeltaState struct {
+	sync.Mutex
+	version      uint64
+	last         any
+	updatesCount int
+	needFull     bool
+}
+
+func memphisWSDeltaKey(subj, tenantName string) string {
+	return subj + "|" + tenantName
+}
+
+// requestWSFullResync makes the next delta update of the subscription a full state
+func (s *Server) requestWSFullResync(subj, tenantName string) {
+	state, ok := s.memphis.ws.deltas.Load(memphisWSDeltaKey(subj, tenantName))
+	if !ok {
+		return
+	}
+	state.Lock()
+	state.needFull = true
+	state.Unlock()
+}
+
+// buildWSDeltaMsg returns the message to publish on the delta subject, nil when nothing changed
+func (s *Server) buildWSDeltaMsg(subj, tenantName string, updateRaw []byte) ([]byte, error) {
+	var current any
+	if err := json.Unmarshal(updateRaw, &current); err != nil {
+		return nil, err
+	}
+
+	deltas := s.memphis.ws.deltas
+	key := memphisWSDeltaKey(subj, tenantName)
+	deltas.Add(key, &memphisWSDeltaState{needFull: true})
+	state, ok := deltas.Load(key)
+	if !ok {
+		return nil, fmt.Errorf("delta state of %v not found", subj)
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	var msg memphisWSDeltaMsg
+	if state.needFull || state.last == nil || state.updatesCount >= memphisWS_FullResyncTicks {
+		state.version++
+		state.needFull = false
+		state.updatesCount = 0
+		msg = memphisWSDeltaMsg{Type: memphisWS_DeltaTypeFull, Version: state.version, Data: current}
+	} else {
+		changes, changed := memphisWSDiff(state.last, current)
+		state.updatesCount++
+		if !changed {
+			return nil, nil
+		}
+		state.version++
+		msg = memphisWSDeltaMsg{Type: memphisWS_DeltaTypeDelta, Version: state.version, BaseVersion: state.version - 1, Changes: changes}
+	}
+	state.last = current
+	return json.Marshal(msg)
+}
+
+func (s *Server) deleteWSDeltaState(subj, tenantName string) {
+	s.memphis.ws.deltas.Delete(memphisWSDeltaKey(subj, tenantName))
+}
+
+// memphisWSDiff returns the merge patch which turns prev into current
+func memphisWSDiff(prev, current any) (any, bool) {
+	prevMap, prevIsMap := prev.(map[string]any)
+	currentMap, currentIsMap := current.(map[string]any)
+	if prevIsMap && currentIsMap {
+		patch := map[string]any{}
+		for k, v := range currentMap {
+			prevValue, ok := prevMap[k]
+			if !ok {
+				patch[k] = v
+				continue
+			}
+			if valuePatch, changed := memphisWSDiff(prevValue, v); changed {
+				patch[k] = valuePatch
+			}
+		}
+		for k := range prevMap {
+			if _, ok := currentMap[k]; !ok {
+				patch[k] = nil
+			}
+		}
+		return patch, len(patch) > 0
+	}
+
+	prevList, prevIsList := prev.([]any)
+	currentList, currentIsList := current.([]any)
+	if prevIsList && currentIsList {
+		if keyField := memphisWSListKey(prevList, currentList); keyField != _EMPTY_ {
+			return memphisWSListDiff(keyField, prevList, currentList)
+		}
+	}
+
+	if reflect.DeepEqual(prev, current) {
+		return nil, false
+	}
+	return current, true
+}
+
+// memphisWSListKey returns the field identifying the items of both lists, empty if there is none
+func memphisWSListKey(lists ...[]any) string {
+	for _, field := range []string{"id", "name"} {
+		valid := true
+		for _, list := range lists {
+			seen := map[string]bool{}
+			for _, item := range list {
+				itemMap, ok := item.(map[string]any)
+				if !ok || itemMap[field] == nil {
+					valid = false
+					break
+				}
+				key := fmt.Sprint(itemMap[field])
+				if seen[key] {
+					valid = false
+					break
+				}
+				seen[key] = true
+			}
+			if !valid {
+				break
+			}
+		}
+		if valid {
+			return field
+		}
+	}
+	return _EMPTY_
+}
+
+func memphisWSListDiff(keyField string, prevList, currentList []any) (any, bool) {
+	prevItems := map[string]any{}
+	prevOrder := make([]string, 0, len(prevList))
+	for _, item := range prevList {
+		key := fmt.Sprint(item.(map[string]any)[keyField])
+		prevItems[key] = item
+		prevOrder = append(prevOrder, key)
+	}
+
+	set := []any{}
+	order := make([]any, 0, len(currentList))
+	currentOrder := make([]string, 0, len(currentList))
+	currentKeys := map[string]bool{}
+	for _, item := range currentList {
+		rawKey := item.(map[string]any)[keyField]
+		key := fmt.Sprint(rawKey)
+		currentKeys[key] = true
+		order = append(order, rawKey)
+		currentOrder = append(currentOrder, key)
+		if prevItem, ok := prevItems[key]; !ok || !reflect.DeepEqual(prevItem, item) {
+			set = append(set, item)
+		}
+	}
+	remove := []any{}
+	for _, item := range prevList {
+		rawKey := item.(map[string]any)[keyField]
+		if !currentKeys[fmt.Sprint(rawKey)] {
+			remove = append(remove, rawKey)
+		}
+	}
+
+	orderChanged := len(remove) > 0 || len(prevOrder) != len(currentOrder)
+	if !orderChanged {
+		for i := range prevOrder {
+			if prevOrder[i] != currentOrder[i] {
+				orderChanged = true
+				break
+			}
+		}
+	}
+	if len(set) == 0 && len(remove) == 0 && !orderChanged {
+		return nil, false
+	}
+
+	patch := map[string]any{"$key": keyField, "$set": set, "$delete": remove}
+	if orderChanged {
+		patch["$order"] = order
+	}
+	return patch, true
+}
@@ -14,14 +14,29 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/memphisdev/memphis/db"
 	"github.com/memphisdev/memphis/models"
+
+	"github.com/gin-gonic/gin"
 )
 
-var zombieCandidates = make(map[string]map[string]interface{}, 0)
+type zombieCandidate struct {
+	strikes     int
+	firstMissed time.Time
+	lastMissed  time.Time
+}
+
+// zombieCandidates holds the connections which own active producers/consumers but were not reported by any broker,
+// a connection is killed once it is missed ZOMBIE_CONN_STRIKES times
+var zombieCandidates = struct {
+	sync.Mutex
+	conns     map[string]*zombieCandidate
+	lastCheck time.Time
+}{conns: make(map[string]*zombieCandidate)}
 
 func (srv *Server) removeStaleStations() {
 	// TODO - handle stale partition and deleting its resources
@@ -41,7 +56,7 @@ func (srv *Server) removeStaleStations() {
 			}
 
 			if 0 < len(partitionsToDelete) {
-				err := removeStationResources(srv, s, false)
+				err := removeStationResources(srv, s, false, _EMPTY_)
 				if err != nil {
 					srv.Errorf("[tenant: %v]removeStaleStations at removeStationResources: %v", s.TenantName, err.Error())
 				}
@@ -80,7 +95,7 @@ func aggregateClientConnections(s *Server) (map[string]string, error) {
 
 	// send message to all brokers to get their connections
 	s.sendInternalAccountMsgWithReply(s.MemphisGlobalAccount(), CONN_STATUS_SUBJ, replySubject, nil, _EMPTY_, true)
-	timeout := time.After(2 * time.Minute)
+	timeout := time.After(time.Duration(configuration.ZOMBIE_CONN_COLLECT_SEC) * time.Second)
 	<-timeout
 	s.unsubscribeOnAcc(s.MemphisGlobalAccount(), sub)
 	return connectionIds, nil
@@ -100,48 +115,125 @@ func killFunc(s *Server) {
 			serv.Errorf("killFunc: aggregateClientConnections: %v", err.Error())
 			return
 		}
+		now := time.Now()
+		zombieCandidates.Lock()
+		zombieCandidates.lastCheck = now
 		for _, conn := range connections {
-			if _, exist := clientConnectionIds[conn]; exist {
+			if _, exist := clientConnectionIds[conn]; exist || sdkHeartbeatsTracked(conn) {
 				continue
-			} else {
-				if _, exist := zombieCandidates[conn]; !exist {
-					zombieCandidates[conn] = make(map[string]interface{}, 0)
-					zombieCandidates[conn]["count"] = 0
-				}
-				zombieCandidates[conn]["count"] = zombieCandidates[conn]["count"].(int) + 1
-				zombieCandidates[conn]["lastUpdate"] = time.Now()
-				if zombieCandidates[conn]["count"].(int) >= 3 {
-					zombieConnections = append(zombieConnections, conn)
-					delete(zombieCandidates, conn)
-				}
+			}
+			candidate, exist := zombieCandidates.conns[conn]
+			if !exist {
+				candidate = &zombieCandidate{firstMissed: now}
+				zombieCandidates.conns[conn] = candidate
+			}
+			candidate.strikes++
+			candidate.lastMissed = now
+			if candidate.strikes >= configuration.ZOMBIE_CONN_STRIKES {
+				zombieConnections = append(zombieConnections, conn)
+				delete(zombieCandidates.conns, conn)
 			}
 		}
 
-		for k, v := range zombieCandidates {
-			if time.Since(v["lastUpdate"].(time.Time)).Minutes() >= 60 && v["count"].(int) < 3 {
-				delete(zombieCandidates, k)
+		candidateTTL := time.Duration(configuration.ZOMBIE_CANDIDATE_TTL_MIN) * time.Minute
+		for k, v := range zombieCandidates.conns {
+			if time.Since(v.lastMissed) >= candidateTTL {
+				delete(zombieCandidates.conns, k)
 			}
 		}
+		zombieCandidates.Unlock()
 
 		if len(zombieConnections) > 0 {
 			serv.Warnf("%v zombie connections found, killing", len(zombieConnections))
+			// the clients are collected before they are marked as inactive so they can be audited afterwards
+			clients, err := db.GetActiveClientsByConnections(zombieConnections)
+			if err != nil {
+				serv.Warnf("killFunc: GetActiveClientsByConnections: %v", err.Error())
+			}
 			err = db.KillProducersByConnections(zombieConnections)
 			if err != nil {
 				serv.Errorf("killFunc: killProducersByConnections: %v", err.Error())
+			} else {
+				producersStateSetConnectionActive(false, zombieConnections...)
 			}
 			err = db.KillConsumersByConnections(zombieConnections)
 			if err != nil {
 				serv.Errorf("killFunc: killConsumersByConnections: %v", err.Error())
+			} else {
+				recordCgRebalancesByConnections(_EMPTY_, cgRebalanceMemberKilled, zombieConnections...)
 			}
+			auditKilledConnectionClients(clients, fmt.Sprintf("was not found on any broker in %v checks", configuration.ZOMBIE_CONN_STRIKES))
+		}
+	}
+}
+
+func (s *Server) getZombieCandidates(tenantName string) (models.ZombieCandidatesResponse, error) {
+	resp := models.ZombieCandidatesResponse{
+		CheckingBroker:       holdsBackgroundTaskLease("KillZombieResources"),
+		CheckIntervalMinutes: configuration.ZOMBIE_CHECK_INTERVAL_MIN,
+		StrikesToKill:        configuration.ZOMBIE_CONN_STRIKES,
+		Candidates:           []models.ZombieCandidate{},
+	}
+	zombieCandidates.Lock()
+	resp.LastCheck = zombieCandidates.lastCheck
+	candidates := make(map[string]models.ZombieCandidate, len(zombieCandidates.conns))
+	connectionIds := make([]string, 0, len(zombieCandidates.conns))
+	for conn, c := range zombieCandidates.conns {
+		candidates[conn] = models.ZombieCandidate{ConnectionId: conn, Strikes: c.strikes, FirstMissed: c.firstMissed, LastMissed: c.lastMissed}
+		connectionIds = append(connectionIds, conn)
+	}
+	zombieCandidates.Unlock()
+	if len(connectionIds) == 0 {
+		return resp, nil
+	}
+
+	clients, err := db.GetActiveClientsByConnections(connectionIds)
+	if err != nil {
+		return models.ZombieCandidatesResponse{}, err
+	}
+	for _, connClient := range clients {
+		if connClient.TenantName != tenantName {
+			continue
+		}
+		candidate := candidates[connClient.ConnectionId]
+		candidate.Clients = append(candidate.Clients, connClient)
+		candidates[connClient.ConnectionId] = candidate
+	}
+	for _, candidate := range candidates {
+		if len(candidate.Clients) > 0 {
+			resp.Candidates = append(resp.Candidates, candidate)
 		}
 	}
+	sort.Slice(resp.Candidates, func(i, j int) bool {
+		return resp.Candidates[i].Strikes > resp.Candidates[j].Strikes
+	})
+	return resp, nil
+}
+
+// GetZombieCandidates lists the connections suspected as zombies, together with the producers and consumers
+// which will be disconnected if they keep missing
+func (mh MonitoringHandler) GetZombieCandidates(c *gin.Context) {
+	user, err := getUserDetailsFromMiddleware(c)
+	if err != nil {
+		serv.Errorf("GetZombieCandidates at getUserDetailsFromMiddleware: %v", err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	resp, err := mh.S.getZombieCandidates(user.TenantName)
+	if err != nil {
+		serv.Errorf("[tenant: %v][user: %v]GetZombieCandidates at getZombieCandidates: %v", user.TenantName, user.Username, err.Error())
+		c.AbortWithStatusJSON(500, gin.H{"message": "Server error"})
+		return
+	}
+	c.IndentedJSON(200, resp)
 }
 
 func (s *Server) KillZombieResources() {
-	count := 0
+	var lastLivenessUpdate time.Time
 	firstIteration := true
-	for range time.Tick(time.Minute * 15) {
-		if s.JetStreamIsClustered() && !s.JetStreamIsLeader() { // logic happens once only on the leader
+	interval := time.Duration(configuration.ZOMBIE_CHECK_INTERVAL_MIN) * time.Minute
+	for range time.Tick(interval) {
+		if !s.leadsBackgroundTask("KillZombieResources", interval) { // logic happens once only on the leader
 			continue
 		}
 
@@ -153,11 +245,10 @@ func (s *Server) KillZombieResources() {
 		killFunc(s)
 		s.RemoveInactiveAsyncTasks()
 
-		if firstIteration || count == 1*60 { // once in 1 hour
+		if time.Since(lastLivenessUpdate) >= time.Hour { // once in 1 hour
 			updateSystemLiveness()
-			count = 0
+			lastLivenessUpdate = time.Now()
 		}
 		firstIteration = false
-		count += 15
 	}
 }
@@ -20,7 +20,12 @@ import (
 )
 
 const (
-	slackIntegrationName = "slack"
+	slackIntegrationName           = "slack"
+	StationCreatedTitle            = "Station created"
+	StationDeletedTitle            = "Station deleted"
+	StationArchivedTitle           = "Station archived"
+	StationLifecycleScheduledTitle = "Station lifecycle action scheduled"
+	SchemaChangedTitle             = "Schema changed"
 )
 
 type NotificationMsg struct {
@@ -97,3 +102,11 @@ func shouldSendNotification(tenantName string, alertType string) bool {
 	}
 	return false
 }
+
+// notifyOperationalEvent is used for resource changes, a failed notification is logged and never fails the change itself
+func (s *Server) notifyOperationalEvent(tenantName, title, message, msgType string) {
+	err := s.SendNotification(tenantName, title, message, msgType)
+	if err != nil {
+		s.Warnf("[tenant: %v]notifyOperationalEvent: %v", tenantName, err.Error())
+	}
+}
@@ -56,7 +56,7 @@ func sendMessageToSlackChannel(integration models.SlackIntegration, title string
 
 func cacheDetailsSlack(keys map[string]interface{}, properties map[string]bool, tenantName string) {
 	var authToken, channelID string
-	var poisonMessageAlert, schemaValidationFailAlert, disconnectionEventsAlert bool
+	var poisonMessageAlert, schemaValidationFailAlert, disconnectionEventsAlert, stationLifecycleAlert, schemaChangeAlert bool
 	slackIntegration := models.SlackIntegration{}
 	slackIntegration.Keys = make(map[string]string)
 	slackIntegration.Properties = make(map[string]bool)
@@ -91,6 +91,8 @@ func cacheDetailsSlack(keys map[string]interface{}, properties map[string]bool,
 	if !ok {
 		disconnectionEventsAlert = false
 	}
+	stationLifecycleAlert = properties[StationLifecycleAlert]
+	schemaChangeAlert = properties[SchemaChangeAlert]
 	if slackIntegration.Keys["auth_token"] != authToken {
 		slackIntegration.Keys["auth_token"] = authToken
 		if authToken != _EMPTY_ {
@@ -102,6 +104,8 @@ func cacheDetailsSlack(keys map[string]interface{}, properties map[string]bool,
 	slackIntegration.Properties[PoisonMAlert] = poisonMessageAlert
 	slackIntegration.Properties[SchemaVAlert] = schemaValidationFailAlert
 	slackIntegration.Properties[DisconEAlert] = disconnectionEventsAlert
+	slackIntegration.Properties[StationLifecycleAlert] = stationLifecycleAlert
+	slackIntegration.Properties[SchemaChangeAlert] = schemaChangeAlert
 	slackIntegration.Name = "slack"
 	if _, ok := IntegrationsConcurrentCache.Load(tenantName); !ok {
 		IntegrationsConcurrentCache.Add(tenantName, map[string]interface{}{"slack": slackIntegration})
@@ -146,6 +150,8 @@ func (it IntegrationsHandler) getSlackIntegrationDetails(body models.CreateInteg
 	}
 
 	keys, properties := createIntegrationsKeysAndProperties("slack", authToken, channelID, pmAlert, svfAlert, disconnectAlert, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, map[string]interface{}{}, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_)
+	properties[StationLifecycleAlert] = body.Properties[StationLifecycleAlert]
+	properties[SchemaChangeAlert] = body.Properties[SchemaChangeAlert]
 	return keys, properties, 0, nil
 }
 
@@ -174,7 +180,7 @@ func (it IntegrationsHandler) handleUpdateSlackIntegration(tenantName, integrati
 	if err != nil {
 		return models.Integration{}, errorCode, err
 	}
-	slackIntegration, err := updateSlackIntegration(tenantName, keys["auth_token"].(string), keys["channel_id"].(string), properties[PoisonMAlert], properties[SchemaVAlert], properties[DisconEAlert], body.UIUrl)
+	slackIntegration, err := updateSlackIntegration(tenantName, keys["auth_token"].(string), keys["channel_id"].(string), properties, body.UIUrl)
 	if err != nil {
 		errMsg := strings.ToLower(err.Error())
 		if strings.Contains(errMsg, "invalid auth token") || strings.Contains(errMsg, "invalid channel") {
@@ -236,7 +242,7 @@ func createSlackIntegration(tenantName string, keys map[string]interface{}, prop
 	return slackIntegration, errors.New("slack integration already exists")
 }
 
-func updateSlackIntegration(tenantName string, authToken string, channelID string, pmAlert bool, svfAlert bool, disconnectAlert bool, uiUrl string) (models.Integration, error) {
+func updateSlackIntegration(tenantName string, authToken string, channelID string, alerts map[string]bool, uiUrl string) (models.Integration, error) {
 	var slackIntegration models.Integration
 	if authToken == _EMPTY_ {
 		exist, integrationFromDb, err := db.GetIntegration("slack", tenantName)
@@ -257,7 +263,9 @@ func updateSlackIntegration(tenantName string, authToken string, channelID strin
 	if err != nil {
 		return slackIntegration, err
 	}
-	keys, properties := createIntegrationsKeysAndProperties("slack", authToken, channelID, pmAlert, svfAlert, disconnectAlert, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, map[string]interface{}{}, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_)
+	keys, properties := createIntegrationsKeysAndProperties("slack", authToken, channelID, alerts[PoisonMAlert], alerts[SchemaVAlert], alerts[DisconEAlert], _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_, map[string]interface{}{}, _EMPTY_, _EMPTY_, _EMPTY_, _EMPTY_)
+	properties[StationLifecycleAlert] = alerts[StationLifecycleAlert]
+	properties[SchemaChangeAlert] = alerts[SchemaChangeAlert]
 	stringMapKeys := GetKeysAsStringMap(keys)
 	cloneKeys := copyMaps(stringMapKeys)
 	encryptedValue, err := EncryptAES([]byte(authToken))
@@ -289,7 +297,7 @@ func updateSlackIntegration(tenantName string, authToken string, channelID strin
 	}
 	update := models.SdkClientsUpdates{
 		Type:   sendNotificationType,
-		Update: svfAlert,
+		Update: alerts[SchemaVAlert],
 	}
 	serv.SendUpdateToClients(update)
 	keys["auth_token"] = hideSlackAuthToken(cloneKeys["auth_token"])
@@ -0,0 +1,256 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	smtpDefaultPort     = "587"
+	smtpImplicitTLSPort = "465"
+	smtpDialTimeout     = 10 * time.Second
+)
+
+var smtpKeys = []string{"host", "port", "username", "password", "from_address"}
+
+func IsSmtpEnabled(tenantName string) (bool, error) {
+	exist, _, err := db.GetIntegration("smtp", tenantName)
+	if err != nil {
+		return false, err
+	}
+	return exist, nil
+}
+
+// getSmtpIntegrationDetails validates the keys of an smtp integration and checks the server accepts them,
+// an empty password keeps the one which is already stored
+func getSmtpIntegrationDetails(tenantName string, bodyKeys map[string]interface{}) (map[string]string, int, error) {
+	keys := make(map[string]string)
+	for _, key := range smtpKeys {
+		if value, ok := bodyKeys[key]; ok && value != nil {
+			keys[key] = strings.TrimSpace(fmt.Sprintf("%v", value))
+		} else {
+			keys[key] = _EMPTY_
+		}
+	}
+	if keys["host"] == _EMPTY_ {
+		return map[string]string{}, SHOWABLE_ERROR_STATUS_CODE, errors.New("must provide a host for smtp integration")
+	}
+	if keys["port"] == _EMPTY_ {
+		keys["port"] = smtpDefaultPort
+	}
+	if keys["from_address"] == _EMPTY_ {
+		return map[string]string{}, SHOWABLE_ERROR_STATUS_CODE, errors.New("must provide a from address for smtp integration")
+	}
+	if _, err := mail.ParseAddress(keys["from_address"]); err != nil {
+		return map[string]string{}, SHOWABLE_ERROR_STATUS_CODE, fmt.Errorf("invalid from address: %v", err.Error())
+	}
+
+	if keys["password"] == _EMPTY_ && keys["username"] != _EMPTY_ {
+		exist, stored, err := getSmtpIntegration(tenantName)
+		if err != nil {
+			return map[string]string{}, 500, err
+		}
+		if !exist {
+			return map[string]string{}, SHOWABLE_ERROR_STATUS_CODE, errors.New("must provide a password for smtp integration")
+		}
+		keys["password"] = stored["password"]
+	}
+
+	err := testSmtpIntegration(keys)
+	if err != nil {
+		return map[string]string{}, SHOWABLE_ERROR_STATUS_CODE, err
+	}
+	return keys, 0, nil
+}
+
+func (it IntegrationsHandler) handleCreateSmtpIntegration(tenantName string, body models.CreateIntegrationSchema) (models.Integration, int, error) {
+	exist, _, err := db.GetIntegration("smtp", tenantName)
+	if err != nil {
+		return models.Integration{}, 500, err
+	}
+	if exist {
+		return models.Integration{}, SHOWABLE_ERROR_STATUS_CODE, errors.New("smtp integration already exists")
+	}
+	keys, errorCode, err := getSmtpIntegrationDetails(tenantName, body.Keys)
+	if err != nil {
+		return models.Integration{}, errorCode, err
+	}
+	encryptedKeys, err := encryptSmtpKeys(keys)
+	if err != nil {
+		return models.Integration{}, 500, err
+	}
+	smtpIntegration, err := db.InsertNewIntegration(tenantName, "smtp", encryptedKeys, map[string]bool{})
+	if err != nil {
+		return models.Integration{}, 500, err
+	}
+	smtpIntegration.Keys = hideSmtpPassword(keys)
+	return smtpIntegration, 0, nil
+}
+
+func (it IntegrationsHandler) handleUpdateSmtpIntegration(tenantName string, body models.CreateIntegrationSchema) (models.Integration, int, error) {
+	keys, errorCode, err := getSmtpIntegrationDetails(tenantName, body.Keys)
+	if err != nil {
+		return models.Integration{}, errorCode, err
+	}
+	encryptedKeys, err := encryptSmtpKeys(keys)
+	if err != nil {
+		return models.Integration{}, 500, err
+	}
+	smtpIntegration, err := db.UpdateIntegration(tenantName, "smtp", encryptedKeys, map[string]bool{})
+	if err != nil {
+		return models.Integration{}, 500, err
+	}
+	smtpIntegration.Keys = hideSmtpPassword(keys)
+	return smtpIntegration, 0, nil
+}
+
+func encryptSmtpKeys(keys map[string]string) (map[string]interface{}, error) {
+	cloneKeys := copyMaps(keys)
+	if cloneKeys["password"] != _EMPTY_ {
+		encryptedValue, err := EncryptAES([]byte(cloneKeys["password"]))
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		cloneKeys["password"] = encryptedValue
+	}
+	return copyStringMapToInterfaceMap(cloneKeys), nil
+}
+
+func hideSmtpPassword(keys map[string]string) map[string]interface{} {
+	hiddenKeys := copyStringMapToInterfaceMap(keys)
+	if keys["password"] != _EMPTY_ {
+		hiddenKeys["password"] = "****"
+	}
+	return hiddenKeys
+}
+
+// getSmtpIntegration returns the keys of the tenant smtp integration with the password decrypted,
+// the keys are read on every email since invitations are rare and it saves caching them on every broker
+func getSmtpIntegration(tenantName string) (bool, map[string]string, error) {
+	exist, integration, err := db.GetIntegration("smtp", tenantName)
+	if err != nil {
+		return false, map[string]string{}, err
+	}
+	if !exist {
+		return false, map[string]string{}, nil
+	}
+	keys := GetKeysAsStringMap(integration.Keys)
+	if keys["password"] != _EMPTY_ {
+		password, err := DecryptAES(getAESKey(), keys["password"])
+		if err != nil {
+			return false, map[string]string{}, err
+		}
+		keys["password"] = password
+	}
+	return true, keys, nil
+}
+
+func dialSmtp(keys map[string]string) (*smtp.Client, error) {
+	host := keys["host"]
+	addr := net.JoinHostPort(host, keys["port"])
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if keys["port"] == smtpImplicitTLSPort {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpDialTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpDialTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the smtp server: %v", err.Error())
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not connect to the smtp server: %v", err.Error())
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok && keys["port"] != smtpImplicitTLSPort {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp server STARTTLS failed: %v", err.Error())
+		}
+	}
+	if keys["username"] != _EMPTY_ {
+		if err := client.Auth(smtp.PlainAuth(_EMPTY_, keys["username"], keys["password"], host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp authentication failed: %v", err.Error())
+		}
+	}
+	return client, nil
+}
+
+func testSmtpIntegration(keys map[string]string) error {
+	client, err := dialSmtp(keys)
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func sendEmail(tenantName, to, subject, body string) error {
+	exist, keys, err := getSmtpIntegration(tenantName)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errors.New("smtp integration does not exist")
+	}
+
+	client, err := dialSmtp(keys)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	from, err := mail.ParseAddress(keys["from_address"])
+	if err != nil {
+		return err
+	}
+	if err = client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	msg := "From: " + from.String() + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=\"UTF-8\"\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+	if _, err = w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
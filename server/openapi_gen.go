@@ -0,0 +1,180 @@
+//go:build ignore
+// +build ignore
+
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const openApiModelsFile = "server/memphis_openapi_models.go"
+
+var templ = `{{ .License }}
+// Generated code, do not edit. Run go generate to update
+
+package server
+
+import "github.com/memphisdev/memphis/models"
+
+// openApiRequestModels maps a handler to the model its request is validated against
+var openApiRequestModels = map[string]interface{}{
+{{- range .Models }}
+	"{{ .Handler }}": {{ .Model }}{},
+{{- end }}
+}
+`
+
+type handlerModel struct {
+	Handler string
+	Model   string
+}
+
+func panicIfErr(err error) {
+	if err == nil {
+		return
+	}
+	panic(err)
+}
+
+// requestModel returns the struct type of the variable a handler passes to utils.Validate, either from the models
+// package or one of the given server structs
+func requestModel(fn *ast.FuncDecl, serverStructs map[string]bool) string {
+	declared := map[string]string{}
+	model := ""
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if model != "" {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.ValueSpec:
+			typeName := ""
+			switch typ := node.Type.(type) {
+			case *ast.SelectorExpr:
+				if pkg, ok := typ.X.(*ast.Ident); ok && pkg.Name == "models" {
+					typeName = "models." + typ.Sel.Name
+				}
+			case *ast.Ident:
+				if serverStructs[typ.Name] {
+					typeName = typ.Name
+				}
+			}
+			if typeName != "" {
+				for _, name := range node.Names {
+					declared[name.Name] = typeName
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Validate" || len(node.Args) < 2 {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "utils" {
+				return true
+			}
+			if ref, ok := node.Args[1].(*ast.UnaryExpr); ok && ref.Op == token.AND {
+				if ident, ok := ref.X.(*ast.Ident); ok {
+					model = declared[ident.Name]
+				}
+			}
+		}
+		return true
+	})
+	return model
+}
+
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok || !strings.HasSuffix(ident.Name, "Handler") {
+		return ""
+	}
+	return ident.Name
+}
+
+func main() {
+	files, err := filepath.Glob("server/*.go")
+	panicIfErr(err)
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	serverStructs := map[string]bool{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || strings.HasSuffix(file, "_gen.go") || file == openApiModelsFile {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		panicIfErr(err)
+		parsed = append(parsed, f)
+		ast.Inspect(f, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				if _, ok := spec.Type.(*ast.StructType); ok {
+					serverStructs[spec.Name.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var handlerModels []handlerModel
+	for _, f := range parsed {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			receiver := receiverName(fn)
+			if receiver == "" {
+				continue
+			}
+			if model := requestModel(fn, serverStructs); model != "" {
+				handlerModels = append(handlerModels, handlerModel{Handler: receiver + "." + fn.Name.Name, Model: model})
+			}
+		}
+	}
+	sort.Slice(handlerModels, func(i, j int) bool {
+		return handlerModels[i].Handler < handlerModels[j].Handler
+	})
+
+	license, err := os.ReadFile("server/memphis_openapi.go")
+	panicIfErr(err)
+	header := string(license[:bytes.Index(license, []byte("package server"))])
+
+	var out bytes.Buffer
+	t := template.Must(template.New("openapi").Parse(templ))
+	panicIfErr(t.Execute(&out, map[string]interface{}{"License": strings.TrimSpace(header), "Models": handlerModels}))
+	panicIfErr(os.WriteFile(openApiModelsFile, out.Bytes(), 0644))
+
+	cmd := exec.Command("go", "fmt", openApiModelsFile)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("go fmt failed: %s", string(output))
+		panicIfErr(err)
+	}
+	fmt.Printf("%v handler request models written to %v\n", len(handlerModels), openApiModelsFile)
+}
@@ -80,6 +80,8 @@ type ClusterOpts struct {
 	PoolSize          int               `json:"-"`
 	PinnedAccounts    []string          `json:"-"`
 	Compression       CompressionOpts   `json:"-"`
+	// ** added by Memphis
+	Edge EdgeOpts `json:"-"`
 
 	// Not exported (used in tests)
 	resolver netResolver
@@ -349,12 +351,15 @@ type Options struct {
 	RestGwPort                         int            `json:"-"`
 	K8sNamespace                       string         `json:"-"`
 	LogsRetentionDays                  int            `json:"-"`
+	LogsRetentionMaxMb                 int            `json:"-"`
 	TieredStorageUploadIntervalSec     int            `json:"-"`
 	DlsRetentionHours                  map[string]int `json:"-"`
 	GCProducersConsumersRetentionHours map[string]int `json:"-"`
 	UiHost                             string         `json:"-"`
 	RestGwHost                         string         `json:"-"`
 	BrokerHost                         string         `json:"-"`
+	// AnalyticsCategories holds which analytics categories are sent
+	AnalyticsCategories map[string]bool `json:"-"`
 	// ** added by Memphis
 
 	// MaxTracedMsgLen is the maximum printable length for traced messages.
@@ -1597,6 +1602,13 @@ func (o *Options) processConfigFileLine(k string, v interface{}, errors *[]error
 			return
 		}
 		o.LogsRetentionDays = value
+	case "logs_retention_max_mb":
+		value := int(v.(int64))
+		if value < 1 {
+			*errors = append(*errors, &configErr{tk, "error logs_retention_max_mb config: has to be positive"})
+			return
+		}
+		o.LogsRetentionMaxMb = value
 	case "tiered_storage_upload_interval_seconds":
 		value := int(v.(int64))
 		if value < 1 || value > 3600 {
@@ -1850,6 +1862,12 @@ func parseCluster(v interface{}, opts *Options, errors *[]error, warnings *[]err
 				*errors = append(*errors, err)
 				continue
 			}
+		// ** added by Memphis
+		case "edge":
+			if err := parseEdge(&opts.Cluster.Edge, tk, mv); err != nil {
+				*errors = append(*errors, err)
+				continue
+			}
 		default:
 			if !tk.IsUsedVariable() {
 				err := &unknownConfigFieldErr{
@@ -5350,6 +5368,12 @@ func ConfigureOptions(fs *flag.FlagSet, args []string, printVersion, printHelp,
 	fs.BoolVar(&opts.JetStream, "jetstream", false, "Enable JetStream.")
 	fs.StringVar(&opts.StoreDir, "sd", _EMPTY_, "Storage directory.")
 	fs.StringVar(&opts.StoreDir, "store_dir", _EMPTY_, "Storage directory.")
+	// Memphis settings are resolved by the conf package straight from the command line, they are only declared here
+	// so parsing accepts them.
+	fs.String("app_config", _EMPTY_, "Memphis settings file.")
+	fs.Func("set", "Memphis setting override, KEY=VALUE.", func(string) error { return nil })
+	fs.Bool("dev", false, "Run as a local playground.")
+	fs.Bool("dev_seed", false, "Seed the local playground with demo data.")
 
 	// The flags definition above set "default" values to some of the options.
 	// Calling Parse() here will override the default options with any value
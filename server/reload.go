@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/klauspost/compress/s2"
+	"github.com/memphisdev/memphis/analytics"
 
 	"github.com/nats-io/jwt/v2"
 	"github.com/nats-io/nuid"
@@ -838,6 +839,17 @@ func (o *profBlockRateReload) Apply(s *Server) {
 }
 
 // ** added by Memphis
+// analyticsCategoriesOption implements the option interface for the `analytics_<category>` settings.
+type analyticsCategoriesOption struct {
+	noopOption
+	newValue map[string]bool
+}
+
+func (o *analyticsCategoriesOption) Apply(server *Server) {
+	analytics.SetEnabledCategories(o.newValue)
+	server.Noticef("Reloaded: analytics categories = %v", o.newValue)
+}
+
 // dlsRetentionHoursOption implements the option interface for the `dls_retention_hours`
 // setting.
 type dlsRetentionHoursOption struct {
@@ -1753,6 +1765,8 @@ func (s *Server) diffOptions(newOpts *Options) ([]option, error) {
 			diffOpts = append(diffOpts, &restGwOption{newValue: newValue.(string)})
 		case "gcproducersconsumersretentionhours":
 			diffOpts = append(diffOpts, &GCProducersConsumersRetentionHoursOption{newValue: newValue.(map[string]int)})
+		case "analyticscategories":
+			diffOpts = append(diffOpts, &analyticsCategoriesOption{newValue: newValue.(map[string]bool)})
 		// ** added by Memphis
 		default:
 			// TODO(ik): Implement String() on those options to have a nice print.
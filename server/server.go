@@ -2977,6 +2977,10 @@ func (s *Server) startMonitoring(secure bool) error {
 	mux.HandleFunc(s.basePath(HealthzPath), s.HandleHealthz)
 	// IPQueuesz
 	mux.HandleFunc(s.basePath(IPQueuesPath), s.HandleIPQueuesz)
+	// ** added by Memphis
+	// Metrics
+	mux.HandleFunc(s.basePath(MetricsPath), s.HandleMetrics)
+	// ** added by Memphis
 
 	// Do not set a WriteTimeout because it could cause cURL/browser
 	// to return empty response or unable to display page if the
@@ -4545,6 +4549,8 @@ func (s *Server) initializeMemphis() {
 		s.Errorf("failed setting existing tenants with dls retention opts: %v", err.Error())
 	}
 	s.CompleteRelevantStuckAsyncTasks()
+	s.FailInterruptedJobs()
+	s.ProvisionConfigUsers()
 	s.InitializeMemphisHandlers()
 	opts := s.getOpts()
 	if !opts.DontListen {
@@ -4567,6 +4573,7 @@ func (s *Server) initializeMemphis() {
 	s.AcceptWSConnections()
 	// run only on the leader
 	go s.KillZombieResources()
+	go s.CheckSdkHeartbeats()
 }
 
 // added by memphis **
@@ -94,10 +94,16 @@ func (s *Server) sendToTier2Storage(storageType interface{}, buf []byte, seq uin
 						subject := fmt.Sprintf("%s.%s.%s", tieredStorageStream, streamName, tenantName)
 						// TODO: if the stream is not exists save the messages in buffer
 						if TIERED_STORAGE_STREAM_CREATED {
+							// the tiered storage stream is not encrypted, so messages of encrypted stations are sealed before leaving their stream
+							sealedBuf, encrypted, err := sealWithStationKey(tenantName, streamName, buf)
+							if err != nil {
+								return err
+							}
 							tierStorageMsg := TieredStorageMsg{
-								Buf:         buf,
+								Buf:         sealedBuf,
 								StationName: streamName,
 								TenantName:  tenantName,
+								Encrypted:   encrypted,
 							}
 
 							msg, err := json.Marshal(tierStorageMsg)
@@ -146,6 +152,14 @@ func (s *Server) handleNewTieredStorageMsg(msg []byte, reply string) {
 		return
 	}
 	payload := tieredStorageMsg.Buf
+	if tieredStorageMsg.Encrypted {
+		var err error
+		payload, err = openWithStationKey(tieredStorageMsg.TenantName, tieredStorageMsg.StationName, payload)
+		if err != nil {
+			s.Errorf("[tenant: %v]ListenForTieredStorageMessages: Failed decrypting tiered storage message of station %v: %v", tieredStorageMsg.TenantName, tieredStorageMsg.StationName, err.Error())
+			return
+		}
+	}
 	rawTs := tokenAt(reply, 8)
 	seq, _, _ := ackReplyInfo(reply)
 	intTs, err := strconv.Atoi(rawTs)
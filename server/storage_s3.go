@@ -38,6 +38,7 @@ type TieredStorageMsg struct {
 	Buf         []byte `json:"buf"`
 	StationName string `json:"station_name"`
 	TenantName  string `json:"tenant_name"`
+	Encrypted   bool   `json:"encrypted,omitempty"`
 }
 
 func cacheDetailsS3(keys map[string]interface{}, properties map[string]bool, tenantName string) {
@@ -366,45 +367,59 @@ type Msg struct {
 	Headers map[string]string `json:"headers"`
 }
 
-func (s *Server) uploadToS3Storage(tenantName string, tenant map[string][]StoredMsg) error {
-	for k, msgs := range tenant {
-		var credentialsMap models.Integration
-		if tenantIntegrations, ok := IntegrationsConcurrentCache.Load(tenantName); !ok {
-			continue
-		} else {
-			if credentialsMap, ok = tenantIntegrations["s3"].(models.Integration); !ok {
-				continue
-			}
+var errNoS3Integration = errors.New("s3 integration is not configured")
+
+// newTenantS3Client builds a client for the s3 integration of the tenant and returns it along with the integration bucket
+func newTenantS3Client(tenantName string) (*s3.Client, string, error) {
+	var credentialsMap models.Integration
+	if tenantIntegrations, ok := IntegrationsConcurrentCache.Load(tenantName); !ok {
+		return nil, _EMPTY_, errNoS3Integration
+	} else {
+		if credentialsMap, ok = tenantIntegrations["s3"].(models.Integration); !ok {
+			return nil, _EMPTY_, errNoS3Integration
 		}
-		provider := credentials.NewStaticCredentialsProvider(
-			credentialsMap.Keys["access_key"].(string),
-			credentialsMap.Keys["secret_key"].(string),
-			_EMPTY_,
-		)
+	}
+	provider := credentials.NewStaticCredentialsProvider(
+		credentialsMap.Keys["access_key"].(string),
+		credentialsMap.Keys["secret_key"].(string),
+		_EMPTY_,
+	)
 
-		region := credentialsMap.Keys["region"]
-		url := credentialsMap.Keys["url"]
-		pathStyle, _ := strconv.ParseBool(credentialsMap.Keys["s3_path_style"].(string))
+	region := credentialsMap.Keys["region"]
+	url := credentialsMap.Keys["url"]
+	pathStyle, _ := strconv.ParseBool(credentialsMap.Keys["s3_path_style"].(string))
 
-		_, err := provider.Retrieve(context.Background())
-		if err != nil {
-			err = errors.New("uploadToS3Storage: Invalid credentials")
-			return err
+	_, err := provider.Retrieve(context.Background())
+	if err != nil {
+		return nil, _EMPTY_, errors.New("Invalid credentials")
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithCredentialsProvider(provider),
+		awsconfig.WithRegion(credentialsMap.Keys["region"].(string)),
+		awsconfig.WithEndpointResolverWithOptions(getS3EndpointResolver(region.(string), url.(string))),
+	)
+	if err != nil {
+		return nil, _EMPTY_, err
+	}
+	svc := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = pathStyle
+	})
+	return svc, credentialsMap.Keys["bucket_name"].(string), nil
+}
+
+func (s *Server) uploadToS3Storage(tenantName string, tenant map[string][]StoredMsg) error {
+	for k, msgs := range tenant {
+		svc, bucketName, err := newTenantS3Client(tenantName)
+		if err == errNoS3Integration {
+			continue
 		}
-		cfg, err := awsconfig.LoadDefaultConfig(context.Background(),
-			awsconfig.WithCredentialsProvider(provider),
-			awsconfig.WithRegion(credentialsMap.Keys["region"].(string)),
-			awsconfig.WithEndpointResolverWithOptions(getS3EndpointResolver(region.(string), url.(string))),
-		)
-		svc := s3.NewFromConfig(cfg, func(o *s3.Options) {
-			o.UsePathStyle = pathStyle
-		})
 		if err != nil {
 			err = errors.New("uploadToS3Storage failure " + err.Error())
 			return err
 		}
 		uploader := manager.NewUploader(svc)
 		uid := serv.memphis.nuid.Next()
+		stationTenantName := tenantName
 		var objectName string
 
 		var messages []Msg
@@ -442,10 +457,15 @@ func (s *Server) uploadToS3Storage(tenantName string, tenant map[string][]Stored
 		if err != nil {
 			return err
 		}
+		body, metadata, err := sealTieredStorageObject(stationTenantName, k, buf.Bytes())
+		if err != nil {
+			return errors.New("uploadToS3Storage: failed to encrypt object: " + err.Error())
+		}
 		_, err = uploader.Upload(context.Background(), &s3.PutObjectInput{
-			Bucket: aws.String(credentialsMap.Keys["bucket_name"].(string)),
-			Key:    aws.String(objectName),
-			Body:   &buf,
+			Bucket:   aws.String(bucketName),
+			Key:      aws.String(objectName),
+			Body:     bytes.NewReader(body),
+			Metadata: metadata,
 		})
 		if err != nil {
 			err = errors.New("uploadToS3Storage: failed to upload object to S3: " + err.Error())
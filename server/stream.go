@@ -3721,6 +3721,15 @@ func (mset *stream) setupStore(fsCfg *FileStoreConfig) error {
 			fsCfg.Cipher = s.getOpts().JetStreamCipher
 		}
 		oldprf := s.jsKeyGen(s.getOpts().JetStreamOldKey, defaultMetaGroupName)
+		// ** added by memphis - encrypted stations use their own storage key when no broker wide key is set
+		if prf == nil {
+			if key, oldKey := s.stationStoreKeys(mset.acc.Name, mset.cfg.Name); key != _EMPTY_ {
+				prf = s.jsKeyGen(key, mset.acc.Name)
+				oldprf = s.jsKeyGen(oldKey, mset.acc.Name)
+				fsCfg.Cipher = s.getOpts().JetStreamCipher
+			}
+		}
+		// ** added by memphis
 		cfg := *fsCfg
 		cfg.srv = s
 		// ** added by mamphis **
@@ -3917,10 +3926,11 @@ type inMsg struct {
 	rply string
 	hdr  []byte
 	msg  []byte
+	recv time.Time // ** added by memphis
 }
 
 func (mset *stream) queueInbound(ib *ipQueue[*inMsg], subj, rply string, hdr, msg []byte) {
-	ib.push(&inMsg{subj, rply, hdr, msg})
+	ib.push(&inMsg{subj, rply, hdr, msg, time.Now()}) // ** time.Now() added by memphis
 }
 
 func (mset *stream) queueInboundMsg(subj, rply string, hdr, msg []byte) {
@@ -4755,6 +4765,7 @@ func (mset *stream) internalLoop() {
 	c.registerWithAccount(mset.acc)
 	defer c.closeConnection(ClientClosed)
 	outq, qch, msgs, gets := mset.outq, mset.qch, mset.msgs, mset.gets
+	latencyStats := getStationLatencyStats(mset.acc, mset.cfg.Name) // ** added by memphis
 
 	// For the ack msgs queue for interest retention.
 	var (
@@ -4847,6 +4858,7 @@ func (mset *stream) internalLoop() {
 				} else {
 					mset.processJetStreamMsg(im.subj, im.rply, im.hdr, im.msg, 0, 0)
 				}
+				s.recordStationProduce(latencyStats, im.recv, im.hdr, im.msg) // ** added by memphis
 			}
 			msgs.recycle(&ims)
 		case <-gets.ch:
@@ -4905,11 +4917,16 @@ func (mset *stream) delete() error {
 func (mset *stream) stop(deleteFlag, advisory bool) error {
 	mset.mu.RLock()
 	js, jsa, name := mset.js, mset.jsa, mset.cfg.Name
+	acc := mset.acc // ** added by memphis
 	mset.mu.RUnlock()
 
 	if jsa == nil {
 		return NewJSNotEnabledForAccountError()
 	}
+	// ** added by memphis
+	if deleteFlag {
+		removeStationLatencyStats(acc, name)
+	}
 
 	// Remove from our account map first.
 	jsa.mu.Lock()
@@ -0,0 +1,145 @@
+// Copyright 2022-2023 The Memphis.dev Authors
+// Licensed under the Memphis Business Source License 1.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// Changed License: [Apache License, Version 2.0 (https://www.apache.org/licenses/LICENSE-2.0), as published by the Apache Foundation.
+//
+// https://github.com/memphisdev/memphis/blob/master/LICENSE
+//
+// Additional Use Grant: You may make use of the Licensed Work (i) only as part of your own product or service, provided it is not a message broker or a message queue product or service; and (ii) provided that you do not use, provide, distribute, or make available the Licensed Work as a Service.
+// A "Service" is a commercial offering, product, hosted, or managed service, that allows third parties (other than your own employees and contractors acting on your behalf) to access and/or use the Licensed Work or a substantial set of the features or functionality of the Licensed Work to third parties as a software-as-a-service, platform-as-a-service, infrastructure-as-a-service or other similar services that compete with Licensor products or services.
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/memphisdev/memphis/db"
+	"github.com/memphisdev/memphis/models"
+)
+
+const (
+	webhookSecretBytesLen    = 32
+	webhookDeliveryAttempts  = 5
+	webhookDeliveryTimeout   = 10 * time.Second
+	webhookSignatureHeader   = "X-Memphis-Signature"
+	webhookTimestampHeader   = "X-Memphis-Timestamp"
+	webhookEventHeader       = "X-Memphis-Event"
+	webhookDeliveryIdHeader  = "X-Memphis-Delivery"
+	webhookSignatureScheme   = "sha256="
+	webhookMaxBackoffSeconds = 60
+)
+
+var webhooksHttpClient = &http.Client{Timeout: webhookDeliveryTimeout}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		switch eventType {
+		case models.EventStationCreated, models.EventStationDeleted, models.EventSchemaVersionActivated, models.EventConsumerCreated, models.EventConsumerDestroyed:
+		default:
+			return fmt.Errorf("event type %v is not supported, supported types are %v, %v, %v, %v and %v", eventType, models.EventStationCreated, models.EventStationDeleted, models.EventSchemaVersionActivated, models.EventConsumerCreated, models.EventConsumerDestroyed)
+		}
+	}
+	return nil
+}
+
+func validateWebhookUrl(webhookUrl string) error {
+	u, err := url.Parse(webhookUrl)
+	if err != nil || u.Host == _EMPTY_ || u.Scheme != "https" {
+		return fmt.Errorf("webhook url must be a valid https url")
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	raw := make([]byte, webhookSecretBytesLen)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return _EMPTY_, err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// signWebhookPayload signs the timestamp together with the body so a captured delivery can't be replayed later
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return webhookSignatureScheme + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) deliverEventToWebhooks(event models.BrokerEvent) {
+	webhooks, err := db.GetEnabledWebhooksByEventType(event.Type, event.TenantName)
+	if err != nil {
+		s.Errorf("[tenant: %v]deliverEventToWebhooks at GetEnabledWebhooksByEventType: %v", event.TenantName, err.Error())
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		s.Errorf("[tenant: %v]deliverEventToWebhooks at json.Marshal: %v", event.TenantName, err.Error())
+		return
+	}
+	for _, webhook := range webhooks {
+		secret, err := DecryptAES(getAESKey(), webhook.Secret)
+		if err != nil {
+			s.Errorf("[tenant: %v]deliverEventToWebhooks at DecryptAES: webhook %v: %v", event.TenantName, webhook.Name, err.Error())
+			continue
+		}
+		go s.deliverWebhook(webhook, secret, event, body)
+	}
+}
+
+func (s *Server) deliverWebhook(webhook models.Webhook, secret string, event models.BrokerEvent, body []byte) {
+	backoff := time.Second
+	var err error
+	for attempt := 1; attempt <= webhookDeliveryAttempts; attempt++ {
+		err = postWebhook(webhook.Url, secret, event, body)
+		if err == nil {
+			return
+		}
+		if attempt < webhookDeliveryAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > webhookMaxBackoffSeconds*time.Second {
+				backoff = webhookMaxBackoffSeconds * time.Second
+			}
+		}
+	}
+	s.Warnf("[tenant: %v]deliverWebhook: failed delivering event %v to webhook %v after %v attempts: %v", event.TenantName, event.Type, webhook.Name, webhookDeliveryAttempts, err.Error())
+}
+
+func postWebhook(webhookUrl, secret string, event models.BrokerEvent, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, event.Type)
+	req.Header.Set(webhookDeliveryIdHeader, event.ID)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(secret, timestamp, body))
+	resp, err := webhooksHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %v", resp.StatusCode)
+	}
+	return nil
+}
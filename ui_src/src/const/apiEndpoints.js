@@ -42,6 +42,7 @@ export const ApiEndpoints = {
     DROP_DLS_MESSAGE: '/stations/dropDlsMessages',
     REMOVE_MESSAGES: '/stations/removeMessages',
     PURGE_STATION: '/stations/purgeStation',
+    GET_PURGE_CONFIRMATION_TOKEN: '/stations/getPurgeConfirmationToken',
     RESEND_POISON_MESSAGE_JOURNEY: '/stations/resendPoisonMessages',
     USE_SCHEMA: '/stations/useSchema',
     GET_UPDATE_SCHEMA: '/stations/getUpdatesForSchemaByStation',
@@ -44,8 +44,10 @@ const PurgeStationModal = ({ title, desc, close, stationName, msgsDisabled = fal
     const handlePurge = async (purgeData) => {
         setLoader(true);
         try {
+            const token = await httpRequest('GET', `${ApiEndpoints.GET_PURGE_CONFIRMATION_TOKEN}?station_name=${stationName}`);
             let purgeDataPayload = purgeData;
             purgeDataPayload['station_name'] = stationName;
+            purgeDataPayload['confirmation_token'] = token?.confirmation_token;
             purgeDataPayload['partitions_list'] = [stationState?.stationPartition];
             await httpRequest('DELETE', `${ApiEndpoints.PURGE_STATION}`, purgeDataPayload);
             stationDispatch({ type: 'SET_SELECTED_ROW_ID', payload: null });